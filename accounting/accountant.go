@@ -0,0 +1,251 @@
+package accounting
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/pivotal-golang/clock"
+	"github.com/pivotal-golang/lager"
+)
+
+//go:generate counterfeiter . Sampler
+//go:generate counterfeiter . HandleLister
+
+// Sample is a point-in-time reading of a container's resource usage.
+// CPUUsage and the network counters are cumulative since the container
+// was created; MemoryBytes, DiskBytes and ScratchBytes are instantaneous.
+// DiskBytes is the usage of the container's root filesystem layer and
+// ScratchBytes that of its scratch space. BandwidthRate (bytes per second)
+// and BandwidthBurst (bytes) are the container's current bandwidth limits,
+// 0 if it is not limited.
+type Sample struct {
+	CPUUsage       time.Duration
+	CPUThrottled   time.Duration
+	MemoryBytes    uint64
+	DiskBytes      uint64
+	ScratchBytes   uint64
+	RxBytes        uint64
+	TxBytes        uint64
+	BandwidthRate  uint64
+	BandwidthBurst uint64
+}
+
+type Sampler interface {
+	Sample(log lager.Logger, handle string) (Sample, error)
+}
+
+type HandleLister interface {
+	Handles() ([]string, error)
+}
+
+// Usage is the cumulative resource usage of a container since it was first
+// seen by the Accountant.
+type Usage struct {
+	Handle              string    `json:"handle"`
+	Since               time.Time `json:"since"`
+	LastSampled         time.Time `json:"last_sampled"`
+	CPUSeconds          float64   `json:"cpu_seconds"`
+	CPUThrottledSeconds float64   `json:"cpu_throttled_seconds"`
+	MemoryByteHours     float64   `json:"memory_byte_hours"`
+	DiskByteHours       float64   `json:"disk_byte_hours"`
+	ScratchByteHours    float64   `json:"scratch_byte_hours"`
+	NetworkRxBytes      uint64    `json:"network_rx_bytes"`
+	NetworkTxBytes      uint64    `json:"network_tx_bytes"`
+
+	// BandwidthRate and BandwidthBurst are the container's bandwidth limits
+	// as of the latest sample
+	BandwidthRate  uint64 `json:"bandwidth_rate_bytes_per_second"`
+	BandwidthBurst uint64 `json:"bandwidth_burst_bytes"`
+
+	// Annotations are selected properties of the container, added when the
+	// usage is exported
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+// Accountant periodically samples every container and integrates the
+// samples in to cumulative usage suitable for billing.
+type Accountant struct {
+	sampler  Sampler
+	lister   HandleLister
+	clock    clock.Clock
+	interval time.Duration
+	logger   lager.Logger
+
+	mu     sync.Mutex
+	usages map[string]*Usage
+}
+
+func NewAccountant(logger lager.Logger, sampler Sampler, lister HandleLister, clock clock.Clock, interval time.Duration) *Accountant {
+	return &Accountant{
+		sampler:  sampler,
+		lister:   lister,
+		clock:    clock,
+		interval: interval,
+		logger:   logger,
+		usages:   make(map[string]*Usage),
+	}
+}
+
+// Start begins sampling in the background every interval.
+func (a *Accountant) Start() error {
+	go func() {
+		ticker := a.clock.NewTicker(a.interval)
+		defer ticker.Stop()
+
+		for range ticker.C() {
+			a.SampleAll()
+		}
+	}()
+
+	return nil
+}
+
+// SampleAll takes a single sample of every container. Memory and disk usage
+// are assumed to have been constant since the previous sample. Containers
+// which no longer exist are forgotten.
+func (a *Accountant) SampleAll() {
+	log := a.logger.Session("sample-all")
+
+	handles, err := a.lister.Handles()
+	if err != nil {
+		log.Error("list-handles-failed", err)
+		return
+	}
+
+	// sampling runs du, which can take a while, so is done before locking
+	// rather than holding up Usages and the exporters
+	samples := make(map[string]Sample, len(handles))
+	for _, handle := range handles {
+		sample, err := a.sampler.Sample(log, handle)
+		if err != nil {
+			log.Error("sample-failed", err, lager.Data{"handle": handle})
+			continue
+		}
+
+		samples[handle] = sample
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	now := a.clock.Now()
+	seen := make(map[string]bool)
+	for _, handle := range handles {
+		seen[handle] = true
+
+		sample, ok := samples[handle]
+		if !ok {
+			continue
+		}
+
+		usage, ok := a.usages[handle]
+		if !ok {
+			usage = &Usage{Handle: handle, Since: now, LastSampled: now}
+			a.usages[handle] = usage
+		}
+
+		hours := now.Sub(usage.LastSampled).Hours()
+		usage.MemoryByteHours += float64(sample.MemoryBytes) * hours
+		usage.DiskByteHours += float64(sample.DiskBytes) * hours
+		usage.ScratchByteHours += float64(sample.ScratchBytes) * hours
+		usage.CPUSeconds = sample.CPUUsage.Seconds()
+		usage.CPUThrottledSeconds = sample.CPUThrottled.Seconds()
+		usage.NetworkRxBytes = sample.RxBytes
+		usage.NetworkTxBytes = sample.TxBytes
+		usage.BandwidthRate = sample.BandwidthRate
+		usage.BandwidthBurst = sample.BandwidthBurst
+		usage.LastSampled = now
+	}
+
+	for handle := range a.usages {
+		if !seen[handle] {
+			delete(a.usages, handle)
+		}
+	}
+}
+
+// Usages returns the current usage of every known container, ordered by
+// handle.
+func (a *Accountant) Usages() []Usage {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	usages := make([]Usage, 0, len(a.usages))
+	for _, usage := range a.usages {
+		usages = append(usages, *usage)
+	}
+
+	sort.Sort(byHandle(usages))
+	return usages
+}
+
+// CPUThrottledSeconds returns the time every known container has spent
+// throttled by its cpu quota, by handle
+func (a *Accountant) CPUThrottledSeconds() (map[string]float64, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	throttled := make(map[string]float64, len(a.usages))
+	for handle, usage := range a.usages {
+		throttled[handle] = usage.CPUThrottledSeconds
+	}
+
+	return throttled, nil
+}
+
+// BandwidthRates returns the rate every known container's network traffic is
+// limited to, in bytes per second, by handle. Containers without limits are
+// not included.
+func (a *Accountant) BandwidthRates() (map[string]float64, error) {
+	return a.bandwidth(func(usage *Usage) uint64 { return usage.BandwidthRate })
+}
+
+// BandwidthBursts returns the burst every known container's network traffic
+// is allowed, in bytes, by handle. Containers without
+// limits are not included.
+func (a *Accountant) BandwidthBursts() (map[string]float64, error) {
+	return a.bandwidth(func(usage *Usage) uint64 { return usage.BandwidthBurst })
+}
+
+func (a *Accountant) bandwidth(limit func(*Usage) uint64) (map[string]float64, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	limits := make(map[string]float64)
+	for handle, usage := range a.usages {
+		if usage.BandwidthRate == 0 {
+			continue
+		}
+
+		limits[handle] = float64(limit(usage))
+	}
+
+	return limits, nil
+}
+
+// CPUSecondsBy returns the CPU time of every known container summed by the
+// value of one of its properties, e.g. by the cpuset the containers are
+// pinned to. Containers without the property are summed under "none".
+func (a *Accountant) CPUSecondsBy(properties PropertyGetter, name string) (map[string]float64, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	seconds := make(map[string]float64)
+	for handle, usage := range a.usages {
+		value, err := properties.Get(handle, name)
+		if err != nil || value == "" {
+			value = "none"
+		}
+
+		seconds[value] += usage.CPUSeconds
+	}
+
+	return seconds, nil
+}
+
+type byHandle []Usage
+
+func (u byHandle) Len() int           { return len(u) }
+func (u byHandle) Swap(i, j int)      { u[i], u[j] = u[j], u[i] }
+func (u byHandle) Less(i, j int) bool { return u[i].Handle < u[j].Handle }
@@ -0,0 +1,212 @@
+package accounting_test
+
+import (
+	"errors"
+	"time"
+
+	"github.com/cloudfoundry-incubator/guardian/accounting"
+	"github.com/cloudfoundry-incubator/guardian/accounting/fakes"
+	"github.com/pivotal-golang/clock/fakeclock"
+	"github.com/pivotal-golang/lager"
+	"github.com/pivotal-golang/lager/lagertest"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Accountant", func() {
+	var (
+		fakeSampler *fakes.FakeSampler
+		fakeLister  *fakes.FakeHandleLister
+		fakeClock   *fakeclock.FakeClock
+		startTime   time.Time
+		accountant  *accounting.Accountant
+	)
+
+	BeforeEach(func() {
+		fakeSampler = new(fakes.FakeSampler)
+		fakeLister = new(fakes.FakeHandleLister)
+		startTime = time.Unix(1000, 0)
+		fakeClock = fakeclock.NewFakeClock(startTime)
+
+		fakeLister.HandlesReturns([]string{"banana", "apple"}, nil)
+		fakeSampler.SampleStub = func(_ lager.Logger, handle string) (accounting.Sample, error) {
+			return accounting.Sample{
+				CPUUsage:     3 * time.Second,
+				CPUThrottled: time.Second,
+				MemoryBytes:  1000,
+				DiskBytes:    2000,
+				ScratchBytes: 500,
+				RxBytes:      10,
+				TxBytes:      20,
+			}, nil
+		}
+
+		accountant = accounting.NewAccountant(lagertest.NewTestLogger("test"), fakeSampler, fakeLister, fakeClock, time.Minute)
+	})
+
+	It("reports usage for every container, ordered by handle", func() {
+		accountant.SampleAll()
+
+		usages := accountant.Usages()
+		Expect(usages).To(HaveLen(2))
+		Expect(usages[0].Handle).To(Equal("apple"))
+		Expect(usages[1].Handle).To(Equal("banana"))
+	})
+
+	It("records when the container was first seen", func() {
+		accountant.SampleAll()
+		fakeClock.Increment(time.Hour)
+		accountant.SampleAll()
+
+		usage := accountant.Usages()[0]
+		Expect(usage.Since).To(Equal(startTime))
+		Expect(usage.LastSampled).To(Equal(startTime.Add(time.Hour)))
+	})
+
+	It("reports the cumulative cpu and network usage from the latest sample", func() {
+		accountant.SampleAll()
+
+		usage := accountant.Usages()[0]
+		Expect(usage.CPUSeconds).To(Equal(3.0))
+		Expect(usage.CPUThrottledSeconds).To(Equal(1.0))
+		Expect(usage.NetworkRxBytes).To(BeEquivalentTo(10))
+		Expect(usage.NetworkTxBytes).To(BeEquivalentTo(20))
+	})
+
+	It("integrates memory, disk and scratch usage over time", func() {
+		accountant.SampleAll()
+		fakeClock.Increment(30 * time.Minute)
+		accountant.SampleAll()
+		fakeClock.Increment(time.Hour)
+		accountant.SampleAll()
+
+		usage := accountant.Usages()[0]
+		Expect(usage.MemoryByteHours).To(Equal(1500.0))
+		Expect(usage.DiskByteHours).To(Equal(3000.0))
+		Expect(usage.ScratchByteHours).To(Equal(750.0))
+	})
+
+	It("reports usage while containers are being sampled", func() {
+		accountant.SampleAll()
+
+		sampling := make(chan struct{})
+		release := make(chan struct{})
+		fakeSampler.SampleStub = func(_ lager.Logger, handle string) (accounting.Sample, error) {
+			if handle == "banana" {
+				close(sampling)
+				<-release
+			}
+
+			return accounting.Sample{}, nil
+		}
+
+		done := make(chan struct{})
+		go func() {
+			accountant.SampleAll()
+			close(done)
+		}()
+
+		Eventually(sampling).Should(BeClosed())
+		Expect(accountant.Usages()).To(HaveLen(2))
+
+		close(release)
+		Eventually(done).Should(BeClosed())
+	})
+
+	It("forgets containers which no longer exist", func() {
+		accountant.SampleAll()
+		fakeLister.HandlesReturns([]string{"banana"}, nil)
+		accountant.SampleAll()
+
+		usages := accountant.Usages()
+		Expect(usages).To(HaveLen(1))
+		Expect(usages[0].Handle).To(Equal("banana"))
+	})
+
+	It("reports the bandwidth limits of every limited container by handle", func() {
+		fakeSampler.SampleStub = func(_ lager.Logger, handle string) (accounting.Sample, error) {
+			if handle == "banana" {
+				return accounting.Sample{BandwidthRate: 1000, BandwidthBurst: 4000}, nil
+			}
+
+			return accounting.Sample{}, nil
+		}
+
+		accountant.SampleAll()
+
+		rates, err := accountant.BandwidthRates()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(rates).To(Equal(map[string]float64{"banana": 1000}))
+
+		bursts, err := accountant.BandwidthBursts()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(bursts).To(Equal(map[string]float64{"banana": 4000}))
+	})
+
+	It("reports the cpu throttled time of every container by handle", func() {
+		accountant.SampleAll()
+
+		throttled, err := accountant.CPUThrottledSeconds()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(throttled).To(Equal(map[string]float64{"apple": 1, "banana": 1}))
+	})
+
+	It("reports the cpu time of every container by the value of a property", func() {
+		properties := new(fakes.FakePropertyGetter)
+		properties.GetStub = func(handle, name string) (string, error) {
+			Expect(name).To(Equal("cpuset-cpus"))
+			if handle == "apple" {
+				return "0-1", nil
+			}
+
+			return "", errors.New("no such property")
+		}
+
+		accountant.SampleAll()
+
+		seconds, err := accountant.CPUSecondsBy(properties, "cpuset-cpus")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(seconds).To(Equal(map[string]float64{"0-1": 3, "none": 3}))
+	})
+
+	Context("when sampling a container fails", func() {
+		BeforeEach(func() {
+			fakeSampler.SampleStub = func(_ lager.Logger, handle string) (accounting.Sample, error) {
+				if handle == "apple" {
+					return accounting.Sample{}, errors.New("boom")
+				}
+
+				return accounting.Sample{MemoryBytes: 1}, nil
+			}
+		})
+
+		It("still reports the other containers", func() {
+			accountant.SampleAll()
+
+			usages := accountant.Usages()
+			Expect(usages).To(HaveLen(1))
+			Expect(usages[0].Handle).To(Equal("banana"))
+		})
+	})
+
+	Context("when listing the containers fails", func() {
+		It("does not forget any containers", func() {
+			accountant.SampleAll()
+			fakeLister.HandlesReturns(nil, errors.New("boom"))
+			accountant.SampleAll()
+
+			Expect(accountant.Usages()).To(HaveLen(2))
+		})
+	})
+
+	Describe("Start", func() {
+		It("samples every interval", func() {
+			Expect(accountant.Start()).To(Succeed())
+
+			Eventually(fakeClock.WatcherCount).Should(Equal(1))
+			fakeClock.Increment(time.Minute)
+			Eventually(fakeSampler.SampleCallCount).Should(Equal(2))
+		})
+	})
+})
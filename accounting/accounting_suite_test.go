@@ -0,0 +1,13 @@
+package accounting_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"testing"
+)
+
+func TestAccounting(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Accounting Suite")
+}
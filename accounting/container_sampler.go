@@ -0,0 +1,296 @@
+package accounting
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cloudfoundry-incubator/garden"
+	"github.com/cloudfoundry-incubator/goci"
+	"github.com/cloudfoundry/gunk/command_runner"
+	"github.com/pivotal-golang/clock"
+	"github.com/pivotal-golang/lager"
+)
+
+// HostInterfaceKey is the property in which kawasaki stores the name of the
+// host side of a container's veth pair.
+const HostInterfaceKey = "kawasaki.host-interface"
+
+// BandwidthKey is the property in which kawasaki stores the bandwidth limits
+// of a container, as the JSON of its garden.BandwidthLimits.
+const BandwidthKey = "kawasaki.bandwidth"
+
+//go:generate counterfeiter . PropertyGetter
+//go:generate counterfeiter . NetworkStatter
+//go:generate counterfeiter . RootFSPather
+//go:generate counterfeiter . ScratchUsager
+
+type PropertyGetter interface {
+	Get(handle string, name string) (string, error)
+}
+
+type NetworkStatter interface {
+	Statistics(name string) (garden.ContainerNetworkStat, error)
+}
+
+type RootFSPather interface {
+	RootFSPath(handle string) (string, error)
+}
+
+type ScratchUsager interface {
+	ScratchUsage(log lager.Logger, handle string) (uint64, error)
+}
+
+// ContainerSampler samples CPU usage, CPU throttling and memory usage from a
+// container's cgroups, network usage and bandwidth limits from the host side
+// of its veth pair and disk usage from its root filesystem and, if a
+// ScratchUsager is configured, its disk quota.
+//
+// Measuring a root filesystem's disk usage walks all of it, so if
+// DiskUsageMaxAge is set each container's is only measured again once its
+// last measurement, by Clock, is that old.
+type ContainerSampler struct {
+	CgroupPath string
+	// Unified is whether CgroupPath is cgroup v2's unified hierarchy, in
+	// which CPU usage and throttling are both read from cpu.stat
+	Unified bool
+
+	Properties     PropertyGetter
+	NetworkStatter NetworkStatter
+	RootFSPather   RootFSPather
+	ScratchUsager  ScratchUsager
+	CommandRunner  command_runner.CommandRunner
+
+	DiskUsageMaxAge time.Duration
+	Clock           clock.Clock
+
+	diskUsagesMu sync.Mutex
+	diskUsages   map[string]diskUsage
+}
+
+type diskUsage struct {
+	bytes      uint64
+	measuredAt time.Time
+}
+
+func (s *ContainerSampler) Sample(log lager.Logger, handle string) (Sample, error) {
+	log = log.Session("sample", lager.Data{"handle": handle})
+
+	sample, err := s.sampleCgroups(log, handle)
+	if err != nil {
+		return Sample{}, err
+	}
+
+	// containers without a kawasaki network (e.g. when a network plugin is
+	// used) simply have no network usage
+	if intf, err := s.Properties.Get(handle, HostInterfaceKey); err == nil && intf != "" {
+		stats, err := s.NetworkStatter.Statistics(intf)
+		if err != nil {
+			log.Error("network-statistics-failed", err)
+		} else {
+			sample.RxBytes = stats.RxBytes
+			sample.TxBytes = stats.TxBytes
+		}
+
+		if limitsJSON, err := s.Properties.Get(handle, BandwidthKey); err == nil && limitsJSON != "" {
+			var limits garden.BandwidthLimits
+			if err := json.Unmarshal([]byte(limitsJSON), &limits); err != nil {
+				log.Error("parse-bandwidth-limits-failed", err)
+			} else {
+				sample.BandwidthRate = limits.RateInBytesPerSecond
+				sample.BandwidthBurst = limits.BurstRateInBytesPerSecond
+			}
+		}
+	}
+
+	diskBytes, err := s.diskUsage(handle)
+	if err != nil {
+		return Sample{}, fmt.Errorf("read disk usage: %s", err)
+	}
+
+	sample.DiskBytes = diskBytes
+
+	if s.ScratchUsager != nil {
+		scratchUsage, err := s.ScratchUsager.ScratchUsage(log, handle)
+		if err != nil {
+			log.Error("scratch-usage-failed", err)
+		} else {
+			sample.ScratchBytes = scratchUsage
+		}
+	}
+
+	return sample, nil
+}
+
+func (s *ContainerSampler) sampleCgroups(log lager.Logger, handle string) (Sample, error) {
+	if s.Unified {
+		return s.sampleUnifiedCgroup(handle)
+	}
+
+	cpuUsage, err := s.readCgroupUint(handle, "cpuacct", "cpuacct.usage")
+	if err != nil {
+		return Sample{}, fmt.Errorf("read cpu usage: %s", err)
+	}
+
+	memoryUsage, err := s.readCgroupUint(handle, "memory", "memory.usage_in_bytes")
+	if err != nil {
+		return Sample{}, fmt.Errorf("read memory usage: %s", err)
+	}
+
+	sample := Sample{
+		CPUUsage:    time.Duration(cpuUsage),
+		MemoryBytes: memoryUsage,
+	}
+
+	// cpu.stat only reports throttling when the kernel supports cpu quotas
+	if stat, err := s.readCgroupStat(filepath.Join(s.CgroupPath, "cpu", handle, "cpu.stat")); err != nil {
+		log.Error("read-cpu-throttling-failed", err)
+	} else {
+		sample.CPUThrottled = time.Duration(stat["throttled_time"])
+	}
+
+	return sample, nil
+}
+
+// sampleUnifiedCgroup samples a container's cgroup in cgroup v2's unified
+// hierarchy, in which cpu.stat reports times in microseconds and is always
+// present, even when the cpu controller is not enabled
+func (s *ContainerSampler) sampleUnifiedCgroup(handle string) (Sample, error) {
+	cgroup := filepath.Join(s.CgroupPath, handle)
+
+	stat, err := s.readCgroupStat(filepath.Join(cgroup, "cpu.stat"))
+	if err != nil {
+		return Sample{}, fmt.Errorf("read cpu usage: %s", err)
+	}
+
+	memoryUsage, err := readUint(filepath.Join(cgroup, "memory.current"))
+	if err != nil {
+		return Sample{}, fmt.Errorf("read memory usage: %s", err)
+	}
+
+	return Sample{
+		CPUUsage:     time.Duration(stat["usage_usec"]) * time.Microsecond,
+		CPUThrottled: time.Duration(stat["throttled_usec"]) * time.Microsecond,
+		MemoryBytes:  memoryUsage,
+	}, nil
+}
+
+func (s *ContainerSampler) readCgroupUint(handle, subsystem, file string) (uint64, error) {
+	return readUint(filepath.Join(s.CgroupPath, subsystem, handle, file))
+}
+
+// readCgroupStat reads a flat keyed file such as cpu.stat
+func (s *ContainerSampler) readCgroupStat(path string) (map[string]uint64, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	stat := make(map[string]uint64)
+	for _, line := range strings.Split(string(data), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			continue
+		}
+
+		value, err := strconv.ParseUint(fields[1], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("parse %s: %s", fields[0], err)
+		}
+
+		stat[fields[0]] = value
+	}
+
+	return stat, nil
+}
+
+func readUint(path string) (uint64, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return 0, err
+	}
+
+	return strconv.ParseUint(strings.TrimSpace(string(data)), 10, 64)
+}
+
+// diskUsage returns the container's last disk usage if it is younger than
+// DiskUsageMaxAge, and measures it otherwise, forgetting the measurements of
+// containers which have not been sampled for as long, e.g. as they are gone
+func (s *ContainerSampler) diskUsage(handle string) (uint64, error) {
+	if s.DiskUsageMaxAge <= 0 {
+		return s.measureDiskUsage(handle)
+	}
+
+	s.diskUsagesMu.Lock()
+	last, ok := s.diskUsages[handle]
+	s.diskUsagesMu.Unlock()
+
+	if ok && s.Clock.Since(last.measuredAt) < s.DiskUsageMaxAge {
+		return last.bytes, nil
+	}
+
+	used, err := s.measureDiskUsage(handle)
+	if err != nil {
+		return 0, err
+	}
+
+	s.diskUsagesMu.Lock()
+	defer s.diskUsagesMu.Unlock()
+
+	if s.diskUsages == nil {
+		s.diskUsages = map[string]diskUsage{}
+	}
+
+	for measured, usage := range s.diskUsages {
+		if s.Clock.Since(usage.measuredAt) >= s.DiskUsageMaxAge {
+			delete(s.diskUsages, measured)
+		}
+	}
+
+	s.diskUsages[handle] = diskUsage{bytes: used, measuredAt: s.Clock.Now()}
+	return used, nil
+}
+
+func (s *ContainerSampler) measureDiskUsage(handle string) (uint64, error) {
+	rootfs, err := s.RootFSPather.RootFSPath(handle)
+	if err != nil {
+		return 0, err
+	}
+
+	var stdout bytes.Buffer
+	cmd := exec.Command("du", "-sxb", rootfs)
+	cmd.Stdout = &stdout
+	if err := s.CommandRunner.Run(cmd); err != nil {
+		return 0, err
+	}
+
+	fields := strings.Fields(stdout.String())
+	if len(fields) == 0 {
+		return 0, fmt.Errorf("unexpected du output: %q", stdout.String())
+	}
+
+	return strconv.ParseUint(fields[0], 10, 64)
+}
+
+// BundleRootFSPather finds a container's root filesystem from the bundle
+// stored in the depot.
+type BundleRootFSPather struct {
+	DepotPath    string
+	BundleLoader *goci.BndlLoader
+}
+
+func (b *BundleRootFSPather) RootFSPath(handle string) (string, error) {
+	bndl, err := b.BundleLoader.Load(filepath.Join(b.DepotPath, handle))
+	if err != nil {
+		return "", err
+	}
+
+	return bndl.Spec.Spec.Root.Path, nil
+}
@@ -0,0 +1,291 @@
+package accounting_test
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"time"
+
+	"github.com/cloudfoundry-incubator/garden"
+	"github.com/cloudfoundry-incubator/guardian/accounting"
+	"github.com/cloudfoundry-incubator/guardian/accounting/fakes"
+	"github.com/cloudfoundry/gunk/command_runner/fake_command_runner"
+	. "github.com/cloudfoundry/gunk/command_runner/fake_command_runner/matchers"
+	"github.com/pivotal-golang/clock/fakeclock"
+	"github.com/pivotal-golang/lager"
+	"github.com/pivotal-golang/lager/lagertest"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ContainerSampler", func() {
+	var (
+		cgroupPath         string
+		fakeProperties     *fakes.FakePropertyGetter
+		fakeNetworkStatter *fakes.FakeNetworkStatter
+		fakeRootFSPather   *fakes.FakeRootFSPather
+		fakeRunner         *fake_command_runner.FakeCommandRunner
+		sampler            *accounting.ContainerSampler
+		logger             lager.Logger
+	)
+
+	writeCgroupFile := func(subsystem, file, contents string) {
+		dir := filepath.Join(cgroupPath, subsystem, "some-handle")
+		Expect(os.MkdirAll(dir, 0755)).To(Succeed())
+		Expect(ioutil.WriteFile(filepath.Join(dir, file), []byte(contents), 0644)).To(Succeed())
+	}
+
+	BeforeEach(func() {
+		var err error
+		cgroupPath, err = ioutil.TempDir("", "cgroups")
+		Expect(err).NotTo(HaveOccurred())
+
+		writeCgroupFile("cpuacct", "cpuacct.usage", "2500000000\n")
+		writeCgroupFile("memory", "memory.usage_in_bytes", "4096\n")
+
+		fakeProperties = new(fakes.FakePropertyGetter)
+		fakeProperties.GetReturns("some-host-intf", nil)
+
+		fakeNetworkStatter = new(fakes.FakeNetworkStatter)
+		fakeNetworkStatter.StatisticsReturns(garden.ContainerNetworkStat{RxBytes: 100, TxBytes: 200}, nil)
+
+		fakeRootFSPather = new(fakes.FakeRootFSPather)
+		fakeRootFSPather.RootFSPathReturns("/path/to/rootfs", nil)
+
+		fakeRunner = fake_command_runner.New()
+		fakeRunner.WhenRunning(fake_command_runner.CommandSpec{
+			Path: "du",
+		}, func(cmd *exec.Cmd) error {
+			cmd.Stdout.Write([]byte("8192\t/path/to/rootfs\n"))
+			return nil
+		})
+
+		sampler = &accounting.ContainerSampler{
+			CgroupPath:     cgroupPath,
+			Properties:     fakeProperties,
+			NetworkStatter: fakeNetworkStatter,
+			RootFSPather:   fakeRootFSPather,
+			CommandRunner:  fakeRunner,
+		}
+
+		logger = lagertest.NewTestLogger("test")
+	})
+
+	AfterEach(func() {
+		Expect(os.RemoveAll(cgroupPath)).To(Succeed())
+	})
+
+	It("reads the cpu and memory usage from the container's cgroups", func() {
+		sample, err := sampler.Sample(logger, "some-handle")
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(sample.CPUUsage).To(Equal(2500 * time.Millisecond))
+		Expect(sample.MemoryBytes).To(BeEquivalentTo(4096))
+	})
+
+	It("reads the time the container has been throttled by its cpu quota", func() {
+		writeCgroupFile("cpu", "cpu.stat", "nr_periods 120\nnr_throttled 30\nthrottled_time 1500000000\n")
+
+		sample, err := sampler.Sample(logger, "some-handle")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(sample.CPUThrottled).To(Equal(1500 * time.Millisecond))
+	})
+
+	Context("when the cpu stats cannot be read", func() {
+		It("reports no throttling", func() {
+			sample, err := sampler.Sample(logger, "some-handle")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(sample.CPUThrottled).To(BeZero())
+		})
+	})
+
+	It("reads the network usage of the container's host interface", func() {
+		sample, err := sampler.Sample(logger, "some-handle")
+		Expect(err).NotTo(HaveOccurred())
+
+		handle, key := fakeProperties.GetArgsForCall(0)
+		Expect(handle).To(Equal("some-handle"))
+		Expect(key).To(Equal(accounting.HostInterfaceKey))
+		Expect(fakeNetworkStatter.StatisticsArgsForCall(0)).To(Equal("some-host-intf"))
+
+		Expect(sample.RxBytes).To(BeEquivalentTo(100))
+		Expect(sample.TxBytes).To(BeEquivalentTo(200))
+	})
+
+	It("reads the bandwidth limits kawasaki recorded for the container", func() {
+		fakeProperties.GetStub = func(handle, key string) (string, error) {
+			if key == accounting.BandwidthKey {
+				limits, err := json.Marshal(garden.BandwidthLimits{
+					RateInBytesPerSecond:      1000,
+					BurstRateInBytesPerSecond: 4000,
+				})
+				return string(limits), err
+			}
+
+			return "some-host-intf", nil
+		}
+
+		sample, err := sampler.Sample(logger, "some-handle")
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(sample.BandwidthRate).To(BeEquivalentTo(1000))
+		Expect(sample.BandwidthBurst).To(BeEquivalentTo(4000))
+	})
+
+	It("measures the disk usage of the container's root filesystem", func() {
+		sample, err := sampler.Sample(logger, "some-handle")
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(fakeRunner).To(HaveExecutedSerially(fake_command_runner.CommandSpec{
+			Path: "du",
+			Args: []string{"-sxb", "/path/to/rootfs"},
+		}))
+		Expect(sample.DiskBytes).To(BeEquivalentTo(8192))
+	})
+
+	It("reports no scratch usage when there is no scratch usager", func() {
+		sample, err := sampler.Sample(logger, "some-handle")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(sample.ScratchBytes).To(BeZero())
+	})
+
+	Context("when there is a scratch usager", func() {
+		var fakeScratchUsager *fakes.FakeScratchUsager
+
+		BeforeEach(func() {
+			fakeScratchUsager = new(fakes.FakeScratchUsager)
+			fakeScratchUsager.ScratchUsageReturns(2048, nil)
+			sampler.ScratchUsager = fakeScratchUsager
+		})
+
+		It("reports the scratch usage separately from the root filesystem", func() {
+			sample, err := sampler.Sample(logger, "some-handle")
+			Expect(err).NotTo(HaveOccurred())
+
+			_, handle := fakeScratchUsager.ScratchUsageArgsForCall(0)
+			Expect(handle).To(Equal("some-handle"))
+			Expect(sample.ScratchBytes).To(BeEquivalentTo(2048))
+			Expect(sample.DiskBytes).To(BeEquivalentTo(8192))
+		})
+
+		It("reports no scratch usage when it cannot be read", func() {
+			fakeScratchUsager.ScratchUsageReturns(0, errors.New("no project"))
+
+			sample, err := sampler.Sample(logger, "some-handle")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(sample.ScratchBytes).To(BeZero())
+		})
+	})
+
+	Context("when the container has no host interface", func() {
+		It("reports no network usage", func() {
+			fakeProperties.GetReturns("", errors.New("no such key"))
+
+			sample, err := sampler.Sample(logger, "some-handle")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(fakeNetworkStatter.StatisticsCallCount()).To(Equal(0))
+			Expect(sample.RxBytes).To(BeZero())
+		})
+	})
+
+	Context("when the cgroup cannot be read", func() {
+		It("returns an error", func() {
+			_, err := sampler.Sample(logger, "another-handle")
+			Expect(err).To(MatchError(ContainSubstring("read cpu usage")))
+		})
+	})
+
+	Context("when the cgroups are the unified hierarchy", func() {
+		BeforeEach(func() {
+			sampler.Unified = true
+			writeCgroupFile("", "cpu.stat", "usage_usec 2500000\nuser_usec 2000000\nsystem_usec 500000\nnr_periods 120\nnr_throttled 30\nthrottled_usec 1500000\n")
+			writeCgroupFile("", "memory.current", "8192\n")
+		})
+
+		It("reads the cpu usage, throttling and memory usage from the container's cgroup", func() {
+			sample, err := sampler.Sample(logger, "some-handle")
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(sample.CPUUsage).To(Equal(2500 * time.Millisecond))
+			Expect(sample.CPUThrottled).To(Equal(1500 * time.Millisecond))
+			Expect(sample.MemoryBytes).To(BeEquivalentTo(8192))
+		})
+
+		It("returns an error when the memory usage cannot be read", func() {
+			Expect(os.Remove(filepath.Join(cgroupPath, "some-handle", "memory.current"))).To(Succeed())
+
+			_, err := sampler.Sample(logger, "some-handle")
+			Expect(err).To(MatchError(ContainSubstring("read memory usage")))
+		})
+	})
+
+	Context("when there is a disk usage max age", func() {
+		var fakeClock *fakeclock.FakeClock
+
+		BeforeEach(func() {
+			fakeClock = fakeclock.NewFakeClock(time.Now())
+			sampler.DiskUsageMaxAge = time.Minute
+			sampler.Clock = fakeClock
+		})
+
+		duRuns := func() int {
+			return len(fakeRunner.ExecutedCommands())
+		}
+
+		It("reuses the last disk usage until it is that old", func() {
+			_, err := sampler.Sample(logger, "some-handle")
+			Expect(err).NotTo(HaveOccurred())
+
+			fakeClock.Increment(59 * time.Second)
+			sample, err := sampler.Sample(logger, "some-handle")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(sample.DiskBytes).To(BeEquivalentTo(8192))
+			Expect(duRuns()).To(Equal(1))
+
+			fakeClock.Increment(time.Second)
+			_, err = sampler.Sample(logger, "some-handle")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(duRuns()).To(Equal(2))
+		})
+
+		It("measures each container's disk usage separately", func() {
+			_, err := sampler.Sample(logger, "some-handle")
+			Expect(err).NotTo(HaveOccurred())
+
+			dir := filepath.Join(cgroupPath, "cpuacct", "another-handle")
+			Expect(os.MkdirAll(dir, 0755)).To(Succeed())
+			Expect(ioutil.WriteFile(filepath.Join(dir, "cpuacct.usage"), []byte("1\n"), 0644)).To(Succeed())
+			dir = filepath.Join(cgroupPath, "memory", "another-handle")
+			Expect(os.MkdirAll(dir, 0755)).To(Succeed())
+			Expect(ioutil.WriteFile(filepath.Join(dir, "memory.usage_in_bytes"), []byte("1\n"), 0644)).To(Succeed())
+
+			_, err = sampler.Sample(logger, "another-handle")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(duRuns()).To(Equal(2))
+		})
+
+		It("does not reuse a disk usage which could not be measured", func() {
+			fakeRootFSPather.RootFSPathReturns("", errors.New("no bundle"))
+			_, err := sampler.Sample(logger, "some-handle")
+			Expect(err).To(HaveOccurred())
+
+			fakeRootFSPather.RootFSPathReturns("/path/to/rootfs", nil)
+			sample, err := sampler.Sample(logger, "some-handle")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(sample.DiskBytes).To(BeEquivalentTo(8192))
+		})
+	})
+
+	Context("when the root filesystem cannot be found", func() {
+		It("returns an error", func() {
+			fakeRootFSPather.RootFSPathReturns("", errors.New("no bundle"))
+
+			_, err := sampler.Sample(logger, "some-handle")
+			Expect(err).To(MatchError("read disk usage: no bundle"))
+		})
+	})
+})
@@ -0,0 +1,53 @@
+// This file was generated by counterfeiter
+package fakes
+
+import (
+	"sync"
+
+	"github.com/cloudfoundry-incubator/guardian/accounting"
+)
+
+type FakeAnnotator struct {
+	AnnotationsStub        func(handle string) map[string]string
+	annotationsMutex       sync.RWMutex
+	annotationsArgsForCall []struct {
+		handle string
+	}
+	annotationsReturns struct {
+		result1 map[string]string
+	}
+}
+
+func (fake *FakeAnnotator) Annotations(handle string) map[string]string {
+	fake.annotationsMutex.Lock()
+	fake.annotationsArgsForCall = append(fake.annotationsArgsForCall, struct {
+		handle string
+	}{handle})
+	fake.annotationsMutex.Unlock()
+	if fake.AnnotationsStub != nil {
+		return fake.AnnotationsStub(handle)
+	} else {
+		return fake.annotationsReturns.result1
+	}
+}
+
+func (fake *FakeAnnotator) AnnotationsCallCount() int {
+	fake.annotationsMutex.RLock()
+	defer fake.annotationsMutex.RUnlock()
+	return len(fake.annotationsArgsForCall)
+}
+
+func (fake *FakeAnnotator) AnnotationsArgsForCall(i int) string {
+	fake.annotationsMutex.RLock()
+	defer fake.annotationsMutex.RUnlock()
+	return fake.annotationsArgsForCall[i].handle
+}
+
+func (fake *FakeAnnotator) AnnotationsReturns(result1 map[string]string) {
+	fake.AnnotationsStub = nil
+	fake.annotationsReturns = struct {
+		result1 map[string]string
+	}{result1}
+}
+
+var _ accounting.Annotator = new(FakeAnnotator)
@@ -0,0 +1,56 @@
+// This file was generated by counterfeiter
+package fakes
+
+import (
+	"sync"
+
+	"github.com/cloudfoundry-incubator/garden"
+	"github.com/cloudfoundry-incubator/guardian/accounting"
+)
+
+type FakeNetworkStatter struct {
+	StatisticsStub        func(name string) (garden.ContainerNetworkStat, error)
+	statisticsMutex       sync.RWMutex
+	statisticsArgsForCall []struct {
+		name string
+	}
+	statisticsReturns struct {
+		result1 garden.ContainerNetworkStat
+		result2 error
+	}
+}
+
+func (fake *FakeNetworkStatter) Statistics(name string) (garden.ContainerNetworkStat, error) {
+	fake.statisticsMutex.Lock()
+	fake.statisticsArgsForCall = append(fake.statisticsArgsForCall, struct {
+		name string
+	}{name})
+	fake.statisticsMutex.Unlock()
+	if fake.StatisticsStub != nil {
+		return fake.StatisticsStub(name)
+	} else {
+		return fake.statisticsReturns.result1, fake.statisticsReturns.result2
+	}
+}
+
+func (fake *FakeNetworkStatter) StatisticsCallCount() int {
+	fake.statisticsMutex.RLock()
+	defer fake.statisticsMutex.RUnlock()
+	return len(fake.statisticsArgsForCall)
+}
+
+func (fake *FakeNetworkStatter) StatisticsArgsForCall(i int) string {
+	fake.statisticsMutex.RLock()
+	defer fake.statisticsMutex.RUnlock()
+	return fake.statisticsArgsForCall[i].name
+}
+
+func (fake *FakeNetworkStatter) StatisticsReturns(result1 garden.ContainerNetworkStat, result2 error) {
+	fake.StatisticsStub = nil
+	fake.statisticsReturns = struct {
+		result1 garden.ContainerNetworkStat
+		result2 error
+	}{result1, result2}
+}
+
+var _ accounting.NetworkStatter = new(FakeNetworkStatter)
@@ -0,0 +1,55 @@
+// This file was generated by counterfeiter
+package fakes
+
+import (
+	"sync"
+
+	"github.com/cloudfoundry-incubator/guardian/accounting"
+)
+
+type FakeRootFSPather struct {
+	RootFSPathStub        func(handle string) (string, error)
+	rootFSPathMutex       sync.RWMutex
+	rootFSPathArgsForCall []struct {
+		handle string
+	}
+	rootFSPathReturns struct {
+		result1 string
+		result2 error
+	}
+}
+
+func (fake *FakeRootFSPather) RootFSPath(handle string) (string, error) {
+	fake.rootFSPathMutex.Lock()
+	fake.rootFSPathArgsForCall = append(fake.rootFSPathArgsForCall, struct {
+		handle string
+	}{handle})
+	fake.rootFSPathMutex.Unlock()
+	if fake.RootFSPathStub != nil {
+		return fake.RootFSPathStub(handle)
+	} else {
+		return fake.rootFSPathReturns.result1, fake.rootFSPathReturns.result2
+	}
+}
+
+func (fake *FakeRootFSPather) RootFSPathCallCount() int {
+	fake.rootFSPathMutex.RLock()
+	defer fake.rootFSPathMutex.RUnlock()
+	return len(fake.rootFSPathArgsForCall)
+}
+
+func (fake *FakeRootFSPather) RootFSPathArgsForCall(i int) string {
+	fake.rootFSPathMutex.RLock()
+	defer fake.rootFSPathMutex.RUnlock()
+	return fake.rootFSPathArgsForCall[i].handle
+}
+
+func (fake *FakeRootFSPather) RootFSPathReturns(result1 string, result2 error) {
+	fake.RootFSPathStub = nil
+	fake.rootFSPathReturns = struct {
+		result1 string
+		result2 error
+	}{result1, result2}
+}
+
+var _ accounting.RootFSPather = new(FakeRootFSPather)
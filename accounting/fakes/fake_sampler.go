@@ -0,0 +1,58 @@
+// This file was generated by counterfeiter
+package fakes
+
+import (
+	"sync"
+
+	"github.com/cloudfoundry-incubator/guardian/accounting"
+	"github.com/pivotal-golang/lager"
+)
+
+type FakeSampler struct {
+	SampleStub        func(log lager.Logger, handle string) (accounting.Sample, error)
+	sampleMutex       sync.RWMutex
+	sampleArgsForCall []struct {
+		log    lager.Logger
+		handle string
+	}
+	sampleReturns struct {
+		result1 accounting.Sample
+		result2 error
+	}
+}
+
+func (fake *FakeSampler) Sample(log lager.Logger, handle string) (accounting.Sample, error) {
+	fake.sampleMutex.Lock()
+	fake.sampleArgsForCall = append(fake.sampleArgsForCall, struct {
+		log    lager.Logger
+		handle string
+	}{log, handle})
+	fake.sampleMutex.Unlock()
+	if fake.SampleStub != nil {
+		return fake.SampleStub(log, handle)
+	} else {
+		return fake.sampleReturns.result1, fake.sampleReturns.result2
+	}
+}
+
+func (fake *FakeSampler) SampleCallCount() int {
+	fake.sampleMutex.RLock()
+	defer fake.sampleMutex.RUnlock()
+	return len(fake.sampleArgsForCall)
+}
+
+func (fake *FakeSampler) SampleArgsForCall(i int) (lager.Logger, string) {
+	fake.sampleMutex.RLock()
+	defer fake.sampleMutex.RUnlock()
+	return fake.sampleArgsForCall[i].log, fake.sampleArgsForCall[i].handle
+}
+
+func (fake *FakeSampler) SampleReturns(result1 accounting.Sample, result2 error) {
+	fake.SampleStub = nil
+	fake.sampleReturns = struct {
+		result1 accounting.Sample
+		result2 error
+	}{result1, result2}
+}
+
+var _ accounting.Sampler = new(FakeSampler)
@@ -0,0 +1,58 @@
+// This file was generated by counterfeiter
+package fakes
+
+import (
+	"sync"
+
+	"github.com/cloudfoundry-incubator/guardian/accounting"
+	"github.com/pivotal-golang/lager"
+)
+
+type FakeScratchUsager struct {
+	ScratchUsageStub        func(log lager.Logger, handle string) (uint64, error)
+	scratchUsageMutex       sync.RWMutex
+	scratchUsageArgsForCall []struct {
+		log    lager.Logger
+		handle string
+	}
+	scratchUsageReturns struct {
+		result1 uint64
+		result2 error
+	}
+}
+
+func (fake *FakeScratchUsager) ScratchUsage(log lager.Logger, handle string) (uint64, error) {
+	fake.scratchUsageMutex.Lock()
+	fake.scratchUsageArgsForCall = append(fake.scratchUsageArgsForCall, struct {
+		log    lager.Logger
+		handle string
+	}{log, handle})
+	fake.scratchUsageMutex.Unlock()
+	if fake.ScratchUsageStub != nil {
+		return fake.ScratchUsageStub(log, handle)
+	} else {
+		return fake.scratchUsageReturns.result1, fake.scratchUsageReturns.result2
+	}
+}
+
+func (fake *FakeScratchUsager) ScratchUsageCallCount() int {
+	fake.scratchUsageMutex.RLock()
+	defer fake.scratchUsageMutex.RUnlock()
+	return len(fake.scratchUsageArgsForCall)
+}
+
+func (fake *FakeScratchUsager) ScratchUsageArgsForCall(i int) (lager.Logger, string) {
+	fake.scratchUsageMutex.RLock()
+	defer fake.scratchUsageMutex.RUnlock()
+	return fake.scratchUsageArgsForCall[i].log, fake.scratchUsageArgsForCall[i].handle
+}
+
+func (fake *FakeScratchUsager) ScratchUsageReturns(result1 uint64, result2 error) {
+	fake.ScratchUsageStub = nil
+	fake.scratchUsageReturns = struct {
+		result1 uint64
+		result2 error
+	}{result1, result2}
+}
+
+var _ accounting.ScratchUsager = new(FakeScratchUsager)
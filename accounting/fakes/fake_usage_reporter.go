@@ -0,0 +1,43 @@
+// This file was generated by counterfeiter
+package fakes
+
+import (
+	"sync"
+
+	"github.com/cloudfoundry-incubator/guardian/accounting"
+)
+
+type FakeUsageReporter struct {
+	UsagesStub        func() []accounting.Usage
+	usagesMutex       sync.RWMutex
+	usagesArgsForCall []struct{}
+	usagesReturns     struct {
+		result1 []accounting.Usage
+	}
+}
+
+func (fake *FakeUsageReporter) Usages() []accounting.Usage {
+	fake.usagesMutex.Lock()
+	fake.usagesArgsForCall = append(fake.usagesArgsForCall, struct{}{})
+	fake.usagesMutex.Unlock()
+	if fake.UsagesStub != nil {
+		return fake.UsagesStub()
+	} else {
+		return fake.usagesReturns.result1
+	}
+}
+
+func (fake *FakeUsageReporter) UsagesCallCount() int {
+	fake.usagesMutex.RLock()
+	defer fake.usagesMutex.RUnlock()
+	return len(fake.usagesArgsForCall)
+}
+
+func (fake *FakeUsageReporter) UsagesReturns(result1 []accounting.Usage) {
+	fake.UsagesStub = nil
+	fake.usagesReturns = struct {
+		result1 []accounting.Usage
+	}{result1}
+}
+
+var _ accounting.UsageReporter = new(FakeUsageReporter)
@@ -0,0 +1,109 @@
+package accounting
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+)
+
+//go:generate counterfeiter . UsageReporter
+//go:generate counterfeiter . Annotator
+
+type UsageReporter interface {
+	Usages() []Usage
+}
+
+type Annotator interface {
+	Annotations(handle string) map[string]string
+}
+
+var csvHeader = []string{
+	"handle",
+	"since",
+	"last_sampled",
+	"cpu_seconds",
+	"cpu_throttled_seconds",
+	"memory_byte_hours",
+	"disk_byte_hours",
+	"scratch_byte_hours",
+	"network_rx_bytes",
+	"network_tx_bytes",
+}
+
+// Handler exports the usage of every container as JSON, or as CSV if the
+// request has a `format=csv` query parameter. If there is an Annotator, each
+// container's annotations are included; in CSV each annotation is a column.
+type Handler struct {
+	Reporter  UsageReporter
+	Annotator Annotator
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	usages := h.Reporter.Usages()
+	if h.Annotator != nil {
+		for i := range usages {
+			usages[i].Annotations = h.Annotator.Annotations(usages[i].Handle)
+		}
+	}
+
+	switch r.URL.Query().Get("format") {
+	case "", "json":
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(usages)
+	case "csv":
+		w.Header().Set("Content-Type", "text/csv")
+		writeCSV(w, usages)
+	default:
+		http.Error(w, fmt.Sprintf("unknown format: %s", r.URL.Query().Get("format")), http.StatusBadRequest)
+	}
+}
+
+func writeCSV(w http.ResponseWriter, usages []Usage) {
+	annotationKeys := annotationKeys(usages)
+
+	writer := csv.NewWriter(w)
+	writer.Write(append(append([]string{}, csvHeader...), annotationKeys...))
+
+	for _, u := range usages {
+		record := []string{
+			u.Handle,
+			u.Since.UTC().Format(time.RFC3339),
+			u.LastSampled.UTC().Format(time.RFC3339),
+			strconv.FormatFloat(u.CPUSeconds, 'f', -1, 64),
+			strconv.FormatFloat(u.CPUThrottledSeconds, 'f', -1, 64),
+			strconv.FormatFloat(u.MemoryByteHours, 'f', -1, 64),
+			strconv.FormatFloat(u.DiskByteHours, 'f', -1, 64),
+			strconv.FormatFloat(u.ScratchByteHours, 'f', -1, 64),
+			strconv.FormatUint(u.NetworkRxBytes, 10),
+			strconv.FormatUint(u.NetworkTxBytes, 10),
+		}
+
+		for _, key := range annotationKeys {
+			record = append(record, u.Annotations[key])
+		}
+
+		writer.Write(record)
+	}
+
+	writer.Flush()
+}
+
+func annotationKeys(usages []Usage) []string {
+	seen := make(map[string]bool)
+	var keys []string
+	for _, u := range usages {
+		for key := range u.Annotations {
+			if !seen[key] {
+				seen[key] = true
+				keys = append(keys, key)
+			}
+		}
+	}
+
+	sort.Strings(keys)
+	return keys
+}
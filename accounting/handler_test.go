@@ -0,0 +1,118 @@
+package accounting_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	"github.com/cloudfoundry-incubator/guardian/accounting"
+	"github.com/cloudfoundry-incubator/guardian/accounting/fakes"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Handler", func() {
+	var (
+		fakeReporter *fakes.FakeUsageReporter
+		handler      *accounting.Handler
+		recorder     *httptest.ResponseRecorder
+		usages       []accounting.Usage
+	)
+
+	BeforeEach(func() {
+		usages = []accounting.Usage{
+			{
+				Handle:              "apple",
+				Since:               time.Unix(0, 0),
+				LastSampled:         time.Unix(3600, 0),
+				CPUSeconds:          1.5,
+				CPUThrottledSeconds: 0.25,
+				MemoryByteHours:     1024,
+				DiskByteHours:       2048,
+				ScratchByteHours:    512,
+				NetworkRxBytes:      10,
+				NetworkTxBytes:      20,
+			},
+		}
+
+		fakeReporter = new(fakes.FakeUsageReporter)
+		fakeReporter.UsagesReturns(usages)
+
+		handler = &accounting.Handler{Reporter: fakeReporter}
+		recorder = httptest.NewRecorder()
+	})
+
+	It("exports the usage as JSON by default", func() {
+		req, err := http.NewRequest("GET", "/accounting", nil)
+		Expect(err).NotTo(HaveOccurred())
+
+		handler.ServeHTTP(recorder, req)
+
+		Expect(recorder.Code).To(Equal(http.StatusOK))
+		Expect(recorder.HeaderMap.Get("Content-Type")).To(Equal("application/json"))
+
+		var exported []accounting.Usage
+		Expect(json.NewDecoder(recorder.Body).Decode(&exported)).To(Succeed())
+		Expect(exported).To(HaveLen(1))
+		Expect(exported[0].Handle).To(Equal("apple"))
+		Expect(exported[0].MemoryByteHours).To(Equal(1024.0))
+	})
+
+	It("exports the usage as CSV when requested", func() {
+		req, err := http.NewRequest("GET", "/accounting?format=csv", nil)
+		Expect(err).NotTo(HaveOccurred())
+
+		handler.ServeHTTP(recorder, req)
+
+		Expect(recorder.Code).To(Equal(http.StatusOK))
+		Expect(recorder.HeaderMap.Get("Content-Type")).To(Equal("text/csv"))
+		Expect(recorder.Body.String()).To(Equal(
+			"handle,since,last_sampled,cpu_seconds,cpu_throttled_seconds,memory_byte_hours,disk_byte_hours,scratch_byte_hours,network_rx_bytes,network_tx_bytes\n" +
+				"apple,1970-01-01T00:00:00Z,1970-01-01T01:00:00Z,1.5,0.25,1024,2048,512,10,20\n",
+		))
+	})
+
+	Context("when there is an annotator", func() {
+		BeforeEach(func() {
+			annotator := new(fakes.FakeAnnotator)
+			annotator.AnnotationsReturns(map[string]string{"org_id": "some-org", "app_id": "some-app"})
+			handler.Annotator = annotator
+		})
+
+		It("includes the annotations in the JSON", func() {
+			req, err := http.NewRequest("GET", "/accounting", nil)
+			Expect(err).NotTo(HaveOccurred())
+
+			handler.ServeHTTP(recorder, req)
+
+			var exported []accounting.Usage
+			Expect(json.NewDecoder(recorder.Body).Decode(&exported)).To(Succeed())
+			Expect(exported[0].Annotations).To(Equal(map[string]string{"org_id": "some-org", "app_id": "some-app"}))
+		})
+
+		It("adds a CSV column for each annotation", func() {
+			req, err := http.NewRequest("GET", "/accounting?format=csv", nil)
+			Expect(err).NotTo(HaveOccurred())
+
+			handler.ServeHTTP(recorder, req)
+
+			Expect(recorder.Body.String()).To(Equal(
+				"handle,since,last_sampled,cpu_seconds,cpu_throttled_seconds,memory_byte_hours,disk_byte_hours,scratch_byte_hours,network_rx_bytes,network_tx_bytes,app_id,org_id\n" +
+					"apple,1970-01-01T00:00:00Z,1970-01-01T01:00:00Z,1.5,0.25,1024,2048,512,10,20,some-app,some-org\n",
+			))
+		})
+	})
+
+	Context("when an unknown format is requested", func() {
+		It("returns a bad request", func() {
+			req, err := http.NewRequest("GET", "/accounting?format=xml", nil)
+			Expect(err).NotTo(HaveOccurred())
+
+			handler.ServeHTTP(recorder, req)
+
+			Expect(recorder.Code).To(Equal(http.StatusBadRequest))
+		})
+	})
+})
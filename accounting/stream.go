@@ -0,0 +1,210 @@
+package accounting
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/pivotal-golang/clock"
+	"github.com/pivotal-golang/lager"
+)
+
+// ContainerSample is a sample of a container's resource usage, as streamed
+// to clients. CPU times are in nanoseconds.
+type ContainerSample struct {
+	Handle         string    `json:"handle"`
+	SampledAt      time.Time `json:"sampled_at"`
+	CPUUsage       uint64    `json:"cpu_usage_ns"`
+	CPUThrottled   uint64    `json:"cpu_throttled_ns"`
+	MemoryBytes    uint64    `json:"memory_bytes"`
+	DiskBytes      uint64    `json:"disk_bytes"`
+	ScratchBytes   uint64    `json:"scratch_bytes"`
+	NetworkRxBytes uint64    `json:"network_rx_bytes"`
+	NetworkTxBytes uint64    `json:"network_tx_bytes"`
+
+	// Annotations are selected properties of the container, added if the
+	// Streamer has an Annotator
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+// Streamer samples every container each Interval and pushes the samples to
+// every subscriber, so that however many clients are streaming metrics each
+// container is sampled once per interval, rather than once per client
+// request. Nothing is sampled while there are no subscribers. If there is an
+// Annotator, each sample includes the container's annotations.
+type Streamer struct {
+	Sampler   Sampler
+	Lister    HandleLister
+	Annotator Annotator
+	Clock     clock.Clock
+	Interval  time.Duration
+	Logger    lager.Logger
+
+	mu          sync.Mutex
+	subscribers map[chan []ContainerSample]struct{}
+}
+
+// Start begins sampling in the background every interval.
+func (s *Streamer) Start() error {
+	go func() {
+		ticker := s.Clock.NewTicker(s.Interval)
+		defer ticker.Stop()
+
+		for range ticker.C() {
+			s.SampleAll()
+		}
+	}()
+
+	return nil
+}
+
+// Subscribe returns a channel on which the samples of every container are
+// received each interval, and a function which unsubscribes. A subscriber
+// which has not received the previous samples misses the next ones rather
+// than holding up the others.
+func (s *Streamer) Subscribe() (<-chan []ContainerSample, func()) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.subscribers == nil {
+		s.subscribers = make(map[chan []ContainerSample]struct{})
+	}
+
+	samples := make(chan []ContainerSample, 1)
+	s.subscribers[samples] = struct{}{}
+
+	return samples, func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		if _, ok := s.subscribers[samples]; ok {
+			delete(s.subscribers, samples)
+			close(samples)
+		}
+	}
+}
+
+// SampleAll samples every container and pushes the samples to the
+// subscribers, if there are any
+func (s *Streamer) SampleAll() {
+	if s.subscriberCount() == 0 {
+		return
+	}
+
+	log := s.Logger.Session("stream-sample-all")
+
+	handles, err := s.Lister.Handles()
+	if err != nil {
+		log.Error("list-handles-failed", err)
+		return
+	}
+
+	samples := make([]ContainerSample, 0, len(handles))
+	for _, handle := range handles {
+		sample, err := s.Sampler.Sample(log, handle)
+		if err != nil {
+			log.Error("sample-failed", err, lager.Data{"handle": handle})
+			continue
+		}
+
+		containerSample := ContainerSample{
+			Handle:         handle,
+			SampledAt:      s.Clock.Now(),
+			CPUUsage:       uint64(sample.CPUUsage),
+			CPUThrottled:   uint64(sample.CPUThrottled),
+			MemoryBytes:    sample.MemoryBytes,
+			DiskBytes:      sample.DiskBytes,
+			ScratchBytes:   sample.ScratchBytes,
+			NetworkRxBytes: sample.RxBytes,
+			NetworkTxBytes: sample.TxBytes,
+		}
+
+		if s.Annotator != nil {
+			containerSample.Annotations = s.Annotator.Annotations(handle)
+		}
+
+		samples = append(samples, containerSample)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for subscriber := range s.subscribers {
+		select {
+		case subscriber <- samples:
+		default:
+			log.Info("subscriber-too-slow-skipping")
+		}
+	}
+}
+
+func (s *Streamer) subscriberCount() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return len(s.subscribers)
+}
+
+// StreamHandler streams the samples of every container as newline-delimited
+// JSON, one line (a list of samples) per interval, until the client
+// disconnects. Samples can be limited to a single container with the
+// `handle` query parameter.
+type StreamHandler struct {
+	Streamer *Streamer
+}
+
+func (h *StreamHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	handle := r.URL.Query().Get("handle")
+
+	stream, unsubscribe := h.Streamer.Subscribe()
+	defer unsubscribe()
+
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.WriteHeader(http.StatusOK)
+	flush(w)
+
+	var closed <-chan bool
+	if notifier, ok := w.(http.CloseNotifier); ok {
+		closed = notifier.CloseNotify()
+	}
+
+	encoder := json.NewEncoder(w)
+	for {
+		select {
+		case samples, ok := <-stream:
+			if !ok {
+				return
+			}
+
+			if handle != "" {
+				samples = forHandle(samples, handle)
+			}
+
+			if err := encoder.Encode(samples); err != nil {
+				return
+			}
+
+			flush(w)
+		case <-closed:
+			return
+		}
+	}
+}
+
+func forHandle(samples []ContainerSample, handle string) []ContainerSample {
+	filtered := []ContainerSample{}
+	for _, sample := range samples {
+		if sample.Handle == handle {
+			filtered = append(filtered, sample)
+		}
+	}
+
+	return filtered
+}
+
+func flush(w http.ResponseWriter) {
+	if flusher, ok := w.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
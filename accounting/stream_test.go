@@ -0,0 +1,189 @@
+package accounting_test
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	"github.com/cloudfoundry-incubator/guardian/accounting"
+	"github.com/cloudfoundry-incubator/guardian/accounting/fakes"
+	"github.com/pivotal-golang/clock/fakeclock"
+	"github.com/pivotal-golang/lager"
+	"github.com/pivotal-golang/lager/lagertest"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Streamer", func() {
+	var (
+		fakeSampler *fakes.FakeSampler
+		fakeLister  *fakes.FakeHandleLister
+		fakeClock   *fakeclock.FakeClock
+		streamer    *accounting.Streamer
+	)
+
+	BeforeEach(func() {
+		fakeSampler = new(fakes.FakeSampler)
+		fakeLister = new(fakes.FakeHandleLister)
+		fakeClock = fakeclock.NewFakeClock(time.Unix(1000, 0))
+
+		fakeLister.HandlesReturns([]string{"apple", "banana"}, nil)
+		fakeSampler.SampleStub = func(_ lager.Logger, handle string) (accounting.Sample, error) {
+			return accounting.Sample{
+				CPUUsage:    3 * time.Second,
+				MemoryBytes: 1000,
+				DiskBytes:   2000,
+				RxBytes:     10,
+				TxBytes:     20,
+			}, nil
+		}
+
+		streamer = &accounting.Streamer{
+			Sampler:  fakeSampler,
+			Lister:   fakeLister,
+			Clock:    fakeClock,
+			Interval: 10 * time.Second,
+			Logger:   lagertest.NewTestLogger("test"),
+		}
+	})
+
+	It("does not sample while there are no subscribers", func() {
+		streamer.SampleAll()
+		Expect(fakeSampler.SampleCallCount()).To(Equal(0))
+	})
+
+	It("pushes the samples of every container to every subscriber", func() {
+		first, unsubscribeFirst := streamer.Subscribe()
+		defer unsubscribeFirst()
+		second, unsubscribeSecond := streamer.Subscribe()
+		defer unsubscribeSecond()
+
+		streamer.SampleAll()
+		Expect(fakeSampler.SampleCallCount()).To(Equal(2))
+
+		var samples []accounting.ContainerSample
+		Expect(first).To(Receive(&samples))
+		Expect(samples).To(Equal([]accounting.ContainerSample{
+			{Handle: "apple", SampledAt: time.Unix(1000, 0), CPUUsage: 3000000000, MemoryBytes: 1000, DiskBytes: 2000, NetworkRxBytes: 10, NetworkTxBytes: 20},
+			{Handle: "banana", SampledAt: time.Unix(1000, 0), CPUUsage: 3000000000, MemoryBytes: 1000, DiskBytes: 2000, NetworkRxBytes: 10, NetworkTxBytes: 20},
+		}))
+		Expect(second).To(Receive(&samples))
+		Expect(samples).To(HaveLen(2))
+	})
+
+	It("adds each container's annotations to its sample", func() {
+		annotator := new(fakes.FakeAnnotator)
+		annotator.AnnotationsStub = func(handle string) map[string]string {
+			return map[string]string{"org_id": handle + "-org"}
+		}
+		streamer.Annotator = annotator
+
+		stream, unsubscribe := streamer.Subscribe()
+		defer unsubscribe()
+
+		streamer.SampleAll()
+
+		var samples []accounting.ContainerSample
+		Expect(stream).To(Receive(&samples))
+		Expect(samples).To(HaveLen(2))
+		Expect(samples[0].Annotations).To(Equal(map[string]string{"org_id": "apple-org"}))
+		Expect(samples[1].Annotations).To(Equal(map[string]string{"org_id": "banana-org"}))
+	})
+
+	It("skips containers which cannot be sampled", func() {
+		fakeSampler.SampleStub = func(_ lager.Logger, handle string) (accounting.Sample, error) {
+			if handle == "apple" {
+				return accounting.Sample{}, errors.New("gone")
+			}
+
+			return accounting.Sample{MemoryBytes: 1}, nil
+		}
+
+		stream, unsubscribe := streamer.Subscribe()
+		defer unsubscribe()
+
+		streamer.SampleAll()
+
+		var samples []accounting.ContainerSample
+		Expect(stream).To(Receive(&samples))
+		Expect(samples).To(HaveLen(1))
+		Expect(samples[0].Handle).To(Equal("banana"))
+	})
+
+	It("does not block on subscribers which are not receiving", func() {
+		stream, unsubscribe := streamer.Subscribe()
+		defer unsubscribe()
+
+		streamer.SampleAll()
+		streamer.SampleAll()
+
+		Expect(stream).To(Receive())
+		Expect(stream).NotTo(Receive())
+	})
+
+	It("closes the stream when unsubscribed", func() {
+		stream, unsubscribe := streamer.Subscribe()
+		unsubscribe()
+
+		Expect(stream).To(BeClosed())
+	})
+
+	It("samples every interval once started", func() {
+		_, unsubscribe := streamer.Subscribe()
+		defer unsubscribe()
+
+		Expect(streamer.Start()).To(Succeed())
+		Eventually(fakeClock.WatcherCount).Should(Equal(1))
+
+		fakeClock.Increment(10 * time.Second)
+		Eventually(fakeSampler.SampleCallCount).Should(Equal(2))
+	})
+
+	Describe("StreamHandler", func() {
+		var server *httptest.Server
+
+		BeforeEach(func() {
+			server = httptest.NewServer(&accounting.StreamHandler{Streamer: streamer})
+		})
+
+		AfterEach(func() {
+			server.Close()
+		})
+
+		readSamples := func(url string) []accounting.ContainerSample {
+			resp, err := http.Get(url)
+			Expect(err).NotTo(HaveOccurred())
+			defer resp.Body.Close()
+
+			Expect(resp.StatusCode).To(Equal(http.StatusOK))
+			Expect(resp.Header.Get("Content-Type")).To(Equal("application/x-ndjson"))
+
+			// the handler has subscribed by the time the headers are sent
+			streamer.SampleAll()
+
+			line, err := bufio.NewReader(resp.Body).ReadBytes('\n')
+			Expect(err).NotTo(HaveOccurred())
+
+			var samples []accounting.ContainerSample
+			Expect(json.Unmarshal(line, &samples)).To(Succeed())
+			return samples
+		}
+
+		It("streams the samples of every container", func() {
+			samples := readSamples(server.URL)
+			Expect(samples).To(HaveLen(2))
+			Expect(samples[0].Handle).To(Equal("apple"))
+			Expect(samples[0].MemoryBytes).To(BeEquivalentTo(1000))
+		})
+
+		It("only streams the samples of the requested container", func() {
+			samples := readSamples(server.URL + "?handle=banana")
+			Expect(samples).To(HaveLen(1))
+			Expect(samples[0].Handle).To(Equal("banana"))
+		})
+	})
+})
@@ -0,0 +1,102 @@
+package authplugin
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os/exec"
+	"strings"
+
+	"github.com/cloudfoundry-incubator/guardian/gardener"
+	"github.com/cloudfoundry/gunk/command_runner"
+	"github.com/pivotal-golang/lager"
+)
+
+// Decision is an authorizer's answer to a gardener.AuthRequest
+type Decision struct {
+	Allowed bool   `json:"allowed"`
+	Reason  string `json:"reason,omitempty"`
+}
+
+// ExternalAuthorizer authorizes requests by running an authorizer binary:
+//
+//	<binary> <extra args> authorize
+//
+// which is given the request as JSON on stdin and prints a Decision as JSON
+// on stdout. If the binary fails or prints anything else, the request is
+// refused. CommandRunner should time the binary out (see cmdtimeout), so that
+// a hung binary refuses requests rather than holding them up.
+type ExternalAuthorizer struct {
+	Binary        string
+	ExtraArgs     []string
+	CommandRunner command_runner.CommandRunner
+}
+
+func (a *ExternalAuthorizer) Authorize(log lager.Logger, request gardener.AuthRequest) error {
+	encoded, err := json.Marshal(request)
+	if err != nil {
+		return err
+	}
+
+	stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+	cmd := exec.Command(a.Binary, append(append([]string{}, a.ExtraArgs...), "authorize")...)
+	cmd.Stdin = bytes.NewReader(encoded)
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+
+	if err := a.CommandRunner.Run(cmd); err != nil {
+		log.Error("authorizer-failed", err, lager.Data{"stderr": stderr.String()})
+		return fmt.Errorf("authorizer: %s: %s", err, strings.TrimSpace(stderr.String()))
+	}
+
+	return decide(request, stdout.Bytes())
+}
+
+// HTTPAuthorizer authorizes requests by POSTing them as JSON to URL, which
+// must respond 200 OK with a Decision as JSON. Any other response refuses
+// the request.
+type HTTPAuthorizer struct {
+	URL    string
+	Client *http.Client
+}
+
+func (a *HTTPAuthorizer) Authorize(log lager.Logger, request gardener.AuthRequest) error {
+	encoded, err := json.Marshal(request)
+	if err != nil {
+		return err
+	}
+
+	resp, err := a.Client.Post(a.URL, "application/json", bytes.NewReader(encoded))
+	if err != nil {
+		log.Error("authorizer-request-failed", err)
+		return fmt.Errorf("authorizer: %s", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("authorizer: read response: %s", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		log.Error("authorizer-request-failed", fmt.Errorf("status %d", resp.StatusCode), lager.Data{"body": string(body)})
+		return fmt.Errorf("authorizer: unexpected status %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	return decide(request, body)
+}
+
+func decide(request gardener.AuthRequest, output []byte) error {
+	var decision Decision
+	if err := json.Unmarshal(output, &decision); err != nil {
+		return fmt.Errorf("authorizer: invalid decision '%s': %s", strings.TrimSpace(string(output)), err)
+	}
+
+	if !decision.Allowed {
+		return gardener.NotAuthorizedError{Action: request.Action, Reason: decision.Reason}
+	}
+
+	return nil
+}
@@ -0,0 +1,134 @@
+package authplugin_test
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os/exec"
+
+	"github.com/cloudfoundry-incubator/guardian/authplugin"
+	"github.com/cloudfoundry-incubator/guardian/gardener"
+	"github.com/cloudfoundry/gunk/command_runner/fake_command_runner"
+	. "github.com/cloudfoundry/gunk/command_runner/fake_command_runner/matchers"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/pivotal-golang/lager/lagertest"
+)
+
+var request = gardener.AuthRequest{
+	Caller:     "unix:/var/vcap/data/garden/ssh.sock",
+	Action:     gardener.ActionCreate,
+	Handle:     "some-handle",
+	Privileged: true,
+}
+
+var _ = Describe("ExternalAuthorizer", func() {
+	var (
+		commandRunner *fake_command_runner.FakeCommandRunner
+		authorizer    *authplugin.ExternalAuthorizer
+		stdin         []byte
+		decision      string
+	)
+
+	BeforeEach(func() {
+		commandRunner = fake_command_runner.New()
+		authorizer = &authplugin.ExternalAuthorizer{
+			Binary:        "/path/to/policy",
+			ExtraArgs:     []string{"--policy", "/etc/policy.json"},
+			CommandRunner: commandRunner,
+		}
+
+		decision = `{"allowed": true}`
+		commandRunner.WhenRunning(fake_command_runner.CommandSpec{Path: "/path/to/policy"}, func(cmd *exec.Cmd) error {
+			var err error
+			stdin, err = ioutil.ReadAll(cmd.Stdin)
+			Expect(err).NotTo(HaveOccurred())
+
+			cmd.Stdout.Write([]byte(decision))
+			return nil
+		})
+	})
+
+	It("runs the binary's authorize command with the request on stdin", func() {
+		Expect(authorizer.Authorize(lagertest.NewTestLogger("test"), request)).To(Succeed())
+
+		Expect(commandRunner).To(HaveExecutedSerially(fake_command_runner.CommandSpec{
+			Path: "/path/to/policy",
+			Args: []string{"--policy", "/etc/policy.json", "authorize"},
+		}))
+
+		var received gardener.AuthRequest
+		Expect(json.Unmarshal(stdin, &received)).To(Succeed())
+		Expect(received).To(Equal(request))
+	})
+
+	It("refuses requests which the binary denies, with its reason", func() {
+		decision = `{"allowed": false, "reason": "privileged containers are reserved for operators"}`
+
+		err := authorizer.Authorize(lagertest.NewTestLogger("test"), request)
+		Expect(err).To(Equal(gardener.NotAuthorizedError{Action: "create", Reason: "privileged containers are reserved for operators"}))
+		Expect(err).To(MatchError("not authorized to create: privileged containers are reserved for operators"))
+	})
+
+	It("refuses requests when the binary prints something other than a decision", func() {
+		decision = "yes"
+		Expect(authorizer.Authorize(lagertest.NewTestLogger("test"), request)).To(MatchError(HavePrefix("authorizer: invalid decision 'yes'")))
+	})
+
+	It("refuses requests when the binary fails", func() {
+		commandRunner.WhenRunning(fake_command_runner.CommandSpec{Path: "/path/to/policy"}, func(cmd *exec.Cmd) error {
+			cmd.Stderr.Write([]byte("policy not found\n"))
+			return errors.New("exit status 2")
+		})
+
+		Expect(authorizer.Authorize(lagertest.NewTestLogger("test"), request)).To(MatchError("authorizer: exit status 2: policy not found"))
+	})
+})
+
+var _ = Describe("HTTPAuthorizer", func() {
+	var (
+		server     *httptest.Server
+		received   gardener.AuthRequest
+		status     int
+		decision   string
+		authorizer *authplugin.HTTPAuthorizer
+	)
+
+	BeforeEach(func() {
+		status = http.StatusOK
+		decision = `{"allowed": true}`
+
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			Expect(r.Method).To(Equal("POST"))
+			Expect(r.Header.Get("Content-Type")).To(Equal("application/json"))
+			Expect(json.NewDecoder(r.Body).Decode(&received)).To(Succeed())
+
+			w.WriteHeader(status)
+			w.Write([]byte(decision))
+		}))
+
+		authorizer = &authplugin.HTTPAuthorizer{URL: server.URL + "/authorize", Client: http.DefaultClient}
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	It("posts the request to the URL", func() {
+		Expect(authorizer.Authorize(lagertest.NewTestLogger("test"), request)).To(Succeed())
+		Expect(received).To(Equal(request))
+	})
+
+	It("refuses requests which the callout denies, with its reason", func() {
+		decision = `{"allowed": false, "reason": "nope"}`
+		Expect(authorizer.Authorize(lagertest.NewTestLogger("test"), request)).To(MatchError("not authorized to create: nope"))
+	})
+
+	It("refuses requests when the callout does not respond OK", func() {
+		status = http.StatusInternalServerError
+		decision = "policy engine down"
+		Expect(authorizer.Authorize(lagertest.NewTestLogger("test"), request)).To(MatchError("authorizer: unexpected status 500: policy engine down"))
+	})
+})
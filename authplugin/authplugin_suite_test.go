@@ -0,0 +1,13 @@
+package authplugin_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"testing"
+)
+
+func TestAuthplugin(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Authplugin Suite")
+}
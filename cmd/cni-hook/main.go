@@ -0,0 +1,101 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/cloudfoundry-incubator/cf-lager"
+	"github.com/cloudfoundry-incubator/guardian/netplugin/cni"
+	"github.com/cloudfoundry/gunk/command_runner/linux_command_runner"
+	"github.com/opencontainers/specs"
+	"github.com/pivotal-golang/lager"
+)
+
+func main() {
+	cf_lager.AddFlags(flag.CommandLine)
+	logger, _ := cf_lager.New("cni-hook")
+
+	logFile := os.Getenv("GARDEN_LOG_FILE")
+	logFileHandle, err := os.Create(logFile)
+	if err != nil {
+		panic(err)
+	}
+
+	logger.RegisterSink(lager.NewWriterSink(logFileHandle, lager.DEBUG))
+
+	defer func() {
+		if err := recover(); err != nil {
+			logger.Fatal("panicked", fmt.Errorf("%#v", err))
+		}
+	}()
+
+	state := specs.State{}
+	if err := json.NewDecoder(os.Stdin).Decode(&state); err != nil {
+		panic(err)
+	}
+
+	action := flag.String("action", "", "up or down")
+	handle := flag.String("handle", "", "the container handle")
+	network := flag.String("network", "", "the network spec requested by the client")
+	confDir := flag.String("cni-conf-dir", "/etc/cni/net.d", "directory containing cni network configuration")
+	binDir := flag.String("cni-bin-dir", "/opt/cni/bin", "directory containing cni plugin binaries")
+	stateDir := flag.String("cni-state-dir", "", "directory in which to store cni results")
+	flag.Parse()
+
+	logger = logger.Session("hook", lager.Data{
+		"action": *action,
+		"handle": *handle,
+		"pid":    state.Pid,
+	})
+
+	logger.Info("start")
+
+	conf, err := cni.LoadConf(*confDir)
+	if err != nil {
+		panic(err)
+	}
+
+	invoker := &cni.Invoker{
+		BinDir:        *binDir,
+		CommandRunner: linux_command_runner.New(),
+	}
+
+	container, err := cni.NewContainer(*handle, *network)
+	if err != nil {
+		panic(err)
+	}
+
+	resultPath := filepath.Join(*stateDir, *handle+".json")
+
+	switch *action {
+	case "up":
+		container.NetNS = fmt.Sprintf("/proc/%d/ns/net", state.Pid)
+
+		result, err := invoker.Add(logger, conf, container)
+		if err != nil {
+			panic(err)
+		}
+
+		if *stateDir != "" {
+			if err := ioutil.WriteFile(resultPath, result, 0600); err != nil {
+				panic(err)
+			}
+		}
+	case "down":
+		// the container's processes, and so its network namespace, have
+		// already gone by the time the poststop hook runs
+		if err := invoker.Del(logger, conf, container); err != nil {
+			panic(err)
+		}
+
+		if *stateDir != "" {
+			os.Remove(resultPath)
+		}
+	default:
+		panic(fmt.Sprintf("unknown action: %s", *action))
+	}
+}
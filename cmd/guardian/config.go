@@ -0,0 +1,211 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"time"
+
+	"github.com/cloudfoundry-incubator/guardian/gardener"
+	"github.com/cloudfoundry-incubator/guardian/imageplugin"
+	"github.com/cloudfoundry-incubator/guardian/kawasaki/iptables"
+	"github.com/cloudfoundry/gunk/vars"
+	"github.com/pivotal-golang/lager"
+	"gopkg.in/yaml.v2"
+)
+
+// The config file is a YAML map of flag names to values, e.g.
+//
+//   depot: /var/vcap/data/garden/depot
+//   containerGraceTime: 5m
+//   denyNetworks: [10.0.0.0/8, 192.168.0.0/16]
+//   registryMirror: [https://mirror.example.com]
+//
+// Lists set repeatable flags once per element and are comma-joined for other
+// flags. Flags given on the command line take precedence over the file.
+
+// reloadableFlags are re-read from the config file on SIGHUP. Changes to any
+// other flag only take effect when the server is restarted.
+var reloadableFlags = []string{"logLevel", "containerGraceTime", "allowNetworks", "denyNetworks", "registryMirror"}
+
+func loadConfig(path string) (map[string]interface{}, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("config: %s", err)
+	}
+
+	var config map[string]interface{}
+	if err := yaml.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("config: parse %s: %s", path, err)
+	}
+
+	for name := range config {
+		if flag.Lookup(name) == nil {
+			return nil, fmt.Errorf("config: unknown flag '%s'", name)
+		}
+	}
+
+	return config, nil
+}
+
+// applyConfig sets each flag in the config which is not in skip
+func applyConfig(flags *flag.FlagSet, config map[string]interface{}, skip map[string]bool) error {
+	for name, value := range config {
+		f := flags.Lookup(name)
+		if f == nil || skip[name] {
+			continue
+		}
+
+		if err := setFlag(f, configValues(value)); err != nil {
+			return fmt.Errorf("config: invalid value for '%s': %s", name, err)
+		}
+	}
+
+	return nil
+}
+
+func configValues(value interface{}) []string {
+	list, ok := value.([]interface{})
+	if !ok {
+		return []string{fmt.Sprint(value)}
+	}
+
+	values := []string{}
+	for _, v := range list {
+		values = append(values, fmt.Sprint(v))
+	}
+
+	return values
+}
+
+func setFlag(f *flag.Flag, values []string) error {
+	if _, repeatable := f.Value.(*vars.StringList); !repeatable {
+		return f.Value.Set(strings.Join(values, ","))
+	}
+
+	for _, v := range values {
+		if err := f.Value.Set(v); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// commandLineFlags returns the names of the flags given on the command line
+func commandLineFlags(flags *flag.FlagSet) map[string]bool {
+	set := make(map[string]bool)
+	flags.Visit(func(f *flag.Flag) {
+		set[f.Name] = true
+	})
+
+	return set
+}
+
+// ConfigReloader re-reads the config file and applies the settings which can
+// change while the server is running. Flags given on the command line are
+// never changed, and flags removed from the file revert to their defaults.
+type ConfigReloader struct {
+	Path        string
+	CommandLine map[string]bool
+	Logger      lager.Logger
+
+	LogSink          *lager.ReconfigurableSink
+	DefaultGraceTime *gardener.DefaultGraceTime
+	// Networks and Registry are nil when not in use
+	Networks *iptables.Starter
+	Registry *imageplugin.RegistryClient
+}
+
+type reloadableConfig struct {
+	logLevel        string
+	graceTime       time.Duration
+	allowNetworks   string
+	denyNetworks    string
+	registryMirrors vars.StringList
+}
+
+func (r *ConfigReloader) Reload() error {
+	log := r.Logger.Session("reload-config", lager.Data{"path": r.Path})
+
+	log.Info("started")
+	defer log.Info("finished")
+
+	config, err := loadConfig(r.Path)
+	if err != nil {
+		log.Error("failed-to-load", err)
+		return err
+	}
+
+	var c reloadableConfig
+	flags := flag.NewFlagSet("config", flag.ContinueOnError)
+	flags.StringVar(&c.logLevel, "logLevel", flag.Lookup("logLevel").DefValue, "")
+	flags.DurationVar(&c.graceTime, "containerGraceTime", 0, "")
+	flags.StringVar(&c.allowNetworks, "allowNetworks", "", "")
+	flags.StringVar(&c.denyNetworks, "denyNetworks", "", "")
+	flags.Var(&c.registryMirrors, "registryMirror", "")
+
+	// keep the command line values of flags which were given there, so they
+	// can be reapplied alongside the values from the file
+	for name := range r.CommandLine {
+		if f := flags.Lookup(name); f != nil && name != "registryMirror" {
+			flags.Set(name, flag.Lookup(name).Value.String())
+		}
+	}
+
+	if err := applyConfig(flags, config, r.CommandLine); err != nil {
+		log.Error("invalid-config", err)
+		return err
+	}
+
+	if !r.CommandLine["logLevel"] {
+		level, err := parseLogLevel(c.logLevel)
+		if err != nil {
+			log.Error("invalid-log-level", err)
+			return err
+		}
+
+		r.LogSink.SetMinLevel(level)
+	}
+
+	if !r.CommandLine["containerGraceTime"] {
+		r.DefaultGraceTime.Set(c.graceTime)
+	}
+
+	if r.Networks != nil && !(r.CommandLine["allowNetworks"] && r.CommandLine["denyNetworks"]) {
+		if err := r.Networks.SetNetworks(splitList(c.allowNetworks), splitList(c.denyNetworks)); err != nil {
+			log.Error("failed-to-set-networks", err)
+			return err
+		}
+	}
+
+	if r.Registry != nil && !r.CommandLine["registryMirror"] {
+		r.Registry.SetMirrors(c.registryMirrors.List)
+	}
+
+	return nil
+}
+
+func parseLogLevel(level string) (lager.LogLevel, error) {
+	switch level {
+	case "debug":
+		return lager.DEBUG, nil
+	case "info":
+		return lager.INFO, nil
+	case "error":
+		return lager.ERROR, nil
+	case "fatal":
+		return lager.FATAL, nil
+	}
+
+	return 0, fmt.Errorf("unknown log level '%s'", level)
+}
+
+func splitList(list string) []string {
+	if list == "" {
+		return nil
+	}
+
+	return strings.Split(list, ",")
+}
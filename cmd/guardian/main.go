@@ -1,62 +1,88 @@
 package main
 
 import (
+	"crypto/tls"
+	"encoding/json"
+	_ "expvar"
 	"flag"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"net"
+	"net/http"
+	_ "net/http/pprof"
 	"os"
+	"os/exec"
 	"os/signal"
 	"path"
 	"path/filepath"
+	"runtime"
 	"strings"
 	"syscall"
 	"time"
 
 	"github.com/cloudfoundry-incubator/cf-debug-server"
 	"github.com/cloudfoundry-incubator/cf-lager"
-	"github.com/cloudfoundry-incubator/garden-shed/distclient"
-	quotaed_aufs "github.com/cloudfoundry-incubator/garden-shed/docker_drivers/aufs"
-	"github.com/cloudfoundry-incubator/garden-shed/layercake"
-	"github.com/cloudfoundry-incubator/garden-shed/repository_fetcher"
+	"github.com/cloudfoundry-incubator/garden"
 	"github.com/cloudfoundry-incubator/garden-shed/rootfs_provider"
 	"github.com/cloudfoundry-incubator/garden/server"
 	"github.com/cloudfoundry-incubator/goci"
+	"github.com/cloudfoundry-incubator/guardian/accounting"
+	"github.com/cloudfoundry-incubator/guardian/authplugin"
+	"github.com/cloudfoundry-incubator/guardian/connlimit"
 	"github.com/cloudfoundry-incubator/guardian/gardener"
+	"github.com/cloudfoundry-incubator/guardian/imageplugin"
 	"github.com/cloudfoundry-incubator/guardian/kawasaki"
+	"github.com/cloudfoundry-incubator/guardian/kawasaki/dns"
 	"github.com/cloudfoundry-incubator/guardian/kawasaki/factory"
 	"github.com/cloudfoundry-incubator/guardian/kawasaki/iptables"
 	"github.com/cloudfoundry-incubator/guardian/kawasaki/ports"
 	"github.com/cloudfoundry-incubator/guardian/kawasaki/subnets"
+	"github.com/cloudfoundry-incubator/guardian/kawasaki/tc"
 	"github.com/cloudfoundry-incubator/guardian/logging"
+	"github.com/cloudfoundry-incubator/guardian/metrics"
 	"github.com/cloudfoundry-incubator/guardian/netplugin"
+	"github.com/cloudfoundry-incubator/guardian/pkg/cmdtimeout"
 	"github.com/cloudfoundry-incubator/guardian/pkg/vars"
 	"github.com/cloudfoundry-incubator/guardian/properties"
 	"github.com/cloudfoundry-incubator/guardian/rundmc"
 	"github.com/cloudfoundry-incubator/guardian/rundmc/bundlerules"
 	"github.com/cloudfoundry-incubator/guardian/rundmc/depot"
 	"github.com/cloudfoundry-incubator/guardian/rundmc/process_tracker"
+	"github.com/cloudfoundry-incubator/guardian/rundmc/process_tracker/logdrain"
+	"github.com/cloudfoundry-incubator/guardian/rundmc/quota"
 	"github.com/cloudfoundry-incubator/guardian/rundmc/runrunc"
+	"github.com/cloudfoundry-incubator/guardian/socketrelay"
 	"github.com/cloudfoundry-incubator/guardian/sysinfo"
+	"github.com/cloudfoundry-incubator/guardian/volumeplugin"
 	"github.com/cloudfoundry/gunk/command_runner/linux_command_runner"
-	"github.com/docker/docker/daemon/graphdriver"
-	_ "github.com/docker/docker/daemon/graphdriver/aufs"
-	"github.com/docker/docker/graph"
-	_ "github.com/docker/docker/pkg/chrootarchive" // allow reexec of docker-applyLayer
-	"github.com/docker/docker/pkg/reexec"
 	"github.com/eapache/go-resiliency/retrier"
 	"github.com/nu7hatch/gouuid"
 	"github.com/opencontainers/specs"
+	"github.com/pivotal-golang/clock"
 	"github.com/pivotal-golang/lager"
 	"github.com/pivotal-golang/localip"
 )
 
 const OciStateDir = "/var/run/opencontainer/containers"
 
+// first project quota ID allocated to containers, chosen to stay clear of
+// projects an operator may have configured by hand
+const firstQuotaProject = 100000
+
+// number of events buffered per events subscriber before events are dropped
+const eventBufferSize = 1024
+
 var PrivilegedContainerNamespaces = []specs.Namespace{
 	goci.NetworkNamespace, goci.PIDNamespace, goci.UTSNamespace, goci.IPCNamespace, goci.MountNamespace,
 }
 
+var configFile = flag.String(
+	"config",
+	"",
+	"path to a YAML file of flag values, e.g. 'depot: /var/garden/depot'; flags given on the command line take precedence. The log level, default grace time, allowed and denied networks and registry mirrors are reloaded from it on SIGHUP",
+)
+
 var listenNetwork = flag.String(
 	"listenNetwork",
 	"unix",
@@ -69,6 +95,42 @@ var listenAddr = flag.String(
 	"address to listen on",
 )
 
+var tlsCert = flag.String(
+	"tlsCert",
+	"",
+	"path to a PEM certificate to serve the garden API over TLS with; listenNetwork must be tcp, and clients must present a certificate signed by tlsCA; the extensions are then served over TLS too",
+)
+
+var tlsKey = flag.String(
+	"tlsKey",
+	"",
+	"path to the PEM private key of tlsCert",
+)
+
+var tlsCA = flag.String(
+	"tlsCA",
+	"",
+	"path to the PEM certificates of the CAs which sign client certificates",
+)
+
+var maxConnectionsPerClient = flag.Int(
+	"maxConnectionsPerClient",
+	0,
+	"most garden API connections one client (by IP address; all unix socket clients are one client) may have open at once; further connections are closed as soon as they are accepted (0 for no limit)",
+)
+
+var connectionKeepAlive = flag.Duration(
+	"connectionKeepAlive",
+	0,
+	"TCP keep-alive period of garden API connections, so that the connections of clients which have gone away are closed (0 for the default)",
+)
+
+var connectionIdleTimeout = flag.Duration(
+	"connectionIdleTimeout",
+	0,
+	"close garden API connections on which nothing is sent or received for this long; attached process streams are idle while their processes are quiet, so this must be longer than they are quiet (0 for no timeout)",
+)
+
 var binPath = flag.String(
 	"bin",
 	"",
@@ -81,12 +143,24 @@ var iodaemonBin = flag.String(
 	"path to iodaemon binary",
 )
 
+var dadooBin = flag.String(
+	"dadooBin",
+	"",
+	"path to dadoo binary, whose running processes are counted by the guardian_dadoo_processes metric (the metric is omitted if not set)",
+)
+
 var nstarBin = flag.String(
 	"nstarBin",
 	"",
 	"path to nstar binary",
 )
 
+var remountBin = flag.String(
+	"remountBin",
+	"",
+	"path to the remount helper binary, used by the remount-ro disk quota action",
+)
+
 var tarBin = flag.String(
 	"tarBin",
 	"",
@@ -99,12 +173,42 @@ var kawasakiBin = flag.String(
 	"path to the kawasaki network hook binary",
 )
 
+var conntrackBin = flag.String(
+	"conntrackBin",
+	"/usr/sbin/conntrack",
+	"path to the conntrack binary, used to forget the connections to a container's forwarded ports when they are unmapped or the container is destroyed",
+)
+
 var initBin = flag.String(
 	"initBin",
 	"",
 	"path to process used as pid 1 inside container",
 )
 
+var runcBin = flag.String(
+	"runcBin",
+	"runc",
+	"path to the runc binary",
+)
+
+var runcSHA256 = flag.String(
+	"runcSHA256",
+	"",
+	"expected hex-encoded sha256 digest of the runc binary (or of the runtime plugin, if one is set); if set, the binary is verified at startup and whenever it changes, and is refused if it does not match",
+)
+
+var runtimePlugin = flag.String(
+	"runtimePlugin",
+	"",
+	"path to an OCI runtime binary to use in place of runc, e.g. crun or runsc (default: the runcBin binary)",
+)
+
+var runtimePluginArgs = flag.String(
+	"runtimePluginArgs",
+	"",
+	"path to a JSON file of text/template templates of the runtime plugin's arguments for each operation (start, exec, kill, delete, checkpoint, restore and log), for runtimes whose arguments differ from runc's; operations which are not in the file take runc's arguments",
+)
+
 var networkPlugin = flag.String(
 	"networkPlugin",
 	"",
@@ -117,12 +221,48 @@ var networkPluginExtraArgs = flag.String(
 	"comma seperated extra args for the network plugin binary",
 )
 
+var cniHookBin = flag.String(
+	"cniHookBin",
+	"",
+	"path to the cni network hook binary; if set, container networking is configured by cni plugins instead of kawasaki (cannot be used with networkPlugin)",
+)
+
+var cniConfDir = flag.String(
+	"cniConfDir",
+	"/etc/cni/net.d",
+	"directory containing the cni network configuration to use",
+)
+
+var cniBinDir = flag.String(
+	"cniBinDir",
+	"/opt/cni/bin",
+	"directory containing cni plugin binaries",
+)
+
+var cniStateDir = flag.String(
+	"cniStateDir",
+	"/var/run/guardian/cni",
+	"directory in which to store the results of cni plugins",
+)
+
 var depotPath = flag.String(
 	"depot",
 	"",
 	"directory in which to store containers",
 )
 
+var propertiesDir = flag.String(
+	"propertiesDir",
+	"/var/run/guardian/properties",
+	"directory in which to store container properties so that they, and the containers' network state, survive a restart",
+)
+
+var allowNetworkDepot = flag.Bool(
+	"allowNetworkDepot",
+	false,
+	"allow the depot and graph to be on a network filesystem such as NFS; otherwise guardian refuses to start",
+)
+
 var rootFSPath = flag.String(
 	"rootfs",
 	"",
@@ -147,10 +287,26 @@ var portPoolSize = flag.Uint(
 	"size of port pool used for mapped container ports",
 )
 
+var portPoolStateFile = flag.String(
+	"portPoolStateFile",
+	"/var/run/guardian/port-pool.json",
+	"file in which to store which ports of the pool each container has reserved, so that they keep them across a restart",
+)
+
 var networkPool = flag.String("networkPool",
 	"10.254.0.0/22",
 	"Pool of dynamically allocated container subnets")
 
+var networkPoolStateFile = flag.String(
+	"networkPoolStateFile",
+	"/var/run/guardian/network-pool.json",
+	"file in which to store which container holds each IP of the network pool, so that the IPs of containers left in the depot are not handed out again across a restart; the IPv6 pool's are stored alongside it, with a -v6 suffix",
+)
+
+var networkPoolV6 = flag.String("networkPoolV6",
+	"",
+	"Pool of dynamically allocated IPv6 container subnets (each container is given a /126); IPv6 is disabled if empty")
+
 var denyNetworks = flag.String(
 	"denyNetworks",
 	"",
@@ -175,6 +331,120 @@ var dockerRegistry = flag.String(
 	"docker registry API endpoint",
 )
 
+var imagePlugin = flag.String(
+	"imagePlugin",
+	"",
+	"path to optional image plugin binary (e.g. grootfs); if unset, docker:// rootfses are pulled from the registry in-process",
+)
+
+var imageCacheMaxAge = flag.Duration(
+	"imageCacheMaxAge",
+	0,
+	"how long a layer or image config may go unused before it is pruned from the cache of the in-process image plugin; the blobs of persistent images are never pruned (0 never prunes)",
+)
+
+var imageCachePruneInterval = flag.Duration(
+	"imageCachePruneInterval",
+	time.Hour,
+	"interval between prunes of the cache of the in-process image plugin, with imageCacheMaxAge",
+)
+
+var imagePluginExtraArgs = flag.String(
+	"imagePluginExtraArgs",
+	"",
+	"comma seperated extra args for the image plugin binary",
+)
+
+var volumePlugin = flag.String(
+	"volumePlugin",
+	"",
+	"path to optional volume plugin binary which mounts the volumes containers ask for in their 'volumes' property, e.g. network storage",
+)
+
+var volumePluginExtraArgs = flag.String(
+	"volumePluginExtraArgs",
+	"",
+	"comma separated extra args for the volume plugin binary",
+)
+
+var volumePluginTimeout = flag.Duration(
+	"volumePluginTimeout",
+	2*time.Minute,
+	"how long the volume plugin may take to mount or unmount a volume before it is killed and the create or destroy fails (0 means no limit)",
+)
+
+var bundlePluginTimeout = flag.Duration(
+	"bundlePluginTimeout",
+	30*time.Second,
+	"how long each bundlePlugin may take to mutate a container's config.json before it is killed and the create fails (0 means no limit)",
+)
+
+var authorizerBin = flag.String(
+	"authorizerBin",
+	"",
+	"path to an optional authorizer binary which is run, with a JSON summary of the request on stdin, to allow or deny each garden API request which would change a container or the host or read a container's files, and each request to extensionsAddr; the caller is identified by the subject of its client certificate on TLS listeners, and by the listener the request arrived on otherwise")
+
+var authorizerURL = flag.String(
+	"authorizerURL",
+	"",
+	"URL which each request which authorizerBin would be run for is POSTed to, as JSON, to be allowed or denied (cannot be used with authorizerBin)")
+
+var authorizerTimeout = flag.Duration(
+	"authorizerTimeout",
+	10*time.Second,
+	"how long to wait for authorizerBin or authorizerURL to decide a request before refusing it")
+
+var imageVerifierBin = flag.String(
+	"imageVerifierBin",
+	"",
+	"path to an optional binary, e.g. a wrapper around notary or cosign, which is run as '<imageVerifierBin> <imageVerifierExtraArgs> verify <rootfs>' to verify the signature of each container's image before the container is created, and prints the digest of the image it verified, which the image is then pulled by; images which fail are only logged unless --requireImageSignatures is set")
+
+var imageVerifierExtraArgs = flag.String(
+	"imageVerifierExtraArgs",
+	"",
+	"comma separated extra args for the image verifier binary, e.g. the trust root")
+
+var imageVerifierTimeout = flag.Duration(
+	"imageVerifierTimeout",
+	time.Minute,
+	"how long imageVerifierBin may take to verify an image before it is killed and the image counts as unverified (0 means no limit)")
+
+var requireImageSignatures = flag.Bool(
+	"requireImageSignatures",
+	false,
+	"refuse to create containers from images which imageVerifierBin cannot verify; the default rootfs and raw:// rootfses on the host are not verified")
+
+var metricsRelayDir = flag.String(
+	"metricsRelayDir",
+	"",
+	"directory in which to create a socket for each container which sets the garden.metrics-socket property, relaying connections to that socket in the container (@name for an abstract socket, or an absolute path), so that exporters in containers can be scraped without a NetIn; if empty, containers may not set the property")
+
+var rootfsLayerDir = flag.String(
+	"rootfsLayerDir",
+	"",
+	"directory holding base layers which containers may build their rootfses on with the '"+gardener.RootFSLayersProperty+"' property; if empty, containers may not have base layers")
+
+var checkpointDir = flag.String(
+	"checkpointDir",
+	"",
+	"directory which holds checkpoints exported through the extensions API, each in a directory named by the checkpoint's destination; if empty, checkpoints cannot be exported or restored from an export")
+
+var imagePluginReadinessArgs = flag.String(
+	"imagePluginReadinessArgs",
+	"",
+	"comma separated args (after imagePluginExtraArgs) with which the image plugin succeeds once its store is ready; if set, container creation waits for the plugin to be ready")
+
+var imagePluginReadinessTimeout = flag.Duration(
+	"imagePluginReadinessTimeout",
+	2*time.Minute,
+	"how long container creation waits for the image plugin to be ready before failing; each run of the readiness probe is killed after this long too")
+
+var registryCredentials = flag.String(
+	"registryCredentials",
+	"",
+	"path to a JSON file mapping registry hosts to {\"username\": ..., \"password\": ...} credentials for pulling images",
+)
+
 var tag = flag.String(
 	"tag",
 	"",
@@ -199,6 +469,12 @@ var allowHostAccess = flag.Bool(
 	"allow network access to host",
 )
 
+var denyNetworkLog = flag.Bool(
+	"denyNetworkLog",
+	false,
+	"log new outbound connections from containers which none of their net-out rules allow, with the container's id as the log prefix, and publish a net-out-denied event for each",
+)
+
 var iptablesLogMethod = flag.String(
 	"iptablesLogMethod",
 	"kernel",
@@ -215,13 +491,359 @@ var externalIP = flag.String(
 	"",
 	"IP address to use to reach container's mapped ports")
 
+var dnsResolvConfTemplate = flag.String(
+	"dnsResolvConfTemplate",
+	"",
+	"path to a text/template for containers' resolv.conf, given .Nameservers, .SearchDomains and .HostResolvConf; by default the host's resolv.conf is copied")
+
+var dnsForwarderBin = flag.String(
+	"dnsForwarderBin",
+	"",
+	"path to dnsmasq, run on the host as the containers' nameserver; disabled if empty")
+
+var dnsForwarderPidFile = flag.String(
+	"dnsForwarderPidFile",
+	"/var/run/guardian/dnsmasq.pid",
+	"pid file of the DNS forwarder")
+
+var extensionsAddr = flag.String(
+	"extensionsAddr",
+	"",
+	"address on which to serve guardian-specific endpoints (/accounting, /containers/changes, /events, checkpointing and mounting into containers), disabled if empty; if there is an authorizerBin or authorizerURL, every request is authorized, and validating container specs at /containers/validate, the runtime details of containers at /containers/runtime and exporting and importing container definitions at /containers/export and /containers/import are only served if there is one")
+
+var accountingAddr = flag.String(
+	"accountingAddr",
+	"",
+	"deprecated: use extensionsAddr, which this was renamed to once it served more than /accounting")
+
+var readOnlyExtensionsAddr = flag.String(
+	"readOnlyExtensionsAddr",
+	"",
+	"address on which to serve only the guardian-specific endpoints which observe containers (/accounting, /metrics/stream, /containers/changes, /events, /capabilities), for monitoring agents; requires extensionsAddr, disabled if empty; if there is an authorizerBin or authorizerURL, every request is authorized")
+
+var minAPIVersion = flag.Int(
+	"minAPIVersion",
+	gardener.APIVersionLegacy,
+	"oldest version of the guardian-specific endpoints' API which is served; requests for older versions are refused")
+
+var deprecatedAPIVersionsBelow = flag.Int(
+	"deprecatedAPIVersionsBelow",
+	0,
+	"API versions older than this are served with a deprecation warning, and the clients using them are logged (0 deprecates none)")
+
+var accountingInterval = flag.Duration(
+	"accountingInterval",
+	time.Minute,
+	"interval between samples of container resource usage for accounting")
+
+var metricsStreamInterval = flag.Duration(
+	"metricsStreamInterval",
+	10*time.Second,
+	"interval between the container metrics samples pushed to clients streaming metrics")
+
+var changeLogSize = flag.Int(
+	"changeLogSize",
+	10000,
+	"number of container changes to retain for delta container listings")
+
+var bundleDriftCheckInterval = flag.Duration(
+	"bundleDriftCheckInterval",
+	0,
+	"interval between checks that container bundle configs have not drifted since creation (0 disables checking)")
+
+var staleStateCheckInterval = flag.Duration(
+	"staleStateCheckInterval",
+	0,
+	"interval between checks for containers whose init process has died even though runc's state says they are running; such containers are destroyed (0 disables checking)")
+
+var ageTrackingInterval = flag.Duration(
+	"ageTrackingInterval",
+	0,
+	"interval at which each container's age, as measured by the monotonic clock, is added to and persisted in its '"+gardener.AgeProperty+"' property, which reports the container's current age; the wall clock is checked for jumps, e.g. after the cell is restored from a VM snapshot, at the same interval. Every container's properties are saved at each interval, so it should be minutes rather than seconds (0 disables tracking)")
+
+var clockSkewThreshold = flag.Duration(
+	"clockSkewThreshold",
+	10*time.Second,
+	"difference between the time passed on the wall clock and on the monotonic clock above which a clock skew is logged and a '"+string(gardener.EventClockSkew)+"' event is published")
+
+var socketSweepInterval = flag.Duration(
+	"socketSweepInterval",
+	10*time.Minute,
+	"interval between sweeps for process sockets which no iodaemon is listening on any more, e.g. because it was killed, which are removed; they are also swept at startup (0 only sweeps at startup)")
+
+var diskQuotaCheckInterval = flag.Duration(
+	"diskQuotaCheckInterval",
+	30*time.Second,
+	"interval between asking the image plugin whether containers have exceeded their disk quotas, if it supports the stats feature; containers which have take their disk-quota-action (0 disables checking)")
+
+var enableNvidiaGPUs = flag.Bool(
+	"enableNvidiaGPUs",
+	false,
+	"give containers created with the '"+bundlerules.GPUProperty+"' property set to true the host's NVIDIA GPUs; the devices and driver libraries are detected at startup unless nvidiaContainerHook is set")
+
+var nvidiaContainerHook = flag.String(
+	"nvidiaContainerHook",
+	"",
+	"path to a prestart hook, such as nvidia-container-runtime-hook, which injects NVIDIA GPUs in to containers asking for them, rather than guardian injecting the GPUs it detects itself (requires enableNvidiaGPUs)")
+
+var devPolicy = flag.String(
+	"devPolicy",
+	bundlerules.DevPolicyDefault,
+	"device nodes, and /dev filesystems, given to containers: 'default' ("+strings.Join(bundlerules.DefaultDevNodes, ",")+"), 'minimal' ("+strings.Join(bundlerules.MinimalDevNodes, ",")+") or a comma separated list of nodes, which may also include fuse and mqueue; containers may choose their own with the '"+bundlerules.DevPolicyProperty+"' property, from these nodes and the devices given by allowDevice")
+
+var keyringMaxKeys = flag.Uint64(
+	"keyringMaxKeys",
+	0,
+	"maximum number of kernel keys each host user may own (kernel.keys.maxkeys); the unprivileged containers whose root maps to the same host uid share this quota (0 leaves the kernel's setting)")
+
+var keyringMaxBytes = flag.Uint64(
+	"keyringMaxBytes",
+	0,
+	"maximum bytes of kernel key payload each host user may own (kernel.keys.maxbytes); the unprivileged containers whose root maps to the same host uid share this quota (0 leaves the kernel's setting)")
+
+var cgroupMode = flag.String(
+	"cgroupMode",
+	"auto",
+	"cgroup hierarchy to run containers in: 'v1', 'v2', or 'auto' to use v2 only on hosts without v1 hierarchies; guardian refuses to start in a mode the host does not support")
+
+var quarantineDriftedBundles = flag.Bool(
+	"quarantineDriftedBundles",
+	false,
+	"kill and mark as quarantined containers whose bundle config has drifted")
+
+var diskQuotaFilesystem = flag.String(
+	"diskQuotaFilesystem",
+	"",
+	"filesystem type (xfs or ext4) of the project quota filesystem at diskQuotaMountPoint; if set, disk limits also cover container scratch space (/tmp)")
+
+var diskQuotaMountPoint = flag.String(
+	"diskQuotaMountPoint",
+	"",
+	"mount point of a filesystem mounted with project quotas enabled, used for container scratch space")
+
+var seccompProfile = flag.String(
+	"seccompProfile",
+	"",
+	"path to a JSON seccomp profile applied to all containers; containers may merge their own profile over it with the '"+bundlerules.SeccompProfileProperty+"' property")
+
+var maxProcessesPerContainer = flag.Uint(
+	"maxProcessesPerContainer",
+	0,
+	"maximum number of processes which may be running in a container at once via the API; further Run requests fail (0 means no limit)")
+
+var maxConcurrentExecsPerContainer = flag.Uint(
+	"maxConcurrentExecsPerContainer",
+	0,
+	"maximum number of processes started by Run and Attach which may be running in a container at once; containers may lower it with the '"+gardener.ExecConcurrencyProperty+"' property (0 means no limit)")
+
+var execQueueTimeout = flag.Duration(
+	"execQueueTimeout",
+	0,
+	"default time Run and Attach requests beyond maxConcurrentExecsPerContainer wait for a running process to exit before failing; containers may override it with the '"+gardener.ExecQueueTimeoutProperty+"' property (0 means they fail immediately)")
+
+var maxContainerOutputRate = flag.Int64(
+	"maxContainerOutputRate",
+	0,
+	"maximum number of bytes per second the processes in each container may write to stdout and stderr, beyond which writes are slowed down; containers may lower it with the '"+gardener.OutputRateProperty+"' property (0 means no limit)")
+
+var ioRateLimit = flag.Int64(
+	"ioRateLimit",
+	0,
+	"maximum number of bytes per second each process may write to stdout and stderr, enforced by its iodaemon, beyond which writes are slowed down (0 means no limit)")
+
+var attachReplayBytes = flag.Int(
+	"attachReplayBytes",
+	64*1024,
+	"bytes of each process's stdout and stderr which are kept and replayed to each client which attaches to the process, so that several clients may attach to it without missing its earlier output (0 disables replay)")
+
+var logDrain = flag.String(
+	"logDrain",
+	"",
+	"URL of a log aggregator to which the stdout and stderr of every process is forwarded, tagged with its container's handle and its process id, as well as being streamed to its clients: syslog://host:port (RFC 5424 over TCP), syslog+udp://host:port or fluentd://host:port[/tag] (fluentd's forward protocol; the tag defaults to 'garden')")
+
+var ioMaxBytes = flag.Int64(
+	"ioMaxBytes",
+	0,
+	"maximum number of bytes each process may write to stdout and stderr, enforced by its iodaemon, beyond which its output is truncated (0 means no limit)")
+
+var iodaemonPool = flag.Bool(
+	"iodaemonPool",
+	false,
+	"spawn the processes of each container from one long-lived iodaemon per container, rather than an iodaemon per process, reducing the overhead and pid count of exec-heavy containers")
+
+var allowContainerRunners = flag.Bool(
+	"allowContainerRunners",
+	false,
+	"allow privileged containers with the "+gardener.ContainerTypeProperty+" property set to "+gardener.ContainerRunnerType+", which may run containers of their own")
+
+var maxRestartBackoff = flag.Duration(
+	"maxRestartBackoff",
+	time.Minute,
+	"longest delay before re-starting a container whose init process has exited, when its '"+gardener.RestartPolicyProperty+"' property asks for it; the delay starts at a second and doubles with each restart")
+
+var createImageTimeout = flag.Duration(
+	"createImageTimeout",
+	0,
+	"maximum time creating a container's rootfs may take before the create fails (0 means no limit)")
+
+var createBundleTimeout = flag.Duration(
+	"createBundleTimeout",
+	0,
+	"maximum time creating a container's bundle may take before the create fails (0 means no limit)")
+
+var createRuntimeTimeout = flag.Duration(
+	"createRuntimeTimeout",
+	0,
+	"maximum time starting a container's init process may take before the create fails (0 means no limit)")
+
+var createNetworkTimeout = flag.Duration(
+	"createNetworkTimeout",
+	0,
+	"maximum time setting up a container's network may take before the create fails (0 means no limit)")
+
+var maxConcurrentCreates = flag.Uint(
+	"maxConcurrentCreates",
+	0,
+	"maximum number of containers which may be created at once; further creates wait for one to finish (0 means no limit)")
+
+var asyncDestroy = flag.Bool(
+	"asyncDestroy",
+	false,
+	"return from destroys as soon as the container is marked as destroying, and clean it up in the background, retrying failed cleanups; failures are served at /debug/destroys on the debug server")
+
+var destroyRetryMaxBackoff = flag.Duration(
+	"destroyRetryMaxBackoff",
+	5*time.Minute,
+	"longest delay between retries of a failed cleanup with asyncDestroy; the delay starts at a second and doubles with each failure")
+
+var destroyRetryMaxAttempts = flag.Int(
+	"destroyRetryMaxAttempts",
+	10,
+	"number of times a container's cleanup is tried with asyncDestroy before it is given up on, so that the container is listed again and can be destroyed again (0 means no limit)")
+
+var destroyQueueStateFile = flag.String(
+	"destroyQueueStateFile",
+	"/var/run/guardian/destroy-queue.json",
+	"file in which to store which containers are being destroyed with asyncDestroy, so that their cleanup carries on across a restart")
+
+var cpuEntitlementCheckInterval = flag.Duration(
+	"cpuEntitlementCheckInterval",
+	0,
+	"interval between checks of each container's CPU usage against its entitlement, the share of the host's CPUs its cpu shares would get it if every container were busy; containers which persistently use more are moved in to a throttled cgroup until they use less (0 disables throttling; requires cgroup v1)")
+
+var cpuThrottleAfter = flag.Int(
+	"cpuThrottleAfter",
+	5,
+	"number of checks in a row a container must use more than its CPU entitlement for before it is throttled")
+
+var cpuReleaseAfter = flag.Int(
+	"cpuReleaseAfter",
+	5,
+	"number of checks in a row a throttled container must use less than its CPU entitlement for before it is released")
+
+var cpuThrottleStateFile = flag.String(
+	"cpuThrottleStateFile",
+	"/var/run/guardian/cpu-throttled.json",
+	"file in which to store the CPU weights of throttled containers with cgroup v2, so that they are released after a restart")
+
+var cpuMaxCheckInterval = flag.Duration(
+	"cpuMaxCheckInterval",
+	0,
+	"interval between checks of the CPU usage of each container with a '"+gardener.CPUMaxProperty+"' property against it; a container which persistently uses more has an event published or, if its '"+gardener.CPUMaxActionProperty+"' property is '"+gardener.CPUMaxActionThrottle+"', also has its cpu-max enforced as its CFS quota (0 disables the checks)")
+
+var cpuMaxExceedAfter = flag.Int(
+	"cpuMaxExceedAfter",
+	3,
+	"number of checks in a row a container must use more than its cpu-max for before it is found to exceed it")
+
+var cpusetCPUsPerContainer = flag.Uint(
+	"cpusetCpusPerContainer",
+	0,
+	"pin each container to this many of the host's online CPUs, choosing those which the fewest containers are pinned to; containers may choose their own CPUs and NUMA memory nodes with the '"+gardener.CPUSetCPUsProperty+"' and '"+gardener.CPUSetMemsProperty+"' properties (0 means containers are not pinned unless they choose to be)")
+
+var maxPidsPerContainer = flag.Int64(
+	"maxPidsPerContainer",
+	0,
+	"maximum number of pids (processes and threads) in each container's pids cgroup; containers may lower it with the '"+gardener.MaxPidsProperty+"' property, but not raise it (0 means no limit)")
+
+var defaultUmask = flag.String(
+	"defaultUmask",
+	"",
+	"default octal umask for processes run in containers, e.g. '0022'; containers may override it with the '"+gardener.UmaskProperty+"' property and processes with the "+runrunc.UmaskEnv+" environment variable (empty means processes inherit the server's umask)")
+
+var egressPolicyFile = flag.String(
+	"egressPolicyFile",
+	"",
+	"path to a JSON array of NetOut rules applied to every new container; the file is reloaded when it changes")
+
+var retroApplyEgressPolicy = flag.Bool(
+	"retroApplyEgressPolicy",
+	false,
+	"also apply rules added to the egress policy file to existing containers when it is reloaded")
+
+var annotationProperties = flag.String(
+	"annotationProperties",
+	"",
+	"comma-separated list of container properties (e.g. org, space and app ids) added to each container's log lines, accounting usage, streamed samples and per-container metrics")
+
+var forwardRuncLogs = flag.Bool(
+	"forwardRuncLogs",
+	true,
+	"have runc log as JSON and forward its log entries to guardian's log, tagged with the container handle and process id")
+
+var runcTimeout = flag.Duration(
+	"runcTimeout",
+	0,
+	"maximum time runc may take to start a container or a process, or to kill, delete, update or report the state of a container, after which it and any processes it started are killed (0 means no timeout)")
+
+var rootless = flag.Bool(
+	"rootless",
+	false,
+	"run as an unprivileged user: containers are run by rootless runc in the cgroup v2 cgroup delegated to the user, with container root mapped to the user, and only unprivileged containers may be created; requires -imagePlugin")
+
+var rootlessNetwork = flag.String(
+	"rootlessNetwork",
+	"none",
+	"network of containers when -rootless is set and no network plugin is given: 'none' (loopback only) or 'slirp4netns'")
+
+var slirp4netnsBin = flag.String(
+	"slirp4netnsBin",
+	"slirp4netns",
+	"path to the slirp4netns binary, for -rootlessNetwork=slirp4netns")
+
 var maxContainers = flag.Uint(
 	"maxContainers",
 	0,
 	"Maximum number of containers that can be created")
 
+var uidMapStart = flag.Uint(
+	"uidMapStart",
+	0,
+	"first host uid mapped in to the user namespace of unprivileged containers, as container root; must be set with uidMapLength (by default container root is mapped to the highest valid uid and other uids to themselves)")
+
+var uidMapLength = flag.Uint(
+	"uidMapLength",
+	0,
+	"number of host uids, starting at uidMapStart, mapped in to the user namespace of unprivileged containers")
+
+var gidMapStart = flag.Uint(
+	"gidMapStart",
+	0,
+	"first host gid mapped in to the user namespace of unprivileged containers, as container root; must be set with gidMapLength (by default container root is mapped to the highest valid gid and other gids to themselves)")
+
+var gidMapLength = flag.Uint(
+	"gidMapLength",
+	0,
+	"number of host gids, starting at gidMapStart, mapped in to the user namespace of unprivileged containers")
+
+// idMappings are the default mappings, used when the uid or gid map flags
+// are not given
 var idMappings rootfs_provider.MappingList
 
+// uidMappings and gidMappings are the mappings of unprivileged containers
+// which do not have their own mappings
+var uidMappings, gidMappings rootfs_provider.MappingList
+
 func init() {
 	maxId := uint32(sysinfo.Min(sysinfo.MustGetMaxValidUID(), sysinfo.MustGetMaxValidGID()))
 	idMappings = rootfs_provider.MappingList{
@@ -239,7 +861,7 @@ func init() {
 }
 
 func main() {
-	if reexec.Init() {
+	if reexecInit() {
 		return
 	}
 
@@ -250,257 +872,1722 @@ func main() {
 		"Docker registry to allow connecting to even if not secure. (Can be specified multiple times to allow insecure connection to multiple repositories)",
 	)
 
-	cf_debug_server.AddFlags(flag.CommandLine)
-	cf_lager.AddFlags(flag.CommandLine)
-	flag.Parse()
-
-	logger, _ := cf_lager.New("guardian")
+	var registryMirrors vars.StringList
+	flag.Var(
+		&registryMirrors,
+		"registryMirror",
+		"URL of a mirror of the default docker registry to try before the registry itself. (Can be specified multiple times)",
+	)
 
-	if *depotPath == "" {
-		missing("-depot")
-	}
+	var runtimePluginExtraArgs vars.StringList
+	flag.Var(
+		&runtimePluginExtraArgs,
+		"runtimePluginExtraArg",
+		"Extra argument passed to the runtime plugin before the arguments of every operation, e.g. '--platform=ptrace' for runsc. (Can be specified multiple times)",
+	)
 
-	if *iodaemonBin == "" {
-		missing("-iodaemonBin")
-	}
+	var dnsServers vars.StringList
+	flag.Var(
+		&dnsServers,
+		"dnsServer",
+		"Nameserver for containers (or, with dnsForwarderBin, for the forwarder) to use instead of those in the host's resolv.conf. (Can be specified multiple times)",
+	)
 
-	if *nstarBin == "" {
-		missing("-nstarBin")
-	}
+	var additionalListeners vars.StringList
+	flag.Var(
+		&additionalListeners,
+		"additionalListen",
+		"Additional network and address to serve the garden API on, as well as listenNetwork and listenAddr, e.g. 'tcp:0.0.0.0:7777'; only unix sockets are allowed when the API is served over TLS. (Can be specified multiple times)",
+	)
 
-	if *tarBin == "" {
-		missing("-tarBin")
-	}
+	var maskedPaths vars.StringList
+	flag.Var(
+		&maskedPaths,
+		"maskPath",
+		"Path masked in every container, replacing the default list ("+strings.Join(bundlerules.DefaultMaskedPaths, ", ")+"); containers may mask more with the '"+bundlerules.MaskedPathsProperty+"' property. (Can be specified multiple times)",
+	)
 
-	if *initBin == "" {
-		missing("-initBin")
-	}
+	var readonlyPaths vars.StringList
+	flag.Var(
+		&readonlyPaths,
+		"readonlyPath",
+		"Path made read-only in every container, replacing the default list ("+strings.Join(bundlerules.DefaultReadonlyPaths, ", ")+"); containers may add more with the '"+bundlerules.ReadonlyPathsProperty+"' property. (Can be specified multiple times)",
+	)
 
-	resolvedRootFSPath, err := filepath.EvalSymlinks(*rootFSPath)
-	if err != nil {
-		panic(err)
-	}
+	var allowedDevices vars.StringList
+	flag.Var(
+		&allowedDevices,
+		"allowDevice",
+		"Path of a host device, e.g. /dev/fuse, which containers may ask for with the '"+bundlerules.DevicesProperty+"' property; the device is created in the container and allowed in its device cgroup. (Can be specified multiple times)",
+	)
+
+	var liveBindMountSources vars.StringList
+	flag.Var(
+		&liveBindMountSources,
+		"liveBindMountSource",
+		"Host directory under which paths may be bind mounted in to running containers through the extensions API. (Can be specified multiple times)",
+	)
+
+	var prestartHooks vars.StringList
+	flag.Var(
+		&prestartHooks,
+		"prestartHook",
+		"Absolute path of an executable run as an OCI prestart hook of every container, after its network is set up, with the container's state on stdin, e.g. to register it with monitoring. (Can be specified multiple times)",
+	)
+
+	var poststopHooks vars.StringList
+	flag.Var(
+		&poststopHooks,
+		"poststopHook",
+		"Absolute path of an executable run as an OCI poststop hook of every container, before its network is torn down, with the container's state on stdin. (Can be specified multiple times)",
+	)
+
+	var persistentImages vars.StringList
+	flag.Var(
+		&persistentImages,
+		"persistentImage",
+		"docker:// rootfs pulled in to the image cache in the background when guardian starts, and pinned there, so that containers are quick to create from it even after the cache is lost; the image's digests are served at /debug/images on the debug server. Requires the in-process image plugin. (Can be specified multiple times)",
+	)
+
+	var bundlePlugins vars.StringList
+	flag.Var(
+		&bundlePlugins,
+		"bundlePlugin",
+		"Path to an executable which mutates each container's config.json, given it and the container's spec as JSON on stdin, and writing the mutated config to stdout. Plugins are run in order, after guardian's own rules. (Can be specified multiple times)",
+	)
+
+	var readOnlyListeners vars.StringList
+	flag.Var(
+		&readOnlyListeners,
+		"readOnlyListen",
+		"Network and address to serve a read-only garden API on, which can only list containers and read their info, metrics and properties, e.g. 'unix:/var/run/garden-ro.sock'; only unix sockets are allowed when the API is served over TLS. (Can be specified multiple times)",
+	)
+
+	cf_debug_server.AddFlags(flag.CommandLine)
+	cf_lager.AddFlags(flag.CommandLine)
+	flag.Parse()
+
+	commandLine := commandLineFlags(flag.CommandLine)
+	if *configFile != "" {
+		config, err := loadConfig(*configFile)
+		if err == nil {
+			err = applyConfig(flag.CommandLine, config, commandLine)
+		}
+
+		if err != nil {
+			println(err.Error())
+			os.Exit(1)
+		}
+	}
+
+	logger, logSink := cf_lager.New("guardian")
+
+	if *depotPath == "" {
+		missing("-depot")
+	}
+
+	if *accountingAddr != "" {
+		if *extensionsAddr != "" && *extensionsAddr != *accountingAddr {
+			logger.Fatal("invalid-extensions-addr", fmt.Errorf("accountingAddr is a deprecated alias of extensionsAddr, and cannot be given a different address"))
+		}
+
+		logger.Info("accounting-addr-deprecated", lager.Data{"use": "extensionsAddr"})
+		*extensionsAddr = *accountingAddr
+	}
+
+	// processes are run without iodaemon on Windows, and streaming files
+	// into and out of its containers is not supported
+	if !windowsHost {
+		if *iodaemonBin == "" {
+			missing("-iodaemonBin")
+		}
+
+		if *nstarBin == "" {
+			missing("-nstarBin")
+		}
+
+		if *remountBin == "" {
+			missing("-remountBin")
+		}
+
+		if *tarBin == "" {
+			missing("-tarBin")
+		}
+	}
+
+	if *initBin == "" {
+		missing("-initBin")
+	}
+
+	uidMappings = wireIDMappings(logger, "uid", *uidMapStart, *uidMapLength, sysinfo.MustGetMaxValidUID())
+	gidMappings = wireIDMappings(logger, "gid", *gidMapStart, *gidMapLength, sysinfo.MustGetMaxValidGID())
+
+	checkCgroupMode(logger)
+	if *rootless {
+		wireRootless(logger)
+		runtimePluginExtraArgs.List = append(runtimePluginExtraArgs.List, "--rootless=true")
+	}
+	checkLocalFilesystem(logger, "depot", *depotPath)
+	checkLocalFilesystem(logger, "graph", *graphRoot)
+
+	resolvedRootFSPath, err := filepath.EvalSymlinks(*rootFSPath)
+	if err != nil {
+		panic(err)
+	}
 
 	_, networkPoolCIDR, err := net.ParseCIDR(*networkPool)
 	if err != nil {
 		panic(err)
 	}
 
-	var denyNetworksList []string
-	if *denyNetworks != "" {
-		denyNetworksList = strings.Split(*denyNetworks, ",")
+	var networkPoolV6CIDR *net.IPNet
+	if *networkPoolV6 != "" {
+		_, networkPoolV6CIDR, err = net.ParseCIDR(*networkPoolV6)
+		if err != nil {
+			panic(err)
+		}
+	}
+
+	denyNetworksList := splitList(*denyNetworks)
+
+	externalIPAddr, err := parseExternalIP(*externalIP)
+	if err != nil {
+		panic(err)
+	}
+
+	interfacePrefix := fmt.Sprintf("g%s", *tag)
+	chainPrefix := fmt.Sprintf("g-%s-", *tag)
+	ipt := wireIptables(logger, chainPrefix)
+
+	dnsConfig, err := parseDNSConfig(dnsServers.List)
+	if err != nil {
+		logger.Fatal("invalid-dns-server", err)
+	}
+
+	propManager, err := properties.NewPersistentManager(logger, *propertiesDir)
+	if err != nil {
+		logger.Fatal("failed-to-load-properties", err)
+	}
+
+	annotator := wireAnnotator(*annotationProperties, propManager)
+
+	// the port pool is only used by the built-in networker
+	var portPool *ports.PersistentPool
+	var networker gardener.Networker = netplugin.New(*networkPlugin, strings.Split(*networkPluginExtraArgs, ",")...)
+	if *cniHookBin != "" && *networkPlugin != "" {
+		logger.Fatal("invalid-network-config", fmt.Errorf("only one of cniHookBin and networkPlugin may be given"))
+	}
+
+	if *cniHookBin != "" {
+		networker = wireCNINetworker(logger, *cniHookBin, *cniConfDir, *cniBinDir, *cniStateDir)
+	} else if *networkPlugin == "" && windowsHost {
+		networker = netplugin.HostNetwork{}
+	} else if *networkPlugin == "" && *rootless {
+		networker = wireRootlessNetworker(logger, externalIPAddr)
+	} else if *networkPlugin == "" {
+		portPool = wirePortPool(logger)
+		networker = wireNetworker(logger, *kawasakiBin, *tag, networkPoolCIDR, networkPoolV6CIDR, externalIPAddr, ipt, interfacePrefix, chainPrefix, propManager, portPool, dnsConfig, *denyNetworkLog)
+	}
+
+	diskQuotas, scratchUsager := wireDiskQuotas(logger, *diskQuotaFilesystem, *diskQuotaMountPoint)
+
+	registry := metrics.NewRegistry(logger.Session("metrics"))
+	wireMetrics(registry, *depotPath, *dadooBin)
+
+	capabilities := &sysinfo.Capabilities{}
+	events := gardener.NewEventHub(eventBufferSize)
+	oomWatcher := &rundmc.OomWatcher{
+		CgroupPath: cgroupMountpoint(),
+		Unified:    unifiedCgroups(),
+		Notifier:   wireOomNotifier(),
+		Publisher:  events,
+		Counter:    registry.NewCounter("guardian_container_ooms_total", "Number of times containers have run out of memory."),
+		Logger:     logger.Session("oom-watcher"),
+	}
+
+	maintenance := &gardener.Maintenance{Logger: logger.Session("maintenance")}
+
+	containerizer, limiter, tracker := wireContainerizer(logger, registry, maintenance, diskQuotas, capabilities, oomWatcher, events, runtimePluginExtraArgs.List, bundlePlugins.List, wireOperatorHooks(logger, prestartHooks.List), wireOperatorHooks(logger, poststopHooks.List), wireMaskedPaths(maskedPaths.List, readonlyPaths.List), wireDevices(logger, allowedDevices.List), wireNvidiaGPU(logger), liveBindMountSources.List, *depotPath, *iodaemonBin, *nstarBin, *tarBin, resolvedRootFSPath)
+	oomWatcher.Lister = containerizer
+
+	// the forwarder listens on the containers' bridges, whose addresses are
+	// in the network pools
+	var dnsNetworks []string
+	if dnsConfig.Forwarder {
+		dnsNetworks = append(dnsNetworks, networkPoolCIDR.String())
+		if networkPoolV6CIDR != nil {
+			dnsNetworks = append(dnsNetworks, networkPoolV6CIDR.String())
+		}
+	}
+
+	iptablesStarter := iptables.NewStarter(ipt, *allowHostAccess, dnsNetworks, interfacePrefix, splitList(*allowNetworks), denyNetworksList, networkPoolV6CIDR != nil)
+
+	// bundles left by an older guardian are migrated before they are
+	// recovered
+	bundleMigrator := &depot.Migrator{
+		Depot:      depot.New(*depotPath),
+		Migrations: depot.Migrations,
+		Version:    depot.SchemaVersion,
+		Logger:     logger,
+	}
+
+	socketRelay := wireSocketRelay(logger)
+	recoverer := &gardener.Recoverer{
+		Containerizer:   containerizer,
+		Networker:       networker,
+		SocketRelay:     socketRelay,
+		PropertyManager: propManager,
+		Logger:          logger,
+	}
+
+	starters := []gardener.Starter{
+		wireStarter(logger, iptablesStarter),
+		// probes after the cgroups have been mounted
+		&sysinfo.CapabilityProber{ProcPath: "/proc", CgroupPath: cgroupMountpoint(), Unified: unifiedCgroups(), Capabilities: capabilities, Logger: logger},
+		bundleMigrator,
+		recoverer,
+		oomWatcher,
+		&rundmc.KeyringQuota{ProcPath: "/proc", MaxKeys: *keyringMaxKeys, MaxBytes: *keyringMaxBytes, Logger: logger},
+	}
+
+	var dnsForwarder *dns.Forwarder
+	if dnsConfig.Forwarder {
+		dnsForwarder = &dns.Forwarder{
+			Bin:             *dnsForwarderBin,
+			InterfacePrefix: interfacePrefix,
+			PidFile:         *dnsForwarderPidFile,
+			Upstreams:       dnsConfig.Nameservers,
+			Runner:          linux_command_runner.New(),
+			Logger:          logger,
+		}
+		starters = append(starters, dnsForwarder)
+	}
+
+	// the port pool is only wired for kawasaki, which logs denied connections
+	if *denyNetworkLog && portPool != nil {
+		starters = append(starters, wireDeniedWatcher(logger, containerizer, propManager, events))
+	}
+
+	if windowsHost {
+		// there are no cgroups or iptables to set up, and every capability is
+		// assumed to be available without them being probed
+		starters = []gardener.Starter{
+			bundleMigrator,
+			recoverer,
+		}
+	}
+
+	var egressPolicy *gardener.EgressPolicy
+	if *egressPolicyFile != "" {
+		egressPolicy = &gardener.EgressPolicy{}
+		starters = append(starters, &gardener.EgressPolicyWatcher{
+			Path:          *egressPolicyFile,
+			Policy:        egressPolicy,
+			RetroApply:    *retroApplyEgressPolicy,
+			Containerizer: containerizer,
+			Networker:     networker,
+			Logger:        logger.Session("egress-policy"),
+		})
+	}
+
+	var staleStateReconciler *rundmc.StaleStateReconciler
+	if *staleStateCheckInterval > 0 {
+		staleStateReconciler = &rundmc.StaleStateReconciler{
+			Lister:    containerizer,
+			Stater:    rundmc.StateChecker{StateFileDir: OciStateDir, ProcPath: "/proc"},
+			Restarter: containerizer,
+			Clock:     maintenance.Clock("stale-state-reconciler", clock.NewClock()),
+			Interval:  *staleStateCheckInterval,
+			Logger:    logger.Session("stale-state-reconciler"),
+		}
+		starters = append(starters, staleStateReconciler)
+	}
+
+	var ages gardener.AgeReader
+	if *ageTrackingInterval > 0 {
+		ageTracker := &gardener.AgeTracker{
+			Lister:        containerizer,
+			Properties:    propManager,
+			Clock:         maintenance.Clock("age-tracker", clock.NewClock()),
+			Interval:      *ageTrackingInterval,
+			SkewThreshold: *clockSkewThreshold,
+			Publisher:     events,
+			Logger:        logger.Session("age-tracker"),
+		}
+		starters = append(starters, ageTracker)
+		ages = ageTracker
+	}
+
+	if !windowsHost {
+		starters = append(starters, &process_tracker.SocketSweeper{
+			ContainerPath: wireProcessDir(logger),
+			ProcNetUnix:   "/proc/net/unix",
+			MinAge:        time.Minute,
+			Clock:         maintenance.Clock("socket-sweeper", clock.NewClock()),
+			Interval:      *socketSweepInterval,
+			Swept:         registry.NewCounter("guardian_orphaned_sockets_removed_total", "Number of process sockets removed because no iodaemon was listening on them any more."),
+			Logger:        logger.Session("socket-sweeper"),
+		})
+	}
+
+	var (
+		throttles       gardener.ThrottleChecker
+		reservedHandles []string
+	)
+	if *cpuEntitlementCheckInterval > 0 && !windowsHost {
+		throttler := wireCPUThrottler(logger, registry, annotator, maintenance, containerizer, events)
+		starters = append(starters, throttler)
+		throttles = throttler
+		if throttler.ThrottledCgroup != "" {
+			reservedHandles = append(reservedHandles, throttler.ThrottledCgroup)
+		}
+	}
+
+	if *cpuMaxCheckInterval > 0 && !windowsHost {
+		starters = append(starters, wireCPUMaxWatcher(logger, registry, maintenance, containerizer, propManager, events))
+	}
+
+	onlineCPUs := wireOnlineCPUs(logger)
+	cpuSets := wireCPUSetAllocator(logger, *cpusetCPUsPerContainer, onlineCPUs.CPUs, containerizer, propManager)
+	if cpuSets != nil {
+		starters = append(starters, cpuSets)
+	}
+
+	volumeCreator := wireImagePlugin(logger, *graphRoot, insecureRegistries, registryMirrors)
+
+	pulledImages := wirePersistentImages(logger, volumeCreator, persistentImages.List)
+	if pulledImages != nil {
+		starters = append(starters, pulledImages)
+	}
+
+	if pruner := wireBlobPruner(logger, maintenance, volumeCreator); pruner != nil {
+		starters = append(starters, pruner)
+	}
+
+	var diskQuotaWatcher *gardener.DiskQuotaWatcher
+	if reporter, ok := volumeCreator.(gardener.DiskUsageReporter); ok && *diskQuotaCheckInterval > 0 {
+		diskQuotaWatcher = &gardener.DiskQuotaWatcher{
+			Reporter:   reporter,
+			Lister:     containerizer,
+			Properties: propManager,
+			Remounter: &rundmc.RootFSRemounter{
+				RemountBin:    *remountBin,
+				Stater:        rundmc.StateChecker{StateFileDir: OciStateDir},
+				CommandRunner: linux_command_runner.New(),
+			},
+			Publisher: events,
+			Clock:     maintenance.Clock("disk-quota-watcher", clock.NewClock()),
+			Interval:  *diskQuotaCheckInterval,
+			Logger:    logger.Session("disk-quota-watcher"),
+		}
+		starters = append(starters, diskQuotaWatcher)
+	}
+	defaultGraceTime := gardener.NewDefaultGraceTime(*graceTime)
+
+	outputLimiter := gardener.NewOutputLimiter(*maxContainerOutputRate, clock.NewClock())
+	registry.NewContainerCounterFunc("guardian_container_output_throttled_bytes_total",
+		"Bytes of stdout and stderr each container has written which were slowed down by its output rate limit.",
+		annotator, outputLimiter.Throttled)
+
+	destroyQueue := wireDestroyQueue(logger, maintenance, *asyncDestroy)
+	imageVerifier := wireImageVerifier(logger)
+
+	backend := &gardener.Gardener{
+		UidGenerator:     wireUidGenerator(),
+		Starter:          &StartAll{starters: starters},
+		SysInfoProvider:  sysinfo.NewProvider(*depotPath),
+		Networker:        networker,
+		VolumeCreator:    volumeCreator,
+		VolumeMounter:    wireVolumePlugin(logger),
+		RuntimeInspector: wireRuntimeInspector(*depotPath, tracker),
+		Containerizer:    containerizer,
+		PropertyManager:  propManager,
+		ChangeLog:        gardener.NewChangeLog(*changeLogSize),
+		Events:           events,
+		Metrics:          metrics.NewGardenerMetrics(registry),
+		ProcessLimiter:   wireProcessLimiter(*maxProcessesPerContainer),
+		ExecLimiter:      gardener.NewExecLimiter(int(*maxConcurrentExecsPerContainer), *execQueueTimeout),
+		Exits:            gardener.NewExitTracker(),
+		CreateQueue:      wireCreateQueue(registry, *maxConcurrentCreates),
+		CreateTimeouts: gardener.CreateTimeouts{
+			Image:   *createImageTimeout,
+			Bundle:  *createBundleTimeout,
+			Runtime: *createRuntimeTimeout,
+			Network: *createNetworkTimeout,
+		},
+		DestroyQueue:     destroyQueue,
+		OutputLimiter:    outputLimiter,
+		CPULimiter:       limiter,
+		CPUSets:          cpuSets,
+		OnlineCPUs:       onlineCPUs,
+		Ages:             ages,
+		Throttles:        throttles,
+		ReservedHandles:  reservedHandles,
+		EgressPolicy:     egressPolicy,
+		IDMappings:       &gardener.IDMappings{UID: uidMappings, GID: gidMappings},
+		Annotator:        annotator,
+		DefaultUmask:     wireUmask(logger, *defaultUmask),
+		ImageVerifier:    imageVerifier,
+		SocketRelay:      socketRelay,
+		DefaultGraceTime: defaultGraceTime,
+		CheckpointDir:    wireCheckpointDir(logger),
+		LayerDir:         *rootfsLayerDir,
+		MaxPids:          *maxPidsPerContainer,
+
+		AllowContainerRunners:  *allowContainerRunners,
+		Rootless:               *rootless,
+		RequireImageSignatures: *requireImageSignatures,
+
+		Logger: logger,
+	}
+
+	// the volumes of peas are destroyed by the backend, which created them
+	recoverer.Peas = backend
+
+	if staleStateReconciler != nil {
+		// containers are destroyed through the backend, so that their network,
+		// volume and properties are cleaned up too
+		staleStateReconciler.Destroyer = backend
+	}
+
+	if diskQuotaWatcher != nil {
+		diskQuotaWatcher.Destroyer = backend
+	}
+
+	if err := backend.ResumeDestroys(); err != nil {
+		logger.Fatal("failed-to-resume-destroys", err)
+	}
+
+	authorizer := wireAuthorizer(logger)
+
+	if *extensionsAddr != "" {
+		sampler := wireContainerSampler(*depotPath, propManager, scratchUsager)
+		accountant := accounting.NewAccountant(logger.Session("accountant"), sampler, backend.Containerizer, maintenance.Clock("accountant", clock.NewClock()), *accountingInterval)
+		if err := accountant.Start(); err != nil {
+			logger.Fatal("failed-to-start-accountant", err)
+		}
+
+		streamer := &accounting.Streamer{
+			Sampler:   sampler,
+			Lister:    backend.Containerizer,
+			Annotator: annotator,
+			Clock:     maintenance.Clock("metrics-streamer", clock.NewClock()),
+			Interval:  *metricsStreamInterval,
+			Logger:    logger.Session("metrics-streamer"),
+		}
+		if err := streamer.Start(); err != nil {
+			logger.Fatal("failed-to-start-metrics-streamer", err)
+		}
+
+		registry.NewContainerGaugeFunc("guardian_container_cpu_throttled_seconds",
+			"Time each container has spent throttled by its cpu quota, as of the last accounting sample.",
+			annotator, accountant.CPUThrottledSeconds)
+		registry.NewContainerGaugeFunc("guardian_container_bandwidth_rate_bytes_per_second",
+			"Rate each container's network traffic is limited to, as of the last accounting sample.",
+			annotator, accountant.BandwidthRates)
+		registry.NewContainerGaugeFunc("guardian_container_bandwidth_burst_bytes",
+			"Burst each container's network traffic is allowed, as of the last accounting sample.",
+			annotator, accountant.BandwidthBursts)
+		registry.NewGaugeFunc("guardian_cpuset_cpu_seconds",
+			"CPU time used by the containers pinned to each cpuset, as of the last accounting sample.",
+			"cpuset", func() (map[string]float64, error) {
+				return accountant.CPUSecondsBy(propManager, gardener.CPUSetCPUsProperty)
+			})
+
+		go serveExtensions(logger, *extensionsAddr, authorizer, accountant, streamer, backend, capabilities, portPool)
+		if *readOnlyExtensionsAddr != "" {
+			go serveReadOnlyExtensions(logger, *readOnlyExtensionsAddr, authorizer, accountant, streamer, backend, capabilities)
+		}
+	}
+
+	if debugAddr := cf_debug_server.DebugAddress(flag.CommandLine); debugAddr != "" {
+		go serveDebug(logger, debugAddr, authorizer, registry, maintenance, portPool, destroyQueue, pulledImages, backend)
+	}
+
+	serverNetwork, serverAddr := *listenNetwork, *listenAddr
+
+	apiProxy := wireAPIProxy(logger, registry)
+	if apiProxy != nil {
+		serverNetwork, serverAddr = "unix", apiProxy.SocketPath()
+
+		if *tlsCert != "" && authorizer != nil {
+			apiProxy.ServeCaller = func(caller, socketPath string) (*server.GardenServer, error) {
+				callerServer := server.New("unix", socketPath, 0, wireAuthorizingBackend(logger, authorizer, caller, sharedBackend{backend}), logger.Session("api", lager.Data{"caller": caller}))
+				return callerServer, callerServer.Start()
+			}
+		}
+	}
+
+	// the backend applies the default grace time, so that it can be reloaded
+	gardenServer := server.New(serverNetwork, serverAddr, 0, wireAuthorizingBackend(logger, authorizer, *listenNetwork+":"+*listenAddr, backend), logger.Session("api"))
+	additionalServers := wireAdditionalServers(logger, "additionalListen", additionalListeners.List, authorizer, sharedBackend{backend})
+	readOnlyServers := wireAdditionalServers(logger, "readOnlyListen", readOnlyListeners.List, nil, gardener.ReadOnlyBackend{Backend: backend})
+
+	err = gardenServer.Start()
+	if err != nil {
+		logger.Fatal("failed-to-start-server", err)
+	}
+
+	if apiProxy != nil {
+		go apiProxy.Serve()
+	}
+
+	for i, additionalServer := range additionalServers {
+		if err := additionalServer.Start(); err != nil {
+			logger.Fatal("failed-to-start-additional-server", err, lager.Data{"listener": additionalListeners.List[i]})
+		}
+	}
+
+	for i, readOnlyServer := range readOnlyServers {
+		if err := readOnlyServer.Start(); err != nil {
+			logger.Fatal("failed-to-start-read-only-server", err, lager.Data{"listener": readOnlyListeners.List[i]})
+		}
+	}
+
+	var reloader *ConfigReloader
+	if *configFile != "" {
+		reloader = &ConfigReloader{
+			Path:             *configFile,
+			CommandLine:      commandLine,
+			Logger:           logger,
+			LogSink:          logSink,
+			DefaultGraceTime: defaultGraceTime,
+		}
+
+		if *networkPlugin == "" && *cniHookBin == "" && !*rootless {
+			reloader.Networks = iptablesStarter
+		}
+
+		if plugin, ok := volumeCreator.(*imageplugin.InProcessPlugin); ok {
+			reloader.Registry = plugin.Registry
+		}
+	}
+
+	signals := make(chan os.Signal, 1)
+
+	go func() {
+		for sig := range signals {
+			if sig == syscall.SIGHUP && reloader != nil {
+				reloader.Reload()
+				continue
+			}
+
+			for _, additionalServer := range additionalServers {
+				additionalServer.Stop()
+			}
+
+			for _, readOnlyServer := range readOnlyServers {
+				readOnlyServer.Stop()
+			}
+
+			if apiProxy != nil {
+				apiProxy.Stop()
+			}
+
+			gardenServer.Stop()
+
+			if dnsForwarder != nil {
+				dnsForwarder.Stop()
+			}
+
+			os.Exit(0)
+		}
+	}()
+
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP)
+
+	logger.Info("started", lager.Data{
+		"network":    *listenNetwork,
+		"addr":       *listenAddr,
+		"tls":        *tlsCert != "",
+		"additional": additionalListeners.List,
+		"read-only":  readOnlyListeners.List,
+	})
+
+	select {}
+}
+
+func wireIDMappings(log lager.Logger, kind string, start, length uint, maxValidID int) rootfs_provider.MappingList {
+	if start == 0 && length == 0 {
+		return idMappings
+	}
+
+	if start == 0 || length == 0 {
+		log.Fatal("invalid-"+kind+"-mapping", fmt.Errorf("%sMapStart and %sMapLength must both be set, and %sMapStart must not be 0", kind, kind, kind))
+	}
+
+	if uint64(start)+uint64(length)-1 > uint64(maxValidID) {
+		log.Fatal("invalid-"+kind+"-mapping", fmt.Errorf("%sMapStart + %sMapLength exceeds the highest valid %s (%d)", kind, kind, kind, maxValidID))
+	}
+
+	return rootfs_provider.MappingList{
+		{
+			ContainerID: 0,
+			HostID:      uint32(start),
+			Size:        uint32(length),
+		},
+	}
+}
+
+func wireAnnotator(keys string, propManager *properties.Manager) *gardener.Annotator {
+	if keys == "" {
+		return nil
+	}
+
+	return &gardener.Annotator{Keys: strings.Split(keys, ","), Properties: propManager}
+}
+
+func wireUidGenerator() gardener.UidGeneratorFunc {
+	return gardener.UidGeneratorFunc(func() string { return mustStringify(uuid.NewV4()) })
+}
+
+func wireStarter(logger lager.Logger, iptablesStarter *iptables.Starter) gardener.Starter {
+	runner := &logging.Runner{CommandRunner: linux_command_runner.New(), Logger: logger.Session("runner")}
+
+	var cgroupStarter gardener.Starter = rundmc.NewStarter(logger, mustOpen("/proc/cgroups"), cgroupMountpoint(), runner)
+	if unifiedCgroups() {
+		cgroupStarter = &rundmc.CgroupV2Starter{CgroupPath: cgroupMountpoint(), CommandRunner: runner, Logger: logger, Delegated: *rootless}
+	}
+
+	// a rootless guardian has no iptables of its own to set up
+	if *rootless {
+		return cgroupStarter
+	}
+
+	return &StartAll{starters: []gardener.Starter{
+		cgroupStarter,
+		iptablesStarter,
+	}}
+}
+
+// rootlessCgroupPath is the cgroup delegated to a rootless guardian, which
+// is its cgroup mountpoint
+var rootlessCgroupPath string
+
+func wireCPUThrottler(logger lager.Logger, registry *metrics.Registry, annotator *gardener.Annotator, maintenance *gardener.Maintenance, lister rundmc.HandleLister, events gardener.EventPublisher) *rundmc.CPUThrottler {
+	if *cpuThrottleAfter < 1 || *cpuReleaseAfter < 1 {
+		logger.Fatal("invalid-cpu-throttling", fmt.Errorf("-cpuThrottleAfter and -cpuReleaseAfter must be at least 1"))
+	}
+
+	// with cgroup v2 containers are throttled in their own cgroups, whose
+	// weights must survive a restart
+	throttledCgroup, statePath := "throttled", ""
+	if unifiedCgroups() {
+		if err := os.MkdirAll(filepath.Dir(*cpuThrottleStateFile), 0755); err != nil {
+			logger.Fatal("failed-to-create-cpu-throttle-state-directory", err)
+		}
+
+		throttledCgroup, statePath = "", *cpuThrottleStateFile
+	}
+
+	throttler := &rundmc.CPUThrottler{
+		CgroupPath:      cgroupMountpoint(),
+		Unified:         unifiedCgroups(),
+		ThrottledCgroup: throttledCgroup,
+		StatePath:       statePath,
+		CPUs:            runtime.NumCPU(),
+		Lister:          lister,
+		ThrottleAfter:   *cpuThrottleAfter,
+		ReleaseAfter:    *cpuReleaseAfter,
+		Publisher:       events,
+		Clock:           maintenance.Clock("cpu-throttler", clock.NewClock()),
+		Interval:        *cpuEntitlementCheckInterval,
+		Logger:          logger.Session("cpu-throttler"),
+	}
+
+	registry.NewContainerGaugeFunc("guardian_cpu_throttled", "Whether each container is throttled for persistently using more than its CPU entitlement, as 1 or 0.", annotator, func() (map[string]float64, error) {
+		return throttler.Throttled(), nil
+	})
+
+	return throttler
+}
+
+func wireCPUMaxWatcher(logger lager.Logger, registry *metrics.Registry, maintenance *gardener.Maintenance, lister gardener.HandleLister, properties gardener.PropertyManager, events gardener.EventPublisher) *gardener.CPUMaxWatcher {
+	if *cpuMaxExceedAfter < 1 {
+		logger.Fatal("invalid-cpu-max", fmt.Errorf("-cpuMaxExceedAfter must be at least 1"))
+	}
+
+	limiter := &rundmc.CgroupCPULimiter{CgroupPath: cgroupMountpoint(), Unified: unifiedCgroups()}
+	watcher := &gardener.CPUMaxWatcher{
+		Reporter:    limiter,
+		Lister:      lister,
+		Properties:  properties,
+		Limiter:     limiter,
+		ExceedAfter: *cpuMaxExceedAfter,
+		Publisher:   events,
+		Clock:       maintenance.Clock("cpu-max-watcher", clock.NewClock()),
+		Interval:    *cpuMaxCheckInterval,
+		Logger:      logger.Session("cpu-max-watcher"),
+	}
+
+	registry.NewGaugeFunc("guardian_cpu_max_exceeded_containers", "Number of containers which have exceeded their cpu-max, by their cpu-max-action.", "action", func() (map[string]float64, error) {
+		return watcher.Exceeded(), nil
+	})
+
+	return watcher
+}
+
+func cgroupMountpoint() string {
+	if rootlessCgroupPath != "" {
+		return rootlessCgroupPath
+	}
+
+	return path.Join(os.TempDir(), fmt.Sprintf("cgroups-%s", *tag))
+}
+
+// rootlessCgroupParent is the path of the cgroup delegated to a rootless
+// guardian relative to the root of the hierarchy, under which containers'
+// cgroups are placed, or empty if guardian is not rootless
+func rootlessCgroupParent() string {
+	if rootlessCgroupPath == "" {
+		return ""
+	}
+
+	return strings.TrimPrefix(rootlessCgroupPath, sysCgroupPath)
+}
+
+const sysCgroupPath = "/sys/fs/cgroup"
+
+// wireRootless checks that guardian can run as an unprivileged user, and
+// finds the cgroup delegated to it, which its containers' cgroups are
+// created in. Container root is mapped to the user, the only id an
+// unprivileged user may map without newuidmap.
+func wireRootless(log lager.Logger) {
+	if *imagePlugin == "" {
+		log.Fatal("invalid-rootless-config", fmt.Errorf("-rootless requires -imagePlugin, e.g. one using fuse-overlayfs, as the in-process image plugin must mount rootfses as root"))
+	}
+
+	if !unifiedCgroups() {
+		log.Fatal("invalid-rootless-config", fmt.Errorf("-rootless requires cgroup v2, as cgroup v1 cannot be delegated to an unprivileged user"))
+	}
+
+	delegated, err := sysinfo.DelegatedCgroup("/proc/self/cgroup", sysCgroupPath)
+	if err != nil {
+		log.Fatal("rootless-cgroup-not-delegated", err)
+	}
+
+	// guardian moves itself in to the guardian child cgroup when it starts,
+	// so after a restart it finds itself there
+	if filepath.Base(delegated) == "guardian" {
+		delegated = filepath.Dir(delegated)
+	}
+
+	rootlessCgroupPath = delegated
+
+	uidMappings = rootfs_provider.MappingList{{ContainerID: 0, HostID: uint32(os.Getuid()), Size: 1}}
+	gidMappings = rootfs_provider.MappingList{{ContainerID: 0, HostID: uint32(os.Getgid()), Size: 1}}
+
+	log.Info("rootless", lager.Data{"cgroup": rootlessCgroupPath, "uid": os.Getuid(), "gid": os.Getgid()})
+}
+
+func wireRootlessNetworker(log lager.Logger, externalIP net.IP) gardener.Networker {
+	switch *rootlessNetwork {
+	case "none":
+		return netplugin.Isolated{}
+	case "slirp4netns":
+		stateDir := path.Join(os.TempDir(), fmt.Sprintf("garden-%s", *tag), "slirp4netns")
+		if err := os.MkdirAll(stateDir, 0700); err != nil {
+			log.Fatal("failed-to-create-slirp4netns-state-dir", err)
+		}
+
+		// forwarded ports are bound to the external IP, as kawasaki's are,
+		// rather than to every address of the host
+		return &netplugin.Slirp4netns{Bin: *slirp4netnsBin, StateDir: stateDir, HostAddr: externalIP}
+	default:
+		log.Fatal("invalid-rootless-network", fmt.Errorf("invalid rootlessNetwork: '%s'", *rootlessNetwork))
+		return nil
+	}
+}
+
+// unifiedCgroups is whether containers are run in cgroup v2's unified
+// hierarchy, in which case that is what is mounted at the cgroup mountpoint
+func unifiedCgroups() bool {
+	if *cgroupMode == "auto" {
+		return sysinfo.DetectCgroupMode("/sys/fs/cgroup") == sysinfo.CgroupModeV2
+	}
+
+	return sysinfo.CgroupMode(*cgroupMode) == sysinfo.CgroupModeV2
+}
+
+// checkCgroupMode refuses to start in a cgroup mode the host does not
+// support, and logs the mode containers are run in
+func checkCgroupMode(log lager.Logger) {
+	if windowsHost {
+		return
+	}
+
+	hostMode := sysinfo.DetectCgroupMode("/sys/fs/cgroup")
+	if *cgroupMode != "auto" {
+		if err := sysinfo.CheckCgroupMode(sysinfo.CgroupMode(*cgroupMode), hostMode); err != nil {
+			log.Fatal("unsupported-cgroup-mode", err)
+		}
+	}
+
+	mode := sysinfo.CgroupModeV1
+	if unifiedCgroups() {
+		mode = sysinfo.CgroupModeV2
+	}
+
+	log.Info("cgroup-mode", lager.Data{"mode": mode, "host": hostMode})
+}
+
+func wireOomNotifier() rundmc.OomNotifier {
+	if unifiedCgroups() {
+		return rundmc.UnifiedCgroupOomNotifier{}
+	}
+
+	return rundmc.CgroupOomNotifier{}
+}
+
+func wireContainerSampler(depotPath string, propManager *properties.Manager, scratchUsager accounting.ScratchUsager) *accounting.ContainerSampler {
+	return &accounting.ContainerSampler{
+		CgroupPath:     cgroupMountpoint(),
+		Unified:        unifiedCgroups(),
+		Properties:     propManager,
+		NetworkStatter: wireNetworkStatter(),
+		RootFSPather: &accounting.BundleRootFSPather{
+			DepotPath:    depotPath,
+			BundleLoader: &goci.BndlLoader{},
+		},
+		ScratchUsager: scratchUsager,
+		CommandRunner: linux_command_runner.New(),
+
+		// the accountant measures each container's disk usage every
+		// accountingInterval, which the metrics streamer's more frequent
+		// samples reuse rather than walking every rootfs again
+		DiskUsageMaxAge: *accountingInterval,
+		Clock:           clock.NewClock(),
+	}
+}
+
+func serveExtensions(logger lager.Logger, addr string, authorizer gardener.Authorizer, accountant *accounting.Accountant, streamer *accounting.Streamer, backend *gardener.Gardener, capabilities *sysinfo.Capabilities, portPool *ports.PersistentPool) {
+	mux := http.NewServeMux()
+	mux.Handle("/accounting", &accounting.Handler{Reporter: accountant, Annotator: backend.Annotator})
+	mux.Handle("/metrics/stream", &accounting.StreamHandler{Streamer: streamer})
+	mux.Handle("/containers/changes", &gardener.ChangesHandler{Lister: backend})
+	mux.Handle("/events", &gardener.EventsHandler{Hub: backend.Events})
+	mux.Handle("/containers/checkpoint", &gardener.CheckpointHandler{Checkpointer: backend})
+	mux.Handle("/containers/restore", &gardener.RestoreHandler{Checkpointer: backend})
+	mux.Handle("/containers/bind-mounts", &gardener.BindMountsHandler{Mounter: backend})
+	mux.Handle("/containers/net-in", &gardener.NetInHandler{Mapper: backend})
+	mux.Handle("/containers/processes/exit", &gardener.ProcessExitHandler{Exits: backend.Exits})
+	mux.Handle("/capabilities", &sysinfo.CapabilitiesHandler{Capabilities: capabilities})
+	if portPool != nil {
+		mux.Handle("/ports", &ports.Handler{Pool: portPool.PortPool})
+	}
+
+	// validating resolves images from registries on the caller's behalf,
+	// the runtime details give away the host's paths and pids, and
+	// definitions hold containers' whole specs, including their environment,
+	// and create containers, so they are only served to authorized callers
+	if authorizer != nil {
+		mux.Handle("/containers/validate", &gardener.ValidateHandler{Validator: backend})
+		mux.Handle("/containers/runtime", &gardener.RuntimeHandler{Inspector: backend})
+		mux.Handle("/containers/export", &gardener.ExportHandler{Definer: backend})
+		mux.Handle("/containers/import", &gardener.ImportHandler{Definer: backend})
+	}
+
+	negotiator := &gardener.APIVersionNegotiator{
+		Handler:         mux,
+		MinVersion:      *minAPIVersion,
+		DeprecatedBelow: *deprecatedAPIVersionsBelow,
+		Logger:          logger.Session("api-version"),
+	}
+	mux.Handle("/api/versions", &gardener.APIVersionsHandler{Negotiator: negotiator})
+
+	var handler http.Handler = negotiator
+	if authorizer != nil {
+		handler = &gardener.AuthorizingHandler{
+			Handler:    negotiator,
+			Authorizer: authorizer,
+			Listener:   "tcp:" + addr,
+			Logger:     logger.Session("extensions-authorizer"),
+		}
+	}
+
+	if err := listenAndServeExtensions(addr, handler); err != nil {
+		logger.Fatal("failed-to-serve-extensions", err)
+	}
+}
+
+// serveReadOnlyExtensions serves the guardian-specific endpoints which do not
+// change any container, so that monitoring agents need not be given access
+// to checkpointing, importing or mounting into containers.
+func serveReadOnlyExtensions(logger lager.Logger, addr string, authorizer gardener.Authorizer, accountant *accounting.Accountant, streamer *accounting.Streamer, backend *gardener.Gardener, capabilities *sysinfo.Capabilities) {
+	mux := http.NewServeMux()
+	mux.Handle("/accounting", &accounting.Handler{Reporter: accountant, Annotator: backend.Annotator})
+	mux.Handle("/metrics/stream", &accounting.StreamHandler{Streamer: streamer})
+	mux.Handle("/containers/changes", &gardener.ChangesHandler{Lister: backend})
+	mux.Handle("/events", &gardener.EventsHandler{Hub: backend.Events})
+	mux.Handle("/containers/processes/exit", &gardener.ProcessExitHandler{Exits: backend.Exits})
+	mux.Handle("/capabilities", &sysinfo.CapabilitiesHandler{Capabilities: capabilities})
+
+	negotiator := &gardener.APIVersionNegotiator{
+		Handler:         mux,
+		MinVersion:      *minAPIVersion,
+		DeprecatedBelow: *deprecatedAPIVersionsBelow,
+		Logger:          logger.Session("read-only-api-version"),
+	}
+	mux.Handle("/api/versions", &gardener.APIVersionsHandler{Negotiator: negotiator})
+
+	var handler http.Handler = negotiator
+	if authorizer != nil {
+		handler = &gardener.AuthorizingHandler{
+			Handler:    negotiator,
+			Authorizer: authorizer,
+			Listener:   "tcp:" + addr,
+			Logger:     logger.Session("read-only-extensions-authorizer"),
+		}
+	}
+
+	if err := listenAndServeExtensions(addr, handler); err != nil {
+		logger.Fatal("failed-to-serve-read-only-extensions", err)
+	}
+}
+
+// listenAndServeExtensions serves handler on addr, over TLS with the garden
+// API's certificate and client CAs when the garden API is served over TLS
+func listenAndServeExtensions(addr string, handler http.Handler) error {
+	if *tlsCert == "" && *tlsKey == "" && *tlsCA == "" {
+		return http.ListenAndServe(addr, handler)
+	}
+
+	tlsConfig, err := wireTLSConfig(*tlsCert, *tlsKey, *tlsCA)
+	if err != nil {
+		return err
+	}
+
+	listener, err := tls.Listen("tcp", addr, tlsConfig)
+	if err != nil {
+		return err
+	}
+
+	return http.Serve(listener, handler)
+}
+
+// serveDebug serves pprof and expvar (registered on the default mux when
+// imported) alongside Prometheus metrics, the port pool's reservations and
+// the cleanups the destroy queue is retrying, the persistent images and the
+// runtime state of each container. Pausing background workers at
+// /maintenance is only served if there is an authorizer, which authorizes
+// each request to it.
+func serveDebug(logger lager.Logger, addr string, authorizer gardener.Authorizer, registry *metrics.Registry, maintenance *gardener.Maintenance, portPool *ports.PersistentPool, destroyQueue *gardener.DestroyQueue, pulledImages *imageplugin.PersistentImages, inspector gardener.ContainerRuntimeInspector) {
+	http.Handle("/metrics", registry)
+	http.Handle(gardener.ContainerStatePath, &gardener.ContainerStateHandler{Inspector: inspector})
+	if portPool != nil {
+		http.Handle("/debug/ports", &ports.Handler{Pool: portPool.PortPool})
+	}
+
+	if destroyQueue != nil {
+		http.Handle("/debug/destroys", &gardener.DestroyQueueHandler{Queue: destroyQueue})
+	}
+
+	if pulledImages != nil {
+		http.Handle("/debug/images", &imageplugin.PersistentImagesHandler{Images: pulledImages})
+	}
+
+	if authorizer != nil {
+		http.Handle("/maintenance", &gardener.AuthorizingHandler{
+			Handler:    &gardener.MaintenanceHandler{Maintenance: maintenance},
+			Authorizer: authorizer,
+			Listener:   "tcp:" + addr,
+			Logger:     logger.Session("maintenance-authorizer"),
+		})
+	}
+
+	if err := http.ListenAndServe(addr, nil); err != nil {
+		logger.Fatal("failed-to-serve-debug", err)
+	}
+}
+
+func wireMetrics(registry *metrics.Registry, depotPath, dadooPath string) {
+	runner := linux_command_runner.New()
+
+	registry.NewGaugeFunc("guardian_iptables_rules", "Number of iptables rules, by table.", "table",
+		(&metrics.IptablesRuleCounter{Runner: runner, Binary: "/sbin/iptables", Tables: []string{"filter", "nat"}}).Collect)
+	registry.NewGaugeFunc("guardian_loop_devices", "Number of loop devices, by whether they are attached to a backing file.", "state",
+		(&metrics.LoopDeviceCounter{SysBlockPath: "/sys/block"}).Collect)
+	registry.NewGaugeFunc("guardian_depot_bytes", "Disk space used by the depot.", "",
+		(&metrics.DepotSizer{Runner: runner, DepotPath: depotPath}).Collect)
+
+	// kernel threads have an empty command line, so would all be counted
+	if dadooPath != "" {
+		registry.NewGaugeFunc("guardian_dadoo_processes", "Number of running dadoo processes.", "",
+			(&metrics.ProcessCounter{ProcPath: "/proc", Binary: dadooPath}).Collect)
+	}
+}
+
+func wireIptables(logger lager.Logger, prefix string) *iptables.IPTables {
+	runner := &logging.Runner{CommandRunner: linux_command_runner.New(), Logger: logger.Session("iptables-runner")}
+	return iptables.New(runner, prefix)
+}
+
+func wireNetworker(
+	log lager.Logger,
+	kawasakiBin string,
+	tag string,
+	networkPoolCIDR *net.IPNet,
+	networkPoolV6CIDR *net.IPNet,
+	externalIP net.IP,
+	ipt *iptables.IPTables,
+	interfacePrefix string,
+	chainPrefix string,
+	propManager *properties.Manager,
+	portPool kawasaki.PortPool,
+	dnsConfig kawasaki.DNSConfig,
+	logDenied bool,
+) gardener.Networker {
+	idGenerator := kawasaki.NewSequentialIDGenerator(time.Now().UnixNano())
+
+	var subnetPoolV6 subnets.Pool
+	if networkPoolV6CIDR != nil {
+		ext := filepath.Ext(*networkPoolStateFile)
+		subnetPoolV6 = wireSubnetPool(log, networkPoolV6CIDR, strings.TrimSuffix(*networkPoolStateFile, ext)+"-v6"+ext)
+	}
+
+	return kawasaki.New(
+		kawasakiBin,
+		kawasaki.SpecParserFunc(kawasaki.ParseSpec),
+		wireSubnetPool(log, networkPoolCIDR, *networkPoolStateFile),
+		subnetPoolV6,
+		kawasaki.NewConfigCreator(idGenerator, interfacePrefix, chainPrefix, externalIP, logDenied),
+		factory.NewDefaultConfigurer(ipt, logDenied),
+		propManager,
+		portPool,
+		iptables.NewPortForwarder(ipt),
+		iptables.NewFirewallOpener(ipt),
+		iptables.NewConntrackFlusher(ipt, *conntrackBin),
+		&tc.BandwidthLimiter{Runner: linux_command_runner.New()},
+		dnsConfig,
+	)
+}
+
+// wireDeniedWatcher publishes an event for each connection logged as denied
+// to the kernel log from the time guardian starts, rather than for those
+// logged before
+func wireDeniedWatcher(log lager.Logger, lister kawasaki.HandleLister, configStore kawasaki.ConfigStore, publisher gardener.EventPublisher) *kawasaki.DeniedWatcher {
+	kmsg, err := os.Open("/dev/kmsg")
+	if err != nil {
+		log.Fatal("failed-to-open-kernel-log", err)
+	}
+
+	if _, err := kmsg.Seek(0, os.SEEK_END); err != nil {
+		log.Fatal("failed-to-seek-kernel-log", err)
+	}
+
+	return &kawasaki.DeniedWatcher{
+		Log:         kmsg,
+		Lister:      lister,
+		ConfigStore: configStore,
+		Publisher:   publisher,
+		Logger:      log,
+	}
+}
+
+func wireSubnetPool(log lager.Logger, ipNet *net.IPNet, stateFile string) *subnets.PersistentPool {
+	if err := os.MkdirAll(filepath.Dir(stateFile), 0755); err != nil {
+		log.Fatal("failed-to-create-network-pool-state-directory", err)
+	}
+
+	pool, err := subnets.NewPersistentPool(ipNet, stateFile)
+	if err != nil {
+		log.Fatal("failed-to-create-network-pool", err, lager.Data{"network-pool": ipNet.String()})
+	}
+
+	return pool
+}
+
+func wirePortPool(log lager.Logger) *ports.PersistentPool {
+	if err := os.MkdirAll(filepath.Dir(*portPoolStateFile), 0755); err != nil {
+		log.Fatal("failed-to-create-port-pool-state-directory", err)
+	}
+
+	portPool, err := ports.NewPersistentPool(uint32(*portPoolStart), uint32(*portPoolSize), *portPoolStateFile)
+	if _, corrupt := err.(ports.CorruptStateError); corrupt {
+		// losing track of which ports are held should not stop guardian from
+		// starting; the corrupt file is kept aside for investigation
+		log.Error("port-pool-state-corrupt", err)
+		if err := os.Rename(*portPoolStateFile, *portPoolStateFile+".corrupt"); err != nil {
+			log.Fatal("failed-to-move-corrupt-port-pool-state", err)
+		}
+
+		portPool, err = ports.NewPersistentPool(uint32(*portPoolStart), uint32(*portPoolSize), *portPoolStateFile)
+	}
+
+	if err != nil {
+		log.Fatal("failed-to-create-port-pool", err)
+	}
+
+	return portPool
+}
+
+func wireCNINetworker(log lager.Logger, hookBin, confDir, binDir, stateDir string) gardener.Networker {
+	if err := os.MkdirAll(stateDir, 0700); err != nil {
+		log.Fatal("failed-to-create-cni-state-directory", err)
+	}
+
+	return netplugin.NewCNIPlugin(hookBin, confDir, binDir, stateDir, linux_command_runner.New())
+}
+
+func wireVolumePlugin(logger lager.Logger) gardener.VolumeMounter {
+	if *volumePlugin == "" {
+		return nil
+	}
+
+	var extraArgs []string
+	if *volumePluginExtraArgs != "" {
+		extraArgs = strings.Split(*volumePluginExtraArgs, ",")
+	}
+
+	return &volumeplugin.ExternalPlugin{
+		Binary:    *volumePlugin,
+		ExtraArgs: extraArgs,
+		CommandRunner: &logging.Runner{
+			CommandRunner: cmdtimeout.Runner{CommandRunner: linux_command_runner.New(), Timeout: *volumePluginTimeout},
+			Logger:        logger.Session("volume-plugin"),
+		},
+	}
+}
+
+func wireAuthorizer(logger lager.Logger) gardener.Authorizer {
+	if *authorizerBin != "" && *authorizerURL != "" {
+		logger.Fatal("invalid-authorizer", fmt.Errorf("only one of authorizerBin and authorizerURL may be given"))
+	}
+
+	if *authorizerBin != "" {
+		return &authplugin.ExternalAuthorizer{
+			Binary: *authorizerBin,
+			CommandRunner: &logging.Runner{
+				CommandRunner: cmdtimeout.Runner{CommandRunner: linux_command_runner.New(), Timeout: *authorizerTimeout},
+				Logger:        logger.Session("authorizer"),
+			},
+		}
+	}
+
+	if *authorizerURL != "" {
+		return &authplugin.HTTPAuthorizer{
+			URL:    *authorizerURL,
+			Client: &http.Client{Timeout: *authorizerTimeout},
+		}
+	}
+
+	return nil
+}
+
+func wireCheckpointDir(logger lager.Logger) string {
+	if *checkpointDir == "" {
+		return ""
+	}
+
+	// exports hold containers' bundles and memory, so only root may read
+	// them
+	if err := os.MkdirAll(*checkpointDir, 0700); err != nil {
+		logger.Fatal("failed-to-create-checkpoint-dir", err)
+	}
+
+	return *checkpointDir
+}
+
+func wireSocketRelay(logger lager.Logger) gardener.SocketRelay {
+	if *metricsRelayDir == "" {
+		return nil
+	}
+
+	if windowsHost {
+		logger.Fatal("invalid-metrics-relay-dir", fmt.Errorf("containers' sockets cannot be relayed on windows"))
+	}
+
+	if err := os.MkdirAll(*metricsRelayDir, 0755); err != nil {
+		logger.Fatal("failed-to-create-metrics-relay-dir", err)
+	}
+
+	return &socketrelay.Relay{
+		Dir:    *metricsRelayDir,
+		Stater: rundmc.StateChecker{StateFileDir: OciStateDir, ProcPath: "/proc"},
+		Dialer: socketrelay.NamespaceDialer{ProcPath: "/proc"},
+	}
+}
+
+func wireImageVerifier(logger lager.Logger) gardener.ImageVerifier {
+	if *imageVerifierBin == "" {
+		if *requireImageSignatures {
+			logger.Fatal("invalid-image-verifier", fmt.Errorf("--requireImageSignatures needs an imageVerifierBin"))
+		}
+
+		return nil
+	}
+
+	var extraArgs []string
+	if *imageVerifierExtraArgs != "" {
+		extraArgs = strings.Split(*imageVerifierExtraArgs, ",")
+	}
+
+	return &imageplugin.ExternalVerifier{
+		Binary:    *imageVerifierBin,
+		ExtraArgs: extraArgs,
+		CommandRunner: &logging.Runner{
+			CommandRunner: cmdtimeout.Runner{CommandRunner: linux_command_runner.New(), Timeout: *imageVerifierTimeout},
+			Logger:        logger.Session("image-verifier"),
+		},
+	}
+}
+
+// wireAuthorizingBackend authorizes the backend's requests as coming from
+// the caller, if there is an authorizer
+func wireAuthorizingBackend(logger lager.Logger, authorizer gardener.Authorizer, caller string, backend garden.Backend) garden.Backend {
+	if authorizer == nil {
+		return backend
+	}
+
+	return gardener.AuthorizingBackend{
+		Backend:    backend,
+		Authorizer: authorizer,
+		Caller:     caller,
+		Logger:     logger.Session("authorizer"),
+	}
+}
+
+func wirePersistentImages(logger lager.Logger, volumeCreator gardener.VolumeCreator, rootfses []string) *imageplugin.PersistentImages {
+	if len(rootfses) == 0 {
+		return nil
+	}
+
+	puller, ok := volumeCreator.(*imageplugin.InProcessPlugin)
+	if !ok {
+		logger.Fatal("invalid-persistent-images", fmt.Errorf("persistent images are only pulled by the in-process image plugin, so cannot be used with -imagePlugin"))
+	}
+
+	return &imageplugin.PersistentImages{
+		RootFSes: rootfses,
+		Puller:   puller,
+		Logger:   logger,
+	}
+}
+
+// wireBlobPruner returns nil unless imageCacheMaxAge is set and images are
+// pulled in-process
+func wireBlobPruner(logger lager.Logger, maintenance *gardener.Maintenance, volumeCreator gardener.VolumeCreator) *imageplugin.BlobPruner {
+	plugin, ok := volumeCreator.(*imageplugin.InProcessPlugin)
+	if *imageCacheMaxAge <= 0 || !ok {
+		return nil
+	}
+
+	if *imageCachePruneInterval <= 0 {
+		logger.Fatal("invalid-image-cache-prune-interval", fmt.Errorf("imageCachePruneInterval must be positive, not %s", *imageCachePruneInterval))
+	}
+
+	return &imageplugin.BlobPruner{
+		Cache:    plugin.Blobs,
+		MaxAge:   *imageCacheMaxAge,
+		Clock:    maintenance.Clock("image-cache-pruner", clock.NewClock()),
+		Interval: *imageCachePruneInterval,
+		Logger:   logger.Session("image-cache-pruner"),
+	}
+}
+
+func wireImagePlugin(logger lager.Logger, graphRoot string, insecureRegistries, registryMirrors vars.StringList) gardener.VolumeCreator {
+	if *imagePlugin != "" {
+		var extraArgs []string
+		if *imagePluginExtraArgs != "" {
+			extraArgs = strings.Split(*imagePluginExtraArgs, ",")
+		}
+
+		runner := &logging.Runner{CommandRunner: linux_command_runner.New(), Logger: logger.Session("image-plugin")}
+
+		var readiness *imageplugin.ReadinessProbe
+		if *imagePluginReadinessArgs != "" {
+			readiness = &imageplugin.ReadinessProbe{
+				Binary:        *imagePlugin,
+				Args:          append(append([]string{}, extraArgs...), strings.Split(*imagePluginReadinessArgs, ",")...),
+				CommandRunner: cmdtimeout.Runner{CommandRunner: runner, Timeout: *imagePluginReadinessTimeout},
+				Clock:         clock.NewClock(),
+				Interval:      time.Second,
+				Timeout:       *imagePluginReadinessTimeout,
+			}
+		}
+
+		return &imageplugin.ExternalPlugin{
+			Binary:            *imagePlugin,
+			ExtraArgs:         extraArgs,
+			CommandRunner:     runner,
+			UIDMappings:       uidMappings,
+			GIDMappings:       gidMappings,
+			Readiness:         readiness,
+			NegotiateProtocol: true,
+		}
+	}
+
+	credentials := make(map[string]imageplugin.Credential)
+	if *registryCredentials != "" {
+		data, err := ioutil.ReadFile(*registryCredentials)
+		if err != nil {
+			logger.Fatal("failed-to-read-registry-credentials", err)
+		}
+
+		if err := json.Unmarshal(data, &credentials); err != nil {
+			logger.Fatal("failed-to-parse-registry-credentials", err)
+		}
 	}
 
-	externalIPAddr, err := parseExternalIP(*externalIP)
-	if err != nil {
-		panic(err)
+	return &imageplugin.InProcessPlugin{
+		Registry: &imageplugin.RegistryClient{
+			DefaultRegistry: *dockerRegistry,
+			Mirrors:         registryMirrors.List,
+			Insecure:        insecureRegistries.List,
+			Credentials:     credentials,
+		},
+		Blobs:       &imageplugin.BlobCache{Path: filepath.Join(graphRoot, "imageplugin", "blobs")},
+		RootFSPath:  filepath.Join(graphRoot, "imageplugin", "rootfs"),
+		UIDMappings: uidMappings,
+		GIDMappings: gidMappings,
+		Fallback:    wireVolumeCreator(logger, graphRoot, insecureRegistries),
 	}
+}
 
-	interfacePrefix := fmt.Sprintf("g%s", *tag)
-	chainPrefix := fmt.Sprintf("g-%s-", *tag)
-	ipt := wireIptables(logger, chainPrefix)
+// checkLocalFilesystem refuses to start with state on a network filesystem,
+// where FIFOs, unix sockets and locks do not behave as guardian expects and
+// failures show up as hangs rather than errors, unless -allowNetworkDepot is
+// set.
+func checkLocalFilesystem(log lager.Logger, name, path string) {
+	fsType, isNetwork, err := sysinfo.NetworkFilesystem(path)
+	if err != nil {
+		log.Error("failed-to-detect-filesystem", err, lager.Data{"name": name, "path": path})
+		return
+	}
 
-	propManager := properties.NewManager()
+	if !isNetwork {
+		return
+	}
 
-	var networker gardener.Networker = netplugin.New(*networkPlugin, strings.Split(*networkPluginExtraArgs, ",")...)
-	if *networkPlugin == "" {
-		networker = wireNetworker(logger, *kawasakiBin, *tag, networkPoolCIDR, externalIPAddr, ipt, interfacePrefix, chainPrefix, propManager)
+	if !*allowNetworkDepot {
+		log.Fatal("network-filesystem-not-allowed", fmt.Errorf(
+			"%s path %s is on a network filesystem (%s); move it to local disk or pass -allowNetworkDepot", name, path, fsType,
+		))
 	}
 
-	backend := &gardener.Gardener{
-		UidGenerator:    wireUidGenerator(),
-		Starter:         wireStarter(logger, ipt, *allowHostAccess, interfacePrefix, denyNetworksList),
-		SysInfoProvider: sysinfo.NewProvider(*depotPath),
-		Networker:       networker,
-		VolumeCreator:   wireVolumeCreator(logger, *graphRoot, insecureRegistries),
-		Containerizer:   wireContainerizer(logger, *depotPath, *iodaemonBin, *nstarBin, *tarBin, resolvedRootFSPath),
-		PropertyManager: propManager,
+	log.Info("using-network-filesystem", lager.Data{"name": name, "path": path, "type": fsType})
+}
 
-		Logger: logger,
+// wireProcessDir returns the directory for the iodaemon sockets of processes.
+// Unix sockets cannot be connected to over a network filesystem, so when the
+// temp dir is on one the sockets are kept under /var/run instead.
+func wireProcessDir(log lager.Logger) string {
+	processDir := path.Join(os.TempDir(), fmt.Sprintf("garden-%s", *tag), "processes")
+	if fsType, isNetwork, err := sysinfo.NetworkFilesystem(processDir); err == nil && isNetwork {
+		localDir := path.Join("/var/run", fmt.Sprintf("garden-%s", *tag), "processes")
+		log.Info("process-dir-on-network-filesystem", lager.Data{"path": processDir, "type": fsType, "using": localDir})
+		return localDir
 	}
 
-	gardenServer := server.New(*listenNetwork, *listenAddr, *graceTime, backend, logger.Session("api"))
+	return processDir
+}
 
-	err = gardenServer.Start()
+func wireRuncVerifier(log lager.Logger, runcBin, digest string) (string, runrunc.BinaryVerifier) {
+	if digest == "" {
+		return runcBin, runrunc.NoopVerifier{}
+	}
+
+	runcPath, err := exec.LookPath(runcBin)
 	if err != nil {
-		logger.Fatal("failed-to-start-server", err)
+		log.Fatal("failed-to-find-runc", err)
 	}
 
-	signals := make(chan os.Signal, 1)
+	verifier := runrunc.NewChecksumVerifier(runcPath, digest)
+	if err := verifier.Verify(); err != nil {
+		log.Fatal("failed-to-verify-runc", err)
+	}
 
-	go func() {
-		<-signals
-		gardenServer.Stop()
-		os.Exit(0)
-	}()
+	if err := runrunc.Watch(log, verifier); err != nil {
+		log.Fatal("failed-to-watch-runc", err)
+	}
 
-	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP)
+	return runcPath, verifier
+}
 
-	logger.Info("started", lager.Data{
-		"network": *listenNetwork,
-		"addr":    *listenAddr,
-	})
+func wireDiskQuotas(logger lager.Logger, filesystem, mountPoint string) (rundmc.DiskQuotaEnforcer, accounting.ScratchUsager) {
+	if filesystem == "" {
+		return rundmc.NoDiskQuotas{}, nil
+	}
 
-	select {}
+	if mountPoint == "" {
+		missing("-diskQuotaMountPoint")
+	}
+
+	projectQuota := &quota.ProjectQuota{
+		Filesystem:    filesystem,
+		MountPoint:    mountPoint,
+		CommandRunner: &logging.Runner{CommandRunner: linux_command_runner.New(), Logger: logger.Session("quota-runner")},
+	}
+
+	manager, err := rundmc.NewDiskQuotaManager(projectQuota, filepath.Join(mountPoint, "scratch"), firstQuotaProject)
+	if err != nil {
+		logger.Fatal("failed-to-create-disk-quota-manager", err)
+	}
+
+	return manager, manager
 }
 
-func wireUidGenerator() gardener.UidGeneratorFunc {
-	return gardener.UidGeneratorFunc(func() string { return mustStringify(uuid.NewV4()) })
+func wireProcessLimiter(max uint) *gardener.ProcessLimiter {
+	if max == 0 {
+		return nil
+	}
+
+	return gardener.NewProcessLimiter(int(max))
 }
 
-func wireStarter(logger lager.Logger, ipt *iptables.IPTables, allowHostAccess bool, nicPrefix string, denyNetworks []string) gardener.Starter {
-	runner := &logging.Runner{CommandRunner: linux_command_runner.New(), Logger: logger.Session("runner")}
+func wireDestroyQueue(logger lager.Logger, maintenance *gardener.Maintenance, async bool) *gardener.DestroyQueue {
+	if !async {
+		return nil
+	}
 
-	return &StartAll{starters: []gardener.Starter{
-		rundmc.NewStarter(logger, mustOpen("/proc/cgroups"), path.Join(os.TempDir(), fmt.Sprintf("cgroups-%s", *tag)), runner),
-		iptables.NewStarter(ipt, allowHostAccess, nicPrefix, denyNetworks),
-	}}
+	if err := os.MkdirAll(filepath.Dir(*destroyQueueStateFile), 0755); err != nil {
+		logger.Fatal("failed-to-create-destroy-queue-state-directory", err)
+	}
+
+	return &gardener.DestroyQueue{
+		InitialBackoff: time.Second,
+		MaxBackoff:     *destroyRetryMaxBackoff,
+		MaxAttempts:    *destroyRetryMaxAttempts,
+		StatePath:      *destroyQueueStateFile,
+		Clock:          maintenance.Clock("destroy-queue", clock.NewClock()),
+		Logger:         logger.Session("destroy-queue"),
+	}
 }
 
-func wireIptables(logger lager.Logger, prefix string) *iptables.IPTables {
-	runner := &logging.Runner{CommandRunner: linux_command_runner.New(), Logger: logger.Session("iptables-runner")}
-	return iptables.New(runner, prefix)
+// wireOnlineCPUs reads the host's online CPUs and NUMA memory nodes. Ids which
+// cannot be read, e.g. the memory nodes of a kernel without NUMA support, are
+// left nil, and so are not checked.
+func wireOnlineCPUs(log lager.Logger) gardener.OnlineCPUs {
+	read := func(path string) []int {
+		online, err := ioutil.ReadFile(path)
+		if err != nil {
+			log.Info("failed-to-read-online-ids", lager.Data{"path": path, "error": err.Error()})
+			return nil
+		}
+
+		ids, err := gardener.ParseCPUList(string(online))
+		if err != nil {
+			log.Fatal("failed-to-parse-online-ids", err, lager.Data{"path": path})
+		}
+
+		return ids
+	}
+
+	return gardener.OnlineCPUs{
+		CPUs: read("/sys/devices/system/cpu/online"),
+		Mems: read("/sys/devices/system/node/online"),
+	}
 }
 
-func wireNetworker(
-	log lager.Logger,
-	kawasakiBin string,
-	tag string,
-	networkPoolCIDR *net.IPNet,
-	externalIP net.IP,
-	ipt *iptables.IPTables,
-	interfacePrefix string,
-	chainPrefix string,
-	propManager *properties.Manager,
-) gardener.Networker {
-	idGenerator := kawasaki.NewSequentialIDGenerator(time.Now().UnixNano())
-	portPool, err := ports.NewPool(uint32(*portPoolStart), uint32(*portPoolSize), ports.State{})
-	if err != nil {
-		log.Fatal("invalid pool range", err)
+func wireCPUSetAllocator(log lager.Logger, perContainer uint, cpus []int, lister gardener.HandleLister, propManager gardener.PropertyManager) *gardener.CPUSetAllocator {
+	if perContainer == 0 {
+		return nil
 	}
 
-	return kawasaki.New(
-		kawasakiBin,
-		kawasaki.SpecParserFunc(kawasaki.ParseSpec),
-		subnets.NewPool(networkPoolCIDR),
-		kawasaki.NewConfigCreator(idGenerator, interfacePrefix, chainPrefix, externalIP),
-		factory.NewDefaultConfigurer(ipt),
-		propManager,
-		portPool,
-		iptables.NewPortForwarder(ipt),
-		iptables.NewFirewallOpener(ipt),
-	)
+	if cpus == nil {
+		log.Fatal("failed-to-read-online-cpus", fmt.Errorf("the host's online cpus are unknown"))
+	}
+
+	if int(perContainer) > len(cpus) {
+		log.Fatal("invalid-cpuset-cpus-per-container", fmt.Errorf("the host has only %d online cpus", len(cpus)))
+	}
+
+	return &gardener.CPUSetAllocator{
+		CPUs:         cpus,
+		PerContainer: int(perContainer),
+		Lister:       lister,
+		Properties:   propManager,
+		Logger:       log.Session("cpuset-allocator"),
+	}
 }
 
-func wireVolumeCreator(logger lager.Logger, graphRoot string, insecureRegistries vars.StringList) *rootfs_provider.CakeOrdinator {
-	logger = logger.Session("volume-creator", lager.Data{"graphRoot": graphRoot})
-	runner := &logging.Runner{CommandRunner: linux_command_runner.New(), Logger: logger}
+func wireCreateQueue(registry *metrics.Registry, max uint) *gardener.CreateQueue {
+	if max == 0 {
+		return nil
+	}
+
+	queue := gardener.NewCreateQueue(int(max))
+	registry.NewGaugeFunc("guardian_container_creates", "Number of container creates, by whether they are waiting for or holding one of the --maxConcurrentCreates slots.", "state",
+		func() (map[string]float64, error) {
+			return map[string]float64{
+				"waiting": float64(queue.Waiting()),
+				"active":  float64(queue.Active()),
+			}, nil
+		})
+
+	return queue
+}
 
-	if err := os.MkdirAll(graphRoot, 0755); err != nil {
-		logger.Fatal("failed-to-create-graph-directory", err)
+func wireSeccompProfile(log lager.Logger, path string) *specs.Seccomp {
+	if path == "" {
+		return nil
 	}
 
-	dockerGraphDriver, err := graphdriver.New(graphRoot, nil)
+	profile, err := bundlerules.LoadSeccompProfile(path)
 	if err != nil {
-		logger.Fatal("failed-to-construct-graph-driver", err)
+		log.Fatal("failed-to-load-seccomp-profile", err)
 	}
 
-	backingStoresPath := filepath.Join(graphRoot, "backing_stores")
-	if err := os.MkdirAll(backingStoresPath, 0660); err != nil {
-		logger.Fatal("failed-to-mkdir-backing-stores", err)
+	return profile
+}
+
+func wireRuntimePlugin(log lager.Logger, runtimePath string, extraArgs []string, argsPath string) *runrunc.RuntimePlugin {
+	defaults := runrunc.RuncArgs
+	if windowsHost {
+		defaults = runrunc.WincArgs
 	}
 
-	quotaedGraphDriver := &quotaed_aufs.QuotaedDriver{
-		GraphDriver: dockerGraphDriver,
-		Unmount:     quotaed_aufs.Unmount,
-		BackingStoreMgr: &quotaed_aufs.BackingStore{
-			RootPath: backingStoresPath,
-			Logger:   logger.Session("backing-store-mgr"),
-		},
-		LoopMounter: &quotaed_aufs.Loop{
-			Retrier: retrier.New(retrier.ConstantBackoff(200, 500*time.Millisecond), nil),
-			Logger:  logger.Session("loop-mounter"),
-		},
-		Retrier:  retrier.New(retrier.ConstantBackoff(200, 500*time.Millisecond), nil),
-		RootPath: graphRoot,
-		Logger:   logger.Session("quotaed-driver"),
+	args := defaults
+	if argsPath != "" {
+		var err error
+		if args, err = runrunc.LoadRuntimeArgs(argsPath, defaults); err != nil {
+			log.Fatal("failed-to-load-runtime-plugin-args", err)
+		}
 	}
 
-	dockerGraph, err := graph.NewGraph(graphRoot, quotaedGraphDriver)
+	runtime, err := runrunc.NewRuntimePlugin(runtimePath, extraArgs, args)
 	if err != nil {
-		logger.Fatal("failed-to-construct-graph", err)
+		log.Fatal("invalid-runtime-plugin-args", err)
 	}
 
-	var cake layercake.Cake = &layercake.Docker{
-		Graph:  dockerGraph,
-		Driver: quotaedGraphDriver,
+	return runtime
+}
+
+func wireDevPolicy(log lager.Logger, policy string, devices bundlerules.Devices) bundlerules.DevPolicy {
+	if _, err := bundlerules.ParseDevPolicy(policy); err != nil {
+		log.Fatal("invalid-dev-policy", err)
 	}
 
-	if cake.DriverName() == "aufs" {
-		cake = &layercake.AufsCake{
-			Cake:      cake,
-			Runner:    runner,
-			GraphRoot: graphRoot,
+	return bundlerules.DevPolicy{Default: policy, AllowedDevices: devices.Allowed}
+}
+
+func wireOperatorHooks(log lager.Logger, paths []string) []string {
+	for _, path := range paths {
+		if !filepath.IsAbs(path) {
+			log.Fatal("invalid-hook", fmt.Errorf("hook path must be absolute: '%s'", path))
+		}
+
+		if _, err := os.Stat(path); err != nil {
+			log.Fatal("invalid-hook", err)
 		}
 	}
 
-	retainer := layercake.NewRetainer()
-	ovenCleaner := layercake.NewOvenCleaner(retainer, false)
+	return paths
+}
 
-	repoFetcher := &repository_fetcher.CompositeFetcher{
-		LocalFetcher: &repository_fetcher.Local{
-			Cake:              cake,
-			DefaultRootFSPath: *rootFSPath,
-			IDProvider:        repository_fetcher.LayerIDProvider{},
-		},
-		RemoteFetcher: repository_fetcher.NewRemote(
-			logger,
-			*dockerRegistry,
-			cake,
-			distclient.NewDialer(insecureRegistries.List),
-			repository_fetcher.VerifyFunc(repository_fetcher.Verify),
-		),
+func wireUmask(log lager.Logger, umask string) string {
+	if umask == "" {
+		return ""
 	}
 
-	rootFSNamespacer := &rootfs_provider.UidNamespacer{
-		Logger: logger,
-		Translator: rootfs_provider.NewUidTranslator(
-			idMappings, // uid
-			idMappings, // gid
-		),
+	parsed, err := runrunc.ParseUmask(umask)
+	if err != nil {
+		log.Fatal("invalid-default-umask", err)
 	}
 
-	layerCreator := rootfs_provider.NewLayerCreator(cake, rootfs_provider.SimpleVolumeCreator{}, rootFSNamespacer)
-	cakeOrdinator := rootfs_provider.NewCakeOrdinator(cake, repoFetcher, layerCreator, ovenCleaner)
-
-	return cakeOrdinator
+	return fmt.Sprintf("%04o", parsed)
 }
 
-func wireContainerizer(log lager.Logger, depotPath, iodaemonPath, nstarPath, tarPath, defaultRootFSPath string) *rundmc.Containerizer {
-	depot := depot.New(depotPath)
+func wireContainerizer(log lager.Logger, registry *metrics.Registry, maintenance *gardener.Maintenance, quotas rundmc.DiskQuotaEnforcer, capabilities *sysinfo.Capabilities, events rundmc.EventWatcher, publisher gardener.EventPublisher, runtimeExtraArgs, bundlePlugins, prestartHooks, poststopHooks []string, maskedPaths bundlerules.MaskedPaths, devices bundlerules.Devices, gpu bundlerules.NvidiaGPU, liveBindMountSources []string, depotPath, iodaemonPath, nstarPath, tarPath, defaultRootFSPath string) (*rundmc.Containerizer, gardener.CPULimiter, process_tracker.ProcessTracker) {
+	depot := wireDepot(depotPath)
 
 	startChecker := rundmc.StartChecker{Expect: "Pid 1 Running", Timeout: 15 * time.Second}
 
-	stateChecker := rundmc.StateChecker{StateFileDir: OciStateDir}
+	var stateChecker rundmc.ContainerStater = rundmc.StateChecker{StateFileDir: OciStateDir, ProcPath: "/proc"}
 
 	commandRunner := linux_command_runner.New()
 
 	execPreparer := runrunc.NewExecPreparer(&goci.BndlLoader{}, runrunc.LookupFunc(runrunc.LookupUser), runrunc.DirectoryCreator{})
 
+	runtimeBin := *runcBin
+	if *runtimePlugin != "" {
+		runtimeBin = *runtimePlugin
+	}
+
+	runcPath, verifier := wireRuncVerifier(log, runtimeBin, *runcSHA256)
+	runtime := wireRuntimePlugin(log, runcPath, runtimeExtraArgs, *runtimePluginArgs)
+	if windowsHost {
+		stateChecker = rundmc.RuntimeStateChecker{Runtime: runcPath, CommandRunner: runrunc.TimeoutRunner{CommandRunner: commandRunner, Timeout: *runcTimeout}}
+	}
+
+	processDir := wireProcessDir(log)
+	outputLimits := process_tracker.OutputLimits{
+		RateLimit:   *ioRateLimit,
+		MaxBytes:    *ioMaxBytes,
+		ReplayBytes: *attachReplayBytes,
+		Truncations: registry.NewCounter("guardian_process_output_truncations_total", "Number of processes whose output was truncated after --ioMaxBytes."),
+	}
+
+	if *logDrain != "" {
+		drain, err := logdrain.New(log, *logDrain, registry.NewCounter("guardian_log_drain_dropped_lines_total", "Number of lines of process output which could not be forwarded to --logDrain."))
+		if err != nil {
+			log.Fatal("invalid-log-drain", err)
+		}
+
+		outputLimits.Drain = drain
+	}
+
+	tracker := wireProcessTracker(processDir, iodaemonPath, commandRunner, outputLimits)
+
+	var runcLogDir string
+	if *forwardRuncLogs {
+		runcLogDir = filepath.Join(processDir, "runc-logs")
+		if err := os.MkdirAll(runcLogDir, 0700); err != nil {
+			log.Fatal("failed-to-create-runc-log-dir", err)
+		}
+	}
+
+	var prioritizer runrunc.Prioritizer = &runrunc.CgroupPrioritizer{CgroupPath: cgroupMountpoint(), Unified: unifiedCgroups()}
+	if windowsHost {
+		prioritizer = nil
+	}
+
 	runcrunner := runrunc.New(
-		process_tracker.New(path.Join(os.TempDir(), fmt.Sprintf("garden-%s", *tag), "processes"), iodaemonPath, commandRunner),
+		tracker,
 		commandRunner,
 		wireUidGenerator(),
-		goci.RuncBinary("runc"),
+		runtime,
+		verifier,
 		execPreparer,
+		prioritizer,
+		runcLogDir,
+		*runcTimeout,
+	)
+
+	checkpointer := runrunc.NewCheckpointer(
+		tracker,
+		commandRunner,
+		wireUidGenerator(),
+		runtime,
+		verifier,
 	)
 
+	// winc has no update command, so windows containers' memory and pid
+	// limits cannot be changed
+	var limiter gardener.CPULimiter = &rundmc.CgroupCPULimiter{CgroupPath: cgroupMountpoint(), Unified: unifiedCgroups()}
+	if !windowsHost {
+		limiter = &rundmc.RuntimeLimiter{
+			Updater:      runrunc.NewUpdater(runrunc.TimeoutRunner{CommandRunner: commandRunner, Timeout: *runcTimeout}, runtime, verifier),
+			Depot:        depot,
+			BundleLoader: &goci.BndlLoader{},
+			CgroupPath:   cgroupMountpoint(),
+			Unified:      unifiedCgroups(),
+		}
+	}
+
+	// the scratch space is mounted over /tmp, which would hide the init
+	// binary, so it must be mounted elsewhere when disk quotas are enabled
+	initPath := "/tmp/garden-init"
+	if _, ok := quotas.(*rundmc.DiskQuotaManager); ok {
+		initPath = "/garden-init"
+	}
+
 	mounts := []specs.Mount{
 		specs.Mount{Type: "proc", Source: "proc", Destination: "/proc"},
 		specs.Mount{Type: "tmpfs", Source: "tmpfs", Destination: "/dev/shm"},
 		specs.Mount{Type: "devpts", Source: "devpts", Destination: "/dev/pts",
 			Options: []string{"nosuid", "noexec", "newinstance", "ptmxmode=0666", "mode=0620"}},
-		specs.Mount{Type: "bind", Source: *initBin, Destination: "/tmp/garden-init", Options: []string{"bind"}},
+		specs.Mount{Type: "bind", Source: *initBin, Destination: initPath, Options: []string{"bind"}},
 	}
 
 	rwm := "rwm"
@@ -528,38 +2615,204 @@ func wireContainerizer(log lager.Logger, depotPath, iodaemonPath, nstarPath, tar
 		WithMounts(mounts...).
 		WithRootFS(defaultRootFSPath)
 
+	// live bind mounts are made on the host, so only reach containers whose
+	// roots are slaves of the host's mounts of their rootfses
+	baseBundle.Spec.Linux.RootfsPropagation = rundmc.RootfsPropagation
+
 	unprivilegedBundle := baseBundle.
 		WithNamespace(goci.UserNamespace).
-		WithUIDMappings(idMappings...).
-		WithGIDMappings(idMappings...)
+		WithUIDMappings(uidMappings...).
+		WithGIDMappings(gidMappings...)
 
 	template := &rundmc.BundleTemplate{
 		Rules: []rundmc.BundlerRule{
 			bundlerules.Base{
 				PrivilegedBase:   baseBundle,
 				UnprivilegedBase: unprivilegedBundle,
+				Capabilities:     capabilities,
 			},
+			wireDevPolicy(log, *devPolicy, devices),
 			bundlerules.RootFS{
-				ContainerRootUID: idMappings.Map(0),
-				ContainerRootGID: idMappings.Map(0),
+				ContainerRootUID: uidMappings.Map(0),
+				ContainerRootGID: gidMappings.Map(0),
 				MkdirChowner:     bundlerules.MkdirChownFunc(bundlerules.MkdirChown),
 			},
-			bundlerules.Limits{},
-			bundlerules.Hooks{LogFilePattern: filepath.Join(depotPath, "%s", "network.log")},
+			bundlerules.UserNamespace{},
+			bundlerules.Limits{Capabilities: capabilities},
+			bundlerules.Pids{Default: *maxPidsPerContainer, Capabilities: capabilities},
+			bundlerules.Hooks{LogFilePattern: filepath.Join(depotPath, "%s", "network.log"), Prestart: prestartHooks, Poststop: poststopHooks},
 			bundlerules.BindMounts{},
+			bundlerules.Seccomp{Default: wireSeccompProfile(log, *seccompProfile), Capabilities: capabilities},
 			bundlerules.InitProcess{
 				Process: specs.Process{
-					Args: []string{"/tmp/garden-init"},
+					Args: []string{initPath},
 					Cwd:  "/",
 				},
 			},
+			maskedPaths,
+			devices,
+			gpu,
+			bundlerules.ContainerRunner{},
+			bundlerules.CgroupsPath{Parent: rootlessCgroupParent()},
 		},
 	}
 
+	for _, plugin := range bundlePlugins {
+		template.Rules = append(template.Rules, bundlerules.Plugin{
+			Path: plugin,
+			CommandRunner: &logging.Runner{
+				CommandRunner: cmdtimeout.Runner{CommandRunner: linux_command_runner.New(), Timeout: *bundlePluginTimeout},
+				Logger:        log.Session("bundle-plugin"),
+			},
+		})
+	}
+
+	if windowsHost {
+		template = wireWindowsBundleTemplate(capabilities, defaultRootFSPath)
+	}
+
 	nstar := rundmc.NewNstarRunner(nstarPath, tarPath, linux_command_runner.New())
 
+	if *bundleDriftCheckInterval > 0 {
+		detector := rundmc.NewDriftDetector(log.Session("drift-detector"), depot, runcrunner, maintenance.Clock("drift-detector", clock.NewClock()), *bundleDriftCheckInterval, *quarantineDriftedBundles)
+		if err := detector.Start(); err != nil {
+			log.Fatal("failed-to-start-drift-detector", err)
+		}
+	}
+
 	stateCheckRetrier := retrier.New(retrier.ConstantBackoff(10, 100*time.Millisecond), nil)
-	return rundmc.New(depot, template, runcrunner, startChecker, stateChecker, nstar, stateCheckRetrier)
+	restartingRunner := &rundmc.RestartingRunner{
+		BundleRunner:   metrics.NewBundleRunner(registry, runcrunner),
+		InitialBackoff: time.Second,
+		MaxBackoff:     *maxRestartBackoff,
+		Clock:          clock.NewClock(),
+		Publisher:      publisher,
+	}
+
+	var peas rundmc.PeaCreator
+	var bindMounter rundmc.BindMounter
+	if !windowsHost {
+		peas = &rundmc.Peas{
+			BundleLoader: &goci.BndlLoader{},
+			Runner:       runcrunner,
+			Users:        runrunc.LookupFunc(runrunc.LookupUser),
+			Stater:       stateChecker,
+		}
+
+		bindMounter = &rundmc.LiveBindMounter{
+			BundleLoader:   &goci.BndlLoader{},
+			Mounter:        rundmc.SyscallMounter{},
+			AllowedSources: liveBindMountSources,
+		}
+	}
+
+	return rundmc.New(depot, template, restartingRunner, startChecker, stateChecker, nstar, stateCheckRetrier, quotas, checkpointer, events, peas, bindMounter), limiter, tracker
+}
+
+// bundleDepot is the depot of container bundles, which the drift detector
+// also verifies
+type bundleDepot interface {
+	rundmc.Depot
+	rundmc.BundleVerifier
+}
+
+// wireRuntimeInspector returns nil on windows, where containers have no proc
+// entries to inspect
+func wireRuntimeInspector(depotPath string, tracker process_tracker.ProcessTracker) gardener.RuntimeInspector {
+	if windowsHost {
+		return nil
+	}
+
+	inspector := &rundmc.RuntimeInspector{
+		Depot:        wireDepot(depotPath),
+		Stater:       rundmc.StateChecker{StateFileDir: OciStateDir, ProcPath: "/proc"},
+		BundleLoader: &goci.BndlLoader{},
+		RawStater:    rundmc.StateChecker{StateFileDir: OciStateDir},
+		ProcPath:     "/proc",
+	}
+
+	if iodaemons, ok := tracker.(rundmc.IODaemonPidLister); ok {
+		inspector.IODaemons = iodaemons
+	}
+
+	return inspector
+}
+
+func wireDepot(depotPath string) bundleDepot {
+	if windowsHost {
+		return depot.NewWindows(depotPath)
+	}
+
+	return depot.New(depotPath)
+}
+
+func wireMaskedPaths(masked, readonly []string) bundlerules.MaskedPaths {
+	if len(masked) == 0 {
+		masked = bundlerules.DefaultMaskedPaths
+	}
+
+	if len(readonly) == 0 {
+		readonly = bundlerules.DefaultReadonlyPaths
+	}
+
+	return bundlerules.MaskedPaths{Masked: masked, Readonly: readonly}
+}
+
+func wireDevices(logger lager.Logger, paths []string) bundlerules.Devices {
+	allowed := make(map[string]specs.Device)
+	for _, path := range paths {
+		device, err := bundlerules.LookupDevice(filepath.Clean(path))
+		if err != nil {
+			logger.Fatal("failed-to-lookup-allowed-device", err, lager.Data{"path": path})
+		}
+
+		allowed[device.Path] = device
+	}
+
+	return bundlerules.Devices{Allowed: allowed}
+}
+
+func wireNvidiaGPU(logger lager.Logger) bundlerules.NvidiaGPU {
+	if !*enableNvidiaGPUs {
+		return bundlerules.NvidiaGPU{}
+	}
+
+	if *nvidiaContainerHook != "" {
+		return bundlerules.NvidiaGPU{Hook: *nvidiaContainerHook}
+	}
+
+	gpu, err := bundlerules.DetectNvidiaGPU("/dev", linux_command_runner.New())
+	if err != nil {
+		logger.Fatal("failed-to-detect-nvidia-gpus", err)
+	}
+
+	logger.Info("detected-nvidia-gpus", lager.Data{"devices": len(gpu.Devices), "libraries": len(gpu.Libraries), "binaries": gpu.Binaries})
+	return gpu
+}
+
+// wireWindowsBundleTemplate generates bundles for winc, which only uses their
+// rootfs, process, bind mounts and limits. The init binary must be a Windows
+// build of cmd/init; its directory is mounted into the container.
+func wireWindowsBundleTemplate(capabilities *sysinfo.Capabilities, defaultRootFSPath string) *rundmc.BundleTemplate {
+	initDir := `C:\garden-init`
+	base := goci.Bundle().
+		WithMounts(specs.Mount{Type: "bind", Source: filepath.Dir(*initBin), Destination: initDir, Options: []string{"bind", "ro"}}).
+		WithRootFS(defaultRootFSPath)
+
+	return &rundmc.BundleTemplate{
+		Rules: []rundmc.BundlerRule{
+			bundlerules.Base{PrivilegedBase: base, UnprivilegedBase: base, Capabilities: capabilities},
+			bundlerules.VolumeRootFS{},
+			bundlerules.Limits{Capabilities: capabilities},
+			bundlerules.BindMounts{},
+			bundlerules.InitProcess{
+				Process: specs.Process{
+					Args: []string{initDir + `\` + filepath.Base(*initBin)},
+					Cwd:  `C:\`,
+				},
+			},
+		},
+	}
 }
 
 func missing(flagName string) {
@@ -570,6 +2823,24 @@ func missing(flagName string) {
 	os.Exit(1)
 }
 
+func parseDNSConfig(servers []string) (kawasaki.DNSConfig, error) {
+	dnsConfig := kawasaki.DNSConfig{
+		ResolvConfTemplate: *dnsResolvConfTemplate,
+		Forwarder:          *dnsForwarderBin != "",
+	}
+
+	for _, server := range servers {
+		ip := net.ParseIP(server)
+		if ip == nil {
+			return kawasaki.DNSConfig{}, fmt.Errorf("invalid IP address: %s", server)
+		}
+
+		dnsConfig.Nameservers = append(dnsConfig.Nameservers, ip)
+	}
+
+	return dnsConfig, nil
+}
+
 func parseExternalIP(ip string) (net.IP, error) {
 	if *externalIP == "" {
 		localIP, err := localip.LocalIP()
@@ -602,6 +2873,109 @@ func mustOpen(path string) io.ReadCloser {
 	}
 }
 
+// wireAPIProxy listens on listenAddr for the garden server, when the server
+// cannot listen there itself: when it serves TLS or governs its clients'
+// connections. It returns nil otherwise.
+func wireAPIProxy(logger lager.Logger, registry *metrics.Registry) *APIProxy {
+	useTLS := tlsConfigured()
+	governed := *maxConnectionsPerClient > 0 || *connectionKeepAlive > 0 || *connectionIdleTimeout > 0
+	if !useTLS && !governed {
+		return nil
+	}
+
+	if useTLS && *listenNetwork != "tcp" {
+		logger.Fatal("invalid-tls-config", fmt.Errorf("TLS requires listenNetwork to be tcp, not '%s'", *listenNetwork))
+	}
+
+	if *listenNetwork == "unix" {
+		os.Remove(*listenAddr)
+	}
+
+	listener, err := net.Listen(*listenNetwork, *listenAddr)
+	if err != nil {
+		logger.Fatal("failed-to-listen", err)
+	}
+
+	if *listenNetwork == "unix" {
+		if err := os.Chmod(*listenAddr, 0777); err != nil {
+			logger.Fatal("failed-to-listen", err)
+		}
+	}
+
+	if governed {
+		limited := &connlimit.Listener{
+			Listener:     listener,
+			MaxPerClient: *maxConnectionsPerClient,
+			KeepAlive:    *connectionKeepAlive,
+			IdleTimeout:  *connectionIdleTimeout,
+			Rejected:     registry.NewCounter("guardian_api_connections_rejected_total", "Number of garden API connections closed because their client had maxConnectionsPerClient open."),
+			TimedOut:     registry.NewCounter("guardian_api_connections_idle_closed_total", "Number of garden API connections closed after connectionIdleTimeout without traffic."),
+			Logger:       logger.Session("api-connections"),
+		}
+
+		registry.NewGaugeFunc("guardian_api_connections", "Number of open garden API connections, by client IP address (or 'unix').", "client", func() (map[string]float64, error) {
+			return limited.Open(), nil
+		})
+
+		listener = limited
+	}
+
+	if useTLS {
+		tlsConfig, err := wireTLSConfig(*tlsCert, *tlsKey, *tlsCA)
+		if err != nil {
+			logger.Fatal("invalid-tls-config", err)
+		}
+
+		listener = tls.NewListener(listener, tlsConfig)
+	}
+
+	proxy, err := NewAPIProxy(logger, listener)
+	if err != nil {
+		logger.Fatal("failed-to-create-api-proxy", err)
+	}
+
+	return proxy
+}
+
+func tlsConfigured() bool {
+	return *tlsCert != "" || *tlsKey != "" || *tlsCA != ""
+}
+
+// wireAdditionalServers creates a garden server for each of the listeners
+// given by the named flag. The backend must not start or stop the shared
+// backend, which the main server does. If there is an authorizer, each
+// listener's requests are authorized as coming from that listener. When the
+// API is served over TLS the listeners must be unix sockets, as only the
+// main listener requires client certificates.
+func wireAdditionalServers(logger lager.Logger, flagName string, listeners []string, authorizer gardener.Authorizer, backend garden.Backend) []*server.GardenServer {
+	var servers []*server.GardenServer
+	for _, listener := range listeners {
+		parts := strings.SplitN(listener, ":", 2)
+		if len(parts) != 2 || (parts[0] != "unix" && parts[0] != "tcp") || parts[1] == "" {
+			logger.Fatal("invalid-additional-listener", fmt.Errorf("%s must be 'unix:<path>' or 'tcp:<host>:<port>', got '%s'", flagName, listener))
+		}
+
+		if parts[0] == *listenNetwork && parts[1] == *listenAddr {
+			logger.Fatal("invalid-additional-listener", fmt.Errorf("%s '%s' is the same as listenNetwork and listenAddr", flagName, listener))
+		}
+
+		if parts[0] == "tcp" && tlsConfigured() {
+			logger.Fatal("invalid-additional-listener", fmt.Errorf("%s '%s' would serve the API without TLS; only unix listeners can be used with tlsCert", flagName, listener))
+		}
+
+		servers = append(servers, server.New(parts[0], parts[1], 0, wireAuthorizingBackend(logger, authorizer, listener, backend), logger.Session("api", lager.Data{"listener": listener})))
+	}
+
+	return servers
+}
+
+type sharedBackend struct {
+	garden.Backend
+}
+
+func (sharedBackend) Start() error { return nil }
+func (sharedBackend) Stop()        {}
+
 type StartAll struct {
 	starters []gardener.Starter
 }
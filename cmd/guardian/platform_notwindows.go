@@ -0,0 +1,27 @@
+// +build !windows
+
+package main
+
+import (
+	"github.com/cloudfoundry-incubator/guardian/accounting"
+	"github.com/cloudfoundry-incubator/guardian/kawasaki/devices"
+	"github.com/cloudfoundry-incubator/guardian/rundmc/process_tracker"
+	"github.com/cloudfoundry/gunk/command_runner"
+)
+
+// windowsHost is whether guardian runs Windows containers, with winc
+const windowsHost = false
+
+// wireProcessTracker runs each process under an iodaemon, from the
+// container's iodaemon pool with -iodaemonPool
+func wireProcessTracker(processDir, iodaemonPath string, runner command_runner.CommandRunner, limits process_tracker.OutputLimits) process_tracker.ProcessTracker {
+	if *iodaemonPool {
+		return process_tracker.NewPooled(processDir, iodaemonPath, runner, limits)
+	}
+
+	return process_tracker.NewWithOutputLimits(processDir, iodaemonPath, runner, limits)
+}
+
+func wireNetworkStatter() accounting.NetworkStatter {
+	return devices.Link{}
+}
@@ -0,0 +1,22 @@
+package main
+
+import (
+	"github.com/cloudfoundry-incubator/guardian/accounting"
+	"github.com/cloudfoundry-incubator/guardian/rundmc/process_tracker"
+	"github.com/cloudfoundry/gunk/command_runner"
+)
+
+// windowsHost is whether guardian runs Windows containers, with winc
+const windowsHost = true
+
+// wireProcessTracker runs processes as guardian's own children, as iodaemon
+// cannot run on Windows
+func wireProcessTracker(processDir, iodaemonPath string, runner command_runner.CommandRunner, limits process_tracker.OutputLimits) process_tracker.ProcessTracker {
+	return process_tracker.NewDirect(runner)
+}
+
+// wireNetworkStatter returns nil, as containers share the host's network
+// compartment and so have no interface of their own to sample
+func wireNetworkStatter() accounting.NetworkStatter {
+	return nil
+}
@@ -0,0 +1,157 @@
+package main
+
+import (
+	"crypto/tls"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/cloudfoundry-incubator/garden/server"
+	"github.com/cloudfoundry-incubator/guardian/gardener"
+	"github.com/pivotal-golang/lager"
+)
+
+// APIProxy serves the garden API on a listener the garden server cannot
+// listen on itself, i.e. one which serves TLS or governs the connections of
+// clients (see connlimit). The garden server listens on a unix socket in a
+// directory only the server's user can reach, and the proxy forwards each
+// connection to it. Connections are forwarded byte for byte, so hijacked
+// process streams work as they do without the proxy.
+type APIProxy struct {
+	Listener net.Listener
+	Logger   lager.Logger
+
+	// ServeCaller, if it is set, starts a garden server on socketPath which
+	// serves the requests of caller. The connections of each client
+	// certificate are then forwarded to a server of their own, started on
+	// the certificate's first connection, so that their requests are
+	// authorized as coming from the certificate's subject rather than from
+	// the listener.
+	ServeCaller func(caller, socketPath string) (*server.GardenServer, error)
+
+	socketDir string
+
+	callersMu sync.Mutex
+	callers   map[string]string
+	servers   []*server.GardenServer
+}
+
+func NewAPIProxy(logger lager.Logger, listener net.Listener) (*APIProxy, error) {
+	socketDir, err := ioutil.TempDir("", "garden-api")
+	if err != nil {
+		listener.Close()
+		return nil, err
+	}
+
+	return &APIProxy{
+		Listener:  listener,
+		Logger:    logger.Session("api-proxy", lager.Data{"addr": listener.Addr().String()}),
+		socketDir: socketDir,
+		callers:   map[string]string{},
+	}, nil
+}
+
+// SocketPath is where the garden server should listen
+func (p *APIProxy) SocketPath() string {
+	return filepath.Join(p.socketDir, "garden.sock")
+}
+
+func (p *APIProxy) Serve() {
+	for {
+		conn, err := p.Listener.Accept()
+		if err != nil {
+			if netErr, ok := err.(net.Error); ok && netErr.Temporary() {
+				continue
+			}
+
+			p.Logger.Info("stopped", lager.Data{"reason": err.Error()})
+			return
+		}
+
+		go p.forward(conn)
+	}
+}
+
+func (p *APIProxy) Stop() {
+	p.Listener.Close()
+
+	p.callersMu.Lock()
+	for _, callerServer := range p.servers {
+		callerServer.Stop()
+	}
+	p.callersMu.Unlock()
+
+	os.RemoveAll(p.socketDir)
+}
+
+// callerSocketPath returns where the garden server serving caller listens,
+// starting it if it has not been
+func (p *APIProxy) callerSocketPath(caller string) (string, error) {
+	p.callersMu.Lock()
+	defer p.callersMu.Unlock()
+
+	if socketPath, ok := p.callers[caller]; ok {
+		return socketPath, nil
+	}
+
+	socketPath := filepath.Join(p.socketDir, fmt.Sprintf("caller-%d.sock", len(p.callers)))
+	callerServer, err := p.ServeCaller(caller, socketPath)
+	if err != nil {
+		return "", err
+	}
+
+	p.callers[caller] = socketPath
+	p.servers = append(p.servers, callerServer)
+	return socketPath, nil
+}
+
+func (p *APIProxy) forward(conn net.Conn) {
+	defer conn.Close()
+
+	socketPath := p.SocketPath()
+	if tlsConn, ok := conn.(*tls.Conn); ok {
+		if err := tlsConn.Handshake(); err != nil {
+			p.Logger.Info("handshake-failed", lager.Data{"remote": conn.RemoteAddr().String(), "error": err.Error()})
+			return
+		}
+
+		if p.ServeCaller != nil {
+			state := tlsConn.ConnectionState()
+			caller := gardener.RequestCaller(&state, "")
+			if caller == "" {
+				p.Logger.Info("no-client-certificate", lager.Data{"remote": conn.RemoteAddr().String()})
+				return
+			}
+
+			var err error
+			if socketPath, err = p.callerSocketPath(caller); err != nil {
+				p.Logger.Error("serve-caller-failed", err, lager.Data{"caller": caller})
+				return
+			}
+		}
+	}
+
+	backend, err := net.Dial("unix", socketPath)
+	if err != nil {
+		p.Logger.Error("dial-garden-failed", err)
+		return
+	}
+	defer backend.Close()
+
+	done := make(chan struct{}, 2)
+	go func() {
+		io.Copy(backend, conn)
+		done <- struct{}{}
+	}()
+
+	go func() {
+		io.Copy(conn, backend)
+		done <- struct{}{}
+	}()
+
+	<-done
+}
@@ -0,0 +1,113 @@
+// +build !windows
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/cloudfoundry-incubator/garden-shed/distclient"
+	quotaed_aufs "github.com/cloudfoundry-incubator/garden-shed/docker_drivers/aufs"
+	"github.com/cloudfoundry-incubator/garden-shed/layercake"
+	"github.com/cloudfoundry-incubator/garden-shed/repository_fetcher"
+	"github.com/cloudfoundry-incubator/garden-shed/rootfs_provider"
+	"github.com/cloudfoundry-incubator/guardian/gardener"
+	"github.com/cloudfoundry-incubator/guardian/imageplugin"
+	"github.com/cloudfoundry-incubator/guardian/logging"
+	"github.com/cloudfoundry-incubator/guardian/pkg/vars"
+	"github.com/cloudfoundry/gunk/command_runner/linux_command_runner"
+	"github.com/docker/docker/daemon/graphdriver"
+	_ "github.com/docker/docker/daemon/graphdriver/aufs"
+	"github.com/docker/docker/graph"
+	_ "github.com/docker/docker/pkg/chrootarchive" // allow reexec of docker-applyLayer
+	"github.com/docker/docker/pkg/reexec"
+	"github.com/eapache/go-resiliency/retrier"
+	"github.com/pivotal-golang/lager"
+)
+
+// reexecInit runs docker's applyLayer, when guardian has been re-executed
+// as it
+func reexecInit() bool {
+	return reexec.Init()
+}
+
+func wireVolumeCreator(logger lager.Logger, graphRoot string, insecureRegistries vars.StringList) gardener.VolumeCreator {
+	logger = logger.Session("volume-creator", lager.Data{"graphRoot": graphRoot})
+	runner := &logging.Runner{CommandRunner: linux_command_runner.New(), Logger: logger}
+
+	if err := os.MkdirAll(graphRoot, 0755); err != nil {
+		logger.Fatal("failed-to-create-graph-directory", err)
+	}
+
+	dockerGraphDriver, err := graphdriver.New(graphRoot, nil)
+	if err != nil {
+		logger.Fatal("failed-to-construct-graph-driver", err)
+	}
+
+	backingStoresPath := filepath.Join(graphRoot, "backing_stores")
+	if err := os.MkdirAll(backingStoresPath, 0660); err != nil {
+		logger.Fatal("failed-to-mkdir-backing-stores", err)
+	}
+
+	quotaedGraphDriver := &quotaed_aufs.QuotaedDriver{
+		GraphDriver: dockerGraphDriver,
+		Unmount:     quotaed_aufs.Unmount,
+		BackingStoreMgr: &quotaed_aufs.BackingStore{
+			RootPath: backingStoresPath,
+			Logger:   logger.Session("backing-store-mgr"),
+		},
+		LoopMounter: &quotaed_aufs.Loop{
+			Retrier: retrier.New(retrier.ConstantBackoff(200, 500*time.Millisecond), nil),
+			Logger:  logger.Session("loop-mounter"),
+		},
+		Retrier:  retrier.New(retrier.ConstantBackoff(200, 500*time.Millisecond), nil),
+		RootPath: graphRoot,
+		Logger:   logger.Session("quotaed-driver"),
+	}
+
+	dockerGraph, err := graph.NewGraph(graphRoot, quotaedGraphDriver)
+	if err != nil {
+		logger.Fatal("failed-to-construct-graph", err)
+	}
+
+	var cake layercake.Cake = &layercake.Docker{
+		Graph:  dockerGraph,
+		Driver: quotaedGraphDriver,
+	}
+
+	if cake.DriverName() == "aufs" {
+		cake = &layercake.AufsCake{
+			Cake:      cake,
+			Runner:    runner,
+			GraphRoot: graphRoot,
+		}
+	}
+
+	retainer := layercake.NewRetainer()
+	ovenCleaner := layercake.NewOvenCleaner(retainer, false)
+
+	repoFetcher := &repository_fetcher.CompositeFetcher{
+		LocalFetcher: &repository_fetcher.Local{
+			Cake:              cake,
+			DefaultRootFSPath: *rootFSPath,
+			IDProvider:        repository_fetcher.LayerIDProvider{},
+		},
+		RemoteFetcher: repository_fetcher.NewRemote(
+			logger,
+			*dockerRegistry,
+			cake,
+			distclient.NewDialer(insecureRegistries.List),
+			repository_fetcher.VerifyFunc(repository_fetcher.Verify),
+		),
+	}
+
+	layerCreator := &imageplugin.MappingLayerCreator{
+		Cake:    cake,
+		Default: gardener.IDMappings{UID: uidMappings, GID: gidMappings},
+		Logger:  logger,
+	}
+	cakeOrdinator := rootfs_provider.NewCakeOrdinator(cake, repoFetcher, layerCreator, ovenCleaner)
+
+	return &imageplugin.ShedVolumeCreator{CakeOrdinator: cakeOrdinator, Layers: layerCreator}
+}
@@ -0,0 +1,20 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/cloudfoundry-incubator/guardian/gardener"
+	"github.com/cloudfoundry-incubator/guardian/pkg/vars"
+	"github.com/pivotal-golang/lager"
+)
+
+func reexecInit() bool {
+	return false
+}
+
+// wireVolumeCreator refuses to start, as the graph drivers only run on
+// Linux, so Windows rootfses must be created by an image plugin
+func wireVolumeCreator(logger lager.Logger, graphRoot string, insecureRegistries vars.StringList) gardener.VolumeCreator {
+	logger.Fatal("invalid-image-plugin", fmt.Errorf("windows rootfses can only be created by an image plugin, e.g. winc-image, given with -imagePlugin"))
+	return nil
+}
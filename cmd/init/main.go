@@ -13,6 +13,8 @@ func main() {
 	signals := make(chan os.Signal)
 	signal.Notify(signals, syscall.SIGTERM)
 
+	reapOrphans()
+
 	for {
 		<-signals
 	}
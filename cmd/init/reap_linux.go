@@ -0,0 +1,12 @@
+package main
+
+import (
+	"time"
+
+	"github.com/cloudfoundry-incubator/guardian/rundmc/reaper"
+)
+
+// as pid 1, init inherits every process orphaned in the container
+func reapOrphans() {
+	go (&reaper.Reaper{Interval: time.Second}).Run(nil)
+}
@@ -0,0 +1,7 @@
+// +build !linux
+
+package main
+
+// orphaned processes are not re-parented to init outside Linux, e.g. in
+// Windows containers
+func reapOrphans() {}
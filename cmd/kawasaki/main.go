@@ -10,10 +10,12 @@ import (
 	"github.com/cloudfoundry-incubator/cf-debug-server"
 	"github.com/cloudfoundry-incubator/cf-lager"
 	"github.com/cloudfoundry-incubator/goci"
+	"github.com/cloudfoundry-incubator/guardian/gardener"
 	"github.com/cloudfoundry-incubator/guardian/kawasaki"
 	"github.com/cloudfoundry-incubator/guardian/kawasaki/dns"
 	"github.com/cloudfoundry-incubator/guardian/kawasaki/factory"
 	"github.com/cloudfoundry-incubator/guardian/kawasaki/iptables"
+	"github.com/cloudfoundry-incubator/guardian/pkg/vars"
 	"github.com/cloudfoundry/gunk/command_runner/linux_command_runner"
 	"github.com/opencontainers/specs"
 	"github.com/pivotal-golang/lager"
@@ -51,10 +53,25 @@ func main() {
 	flag.StringVar(&config.IPTablePrefix, "iptable-prefix", "", "the iptable chain prefix")
 	flag.StringVar(&config.IPTableInstance, "iptable-instance", "", "the iptable instance to add rules to")
 	flag.IntVar(&config.Mtu, "mtu", 1500, "the mtu")
+	flag.BoolVar(&config.LogDenied, "log-denied", false, "log new outbound connections which none of the container's net-out rules allow")
 	flag.Var(&IPValue{&config.BridgeIP}, "bridge-ip", "the IP address of the bridge interface")
 	flag.Var(&IPValue{&config.ExternalIP}, "external-ip", "the IP address of the host interface")
 	flag.Var(&IPValue{&config.ContainerIP}, "container-ip", "the IP address of the container interface")
 	subnet := flag.String("subnet", "", "subnet of the bridge")
+	flag.Var(&IPValue{&config.BridgeIPv6}, "bridge-ipv6", "the IPv6 address of the bridge interface")
+	flag.Var(&IPValue{&config.ContainerIPv6}, "container-ipv6", "the IPv6 address of the container interface")
+	subnetV6 := flag.String("subnet-v6", "", "IPv6 subnet of the bridge")
+
+	var routes vars.StringList
+	flag.Var(&routes, "route", "static route for the container, a CIDR optionally followed by 'via' and the next hop (can be specified multiple times)")
+
+	var dnsConfig kawasaki.DNSConfig
+	var nameservers, searchDomains, additionalHosts vars.StringList
+	flag.StringVar(&dnsConfig.ResolvConfTemplate, "resolv-conf-template", "", "template for the container's resolv.conf")
+	flag.Var(&nameservers, "nameserver", "nameserver to use instead of the host's (can be specified multiple times)")
+	flag.BoolVar(&dnsConfig.Forwarder, "dns-forwarder", false, "a DNS forwarder listens on the bridge IP")
+	flag.Var(&searchDomains, "search-domain", "search domain for the container's resolv.conf (can be specified multiple times)")
+	flag.Var(&additionalHosts, "additional-host", "additional line for the container's /etc/hosts (can be specified multiple times)")
 	flag.Parse()
 
 	_, config.Subnet, err = net.ParseCIDR(*subnet)
@@ -62,6 +79,31 @@ func main() {
 		panic(err)
 	}
 
+	if *subnetV6 != "" {
+		_, config.SubnetV6, err = net.ParseCIDR(*subnetV6)
+		if err != nil {
+			panic(err)
+		}
+	}
+
+	for _, raw := range routes.List {
+		route, err := gardener.ParseRoute(raw)
+		if err != nil {
+			panic(fmt.Errorf("invalid route: %s", err))
+		}
+
+		config.Routes = append(config.Routes, route)
+	}
+
+	for _, nameserver := range nameservers.List {
+		ip := net.ParseIP(nameserver)
+		if ip == nil {
+			panic(fmt.Errorf("invalid nameserver: %s", nameserver))
+		}
+
+		dnsConfig.Nameservers = append(dnsConfig.Nameservers, ip)
+	}
+
 	logger = logger.Session("hook", lager.Data{
 		"config": config,
 		"pid":    state.Pid,
@@ -69,12 +111,12 @@ func main() {
 
 	logger.Info("start")
 
-	configurer := factory.NewDefaultConfigurer(iptables.New(linux_command_runner.New(), config.IPTablePrefix))
+	configurer := factory.NewDefaultConfigurer(iptables.New(linux_command_runner.New(), config.IPTablePrefix), config.LogDenied)
 	if err := configurer.Apply(logger, config, fmt.Sprintf("/proc/%d/ns/net", state.Pid)); err != nil {
 		panic(err)
 	}
 
-	dnsResolvConfigurer := wireDNSResolvConfigurer(state, config)
+	dnsResolvConfigurer := wireDNSResolvConfigurer(state, config, dnsConfig, searchDomains.List, additionalHosts.List)
 	if err := dnsResolvConfigurer.Configure(logger); err != nil {
 		panic(err)
 	}
@@ -100,7 +142,7 @@ func extractRootIds(bndl *goci.Bndl) (int, int) {
 	return rootUid, rootGid
 }
 
-func wireDNSResolvConfigurer(state specs.State, config kawasaki.NetworkConfig) *dns.ResolvConfigurer {
+func wireDNSResolvConfigurer(state specs.State, config kawasaki.NetworkConfig, dnsConfig kawasaki.DNSConfig, searchDomains, additionalHosts []string) *dns.ResolvConfigurer {
 	bundleLoader := &goci.BndlLoader{}
 	bndl, err := bundleLoader.Load(state.BundlePath)
 	if err != nil {
@@ -111,12 +153,17 @@ func wireDNSResolvConfigurer(state specs.State, config kawasaki.NetworkConfig) *
 
 	configurer := &dns.ResolvConfigurer{
 		HostsFileCompiler: &dns.HostsFileCompiler{
-			Handle: state.ID,
-			IP:     config.ContainerIP,
+			Handle:          state.ID,
+			IP:              config.ContainerIP,
+			AdditionalHosts: additionalHosts,
 		},
 		ResolvFileCompiler: &dns.ResolvFileCompiler{
 			HostResolvConfPath: "/etc/resolv.conf",
 			HostIP:             config.BridgeIP,
+			TemplatePath:       dnsConfig.ResolvConfTemplate,
+			Nameservers:        dnsConfig.Nameservers,
+			SearchDomains:      searchDomains,
+			Forwarder:          dnsConfig.Forwarder,
 		},
 		FileWriter: &dns.RootfsWriter{
 			RootfsPath: bndl.Spec.Spec.Root.Path,
@@ -0,0 +1,188 @@
+// Package connlimit governs the connections clients open to the garden API,
+// so that one busy client cannot exhaust the server's connections, and
+// connections its clients have abandoned do not linger.
+package connlimit
+
+import (
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/pivotal-golang/lager"
+)
+
+// Counter counts connections, e.g. for a metric
+type Counter interface {
+	Inc()
+}
+
+// Listener accepts connections from the listener it wraps, limiting the
+// number each client may have open at once. Clients are told apart by IP
+// address; all the clients of a unix socket count as one client.
+//
+// Accepted TCP connections are kept alive every KeepAlive, so that the
+// connections of clients which have gone away are noticed, and connections
+// on which nothing is read or written for IdleTimeout are closed.
+type Listener struct {
+	net.Listener
+
+	// MaxPerClient is the most connections a client may have open; 0 is no
+	// limit. Connections beyond it are closed as soon as they are accepted.
+	MaxPerClient int
+
+	// KeepAlive is the TCP keep-alive period (optional)
+	KeepAlive time.Duration
+
+	// IdleTimeout closes connections idle for longer (optional). A process
+	// which is attached to is streamed over a connection which is idle while
+	// the process is quiet, so it should be longer than processes are quiet.
+	IdleTimeout time.Duration
+
+	// Rejected counts connections closed for being over MaxPerClient, and
+	// TimedOut those closed for being idle (both optional)
+	Rejected Counter
+	TimedOut Counter
+
+	Logger lager.Logger
+
+	mu   sync.Mutex
+	open map[string]int
+}
+
+func (l *Listener) Accept() (net.Conn, error) {
+	for {
+		conn, err := l.Listener.Accept()
+		if err != nil {
+			return nil, err
+		}
+
+		client := clientOf(conn)
+		if !l.acquire(client) {
+			l.Logger.Info("too-many-connections", lager.Data{"client": client, "max": l.MaxPerClient})
+			count(l.Rejected)
+			conn.Close()
+			continue
+		}
+
+		if tcpConn, ok := conn.(*net.TCPConn); ok && l.KeepAlive > 0 {
+			tcpConn.SetKeepAlive(true)
+			tcpConn.SetKeepAlivePeriod(l.KeepAlive)
+		}
+
+		governed := &governedConn{Conn: conn, listener: l, client: client}
+		governed.touch()
+
+		return governed, nil
+	}
+}
+
+// Open is the number of connections each client has open, e.g. for a metric
+func (l *Listener) Open() map[string]float64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	open := make(map[string]float64, len(l.open))
+	for client, n := range l.open {
+		open[client] = float64(n)
+	}
+
+	return open
+}
+
+func (l *Listener) acquire(client string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.open == nil {
+		l.open = make(map[string]int)
+	}
+
+	if l.MaxPerClient > 0 && l.open[client] >= l.MaxPerClient {
+		return false
+	}
+
+	l.open[client]++
+	return true
+}
+
+func (l *Listener) release(client string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.open[client]--
+	if l.open[client] <= 0 {
+		delete(l.open, client)
+	}
+}
+
+// clientOf is the IP address of a TCP connection's client, or the network
+// of any other connection
+func clientOf(conn net.Conn) string {
+	if addr, ok := conn.RemoteAddr().(*net.TCPAddr); ok {
+		return addr.IP.String()
+	}
+
+	return conn.LocalAddr().Network()
+}
+
+func count(counter Counter) {
+	if counter != nil {
+		counter.Inc()
+	}
+}
+
+// governedConn is released from its client's count when it is closed, and
+// pushes its deadline back whenever anything is read or written
+type governedConn struct {
+	net.Conn
+	listener *Listener
+	client   string
+
+	timedOut  int32
+	closeOnce sync.Once
+}
+
+func (c *governedConn) Read(b []byte) (int, error) {
+	n, err := c.Conn.Read(b)
+	c.observe(n, err)
+	return n, err
+}
+
+func (c *governedConn) Write(b []byte) (int, error) {
+	n, err := c.Conn.Write(b)
+	c.observe(n, err)
+	return n, err
+}
+
+func (c *governedConn) Close() error {
+	c.closeOnce.Do(func() {
+		c.listener.release(c.client)
+
+		if atomic.LoadInt32(&c.timedOut) == 1 {
+			c.listener.Logger.Info("idle-connection-closed", lager.Data{"client": c.client, "idle-timeout": c.listener.IdleTimeout.String()})
+			count(c.listener.TimedOut)
+		}
+	})
+
+	return c.Conn.Close()
+}
+
+func (c *governedConn) observe(n int, err error) {
+	if n > 0 {
+		c.touch()
+	}
+
+	if netErr, ok := err.(net.Error); ok && netErr.Timeout() {
+		atomic.StoreInt32(&c.timedOut, 1)
+	}
+}
+
+// touch pushes the deadline of both reads and writes back by IdleTimeout,
+// so that a read waiting while the other side is written to is not timed
+// out
+func (c *governedConn) touch() {
+	if c.listener.IdleTimeout > 0 {
+		c.Conn.SetDeadline(time.Now().Add(c.listener.IdleTimeout))
+	}
+}
@@ -0,0 +1,150 @@
+package connlimit_test
+
+import (
+	"io"
+	"io/ioutil"
+	"net"
+	"sync/atomic"
+	"time"
+
+	"github.com/cloudfoundry-incubator/guardian/connlimit"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/pivotal-golang/lager/lagertest"
+)
+
+type counter struct {
+	n int32
+}
+
+func (c *counter) Inc() {
+	atomic.AddInt32(&c.n, 1)
+}
+
+func (c *counter) Count() int {
+	return int(atomic.LoadInt32(&c.n))
+}
+
+var _ = Describe("Listener", func() {
+	var (
+		rejected *counter
+		timedOut *counter
+		listener *connlimit.Listener
+		accepted chan net.Conn
+	)
+
+	BeforeEach(func() {
+		tcpListener, err := net.Listen("tcp", "127.0.0.1:0")
+		Expect(err).NotTo(HaveOccurred())
+
+		rejected, timedOut = &counter{}, &counter{}
+		listener = &connlimit.Listener{
+			Listener:     tcpListener,
+			MaxPerClient: 1,
+			KeepAlive:    time.Minute,
+			Rejected:     rejected,
+			TimedOut:     timedOut,
+			Logger:       lagertest.NewTestLogger("test"),
+		}
+
+		accepted = make(chan net.Conn, 10)
+	})
+
+	JustBeforeEach(func() {
+		go func() {
+			defer GinkgoRecover()
+
+			for {
+				conn, err := listener.Accept()
+				if err != nil {
+					return
+				}
+
+				accepted <- conn
+			}
+		}()
+	})
+
+	AfterEach(func() {
+		Expect(listener.Close()).To(Succeed())
+	})
+
+	dial := func() net.Conn {
+		conn, err := net.Dial("tcp", listener.Addr().String())
+		Expect(err).NotTo(HaveOccurred())
+		return conn
+	}
+
+	It("closes connections from a client which has MaxPerClient open", func() {
+		first := dial()
+		defer first.Close()
+		Eventually(accepted).Should(Receive())
+
+		second := dial()
+		defer second.Close()
+
+		_, err := second.Read(make([]byte, 1))
+		Expect(err).To(Equal(io.EOF))
+		Expect(rejected.Count()).To(Equal(1))
+		Expect(listener.Open()).To(Equal(map[string]float64{"127.0.0.1": 1}))
+	})
+
+	It("accepts the client's connections again once one is closed", func() {
+		first := dial()
+		defer first.Close()
+
+		var conn net.Conn
+		Eventually(accepted).Should(Receive(&conn))
+		Expect(conn.Close()).To(Succeed())
+		Expect(listener.Open()).To(BeEmpty())
+
+		second := dial()
+		defer second.Close()
+		Eventually(accepted).Should(Receive())
+	})
+
+	Context("with an idle timeout", func() {
+		BeforeEach(func() {
+			listener.IdleTimeout = 100 * time.Millisecond
+		})
+
+		It("times out connections on which nothing is read or written", func() {
+			client := dial()
+			defer client.Close()
+
+			var conn net.Conn
+			Eventually(accepted).Should(Receive(&conn))
+
+			_, err := conn.Read(make([]byte, 1))
+			Expect(err).To(HaveOccurred())
+			Expect(err.(net.Error).Timeout()).To(BeTrue())
+
+			Expect(conn.Close()).To(Succeed())
+			Expect(timedOut.Count()).To(Equal(1))
+		})
+
+		It("does not time out a read while the connection is being written to", func() {
+			client := dial()
+			defer client.Close()
+			go io.Copy(ioutil.Discard, client)
+
+			var conn net.Conn
+			Eventually(accepted).Should(Receive(&conn))
+			defer conn.Close()
+
+			readErr := make(chan error, 1)
+			go func() {
+				_, err := conn.Read(make([]byte, 1))
+				readErr <- err
+			}()
+
+			for i := 0; i < 15; i++ {
+				_, err := conn.Write([]byte("x"))
+				Expect(err).NotTo(HaveOccurred())
+				time.Sleep(20 * time.Millisecond)
+			}
+
+			Expect(readErr).NotTo(Receive())
+		})
+	})
+})
@@ -0,0 +1,193 @@
+package gardener
+
+import (
+	"sync"
+	"time"
+
+	"github.com/pivotal-golang/clock"
+	"github.com/pivotal-golang/lager"
+)
+
+// AgeProperty is the property which holds how long a container has existed,
+// as a duration. It is guardian's own, so clients may not set it.
+const AgeProperty = "garden.age"
+
+//go:generate counterfeiter . AgeStore
+//go:generate counterfeiter . AgeReader
+
+// AgeStore persists the ages of containers in their properties
+type AgeStore interface {
+	Get(handle string, name string) (string, error)
+
+	// SetIfExists sets the property only if the container has properties,
+	// so that a container destroyed in the meantime does not have its
+	// properties recreated, and returns whether it was set
+	SetIfExists(handle string, name string, value string) bool
+}
+
+// AgeReader returns how long a container has existed, and whether it is
+// known
+type AgeReader interface {
+	Age(handle string) (time.Duration, bool)
+}
+
+// EventClockSkew is published when the wall clock jumps, e.g. once a cell
+// restored from a VM snapshot has its clock corrected
+const EventClockSkew EventType = "clock-skew"
+
+// AgeTracker tracks how long each container has existed, to within
+// Interval, by adding up the time which passes on the monotonic clock. Ages
+// are persisted in the containers' AgeProperty as durations rather than as
+// wall-clock creation times. A cell restored from a VM snapshot, whose wall
+// clock then jumps, therefore does not throw them. Time during which
+// guardian is not running is not counted. Containers report their current
+// age in their AgeProperty (see Gardener.Ages), rather than the age last
+// persisted.
+//
+// The wall clock is compared with the monotonic clock on every tick; when
+// they disagree by more than SkewThreshold the skew is logged and an
+// EventClockSkew is published for the operator, as anything else relying on
+// wall-clock times, e.g. file timestamps, cannot be trusted.
+type AgeTracker struct {
+	Lister     HandleLister
+	Properties AgeStore
+
+	// Clock measures elapsed time, and should be monotonic
+	Clock clock.Clock
+	// WallClock reads the wall clock (optional; defaults to time.Now without
+	// its monotonic reading)
+	WallClock     func() time.Time
+	Interval      time.Duration
+	SkewThreshold time.Duration
+
+	// Publisher is optional
+	Publisher EventPublisher
+	Logger    lager.Logger
+
+	mu       sync.Mutex
+	ages     map[string]time.Duration
+	lastTick time.Time
+	lastWall time.Time
+}
+
+// Start loads the containers' ages and begins adding to them every Interval
+// in the background
+func (t *AgeTracker) Start() error {
+	log := t.Logger.Session("age-tracker-start")
+
+	t.mu.Lock()
+	t.ages = make(map[string]time.Duration)
+	t.lastTick, t.lastWall = t.Clock.Now(), t.wallNow()
+
+	handles, err := t.Lister.Handles()
+	if err != nil {
+		log.Error("list-handles-failed", err)
+	}
+
+	for _, handle := range handles {
+		t.ages[handle] = t.persistedAge(log, handle)
+	}
+	t.mu.Unlock()
+
+	go func() {
+		ticker := t.Clock.NewTicker(t.Interval)
+		defer ticker.Stop()
+
+		for range ticker.C() {
+			t.Tick()
+		}
+	}()
+
+	return nil
+}
+
+// Tick adds the time which has passed since the last tick to the age of
+// every container, and checks the wall clock for skew
+func (t *AgeTracker) Tick() {
+	log := t.Logger.Session("track-ages")
+
+	handles, err := t.Lister.Handles()
+	if err != nil {
+		log.Error("list-handles-failed", err)
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.ages == nil {
+		t.ages = make(map[string]time.Duration)
+	}
+
+	now, wall := t.Clock.Now(), t.wallNow()
+	elapsed := now.Sub(t.lastTick)
+
+	if !t.lastWall.IsZero() {
+		t.checkSkew(log, elapsed, wall.Sub(t.lastWall))
+	}
+
+	t.lastTick, t.lastWall = now, wall
+
+	current := make(map[string]time.Duration, len(handles))
+	for _, handle := range handles {
+		age, ok := t.ages[handle]
+		if ok {
+			age += elapsed
+		} else {
+			// created since the last tick
+			age = t.persistedAge(log, handle)
+		}
+
+		current[handle] = age
+		t.Properties.SetIfExists(handle, AgeProperty, age.String())
+	}
+
+	t.ages = current
+}
+
+// Age returns how long the container has existed, and whether it is known
+func (t *AgeTracker) Age(handle string) (time.Duration, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	age, ok := t.ages[handle]
+	return age, ok
+}
+
+func (t *AgeTracker) checkSkew(log lager.Logger, elapsed, wallElapsed time.Duration) {
+	skew := wallElapsed - elapsed
+	if skew <= t.SkewThreshold && -skew <= t.SkewThreshold {
+		return
+	}
+
+	log.Info("clock-skew-detected", lager.Data{"skew": skew.String(), "elapsed": elapsed.String(), "wall-elapsed": wallElapsed.String()})
+
+	if t.Publisher != nil {
+		t.Publisher.Publish(Event{Type: EventClockSkew, Data: map[string]string{"skew": skew.String()}})
+	}
+}
+
+// persistedAge is the container's age from its AgeProperty, or 0 if it has
+// none
+func (t *AgeTracker) persistedAge(log lager.Logger, handle string) time.Duration {
+	raw, err := t.Properties.Get(handle, AgeProperty)
+	if err != nil {
+		return 0
+	}
+
+	age, err := time.ParseDuration(raw)
+	if err != nil {
+		log.Error("invalid-age", err, lager.Data{"handle": handle, "age": raw})
+		return 0
+	}
+
+	return age
+}
+
+func (t *AgeTracker) wallNow() time.Time {
+	if t.WallClock != nil {
+		return t.WallClock()
+	}
+
+	return time.Now().Round(0)
+}
@@ -0,0 +1,150 @@
+package gardener_test
+
+import (
+	"errors"
+	"time"
+
+	"github.com/cloudfoundry-incubator/guardian/gardener"
+	"github.com/cloudfoundry-incubator/guardian/gardener/fakes"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+	"github.com/pivotal-golang/clock/fakeclock"
+	"github.com/pivotal-golang/lager/lagertest"
+)
+
+var _ = Describe("AgeTracker", func() {
+	var (
+		logger        *lagertest.TestLogger
+		lister        *fakes.FakeHandleLister
+		props         *fakes.FakeAgeStore
+		publisher     *fakes.FakeEventPublisher
+		fakeClock     *fakeclock.FakeClock
+		wall          time.Time
+		persistedAges map[string]string
+		destroyed     map[string]bool
+
+		tracker *gardener.AgeTracker
+	)
+
+	BeforeEach(func() {
+		logger = lagertest.NewTestLogger("test")
+		lister = new(fakes.FakeHandleLister)
+		lister.HandlesReturns([]string{"old", "new"}, nil)
+		publisher = new(fakes.FakeEventPublisher)
+		fakeClock = fakeclock.NewFakeClock(time.Now())
+		wall = time.Date(2016, 1, 1, 0, 0, 0, 0, time.UTC)
+
+		// the old container was an hour old when guardian last stopped
+		persistedAges = map[string]string{"old": "1h0m0s"}
+		destroyed = map[string]bool{}
+		props = new(fakes.FakeAgeStore)
+		props.GetStub = func(handle, name string) (string, error) {
+			if age, ok := persistedAges[handle]; ok && name == gardener.AgeProperty {
+				return age, nil
+			}
+
+			return "", errors.New("no such property")
+		}
+		props.SetIfExistsStub = func(handle, name, value string) bool {
+			if destroyed[handle] {
+				return false
+			}
+
+			persistedAges[handle] = value
+			return true
+		}
+
+		tracker = &gardener.AgeTracker{
+			Lister:     lister,
+			Properties: props,
+			Clock:      fakeClock,
+			WallClock:  func() time.Time { return wall },
+			// longer than the test, so that only its own ticks are counted
+			Interval:      time.Hour,
+			SkewThreshold: 10 * time.Second,
+			Publisher:     publisher,
+			Logger:        logger,
+		}
+
+		Expect(tracker.Start()).To(Succeed())
+	})
+
+	// tick passes the time on both clocks, and the wall clock by skew more
+	tick := func(elapsed, skew time.Duration) {
+		fakeClock.Increment(elapsed)
+		wall = wall.Add(elapsed + skew)
+		tracker.Tick()
+	}
+
+	age := func(handle string) time.Duration {
+		age, ok := tracker.Age(handle)
+		Expect(ok).To(BeTrue())
+		return age
+	}
+
+	It("resumes the ages persisted before a restart", func() {
+		Expect(age("old")).To(Equal(time.Hour))
+		Expect(age("new")).To(Equal(time.Duration(0)))
+	})
+
+	It("adds the time which passes to every container's age, and persists it as a duration", func() {
+		tick(time.Minute, 0)
+		tick(time.Minute, 0)
+
+		Expect(age("old")).To(Equal(time.Hour + 2*time.Minute))
+		Expect(age("new")).To(Equal(2 * time.Minute))
+		Expect(persistedAges).To(Equal(map[string]string{"old": "1h2m0s", "new": "2m0s"}))
+	})
+
+	It("starts tracking containers created since the last tick, and forgets destroyed ones", func() {
+		lister.HandlesReturns([]string{"new", "newer"}, nil)
+		tick(time.Minute, 0)
+
+		Expect(age("newer")).To(Equal(time.Duration(0)))
+		_, ok := tracker.Age("old")
+		Expect(ok).To(BeFalse())
+	})
+
+	It("does not recreate the properties of a container destroyed since the handles were listed", func() {
+		destroyed["new"] = true
+		tick(time.Minute, 0)
+
+		Expect(persistedAges).To(Equal(map[string]string{"old": "1h1m0s"}))
+	})
+
+	Context("when the wall clock jumps", func() {
+		BeforeEach(func() {
+			tick(time.Minute, 24*time.Hour)
+		})
+
+		It("does not add the jump to the ages", func() {
+			Expect(age("new")).To(Equal(time.Minute))
+		})
+
+		It("logs the skew and publishes an event", func() {
+			Expect(logger).To(gbytes.Say("clock-skew-detected"))
+
+			Expect(publisher.PublishCallCount()).To(Equal(1))
+			event := publisher.PublishArgsForCall(0)
+			Expect(event.Type).To(Equal(gardener.EventClockSkew))
+			Expect(event.Data).To(HaveKeyWithValue("skew", "24h0m0s"))
+		})
+	})
+
+	Context("when the wall clock jumps backwards", func() {
+		It("publishes an event", func() {
+			tick(time.Minute, -time.Hour)
+
+			Expect(publisher.PublishCallCount()).To(Equal(1))
+			Expect(publisher.PublishArgsForCall(0).Data).To(HaveKeyWithValue("skew", "-1h0m0s"))
+		})
+	})
+
+	Context("when the wall clock drifts within the threshold", func() {
+		It("does not publish an event", func() {
+			tick(time.Minute, 5*time.Second)
+			Expect(publisher.PublishCallCount()).To(Equal(0))
+		})
+	})
+})
@@ -0,0 +1,108 @@
+package gardener
+
+import (
+	"github.com/cloudfoundry-incubator/garden"
+	"github.com/pivotal-golang/lager"
+)
+
+// Annotator attaches the values of selected container properties (such as
+// an org, space or app id) to the container's log lines and metrics, so that
+// they can be aggregated without first joining handles to metadata. A nil
+// Annotator attaches nothing.
+type Annotator struct {
+	Keys       []string
+	Properties PropertyManager
+}
+
+// AnnotationKeys returns the names of the annotated properties.
+func (a *Annotator) AnnotationKeys() []string {
+	if a == nil {
+		return nil
+	}
+
+	return a.Keys
+}
+
+// Annotations returns the container's values of the annotated properties.
+// Properties which are not set are omitted.
+func (a *Annotator) Annotations(handle string) map[string]string {
+	if a == nil || len(a.Keys) == 0 {
+		return nil
+	}
+
+	annotations := make(map[string]string)
+	for _, key := range a.Keys {
+		if value, err := a.Properties.Get(handle, key); err == nil {
+			annotations[key] = value
+		}
+	}
+
+	return annotations
+}
+
+// Logger returns a logger which adds the container's annotations to every
+// log line.
+func (a *Annotator) Logger(log lager.Logger, handle string) lager.Logger {
+	return a.logger(log, toData(a.Annotations(handle)))
+}
+
+// SpecLogger is like Logger, but takes the annotations from the properties in
+// the spec, for use before the container's properties have been stored.
+func (a *Annotator) SpecLogger(log lager.Logger, spec garden.ContainerSpec) lager.Logger {
+	if a == nil {
+		return log
+	}
+
+	data := lager.Data{}
+	for _, key := range a.Keys {
+		if value, ok := spec.Properties[key]; ok {
+			data[key] = value
+		}
+	}
+
+	return a.logger(log, data)
+}
+
+func (a *Annotator) logger(log lager.Logger, data lager.Data) lager.Logger {
+	if len(data) == 0 {
+		return log
+	}
+
+	return &annotatedLogger{Logger: log, data: data}
+}
+
+func toData(annotations map[string]string) lager.Data {
+	data := lager.Data{}
+	for key, value := range annotations {
+		data[key] = value
+	}
+
+	return data
+}
+
+// annotatedLogger adds data to every log line without starting a new session,
+// so that the names of log lines are unchanged.
+type annotatedLogger struct {
+	lager.Logger
+	data lager.Data
+}
+
+func (l *annotatedLogger) Session(task string, data ...lager.Data) lager.Logger {
+	return l.Logger.Session(task, append([]lager.Data{l.data}, data...)...)
+}
+
+func (l *annotatedLogger) Debug(action string, data ...lager.Data) {
+	l.Logger.Debug(action, append([]lager.Data{l.data}, data...)...)
+}
+
+func (l *annotatedLogger) Info(action string, data ...lager.Data) {
+	l.Logger.Info(action, append([]lager.Data{l.data}, data...)...)
+}
+
+func (l *annotatedLogger) Error(action string, err error, data ...lager.Data) {
+	l.Logger.Error(action, err, append([]lager.Data{l.data}, data...)...)
+}
+
+func (l *annotatedLogger) Fatal(action string, err error, data ...lager.Data) {
+	l.Logger.Fatal(action, err, append([]lager.Data{l.data}, data...)...)
+}
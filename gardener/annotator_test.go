@@ -0,0 +1,89 @@
+package gardener_test
+
+import (
+	"errors"
+
+	"github.com/cloudfoundry-incubator/garden"
+	"github.com/cloudfoundry-incubator/guardian/gardener"
+	"github.com/cloudfoundry-incubator/guardian/gardener/fakes"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/pivotal-golang/lager"
+	"github.com/pivotal-golang/lager/lagertest"
+)
+
+var _ = Describe("Annotator", func() {
+	var (
+		properties *fakes.FakePropertyManager
+		annotator  *gardener.Annotator
+		logger     *lagertest.TestLogger
+	)
+
+	BeforeEach(func() {
+		properties = new(fakes.FakePropertyManager)
+		properties.GetStub = func(handle, name string) (string, error) {
+			switch name {
+			case "org_id":
+				return handle + "-org", nil
+			case "app_id":
+				return handle + "-app", nil
+			default:
+				return "", errors.New("no such property")
+			}
+		}
+
+		annotator = &gardener.Annotator{Keys: []string{"org_id", "app_id", "space_id"}, Properties: properties}
+		logger = lagertest.NewTestLogger("test")
+	})
+
+	It("returns the values of the annotated properties which are set", func() {
+		Expect(annotator.Annotations("banana")).To(Equal(map[string]string{
+			"org_id": "banana-org",
+			"app_id": "banana-app",
+		}))
+	})
+
+	It("returns the names of the annotated properties", func() {
+		Expect(annotator.AnnotationKeys()).To(Equal([]string{"org_id", "app_id", "space_id"}))
+	})
+
+	It("adds the annotations to every log line without changing their names", func() {
+		log := annotator.Logger(logger, "banana")
+		log.Info("hello", lager.Data{"some": "data"})
+		log.Session("run").Error("failed", errors.New("boom"))
+
+		logs := logger.Logs()
+		Expect(logs).To(HaveLen(2))
+
+		Expect(logs[0].Message).To(Equal("test.hello"))
+		Expect(logs[0].Data).To(HaveKeyWithValue("org_id", "banana-org"))
+		Expect(logs[0].Data).To(HaveKeyWithValue("app_id", "banana-app"))
+		Expect(logs[0].Data).To(HaveKeyWithValue("some", "data"))
+
+		Expect(logs[1].Message).To(Equal("test.run.failed"))
+		Expect(logs[1].Data).To(HaveKeyWithValue("org_id", "banana-org"))
+	})
+
+	It("takes annotations from a container spec before the properties are stored", func() {
+		log := annotator.SpecLogger(logger, garden.ContainerSpec{
+			Properties: garden.Properties{"org_id": "spec-org", "other": "ignored"},
+		})
+		log.Info("hello")
+
+		Expect(logger.Logs()[0].Data).To(HaveKeyWithValue("org_id", "spec-org"))
+		Expect(logger.Logs()[0].Data).NotTo(HaveKey("other"))
+	})
+
+	Context("when the annotator is nil", func() {
+		BeforeEach(func() {
+			annotator = nil
+		})
+
+		It("has no annotations and does not wrap the logger", func() {
+			Expect(annotator.AnnotationKeys()).To(BeEmpty())
+			Expect(annotator.Annotations("banana")).To(BeEmpty())
+			Expect(annotator.Logger(logger, "banana")).To(Equal(logger))
+			Expect(annotator.SpecLogger(logger, garden.ContainerSpec{})).To(Equal(logger))
+		})
+	})
+})
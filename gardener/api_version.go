@@ -0,0 +1,193 @@
+package gardener
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/pivotal-golang/lager"
+)
+
+// APIVersionHeader is the header in which clients of the extension API list
+// the versions they understand, e.g. "2, 1", and in which the server answers
+// with the version it chose.
+const APIVersionHeader = "X-Guardian-API-Version"
+
+// Versions of the extension API. Clients which do not ask for a version get
+// APIVersionLegacy, so that existing clients keep working unchanged.
+const (
+	// APIVersionLegacy serves errors as plain text
+	APIVersionLegacy = 1
+	// APIVersionTypedErrors serves errors as JSON APIErrors
+	APIVersionTypedErrors = 2
+
+	CurrentAPIVersion = APIVersionTypedErrors
+)
+
+// APIFeatures are the features of the extension API, by the version which
+// introduced them
+var APIFeatures = map[string]int{
+	"accounting":   APIVersionLegacy,
+	"changes":      APIVersionLegacy,
+	"events":       APIVersionLegacy,
+	"checkpoint":   APIVersionLegacy,
+	"export":       APIVersionLegacy,
+	"validate":     APIVersionLegacy,
+	"maintenance":  APIVersionLegacy,
+	"runtime":      APIVersionLegacy,
+	"typed-errors": APIVersionTypedErrors,
+}
+
+// APIError is the body of an error response to a client which negotiated
+// APIVersionTypedErrors or later
+type APIError struct {
+	Type    string `json:"type"`
+	Message string `json:"message"`
+}
+
+var apiErrorTypes = map[int]string{
+	http.StatusBadRequest:          "InvalidRequestError",
+	http.StatusNotFound:            "NotFoundError",
+	http.StatusMethodNotAllowed:    "MethodNotAllowedError",
+	http.StatusNotAcceptable:       "UnsupportedAPIVersionError",
+	http.StatusGone:                "GoneError",
+	http.StatusInternalServerError: "ServerError",
+}
+
+// APIVersionNegotiator chooses an API version for each request to Handler:
+// the newest version which both the client and the server support. The
+// chosen version replaces the request's APIVersionHeader, so that handlers
+// can read it with RequestAPIVersion, and is returned in the response's.
+// Requests for versions older than MinVersion are refused with 406 Not
+// Acceptable, and those for versions older than DeprecatedBelow are served
+// with a warning, and logged so that operators can find the clients which
+// need to be upgraded.
+type APIVersionNegotiator struct {
+	Handler http.Handler
+
+	// MinVersion is the oldest version which is served (0 means
+	// APIVersionLegacy)
+	MinVersion int
+
+	// DeprecatedBelow is the oldest version which is not deprecated (0 means
+	// no version is deprecated)
+	DeprecatedBelow int
+
+	Logger lager.Logger
+}
+
+func (n *APIVersionNegotiator) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	minVersion := n.MinVersion
+	if minVersion == 0 {
+		minVersion = APIVersionLegacy
+	}
+
+	version, err := negotiateAPIVersion(r.Header.Get(APIVersionHeader), minVersion)
+	if err != nil {
+		w.Header().Set(APIVersionHeader, strconv.Itoa(CurrentAPIVersion))
+		writeAPIError(w, CurrentAPIVersion, err.Error(), http.StatusNotAcceptable)
+		return
+	}
+
+	if version < n.DeprecatedBelow {
+		n.Logger.Info("deprecated-api-version", lager.Data{
+			"version":    version,
+			"path":       r.URL.Path,
+			"user-agent": r.UserAgent(),
+			"remote":     r.RemoteAddr,
+		})
+
+		w.Header().Set("Warning", fmt.Sprintf(
+			`299 guardian "API version %d is deprecated; ask for version %d or later in the %s header"`,
+			version, n.DeprecatedBelow, APIVersionHeader,
+		))
+	}
+
+	r.Header.Set(APIVersionHeader, strconv.Itoa(version))
+	w.Header().Set(APIVersionHeader, strconv.Itoa(version))
+	n.Handler.ServeHTTP(w, r)
+}
+
+func negotiateAPIVersion(requested string, minVersion int) (int, error) {
+	if strings.TrimSpace(requested) == "" {
+		if APIVersionLegacy < minVersion {
+			return 0, fmt.Errorf("API version %d is no longer supported: ask for a version between %d and %d in the %s header", APIVersionLegacy, minVersion, CurrentAPIVersion, APIVersionHeader)
+		}
+
+		return APIVersionLegacy, nil
+	}
+
+	chosen := 0
+	for _, v := range strings.Split(requested, ",") {
+		version, err := strconv.Atoi(strings.TrimSpace(v))
+		if err != nil {
+			return 0, fmt.Errorf("invalid API version: '%s'", strings.TrimSpace(v))
+		}
+
+		if version >= minVersion && version <= CurrentAPIVersion && version > chosen {
+			chosen = version
+		}
+	}
+
+	if chosen == 0 {
+		return 0, fmt.Errorf("none of the API versions '%s' are supported: the server supports versions %d to %d", requested, minVersion, CurrentAPIVersion)
+	}
+
+	return chosen, nil
+}
+
+// RequestAPIVersion returns the API version negotiated for a request, or
+// APIVersionLegacy if none was
+func RequestAPIVersion(r *http.Request) int {
+	version, err := strconv.Atoi(r.Header.Get(APIVersionHeader))
+	if err != nil {
+		return APIVersionLegacy
+	}
+
+	return version
+}
+
+// APIVersionsHandler serves the API versions the server supports and the
+// features each of them introduced
+type APIVersionsHandler struct {
+	Negotiator *APIVersionNegotiator
+}
+
+func (h *APIVersionsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	minVersion := h.Negotiator.MinVersion
+	if minVersion == 0 {
+		minVersion = APIVersionLegacy
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"min_version":      minVersion,
+		"max_version":      CurrentAPIVersion,
+		"deprecated_below": h.Negotiator.DeprecatedBelow,
+		"features":         APIFeatures,
+	})
+}
+
+// writeError writes an error response in the form the request's API version
+// expects
+func writeError(w http.ResponseWriter, r *http.Request, message string, code int) {
+	writeAPIError(w, RequestAPIVersion(r), message, code)
+}
+
+func writeAPIError(w http.ResponseWriter, version int, message string, code int) {
+	if version < APIVersionTypedErrors {
+		http.Error(w, message, code)
+		return
+	}
+
+	errorType, ok := apiErrorTypes[code]
+	if !ok {
+		errorType = "ServerError"
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(APIError{Type: errorType, Message: message})
+}
@@ -0,0 +1,168 @@
+package gardener_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/cloudfoundry-incubator/guardian/gardener"
+	"github.com/cloudfoundry-incubator/guardian/gardener/fakes"
+	"github.com/pivotal-golang/lager/lagertest"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("API versions", func() {
+	var (
+		logger     *lagertest.TestLogger
+		negotiator *gardener.APIVersionNegotiator
+		recorder   *httptest.ResponseRecorder
+
+		servedVersion int
+	)
+
+	serve := func(versions string) {
+		req, err := http.NewRequest("GET", "/some/path", nil)
+		Expect(err).NotTo(HaveOccurred())
+		if versions != "" {
+			req.Header.Set(gardener.APIVersionHeader, versions)
+		}
+
+		negotiator.ServeHTTP(recorder, req)
+	}
+
+	BeforeEach(func() {
+		logger = lagertest.NewTestLogger("test")
+		recorder = httptest.NewRecorder()
+		servedVersion = 0
+
+		negotiator = &gardener.APIVersionNegotiator{
+			Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				servedVersion = gardener.RequestAPIVersion(r)
+			}),
+			Logger: logger,
+		}
+	})
+
+	Describe("APIVersionNegotiator", func() {
+		It("serves clients which do not ask for a version with the legacy version", func() {
+			serve("")
+
+			Expect(servedVersion).To(Equal(gardener.APIVersionLegacy))
+			Expect(recorder.HeaderMap.Get(gardener.APIVersionHeader)).To(Equal("1"))
+			Expect(recorder.HeaderMap.Get("Warning")).To(BeEmpty())
+		})
+
+		It("chooses the newest version which both the client and the server support", func() {
+			serve("1, 2, 99")
+
+			Expect(servedVersion).To(Equal(gardener.CurrentAPIVersion))
+			Expect(recorder.HeaderMap.Get(gardener.APIVersionHeader)).To(Equal("2"))
+		})
+
+		It("refuses clients which support none of the server's versions", func() {
+			serve("99")
+
+			Expect(recorder.Code).To(Equal(http.StatusNotAcceptable))
+			Expect(recorder.Body.String()).To(ContainSubstring("the server supports versions 1 to 2"))
+			Expect(servedVersion).To(BeZero())
+		})
+
+		It("refuses invalid versions", func() {
+			serve("two")
+
+			Expect(recorder.Code).To(Equal(http.StatusNotAcceptable))
+			Expect(recorder.Body.String()).To(ContainSubstring("invalid API version: 'two'"))
+		})
+
+		Context("when there is a minimum version", func() {
+			BeforeEach(func() {
+				negotiator.MinVersion = gardener.APIVersionTypedErrors
+			})
+
+			It("refuses clients which do not ask for a version", func() {
+				serve("")
+
+				Expect(recorder.Code).To(Equal(http.StatusNotAcceptable))
+				Expect(servedVersion).To(BeZero())
+			})
+
+			It("refuses clients which only support older versions", func() {
+				serve("1")
+
+				Expect(recorder.Code).To(Equal(http.StatusNotAcceptable))
+			})
+		})
+
+		Context("when old versions are deprecated", func() {
+			BeforeEach(func() {
+				negotiator.DeprecatedBelow = gardener.APIVersionTypedErrors
+			})
+
+			It("warns and logs clients which use them, but still serves them", func() {
+				serve("1")
+
+				Expect(servedVersion).To(Equal(gardener.APIVersionLegacy))
+				Expect(recorder.HeaderMap.Get("Warning")).To(ContainSubstring("API version 1 is deprecated"))
+				Expect(logger.LogMessages()).To(ContainElement("test.deprecated-api-version"))
+			})
+
+			It("does not warn clients which use newer versions", func() {
+				serve("2")
+
+				Expect(recorder.HeaderMap.Get("Warning")).To(BeEmpty())
+				Expect(logger.LogMessages()).To(BeEmpty())
+			})
+		})
+	})
+
+	Describe("APIVersionsHandler", func() {
+		It("serves the supported versions and their features", func() {
+			negotiator.DeprecatedBelow = 2
+			handler := &gardener.APIVersionsHandler{Negotiator: negotiator}
+
+			req, err := http.NewRequest("GET", "/api/versions", nil)
+			Expect(err).NotTo(HaveOccurred())
+			handler.ServeHTTP(recorder, req)
+
+			var versions struct {
+				MinVersion      int            `json:"min_version"`
+				MaxVersion      int            `json:"max_version"`
+				DeprecatedBelow int            `json:"deprecated_below"`
+				Features        map[string]int `json:"features"`
+			}
+			Expect(json.NewDecoder(recorder.Body).Decode(&versions)).To(Succeed())
+			Expect(versions.MinVersion).To(Equal(1))
+			Expect(versions.MaxVersion).To(Equal(2))
+			Expect(versions.DeprecatedBelow).To(Equal(2))
+			Expect(versions.Features).To(HaveKeyWithValue("typed-errors", 2))
+		})
+	})
+
+	Describe("errors", func() {
+		var fakeDefiner *fakes.FakeContainerDefiner
+
+		BeforeEach(func() {
+			fakeDefiner = new(fakes.FakeContainerDefiner)
+			fakeDefiner.ExportReturns(gardener.ContainerDefinition{}, errors.New("no recorded spec"))
+			negotiator.Handler = &gardener.ExportHandler{Definer: fakeDefiner}
+		})
+
+		It("are plain text for legacy clients", func() {
+			serve("")
+
+			Expect(recorder.Code).To(Equal(http.StatusBadRequest))
+			Expect(recorder.Body.String()).To(Equal("handle is required\n"))
+		})
+
+		It("are typed JSON objects for clients which negotiate typed errors", func() {
+			serve("2")
+
+			Expect(recorder.Code).To(Equal(http.StatusBadRequest))
+			Expect(recorder.HeaderMap.Get("Content-Type")).To(Equal("application/json"))
+			Expect(recorder.Body.String()).To(MatchJSON(`{"type": "InvalidRequestError", "message": "handle is required"}`))
+		})
+	})
+})
@@ -0,0 +1,394 @@
+package gardener
+
+import (
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/cloudfoundry-incubator/garden"
+	"github.com/pivotal-golang/lager"
+)
+
+//go:generate counterfeiter . Authorizer
+
+// Actions which an Authorizer is asked to authorize
+const (
+	ActionCreate         = "create"
+	ActionDestroy        = "destroy"
+	ActionRun            = "run"
+	ActionAttach         = "attach"
+	ActionStop           = "stop"
+	ActionStreamIn       = "stream-in"
+	ActionLimit          = "limit"
+	ActionNetIn          = "net-in"
+	ActionNetOut         = "net-out"
+	ActionSetProperty    = "set-property"
+	ActionRemoveProperty = "remove-property"
+	ActionSetGraceTime   = "set-grace-time"
+	ActionStreamOut      = "stream-out"
+
+	// ActionExtension is asked for requests to the extensions server, with
+	// the endpoint they were made to
+	ActionExtension = "extension"
+)
+
+// AuthRequest summarises a request which would change a container or the
+// host. The garden protocol carries no credentials, so the caller is the
+// subject of the client certificate the request was made with, if the
+// listener serves TLS, and the listener the request arrived on otherwise.
+type AuthRequest struct {
+	Caller string `json:"caller"`
+	Action string `json:"action"`
+	Handle string `json:"handle,omitempty"`
+
+	// Privileged and RootFSPath are set for creates
+	Privileged bool   `json:"privileged,omitempty"`
+	RootFSPath string `json:"rootfs,omitempty"`
+
+	// Path and User are set for runs
+	Path string `json:"path,omitempty"`
+	User string `json:"user,omitempty"`
+
+	// Property is set for setting and removing properties
+	Property string `json:"property,omitempty"`
+
+	// Endpoint is set for requests to the extensions server
+	Endpoint string `json:"endpoint,omitempty"`
+}
+
+// Authorizer decides whether requests may be served, e.g. by running a
+// policy binary, so that deployments can limit who may create privileged
+// containers or run processes. It returns a NotAuthorizedError if the
+// request is denied, and any other error if it could not be decided, in
+// which case the request is refused too.
+type Authorizer interface {
+	Authorize(log lager.Logger, request AuthRequest) error
+}
+
+// NotAuthorizedError is returned for requests which the Authorizer denies
+type NotAuthorizedError struct {
+	Action string
+	Reason string
+}
+
+func (e NotAuthorizedError) Error() string {
+	if e.Reason == "" {
+		return fmt.Sprintf("not authorized to %s", e.Action)
+	}
+
+	return fmt.Sprintf("not authorized to %s: %s", e.Action, e.Reason)
+}
+
+// AuthorizingBackend asks the Authorizer to authorize each request to the
+// Backend, and the containers it looks up, which would change a container or
+// the host, or read a container's files. Requests which only observe them are
+// served as they are. It wraps each of the Backend's and the containers'
+// methods explicitly, rather than embedding them, so that methods added to
+// the garden API are refused until they are authorized here.
+type AuthorizingBackend struct {
+	Backend    garden.Backend
+	Authorizer Authorizer
+	Caller     string
+	Logger     lager.Logger
+}
+
+func (b AuthorizingBackend) Start() error { return b.Backend.Start() }
+func (b AuthorizingBackend) Stop()        { b.Backend.Stop() }
+func (b AuthorizingBackend) Ping() error  { return b.Backend.Ping() }
+
+func (b AuthorizingBackend) GraceTime(container garden.Container) time.Duration {
+	if authorizing, ok := container.(authorizingContainer); ok {
+		container = authorizing.container
+	}
+
+	return b.Backend.GraceTime(container)
+}
+
+func (b AuthorizingBackend) Capacity() (garden.Capacity, error) {
+	return b.Backend.Capacity()
+}
+
+func (b AuthorizingBackend) BulkInfo(handles []string) (map[string]garden.ContainerInfoEntry, error) {
+	return b.Backend.BulkInfo(handles)
+}
+
+func (b AuthorizingBackend) BulkMetrics(handles []string) (map[string]garden.ContainerMetricsEntry, error) {
+	return b.Backend.BulkMetrics(handles)
+}
+
+func (b AuthorizingBackend) Create(spec garden.ContainerSpec) (garden.Container, error) {
+	if err := b.authorize(AuthRequest{
+		Action:     ActionCreate,
+		Handle:     spec.Handle,
+		Privileged: spec.Privileged,
+		RootFSPath: spec.RootFSPath,
+	}); err != nil {
+		return nil, err
+	}
+
+	container, err := b.Backend.Create(spec)
+	if err != nil {
+		return nil, err
+	}
+
+	return authorizingContainer{container: container, backend: b}, nil
+}
+
+func (b AuthorizingBackend) Destroy(handle string) error {
+	if err := b.authorize(AuthRequest{Action: ActionDestroy, Handle: handle}); err != nil {
+		return err
+	}
+
+	return b.Backend.Destroy(handle)
+}
+
+func (b AuthorizingBackend) Lookup(handle string) (garden.Container, error) {
+	container, err := b.Backend.Lookup(handle)
+	if err != nil {
+		return nil, err
+	}
+
+	return authorizingContainer{container: container, backend: b}, nil
+}
+
+func (b AuthorizingBackend) Containers(props garden.Properties) ([]garden.Container, error) {
+	containers, err := b.Backend.Containers(props)
+	if err != nil {
+		return nil, err
+	}
+
+	authorizing := make([]garden.Container, len(containers))
+	for i, container := range containers {
+		authorizing[i] = authorizingContainer{container: container, backend: b}
+	}
+
+	return authorizing, nil
+}
+
+func (b AuthorizingBackend) authorize(request AuthRequest) error {
+	request.Caller = b.Caller
+	return authorize(b.Logger, b.Authorizer, request)
+}
+
+func authorize(logger lager.Logger, authorizer Authorizer, request AuthRequest) error {
+	log := logger.Session("authorize", lager.Data{"caller": request.Caller, "action": request.Action, "handle": request.Handle, "endpoint": request.Endpoint})
+
+	if err := authorizer.Authorize(log, request); err != nil {
+		log.Info("refused", lager.Data{"error": err.Error()})
+		return err
+	}
+
+	return nil
+}
+
+type authorizingContainer struct {
+	container garden.Container
+	backend   AuthorizingBackend
+}
+
+func (c authorizingContainer) authorize(action string) error {
+	return c.backend.authorize(AuthRequest{Action: action, Handle: c.container.Handle()})
+}
+
+func (c authorizingContainer) Handle() string {
+	return c.container.Handle()
+}
+
+func (c authorizingContainer) Info() (garden.ContainerInfo, error) {
+	return c.container.Info()
+}
+
+func (c authorizingContainer) Metrics() (garden.Metrics, error) {
+	return c.container.Metrics()
+}
+
+func (c authorizingContainer) Properties() (garden.Properties, error) {
+	return c.container.Properties()
+}
+
+func (c authorizingContainer) Property(name string) (string, error) {
+	return c.container.Property(name)
+}
+
+func (c authorizingContainer) CurrentBandwidthLimits() (garden.BandwidthLimits, error) {
+	return c.container.CurrentBandwidthLimits()
+}
+
+func (c authorizingContainer) CurrentCPULimits() (garden.CPULimits, error) {
+	return c.container.CurrentCPULimits()
+}
+
+func (c authorizingContainer) CurrentDiskLimits() (garden.DiskLimits, error) {
+	return c.container.CurrentDiskLimits()
+}
+
+func (c authorizingContainer) CurrentMemoryLimits() (garden.MemoryLimits, error) {
+	return c.container.CurrentMemoryLimits()
+}
+
+func (c authorizingContainer) Run(spec garden.ProcessSpec, io garden.ProcessIO) (garden.Process, error) {
+	if err := c.backend.authorize(AuthRequest{Action: ActionRun, Handle: c.container.Handle(), Path: spec.Path, User: spec.User}); err != nil {
+		return nil, err
+	}
+
+	return c.container.Run(spec, io)
+}
+
+func (c authorizingContainer) Attach(processID string, io garden.ProcessIO) (garden.Process, error) {
+	if err := c.authorize(ActionAttach); err != nil {
+		return nil, err
+	}
+
+	return c.container.Attach(processID, io)
+}
+
+func (c authorizingContainer) Stop(kill bool) error {
+	if err := c.authorize(ActionStop); err != nil {
+		return err
+	}
+
+	return c.container.Stop(kill)
+}
+
+func (c authorizingContainer) StreamIn(spec garden.StreamInSpec) error {
+	if err := c.authorize(ActionStreamIn); err != nil {
+		return err
+	}
+
+	return c.container.StreamIn(spec)
+}
+
+// StreamOut is authorized, though it does not change the container, since a
+// container's files may hold its secrets
+func (c authorizingContainer) StreamOut(spec garden.StreamOutSpec) (io.ReadCloser, error) {
+	if err := c.authorize(ActionStreamOut); err != nil {
+		return nil, err
+	}
+
+	return c.container.StreamOut(spec)
+}
+
+func (c authorizingContainer) LimitBandwidth(limits garden.BandwidthLimits) error {
+	if err := c.authorize(ActionLimit); err != nil {
+		return err
+	}
+
+	return c.container.LimitBandwidth(limits)
+}
+
+func (c authorizingContainer) LimitCPU(limits garden.CPULimits) error {
+	if err := c.authorize(ActionLimit); err != nil {
+		return err
+	}
+
+	return c.container.LimitCPU(limits)
+}
+
+func (c authorizingContainer) LimitDisk(limits garden.DiskLimits) error {
+	if err := c.authorize(ActionLimit); err != nil {
+		return err
+	}
+
+	return c.container.LimitDisk(limits)
+}
+
+func (c authorizingContainer) LimitMemory(limits garden.MemoryLimits) error {
+	if err := c.authorize(ActionLimit); err != nil {
+		return err
+	}
+
+	return c.container.LimitMemory(limits)
+}
+
+func (c authorizingContainer) NetIn(hostPort, containerPort uint32) (uint32, uint32, error) {
+	if err := c.authorize(ActionNetIn); err != nil {
+		return 0, 0, err
+	}
+
+	return c.container.NetIn(hostPort, containerPort)
+}
+
+func (c authorizingContainer) NetOut(rule garden.NetOutRule) error {
+	if err := c.authorize(ActionNetOut); err != nil {
+		return err
+	}
+
+	return c.container.NetOut(rule)
+}
+
+func (c authorizingContainer) BulkNetOut(rules []garden.NetOutRule) error {
+	if err := c.authorize(ActionNetOut); err != nil {
+		return err
+	}
+
+	return c.container.BulkNetOut(rules)
+}
+
+func (c authorizingContainer) SetProperty(name, value string) error {
+	if err := c.backend.authorize(AuthRequest{Action: ActionSetProperty, Handle: c.container.Handle(), Property: name}); err != nil {
+		return err
+	}
+
+	return c.container.SetProperty(name, value)
+}
+
+func (c authorizingContainer) RemoveProperty(name string) error {
+	if err := c.backend.authorize(AuthRequest{Action: ActionRemoveProperty, Handle: c.container.Handle(), Property: name}); err != nil {
+		return err
+	}
+
+	return c.container.RemoveProperty(name)
+}
+
+func (c authorizingContainer) SetGraceTime(t time.Duration) error {
+	if err := c.authorize(ActionSetGraceTime); err != nil {
+		return err
+	}
+
+	return c.container.SetGraceTime(t)
+}
+
+// AuthorizingHandler asks the Authorizer to authorize every request to
+// Handler, e.g. the extensions server, whose endpoints checkpoint, import and
+// mount into containers. Each request is authorized as ActionExtension with
+// its endpoint and the container it names, if any, from the caller given by
+// RequestCaller.
+type AuthorizingHandler struct {
+	Handler    http.Handler
+	Authorizer Authorizer
+	Listener   string
+	Logger     lager.Logger
+}
+
+func (h *AuthorizingHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	err := authorize(h.Logger, h.Authorizer, AuthRequest{
+		Caller:   RequestCaller(r.TLS, h.Listener),
+		Action:   ActionExtension,
+		Endpoint: r.URL.Path,
+		Handle:   r.URL.Query().Get("handle"),
+	})
+	if err != nil {
+		if _, ok := err.(NotAuthorizedError); ok {
+			http.Error(w, err.Error(), http.StatusForbidden)
+		} else {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+
+		return
+	}
+
+	h.Handler.ServeHTTP(w, r)
+}
+
+// RequestCaller identifies the caller of a request which arrived on listener:
+// by the subject of its verified client certificate, if it was made over
+// TLS, and by the listener otherwise
+func RequestCaller(state *tls.ConnectionState, listener string) string {
+	if state == nil || len(state.VerifiedChains) == 0 {
+		return listener
+	}
+
+	return "cert:" + state.VerifiedChains[0][0].Subject.String()
+}
@@ -0,0 +1,234 @@
+package gardener_test
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/cloudfoundry-incubator/garden"
+	gardenfakes "github.com/cloudfoundry-incubator/garden/fakes"
+	"github.com/cloudfoundry-incubator/guardian/gardener"
+	"github.com/cloudfoundry-incubator/guardian/gardener/fakes"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/pivotal-golang/lager/lagertest"
+)
+
+var _ = Describe("AuthorizingBackend", func() {
+	var (
+		fakeBackend   *gardenfakes.FakeBackend
+		fakeContainer *gardenfakes.FakeContainer
+		authorizer    *fakes.FakeAuthorizer
+		backend       gardener.AuthorizingBackend
+		denied        error
+	)
+
+	BeforeEach(func() {
+		fakeBackend = new(gardenfakes.FakeBackend)
+		fakeContainer = new(gardenfakes.FakeContainer)
+		fakeContainer.HandleReturns("banana")
+		fakeBackend.CreateReturns(fakeContainer, nil)
+		fakeBackend.LookupReturns(fakeContainer, nil)
+		fakeBackend.ContainersReturns([]garden.Container{fakeContainer}, nil)
+
+		authorizer = new(fakes.FakeAuthorizer)
+		denied = gardener.NotAuthorizedError{Action: "create", Reason: "nope"}
+
+		backend = gardener.AuthorizingBackend{
+			Backend:    fakeBackend,
+			Authorizer: authorizer,
+			Caller:     "tcp:0.0.0.0:7777",
+			Logger:     lagertest.NewTestLogger("test"),
+		}
+	})
+
+	It("authorizes creates with the caller and a summary of the spec", func() {
+		_, err := backend.Create(garden.ContainerSpec{Handle: "banana", Privileged: true, RootFSPath: "docker:///busybox"})
+		Expect(err).NotTo(HaveOccurred())
+
+		_, request := authorizer.AuthorizeArgsForCall(0)
+		Expect(request).To(Equal(gardener.AuthRequest{
+			Caller:     "tcp:0.0.0.0:7777",
+			Action:     gardener.ActionCreate,
+			Handle:     "banana",
+			Privileged: true,
+			RootFSPath: "docker:///busybox",
+		}))
+		Expect(fakeBackend.CreateCallCount()).To(Equal(1))
+	})
+
+	It("refuses creates which are not authorized", func() {
+		authorizer.AuthorizeReturns(denied)
+
+		_, err := backend.Create(garden.ContainerSpec{Handle: "banana"})
+		Expect(err).To(MatchError("not authorized to create: nope"))
+		Expect(fakeBackend.CreateCallCount()).To(Equal(0))
+	})
+
+	It("refuses destroys which are not authorized", func() {
+		authorizer.AuthorizeReturns(denied)
+
+		Expect(backend.Destroy("banana")).To(MatchError(denied))
+		Expect(fakeBackend.DestroyCallCount()).To(Equal(0))
+
+		_, request := authorizer.AuthorizeArgsForCall(0)
+		Expect(request.Action).To(Equal(gardener.ActionDestroy))
+	})
+
+	It("does not authorize requests which only observe the backend", func() {
+		_, err := backend.Containers(nil)
+		Expect(err).NotTo(HaveOccurred())
+
+		container, err := backend.Lookup("banana")
+		Expect(err).NotTo(HaveOccurred())
+		_, err = container.Info()
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(authorizer.AuthorizeCallCount()).To(Equal(0))
+	})
+
+	Describe("containers", func() {
+		var container garden.Container
+
+		BeforeEach(func() {
+			var err error
+			container, err = backend.Lookup("banana")
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("authorizes runs with the process's path and user", func() {
+			_, err := container.Run(garden.ProcessSpec{Path: "/bin/sh", User: "root"}, garden.ProcessIO{})
+			Expect(err).NotTo(HaveOccurred())
+
+			_, request := authorizer.AuthorizeArgsForCall(0)
+			Expect(request).To(Equal(gardener.AuthRequest{
+				Caller: "tcp:0.0.0.0:7777",
+				Action: gardener.ActionRun,
+				Handle: "banana",
+				Path:   "/bin/sh",
+				User:   "root",
+			}))
+			Expect(fakeContainer.RunCallCount()).To(Equal(1))
+		})
+
+		It("refuses runs which are not authorized", func() {
+			authorizer.AuthorizeReturns(denied)
+
+			_, err := container.Run(garden.ProcessSpec{Path: "/bin/sh"}, garden.ProcessIO{})
+			Expect(err).To(MatchError(denied))
+			Expect(fakeContainer.RunCallCount()).To(Equal(0))
+		})
+
+		It("authorizes setting properties with the property's name", func() {
+			Expect(container.SetProperty("owner", "alice")).To(Succeed())
+
+			_, request := authorizer.AuthorizeArgsForCall(0)
+			Expect(request.Action).To(Equal(gardener.ActionSetProperty))
+			Expect(request.Property).To(Equal("owner"))
+		})
+
+		It("refuses requests when the authorizer fails", func() {
+			authorizer.AuthorizeReturns(errors.New("authorizer: exit status 2"))
+
+			Expect(container.NetOut(garden.NetOutRule{})).To(MatchError("authorizer: exit status 2"))
+			Expect(container.StreamIn(garden.StreamInSpec{})).To(MatchError("authorizer: exit status 2"))
+			Expect(container.Stop(false)).To(MatchError("authorizer: exit status 2"))
+			Expect(fakeContainer.NetOutCallCount()).To(Equal(0))
+			Expect(fakeContainer.StreamInCallCount()).To(Equal(0))
+			Expect(fakeContainer.StopCallCount()).To(Equal(0))
+		})
+
+		It("authorizes streaming files out, since they may hold the container's secrets", func() {
+			authorizer.AuthorizeReturns(denied)
+
+			_, err := container.StreamOut(garden.StreamOutSpec{Path: "/etc/secret"})
+			Expect(err).To(MatchError(denied))
+			Expect(fakeContainer.StreamOutCallCount()).To(Equal(0))
+
+			_, request := authorizer.AuthorizeArgsForCall(0)
+			Expect(request.Action).To(Equal(gardener.ActionStreamOut))
+		})
+
+		It("passes the container it wrapped to the backend's grace time", func() {
+			backend.GraceTime(container)
+			Expect(fakeBackend.GraceTimeArgsForCall(0)).To(Equal(fakeContainer))
+		})
+	})
+})
+
+var _ = Describe("AuthorizingHandler", func() {
+	var (
+		authorizer *fakes.FakeAuthorizer
+		served     int
+		handler    *gardener.AuthorizingHandler
+	)
+
+	BeforeEach(func() {
+		authorizer = new(fakes.FakeAuthorizer)
+		served = 0
+		handler = &gardener.AuthorizingHandler{
+			Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				served++
+			}),
+			Authorizer: authorizer,
+			Listener:   "tcp:127.0.0.1:7778",
+			Logger:     lagertest.NewTestLogger("test"),
+		}
+	})
+
+	serve := func(method, url string) *httptest.ResponseRecorder {
+		req, err := http.NewRequest(method, url, nil)
+		Expect(err).NotTo(HaveOccurred())
+
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, req)
+		return recorder
+	}
+
+	It("authorizes each request with its endpoint and handle", func() {
+		recorder := serve("POST", "/containers/checkpoint?handle=banana&destination=snap")
+		Expect(recorder.Code).To(Equal(http.StatusOK))
+		Expect(served).To(Equal(1))
+
+		_, request := authorizer.AuthorizeArgsForCall(0)
+		Expect(request).To(Equal(gardener.AuthRequest{
+			Caller:   "tcp:127.0.0.1:7778",
+			Action:   gardener.ActionExtension,
+			Endpoint: "/containers/checkpoint",
+			Handle:   "banana",
+		}))
+	})
+
+	It("refuses requests which are not authorized", func() {
+		authorizer.AuthorizeReturns(gardener.NotAuthorizedError{Action: gardener.ActionExtension, Reason: "nope"})
+
+		recorder := serve("POST", "/maintenance")
+		Expect(recorder.Code).To(Equal(http.StatusForbidden))
+		Expect(served).To(Equal(0))
+	})
+
+	It("refuses requests when the authorizer fails", func() {
+		authorizer.AuthorizeReturns(errors.New("authorizer: exit status 2"))
+
+		recorder := serve("POST", "/containers/import")
+		Expect(recorder.Code).To(Equal(http.StatusInternalServerError))
+		Expect(served).To(Equal(0))
+	})
+})
+
+var _ = Describe("RequestCaller", func() {
+	It("identifies callers by the subject of their verified client certificate", func() {
+		cert := &x509.Certificate{Subject: pkix.Name{CommonName: "alice", Organization: []string{"ops"}}}
+		state := &tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{cert}}}
+
+		Expect(gardener.RequestCaller(state, "tcp:0.0.0.0:7777")).To(Equal("cert:CN=alice,O=ops"))
+	})
+
+	It("identifies callers without a verified certificate by the listener", func() {
+		Expect(gardener.RequestCaller(nil, "tcp:0.0.0.0:7777")).To(Equal("tcp:0.0.0.0:7777"))
+		Expect(gardener.RequestCaller(&tls.ConnectionState{}, "tcp:0.0.0.0:7777")).To(Equal("tcp:0.0.0.0:7777"))
+	})
+})
@@ -0,0 +1,31 @@
+package gardener
+
+import (
+	"errors"
+
+	"github.com/cloudfoundry-incubator/garden"
+	"github.com/pivotal-golang/lager"
+)
+
+// ErrBandwidthLimitsNotSupported is returned when bandwidth limits are set on
+// a container whose Networker cannot apply them, rather than silently
+// ignoring them
+var ErrBandwidthLimitsNotSupported = errors.New("bandwidth limits are not supported by the network plugin")
+
+//go:generate counterfeiter . BandwidthNetworker
+
+// BandwidthNetworker is implemented by Networkers which can limit the rate
+// of a container's network traffic
+type BandwidthNetworker interface {
+	LimitBandwidth(log lager.Logger, handle string, limits garden.BandwidthLimits) error
+	CurrentBandwidthLimits(log lager.Logger, handle string) (garden.BandwidthLimits, error)
+}
+
+func limitBandwidth(log lager.Logger, networker Networker, handle string, limits garden.BandwidthLimits) error {
+	bandwidthNetworker, ok := networker.(BandwidthNetworker)
+	if !ok {
+		return ErrBandwidthLimitsNotSupported
+	}
+
+	return bandwidthNetworker.LimitBandwidth(log, handle, limits)
+}
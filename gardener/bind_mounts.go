@@ -0,0 +1,96 @@
+package gardener
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+
+	"github.com/cloudfoundry-incubator/garden"
+	"github.com/pivotal-golang/lager"
+)
+
+// AddBindMount bind mounts a host path in to the running container, e.g. to
+// attach a volume without recreating the container. The mount is added to
+// the container's recorded spec, so that it is exported with the container.
+func (c *container) AddBindMount(mount garden.BindMount) error {
+	if mount.Origin != garden.BindMountOriginHost {
+		return fmt.Errorf("add bind mount: only host paths can be bind mounted in to running containers")
+	}
+
+	if !filepath.IsAbs(mount.DstPath) {
+		return fmt.Errorf("add bind mount: destination '%s' is not an absolute path", mount.DstPath)
+	}
+
+	if err := c.containerizer.AddBindMount(c.logger, c.handle, mount); err != nil {
+		return err
+	}
+
+	c.updateRecordedSpec(func(spec *garden.ContainerSpec) {
+		spec.BindMounts = append(spec.BindMounts, mount)
+	})
+
+	c.changeLog.Record(c.handle, ChangeChanged)
+	return nil
+}
+
+// RemoveBindMount removes a bind mount from the running container, whether
+// it was added by AddBindMount or the container was created with it
+func (c *container) RemoveBindMount(dstPath string) error {
+	if err := c.containerizer.RemoveBindMount(c.logger, c.handle, dstPath); err != nil {
+		return err
+	}
+
+	c.updateRecordedSpec(func(spec *garden.ContainerSpec) {
+		var remaining []garden.BindMount
+		for _, mount := range spec.BindMounts {
+			if filepath.Clean(mount.DstPath) != filepath.Clean(dstPath) {
+				remaining = append(remaining, mount)
+			}
+		}
+
+		spec.BindMounts = remaining
+	})
+
+	c.changeLog.Record(c.handle, ChangeChanged)
+	return nil
+}
+
+// updateRecordedSpec changes the spec the container was created with, if it
+// was recorded. The container has already been changed, so failing to
+// record the change is only logged.
+func (c *container) updateRecordedSpec(update func(spec *garden.ContainerSpec)) {
+	raw, err := c.propertyManager.Get(c.handle, SpecKey)
+	if err != nil {
+		return
+	}
+
+	var spec garden.ContainerSpec
+	if err := json.Unmarshal([]byte(raw), &spec); err != nil {
+		c.logger.Error("decode-recorded-spec-failed", err)
+		return
+	}
+
+	update(&spec)
+
+	data, err := json.Marshal(spec)
+	if err != nil {
+		c.logger.Error("encode-recorded-spec-failed", err)
+		return
+	}
+
+	c.propertyManager.Set(c.handle, SpecKey, string(data))
+}
+
+// AddBindMount bind mounts a host path in to the running container with the
+// given handle
+func (g *Gardener) AddBindMount(handle string, mount garden.BindMount) error {
+	g.Logger.Info("add-bind-mount", lager.Data{"handle": handle, "src": mount.SrcPath, "dst": mount.DstPath})
+	return g.container(handle).AddBindMount(mount)
+}
+
+// RemoveBindMount removes a bind mount from the running container with the
+// given handle
+func (g *Gardener) RemoveBindMount(handle, dstPath string) error {
+	g.Logger.Info("remove-bind-mount", lager.Data{"handle": handle, "dst": dstPath})
+	return g.container(handle).RemoveBindMount(dstPath)
+}
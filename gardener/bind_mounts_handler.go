@@ -0,0 +1,61 @@
+package gardener
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/cloudfoundry-incubator/garden"
+)
+
+//go:generate counterfeiter . ContainerBindMounter
+
+type ContainerBindMounter interface {
+	AddBindMount(handle string, mount garden.BindMount) error
+	RemoveBindMount(handle, dstPath string) error
+}
+
+// BindMountsHandler changes the bind mounts of the running container named by
+// the `handle` query parameter. A POST adds the bind mount given as JSON in
+// the request body; a DELETE removes the bind mount at the `dst` query
+// parameter.
+type BindMountsHandler struct {
+	Mounter ContainerBindMounter
+}
+
+func (h *BindMountsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	handle := r.URL.Query().Get("handle")
+	if handle == "" {
+		writeError(w, r, "handle is required", http.StatusBadRequest)
+		return
+	}
+
+	switch r.Method {
+	case "POST":
+		var mount garden.BindMount
+		if err := json.NewDecoder(r.Body).Decode(&mount); err != nil {
+			writeError(w, r, "invalid bind mount: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		if err := h.Mounter.AddBindMount(handle, mount); err != nil {
+			writeError(w, r, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	case "DELETE":
+		dst := r.URL.Query().Get("dst")
+		if dst == "" {
+			writeError(w, r, "dst is required", http.StatusBadRequest)
+			return
+		}
+
+		if err := h.Mounter.RemoveBindMount(handle, dst); err != nil {
+			writeError(w, r, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	default:
+		writeError(w, r, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,98 @@
+package gardener_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	"github.com/cloudfoundry-incubator/garden"
+	"github.com/cloudfoundry-incubator/guardian/gardener"
+	"github.com/cloudfoundry-incubator/guardian/gardener/fakes"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("BindMountsHandler", func() {
+	var (
+		fakeMounter *fakes.FakeContainerBindMounter
+		recorder    *httptest.ResponseRecorder
+		handler     *gardener.BindMountsHandler
+	)
+
+	serve := func(method, url, body string) {
+		req, err := http.NewRequest(method, url, strings.NewReader(body))
+		Expect(err).NotTo(HaveOccurred())
+		handler.ServeHTTP(recorder, req)
+	}
+
+	BeforeEach(func() {
+		fakeMounter = new(fakes.FakeContainerBindMounter)
+		recorder = httptest.NewRecorder()
+		handler = &gardener.BindMountsHandler{Mounter: fakeMounter}
+	})
+
+	It("adds the bind mount in the body of a POST", func() {
+		mount := garden.BindMount{
+			SrcPath: "/var/vcap/data/volume",
+			DstPath: "/data",
+			Mode:    garden.BindMountModeRW,
+		}
+		body, err := json.Marshal(mount)
+		Expect(err).NotTo(HaveOccurred())
+
+		serve("POST", "/containers/bind-mounts?handle=some-handle", string(body))
+
+		Expect(recorder.Code).To(Equal(http.StatusNoContent))
+		Expect(fakeMounter.AddBindMountCallCount()).To(Equal(1))
+		handle, actualMount := fakeMounter.AddBindMountArgsForCall(0)
+		Expect(handle).To(Equal("some-handle"))
+		Expect(actualMount).To(Equal(mount))
+	})
+
+	It("returns 400 when the bind mount is not valid JSON", func() {
+		serve("POST", "/containers/bind-mounts?handle=some-handle", `{"SrcPath":`)
+
+		Expect(recorder.Code).To(Equal(http.StatusBadRequest))
+		Expect(fakeMounter.AddBindMountCallCount()).To(Equal(0))
+	})
+
+	It("returns 500 when adding the bind mount fails", func() {
+		fakeMounter.AddBindMountReturns(errors.New("no such file"))
+		serve("POST", "/containers/bind-mounts?handle=some-handle", `{}`)
+
+		Expect(recorder.Code).To(Equal(http.StatusInternalServerError))
+		Expect(recorder.Body.String()).To(ContainSubstring("no such file"))
+	})
+
+	It("removes the bind mount at dst on a DELETE", func() {
+		serve("DELETE", "/containers/bind-mounts?handle=some-handle&dst=/data", "")
+
+		Expect(recorder.Code).To(Equal(http.StatusNoContent))
+		Expect(fakeMounter.RemoveBindMountCallCount()).To(Equal(1))
+		handle, dst := fakeMounter.RemoveBindMountArgsForCall(0)
+		Expect(handle).To(Equal("some-handle"))
+		Expect(dst).To(Equal("/data"))
+	})
+
+	It("returns 400 when a DELETE has no dst", func() {
+		serve("DELETE", "/containers/bind-mounts?handle=some-handle", "")
+
+		Expect(recorder.Code).To(Equal(http.StatusBadRequest))
+		Expect(fakeMounter.RemoveBindMountCallCount()).To(Equal(0))
+	})
+
+	It("returns 400 when no handle is given", func() {
+		serve("DELETE", "/containers/bind-mounts?dst=/data", "")
+
+		Expect(recorder.Code).To(Equal(http.StatusBadRequest))
+	})
+
+	It("only accepts POST and DELETE requests", func() {
+		serve("GET", "/containers/bind-mounts?handle=some-handle", "")
+
+		Expect(recorder.Code).To(Equal(http.StatusMethodNotAllowed))
+	})
+})
@@ -0,0 +1,124 @@
+package gardener
+
+import (
+	"errors"
+	"sync"
+)
+
+var (
+	ErrCursorExpired     = errors.New("cursor has expired, list all containers to get a new one")
+	ErrChangeLogDisabled = errors.New("container change log is not enabled")
+)
+
+type ChangeKind int
+
+const (
+	ChangeCreated ChangeKind = iota
+	ChangeChanged
+	ChangeDestroyed
+)
+
+// ContainerDelta describes the containers which have been created, changed
+// or destroyed since a cursor. Cursor should be passed to the next call to
+// receive only subsequent changes.
+type ContainerDelta struct {
+	Cursor    uint64   `json:"cursor"`
+	Created   []string `json:"created"`
+	Changed   []string `json:"changed"`
+	Destroyed []string `json:"destroyed"`
+}
+
+type change struct {
+	seq    uint64
+	handle string
+	kind   ChangeKind
+}
+
+// ChangeLog records container lifecycle and property changes so that
+// clients which poll the container list only need to fetch what changed.
+// Only the most recent changes are retained; older cursors expire.
+// A nil ChangeLog records nothing.
+type ChangeLog struct {
+	mu         sync.Mutex
+	seq        uint64
+	changes    []change
+	maxChanges int
+}
+
+func NewChangeLog(maxChanges int) *ChangeLog {
+	return &ChangeLog{
+		maxChanges: maxChanges,
+	}
+}
+
+func (l *ChangeLog) Record(handle string, kind ChangeKind) {
+	if l == nil {
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.seq++
+	l.changes = append(l.changes, change{seq: l.seq, handle: handle, kind: kind})
+	if len(l.changes) > l.maxChanges {
+		l.changes = l.changes[len(l.changes)-l.maxChanges:]
+	}
+}
+
+// Cursor returns a cursor which will only see changes from now on.
+func (l *ChangeLog) Cursor() uint64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.seq
+}
+
+// Since returns the changes after cursor, collapsing multiple changes to the
+// same container in to its final state.
+func (l *ChangeLog) Since(cursor uint64) (ContainerDelta, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if cursor > l.seq {
+		return ContainerDelta{}, ErrCursorExpired
+	}
+
+	if len(l.changes) > 0 && cursor < l.changes[0].seq-1 {
+		return ContainerDelta{}, ErrCursorExpired
+	}
+
+	var order []string
+	final := make(map[string]ChangeKind)
+	for _, c := range l.changes {
+		if c.seq <= cursor {
+			continue
+		}
+
+		previous, seen := final[c.handle]
+		if !seen {
+			order = append(order, c.handle)
+		}
+
+		switch {
+		case c.kind == ChangeChanged && seen && previous == ChangeCreated:
+			// still reported as created
+		default:
+			final[c.handle] = c.kind
+		}
+	}
+
+	delta := ContainerDelta{Cursor: l.seq}
+	for _, handle := range order {
+		switch final[handle] {
+		case ChangeCreated:
+			delta.Created = append(delta.Created, handle)
+		case ChangeChanged:
+			delta.Changed = append(delta.Changed, handle)
+		case ChangeDestroyed:
+			delta.Destroyed = append(delta.Destroyed, handle)
+		}
+	}
+
+	return delta, nil
+}
@@ -0,0 +1,90 @@
+package gardener_test
+
+import (
+	"github.com/cloudfoundry-incubator/guardian/gardener"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ChangeLog", func() {
+	var changeLog *gardener.ChangeLog
+
+	BeforeEach(func() {
+		changeLog = gardener.NewChangeLog(5)
+	})
+
+	It("reports changes since the cursor", func() {
+		changeLog.Record("apple", gardener.ChangeCreated)
+		cursor := changeLog.Cursor()
+
+		changeLog.Record("banana", gardener.ChangeCreated)
+		changeLog.Record("apple", gardener.ChangeChanged)
+
+		delta, err := changeLog.Since(cursor)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(delta.Created).To(ConsistOf("banana"))
+		Expect(delta.Changed).To(ConsistOf("apple"))
+		Expect(delta.Destroyed).To(BeEmpty())
+		Expect(delta.Cursor).To(Equal(changeLog.Cursor()))
+	})
+
+	It("reports nothing when nothing has changed", func() {
+		changeLog.Record("apple", gardener.ChangeCreated)
+
+		delta, err := changeLog.Since(changeLog.Cursor())
+		Expect(err).NotTo(HaveOccurred())
+		Expect(delta.Created).To(BeEmpty())
+		Expect(delta.Changed).To(BeEmpty())
+		Expect(delta.Destroyed).To(BeEmpty())
+	})
+
+	It("reports a container created and then changed as created", func() {
+		changeLog.Record("apple", gardener.ChangeCreated)
+		changeLog.Record("apple", gardener.ChangeChanged)
+
+		delta, err := changeLog.Since(0)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(delta.Created).To(ConsistOf("apple"))
+		Expect(delta.Changed).To(BeEmpty())
+	})
+
+	It("reports a container which has been destroyed as destroyed", func() {
+		changeLog.Record("apple", gardener.ChangeCreated)
+		changeLog.Record("apple", gardener.ChangeChanged)
+		changeLog.Record("apple", gardener.ChangeDestroyed)
+
+		delta, err := changeLog.Since(0)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(delta.Created).To(BeEmpty())
+		Expect(delta.Destroyed).To(ConsistOf("apple"))
+	})
+
+	Context("when changes older than the cursor have been discarded", func() {
+		It("returns ErrCursorExpired", func() {
+			for i := 0; i < 7; i++ {
+				changeLog.Record("apple", gardener.ChangeChanged)
+			}
+
+			_, err := changeLog.Since(1)
+			Expect(err).To(Equal(gardener.ErrCursorExpired))
+
+			_, err = changeLog.Since(2)
+			Expect(err).NotTo(HaveOccurred())
+		})
+	})
+
+	Context("when the cursor is from the future (e.g. after a restart)", func() {
+		It("returns ErrCursorExpired", func() {
+			_, err := changeLog.Since(42)
+			Expect(err).To(Equal(gardener.ErrCursorExpired))
+		})
+	})
+
+	Context("when the change log is nil", func() {
+		It("does not record anything", func() {
+			var nilLog *gardener.ChangeLog
+			Expect(func() { nilLog.Record("apple", gardener.ChangeCreated) }).NotTo(Panic())
+		})
+	})
+})
@@ -0,0 +1,55 @@
+package gardener
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+)
+
+//go:generate counterfeiter . DeltaLister
+
+type DeltaLister interface {
+	ContainersSnapshot() (ContainerDelta, error)
+	ContainersSince(cursor uint64) (ContainerDelta, error)
+}
+
+// ChangesHandler serves delta container listings. Without a `since` query
+// parameter every container is listed as created; clients then pass the
+// returned cursor as `since` to receive only subsequent changes. An expired
+// cursor results in 410 Gone, after which the client should list again
+// without `since`.
+type ChangesHandler struct {
+	Lister DeltaLister
+}
+
+func (h *ChangesHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var (
+		delta ContainerDelta
+		err   error
+	)
+
+	if since := r.URL.Query().Get("since"); since == "" {
+		delta, err = h.Lister.ContainersSnapshot()
+	} else {
+		cursor, parseErr := strconv.ParseUint(since, 10, 64)
+		if parseErr != nil {
+			writeError(w, r, "invalid cursor: "+since, http.StatusBadRequest)
+			return
+		}
+
+		delta, err = h.Lister.ContainersSince(cursor)
+	}
+
+	switch err {
+	case nil:
+	case ErrCursorExpired:
+		writeError(w, r, err.Error(), http.StatusGone)
+		return
+	default:
+		writeError(w, r, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(delta)
+}
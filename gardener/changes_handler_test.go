@@ -0,0 +1,87 @@
+package gardener_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/cloudfoundry-incubator/guardian/gardener"
+	"github.com/cloudfoundry-incubator/guardian/gardener/fakes"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ChangesHandler", func() {
+	var (
+		fakeLister *fakes.FakeDeltaLister
+		handler    *gardener.ChangesHandler
+		recorder   *httptest.ResponseRecorder
+	)
+
+	get := func(url string) {
+		req, err := http.NewRequest("GET", url, nil)
+		Expect(err).NotTo(HaveOccurred())
+		handler.ServeHTTP(recorder, req)
+	}
+
+	BeforeEach(func() {
+		fakeLister = new(fakes.FakeDeltaLister)
+		handler = &gardener.ChangesHandler{Lister: fakeLister}
+		recorder = httptest.NewRecorder()
+	})
+
+	Context("when no cursor is given", func() {
+		It("returns a snapshot of every container", func() {
+			fakeLister.ContainersSnapshotReturns(gardener.ContainerDelta{Cursor: 3, Created: []string{"apple"}}, nil)
+
+			get("/containers/changes")
+
+			Expect(recorder.Code).To(Equal(http.StatusOK))
+			var delta gardener.ContainerDelta
+			Expect(json.NewDecoder(recorder.Body).Decode(&delta)).To(Succeed())
+			Expect(delta.Cursor).To(BeEquivalentTo(3))
+			Expect(delta.Created).To(ConsistOf("apple"))
+		})
+	})
+
+	Context("when a cursor is given", func() {
+		It("returns the changes since the cursor", func() {
+			fakeLister.ContainersSinceReturns(gardener.ContainerDelta{Cursor: 7, Destroyed: []string{"banana"}}, nil)
+
+			get("/containers/changes?since=5")
+
+			Expect(fakeLister.ContainersSinceArgsForCall(0)).To(BeEquivalentTo(5))
+			Expect(recorder.Code).To(Equal(http.StatusOK))
+			var delta gardener.ContainerDelta
+			Expect(json.NewDecoder(recorder.Body).Decode(&delta)).To(Succeed())
+			Expect(delta.Destroyed).To(ConsistOf("banana"))
+		})
+
+		Context("and it has expired", func() {
+			It("returns 410 Gone", func() {
+				fakeLister.ContainersSinceReturns(gardener.ContainerDelta{}, gardener.ErrCursorExpired)
+
+				get("/containers/changes?since=5")
+				Expect(recorder.Code).To(Equal(http.StatusGone))
+			})
+		})
+
+		Context("and it is not a number", func() {
+			It("returns 400 Bad Request", func() {
+				get("/containers/changes?since=potato")
+				Expect(recorder.Code).To(Equal(http.StatusBadRequest))
+			})
+		})
+	})
+
+	Context("when listing fails", func() {
+		It("returns 500", func() {
+			fakeLister.ContainersSnapshotReturns(gardener.ContainerDelta{}, errors.New("boom"))
+
+			get("/containers/changes")
+			Expect(recorder.Code).To(Equal(http.StatusInternalServerError))
+		})
+	})
+})
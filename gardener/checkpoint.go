@@ -0,0 +1,157 @@
+package gardener
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/cloudfoundry-incubator/garden"
+	"github.com/pivotal-golang/lager"
+)
+
+// CheckpointPropertiesFile is written alongside an exported checkpoint so
+// that the container's properties, which include its network configuration,
+// can be restored with it on another host.
+const CheckpointPropertiesFile = "properties.json"
+
+// Checkpoint stops the container, saving the state of its processes so that
+// it can later be restored with Restore. If destination is not empty the
+// checkpoint, the container's rootfs and its properties are also exported to
+// the directory of that name in CheckpointDir, which allows the container to
+// be restored on another host or after guardian's in-memory state has been
+// lost (e.g. by a reboot).
+func (g *Gardener) Checkpoint(handle, destination string) error {
+	log := g.Logger.Session("checkpoint", lager.Data{"handle": handle, "destination": destination})
+
+	log.Info("started")
+	defer log.Info("finished")
+
+	destination, err := g.checkpointPath(destination)
+	if err != nil {
+		return err
+	}
+
+	if err := g.Containerizer.Checkpoint(log, handle, destination); err != nil {
+		return err
+	}
+
+	if err := g.Networker.Checkpoint(log, handle); err != nil {
+		return err
+	}
+
+	if destination != "" {
+		if err := g.exportProperties(handle, destination); err != nil {
+			log.Error("export-properties-failed", err)
+			return err
+		}
+	}
+
+	g.Events.Publish(Event{Handle: handle, Type: EventCheckpoint})
+	return nil
+}
+
+// Restore restores a container previously checkpointed with Checkpoint. If
+// source is not empty, the container is restored from the checkpoint exported
+// with that name, along with its properties.
+func (g *Gardener) Restore(handle, source string) error {
+	log := g.Logger.Session("restore", lager.Data{"handle": handle, "source": source})
+
+	log.Info("started")
+	defer log.Info("finished")
+
+	source, err := g.checkpointPath(source)
+	if err != nil {
+		return err
+	}
+
+	if source != "" {
+		if err := g.importProperties(handle, source); err != nil {
+			log.Error("import-properties-failed", err)
+			return err
+		}
+	}
+
+	if err := g.Containerizer.Restore(log, handle, source); err != nil {
+		return err
+	}
+
+	if err := g.Networker.Restore(log, handle); err != nil {
+		return err
+	}
+
+	if source != "" {
+		g.ChangeLog.Record(handle, ChangeCreated)
+	}
+
+	g.Events.Publish(Event{Handle: handle, Type: EventRestore})
+	return nil
+}
+
+// checkpointPath returns the directory in CheckpointDir of the exported
+// checkpoint with the given name. Exports are confined to CheckpointDir,
+// which only guardian writes to, since restoring one trusts its bundle.
+func (g *Gardener) checkpointPath(name string) (string, error) {
+	if name == "" {
+		return "", nil
+	}
+
+	if g.CheckpointDir == "" {
+		return "", errors.New("exporting checkpoints is not enabled")
+	}
+
+	if name == "." || name == ".." || strings.ContainsAny(name, "/\x00") {
+		return "", fmt.Errorf("invalid checkpoint name '%s'", name)
+	}
+
+	return filepath.Join(g.CheckpointDir, name), nil
+}
+
+func (g *Gardener) exportProperties(handle, destination string) error {
+	props, err := g.PropertyManager.All(handle)
+	if err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(props)
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(filepath.Join(destination, CheckpointPropertiesFile), data, 0600)
+}
+
+// importProperties sets the properties exported with a checkpoint, unless
+// the container already has properties (i.e. it is being restored on the
+// host it was checkpointed on).
+func (g *Gardener) importProperties(handle, source string) error {
+	existing, err := g.PropertyManager.All(handle)
+	if err != nil {
+		return err
+	}
+
+	if len(existing) > 0 {
+		return nil
+	}
+
+	data, err := ioutil.ReadFile(filepath.Join(source, CheckpointPropertiesFile))
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	var props garden.Properties
+	if err := json.Unmarshal(data, &props); err != nil {
+		return fmt.Errorf("decode checkpoint properties: %s", err)
+	}
+
+	for name, value := range props {
+		g.PropertyManager.Set(handle, name, value)
+	}
+
+	return nil
+}
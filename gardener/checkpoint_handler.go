@@ -0,0 +1,68 @@
+package gardener
+
+import (
+	"net/http"
+)
+
+//go:generate counterfeiter . ContainerCheckpointer
+
+type ContainerCheckpointer interface {
+	Checkpoint(handle, destination string) error
+	Restore(handle, source string) error
+}
+
+// CheckpointHandler checkpoints the container named by the `handle` query
+// parameter, exporting the checkpoint under the optional `destination` name.
+type CheckpointHandler struct {
+	Checkpointer ContainerCheckpointer
+}
+
+func (h *CheckpointHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	handle, ok := checkpointRequestHandle(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.Checkpointer.Checkpoint(handle, r.URL.Query().Get("destination")); err != nil {
+		writeError(w, r, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// RestoreHandler restores the container named by the `handle` query
+// parameter, importing it from the checkpoint exported under the optional
+// `source` name.
+type RestoreHandler struct {
+	Checkpointer ContainerCheckpointer
+}
+
+func (h *RestoreHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	handle, ok := checkpointRequestHandle(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.Checkpointer.Restore(handle, r.URL.Query().Get("source")); err != nil {
+		writeError(w, r, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func checkpointRequestHandle(w http.ResponseWriter, r *http.Request) (string, bool) {
+	if r.Method != "POST" {
+		writeError(w, r, "method not allowed", http.StatusMethodNotAllowed)
+		return "", false
+	}
+
+	handle := r.URL.Query().Get("handle")
+	if handle == "" {
+		writeError(w, r, "handle is required", http.StatusBadRequest)
+		return "", false
+	}
+
+	return handle, true
+}
@@ -0,0 +1,96 @@
+package gardener_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/cloudfoundry-incubator/guardian/gardener"
+	"github.com/cloudfoundry-incubator/guardian/gardener/fakes"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Checkpoint and restore handlers", func() {
+	var (
+		fakeCheckpointer *fakes.FakeContainerCheckpointer
+		recorder         *httptest.ResponseRecorder
+	)
+
+	serve := func(handler http.Handler, method, url string) {
+		req, err := http.NewRequest(method, url, nil)
+		Expect(err).NotTo(HaveOccurred())
+		handler.ServeHTTP(recorder, req)
+	}
+
+	BeforeEach(func() {
+		fakeCheckpointer = new(fakes.FakeContainerCheckpointer)
+		recorder = httptest.NewRecorder()
+	})
+
+	Describe("CheckpointHandler", func() {
+		var handler *gardener.CheckpointHandler
+
+		BeforeEach(func() {
+			handler = &gardener.CheckpointHandler{Checkpointer: fakeCheckpointer}
+		})
+
+		It("checkpoints the container to the destination", func() {
+			serve(handler, "POST", "/containers/checkpoint?handle=some-handle&destination=/some/dest")
+
+			Expect(recorder.Code).To(Equal(http.StatusNoContent))
+			Expect(fakeCheckpointer.CheckpointCallCount()).To(Equal(1))
+			handle, dest := fakeCheckpointer.CheckpointArgsForCall(0)
+			Expect(handle).To(Equal("some-handle"))
+			Expect(dest).To(Equal("/some/dest"))
+		})
+
+		It("returns 500 when checkpointing fails", func() {
+			fakeCheckpointer.CheckpointReturns(errors.New("criu failed"))
+			serve(handler, "POST", "/containers/checkpoint?handle=some-handle")
+
+			Expect(recorder.Code).To(Equal(http.StatusInternalServerError))
+			Expect(recorder.Body.String()).To(ContainSubstring("criu failed"))
+		})
+
+		It("returns 400 when no handle is given", func() {
+			serve(handler, "POST", "/containers/checkpoint")
+
+			Expect(recorder.Code).To(Equal(http.StatusBadRequest))
+			Expect(fakeCheckpointer.CheckpointCallCount()).To(Equal(0))
+		})
+
+		It("only accepts POST requests", func() {
+			serve(handler, "GET", "/containers/checkpoint?handle=some-handle")
+
+			Expect(recorder.Code).To(Equal(http.StatusMethodNotAllowed))
+			Expect(fakeCheckpointer.CheckpointCallCount()).To(Equal(0))
+		})
+	})
+
+	Describe("RestoreHandler", func() {
+		var handler *gardener.RestoreHandler
+
+		BeforeEach(func() {
+			handler = &gardener.RestoreHandler{Checkpointer: fakeCheckpointer}
+		})
+
+		It("restores the container from the source", func() {
+			serve(handler, "POST", "/containers/restore?handle=some-handle&source=/some/src")
+
+			Expect(recorder.Code).To(Equal(http.StatusNoContent))
+			Expect(fakeCheckpointer.RestoreCallCount()).To(Equal(1))
+			handle, src := fakeCheckpointer.RestoreArgsForCall(0)
+			Expect(handle).To(Equal("some-handle"))
+			Expect(src).To(Equal("/some/src"))
+		})
+
+		It("returns 500 when restoring fails", func() {
+			fakeCheckpointer.RestoreReturns(errors.New("criu failed"))
+			serve(handler, "POST", "/containers/restore?handle=some-handle")
+
+			Expect(recorder.Code).To(Equal(http.StatusInternalServerError))
+		})
+	})
+})
@@ -0,0 +1,236 @@
+package gardener_test
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/cloudfoundry-incubator/garden"
+	"github.com/cloudfoundry-incubator/guardian/gardener"
+	"github.com/cloudfoundry-incubator/guardian/gardener/fakes"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+	"github.com/pivotal-golang/lager"
+	"github.com/pivotal-golang/lager/lagertest"
+)
+
+var _ = Describe("Checkpointing and restoring containers", func() {
+	var (
+		networker       *fakes.FakeNetworker
+		containerizer   *fakes.FakeContainerizer
+		propertyManager *fakes.FakePropertyManager
+		hub             *gardener.EventHub
+		events          <-chan gardener.Event
+		unsubscribe     func()
+		checkpointDir   string
+		exportDir       string
+
+		gdnr *gardener.Gardener
+	)
+
+	BeforeEach(func() {
+		networker = new(fakes.FakeNetworker)
+		containerizer = new(fakes.FakeContainerizer)
+		propertyManager = new(fakes.FakePropertyManager)
+		hub = gardener.NewEventHub(10)
+		events, unsubscribe = hub.Subscribe()
+
+		var err error
+		checkpointDir, err = ioutil.TempDir("", "checkpoint")
+		Expect(err).NotTo(HaveOccurred())
+
+		exportDir = filepath.Join(checkpointDir, "some-export")
+		Expect(os.MkdirAll(exportDir, 0700)).To(Succeed())
+
+		gdnr = &gardener.Gardener{
+			Containerizer:   containerizer,
+			Networker:       networker,
+			PropertyManager: propertyManager,
+			Logger:          lagertest.NewTestLogger("test"),
+			ChangeLog:       gardener.NewChangeLog(10),
+			Events:          hub,
+			CheckpointDir:   checkpointDir,
+		}
+	})
+
+	AfterEach(func() {
+		unsubscribe()
+		Expect(os.RemoveAll(checkpointDir)).To(Succeed())
+	})
+
+	Describe("Checkpoint", func() {
+		It("checkpoints the container and its network", func() {
+			Expect(gdnr.Checkpoint("some-handle", "")).To(Succeed())
+
+			Expect(containerizer.CheckpointCallCount()).To(Equal(1))
+			_, handle, dest := containerizer.CheckpointArgsForCall(0)
+			Expect(handle).To(Equal("some-handle"))
+			Expect(dest).To(BeEmpty())
+
+			Expect(networker.CheckpointCallCount()).To(Equal(1))
+			_, handle = networker.CheckpointArgsForCall(0)
+			Expect(handle).To(Equal("some-handle"))
+		})
+
+		It("publishes a checkpoint event", func() {
+			Expect(gdnr.Checkpoint("some-handle", "")).To(Succeed())
+
+			var event gardener.Event
+			Expect(events).To(Receive(&event))
+			Expect(event.Handle).To(Equal("some-handle"))
+			Expect(event.Type).To(Equal(gardener.EventCheckpoint))
+		})
+
+		Context("when a destination is given", func() {
+			It("exports the container's properties with the checkpoint", func() {
+				propertyManager.AllReturns(garden.Properties{"kawasaki.subnet": "10.0.0.0/30"}, nil)
+
+				Expect(gdnr.Checkpoint("some-handle", "some-export")).To(Succeed())
+
+				_, _, dest := containerizer.CheckpointArgsForCall(0)
+				Expect(dest).To(Equal(exportDir))
+
+				data, err := ioutil.ReadFile(filepath.Join(exportDir, gardener.CheckpointPropertiesFile))
+				Expect(err).NotTo(HaveOccurred())
+				Expect(data).To(MatchJSON(`{"kawasaki.subnet": "10.0.0.0/30"}`))
+			})
+		})
+
+		DescribeTable("refuses destinations outside the checkpoint directory",
+			func(destination string) {
+				Expect(gdnr.Checkpoint("some-handle", destination)).To(MatchError(fmt.Sprintf("invalid checkpoint name '%s'", destination)))
+				Expect(containerizer.CheckpointCallCount()).To(Equal(0))
+			},
+			Entry("a path", "/etc"),
+			Entry("a relative path", "../etc"),
+			Entry("the parent directory", ".."),
+		)
+
+		Context("when there is no checkpoint directory", func() {
+			It("refuses to export checkpoints", func() {
+				gdnr.CheckpointDir = ""
+
+				Expect(gdnr.Checkpoint("some-handle", "some-export")).To(MatchError("exporting checkpoints is not enabled"))
+				Expect(containerizer.CheckpointCallCount()).To(Equal(0))
+			})
+		})
+
+		Context("when the containerizer fails to checkpoint the container", func() {
+			BeforeEach(func() {
+				containerizer.CheckpointReturns(errors.New("criu failed"))
+			})
+
+			It("returns the error without touching the network", func() {
+				Expect(gdnr.Checkpoint("some-handle", "")).To(MatchError("criu failed"))
+				Expect(networker.CheckpointCallCount()).To(Equal(0))
+			})
+		})
+
+		Context("when the networker fails to checkpoint the network", func() {
+			It("returns the error", func() {
+				networker.CheckpointReturns(errors.New("iptables failed"))
+				Expect(gdnr.Checkpoint("some-handle", "")).To(MatchError("iptables failed"))
+			})
+		})
+	})
+
+	Describe("Restore", func() {
+		It("restores the container and then its network", func() {
+			Expect(gdnr.Restore("some-handle", "")).To(Succeed())
+
+			Expect(containerizer.RestoreCallCount()).To(Equal(1))
+			_, handle, source := containerizer.RestoreArgsForCall(0)
+			Expect(handle).To(Equal("some-handle"))
+			Expect(source).To(BeEmpty())
+
+			Expect(networker.RestoreCallCount()).To(Equal(1))
+			_, handle = networker.RestoreArgsForCall(0)
+			Expect(handle).To(Equal("some-handle"))
+		})
+
+		It("publishes a restore event", func() {
+			Expect(gdnr.Restore("some-handle", "")).To(Succeed())
+
+			var event gardener.Event
+			Expect(events).To(Receive(&event))
+			Expect(event.Type).To(Equal(gardener.EventRestore))
+		})
+
+		Context("when a source is given", func() {
+			BeforeEach(func() {
+				Expect(ioutil.WriteFile(
+					filepath.Join(exportDir, gardener.CheckpointPropertiesFile),
+					[]byte(`{"kawasaki.subnet": "10.0.0.0/30"}`), 0600,
+				)).To(Succeed())
+			})
+
+			It("imports the exported properties before restoring the network", func() {
+				networker.RestoreStub = func(_ lager.Logger, _ string) error {
+					Expect(propertyManager.SetCallCount()).To(Equal(1))
+					return nil
+				}
+
+				Expect(gdnr.Restore("some-handle", "some-export")).To(Succeed())
+
+				handle, name, value := propertyManager.SetArgsForCall(0)
+				Expect(handle).To(Equal("some-handle"))
+				Expect(name).To(Equal("kawasaki.subnet"))
+				Expect(value).To(Equal("10.0.0.0/30"))
+			})
+
+			It("records the container as created in the change log", func() {
+				Expect(gdnr.Restore("some-handle", "some-export")).To(Succeed())
+
+				delta, err := gdnr.ContainersSince(0)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(delta.Created).To(ConsistOf("some-handle"))
+			})
+
+			Context("when the container already has properties", func() {
+				It("keeps the existing properties", func() {
+					propertyManager.AllReturns(garden.Properties{"foo": "bar"}, nil)
+
+					Expect(gdnr.Restore("some-handle", "some-export")).To(Succeed())
+					Expect(propertyManager.SetCallCount()).To(Equal(0))
+				})
+			})
+
+			Context("when the exported properties are invalid", func() {
+				It("returns an error without restoring", func() {
+					Expect(ioutil.WriteFile(
+						filepath.Join(exportDir, gardener.CheckpointPropertiesFile), []byte("{"), 0600,
+					)).To(Succeed())
+
+					Expect(gdnr.Restore("some-handle", "some-export")).To(MatchError(ContainSubstring("decode checkpoint properties")))
+					Expect(containerizer.RestoreCallCount()).To(Equal(0))
+				})
+			})
+		})
+
+		It("refuses sources outside the checkpoint directory", func() {
+			Expect(gdnr.Restore("some-handle", "/tmp/evil")).To(MatchError("invalid checkpoint name '/tmp/evil'"))
+			Expect(containerizer.RestoreCallCount()).To(Equal(0))
+		})
+
+		Context("when the containerizer fails to restore the container", func() {
+			BeforeEach(func() {
+				containerizer.RestoreReturns(errors.New("criu failed"))
+			})
+
+			It("returns the error without restoring the network", func() {
+				Expect(gdnr.Restore("some-handle", "")).To(MatchError("criu failed"))
+				Expect(networker.RestoreCallCount()).To(Equal(0))
+			})
+		})
+
+		Context("when the networker fails to restore the network", func() {
+			It("returns the error", func() {
+				networker.RestoreReturns(errors.New("subnet taken"))
+				Expect(gdnr.Restore("some-handle", "")).To(MatchError("subnet taken"))
+			})
+		})
+	})
+})
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"strconv"
 	"time"
 
 	"github.com/cloudfoundry-incubator/garden"
@@ -17,6 +18,17 @@ type container struct {
 	containerizer   Containerizer
 	networker       Networker
 	propertyManager PropertyManager
+	changeLog       *ChangeLog
+	events          *EventHub
+	processLimiter  *ProcessLimiter
+	execLimiter     *ExecLimiter
+	exits           *ExitTracker
+	outputLimiter   *OutputLimiter
+	cpuLimiter      CPULimiter
+	ages            AgeReader
+	throttles       ThrottleChecker
+	maxPids         int64
+	runPea          func(log lager.Logger, handle string, spec garden.ProcessSpec, io garden.ProcessIO) (garden.Process, error)
 }
 
 func (c *container) Handle() string {
@@ -24,7 +36,121 @@ func (c *container) Handle() string {
 }
 
 func (c *container) Run(spec garden.ProcessSpec, io garden.ProcessIO) (garden.Process, error) {
-	return c.containerizer.Run(c.logger, c.handle, spec, io)
+	outputRate, err := c.outputRate()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := c.acquireExecSlot(); err != nil {
+		return nil, err
+	}
+
+	slot, err := c.processLimiter.Acquire(c.handle)
+	if err != nil {
+		c.execLimiter.Release(c.handle)
+		return nil, err
+	}
+
+	io = c.outputLimiter.Limit(c.handle, outputRate, io)
+
+	process, err := c.run(spec, io)
+	if err != nil {
+		c.processLimiter.Release(slot)
+		c.execLimiter.Release(c.handle)
+		return nil, err
+	}
+
+	process = c.exits.Track(c.handle, process)
+
+	if c.events != nil || c.processLimiter != nil || c.execLimiter != nil {
+		go c.awaitExit(process, slot)
+	}
+
+	return process, nil
+}
+
+// run runs the process in the container, or in a pea if it has an image of
+// its own, in which case the container's image defaults do not apply
+func (c *container) run(spec garden.ProcessSpec, io garden.ProcessIO) (garden.Process, error) {
+	if spec.Image.URI != "" {
+		return c.runPea(c.logger, c.handle, spec, io)
+	}
+
+	return c.containerizer.Run(c.logger, c.handle, c.withImageDefaults(spec), io)
+}
+
+// outputRate returns the rate the container's output-rate property limits its
+// output to (0 if it has none)
+func (c *container) outputRate() (int64, error) {
+	if c.outputLimiter == nil {
+		return 0, nil
+	}
+
+	properties, err := c.propertyManager.All(c.handle)
+	if err != nil {
+		return 0, fmt.Errorf("read %s property: %s", OutputRateProperty, err)
+	}
+
+	return parseOutputRate(properties)
+}
+
+// acquireExecSlot waits for one of the container's slots for processes
+// started by Run and Attach, with the limits in its properties. The slot is
+// held until the process exits.
+func (c *container) acquireExecSlot() error {
+	if c.execLimiter == nil {
+		return nil
+	}
+
+	properties := garden.Properties{}
+	for _, name := range []string{ExecConcurrencyProperty, ExecQueueTimeoutProperty} {
+		if value, err := c.propertyManager.Get(c.handle, name); err == nil {
+			properties[name] = value
+		}
+	}
+
+	max, queueTimeout, err := parseExecConcurrency(properties)
+	if err != nil {
+		return err
+	}
+
+	return c.execLimiter.Acquire(c.handle, max, queueTimeout)
+}
+
+func (c *container) awaitExit(process garden.Process, slot ProcessSlot) {
+	status, err := waitStatus(process)
+	c.processLimiter.Release(slot)
+	c.execLimiter.Release(c.handle)
+
+	if c.events == nil {
+		return
+	}
+
+	data := map[string]string{"process-id": process.ID()}
+	if err != nil {
+		data["error"] = err.Error()
+	} else {
+		data["exit-status"] = strconv.Itoa(status.Code)
+		if status.Reason != "" {
+			data["reason"] = string(status.Reason)
+		}
+		if status.Signalled() {
+			data["signal"] = strconv.Itoa(status.Signal)
+		}
+	}
+
+	c.events.Publish(Event{Handle: c.handle, Type: EventProcessExit, Data: data})
+}
+
+// waitStatus waits for the process, with the reason it exited if it is
+// tracked
+func waitStatus(process garden.Process) (ExitStatus, error) {
+	if waiter, ok := process.(ExitStatusWaiter); ok {
+		return waiter.WaitStatus()
+	}
+
+	code, err := process.Wait()
+	return ExitStatus{Code: code}, err
 }
 
 func (c *container) Stop(kill bool) error {
@@ -57,6 +183,8 @@ func (c *container) Info() (garden.ContainerInfo, error) {
 		return garden.ContainerInfo{}, err
 	}
 
+	properties = withoutHidden(properties)
+
 	mappedPorts := []garden.PortMapping{}
 
 	mappedPortsCfg, err := c.propertyManager.Get(c.handle, MappedPortsKey)
@@ -67,8 +195,29 @@ func (c *container) Info() (garden.ContainerInfo, error) {
 
 	json.Unmarshal([]byte(mappedPortsCfg), &mappedPorts)
 
+	if limits, ok := c.currentLimits(); ok {
+		withLimits := garden.Properties{LimitsProperty: limits}
+		for name, value := range properties {
+			withLimits[name] = value
+		}
+
+		properties = withLimits
+	}
+
+	if c.throttles != nil {
+		withThrottled := garden.Properties{CPUThrottledProperty: strconv.FormatBool(c.throttles.IsThrottled(c.handle))}
+		for name, value := range properties {
+			withThrottled[name] = value
+		}
+
+		properties = withThrottled
+	}
+
+	properties = c.withAge(properties)
+
 	return garden.ContainerInfo{
 		State:         "active",
+		Events:        actualContainerSpec.Events,
 		ContainerIP:   containerIP,
 		HostIP:        hostIP,
 		ExternalIP:    externalIP,
@@ -78,6 +227,28 @@ func (c *container) Info() (garden.ContainerInfo, error) {
 	}, nil
 }
 
+// currentLimits is the container's current limits as JSON, if the CPULimiter
+// can report them
+func (c *container) currentLimits() (string, bool) {
+	limiter, ok := c.cpuLimiter.(Limiter)
+	if !ok {
+		return "", false
+	}
+
+	limits, err := limiter.CurrentLimits(c.logger, c.handle)
+	if err != nil {
+		c.logger.Error("current-limits-failed", err, lager.Data{"handle": c.handle})
+		return "", false
+	}
+
+	limitsJSON, err := json.Marshal(limits)
+	if err != nil {
+		return "", false
+	}
+
+	return string(limitsJSON), true
+}
+
 func (c *container) StreamIn(spec garden.StreamInSpec) error {
 	return c.containerizer.StreamIn(c.logger, c.handle, spec)
 }
@@ -87,19 +258,45 @@ func (c *container) StreamOut(spec garden.StreamOutSpec) (io.ReadCloser, error)
 }
 
 func (c *container) LimitBandwidth(limits garden.BandwidthLimits) error {
-	return nil
+	return limitBandwidth(c.logger, c.networker, c.handle, limits)
 }
 
 func (c *container) CurrentBandwidthLimits() (garden.BandwidthLimits, error) {
-	return garden.BandwidthLimits{}, nil
+	bandwidthNetworker, ok := c.networker.(BandwidthNetworker)
+	if !ok {
+		return garden.BandwidthLimits{}, nil
+	}
+
+	return bandwidthNetworker.CurrentBandwidthLimits(c.logger, c.handle)
 }
 
+// LimitCPU sets the container's CPU shares and re-applies its cpu-quota
+// property, so that the quota can be changed (or, by removing the property,
+// lifted) by setting the property and then limiting the CPU.
 func (c *container) LimitCPU(limits garden.CPULimits) error {
-	return nil
+	if c.cpuLimiter == nil {
+		return nil
+	}
+
+	properties := garden.Properties{}
+	if raw, err := c.propertyManager.Get(c.handle, CPUQuotaProperty); err == nil {
+		properties[CPUQuotaProperty] = raw
+	}
+
+	quota, err := parseCPUQuota(properties)
+	if err != nil {
+		return err
+	}
+
+	return c.cpuLimiter.LimitCPU(c.logger, c.handle, limits, quota)
 }
 
 func (c *container) CurrentCPULimits() (garden.CPULimits, error) {
-	return garden.CPULimits{}, nil
+	if c.cpuLimiter == nil {
+		return garden.CPULimits{}, nil
+	}
+
+	return c.cpuLimiter.CurrentCPULimits(c.logger, c.handle)
 }
 
 func (c *container) LimitDisk(limits garden.DiskLimits) error {
@@ -111,11 +308,26 @@ func (c *container) CurrentDiskLimits() (garden.DiskLimits, error) {
 }
 
 func (c *container) LimitMemory(limits garden.MemoryLimits) error {
-	return nil
+	limiter, ok := c.cpuLimiter.(Limiter)
+	if !ok {
+		return nil
+	}
+
+	return limiter.LimitMemory(c.logger, c.handle, limits)
 }
 
 func (c *container) CurrentMemoryLimits() (garden.MemoryLimits, error) {
-	return garden.MemoryLimits{}, nil
+	limiter, ok := c.cpuLimiter.(Limiter)
+	if !ok {
+		return garden.MemoryLimits{}, nil
+	}
+
+	limits, err := limiter.CurrentLimits(c.logger, c.handle)
+	if err != nil {
+		return garden.MemoryLimits{}, err
+	}
+
+	return limits.Memory, nil
 }
 
 func (c *container) NetIn(hostPort, containerPort uint32) (uint32, uint32, error) {
@@ -126,8 +338,29 @@ func (c *container) NetOut(netOutRule garden.NetOutRule) error {
 	return c.networker.NetOut(c.logger, c.handle, netOutRule)
 }
 
+func (c *container) BulkNetOut(netOutRules []garden.NetOutRule) error {
+	return c.networker.BulkNetOut(c.logger, c.handle, netOutRules)
+}
+
 func (c *container) Attach(processID string, io garden.ProcessIO) (garden.Process, error) {
-	return nil, nil
+	if err := c.acquireExecSlot(); err != nil {
+		return nil, err
+	}
+
+	process, err := c.containerizer.Attach(c.logger, c.handle, processID, io)
+	if err != nil {
+		c.execLimiter.Release(c.handle)
+		return nil, err
+	}
+
+	if c.execLimiter != nil {
+		go func() {
+			process.Wait()
+			c.execLimiter.Release(c.handle)
+		}()
+	}
+
+	return process, nil
 }
 
 func (c *container) Metrics() (garden.Metrics, error) {
@@ -135,22 +368,113 @@ func (c *container) Metrics() (garden.Metrics, error) {
 }
 
 func (c *container) Properties() (garden.Properties, error) {
-	return c.propertyManager.All(c.handle)
+	properties, err := c.propertyManager.All(c.handle)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.withAge(withoutHidden(properties)), nil
 }
 
 func (c *container) Property(name string) (string, error) {
+	if hiddenProperties[name] {
+		return "", fmt.Errorf("cannot Get %s:%s", c.handle, name)
+	}
+
+	if name == AgeProperty && c.ages != nil {
+		if age, ok := c.ages.Age(c.handle); ok {
+			return age.String(), nil
+		}
+	}
+
 	return c.propertyManager.Get(c.handle, name)
 }
 
+// withAge replaces the AgeProperty last persisted with the container's
+// current age, if it is known
+func (c *container) withAge(properties garden.Properties) garden.Properties {
+	if c.ages == nil {
+		return properties
+	}
+
+	age, ok := c.ages.Age(c.handle)
+	if !ok {
+		return properties
+	}
+
+	withAge := garden.Properties{AgeProperty: age.String()}
+	for name, value := range properties {
+		if name != AgeProperty {
+			withAge[name] = value
+		}
+	}
+
+	return withAge
+}
+
+// SetProperty sets a property. Setting the max-pids property also changes the
+// running container's pid limit, when the CPULimiter can, capped at the
+// server's maximum; the capped limit is what is set.
 func (c *container) SetProperty(name string, value string) error {
+	if err := checkChangeable(name); err != nil {
+		return err
+	}
+
+	if name == OutputRateProperty {
+		if _, err := parseOutputRate(garden.Properties{name: value}); err != nil {
+			return err
+		}
+	}
+
+	if name == MaxPidsProperty {
+		maxPids, err := c.limitPids(value)
+		if err != nil {
+			return err
+		}
+
+		value = strconv.FormatInt(maxPids, 10)
+	}
+
 	c.propertyManager.Set(c.handle, name, value)
+	c.changeLog.Record(c.handle, ChangeChanged)
 	return nil
 }
 
+// limitPids changes the running container's pid limit, returning the limit
+// it set
+func (c *container) limitPids(value string) (int64, error) {
+	maxPids, err := parseMaxPids(garden.Properties{MaxPidsProperty: value})
+	if err != nil {
+		return 0, err
+	}
+
+	if maxPids == 0 && c.maxPids > 0 {
+		return 0, fmt.Errorf("invalid %s property: containers may not have more than %d pids", MaxPidsProperty, c.maxPids)
+	}
+	maxPids = capMaxPids(maxPids, c.maxPids)
+
+	limiter, ok := c.cpuLimiter.(Limiter)
+	if !ok {
+		return maxPids, nil
+	}
+
+	return maxPids, limiter.LimitPids(c.logger, c.handle, maxPids)
+}
+
 func (c *container) RemoveProperty(name string) error {
-	return c.propertyManager.Remove(c.handle, name)
+	if err := checkChangeable(name); err != nil {
+		return err
+	}
+
+	if err := c.propertyManager.Remove(c.handle, name); err != nil {
+		return err
+	}
+
+	c.changeLog.Record(c.handle, ChangeChanged)
+	return nil
 }
 
 func (c *container) SetGraceTime(t time.Duration) error {
+	c.propertyManager.Set(c.handle, GraceTimeKey, t.String())
 	return nil
 }
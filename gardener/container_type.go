@@ -0,0 +1,41 @@
+package gardener
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/cloudfoundry-incubator/garden"
+)
+
+// ContainerTypeProperty is the container property which may hold the type
+// of the container. The only type is ContainerRunnerType; containers without
+// the property are ordinary containers.
+const ContainerTypeProperty = "container-type"
+
+// ContainerRunnerType is the type of containers which run containers of their
+// own, e.g. a guardian inside a guardian used by CI to build images. They
+// must be privileged, and are only allowed when the Gardener's
+// AllowContainerRunners is set, since they are given every capability, the
+// loop and fuse devices, their cgroups and no seccomp profile.
+const ContainerRunnerType = "container-runner"
+
+func validateContainerType(spec garden.ContainerSpec, allowContainerRunners bool) error {
+	containerType, ok := spec.Properties[ContainerTypeProperty]
+	if !ok {
+		return nil
+	}
+
+	if containerType != ContainerRunnerType {
+		return fmt.Errorf("invalid %s property: '%s'", ContainerTypeProperty, containerType)
+	}
+
+	if !allowContainerRunners {
+		return errors.New("container-runner containers are not allowed")
+	}
+
+	if !spec.Privileged {
+		return errors.New("container-runner containers must be privileged")
+	}
+
+	return nil
+}
@@ -0,0 +1,254 @@
+package gardener
+
+import (
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/cloudfoundry-incubator/garden"
+	"github.com/pivotal-golang/clock"
+	"github.com/pivotal-golang/lager"
+)
+
+// CPUMaxProperty is the container property which may hold the most CPU the
+// container should use, in the same forms as CPUQuotaProperty, e.g. "1.5"
+// cores. Unlike a cpu-quota it is not enforced by the kernel from the start:
+// what happens when the container persistently uses more is its
+// CPUMaxActionProperty.
+const CPUMaxProperty = "cpu-max"
+
+// CPUMaxActionProperty is the container property which may hold what is
+// done when the container exceeds its cpu-max: CPUMaxActionNotify (the
+// default) or CPUMaxActionThrottle
+const CPUMaxActionProperty = "cpu-max-action"
+
+const (
+	// CPUMaxActionNotify only publishes an EventCPUMaxExceeded
+	CPUMaxActionNotify = "notify"
+
+	// CPUMaxActionThrottle also sets the container's CFS quota to its
+	// cpu-max, so that the kernel enforces it. The quota stays until the
+	// container's CPU is next limited, which re-applies its cpu-quota.
+	CPUMaxActionThrottle = "throttle"
+)
+
+//go:generate counterfeiter . CPUUsageReporter
+
+// CPUUsageReporter reports the total CPU time used by a container's
+// processes since it was created
+type CPUUsageReporter interface {
+	CPUUsage(log lager.Logger, handle string) (time.Duration, error)
+}
+
+// CPUMaxWatcher periodically compares the CPU each container with a cpu-max
+// used since the last check with its cpu-max. The first time a container
+// has used more for ExceedAfter checks in a row, it publishes an
+// EventCPUMaxExceeded and takes the container's cpu-max-action, so that
+// platforms can try out a CPU policy by being notified of its breaches
+// before enforcing it. A container which is notified and falls back under
+// its cpu-max may exceed it again.
+type CPUMaxWatcher struct {
+	Reporter   CPUUsageReporter
+	Lister     HandleLister
+	Properties PropertyManager
+	Limiter    CPULimiter
+
+	ExceedAfter int
+
+	// Publisher is optional
+	Publisher EventPublisher
+
+	Clock    clock.Clock
+	Interval time.Duration
+	Logger   lager.Logger
+
+	mu         sync.Mutex
+	containers map[string]*cpuMaxUsage
+}
+
+// cpuMaxUsage is what the watcher knows of a container
+type cpuMaxUsage struct {
+	usage     time.Duration
+	sampledAt time.Time
+
+	over     int
+	exceeded bool
+	action   string
+}
+
+// Start begins checking in the background every interval.
+func (w *CPUMaxWatcher) Start() error {
+	go func() {
+		ticker := w.Clock.NewTicker(w.Interval)
+		defer ticker.Stop()
+
+		for range ticker.C() {
+			w.Check()
+		}
+	}()
+
+	return nil
+}
+
+// Check compares the CPU usage of every container with a cpu-max once.
+func (w *CPUMaxWatcher) Check() {
+	log := w.Logger.Session("check-cpu-max")
+
+	handles, err := w.Lister.Handles()
+	if err != nil {
+		log.Error("list-handles-failed", err)
+		return
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.containers == nil {
+		w.containers = make(map[string]*cpuMaxUsage)
+	}
+
+	seen := make(map[string]bool)
+	now := w.Clock.Now()
+	for _, handle := range handles {
+		max, ok := w.cpuMax(log, handle)
+		if !ok {
+			continue
+		}
+		seen[handle] = true
+
+		usage, err := w.Reporter.CPUUsage(log, handle)
+		if err != nil {
+			log.Error("cpu-usage-failed", err, lager.Data{"handle": handle})
+			continue
+		}
+
+		c, ok := w.containers[handle]
+		if !ok {
+			c = &cpuMaxUsage{}
+			w.containers[handle] = c
+		}
+
+		previous, elapsed := c.usage, now.Sub(c.sampledAt)
+		first := c.sampledAt.IsZero()
+		c.usage, c.sampledAt = usage, now
+
+		if first || elapsed <= 0 || usage < previous {
+			continue
+		}
+
+		used := float64(usage-previous) / float64(elapsed)
+		if used <= cores(max) {
+			c.over = 0
+
+			// a throttled container cannot exceed its cpu-max again
+			if c.exceeded && c.action != CPUMaxActionThrottle {
+				c.exceeded = false
+			}
+
+			continue
+		}
+
+		c.over++
+		if c.exceeded || c.over < w.ExceedAfter {
+			continue
+		}
+
+		c.exceeded = true
+		c.action = w.cpuMaxExceeded(log, handle, max, used)
+	}
+
+	for handle := range w.containers {
+		if !seen[handle] {
+			delete(w.containers, handle)
+		}
+	}
+}
+
+// Exceeded is the number of containers which have exceeded their cpu-max,
+// and have either been throttled or not yet fallen back under it, by their
+// cpu-max-action, e.g. for a metric
+func (w *CPUMaxWatcher) Exceeded() map[string]float64 {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	exceeded := map[string]float64{CPUMaxActionNotify: 0, CPUMaxActionThrottle: 0}
+	for _, c := range w.containers {
+		if c.exceeded {
+			exceeded[c.action]++
+		}
+	}
+
+	return exceeded
+}
+
+// cpuMax is the container's cpu-max, and whether it has a valid one
+func (w *CPUMaxWatcher) cpuMax(log lager.Logger, handle string) (CPUQuota, bool) {
+	raw, err := w.Properties.Get(handle, CPUMaxProperty)
+	if err != nil || raw == "" {
+		return CPUQuota{}, false
+	}
+
+	max, err := parseCPUQuotaValue(raw)
+	if err != nil {
+		log.Error("invalid-cpu-max", err, lager.Data{"handle": handle, "cpu-max": raw})
+		return CPUQuota{}, false
+	}
+
+	return max, true
+}
+
+// cpuMaxExceeded publishes the event and takes the container's action,
+// returning the action
+func (w *CPUMaxWatcher) cpuMaxExceeded(log lager.Logger, handle string, max CPUQuota, used float64) string {
+	action, err := w.Properties.Get(handle, CPUMaxActionProperty)
+	if err != nil || action != CPUMaxActionThrottle {
+		action = CPUMaxActionNotify
+	}
+
+	log = log.Session("cpu-max-exceeded", lager.Data{"handle": handle, "action": action, "used": used, "max": cores(max)})
+	log.Info("started")
+	defer log.Info("finished")
+
+	if w.Publisher != nil {
+		w.Publisher.Publish(Event{Handle: handle, Type: EventCPUMaxExceeded, Data: map[string]string{
+			"action": action,
+			"used":   strconv.FormatFloat(used, 'f', 3, 64),
+			"max":    strconv.FormatFloat(cores(max), 'f', 3, 64),
+		}})
+	}
+
+	if action == CPUMaxActionThrottle {
+		// zero shares leaves the container's shares as they are
+		if err := w.Limiter.LimitCPU(log, handle, garden.CPULimits{}, max); err != nil {
+			log.Error("limit-cpu-failed", err)
+		}
+	}
+
+	return action
+}
+
+// cores is the number of CPUs' worth of time the quota allows
+func cores(quota CPUQuota) float64 {
+	return float64(quota.Quota) / float64(quota.Period)
+}
+
+func validateCPUMax(properties garden.Properties) error {
+	if raw, ok := properties[CPUMaxProperty]; ok {
+		if _, err := parseCPUQuotaValue(raw); err != nil {
+			return fmt.Errorf("invalid %s property: '%s': %s", CPUMaxProperty, raw, err)
+		}
+	}
+
+	action, ok := properties[CPUMaxActionProperty]
+	if !ok {
+		return nil
+	}
+
+	switch action {
+	case CPUMaxActionNotify, CPUMaxActionThrottle:
+		return nil
+	}
+
+	return fmt.Errorf("invalid %s property: '%s'", CPUMaxActionProperty, action)
+}
@@ -0,0 +1,190 @@
+package gardener_test
+
+import (
+	"errors"
+	"time"
+
+	"github.com/cloudfoundry-incubator/garden"
+	"github.com/cloudfoundry-incubator/guardian/gardener"
+	"github.com/cloudfoundry-incubator/guardian/gardener/fakes"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+	"github.com/pivotal-golang/clock/fakeclock"
+	"github.com/pivotal-golang/lager"
+	"github.com/pivotal-golang/lager/lagertest"
+)
+
+var _ = Describe("CPUMaxWatcher", func() {
+	var (
+		logger     *lagertest.TestLogger
+		reporter   *fakes.FakeCPUUsageReporter
+		lister     *fakes.FakeHandleLister
+		properties *fakes.FakePropertyManager
+		limiter    *fakes.FakeCPULimiter
+		publisher  *fakes.FakeEventPublisher
+		fakeClock  *fakeclock.FakeClock
+
+		usage map[string]time.Duration
+		props map[string]map[string]string
+
+		watcher *gardener.CPUMaxWatcher
+	)
+
+	BeforeEach(func() {
+		logger = lagertest.NewTestLogger("test")
+		reporter = new(fakes.FakeCPUUsageReporter)
+		lister = new(fakes.FakeHandleLister)
+		properties = new(fakes.FakePropertyManager)
+		limiter = new(fakes.FakeCPULimiter)
+		publisher = new(fakes.FakeEventPublisher)
+		fakeClock = fakeclock.NewFakeClock(time.Now())
+
+		usage = map[string]time.Duration{}
+		props = map[string]map[string]string{
+			"busy":      {gardener.CPUMaxProperty: "1"},
+			"idle":      {gardener.CPUMaxProperty: "1"},
+			"unlimited": {},
+		}
+
+		lister.HandlesReturns([]string{"busy", "idle", "unlimited"}, nil)
+		reporter.CPUUsageStub = func(_ lager.Logger, handle string) (time.Duration, error) {
+			return usage[handle], nil
+		}
+		properties.GetStub = func(handle, name string) (string, error) {
+			value, ok := props[handle][name]
+			if !ok {
+				return "", errors.New("no such property")
+			}
+
+			return value, nil
+		}
+
+		watcher = &gardener.CPUMaxWatcher{
+			Reporter:    reporter,
+			Lister:      lister,
+			Properties:  properties,
+			Limiter:     limiter,
+			ExceedAfter: 2,
+			Publisher:   publisher,
+			Clock:       fakeClock,
+			Interval:    time.Hour,
+			Logger:      logger,
+		}
+	})
+
+	// check passes a minute, in which busy uses two CPUs and idle uses half of
+	// one, and checks
+	check := func() {
+		fakeClock.Increment(time.Minute)
+		usage["busy"] += 2 * time.Minute
+		usage["idle"] += 30 * time.Second
+		watcher.Check()
+	}
+
+	It("does not act until a container has used more than its cpu-max for ExceedAfter checks in a row", func() {
+		watcher.Check()
+		check()
+		Expect(publisher.PublishCallCount()).To(Equal(0))
+
+		check()
+		Expect(publisher.PublishCallCount()).To(Equal(1))
+	})
+
+	It("only samples containers with a cpu-max", func() {
+		watcher.Check()
+		Expect(reporter.CPUUsageCallCount()).To(Equal(2))
+	})
+
+	It("publishes an event for a container which exceeds its cpu-max once, and does not limit it by default", func() {
+		watcher.Check()
+		check()
+		check()
+		check()
+
+		Expect(publisher.PublishCallCount()).To(Equal(1))
+		event := publisher.PublishArgsForCall(0)
+		Expect(event.Handle).To(Equal("busy"))
+		Expect(event.Type).To(Equal(gardener.EventCPUMaxExceeded))
+		Expect(event.Data).To(Equal(map[string]string{
+			"action": gardener.CPUMaxActionNotify,
+			"used":   "2.000",
+			"max":    "1.000",
+		}))
+
+		Expect(limiter.LimitCPUCallCount()).To(Equal(0))
+		Expect(watcher.Exceeded()).To(Equal(map[string]float64{"notify": 1, "throttle": 0}))
+		Expect(logger).To(gbytes.Say("cpu-max-exceeded"))
+	})
+
+	It("publishes another event when a notified container falls back under its cpu-max and exceeds it again", func() {
+		watcher.Check()
+		check()
+		check()
+
+		props["busy"][gardener.CPUMaxProperty] = "3"
+		check()
+		Expect(watcher.Exceeded()).To(Equal(map[string]float64{"notify": 0, "throttle": 0}))
+
+		props["busy"][gardener.CPUMaxProperty] = "1"
+		check()
+		check()
+		Expect(publisher.PublishCallCount()).To(Equal(2))
+	})
+
+	Context("when the container's cpu-max-action is throttle", func() {
+		BeforeEach(func() {
+			props["busy"][gardener.CPUMaxActionProperty] = gardener.CPUMaxActionThrottle
+		})
+
+		It("enforces the cpu-max as the container's CFS quota, without changing its shares", func() {
+			watcher.Check()
+			check()
+			check()
+
+			Expect(limiter.LimitCPUCallCount()).To(Equal(1))
+			_, handle, limits, quota := limiter.LimitCPUArgsForCall(0)
+			Expect(handle).To(Equal("busy"))
+			Expect(limits).To(Equal(garden.CPULimits{}))
+			Expect(quota).To(Equal(gardener.CPUQuota{Quota: 100000, Period: gardener.DefaultCPUPeriod}))
+
+			Expect(publisher.PublishArgsForCall(0).Data).To(HaveKeyWithValue("action", gardener.CPUMaxActionThrottle))
+			Expect(watcher.Exceeded()).To(Equal(map[string]float64{"notify": 0, "throttle": 1}))
+		})
+
+		It("does not throttle the container again once its usage falls to its cpu-max", func() {
+			watcher.Check()
+			check()
+			check()
+
+			props["busy"][gardener.CPUMaxProperty] = "3"
+			check()
+			props["busy"][gardener.CPUMaxProperty] = "1"
+			check()
+			check()
+
+			Expect(limiter.LimitCPUCallCount()).To(Equal(1))
+			Expect(publisher.PublishCallCount()).To(Equal(1))
+		})
+	})
+
+	It("forgets containers which have been destroyed", func() {
+		watcher.Check()
+		check()
+		check()
+
+		lister.HandlesReturns([]string{"idle"}, nil)
+		check()
+		Expect(watcher.Exceeded()).To(Equal(map[string]float64{"notify": 0, "throttle": 0}))
+	})
+
+	It("ignores invalid cpu-max properties", func() {
+		props["busy"][gardener.CPUMaxProperty] = "lots"
+		watcher.Check()
+		check()
+		check()
+
+		Expect(publisher.PublishCallCount()).To(Equal(0))
+		Expect(logger).To(gbytes.Say("invalid-cpu-max"))
+	})
+})
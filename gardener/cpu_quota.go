@@ -0,0 +1,88 @@
+package gardener
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/cloudfoundry-incubator/garden"
+	"github.com/pivotal-golang/lager"
+)
+
+// CPUQuotaProperty is the container property which may hold the container's
+// absolute CPU entitlement, either as a number of cores, e.g. "1.5", or as a
+// CFS quota and period in microseconds, e.g. "50000/100000"
+const CPUQuotaProperty = "cpu-quota"
+
+// DefaultCPUPeriod is the CFS period, in microseconds, of quotas which are
+// given as a number of cores
+const DefaultCPUPeriod = 100000
+
+// The kernel's bounds on the CFS period and the smallest quota, in
+// microseconds
+const (
+	minCPUPeriod = 1000
+	maxCPUPeriod = 1000000
+	minCPUQuota  = 1000
+)
+
+// CPUQuota is a hard cap on the CPU time a container may use: Quota
+// microseconds in every Period microseconds. The zero value is no cap.
+type CPUQuota struct {
+	Quota  int64
+	Period uint64
+}
+
+//go:generate counterfeiter . CPULimiter
+
+// CPULimiter changes the CPU limits of running containers
+type CPULimiter interface {
+	LimitCPU(log lager.Logger, handle string, limits garden.CPULimits, quota CPUQuota) error
+	CurrentCPULimits(log lager.Logger, handle string) (garden.CPULimits, error)
+}
+
+func parseCPUQuota(properties garden.Properties) (CPUQuota, error) {
+	raw, ok := properties[CPUQuotaProperty]
+	if !ok {
+		return CPUQuota{}, nil
+	}
+
+	quota, err := parseCPUQuotaValue(raw)
+	if err != nil {
+		return CPUQuota{}, fmt.Errorf("invalid %s property: '%s': %s", CPUQuotaProperty, raw, err)
+	}
+
+	return quota, nil
+}
+
+func parseCPUQuotaValue(raw string) (CPUQuota, error) {
+	var quota CPUQuota
+
+	if parts := strings.SplitN(raw, "/", 2); len(parts) == 2 {
+		var err error
+		if quota.Quota, err = strconv.ParseInt(parts[0], 10, 64); err != nil {
+			return CPUQuota{}, err
+		}
+
+		if quota.Period, err = strconv.ParseUint(parts[1], 10, 64); err != nil {
+			return CPUQuota{}, err
+		}
+	} else {
+		cores, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return CPUQuota{}, err
+		}
+
+		quota = CPUQuota{Quota: int64(cores * DefaultCPUPeriod), Period: DefaultCPUPeriod}
+	}
+
+	if quota.Period < minCPUPeriod || quota.Period > maxCPUPeriod {
+		return CPUQuota{}, fmt.Errorf("period must be between %dus and %dus", minCPUPeriod, maxCPUPeriod)
+	}
+
+	if quota.Quota < minCPUQuota {
+		return CPUQuota{}, fmt.Errorf("quota must be at least %dus", minCPUQuota)
+	}
+
+	return quota, nil
+}
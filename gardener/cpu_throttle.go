@@ -0,0 +1,15 @@
+package gardener
+
+// CPUThrottledProperty is the property under which Info reports whether the
+// container is throttled for persistently using more than its CPU
+// entitlement, as "true" or "false". It is read on every Info rather than
+// stored, so it cannot be set.
+const CPUThrottledProperty = "garden.cpu-throttled"
+
+//go:generate counterfeiter . ThrottleChecker
+
+// ThrottleChecker reports whether a container is throttled, e.g. a
+// rundmc.CPUThrottler
+type ThrottleChecker interface {
+	IsThrottled(handle string) bool
+}
@@ -0,0 +1,287 @@
+package gardener
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/cloudfoundry-incubator/garden"
+	"github.com/pivotal-golang/lager"
+)
+
+// CPUSetCPUsProperty and CPUSetMemsProperty are the container properties
+// which may pin the container to CPUs and to NUMA memory nodes, as kernel
+// cpu lists, e.g. "0-3,8"
+const (
+	CPUSetCPUsProperty = "cpuset-cpus"
+	CPUSetMemsProperty = "cpuset-mems"
+)
+
+// MaxCPUListID is the highest id a cpu list may contain, the kernel's highest
+// NR_CPUS, so that parsing a list from a client cannot expand a range of
+// billions of ids
+const MaxCPUListID = 8191
+
+// CPUSet is the CPUs and NUMA memory nodes a container is pinned to, as
+// kernel cpu lists. Empty lists mean no restriction.
+type CPUSet struct {
+	CPUs string
+	Mems string
+}
+
+// OnlineCPUs is the host's online CPUs and NUMA memory nodes, which
+// containers may be pinned to. Nil ids are not checked.
+type OnlineCPUs struct {
+	CPUs []int
+	Mems []int
+}
+
+func parseCPUSet(properties garden.Properties, online OnlineCPUs) (CPUSet, error) {
+	var cpuSet CPUSet
+
+	for _, p := range []struct {
+		name   string
+		value  *string
+		online []int
+	}{
+		{CPUSetCPUsProperty, &cpuSet.CPUs, online.CPUs},
+		{CPUSetMemsProperty, &cpuSet.Mems, online.Mems},
+	} {
+		raw, ok := properties[p.name]
+		if !ok {
+			continue
+		}
+
+		list, err := ParseCPUList(raw)
+		if err != nil {
+			return CPUSet{}, fmt.Errorf("invalid %s property: '%s': %s", p.name, raw, err)
+		}
+
+		if offline := notIn(list, p.online); p.online != nil && len(offline) > 0 {
+			return CPUSet{}, fmt.Errorf("invalid %s property: '%s': %s not online", p.name, raw, FormatCPUList(offline))
+		}
+
+		*p.value = FormatCPUList(list)
+	}
+
+	return cpuSet, nil
+}
+
+// ParseCPUList parses a kernel cpu list, e.g. "0-3,8", in to its sorted,
+// distinct ids, which may be no higher than MaxCPUListID
+func ParseCPUList(raw string) ([]int, error) {
+	seen := map[int]bool{}
+	for _, r := range strings.Split(strings.TrimSpace(raw), ",") {
+		bounds := strings.SplitN(r, "-", 2)
+
+		first, err := strconv.Atoi(bounds[0])
+		if err != nil || first < 0 {
+			return nil, fmt.Errorf("'%s' is not an id or a range of ids", r)
+		}
+
+		last := first
+		if len(bounds) == 2 {
+			if last, err = strconv.Atoi(bounds[1]); err != nil || last < first {
+				return nil, fmt.Errorf("'%s' is not an id or a range of ids", r)
+			}
+		}
+
+		if last > MaxCPUListID {
+			return nil, fmt.Errorf("'%s' is higher than the highest id, %d", r, MaxCPUListID)
+		}
+
+		for id := first; id <= last; id++ {
+			seen[id] = true
+		}
+	}
+
+	ids := make([]int, 0, len(seen))
+	for id := range seen {
+		ids = append(ids, id)
+	}
+
+	sort.Ints(ids)
+	return ids, nil
+}
+
+// notIn returns the sorted ids which are not in the sorted set
+func notIn(ids, set []int) []int {
+	var missing []int
+	for _, id := range ids {
+		if i := sort.SearchInts(set, id); i == len(set) || set[i] != id {
+			missing = append(missing, id)
+		}
+	}
+
+	return missing
+}
+
+// FormatCPUList formats sorted, distinct ids as a kernel cpu list, with
+// consecutive ids as ranges
+func FormatCPUList(ids []int) string {
+	var ranges []string
+	for i := 0; i < len(ids); {
+		j := i
+		for j+1 < len(ids) && ids[j+1] == ids[j]+1 {
+			j++
+		}
+
+		if i == j {
+			ranges = append(ranges, strconv.Itoa(ids[i]))
+		} else {
+			ranges = append(ranges, fmt.Sprintf("%d-%d", ids[i], ids[j]))
+		}
+
+		i = j + 1
+	}
+
+	return strings.Join(ranges, ",")
+}
+
+// CPUSetAllocator pins each container which does not choose its own CPUs to
+// PerContainer of CPUs, choosing those which the fewest containers are pinned
+// to, so that latency-sensitive containers are isolated from each other as
+// far as the host's CPUs allow. Containers which choose their own CPUs are
+// counted too. The pins of existing containers are read from their
+// properties on Start. A nil CPUSetAllocator pins nothing.
+type CPUSetAllocator struct {
+	CPUs         []int
+	PerContainer int
+
+	Lister     HandleLister
+	Properties PropertyManager
+	Logger     lager.Logger
+
+	mu     sync.Mutex
+	pinned map[string][]int
+}
+
+// Start records the pins of existing containers
+func (a *CPUSetAllocator) Start() error {
+	if a.PerContainer > len(a.CPUs) {
+		return errors.New("cpuset allocator: fewer cpus than are pinned to each container")
+	}
+
+	handles, err := a.Lister.Handles()
+	if err != nil {
+		return err
+	}
+
+	for _, handle := range handles {
+		raw, err := a.Properties.Get(handle, CPUSetCPUsProperty)
+		if err != nil || raw == "" {
+			continue
+		}
+
+		a.Pin(handle, raw)
+	}
+
+	return nil
+}
+
+// Allocate pins the container to the CPUs which the fewest containers are
+// pinned to, and returns them as a cpu list. It returns "" if the container
+// is already pinned.
+func (a *CPUSetAllocator) Allocate(handle string) string {
+	if a == nil || a.PerContainer == 0 {
+		return ""
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if _, ok := a.pinned[handle]; ok {
+		return ""
+	}
+
+	load := map[int]int{}
+	for _, cpus := range a.pinned {
+		for _, cpu := range cpus {
+			load[cpu]++
+		}
+	}
+
+	candidates := make([]int, len(a.CPUs))
+	copy(candidates, a.CPUs)
+	sort.Sort(byLoad{cpus: candidates, load: load})
+
+	chosen := candidates[:a.PerContainer]
+	sort.Ints(chosen)
+
+	a.pin(handle, chosen)
+	return FormatCPUList(chosen)
+}
+
+// Pin records that the container is pinned to the CPUs in the cpu list, and
+// returns whether it was not pinned already
+func (a *CPUSetAllocator) Pin(handle, cpus string) bool {
+	if a == nil {
+		return false
+	}
+
+	ids, err := ParseCPUList(cpus)
+	if err != nil {
+		a.Logger.Error("parse-cpuset-failed", err, lager.Data{"handle": handle, "cpus": cpus})
+		return false
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if _, ok := a.pinned[handle]; ok {
+		return false
+	}
+
+	a.pin(handle, ids)
+	return true
+}
+
+// Release forgets the container's pin, e.g. once it is destroyed
+func (a *CPUSetAllocator) Release(handle string) {
+	if a == nil {
+		return
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	delete(a.pinned, handle)
+}
+
+func (a *CPUSetAllocator) pin(handle string, cpus []int) {
+	if a.pinned == nil {
+		a.pinned = make(map[string][]int)
+	}
+
+	a.pinned[handle] = cpus
+}
+
+type byLoad struct {
+	cpus []int
+	load map[int]int
+}
+
+func (b byLoad) Len() int      { return len(b.cpus) }
+func (b byLoad) Swap(i, j int) { b.cpus[i], b.cpus[j] = b.cpus[j], b.cpus[i] }
+func (b byLoad) Less(i, j int) bool {
+	if b.load[b.cpus[i]] != b.load[b.cpus[j]] {
+		return b.load[b.cpus[i]] < b.load[b.cpus[j]]
+	}
+
+	return b.cpus[i] < b.cpus[j]
+}
+
+// withProperty returns a copy of the properties with the property set
+func withProperty(properties garden.Properties, name, value string) garden.Properties {
+	props := garden.Properties{name: value}
+	for k, v := range properties {
+		if k != name {
+			props[k] = v
+		}
+	}
+
+	return props
+}
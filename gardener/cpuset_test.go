@@ -0,0 +1,94 @@
+package gardener_test
+
+import (
+	"errors"
+
+	"github.com/cloudfoundry-incubator/guardian/gardener"
+	"github.com/cloudfoundry-incubator/guardian/gardener/fakes"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/pivotal-golang/lager/lagertest"
+)
+
+var _ = Describe("CPU lists", func() {
+	It("parses ids and ranges in to sorted, distinct ids", func() {
+		Expect(gardener.ParseCPUList("4,0-2,1\n")).To(Equal([]int{0, 1, 2, 4}))
+	})
+
+	It("refuses lists which are not ids or ranges", func() {
+		_, err := gardener.ParseCPUList("0,two")
+		Expect(err).To(MatchError("'two' is not an id or a range of ids"))
+	})
+
+	It("refuses ids higher than the highest id, without expanding them", func() {
+		_, err := gardener.ParseCPUList("0-2000000000")
+		Expect(err).To(MatchError("'0-2000000000' is higher than the highest id, 8191"))
+	})
+
+	It("formats consecutive ids as ranges", func() {
+		Expect(gardener.FormatCPUList([]int{0, 1, 2, 4, 6, 7})).To(Equal("0-2,4,6-7"))
+	})
+})
+
+var _ = Describe("CPUSetAllocator", func() {
+	var (
+		lister     *fakes.FakeHandleLister
+		properties *fakes.FakePropertyManager
+		allocator  *gardener.CPUSetAllocator
+	)
+
+	BeforeEach(func() {
+		lister = new(fakes.FakeHandleLister)
+		properties = new(fakes.FakePropertyManager)
+		allocator = &gardener.CPUSetAllocator{
+			CPUs:         []int{0, 1, 2, 3},
+			PerContainer: 2,
+			Lister:       lister,
+			Properties:   properties,
+			Logger:       lagertest.NewTestLogger("test"),
+		}
+	})
+
+	It("pins containers to the cpus the fewest containers are pinned to", func() {
+		Expect(allocator.Allocate("a")).To(Equal("0-1"))
+		Expect(allocator.Allocate("b")).To(Equal("2-3"))
+		Expect(allocator.Pin("c", "1")).To(BeTrue())
+		Expect(allocator.Allocate("d")).To(Equal("0,2"))
+	})
+
+	It("does not pin a container twice", func() {
+		Expect(allocator.Allocate("a")).To(Equal("0-1"))
+		Expect(allocator.Allocate("a")).To(BeEmpty())
+		Expect(allocator.Pin("a", "3")).To(BeFalse())
+	})
+
+	It("frees the cpus of released containers", func() {
+		allocator.Allocate("a")
+		allocator.Allocate("b")
+		allocator.Release("a")
+
+		Expect(allocator.Allocate("c")).To(Equal("0-1"))
+	})
+
+	It("records the pins of existing containers on start", func() {
+		lister.HandlesReturns([]string{"a", "b"}, nil)
+		properties.GetStub = func(handle, name string) (string, error) {
+			Expect(name).To(Equal(gardener.CPUSetCPUsProperty))
+			if handle == "a" {
+				return "0-1", nil
+			}
+
+			return "", errors.New("no such property")
+		}
+
+		Expect(allocator.Start()).To(Succeed())
+		Expect(allocator.Allocate("c")).To(Equal("2-3"))
+	})
+
+	It("pins nothing when it is nil", func() {
+		var nilAllocator *gardener.CPUSetAllocator
+		Expect(nilAllocator.Allocate("a")).To(BeEmpty())
+		Expect(nilAllocator.Pin("a", "0")).To(BeFalse())
+		nilAllocator.Release("a")
+	})
+})
@@ -0,0 +1,59 @@
+package gardener
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// CreateQueue bounds the number of containers being created at once.
+// Creates beyond the bound wait, in no particular order, for one to finish.
+// A nil CreateQueue imposes no bound.
+type CreateQueue struct {
+	slots   chan struct{}
+	waiting int64
+}
+
+func NewCreateQueue(max int) *CreateQueue {
+	return &CreateQueue{slots: make(chan struct{}, max)}
+}
+
+// Enter waits for a create slot, returning how long it waited
+func (q *CreateQueue) Enter() time.Duration {
+	if q == nil {
+		return 0
+	}
+
+	start := time.Now()
+	atomic.AddInt64(&q.waiting, 1)
+	q.slots <- struct{}{}
+	atomic.AddInt64(&q.waiting, -1)
+
+	return time.Since(start)
+}
+
+// Leave gives up a slot taken by Enter
+func (q *CreateQueue) Leave() {
+	if q == nil {
+		return
+	}
+
+	<-q.slots
+}
+
+// Waiting is the number of creates waiting for a slot
+func (q *CreateQueue) Waiting() int {
+	if q == nil {
+		return 0
+	}
+
+	return int(atomic.LoadInt64(&q.waiting))
+}
+
+// Active is the number of creates holding a slot
+func (q *CreateQueue) Active() int {
+	if q == nil {
+		return 0
+	}
+
+	return len(q.slots)
+}
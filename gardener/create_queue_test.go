@@ -0,0 +1,57 @@
+package gardener_test
+
+import (
+	"time"
+
+	"github.com/cloudfoundry-incubator/guardian/gardener"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("CreateQueue", func() {
+	var queue *gardener.CreateQueue
+
+	BeforeEach(func() {
+		queue = gardener.NewCreateQueue(2)
+	})
+
+	It("lets creates in up to the limit without waiting", func() {
+		queue.Enter()
+		queue.Enter()
+		Expect(queue.Active()).To(Equal(2))
+		Expect(queue.Waiting()).To(Equal(0))
+	})
+
+	It("makes creates beyond the limit wait for one to leave", func() {
+		queue.Enter()
+		queue.Enter()
+
+		entered := make(chan time.Duration)
+		go func() {
+			entered <- queue.Enter()
+		}()
+
+		Eventually(queue.Waiting).Should(Equal(1))
+		Consistently(entered).ShouldNot(Receive())
+
+		time.Sleep(10 * time.Millisecond)
+		queue.Leave()
+
+		var wait time.Duration
+		Eventually(entered).Should(Receive(&wait))
+		Expect(wait).To(BeNumerically(">=", 10*time.Millisecond))
+		Expect(queue.Waiting()).To(Equal(0))
+		Expect(queue.Active()).To(Equal(2))
+	})
+
+	Context("when the queue is nil", func() {
+		It("imposes no limit", func() {
+			var nilQueue *gardener.CreateQueue
+			for i := 0; i < 10; i++ {
+				Expect(nilQueue.Enter()).To(BeZero())
+			}
+			nilQueue.Leave()
+			Expect(nilQueue.Active()).To(Equal(0))
+		})
+	})
+})
@@ -0,0 +1,162 @@
+package gardener
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pivotal-golang/lager"
+)
+
+//go:generate counterfeiter . StagedContainerizer
+
+// StagedContainerizer is implemented by Containerizers which can create a
+// container's bundle and start it separately, so that each has its own
+// create timeout. Create is then not called.
+type StagedContainerizer interface {
+	CreateBundle(log lager.Logger, spec DesiredContainerSpec) error
+	StartBundle(log lager.Logger, handle string) error
+}
+
+// CreateTimeouts bound the time each stage of a create may take. A zero
+// timeout does not bound its stage. The Bundle and Runtime stages are only
+// timed separately with a StagedContainerizer; otherwise the container is
+// created in one stage bounded by their sum, if both are set.
+type CreateTimeouts struct {
+	Image   time.Duration
+	Bundle  time.Duration
+	Runtime time.Duration
+	Network time.Duration
+}
+
+// StageDuration is how long a completed stage of a create took
+type StageDuration struct {
+	Stage    string
+	Duration time.Duration
+}
+
+// StageTimeoutError is returned by Create when a stage takes longer than its
+// timeout, with how long each of the stages which had completed took. The
+// completed stages, and the timed out one once it returns, are rolled back
+// in the background.
+type StageTimeoutError struct {
+	Stage     string
+	Timeout   time.Duration
+	Completed []StageDuration
+}
+
+func (e StageTimeoutError) Error() string {
+	completed := make([]string, 0, len(e.Completed))
+	for _, stage := range e.Completed {
+		completed = append(completed, fmt.Sprintf("%s took %s", stage.Stage, stage.Duration))
+	}
+
+	if len(completed) == 0 {
+		return fmt.Sprintf("create timed out in the %s stage after %s, with no stages completed", e.Stage, e.Timeout)
+	}
+
+	return fmt.Sprintf("create timed out in the %s stage after %s (completed: %s)", e.Stage, e.Timeout, strings.Join(completed, ", "))
+}
+
+// HandleInUseError is returned by Create when another create of the same
+// handle is in progress, or is still being rolled back after timing out
+type HandleInUseError struct {
+	Handle string
+}
+
+func (e HandleInUseError) Error() string {
+	return fmt.Sprintf("handle '%s' is in use by a container which is being created or rolled back", e.Handle)
+}
+
+// createStages times the stages of one create
+type createStages struct {
+	log lager.Logger
+
+	mu        sync.Mutex
+	completed []StageDuration
+
+	// rollbacks are the rollbacks of timed out stages still to finish
+	rollbacks sync.WaitGroup
+	timedOut  bool
+}
+
+// run runs the stage, failing with a StageTimeoutError if it takes longer
+// than timeout. A timed out stage carries on in the background, and
+// rollback is called once it returns to undo whatever it did.
+func (s *createStages) run(stage string, timeout time.Duration, fn func() error, rollback func()) error {
+	start := time.Now()
+
+	if timeout <= 0 {
+		err := fn()
+		s.complete(stage, start)
+		return err
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- fn()
+	}()
+
+	select {
+	case err := <-done:
+		s.complete(stage, start)
+		return err
+	case <-time.After(timeout):
+	}
+
+	s.rollbacks.Add(1)
+	go func() {
+		defer s.rollbacks.Done()
+
+		<-done
+		s.log.Info("rolling-back-timed-out-stage", lager.Data{"stage": stage, "took": time.Since(start).String()})
+		rollback()
+	}()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.timedOut = true
+	return Classify(FailureStageTimeout, StageTimeoutError{
+		Stage:     stage,
+		Timeout:   timeout,
+		Completed: append([]StageDuration{}, s.completed...),
+	})
+}
+
+// afterRollbacks calls fn once the rollbacks of any stages which timed out
+// have finished, without waiting for them
+func (s *createStages) afterRollbacks(fn func()) {
+	s.mu.Lock()
+	timedOut := s.timedOut
+	s.mu.Unlock()
+
+	if !timedOut {
+		fn()
+		return
+	}
+
+	go func() {
+		s.rollbacks.Wait()
+		fn()
+	}()
+}
+
+func (s *createStages) complete(stage string, start time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.completed = append(s.completed, StageDuration{Stage: stage, Duration: time.Since(start)})
+}
+
+// timedOutStage returns the stage err timed out in, if it is a
+// StageTimeoutError
+func timedOutStage(err error) (string, bool) {
+	if classified, ok := err.(ClassifiedError); ok {
+		err = classified.Err
+	}
+
+	timeout, ok := err.(StageTimeoutError)
+	return timeout.Stage, ok
+}
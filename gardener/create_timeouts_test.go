@@ -0,0 +1,240 @@
+package gardener_test
+
+import (
+	"errors"
+	"time"
+
+	"github.com/cloudfoundry-incubator/garden"
+	"github.com/cloudfoundry-incubator/garden-shed/rootfs_provider"
+	"github.com/cloudfoundry-incubator/guardian/gardener"
+	"github.com/cloudfoundry-incubator/guardian/gardener/fakes"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/pivotal-golang/lager"
+	"github.com/pivotal-golang/lager/lagertest"
+)
+
+// stagedContainerizer is a Containerizer which creates and starts bundles
+// separately
+type stagedContainerizer struct {
+	*fakes.FakeContainerizer
+	*fakes.FakeStagedContainerizer
+}
+
+var _ = Describe("Create timeouts", func() {
+	var (
+		networker     *fakes.FakeNetworker
+		volumeCreator *fakes.FakeVolumeCreator
+		containerizer *fakes.FakeContainerizer
+		staged        *fakes.FakeStagedContainerizer
+		metrics       *fakes.FakeMetricsRecorder
+
+		// release unblocks the stage which is made to hang
+		release chan struct{}
+
+		gdnr *gardener.Gardener
+	)
+
+	BeforeEach(func() {
+		networker = new(fakes.FakeNetworker)
+		volumeCreator = new(fakes.FakeVolumeCreator)
+		containerizer = new(fakes.FakeContainerizer)
+		staged = new(fakes.FakeStagedContainerizer)
+		metrics = new(fakes.FakeMetricsRecorder)
+		release = make(chan struct{})
+
+		gdnr = &gardener.Gardener{
+			SysInfoProvider: new(fakes.FakeSysInfoProvider),
+			Containerizer:   stagedContainerizer{containerizer, staged},
+			UidGenerator:    new(fakes.FakeUidGenerator),
+			Networker:       networker,
+			VolumeCreator:   volumeCreator,
+			Logger:          lagertest.NewTestLogger("test"),
+			PropertyManager: new(fakes.FakePropertyManager),
+			Metrics:         metrics,
+			CreateTimeouts: gardener.CreateTimeouts{
+				Image:   50 * time.Millisecond,
+				Bundle:  50 * time.Millisecond,
+				Runtime: 50 * time.Millisecond,
+				Network: 50 * time.Millisecond,
+			},
+		}
+	})
+
+	create := func() error {
+		_, err := gdnr.Create(garden.ContainerSpec{Handle: "bob"})
+		return err
+	}
+
+	It("creates and starts the bundle in separate stages", func() {
+		Expect(create()).To(Succeed())
+
+		Expect(staged.CreateBundleCallCount()).To(Equal(1))
+		_, spec := staged.CreateBundleArgsForCall(0)
+		Expect(spec.Handle).To(Equal("bob"))
+
+		Expect(staged.StartBundleCallCount()).To(Equal(1))
+		_, handle := staged.StartBundleArgsForCall(0)
+		Expect(handle).To(Equal("bob"))
+
+		Expect(containerizer.CreateCallCount()).To(Equal(0))
+	})
+
+	Context("when the runtime stage times out", func() {
+		BeforeEach(func() {
+			staged.StartBundleStub = func(lager.Logger, string) error {
+				<-release
+				return nil
+			}
+		})
+
+		It("returns an error naming the stage and how long each completed stage took", func() {
+			err := create()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(MatchRegexp(`^create timed out in the runtime stage after 50ms \(completed: (network|image) took .*, (network|image) took .*, bundle took .*\)$`))
+
+			Expect(gardener.FailureClass(err, "")).To(Equal(gardener.FailureStageTimeout))
+			timeout := err.(gardener.ClassifiedError).Err.(gardener.StageTimeoutError)
+			Expect(timeout.Stage).To(Equal(gardener.StageRuntime))
+			Expect(timeout.Completed).To(HaveLen(3))
+
+			close(release)
+		})
+
+		It("records the failure against the stage", func() {
+			create()
+			close(release)
+
+			stage, class := metrics.ContainerCreateFailedArgsForCall(0)
+			Expect(stage).To(Equal(gardener.StageRuntime))
+			Expect(class).To(Equal(gardener.FailureStageTimeout))
+		})
+
+		It("rolls back every stage once the runtime stage returns", func() {
+			create()
+
+			Consistently(containerizer.DestroyCallCount).Should(Equal(0))
+			Expect(networker.DestroyCallCount()).To(Equal(0))
+			Expect(volumeCreator.DestroyCallCount()).To(Equal(0))
+
+			close(release)
+
+			Eventually(volumeCreator.DestroyCallCount).Should(Equal(1))
+			Expect(containerizer.DestroyCallCount()).To(Equal(1))
+			Expect(networker.DestroyCallCount()).To(Equal(1))
+		})
+
+		It("refuses to create the handle again until the rollback has finished", func() {
+			create()
+
+			err := create()
+			Expect(err).To(MatchError(gardener.HandleInUseError{Handle: "bob"}))
+			Expect(staged.CreateBundleCallCount()).To(Equal(1))
+
+			staged.StartBundleStub = nil
+			close(release)
+
+			Eventually(create).Should(Succeed())
+			Expect(containerizer.DestroyCallCount()).To(Equal(1))
+		})
+	})
+
+	Context("when the bundle stage times out", func() {
+		It("does not start the bundle", func() {
+			staged.CreateBundleStub = func(lager.Logger, gardener.DesiredContainerSpec) error {
+				<-release
+				return nil
+			}
+
+			err := create()
+			Expect(err).To(MatchError(ContainSubstring("timed out in the bundle stage")))
+			close(release)
+
+			Eventually(containerizer.DestroyCallCount).Should(Equal(1))
+			Expect(staged.StartBundleCallCount()).To(Equal(0))
+		})
+	})
+
+	Context("when the image stage times out", func() {
+		BeforeEach(func() {
+			volumeCreator.CreateStub = func(lager.Logger, string, rootfs_provider.Spec) (string, []string, error) {
+				<-release
+				return "/rootfs", nil, nil
+			}
+		})
+
+		It("returns an error naming the stage, and destroys the network straight away", func() {
+			err := create()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(MatchRegexp(`^create timed out in the image stage after 50ms \(completed: network took .*\)$`))
+			Expect(networker.DestroyCallCount()).To(Equal(1))
+			Expect(staged.CreateBundleCallCount()).To(Equal(0))
+
+			close(release)
+		})
+
+		It("destroys the volume once it has been created", func() {
+			create()
+			Expect(volumeCreator.DestroyCallCount()).To(Equal(0))
+
+			close(release)
+			Eventually(volumeCreator.DestroyCallCount).Should(Equal(1))
+		})
+	})
+
+	Context("when the network stage times out", func() {
+		It("destroys the volume straight away, and the network once its hooks are returned", func() {
+			networker.HooksStub = func(lager.Logger, string, string) (gardener.Hooks, error) {
+				<-release
+				return gardener.Hooks{}, nil
+			}
+
+			err := create()
+			Expect(err).To(MatchError(ContainSubstring("timed out in the network stage")))
+			Expect(volumeCreator.DestroyCallCount()).To(Equal(1))
+			Expect(networker.DestroyCallCount()).To(Equal(0))
+
+			close(release)
+			Eventually(networker.DestroyCallCount).Should(Equal(1))
+		})
+	})
+
+	Context("when a stage fails within its timeout", func() {
+		It("returns its error", func() {
+			staged.StartBundleReturns(errors.New("runc exploded"))
+			Expect(create()).To(MatchError("runc exploded"))
+		})
+	})
+
+	Context("when the containerizer cannot create and start bundles separately", func() {
+		BeforeEach(func() {
+			gdnr.Containerizer = containerizer
+		})
+
+		It("bounds creating the container by the bundle and runtime timeouts together", func() {
+			containerizer.CreateStub = func(lager.Logger, gardener.DesiredContainerSpec) error {
+				select {
+				case <-release:
+					return nil
+				case <-time.After(75 * time.Millisecond):
+					return nil
+				}
+			}
+
+			Expect(create()).To(Succeed())
+		})
+
+		It("times out in the container stage", func() {
+			containerizer.CreateStub = func(lager.Logger, gardener.DesiredContainerSpec) error {
+				<-release
+				return nil
+			}
+
+			err := create()
+			Expect(err).To(MatchError(ContainSubstring("timed out in the container stage after 100ms")))
+			close(release)
+
+			Eventually(containerizer.DestroyCallCount).Should(Equal(1))
+		})
+	})
+})
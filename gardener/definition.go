@@ -0,0 +1,163 @@
+package gardener
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/cloudfoundry-incubator/garden"
+	"github.com/pivotal-golang/lager"
+)
+
+// SpecKey is the property which holds the spec a container was created with,
+// minus its properties and grace time, which are kept up to date separately
+const SpecKey = "garden.spec"
+
+// internalPropertyPrefixes are the prefixes of properties which record the
+// backend's state of a container rather than being part of its definition
+var internalPropertyPrefixes = []string{"garden.", "kawasaki."}
+
+// ContainerDefinition describes everything needed to create an equivalent
+// container: its spec (including its current properties and grace time), and
+// the NetIn and NetOut rules which have been applied to it. It does not
+// include any runtime state such as processes or the contents of the
+// container's filesystem.
+type ContainerDefinition struct {
+	Spec   garden.ContainerSpec `json:"spec"`
+	NetIn  []garden.PortMapping `json:"net_in,omitempty"`
+	NetOut []garden.NetOutRule  `json:"net_out,omitempty"`
+}
+
+func (g *Gardener) recordSpec(spec garden.ContainerSpec) error {
+	spec.Properties = nil
+	spec.GraceTime = 0
+
+	data, err := json.Marshal(spec)
+	if err != nil {
+		return fmt.Errorf("record spec: %s", err)
+	}
+
+	g.PropertyManager.Set(spec.Handle, SpecKey, string(data))
+	return nil
+}
+
+// Export returns the definition of a container
+func (g *Gardener) Export(handle string) (ContainerDefinition, error) {
+	log := g.Logger.Session("export", lager.Data{"handle": handle})
+
+	log.Info("started")
+	defer log.Info("finished")
+
+	props, err := g.PropertyManager.All(handle)
+	if err != nil {
+		log.Error("get-properties-failed", err)
+		return ContainerDefinition{}, err
+	}
+
+	specJson, ok := props[SpecKey]
+	if !ok {
+		return ContainerDefinition{}, fmt.Errorf("export: container '%s' has no recorded spec", handle)
+	}
+
+	var definition ContainerDefinition
+	if err := json.Unmarshal([]byte(specJson), &definition.Spec); err != nil {
+		return ContainerDefinition{}, fmt.Errorf("export: decode spec: %s", err)
+	}
+
+	definition.Spec.Properties = garden.Properties{}
+	for name, value := range props {
+		if !isInternalProperty(name) {
+			definition.Spec.Properties[name] = value
+		}
+	}
+
+	if graceTime, err := time.ParseDuration(props[GraceTimeKey]); err == nil {
+		definition.Spec.GraceTime = graceTime
+	}
+
+	if mappings, ok := props[MappedPortsKey]; ok {
+		if err := json.Unmarshal([]byte(mappings), &definition.NetIn); err != nil {
+			return ContainerDefinition{}, fmt.Errorf("export: decode %s: %s", MappedPortsKey, err)
+		}
+	}
+
+	if rules, ok := props[NetOutRulesKey]; ok {
+		if err := json.Unmarshal([]byte(rules), &definition.NetOut); err != nil {
+			return ContainerDefinition{}, fmt.Errorf("export: decode %s: %s", NetOutRulesKey, err)
+		}
+	}
+
+	return definition, nil
+}
+
+// Import creates a container from a definition returned by Export, giving it
+// the definition's handle unless handle is not empty. Its container ports are
+// mapped to newly allocated host ports, since the original ones may not be
+// available. NetOut rules in the egress policy are already applied to every
+// new container, so are not applied again.
+func (g *Gardener) Import(definition ContainerDefinition, handle string) (garden.Container, error) {
+	spec := definition.Spec
+	if handle != "" {
+		spec.Handle = handle
+	}
+
+	log := g.Logger.Session("import", lager.Data{"handle": spec.Handle})
+
+	log.Info("started")
+	defer log.Info("finished")
+
+	container, err := g.Create(spec)
+	if err != nil {
+		log.Error("create-failed", err)
+		return nil, err
+	}
+
+	if err := g.applyDefinitionRules(log, container, definition); err != nil {
+		log.Error("apply-rules-failed", err)
+		if destroyErr := g.Destroy(container.Handle()); destroyErr != nil {
+			log.Error("destroy-failed", destroyErr)
+		}
+
+		return nil, err
+	}
+
+	return container, nil
+}
+
+func (g *Gardener) applyDefinitionRules(log lager.Logger, container garden.Container, definition ContainerDefinition) error {
+	for _, mapping := range definition.NetIn {
+		if _, _, err := container.NetIn(0, mapping.ContainerPort); err != nil {
+			return fmt.Errorf("import: net in: %s", err)
+		}
+	}
+
+	policy := g.EgressPolicy.Rules()
+
+	var rules []garden.NetOutRule
+	for _, rule := range definition.NetOut {
+		if !containsRule(policy, rule) {
+			rules = append(rules, rule)
+		}
+	}
+
+	if len(rules) == 0 {
+		return nil
+	}
+
+	if err := g.Networker.BulkNetOut(log, container.Handle(), rules); err != nil {
+		return fmt.Errorf("import: net out: %s", err)
+	}
+
+	return nil
+}
+
+func isInternalProperty(name string) bool {
+	for _, prefix := range internalPropertyPrefixes {
+		if strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+
+	return false
+}
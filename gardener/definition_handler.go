@@ -0,0 +1,73 @@
+package gardener
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/cloudfoundry-incubator/garden"
+)
+
+//go:generate counterfeiter . ContainerDefiner
+
+type ContainerDefiner interface {
+	Export(handle string) (ContainerDefinition, error)
+	Import(definition ContainerDefinition, handle string) (garden.Container, error)
+}
+
+// ExportHandler serves the definition of the container named by the `handle`
+// query parameter as a JSON document
+type ExportHandler struct {
+	Definer ContainerDefiner
+}
+
+func (h *ExportHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		writeError(w, r, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	handle := r.URL.Query().Get("handle")
+	if handle == "" {
+		writeError(w, r, "handle is required", http.StatusBadRequest)
+		return
+	}
+
+	definition, err := h.Definer.Export(handle)
+	if err != nil {
+		writeError(w, r, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(definition)
+}
+
+// ImportHandler creates a container from the JSON definition in the request
+// body, as served by ExportHandler. The container is given the optional
+// `handle` query parameter as its handle in place of the definition's one.
+type ImportHandler struct {
+	Definer ContainerDefiner
+}
+
+func (h *ImportHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		writeError(w, r, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var definition ContainerDefinition
+	if err := json.NewDecoder(r.Body).Decode(&definition); err != nil {
+		writeError(w, r, "invalid definition: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	container, err := h.Definer.Import(definition, r.URL.Query().Get("handle"))
+	if err != nil {
+		writeError(w, r, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(map[string]string{"handle": container.Handle()})
+}
@@ -0,0 +1,123 @@
+package gardener_test
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	"github.com/cloudfoundry-incubator/garden"
+	gardenfakes "github.com/cloudfoundry-incubator/garden/fakes"
+	"github.com/cloudfoundry-incubator/guardian/gardener"
+	"github.com/cloudfoundry-incubator/guardian/gardener/fakes"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Export and import handlers", func() {
+	var (
+		fakeDefiner *fakes.FakeContainerDefiner
+		recorder    *httptest.ResponseRecorder
+	)
+
+	serve := func(handler http.Handler, method, url string, body io.Reader) {
+		req, err := http.NewRequest(method, url, body)
+		Expect(err).NotTo(HaveOccurred())
+		handler.ServeHTTP(recorder, req)
+	}
+
+	BeforeEach(func() {
+		fakeDefiner = new(fakes.FakeContainerDefiner)
+		recorder = httptest.NewRecorder()
+	})
+
+	Describe("ExportHandler", func() {
+		var handler *gardener.ExportHandler
+
+		BeforeEach(func() {
+			handler = &gardener.ExportHandler{Definer: fakeDefiner}
+		})
+
+		It("serves the container's definition as JSON", func() {
+			definition := gardener.ContainerDefinition{
+				Spec:  garden.ContainerSpec{Handle: "some-handle", RootFSPath: "docker:///busybox"},
+				NetIn: []garden.PortMapping{{HostPort: 60000, ContainerPort: 8080}},
+			}
+			fakeDefiner.ExportReturns(definition, nil)
+
+			serve(handler, "GET", "/containers/export?handle=some-handle", nil)
+
+			Expect(recorder.Code).To(Equal(http.StatusOK))
+			Expect(fakeDefiner.ExportArgsForCall(0)).To(Equal("some-handle"))
+
+			var served gardener.ContainerDefinition
+			Expect(json.NewDecoder(recorder.Body).Decode(&served)).To(Succeed())
+			Expect(served).To(Equal(definition))
+		})
+
+		It("returns 500 when exporting fails", func() {
+			fakeDefiner.ExportReturns(gardener.ContainerDefinition{}, errors.New("no recorded spec"))
+			serve(handler, "GET", "/containers/export?handle=some-handle", nil)
+
+			Expect(recorder.Code).To(Equal(http.StatusInternalServerError))
+			Expect(recorder.Body.String()).To(ContainSubstring("no recorded spec"))
+		})
+
+		It("returns 400 when no handle is given", func() {
+			serve(handler, "GET", "/containers/export", nil)
+
+			Expect(recorder.Code).To(Equal(http.StatusBadRequest))
+			Expect(fakeDefiner.ExportCallCount()).To(Equal(0))
+		})
+	})
+
+	Describe("ImportHandler", func() {
+		var handler *gardener.ImportHandler
+
+		BeforeEach(func() {
+			handler = &gardener.ImportHandler{Definer: fakeDefiner}
+
+			container := new(gardenfakes.FakeContainer)
+			container.HandleReturns("new-handle")
+			fakeDefiner.ImportReturns(container, nil)
+		})
+
+		It("creates a container from the definition", func() {
+			serve(handler, "POST", "/containers/import?handle=new-handle",
+				strings.NewReader(`{"spec": {"RootFSPath": "docker:///busybox"}, "net_out": [{"protocol": 1}]}`))
+
+			Expect(recorder.Code).To(Equal(http.StatusCreated))
+			Expect(recorder.Body.String()).To(MatchJSON(`{"handle": "new-handle"}`))
+
+			Expect(fakeDefiner.ImportCallCount()).To(Equal(1))
+			definition, handle := fakeDefiner.ImportArgsForCall(0)
+			Expect(handle).To(Equal("new-handle"))
+			Expect(definition.Spec.RootFSPath).To(Equal("docker:///busybox"))
+			Expect(definition.NetOut).To(Equal([]garden.NetOutRule{{Protocol: garden.ProtocolTCP}}))
+		})
+
+		It("returns 400 when the definition is invalid", func() {
+			serve(handler, "POST", "/containers/import", strings.NewReader("potato"))
+
+			Expect(recorder.Code).To(Equal(http.StatusBadRequest))
+			Expect(fakeDefiner.ImportCallCount()).To(Equal(0))
+		})
+
+		It("returns 500 when importing fails", func() {
+			fakeDefiner.ImportReturns(nil, errors.New("handle already exists"))
+			serve(handler, "POST", "/containers/import", strings.NewReader(`{"spec": {}}`))
+
+			Expect(recorder.Code).To(Equal(http.StatusInternalServerError))
+			Expect(recorder.Body.String()).To(ContainSubstring("handle already exists"))
+		})
+
+		It("only accepts POST requests", func() {
+			serve(handler, "GET", "/containers/import", nil)
+
+			Expect(recorder.Code).To(Equal(http.StatusMethodNotAllowed))
+		})
+	})
+})
@@ -0,0 +1,220 @@
+package gardener_test
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/cloudfoundry-incubator/garden"
+	"github.com/cloudfoundry-incubator/guardian/gardener"
+	"github.com/cloudfoundry-incubator/guardian/gardener/fakes"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/pivotal-golang/lager/lagertest"
+)
+
+var _ = Describe("Exporting and importing container definitions", func() {
+	var (
+		networker       *fakes.FakeNetworker
+		containerizer   *fakes.FakeContainerizer
+		volumeCreator   *fakes.FakeVolumeCreator
+		propertyManager *fakes.FakePropertyManager
+
+		gdnr *gardener.Gardener
+	)
+
+	BeforeEach(func() {
+		networker = new(fakes.FakeNetworker)
+		containerizer = new(fakes.FakeContainerizer)
+		volumeCreator = new(fakes.FakeVolumeCreator)
+		propertyManager = new(fakes.FakePropertyManager)
+
+		gdnr = &gardener.Gardener{
+			Containerizer:   containerizer,
+			Networker:       networker,
+			VolumeCreator:   volumeCreator,
+			PropertyManager: propertyManager,
+			Logger:          lagertest.NewTestLogger("test"),
+			ChangeLog:       gardener.NewChangeLog(10),
+			Events:          gardener.NewEventHub(10),
+		}
+	})
+
+	Describe("Create", func() {
+		It("records the spec without its properties or grace time", func() {
+			_, err := gdnr.Create(garden.ContainerSpec{
+				Handle:     "some-handle",
+				RootFSPath: "docker:///busybox",
+				Privileged: true,
+				Env:        []string{"FOO=bar"},
+				GraceTime:  time.Minute,
+				Properties: garden.Properties{"name": "value"},
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			var recorded *garden.ContainerSpec
+			for i := 0; i < propertyManager.SetCallCount(); i++ {
+				handle, name, value := propertyManager.SetArgsForCall(i)
+				if name == gardener.SpecKey {
+					Expect(handle).To(Equal("some-handle"))
+					recorded = new(garden.ContainerSpec)
+					Expect(json.Unmarshal([]byte(value), recorded)).To(Succeed())
+				}
+			}
+
+			Expect(recorded).To(Equal(&garden.ContainerSpec{
+				Handle:     "some-handle",
+				RootFSPath: "docker:///busybox",
+				Privileged: true,
+				Env:        []string{"FOO=bar"},
+			}))
+		})
+	})
+
+	Describe("Export", func() {
+		var props garden.Properties
+
+		BeforeEach(func() {
+			spec, err := json.Marshal(garden.ContainerSpec{
+				Handle:     "some-handle",
+				RootFSPath: "docker:///busybox",
+				Limits:     garden.Limits{Memory: garden.MemoryLimits{LimitInBytes: 1024}},
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			props = garden.Properties{
+				gardener.SpecKey:         string(spec),
+				gardener.GraceTimeKey:    "5m0s",
+				gardener.ContainerIPKey:  "10.0.0.2",
+				gardener.MappedPortsKey:  `[{"HostPort":60000,"ContainerPort":8080}]`,
+				gardener.NetOutRulesKey:  `[{"protocol":1}]`,
+				"kawasaki.subnet":        "10.0.0.0/30",
+				gardener.MaxPidsProperty: "100",
+				"name":                   "value",
+			}
+			propertyManager.AllReturns(props, nil)
+		})
+
+		It("returns the container's definition", func() {
+			definition, err := gdnr.Export("some-handle")
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(propertyManager.AllArgsForCall(0)).To(Equal("some-handle"))
+			Expect(definition).To(Equal(gardener.ContainerDefinition{
+				Spec: garden.ContainerSpec{
+					Handle:     "some-handle",
+					RootFSPath: "docker:///busybox",
+					Limits:     garden.Limits{Memory: garden.MemoryLimits{LimitInBytes: 1024}},
+					GraceTime:  5 * time.Minute,
+					Properties: garden.Properties{
+						gardener.MaxPidsProperty: "100",
+						"name":                   "value",
+					},
+				},
+				NetIn:  []garden.PortMapping{{HostPort: 60000, ContainerPort: 8080}},
+				NetOut: []garden.NetOutRule{{Protocol: garden.ProtocolTCP}},
+			}))
+		})
+
+		Context("when the container's spec was not recorded", func() {
+			It("returns an error", func() {
+				delete(props, gardener.SpecKey)
+
+				_, err := gdnr.Export("some-handle")
+				Expect(err).To(MatchError("export: container 'some-handle' has no recorded spec"))
+			})
+		})
+
+		Context("when the container's properties cannot be read", func() {
+			It("returns the error", func() {
+				propertyManager.AllReturns(nil, errors.New("no such container"))
+
+				_, err := gdnr.Export("some-handle")
+				Expect(err).To(MatchError("no such container"))
+			})
+		})
+	})
+
+	Describe("Import", func() {
+		var definition gardener.ContainerDefinition
+
+		BeforeEach(func() {
+			definition = gardener.ContainerDefinition{
+				Spec: garden.ContainerSpec{
+					Handle:     "some-handle",
+					RootFSPath: "docker:///busybox",
+					Privileged: true,
+				},
+				NetIn: []garden.PortMapping{{HostPort: 60000, ContainerPort: 8080}},
+				NetOut: []garden.NetOutRule{
+					{Protocol: garden.ProtocolTCP},
+					{Protocol: garden.ProtocolUDP},
+				},
+			}
+		})
+
+		It("creates a container from the spec", func() {
+			container, err := gdnr.Import(definition, "")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(container.Handle()).To(Equal("some-handle"))
+
+			Expect(containerizer.CreateCallCount()).To(Equal(1))
+			_, spec := containerizer.CreateArgsForCall(0)
+			Expect(spec.Handle).To(Equal("some-handle"))
+			Expect(spec.Privileged).To(BeTrue())
+		})
+
+		It("gives the container the handle, when one is given", func() {
+			container, err := gdnr.Import(definition, "other-handle")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(container.Handle()).To(Equal("other-handle"))
+		})
+
+		It("maps the container ports to newly allocated host ports", func() {
+			_, err := gdnr.Import(definition, "")
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(networker.NetInCallCount()).To(Equal(1))
+			_, handle, hostPort, containerPort := networker.NetInArgsForCall(0)
+			Expect(handle).To(Equal("some-handle"))
+			Expect(hostPort).To(BeZero())
+			Expect(containerPort).To(BeEquivalentTo(8080))
+		})
+
+		It("applies the net out rules which are not in the egress policy", func() {
+			gdnr.EgressPolicy = &gardener.EgressPolicy{}
+			gdnr.EgressPolicy.Set([]garden.NetOutRule{{Protocol: garden.ProtocolUDP}})
+
+			_, err := gdnr.Import(definition, "")
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(networker.BulkNetOutCallCount()).To(Equal(2))
+			_, handle, rules := networker.BulkNetOutArgsForCall(1)
+			Expect(handle).To(Equal("some-handle"))
+			Expect(rules).To(Equal([]garden.NetOutRule{{Protocol: garden.ProtocolTCP}}))
+		})
+
+		Context("when creating the container fails", func() {
+			It("returns the error", func() {
+				containerizer.CreateReturns(errors.New("boom"))
+
+				_, err := gdnr.Import(definition, "")
+				Expect(err).To(MatchError("boom"))
+				Expect(networker.NetInCallCount()).To(Equal(0))
+			})
+		})
+
+		Context("when a rule cannot be applied", func() {
+			It("destroys the container and returns an error", func() {
+				networker.BulkNetOutReturns(errors.New("iptables-restore failed"))
+
+				_, err := gdnr.Import(definition, "")
+				Expect(err).To(MatchError("import: net out: iptables-restore failed"))
+
+				Expect(containerizer.DestroyCallCount()).To(Equal(1))
+				_, handle := containerizer.DestroyArgsForCall(0)
+				Expect(handle).To(Equal("some-handle"))
+			})
+		})
+	})
+})
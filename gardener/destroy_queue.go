@@ -0,0 +1,256 @@
+package gardener
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/cloudfoundry-incubator/guardian/pkg/atomicfile"
+	"github.com/pivotal-golang/clock"
+	"github.com/pivotal-golang/lager"
+)
+
+// DestroyFailure is a container whose cleanup has failed, and is being
+// retried, or has been given up on
+type DestroyFailure struct {
+	Handle      string    `json:"handle"`
+	Attempts    int       `json:"attempts"`
+	LastError   string    `json:"last_error"`
+	NextAttempt time.Time `json:"next_attempt"`
+	GaveUp      bool      `json:"gave_up"`
+}
+
+// DestroyQueue destroys containers in the background, so that a slow or
+// stuck teardown (e.g. an unmount which hangs) does not block the client
+// destroying the container. Containers are marked as destroying as soon as
+// they are enqueued, and their cleanup is retried with a backoff which
+// doubles from InitialBackoff up to MaxBackoff, until it succeeds or has
+// failed MaxAttempts times. Containers which are given up on are no longer
+// marked as destroying, so that they can be destroyed again. A nil
+// DestroyQueue destroys nothing, and marks nothing as destroying.
+type DestroyQueue struct {
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+
+	// MaxAttempts is the number of times a container's cleanup is tried
+	// before it is given up on (0 means no limit)
+	MaxAttempts int
+
+	// StatePath is the file in which the handles of the containers being
+	// destroyed are saved, so that Resume can carry on destroying them after
+	// a restart (optional)
+	StatePath string
+
+	Clock  clock.Clock
+	Logger lager.Logger
+
+	mu         sync.Mutex
+	destroying map[string]*DestroyFailure
+	gaveUp     map[string]*DestroyFailure
+}
+
+// Enqueue marks the container as destroying and runs destroy in the
+// background until it succeeds or is given up on. Enqueuing a container
+// which is already being destroyed does nothing. An error is returned, and
+// the container is not destroyed, if it cannot be saved as destroying.
+func (q *DestroyQueue) Enqueue(handle string, destroy func() error) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.destroying == nil {
+		q.destroying = make(map[string]*DestroyFailure)
+	}
+
+	if _, ok := q.destroying[handle]; ok {
+		return nil
+	}
+
+	q.destroying[handle] = &DestroyFailure{Handle: handle}
+	if err := q.saveState(); err != nil {
+		delete(q.destroying, handle)
+		return err
+	}
+
+	delete(q.gaveUp, handle)
+	go q.reap(q.Logger.Session("destroy", lager.Data{"handle": handle}), handle, destroy)
+	return nil
+}
+
+// Resume enqueues the containers which were still being destroyed when the
+// state was last saved, with the function destroyer returns for each
+func (q *DestroyQueue) Resume(destroyer func(handle string) func() error) error {
+	if q.StatePath == "" {
+		return nil
+	}
+
+	contents, err := ioutil.ReadFile(q.StatePath)
+	if os.IsNotExist(err) {
+		return nil
+	}
+
+	if err != nil {
+		return fmt.Errorf("reading destroy queue state: %s", err)
+	}
+
+	var handles []string
+	if err := json.Unmarshal(contents, &handles); err != nil {
+		return fmt.Errorf("parsing destroy queue state %s: %s", q.StatePath, err)
+	}
+
+	for _, handle := range handles {
+		if err := q.Enqueue(handle, destroyer(handle)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// GaveUp returns whether the container's cleanup has been given up on
+func (q *DestroyQueue) GaveUp(handle string) bool {
+	if q == nil {
+		return false
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	_, ok := q.gaveUp[handle]
+	return ok
+}
+
+// Destroying returns whether the container is being destroyed
+func (q *DestroyQueue) Destroying(handle string) bool {
+	if q == nil {
+		return false
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	_, ok := q.destroying[handle]
+	return ok
+}
+
+// Failures returns the containers whose cleanup has failed at least once and
+// is still being retried, or has been given up on, ordered by handle
+func (q *DestroyQueue) Failures() []DestroyFailure {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	failures := []DestroyFailure{}
+	for _, failure := range q.destroying {
+		if failure.Attempts > 0 {
+			failures = append(failures, *failure)
+		}
+	}
+
+	for _, failure := range q.gaveUp {
+		failures = append(failures, *failure)
+	}
+
+	sort.Sort(byHandle(failures))
+	return failures
+}
+
+type byHandle []DestroyFailure
+
+func (f byHandle) Len() int           { return len(f) }
+func (f byHandle) Less(i, j int) bool { return f[i].Handle < f[j].Handle }
+func (f byHandle) Swap(i, j int)      { f[i], f[j] = f[j], f[i] }
+
+func (q *DestroyQueue) reap(log lager.Logger, handle string, destroy func() error) {
+	backoff := q.InitialBackoff
+
+	for {
+		err := destroy()
+		if err == nil {
+			q.finish(log, handle, nil)
+			return
+		}
+
+		q.mu.Lock()
+		failure := q.destroying[handle]
+		failure.Attempts++
+		failure.LastError = err.Error()
+		failure.NextAttempt = q.Clock.Now().Add(backoff)
+		attempts := failure.Attempts
+		q.mu.Unlock()
+
+		if q.MaxAttempts > 0 && attempts >= q.MaxAttempts {
+			log.Error("giving-up", err, lager.Data{"attempts": attempts})
+			q.finish(log, handle, failure)
+			return
+		}
+
+		log.Error("failed", err, lager.Data{"attempts": attempts, "backoff": backoff.String()})
+		<-q.Clock.NewTimer(backoff).C()
+
+		backoff *= 2
+		if backoff > q.MaxBackoff {
+			backoff = q.MaxBackoff
+		}
+	}
+}
+
+// finish stops marking the container as destroying, recording failure if the
+// container was given up on
+func (q *DestroyQueue) finish(log lager.Logger, handle string, failure *DestroyFailure) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	delete(q.destroying, handle)
+	if failure != nil {
+		if q.gaveUp == nil {
+			q.gaveUp = make(map[string]*DestroyFailure)
+		}
+
+		failure.GaveUp = true
+		failure.NextAttempt = time.Time{}
+		q.gaveUp[handle] = failure
+	}
+
+	if err := q.saveState(); err != nil {
+		log.Error("save-state-failed", err)
+	}
+}
+
+// saveState saves the handles being destroyed; q.mu must be held
+func (q *DestroyQueue) saveState() error {
+	if q.StatePath == "" {
+		return nil
+	}
+
+	handles := []string{}
+	for handle := range q.destroying {
+		handles = append(handles, handle)
+	}
+	sort.Strings(handles)
+
+	if err := atomicfile.WriteJSON(q.StatePath, handles); err != nil {
+		return fmt.Errorf("saving destroy queue state: %s", err)
+	}
+
+	return nil
+}
+
+// DestroyQueueHandler serves the failures of a DestroyQueue as JSON, e.g. on
+// the debug server
+type DestroyQueueHandler struct {
+	Queue *DestroyQueue
+}
+
+func (h *DestroyQueueHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(h.Queue.Failures())
+}
@@ -0,0 +1,239 @@
+package gardener_test
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/cloudfoundry-incubator/guardian/gardener"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/pivotal-golang/clock/fakeclock"
+	"github.com/pivotal-golang/lager/lagertest"
+)
+
+var _ = Describe("DestroyQueue", func() {
+	var (
+		fakeClock *fakeclock.FakeClock
+		queue     *gardener.DestroyQueue
+
+		mu       sync.Mutex
+		attempts int
+		failures int
+	)
+
+	BeforeEach(func() {
+		fakeClock = fakeclock.NewFakeClock(time.Now())
+		queue = &gardener.DestroyQueue{
+			InitialBackoff: time.Second,
+			MaxBackoff:     3 * time.Second,
+			Clock:          fakeClock,
+			Logger:         lagertest.NewTestLogger("test"),
+		}
+
+		attempts, failures = 0, 0
+	})
+
+	destroy := func() error {
+		mu.Lock()
+		defer mu.Unlock()
+
+		attempts++
+		if attempts <= failures {
+			return errors.New("device or resource busy")
+		}
+
+		return nil
+	}
+
+	attemptCount := func() int {
+		mu.Lock()
+		defer mu.Unlock()
+		return attempts
+	}
+
+	It("destroys the container in the background", func() {
+		queue.Enqueue("some-handle", destroy)
+
+		Eventually(attemptCount).Should(Equal(1))
+		Eventually(func() bool { return queue.Destroying("some-handle") }).Should(BeFalse())
+		Expect(queue.Failures()).To(BeEmpty())
+	})
+
+	It("marks the container as destroying until it is destroyed", func() {
+		failures = 1
+		queue.Enqueue("some-handle", destroy)
+
+		Expect(queue.Destroying("some-handle")).To(BeTrue())
+		Expect(queue.Destroying("other-handle")).To(BeFalse())
+	})
+
+	It("does not destroy a container twice at once", func() {
+		failures = 1
+		queue.Enqueue("some-handle", destroy)
+		queue.Enqueue("some-handle", destroy)
+
+		Eventually(attemptCount).Should(Equal(1))
+		Consistently(attemptCount).Should(Equal(1))
+	})
+
+	Context("when destroying the container fails", func() {
+		BeforeEach(func() {
+			failures = 3
+		})
+
+		It("retries with a backoff which doubles up to the maximum", func() {
+			queue.Enqueue("some-handle", destroy)
+			Eventually(attemptCount).Should(Equal(1))
+
+			fakeClock.WaitForWatcherAndIncrement(time.Second)
+			Eventually(attemptCount).Should(Equal(2))
+
+			fakeClock.WaitForWatcherAndIncrement(time.Second)
+			Consistently(attemptCount).Should(Equal(2))
+			fakeClock.Increment(time.Second)
+			Eventually(attemptCount).Should(Equal(3))
+
+			fakeClock.WaitForWatcherAndIncrement(3 * time.Second)
+			Eventually(attemptCount).Should(Equal(4))
+			Eventually(func() bool { return queue.Destroying("some-handle") }).Should(BeFalse())
+		})
+
+		It("reports the failure until the container is destroyed", func() {
+			queue.Enqueue("some-handle", destroy)
+			Eventually(queue.Failures).Should(HaveLen(1))
+
+			failure := queue.Failures()[0]
+			Expect(failure.Handle).To(Equal("some-handle"))
+			Expect(failure.Attempts).To(Equal(1))
+			Expect(failure.LastError).To(Equal("device or resource busy"))
+			Expect(failure.NextAttempt).To(Equal(fakeClock.Now().Add(time.Second)))
+		})
+
+		Context("when the cleanup has failed the maximum number of attempts", func() {
+			BeforeEach(func() {
+				queue.MaxAttempts = 2
+			})
+
+			It("gives up on the container, so that it can be destroyed again", func() {
+				queue.Enqueue("some-handle", destroy)
+				Eventually(attemptCount).Should(Equal(1))
+
+				fakeClock.WaitForWatcherAndIncrement(time.Second)
+				Eventually(func() bool { return queue.Destroying("some-handle") }).Should(BeFalse())
+				Expect(queue.GaveUp("some-handle")).To(BeTrue())
+
+				fakeClock.Increment(time.Hour)
+				Consistently(attemptCount).Should(Equal(2))
+
+				Expect(queue.Failures()).To(HaveLen(1))
+				Expect(queue.Failures()[0].GaveUp).To(BeTrue())
+				Expect(queue.Failures()[0].Attempts).To(Equal(2))
+			})
+
+			It("forgets that it gave up once the container is enqueued again", func() {
+				queue.Enqueue("some-handle", destroy)
+				Eventually(attemptCount).Should(Equal(1))
+				fakeClock.WaitForWatcherAndIncrement(time.Second)
+				Eventually(func() bool { return queue.GaveUp("some-handle") }).Should(BeTrue())
+
+				Expect(queue.Enqueue("some-handle", destroy)).To(Succeed())
+				Expect(queue.GaveUp("some-handle")).To(BeFalse())
+				Expect(queue.Destroying("some-handle")).To(BeTrue())
+			})
+		})
+
+		It("serves the failures as JSON", func() {
+			queue.Enqueue("some-handle", destroy)
+			Eventually(queue.Failures).Should(HaveLen(1))
+
+			recorder := httptest.NewRecorder()
+			request, err := http.NewRequest("GET", "/debug/destroys", nil)
+			Expect(err).NotTo(HaveOccurred())
+			(&gardener.DestroyQueueHandler{Queue: queue}).ServeHTTP(recorder, request)
+
+			var served []gardener.DestroyFailure
+			Expect(json.NewDecoder(recorder.Body).Decode(&served)).To(Succeed())
+			Expect(served).To(HaveLen(1))
+			Expect(served[0].Handle).To(Equal("some-handle"))
+			Expect(served[0].LastError).To(Equal("device or resource busy"))
+		})
+	})
+
+	Context("with a state path", func() {
+		var statePath string
+
+		BeforeEach(func() {
+			stateDir, err := ioutil.TempDir("", "destroy-queue")
+			Expect(err).NotTo(HaveOccurred())
+
+			statePath = filepath.Join(stateDir, "destroy-queue.json")
+			queue.StatePath = statePath
+		})
+
+		AfterEach(func() {
+			Expect(os.RemoveAll(filepath.Dir(statePath))).To(Succeed())
+		})
+
+		savedHandles := func() []string {
+			var handles []string
+			contents, err := ioutil.ReadFile(statePath)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(json.Unmarshal(contents, &handles)).To(Succeed())
+			return handles
+		}
+
+		It("saves the containers being destroyed until they are destroyed", func() {
+			failures = 1
+			Expect(queue.Enqueue("some-handle", destroy)).To(Succeed())
+			Expect(savedHandles()).To(Equal([]string{"some-handle"}))
+
+			fakeClock.WaitForWatcherAndIncrement(time.Second)
+			Eventually(savedHandles).Should(BeEmpty())
+		})
+
+		It("resumes destroying the saved containers", func() {
+			Expect(ioutil.WriteFile(statePath, []byte(`["some-handle","other-handle"]`), 0644)).To(Succeed())
+
+			var resumed []string
+			Expect(queue.Resume(func(handle string) func() error {
+				resumed = append(resumed, handle)
+				return destroy
+			})).To(Succeed())
+
+			Expect(resumed).To(Equal([]string{"some-handle", "other-handle"}))
+			Eventually(attemptCount).Should(Equal(2))
+		})
+
+		It("resumes nothing when there is no state file", func() {
+			Expect(queue.Resume(func(string) func() error {
+				Fail("nothing should be resumed")
+				return nil
+			})).To(Succeed())
+		})
+
+		It("fails to resume from a corrupt state file", func() {
+			Expect(ioutil.WriteFile(statePath, []byte("{"), 0644)).To(Succeed())
+
+			err := queue.Resume(func(string) func() error { return destroy })
+			Expect(err).To(MatchError(ContainSubstring("parsing destroy queue state")))
+		})
+
+		Context("when the state cannot be saved", func() {
+			It("returns the error without destroying the container", func() {
+				queue.StatePath = "/path/does/not/exist/destroy-queue.json"
+
+				err := queue.Enqueue("some-handle", destroy)
+				Expect(err).To(MatchError(ContainSubstring("saving destroy queue state")))
+				Expect(queue.Destroying("some-handle")).To(BeFalse())
+				Consistently(attemptCount).Should(Equal(0))
+			})
+		})
+	})
+})
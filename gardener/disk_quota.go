@@ -0,0 +1,208 @@
+package gardener
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/cloudfoundry-incubator/garden"
+	"github.com/pivotal-golang/clock"
+	"github.com/pivotal-golang/lager"
+)
+
+// DiskQuotaActionProperty is the container property which may hold what is
+// done when the container exceeds its disk quota: DiskQuotaActionWarn (the
+// default), DiskQuotaActionRemountReadOnly or DiskQuotaActionDestroy
+const DiskQuotaActionProperty = "disk-quota-action"
+
+const (
+	// DiskQuotaActionWarn only publishes an EventDiskQuotaExceeded
+	DiskQuotaActionWarn = "warn"
+
+	// DiskQuotaActionRemountReadOnly also remounts the container's root
+	// filesystem read-only, so that its processes fail to write with EROFS
+	// rather than ENOSPC. It stays read-only until the container is
+	// destroyed.
+	DiskQuotaActionRemountReadOnly = "remount-ro"
+
+	// DiskQuotaActionDestroy also destroys the container
+	DiskQuotaActionDestroy = "destroy"
+)
+
+// ErrDiskUsageNotSupported is returned by DiskUsageReporters which cannot
+// report the usage of containers, e.g. image plugins without the stats
+// feature
+var ErrDiskUsageNotSupported = errors.New("disk usage is not supported by the image plugin")
+
+// DiskUsage is the disk used by a container's root filesystem as reported by
+// the image plugin, which enforces its quota
+type DiskUsage struct {
+	TotalBytesUsed     uint64
+	ExclusiveBytesUsed uint64
+	QuotaBytes         uint64
+	QuotaExceeded      bool
+}
+
+//go:generate counterfeiter . DiskUsageReporter
+//go:generate counterfeiter . ReadOnlyRemounter
+//go:generate counterfeiter . ContainerDestroyer
+//go:generate counterfeiter . HandleLister
+
+// DiskUsageReporter is implemented by VolumeCreators which can report the
+// disk usage of the rootfses they create
+type DiskUsageReporter interface {
+	DiskUsage(log lager.Logger, handle string) (DiskUsage, error)
+}
+
+// ReadOnlyRemounter remounts the root filesystem of a running container
+// read-only
+type ReadOnlyRemounter interface {
+	RemountReadOnly(log lager.Logger, handle string) error
+}
+
+type ContainerDestroyer interface {
+	Destroy(handle string) error
+}
+
+type HandleLister interface {
+	Handles() ([]string, error)
+}
+
+// DiskQuotaWatcher periodically asks the image plugin whether each container
+// has exceeded its disk quota. The first time it finds a container has, it
+// publishes an EventDiskQuotaExceeded and takes the container's
+// disk-quota-action, so that the platform learns why the container's writes
+// fail with ENOSPC. A container which falls back under its quota may exceed
+// it again.
+type DiskQuotaWatcher struct {
+	Reporter   DiskUsageReporter
+	Lister     HandleLister
+	Properties PropertyManager
+
+	// Remounter and Destroyer carry out the remount-ro and destroy actions;
+	// Destroyer destroys the container and all of its resources
+	Remounter ReadOnlyRemounter
+	Destroyer ContainerDestroyer
+
+	// Publisher is optional
+	Publisher EventPublisher
+
+	Clock    clock.Clock
+	Interval time.Duration
+	Logger   lager.Logger
+
+	mu       sync.Mutex
+	exceeded map[string]bool
+}
+
+// Start begins checking in the background every interval.
+func (w *DiskQuotaWatcher) Start() error {
+	go func() {
+		ticker := w.Clock.NewTicker(w.Interval)
+		defer ticker.Stop()
+
+		for range ticker.C() {
+			w.Check()
+		}
+	}()
+
+	return nil
+}
+
+// Check checks the disk usage of every container once.
+func (w *DiskQuotaWatcher) Check() {
+	log := w.Logger.Session("check-disk-quotas")
+
+	handles, err := w.Lister.Handles()
+	if err != nil {
+		log.Error("list-handles-failed", err)
+		return
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.exceeded == nil {
+		w.exceeded = make(map[string]bool)
+	}
+
+	seen := make(map[string]bool)
+	for _, handle := range handles {
+		seen[handle] = true
+
+		usage, err := w.Reporter.DiskUsage(log, handle)
+		if err == ErrDiskUsageNotSupported {
+			return
+		}
+
+		if err != nil {
+			log.Error("disk-usage-failed", err, lager.Data{"handle": handle})
+			continue
+		}
+
+		if !usage.QuotaExceeded {
+			delete(w.exceeded, handle)
+			continue
+		}
+
+		if w.exceeded[handle] {
+			continue
+		}
+
+		w.exceeded[handle] = true
+		w.quotaExceeded(log, handle, usage)
+	}
+
+	for handle := range w.exceeded {
+		if !seen[handle] {
+			delete(w.exceeded, handle)
+		}
+	}
+}
+
+func (w *DiskQuotaWatcher) quotaExceeded(log lager.Logger, handle string, usage DiskUsage) {
+	action, err := w.Properties.Get(handle, DiskQuotaActionProperty)
+	if err != nil || action == "" {
+		action = DiskQuotaActionWarn
+	}
+
+	log = log.Session("disk-quota-exceeded", lager.Data{"handle": handle, "action": action, "usage": usage})
+	log.Info("started")
+	defer log.Info("finished")
+
+	if w.Publisher != nil {
+		w.Publisher.Publish(Event{Handle: handle, Type: EventDiskQuotaExceeded, Data: map[string]string{
+			"action":               action,
+			"total_bytes_used":     strconv.FormatUint(usage.TotalBytesUsed, 10),
+			"exclusive_bytes_used": strconv.FormatUint(usage.ExclusiveBytesUsed, 10),
+			"quota_bytes":          strconv.FormatUint(usage.QuotaBytes, 10),
+		}})
+	}
+
+	switch action {
+	case DiskQuotaActionRemountReadOnly:
+		if err := w.Remounter.RemountReadOnly(log, handle); err != nil {
+			log.Error("remount-read-only-failed", err)
+		}
+	case DiskQuotaActionDestroy:
+		if err := w.Destroyer.Destroy(handle); err != nil {
+			log.Error("destroy-failed", err)
+		}
+	}
+}
+
+func validateDiskQuotaAction(properties garden.Properties) error {
+	action, ok := properties[DiskQuotaActionProperty]
+	if !ok {
+		return nil
+	}
+
+	switch action {
+	case DiskQuotaActionWarn, DiskQuotaActionRemountReadOnly, DiskQuotaActionDestroy:
+		return nil
+	}
+
+	return fmt.Errorf("invalid %s property: '%s'", DiskQuotaActionProperty, action)
+}
@@ -0,0 +1,175 @@
+package gardener_test
+
+import (
+	"errors"
+	"time"
+
+	"github.com/cloudfoundry-incubator/guardian/gardener"
+	"github.com/cloudfoundry-incubator/guardian/gardener/fakes"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+	"github.com/pivotal-golang/clock/fakeclock"
+	"github.com/pivotal-golang/lager"
+	"github.com/pivotal-golang/lager/lagertest"
+)
+
+var _ = Describe("DiskQuotaWatcher", func() {
+	var (
+		logger     *lagertest.TestLogger
+		reporter   *fakes.FakeDiskUsageReporter
+		lister     *fakes.FakeHandleLister
+		properties *fakes.FakePropertyManager
+		remounter  *fakes.FakeReadOnlyRemounter
+		destroyer  *fakes.FakeContainerDestroyer
+		publisher  *fakes.FakeEventPublisher
+
+		exceeded map[string]bool
+		actions  map[string]string
+
+		watcher *gardener.DiskQuotaWatcher
+	)
+
+	BeforeEach(func() {
+		logger = lagertest.NewTestLogger("test")
+		reporter = new(fakes.FakeDiskUsageReporter)
+		lister = new(fakes.FakeHandleLister)
+		properties = new(fakes.FakePropertyManager)
+		remounter = new(fakes.FakeReadOnlyRemounter)
+		destroyer = new(fakes.FakeContainerDestroyer)
+		publisher = new(fakes.FakeEventPublisher)
+
+		exceeded = map[string]bool{"full": true}
+		actions = map[string]string{}
+
+		lister.HandlesReturns([]string{"full", "roomy"}, nil)
+		reporter.DiskUsageStub = func(_ lager.Logger, handle string) (gardener.DiskUsage, error) {
+			return gardener.DiskUsage{
+				TotalBytesUsed:     2048,
+				ExclusiveBytesUsed: 1024,
+				QuotaBytes:         1000,
+				QuotaExceeded:      exceeded[handle],
+			}, nil
+		}
+		properties.GetStub = func(handle, name string) (string, error) {
+			Expect(name).To(Equal(gardener.DiskQuotaActionProperty))
+			action, ok := actions[handle]
+			if !ok {
+				return "", errors.New("no such property")
+			}
+
+			return action, nil
+		}
+
+		watcher = &gardener.DiskQuotaWatcher{
+			Reporter:   reporter,
+			Lister:     lister,
+			Properties: properties,
+			Remounter:  remounter,
+			Destroyer:  destroyer,
+			Publisher:  publisher,
+			Clock:      fakeclock.NewFakeClock(time.Now()),
+			Interval:   time.Minute,
+			Logger:     logger,
+		}
+	})
+
+	It("publishes an event for each container which has exceeded its quota", func() {
+		watcher.Check()
+
+		Expect(publisher.PublishCallCount()).To(Equal(1))
+		Expect(publisher.PublishArgsForCall(0)).To(Equal(gardener.Event{
+			Handle: "full",
+			Type:   gardener.EventDiskQuotaExceeded,
+			Data: map[string]string{
+				"action":               "warn",
+				"total_bytes_used":     "2048",
+				"exclusive_bytes_used": "1024",
+				"quota_bytes":          "1000",
+			},
+		}))
+		Expect(remounter.RemountReadOnlyCallCount()).To(Equal(0))
+		Expect(destroyer.DestroyCallCount()).To(Equal(0))
+	})
+
+	It("only acts once while the container stays over its quota", func() {
+		watcher.Check()
+		watcher.Check()
+
+		Expect(publisher.PublishCallCount()).To(Equal(1))
+	})
+
+	It("acts again when the container exceeds its quota after falling back under it", func() {
+		watcher.Check()
+		exceeded["full"] = false
+		watcher.Check()
+		exceeded["full"] = true
+		watcher.Check()
+
+		Expect(publisher.PublishCallCount()).To(Equal(2))
+	})
+
+	Context("when the container's action is remount-ro", func() {
+		BeforeEach(func() {
+			actions["full"] = gardener.DiskQuotaActionRemountReadOnly
+		})
+
+		It("remounts its root filesystem read-only", func() {
+			watcher.Check()
+
+			Expect(remounter.RemountReadOnlyCallCount()).To(Equal(1))
+			_, handle := remounter.RemountReadOnlyArgsForCall(0)
+			Expect(handle).To(Equal("full"))
+			Expect(publisher.PublishArgsForCall(0).Data).To(HaveKeyWithValue("action", "remount-ro"))
+		})
+
+		It("logs when the remount fails", func() {
+			remounter.RemountReadOnlyReturns(errors.New("busy"))
+
+			watcher.Check()
+			Expect(logger).To(gbytes.Say("remount-read-only-failed"))
+		})
+	})
+
+	Context("when the container's action is destroy", func() {
+		BeforeEach(func() {
+			actions["full"] = gardener.DiskQuotaActionDestroy
+		})
+
+		It("destroys the container", func() {
+			watcher.Check()
+
+			Expect(destroyer.DestroyCallCount()).To(Equal(1))
+			Expect(destroyer.DestroyArgsForCall(0)).To(Equal("full"))
+		})
+	})
+
+	Context("when the usage of a container cannot be read", func() {
+		It("carries on with the other containers", func() {
+			lister.HandlesReturns([]string{"broken", "full"}, nil)
+			reporter.DiskUsageStub = func(_ lager.Logger, handle string) (gardener.DiskUsage, error) {
+				if handle == "broken" {
+					return gardener.DiskUsage{}, errors.New("boom")
+				}
+
+				return gardener.DiskUsage{QuotaExceeded: true}, nil
+			}
+
+			watcher.Check()
+
+			Expect(logger).To(gbytes.Say("disk-usage-failed"))
+			Expect(publisher.PublishCallCount()).To(Equal(1))
+		})
+	})
+
+	Context("when the image plugin cannot report disk usage", func() {
+		It("does not ask about every container", func() {
+			reporter.DiskUsageStub = nil
+			reporter.DiskUsageReturns(gardener.DiskUsage{}, gardener.ErrDiskUsageNotSupported)
+
+			watcher.Check()
+
+			Expect(reporter.DiskUsageCallCount()).To(Equal(1))
+		})
+	})
+})
@@ -0,0 +1,103 @@
+package gardener
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"unicode"
+
+	"github.com/cloudfoundry-incubator/garden"
+	"github.com/pivotal-golang/lager"
+)
+
+// DNSSearchDomainsProperty lists, separated by commas, search domains added
+// to the container's /etc/resolv.conf
+const DNSSearchDomainsProperty = "dns-search-domains"
+
+// DNSAdditionalHostsProperty lists, separated by commas, entries added to
+// the container's /etc/hosts, each an IP address followed by one or more
+// host names separated by spaces, e.g. "10.0.0.5 db db.internal"
+const DNSAdditionalHostsProperty = "dns-additional-hosts"
+
+// DNSSpec is a container's own DNS configuration
+type DNSSpec struct {
+	SearchDomains []string
+
+	// AdditionalHosts are /etc/hosts lines
+	AdditionalHosts []string
+}
+
+func (d DNSSpec) Empty() bool {
+	return len(d.SearchDomains) == 0 && len(d.AdditionalHosts) == 0
+}
+
+//go:generate counterfeiter . DNSNetworker
+
+// DNSNetworker is implemented by Networkers which can give a container its
+// own DNS configuration
+type DNSNetworker interface {
+	HooksWithDNS(log lager.Logger, handle, spec string, dns DNSSpec) (Hooks, error)
+}
+
+var errDNSNotSupported = Classify(FailureInvalidSpec, errors.New("the networker does not support per-container DNS"))
+
+func parseDNS(properties garden.Properties) (DNSSpec, error) {
+	var dns DNSSpec
+	for _, domain := range splitProperty(properties[DNSSearchDomainsProperty]) {
+		// a domain containing whitespace could add lines to resolv.conf
+		if strings.IndexFunc(domain, isSpaceOrControl) >= 0 {
+			return DNSSpec{}, fmt.Errorf("invalid %s property: '%s' is not a domain", DNSSearchDomainsProperty, domain)
+		}
+
+		dns.SearchDomains = append(dns.SearchDomains, domain)
+	}
+
+	for _, entry := range splitProperty(properties[DNSAdditionalHostsProperty]) {
+		fields := strings.Fields(entry)
+		if len(fields) < 2 || net.ParseIP(fields[0]) == nil {
+			return DNSSpec{}, fmt.Errorf("invalid %s property: '%s' is not an IP address followed by host names", DNSAdditionalHostsProperty, entry)
+		}
+
+		dns.AdditionalHosts = append(dns.AdditionalHosts, strings.Join(fields, " "))
+	}
+
+	return dns, nil
+}
+
+func isSpaceOrControl(r rune) bool {
+	return unicode.IsSpace(r) || unicode.IsControl(r)
+}
+
+func splitProperty(value string) []string {
+	var values []string
+	for _, v := range strings.Split(value, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			values = append(values, v)
+		}
+	}
+
+	return values
+}
+
+func (g *Gardener) networkHooks(log lager.Logger, handle, spec string, dns DNSSpec, routes []Route) (Hooks, error) {
+	if len(routes) > 0 {
+		routesNetworker, ok := g.Networker.(RoutesNetworker)
+		if !ok {
+			return Hooks{}, errRoutesNotSupported
+		}
+
+		return routesNetworker.HooksWithRoutes(log, handle, spec, dns, routes)
+	}
+
+	if dns.Empty() {
+		return g.Networker.Hooks(log, handle, spec)
+	}
+
+	dnsNetworker, ok := g.Networker.(DNSNetworker)
+	if !ok {
+		return Hooks{}, errDNSNotSupported
+	}
+
+	return dnsNetworker.HooksWithDNS(log, handle, spec, dns)
+}
@@ -0,0 +1,92 @@
+package gardener_test
+
+import (
+	"github.com/cloudfoundry-incubator/garden"
+	"github.com/cloudfoundry-incubator/guardian/gardener"
+	"github.com/cloudfoundry-incubator/guardian/gardener/fakes"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/pivotal-golang/lager/lagertest"
+)
+
+type fakeDNSNetworker struct {
+	*fakes.FakeNetworker
+	*fakes.FakeDNSNetworker
+}
+
+var _ = Describe("Per-container DNS", func() {
+	var (
+		networker fakeDNSNetworker
+		gdnr      *gardener.Gardener
+	)
+
+	BeforeEach(func() {
+		networker = fakeDNSNetworker{new(fakes.FakeNetworker), new(fakes.FakeDNSNetworker)}
+		networker.HooksWithDNSReturns(gardener.Hooks{Prestart: gardener.Hook{Path: "/path/to/hook"}}, nil)
+
+		gdnr = &gardener.Gardener{
+			Containerizer:   new(fakes.FakeContainerizer),
+			Networker:       networker,
+			VolumeCreator:   new(fakes.FakeVolumeCreator),
+			PropertyManager: new(fakes.FakePropertyManager),
+			UidGenerator:    new(fakes.FakeUidGenerator),
+			Logger:          lagertest.NewTestLogger("test"),
+		}
+	})
+
+	create := func(properties garden.Properties) error {
+		_, err := gdnr.Create(garden.ContainerSpec{Handle: "bob", Network: "10.0.0.0/30", Properties: properties})
+		return err
+	}
+
+	It("passes the container's search domains and additional hosts to the networker", func() {
+		Expect(create(garden.Properties{
+			gardener.DNSSearchDomainsProperty:   "corp.example.com, example.com",
+			gardener.DNSAdditionalHostsProperty: "10.0.0.5  db db.internal,fd00::1 v6host",
+		})).To(Succeed())
+
+		Expect(networker.HooksWithDNSCallCount()).To(Equal(1))
+		_, handle, spec, dns := networker.HooksWithDNSArgsForCall(0)
+		Expect(handle).To(Equal("bob"))
+		Expect(spec).To(Equal("10.0.0.0/30"))
+		Expect(dns).To(Equal(gardener.DNSSpec{
+			SearchDomains:   []string{"corp.example.com", "example.com"},
+			AdditionalHosts: []string{"10.0.0.5 db db.internal", "fd00::1 v6host"},
+		}))
+		Expect(networker.HooksCallCount()).To(Equal(0))
+
+		_, containerSpec := gdnr.Containerizer.(*fakes.FakeContainerizer).CreateArgsForCall(0)
+		Expect(containerSpec.NetworkHooks.Prestart.Path).To(Equal("/path/to/hook"))
+	})
+
+	It("gets the hooks as usual when the container has no DNS configuration", func() {
+		Expect(create(garden.Properties{})).To(Succeed())
+
+		Expect(networker.HooksCallCount()).To(Equal(1))
+		Expect(networker.HooksWithDNSCallCount()).To(Equal(0))
+	})
+
+	It("rejects additional hosts which do not start with an IP address", func() {
+		err := create(garden.Properties{gardener.DNSAdditionalHostsProperty: "db 10.0.0.5"})
+		Expect(err).To(MatchError("invalid dns-additional-hosts property: 'db 10.0.0.5' is not an IP address followed by host names"))
+		Expect(networker.HooksWithDNSCallCount()).To(Equal(0))
+	})
+
+	It("rejects search domains containing spaces", func() {
+		err := create(garden.Properties{gardener.DNSSearchDomainsProperty: "a.com b.com"})
+		Expect(err).To(MatchError("invalid dns-search-domains property: 'a.com b.com' is not a domain"))
+	})
+
+	It("rejects search domains which would add lines to resolv.conf", func() {
+		err := create(garden.Properties{gardener.DNSSearchDomainsProperty: "a.com\noptions"})
+		Expect(err).To(MatchError("invalid dns-search-domains property: 'a.com\noptions' is not a domain"))
+	})
+
+	Context("when the networker does not support per-container DNS", func() {
+		It("fails", func() {
+			gdnr.Networker = new(fakes.FakeNetworker)
+			err := create(garden.Properties{gardener.DNSSearchDomainsProperty: "example.com"})
+			Expect(err).To(MatchError("the networker does not support per-container DNS"))
+		})
+	})
+})
@@ -0,0 +1,148 @@
+package gardener
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"reflect"
+	"sync"
+
+	"github.com/cloudfoundry-incubator/garden"
+	"github.com/pivotal-golang/lager"
+)
+
+// EgressPolicy holds the default NetOut rules which are applied to every new
+// container. A nil EgressPolicy has no rules.
+type EgressPolicy struct {
+	mu    sync.RWMutex
+	rules []garden.NetOutRule
+}
+
+func (p *EgressPolicy) Rules() []garden.NetOutRule {
+	if p == nil {
+		return nil
+	}
+
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	return p.rules
+}
+
+// Set replaces the rules, returning any which were not in the previous set.
+func (p *EgressPolicy) Set(rules []garden.NetOutRule) []garden.NetOutRule {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var added []garden.NetOutRule
+	for _, rule := range rules {
+		if !containsRule(p.rules, rule) {
+			added = append(added, rule)
+		}
+	}
+
+	p.rules = rules
+	return added
+}
+
+// LoadEgressPolicy reads a JSON array of NetOut rules from a file.
+func LoadEgressPolicy(path string) ([]garden.NetOutRule, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("egress policy: %s", err)
+	}
+
+	var rules []garden.NetOutRule
+	if err := json.Unmarshal(data, &rules); err != nil {
+		return nil, fmt.Errorf("egress policy: parse %s: %s", path, err)
+	}
+
+	return rules, nil
+}
+
+// EgressPolicyWatcher loads an EgressPolicy from a file on start and reloads
+// it whenever the file changes. Reloads normally only affect containers
+// created afterwards; with RetroApply, rules which were added to the file are
+// also applied to all existing containers. Rules which are removed from the
+// file cannot be retracted from existing containers.
+type EgressPolicyWatcher struct {
+	Path       string
+	Policy     *EgressPolicy
+	RetroApply bool
+
+	Containerizer Containerizer
+	Networker     Networker
+	Logger        lager.Logger
+
+	stop chan struct{}
+}
+
+func (w *EgressPolicyWatcher) Start() error {
+	rules, err := LoadEgressPolicy(w.Path)
+	if err != nil {
+		return err
+	}
+
+	w.Policy.Set(rules)
+	w.Logger.Info("egress-policy-loaded", lager.Data{"path": w.Path, "rules": len(rules)})
+
+	w.stop = make(chan struct{})
+	changes, err := watchFile(w.Path, w.stop)
+	if err != nil {
+		return fmt.Errorf("egress policy: watch %s: %s", w.Path, err)
+	}
+
+	go func() {
+		for range changes {
+			w.Reload()
+		}
+	}()
+
+	return nil
+}
+
+// Stop stops watching the file for changes.
+func (w *EgressPolicyWatcher) Stop() {
+	close(w.stop)
+}
+
+// Reload re-reads the file. If it cannot be loaded the current rules are
+// kept.
+func (w *EgressPolicyWatcher) Reload() {
+	log := w.Logger.Session("reload-egress-policy", lager.Data{"path": w.Path})
+
+	rules, err := LoadEgressPolicy(w.Path)
+	if err != nil {
+		log.Error("load-failed", err)
+		return
+	}
+
+	added := w.Policy.Set(rules)
+	log.Info("reloaded", lager.Data{"rules": len(rules), "added": len(added)})
+
+	if !w.RetroApply || len(added) == 0 {
+		return
+	}
+
+	handles, err := w.Containerizer.Handles()
+	if err != nil {
+		log.Error("list-containers-failed", err)
+		return
+	}
+
+	for _, handle := range handles {
+		if err := w.Networker.BulkNetOut(log, handle, added); err != nil {
+			log.Error("retro-apply-failed", err, lager.Data{"handle": handle})
+		}
+	}
+}
+
+func containsRule(rules []garden.NetOutRule, rule garden.NetOutRule) bool {
+	for _, r := range rules {
+		if reflect.DeepEqual(r, rule) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,152 @@
+package gardener_test
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/cloudfoundry-incubator/garden"
+	"github.com/cloudfoundry-incubator/guardian/gardener"
+	"github.com/cloudfoundry-incubator/guardian/gardener/fakes"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/pivotal-golang/lager/lagertest"
+)
+
+var _ = Describe("EgressPolicy", func() {
+	var (
+		dnsRule   = garden.NetOutRule{Protocol: garden.ProtocolUDP, Ports: []garden.PortRange{garden.PortRangeFromPort(53)}}
+		httpsRule = garden.NetOutRule{Protocol: garden.ProtocolTCP, Ports: []garden.PortRange{garden.PortRangeFromPort(443)}}
+	)
+
+	It("has no rules when nil", func() {
+		var policy *gardener.EgressPolicy
+		Expect(policy.Rules()).To(BeEmpty())
+	})
+
+	It("returns the rules which were added when the rules are replaced", func() {
+		policy := &gardener.EgressPolicy{}
+		Expect(policy.Set([]garden.NetOutRule{dnsRule})).To(Equal([]garden.NetOutRule{dnsRule}))
+		Expect(policy.Set([]garden.NetOutRule{dnsRule, httpsRule})).To(Equal([]garden.NetOutRule{httpsRule}))
+		Expect(policy.Rules()).To(Equal([]garden.NetOutRule{dnsRule, httpsRule}))
+	})
+
+	Describe("EgressPolicyWatcher", func() {
+		var (
+			tmpDir        string
+			path          string
+			policy        *gardener.EgressPolicy
+			containerizer *fakes.FakeContainerizer
+			networker     *fakes.FakeNetworker
+			watcher       *gardener.EgressPolicyWatcher
+		)
+
+		writePolicy := func(contents string) {
+			Expect(ioutil.WriteFile(path+".tmp", []byte(contents), 0644)).To(Succeed())
+			Expect(os.Rename(path+".tmp", path)).To(Succeed())
+		}
+
+		BeforeEach(func() {
+			var err error
+			tmpDir, err = ioutil.TempDir("", "egress-policy")
+			Expect(err).NotTo(HaveOccurred())
+
+			path = filepath.Join(tmpDir, "policy.json")
+			writePolicy(`[{"protocol": 2, "ports": [{"start": 53, "end": 53}]}]`)
+
+			policy = &gardener.EgressPolicy{}
+			containerizer = new(fakes.FakeContainerizer)
+			networker = new(fakes.FakeNetworker)
+			watcher = &gardener.EgressPolicyWatcher{
+				Path:          path,
+				Policy:        policy,
+				Containerizer: containerizer,
+				Networker:     networker,
+				Logger:        lagertest.NewTestLogger("test"),
+			}
+		})
+
+		AfterEach(func() {
+			Expect(os.RemoveAll(tmpDir)).To(Succeed())
+		})
+
+		It("loads the policy on start", func() {
+			Expect(watcher.Start()).To(Succeed())
+			defer watcher.Stop()
+
+			Expect(policy.Rules()).To(Equal([]garden.NetOutRule{dnsRule}))
+		})
+
+		It("fails to start when the policy file is invalid", func() {
+			writePolicy("banana")
+			Expect(watcher.Start()).To(MatchError(ContainSubstring("egress policy: parse")))
+		})
+
+		It("reloads the policy when the file changes", func() {
+			Expect(watcher.Start()).To(Succeed())
+			defer watcher.Stop()
+
+			writePolicy(`[{"protocol": 1, "ports": [{"start": 443, "end": 443}]}]`)
+			Eventually(policy.Rules).Should(Equal([]garden.NetOutRule{httpsRule}))
+		})
+
+		It("does not apply reloaded rules to existing containers", func() {
+			containerizer.HandlesReturns([]string{"some-handle"}, nil)
+			Expect(watcher.Start()).To(Succeed())
+			watcher.Stop()
+
+			writePolicy(`[{"protocol": 1, "ports": [{"start": 443, "end": 443}]}]`)
+			watcher.Reload()
+
+			Expect(networker.BulkNetOutCallCount()).To(Equal(0))
+		})
+
+		It("keeps the current rules when the file becomes invalid", func() {
+			Expect(watcher.Start()).To(Succeed())
+			watcher.Stop()
+
+			writePolicy("banana")
+			watcher.Reload()
+
+			Expect(policy.Rules()).To(Equal([]garden.NetOutRule{dnsRule}))
+		})
+
+		Context("when RetroApply is set", func() {
+			BeforeEach(func() {
+				watcher.RetroApply = true
+				containerizer.HandlesReturns([]string{"handle-a", "handle-b"}, nil)
+			})
+
+			It("applies only the added rules to existing containers", func() {
+				Expect(watcher.Start()).To(Succeed())
+				watcher.Stop()
+
+				writePolicy(`[
+					{"protocol": 2, "ports": [{"start": 53, "end": 53}]},
+					{"protocol": 1, "ports": [{"start": 443, "end": 443}]}
+				]`)
+				watcher.Reload()
+
+				Expect(networker.BulkNetOutCallCount()).To(Equal(2))
+				_, handle, rules := networker.BulkNetOutArgsForCall(0)
+				Expect(handle).To(Equal("handle-a"))
+				Expect(rules).To(Equal([]garden.NetOutRule{httpsRule}))
+				_, handle, rules = networker.BulkNetOutArgsForCall(1)
+				Expect(handle).To(Equal("handle-b"))
+				Expect(rules).To(Equal([]garden.NetOutRule{httpsRule}))
+			})
+
+			It("carries on with other containers when a rule cannot be applied", func() {
+				networker.BulkNetOutReturns(errors.New("boom"))
+				Expect(watcher.Start()).To(Succeed())
+				watcher.Stop()
+
+				writePolicy(`[{"protocol": 1, "ports": [{"start": 443, "end": 443}]}]`)
+				watcher.Reload()
+
+				Expect(networker.BulkNetOutCallCount()).To(Equal(2))
+			})
+		})
+	})
+})
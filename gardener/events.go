@@ -0,0 +1,103 @@
+package gardener
+
+import (
+	"sync"
+	"time"
+)
+
+type EventType string
+
+const (
+	EventCreate         EventType = "create"
+	EventDestroy        EventType = "destroy"
+	EventOOM            EventType = "oom"
+	EventProcessExit    EventType = "process-exit"
+	EventProcessRestart EventType = "process-restart"
+	EventNetOutDenied   EventType = "net-out-denied"
+	EventCheckpoint     EventType = "checkpoint"
+	EventRestore        EventType = "restore"
+
+	EventDiskQuotaExceeded EventType = "disk-quota-exceeded"
+
+	// EventCPUThrottled and EventCPUReleased are published when a container
+	// is throttled for using more than its CPU entitlement, and released
+	EventCPUThrottled EventType = "cpu-throttled"
+	EventCPUReleased  EventType = "cpu-released"
+
+	// EventCPUMaxExceeded is published when a container persistently uses
+	// more than its cpu-max
+	EventCPUMaxExceeded EventType = "cpu-max-exceeded"
+)
+
+// Event is a container lifecycle event.
+type Event struct {
+	Handle string            `json:"handle"`
+	Type   EventType         `json:"type"`
+	Time   time.Time         `json:"time"`
+	Data   map[string]string `json:"data,omitempty"`
+}
+
+//go:generate counterfeiter . EventPublisher
+
+// EventPublisher is implemented by anything which can report container
+// events, so that components other than the gardener (e.g. an OOM watcher)
+// can publish them.
+type EventPublisher interface {
+	Publish(event Event)
+}
+
+// EventHub fans events out to every subscriber. Publishing never blocks: a
+// subscriber which falls more than its buffer behind misses events. A nil
+// EventHub drops all events.
+type EventHub struct {
+	mu          sync.Mutex
+	subscribers map[chan Event]struct{}
+	bufferSize  int
+}
+
+func NewEventHub(bufferSize int) *EventHub {
+	return &EventHub{
+		subscribers: make(map[chan Event]struct{}),
+		bufferSize:  bufferSize,
+	}
+}
+
+func (h *EventHub) Publish(event Event) {
+	if h == nil {
+		return
+	}
+
+	if event.Time.IsZero() {
+		event.Time = time.Now()
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for sub := range h.subscribers {
+		select {
+		case sub <- event:
+		default:
+		}
+	}
+}
+
+// Subscribe returns a channel of all subsequent events, and a function which
+// must be called to unsubscribe, after which the channel is closed.
+func (h *EventHub) Subscribe() (<-chan Event, func()) {
+	sub := make(chan Event, h.bufferSize)
+
+	h.mu.Lock()
+	h.subscribers[sub] = struct{}{}
+	h.mu.Unlock()
+
+	var once sync.Once
+	return sub, func() {
+		once.Do(func() {
+			h.mu.Lock()
+			delete(h.subscribers, sub)
+			h.mu.Unlock()
+			close(sub)
+		})
+	}
+}
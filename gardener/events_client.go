@@ -0,0 +1,57 @@
+package gardener
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+)
+
+// EventsClient subscribes to the events endpoint served by EventsHandler.
+type EventsClient struct {
+	// URL of the events endpoint, e.g. http://127.0.0.1:7778/events
+	URL string
+}
+
+// EventStream is a stream of events read from the events endpoint.
+type EventStream struct {
+	body    io.ReadCloser
+	decoder *json.Decoder
+}
+
+// Events subscribes to the events of the given container, or of every
+// container if handle is empty.
+func (c *EventsClient) Events(handle string) (*EventStream, error) {
+	u, err := url.Parse(c.URL)
+	if err != nil {
+		return nil, err
+	}
+
+	if handle != "" {
+		u.RawQuery = url.Values{"handle": []string{handle}}.Encode()
+	}
+
+	resp, err := http.Get(u.String())
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("subscribe to events: unexpected status %d", resp.StatusCode)
+	}
+
+	return &EventStream{body: resp.Body, decoder: json.NewDecoder(resp.Body)}, nil
+}
+
+// Next blocks until the next event arrives.
+func (s *EventStream) Next() (Event, error) {
+	var event Event
+	err := s.decoder.Decode(&event)
+	return event, err
+}
+
+func (s *EventStream) Close() error {
+	return s.body.Close()
+}
@@ -0,0 +1,57 @@
+package gardener
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// EventsHandler streams container events as newline-delimited JSON until
+// the client disconnects. Events can be limited to a single container with
+// the `handle` query parameter.
+type EventsHandler struct {
+	Hub *EventHub
+}
+
+func (h *EventsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	handle := r.URL.Query().Get("handle")
+
+	events, unsubscribe := h.Hub.Subscribe()
+	defer unsubscribe()
+
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.WriteHeader(http.StatusOK)
+	flush(w)
+
+	var closed <-chan bool
+	if notifier, ok := w.(http.CloseNotifier); ok {
+		closed = notifier.CloseNotify()
+	}
+
+	encoder := json.NewEncoder(w)
+	for {
+		select {
+		case event, ok := <-events:
+			if !ok {
+				return
+			}
+
+			if handle != "" && event.Handle != handle {
+				continue
+			}
+
+			if err := encoder.Encode(event); err != nil {
+				return
+			}
+
+			flush(w)
+		case <-closed:
+			return
+		}
+	}
+}
+
+func flush(w http.ResponseWriter) {
+	if flusher, ok := w.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
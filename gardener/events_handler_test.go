@@ -0,0 +1,61 @@
+package gardener_test
+
+import (
+	"net/http/httptest"
+
+	"github.com/cloudfoundry-incubator/guardian/gardener"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("EventsHandler and EventsClient", func() {
+	var (
+		hub    *gardener.EventHub
+		server *httptest.Server
+		client *gardener.EventsClient
+	)
+
+	BeforeEach(func() {
+		hub = gardener.NewEventHub(10)
+		server = httptest.NewServer(&gardener.EventsHandler{Hub: hub})
+		client = &gardener.EventsClient{URL: server.URL + "/events"}
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	It("streams events to the client", func() {
+		stream, err := client.Events("")
+		Expect(err).NotTo(HaveOccurred())
+		defer stream.Close()
+
+		hub.Publish(gardener.Event{Handle: "apple", Type: gardener.EventCreate})
+		hub.Publish(gardener.Event{Handle: "banana", Type: gardener.EventDestroy})
+
+		event, err := stream.Next()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(event.Handle).To(Equal("apple"))
+		Expect(event.Type).To(Equal(gardener.EventCreate))
+
+		event, err = stream.Next()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(event.Handle).To(Equal("banana"))
+		Expect(event.Type).To(Equal(gardener.EventDestroy))
+	})
+
+	It("only streams events for the requested container", func() {
+		stream, err := client.Events("banana")
+		Expect(err).NotTo(HaveOccurred())
+		defer stream.Close()
+
+		hub.Publish(gardener.Event{Handle: "apple", Type: gardener.EventCreate})
+		hub.Publish(gardener.Event{Handle: "banana", Type: gardener.EventOOM})
+
+		event, err := stream.Next()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(event.Handle).To(Equal("banana"))
+		Expect(event.Type).To(Equal(gardener.EventOOM))
+	})
+})
@@ -0,0 +1,58 @@
+package gardener_test
+
+import (
+	"github.com/cloudfoundry-incubator/guardian/gardener"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("EventHub", func() {
+	var hub *gardener.EventHub
+
+	BeforeEach(func() {
+		hub = gardener.NewEventHub(2)
+	})
+
+	It("delivers published events to every subscriber", func() {
+		first, unsubscribeFirst := hub.Subscribe()
+		defer unsubscribeFirst()
+		second, unsubscribeSecond := hub.Subscribe()
+		defer unsubscribeSecond()
+
+		hub.Publish(gardener.Event{Handle: "apple", Type: gardener.EventCreate})
+
+		var event gardener.Event
+		Expect(first).To(Receive(&event))
+		Expect(event.Handle).To(Equal("apple"))
+		Expect(event.Time).NotTo(BeZero())
+		Expect(second).To(Receive())
+	})
+
+	It("does not block when a subscriber is not keeping up", func() {
+		events, unsubscribe := hub.Subscribe()
+		defer unsubscribe()
+
+		for i := 0; i < 5; i++ {
+			hub.Publish(gardener.Event{Handle: "apple", Type: gardener.EventCreate})
+		}
+
+		Expect(events).To(HaveLen(2))
+	})
+
+	It("closes the channel when unsubscribing", func() {
+		events, unsubscribe := hub.Subscribe()
+		unsubscribe()
+		unsubscribe()
+
+		Expect(events).To(BeClosed())
+		hub.Publish(gardener.Event{Handle: "apple", Type: gardener.EventCreate})
+	})
+
+	Context("when the hub is nil", func() {
+		It("drops published events", func() {
+			var nilHub *gardener.EventHub
+			Expect(func() { nilHub.Publish(gardener.Event{}) }).NotTo(Panic())
+		})
+	})
+})
@@ -0,0 +1,169 @@
+package gardener
+
+import (
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/cloudfoundry-incubator/garden"
+)
+
+// ExecConcurrencyProperty is the container property which may hold the
+// maximum number of processes started by Run and Attach which may be running
+// in the container at once. It may lower the ExecLimiter's default but not
+// raise it (0 means the default).
+const ExecConcurrencyProperty = "exec-concurrency"
+
+// ExecQueueTimeoutProperty is the container property which may hold how long,
+// as a duration such as '5s', requests beyond the container's exec
+// concurrency wait for a slot before they fail, overriding the ExecLimiter's
+// default (0 means they fail immediately)
+const ExecQueueTimeoutProperty = "exec-queue-timeout"
+
+// ExecConcurrencyExceededError is returned by Run and Attach when the
+// container already has its maximum number of run or attached processes
+// running, and no slot became free within its queue timeout
+type ExecConcurrencyExceededError struct {
+	Handle string
+	Limit  int
+	Waited time.Duration
+}
+
+func (e ExecConcurrencyExceededError) Error() string {
+	if e.Waited == 0 {
+		return fmt.Sprintf("container %s already has its limit of %d run or attached processes running", e.Handle, e.Limit)
+	}
+
+	return fmt.Sprintf("container %s still had its limit of %d run or attached processes running after waiting %s", e.Handle, e.Limit, e.Waited)
+}
+
+// ExecLimiter caps the number of processes started by Run and Attach in each
+// container which are running at once, so that e.g. a storm of health checks
+// cannot overwhelm a tiny container. Each Run and Attach holds a slot until
+// its process exits. A nil ExecLimiter imposes no limit.
+type ExecLimiter struct {
+	mu           sync.Mutex
+	max          int
+	queueTimeout time.Duration
+	slots        map[string]*execSlots
+}
+
+type execSlots struct {
+	active int
+
+	// freed is closed, and replaced, whenever a slot is freed
+	freed chan struct{}
+}
+
+// NewExecLimiter returns an ExecLimiter which by default allows max requests
+// in progress per container (0 means no limit), with requests beyond it
+// waiting up to queueTimeout for a slot
+func NewExecLimiter(max int, queueTimeout time.Duration) *ExecLimiter {
+	return &ExecLimiter{
+		max:          max,
+		queueTimeout: queueTimeout,
+		slots:        make(map[string]*execSlots),
+	}
+}
+
+// Acquire reserves a slot in the container, waiting up to queueTimeout for
+// one to be freed. max and queueTimeout are the container's own settings;
+// if max is 0, or is above the default, the defaults apply, and there is no
+// limit if neither is set. Every successful Acquire must be followed by a
+// Release.
+func (l *ExecLimiter) Acquire(handle string, max int, queueTimeout time.Duration) error {
+	if l == nil {
+		return nil
+	}
+
+	if max == 0 || (l.max > 0 && max > l.max) {
+		max, queueTimeout = l.max, l.queueTimeout
+	}
+
+	start := time.Now()
+	for {
+		l.mu.Lock()
+		slots, ok := l.slots[handle]
+		if !ok {
+			slots = &execSlots{freed: make(chan struct{})}
+			l.slots[handle] = slots
+		}
+
+		if max <= 0 || slots.active < max {
+			slots.active++
+			l.mu.Unlock()
+			return nil
+		}
+
+		freed := slots.freed
+		l.mu.Unlock()
+
+		remaining := queueTimeout - time.Since(start)
+		if remaining <= 0 {
+			return ExecConcurrencyExceededError{Handle: handle, Limit: max, Waited: queueTimeout}
+		}
+
+		select {
+		case <-freed:
+		case <-time.After(remaining):
+		}
+	}
+}
+
+// Release frees a slot reserved with Acquire, letting a waiting request in
+func (l *ExecLimiter) Release(handle string) {
+	if l == nil {
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	slots, ok := l.slots[handle]
+	if !ok {
+		return
+	}
+
+	slots.active--
+	close(slots.freed)
+	slots.freed = make(chan struct{})
+}
+
+// Forget drops the container's slots, e.g. once it is destroyed
+func (l *ExecLimiter) Forget(handle string) {
+	if l == nil {
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	delete(l.slots, handle)
+}
+
+func parseExecConcurrency(properties garden.Properties) (int, time.Duration, error) {
+	var (
+		max          int
+		queueTimeout time.Duration
+		err          error
+	)
+
+	if raw, ok := properties[ExecConcurrencyProperty]; ok {
+		if max, err = strconv.Atoi(raw); err != nil || max < 0 {
+			return 0, 0, fmt.Errorf("invalid %s property: '%s'", ExecConcurrencyProperty, raw)
+		}
+	}
+
+	if raw, ok := properties[ExecQueueTimeoutProperty]; ok {
+		if queueTimeout, err = time.ParseDuration(raw); err != nil || queueTimeout < 0 {
+			return 0, 0, fmt.Errorf("invalid %s property: '%s'", ExecQueueTimeoutProperty, raw)
+		}
+
+		if max == 0 {
+			return 0, 0, fmt.Errorf("invalid %s property: '%s': the %s property must also be set", ExecQueueTimeoutProperty, raw, ExecConcurrencyProperty)
+		}
+	}
+
+	return max, queueTimeout, nil
+}
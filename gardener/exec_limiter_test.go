@@ -0,0 +1,132 @@
+package gardener_test
+
+import (
+	"time"
+
+	"github.com/cloudfoundry-incubator/garden"
+	"github.com/cloudfoundry-incubator/guardian/gardener"
+	"github.com/cloudfoundry-incubator/guardian/gardener/fakes"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/pivotal-golang/lager/lagertest"
+)
+
+var _ = Describe("ExecLimiter", func() {
+	var limiter *gardener.ExecLimiter
+
+	BeforeEach(func() {
+		limiter = gardener.NewExecLimiter(1, 0)
+	})
+
+	It("rejects requests beyond the default limit", func() {
+		Expect(limiter.Acquire("some-handle", 0, 0)).To(Succeed())
+
+		err := limiter.Acquire("some-handle", 0, 0)
+		Expect(err).To(Equal(gardener.ExecConcurrencyExceededError{Handle: "some-handle", Limit: 1}))
+		Expect(err).To(MatchError("container some-handle already has its limit of 1 run or attached processes running"))
+	})
+
+	It("limits each container separately", func() {
+		Expect(limiter.Acquire("some-handle", 0, 0)).To(Succeed())
+		Expect(limiter.Acquire("other-handle", 0, 0)).To(Succeed())
+	})
+
+	It("does not let the container's own limit raise the default", func() {
+		Expect(limiter.Acquire("some-handle", 2, 0)).To(Succeed())
+		Expect(limiter.Acquire("some-handle", 2, 0)).To(MatchError(ContainSubstring("limit of 1")))
+	})
+
+	It("lets the container's own limit lower the default", func() {
+		limiter = gardener.NewExecLimiter(3, 0)
+
+		Expect(limiter.Acquire("some-handle", 2, 0)).To(Succeed())
+		Expect(limiter.Acquire("some-handle", 2, 0)).To(Succeed())
+		Expect(limiter.Acquire("some-handle", 2, 0)).To(MatchError(ContainSubstring("limit of 2")))
+	})
+
+	It("allows another request once one is released", func() {
+		Expect(limiter.Acquire("some-handle", 0, 0)).To(Succeed())
+		limiter.Release("some-handle")
+
+		Expect(limiter.Acquire("some-handle", 0, 0)).To(Succeed())
+	})
+
+	It("forgets a container's requests", func() {
+		Expect(limiter.Acquire("some-handle", 0, 0)).To(Succeed())
+		limiter.Forget("some-handle")
+
+		Expect(limiter.Acquire("some-handle", 0, 0)).To(Succeed())
+	})
+
+	Context("when requests may queue", func() {
+		It("lets a waiting request in when a slot is released", func() {
+			Expect(limiter.Acquire("some-handle", 1, 5*time.Second)).To(Succeed())
+
+			acquired := make(chan error)
+			go func() {
+				acquired <- limiter.Acquire("some-handle", 1, 5*time.Second)
+			}()
+			Consistently(acquired).ShouldNot(Receive())
+
+			limiter.Release("some-handle")
+			Eventually(acquired).Should(Receive(BeNil()))
+		})
+
+		It("fails requests which are still waiting after the queue timeout", func() {
+			Expect(limiter.Acquire("some-handle", 1, 50*time.Millisecond)).To(Succeed())
+
+			err := limiter.Acquire("some-handle", 1, 50*time.Millisecond)
+			Expect(err).To(MatchError("container some-handle still had its limit of 1 run or attached processes running after waiting 50ms"))
+		})
+	})
+
+	Context("when there is no default limit", func() {
+		It("does not limit containers without a limit of their own", func() {
+			limiter = gardener.NewExecLimiter(0, 0)
+			for i := 0; i < 10; i++ {
+				Expect(limiter.Acquire("some-handle", 0, 0)).To(Succeed())
+			}
+		})
+
+		It("limits containers with a limit of their own", func() {
+			limiter = gardener.NewExecLimiter(0, 0)
+			Expect(limiter.Acquire("some-handle", 1, 0)).To(Succeed())
+			Expect(limiter.Acquire("some-handle", 1, 0)).NotTo(Succeed())
+		})
+	})
+
+	Context("when the limiter is nil", func() {
+		It("does not limit anything", func() {
+			limiter = nil
+			Expect(limiter.Acquire("some-handle", 1, 0)).To(Succeed())
+			Expect(limiter.Acquire("some-handle", 1, 0)).To(Succeed())
+		})
+	})
+
+	Describe("validating the container's properties", func() {
+		var gdnr *gardener.Gardener
+
+		BeforeEach(func() {
+			gdnr = &gardener.Gardener{
+				Containerizer:   new(fakes.FakeContainerizer),
+				Networker:       new(fakes.FakeNetworker),
+				VolumeCreator:   new(fakes.FakeVolumeCreator),
+				PropertyManager: new(fakes.FakePropertyManager),
+				Logger:          lagertest.NewTestLogger("test"),
+			}
+		})
+
+		It("rejects a queue timeout without a concurrency limit", func() {
+			err := gdnr.ValidateCreate(garden.ContainerSpec{Properties: garden.Properties{gardener.ExecQueueTimeoutProperty: "5s"}})
+			Expect(err).To(MatchError("invalid exec-queue-timeout property: '5s': the exec-concurrency property must also be set"))
+		})
+
+		It("rejects a queue timeout which is not a duration", func() {
+			err := gdnr.ValidateCreate(garden.ContainerSpec{Properties: garden.Properties{
+				gardener.ExecConcurrencyProperty:  "1",
+				gardener.ExecQueueTimeoutProperty: "soon",
+			}})
+			Expect(err).To(MatchError("invalid exec-queue-timeout property: 'soon'"))
+		})
+	})
+})
@@ -0,0 +1,313 @@
+package gardener
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync"
+
+	"github.com/cloudfoundry-incubator/garden"
+)
+
+// ExitReason says why a process exited
+type ExitReason string
+
+const (
+	// ExitReasonExited is a process which exited without guardian signalling
+	// it. The runtime reports a process killed by signal n as exiting with
+	// 128+n, which cannot be told apart from the process exiting with that
+	// code itself, so such a process may also have been killed by a signal
+	// guardian did not send, e.g. from another process in the container or
+	// the OOM killer.
+	ExitReasonExited ExitReason = "exited"
+
+	// ExitReasonStopped is a process which exited, gracefully or not, after a
+	// client signalled it to terminate or be killed
+	ExitReasonStopped ExitReason = "stopped"
+
+	// ExitReasonDestroyed is a process killed because its container was
+	// destroyed
+	ExitReasonDestroyed ExitReason = "destroyed"
+)
+
+// maxExitStatuses is how many exit statuses the ExitTracker keeps for
+// clients to fetch once their processes have exited
+const maxExitStatuses = 1000
+
+// ExitStatus is how a process exited, so that clients need not guess from an
+// exit code of 128+n whether the process was killed, and by whom
+type ExitStatus struct {
+	// Code is the exit code returned by Wait
+	Code int `json:"code"`
+
+	Reason ExitReason `json:"reason"`
+
+	// Signal is the signal guardian sent which killed the process, i.e. when
+	// it was stopped or destroyed and exited with 128 plus that signal, or 0
+	Signal int `json:"signal,omitempty"`
+
+	// SentSignal is the last signal a client sent the process ("terminate" or
+	// "kill"), if any
+	SentSignal string `json:"sent_signal,omitempty"`
+}
+
+// Signalled returns whether the process was killed by a signal guardian sent
+// rather than exiting, e.g. to tell a graceful stop from a kill
+func (s ExitStatus) Signalled() bool {
+	return s.Signal != 0
+}
+
+// ExitStatusWaiter is implemented by processes run by the Gardener which are
+// tracked by an ExitTracker
+type ExitStatusWaiter interface {
+	WaitStatus() (ExitStatus, error)
+}
+
+// ExitTracker follows the processes running in each container, recording the
+// signals clients send them and whether their container is being destroyed,
+// so that the reason each exits can be reported. The statuses of the last
+// maxExitStatuses processes to exit are kept for clients, whose Wait only
+// returns the exit code, to fetch. A nil ExitTracker tracks nothing.
+type ExitTracker struct {
+	mu      sync.Mutex
+	running map[string]map[*trackedProcess]struct{}
+	exited  map[exitKey]ExitStatus
+	order   []exitKey
+}
+
+type exitKey struct {
+	handle    string
+	processID string
+}
+
+func NewExitTracker() *ExitTracker {
+	return &ExitTracker{
+		running: make(map[string]map[*trackedProcess]struct{}),
+		exited:  make(map[exitKey]ExitStatus),
+	}
+}
+
+// Track returns a process which stands in for the given one, recording the
+// signals sent to it, until it exits.
+func (t *ExitTracker) Track(handle string, process garden.Process) garden.Process {
+	if t == nil {
+		return process
+	}
+
+	tracked := &trackedProcess{Process: process, recorded: make(chan struct{})}
+
+	t.mu.Lock()
+	if t.running[handle] == nil {
+		t.running[handle] = make(map[*trackedProcess]struct{})
+	}
+	t.running[handle][tracked] = struct{}{}
+	t.mu.Unlock()
+
+	go func() {
+		status, err := tracked.waitStatus()
+		t.exit(handle, tracked, status, err)
+	}()
+
+	return tracked
+}
+
+// ContainerDestroying marks the processes running in the container as killed
+// by its destruction. It must be called before the container is destroyed.
+func (t *ExitTracker) ContainerDestroying(handle string) {
+	t.markDestroyed(handle, true)
+}
+
+// ContainerDestroyFailed unmarks the processes still running in a container
+// which could not be destroyed, as they have not been killed
+func (t *ExitTracker) ContainerDestroyFailed(handle string) {
+	t.markDestroyed(handle, false)
+}
+
+// ExitStatus returns how the container's process exited, if it is one of the
+// last maxExitStatuses tracked processes to have exited
+func (t *ExitTracker) ExitStatus(handle, processID string) (ExitStatus, bool) {
+	if t == nil {
+		return ExitStatus{}, false
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	status, ok := t.exited[exitKey{handle, processID}]
+	return status, ok
+}
+
+// Running returns whether the container's process is being tracked and has
+// not yet exited
+func (t *ExitTracker) Running(handle, processID string) bool {
+	if t == nil {
+		return false
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for process := range t.running[handle] {
+		if process.Process.ID() == processID {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (t *ExitTracker) markDestroyed(handle string, destroyed bool) {
+	if t == nil {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for process := range t.running[handle] {
+		process.markDestroyed(destroyed)
+	}
+}
+
+// exit records the status of a process which has exited, before its waiters
+// are told, so that they can fetch the status as soon as their Wait returns
+func (t *ExitTracker) exit(handle string, process *trackedProcess, status ExitStatus, err error) {
+	t.mu.Lock()
+	delete(t.running[handle], process)
+	if len(t.running[handle]) == 0 {
+		delete(t.running, handle)
+	}
+
+	if err == nil {
+		key := exitKey{handle, process.Process.ID()}
+		if _, ok := t.exited[key]; !ok {
+			t.order = append(t.order, key)
+		}
+		t.exited[key] = status
+
+		if len(t.order) > maxExitStatuses {
+			delete(t.exited, t.order[0])
+			t.order = t.order[1:]
+		}
+	}
+	t.mu.Unlock()
+
+	process.record(status, err)
+}
+
+type trackedProcess struct {
+	garden.Process
+
+	mu         sync.Mutex
+	sentSignal string
+	destroyed  bool
+
+	recorded  chan struct{}
+	status    ExitStatus
+	statusErr error
+}
+
+func (p *trackedProcess) Signal(signal garden.Signal) error {
+	p.mu.Lock()
+	switch signal {
+	case garden.SignalTerminate:
+		p.sentSignal = "terminate"
+	case garden.SignalKill:
+		p.sentSignal = "kill"
+	}
+	p.mu.Unlock()
+
+	return p.Process.Signal(signal)
+}
+
+// Wait returns once the process's exit status has been recorded
+func (p *trackedProcess) Wait() (int, error) {
+	status, err := p.WaitStatus()
+	return status.Code, err
+}
+
+func (p *trackedProcess) WaitStatus() (ExitStatus, error) {
+	<-p.recorded
+	return p.status, p.statusErr
+}
+
+// signalNumbers are the numbers of the signals guardian sends processes
+var signalNumbers = map[string]int{
+	"terminate": 15,
+	"kill":      9,
+}
+
+func (p *trackedProcess) waitStatus() (ExitStatus, error) {
+	code, err := p.Process.Wait()
+	if err != nil {
+		return ExitStatus{}, err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	status := ExitStatus{Code: code, SentSignal: p.sentSignal}
+
+	switch {
+	case p.destroyed:
+		status.Reason = ExitReasonDestroyed
+		if code == 128+signalNumbers["kill"] {
+			status.Signal = signalNumbers["kill"]
+		}
+	case p.sentSignal != "":
+		status.Reason = ExitReasonStopped
+	default:
+		status.Reason = ExitReasonExited
+	}
+
+	if signal := signalNumbers[p.sentSignal]; signal != 0 && code == 128+signal {
+		status.Signal = signal
+	}
+
+	return status, nil
+}
+
+func (p *trackedProcess) record(status ExitStatus, err error) {
+	p.status, p.statusErr = status, err
+	close(p.recorded)
+}
+
+func (p *trackedProcess) markDestroyed(destroyed bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.destroyed = destroyed
+}
+
+// ProcessExitHandler serves the ExitStatus of the process named by the
+// `process` query parameter in the container named by `handle` as JSON, so
+// that clients can find out why a process exited once their Wait returns
+type ProcessExitHandler struct {
+	Exits *ExitTracker
+}
+
+func (h *ProcessExitHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		writeError(w, r, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	handle, processID := r.URL.Query().Get("handle"), r.URL.Query().Get("process")
+	if handle == "" || processID == "" {
+		writeError(w, r, "handle and process are required", http.StatusBadRequest)
+		return
+	}
+
+	status, ok := h.Exits.ExitStatus(handle, processID)
+	if !ok {
+		if h.Exits.Running(handle, processID) {
+			writeError(w, r, "process has not exited", http.StatusConflict)
+			return
+		}
+
+		writeError(w, r, "exit status not found", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(status)
+}
@@ -0,0 +1,193 @@
+package gardener_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/cloudfoundry-incubator/garden"
+	gardenfakes "github.com/cloudfoundry-incubator/garden/fakes"
+	"github.com/cloudfoundry-incubator/guardian/gardener"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ExitTracker", func() {
+	var (
+		tracker *gardener.ExitTracker
+		process *gardenfakes.FakeProcess
+		exit    chan int
+		tracked garden.Process
+	)
+
+	BeforeEach(func() {
+		tracker = gardener.NewExitTracker()
+
+		exit = make(chan int, 1)
+		process = new(gardenfakes.FakeProcess)
+		process.WaitStub = func() (int, error) {
+			// both the tracker and the test wait for the process
+			code := <-exit
+			exit <- code
+			return code, nil
+		}
+	})
+
+	JustBeforeEach(func() {
+		tracked = tracker.Track("banana", process)
+	})
+
+	waitStatus := func() gardener.ExitStatus {
+		status, err := tracked.(gardener.ExitStatusWaiter).WaitStatus()
+		Expect(err).NotTo(HaveOccurred())
+		return status
+	}
+
+	It("reports processes which exit of their own accord", func() {
+		exit <- 3
+		Expect(waitStatus()).To(Equal(gardener.ExitStatus{Code: 3, Reason: gardener.ExitReasonExited}))
+	})
+
+	It("does not guess that processes guardian did not signal were killed by a signal", func() {
+		exit <- 137
+		status := waitStatus()
+		Expect(status).To(Equal(gardener.ExitStatus{Code: 137, Reason: gardener.ExitReasonExited}))
+		Expect(status.Signalled()).To(BeFalse())
+	})
+
+	It("returns the exit code from Wait once the status has been recorded", func() {
+		exit <- 3
+		Expect(tracked.Wait()).To(Equal(3))
+
+		status, ok := tracker.ExitStatus("banana", tracked.ID())
+		Expect(ok).To(BeTrue())
+		Expect(status.Code).To(Equal(3))
+		Expect(tracker.Running("banana", tracked.ID())).To(BeFalse())
+	})
+
+	It("reports processes which exit gracefully once a client terminates them", func() {
+		Expect(tracked.Signal(garden.SignalTerminate)).To(Succeed())
+		Expect(process.SignalArgsForCall(0)).To(Equal(garden.SignalTerminate))
+
+		exit <- 0
+		status := waitStatus()
+		Expect(status).To(Equal(gardener.ExitStatus{Code: 0, Reason: gardener.ExitReasonStopped, SentSignal: "terminate"}))
+		Expect(status.Signalled()).To(BeFalse())
+	})
+
+	It("reports processes a client kills", func() {
+		Expect(tracked.Signal(garden.SignalKill)).To(Succeed())
+
+		exit <- 137
+		Expect(waitStatus()).To(Equal(gardener.ExitStatus{Code: 137, Reason: gardener.ExitReasonStopped, Signal: 9, SentSignal: "kill"}))
+	})
+
+	It("reports processes killed because their container was destroyed", func() {
+		tracker.ContainerDestroying("banana")
+
+		exit <- 137
+		Expect(waitStatus()).To(Equal(gardener.ExitStatus{Code: 137, Reason: gardener.ExitReasonDestroyed, Signal: 9}))
+	})
+
+	It("does not mark the processes of containers which could not be destroyed", func() {
+		tracker.ContainerDestroying("banana")
+		tracker.ContainerDestroyFailed("banana")
+
+		exit <- 0
+		Expect(waitStatus().Reason).To(Equal(gardener.ExitReasonExited))
+	})
+
+	It("does not mark the processes of other containers as destroyed", func() {
+		tracker.ContainerDestroying("apple")
+
+		exit <- 0
+		Expect(waitStatus().Reason).To(Equal(gardener.ExitReasonExited))
+	})
+
+	Context("when waiting for the process fails", func() {
+		BeforeEach(func() {
+			process.WaitStub = nil
+			process.WaitReturns(0, errors.New("lost the link"))
+		})
+
+		It("returns the error", func() {
+			_, err := tracked.(gardener.ExitStatusWaiter).WaitStatus()
+			Expect(err).To(MatchError("lost the link"))
+		})
+
+		It("does not record an exit status", func() {
+			tracked.Wait()
+
+			_, ok := tracker.ExitStatus("banana", tracked.ID())
+			Expect(ok).To(BeFalse())
+		})
+	})
+
+	Context("when the tracker is nil", func() {
+		It("returns the process unchanged", func() {
+			var nilTracker *gardener.ExitTracker
+			Expect(nilTracker.Track("banana", process)).To(BeIdenticalTo(process))
+			nilTracker.ContainerDestroying("banana")
+
+			exit <- 0
+		})
+	})
+
+	Describe("ProcessExitHandler", func() {
+		var server *httptest.Server
+
+		JustBeforeEach(func() {
+			process.IDReturns("some-process")
+			server = httptest.NewServer(&gardener.ProcessExitHandler{Exits: tracker})
+		})
+
+		AfterEach(func() {
+			server.Close()
+		})
+
+		get := func(query string) *http.Response {
+			resp, err := http.Get(server.URL + "?" + query)
+			Expect(err).NotTo(HaveOccurred())
+			return resp
+		}
+
+		It("serves the exit status of a process which has exited", func() {
+			tracked.Signal(garden.SignalTerminate)
+			exit <- 143
+			tracked.Wait()
+
+			resp := get("handle=banana&process=some-process")
+			defer resp.Body.Close()
+			Expect(resp.StatusCode).To(Equal(http.StatusOK))
+
+			var status gardener.ExitStatus
+			Expect(json.NewDecoder(resp.Body).Decode(&status)).To(Succeed())
+			Expect(status).To(Equal(gardener.ExitStatus{Code: 143, Reason: gardener.ExitReasonStopped, Signal: 15, SentSignal: "terminate"}))
+		})
+
+		It("returns 409 while the process is running", func() {
+			resp := get("handle=banana&process=some-process")
+			resp.Body.Close()
+			Expect(resp.StatusCode).To(Equal(http.StatusConflict))
+
+			exit <- 0
+		})
+
+		It("returns 404 for processes it does not know", func() {
+			resp := get("handle=banana&process=another-process")
+			resp.Body.Close()
+			Expect(resp.StatusCode).To(Equal(http.StatusNotFound))
+
+			exit <- 0
+		})
+
+		It("requires the handle and process", func() {
+			resp := get("handle=banana")
+			resp.Body.Close()
+			Expect(resp.StatusCode).To(Equal(http.StatusBadRequest))
+
+			exit <- 0
+		})
+	})
+})
@@ -0,0 +1,65 @@
+package gardener
+
+// Stages of creating and destroying a container at which it can fail
+const (
+	StageSpec       = "spec"
+	StageNetwork    = "network"
+	StageImage      = "image"
+	StageVolumes    = "volumes"
+	StageContainer  = "container"
+	StageBundle     = "bundle"
+	StageRuntime    = "runtime"
+	StageEgress     = "egress"
+	StageProperties = "properties"
+)
+
+// Classes of failure, so that e.g. registry outages can be told apart from
+// iptables problems
+const (
+	FailureInvalidSpec   = "invalid-spec"
+	FailureImagePull     = "image-pull"
+	FailureBundle        = "bundle"
+	FailureRuncStart     = "runc-start"
+	FailureRunc          = "runc"
+	FailureRuncTimeout   = "runc-timeout"
+	FailureNetwork       = "network"
+	FailurePluginTimeout = "plugin-timeout"
+	FailureStageTimeout  = "stage-timeout"
+	FailureOther         = "other"
+)
+
+// ClassifiedError is an error whose failure class is known where it happens,
+// e.g. in the containerizer, rather than from the stage which returned it.
+// It reads exactly like the error it wraps.
+type ClassifiedError struct {
+	Class string
+	Err   error
+}
+
+func (e ClassifiedError) Error() string {
+	return e.Err.Error()
+}
+
+// Classify records the class of err, unless it is nil or has already been
+// classified
+func Classify(class string, err error) error {
+	if err == nil {
+		return nil
+	}
+
+	if _, ok := err.(ClassifiedError); ok {
+		return err
+	}
+
+	return ClassifiedError{Class: class, Err: err}
+}
+
+// FailureClass returns the class of err, or fallback if it has not been
+// classified
+func FailureClass(err error, fallback string) string {
+	if classified, ok := err.(ClassifiedError); ok {
+		return classified.Class
+	}
+
+	return fallback
+}
@@ -0,0 +1,56 @@
+// This file was generated by counterfeiter
+package fakes
+
+import (
+	"sync"
+	"time"
+
+	"github.com/cloudfoundry-incubator/guardian/gardener"
+)
+
+type FakeAgeReader struct {
+	AgeStub        func(handle string) (time.Duration, bool)
+	ageMutex       sync.RWMutex
+	ageArgsForCall []struct {
+		handle string
+	}
+	ageReturns struct {
+		result1 time.Duration
+		result2 bool
+	}
+}
+
+func (fake *FakeAgeReader) Age(handle string) (time.Duration, bool) {
+	fake.ageMutex.Lock()
+	fake.ageArgsForCall = append(fake.ageArgsForCall, struct {
+		handle string
+	}{handle})
+	fake.ageMutex.Unlock()
+	if fake.AgeStub != nil {
+		return fake.AgeStub(handle)
+	} else {
+		return fake.ageReturns.result1, fake.ageReturns.result2
+	}
+}
+
+func (fake *FakeAgeReader) AgeCallCount() int {
+	fake.ageMutex.RLock()
+	defer fake.ageMutex.RUnlock()
+	return len(fake.ageArgsForCall)
+}
+
+func (fake *FakeAgeReader) AgeArgsForCall(i int) string {
+	fake.ageMutex.RLock()
+	defer fake.ageMutex.RUnlock()
+	return fake.ageArgsForCall[i].handle
+}
+
+func (fake *FakeAgeReader) AgeReturns(result1 time.Duration, result2 bool) {
+	fake.AgeStub = nil
+	fake.ageReturns = struct {
+		result1 time.Duration
+		result2 bool
+	}{result1, result2}
+}
+
+var _ gardener.AgeReader = new(FakeAgeReader)
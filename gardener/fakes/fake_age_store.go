@@ -0,0 +1,101 @@
+// This file was generated by counterfeiter
+package fakes
+
+import (
+	"sync"
+
+	"github.com/cloudfoundry-incubator/guardian/gardener"
+)
+
+type FakeAgeStore struct {
+	GetStub        func(handle string, name string) (string, error)
+	getMutex       sync.RWMutex
+	getArgsForCall []struct {
+		handle string
+		name   string
+	}
+	getReturns struct {
+		result1 string
+		result2 error
+	}
+	SetIfExistsStub        func(handle string, name string, value string) bool
+	setIfExistsMutex       sync.RWMutex
+	setIfExistsArgsForCall []struct {
+		handle string
+		name   string
+		value  string
+	}
+	setIfExistsReturns struct {
+		result1 bool
+	}
+}
+
+func (fake *FakeAgeStore) Get(handle string, name string) (string, error) {
+	fake.getMutex.Lock()
+	fake.getArgsForCall = append(fake.getArgsForCall, struct {
+		handle string
+		name   string
+	}{handle, name})
+	fake.getMutex.Unlock()
+	if fake.GetStub != nil {
+		return fake.GetStub(handle, name)
+	} else {
+		return fake.getReturns.result1, fake.getReturns.result2
+	}
+}
+
+func (fake *FakeAgeStore) GetCallCount() int {
+	fake.getMutex.RLock()
+	defer fake.getMutex.RUnlock()
+	return len(fake.getArgsForCall)
+}
+
+func (fake *FakeAgeStore) GetArgsForCall(i int) (string, string) {
+	fake.getMutex.RLock()
+	defer fake.getMutex.RUnlock()
+	return fake.getArgsForCall[i].handle, fake.getArgsForCall[i].name
+}
+
+func (fake *FakeAgeStore) GetReturns(result1 string, result2 error) {
+	fake.GetStub = nil
+	fake.getReturns = struct {
+		result1 string
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeAgeStore) SetIfExists(handle string, name string, value string) bool {
+	fake.setIfExistsMutex.Lock()
+	fake.setIfExistsArgsForCall = append(fake.setIfExistsArgsForCall, struct {
+		handle string
+		name   string
+		value  string
+	}{handle, name, value})
+	fake.setIfExistsMutex.Unlock()
+	if fake.SetIfExistsStub != nil {
+		return fake.SetIfExistsStub(handle, name, value)
+	} else {
+		return fake.setIfExistsReturns.result1
+	}
+}
+
+func (fake *FakeAgeStore) SetIfExistsCallCount() int {
+	fake.setIfExistsMutex.RLock()
+	defer fake.setIfExistsMutex.RUnlock()
+	return len(fake.setIfExistsArgsForCall)
+}
+
+func (fake *FakeAgeStore) SetIfExistsArgsForCall(i int) (string, string, string) {
+	fake.setIfExistsMutex.RLock()
+	defer fake.setIfExistsMutex.RUnlock()
+	return fake.setIfExistsArgsForCall[i].handle, fake.setIfExistsArgsForCall[i].name, fake.setIfExistsArgsForCall[i].value
+}
+
+func (fake *FakeAgeStore) SetIfExistsReturns(result1 bool) {
+	fake.SetIfExistsStub = nil
+	fake.setIfExistsReturns = struct {
+		result1 bool
+	}{result1}
+}
+
+var _ gardener.AgeStore = new(FakeAgeStore)
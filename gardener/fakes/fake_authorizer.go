@@ -0,0 +1,56 @@
+// This file was generated by counterfeiter
+package fakes
+
+import (
+	"sync"
+
+	"github.com/cloudfoundry-incubator/guardian/gardener"
+	"github.com/pivotal-golang/lager"
+)
+
+type FakeAuthorizer struct {
+	AuthorizeStub        func(log lager.Logger, request gardener.AuthRequest) error
+	authorizeMutex       sync.RWMutex
+	authorizeArgsForCall []struct {
+		log     lager.Logger
+		request gardener.AuthRequest
+	}
+	authorizeReturns struct {
+		result1 error
+	}
+}
+
+func (fake *FakeAuthorizer) Authorize(log lager.Logger, request gardener.AuthRequest) error {
+	fake.authorizeMutex.Lock()
+	fake.authorizeArgsForCall = append(fake.authorizeArgsForCall, struct {
+		log     lager.Logger
+		request gardener.AuthRequest
+	}{log, request})
+	fake.authorizeMutex.Unlock()
+	if fake.AuthorizeStub != nil {
+		return fake.AuthorizeStub(log, request)
+	} else {
+		return fake.authorizeReturns.result1
+	}
+}
+
+func (fake *FakeAuthorizer) AuthorizeCallCount() int {
+	fake.authorizeMutex.RLock()
+	defer fake.authorizeMutex.RUnlock()
+	return len(fake.authorizeArgsForCall)
+}
+
+func (fake *FakeAuthorizer) AuthorizeArgsForCall(i int) (lager.Logger, gardener.AuthRequest) {
+	fake.authorizeMutex.RLock()
+	defer fake.authorizeMutex.RUnlock()
+	return fake.authorizeArgsForCall[i].log, fake.authorizeArgsForCall[i].request
+}
+
+func (fake *FakeAuthorizer) AuthorizeReturns(result1 error) {
+	fake.AuthorizeStub = nil
+	fake.authorizeReturns = struct {
+		result1 error
+	}{result1}
+}
+
+var _ gardener.Authorizer = new(FakeAuthorizer)
@@ -0,0 +1,103 @@
+// This file was generated by counterfeiter
+package fakes
+
+import (
+	"sync"
+
+	"github.com/cloudfoundry-incubator/garden"
+	"github.com/cloudfoundry-incubator/guardian/gardener"
+	"github.com/pivotal-golang/lager"
+)
+
+type FakeBandwidthNetworker struct {
+	LimitBandwidthStub        func(log lager.Logger, handle string, limits garden.BandwidthLimits) error
+	limitBandwidthMutex       sync.RWMutex
+	limitBandwidthArgsForCall []struct {
+		log    lager.Logger
+		handle string
+		limits garden.BandwidthLimits
+	}
+	limitBandwidthReturns struct {
+		result1 error
+	}
+	CurrentBandwidthLimitsStub        func(log lager.Logger, handle string) (garden.BandwidthLimits, error)
+	currentBandwidthLimitsMutex       sync.RWMutex
+	currentBandwidthLimitsArgsForCall []struct {
+		log    lager.Logger
+		handle string
+	}
+	currentBandwidthLimitsReturns struct {
+		result1 garden.BandwidthLimits
+		result2 error
+	}
+}
+
+func (fake *FakeBandwidthNetworker) LimitBandwidth(log lager.Logger, handle string, limits garden.BandwidthLimits) error {
+	fake.limitBandwidthMutex.Lock()
+	fake.limitBandwidthArgsForCall = append(fake.limitBandwidthArgsForCall, struct {
+		log    lager.Logger
+		handle string
+		limits garden.BandwidthLimits
+	}{log, handle, limits})
+	fake.limitBandwidthMutex.Unlock()
+	if fake.LimitBandwidthStub != nil {
+		return fake.LimitBandwidthStub(log, handle, limits)
+	} else {
+		return fake.limitBandwidthReturns.result1
+	}
+}
+
+func (fake *FakeBandwidthNetworker) LimitBandwidthCallCount() int {
+	fake.limitBandwidthMutex.RLock()
+	defer fake.limitBandwidthMutex.RUnlock()
+	return len(fake.limitBandwidthArgsForCall)
+}
+
+func (fake *FakeBandwidthNetworker) LimitBandwidthArgsForCall(i int) (lager.Logger, string, garden.BandwidthLimits) {
+	fake.limitBandwidthMutex.RLock()
+	defer fake.limitBandwidthMutex.RUnlock()
+	return fake.limitBandwidthArgsForCall[i].log, fake.limitBandwidthArgsForCall[i].handle, fake.limitBandwidthArgsForCall[i].limits
+}
+
+func (fake *FakeBandwidthNetworker) LimitBandwidthReturns(result1 error) {
+	fake.LimitBandwidthStub = nil
+	fake.limitBandwidthReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeBandwidthNetworker) CurrentBandwidthLimits(log lager.Logger, handle string) (garden.BandwidthLimits, error) {
+	fake.currentBandwidthLimitsMutex.Lock()
+	fake.currentBandwidthLimitsArgsForCall = append(fake.currentBandwidthLimitsArgsForCall, struct {
+		log    lager.Logger
+		handle string
+	}{log, handle})
+	fake.currentBandwidthLimitsMutex.Unlock()
+	if fake.CurrentBandwidthLimitsStub != nil {
+		return fake.CurrentBandwidthLimitsStub(log, handle)
+	} else {
+		return fake.currentBandwidthLimitsReturns.result1, fake.currentBandwidthLimitsReturns.result2
+	}
+}
+
+func (fake *FakeBandwidthNetworker) CurrentBandwidthLimitsCallCount() int {
+	fake.currentBandwidthLimitsMutex.RLock()
+	defer fake.currentBandwidthLimitsMutex.RUnlock()
+	return len(fake.currentBandwidthLimitsArgsForCall)
+}
+
+func (fake *FakeBandwidthNetworker) CurrentBandwidthLimitsArgsForCall(i int) (lager.Logger, string) {
+	fake.currentBandwidthLimitsMutex.RLock()
+	defer fake.currentBandwidthLimitsMutex.RUnlock()
+	return fake.currentBandwidthLimitsArgsForCall[i].log, fake.currentBandwidthLimitsArgsForCall[i].handle
+}
+
+func (fake *FakeBandwidthNetworker) CurrentBandwidthLimitsReturns(result1 garden.BandwidthLimits, result2 error) {
+	fake.CurrentBandwidthLimitsStub = nil
+	fake.currentBandwidthLimitsReturns = struct {
+		result1 garden.BandwidthLimits
+		result2 error
+	}{result1, result2}
+}
+
+var _ gardener.BandwidthNetworker = new(FakeBandwidthNetworker)
@@ -0,0 +1,98 @@
+// This file was generated by counterfeiter
+package fakes
+
+import (
+	"sync"
+
+	"github.com/cloudfoundry-incubator/garden"
+	"github.com/cloudfoundry-incubator/guardian/gardener"
+)
+
+type FakeContainerBindMounter struct {
+	AddBindMountStub        func(handle string, mount garden.BindMount) error
+	addBindMountMutex       sync.RWMutex
+	addBindMountArgsForCall []struct {
+		handle string
+		mount  garden.BindMount
+	}
+	addBindMountReturns struct {
+		result1 error
+	}
+	RemoveBindMountStub        func(handle, dstPath string) error
+	removeBindMountMutex       sync.RWMutex
+	removeBindMountArgsForCall []struct {
+		handle  string
+		dstPath string
+	}
+	removeBindMountReturns struct {
+		result1 error
+	}
+}
+
+func (fake *FakeContainerBindMounter) AddBindMount(handle string, mount garden.BindMount) error {
+	fake.addBindMountMutex.Lock()
+	fake.addBindMountArgsForCall = append(fake.addBindMountArgsForCall, struct {
+		handle string
+		mount  garden.BindMount
+	}{handle, mount})
+	fake.addBindMountMutex.Unlock()
+	if fake.AddBindMountStub != nil {
+		return fake.AddBindMountStub(handle, mount)
+	} else {
+		return fake.addBindMountReturns.result1
+	}
+}
+
+func (fake *FakeContainerBindMounter) AddBindMountCallCount() int {
+	fake.addBindMountMutex.RLock()
+	defer fake.addBindMountMutex.RUnlock()
+	return len(fake.addBindMountArgsForCall)
+}
+
+func (fake *FakeContainerBindMounter) AddBindMountArgsForCall(i int) (string, garden.BindMount) {
+	fake.addBindMountMutex.RLock()
+	defer fake.addBindMountMutex.RUnlock()
+	return fake.addBindMountArgsForCall[i].handle, fake.addBindMountArgsForCall[i].mount
+}
+
+func (fake *FakeContainerBindMounter) AddBindMountReturns(result1 error) {
+	fake.AddBindMountStub = nil
+	fake.addBindMountReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeContainerBindMounter) RemoveBindMount(handle string, dstPath string) error {
+	fake.removeBindMountMutex.Lock()
+	fake.removeBindMountArgsForCall = append(fake.removeBindMountArgsForCall, struct {
+		handle  string
+		dstPath string
+	}{handle, dstPath})
+	fake.removeBindMountMutex.Unlock()
+	if fake.RemoveBindMountStub != nil {
+		return fake.RemoveBindMountStub(handle, dstPath)
+	} else {
+		return fake.removeBindMountReturns.result1
+	}
+}
+
+func (fake *FakeContainerBindMounter) RemoveBindMountCallCount() int {
+	fake.removeBindMountMutex.RLock()
+	defer fake.removeBindMountMutex.RUnlock()
+	return len(fake.removeBindMountArgsForCall)
+}
+
+func (fake *FakeContainerBindMounter) RemoveBindMountArgsForCall(i int) (string, string) {
+	fake.removeBindMountMutex.RLock()
+	defer fake.removeBindMountMutex.RUnlock()
+	return fake.removeBindMountArgsForCall[i].handle, fake.removeBindMountArgsForCall[i].dstPath
+}
+
+func (fake *FakeContainerBindMounter) RemoveBindMountReturns(result1 error) {
+	fake.RemoveBindMountStub = nil
+	fake.removeBindMountReturns = struct {
+		result1 error
+	}{result1}
+}
+
+var _ gardener.ContainerBindMounter = new(FakeContainerBindMounter)
@@ -0,0 +1,97 @@
+// This file was generated by counterfeiter
+package fakes
+
+import (
+	"sync"
+
+	"github.com/cloudfoundry-incubator/guardian/gardener"
+)
+
+type FakeContainerCheckpointer struct {
+	CheckpointStub        func(handle, destination string) error
+	checkpointMutex       sync.RWMutex
+	checkpointArgsForCall []struct {
+		handle      string
+		destination string
+	}
+	checkpointReturns struct {
+		result1 error
+	}
+	RestoreStub        func(handle, source string) error
+	restoreMutex       sync.RWMutex
+	restoreArgsForCall []struct {
+		handle string
+		source string
+	}
+	restoreReturns struct {
+		result1 error
+	}
+}
+
+func (fake *FakeContainerCheckpointer) Checkpoint(handle string, destination string) error {
+	fake.checkpointMutex.Lock()
+	fake.checkpointArgsForCall = append(fake.checkpointArgsForCall, struct {
+		handle      string
+		destination string
+	}{handle, destination})
+	fake.checkpointMutex.Unlock()
+	if fake.CheckpointStub != nil {
+		return fake.CheckpointStub(handle, destination)
+	} else {
+		return fake.checkpointReturns.result1
+	}
+}
+
+func (fake *FakeContainerCheckpointer) CheckpointCallCount() int {
+	fake.checkpointMutex.RLock()
+	defer fake.checkpointMutex.RUnlock()
+	return len(fake.checkpointArgsForCall)
+}
+
+func (fake *FakeContainerCheckpointer) CheckpointArgsForCall(i int) (string, string) {
+	fake.checkpointMutex.RLock()
+	defer fake.checkpointMutex.RUnlock()
+	return fake.checkpointArgsForCall[i].handle, fake.checkpointArgsForCall[i].destination
+}
+
+func (fake *FakeContainerCheckpointer) CheckpointReturns(result1 error) {
+	fake.CheckpointStub = nil
+	fake.checkpointReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeContainerCheckpointer) Restore(handle string, source string) error {
+	fake.restoreMutex.Lock()
+	fake.restoreArgsForCall = append(fake.restoreArgsForCall, struct {
+		handle string
+		source string
+	}{handle, source})
+	fake.restoreMutex.Unlock()
+	if fake.RestoreStub != nil {
+		return fake.RestoreStub(handle, source)
+	} else {
+		return fake.restoreReturns.result1
+	}
+}
+
+func (fake *FakeContainerCheckpointer) RestoreCallCount() int {
+	fake.restoreMutex.RLock()
+	defer fake.restoreMutex.RUnlock()
+	return len(fake.restoreArgsForCall)
+}
+
+func (fake *FakeContainerCheckpointer) RestoreArgsForCall(i int) (string, string) {
+	fake.restoreMutex.RLock()
+	defer fake.restoreMutex.RUnlock()
+	return fake.restoreArgsForCall[i].handle, fake.restoreArgsForCall[i].source
+}
+
+func (fake *FakeContainerCheckpointer) RestoreReturns(result1 error) {
+	fake.RestoreStub = nil
+	fake.restoreReturns = struct {
+		result1 error
+	}{result1}
+}
+
+var _ gardener.ContainerCheckpointer = new(FakeContainerCheckpointer)
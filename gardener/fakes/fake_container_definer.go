@@ -0,0 +1,100 @@
+// This file was generated by counterfeiter
+package fakes
+
+import (
+	"sync"
+
+	"github.com/cloudfoundry-incubator/garden"
+	"github.com/cloudfoundry-incubator/guardian/gardener"
+)
+
+type FakeContainerDefiner struct {
+	ExportStub        func(handle string) (gardener.ContainerDefinition, error)
+	exportMutex       sync.RWMutex
+	exportArgsForCall []struct {
+		handle string
+	}
+	exportReturns struct {
+		result1 gardener.ContainerDefinition
+		result2 error
+	}
+	ImportStub        func(definition gardener.ContainerDefinition, handle string) (garden.Container, error)
+	importMutex       sync.RWMutex
+	importArgsForCall []struct {
+		definition gardener.ContainerDefinition
+		handle     string
+	}
+	importReturns struct {
+		result1 garden.Container
+		result2 error
+	}
+}
+
+func (fake *FakeContainerDefiner) Export(handle string) (gardener.ContainerDefinition, error) {
+	fake.exportMutex.Lock()
+	fake.exportArgsForCall = append(fake.exportArgsForCall, struct {
+		handle string
+	}{handle})
+	fake.exportMutex.Unlock()
+	if fake.ExportStub != nil {
+		return fake.ExportStub(handle)
+	} else {
+		return fake.exportReturns.result1, fake.exportReturns.result2
+	}
+}
+
+func (fake *FakeContainerDefiner) ExportCallCount() int {
+	fake.exportMutex.RLock()
+	defer fake.exportMutex.RUnlock()
+	return len(fake.exportArgsForCall)
+}
+
+func (fake *FakeContainerDefiner) ExportArgsForCall(i int) string {
+	fake.exportMutex.RLock()
+	defer fake.exportMutex.RUnlock()
+	return fake.exportArgsForCall[i].handle
+}
+
+func (fake *FakeContainerDefiner) ExportReturns(result1 gardener.ContainerDefinition, result2 error) {
+	fake.ExportStub = nil
+	fake.exportReturns = struct {
+		result1 gardener.ContainerDefinition
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeContainerDefiner) Import(definition gardener.ContainerDefinition, handle string) (garden.Container, error) {
+	fake.importMutex.Lock()
+	fake.importArgsForCall = append(fake.importArgsForCall, struct {
+		definition gardener.ContainerDefinition
+		handle     string
+	}{definition, handle})
+	fake.importMutex.Unlock()
+	if fake.ImportStub != nil {
+		return fake.ImportStub(definition, handle)
+	} else {
+		return fake.importReturns.result1, fake.importReturns.result2
+	}
+}
+
+func (fake *FakeContainerDefiner) ImportCallCount() int {
+	fake.importMutex.RLock()
+	defer fake.importMutex.RUnlock()
+	return len(fake.importArgsForCall)
+}
+
+func (fake *FakeContainerDefiner) ImportArgsForCall(i int) (gardener.ContainerDefinition, string) {
+	fake.importMutex.RLock()
+	defer fake.importMutex.RUnlock()
+	return fake.importArgsForCall[i].definition, fake.importArgsForCall[i].handle
+}
+
+func (fake *FakeContainerDefiner) ImportReturns(result1 garden.Container, result2 error) {
+	fake.ImportStub = nil
+	fake.importReturns = struct {
+		result1 garden.Container
+		result2 error
+	}{result1, result2}
+}
+
+var _ gardener.ContainerDefiner = new(FakeContainerDefiner)
@@ -0,0 +1,53 @@
+// This file was generated by counterfeiter
+package fakes
+
+import (
+	"sync"
+
+	"github.com/cloudfoundry-incubator/guardian/gardener"
+)
+
+type FakeContainerDestroyer struct {
+	DestroyStub        func(handle string) error
+	destroyMutex       sync.RWMutex
+	destroyArgsForCall []struct {
+		handle string
+	}
+	destroyReturns struct {
+		result1 error
+	}
+}
+
+func (fake *FakeContainerDestroyer) Destroy(handle string) error {
+	fake.destroyMutex.Lock()
+	fake.destroyArgsForCall = append(fake.destroyArgsForCall, struct {
+		handle string
+	}{handle})
+	fake.destroyMutex.Unlock()
+	if fake.DestroyStub != nil {
+		return fake.DestroyStub(handle)
+	} else {
+		return fake.destroyReturns.result1
+	}
+}
+
+func (fake *FakeContainerDestroyer) DestroyCallCount() int {
+	fake.destroyMutex.RLock()
+	defer fake.destroyMutex.RUnlock()
+	return len(fake.destroyArgsForCall)
+}
+
+func (fake *FakeContainerDestroyer) DestroyArgsForCall(i int) string {
+	fake.destroyMutex.RLock()
+	defer fake.destroyMutex.RUnlock()
+	return fake.destroyArgsForCall[i].handle
+}
+
+func (fake *FakeContainerDestroyer) DestroyReturns(result1 error) {
+	fake.DestroyStub = nil
+	fake.destroyReturns = struct {
+		result1 error
+	}{result1}
+}
+
+var _ gardener.ContainerDestroyer = new(FakeContainerDestroyer)
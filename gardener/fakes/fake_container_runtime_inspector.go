@@ -0,0 +1,55 @@
+// This file was generated by counterfeiter
+package fakes
+
+import (
+	"sync"
+
+	"github.com/cloudfoundry-incubator/guardian/gardener"
+)
+
+type FakeContainerRuntimeInspector struct {
+	InspectRuntimeStub        func(handle string) (gardener.RuntimeDetails, error)
+	inspectRuntimeMutex       sync.RWMutex
+	inspectRuntimeArgsForCall []struct {
+		handle string
+	}
+	inspectRuntimeReturns struct {
+		result1 gardener.RuntimeDetails
+		result2 error
+	}
+}
+
+func (fake *FakeContainerRuntimeInspector) InspectRuntime(handle string) (gardener.RuntimeDetails, error) {
+	fake.inspectRuntimeMutex.Lock()
+	fake.inspectRuntimeArgsForCall = append(fake.inspectRuntimeArgsForCall, struct {
+		handle string
+	}{handle})
+	fake.inspectRuntimeMutex.Unlock()
+	if fake.InspectRuntimeStub != nil {
+		return fake.InspectRuntimeStub(handle)
+	} else {
+		return fake.inspectRuntimeReturns.result1, fake.inspectRuntimeReturns.result2
+	}
+}
+
+func (fake *FakeContainerRuntimeInspector) InspectRuntimeCallCount() int {
+	fake.inspectRuntimeMutex.RLock()
+	defer fake.inspectRuntimeMutex.RUnlock()
+	return len(fake.inspectRuntimeArgsForCall)
+}
+
+func (fake *FakeContainerRuntimeInspector) InspectRuntimeArgsForCall(i int) string {
+	fake.inspectRuntimeMutex.RLock()
+	defer fake.inspectRuntimeMutex.RUnlock()
+	return fake.inspectRuntimeArgsForCall[i].handle
+}
+
+func (fake *FakeContainerRuntimeInspector) InspectRuntimeReturns(result1 gardener.RuntimeDetails, result2 error) {
+	fake.InspectRuntimeStub = nil
+	fake.inspectRuntimeReturns = struct {
+		result1 gardener.RuntimeDetails
+		result2 error
+	}{result1, result2}
+}
+
+var _ gardener.ContainerRuntimeInspector = new(FakeContainerRuntimeInspector)
@@ -0,0 +1,56 @@
+// This file was generated by counterfeiter
+package fakes
+
+import (
+	"sync"
+
+	"github.com/cloudfoundry-incubator/guardian/gardener"
+	"github.com/pivotal-golang/lager"
+)
+
+type FakeContainerValidator struct {
+	ValidateStub        func(log lager.Logger, spec gardener.DesiredContainerSpec) error
+	validateMutex       sync.RWMutex
+	validateArgsForCall []struct {
+		log  lager.Logger
+		spec gardener.DesiredContainerSpec
+	}
+	validateReturns struct {
+		result1 error
+	}
+}
+
+func (fake *FakeContainerValidator) Validate(log lager.Logger, spec gardener.DesiredContainerSpec) error {
+	fake.validateMutex.Lock()
+	fake.validateArgsForCall = append(fake.validateArgsForCall, struct {
+		log  lager.Logger
+		spec gardener.DesiredContainerSpec
+	}{log, spec})
+	fake.validateMutex.Unlock()
+	if fake.ValidateStub != nil {
+		return fake.ValidateStub(log, spec)
+	} else {
+		return fake.validateReturns.result1
+	}
+}
+
+func (fake *FakeContainerValidator) ValidateCallCount() int {
+	fake.validateMutex.RLock()
+	defer fake.validateMutex.RUnlock()
+	return len(fake.validateArgsForCall)
+}
+
+func (fake *FakeContainerValidator) ValidateArgsForCall(i int) (lager.Logger, gardener.DesiredContainerSpec) {
+	fake.validateMutex.RLock()
+	defer fake.validateMutex.RUnlock()
+	return fake.validateArgsForCall[i].log, fake.validateArgsForCall[i].spec
+}
+
+func (fake *FakeContainerValidator) ValidateReturns(result1 error) {
+	fake.ValidateStub = nil
+	fake.validateReturns = struct {
+		result1 error
+	}{result1}
+}
+
+var _ gardener.ContainerValidator = new(FakeContainerValidator)
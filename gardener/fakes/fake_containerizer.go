@@ -53,6 +53,18 @@ type FakeContainerizer struct {
 		result1 garden.Process
 		result2 error
 	}
+	AttachStub        func(log lager.Logger, handle, processID string, io garden.ProcessIO) (garden.Process, error)
+	attachMutex       sync.RWMutex
+	attachArgsForCall []struct {
+		log       lager.Logger
+		handle    string
+		processID string
+		io        garden.ProcessIO
+	}
+	attachReturns struct {
+		result1 garden.Process
+		result2 error
+	}
 	DestroyStub        func(log lager.Logger, handle string) error
 	destroyMutex       sync.RWMutex
 	destroyArgsForCall []struct {
@@ -79,6 +91,46 @@ type FakeContainerizer struct {
 		result1 []string
 		result2 error
 	}
+	CheckpointStub        func(log lager.Logger, handle, destination string) error
+	checkpointMutex       sync.RWMutex
+	checkpointArgsForCall []struct {
+		log         lager.Logger
+		handle      string
+		destination string
+	}
+	checkpointReturns struct {
+		result1 error
+	}
+	RestoreStub        func(log lager.Logger, handle, source string) error
+	restoreMutex       sync.RWMutex
+	restoreArgsForCall []struct {
+		log    lager.Logger
+		handle string
+		source string
+	}
+	restoreReturns struct {
+		result1 error
+	}
+	AddBindMountStub        func(log lager.Logger, handle string, mount garden.BindMount) error
+	addBindMountMutex       sync.RWMutex
+	addBindMountArgsForCall []struct {
+		log    lager.Logger
+		handle string
+		mount  garden.BindMount
+	}
+	addBindMountReturns struct {
+		result1 error
+	}
+	RemoveBindMountStub        func(log lager.Logger, handle, dstPath string) error
+	removeBindMountMutex       sync.RWMutex
+	removeBindMountArgsForCall []struct {
+		log     lager.Logger
+		handle  string
+		dstPath string
+	}
+	removeBindMountReturns struct {
+		result1 error
+	}
 }
 
 func (fake *FakeContainerizer) Create(log lager.Logger, spec gardener.DesiredContainerSpec) error {
@@ -219,6 +271,42 @@ func (fake *FakeContainerizer) RunReturns(result1 garden.Process, result2 error)
 	}{result1, result2}
 }
 
+func (fake *FakeContainerizer) Attach(log lager.Logger, handle string, processID string, io garden.ProcessIO) (garden.Process, error) {
+	fake.attachMutex.Lock()
+	fake.attachArgsForCall = append(fake.attachArgsForCall, struct {
+		log       lager.Logger
+		handle    string
+		processID string
+		io        garden.ProcessIO
+	}{log, handle, processID, io})
+	fake.attachMutex.Unlock()
+	if fake.AttachStub != nil {
+		return fake.AttachStub(log, handle, processID, io)
+	} else {
+		return fake.attachReturns.result1, fake.attachReturns.result2
+	}
+}
+
+func (fake *FakeContainerizer) AttachCallCount() int {
+	fake.attachMutex.RLock()
+	defer fake.attachMutex.RUnlock()
+	return len(fake.attachArgsForCall)
+}
+
+func (fake *FakeContainerizer) AttachArgsForCall(i int) (lager.Logger, string, string, garden.ProcessIO) {
+	fake.attachMutex.RLock()
+	defer fake.attachMutex.RUnlock()
+	return fake.attachArgsForCall[i].log, fake.attachArgsForCall[i].handle, fake.attachArgsForCall[i].processID, fake.attachArgsForCall[i].io
+}
+
+func (fake *FakeContainerizer) AttachReturns(result1 garden.Process, result2 error) {
+	fake.AttachStub = nil
+	fake.attachReturns = struct {
+		result1 garden.Process
+		result2 error
+	}{result1, result2}
+}
+
 func (fake *FakeContainerizer) Destroy(log lager.Logger, handle string) error {
 	fake.destroyMutex.Lock()
 	fake.destroyArgsForCall = append(fake.destroyArgsForCall, struct {
@@ -311,4 +399,140 @@ func (fake *FakeContainerizer) HandlesReturns(result1 []string, result2 error) {
 	}{result1, result2}
 }
 
+func (fake *FakeContainerizer) Checkpoint(log lager.Logger, handle string, destination string) error {
+	fake.checkpointMutex.Lock()
+	fake.checkpointArgsForCall = append(fake.checkpointArgsForCall, struct {
+		log         lager.Logger
+		handle      string
+		destination string
+	}{log, handle, destination})
+	fake.checkpointMutex.Unlock()
+	if fake.CheckpointStub != nil {
+		return fake.CheckpointStub(log, handle, destination)
+	} else {
+		return fake.checkpointReturns.result1
+	}
+}
+
+func (fake *FakeContainerizer) CheckpointCallCount() int {
+	fake.checkpointMutex.RLock()
+	defer fake.checkpointMutex.RUnlock()
+	return len(fake.checkpointArgsForCall)
+}
+
+func (fake *FakeContainerizer) CheckpointArgsForCall(i int) (lager.Logger, string, string) {
+	fake.checkpointMutex.RLock()
+	defer fake.checkpointMutex.RUnlock()
+	return fake.checkpointArgsForCall[i].log, fake.checkpointArgsForCall[i].handle, fake.checkpointArgsForCall[i].destination
+}
+
+func (fake *FakeContainerizer) CheckpointReturns(result1 error) {
+	fake.CheckpointStub = nil
+	fake.checkpointReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeContainerizer) Restore(log lager.Logger, handle string, source string) error {
+	fake.restoreMutex.Lock()
+	fake.restoreArgsForCall = append(fake.restoreArgsForCall, struct {
+		log    lager.Logger
+		handle string
+		source string
+	}{log, handle, source})
+	fake.restoreMutex.Unlock()
+	if fake.RestoreStub != nil {
+		return fake.RestoreStub(log, handle, source)
+	} else {
+		return fake.restoreReturns.result1
+	}
+}
+
+func (fake *FakeContainerizer) RestoreCallCount() int {
+	fake.restoreMutex.RLock()
+	defer fake.restoreMutex.RUnlock()
+	return len(fake.restoreArgsForCall)
+}
+
+func (fake *FakeContainerizer) RestoreArgsForCall(i int) (lager.Logger, string, string) {
+	fake.restoreMutex.RLock()
+	defer fake.restoreMutex.RUnlock()
+	return fake.restoreArgsForCall[i].log, fake.restoreArgsForCall[i].handle, fake.restoreArgsForCall[i].source
+}
+
+func (fake *FakeContainerizer) RestoreReturns(result1 error) {
+	fake.RestoreStub = nil
+	fake.restoreReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeContainerizer) AddBindMount(log lager.Logger, handle string, mount garden.BindMount) error {
+	fake.addBindMountMutex.Lock()
+	fake.addBindMountArgsForCall = append(fake.addBindMountArgsForCall, struct {
+		log    lager.Logger
+		handle string
+		mount  garden.BindMount
+	}{log, handle, mount})
+	fake.addBindMountMutex.Unlock()
+	if fake.AddBindMountStub != nil {
+		return fake.AddBindMountStub(log, handle, mount)
+	} else {
+		return fake.addBindMountReturns.result1
+	}
+}
+
+func (fake *FakeContainerizer) AddBindMountCallCount() int {
+	fake.addBindMountMutex.RLock()
+	defer fake.addBindMountMutex.RUnlock()
+	return len(fake.addBindMountArgsForCall)
+}
+
+func (fake *FakeContainerizer) AddBindMountArgsForCall(i int) (lager.Logger, string, garden.BindMount) {
+	fake.addBindMountMutex.RLock()
+	defer fake.addBindMountMutex.RUnlock()
+	return fake.addBindMountArgsForCall[i].log, fake.addBindMountArgsForCall[i].handle, fake.addBindMountArgsForCall[i].mount
+}
+
+func (fake *FakeContainerizer) AddBindMountReturns(result1 error) {
+	fake.AddBindMountStub = nil
+	fake.addBindMountReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeContainerizer) RemoveBindMount(log lager.Logger, handle string, dstPath string) error {
+	fake.removeBindMountMutex.Lock()
+	fake.removeBindMountArgsForCall = append(fake.removeBindMountArgsForCall, struct {
+		log     lager.Logger
+		handle  string
+		dstPath string
+	}{log, handle, dstPath})
+	fake.removeBindMountMutex.Unlock()
+	if fake.RemoveBindMountStub != nil {
+		return fake.RemoveBindMountStub(log, handle, dstPath)
+	} else {
+		return fake.removeBindMountReturns.result1
+	}
+}
+
+func (fake *FakeContainerizer) RemoveBindMountCallCount() int {
+	fake.removeBindMountMutex.RLock()
+	defer fake.removeBindMountMutex.RUnlock()
+	return len(fake.removeBindMountArgsForCall)
+}
+
+func (fake *FakeContainerizer) RemoveBindMountArgsForCall(i int) (lager.Logger, string, string) {
+	fake.removeBindMountMutex.RLock()
+	defer fake.removeBindMountMutex.RUnlock()
+	return fake.removeBindMountArgsForCall[i].log, fake.removeBindMountArgsForCall[i].handle, fake.removeBindMountArgsForCall[i].dstPath
+}
+
+func (fake *FakeContainerizer) RemoveBindMountReturns(result1 error) {
+	fake.RemoveBindMountStub = nil
+	fake.removeBindMountReturns = struct {
+		result1 error
+	}{result1}
+}
+
 var _ gardener.Containerizer = new(FakeContainerizer)
@@ -0,0 +1,105 @@
+// This file was generated by counterfeiter
+package fakes
+
+import (
+	"sync"
+
+	"github.com/cloudfoundry-incubator/garden"
+	"github.com/cloudfoundry-incubator/guardian/gardener"
+	"github.com/pivotal-golang/lager"
+)
+
+type FakeCPULimiter struct {
+	LimitCPUStub        func(log lager.Logger, handle string, limits garden.CPULimits, quota gardener.CPUQuota) error
+	limitCPUMutex       sync.RWMutex
+	limitCPUArgsForCall []struct {
+		log    lager.Logger
+		handle string
+		limits garden.CPULimits
+		quota  gardener.CPUQuota
+	}
+	limitCPUReturns struct {
+		result1 error
+	}
+	CurrentCPULimitsStub        func(log lager.Logger, handle string) (garden.CPULimits, error)
+	currentCPULimitsMutex       sync.RWMutex
+	currentCPULimitsArgsForCall []struct {
+		log    lager.Logger
+		handle string
+	}
+	currentCPULimitsReturns struct {
+		result1 garden.CPULimits
+		result2 error
+	}
+}
+
+func (fake *FakeCPULimiter) LimitCPU(log lager.Logger, handle string, limits garden.CPULimits, quota gardener.CPUQuota) error {
+	fake.limitCPUMutex.Lock()
+	fake.limitCPUArgsForCall = append(fake.limitCPUArgsForCall, struct {
+		log    lager.Logger
+		handle string
+		limits garden.CPULimits
+		quota  gardener.CPUQuota
+	}{log, handle, limits, quota})
+	fake.limitCPUMutex.Unlock()
+	if fake.LimitCPUStub != nil {
+		return fake.LimitCPUStub(log, handle, limits, quota)
+	} else {
+		return fake.limitCPUReturns.result1
+	}
+}
+
+func (fake *FakeCPULimiter) LimitCPUCallCount() int {
+	fake.limitCPUMutex.RLock()
+	defer fake.limitCPUMutex.RUnlock()
+	return len(fake.limitCPUArgsForCall)
+}
+
+func (fake *FakeCPULimiter) LimitCPUArgsForCall(i int) (lager.Logger, string, garden.CPULimits, gardener.CPUQuota) {
+	fake.limitCPUMutex.RLock()
+	defer fake.limitCPUMutex.RUnlock()
+	return fake.limitCPUArgsForCall[i].log, fake.limitCPUArgsForCall[i].handle, fake.limitCPUArgsForCall[i].limits, fake.limitCPUArgsForCall[i].quota
+}
+
+func (fake *FakeCPULimiter) LimitCPUReturns(result1 error) {
+	fake.LimitCPUStub = nil
+	fake.limitCPUReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeCPULimiter) CurrentCPULimits(log lager.Logger, handle string) (garden.CPULimits, error) {
+	fake.currentCPULimitsMutex.Lock()
+	fake.currentCPULimitsArgsForCall = append(fake.currentCPULimitsArgsForCall, struct {
+		log    lager.Logger
+		handle string
+	}{log, handle})
+	fake.currentCPULimitsMutex.Unlock()
+	if fake.CurrentCPULimitsStub != nil {
+		return fake.CurrentCPULimitsStub(log, handle)
+	} else {
+		return fake.currentCPULimitsReturns.result1, fake.currentCPULimitsReturns.result2
+	}
+}
+
+func (fake *FakeCPULimiter) CurrentCPULimitsCallCount() int {
+	fake.currentCPULimitsMutex.RLock()
+	defer fake.currentCPULimitsMutex.RUnlock()
+	return len(fake.currentCPULimitsArgsForCall)
+}
+
+func (fake *FakeCPULimiter) CurrentCPULimitsArgsForCall(i int) (lager.Logger, string) {
+	fake.currentCPULimitsMutex.RLock()
+	defer fake.currentCPULimitsMutex.RUnlock()
+	return fake.currentCPULimitsArgsForCall[i].log, fake.currentCPULimitsArgsForCall[i].handle
+}
+
+func (fake *FakeCPULimiter) CurrentCPULimitsReturns(result1 garden.CPULimits, result2 error) {
+	fake.CurrentCPULimitsStub = nil
+	fake.currentCPULimitsReturns = struct {
+		result1 garden.CPULimits
+		result2 error
+	}{result1, result2}
+}
+
+var _ gardener.CPULimiter = new(FakeCPULimiter)
@@ -0,0 +1,59 @@
+// This file was generated by counterfeiter
+package fakes
+
+import (
+	"sync"
+	"time"
+
+	"github.com/cloudfoundry-incubator/guardian/gardener"
+	"github.com/pivotal-golang/lager"
+)
+
+type FakeCPUUsageReporter struct {
+	CPUUsageStub        func(log lager.Logger, handle string) (time.Duration, error)
+	cPUUsageMutex       sync.RWMutex
+	cPUUsageArgsForCall []struct {
+		log    lager.Logger
+		handle string
+	}
+	cPUUsageReturns struct {
+		result1 time.Duration
+		result2 error
+	}
+}
+
+func (fake *FakeCPUUsageReporter) CPUUsage(log lager.Logger, handle string) (time.Duration, error) {
+	fake.cPUUsageMutex.Lock()
+	fake.cPUUsageArgsForCall = append(fake.cPUUsageArgsForCall, struct {
+		log    lager.Logger
+		handle string
+	}{log, handle})
+	fake.cPUUsageMutex.Unlock()
+	if fake.CPUUsageStub != nil {
+		return fake.CPUUsageStub(log, handle)
+	} else {
+		return fake.cPUUsageReturns.result1, fake.cPUUsageReturns.result2
+	}
+}
+
+func (fake *FakeCPUUsageReporter) CPUUsageCallCount() int {
+	fake.cPUUsageMutex.RLock()
+	defer fake.cPUUsageMutex.RUnlock()
+	return len(fake.cPUUsageArgsForCall)
+}
+
+func (fake *FakeCPUUsageReporter) CPUUsageArgsForCall(i int) (lager.Logger, string) {
+	fake.cPUUsageMutex.RLock()
+	defer fake.cPUUsageMutex.RUnlock()
+	return fake.cPUUsageArgsForCall[i].log, fake.cPUUsageArgsForCall[i].handle
+}
+
+func (fake *FakeCPUUsageReporter) CPUUsageReturns(result1 time.Duration, result2 error) {
+	fake.CPUUsageStub = nil
+	fake.cPUUsageReturns = struct {
+		result1 time.Duration
+		result2 error
+	}{result1, result2}
+}
+
+var _ gardener.CPUUsageReporter = new(FakeCPUUsageReporter)
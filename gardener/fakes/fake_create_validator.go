@@ -0,0 +1,54 @@
+// This file was generated by counterfeiter
+package fakes
+
+import (
+	"sync"
+
+	"github.com/cloudfoundry-incubator/garden"
+	"github.com/cloudfoundry-incubator/guardian/gardener"
+)
+
+type FakeCreateValidator struct {
+	ValidateCreateStub        func(spec garden.ContainerSpec) error
+	validateCreateMutex       sync.RWMutex
+	validateCreateArgsForCall []struct {
+		spec garden.ContainerSpec
+	}
+	validateCreateReturns struct {
+		result1 error
+	}
+}
+
+func (fake *FakeCreateValidator) ValidateCreate(spec garden.ContainerSpec) error {
+	fake.validateCreateMutex.Lock()
+	fake.validateCreateArgsForCall = append(fake.validateCreateArgsForCall, struct {
+		spec garden.ContainerSpec
+	}{spec})
+	fake.validateCreateMutex.Unlock()
+	if fake.ValidateCreateStub != nil {
+		return fake.ValidateCreateStub(spec)
+	} else {
+		return fake.validateCreateReturns.result1
+	}
+}
+
+func (fake *FakeCreateValidator) ValidateCreateCallCount() int {
+	fake.validateCreateMutex.RLock()
+	defer fake.validateCreateMutex.RUnlock()
+	return len(fake.validateCreateArgsForCall)
+}
+
+func (fake *FakeCreateValidator) ValidateCreateArgsForCall(i int) garden.ContainerSpec {
+	fake.validateCreateMutex.RLock()
+	defer fake.validateCreateMutex.RUnlock()
+	return fake.validateCreateArgsForCall[i].spec
+}
+
+func (fake *FakeCreateValidator) ValidateCreateReturns(result1 error) {
+	fake.ValidateCreateStub = nil
+	fake.validateCreateReturns = struct {
+		result1 error
+	}{result1}
+}
+
+var _ gardener.CreateValidator = new(FakeCreateValidator)
@@ -0,0 +1,87 @@
+// This file was generated by counterfeiter
+package fakes
+
+import (
+	"sync"
+
+	"github.com/cloudfoundry-incubator/guardian/gardener"
+)
+
+type FakeDeltaLister struct {
+	ContainersSnapshotStub        func() (gardener.ContainerDelta, error)
+	containersSnapshotMutex       sync.RWMutex
+	containersSnapshotArgsForCall []struct{}
+	containersSnapshotReturns     struct {
+		result1 gardener.ContainerDelta
+		result2 error
+	}
+	ContainersSinceStub        func(cursor uint64) (gardener.ContainerDelta, error)
+	containersSinceMutex       sync.RWMutex
+	containersSinceArgsForCall []struct {
+		cursor uint64
+	}
+	containersSinceReturns struct {
+		result1 gardener.ContainerDelta
+		result2 error
+	}
+}
+
+func (fake *FakeDeltaLister) ContainersSnapshot() (gardener.ContainerDelta, error) {
+	fake.containersSnapshotMutex.Lock()
+	fake.containersSnapshotArgsForCall = append(fake.containersSnapshotArgsForCall, struct{}{})
+	fake.containersSnapshotMutex.Unlock()
+	if fake.ContainersSnapshotStub != nil {
+		return fake.ContainersSnapshotStub()
+	} else {
+		return fake.containersSnapshotReturns.result1, fake.containersSnapshotReturns.result2
+	}
+}
+
+func (fake *FakeDeltaLister) ContainersSnapshotCallCount() int {
+	fake.containersSnapshotMutex.RLock()
+	defer fake.containersSnapshotMutex.RUnlock()
+	return len(fake.containersSnapshotArgsForCall)
+}
+
+func (fake *FakeDeltaLister) ContainersSnapshotReturns(result1 gardener.ContainerDelta, result2 error) {
+	fake.ContainersSnapshotStub = nil
+	fake.containersSnapshotReturns = struct {
+		result1 gardener.ContainerDelta
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeDeltaLister) ContainersSince(cursor uint64) (gardener.ContainerDelta, error) {
+	fake.containersSinceMutex.Lock()
+	fake.containersSinceArgsForCall = append(fake.containersSinceArgsForCall, struct {
+		cursor uint64
+	}{cursor})
+	fake.containersSinceMutex.Unlock()
+	if fake.ContainersSinceStub != nil {
+		return fake.ContainersSinceStub(cursor)
+	} else {
+		return fake.containersSinceReturns.result1, fake.containersSinceReturns.result2
+	}
+}
+
+func (fake *FakeDeltaLister) ContainersSinceCallCount() int {
+	fake.containersSinceMutex.RLock()
+	defer fake.containersSinceMutex.RUnlock()
+	return len(fake.containersSinceArgsForCall)
+}
+
+func (fake *FakeDeltaLister) ContainersSinceArgsForCall(i int) uint64 {
+	fake.containersSinceMutex.RLock()
+	defer fake.containersSinceMutex.RUnlock()
+	return fake.containersSinceArgsForCall[i].cursor
+}
+
+func (fake *FakeDeltaLister) ContainersSinceReturns(result1 gardener.ContainerDelta, result2 error) {
+	fake.ContainersSinceStub = nil
+	fake.containersSinceReturns = struct {
+		result1 gardener.ContainerDelta
+		result2 error
+	}{result1, result2}
+}
+
+var _ gardener.DeltaLister = new(FakeDeltaLister)
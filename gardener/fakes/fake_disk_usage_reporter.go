@@ -0,0 +1,58 @@
+// This file was generated by counterfeiter
+package fakes
+
+import (
+	"sync"
+
+	"github.com/cloudfoundry-incubator/guardian/gardener"
+	"github.com/pivotal-golang/lager"
+)
+
+type FakeDiskUsageReporter struct {
+	DiskUsageStub        func(log lager.Logger, handle string) (gardener.DiskUsage, error)
+	diskUsageMutex       sync.RWMutex
+	diskUsageArgsForCall []struct {
+		log    lager.Logger
+		handle string
+	}
+	diskUsageReturns struct {
+		result1 gardener.DiskUsage
+		result2 error
+	}
+}
+
+func (fake *FakeDiskUsageReporter) DiskUsage(log lager.Logger, handle string) (gardener.DiskUsage, error) {
+	fake.diskUsageMutex.Lock()
+	fake.diskUsageArgsForCall = append(fake.diskUsageArgsForCall, struct {
+		log    lager.Logger
+		handle string
+	}{log, handle})
+	fake.diskUsageMutex.Unlock()
+	if fake.DiskUsageStub != nil {
+		return fake.DiskUsageStub(log, handle)
+	} else {
+		return fake.diskUsageReturns.result1, fake.diskUsageReturns.result2
+	}
+}
+
+func (fake *FakeDiskUsageReporter) DiskUsageCallCount() int {
+	fake.diskUsageMutex.RLock()
+	defer fake.diskUsageMutex.RUnlock()
+	return len(fake.diskUsageArgsForCall)
+}
+
+func (fake *FakeDiskUsageReporter) DiskUsageArgsForCall(i int) (lager.Logger, string) {
+	fake.diskUsageMutex.RLock()
+	defer fake.diskUsageMutex.RUnlock()
+	return fake.diskUsageArgsForCall[i].log, fake.diskUsageArgsForCall[i].handle
+}
+
+func (fake *FakeDiskUsageReporter) DiskUsageReturns(result1 gardener.DiskUsage, result2 error) {
+	fake.DiskUsageStub = nil
+	fake.diskUsageReturns = struct {
+		result1 gardener.DiskUsage
+		result2 error
+	}{result1, result2}
+}
+
+var _ gardener.DiskUsageReporter = new(FakeDiskUsageReporter)
@@ -0,0 +1,62 @@
+// This file was generated by counterfeiter
+package fakes
+
+import (
+	"sync"
+
+	"github.com/cloudfoundry-incubator/guardian/gardener"
+	"github.com/pivotal-golang/lager"
+)
+
+type FakeDNSNetworker struct {
+	HooksWithDNSStub        func(log lager.Logger, handle, spec string, dns gardener.DNSSpec) (gardener.Hooks, error)
+	hooksWithDNSMutex       sync.RWMutex
+	hooksWithDNSArgsForCall []struct {
+		log    lager.Logger
+		handle string
+		spec   string
+		dns    gardener.DNSSpec
+	}
+	hooksWithDNSReturns struct {
+		result1 gardener.Hooks
+		result2 error
+	}
+}
+
+func (fake *FakeDNSNetworker) HooksWithDNS(log lager.Logger, handle string, spec string, dns gardener.DNSSpec) (gardener.Hooks, error) {
+	fake.hooksWithDNSMutex.Lock()
+	fake.hooksWithDNSArgsForCall = append(fake.hooksWithDNSArgsForCall, struct {
+		log    lager.Logger
+		handle string
+		spec   string
+		dns    gardener.DNSSpec
+	}{log, handle, spec, dns})
+	fake.hooksWithDNSMutex.Unlock()
+	if fake.HooksWithDNSStub != nil {
+		return fake.HooksWithDNSStub(log, handle, spec, dns)
+	} else {
+		return fake.hooksWithDNSReturns.result1, fake.hooksWithDNSReturns.result2
+	}
+}
+
+func (fake *FakeDNSNetworker) HooksWithDNSCallCount() int {
+	fake.hooksWithDNSMutex.RLock()
+	defer fake.hooksWithDNSMutex.RUnlock()
+	return len(fake.hooksWithDNSArgsForCall)
+}
+
+func (fake *FakeDNSNetworker) HooksWithDNSArgsForCall(i int) (lager.Logger, string, string, gardener.DNSSpec) {
+	fake.hooksWithDNSMutex.RLock()
+	defer fake.hooksWithDNSMutex.RUnlock()
+	return fake.hooksWithDNSArgsForCall[i].log, fake.hooksWithDNSArgsForCall[i].handle, fake.hooksWithDNSArgsForCall[i].spec, fake.hooksWithDNSArgsForCall[i].dns
+}
+
+func (fake *FakeDNSNetworker) HooksWithDNSReturns(result1 gardener.Hooks, result2 error) {
+	fake.HooksWithDNSStub = nil
+	fake.hooksWithDNSReturns = struct {
+		result1 gardener.Hooks
+		result2 error
+	}{result1, result2}
+}
+
+var _ gardener.DNSNetworker = new(FakeDNSNetworker)
@@ -0,0 +1,41 @@
+// This file was generated by counterfeiter
+package fakes
+
+import (
+	"sync"
+
+	"github.com/cloudfoundry-incubator/guardian/gardener"
+)
+
+type FakeEventPublisher struct {
+	PublishStub        func(event gardener.Event)
+	publishMutex       sync.RWMutex
+	publishArgsForCall []struct {
+		event gardener.Event
+	}
+}
+
+func (fake *FakeEventPublisher) Publish(event gardener.Event) {
+	fake.publishMutex.Lock()
+	fake.publishArgsForCall = append(fake.publishArgsForCall, struct {
+		event gardener.Event
+	}{event})
+	fake.publishMutex.Unlock()
+	if fake.PublishStub != nil {
+		fake.PublishStub(event)
+	}
+}
+
+func (fake *FakeEventPublisher) PublishCallCount() int {
+	fake.publishMutex.RLock()
+	defer fake.publishMutex.RUnlock()
+	return len(fake.publishArgsForCall)
+}
+
+func (fake *FakeEventPublisher) PublishArgsForCall(i int) gardener.Event {
+	fake.publishMutex.RLock()
+	defer fake.publishMutex.RUnlock()
+	return fake.publishArgsForCall[i].event
+}
+
+var _ gardener.EventPublisher = new(FakeEventPublisher)
@@ -0,0 +1,58 @@
+// This file was generated by counterfeiter
+package fakes
+
+import (
+	"sync"
+
+	"github.com/cloudfoundry-incubator/guardian/gardener"
+	"github.com/pivotal-golang/lager"
+)
+
+type FakeImageConfigVolumeCreator struct {
+	ImageConfigStub        func(log lager.Logger, handle string) (gardener.ImageConfig, error)
+	imageConfigMutex       sync.RWMutex
+	imageConfigArgsForCall []struct {
+		log    lager.Logger
+		handle string
+	}
+	imageConfigReturns struct {
+		result1 gardener.ImageConfig
+		result2 error
+	}
+}
+
+func (fake *FakeImageConfigVolumeCreator) ImageConfig(log lager.Logger, handle string) (gardener.ImageConfig, error) {
+	fake.imageConfigMutex.Lock()
+	fake.imageConfigArgsForCall = append(fake.imageConfigArgsForCall, struct {
+		log    lager.Logger
+		handle string
+	}{log, handle})
+	fake.imageConfigMutex.Unlock()
+	if fake.ImageConfigStub != nil {
+		return fake.ImageConfigStub(log, handle)
+	} else {
+		return fake.imageConfigReturns.result1, fake.imageConfigReturns.result2
+	}
+}
+
+func (fake *FakeImageConfigVolumeCreator) ImageConfigCallCount() int {
+	fake.imageConfigMutex.RLock()
+	defer fake.imageConfigMutex.RUnlock()
+	return len(fake.imageConfigArgsForCall)
+}
+
+func (fake *FakeImageConfigVolumeCreator) ImageConfigArgsForCall(i int) (lager.Logger, string) {
+	fake.imageConfigMutex.RLock()
+	defer fake.imageConfigMutex.RUnlock()
+	return fake.imageConfigArgsForCall[i].log, fake.imageConfigArgsForCall[i].handle
+}
+
+func (fake *FakeImageConfigVolumeCreator) ImageConfigReturns(result1 gardener.ImageConfig, result2 error) {
+	fake.ImageConfigStub = nil
+	fake.imageConfigReturns = struct {
+		result1 gardener.ImageConfig
+		result2 error
+	}{result1, result2}
+}
+
+var _ gardener.ImageConfigVolumeCreator = new(FakeImageConfigVolumeCreator)
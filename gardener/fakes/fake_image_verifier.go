@@ -0,0 +1,59 @@
+// This file was generated by counterfeiter
+package fakes
+
+import (
+	"net/url"
+	"sync"
+
+	"github.com/cloudfoundry-incubator/guardian/gardener"
+	"github.com/pivotal-golang/lager"
+)
+
+type FakeImageVerifier struct {
+	VerifyImageStub        func(log lager.Logger, image *url.URL) (string, error)
+	verifyImageMutex       sync.RWMutex
+	verifyImageArgsForCall []struct {
+		log   lager.Logger
+		image *url.URL
+	}
+	verifyImageReturns struct {
+		result1 string
+		result2 error
+	}
+}
+
+func (fake *FakeImageVerifier) VerifyImage(log lager.Logger, image *url.URL) (string, error) {
+	fake.verifyImageMutex.Lock()
+	fake.verifyImageArgsForCall = append(fake.verifyImageArgsForCall, struct {
+		log   lager.Logger
+		image *url.URL
+	}{log, image})
+	fake.verifyImageMutex.Unlock()
+	if fake.VerifyImageStub != nil {
+		return fake.VerifyImageStub(log, image)
+	} else {
+		return fake.verifyImageReturns.result1, fake.verifyImageReturns.result2
+	}
+}
+
+func (fake *FakeImageVerifier) VerifyImageCallCount() int {
+	fake.verifyImageMutex.RLock()
+	defer fake.verifyImageMutex.RUnlock()
+	return len(fake.verifyImageArgsForCall)
+}
+
+func (fake *FakeImageVerifier) VerifyImageArgsForCall(i int) (lager.Logger, *url.URL) {
+	fake.verifyImageMutex.RLock()
+	defer fake.verifyImageMutex.RUnlock()
+	return fake.verifyImageArgsForCall[i].log, fake.verifyImageArgsForCall[i].image
+}
+
+func (fake *FakeImageVerifier) VerifyImageReturns(result1 string, result2 error) {
+	fake.VerifyImageStub = nil
+	fake.verifyImageReturns = struct {
+		result1 string
+		result2 error
+	}{result1, result2}
+}
+
+var _ gardener.ImageVerifier = new(FakeImageVerifier)
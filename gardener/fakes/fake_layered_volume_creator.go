@@ -0,0 +1,67 @@
+// This file was generated by counterfeiter
+package fakes
+
+import (
+	"sync"
+
+	"github.com/cloudfoundry-incubator/garden-shed/rootfs_provider"
+	"github.com/cloudfoundry-incubator/guardian/gardener"
+	"github.com/pivotal-golang/lager"
+)
+
+type FakeLayeredVolumeCreator struct {
+	CreateLayeredStub        func(log lager.Logger, handle string, spec rootfs_provider.Spec, mappings *gardener.IDMappings, layers []string) (string, []string, error)
+	createLayeredMutex       sync.RWMutex
+	createLayeredArgsForCall []struct {
+		log      lager.Logger
+		handle   string
+		spec     rootfs_provider.Spec
+		mappings *gardener.IDMappings
+		layers   []string
+	}
+	createLayeredReturns struct {
+		result1 string
+		result2 []string
+		result3 error
+	}
+}
+
+func (fake *FakeLayeredVolumeCreator) CreateLayered(log lager.Logger, handle string, spec rootfs_provider.Spec, mappings *gardener.IDMappings, layers []string) (string, []string, error) {
+	fake.createLayeredMutex.Lock()
+	fake.createLayeredArgsForCall = append(fake.createLayeredArgsForCall, struct {
+		log      lager.Logger
+		handle   string
+		spec     rootfs_provider.Spec
+		mappings *gardener.IDMappings
+		layers   []string
+	}{log, handle, spec, mappings, layers})
+	fake.createLayeredMutex.Unlock()
+	if fake.CreateLayeredStub != nil {
+		return fake.CreateLayeredStub(log, handle, spec, mappings, layers)
+	} else {
+		return fake.createLayeredReturns.result1, fake.createLayeredReturns.result2, fake.createLayeredReturns.result3
+	}
+}
+
+func (fake *FakeLayeredVolumeCreator) CreateLayeredCallCount() int {
+	fake.createLayeredMutex.RLock()
+	defer fake.createLayeredMutex.RUnlock()
+	return len(fake.createLayeredArgsForCall)
+}
+
+func (fake *FakeLayeredVolumeCreator) CreateLayeredArgsForCall(i int) (lager.Logger, string, rootfs_provider.Spec, *gardener.IDMappings, []string) {
+	fake.createLayeredMutex.RLock()
+	defer fake.createLayeredMutex.RUnlock()
+	return fake.createLayeredArgsForCall[i].log, fake.createLayeredArgsForCall[i].handle, fake.createLayeredArgsForCall[i].spec, fake.createLayeredArgsForCall[i].mappings, fake.createLayeredArgsForCall[i].layers
+}
+
+func (fake *FakeLayeredVolumeCreator) CreateLayeredReturns(result1 string, result2 []string, result3 error) {
+	fake.CreateLayeredStub = nil
+	fake.createLayeredReturns = struct {
+		result1 string
+		result2 []string
+		result3 error
+	}{result1, result2, result3}
+}
+
+var _ gardener.LayeredVolumeCreator = new(FakeLayeredVolumeCreator)
@@ -0,0 +1,237 @@
+// This file was generated by counterfeiter
+package fakes
+
+import (
+	"sync"
+
+	"github.com/cloudfoundry-incubator/garden"
+	"github.com/cloudfoundry-incubator/guardian/gardener"
+	"github.com/pivotal-golang/lager"
+)
+
+type FakeLimiter struct {
+	LimitCPUStub        func(log lager.Logger, handle string, limits garden.CPULimits, quota gardener.CPUQuota) error
+	limitCPUMutex       sync.RWMutex
+	limitCPUArgsForCall []struct {
+		log    lager.Logger
+		handle string
+		limits garden.CPULimits
+		quota  gardener.CPUQuota
+	}
+	limitCPUReturns struct {
+		result1 error
+	}
+	CurrentCPULimitsStub        func(log lager.Logger, handle string) (garden.CPULimits, error)
+	currentCPULimitsMutex       sync.RWMutex
+	currentCPULimitsArgsForCall []struct {
+		log    lager.Logger
+		handle string
+	}
+	currentCPULimitsReturns struct {
+		result1 garden.CPULimits
+		result2 error
+	}
+	LimitMemoryStub        func(log lager.Logger, handle string, limits garden.MemoryLimits) error
+	limitMemoryMutex       sync.RWMutex
+	limitMemoryArgsForCall []struct {
+		log    lager.Logger
+		handle string
+		limits garden.MemoryLimits
+	}
+	limitMemoryReturns struct {
+		result1 error
+	}
+	LimitPidsStub        func(log lager.Logger, handle string, maxPids int64) error
+	limitPidsMutex       sync.RWMutex
+	limitPidsArgsForCall []struct {
+		log     lager.Logger
+		handle  string
+		maxPids int64
+	}
+	limitPidsReturns struct {
+		result1 error
+	}
+	CurrentLimitsStub        func(log lager.Logger, handle string) (gardener.Limits, error)
+	currentLimitsMutex       sync.RWMutex
+	currentLimitsArgsForCall []struct {
+		log    lager.Logger
+		handle string
+	}
+	currentLimitsReturns struct {
+		result1 gardener.Limits
+		result2 error
+	}
+}
+
+func (fake *FakeLimiter) LimitCPU(log lager.Logger, handle string, limits garden.CPULimits, quota gardener.CPUQuota) error {
+	fake.limitCPUMutex.Lock()
+	fake.limitCPUArgsForCall = append(fake.limitCPUArgsForCall, struct {
+		log    lager.Logger
+		handle string
+		limits garden.CPULimits
+		quota  gardener.CPUQuota
+	}{log, handle, limits, quota})
+	fake.limitCPUMutex.Unlock()
+	if fake.LimitCPUStub != nil {
+		return fake.LimitCPUStub(log, handle, limits, quota)
+	} else {
+		return fake.limitCPUReturns.result1
+	}
+}
+
+func (fake *FakeLimiter) LimitCPUCallCount() int {
+	fake.limitCPUMutex.RLock()
+	defer fake.limitCPUMutex.RUnlock()
+	return len(fake.limitCPUArgsForCall)
+}
+
+func (fake *FakeLimiter) LimitCPUArgsForCall(i int) (lager.Logger, string, garden.CPULimits, gardener.CPUQuota) {
+	fake.limitCPUMutex.RLock()
+	defer fake.limitCPUMutex.RUnlock()
+	return fake.limitCPUArgsForCall[i].log, fake.limitCPUArgsForCall[i].handle, fake.limitCPUArgsForCall[i].limits, fake.limitCPUArgsForCall[i].quota
+}
+
+func (fake *FakeLimiter) LimitCPUReturns(result1 error) {
+	fake.LimitCPUStub = nil
+	fake.limitCPUReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeLimiter) CurrentCPULimits(log lager.Logger, handle string) (garden.CPULimits, error) {
+	fake.currentCPULimitsMutex.Lock()
+	fake.currentCPULimitsArgsForCall = append(fake.currentCPULimitsArgsForCall, struct {
+		log    lager.Logger
+		handle string
+	}{log, handle})
+	fake.currentCPULimitsMutex.Unlock()
+	if fake.CurrentCPULimitsStub != nil {
+		return fake.CurrentCPULimitsStub(log, handle)
+	} else {
+		return fake.currentCPULimitsReturns.result1, fake.currentCPULimitsReturns.result2
+	}
+}
+
+func (fake *FakeLimiter) CurrentCPULimitsCallCount() int {
+	fake.currentCPULimitsMutex.RLock()
+	defer fake.currentCPULimitsMutex.RUnlock()
+	return len(fake.currentCPULimitsArgsForCall)
+}
+
+func (fake *FakeLimiter) CurrentCPULimitsArgsForCall(i int) (lager.Logger, string) {
+	fake.currentCPULimitsMutex.RLock()
+	defer fake.currentCPULimitsMutex.RUnlock()
+	return fake.currentCPULimitsArgsForCall[i].log, fake.currentCPULimitsArgsForCall[i].handle
+}
+
+func (fake *FakeLimiter) CurrentCPULimitsReturns(result1 garden.CPULimits, result2 error) {
+	fake.CurrentCPULimitsStub = nil
+	fake.currentCPULimitsReturns = struct {
+		result1 garden.CPULimits
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeLimiter) LimitMemory(log lager.Logger, handle string, limits garden.MemoryLimits) error {
+	fake.limitMemoryMutex.Lock()
+	fake.limitMemoryArgsForCall = append(fake.limitMemoryArgsForCall, struct {
+		log    lager.Logger
+		handle string
+		limits garden.MemoryLimits
+	}{log, handle, limits})
+	fake.limitMemoryMutex.Unlock()
+	if fake.LimitMemoryStub != nil {
+		return fake.LimitMemoryStub(log, handle, limits)
+	} else {
+		return fake.limitMemoryReturns.result1
+	}
+}
+
+func (fake *FakeLimiter) LimitMemoryCallCount() int {
+	fake.limitMemoryMutex.RLock()
+	defer fake.limitMemoryMutex.RUnlock()
+	return len(fake.limitMemoryArgsForCall)
+}
+
+func (fake *FakeLimiter) LimitMemoryArgsForCall(i int) (lager.Logger, string, garden.MemoryLimits) {
+	fake.limitMemoryMutex.RLock()
+	defer fake.limitMemoryMutex.RUnlock()
+	return fake.limitMemoryArgsForCall[i].log, fake.limitMemoryArgsForCall[i].handle, fake.limitMemoryArgsForCall[i].limits
+}
+
+func (fake *FakeLimiter) LimitMemoryReturns(result1 error) {
+	fake.LimitMemoryStub = nil
+	fake.limitMemoryReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeLimiter) LimitPids(log lager.Logger, handle string, maxPids int64) error {
+	fake.limitPidsMutex.Lock()
+	fake.limitPidsArgsForCall = append(fake.limitPidsArgsForCall, struct {
+		log     lager.Logger
+		handle  string
+		maxPids int64
+	}{log, handle, maxPids})
+	fake.limitPidsMutex.Unlock()
+	if fake.LimitPidsStub != nil {
+		return fake.LimitPidsStub(log, handle, maxPids)
+	} else {
+		return fake.limitPidsReturns.result1
+	}
+}
+
+func (fake *FakeLimiter) LimitPidsCallCount() int {
+	fake.limitPidsMutex.RLock()
+	defer fake.limitPidsMutex.RUnlock()
+	return len(fake.limitPidsArgsForCall)
+}
+
+func (fake *FakeLimiter) LimitPidsArgsForCall(i int) (lager.Logger, string, int64) {
+	fake.limitPidsMutex.RLock()
+	defer fake.limitPidsMutex.RUnlock()
+	return fake.limitPidsArgsForCall[i].log, fake.limitPidsArgsForCall[i].handle, fake.limitPidsArgsForCall[i].maxPids
+}
+
+func (fake *FakeLimiter) LimitPidsReturns(result1 error) {
+	fake.LimitPidsStub = nil
+	fake.limitPidsReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeLimiter) CurrentLimits(log lager.Logger, handle string) (gardener.Limits, error) {
+	fake.currentLimitsMutex.Lock()
+	fake.currentLimitsArgsForCall = append(fake.currentLimitsArgsForCall, struct {
+		log    lager.Logger
+		handle string
+	}{log, handle})
+	fake.currentLimitsMutex.Unlock()
+	if fake.CurrentLimitsStub != nil {
+		return fake.CurrentLimitsStub(log, handle)
+	} else {
+		return fake.currentLimitsReturns.result1, fake.currentLimitsReturns.result2
+	}
+}
+
+func (fake *FakeLimiter) CurrentLimitsCallCount() int {
+	fake.currentLimitsMutex.RLock()
+	defer fake.currentLimitsMutex.RUnlock()
+	return len(fake.currentLimitsArgsForCall)
+}
+
+func (fake *FakeLimiter) CurrentLimitsArgsForCall(i int) (lager.Logger, string) {
+	fake.currentLimitsMutex.RLock()
+	defer fake.currentLimitsMutex.RUnlock()
+	return fake.currentLimitsArgsForCall[i].log, fake.currentLimitsArgsForCall[i].handle
+}
+
+func (fake *FakeLimiter) CurrentLimitsReturns(result1 gardener.Limits, result2 error) {
+	fake.CurrentLimitsStub = nil
+	fake.currentLimitsReturns = struct {
+		result1 gardener.Limits
+		result2 error
+	}{result1, result2}
+}
+
+var _ gardener.Limiter = new(FakeLimiter)
@@ -0,0 +1,65 @@
+// This file was generated by counterfeiter
+package fakes
+
+import (
+	"sync"
+
+	"github.com/cloudfoundry-incubator/garden-shed/rootfs_provider"
+	"github.com/cloudfoundry-incubator/guardian/gardener"
+	"github.com/pivotal-golang/lager"
+)
+
+type FakeMappingVolumeCreator struct {
+	CreateMappedStub        func(log lager.Logger, handle string, spec rootfs_provider.Spec, mappings gardener.IDMappings) (string, []string, error)
+	createMappedMutex       sync.RWMutex
+	createMappedArgsForCall []struct {
+		log      lager.Logger
+		handle   string
+		spec     rootfs_provider.Spec
+		mappings gardener.IDMappings
+	}
+	createMappedReturns struct {
+		result1 string
+		result2 []string
+		result3 error
+	}
+}
+
+func (fake *FakeMappingVolumeCreator) CreateMapped(log lager.Logger, handle string, spec rootfs_provider.Spec, mappings gardener.IDMappings) (string, []string, error) {
+	fake.createMappedMutex.Lock()
+	fake.createMappedArgsForCall = append(fake.createMappedArgsForCall, struct {
+		log      lager.Logger
+		handle   string
+		spec     rootfs_provider.Spec
+		mappings gardener.IDMappings
+	}{log, handle, spec, mappings})
+	fake.createMappedMutex.Unlock()
+	if fake.CreateMappedStub != nil {
+		return fake.CreateMappedStub(log, handle, spec, mappings)
+	} else {
+		return fake.createMappedReturns.result1, fake.createMappedReturns.result2, fake.createMappedReturns.result3
+	}
+}
+
+func (fake *FakeMappingVolumeCreator) CreateMappedCallCount() int {
+	fake.createMappedMutex.RLock()
+	defer fake.createMappedMutex.RUnlock()
+	return len(fake.createMappedArgsForCall)
+}
+
+func (fake *FakeMappingVolumeCreator) CreateMappedArgsForCall(i int) (lager.Logger, string, rootfs_provider.Spec, gardener.IDMappings) {
+	fake.createMappedMutex.RLock()
+	defer fake.createMappedMutex.RUnlock()
+	return fake.createMappedArgsForCall[i].log, fake.createMappedArgsForCall[i].handle, fake.createMappedArgsForCall[i].spec, fake.createMappedArgsForCall[i].mappings
+}
+
+func (fake *FakeMappingVolumeCreator) CreateMappedReturns(result1 string, result2 []string, result3 error) {
+	fake.CreateMappedStub = nil
+	fake.createMappedReturns = struct {
+		result1 string
+		result2 []string
+		result3 error
+	}{result1, result2, result3}
+}
+
+var _ gardener.MappingVolumeCreator = new(FakeMappingVolumeCreator)
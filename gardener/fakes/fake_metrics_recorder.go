@@ -0,0 +1,148 @@
+// This file was generated by counterfeiter
+package fakes
+
+import (
+	"sync"
+	"time"
+
+	"github.com/cloudfoundry-incubator/guardian/gardener"
+)
+
+type FakeMetricsRecorder struct {
+	ContainerCreatedStub        func(duration time.Duration)
+	containerCreatedMutex       sync.RWMutex
+	containerCreatedArgsForCall []struct {
+		duration time.Duration
+	}
+	ContainerDestroyedStub           func()
+	containerDestroyedMutex          sync.RWMutex
+	containerDestroyedArgsForCall    []struct{}
+	ContainerCreateQueuedStub        func(wait time.Duration)
+	containerCreateQueuedMutex       sync.RWMutex
+	containerCreateQueuedArgsForCall []struct {
+		wait time.Duration
+	}
+	ContainerCreateFailedStub        func(stage, class string)
+	containerCreateFailedMutex       sync.RWMutex
+	containerCreateFailedArgsForCall []struct {
+		stage string
+		class string
+	}
+	ContainerDestroyFailedStub        func(stage, class string)
+	containerDestroyFailedMutex       sync.RWMutex
+	containerDestroyFailedArgsForCall []struct {
+		stage string
+		class string
+	}
+}
+
+func (fake *FakeMetricsRecorder) ContainerCreated(duration time.Duration) {
+	fake.containerCreatedMutex.Lock()
+	fake.containerCreatedArgsForCall = append(fake.containerCreatedArgsForCall, struct {
+		duration time.Duration
+	}{duration})
+	fake.containerCreatedMutex.Unlock()
+	if fake.ContainerCreatedStub != nil {
+		fake.ContainerCreatedStub(duration)
+	}
+}
+
+func (fake *FakeMetricsRecorder) ContainerCreatedCallCount() int {
+	fake.containerCreatedMutex.RLock()
+	defer fake.containerCreatedMutex.RUnlock()
+	return len(fake.containerCreatedArgsForCall)
+}
+
+func (fake *FakeMetricsRecorder) ContainerCreatedArgsForCall(i int) time.Duration {
+	fake.containerCreatedMutex.RLock()
+	defer fake.containerCreatedMutex.RUnlock()
+	return fake.containerCreatedArgsForCall[i].duration
+}
+
+func (fake *FakeMetricsRecorder) ContainerDestroyed() {
+	fake.containerDestroyedMutex.Lock()
+	fake.containerDestroyedArgsForCall = append(fake.containerDestroyedArgsForCall, struct{}{})
+	fake.containerDestroyedMutex.Unlock()
+	if fake.ContainerDestroyedStub != nil {
+		fake.ContainerDestroyedStub()
+	}
+}
+
+func (fake *FakeMetricsRecorder) ContainerDestroyedCallCount() int {
+	fake.containerDestroyedMutex.RLock()
+	defer fake.containerDestroyedMutex.RUnlock()
+	return len(fake.containerDestroyedArgsForCall)
+}
+
+func (fake *FakeMetricsRecorder) ContainerCreateQueued(wait time.Duration) {
+	fake.containerCreateQueuedMutex.Lock()
+	fake.containerCreateQueuedArgsForCall = append(fake.containerCreateQueuedArgsForCall, struct {
+		wait time.Duration
+	}{wait})
+	fake.containerCreateQueuedMutex.Unlock()
+	if fake.ContainerCreateQueuedStub != nil {
+		fake.ContainerCreateQueuedStub(wait)
+	}
+}
+
+func (fake *FakeMetricsRecorder) ContainerCreateQueuedCallCount() int {
+	fake.containerCreateQueuedMutex.RLock()
+	defer fake.containerCreateQueuedMutex.RUnlock()
+	return len(fake.containerCreateQueuedArgsForCall)
+}
+
+func (fake *FakeMetricsRecorder) ContainerCreateQueuedArgsForCall(i int) time.Duration {
+	fake.containerCreateQueuedMutex.RLock()
+	defer fake.containerCreateQueuedMutex.RUnlock()
+	return fake.containerCreateQueuedArgsForCall[i].wait
+}
+
+func (fake *FakeMetricsRecorder) ContainerCreateFailed(stage string, class string) {
+	fake.containerCreateFailedMutex.Lock()
+	fake.containerCreateFailedArgsForCall = append(fake.containerCreateFailedArgsForCall, struct {
+		stage string
+		class string
+	}{stage, class})
+	fake.containerCreateFailedMutex.Unlock()
+	if fake.ContainerCreateFailedStub != nil {
+		fake.ContainerCreateFailedStub(stage, class)
+	}
+}
+
+func (fake *FakeMetricsRecorder) ContainerCreateFailedCallCount() int {
+	fake.containerCreateFailedMutex.RLock()
+	defer fake.containerCreateFailedMutex.RUnlock()
+	return len(fake.containerCreateFailedArgsForCall)
+}
+
+func (fake *FakeMetricsRecorder) ContainerCreateFailedArgsForCall(i int) (string, string) {
+	fake.containerCreateFailedMutex.RLock()
+	defer fake.containerCreateFailedMutex.RUnlock()
+	return fake.containerCreateFailedArgsForCall[i].stage, fake.containerCreateFailedArgsForCall[i].class
+}
+
+func (fake *FakeMetricsRecorder) ContainerDestroyFailed(stage string, class string) {
+	fake.containerDestroyFailedMutex.Lock()
+	fake.containerDestroyFailedArgsForCall = append(fake.containerDestroyFailedArgsForCall, struct {
+		stage string
+		class string
+	}{stage, class})
+	fake.containerDestroyFailedMutex.Unlock()
+	if fake.ContainerDestroyFailedStub != nil {
+		fake.ContainerDestroyFailedStub(stage, class)
+	}
+}
+
+func (fake *FakeMetricsRecorder) ContainerDestroyFailedCallCount() int {
+	fake.containerDestroyFailedMutex.RLock()
+	defer fake.containerDestroyFailedMutex.RUnlock()
+	return len(fake.containerDestroyFailedArgsForCall)
+}
+
+func (fake *FakeMetricsRecorder) ContainerDestroyFailedArgsForCall(i int) (string, string) {
+	fake.containerDestroyFailedMutex.RLock()
+	defer fake.containerDestroyFailedMutex.RUnlock()
+	return fake.containerDestroyFailedArgsForCall[i].stage, fake.containerDestroyFailedArgsForCall[i].class
+}
+
+var _ gardener.MetricsRecorder = new(FakeMetricsRecorder)
@@ -0,0 +1,58 @@
+// This file was generated by counterfeiter
+package fakes
+
+import (
+	"sync"
+
+	"github.com/cloudfoundry-incubator/guardian/gardener"
+	"github.com/pivotal-golang/lager"
+)
+
+type FakeNetworkInspector struct {
+	InspectNetworkStub        func(log lager.Logger, handle string) (gardener.NetworkRuntime, error)
+	inspectNetworkMutex       sync.RWMutex
+	inspectNetworkArgsForCall []struct {
+		log    lager.Logger
+		handle string
+	}
+	inspectNetworkReturns struct {
+		result1 gardener.NetworkRuntime
+		result2 error
+	}
+}
+
+func (fake *FakeNetworkInspector) InspectNetwork(log lager.Logger, handle string) (gardener.NetworkRuntime, error) {
+	fake.inspectNetworkMutex.Lock()
+	fake.inspectNetworkArgsForCall = append(fake.inspectNetworkArgsForCall, struct {
+		log    lager.Logger
+		handle string
+	}{log, handle})
+	fake.inspectNetworkMutex.Unlock()
+	if fake.InspectNetworkStub != nil {
+		return fake.InspectNetworkStub(log, handle)
+	} else {
+		return fake.inspectNetworkReturns.result1, fake.inspectNetworkReturns.result2
+	}
+}
+
+func (fake *FakeNetworkInspector) InspectNetworkCallCount() int {
+	fake.inspectNetworkMutex.RLock()
+	defer fake.inspectNetworkMutex.RUnlock()
+	return len(fake.inspectNetworkArgsForCall)
+}
+
+func (fake *FakeNetworkInspector) InspectNetworkArgsForCall(i int) (lager.Logger, string) {
+	fake.inspectNetworkMutex.RLock()
+	defer fake.inspectNetworkMutex.RUnlock()
+	return fake.inspectNetworkArgsForCall[i].log, fake.inspectNetworkArgsForCall[i].handle
+}
+
+func (fake *FakeNetworkInspector) InspectNetworkReturns(result1 gardener.NetworkRuntime, result2 error) {
+	fake.InspectNetworkStub = nil
+	fake.inspectNetworkReturns = struct {
+		result1 gardener.NetworkRuntime
+		result2 error
+	}{result1, result2}
+}
+
+var _ gardener.NetworkInspector = new(FakeNetworkInspector)
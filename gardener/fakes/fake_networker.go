@@ -59,6 +59,43 @@ type FakeNetworker struct {
 	netOutReturns struct {
 		result1 error
 	}
+	BulkNetOutStub        func(log lager.Logger, handle string, rules []garden.NetOutRule) error
+	bulkNetOutMutex       sync.RWMutex
+	bulkNetOutArgsForCall []struct {
+		log    lager.Logger
+		handle string
+		rules  []garden.NetOutRule
+	}
+	bulkNetOutReturns struct {
+		result1 error
+	}
+	CheckpointStub        func(log lager.Logger, handle string) error
+	checkpointMutex       sync.RWMutex
+	checkpointArgsForCall []struct {
+		log    lager.Logger
+		handle string
+	}
+	checkpointReturns struct {
+		result1 error
+	}
+	RestoreStub        func(log lager.Logger, handle string) error
+	restoreMutex       sync.RWMutex
+	restoreArgsForCall []struct {
+		log    lager.Logger
+		handle string
+	}
+	restoreReturns struct {
+		result1 error
+	}
+	RecoverStub        func(log lager.Logger, handle string) error
+	recoverMutex       sync.RWMutex
+	recoverArgsForCall []struct {
+		log    lager.Logger
+		handle string
+	}
+	recoverReturns struct {
+		result1 error
+	}
 }
 
 func (fake *FakeNetworker) Hooks(log lager.Logger, handle string, spec string) (gardener.Hooks, error) {
@@ -224,4 +261,137 @@ func (fake *FakeNetworker) NetOutReturns(result1 error) {
 	}{result1}
 }
 
+func (fake *FakeNetworker) BulkNetOut(log lager.Logger, handle string, rules []garden.NetOutRule) error {
+	fake.bulkNetOutMutex.Lock()
+	fake.bulkNetOutArgsForCall = append(fake.bulkNetOutArgsForCall, struct {
+		log    lager.Logger
+		handle string
+		rules  []garden.NetOutRule
+	}{log, handle, rules})
+	fake.bulkNetOutMutex.Unlock()
+	if fake.BulkNetOutStub != nil {
+		return fake.BulkNetOutStub(log, handle, rules)
+	} else {
+		return fake.bulkNetOutReturns.result1
+	}
+}
+
+func (fake *FakeNetworker) BulkNetOutCallCount() int {
+	fake.bulkNetOutMutex.RLock()
+	defer fake.bulkNetOutMutex.RUnlock()
+	return len(fake.bulkNetOutArgsForCall)
+}
+
+func (fake *FakeNetworker) BulkNetOutArgsForCall(i int) (lager.Logger, string, []garden.NetOutRule) {
+	fake.bulkNetOutMutex.RLock()
+	defer fake.bulkNetOutMutex.RUnlock()
+	return fake.bulkNetOutArgsForCall[i].log, fake.bulkNetOutArgsForCall[i].handle, fake.bulkNetOutArgsForCall[i].rules
+}
+
+func (fake *FakeNetworker) BulkNetOutReturns(result1 error) {
+	fake.BulkNetOutStub = nil
+	fake.bulkNetOutReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeNetworker) Checkpoint(log lager.Logger, handle string) error {
+	fake.checkpointMutex.Lock()
+	fake.checkpointArgsForCall = append(fake.checkpointArgsForCall, struct {
+		log    lager.Logger
+		handle string
+	}{log, handle})
+	fake.checkpointMutex.Unlock()
+	if fake.CheckpointStub != nil {
+		return fake.CheckpointStub(log, handle)
+	} else {
+		return fake.checkpointReturns.result1
+	}
+}
+
+func (fake *FakeNetworker) CheckpointCallCount() int {
+	fake.checkpointMutex.RLock()
+	defer fake.checkpointMutex.RUnlock()
+	return len(fake.checkpointArgsForCall)
+}
+
+func (fake *FakeNetworker) CheckpointArgsForCall(i int) (lager.Logger, string) {
+	fake.checkpointMutex.RLock()
+	defer fake.checkpointMutex.RUnlock()
+	return fake.checkpointArgsForCall[i].log, fake.checkpointArgsForCall[i].handle
+}
+
+func (fake *FakeNetworker) CheckpointReturns(result1 error) {
+	fake.CheckpointStub = nil
+	fake.checkpointReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeNetworker) Restore(log lager.Logger, handle string) error {
+	fake.restoreMutex.Lock()
+	fake.restoreArgsForCall = append(fake.restoreArgsForCall, struct {
+		log    lager.Logger
+		handle string
+	}{log, handle})
+	fake.restoreMutex.Unlock()
+	if fake.RestoreStub != nil {
+		return fake.RestoreStub(log, handle)
+	} else {
+		return fake.restoreReturns.result1
+	}
+}
+
+func (fake *FakeNetworker) RestoreCallCount() int {
+	fake.restoreMutex.RLock()
+	defer fake.restoreMutex.RUnlock()
+	return len(fake.restoreArgsForCall)
+}
+
+func (fake *FakeNetworker) RestoreArgsForCall(i int) (lager.Logger, string) {
+	fake.restoreMutex.RLock()
+	defer fake.restoreMutex.RUnlock()
+	return fake.restoreArgsForCall[i].log, fake.restoreArgsForCall[i].handle
+}
+
+func (fake *FakeNetworker) RestoreReturns(result1 error) {
+	fake.RestoreStub = nil
+	fake.restoreReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeNetworker) Recover(log lager.Logger, handle string) error {
+	fake.recoverMutex.Lock()
+	fake.recoverArgsForCall = append(fake.recoverArgsForCall, struct {
+		log    lager.Logger
+		handle string
+	}{log, handle})
+	fake.recoverMutex.Unlock()
+	if fake.RecoverStub != nil {
+		return fake.RecoverStub(log, handle)
+	} else {
+		return fake.recoverReturns.result1
+	}
+}
+
+func (fake *FakeNetworker) RecoverCallCount() int {
+	fake.recoverMutex.RLock()
+	defer fake.recoverMutex.RUnlock()
+	return len(fake.recoverArgsForCall)
+}
+
+func (fake *FakeNetworker) RecoverArgsForCall(i int) (lager.Logger, string) {
+	fake.recoverMutex.RLock()
+	defer fake.recoverMutex.RUnlock()
+	return fake.recoverArgsForCall[i].log, fake.recoverArgsForCall[i].handle
+}
+
+func (fake *FakeNetworker) RecoverReturns(result1 error) {
+	fake.RecoverStub = nil
+	fake.recoverReturns = struct {
+		result1 error
+	}{result1}
+}
+
 var _ gardener.Networker = new(FakeNetworker)
@@ -0,0 +1,58 @@
+// This file was generated by counterfeiter
+package fakes
+
+import (
+	"sync"
+
+	"github.com/cloudfoundry-incubator/guardian/gardener"
+	"github.com/pivotal-golang/lager"
+)
+
+type FakePeaReaper struct {
+	ReapPeasStub        func(log lager.Logger, handle string) ([]string, error)
+	reapPeasMutex       sync.RWMutex
+	reapPeasArgsForCall []struct {
+		log    lager.Logger
+		handle string
+	}
+	reapPeasReturns struct {
+		result1 []string
+		result2 error
+	}
+}
+
+func (fake *FakePeaReaper) ReapPeas(log lager.Logger, handle string) ([]string, error) {
+	fake.reapPeasMutex.Lock()
+	fake.reapPeasArgsForCall = append(fake.reapPeasArgsForCall, struct {
+		log    lager.Logger
+		handle string
+	}{log, handle})
+	fake.reapPeasMutex.Unlock()
+	if fake.ReapPeasStub != nil {
+		return fake.ReapPeasStub(log, handle)
+	} else {
+		return fake.reapPeasReturns.result1, fake.reapPeasReturns.result2
+	}
+}
+
+func (fake *FakePeaReaper) ReapPeasCallCount() int {
+	fake.reapPeasMutex.RLock()
+	defer fake.reapPeasMutex.RUnlock()
+	return len(fake.reapPeasArgsForCall)
+}
+
+func (fake *FakePeaReaper) ReapPeasArgsForCall(i int) (lager.Logger, string) {
+	fake.reapPeasMutex.RLock()
+	defer fake.reapPeasMutex.RUnlock()
+	return fake.reapPeasArgsForCall[i].log, fake.reapPeasArgsForCall[i].handle
+}
+
+func (fake *FakePeaReaper) ReapPeasReturns(result1 []string, result2 error) {
+	fake.ReapPeasStub = nil
+	fake.reapPeasReturns = struct {
+		result1 []string
+		result2 error
+	}{result1, result2}
+}
+
+var _ gardener.PeaReaper = new(FakePeaReaper)
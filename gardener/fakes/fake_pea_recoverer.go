@@ -0,0 +1,56 @@
+// This file was generated by counterfeiter
+package fakes
+
+import (
+	"sync"
+
+	"github.com/cloudfoundry-incubator/guardian/gardener"
+	"github.com/pivotal-golang/lager"
+)
+
+type FakePeaRecoverer struct {
+	RecoverPeasStub        func(log lager.Logger, handle string) error
+	recoverPeasMutex       sync.RWMutex
+	recoverPeasArgsForCall []struct {
+		log    lager.Logger
+		handle string
+	}
+	recoverPeasReturns struct {
+		result1 error
+	}
+}
+
+func (fake *FakePeaRecoverer) RecoverPeas(log lager.Logger, handle string) error {
+	fake.recoverPeasMutex.Lock()
+	fake.recoverPeasArgsForCall = append(fake.recoverPeasArgsForCall, struct {
+		log    lager.Logger
+		handle string
+	}{log, handle})
+	fake.recoverPeasMutex.Unlock()
+	if fake.RecoverPeasStub != nil {
+		return fake.RecoverPeasStub(log, handle)
+	} else {
+		return fake.recoverPeasReturns.result1
+	}
+}
+
+func (fake *FakePeaRecoverer) RecoverPeasCallCount() int {
+	fake.recoverPeasMutex.RLock()
+	defer fake.recoverPeasMutex.RUnlock()
+	return len(fake.recoverPeasArgsForCall)
+}
+
+func (fake *FakePeaRecoverer) RecoverPeasArgsForCall(i int) (lager.Logger, string) {
+	fake.recoverPeasMutex.RLock()
+	defer fake.recoverPeasMutex.RUnlock()
+	return fake.recoverPeasArgsForCall[i].log, fake.recoverPeasArgsForCall[i].handle
+}
+
+func (fake *FakePeaRecoverer) RecoverPeasReturns(result1 error) {
+	fake.RecoverPeasStub = nil
+	fake.recoverPeasReturns = struct {
+		result1 error
+	}{result1}
+}
+
+var _ gardener.PeaRecoverer = new(FakePeaRecoverer)
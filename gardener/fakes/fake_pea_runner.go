@@ -0,0 +1,63 @@
+// This file was generated by counterfeiter
+package fakes
+
+import (
+	"sync"
+
+	"github.com/cloudfoundry-incubator/garden"
+	"github.com/cloudfoundry-incubator/guardian/gardener"
+	"github.com/pivotal-golang/lager"
+)
+
+type FakePeaRunner struct {
+	RunPeaStub        func(log lager.Logger, handle string, pea gardener.PeaSpec, io garden.ProcessIO) (garden.Process, error)
+	runPeaMutex       sync.RWMutex
+	runPeaArgsForCall []struct {
+		log    lager.Logger
+		handle string
+		pea    gardener.PeaSpec
+		io     garden.ProcessIO
+	}
+	runPeaReturns struct {
+		result1 garden.Process
+		result2 error
+	}
+}
+
+func (fake *FakePeaRunner) RunPea(log lager.Logger, handle string, pea gardener.PeaSpec, io garden.ProcessIO) (garden.Process, error) {
+	fake.runPeaMutex.Lock()
+	fake.runPeaArgsForCall = append(fake.runPeaArgsForCall, struct {
+		log    lager.Logger
+		handle string
+		pea    gardener.PeaSpec
+		io     garden.ProcessIO
+	}{log, handle, pea, io})
+	fake.runPeaMutex.Unlock()
+	if fake.RunPeaStub != nil {
+		return fake.RunPeaStub(log, handle, pea, io)
+	} else {
+		return fake.runPeaReturns.result1, fake.runPeaReturns.result2
+	}
+}
+
+func (fake *FakePeaRunner) RunPeaCallCount() int {
+	fake.runPeaMutex.RLock()
+	defer fake.runPeaMutex.RUnlock()
+	return len(fake.runPeaArgsForCall)
+}
+
+func (fake *FakePeaRunner) RunPeaArgsForCall(i int) (lager.Logger, string, gardener.PeaSpec, garden.ProcessIO) {
+	fake.runPeaMutex.RLock()
+	defer fake.runPeaMutex.RUnlock()
+	return fake.runPeaArgsForCall[i].log, fake.runPeaArgsForCall[i].handle, fake.runPeaArgsForCall[i].pea, fake.runPeaArgsForCall[i].io
+}
+
+func (fake *FakePeaRunner) RunPeaReturns(result1 garden.Process, result2 error) {
+	fake.RunPeaStub = nil
+	fake.runPeaReturns = struct {
+		result1 garden.Process
+		result2 error
+	}{result1, result2}
+}
+
+var _ gardener.PeaRunner = new(FakePeaRunner)
@@ -0,0 +1,105 @@
+// This file was generated by counterfeiter
+package fakes
+
+import (
+	"sync"
+
+	"github.com/cloudfoundry-incubator/guardian/gardener"
+)
+
+type FakePortMapper struct {
+	NetInProtocolsStub        func(handle string, hostPort, containerPort uint32, protocols []string) (uint32, uint32, error)
+	netInProtocolsMutex       sync.RWMutex
+	netInProtocolsArgsForCall []struct {
+		handle        string
+		hostPort      uint32
+		containerPort uint32
+		protocols     []string
+	}
+	netInProtocolsReturns struct {
+		result1 uint32
+		result2 uint32
+		result3 error
+	}
+	NetInRemoveStub        func(handle string, hostPort uint32) error
+	netInRemoveMutex       sync.RWMutex
+	netInRemoveArgsForCall []struct {
+		handle   string
+		hostPort uint32
+	}
+	netInRemoveReturns struct {
+		result1 error
+	}
+}
+
+func (fake *FakePortMapper) NetInProtocols(handle string, hostPort uint32, containerPort uint32, protocols []string) (uint32, uint32, error) {
+	fake.netInProtocolsMutex.Lock()
+	fake.netInProtocolsArgsForCall = append(fake.netInProtocolsArgsForCall, struct {
+		handle        string
+		hostPort      uint32
+		containerPort uint32
+		protocols     []string
+	}{handle, hostPort, containerPort, protocols})
+	fake.netInProtocolsMutex.Unlock()
+	if fake.NetInProtocolsStub != nil {
+		return fake.NetInProtocolsStub(handle, hostPort, containerPort, protocols)
+	} else {
+		return fake.netInProtocolsReturns.result1, fake.netInProtocolsReturns.result2, fake.netInProtocolsReturns.result3
+	}
+}
+
+func (fake *FakePortMapper) NetInProtocolsCallCount() int {
+	fake.netInProtocolsMutex.RLock()
+	defer fake.netInProtocolsMutex.RUnlock()
+	return len(fake.netInProtocolsArgsForCall)
+}
+
+func (fake *FakePortMapper) NetInProtocolsArgsForCall(i int) (string, uint32, uint32, []string) {
+	fake.netInProtocolsMutex.RLock()
+	defer fake.netInProtocolsMutex.RUnlock()
+	return fake.netInProtocolsArgsForCall[i].handle, fake.netInProtocolsArgsForCall[i].hostPort, fake.netInProtocolsArgsForCall[i].containerPort, fake.netInProtocolsArgsForCall[i].protocols
+}
+
+func (fake *FakePortMapper) NetInProtocolsReturns(result1 uint32, result2 uint32, result3 error) {
+	fake.NetInProtocolsStub = nil
+	fake.netInProtocolsReturns = struct {
+		result1 uint32
+		result2 uint32
+		result3 error
+	}{result1, result2, result3}
+}
+
+func (fake *FakePortMapper) NetInRemove(handle string, hostPort uint32) error {
+	fake.netInRemoveMutex.Lock()
+	fake.netInRemoveArgsForCall = append(fake.netInRemoveArgsForCall, struct {
+		handle   string
+		hostPort uint32
+	}{handle, hostPort})
+	fake.netInRemoveMutex.Unlock()
+	if fake.NetInRemoveStub != nil {
+		return fake.NetInRemoveStub(handle, hostPort)
+	} else {
+		return fake.netInRemoveReturns.result1
+	}
+}
+
+func (fake *FakePortMapper) NetInRemoveCallCount() int {
+	fake.netInRemoveMutex.RLock()
+	defer fake.netInRemoveMutex.RUnlock()
+	return len(fake.netInRemoveArgsForCall)
+}
+
+func (fake *FakePortMapper) NetInRemoveArgsForCall(i int) (string, uint32) {
+	fake.netInRemoveMutex.RLock()
+	defer fake.netInRemoveMutex.RUnlock()
+	return fake.netInRemoveArgsForCall[i].handle, fake.netInRemoveArgsForCall[i].hostPort
+}
+
+func (fake *FakePortMapper) NetInRemoveReturns(result1 error) {
+	fake.NetInRemoveStub = nil
+	fake.netInRemoveReturns = struct {
+		result1 error
+	}{result1}
+}
+
+var _ gardener.PortMapper = new(FakePortMapper)
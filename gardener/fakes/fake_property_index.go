@@ -0,0 +1,58 @@
+// This file was generated by counterfeiter
+package fakes
+
+import (
+	"sync"
+
+	"github.com/cloudfoundry-incubator/garden"
+	"github.com/cloudfoundry-incubator/guardian/gardener"
+)
+
+type FakePropertyIndex struct {
+	SelectStub        func(props garden.Properties, selector string) ([]string, error)
+	selectMutex       sync.RWMutex
+	selectArgsForCall []struct {
+		props    garden.Properties
+		selector string
+	}
+	selectReturns struct {
+		result1 []string
+		result2 error
+	}
+}
+
+func (fake *FakePropertyIndex) Select(props garden.Properties, selector string) ([]string, error) {
+	fake.selectMutex.Lock()
+	fake.selectArgsForCall = append(fake.selectArgsForCall, struct {
+		props    garden.Properties
+		selector string
+	}{props, selector})
+	fake.selectMutex.Unlock()
+	if fake.SelectStub != nil {
+		return fake.SelectStub(props, selector)
+	} else {
+		return fake.selectReturns.result1, fake.selectReturns.result2
+	}
+}
+
+func (fake *FakePropertyIndex) SelectCallCount() int {
+	fake.selectMutex.RLock()
+	defer fake.selectMutex.RUnlock()
+	return len(fake.selectArgsForCall)
+}
+
+func (fake *FakePropertyIndex) SelectArgsForCall(i int) (garden.Properties, string) {
+	fake.selectMutex.RLock()
+	defer fake.selectMutex.RUnlock()
+	return fake.selectArgsForCall[i].props, fake.selectArgsForCall[i].selector
+}
+
+func (fake *FakePropertyIndex) SelectReturns(result1 []string, result2 error) {
+	fake.SelectStub = nil
+	fake.selectReturns = struct {
+		result1 []string
+		result2 error
+	}{result1, result2}
+}
+
+var _ gardener.PropertyIndex = new(FakePropertyIndex)
@@ -0,0 +1,110 @@
+// This file was generated by counterfeiter
+package fakes
+
+import (
+	"sync"
+
+	"github.com/cloudfoundry-incubator/guardian/gardener"
+	"github.com/pivotal-golang/lager"
+)
+
+type FakeProtocolNetworker struct {
+	NetInProtocolsStub        func(log lager.Logger, handle string, hostPort, containerPort uint32, protocols []string) (uint32, uint32, error)
+	netInProtocolsMutex       sync.RWMutex
+	netInProtocolsArgsForCall []struct {
+		log           lager.Logger
+		handle        string
+		hostPort      uint32
+		containerPort uint32
+		protocols     []string
+	}
+	netInProtocolsReturns struct {
+		result1 uint32
+		result2 uint32
+		result3 error
+	}
+	NetInRemoveStub        func(log lager.Logger, handle string, hostPort uint32) error
+	netInRemoveMutex       sync.RWMutex
+	netInRemoveArgsForCall []struct {
+		log      lager.Logger
+		handle   string
+		hostPort uint32
+	}
+	netInRemoveReturns struct {
+		result1 error
+	}
+}
+
+func (fake *FakeProtocolNetworker) NetInProtocols(log lager.Logger, handle string, hostPort uint32, containerPort uint32, protocols []string) (uint32, uint32, error) {
+	fake.netInProtocolsMutex.Lock()
+	fake.netInProtocolsArgsForCall = append(fake.netInProtocolsArgsForCall, struct {
+		log           lager.Logger
+		handle        string
+		hostPort      uint32
+		containerPort uint32
+		protocols     []string
+	}{log, handle, hostPort, containerPort, protocols})
+	fake.netInProtocolsMutex.Unlock()
+	if fake.NetInProtocolsStub != nil {
+		return fake.NetInProtocolsStub(log, handle, hostPort, containerPort, protocols)
+	} else {
+		return fake.netInProtocolsReturns.result1, fake.netInProtocolsReturns.result2, fake.netInProtocolsReturns.result3
+	}
+}
+
+func (fake *FakeProtocolNetworker) NetInProtocolsCallCount() int {
+	fake.netInProtocolsMutex.RLock()
+	defer fake.netInProtocolsMutex.RUnlock()
+	return len(fake.netInProtocolsArgsForCall)
+}
+
+func (fake *FakeProtocolNetworker) NetInProtocolsArgsForCall(i int) (lager.Logger, string, uint32, uint32, []string) {
+	fake.netInProtocolsMutex.RLock()
+	defer fake.netInProtocolsMutex.RUnlock()
+	return fake.netInProtocolsArgsForCall[i].log, fake.netInProtocolsArgsForCall[i].handle, fake.netInProtocolsArgsForCall[i].hostPort, fake.netInProtocolsArgsForCall[i].containerPort, fake.netInProtocolsArgsForCall[i].protocols
+}
+
+func (fake *FakeProtocolNetworker) NetInProtocolsReturns(result1 uint32, result2 uint32, result3 error) {
+	fake.NetInProtocolsStub = nil
+	fake.netInProtocolsReturns = struct {
+		result1 uint32
+		result2 uint32
+		result3 error
+	}{result1, result2, result3}
+}
+
+func (fake *FakeProtocolNetworker) NetInRemove(log lager.Logger, handle string, hostPort uint32) error {
+	fake.netInRemoveMutex.Lock()
+	fake.netInRemoveArgsForCall = append(fake.netInRemoveArgsForCall, struct {
+		log      lager.Logger
+		handle   string
+		hostPort uint32
+	}{log, handle, hostPort})
+	fake.netInRemoveMutex.Unlock()
+	if fake.NetInRemoveStub != nil {
+		return fake.NetInRemoveStub(log, handle, hostPort)
+	} else {
+		return fake.netInRemoveReturns.result1
+	}
+}
+
+func (fake *FakeProtocolNetworker) NetInRemoveCallCount() int {
+	fake.netInRemoveMutex.RLock()
+	defer fake.netInRemoveMutex.RUnlock()
+	return len(fake.netInRemoveArgsForCall)
+}
+
+func (fake *FakeProtocolNetworker) NetInRemoveArgsForCall(i int) (lager.Logger, string, uint32) {
+	fake.netInRemoveMutex.RLock()
+	defer fake.netInRemoveMutex.RUnlock()
+	return fake.netInRemoveArgsForCall[i].log, fake.netInRemoveArgsForCall[i].handle, fake.netInRemoveArgsForCall[i].hostPort
+}
+
+func (fake *FakeProtocolNetworker) NetInRemoveReturns(result1 error) {
+	fake.NetInRemoveStub = nil
+	fake.netInRemoveReturns = struct {
+		result1 error
+	}{result1}
+}
+
+var _ gardener.ProtocolNetworker = new(FakeProtocolNetworker)
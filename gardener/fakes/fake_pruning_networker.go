@@ -0,0 +1,56 @@
+// This file was generated by counterfeiter
+package fakes
+
+import (
+	"sync"
+
+	"github.com/cloudfoundry-incubator/guardian/gardener"
+	"github.com/pivotal-golang/lager"
+)
+
+type FakePruningNetworker struct {
+	PruneStub        func(log lager.Logger, handles []string) error
+	pruneMutex       sync.RWMutex
+	pruneArgsForCall []struct {
+		log     lager.Logger
+		handles []string
+	}
+	pruneReturns struct {
+		result1 error
+	}
+}
+
+func (fake *FakePruningNetworker) Prune(log lager.Logger, handles []string) error {
+	fake.pruneMutex.Lock()
+	fake.pruneArgsForCall = append(fake.pruneArgsForCall, struct {
+		log     lager.Logger
+		handles []string
+	}{log, handles})
+	fake.pruneMutex.Unlock()
+	if fake.PruneStub != nil {
+		return fake.PruneStub(log, handles)
+	} else {
+		return fake.pruneReturns.result1
+	}
+}
+
+func (fake *FakePruningNetworker) PruneCallCount() int {
+	fake.pruneMutex.RLock()
+	defer fake.pruneMutex.RUnlock()
+	return len(fake.pruneArgsForCall)
+}
+
+func (fake *FakePruningNetworker) PruneArgsForCall(i int) (lager.Logger, []string) {
+	fake.pruneMutex.RLock()
+	defer fake.pruneMutex.RUnlock()
+	return fake.pruneArgsForCall[i].log, fake.pruneArgsForCall[i].handles
+}
+
+func (fake *FakePruningNetworker) PruneReturns(result1 error) {
+	fake.PruneStub = nil
+	fake.pruneReturns = struct {
+		result1 error
+	}{result1}
+}
+
+var _ gardener.PruningNetworker = new(FakePruningNetworker)
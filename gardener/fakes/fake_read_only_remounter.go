@@ -0,0 +1,56 @@
+// This file was generated by counterfeiter
+package fakes
+
+import (
+	"sync"
+
+	"github.com/cloudfoundry-incubator/guardian/gardener"
+	"github.com/pivotal-golang/lager"
+)
+
+type FakeReadOnlyRemounter struct {
+	RemountReadOnlyStub        func(log lager.Logger, handle string) error
+	remountReadOnlyMutex       sync.RWMutex
+	remountReadOnlyArgsForCall []struct {
+		log    lager.Logger
+		handle string
+	}
+	remountReadOnlyReturns struct {
+		result1 error
+	}
+}
+
+func (fake *FakeReadOnlyRemounter) RemountReadOnly(log lager.Logger, handle string) error {
+	fake.remountReadOnlyMutex.Lock()
+	fake.remountReadOnlyArgsForCall = append(fake.remountReadOnlyArgsForCall, struct {
+		log    lager.Logger
+		handle string
+	}{log, handle})
+	fake.remountReadOnlyMutex.Unlock()
+	if fake.RemountReadOnlyStub != nil {
+		return fake.RemountReadOnlyStub(log, handle)
+	} else {
+		return fake.remountReadOnlyReturns.result1
+	}
+}
+
+func (fake *FakeReadOnlyRemounter) RemountReadOnlyCallCount() int {
+	fake.remountReadOnlyMutex.RLock()
+	defer fake.remountReadOnlyMutex.RUnlock()
+	return len(fake.remountReadOnlyArgsForCall)
+}
+
+func (fake *FakeReadOnlyRemounter) RemountReadOnlyArgsForCall(i int) (lager.Logger, string) {
+	fake.remountReadOnlyMutex.RLock()
+	defer fake.remountReadOnlyMutex.RUnlock()
+	return fake.remountReadOnlyArgsForCall[i].log, fake.remountReadOnlyArgsForCall[i].handle
+}
+
+func (fake *FakeReadOnlyRemounter) RemountReadOnlyReturns(result1 error) {
+	fake.RemountReadOnlyStub = nil
+	fake.remountReadOnlyReturns = struct {
+		result1 error
+	}{result1}
+}
+
+var _ gardener.ReadOnlyRemounter = new(FakeReadOnlyRemounter)
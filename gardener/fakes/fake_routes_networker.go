@@ -0,0 +1,64 @@
+// This file was generated by counterfeiter
+package fakes
+
+import (
+	"sync"
+
+	"github.com/cloudfoundry-incubator/guardian/gardener"
+	"github.com/pivotal-golang/lager"
+)
+
+type FakeRoutesNetworker struct {
+	HooksWithRoutesStub        func(log lager.Logger, handle, spec string, dns gardener.DNSSpec, routes []gardener.Route) (gardener.Hooks, error)
+	hooksWithRoutesMutex       sync.RWMutex
+	hooksWithRoutesArgsForCall []struct {
+		log    lager.Logger
+		handle string
+		spec   string
+		dns    gardener.DNSSpec
+		routes []gardener.Route
+	}
+	hooksWithRoutesReturns struct {
+		result1 gardener.Hooks
+		result2 error
+	}
+}
+
+func (fake *FakeRoutesNetworker) HooksWithRoutes(log lager.Logger, handle string, spec string, dns gardener.DNSSpec, routes []gardener.Route) (gardener.Hooks, error) {
+	fake.hooksWithRoutesMutex.Lock()
+	fake.hooksWithRoutesArgsForCall = append(fake.hooksWithRoutesArgsForCall, struct {
+		log    lager.Logger
+		handle string
+		spec   string
+		dns    gardener.DNSSpec
+		routes []gardener.Route
+	}{log, handle, spec, dns, routes})
+	fake.hooksWithRoutesMutex.Unlock()
+	if fake.HooksWithRoutesStub != nil {
+		return fake.HooksWithRoutesStub(log, handle, spec, dns, routes)
+	} else {
+		return fake.hooksWithRoutesReturns.result1, fake.hooksWithRoutesReturns.result2
+	}
+}
+
+func (fake *FakeRoutesNetworker) HooksWithRoutesCallCount() int {
+	fake.hooksWithRoutesMutex.RLock()
+	defer fake.hooksWithRoutesMutex.RUnlock()
+	return len(fake.hooksWithRoutesArgsForCall)
+}
+
+func (fake *FakeRoutesNetworker) HooksWithRoutesArgsForCall(i int) (lager.Logger, string, string, gardener.DNSSpec, []gardener.Route) {
+	fake.hooksWithRoutesMutex.RLock()
+	defer fake.hooksWithRoutesMutex.RUnlock()
+	return fake.hooksWithRoutesArgsForCall[i].log, fake.hooksWithRoutesArgsForCall[i].handle, fake.hooksWithRoutesArgsForCall[i].spec, fake.hooksWithRoutesArgsForCall[i].dns, fake.hooksWithRoutesArgsForCall[i].routes
+}
+
+func (fake *FakeRoutesNetworker) HooksWithRoutesReturns(result1 gardener.Hooks, result2 error) {
+	fake.HooksWithRoutesStub = nil
+	fake.hooksWithRoutesReturns = struct {
+		result1 gardener.Hooks
+		result2 error
+	}{result1, result2}
+}
+
+var _ gardener.RoutesNetworker = new(FakeRoutesNetworker)
@@ -0,0 +1,58 @@
+// This file was generated by counterfeiter
+package fakes
+
+import (
+	"sync"
+
+	"github.com/cloudfoundry-incubator/guardian/gardener"
+	"github.com/pivotal-golang/lager"
+)
+
+type FakeRuntimeInspector struct {
+	InspectStub        func(log lager.Logger, handle string) (gardener.ContainerRuntime, error)
+	inspectMutex       sync.RWMutex
+	inspectArgsForCall []struct {
+		log    lager.Logger
+		handle string
+	}
+	inspectReturns struct {
+		result1 gardener.ContainerRuntime
+		result2 error
+	}
+}
+
+func (fake *FakeRuntimeInspector) Inspect(log lager.Logger, handle string) (gardener.ContainerRuntime, error) {
+	fake.inspectMutex.Lock()
+	fake.inspectArgsForCall = append(fake.inspectArgsForCall, struct {
+		log    lager.Logger
+		handle string
+	}{log, handle})
+	fake.inspectMutex.Unlock()
+	if fake.InspectStub != nil {
+		return fake.InspectStub(log, handle)
+	} else {
+		return fake.inspectReturns.result1, fake.inspectReturns.result2
+	}
+}
+
+func (fake *FakeRuntimeInspector) InspectCallCount() int {
+	fake.inspectMutex.RLock()
+	defer fake.inspectMutex.RUnlock()
+	return len(fake.inspectArgsForCall)
+}
+
+func (fake *FakeRuntimeInspector) InspectArgsForCall(i int) (lager.Logger, string) {
+	fake.inspectMutex.RLock()
+	defer fake.inspectMutex.RUnlock()
+	return fake.inspectArgsForCall[i].log, fake.inspectArgsForCall[i].handle
+}
+
+func (fake *FakeRuntimeInspector) InspectReturns(result1 gardener.ContainerRuntime, result2 error) {
+	fake.InspectStub = nil
+	fake.inspectReturns = struct {
+		result1 gardener.ContainerRuntime
+		result2 error
+	}{result1, result2}
+}
+
+var _ gardener.RuntimeInspector = new(FakeRuntimeInspector)
@@ -0,0 +1,102 @@
+// This file was generated by counterfeiter
+package fakes
+
+import (
+	"sync"
+
+	"github.com/cloudfoundry-incubator/guardian/gardener"
+	"github.com/pivotal-golang/lager"
+)
+
+type FakeSocketRelay struct {
+	RelayStub        func(log lager.Logger, handle, socket string) (string, error)
+	relayMutex       sync.RWMutex
+	relayArgsForCall []struct {
+		log    lager.Logger
+		handle string
+		socket string
+	}
+	relayReturns struct {
+		result1 string
+		result2 error
+	}
+	StopStub        func(log lager.Logger, handle string) error
+	stopMutex       sync.RWMutex
+	stopArgsForCall []struct {
+		log    lager.Logger
+		handle string
+	}
+	stopReturns struct {
+		result1 error
+	}
+}
+
+func (fake *FakeSocketRelay) Relay(log lager.Logger, handle string, socket string) (string, error) {
+	fake.relayMutex.Lock()
+	fake.relayArgsForCall = append(fake.relayArgsForCall, struct {
+		log    lager.Logger
+		handle string
+		socket string
+	}{log, handle, socket})
+	fake.relayMutex.Unlock()
+	if fake.RelayStub != nil {
+		return fake.RelayStub(log, handle, socket)
+	} else {
+		return fake.relayReturns.result1, fake.relayReturns.result2
+	}
+}
+
+func (fake *FakeSocketRelay) RelayCallCount() int {
+	fake.relayMutex.RLock()
+	defer fake.relayMutex.RUnlock()
+	return len(fake.relayArgsForCall)
+}
+
+func (fake *FakeSocketRelay) RelayArgsForCall(i int) (lager.Logger, string, string) {
+	fake.relayMutex.RLock()
+	defer fake.relayMutex.RUnlock()
+	return fake.relayArgsForCall[i].log, fake.relayArgsForCall[i].handle, fake.relayArgsForCall[i].socket
+}
+
+func (fake *FakeSocketRelay) RelayReturns(result1 string, result2 error) {
+	fake.RelayStub = nil
+	fake.relayReturns = struct {
+		result1 string
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeSocketRelay) Stop(log lager.Logger, handle string) error {
+	fake.stopMutex.Lock()
+	fake.stopArgsForCall = append(fake.stopArgsForCall, struct {
+		log    lager.Logger
+		handle string
+	}{log, handle})
+	fake.stopMutex.Unlock()
+	if fake.StopStub != nil {
+		return fake.StopStub(log, handle)
+	} else {
+		return fake.stopReturns.result1
+	}
+}
+
+func (fake *FakeSocketRelay) StopCallCount() int {
+	fake.stopMutex.RLock()
+	defer fake.stopMutex.RUnlock()
+	return len(fake.stopArgsForCall)
+}
+
+func (fake *FakeSocketRelay) StopArgsForCall(i int) (lager.Logger, string) {
+	fake.stopMutex.RLock()
+	defer fake.stopMutex.RUnlock()
+	return fake.stopArgsForCall[i].log, fake.stopArgsForCall[i].handle
+}
+
+func (fake *FakeSocketRelay) StopReturns(result1 error) {
+	fake.StopStub = nil
+	fake.stopReturns = struct {
+		result1 error
+	}{result1}
+}
+
+var _ gardener.SocketRelay = new(FakeSocketRelay)
@@ -0,0 +1,98 @@
+// This file was generated by counterfeiter
+package fakes
+
+import (
+	"sync"
+
+	"github.com/cloudfoundry-incubator/guardian/gardener"
+	"github.com/pivotal-golang/lager"
+)
+
+type FakeStagedContainerizer struct {
+	CreateBundleStub        func(log lager.Logger, spec gardener.DesiredContainerSpec) error
+	createBundleMutex       sync.RWMutex
+	createBundleArgsForCall []struct {
+		log  lager.Logger
+		spec gardener.DesiredContainerSpec
+	}
+	createBundleReturns struct {
+		result1 error
+	}
+	StartBundleStub        func(log lager.Logger, handle string) error
+	startBundleMutex       sync.RWMutex
+	startBundleArgsForCall []struct {
+		log    lager.Logger
+		handle string
+	}
+	startBundleReturns struct {
+		result1 error
+	}
+}
+
+func (fake *FakeStagedContainerizer) CreateBundle(log lager.Logger, spec gardener.DesiredContainerSpec) error {
+	fake.createBundleMutex.Lock()
+	fake.createBundleArgsForCall = append(fake.createBundleArgsForCall, struct {
+		log  lager.Logger
+		spec gardener.DesiredContainerSpec
+	}{log, spec})
+	fake.createBundleMutex.Unlock()
+	if fake.CreateBundleStub != nil {
+		return fake.CreateBundleStub(log, spec)
+	} else {
+		return fake.createBundleReturns.result1
+	}
+}
+
+func (fake *FakeStagedContainerizer) CreateBundleCallCount() int {
+	fake.createBundleMutex.RLock()
+	defer fake.createBundleMutex.RUnlock()
+	return len(fake.createBundleArgsForCall)
+}
+
+func (fake *FakeStagedContainerizer) CreateBundleArgsForCall(i int) (lager.Logger, gardener.DesiredContainerSpec) {
+	fake.createBundleMutex.RLock()
+	defer fake.createBundleMutex.RUnlock()
+	return fake.createBundleArgsForCall[i].log, fake.createBundleArgsForCall[i].spec
+}
+
+func (fake *FakeStagedContainerizer) CreateBundleReturns(result1 error) {
+	fake.CreateBundleStub = nil
+	fake.createBundleReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeStagedContainerizer) StartBundle(log lager.Logger, handle string) error {
+	fake.startBundleMutex.Lock()
+	fake.startBundleArgsForCall = append(fake.startBundleArgsForCall, struct {
+		log    lager.Logger
+		handle string
+	}{log, handle})
+	fake.startBundleMutex.Unlock()
+	if fake.StartBundleStub != nil {
+		return fake.StartBundleStub(log, handle)
+	} else {
+		return fake.startBundleReturns.result1
+	}
+}
+
+func (fake *FakeStagedContainerizer) StartBundleCallCount() int {
+	fake.startBundleMutex.RLock()
+	defer fake.startBundleMutex.RUnlock()
+	return len(fake.startBundleArgsForCall)
+}
+
+func (fake *FakeStagedContainerizer) StartBundleArgsForCall(i int) (lager.Logger, string) {
+	fake.startBundleMutex.RLock()
+	defer fake.startBundleMutex.RUnlock()
+	return fake.startBundleArgsForCall[i].log, fake.startBundleArgsForCall[i].handle
+}
+
+func (fake *FakeStagedContainerizer) StartBundleReturns(result1 error) {
+	fake.StartBundleStub = nil
+	fake.startBundleReturns = struct {
+		result1 error
+	}{result1}
+}
+
+var _ gardener.StagedContainerizer = new(FakeStagedContainerizer)
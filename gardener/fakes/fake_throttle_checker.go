@@ -0,0 +1,53 @@
+// This file was generated by counterfeiter
+package fakes
+
+import (
+	"sync"
+
+	"github.com/cloudfoundry-incubator/guardian/gardener"
+)
+
+type FakeThrottleChecker struct {
+	IsThrottledStub        func(handle string) bool
+	isThrottledMutex       sync.RWMutex
+	isThrottledArgsForCall []struct {
+		handle string
+	}
+	isThrottledReturns struct {
+		result1 bool
+	}
+}
+
+func (fake *FakeThrottleChecker) IsThrottled(handle string) bool {
+	fake.isThrottledMutex.Lock()
+	fake.isThrottledArgsForCall = append(fake.isThrottledArgsForCall, struct {
+		handle string
+	}{handle})
+	fake.isThrottledMutex.Unlock()
+	if fake.IsThrottledStub != nil {
+		return fake.IsThrottledStub(handle)
+	} else {
+		return fake.isThrottledReturns.result1
+	}
+}
+
+func (fake *FakeThrottleChecker) IsThrottledCallCount() int {
+	fake.isThrottledMutex.RLock()
+	defer fake.isThrottledMutex.RUnlock()
+	return len(fake.isThrottledArgsForCall)
+}
+
+func (fake *FakeThrottleChecker) IsThrottledArgsForCall(i int) string {
+	fake.isThrottledMutex.RLock()
+	defer fake.isThrottledMutex.RUnlock()
+	return fake.isThrottledArgsForCall[i].handle
+}
+
+func (fake *FakeThrottleChecker) IsThrottledReturns(result1 bool) {
+	fake.IsThrottledStub = nil
+	fake.isThrottledReturns = struct {
+		result1 bool
+	}{result1}
+}
+
+var _ gardener.ThrottleChecker = new(FakeThrottleChecker)
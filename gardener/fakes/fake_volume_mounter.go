@@ -0,0 +1,104 @@
+// This file was generated by counterfeiter
+package fakes
+
+import (
+	"sync"
+
+	"github.com/cloudfoundry-incubator/guardian/gardener"
+	"github.com/pivotal-golang/lager"
+)
+
+type FakeVolumeMounter struct {
+	MountStub        func(log lager.Logger, handle string, volume gardener.Volume) (string, error)
+	mountMutex       sync.RWMutex
+	mountArgsForCall []struct {
+		log    lager.Logger
+		handle string
+		volume gardener.Volume
+	}
+	mountReturns struct {
+		result1 string
+		result2 error
+	}
+	UnmountStub        func(log lager.Logger, handle string, volume gardener.Volume) error
+	unmountMutex       sync.RWMutex
+	unmountArgsForCall []struct {
+		log    lager.Logger
+		handle string
+		volume gardener.Volume
+	}
+	unmountReturns struct {
+		result1 error
+	}
+}
+
+func (fake *FakeVolumeMounter) Mount(log lager.Logger, handle string, volume gardener.Volume) (string, error) {
+	fake.mountMutex.Lock()
+	fake.mountArgsForCall = append(fake.mountArgsForCall, struct {
+		log    lager.Logger
+		handle string
+		volume gardener.Volume
+	}{log, handle, volume})
+	fake.mountMutex.Unlock()
+	if fake.MountStub != nil {
+		return fake.MountStub(log, handle, volume)
+	} else {
+		return fake.mountReturns.result1, fake.mountReturns.result2
+	}
+}
+
+func (fake *FakeVolumeMounter) MountCallCount() int {
+	fake.mountMutex.RLock()
+	defer fake.mountMutex.RUnlock()
+	return len(fake.mountArgsForCall)
+}
+
+func (fake *FakeVolumeMounter) MountArgsForCall(i int) (lager.Logger, string, gardener.Volume) {
+	fake.mountMutex.RLock()
+	defer fake.mountMutex.RUnlock()
+	return fake.mountArgsForCall[i].log, fake.mountArgsForCall[i].handle, fake.mountArgsForCall[i].volume
+}
+
+func (fake *FakeVolumeMounter) MountReturns(result1 string, result2 error) {
+	fake.MountStub = nil
+	fake.mountReturns = struct {
+		result1 string
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeVolumeMounter) Unmount(log lager.Logger, handle string, volume gardener.Volume) error {
+	fake.unmountMutex.Lock()
+	fake.unmountArgsForCall = append(fake.unmountArgsForCall, struct {
+		log    lager.Logger
+		handle string
+		volume gardener.Volume
+	}{log, handle, volume})
+	fake.unmountMutex.Unlock()
+	if fake.UnmountStub != nil {
+		return fake.UnmountStub(log, handle, volume)
+	} else {
+		return fake.unmountReturns.result1
+	}
+}
+
+func (fake *FakeVolumeMounter) UnmountCallCount() int {
+	fake.unmountMutex.RLock()
+	defer fake.unmountMutex.RUnlock()
+	return len(fake.unmountArgsForCall)
+}
+
+func (fake *FakeVolumeMounter) UnmountArgsForCall(i int) (lager.Logger, string, gardener.Volume) {
+	fake.unmountMutex.RLock()
+	defer fake.unmountMutex.RUnlock()
+	return fake.unmountArgsForCall[i].log, fake.unmountArgsForCall[i].handle, fake.unmountArgsForCall[i].volume
+}
+
+func (fake *FakeVolumeMounter) UnmountReturns(result1 error) {
+	fake.UnmountStub = nil
+	fake.unmountReturns = struct {
+		result1 error
+	}{result1}
+}
+
+var _ gardener.VolumeMounter = new(FakeVolumeMounter)
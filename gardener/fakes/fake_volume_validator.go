@@ -0,0 +1,61 @@
+// This file was generated by counterfeiter
+package fakes
+
+import (
+	"sync"
+
+	"github.com/cloudfoundry-incubator/garden-shed/rootfs_provider"
+	"github.com/cloudfoundry-incubator/guardian/gardener"
+	"github.com/pivotal-golang/lager"
+)
+
+type FakeVolumeValidator struct {
+	ValidateVolumeStub        func(log lager.Logger, spec rootfs_provider.Spec, idMappings *gardener.IDMappings, layers []string) error
+	validateVolumeMutex       sync.RWMutex
+	validateVolumeArgsForCall []struct {
+		log        lager.Logger
+		spec       rootfs_provider.Spec
+		idMappings *gardener.IDMappings
+		layers     []string
+	}
+	validateVolumeReturns struct {
+		result1 error
+	}
+}
+
+func (fake *FakeVolumeValidator) ValidateVolume(log lager.Logger, spec rootfs_provider.Spec, idMappings *gardener.IDMappings, layers []string) error {
+	fake.validateVolumeMutex.Lock()
+	fake.validateVolumeArgsForCall = append(fake.validateVolumeArgsForCall, struct {
+		log        lager.Logger
+		spec       rootfs_provider.Spec
+		idMappings *gardener.IDMappings
+		layers     []string
+	}{log, spec, idMappings, layers})
+	fake.validateVolumeMutex.Unlock()
+	if fake.ValidateVolumeStub != nil {
+		return fake.ValidateVolumeStub(log, spec, idMappings, layers)
+	} else {
+		return fake.validateVolumeReturns.result1
+	}
+}
+
+func (fake *FakeVolumeValidator) ValidateVolumeCallCount() int {
+	fake.validateVolumeMutex.RLock()
+	defer fake.validateVolumeMutex.RUnlock()
+	return len(fake.validateVolumeArgsForCall)
+}
+
+func (fake *FakeVolumeValidator) ValidateVolumeArgsForCall(i int) (lager.Logger, rootfs_provider.Spec, *gardener.IDMappings, []string) {
+	fake.validateVolumeMutex.RLock()
+	defer fake.validateVolumeMutex.RUnlock()
+	return fake.validateVolumeArgsForCall[i].log, fake.validateVolumeArgsForCall[i].spec, fake.validateVolumeArgsForCall[i].idMappings, fake.validateVolumeArgsForCall[i].layers
+}
+
+func (fake *FakeVolumeValidator) ValidateVolumeReturns(result1 error) {
+	fake.ValidateVolumeStub = nil
+	fake.validateVolumeReturns = struct {
+		result1 error
+	}{result1}
+}
+
+var _ gardener.VolumeValidator = new(FakeVolumeValidator)
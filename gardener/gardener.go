@@ -1,8 +1,11 @@
 package gardener
 
 import (
+	"encoding/json"
+	"fmt"
 	"io"
-	"net/url"
+	"strconv"
+	"sync"
 	"time"
 
 	"github.com/cloudfoundry-incubator/garden"
@@ -15,12 +18,51 @@ import (
 //go:generate counterfeiter . Networker
 //go:generate counterfeiter . VolumeCreator
 //go:generate counterfeiter . UidGenerator
+//go:generate counterfeiter . PeaRunner
 
 const ContainerIPKey = "garden.network.container-ip"
+const ContainerIPv6Key = "garden.network.container-ipv6"
 const BridgeIPKey = "garden.network.host-ip"
 const ExternalIPKey = "garden.network.external-ip"
 const MappedPortsKey = "garden.network.mapped-ports"
 
+// NetOutRulesKey is the property in which the networker records the NetOut
+// rules applied to a container. It keeps the name kawasaki first recorded
+// the rules under, so that those of existing containers are still found.
+const NetOutRulesKey = "kawasaki.net-out-rules"
+
+// MaxPidsProperty is the container property which may hold the maximum
+// number of pids (processes and threads) the container may have at once
+const MaxPidsProperty = "max-pids"
+
+// UmaskProperty is the container property which may hold the octal umask
+// processes in the container are run with, e.g. "0027"
+const UmaskProperty = "umask"
+
+// RestartPolicyProperty is the container property which may hold when the
+// container's init process is re-run after it exits: RestartNever (the
+// default), RestartOnFailure or RestartAlways. It can only be set when the
+// container is created.
+const RestartPolicyProperty = "restart-policy"
+
+const (
+	// RestartNever leaves the container's init process exited
+	RestartNever = "never"
+
+	// RestartOnFailure re-runs the init process when it exits non-zero, or
+	// its exit status is lost
+	RestartOnFailure = "on-failure"
+
+	// RestartAlways re-runs the init process whenever it exits, until the
+	// container is destroyed
+	RestartAlways = "always"
+)
+
+// NetInPortRangeProperty is the container property which may hold a range of
+// host ports, e.g. "61000-61099", from which NetIn picks a host port when
+// none is given. It is read by each NetIn, so may be changed between them.
+const NetInPortRangeProperty = "net-in-port-range"
+
 type SysInfoProvider interface {
 	TotalMemory() (uint64, error)
 	TotalDisk() (uint64, error)
@@ -31,9 +73,14 @@ type Containerizer interface {
 	StreamIn(log lager.Logger, handle string, spec garden.StreamInSpec) error
 	StreamOut(log lager.Logger, handle string, spec garden.StreamOutSpec) (io.ReadCloser, error)
 	Run(log lager.Logger, handle string, spec garden.ProcessSpec, io garden.ProcessIO) (garden.Process, error)
+	Attach(log lager.Logger, handle, processID string, io garden.ProcessIO) (garden.Process, error)
 	Destroy(log lager.Logger, handle string) error
 	Info(log lager.Logger, handle string) (ActualContainerSpec, error)
 	Handles() ([]string, error)
+	Checkpoint(log lager.Logger, handle, destination string) error
+	Restore(log lager.Logger, handle, source string) error
+	AddBindMount(log lager.Logger, handle string, mount garden.BindMount) error
+	RemoveBindMount(log lager.Logger, handle, dstPath string) error
 }
 
 type Networker interface {
@@ -42,6 +89,10 @@ type Networker interface {
 	Destroy(log lager.Logger, handle string) error
 	NetIn(log lager.Logger, handle string, hostPort, containerPort uint32) (uint32, uint32, error)
 	NetOut(log lager.Logger, handle string, rule garden.NetOutRule) error
+	BulkNetOut(log lager.Logger, handle string, rules []garden.NetOutRule) error
+	Checkpoint(log lager.Logger, handle string) error
+	Restore(log lager.Logger, handle string) error
+	Recover(log lager.Logger, handle string) error
 }
 
 type VolumeCreator interface {
@@ -64,6 +115,22 @@ type PropertyManager interface {
 	DestroyKeySpace(string) error
 }
 
+//go:generate counterfeiter . MetricsRecorder
+
+type MetricsRecorder interface {
+	ContainerCreated(duration time.Duration)
+	ContainerDestroyed()
+
+	// ContainerCreateQueued records how long a create waited in the
+	// CreateQueue
+	ContainerCreateQueued(wait time.Duration)
+
+	// ContainerCreateFailed and ContainerDestroyFailed count failures by the
+	// stage which failed and the class of the failure
+	ContainerCreateFailed(stage, class string)
+	ContainerDestroyFailed(stage, class string)
+}
+
 type Starter interface {
 	Start() error
 }
@@ -101,7 +168,37 @@ type DesiredContainerSpec struct {
 
 	Limits garden.Limits
 
+	// The container's own user namespace id mappings (nil means the server
+	// default)
+	IDMappings *IDMappings
+
+	// Maximum number of pids in the container's pids cgroup (0 means the
+	// server default)
+	MaxPids int64
+
+	// Octal umask processes in the container, including its init process,
+	// are run with ("" means they inherit the server's umask)
+	Umask string
+
+	// Hard cap on the container's CPU time (the zero value means no cap)
+	CPUQuota CPUQuota
+
+	// CPUs and NUMA memory nodes the container is pinned to (the zero value
+	// means no restriction)
+	CPUSet CPUSet
+
+	// When the container's init process is re-run after it exits ("" means
+	// RestartNever)
+	RestartPolicy string
+
 	Env []string
+
+	// Properties the container was created with
+	Properties garden.Properties
+
+	// DryRun is set when the spec is only being validated: nothing may be
+	// created or changed for it
+	DryRun bool
 }
 
 type ActualContainerSpec struct {
@@ -111,6 +208,9 @@ type ActualContainerSpec struct {
 	// Whether the container is stopped
 	Stopped bool
 
+	// Events which have happened to the container, e.g. "out of memory"
+	Events []string
+
 	ProcessIDs []string
 }
 
@@ -138,94 +238,624 @@ type Gardener struct {
 
 	// PropertyManager creates map of container properties
 	PropertyManager PropertyManager
+
+	// ChangeLog records container changes for delta listings (optional)
+	ChangeLog *ChangeLog
+
+	// Events receives container lifecycle events (optional)
+	Events *EventHub
+
+	// Metrics records container lifecycle metrics (optional)
+	Metrics MetricsRecorder
+
+	// ProcessLimiter caps the number of active processes per container (optional)
+	ProcessLimiter *ProcessLimiter
+
+	// ExecLimiter caps the number of processes started by Run and Attach
+	// which are running in each container (optional)
+	ExecLimiter *ExecLimiter
+
+	// Exits records why processes exit, in their process-exit events,
+	// through ExitStatusWaiter and for ProcessExitHandler (optional)
+	Exits *ExitTracker
+
+	// OutputLimiter caps the rate at which each container's processes write
+	// to stdout and stderr (optional)
+	OutputLimiter *OutputLimiter
+
+	// CreateQueue caps the number of containers created at once (optional)
+	CreateQueue *CreateQueue
+
+	// CreateTimeouts bound the time each stage of a create may take
+	CreateTimeouts CreateTimeouts
+
+	// DestroyQueue destroys containers in the background, so that Destroy
+	// returns as soon as the container is marked as destroying (optional; if
+	// unset Destroy returns once the container is destroyed)
+	DestroyQueue *DestroyQueue
+
+	// CPULimiter changes the CPU limits of running containers, and their
+	// memory and pid limits if it is a Limiter (optional; if unset LimitCPU
+	// has no effect)
+	CPULimiter CPULimiter
+
+	// RuntimeInspector reads the runtime state of containers for
+	// InspectRuntime (optional)
+	RuntimeInspector RuntimeInspector
+
+	// VolumeMounter mounts the volumes containers ask for in their
+	// VolumesProperty (optional)
+	VolumeMounter VolumeMounter
+
+	// CPUSets pins containers which do not choose their own CPUs (optional)
+	CPUSets *CPUSetAllocator
+
+	// OnlineCPUs are the CPUs and NUMA memory nodes containers may choose to
+	// be pinned to (optional; if unset any ids are allowed)
+	OnlineCPUs OnlineCPUs
+
+	// Ages reports the current age of each container in its AgeProperty,
+	// e.g. an AgeTracker (optional)
+	Ages AgeReader
+
+	// Throttles reports whether each container is throttled in its
+	// CPUThrottledProperty (optional)
+	Throttles ThrottleChecker
+
+	// ReservedHandles name guardian's own cgroups, e.g. the CPUThrottler's
+	// throttled cgroup, which are siblings of the containers' cgroups, so
+	// containers may not be created with them
+	ReservedHandles []string
+
+	// EgressPolicy holds NetOut rules applied to every new container (optional)
+	EgressPolicy *EgressPolicy
+
+	// Annotator adds selected container properties to the container's logs
+	// (optional)
+	Annotator *Annotator
+
+	// ImageVerifier checks the signatures of containers' images before they
+	// are created (optional)
+	ImageVerifier ImageVerifier
+
+	// RequireImageSignatures refuses to create containers whose images the
+	// ImageVerifier cannot verify, rather than only logging them
+	RequireImageSignatures bool
+
+	// IDMappings are the default user namespace mappings of unprivileged
+	// containers. Containers may choose their own mappings within these
+	// (optional; if unset containers may not choose their own mappings)
+	IDMappings *IDMappings
+
+	// SocketRelay relays the sockets containers ask for in their
+	// MetricsSocketProperty to the host (optional)
+	SocketRelay SocketRelay
+
+	// DefaultGraceTime is the grace time of containers created without one
+	// (optional)
+	DefaultGraceTime *DefaultGraceTime
+
+	// LayerDir holds the base layers containers may build their rootfses on
+	// with the RootFSLayersProperty (optional; if unset containers may not
+	// have base layers)
+	LayerDir string
+
+	// CheckpointDir holds checkpoints exported by Checkpoint, each in the
+	// directory named by its destination (optional; if unset checkpoints
+	// cannot be exported)
+	CheckpointDir string
+
+	// MaxPids caps the max-pids property of containers, which may not remove
+	// their pid limit while it is set (optional)
+	MaxPids int64
+
+	// DefaultUmask is the 4 digit octal umask of containers created without
+	// the umask property (optional; if unset their processes inherit the
+	// server's umask)
+	DefaultUmask string
+
+	// AllowContainerRunners allows privileged containers of the
+	// container-runner type, which may run containers of their own
+	AllowContainerRunners bool
+
+	// Rootless is set when guardian runs as an unprivileged user, which
+	// cannot create privileged containers
+	Rootless bool
+
+	// peasMu serialises changes to containers' PeasKey
+	peasMu sync.Mutex
+
+	// reserved holds the handles of containers which are being created, or
+	// whose timed out creates are still being rolled back
+	reservedMu sync.Mutex
+	reserved   map[string]bool
 }
 
 func (g *Gardener) Create(spec garden.ContainerSpec) (garden.Container, error) {
-	log := g.Logger.Session("create")
+	log := g.Annotator.SpecLogger(g.Logger, spec).Session("create")
+	start := time.Now()
 
 	if spec.Handle == "" {
 		spec.Handle = g.UidGenerator.Generate()
 	}
 
-	hooks, err := g.Networker.Hooks(log, spec.Handle, spec.Network)
-	if err != nil {
+	pinned := false
+	fail := func(stage, class string, err error) (garden.Container, error) {
+		if pinned {
+			g.CPUSets.Release(spec.Handle)
+		}
+
+		g.createFailed(stage, FailureClass(err, class))
 		return nil, err
 	}
 
-	rootFSURL, err := url.Parse(spec.RootFSPath)
+	parsed, err := g.parseCreateSpec(spec)
+	if err != nil {
+		return fail(StageSpec, FailureInvalidSpec, err)
+	}
+
+	if maxPids := capMaxPids(parsed.maxPids, g.MaxPids); maxPids != parsed.maxPids {
+		parsed.maxPids = maxPids
+		spec.Properties = withProperty(spec.Properties, MaxPidsProperty, strconv.FormatInt(maxPids, 10))
+	}
+
+	// the handle is held until any stages which time out have been rolled
+	// back, so that a retried create's container is not rolled back with them
+	if !g.reserveHandle(spec.Handle) {
+		return fail(StageSpec, FailureInvalidSpec, HandleInUseError{Handle: spec.Handle})
+	}
+
+	stages := &createStages{log: log}
+	defer stages.afterRollbacks(func() { g.releaseHandle(spec.Handle) })
+
+	if parsed.rootFSURL, err = g.verifyImage(log, parsed.rootFSURL); err != nil {
+		return fail(StageImage, FailureUntrustedImage, err)
+	}
+
+	wait := g.CreateQueue.Enter()
+	defer g.CreateQueue.Leave()
+	if g.Metrics != nil && g.CreateQueue != nil {
+		g.Metrics.ContainerCreateQueued(wait)
+	}
+
+	if parsed.cpuSet.CPUs != "" {
+		pinned = g.CPUSets.Pin(spec.Handle, parsed.cpuSet.CPUs)
+	} else if cpus := g.CPUSets.Allocate(spec.Handle); cpus != "" {
+		pinned = true
+		parsed.cpuSet.CPUs = cpus
+		spec.Properties = withProperty(spec.Properties, CPUSetCPUsProperty, cpus)
+	}
+
+	// the network and the rootfs do not depend on each other, and pulling
+	// the rootfs is usually the slowest part of a create
+	var (
+		hooks      Hooks
+		hooksErr   error
+		rootFSPath string
+		env        []string
+		wg         sync.WaitGroup
+	)
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		hooksErr = stages.run(StageNetwork, g.CreateTimeouts.Network, func() error {
+			var err error
+			hooks, err = g.networkHooks(log, spec.Handle, spec.Network, parsed.dns, parsed.routes)
+			return err
+		}, func() { g.Networker.Destroy(g.Logger, spec.Handle) })
+	}()
+
+	volumeErr := stages.run(StageImage, g.CreateTimeouts.Image, func() error {
+		var err error
+		rootFSPath, env, err = g.createVolume(log, spec.Handle, parsed.volumeSpec(spec), parsed.idMappings, parsed.layers)
+		return err
+	}, func() { g.destroyVolume(log, spec.Handle) })
+	wg.Wait()
+
+	if hooksErr != nil {
+		if volumeErr == nil {
+			g.destroyVolume(log, spec.Handle)
+		}
+
+		return fail(StageNetwork, FailureNetwork, hooksErr)
+	}
+
+	if volumeErr != nil {
+		g.Networker.Destroy(g.Logger, spec.Handle)
+		return fail(StageImage, FailureImagePull, volumeErr)
+	}
+
+	imageConfig, err := g.imageConfig(log, spec.Handle)
 	if err != nil {
 		g.Networker.Destroy(g.Logger, spec.Handle)
-		return nil, err
+		g.destroyVolume(log, spec.Handle)
+		return fail(StageImage, FailureImagePull, fmt.Errorf("image config: %s", err))
 	}
 
-	rootFSPath, env, err := g.VolumeCreator.Create(log, spec.Handle, rootfs_provider.Spec{
-		RootFS:     rootFSURL,
-		QuotaSize:  int64(spec.Limits.Disk.ByteHard),
-		QuotaScope: spec.Limits.Disk.Scope,
-		Namespaced: !spec.Privileged,
-	})
+	volumeMounts, err := g.mountVolumes(log, spec.Handle, parsed.volumes)
 	if err != nil {
 		g.Networker.Destroy(g.Logger, spec.Handle)
-		return nil, err
+		g.destroyVolume(log, spec.Handle)
+		return fail(StageVolumes, FailureOther, err)
 	}
 
-	if err := g.Containerizer.Create(log, DesiredContainerSpec{
-		Handle:       spec.Handle,
-		RootFSPath:   rootFSPath,
-		NetworkHooks: hooks,
-		Privileged:   spec.Privileged,
-		BindMounts:   spec.BindMounts,
-		Limits:       spec.Limits,
-		Env:          append(env, spec.Env...),
-	}); err != nil {
+	desired := parsed.desiredSpec(spec, rootFSPath, hooks, env)
+	desired.BindMounts = append(append([]garden.BindMount{}, desired.BindMounts...), volumeMounts...)
+
+	if err := g.createContainer(log, stages, desired, parsed.volumes); err != nil {
+		if stage, ok := timedOutStage(err); ok {
+			// everything is rolled back once the stage returns
+			return fail(stage, FailureStageTimeout, err)
+		}
+
+		g.unmountVolumes(log, spec.Handle, parsed.volumes)
 		g.Networker.Destroy(g.Logger, spec.Handle)
+		g.destroyVolume(log, spec.Handle)
+		return fail(StageContainer, FailureOther, err)
+	}
+
+	if len(volumeMounts) > 0 {
+		// recorded now so that the volumes are unmounted if the container is
+		// destroyed before its properties are set
+		if err := g.recordVolumes(spec.Handle, parsed.volumes); err != nil {
+			log.Error("record-volumes-failed", err)
+		}
+	}
+
+	if rules := g.EgressPolicy.Rules(); len(rules) > 0 {
+		if err := g.Networker.BulkNetOut(log, spec.Handle, rules); err != nil {
+			log.Error("apply-egress-policy-failed", err)
+			if destroyErr := g.Destroy(spec.Handle); destroyErr != nil {
+				log.Error("destroy-failed", destroyErr)
+			}
+
+			return fail(StageEgress, FailureNetwork, fmt.Errorf("apply egress policy: %s", err))
+		}
+	}
+
+	if spec.Limits.Bandwidth != (garden.BandwidthLimits{}) {
+		if err := limitBandwidth(log, g.Networker, spec.Handle, spec.Limits.Bandwidth); err != nil {
+			log.Error("limit-bandwidth-failed", err)
+			if destroyErr := g.Destroy(spec.Handle); destroyErr != nil {
+				log.Error("destroy-failed", destroyErr)
+			}
+
+			return fail(StageNetwork, FailureNetwork, fmt.Errorf("limit bandwidth: %s", err))
+		}
+	}
+
+	if err := g.relayMetricsSocket(log, spec.Handle, parsed.metricsSocket); err != nil {
+		log.Error("relay-metrics-socket-failed", err)
+		if destroyErr := g.Destroy(spec.Handle); destroyErr != nil {
+			log.Error("destroy-failed", destroyErr)
+		}
+
+		return fail(StageRelay, FailureOther, err)
+	}
+
+	g.ChangeLog.Record(spec.Handle, ChangeCreated)
+	g.Events.Publish(Event{Handle: spec.Handle, Type: EventCreate})
+
+	// the container exists from here on, so it is destroyed if it cannot be
+	// finished, which fails the create
+	destroy := func(err error) (garden.Container, error) {
+		if destroyErr := g.Destroy(spec.Handle); destroyErr != nil {
+			log.Error("destroy-failed", destroyErr)
+		}
+
+		g.createFailed(StageProperties, FailureClass(err, FailureOther))
 		return nil, err
 	}
 
 	container, err := g.Lookup(spec.Handle)
 	if err != nil {
-		return nil, err
+		return destroy(err)
 	}
 
 	for name, value := range spec.Properties {
+		if createOnlyProperties[name] {
+			g.PropertyManager.Set(spec.Handle, name, value)
+			continue
+		}
+
 		err := container.SetProperty(name, value)
 		if err != nil {
-			return nil, err
+			log.Error("set-property-failed", err)
+			return destroy(err)
+		}
+	}
+
+	if !imageConfig.Empty() {
+		config, err := json.Marshal(imageConfig)
+		if err != nil {
+			return destroy(err)
+		}
+
+		if err := container.SetProperty(ImageConfigKey, string(config)); err != nil {
+			return destroy(err)
+		}
+	}
+
+	graceTime := spec.GraceTime
+	if graceTime == 0 {
+		graceTime = g.DefaultGraceTime.Get()
+	}
+
+	if graceTime != 0 {
+		if err := container.SetGraceTime(graceTime); err != nil {
+			return destroy(err)
 		}
 	}
 
+	if err := g.recordSpec(spec); err != nil {
+		return destroy(err)
+	}
+
+	if g.Metrics != nil {
+		g.Metrics.ContainerCreated(time.Since(start))
+	}
+
 	return container, nil
 }
 
+func (g *Gardener) createVolume(log lager.Logger, handle string, spec rootfs_provider.Spec, idMappings *IDMappings, layers []string) (string, []string, error) {
+	if len(layers) > 0 {
+		volumeCreator, ok := g.VolumeCreator.(LayeredVolumeCreator)
+		if !ok {
+			return "", nil, errLayersNotSupported
+		}
+
+		return volumeCreator.CreateLayered(log, handle, spec, idMappings, layers)
+	}
+
+	if idMappings == nil {
+		return g.VolumeCreator.Create(log, handle, spec)
+	}
+
+	volumeCreator, ok := g.VolumeCreator.(MappingVolumeCreator)
+	if !ok {
+		return "", nil, errMappingsNotSupported
+	}
+
+	return volumeCreator.CreateMapped(log, handle, spec, *idMappings)
+}
+
+// createContainer creates the container's bundle and starts it, in a
+// stage each if the Containerizer is a StagedContainerizer. If a stage times
+// out the container, its volumes, its network and its rootfs are all rolled
+// back once the stage returns.
+func (g *Gardener) createContainer(log lager.Logger, stages *createStages, spec DesiredContainerSpec, volumes []Volume) error {
+	rollback := func() {
+		if err := g.Containerizer.Destroy(log, spec.Handle); err != nil {
+			log.Error("destroy-container-failed", err)
+		}
+
+		g.unmountVolumes(log, spec.Handle, volumes)
+		g.Networker.Destroy(g.Logger, spec.Handle)
+		g.destroyVolume(log, spec.Handle)
+	}
+
+	staged, ok := g.Containerizer.(StagedContainerizer)
+	if !ok {
+		var timeout time.Duration
+		if g.CreateTimeouts.Bundle > 0 && g.CreateTimeouts.Runtime > 0 {
+			timeout = g.CreateTimeouts.Bundle + g.CreateTimeouts.Runtime
+		}
+
+		return stages.run(StageContainer, timeout, func() error {
+			return g.Containerizer.Create(log, spec)
+		}, rollback)
+	}
+
+	if err := stages.run(StageBundle, g.CreateTimeouts.Bundle, func() error {
+		return staged.CreateBundle(log, spec)
+	}, rollback); err != nil {
+		return err
+	}
+
+	return stages.run(StageRuntime, g.CreateTimeouts.Runtime, func() error {
+		return staged.StartBundle(log, spec.Handle)
+	}, rollback)
+}
+
+func (g *Gardener) reserveHandle(handle string) bool {
+	g.reservedMu.Lock()
+	defer g.reservedMu.Unlock()
+
+	if g.reserved[handle] {
+		return false
+	}
+
+	if g.reserved == nil {
+		g.reserved = map[string]bool{}
+	}
+
+	g.reserved[handle] = true
+	return true
+}
+
+func (g *Gardener) releaseHandle(handle string) {
+	g.reservedMu.Lock()
+	defer g.reservedMu.Unlock()
+
+	delete(g.reserved, handle)
+}
+
+// destroyVolume removes a volume whose container could not be created
+func (g *Gardener) destroyVolume(log lager.Logger, handle string) {
+	if err := g.VolumeCreator.Destroy(log, handle); err != nil {
+		log.Error("destroy-volume-failed", err)
+	}
+}
+
 func (g *Gardener) Lookup(handle string) (garden.Container, error) {
+	if g.DestroyQueue.Destroying(handle) {
+		return nil, garden.ContainerNotFoundError{Handle: handle}
+	}
+
+	return g.container(handle), nil
+}
+
+func (g *Gardener) container(handle string) *container {
 	return &container{
-		logger:          g.Logger,
+		logger:          g.Annotator.Logger(g.Logger, handle),
 		handle:          handle,
 		containerizer:   g.Containerizer,
 		networker:       g.Networker,
 		propertyManager: g.PropertyManager,
-	}, nil
+		changeLog:       g.ChangeLog,
+		events:          g.Events,
+		processLimiter:  g.ProcessLimiter,
+		execLimiter:     g.ExecLimiter,
+		exits:           g.Exits,
+		outputLimiter:   g.OutputLimiter,
+		cpuLimiter:      g.CPULimiter,
+		ages:            g.Ages,
+		throttles:       g.Throttles,
+		maxPids:         g.MaxPids,
+		runPea:          g.runPea,
+	}
 }
 
+// Destroy destroys the container, or, with a DestroyQueue, marks it as
+// destroying and leaves the queue to destroy it. Containers being destroyed
+// are not listed, and cannot be looked up.
 func (g *Gardener) Destroy(handle string) error {
-	if err := g.Containerizer.Destroy(g.Logger, handle); err != nil {
+	if g.DestroyQueue != nil {
+		if err := g.checkDestroyable(handle); err != nil {
+			return err
+		}
+
+		return g.DestroyQueue.Enqueue(handle, g.queuedDestroy(handle))
+	}
+
+	return g.destroy(handle, g.destroyFailed)
+}
+
+// ResumeDestroys carries on destroying the containers which were being
+// destroyed in the background when guardian stopped
+func (g *Gardener) ResumeDestroys() error {
+	if g.DestroyQueue == nil {
+		return nil
+	}
+
+	return g.DestroyQueue.Resume(g.queuedDestroy)
+}
+
+// checkDestroyable returns a ContainerNotFoundError, as a synchronous destroy
+// would, unless the container exists, or its destroy was given up on part way
+func (g *Gardener) checkDestroyable(handle string) error {
+	if g.DestroyQueue.Destroying(handle) {
+		return garden.ContainerNotFoundError{Handle: handle}
+	}
+
+	if g.DestroyQueue.GaveUp(handle) {
+		return nil
+	}
+
+	handles, err := g.Containerizer.Handles()
+	if err != nil {
+		return err
+	}
+
+	for _, h := range handles {
+		if h == handle {
+			return nil
+		}
+	}
+
+	return garden.ContainerNotFoundError{Handle: handle}
+}
+
+// queuedDestroy returns the cleanup of the container for the DestroyQueue to
+// retry. Its failure is only counted once, rather than for every retry.
+func (g *Gardener) queuedDestroy(handle string) func() error {
+	failed := false
+	return func() error {
+		return g.destroy(handle, func(stage, class string) {
+			if !failed {
+				failed = true
+				g.destroyFailed(stage, class)
+			}
+		})
+	}
+}
+
+func (g *Gardener) destroy(handle string, failed func(stage, class string)) error {
+	log := g.Annotator.Logger(g.Logger, handle)
+
+	g.Exits.ContainerDestroying(handle)
+	g.stopRelay(log, handle)
+	if err := g.Containerizer.Destroy(log, handle); err != nil {
+		g.Exits.ContainerDestroyFailed(handle)
+		failed(StageContainer, FailureClass(err, FailureRunc))
 		return err
 	}
+	g.destroyPeas(log, handle)
 
-	if err := g.Networker.Destroy(g.Logger, handle); err != nil {
+	if err := g.Networker.Destroy(log, handle); err != nil {
+		failed(StageNetwork, FailureClass(err, FailureNetwork))
 		return err
 	}
 
-	if err := g.VolumeCreator.Destroy(g.Logger, handle); err != nil {
+	if err := g.destroyVolumes(log, handle); err != nil {
+		failed(StageVolumes, FailureClass(err, FailureOther))
 		return err
 	}
 
-	return g.PropertyManager.DestroyKeySpace(handle)
+	if err := g.VolumeCreator.Destroy(log, handle); err != nil {
+		failed(StageImage, FailureClass(err, FailureOther))
+		return err
+	}
+
+	if err := g.PropertyManager.DestroyKeySpace(handle); err != nil {
+		failed(StageProperties, FailureClass(err, FailureOther))
+		return err
+	}
+
+	g.ProcessLimiter.Forget(handle)
+	g.ExecLimiter.Forget(handle)
+	g.OutputLimiter.Forget(handle)
+	g.CPUSets.Release(handle)
+	g.ChangeLog.Record(handle, ChangeDestroyed)
+	g.Events.Publish(Event{Handle: handle, Type: EventDestroy})
+	if g.Metrics != nil {
+		g.Metrics.ContainerDestroyed()
+	}
+	return nil
+}
+
+func (g *Gardener) createFailed(stage, class string) {
+	if g.Metrics != nil {
+		g.Metrics.ContainerCreateFailed(stage, class)
+	}
+}
+
+func (g *Gardener) destroyFailed(stage, class string) {
+	if g.Metrics != nil {
+		g.Metrics.ContainerDestroyFailed(stage, class)
+	}
 }
 
-func (g *Gardener) Stop()                                    {}
-func (g *Gardener) GraceTime(garden.Container) time.Duration { return 0 }
-func (g *Gardener) Ping() error                              { return nil }
+func (g *Gardener) Stop()       {}
+func (g *Gardener) Ping() error { return nil }
+
+// GraceTime returns the time after which the container should be destroyed
+// if nothing is connected to it, or 0 if it should never be destroyed
+func (g *Gardener) GraceTime(container garden.Container) time.Duration {
+	value, err := g.PropertyManager.Get(container.Handle(), GraceTimeKey)
+	if err != nil {
+		return 0
+	}
+
+	graceTime, err := time.ParseDuration(value)
+	if err != nil {
+		return 0
+	}
+
+	return graceTime
+}
 
 func (g *Gardener) Capacity() (garden.Capacity, error) {
 	mem, err := g.SysInfoProvider.TotalMemory()
@@ -259,26 +889,65 @@ func (g *Gardener) Containers(props garden.Properties) ([]garden.Container, erro
 		return []garden.Container{}, err
 	}
 
+	handles, err = g.selectHandles(handles, props)
+	if err != nil {
+		log.Error("select-failed", err)
+		return []garden.Container{}, err
+	}
+
+	// Lookup hides the containers being destroyed, including those whose
+	// destroy has started since they were listed
 	var containers []garden.Container
 	for _, handle := range handles {
-		if g.PropertyManager.MatchesAll(handle, props) {
-			container, err := g.Lookup(handle)
-			if err != nil {
-				log.Error("lookup-failed", err)
-			}
-
-			containers = append(containers, container)
+		container, err := g.Lookup(handle)
+		if err != nil {
+			log.Error("lookup-failed", err)
+			continue
 		}
+
+		containers = append(containers, container)
 	}
 
 	return containers, nil
 }
 
+// ContainersSnapshot returns every container as created, along with a cursor
+// which can be passed to ContainersSince to receive subsequent changes.
+func (g *Gardener) ContainersSnapshot() (ContainerDelta, error) {
+	if g.ChangeLog == nil {
+		return ContainerDelta{}, ErrChangeLogDisabled
+	}
+
+	// take the cursor first so that nothing is missed; a container created
+	// in between is reported again by the next ContainersSince
+	cursor := g.ChangeLog.Cursor()
+
+	handles, err := g.Containerizer.Handles()
+	if err != nil {
+		return ContainerDelta{}, err
+	}
+
+	return ContainerDelta{Cursor: cursor, Created: handles}, nil
+}
+
+// ContainersSince returns the containers which have been created, changed or
+// destroyed since cursor.
+func (g *Gardener) ContainersSince(cursor uint64) (ContainerDelta, error) {
+	if g.ChangeLog == nil {
+		return ContainerDelta{}, ErrChangeLogDisabled
+	}
+
+	return g.ChangeLog.Since(cursor)
+}
+
 func (g *Gardener) BulkInfo(handles []string) (map[string]garden.ContainerInfoEntry, error) {
 	result := make(map[string]garden.ContainerInfoEntry)
 	for _, handle := range handles {
-		// currently never returns error
-		container, _ := g.Lookup(handle)
+		container, err := g.Lookup(handle)
+		if err != nil {
+			result[handle] = garden.ContainerInfoEntry{Err: garden.NewError(err.Error())}
+			continue
+		}
 
 		var infoErr *garden.Error = nil
 		info, err := container.Info()
@@ -297,3 +966,54 @@ func (g *Gardener) BulkInfo(handles []string) (map[string]garden.ContainerInfoEn
 func (g *Gardener) BulkMetrics(handles []string) (map[string]garden.ContainerMetricsEntry, error) {
 	return nil, nil
 }
+
+func parseMaxPids(properties garden.Properties) (int64, error) {
+	raw, ok := properties[MaxPidsProperty]
+	if !ok {
+		return 0, nil
+	}
+
+	maxPids, err := strconv.ParseInt(raw, 10, 64)
+	if err != nil || maxPids < 0 {
+		return 0, fmt.Errorf("invalid %s property: '%s'", MaxPidsProperty, raw)
+	}
+
+	return maxPids, nil
+}
+
+// capMaxPids caps a container's max-pids at the server's, if it has one
+func capMaxPids(maxPids, max int64) int64 {
+	if max > 0 && maxPids > max {
+		return max
+	}
+
+	return maxPids
+}
+
+func parseRestartPolicy(properties garden.Properties) (string, error) {
+	policy, ok := properties[RestartPolicyProperty]
+	if !ok {
+		return RestartNever, nil
+	}
+
+	switch policy {
+	case RestartNever, RestartOnFailure, RestartAlways:
+		return policy, nil
+	default:
+		return "", fmt.Errorf("invalid %s property: '%s'", RestartPolicyProperty, policy)
+	}
+}
+
+func parseUmask(properties garden.Properties) (string, error) {
+	raw, ok := properties[UmaskProperty]
+	if !ok {
+		return "", nil
+	}
+
+	umask, err := strconv.ParseUint(raw, 8, 32)
+	if err != nil || umask > 0777 {
+		return "", fmt.Errorf("invalid %s property: '%s'", UmaskProperty, raw)
+	}
+
+	return fmt.Sprintf("%04o", umask), nil
+}
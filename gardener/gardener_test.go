@@ -1,17 +1,25 @@
 package gardener_test
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
+	"io/ioutil"
 	"net"
+	"os"
+	"path/filepath"
+	"time"
 
 	"github.com/cloudfoundry-incubator/garden"
 	"github.com/cloudfoundry-incubator/garden-shed/rootfs_provider"
+	gardenfakes "github.com/cloudfoundry-incubator/garden/fakes"
 	"github.com/cloudfoundry-incubator/guardian/gardener"
 	"github.com/cloudfoundry-incubator/guardian/gardener/fakes"
 	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
 	. "github.com/onsi/gomega"
 	"github.com/onsi/gomega/gbytes"
+	"github.com/pivotal-golang/clock/fakeclock"
 	"github.com/pivotal-golang/lager"
 	"github.com/pivotal-golang/lager/lagertest"
 )
@@ -95,9 +103,93 @@ var _ = Describe("Gardener", func() {
 					Expect(err).To(MatchError("booom!"))
 				})
 
-				It("should not create the volume", func() {
+				It("destroys the volume created alongside the network", func() {
 					gdnr.Create(garden.ContainerSpec{Handle: "bob"})
-					Expect(volumeCreator.CreateCallCount()).To(Equal(0))
+					Expect(volumeCreator.CreateCallCount()).To(Equal(1))
+					Expect(volumeCreator.DestroyCallCount()).To(Equal(1))
+					_, handle := volumeCreator.DestroyArgsForCall(0)
+					Expect(handle).To(Equal("bob"))
+				})
+
+				Context("and the volume creator fails too", func() {
+					BeforeEach(func() {
+						volumeCreator.CreateReturns("", nil, errors.New("no rootfs"))
+					})
+
+					It("returns the network error without destroying the volume", func() {
+						_, err := gdnr.Create(garden.ContainerSpec{Handle: "bob"})
+						Expect(err).To(MatchError("booom!"))
+						Expect(volumeCreator.DestroyCallCount()).To(Equal(0))
+					})
+				})
+			})
+
+			It("sets up the network while the volume is being created", func() {
+				hooked := make(chan struct{})
+				networker.HooksStub = func(lager.Logger, string, string) (gardener.Hooks, error) {
+					close(hooked)
+					return gardener.Hooks{}, nil
+				}
+
+				volumeCreator.CreateStub = func(lager.Logger, string, rootfs_provider.Spec) (string, []string, error) {
+					select {
+					case <-hooked:
+						return "", nil, nil
+					case <-time.After(time.Second):
+						return "", nil, errors.New("network was not set up concurrently")
+					}
+				}
+
+				_, err := gdnr.Create(garden.ContainerSpec{Handle: "bob"})
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			Context("when a create queue is configured", func() {
+				var metrics *fakes.FakeMetricsRecorder
+
+				BeforeEach(func() {
+					metrics = new(fakes.FakeMetricsRecorder)
+					gdnr.Metrics = metrics
+					gdnr.CreateQueue = gardener.NewCreateQueue(1)
+				})
+
+				It("only creates one container at a time", func() {
+					release := make(chan struct{})
+					volumeCreator.CreateStub = func(lager.Logger, string, rootfs_provider.Spec) (string, []string, error) {
+						<-release
+						return "", nil, nil
+					}
+
+					done := make(chan error, 2)
+					go func() {
+						_, err := gdnr.Create(garden.ContainerSpec{Handle: "first"})
+						done <- err
+					}()
+					go func() {
+						_, err := gdnr.Create(garden.ContainerSpec{Handle: "second"})
+						done <- err
+					}()
+
+					Eventually(volumeCreator.CreateCallCount).Should(Equal(1))
+					Eventually(gdnr.CreateQueue.Waiting).Should(Equal(1))
+					Consistently(volumeCreator.CreateCallCount).Should(Equal(1))
+
+					close(release)
+					Eventually(done).Should(Receive(BeNil()))
+					Eventually(done).Should(Receive(BeNil()))
+					Expect(gdnr.CreateQueue.Active()).To(Equal(0))
+				})
+
+				It("records how long each create was queued", func() {
+					_, err := gdnr.Create(garden.ContainerSpec{Handle: "bob"})
+					Expect(err).NotTo(HaveOccurred())
+					Expect(metrics.ContainerCreateQueuedCallCount()).To(Equal(1))
+				})
+
+				It("releases its slot when the create fails", func() {
+					networker.HooksReturns(gardener.Hooks{}, errors.New("booom!"))
+					gdnr.Create(garden.ContainerSpec{Handle: "bob"})
+					Expect(gdnr.CreateQueue.Active()).To(Equal(0))
 				})
 			})
 
@@ -166,16 +258,15 @@ var _ = Describe("Gardener", func() {
 					Expect(err).To(HaveOccurred())
 				})
 
-				It("should clean up networking configuration", func() {
+				It("should not set up networking", func() {
 					_, err := gdnr.Create(garden.ContainerSpec{
 						Handle:     "banana-container",
 						RootFSPath: "://banana",
 					})
 					Expect(err).To(HaveOccurred())
 
-					Expect(networker.DestroyCallCount()).To(Equal(1))
-					_, handle := networker.DestroyArgsForCall(0)
-					Expect(handle).To(Equal("banana-container"))
+					Expect(networker.HooksCallCount()).To(Equal(0))
+					Expect(networker.DestroyCallCount()).To(Equal(0))
 				})
 			})
 
@@ -260,413 +351,2408 @@ var _ = Describe("Gardener", func() {
 				Expect(spec.Privileged).To(BeTrue())
 			})
 
-			Context("when the containerizer fails to create the container", func() {
-				BeforeEach(func() {
-					containerizer.CreateReturns(errors.New("failed to create the banana"))
-				})
-
-				It("should return an error", func() {
-					_, err := gdnr.Create(garden.ContainerSpec{
-						Handle: "poor-banana",
-					})
-					Expect(err).To(HaveOccurred())
+			It("passes the properties to the containerizer", func() {
+				_, err := gdnr.Create(garden.ContainerSpec{
+					Handle:     "bob",
+					Properties: garden.Properties{"seccomp-profile": "{}"},
 				})
+				Expect(err).NotTo(HaveOccurred())
 
-				It("should cleanup the networking configuration", func() {
-					_, err := gdnr.Create(garden.ContainerSpec{
-						Handle: "poor-banana",
-					})
-					Expect(err).To(HaveOccurred())
-
-					Expect(networker.DestroyCallCount()).To(Equal(1))
-					_, handle := networker.DestroyArgsForCall(0)
-					Expect(handle).To(Equal("poor-banana"))
-				})
+				_, spec := containerizer.CreateArgsForCall(0)
+				Expect(spec.Properties).To(Equal(garden.Properties{"seccomp-profile": "{}"}))
 			})
 
-			It("returns the container that Lookup would return", func() {
-				c, err := gdnr.Create(garden.ContainerSpec{Handle: "handle"})
+			It("passes the max-pids property to the containerizer as MaxPids", func() {
+				_, err := gdnr.Create(garden.ContainerSpec{
+					Handle:     "bob",
+					Properties: garden.Properties{gardener.MaxPidsProperty: "128"},
+				})
 				Expect(err).NotTo(HaveOccurred())
 
-				d, err := gdnr.Lookup("handle")
-				Expect(err).NotTo(HaveOccurred())
-				Expect(c).To(Equal(d))
+				_, spec := containerizer.CreateArgsForCall(0)
+				Expect(spec.MaxPids).To(BeEquivalentTo(128))
 			})
-		})
-
-		Context("when no handle is specified", func() {
-			It("assigns a handle to the container", func() {
-				uidGenerator.GenerateReturns("generated-handle")
 
-				_, err := gdnr.Create(garden.ContainerSpec{})
+			It("caps the max-pids property at the server's", func() {
+				gdnr.MaxPids = 1000
 
+				_, err := gdnr.Create(garden.ContainerSpec{
+					Handle:     "bob",
+					Properties: garden.Properties{gardener.MaxPidsProperty: "100000"},
+				})
 				Expect(err).NotTo(HaveOccurred())
-				Expect(containerizer.CreateCallCount()).To(Equal(1))
+
 				_, spec := containerizer.CreateArgsForCall(0)
-				Expect(spec.Handle).To(Equal("generated-handle"))
+				Expect(spec.MaxPids).To(BeEquivalentTo(1000))
+				Expect(spec.Properties).To(HaveKeyWithValue(gardener.MaxPidsProperty, "1000"))
 			})
 
-			It("returns the container that Lookup would return", func() {
-				c, err := gdnr.Create(garden.ContainerSpec{})
-				Expect(err).NotTo(HaveOccurred())
+			Context("when the max-pids property is not a valid number", func() {
+				It("returns an error without creating anything", func() {
+					_, err := gdnr.Create(garden.ContainerSpec{
+						Handle:     "bob",
+						Properties: garden.Properties{gardener.MaxPidsProperty: "lots"},
+					})
+					Expect(err).To(MatchError("invalid max-pids property: 'lots'"))
 
-				d, err := gdnr.Lookup(c.Handle())
-				Expect(err).NotTo(HaveOccurred())
-				Expect(c).To(Equal(d))
+					Expect(networker.HooksCallCount()).To(Equal(0))
+					Expect(containerizer.CreateCallCount()).To(Equal(0))
+				})
 			})
-		})
 
-		Context("when properties are specified", func() {
-			var startingProperties garden.Properties
+			DescribeTable("refusing handles which would escape guardian's directories",
+				func(handle string) {
+					_, err := gdnr.Create(garden.ContainerSpec{Handle: handle})
+					Expect(err).To(MatchError(fmt.Sprintf("invalid handle '%s'", handle)))
 
-			BeforeEach(func() {
-				startingProperties = garden.Properties{
-					"thingy": "thing",
-					"blingy": "bling",
-				}
+					Expect(networker.HooksCallCount()).To(Equal(0))
+					Expect(containerizer.CreateCallCount()).To(Equal(0))
+				},
+				Entry("a path", "../../etc"),
+				Entry("the parent directory", ".."),
+				Entry("a nul byte", "bob\x00"),
+			)
+
+			It("refuses handles reserved for guardian's own cgroups", func() {
+				gdnr.ReservedHandles = []string{"throttled"}
+
+				_, err := gdnr.Create(garden.ContainerSpec{Handle: "throttled"})
+				Expect(err).To(MatchError("handle 'throttled' is reserved"))
+				Expect(containerizer.CreateCallCount()).To(Equal(0))
 			})
 
-			It("sets every property on the container", func() {
-				_, err := gdnr.Create(garden.ContainerSpec{
-					Handle:     "something",
-					Properties: startingProperties,
+			Context("when the server is rootless", func() {
+				BeforeEach(func() {
+					gdnr.Rootless = true
 				})
-				Expect(err).NotTo(HaveOccurred())
 
-				Expect(propertyManager.SetCallCount()).To(Equal(2))
+				It("refuses privileged containers without creating anything", func() {
+					_, err := gdnr.Create(garden.ContainerSpec{Handle: "bob", Privileged: true})
+					Expect(err).To(MatchError("privileged containers cannot be created by a rootless server"))
 
-				var allProps = make(map[string]string)
-				for i := 0; i < 2; i++ {
-					handle, name, value := propertyManager.SetArgsForCall(i)
-					Expect(handle).To(Equal("something"))
-					allProps[name] = value
-				}
+					Expect(networker.HooksCallCount()).To(Equal(0))
+					Expect(containerizer.CreateCallCount()).To(Equal(0))
+				})
 
-				Expect(allProps).To(Equal(map[string]string{
-					"blingy": "bling",
-					"thingy": "thing",
-				}))
+				It("creates unprivileged containers", func() {
+					_, err := gdnr.Create(garden.ContainerSpec{Handle: "bob"})
+					Expect(err).NotTo(HaveOccurred())
+					Expect(containerizer.CreateCallCount()).To(Equal(1))
+				})
 			})
-		})
 
-		Context("when bind mounts are specified", func() {
-			It("generates a proper mount spec", func() {
-				bindMounts := []garden.BindMount{
-					garden.BindMount{
-						SrcPath: "src",
-						DstPath: "dst",
-					},
-				}
+			Context("when the container is a container-runner", func() {
+				var spec garden.ContainerSpec
 
-				_, err := gdnr.Create(garden.ContainerSpec{
-					BindMounts: bindMounts,
+				BeforeEach(func() {
+					gdnr.AllowContainerRunners = true
+					spec = garden.ContainerSpec{
+						Handle:     "bob",
+						Privileged: true,
+						Properties: garden.Properties{gardener.ContainerTypeProperty: gardener.ContainerRunnerType},
+					}
 				})
-				Expect(err).NotTo(HaveOccurred())
 
-				Expect(containerizer.CreateCallCount()).To(Equal(1))
-				_, spec := containerizer.CreateArgsForCall(0)
-				Expect(spec.BindMounts).To(Equal(bindMounts))
-			})
-		})
-	})
+				It("passes the container type to the containerizer", func() {
+					_, err := gdnr.Create(spec)
+					Expect(err).NotTo(HaveOccurred())
 
-	Context("when having a container", func() {
-		var container garden.Container
+					_, desiredSpec := containerizer.CreateArgsForCall(0)
+					Expect(desiredSpec.Properties).To(HaveKeyWithValue(gardener.ContainerTypeProperty, gardener.ContainerRunnerType))
+				})
 
-		BeforeEach(func() {
-			var err error
-			container, err = gdnr.Lookup("banana")
-			Expect(err).NotTo(HaveOccurred())
-		})
+				Context("when container-runners are not allowed", func() {
+					It("returns an error without creating anything", func() {
+						gdnr.AllowContainerRunners = false
 
-		Describe("running a process in a container", func() {
-			It("asks the containerizer to run the process", func() {
-				origSpec := garden.ProcessSpec{Path: "ripe"}
-				origIO := garden.ProcessIO{
-					Stdout: gbytes.NewBuffer(),
-				}
-				_, err := container.Run(origSpec, origIO)
-				Expect(err).ToNot(HaveOccurred())
+						_, err := gdnr.Create(spec)
+						Expect(err).To(MatchError("container-runner containers are not allowed"))
+						Expect(containerizer.CreateCallCount()).To(Equal(0))
+					})
+				})
 
-				Expect(containerizer.RunCallCount()).To(Equal(1))
-				_, id, spec, io := containerizer.RunArgsForCall(0)
-				Expect(id).To(Equal("banana"))
-				Expect(spec).To(Equal(origSpec))
-				Expect(io).To(Equal(origIO))
-			})
+				Context("when the container is not privileged", func() {
+					It("returns an error without creating anything", func() {
+						spec.Privileged = false
 
-			Context("when the containerizer fails to run a process", func() {
-				BeforeEach(func() {
-					containerizer.RunReturns(nil, errors.New("lost my banana"))
+						_, err := gdnr.Create(spec)
+						Expect(err).To(MatchError("container-runner containers must be privileged"))
+						Expect(containerizer.CreateCallCount()).To(Equal(0))
+					})
 				})
+			})
 
-				It("returns the error", func() {
-					_, err := container.Run(garden.ProcessSpec{}, garden.ProcessIO{})
-					Expect(err).To(MatchError("lost my banana"))
+			Context("when the container-type property is not a known type", func() {
+				It("returns an error without creating anything", func() {
+					_, err := gdnr.Create(garden.ContainerSpec{
+						Handle:     "bob",
+						Properties: garden.Properties{gardener.ContainerTypeProperty: "vm"},
+					})
+					Expect(err).To(MatchError("invalid container-type property: 'vm'"))
+					Expect(containerizer.CreateCallCount()).To(Equal(0))
 				})
 			})
-		})
 
-		Describe("streaming files in to the container", func() {
-			It("asks the containerizer to stream in the tar stream", func() {
-				spec := garden.StreamInSpec{Path: "potato", User: "chef", TarStream: gbytes.NewBuffer()}
-				Expect(container.StreamIn(spec)).To(Succeed())
+			Context("when the output-rate property is not a valid number", func() {
+				It("returns an error without creating anything", func() {
+					_, err := gdnr.Create(garden.ContainerSpec{
+						Handle:     "bob",
+						Properties: garden.Properties{gardener.OutputRateProperty: "-1"},
+					})
+					Expect(err).To(MatchError("invalid output-rate property: '-1'"))
 
-				_, handle, specArg := containerizer.StreamInArgsForCall(0)
-				Expect(handle).To(Equal("banana"))
-				Expect(specArg).To(Equal(spec))
+					Expect(networker.HooksCallCount()).To(Equal(0))
+					Expect(containerizer.CreateCallCount()).To(Equal(0))
+				})
 			})
-		})
 
-		Describe("streaming files outside the container", func() {
-			It("asks the containerizer to stream out the files", func() {
-				spec := garden.StreamOutSpec{Path: "potato", User: "chef"}
-				_, err := container.StreamOut(spec)
-				Expect(err).To(Succeed())
+			Context("when the disk-quota-action property is not a known action", func() {
+				It("returns an error without creating anything", func() {
+					_, err := gdnr.Create(garden.ContainerSpec{
+						Handle:     "bob",
+						Properties: garden.Properties{gardener.DiskQuotaActionProperty: "panic"},
+					})
+					Expect(err).To(MatchError("invalid disk-quota-action property: 'panic'"))
 
-				_, handle, specArg := containerizer.StreamOutArgsForCall(0)
-				Expect(handle).To(Equal("banana"))
-				Expect(specArg).To(Equal(spec))
+					Expect(networker.HooksCallCount()).To(Equal(0))
+					Expect(containerizer.CreateCallCount()).To(Equal(0))
+				})
 			})
-		})
-	})
 
-	Describe("listing containers", func() {
-		BeforeEach(func() {
-			containerizer.HandlesReturns([]string{"banana", "banana2", "cola"}, nil)
-		})
+			Context("when the cpu-max or cpu-max-action property is invalid", func() {
+				It("returns an error without creating anything", func() {
+					_, err := gdnr.Create(garden.ContainerSpec{
+						Handle:     "bob",
+						Properties: garden.Properties{gardener.CPUMaxProperty: "0.001"},
+					})
+					Expect(err).To(MatchError("invalid cpu-max property: '0.001': quota must be at least 1000us"))
 
-		It("should return matching containers", func() {
-			propertyManager.MatchesAllStub = func(handle string, props garden.Properties) bool {
-				if handle != "banana" {
-					return true
-				}
-				return false
-			}
+					_, err = gdnr.Create(garden.ContainerSpec{
+						Handle:     "bob",
+						Properties: garden.Properties{gardener.CPUMaxProperty: "1", gardener.CPUMaxActionProperty: "kill"},
+					})
+					Expect(err).To(MatchError("invalid cpu-max-action property: 'kill'"))
 
-			c, err := gdnr.Containers(garden.Properties{
-				"somename": "somevalue",
+					Expect(containerizer.CreateCallCount()).To(Equal(0))
+				})
 			})
-			Expect(err).NotTo(HaveOccurred())
-			Expect(c).To(HaveLen(2))
-			Expect(c[0].Handle()).To(Equal("banana2"))
-			Expect(c[1].Handle()).To(Equal("cola"))
-		})
 
-		Describe("NetIn", func() {
-			var container garden.Container
+			It("passes the cpu-quota property to the containerizer as a CPUQuota", func() {
+				_, err := gdnr.Create(garden.ContainerSpec{
+					Handle:     "bob",
+					Properties: garden.Properties{gardener.CPUQuotaProperty: "1.5"},
+				})
+				Expect(err).NotTo(HaveOccurred())
 
-			const (
-				externalPort  uint32 = 8888
-				contianerPort uint32 = 8080
-			)
+				_, spec := containerizer.CreateArgsForCall(0)
+				Expect(spec.CPUQuota).To(Equal(gardener.CPUQuota{Quota: 150000, Period: 100000}))
+			})
 
-			BeforeEach(func() {
-				var err error
-				container, err = gdnr.Lookup("banana")
+			It("accepts a cpu-quota property given as a quota and period", func() {
+				_, err := gdnr.Create(garden.ContainerSpec{
+					Handle:     "bob",
+					Properties: garden.Properties{gardener.CPUQuotaProperty: "25000/50000"},
+				})
 				Expect(err).NotTo(HaveOccurred())
+
+				_, spec := containerizer.CreateArgsForCall(0)
+				Expect(spec.CPUQuota).To(Equal(gardener.CPUQuota{Quota: 25000, Period: 50000}))
 			})
 
-			It("asks the netwoker to forward the correct ports", func() {
-				_, _, err := container.NetIn(externalPort, contianerPort)
+			Context("when the cpu-quota property is not valid", func() {
+				DescribeTable("returns an error without creating anything",
+					func(quota string) {
+						_, err := gdnr.Create(garden.ContainerSpec{
+							Handle:     "bob",
+							Properties: garden.Properties{gardener.CPUQuotaProperty: quota},
+						})
+						Expect(err).To(MatchError(HavePrefix("invalid cpu-quota property: '" + quota + "'")))
+
+						Expect(networker.HooksCallCount()).To(Equal(0))
+						Expect(containerizer.CreateCallCount()).To(Equal(0))
+					},
+					Entry("not a number", "lots"),
+					Entry("too small a quota", "0.001"),
+					Entry("too long a period", "50000/2000000"),
+					Entry("a negative quota", "-50000/100000"),
+				)
+			})
 
+			It("passes the cpuset properties to the containerizer as a CPUSet", func() {
+				_, err := gdnr.Create(garden.ContainerSpec{
+					Handle: "bob",
+					Properties: garden.Properties{
+						gardener.CPUSetCPUsProperty: "3,0-1,2",
+						gardener.CPUSetMemsProperty: "0",
+					},
+				})
+				Expect(err).NotTo(HaveOccurred())
+
+				_, spec := containerizer.CreateArgsForCall(0)
+				Expect(spec.CPUSet).To(Equal(gardener.CPUSet{CPUs: "0-3", Mems: "0"}))
+			})
+
+			It("returns an error without creating anything when a cpuset property is not valid", func() {
+				_, err := gdnr.Create(garden.ContainerSpec{
+					Handle:     "bob",
+					Properties: garden.Properties{gardener.CPUSetCPUsProperty: "3-1"},
+				})
+				Expect(err).To(MatchError("invalid cpuset-cpus property: '3-1': '3-1' is not an id or a range of ids"))
+				Expect(containerizer.CreateCallCount()).To(Equal(0))
+			})
+
+			It("returns an error without creating anything when a cpuset property names offline cpus or nodes", func() {
+				gdnr.OnlineCPUs = gardener.OnlineCPUs{CPUs: []int{0, 1, 2, 3}, Mems: []int{0}}
+
+				_, err := gdnr.Create(garden.ContainerSpec{
+					Handle:     "bob",
+					Properties: garden.Properties{gardener.CPUSetCPUsProperty: "2-5"},
+				})
+				Expect(err).To(MatchError("invalid cpuset-cpus property: '2-5': 4-5 not online"))
+
+				_, err = gdnr.Create(garden.ContainerSpec{
+					Handle:     "bob",
+					Properties: garden.Properties{gardener.CPUSetMemsProperty: "1"},
+				})
+				Expect(err).To(MatchError("invalid cpuset-mems property: '1': 1 not online"))
+				Expect(containerizer.CreateCallCount()).To(Equal(0))
+			})
+
+			Context("with a cpuset allocator", func() {
+				BeforeEach(func() {
+					gdnr.CPUSets = &gardener.CPUSetAllocator{CPUs: []int{0, 1, 2, 3}, PerContainer: 2}
+				})
+
+				It("pins containers which do not choose their own cpus, and records their pin", func() {
+					_, err := gdnr.Create(garden.ContainerSpec{Handle: "bob"})
+					Expect(err).NotTo(HaveOccurred())
+
+					_, spec := containerizer.CreateArgsForCall(0)
+					Expect(spec.CPUSet.CPUs).To(Equal("0-1"))
+					Expect(spec.Properties).To(HaveKeyWithValue(gardener.CPUSetCPUsProperty, "0-1"))
+				})
+
+				It("does not pin containers which choose their own cpus elsewhere", func() {
+					_, err := gdnr.Create(garden.ContainerSpec{
+						Handle:     "bob",
+						Properties: garden.Properties{gardener.CPUSetCPUsProperty: "0"},
+					})
+					Expect(err).NotTo(HaveOccurred())
+
+					_, spec := containerizer.CreateArgsForCall(0)
+					Expect(spec.CPUSet.CPUs).To(Equal("0"))
+					Expect(gdnr.CPUSets.Allocate("alice")).To(Equal("1-2"))
+				})
+
+				It("releases the pin when the create fails", func() {
+					containerizer.CreateReturns(errors.New("boom"))
+
+					_, err := gdnr.Create(garden.ContainerSpec{Handle: "bob"})
+					Expect(err).To(HaveOccurred())
+					Expect(gdnr.CPUSets.Allocate("alice")).To(Equal("0-1"))
+				})
+			})
+
+			Describe("volumes", func() {
+				var volumeMounter *fakes.FakeVolumeMounter
+
+				BeforeEach(func() {
+					volumeMounter = new(fakes.FakeVolumeMounter)
+					volumeMounter.MountStub = func(_ lager.Logger, handle string, volume gardener.Volume) (string, error) {
+						return "/volumes/" + handle + "/" + volume.Name, nil
+					}
+					gdnr.VolumeMounter = volumeMounter
+				})
+
+				volumes := `[{"name": "data", "destination": "/data", "options": {"server": "nfs-1"}}, {"name": "config", "destination": "/config", "read_only": true}]`
+
+				It("mounts the volumes and bind mounts them in to the container", func() {
+					_, err := gdnr.Create(garden.ContainerSpec{
+						Handle:     "bob",
+						BindMounts: []garden.BindMount{{SrcPath: "/host", DstPath: "/container"}},
+						Properties: garden.Properties{gardener.VolumesProperty: volumes},
+					})
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(volumeMounter.MountCallCount()).To(Equal(2))
+					_, handle, volume := volumeMounter.MountArgsForCall(0)
+					Expect(handle).To(Equal("bob"))
+					Expect(volume).To(Equal(gardener.Volume{Name: "data", DstPath: "/data", Options: map[string]string{"server": "nfs-1"}}))
+
+					_, spec := containerizer.CreateArgsForCall(0)
+					Expect(spec.BindMounts).To(Equal([]garden.BindMount{
+						{SrcPath: "/host", DstPath: "/container"},
+						{SrcPath: "/volumes/bob/data", DstPath: "/data", Mode: garden.BindMountModeRW, Origin: garden.BindMountOriginHost},
+						{SrcPath: "/volumes/bob/config", DstPath: "/config", Mode: garden.BindMountModeRO, Origin: garden.BindMountOriginHost},
+					}))
+				})
+
+				It("unmounts the volumes already mounted when one fails to mount", func() {
+					volumeMounter.MountStub = func(_ lager.Logger, handle string, volume gardener.Volume) (string, error) {
+						if volume.Name == "config" {
+							return "", errors.New("no such share")
+						}
+
+						return "/volumes/" + handle + "/" + volume.Name, nil
+					}
+
+					_, err := gdnr.Create(garden.ContainerSpec{Handle: "bob", Properties: garden.Properties{gardener.VolumesProperty: volumes}})
+					Expect(err).To(MatchError("mount volume 'config': no such share"))
+					Expect(containerizer.CreateCallCount()).To(Equal(0))
+
+					Expect(volumeMounter.UnmountCallCount()).To(Equal(1))
+					_, _, volume := volumeMounter.UnmountArgsForCall(0)
+					Expect(volume.Name).To(Equal("data"))
+				})
+
+				It("destroys the container's rootfs when a volume fails to mount", func() {
+					volumeMounter.MountReturns("", errors.New("no such share"))
+
+					_, err := gdnr.Create(garden.ContainerSpec{Handle: "bob", Properties: garden.Properties{gardener.VolumesProperty: volumes}})
+					Expect(err).To(HaveOccurred())
+
+					Expect(volumeCreator.DestroyCallCount()).To(Equal(1))
+					_, handle := volumeCreator.DestroyArgsForCall(0)
+					Expect(handle).To(Equal("bob"))
+				})
+
+				It("unmounts the volumes and destroys the rootfs when the container cannot be created", func() {
+					containerizer.CreateReturns(errors.New("boom"))
+
+					_, err := gdnr.Create(garden.ContainerSpec{Handle: "bob", Properties: garden.Properties{gardener.VolumesProperty: volumes}})
+					Expect(err).To(MatchError("boom"))
+					Expect(volumeMounter.UnmountCallCount()).To(Equal(2))
+					Expect(volumeCreator.DestroyCallCount()).To(Equal(1))
+				})
+
+				It("records the mounted volumes where clients cannot change them", func() {
+					_, err := gdnr.Create(garden.ContainerSpec{Handle: "bob", Properties: garden.Properties{gardener.VolumesProperty: volumes}})
+					Expect(err).NotTo(HaveOccurred())
+
+					var recorded string
+					for i := 0; i < propertyManager.SetCallCount(); i++ {
+						handle, name, value := propertyManager.SetArgsForCall(i)
+						if handle == "bob" && name == gardener.MountedVolumesKey {
+							recorded = value
+						}
+					}
+
+					var recordedVolumes []gardener.Volume
+					Expect(json.Unmarshal([]byte(recorded), &recordedVolumes)).To(Succeed())
+					Expect(recordedVolumes).To(HaveLen(2))
+					Expect(recordedVolumes[0].Name).To(Equal("data"))
+					Expect(recordedVolumes[1].Name).To(Equal("config"))
+				})
+
+				It("refuses volumes without a destination", func() {
+					_, err := gdnr.Create(garden.ContainerSpec{
+						Handle:     "bob",
+						Properties: garden.Properties{gardener.VolumesProperty: `[{"name": "data"}]`},
+					})
+					Expect(err).To(MatchError(`invalid volumes property: '[{"name": "data"}]': destination '' is not an absolute path`))
+					Expect(volumeMounter.MountCallCount()).To(Equal(0))
+				})
+
+				It("refuses volumes when there is no volume mounter", func() {
+					gdnr.VolumeMounter = nil
+
+					_, err := gdnr.Create(garden.ContainerSpec{Handle: "bob", Properties: garden.Properties{gardener.VolumesProperty: volumes}})
+					Expect(err).To(MatchError("volumes are not supported: no volume plugin is configured"))
+					Expect(containerizer.CreateCallCount()).To(Equal(0))
+				})
+			})
+
+			It("passes the umask property to the containerizer as a 4 digit octal Umask", func() {
+				_, err := gdnr.Create(garden.ContainerSpec{
+					Handle:     "bob",
+					Properties: garden.Properties{gardener.UmaskProperty: "27"},
+				})
+				Expect(err).NotTo(HaveOccurred())
+
+				_, spec := containerizer.CreateArgsForCall(0)
+				Expect(spec.Umask).To(Equal("0027"))
+			})
+
+			Context("when there is a default umask", func() {
+				BeforeEach(func() {
+					gdnr.DefaultUmask = "0022"
+				})
+
+				It("passes it to the containerizer for containers without the umask property", func() {
+					_, err := gdnr.Create(garden.ContainerSpec{Handle: "bob"})
+					Expect(err).NotTo(HaveOccurred())
+
+					_, spec := containerizer.CreateArgsForCall(0)
+					Expect(spec.Umask).To(Equal("0022"))
+				})
+
+				It("lets the umask property override it", func() {
+					_, err := gdnr.Create(garden.ContainerSpec{
+						Handle:     "bob",
+						Properties: garden.Properties{gardener.UmaskProperty: "077"},
+					})
+					Expect(err).NotTo(HaveOccurred())
+
+					_, spec := containerizer.CreateArgsForCall(0)
+					Expect(spec.Umask).To(Equal("0077"))
+				})
+			})
+
+			It("passes the restart policy property to the containerizer", func() {
+				_, err := gdnr.Create(garden.ContainerSpec{
+					Handle:     "bob",
+					Properties: garden.Properties{gardener.RestartPolicyProperty: gardener.RestartOnFailure},
+				})
+				Expect(err).NotTo(HaveOccurred())
+
+				_, spec := containerizer.CreateArgsForCall(0)
+				Expect(spec.RestartPolicy).To(Equal(gardener.RestartOnFailure))
+			})
+
+			Context("when the restart policy property is invalid", func() {
+				It("returns an error without creating anything", func() {
+					_, err := gdnr.Create(garden.ContainerSpec{
+						Handle:     "bob",
+						Properties: garden.Properties{gardener.RestartPolicyProperty: "sometimes"},
+					})
+					Expect(err).To(MatchError("invalid restart-policy property: 'sometimes'"))
+					Expect(containerizer.CreateCallCount()).To(Equal(0))
+				})
+			})
+
+			Context("when the umask property is not a valid octal umask", func() {
+				It("returns an error without creating anything", func() {
+					_, err := gdnr.Create(garden.ContainerSpec{
+						Handle:     "bob",
+						Properties: garden.Properties{gardener.UmaskProperty: "0999"},
+					})
+					Expect(err).To(MatchError("invalid umask property: '0999'"))
+
+					Expect(networker.HooksCallCount()).To(Equal(0))
+					Expect(containerizer.CreateCallCount()).To(Equal(0))
+				})
+			})
+
+			Context("when the containerizer fails to create the container", func() {
+				BeforeEach(func() {
+					containerizer.CreateReturns(errors.New("failed to create the banana"))
+				})
+
+				It("should return an error", func() {
+					_, err := gdnr.Create(garden.ContainerSpec{
+						Handle: "poor-banana",
+					})
+					Expect(err).To(HaveOccurred())
+				})
+
+				It("should cleanup the networking configuration", func() {
+					_, err := gdnr.Create(garden.ContainerSpec{
+						Handle: "poor-banana",
+					})
+					Expect(err).To(HaveOccurred())
+
+					Expect(networker.DestroyCallCount()).To(Equal(1))
+					_, handle := networker.DestroyArgsForCall(0)
+					Expect(handle).To(Equal("poor-banana"))
+				})
+			})
+
+			Context("when there is an egress policy", func() {
+				var rules []garden.NetOutRule
+
+				BeforeEach(func() {
+					rules = []garden.NetOutRule{
+						{Protocol: garden.ProtocolTCP, Ports: []garden.PortRange{garden.PortRangeFromPort(443)}},
+						{Protocol: garden.ProtocolUDP, Ports: []garden.PortRange{garden.PortRangeFromPort(53)}},
+					}
+
+					gdnr.EgressPolicy = &gardener.EgressPolicy{}
+					gdnr.EgressPolicy.Set(rules)
+				})
+
+				It("applies the policy's rules to the container", func() {
+					_, err := gdnr.Create(garden.ContainerSpec{Handle: "bob"})
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(networker.BulkNetOutCallCount()).To(Equal(1))
+					_, handle, appliedRules := networker.BulkNetOutArgsForCall(0)
+					Expect(handle).To(Equal("bob"))
+					Expect(appliedRules).To(Equal(rules))
+				})
+
+				Context("when the rules cannot be applied", func() {
+					BeforeEach(func() {
+						networker.BulkNetOutReturns(errors.New("iptables-is-sad"))
+					})
+
+					It("destroys the container and returns an error", func() {
+						_, err := gdnr.Create(garden.ContainerSpec{Handle: "bob"})
+						Expect(err).To(MatchError("apply egress policy: iptables-is-sad"))
+
+						Expect(containerizer.DestroyCallCount()).To(Equal(1))
+						_, handle := containerizer.DestroyArgsForCall(0)
+						Expect(handle).To(Equal("bob"))
+						Expect(networker.DestroyCallCount()).To(Equal(1))
+					})
+				})
+			})
+
+			It("returns the container that Lookup would return", func() {
+				c, err := gdnr.Create(garden.ContainerSpec{Handle: "handle"})
+				Expect(err).NotTo(HaveOccurred())
+
+				d, err := gdnr.Lookup("handle")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(c).To(Equal(d))
+			})
+		})
+
+		Context("when no handle is specified", func() {
+			It("assigns a handle to the container", func() {
+				uidGenerator.GenerateReturns("generated-handle")
+
+				_, err := gdnr.Create(garden.ContainerSpec{})
+
+				Expect(err).NotTo(HaveOccurred())
+				Expect(containerizer.CreateCallCount()).To(Equal(1))
+				_, spec := containerizer.CreateArgsForCall(0)
+				Expect(spec.Handle).To(Equal("generated-handle"))
+			})
+
+			It("returns the container that Lookup would return", func() {
+				c, err := gdnr.Create(garden.ContainerSpec{})
+				Expect(err).NotTo(HaveOccurred())
+
+				d, err := gdnr.Lookup(c.Handle())
+				Expect(err).NotTo(HaveOccurred())
+				Expect(c).To(Equal(d))
+			})
+		})
+
+		Context("when properties are specified", func() {
+			var startingProperties garden.Properties
+
+			BeforeEach(func() {
+				startingProperties = garden.Properties{
+					"thingy": "thing",
+					"blingy": "bling",
+				}
+			})
+
+			It("sets every property on the container", func() {
+				_, err := gdnr.Create(garden.ContainerSpec{
+					Handle:     "something",
+					Properties: startingProperties,
+				})
+				Expect(err).NotTo(HaveOccurred())
+
+				// the properties are followed by the container's spec
+				Expect(propertyManager.SetCallCount()).To(Equal(3))
+
+				var allProps = make(map[string]string)
+				for i := 0; i < 2; i++ {
+					handle, name, value := propertyManager.SetArgsForCall(i)
+					Expect(handle).To(Equal("something"))
+					allProps[name] = value
+				}
+
+				Expect(allProps).To(Equal(map[string]string{
+					"blingy": "bling",
+					"thingy": "thing",
+				}))
+			})
+		})
+
+		Context("when bind mounts are specified", func() {
+			It("generates a proper mount spec", func() {
+				bindMounts := []garden.BindMount{
+					garden.BindMount{
+						SrcPath: "src",
+						DstPath: "dst",
+					},
+				}
+
+				_, err := gdnr.Create(garden.ContainerSpec{
+					BindMounts: bindMounts,
+				})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(containerizer.CreateCallCount()).To(Equal(1))
+				_, spec := containerizer.CreateArgsForCall(0)
+				Expect(spec.BindMounts).To(Equal(bindMounts))
+			})
+		})
+	})
+
+	Context("when having a container", func() {
+		var container garden.Container
+
+		BeforeEach(func() {
+			var err error
+			container, err = gdnr.Lookup("banana")
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		Describe("changing the bind mounts of a running container", func() {
+			var mount garden.BindMount
+
+			BeforeEach(func() {
+				mount = garden.BindMount{SrcPath: "/var/vcap/data/volume", DstPath: "/data", Mode: garden.BindMountModeRW}
+				propertyManager.GetStub = func(_, name string) (string, error) {
+					Expect(name).To(Equal(gardener.SpecKey))
+					return `{"Handle":"banana","BindMounts":[{"SrcPath":"/var/cache","DstPath":"/cache"}]}`, nil
+				}
+			})
+
+			It("asks the containerizer to add the bind mount", func() {
+				Expect(gdnr.AddBindMount("banana", mount)).To(Succeed())
+
+				Expect(containerizer.AddBindMountCallCount()).To(Equal(1))
+				_, handle, actualMount := containerizer.AddBindMountArgsForCall(0)
+				Expect(handle).To(Equal("banana"))
+				Expect(actualMount).To(Equal(mount))
+			})
+
+			It("records the added bind mount in the container's spec", func() {
+				Expect(gdnr.AddBindMount("banana", mount)).To(Succeed())
+
+				Expect(propertyManager.SetCallCount()).To(Equal(1))
+				handle, name, value := propertyManager.SetArgsForCall(0)
+				Expect(handle).To(Equal("banana"))
+				Expect(name).To(Equal(gardener.SpecKey))
+
+				var spec garden.ContainerSpec
+				Expect(json.Unmarshal([]byte(value), &spec)).To(Succeed())
+				Expect(spec.BindMounts).To(Equal([]garden.BindMount{
+					{SrcPath: "/var/cache", DstPath: "/cache"},
+					mount,
+				}))
+			})
+
+			It("rejects destinations which are not absolute", func() {
+				mount.DstPath = "data"
+
+				Expect(gdnr.AddBindMount("banana", mount)).To(MatchError("add bind mount: destination 'data' is not an absolute path"))
+				Expect(containerizer.AddBindMountCallCount()).To(Equal(0))
+			})
+
+			Context("when the containerizer fails to add the bind mount", func() {
+				It("returns the error without recording the bind mount", func() {
+					containerizer.AddBindMountReturns(errors.New("no such file"))
+
+					Expect(gdnr.AddBindMount("banana", mount)).To(MatchError("no such file"))
+					Expect(propertyManager.SetCallCount()).To(Equal(0))
+				})
+			})
+
+			It("asks the containerizer to remove a bind mount, and removes it from the container's spec", func() {
+				Expect(gdnr.RemoveBindMount("banana", "/cache")).To(Succeed())
+
+				Expect(containerizer.RemoveBindMountCallCount()).To(Equal(1))
+				_, handle, dst := containerizer.RemoveBindMountArgsForCall(0)
+				Expect(handle).To(Equal("banana"))
+				Expect(dst).To(Equal("/cache"))
+
+				_, _, value := propertyManager.SetArgsForCall(0)
+				var spec garden.ContainerSpec
+				Expect(json.Unmarshal([]byte(value), &spec)).To(Succeed())
+				Expect(spec.BindMounts).To(BeEmpty())
+			})
+		})
+
+		Describe("attaching to a process in a container", func() {
+			It("asks the containerizer to attach to the process", func() {
+				process := new(gardenfakes.FakeProcess)
+				containerizer.AttachReturns(process, nil)
+
+				attached, err := container.Attach("some-process-id", garden.ProcessIO{})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(attached).To(Equal(process))
+
+				_, handle, processID, _ := containerizer.AttachArgsForCall(0)
+				Expect(handle).To(Equal("banana"))
+				Expect(processID).To(Equal("some-process-id"))
+			})
+
+			Context("when the containerizer fails to attach", func() {
+				It("returns the error", func() {
+					containerizer.AttachReturns(nil, errors.New("unknown process"))
+
+					_, err := container.Attach("some-process-id", garden.ProcessIO{})
+					Expect(err).To(MatchError("unknown process"))
+				})
+			})
+		})
+
+		Describe("running a process in a container", func() {
+			It("asks the containerizer to run the process", func() {
+				origSpec := garden.ProcessSpec{Path: "ripe"}
+				origIO := garden.ProcessIO{
+					Stdout: gbytes.NewBuffer(),
+				}
+				_, err := container.Run(origSpec, origIO)
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(containerizer.RunCallCount()).To(Equal(1))
+				_, id, spec, io := containerizer.RunArgsForCall(0)
+				Expect(id).To(Equal("banana"))
+				Expect(spec).To(Equal(origSpec))
+				Expect(io).To(Equal(origIO))
+			})
+
+			Context("when the containerizer fails to run a process", func() {
+				BeforeEach(func() {
+					containerizer.RunReturns(nil, errors.New("lost my banana"))
+				})
+
+				It("returns the error", func() {
+					_, err := container.Run(garden.ProcessSpec{}, garden.ProcessIO{})
+					Expect(err).To(MatchError("lost my banana"))
+				})
+			})
+
+			Context("when the process has an image of its own", func() {
+				var (
+					peaRunner *fakes.FakePeaRunner
+					process   *gardenfakes.FakeProcess
+					exit      chan int
+					spec      garden.ProcessSpec
+					props     map[string]string
+				)
+
+				BeforeEach(func() {
+					peaRunner = new(fakes.FakePeaRunner)
+					gdnr.Containerizer = struct {
+						*fakes.FakeContainerizer
+						*fakes.FakePeaRunner
+					}{containerizer, peaRunner}
+
+					exit = make(chan int, 1)
+					process = new(gardenfakes.FakeProcess)
+					process.WaitStub = func() (int, error) {
+						return <-exit, nil
+					}
+					peaRunner.RunPeaReturns(process, nil)
+
+					uidGenerator.GenerateReturns("some-uid")
+					propertyManager.AllReturns(garden.Properties{
+						gardener.SpecKey: `{"Handle":"banana","Privileged":false}`,
+					}, nil)
+					volumeCreator.CreateReturns("/path/to/pea/rootfs", []string{"IMAGE=env"}, nil)
+
+					props = map[string]string{}
+					propertyManager.SetStub = func(_, name, value string) {
+						props[name] = value
+					}
+					propertyManager.GetStub = func(_, name string) (string, error) {
+						return props[name], nil
+					}
+					propertyManager.RemoveStub = func(_, name string) error {
+						delete(props, name)
+						return nil
+					}
+
+					spec = garden.ProcessSpec{
+						Path:  "sidecar",
+						Env:   []string{"PROCESS=env"},
+						Image: garden.ImageRef{URI: "docker:///busybox"},
+					}
+
+					var err error
+					container, err = gdnr.Lookup("banana")
+					Expect(err).NotTo(HaveOccurred())
+				})
+
+				It("creates a volume for the process from its image", func() {
+					_, err := container.Run(spec, garden.ProcessIO{})
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(volumeCreator.CreateCallCount()).To(Equal(1))
+					_, handle, rootfsSpec := volumeCreator.CreateArgsForCall(0)
+					Expect(handle).To(Equal("banana-pea-some-uid"))
+					Expect(rootfsSpec.RootFS.String()).To(Equal("docker:///busybox"))
+					Expect(rootfsSpec.Namespaced).To(BeTrue())
+				})
+
+				It("runs the process in a pea rather than in the container", func() {
+					runProcess, err := container.Run(spec, garden.ProcessIO{})
+					Expect(err).NotTo(HaveOccurred())
+					Expect(runProcess).To(Equal(process))
+					Expect(containerizer.RunCallCount()).To(Equal(0))
+
+					Expect(peaRunner.RunPeaCallCount()).To(Equal(1))
+					_, handle, pea, _ := peaRunner.RunPeaArgsForCall(0)
+					Expect(handle).To(Equal("banana"))
+					Expect(pea.ID).To(Equal("banana-pea-some-uid"))
+					Expect(pea.RootFSPath).To(Equal("/path/to/pea/rootfs"))
+					Expect(pea.Process.Path).To(Equal("sidecar"))
+					Expect(pea.Process.Env).To(Equal([]string{"IMAGE=env", "PROCESS=env"}))
+				})
+
+				It("destroys the volume once the process exits", func() {
+					_, err := container.Run(spec, garden.ProcessIO{})
+					Expect(err).NotTo(HaveOccurred())
+					Consistently(volumeCreator.DestroyCallCount).Should(Equal(0))
+
+					exit <- 0
+					Eventually(volumeCreator.DestroyCallCount).Should(Equal(1))
+					_, handle := volumeCreator.DestroyArgsForCall(0)
+					Expect(handle).To(Equal("banana-pea-some-uid"))
+				})
+
+				It("records the pea on the container until the process exits", func() {
+					_, err := container.Run(spec, garden.ProcessIO{})
+					Expect(err).NotTo(HaveOccurred())
+					Expect(props).To(HaveKeyWithValue(gardener.PeasKey, "banana-pea-some-uid"))
+
+					exit <- 0
+					Eventually(func() map[string]string { return props }).ShouldNot(HaveKey(gardener.PeasKey))
+				})
+
+				It("destroys the volumes of its peas when the container is destroyed", func() {
+					_, err := container.Run(spec, garden.ProcessIO{})
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(gdnr.Destroy("banana")).To(Succeed())
+					Expect(volumeCreator.DestroyCallCount()).To(Equal(2))
+					_, handle := volumeCreator.DestroyArgsForCall(0)
+					Expect(handle).To(Equal("banana-pea-some-uid"))
+
+					exit <- 0
+					Consistently(volumeCreator.DestroyCallCount).Should(Equal(2))
+				})
+
+				Describe("recovering the container's peas", func() {
+					var peaReaper *fakes.FakePeaReaper
+
+					BeforeEach(func() {
+						peaReaper = new(fakes.FakePeaReaper)
+						gdnr.Containerizer = struct {
+							*fakes.FakeContainerizer
+							*fakes.FakePeaRunner
+							*fakes.FakePeaReaper
+						}{containerizer, peaRunner, peaReaper}
+
+						props[gardener.PeasKey] = "banana-pea-1,banana-pea-2"
+					})
+
+					It("destroys the volumes of the peas which the containerizer reaped", func() {
+						peaReaper.ReapPeasReturns([]string{"banana-pea-1"}, nil)
+
+						Expect(gdnr.RecoverPeas(logger, "banana")).To(Succeed())
+						_, handle := peaReaper.ReapPeasArgsForCall(0)
+						Expect(handle).To(Equal("banana"))
+
+						Expect(volumeCreator.DestroyCallCount()).To(Equal(1))
+						_, volume := volumeCreator.DestroyArgsForCall(0)
+						Expect(volume).To(Equal("banana-pea-1"))
+						Expect(props).To(HaveKeyWithValue(gardener.PeasKey, "banana-pea-2"))
+					})
+
+					Context("when reaping the peas fails", func() {
+						It("returns the error without destroying any volumes", func() {
+							peaReaper.ReapPeasReturns(nil, errors.New("runc exploded"))
+
+							Expect(gdnr.RecoverPeas(logger, "banana")).To(MatchError("runc exploded"))
+							Expect(volumeCreator.DestroyCallCount()).To(Equal(0))
+						})
+					})
+				})
+
+				Context("when the container is privileged", func() {
+					BeforeEach(func() {
+						propertyManager.AllReturns(garden.Properties{
+							gardener.SpecKey: `{"Handle":"banana","Privileged":true}`,
+						}, nil)
+					})
+
+					It("does not namespace the volume", func() {
+						_, err := container.Run(spec, garden.ProcessIO{})
+						Expect(err).NotTo(HaveOccurred())
+
+						_, _, rootfsSpec := volumeCreator.CreateArgsForCall(0)
+						Expect(rootfsSpec.Namespaced).To(BeFalse())
+					})
+				})
+
+				Context("when creating the volume fails", func() {
+					It("returns an error without running the process", func() {
+						volumeCreator.CreateReturns("", nil, errors.New("no such image"))
+
+						_, err := container.Run(spec, garden.ProcessIO{})
+						Expect(err).To(MatchError("create process image: no such image"))
+						Expect(peaRunner.RunPeaCallCount()).To(Equal(0))
+					})
+				})
+
+				Context("when running the pea fails", func() {
+					It("destroys the volume and returns the error", func() {
+						peaRunner.RunPeaReturns(nil, errors.New("runc exploded"))
+
+						_, err := container.Run(spec, garden.ProcessIO{})
+						Expect(err).To(MatchError("runc exploded"))
+						Expect(volumeCreator.DestroyCallCount()).To(Equal(1))
+					})
+				})
+
+				Context("when the containerizer cannot run peas", func() {
+					It("returns an error", func() {
+						gdnr.Containerizer = containerizer
+
+						var err error
+						container, err = gdnr.Lookup("banana")
+						Expect(err).NotTo(HaveOccurred())
+
+						_, err = container.Run(spec, garden.ProcessIO{})
+						Expect(err).To(MatchError("the containerizer does not support process images"))
+					})
+				})
+			})
+
+			Context("when events are enabled", func() {
+				var (
+					events      <-chan gardener.Event
+					unsubscribe func()
+					process     *gardenfakes.FakeProcess
+				)
+
+				BeforeEach(func() {
+					hub := gardener.NewEventHub(10)
+					events, unsubscribe = hub.Subscribe()
+					gdnr.Events = hub
+
+					process = new(gardenfakes.FakeProcess)
+					process.IDReturns("some-process")
+					process.WaitReturns(42, nil)
+					containerizer.RunReturns(process, nil)
+
+					var err error
+					container, err = gdnr.Lookup("banana")
+					Expect(err).NotTo(HaveOccurred())
+				})
+
+				AfterEach(func() {
+					unsubscribe()
+				})
+
+				It("publishes an event when the process exits", func() {
+					_, err := container.Run(garden.ProcessSpec{}, garden.ProcessIO{})
+					Expect(err).NotTo(HaveOccurred())
+
+					var event gardener.Event
+					Eventually(events).Should(Receive(&event))
+					Expect(event.Handle).To(Equal("banana"))
+					Expect(event.Type).To(Equal(gardener.EventProcessExit))
+					Expect(event.Data).To(Equal(map[string]string{
+						"process-id":  "some-process",
+						"exit-status": "42",
+					}))
+				})
+
+				Context("when exits are tracked", func() {
+					BeforeEach(func() {
+						gdnr.Exits = gardener.NewExitTracker()
+						process.WaitReturns(137, nil)
+
+						var err error
+						container, err = gdnr.Lookup("banana")
+						Expect(err).NotTo(HaveOccurred())
+					})
+
+					It("publishes why the process exited, without guessing that it was signalled", func() {
+						_, err := container.Run(garden.ProcessSpec{}, garden.ProcessIO{})
+						Expect(err).NotTo(HaveOccurred())
+
+						var event gardener.Event
+						Eventually(events).Should(Receive(&event))
+						Expect(event.Data).To(Equal(map[string]string{
+							"process-id":  "some-process",
+							"exit-status": "137",
+							"reason":      "exited",
+						}))
+					})
+				})
+			})
+
+			Context("when the number of processes is limited", func() {
+				var (
+					limiter *gardener.ProcessLimiter
+					exit    chan struct{}
+				)
+
+				BeforeEach(func() {
+					limiter = gardener.NewProcessLimiter(1)
+					gdnr.ProcessLimiter = limiter
+
+					exit = make(chan struct{})
+					process := new(gardenfakes.FakeProcess)
+					process.WaitStub = func() (int, error) {
+						<-exit
+						return 0, nil
+					}
+					containerizer.RunReturns(process, nil)
+
+					var err error
+					container, err = gdnr.Lookup("banana")
+					Expect(err).NotTo(HaveOccurred())
+				})
+
+				AfterEach(func() {
+					select {
+					case <-exit:
+					default:
+						close(exit)
+					}
+				})
+
+				It("rejects processes beyond the limit with a ProcessLimitExceededError", func() {
+					_, err := container.Run(garden.ProcessSpec{}, garden.ProcessIO{})
+					Expect(err).NotTo(HaveOccurred())
+
+					_, err = container.Run(garden.ProcessSpec{}, garden.ProcessIO{})
+					Expect(err).To(Equal(gardener.ProcessLimitExceededError{Handle: "banana", Limit: 1}))
+					Expect(containerizer.RunCallCount()).To(Equal(1))
+				})
+
+				It("allows another process once a process exits", func() {
+					_, err := container.Run(garden.ProcessSpec{}, garden.ProcessIO{})
+					Expect(err).NotTo(HaveOccurred())
+
+					close(exit)
+					Eventually(func() int { return limiter.Active("banana") }).Should(Equal(0))
+
+					_, err = container.Run(garden.ProcessSpec{}, garden.ProcessIO{})
+					Expect(err).NotTo(HaveOccurred())
+				})
+
+				Context("when the containerizer fails to run the process", func() {
+					BeforeEach(func() {
+						containerizer.RunReturns(nil, errors.New("lost my banana"))
+					})
+
+					It("releases the slot", func() {
+						_, err := container.Run(garden.ProcessSpec{}, garden.ProcessIO{})
+						Expect(err).To(HaveOccurred())
+						Expect(limiter.Active("banana")).To(Equal(0))
+					})
+				})
+
+				Context("when the container is destroyed", func() {
+					It("forgets the container's processes", func() {
+						_, err := container.Run(garden.ProcessSpec{}, garden.ProcessIO{})
+						Expect(err).NotTo(HaveOccurred())
+
+						Expect(gdnr.Destroy("banana")).To(Succeed())
+						Expect(limiter.Active("banana")).To(Equal(0))
+					})
+				})
+			})
+
+			Context("when the number of running processes started by Run is limited", func() {
+				var (
+					properties garden.Properties
+					exit       chan struct{}
+				)
+
+				BeforeEach(func() {
+					gdnr.ExecLimiter = gardener.NewExecLimiter(1, 0)
+
+					properties = garden.Properties{}
+					propertyManager.GetStub = func(_, name string) (string, error) {
+						value, ok := properties[name]
+						if !ok {
+							return "", errors.New("no such property")
+						}
+						return value, nil
+					}
+
+					exit = make(chan struct{})
+					runningProcess := func() garden.Process {
+						process := new(gardenfakes.FakeProcess)
+						process.WaitStub = func() (int, error) {
+							<-exit
+							return 0, nil
+						}
+						return process
+					}
+
+					containerizer.RunStub = func(lager.Logger, string, garden.ProcessSpec, garden.ProcessIO) (garden.Process, error) {
+						return runningProcess(), nil
+					}
+					containerizer.AttachStub = func(lager.Logger, string, string, garden.ProcessIO) (garden.Process, error) {
+						return runningProcess(), nil
+					}
+
+					var err error
+					container, err = gdnr.Lookup("banana")
+					Expect(err).NotTo(HaveOccurred())
+				})
+
+				AfterEach(func() {
+					select {
+					case <-exit:
+					default:
+						close(exit)
+					}
+				})
+
+				It("rejects Run requests beyond the limit with an ExecConcurrencyExceededError", func() {
+					_, err := container.Run(garden.ProcessSpec{}, garden.ProcessIO{})
+					Expect(err).NotTo(HaveOccurred())
+
+					_, err = container.Run(garden.ProcessSpec{}, garden.ProcessIO{})
+					Expect(err).To(Equal(gardener.ExecConcurrencyExceededError{Handle: "banana", Limit: 1}))
+					Expect(containerizer.RunCallCount()).To(Equal(1))
+				})
+
+				It("allows another Run request once the running process exits", func() {
+					_, err := container.Run(garden.ProcessSpec{}, garden.ProcessIO{})
+					Expect(err).NotTo(HaveOccurred())
+					close(exit)
+
+					Eventually(func() error {
+						_, err := container.Run(garden.ProcessSpec{}, garden.ProcessIO{})
+						return err
+					}).Should(Succeed())
+				})
+
+				It("frees the slot when the process fails to run", func() {
+					containerizer.RunStub = nil
+					containerizer.RunReturns(nil, errors.New("no runc"))
+					_, err := container.Run(garden.ProcessSpec{}, garden.ProcessIO{})
+					Expect(err).To(MatchError("no runc"))
+
+					containerizer.RunReturns(new(gardenfakes.FakeProcess), nil)
+					_, err = container.Run(garden.ProcessSpec{}, garden.ProcessIO{})
+					Expect(err).NotTo(HaveOccurred())
+				})
+
+				It("holds a slot for each attached process until it exits", func() {
+					_, err := container.Attach("some-process", garden.ProcessIO{})
+					Expect(err).NotTo(HaveOccurred())
+
+					_, err = container.Run(garden.ProcessSpec{}, garden.ProcessIO{})
+					Expect(err).To(Equal(gardener.ExecConcurrencyExceededError{Handle: "banana", Limit: 1}))
+
+					close(exit)
+					Eventually(func() error {
+						_, err := container.Run(garden.ProcessSpec{}, garden.ProcessIO{})
+						return err
+					}).Should(Succeed())
+				})
+
+				Context("when the container's exec-concurrency property is above the server's limit", func() {
+					BeforeEach(func() {
+						properties[gardener.ExecConcurrencyProperty] = "5"
+					})
+
+					It("applies the server's limit", func() {
+						_, err := container.Run(garden.ProcessSpec{}, garden.ProcessIO{})
+						Expect(err).NotTo(HaveOccurred())
+
+						_, err = container.Run(garden.ProcessSpec{}, garden.ProcessIO{})
+						Expect(err).To(Equal(gardener.ExecConcurrencyExceededError{Handle: "banana", Limit: 1}))
+					})
+				})
+
+				Context("when the container's properties allow requests to queue", func() {
+					BeforeEach(func() {
+						properties[gardener.ExecConcurrencyProperty] = "1"
+						properties[gardener.ExecQueueTimeoutProperty] = "5s"
+					})
+
+					It("waits for the running process to exit", func() {
+						_, err := container.Run(garden.ProcessSpec{}, garden.ProcessIO{})
+						Expect(err).NotTo(HaveOccurred())
+
+						done := make(chan error)
+						go func() {
+							_, err := container.Run(garden.ProcessSpec{}, garden.ProcessIO{})
+							done <- err
+						}()
+						Consistently(done).ShouldNot(Receive())
+
+						close(exit)
+						Eventually(done).Should(Receive(BeNil()))
+					})
+				})
+
+				Context("when the container's exec-concurrency property is invalid", func() {
+					BeforeEach(func() {
+						properties[gardener.ExecConcurrencyProperty] = "lots"
+					})
+
+					It("returns an error without running the process", func() {
+						_, err := container.Run(garden.ProcessSpec{}, garden.ProcessIO{})
+						Expect(err).To(MatchError("invalid exec-concurrency property: 'lots'"))
+						Expect(containerizer.RunCallCount()).To(Equal(0))
+					})
+				})
+			})
+
+			Context("when output is rate limited", func() {
+				var stdout *gbytes.Buffer
+
+				BeforeEach(func() {
+					gdnr.OutputLimiter = gardener.NewOutputLimiter(1024, fakeclock.NewFakeClock(time.Now()))
+					propertyManager.AllReturns(garden.Properties{}, nil)
+					stdout = gbytes.NewBuffer()
+
+					var err error
+					container, err = gdnr.Lookup("banana")
+					Expect(err).NotTo(HaveOccurred())
+				})
+
+				It("passes the containerizer the process's output through the limiter", func() {
+					_, err := container.Run(garden.ProcessSpec{}, garden.ProcessIO{Stdout: stdout})
+					Expect(err).NotTo(HaveOccurred())
+
+					_, _, _, io := containerizer.RunArgsForCall(0)
+					Expect(io.Stdout).NotTo(Equal(stdout))
+
+					_, err = io.Stdout.Write([]byte("hello"))
+					Expect(err).NotTo(HaveOccurred())
+					Expect(stdout).To(gbytes.Say("hello"))
+				})
+
+				Context("when the container's output-rate property is invalid", func() {
+					BeforeEach(func() {
+						propertyManager.AllReturns(garden.Properties{gardener.OutputRateProperty: "fast"}, nil)
+					})
+
+					It("returns an error without running the process", func() {
+						_, err := container.Run(garden.ProcessSpec{}, garden.ProcessIO{Stdout: stdout})
+						Expect(err).To(MatchError("invalid output-rate property: 'fast'"))
+						Expect(containerizer.RunCallCount()).To(Equal(0))
+					})
+				})
+
+				Context("when the container's properties cannot be read", func() {
+					BeforeEach(func() {
+						propertyManager.AllReturns(nil, errors.New("no such key space"))
+					})
+
+					It("returns an error without running the process", func() {
+						_, err := container.Run(garden.ProcessSpec{}, garden.ProcessIO{Stdout: stdout})
+						Expect(err).To(MatchError("read output-rate property: no such key space"))
+						Expect(containerizer.RunCallCount()).To(Equal(0))
+					})
+				})
+
+				It("refuses to set an invalid output-rate property", func() {
+					Expect(container.SetProperty(gardener.OutputRateProperty, "fast")).To(MatchError("invalid output-rate property: 'fast'"))
+					Expect(propertyManager.SetCallCount()).To(Equal(0))
+				})
+			})
+		})
+
+		Describe("streaming files in to the container", func() {
+			It("asks the containerizer to stream in the tar stream", func() {
+				spec := garden.StreamInSpec{Path: "potato", User: "chef", TarStream: gbytes.NewBuffer()}
+				Expect(container.StreamIn(spec)).To(Succeed())
+
+				_, handle, specArg := containerizer.StreamInArgsForCall(0)
+				Expect(handle).To(Equal("banana"))
+				Expect(specArg).To(Equal(spec))
+			})
+		})
+
+		Describe("streaming files outside the container", func() {
+			It("asks the containerizer to stream out the files", func() {
+				spec := garden.StreamOutSpec{Path: "potato", User: "chef"}
+				_, err := container.StreamOut(spec)
+				Expect(err).To(Succeed())
+
+				_, handle, specArg := containerizer.StreamOutArgsForCall(0)
+				Expect(handle).To(Equal("banana"))
+				Expect(specArg).To(Equal(spec))
+			})
+		})
+	})
+
+	Describe("listing containers", func() {
+		BeforeEach(func() {
+			containerizer.HandlesReturns([]string{"banana", "banana2", "cola"}, nil)
+		})
+
+		It("should return matching containers", func() {
+			propertyManager.MatchesAllStub = func(handle string, props garden.Properties) bool {
+				if handle != "banana" {
+					return true
+				}
+				return false
+			}
+
+			c, err := gdnr.Containers(garden.Properties{
+				"somename": "somevalue",
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(c).To(HaveLen(2))
+			Expect(c[0].Handle()).To(Equal("banana2"))
+			Expect(c[1].Handle()).To(Equal("cola"))
+		})
+
+		It("refuses label selectors, which the property manager does not support", func() {
+			_, err := gdnr.Containers(garden.Properties{gardener.LabelSelectorProperty: "app=web"})
+			Expect(err).To(MatchError("label selectors are not supported"))
+		})
+
+		Context("when the property manager indexes properties", func() {
+			var index *fakes.FakePropertyIndex
+
+			BeforeEach(func() {
+				index = new(fakes.FakePropertyIndex)
+				gdnr.PropertyManager = struct {
+					*fakes.FakePropertyManager
+					*fakes.FakePropertyIndex
+				}{propertyManager, index}
+			})
+
+			It("selects the containers from the index, with the label selector", func() {
+				// a destroyed container may still be in the index
+				index.SelectReturns([]string{"cola", "banana", "gone"}, nil)
+
+				c, err := gdnr.Containers(garden.Properties{
+					"somename":                     "somevalue",
+					gardener.LabelSelectorProperty: "app in (web,worker)",
+				})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(c).To(HaveLen(2))
+				Expect(c[0].Handle()).To(Equal("banana"))
+				Expect(c[1].Handle()).To(Equal("cola"))
+
+				props, selector := index.SelectArgsForCall(0)
+				Expect(props).To(Equal(garden.Properties{"somename": "somevalue"}))
+				Expect(selector).To(Equal("app in (web,worker)"))
+				Expect(propertyManager.MatchesAllCallCount()).To(Equal(0))
+			})
+
+			It("lists every container without consulting the index when there is no filter", func() {
+				c, err := gdnr.Containers(garden.Properties{})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(c).To(HaveLen(3))
+				Expect(index.SelectCallCount()).To(Equal(0))
+			})
+
+			It("returns the error when the selector is invalid", func() {
+				index.SelectReturns(nil, errors.New("invalid label selector"))
+
+				_, err := gdnr.Containers(garden.Properties{gardener.LabelSelectorProperty: "app in web"})
+				Expect(err).To(MatchError("invalid label selector"))
+			})
+		})
+
+		Describe("NetIn", func() {
+			var container garden.Container
+
+			const (
+				externalPort  uint32 = 8888
+				contianerPort uint32 = 8080
+			)
+
+			BeforeEach(func() {
+				var err error
+				container, err = gdnr.Lookup("banana")
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			It("asks the netwoker to forward the correct ports", func() {
+				_, _, err := container.NetIn(externalPort, contianerPort)
+
+				Expect(err).NotTo(HaveOccurred())
+				Expect(networker.NetInCallCount()).To(Equal(1))
+
+				actualLogger, actualHandle, actualExtPort, actualContainerPort := networker.NetInArgsForCall(0)
+				Expect(actualLogger).To(Equal(logger))
+				Expect(actualHandle).To(Equal(container.Handle()))
+				Expect(actualExtPort).To(Equal(externalPort))
+				Expect(actualContainerPort).To(Equal(contianerPort))
+			})
+
+			Context("when networker returns an error", func() {
+				It("returns the error", func() {
+					networker.NetInReturns(uint32(0), uint32(0), fmt.Errorf("error"))
+
+					_, _, err := container.NetIn(externalPort, contianerPort)
+
+					Expect(err).To(MatchError("error"))
+				})
+			})
+		})
+
+		Describe("NetOut", func() {
+			var (
+				container garden.Container
+				rule      garden.NetOutRule
+			)
+
+			BeforeEach(func() {
+				var err error
+				container, err = gdnr.Lookup("banana")
+				Expect(err).NotTo(HaveOccurred())
+
+				rule = garden.NetOutRule{
+					Networks: []garden.IPRange{garden.IPRangeFromIP(net.ParseIP("8.2.3.4"))},
+					Ports:    []garden.PortRange{garden.PortRangeFromPort(9321)},
+				}
+			})
+
+			It("asks the networker to apply the provided netout rule", func() {
+				Expect(container.NetOut(rule)).To(Succeed())
+				Expect(networker.NetOutCallCount()).To(Equal(1))
+
+				_, handle, actualRule := networker.NetOutArgsForCall(0)
+				Expect(handle).To(Equal("banana"))
+				Expect(actualRule).To(Equal(rule))
+			})
+
+			Context("when networker returns an error", func() {
+				It("return the error", func() {
+					networker.NetOutReturns(fmt.Errorf("banana republic"))
+					Expect(container.NetOut(rule)).To(MatchError("banana republic"))
+				})
+			})
+		})
+
+		Describe("BulkNetOut", func() {
+			var (
+				container garden.Container
+				rules     []garden.NetOutRule
+			)
+
+			BeforeEach(func() {
+				var err error
+				container, err = gdnr.Lookup("banana")
+				Expect(err).NotTo(HaveOccurred())
+
+				rules = []garden.NetOutRule{
+					{Networks: []garden.IPRange{garden.IPRangeFromIP(net.ParseIP("8.2.3.4"))}},
+					{Ports: []garden.PortRange{garden.PortRangeFromPort(9321)}},
+				}
+			})
+
+			It("asks the networker to apply the provided netout rules", func() {
+				Expect(container.BulkNetOut(rules)).To(Succeed())
+				Expect(networker.BulkNetOutCallCount()).To(Equal(1))
+
+				_, handle, actualRules := networker.BulkNetOutArgsForCall(0)
+				Expect(handle).To(Equal("banana"))
+				Expect(actualRules).To(Equal(rules))
+			})
+
+			Context("when networker returns an error", func() {
+				It("return the error", func() {
+					networker.BulkNetOutReturns(fmt.Errorf("banana republic"))
+					Expect(container.BulkNetOut(rules)).To(MatchError("banana republic"))
+				})
+			})
+		})
+	})
+
+	Context("when no containers exist", func() {
+		BeforeEach(func() {
+			containerizer.HandlesReturns([]string{}, nil)
+		})
+
+		It("should return an empty list", func() {
+			containers, err := gdnr.Containers(garden.Properties{})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(containers).To(BeEmpty())
+		})
+	})
+
+	Context("when the containerizer returns an error", func() {
+		testErr := errors.New("failure")
+
+		BeforeEach(func() {
+			containerizer.HandlesReturns([]string{}, testErr)
+		})
+
+		It("should return an error", func() {
+			_, err := gdnr.Containers(garden.Properties{})
+			Expect(err).To(MatchError(testErr))
+		})
+	})
+
+	Describe("destroying a container", func() {
+		It("asks the containerizer to destroy the container", func() {
+			Expect(gdnr.Destroy("some-handle")).To(Succeed())
+			Expect(containerizer.DestroyCallCount()).To(Equal(1))
+			_, handle := containerizer.DestroyArgsForCall(0)
+			Expect(handle).To(Equal("some-handle"))
+		})
+
+		It("asks the networker to destroy the container network", func() {
+			gdnr.Destroy("some-handle")
+			Expect(networker.DestroyCallCount()).To(Equal(1))
+			networkLogger, handleToDestroy := networker.DestroyArgsForCall(0)
+			Expect(handleToDestroy).To(Equal("some-handle"))
+			Expect(networkLogger).To(Equal(logger))
+		})
+
+		It("asks the volume creator to destroy the container rootfs", func() {
+			gdnr.Destroy("some-handle")
+			Expect(volumeCreator.DestroyCallCount()).To(Equal(1))
+			_, handleToDestroy := volumeCreator.DestroyArgsForCall(0)
+			Expect(handleToDestroy).To(Equal("some-handle"))
+		})
+
+		It("unmounts the container's volumes", func() {
+			volumeMounter := new(fakes.FakeVolumeMounter)
+			gdnr.VolumeMounter = volumeMounter
+			propertyManager.GetStub = func(handle, name string) (string, error) {
+				if name == gardener.MountedVolumesKey {
+					return `[{"name": "data", "destination": "/data"}]`, nil
+				}
+
+				return "", errors.New("no such property")
+			}
+
+			Expect(gdnr.Destroy("some-handle")).To(Succeed())
+			Expect(volumeMounter.UnmountCallCount()).To(Equal(1))
+			_, handle, volume := volumeMounter.UnmountArgsForCall(0)
+			Expect(handle).To(Equal("some-handle"))
+			Expect(volume).To(Equal(gardener.Volume{Name: "data", DstPath: "/data"}))
+		})
+
+		It("does not unmount the volumes a client puts in the container's volumes property", func() {
+			volumeMounter := new(fakes.FakeVolumeMounter)
+			gdnr.VolumeMounter = volumeMounter
+			propertyManager.GetStub = func(handle, name string) (string, error) {
+				if name == gardener.VolumesProperty {
+					return `[{"name": "someone-elses", "destination": "/data"}]`, nil
+				}
+
+				return "", errors.New("no such property")
+			}
+
+			Expect(gdnr.Destroy("some-handle")).To(Succeed())
+			Expect(volumeMounter.UnmountCallCount()).To(Equal(0))
+		})
+
+		It("should destroy the key space of the property manager", func() {
+			gdnr.Destroy("some-handle")
+
+			Expect(propertyManager.DestroyKeySpaceCallCount()).To(Equal(1))
+			Expect(propertyManager.DestroyKeySpaceArgsForCall(0)).To(Equal("some-handle"))
+		})
+
+		Context("when containerizer fails to destroy the container", func() {
+			BeforeEach(func() {
+				containerizer.DestroyReturns(errors.New("containerized deletion failed"))
+			})
+
+			It("return the error", func() {
+				err := gdnr.Destroy("some-handle")
+				Expect(err).To(MatchError("containerized deletion failed"))
+			})
+
+			It("should not destroy the network configuration", func() {
+				err := gdnr.Destroy("some-handle")
+				Expect(err).To(HaveOccurred())
+
+				Expect(networker.DestroyCallCount()).To(Equal(0))
+			})
+		})
+
+		Context("when network deletion fails", func() {
+			BeforeEach(func() {
+				networker.DestroyReturns(errors.New("network deletion failed"))
+			})
+
+			It("returns the error", func() {
+				err := gdnr.Destroy("some-handle")
+				Expect(err).To(MatchError("network deletion failed"))
+			})
+
+			It("should not destroy the key space of the property manager", func() {
+				err := gdnr.Destroy("some-handle")
+				Expect(err).To(HaveOccurred())
+
+				Expect(propertyManager.DestroyKeySpaceCallCount()).To(Equal(0))
+			})
+		})
+
+		Context("when destroying the rootfs fails", func() {
+			BeforeEach(func() {
+				volumeCreator.DestroyReturns(errors.New("rootfs deletion failed"))
+			})
+
+			It("returns the error", func() {
+				err := gdnr.Destroy("some-handle")
+				Expect(err).To(MatchError("rootfs deletion failed"))
+			})
+		})
+
+		Context("with a destroy queue", func() {
+			var (
+				unblock   chan struct{}
+				fakeClock *fakeclock.FakeClock
+			)
+
+			BeforeEach(func() {
+				unblock = make(chan struct{})
+				containerizer.DestroyStub = func(lager.Logger, string) error {
+					<-unblock
+					return nil
+				}
+				containerizer.HandlesReturns([]string{"some-handle"}, nil)
+
+				fakeClock = fakeclock.NewFakeClock(time.Now())
+				gdnr.DestroyQueue = &gardener.DestroyQueue{
+					InitialBackoff: time.Second,
+					MaxBackoff:     time.Minute,
+					Clock:          fakeClock,
+					Logger:         logger,
+				}
+			})
+
+			AfterEach(func() {
+				close(unblock)
+			})
+
+			It("returns before the container is destroyed", func() {
+				Expect(gdnr.Destroy("some-handle")).To(Succeed())
+				Expect(volumeCreator.DestroyCallCount()).To(Equal(0))
+
+				unblock <- struct{}{}
+				Eventually(propertyManager.DestroyKeySpaceCallCount).Should(Equal(1))
+			})
+
+			It("hides the container while it is destroyed", func() {
+				containerizer.HandlesReturns([]string{"some-handle", "other-handle"}, nil)
+				propertyManager.MatchesAllReturns(true)
+				Expect(gdnr.Destroy("some-handle")).To(Succeed())
+
+				_, err := gdnr.Lookup("some-handle")
+				Expect(err).To(MatchError(garden.ContainerNotFoundError{Handle: "some-handle"}))
+
+				containers, err := gdnr.Containers(nil)
 				Expect(err).NotTo(HaveOccurred())
-				Expect(networker.NetInCallCount()).To(Equal(1))
+				Expect(containers).To(HaveLen(1))
+				Expect(containers[0].Handle()).To(Equal("other-handle"))
+			})
+
+			It("skips a container whose destroy starts while the containers are listed", func() {
+				containerizer.HandlesReturns([]string{"some-handle", "other-handle"}, nil)
+				propertyManager.MatchesAllStub = func(handle string, _ garden.Properties) bool {
+					if handle == "other-handle" {
+						Expect(gdnr.Destroy("some-handle")).To(Succeed())
+					}
+
+					return true
+				}
+
+				containers, err := gdnr.Containers(nil)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(containers).To(HaveLen(1))
+				Expect(containers[0].Handle()).To(Equal("other-handle"))
+			})
+
+			It("refuses to create a container with the handle while it is destroyed", func() {
+				Expect(gdnr.Destroy("some-handle")).To(Succeed())
+
+				_, err := gdnr.Create(garden.ContainerSpec{Handle: "some-handle"})
+				Expect(err).To(MatchError("container some-handle is still being destroyed"))
+			})
+
+			It("returns a ContainerNotFoundError for a container which does not exist", func() {
+				err := gdnr.Destroy("missing-handle")
+				Expect(err).To(MatchError(garden.ContainerNotFoundError{Handle: "missing-handle"}))
+				Expect(gdnr.DestroyQueue.Destroying("missing-handle")).To(BeFalse())
+			})
+
+			It("returns a ContainerNotFoundError for a container which is already being destroyed", func() {
+				Expect(gdnr.Destroy("some-handle")).To(Succeed())
+
+				err := gdnr.Destroy("some-handle")
+				Expect(err).To(MatchError(garden.ContainerNotFoundError{Handle: "some-handle"}))
+			})
+
+			It("records a failed cleanup once, however often it is retried", func() {
+				metrics := new(fakes.FakeMetricsRecorder)
+				gdnr.Metrics = metrics
+				networker.DestroyReturns(errors.New("iptables"))
+
+				Expect(gdnr.Destroy("some-handle")).To(Succeed())
+				unblock <- struct{}{}
+				Eventually(networker.DestroyCallCount).Should(Equal(1))
+
+				fakeClock.WaitForWatcherAndIncrement(time.Second)
+				unblock <- struct{}{}
+				Eventually(networker.DestroyCallCount).Should(Equal(2))
+
+				Expect(metrics.ContainerDestroyFailedCallCount()).To(Equal(1))
+				stage, class := metrics.ContainerDestroyFailedArgsForCall(0)
+				Expect(stage).To(Equal(gardener.StageNetwork))
+				Expect(class).To(Equal(gardener.FailureNetwork))
+			})
+
+			It("resumes destroying the containers saved as being destroyed", func() {
+				stateDir, err := ioutil.TempDir("", "destroy-queue")
+				Expect(err).NotTo(HaveOccurred())
+				defer os.RemoveAll(stateDir)
+
+				statePath := filepath.Join(stateDir, "destroy-queue.json")
+				Expect(ioutil.WriteFile(statePath, []byte(`["some-handle"]`), 0644)).To(Succeed())
+				gdnr.DestroyQueue.StatePath = statePath
+
+				Expect(gdnr.ResumeDestroys()).To(Succeed())
+				Expect(gdnr.DestroyQueue.Destroying("some-handle")).To(BeTrue())
+
+				unblock <- struct{}{}
+				Eventually(propertyManager.DestroyKeySpaceCallCount).Should(Equal(1))
+				Expect(propertyManager.DestroyKeySpaceArgsForCall(0)).To(Equal("some-handle"))
+			})
+		})
+	})
+
+	Describe("delta listing", func() {
+		var changeLog *gardener.ChangeLog
+
+		BeforeEach(func() {
+			changeLog = gardener.NewChangeLog(100)
+			gdnr.ChangeLog = changeLog
+		})
+
+		It("records created containers", func() {
+			cursor := changeLog.Cursor()
+			_, err := gdnr.Create(garden.ContainerSpec{Handle: "bob"})
+			Expect(err).NotTo(HaveOccurred())
+
+			delta, err := gdnr.ContainersSince(cursor)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(delta.Created).To(ConsistOf("bob"))
+		})
+
+		It("does not record containers which failed to be created", func() {
+			containerizer.CreateReturns(errors.New("boom"))
+			cursor := changeLog.Cursor()
+			gdnr.Create(garden.ContainerSpec{Handle: "bob"})
+
+			delta, err := gdnr.ContainersSince(cursor)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(delta.Created).To(BeEmpty())
+		})
+
+		It("records destroyed containers", func() {
+			cursor := changeLog.Cursor()
+			Expect(gdnr.Destroy("bob")).To(Succeed())
+
+			delta, err := gdnr.ContainersSince(cursor)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(delta.Destroyed).To(ConsistOf("bob"))
+		})
+
+		It("records property changes", func() {
+			container, err := gdnr.Lookup("bob")
+			Expect(err).NotTo(HaveOccurred())
+
+			cursor := changeLog.Cursor()
+			Expect(container.SetProperty("name", "value")).To(Succeed())
+
+			delta, err := gdnr.ContainersSince(cursor)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(delta.Changed).To(ConsistOf("bob"))
+		})
+
+		It("returns a snapshot of all containers with the current cursor", func() {
+			containerizer.HandlesReturns([]string{"apple", "banana"}, nil)
+			changeLog.Record("apple", gardener.ChangeCreated)
+
+			delta, err := gdnr.ContainersSnapshot()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(delta.Created).To(ConsistOf("apple", "banana"))
+			Expect(delta.Cursor).To(Equal(changeLog.Cursor()))
+		})
+
+		Context("when the change log is not enabled", func() {
+			It("returns ErrChangeLogDisabled", func() {
+				gdnr.ChangeLog = nil
+
+				_, err := gdnr.ContainersSince(0)
+				Expect(err).To(Equal(gardener.ErrChangeLogDisabled))
+
+				_, err = gdnr.ContainersSnapshot()
+				Expect(err).To(Equal(gardener.ErrChangeLogDisabled))
+			})
+		})
+	})
+
+	Describe("lifecycle events", func() {
+		var (
+			events      <-chan gardener.Event
+			unsubscribe func()
+		)
+
+		BeforeEach(func() {
+			hub := gardener.NewEventHub(10)
+			events, unsubscribe = hub.Subscribe()
+			gdnr.Events = hub
+		})
+
+		AfterEach(func() {
+			unsubscribe()
+		})
+
+		It("publishes an event when a container is created", func() {
+			_, err := gdnr.Create(garden.ContainerSpec{Handle: "bob"})
+			Expect(err).NotTo(HaveOccurred())
+
+			var event gardener.Event
+			Expect(events).To(Receive(&event))
+			Expect(event.Handle).To(Equal("bob"))
+			Expect(event.Type).To(Equal(gardener.EventCreate))
+		})
+
+		It("publishes an event when a container is destroyed", func() {
+			Expect(gdnr.Destroy("bob")).To(Succeed())
+
+			var event gardener.Event
+			Expect(events).To(Receive(&event))
+			Expect(event.Handle).To(Equal("bob"))
+			Expect(event.Type).To(Equal(gardener.EventDestroy))
+		})
+
+		It("does not publish an event when destroying fails", func() {
+			containerizer.DestroyReturns(errors.New("boom"))
+			Expect(gdnr.Destroy("bob")).NotTo(Succeed())
+
+			Expect(events).NotTo(Receive())
+		})
+	})
+
+	Describe("lifecycle metrics", func() {
+		var metrics *fakes.FakeMetricsRecorder
+
+		BeforeEach(func() {
+			metrics = new(fakes.FakeMetricsRecorder)
+			gdnr.Metrics = metrics
+		})
+
+		It("records the creation of a container", func() {
+			_, err := gdnr.Create(garden.ContainerSpec{Handle: "bob"})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(metrics.ContainerCreatedCallCount()).To(Equal(1))
+			Expect(metrics.ContainerCreatedArgsForCall(0)).To(BeNumerically(">", 0))
+		})
+
+		It("does not record a failed creation", func() {
+			containerizer.CreateReturns(errors.New("boom"))
+			_, err := gdnr.Create(garden.ContainerSpec{Handle: "bob"})
+			Expect(err).To(HaveOccurred())
+
+			Expect(metrics.ContainerCreatedCallCount()).To(Equal(0))
+		})
+
+		It("records the destruction of a container", func() {
+			Expect(gdnr.Destroy("bob")).To(Succeed())
+			Expect(metrics.ContainerDestroyedCallCount()).To(Equal(1))
+		})
+
+		It("does not record a failed destruction", func() {
+			containerizer.DestroyReturns(errors.New("boom"))
+			Expect(gdnr.Destroy("bob")).NotTo(Succeed())
+
+			Expect(metrics.ContainerDestroyedCallCount()).To(Equal(0))
+		})
+
+		Describe("failures", func() {
+			var spec garden.ContainerSpec
+
+			BeforeEach(func() {
+				spec = garden.ContainerSpec{Handle: "bob", Properties: garden.Properties{}}
+			})
+
+			DescribeTable("classifies create failures by stage",
+				func(setup func(), stage, class string) {
+					setup()
+					_, err := gdnr.Create(spec)
+					Expect(err).To(HaveOccurred())
+
+					Expect(metrics.ContainerCreateFailedCallCount()).To(Equal(1))
+					actualStage, actualClass := metrics.ContainerCreateFailedArgsForCall(0)
+					Expect(actualStage).To(Equal(stage))
+					Expect(actualClass).To(Equal(class))
+				},
+				Entry("an invalid spec", func() {
+					spec.Properties[gardener.MaxPidsProperty] = "lots"
+				}, gardener.StageSpec, gardener.FailureInvalidSpec),
+				Entry("network hooks", func() {
+					networker.HooksReturns(gardener.Hooks{}, errors.New("iptables"))
+				}, gardener.StageNetwork, gardener.FailureNetwork),
+				Entry("the image", func() {
+					volumeCreator.CreateReturns("", nil, errors.New("registry down"))
+				}, gardener.StageImage, gardener.FailureImagePull),
+				Entry("the container", func() {
+					containerizer.CreateReturns(errors.New("boom"))
+				}, gardener.StageContainer, gardener.FailureOther),
+				Entry("the properties", func() {
+					limiter := new(fakes.FakeLimiter)
+					limiter.LimitPidsReturns(errors.New("runc update failed"))
+					gdnr.CPULimiter = limiter
+					spec.Properties[gardener.MaxPidsProperty] = "100"
+				}, gardener.StageProperties, gardener.FailureOther),
+			)
+
+			It("does not record the creation of a container whose properties cannot be set", func() {
+				limiter := new(fakes.FakeLimiter)
+				limiter.LimitPidsReturns(errors.New("runc update failed"))
+				gdnr.CPULimiter = limiter
+
+				_, err := gdnr.Create(garden.ContainerSpec{Handle: "bob", Properties: garden.Properties{gardener.MaxPidsProperty: "100"}})
+				Expect(err).To(HaveOccurred())
+				Expect(metrics.ContainerCreatedCallCount()).To(Equal(0))
+			})
+
+			It("uses the class of a classified error", func() {
+				volumeCreator.CreateReturns("", nil, gardener.Classify(gardener.FailurePluginTimeout, errors.New("not ready")))
+				_, err := gdnr.Create(garden.ContainerSpec{Handle: "bob"})
+				Expect(err).To(MatchError("not ready"))
+
+				_, class := metrics.ContainerCreateFailedArgsForCall(0)
+				Expect(class).To(Equal(gardener.FailurePluginTimeout))
+			})
+
+			It("records no failure when the container is created", func() {
+				_, err := gdnr.Create(garden.ContainerSpec{Handle: "bob"})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(metrics.ContainerCreateFailedCallCount()).To(Equal(0))
+			})
+
+			DescribeTable("classifies destroy failures by stage",
+				func(setup func(), stage, class string) {
+					setup()
+					Expect(gdnr.Destroy("bob")).NotTo(Succeed())
+
+					Expect(metrics.ContainerDestroyFailedCallCount()).To(Equal(1))
+					actualStage, actualClass := metrics.ContainerDestroyFailedArgsForCall(0)
+					Expect(actualStage).To(Equal(stage))
+					Expect(actualClass).To(Equal(class))
+				},
+				Entry("the container", func() {
+					containerizer.DestroyReturns(errors.New("runc"))
+				}, gardener.StageContainer, gardener.FailureRunc),
+				Entry("the network", func() {
+					networker.DestroyReturns(errors.New("iptables"))
+				}, gardener.StageNetwork, gardener.FailureNetwork),
+				Entry("the image", func() {
+					volumeCreator.DestroyReturns(errors.New("busy"))
+				}, gardener.StageImage, gardener.FailureOther),
+			)
+		})
+	})
+
+	Describe("getting capacity", func() {
+		BeforeEach(func() {
+			sysinfoProvider.TotalMemoryReturns(999, nil)
+			sysinfoProvider.TotalDiskReturns(888, nil)
+			networker.CapacityReturns(1000)
+		})
+
+		It("returns capacity", func() {
+			capacity, err := gdnr.Capacity()
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(capacity.MemoryInBytes).To(BeEquivalentTo(999))
+			Expect(capacity.DiskInBytes).To(BeEquivalentTo(888))
+			Expect(capacity.MaxContainers).To(BeEquivalentTo(1000))
+		})
+
+		Context("when getting the total memory fails", func() {
+			BeforeEach(func() {
+				sysinfoProvider.TotalMemoryReturns(0, errors.New("whelp"))
+			})
+
+			It("returns the error", func() {
+				_, err := gdnr.Capacity()
+				Expect(sysinfoProvider.TotalMemoryCallCount()).To(Equal(1))
+				Expect(err).To(MatchError(errors.New("whelp")))
+			})
+		})
+
+		Context("when getting the total disk fails", func() {
+			BeforeEach(func() {
+				sysinfoProvider.TotalDiskReturns(0, errors.New("whelp"))
+			})
+
+			It("returns the error", func() {
+				_, err := gdnr.Capacity()
+				Expect(sysinfoProvider.TotalDiskCallCount()).To(Equal(1))
+				Expect(err).To(MatchError(errors.New("whelp")))
+			})
+		})
+	})
+
+	Describe("CPU limits", func() {
+		var (
+			cpuLimiter *fakes.FakeCPULimiter
+			container  garden.Container
+		)
+
+		BeforeEach(func() {
+			cpuLimiter = new(fakes.FakeCPULimiter)
+			gdnr.CPULimiter = cpuLimiter
+
+			var err error
+			container, err = gdnr.Lookup("some-handle")
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("limits the cpu shares and lifts the cpu quota of a container without a cpu-quota property", func() {
+			propertyManager.GetReturns("", errors.New("no such property"))
+
+			Expect(container.LimitCPU(garden.CPULimits{LimitInShares: 512})).To(Succeed())
+
+			Expect(cpuLimiter.LimitCPUCallCount()).To(Equal(1))
+			_, handle, limits, quota := cpuLimiter.LimitCPUArgsForCall(0)
+			Expect(handle).To(Equal("some-handle"))
+			Expect(limits).To(Equal(garden.CPULimits{LimitInShares: 512}))
+			Expect(quota).To(Equal(gardener.CPUQuota{}))
+		})
+
+		It("applies the container's current cpu-quota property", func() {
+			propertyManager.GetStub = func(handle, name string) (string, error) {
+				Expect(name).To(Equal(gardener.CPUQuotaProperty))
+				return "2", nil
+			}
+
+			Expect(container.LimitCPU(garden.CPULimits{})).To(Succeed())
+
+			_, _, _, quota := cpuLimiter.LimitCPUArgsForCall(0)
+			Expect(quota).To(Equal(gardener.CPUQuota{Quota: 200000, Period: 100000}))
+		})
+
+		It("does not limit the cpu when the cpu-quota property is invalid", func() {
+			propertyManager.GetReturns("lots", nil)
+
+			Expect(container.LimitCPU(garden.CPULimits{})).To(MatchError(HavePrefix("invalid cpu-quota property: 'lots'")))
+			Expect(cpuLimiter.LimitCPUCallCount()).To(Equal(0))
+		})
+
+		It("returns the error when the limiter fails", func() {
+			cpuLimiter.LimitCPUReturns(errors.New("no cgroup"))
+			Expect(container.LimitCPU(garden.CPULimits{})).To(MatchError("no cgroup"))
+		})
+
+		It("gets the current cpu limits from the limiter", func() {
+			cpuLimiter.CurrentCPULimitsReturns(garden.CPULimits{LimitInShares: 1024}, nil)
+
+			limits, err := container.CurrentCPULimits()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(limits).To(Equal(garden.CPULimits{LimitInShares: 1024}))
+		})
+
+		Context("when the limiter cannot change memory and pid limits", func() {
+			It("does not limit the memory", func() {
+				Expect(container.LimitMemory(garden.MemoryLimits{LimitInBytes: 1024})).To(Succeed())
+			})
+
+			It("does not report the limits in the container's info", func() {
+				info, err := container.Info()
+				Expect(err).NotTo(HaveOccurred())
+				Expect(info.Properties).NotTo(HaveKey(gardener.LimitsProperty))
+			})
+		})
+	})
+
+	Describe("memory and pid limits", func() {
+		var (
+			limiter   *fakes.FakeLimiter
+			container garden.Container
+		)
+
+		BeforeEach(func() {
+			limiter = new(fakes.FakeLimiter)
+			gdnr.CPULimiter = limiter
+
+			var err error
+			container, err = gdnr.Lookup("some-handle")
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("limits the memory of the running container", func() {
+			Expect(container.LimitMemory(garden.MemoryLimits{LimitInBytes: 1024})).To(Succeed())
+
+			Expect(limiter.LimitMemoryCallCount()).To(Equal(1))
+			_, handle, limits := limiter.LimitMemoryArgsForCall(0)
+			Expect(handle).To(Equal("some-handle"))
+			Expect(limits).To(Equal(garden.MemoryLimits{LimitInBytes: 1024}))
+		})
 
-				actualLogger, actualHandle, actualExtPort, actualContainerPort := networker.NetInArgsForCall(0)
-				Expect(actualLogger).To(Equal(logger))
-				Expect(actualHandle).To(Equal(container.Handle()))
-				Expect(actualExtPort).To(Equal(externalPort))
-				Expect(actualContainerPort).To(Equal(contianerPort))
-			})
+		It("returns the error when limiting the memory fails", func() {
+			limiter.LimitMemoryReturns(errors.New("runc update failed"))
+			Expect(container.LimitMemory(garden.MemoryLimits{})).To(MatchError("runc update failed"))
+		})
 
-			Context("when networker returns an error", func() {
-				It("returns the error", func() {
-					networker.NetInReturns(uint32(0), uint32(0), fmt.Errorf("error"))
+		It("gets the current memory limits from the limiter", func() {
+			limiter.CurrentLimitsReturns(gardener.Limits{Memory: garden.MemoryLimits{LimitInBytes: 2048}}, nil)
 
-					_, _, err := container.NetIn(externalPort, contianerPort)
+			Expect(container.CurrentMemoryLimits()).To(Equal(garden.MemoryLimits{LimitInBytes: 2048}))
+		})
 
-					Expect(err).To(MatchError("error"))
-				})
-			})
+		It("limits the pids of the running container when its max-pids property is set", func() {
+			Expect(container.SetProperty(gardener.MaxPidsProperty, "100")).To(Succeed())
+
+			Expect(limiter.LimitPidsCallCount()).To(Equal(1))
+			_, handle, maxPids := limiter.LimitPidsArgsForCall(0)
+			Expect(handle).To(Equal("some-handle"))
+			Expect(maxPids).To(Equal(int64(100)))
+
+			Expect(propertyManager.SetCallCount()).To(Equal(1))
 		})
 
-		Describe("NetOut", func() {
-			var (
-				container garden.Container
-				rule      garden.NetOutRule
-			)
+		It("does not set an invalid max-pids property", func() {
+			Expect(container.SetProperty(gardener.MaxPidsProperty, "lots")).To(MatchError("invalid max-pids property: 'lots'"))
+
+			Expect(limiter.LimitPidsCallCount()).To(Equal(0))
+			Expect(propertyManager.SetCallCount()).To(Equal(0))
+		})
+
+		It("does not set the max-pids property when limiting the pids fails", func() {
+			limiter.LimitPidsReturns(errors.New("runc update failed"))
+
+			Expect(container.SetProperty(gardener.MaxPidsProperty, "100")).To(MatchError("runc update failed"))
+			Expect(propertyManager.SetCallCount()).To(Equal(0))
+		})
+
+		It("destroys a container whose properties cannot be set when it is created", func() {
+			limiter.LimitPidsReturns(errors.New("runc update failed"))
 
+			_, err := gdnr.Create(garden.ContainerSpec{Handle: "some-handle", Properties: garden.Properties{gardener.MaxPidsProperty: "100"}})
+			Expect(err).To(MatchError("runc update failed"))
+
+			Expect(containerizer.DestroyCallCount()).To(Equal(1))
+			_, handle := containerizer.DestroyArgsForCall(0)
+			Expect(handle).To(Equal("some-handle"))
+		})
+
+		Context("when the server caps containers' pids", func() {
 			BeforeEach(func() {
+				gdnr.MaxPids = 1000
+
 				var err error
-				container, err = gdnr.Lookup("banana")
+				container, err = gdnr.Lookup("some-handle")
 				Expect(err).NotTo(HaveOccurred())
-
-				rule = garden.NetOutRule{
-					Networks: []garden.IPRange{garden.IPRangeFromIP(net.ParseIP("8.2.3.4"))},
-					Ports:    []garden.PortRange{garden.PortRangeFromPort(9321)},
-				}
 			})
 
-			It("asks the networker to apply the provided netout rule", func() {
-				Expect(container.NetOut(rule)).To(Succeed())
-				Expect(networker.NetOutCallCount()).To(Equal(1))
+			It("caps the max-pids property at the server's", func() {
+				Expect(container.SetProperty(gardener.MaxPidsProperty, "100000")).To(Succeed())
 
-				_, handle, actualRule := networker.NetOutArgsForCall(0)
-				Expect(handle).To(Equal("banana"))
-				Expect(actualRule).To(Equal(rule))
+				_, _, maxPids := limiter.LimitPidsArgsForCall(0)
+				Expect(maxPids).To(Equal(int64(1000)))
+
+				_, name, value := propertyManager.SetArgsForCall(0)
+				Expect(name).To(Equal(gardener.MaxPidsProperty))
+				Expect(value).To(Equal("1000"))
 			})
 
-			Context("when networker returns an error", func() {
-				It("return the error", func() {
-					networker.NetOutReturns(fmt.Errorf("banana republic"))
-					Expect(container.NetOut(rule)).To(MatchError("banana republic"))
-				})
+			It("does not let containers remove their pid limit", func() {
+				Expect(container.SetProperty(gardener.MaxPidsProperty, "0")).To(MatchError("invalid max-pids property: containers may not have more than 1000 pids"))
+
+				Expect(limiter.LimitPidsCallCount()).To(Equal(0))
+				Expect(propertyManager.SetCallCount()).To(Equal(0))
 			})
 		})
-	})
 
-	Context("when no containers exist", func() {
-		BeforeEach(func() {
-			containerizer.HandlesReturns([]string{}, nil)
+		It("does not allow the limits property to be set", func() {
+			Expect(container.SetProperty(gardener.LimitsProperty, "{}")).To(HaveOccurred())
+			Expect(propertyManager.SetCallCount()).To(Equal(0))
 		})
 
-		It("should return an empty list", func() {
-			containers, err := gdnr.Containers(garden.Properties{})
+		It("reports the current limits in the container's info, without changing its stored properties", func() {
+			stored := garden.Properties{"spider": "man"}
+			propertyManager.AllReturns(stored, nil)
+			limiter.CurrentLimitsReturns(gardener.Limits{
+				Memory:   garden.MemoryLimits{LimitInBytes: 2048},
+				CPU:      garden.CPULimits{LimitInShares: 512},
+				CPUQuota: gardener.CPUQuota{Quota: 50000, Period: 100000},
+				MaxPids:  100,
+			}, nil)
+
+			info, err := container.Info()
 			Expect(err).NotTo(HaveOccurred())
 
-			Expect(containers).To(BeEmpty())
+			Expect(info.Properties).To(HaveKeyWithValue("spider", "man"))
+			Expect(stored).NotTo(HaveKey(gardener.LimitsProperty))
+
+			var limits gardener.Limits
+			Expect(json.Unmarshal([]byte(info.Properties[gardener.LimitsProperty]), &limits)).To(Succeed())
+			Expect(limits).To(Equal(gardener.Limits{
+				Memory:   garden.MemoryLimits{LimitInBytes: 2048},
+				CPU:      garden.CPULimits{LimitInShares: 512},
+				CPUQuota: gardener.CPUQuota{Quota: 50000, Period: 100000},
+				MaxPids:  100,
+			}))
+		})
+
+		It("leaves the limits out of the container's info when they cannot be read", func() {
+			limiter.CurrentLimitsReturns(gardener.Limits{}, errors.New("no cgroup"))
+
+			info, err := container.Info()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(info.Properties).NotTo(HaveKey(gardener.LimitsProperty))
 		})
 	})
 
-	Context("when the containerizer returns an error", func() {
-		testErr := errors.New("failure")
+	Describe("ages", func() {
+		var (
+			ages      *fakes.FakeAgeReader
+			container garden.Container
+		)
 
 		BeforeEach(func() {
-			containerizer.HandlesReturns([]string{}, testErr)
-		})
+			ages = new(fakes.FakeAgeReader)
+			ages.AgeReturns(90*time.Minute, true)
+			gdnr.Ages = ages
 
-		It("should return an error", func() {
-			_, err := gdnr.Containers(garden.Properties{})
-			Expect(err).To(MatchError(testErr))
+			var err error
+			container, err = gdnr.Lookup("some-handle")
+			Expect(err).NotTo(HaveOccurred())
 		})
-	})
 
-	Describe("destroying a container", func() {
-		It("asks the containerizer to destroy the container", func() {
-			Expect(gdnr.Destroy("some-handle")).To(Succeed())
-			Expect(containerizer.DestroyCallCount()).To(Equal(1))
-			_, handle := containerizer.DestroyArgsForCall(0)
-			Expect(handle).To(Equal("some-handle"))
-		})
+		It("reports the container's current age rather than the age last persisted, without changing its stored properties", func() {
+			stored := garden.Properties{"spider": "man", gardener.AgeProperty: "1h0m0s"}
+			propertyManager.AllReturns(stored, nil)
 
-		It("asks the networker to destroy the container network", func() {
-			gdnr.Destroy("some-handle")
-			Expect(networker.DestroyCallCount()).To(Equal(1))
-			networkLogger, handleToDestroy := networker.DestroyArgsForCall(0)
-			Expect(handleToDestroy).To(Equal("some-handle"))
-			Expect(networkLogger).To(Equal(logger))
-		})
+			info, err := container.Info()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(info.Properties).To(HaveKeyWithValue("spider", "man"))
+			Expect(info.Properties).To(HaveKeyWithValue(gardener.AgeProperty, "1h30m0s"))
 
-		It("asks the volume creator to destroy the container rootfs", func() {
-			gdnr.Destroy("some-handle")
-			Expect(volumeCreator.DestroyCallCount()).To(Equal(1))
-			_, handleToDestroy := volumeCreator.DestroyArgsForCall(0)
-			Expect(handleToDestroy).To(Equal("some-handle"))
+			properties, err := container.Properties()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(properties).To(HaveKeyWithValue(gardener.AgeProperty, "1h30m0s"))
+
+			Expect(container.Property(gardener.AgeProperty)).To(Equal("1h30m0s"))
+			Expect(ages.AgeArgsForCall(0)).To(Equal("some-handle"))
+			Expect(stored).To(HaveKeyWithValue(gardener.AgeProperty, "1h0m0s"))
 		})
 
-		It("should destroy the key space of the property manager", func() {
-			gdnr.Destroy("some-handle")
+		It("reports the age last persisted when the container's age is not known", func() {
+			ages.AgeReturns(0, false)
+			propertyManager.GetReturns("1h0m0s", nil)
 
-			Expect(propertyManager.DestroyKeySpaceCallCount()).To(Equal(1))
-			Expect(propertyManager.DestroyKeySpaceArgsForCall(0)).To(Equal("some-handle"))
+			Expect(container.Property(gardener.AgeProperty)).To(Equal("1h0m0s"))
 		})
 
-		Context("when containerizer fails to destroy the container", func() {
-			BeforeEach(func() {
-				containerizer.DestroyReturns(errors.New("containerized deletion failed"))
-			})
+		It("does not let clients set the age", func() {
+			Expect(container.SetProperty(gardener.AgeProperty, "1000h")).To(MatchError("garden.age property cannot be set"))
+			Expect(propertyManager.SetCallCount()).To(Equal(0))
+		})
+	})
 
-			It("return the error", func() {
-				err := gdnr.Destroy("some-handle")
-				Expect(err).To(MatchError("containerized deletion failed"))
-			})
+	Describe("cpu throttling", func() {
+		var (
+			throttles *fakes.FakeThrottleChecker
+			container garden.Container
+		)
 
-			It("should not destroy the network configuration", func() {
-				err := gdnr.Destroy("some-handle")
-				Expect(err).To(HaveOccurred())
+		BeforeEach(func() {
+			throttles = new(fakes.FakeThrottleChecker)
+			gdnr.Throttles = throttles
 
-				Expect(networker.DestroyCallCount()).To(Equal(0))
-			})
+			var err error
+			container, err = gdnr.Lookup("some-handle")
+			Expect(err).NotTo(HaveOccurred())
 		})
 
-		Context("when network deletion fails", func() {
-			BeforeEach(func() {
-				networker.DestroyReturns(errors.New("network deletion failed"))
-			})
+		It("reports whether the container is throttled in its info", func() {
+			throttles.IsThrottledReturns(true)
 
-			It("returns the error", func() {
-				err := gdnr.Destroy("some-handle")
-				Expect(err).To(MatchError("network deletion failed"))
-			})
+			info, err := container.Info()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(info.Properties).To(HaveKeyWithValue(gardener.CPUThrottledProperty, "true"))
+			Expect(throttles.IsThrottledArgsForCall(0)).To(Equal("some-handle"))
 
-			It("should not destroy the key space of the property manager", func() {
-				err := gdnr.Destroy("some-handle")
-				Expect(err).To(HaveOccurred())
+			throttles.IsThrottledReturns(false)
 
-				Expect(propertyManager.DestroyKeySpaceCallCount()).To(Equal(0))
-			})
+			info, err = container.Info()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(info.Properties).To(HaveKeyWithValue(gardener.CPUThrottledProperty, "false"))
 		})
 
-		Context("when destroying the rootfs fails", func() {
-			BeforeEach(func() {
-				volumeCreator.DestroyReturns(errors.New("rootfs deletion failed"))
-			})
-
-			It("returns the error", func() {
-				err := gdnr.Destroy("some-handle")
-				Expect(err).To(MatchError("rootfs deletion failed"))
-			})
+		It("does not let clients set whether the container is throttled", func() {
+			Expect(container.SetProperty(gardener.CPUThrottledProperty, "false")).To(MatchError("garden.cpu-throttled property cannot be set"))
+			Expect(propertyManager.SetCallCount()).To(Equal(0))
 		})
 	})
 
-	Describe("getting capacity", func() {
+	Describe("bandwidth limits", func() {
+		var bandwidthNetworker *fakes.FakeBandwidthNetworker
+
 		BeforeEach(func() {
-			sysinfoProvider.TotalMemoryReturns(999, nil)
-			sysinfoProvider.TotalDiskReturns(888, nil)
-			networker.CapacityReturns(1000)
+			bandwidthNetworker = new(fakes.FakeBandwidthNetworker)
+			gdnr.Networker = struct {
+				*fakes.FakeNetworker
+				*fakes.FakeBandwidthNetworker
+			}{networker, bandwidthNetworker}
 		})
 
-		It("returns capacity", func() {
-			capacity, err := gdnr.Capacity()
+		It("limits the bandwidth of a new container", func() {
+			limits := garden.BandwidthLimits{RateInBytesPerSecond: 1024, BurstRateInBytesPerSecond: 4096}
+
+			_, err := gdnr.Create(garden.ContainerSpec{Handle: "bob", Limits: garden.Limits{Bandwidth: limits}})
 			Expect(err).NotTo(HaveOccurred())
 
-			Expect(capacity.MemoryInBytes).To(BeEquivalentTo(999))
-			Expect(capacity.DiskInBytes).To(BeEquivalentTo(888))
-			Expect(capacity.MaxContainers).To(BeEquivalentTo(1000))
+			Expect(bandwidthNetworker.LimitBandwidthCallCount()).To(Equal(1))
+			_, handle, limitsArg := bandwidthNetworker.LimitBandwidthArgsForCall(0)
+			Expect(handle).To(Equal("bob"))
+			Expect(limitsArg).To(Equal(limits))
 		})
 
-		Context("when getting the total memory fails", func() {
-			BeforeEach(func() {
-				sysinfoProvider.TotalMemoryReturns(0, errors.New("whelp"))
-			})
+		It("does not limit the bandwidth of containers without limits", func() {
+			_, err := gdnr.Create(garden.ContainerSpec{Handle: "bob"})
+			Expect(err).NotTo(HaveOccurred())
 
-			It("returns the error", func() {
-				_, err := gdnr.Capacity()
-				Expect(sysinfoProvider.TotalMemoryCallCount()).To(Equal(1))
-				Expect(err).To(MatchError(errors.New("whelp")))
+			Expect(bandwidthNetworker.LimitBandwidthCallCount()).To(Equal(0))
+		})
+
+		Context("when the limits cannot be applied to a new container", func() {
+			It("destroys the container and returns an error", func() {
+				bandwidthNetworker.LimitBandwidthReturns(errors.New("tc failed"))
+
+				_, err := gdnr.Create(garden.ContainerSpec{Handle: "bob", Limits: garden.Limits{
+					Bandwidth: garden.BandwidthLimits{RateInBytesPerSecond: 1024},
+				}})
+				Expect(err).To(MatchError("limit bandwidth: tc failed"))
+				Expect(containerizer.DestroyCallCount()).To(Equal(1))
 			})
 		})
 
-		Context("when getting the total disk fails", func() {
+		It("limits and reports the bandwidth of a running container", func() {
+			container, err := gdnr.Lookup("bob")
+			Expect(err).NotTo(HaveOccurred())
+
+			limits := garden.BandwidthLimits{RateInBytesPerSecond: 2048}
+			Expect(container.LimitBandwidth(limits)).To(Succeed())
+			_, _, limitsArg := bandwidthNetworker.LimitBandwidthArgsForCall(0)
+			Expect(limitsArg).To(Equal(limits))
+
+			bandwidthNetworker.CurrentBandwidthLimitsReturns(limits, nil)
+			Expect(container.CurrentBandwidthLimits()).To(Equal(limits))
+		})
+
+		Context("when the networker cannot limit bandwidth", func() {
 			BeforeEach(func() {
-				sysinfoProvider.TotalDiskReturns(0, errors.New("whelp"))
+				gdnr.Networker = networker
 			})
 
-			It("returns the error", func() {
-				_, err := gdnr.Capacity()
-				Expect(sysinfoProvider.TotalDiskCallCount()).To(Equal(1))
-				Expect(err).To(MatchError(errors.New("whelp")))
+			It("refuses to create containers with limits rather than ignoring them", func() {
+				_, err := gdnr.Create(garden.ContainerSpec{Handle: "bob", Limits: garden.Limits{
+					Bandwidth: garden.BandwidthLimits{RateInBytesPerSecond: 1024},
+				}})
+				Expect(err).To(MatchError("limit bandwidth: " + gardener.ErrBandwidthLimitsNotSupported.Error()))
+			})
+
+			It("returns an error when limiting a running container", func() {
+				container, err := gdnr.Lookup("bob")
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(container.LimitBandwidth(garden.BandwidthLimits{})).To(MatchError(gardener.ErrBandwidthLimitsNotSupported))
 			})
 		})
 	})
@@ -711,6 +2797,120 @@ var _ = Describe("Gardener", func() {
 			Expect(handle).To(Equal("some-handle"))
 			Expect(name).To(Equal("name"))
 		})
+
+		DescribeTable("network state properties",
+			func(name string) {
+				Expect(container.SetProperty(name, "[]")).To(MatchError(name + " property cannot be set"))
+				Expect(container.RemoveProperty(name)).To(MatchError(name + " property cannot be set"))
+
+				_, err := gdnr.Create(garden.ContainerSpec{Properties: garden.Properties{name: "[]"}})
+				Expect(err).To(MatchError(name + " property cannot be set"))
+
+				Expect(propertyManager.SetCallCount()).To(Equal(0))
+				Expect(propertyManager.RemoveCallCount()).To(Equal(0))
+			},
+			Entry("net out rules", gardener.NetOutRulesKey),
+			Entry("mapped ports", gardener.MappedPortsKey),
+			Entry("the iptables instance", "kawasaki.iptable-inst"),
+		)
+
+		Describe("the recorded spec", func() {
+			It("is not shown to clients", func() {
+				propertyManager.AllReturns(garden.Properties{"name": "value", gardener.SpecKey: `{"Privileged":false}`}, nil)
+
+				Expect(container.Properties()).To(Equal(garden.Properties{"name": "value"}))
+
+				_, err := container.Property(gardener.SpecKey)
+				Expect(err).To(HaveOccurred())
+				Expect(propertyManager.GetCallCount()).To(Equal(0))
+			})
+
+			It("cannot be changed by clients", func() {
+				Expect(container.SetProperty(gardener.SpecKey, `{"Privileged":true}`)).To(MatchError("garden.spec property cannot be set"))
+				Expect(container.RemoveProperty(gardener.SpecKey)).To(MatchError("garden.spec property cannot be set"))
+				Expect(propertyManager.SetCallCount()).To(Equal(0))
+			})
+		})
+
+		It("refuses changes to the volumes a container was created with", func() {
+			Expect(container.SetProperty(gardener.VolumesProperty, "[]")).To(MatchError("volumes property can only be set when the container is created"))
+			Expect(container.SetProperty(gardener.MountedVolumesKey, "[]")).To(MatchError("garden.mounted-volumes property cannot be set"))
+			Expect(propertyManager.SetCallCount()).To(Equal(0))
+		})
+	})
+
+	Describe("grace time", func() {
+		It("stores the grace time of a new container as a property", func() {
+			_, err := gdnr.Create(garden.ContainerSpec{Handle: "some-handle", GraceTime: time.Minute})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(propertyManager.SetCallCount()).To(Equal(2))
+			handle, name, value := propertyManager.SetArgsForCall(0)
+			Expect(handle).To(Equal("some-handle"))
+			Expect(name).To(Equal(gardener.GraceTimeKey))
+			Expect(value).To(Equal("1m0s"))
+		})
+
+		It("uses the default grace time when the container does not have one", func() {
+			gdnr.DefaultGraceTime = gardener.NewDefaultGraceTime(time.Hour)
+
+			_, err := gdnr.Create(garden.ContainerSpec{Handle: "some-handle"})
+			Expect(err).NotTo(HaveOccurred())
+
+			_, _, value := propertyManager.SetArgsForCall(0)
+			Expect(value).To(Equal("1h0m0s"))
+		})
+
+		It("uses the latest default grace time", func() {
+			gdnr.DefaultGraceTime = gardener.NewDefaultGraceTime(time.Hour)
+			gdnr.DefaultGraceTime.Set(time.Second)
+
+			_, err := gdnr.Create(garden.ContainerSpec{Handle: "some-handle"})
+			Expect(err).NotTo(HaveOccurred())
+
+			_, _, value := propertyManager.SetArgsForCall(0)
+			Expect(value).To(Equal("1s"))
+		})
+
+		It("does not store a grace time when there is neither a container grace time nor a default", func() {
+			_, err := gdnr.Create(garden.ContainerSpec{Handle: "some-handle"})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(propertyManager.SetCallCount()).To(Equal(1))
+			_, name, _ := propertyManager.SetArgsForCall(0)
+			Expect(name).To(Equal(gardener.SpecKey))
+		})
+
+		It("returns the stored grace time", func() {
+			propertyManager.GetReturns("30s", nil)
+
+			container, err := gdnr.Lookup("some-handle")
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(gdnr.GraceTime(container)).To(Equal(30 * time.Second))
+			handle, name := propertyManager.GetArgsForCall(0)
+			Expect(handle).To(Equal("some-handle"))
+			Expect(name).To(Equal(gardener.GraceTimeKey))
+		})
+
+		It("returns 0 when the container has no grace time", func() {
+			propertyManager.GetReturns("", errors.New("no such property"))
+
+			container, err := gdnr.Lookup("some-handle")
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(gdnr.GraceTime(container)).To(BeZero())
+		})
+
+		It("stores the grace time when it is set on the container", func() {
+			container, err := gdnr.Lookup("some-handle")
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(container.SetGraceTime(5 * time.Minute)).To(Succeed())
+			_, name, value := propertyManager.SetArgsForCall(0)
+			Expect(name).To(Equal(gardener.GraceTimeKey))
+			Expect(value).To(Equal("5m0s"))
+		})
 	})
 
 	Describe("BulkInfo", func() {
@@ -787,6 +2987,15 @@ var _ = Describe("Gardener", func() {
 			Expect(info.State).To(Equal("active"))
 		})
 
+		It("returns the events recorded by the containerizer", func() {
+			containerizer.InfoReturns(gardener.ActualContainerSpec{Events: []string{"out of memory"}}, nil)
+
+			info, err := container.Info()
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(info.Events).To(Equal([]string{"out of memory"}))
+		})
+
 		It("returns the garden.network.container-ip property from the propertyManager as the ContainerIP", func() {
 			properties[gardener.ContainerIPKey] = "1.2.3.4"
 
@@ -0,0 +1,40 @@
+package gardener
+
+import (
+	"sync"
+	"time"
+)
+
+// GraceTimeKey is the property which holds a container's grace time
+const GraceTimeKey = "garden.grace-time"
+
+// DefaultGraceTime holds the grace time of containers which are created
+// without one. It may be changed while the server is running, which affects
+// containers created afterwards. A nil DefaultGraceTime is 0, meaning such
+// containers are never reaped.
+type DefaultGraceTime struct {
+	mu        sync.RWMutex
+	graceTime time.Duration
+}
+
+func NewDefaultGraceTime(graceTime time.Duration) *DefaultGraceTime {
+	return &DefaultGraceTime{graceTime: graceTime}
+}
+
+func (d *DefaultGraceTime) Get() time.Duration {
+	if d == nil {
+		return 0
+	}
+
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	return d.graceTime
+}
+
+func (d *DefaultGraceTime) Set(graceTime time.Duration) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.graceTime = graceTime
+}
@@ -0,0 +1,137 @@
+package gardener
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/cloudfoundry-incubator/garden"
+	"github.com/cloudfoundry-incubator/garden-shed/rootfs_provider"
+	"github.com/opencontainers/specs"
+	"github.com/pivotal-golang/lager"
+)
+
+// UIDMappingsProperty and GIDMappingsProperty are the container properties
+// which may hold user namespace id mappings for an unprivileged container, to
+// use instead of the server's default mappings. Each is a comma-separated list
+// of containerID:hostID:size mappings. If only UIDMappingsProperty is given
+// the same mappings are used for gids.
+const (
+	UIDMappingsProperty = "uid-mappings"
+	GIDMappingsProperty = "gid-mappings"
+)
+
+var errMappingsNotSupported = errors.New("the volume creator does not support per-container id mappings")
+
+// IDMappings are the uid and gid mappings of a container's user namespace
+type IDMappings struct {
+	UID rootfs_provider.MappingList
+	GID rootfs_provider.MappingList
+}
+
+//go:generate counterfeiter . MappingVolumeCreator
+
+// MappingVolumeCreator is implemented by VolumeCreators which can create a
+// rootfs for an unprivileged container with its own id mappings
+type MappingVolumeCreator interface {
+	CreateMapped(log lager.Logger, handle string, spec rootfs_provider.Spec, mappings IDMappings) (string, []string, error)
+}
+
+// ParseMappingList parses a comma-separated list of containerID:hostID:size
+// mappings.
+func ParseMappingList(s string) (rootfs_provider.MappingList, error) {
+	var mappings rootfs_provider.MappingList
+	for _, part := range strings.Split(s, ",") {
+		fields := strings.Split(strings.TrimSpace(part), ":")
+		if len(fields) != 3 {
+			return nil, fmt.Errorf("invalid mapping '%s': expected containerID:hostID:size", part)
+		}
+
+		var ids [3]uint32
+		for i, field := range fields {
+			id, err := strconv.ParseUint(field, 10, 32)
+			if err != nil {
+				return nil, fmt.Errorf("invalid mapping '%s': %s", part, err)
+			}
+
+			ids[i] = uint32(id)
+		}
+
+		if ids[2] == 0 {
+			return nil, fmt.Errorf("invalid mapping '%s': size must be greater than 0", part)
+		}
+
+		mappings = append(mappings, specs.IDMapping{ContainerID: ids[0], HostID: ids[1], Size: ids[2]})
+	}
+
+	return mappings, nil
+}
+
+// parseIDMappings returns the container's own id mappings, or nil if it uses
+// the server's defaults. The host ids of the container's mappings must lie
+// within the host ids of the defaults, so that clients can only choose
+// between ranges which the operator has set aside for containers.
+func parseIDMappings(spec garden.ContainerSpec, defaults *IDMappings) (*IDMappings, error) {
+	rawUID, hasUID := spec.Properties[UIDMappingsProperty]
+	rawGID, hasGID := spec.Properties[GIDMappingsProperty]
+	if !hasUID && !hasGID {
+		return nil, nil
+	}
+
+	if spec.Privileged {
+		return nil, fmt.Errorf("id mappings cannot be set for privileged containers")
+	}
+
+	if defaults == nil {
+		return nil, fmt.Errorf("per-container id mappings are not supported")
+	}
+
+	if !hasUID {
+		return nil, fmt.Errorf("%s property requires the %s property", GIDMappingsProperty, UIDMappingsProperty)
+	}
+
+	if !hasGID {
+		rawGID = rawUID
+	}
+
+	uids, err := ParseMappingList(rawUID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s property: %s", UIDMappingsProperty, err)
+	}
+
+	gids, err := ParseMappingList(rawGID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s property: %s", GIDMappingsProperty, err)
+	}
+
+	if err := checkWithin(uids, defaults.UID); err != nil {
+		return nil, fmt.Errorf("invalid %s property: %s", UIDMappingsProperty, err)
+	}
+
+	if err := checkWithin(gids, defaults.GID); err != nil {
+		return nil, fmt.Errorf("invalid %s property: %s", GIDMappingsProperty, err)
+	}
+
+	return &IDMappings{UID: uids, GID: gids}, nil
+}
+
+func checkWithin(mappings, allowed rootfs_provider.MappingList) error {
+	for _, m := range mappings {
+		if !hostRangeWithin(uint64(m.HostID), uint64(m.Size), allowed) {
+			return fmt.Errorf("host ids %d-%d are outside the ranges available to containers", m.HostID, uint64(m.HostID)+uint64(m.Size)-1)
+		}
+	}
+
+	return nil
+}
+
+func hostRangeWithin(start, size uint64, allowed rootfs_provider.MappingList) bool {
+	for _, a := range allowed {
+		if start >= uint64(a.HostID) && start+size <= uint64(a.HostID)+uint64(a.Size) {
+			return true
+		}
+	}
+
+	return false
+}
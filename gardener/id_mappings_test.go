@@ -0,0 +1,171 @@
+package gardener_test
+
+import (
+	"errors"
+
+	"github.com/cloudfoundry-incubator/garden"
+	"github.com/cloudfoundry-incubator/garden-shed/rootfs_provider"
+	"github.com/cloudfoundry-incubator/guardian/gardener"
+	"github.com/cloudfoundry-incubator/guardian/gardener/fakes"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/opencontainers/specs"
+	"github.com/pivotal-golang/lager/lagertest"
+)
+
+type fakeMappingVolumeCreator struct {
+	*fakes.FakeVolumeCreator
+	*fakes.FakeMappingVolumeCreator
+}
+
+var _ = Describe("ParseMappingList", func() {
+	It("parses a comma-separated list of mappings", func() {
+		Expect(gardener.ParseMappingList("0:100000:1,1:100001:65535")).To(Equal(rootfs_provider.MappingList{
+			specs.IDMapping{ContainerID: 0, HostID: 100000, Size: 1},
+			specs.IDMapping{ContainerID: 1, HostID: 100001, Size: 65535},
+		}))
+	})
+
+	It("rejects mappings without three fields", func() {
+		_, err := gardener.ParseMappingList("0:100000")
+		Expect(err).To(MatchError("invalid mapping '0:100000': expected containerID:hostID:size"))
+	})
+
+	It("rejects mappings with non-numeric fields", func() {
+		_, err := gardener.ParseMappingList("0:lots:1")
+		Expect(err).To(MatchError(ContainSubstring("invalid mapping '0:lots:1'")))
+	})
+
+	It("rejects empty mappings", func() {
+		_, err := gardener.ParseMappingList("0:100000:0")
+		Expect(err).To(MatchError("invalid mapping '0:100000:0': size must be greater than 0"))
+	})
+})
+
+var _ = Describe("Creating a container with its own id mappings", func() {
+	var (
+		containerizer *fakes.FakeContainerizer
+		volumeCreator fakeMappingVolumeCreator
+		gdnr          *gardener.Gardener
+	)
+
+	BeforeEach(func() {
+		containerizer = new(fakes.FakeContainerizer)
+		volumeCreator = fakeMappingVolumeCreator{new(fakes.FakeVolumeCreator), new(fakes.FakeMappingVolumeCreator)}
+		volumeCreator.CreateMappedReturns("/path/to/rootfs", nil, nil)
+
+		gdnr = &gardener.Gardener{
+			Containerizer:   containerizer,
+			Networker:       new(fakes.FakeNetworker),
+			VolumeCreator:   volumeCreator,
+			PropertyManager: new(fakes.FakePropertyManager),
+			UidGenerator:    new(fakes.FakeUidGenerator),
+			Logger:          lagertest.NewTestLogger("test"),
+			IDMappings: &gardener.IDMappings{
+				UID: rootfs_provider.MappingList{{ContainerID: 0, HostID: 100000, Size: 200000}},
+				GID: rootfs_provider.MappingList{{ContainerID: 0, HostID: 500000, Size: 200000}},
+			},
+		}
+	})
+
+	create := func(properties garden.Properties) error {
+		_, err := gdnr.Create(garden.ContainerSpec{Handle: "bob", Properties: properties})
+		return err
+	}
+
+	It("creates the rootfs and the container with the mappings", func() {
+		Expect(create(garden.Properties{
+			gardener.UIDMappingsProperty: "0:100000:65536",
+			gardener.GIDMappingsProperty: "0:500000:65536",
+		})).To(Succeed())
+
+		expected := gardener.IDMappings{
+			UID: rootfs_provider.MappingList{{ContainerID: 0, HostID: 100000, Size: 65536}},
+			GID: rootfs_provider.MappingList{{ContainerID: 0, HostID: 500000, Size: 65536}},
+		}
+
+		Expect(volumeCreator.FakeVolumeCreator.CreateCallCount()).To(Equal(0))
+		Expect(volumeCreator.CreateMappedCallCount()).To(Equal(1))
+		_, handle, spec, mappings := volumeCreator.CreateMappedArgsForCall(0)
+		Expect(handle).To(Equal("bob"))
+		Expect(spec.Namespaced).To(BeTrue())
+		Expect(mappings).To(Equal(expected))
+
+		_, desiredSpec := containerizer.CreateArgsForCall(0)
+		Expect(desiredSpec.RootFSPath).To(Equal("/path/to/rootfs"))
+		Expect(desiredSpec.IDMappings).To(Equal(&expected))
+	})
+
+	It("uses the uid mappings for gids when no gid mappings are given", func() {
+		Expect(create(garden.Properties{gardener.UIDMappingsProperty: "0:100000:65536"})).To(MatchError(
+			"invalid gid-mappings property: host ids 100000-165535 are outside the ranges available to containers",
+		))
+
+		gdnr.IDMappings.GID = gdnr.IDMappings.UID
+		Expect(create(garden.Properties{gardener.UIDMappingsProperty: "0:100000:65536"})).To(Succeed())
+
+		_, desiredSpec := containerizer.CreateArgsForCall(0)
+		Expect(desiredSpec.IDMappings.GID).To(Equal(desiredSpec.IDMappings.UID))
+	})
+
+	It("does not set mappings when the container uses the defaults", func() {
+		Expect(create(nil)).To(Succeed())
+
+		Expect(volumeCreator.FakeVolumeCreator.CreateCallCount()).To(Equal(1))
+		_, desiredSpec := containerizer.CreateArgsForCall(0)
+		Expect(desiredSpec.IDMappings).To(BeNil())
+	})
+
+	It("rejects mappings outside of the default host ranges", func() {
+		err := create(garden.Properties{gardener.UIDMappingsProperty: "0:0:1"})
+		Expect(err).To(MatchError("invalid uid-mappings property: host ids 0-0 are outside the ranges available to containers"))
+
+		err = create(garden.Properties{gardener.UIDMappingsProperty: "0:250000:65536"})
+		Expect(err).To(MatchError("invalid uid-mappings property: host ids 250000-315535 are outside the ranges available to containers"))
+
+		Expect(containerizer.CreateCallCount()).To(Equal(0))
+	})
+
+	It("rejects invalid mappings", func() {
+		Expect(create(garden.Properties{gardener.UIDMappingsProperty: "banana"})).To(MatchError(ContainSubstring("invalid uid-mappings property")))
+	})
+
+	It("rejects gid mappings without uid mappings", func() {
+		Expect(create(garden.Properties{gardener.GIDMappingsProperty: "0:500000:1"})).To(MatchError("gid-mappings property requires the uid-mappings property"))
+	})
+
+	It("rejects mappings for privileged containers", func() {
+		_, err := gdnr.Create(garden.ContainerSpec{
+			Privileged: true,
+			Properties: garden.Properties{gardener.UIDMappingsProperty: "0:100000:1"},
+		})
+		Expect(err).To(MatchError("id mappings cannot be set for privileged containers"))
+	})
+
+	Context("when the server has no default mappings", func() {
+		BeforeEach(func() {
+			gdnr.IDMappings = nil
+		})
+
+		It("rejects per-container mappings", func() {
+			Expect(create(garden.Properties{gardener.UIDMappingsProperty: "0:100000:1"})).To(MatchError("per-container id mappings are not supported"))
+		})
+	})
+
+	Context("when the volume creator does not support per-container mappings", func() {
+		BeforeEach(func() {
+			gdnr.VolumeCreator = new(fakes.FakeVolumeCreator)
+		})
+
+		It("returns an error", func() {
+			Expect(create(garden.Properties{gardener.UIDMappingsProperty: "0:100000:1"})).To(MatchError("the volume creator does not support per-container id mappings"))
+		})
+	})
+
+	Context("when creating the rootfs fails", func() {
+		It("returns the error", func() {
+			volumeCreator.CreateMappedReturns("", nil, errors.New("chown-failed"))
+			Expect(create(garden.Properties{gardener.UIDMappingsProperty: "0:100000:1", gardener.GIDMappingsProperty: "0:500000:1"})).To(MatchError("chown-failed"))
+		})
+	})
+})
@@ -0,0 +1,86 @@
+package gardener
+
+import (
+	"encoding/json"
+
+	"github.com/cloudfoundry-incubator/garden"
+	"github.com/pivotal-golang/lager"
+)
+
+// ImageConfigKey is the property which holds, as JSON, the defaults from
+// the config of the image the container's rootfs was created from, so that
+// clients can see them in the container's info
+const ImageConfigKey = "garden.image-config"
+
+// ImageConfig is the part of an OCI image config which gives processes
+// defaults. The image's environment is returned by the VolumeCreator.
+type ImageConfig struct {
+	Entrypoint []string `json:"entrypoint,omitempty"`
+	Cmd        []string `json:"cmd,omitempty"`
+	User       string   `json:"user,omitempty"`
+	WorkingDir string   `json:"working_dir,omitempty"`
+}
+
+//go:generate counterfeiter . ImageConfigVolumeCreator
+
+// ImageConfigVolumeCreator is implemented by VolumeCreators which can return
+// the config of the image a rootfs was created from
+type ImageConfigVolumeCreator interface {
+	// ImageConfig returns the zero ImageConfig if the rootfs was not created
+	// from an image
+	ImageConfig(log lager.Logger, handle string) (ImageConfig, error)
+}
+
+func (c ImageConfig) Empty() bool {
+	return len(c.Entrypoint) == 0 && len(c.Cmd) == 0 && c.User == "" && c.WorkingDir == ""
+}
+
+// Defaults fills in the parts of spec which were not given from the image
+// config, as docker does: a process without a path runs the entrypoint with
+// spec's args, or with the image's cmd if there are none.
+func (c ImageConfig) Defaults(spec garden.ProcessSpec) garden.ProcessSpec {
+	if spec.Path == "" {
+		args := spec.Args
+		if len(args) == 0 {
+			args = c.Cmd
+		}
+
+		if command := append(append([]string{}, c.Entrypoint...), args...); len(command) > 0 {
+			spec.Path, spec.Args = command[0], command[1:]
+		}
+	}
+
+	if spec.User == "" {
+		spec.User = c.User
+	}
+
+	if spec.Dir == "" {
+		spec.Dir = c.WorkingDir
+	}
+
+	return spec
+}
+
+func (g *Gardener) imageConfig(log lager.Logger, handle string) (ImageConfig, error) {
+	volumeCreator, ok := g.VolumeCreator.(ImageConfigVolumeCreator)
+	if !ok {
+		return ImageConfig{}, nil
+	}
+
+	return volumeCreator.ImageConfig(log, handle)
+}
+
+func (c *container) withImageDefaults(spec garden.ProcessSpec) garden.ProcessSpec {
+	value, err := c.propertyManager.Get(c.handle, ImageConfigKey)
+	if err != nil || value == "" {
+		return spec
+	}
+
+	var config ImageConfig
+	if err := json.Unmarshal([]byte(value), &config); err != nil {
+		c.logger.Error("invalid-image-config", err, lager.Data{"value": value})
+		return spec
+	}
+
+	return config.Defaults(spec)
+}
@@ -0,0 +1,142 @@
+package gardener_test
+
+import (
+	"errors"
+
+	"github.com/cloudfoundry-incubator/garden"
+	"github.com/cloudfoundry-incubator/guardian/gardener"
+	"github.com/cloudfoundry-incubator/guardian/gardener/fakes"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/pivotal-golang/lager/lagertest"
+)
+
+type fakeImageConfigVolumeCreator struct {
+	*fakes.FakeVolumeCreator
+	*fakes.FakeImageConfigVolumeCreator
+}
+
+var _ = Describe("Image config", func() {
+	Describe("Defaults", func() {
+		config := gardener.ImageConfig{
+			Entrypoint: []string{"/bin/app", "--verbose"},
+			Cmd:        []string{"--serve"},
+			User:       "app",
+			WorkingDir: "/srv",
+		}
+
+		It("runs the entrypoint with the cmd when no path or args are given", func() {
+			spec := config.Defaults(garden.ProcessSpec{})
+			Expect(spec).To(Equal(garden.ProcessSpec{
+				Path: "/bin/app",
+				Args: []string{"--verbose", "--serve"},
+				User: "app",
+				Dir:  "/srv",
+			}))
+		})
+
+		It("runs the entrypoint with the given args instead of the cmd", func() {
+			spec := config.Defaults(garden.ProcessSpec{Args: []string{"--migrate"}})
+			Expect(spec.Path).To(Equal("/bin/app"))
+			Expect(spec.Args).To(Equal([]string{"--verbose", "--migrate"}))
+		})
+
+		It("runs the cmd when there is no entrypoint", func() {
+			spec := gardener.ImageConfig{Cmd: []string{"sh", "-c", "echo hi"}}.Defaults(garden.ProcessSpec{})
+			Expect(spec.Path).To(Equal("sh"))
+			Expect(spec.Args).To(Equal([]string{"-c", "echo hi"}))
+		})
+
+		It("does not override explicit values", func() {
+			explicit := garden.ProcessSpec{Path: "ls", Args: []string{"-l"}, User: "root", Dir: "/tmp"}
+			Expect(config.Defaults(explicit)).To(Equal(explicit))
+		})
+	})
+
+	Describe("containers created from images", func() {
+		var (
+			volumeCreator   fakeImageConfigVolumeCreator
+			containerizer   *fakes.FakeContainerizer
+			propertyManager *fakes.FakePropertyManager
+			properties      map[string]string
+			gdnr            *gardener.Gardener
+		)
+
+		BeforeEach(func() {
+			volumeCreator = fakeImageConfigVolumeCreator{new(fakes.FakeVolumeCreator), new(fakes.FakeImageConfigVolumeCreator)}
+			volumeCreator.ImageConfigReturns(gardener.ImageConfig{Entrypoint: []string{"/bin/app"}, User: "app"}, nil)
+
+			properties = map[string]string{}
+			propertyManager = new(fakes.FakePropertyManager)
+			propertyManager.SetStub = func(handle, name, value string) {
+				properties[name] = value
+			}
+			propertyManager.GetStub = func(handle, name string) (string, error) {
+				value, ok := properties[name]
+				if !ok {
+					return "", errors.New("no such property")
+				}
+
+				return value, nil
+			}
+
+			containerizer = new(fakes.FakeContainerizer)
+			gdnr = &gardener.Gardener{
+				Containerizer:   containerizer,
+				Networker:       new(fakes.FakeNetworker),
+				VolumeCreator:   volumeCreator,
+				PropertyManager: propertyManager,
+				UidGenerator:    new(fakes.FakeUidGenerator),
+				Logger:          lagertest.NewTestLogger("test"),
+			}
+		})
+
+		It("stores the image's defaults in a property, so that they are in the container's info", func() {
+			_, err := gdnr.Create(garden.ContainerSpec{Handle: "bob"})
+			Expect(err).NotTo(HaveOccurred())
+
+			_, handle := volumeCreator.ImageConfigArgsForCall(0)
+			Expect(handle).To(Equal("bob"))
+			Expect(properties).To(HaveKeyWithValue(gardener.ImageConfigKey, `{"entrypoint":["/bin/app"],"user":"app"}`))
+		})
+
+		It("runs processes with the image's defaults", func() {
+			container, err := gdnr.Create(garden.ContainerSpec{Handle: "bob"})
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = container.Run(garden.ProcessSpec{Args: []string{"--serve"}}, garden.ProcessIO{})
+			Expect(err).NotTo(HaveOccurred())
+
+			_, _, spec, _ := containerizer.RunArgsForCall(0)
+			Expect(spec.Path).To(Equal("/bin/app"))
+			Expect(spec.Args).To(Equal([]string{"--serve"}))
+			Expect(spec.User).To(Equal("app"))
+		})
+
+		It("does not store a property when the rootfs was not created from an image", func() {
+			volumeCreator.ImageConfigReturns(gardener.ImageConfig{}, nil)
+
+			_, err := gdnr.Create(garden.ContainerSpec{Handle: "bob"})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(properties).NotTo(HaveKey(gardener.ImageConfigKey))
+		})
+
+		It("fails when the image config cannot be read", func() {
+			volumeCreator.ImageConfigReturns(gardener.ImageConfig{}, errors.New("corrupt"))
+
+			_, err := gdnr.Create(garden.ContainerSpec{Handle: "bob"})
+			Expect(err).To(MatchError("image config: corrupt"))
+		})
+
+		It("destroys the volume when the image config cannot be read", func() {
+			volumeCreator.ImageConfigReturns(gardener.ImageConfig{}, errors.New("corrupt"))
+
+			_, err := gdnr.Create(garden.ContainerSpec{Handle: "bob"})
+			Expect(err).To(HaveOccurred())
+
+			Expect(volumeCreator.FakeVolumeCreator.DestroyCallCount()).To(Equal(1))
+			_, handle := volumeCreator.FakeVolumeCreator.DestroyArgsForCall(0)
+			Expect(handle).To(Equal("bob"))
+		})
+	})
+})
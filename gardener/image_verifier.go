@@ -0,0 +1,55 @@
+package gardener
+
+import (
+	"fmt"
+	"net/url"
+
+	"github.com/pivotal-golang/lager"
+)
+
+// FailureUntrustedImage is the class of creates refused because their image
+// could not be verified
+const FailureUntrustedImage = "untrusted-image"
+
+//go:generate counterfeiter . ImageVerifier
+
+// ImageVerifier checks that an image, e.g. docker:///busybox#1.24, is signed
+// by a party the operator trusts, and returns the digest of the image it
+// verified, e.g. sha256:..., as the image's tag may be moved to another
+// image at any time
+type ImageVerifier interface {
+	VerifyImage(log lager.Logger, image *url.URL) (string, error)
+}
+
+// verifyImage checks the signature of the container's image with the
+// ImageVerifier, if there is one, and returns the image to create the
+// container from. A verified image is pinned to the digest the verifier
+// verified, so that the image which is pulled is the one which was verified
+// even if its tag is moved in between. Rootfses on the host, i.e. the default
+// rootfs and raw:// paths, are the operator's own and are not verified. An
+// image which fails verification is refused when RequireImageSignatures is
+// set and otherwise only logged, so that verification can be tried out
+// before it is enforced.
+func (g *Gardener) verifyImage(log lager.Logger, image *url.URL) (*url.URL, error) {
+	if g.ImageVerifier == nil || image == nil || image.Scheme == "" || image.Scheme == "raw" {
+		return image, nil
+	}
+
+	log = log.Session("verify-image", lager.Data{"image": image.String()})
+
+	digest, err := g.ImageVerifier.VerifyImage(log, image)
+	if err == nil {
+		pinned := *image
+		pinned.Fragment = digest
+		log.Info("verified", lager.Data{"digest": digest})
+		return &pinned, nil
+	}
+
+	if !g.RequireImageSignatures {
+		log.Info("unverified-image-allowed", lager.Data{"error": err.Error()})
+		return image, nil
+	}
+
+	log.Error("unverified-image-refused", err)
+	return nil, Classify(FailureUntrustedImage, fmt.Errorf("image %s could not be verified: %s", image, err))
+}
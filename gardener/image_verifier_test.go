@@ -0,0 +1,113 @@
+package gardener_test
+
+import (
+	"errors"
+
+	"github.com/cloudfoundry-incubator/garden"
+	"github.com/cloudfoundry-incubator/guardian/gardener"
+	"github.com/cloudfoundry-incubator/guardian/gardener/fakes"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+	"github.com/pivotal-golang/lager/lagertest"
+)
+
+var _ = Describe("Verifying images", func() {
+	var (
+		logger        *lagertest.TestLogger
+		verifier      *fakes.FakeImageVerifier
+		volumeCreator *fakes.FakeVolumeCreator
+		metrics       *fakes.FakeMetricsRecorder
+
+		gdnr *gardener.Gardener
+	)
+
+	BeforeEach(func() {
+		logger = lagertest.NewTestLogger("test")
+		verifier = new(fakes.FakeImageVerifier)
+		volumeCreator = new(fakes.FakeVolumeCreator)
+		metrics = new(fakes.FakeMetricsRecorder)
+
+		gdnr = &gardener.Gardener{
+			SysInfoProvider: new(fakes.FakeSysInfoProvider),
+			Containerizer:   new(fakes.FakeContainerizer),
+			UidGenerator:    new(fakes.FakeUidGenerator),
+			Networker:       new(fakes.FakeNetworker),
+			VolumeCreator:   volumeCreator,
+			PropertyManager: new(fakes.FakePropertyManager),
+			Metrics:         metrics,
+			ImageVerifier:   verifier,
+			Logger:          logger,
+		}
+	})
+
+	It("verifies the container's image before creating it", func() {
+		_, err := gdnr.Create(garden.ContainerSpec{Handle: "some-handle", RootFSPath: "docker:///busybox#1.24"})
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(verifier.VerifyImageCallCount()).To(Equal(1))
+		_, image := verifier.VerifyImageArgsForCall(0)
+		Expect(image.String()).To(Equal("docker:///busybox#1.24"))
+	})
+
+	It("creates the container from the digest which was verified", func() {
+		verifier.VerifyImageReturns("sha256:0123456789abcdef0123456789abcdef", nil)
+
+		_, err := gdnr.Create(garden.ContainerSpec{Handle: "some-handle", RootFSPath: "docker:///busybox#1.24"})
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(volumeCreator.CreateCallCount()).To(Equal(1))
+		_, _, spec := volumeCreator.CreateArgsForCall(0)
+		Expect(spec.RootFS.String()).To(Equal("docker:///busybox#sha256:0123456789abcdef0123456789abcdef"))
+	})
+
+	It("does not verify rootfses on the host", func() {
+		_, err := gdnr.Create(garden.ContainerSpec{Handle: "default"})
+		Expect(err).NotTo(HaveOccurred())
+
+		_, err = gdnr.Create(garden.ContainerSpec{Handle: "raw", RootFSPath: "raw:///path/to/rootfs"})
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(verifier.VerifyImageCallCount()).To(Equal(0))
+	})
+
+	Context("when the image cannot be verified", func() {
+		BeforeEach(func() {
+			verifier.VerifyImageReturns("", errors.New("no trust data"))
+		})
+
+		It("creates the container from the image as it was given, and logs the image, by default", func() {
+			_, err := gdnr.Create(garden.ContainerSpec{Handle: "some-handle", RootFSPath: "docker:///busybox"})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(logger).To(gbytes.Say("unverified-image-allowed"))
+
+			_, _, spec := volumeCreator.CreateArgsForCall(0)
+			Expect(spec.RootFS.String()).To(Equal("docker:///busybox"))
+		})
+
+		Context("when image signatures are required", func() {
+			BeforeEach(func() {
+				gdnr.RequireImageSignatures = true
+			})
+
+			It("refuses to create the container before pulling its image", func() {
+				_, err := gdnr.Create(garden.ContainerSpec{Handle: "some-handle", RootFSPath: "docker:///busybox"})
+				Expect(err).To(MatchError("image docker:///busybox could not be verified: no trust data"))
+
+				Expect(volumeCreator.CreateCallCount()).To(Equal(0))
+
+				Expect(metrics.ContainerCreateFailedCallCount()).To(Equal(1))
+				stage, class := metrics.ContainerCreateFailedArgsForCall(0)
+				Expect(stage).To(Equal(gardener.StageImage))
+				Expect(class).To(Equal(gardener.FailureUntrustedImage))
+			})
+
+			It("reports that Create would fail when validating", func() {
+				err := gdnr.ValidateCreate(garden.ContainerSpec{RootFSPath: "docker:///busybox"})
+				Expect(err).To(BeAssignableToTypeOf(gardener.CreateValidationError{}))
+				Expect(err.(gardener.CreateValidationError).Class).To(Equal(gardener.FailureUntrustedImage))
+			})
+		})
+	})
+})
@@ -0,0 +1,75 @@
+package gardener
+
+import (
+	"errors"
+
+	"github.com/cloudfoundry-incubator/garden"
+)
+
+// LabelSelectorProperty is the property which, passed to Containers, holds a
+// label selector rather than a property to match exactly, e.g.
+// "app in (web,worker),team=data-*"
+const LabelSelectorProperty = "garden.label-selector"
+
+var errLabelSelectorsNotSupported = errors.New("label selectors are not supported")
+
+//go:generate counterfeiter . PropertyIndex
+
+// PropertyIndex is implemented by PropertyManagers which index containers'
+// properties, so that Containers finds the containers matching its filter
+// without checking the properties of every container. Only PropertyIndexes
+// support label selectors.
+type PropertyIndex interface {
+	Select(props garden.Properties, selector string) ([]string, error)
+}
+
+// selectHandles returns those of the handles whose containers match the
+// filter, which may hold a label selector
+func (g *Gardener) selectHandles(handles []string, filter garden.Properties) ([]string, error) {
+	props := garden.Properties{}
+	for name, value := range filter {
+		props[name] = value
+	}
+
+	selector, hasSelector := props[LabelSelectorProperty]
+	delete(props, LabelSelectorProperty)
+
+	index, ok := g.PropertyManager.(PropertyIndex)
+	if !ok {
+		if hasSelector {
+			return nil, errLabelSelectorsNotSupported
+		}
+
+		var selected []string
+		for _, handle := range handles {
+			if g.PropertyManager.MatchesAll(handle, props) {
+				selected = append(selected, handle)
+			}
+		}
+
+		return selected, nil
+	}
+
+	if len(props) == 0 && selector == "" {
+		return handles, nil
+	}
+
+	matching, err := index.Select(props, selector)
+	if err != nil {
+		return nil, err
+	}
+
+	matches := make(map[string]bool, len(matching))
+	for _, handle := range matching {
+		matches[handle] = true
+	}
+
+	var selected []string
+	for _, handle := range handles {
+		if matches[handle] {
+			selected = append(selected, handle)
+		}
+	}
+
+	return selected, nil
+}
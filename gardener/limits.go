@@ -0,0 +1,33 @@
+package gardener
+
+import (
+	"github.com/cloudfoundry-incubator/garden"
+	"github.com/pivotal-golang/lager"
+)
+
+// LimitsProperty is the property under which Info reports the limits the
+// container is currently subject to, as JSON. It is read from the kernel on
+// every Info rather than stored, so it cannot be set.
+const LimitsProperty = "garden.limits"
+
+// Limits are the resource limits of a running container. Zero values are no
+// limit.
+type Limits struct {
+	Memory   garden.MemoryLimits `json:"memory"`
+	CPU      garden.CPULimits    `json:"cpu"`
+	CPUQuota CPUQuota            `json:"cpu_quota"`
+	MaxPids  int64               `json:"max_pids"`
+}
+
+//go:generate counterfeiter . Limiter
+
+// Limiter is a CPULimiter which can also change the memory and pid limits of
+// running containers, and report all of their current limits. If the
+// CPULimiter is not a Limiter, LimitMemory has no effect and no limits are
+// reported.
+type Limiter interface {
+	CPULimiter
+	LimitMemory(log lager.Logger, handle string, limits garden.MemoryLimits) error
+	LimitPids(log lager.Logger, handle string, maxPids int64) error
+	CurrentLimits(log lager.Logger, handle string) (Limits, error)
+}
@@ -0,0 +1,297 @@
+package gardener
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/pivotal-golang/clock"
+	"github.com/pivotal-golang/lager"
+)
+
+// Maintenance pauses and resumes guardian's background workers (reconcilers,
+// watchers, metrics samplers and the destroy queue's retries), so that an
+// operator can stabilise a cell, e.g. while investigating it or during a
+// maintenance window, without stopping guardian. Workers run off the clock
+// which Clock returns; while a worker is paused the ticks of its tickers are
+// dropped, and its timers do not fire until it is resumed, so that it
+// finishes what it is doing and then does nothing until it is resumed. API
+// calls are not paused. A nil Maintenance pauses nothing.
+type Maintenance struct {
+	Logger lager.Logger
+
+	mu      sync.Mutex
+	paused  map[string]bool
+	resumed chan struct{}
+}
+
+// Clock registers a worker and returns the clock it should run off
+func (m *Maintenance) Clock(worker string, c clock.Clock) clock.Clock {
+	if m == nil {
+		return c
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.paused == nil {
+		m.paused = make(map[string]bool)
+	}
+	m.paused[worker] = false
+
+	return &maintenanceClock{Clock: c, maintenance: m, worker: worker}
+}
+
+// Pause pauses the given workers, or every worker if none are given
+func (m *Maintenance) Pause(workers ...string) error {
+	return m.set(true, workers)
+}
+
+// Resume resumes the given workers, or every worker if none are given
+func (m *Maintenance) Resume(workers ...string) error {
+	return m.set(false, workers)
+}
+
+// Paused returns whether the worker is paused
+func (m *Maintenance) Paused(worker string) bool {
+	if m == nil {
+		return false
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.paused[worker]
+}
+
+// Workers returns whether each registered worker is paused, by name
+func (m *Maintenance) Workers() map[string]bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	workers := make(map[string]bool, len(m.paused))
+	for worker, paused := range m.paused {
+		workers[worker] = paused
+	}
+
+	return workers
+}
+
+func (m *Maintenance) set(paused bool, workers []string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, worker := range workers {
+		if _, ok := m.paused[worker]; !ok {
+			return fmt.Errorf("unknown worker: '%s'", worker)
+		}
+	}
+
+	if len(workers) == 0 {
+		for worker := range m.paused {
+			workers = append(workers, worker)
+		}
+	}
+
+	for _, worker := range workers {
+		if m.paused[worker] != paused {
+			m.Logger.Info("set-paused", lager.Data{"worker": worker, "paused": paused})
+		}
+		m.paused[worker] = paused
+	}
+
+	if !paused && m.resumed != nil {
+		close(m.resumed)
+		m.resumed = nil
+	}
+
+	return nil
+}
+
+// awaitResumed waits until the worker is not paused, returning false if stop
+// is closed first
+func (m *Maintenance) awaitResumed(worker string, stop <-chan struct{}) bool {
+	for {
+		m.mu.Lock()
+		if !m.paused[worker] {
+			m.mu.Unlock()
+			return true
+		}
+
+		if m.resumed == nil {
+			m.resumed = make(chan struct{})
+		}
+		resumed := m.resumed
+		m.mu.Unlock()
+
+		select {
+		case <-resumed:
+		case <-stop:
+			return false
+		}
+	}
+}
+
+type maintenanceClock struct {
+	clock.Clock
+	maintenance *Maintenance
+	worker      string
+}
+
+func (c *maintenanceClock) NewTicker(d time.Duration) clock.Ticker {
+	t := &maintenanceTicker{
+		Ticker: c.Clock.NewTicker(d),
+		c:      make(chan time.Time, 1),
+		stop:   make(chan struct{}),
+	}
+
+	go func() {
+		for {
+			select {
+			case tick := <-t.Ticker.C():
+				if c.maintenance.Paused(c.worker) {
+					continue
+				}
+
+				// like a time.Ticker, drop ticks for slow receivers
+				select {
+				case t.c <- tick:
+				default:
+				}
+			case <-t.stop:
+				return
+			}
+		}
+	}()
+
+	return t
+}
+
+type maintenanceTicker struct {
+	clock.Ticker
+	c    chan time.Time
+	stop chan struct{}
+	once sync.Once
+}
+
+func (t *maintenanceTicker) C() <-chan time.Time {
+	return t.c
+}
+
+func (t *maintenanceTicker) Stop() {
+	t.once.Do(func() {
+		t.Ticker.Stop()
+		close(t.stop)
+	})
+}
+
+func (c *maintenanceClock) NewTimer(d time.Duration) clock.Timer {
+	t := &maintenanceTimer{
+		Timer: c.Clock.NewTimer(d),
+		clock: c,
+		c:     make(chan time.Time, 1),
+	}
+
+	t.watch()
+	return t
+}
+
+// maintenanceTimer holds back the firing of its timer while its worker is
+// paused, and fires once the worker is resumed
+type maintenanceTimer struct {
+	clock.Timer
+	clock *maintenanceClock
+	c     chan time.Time
+
+	mu   sync.Mutex
+	stop chan struct{}
+}
+
+func (t *maintenanceTimer) watch() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	stop := make(chan struct{})
+	t.stop = stop
+
+	go func() {
+		select {
+		case tick := <-t.Timer.C():
+			if !t.clock.maintenance.awaitResumed(t.clock.worker, stop) {
+				return
+			}
+
+			select {
+			case t.c <- tick:
+			default:
+			}
+		case <-stop:
+		}
+	}()
+}
+
+func (t *maintenanceTimer) C() <-chan time.Time {
+	return t.c
+}
+
+func (t *maintenanceTimer) Stop() bool {
+	t.mu.Lock()
+	if t.stop != nil {
+		close(t.stop)
+		t.stop = nil
+	}
+	t.mu.Unlock()
+
+	return t.Timer.Stop()
+}
+
+func (t *maintenanceTimer) Reset(d time.Duration) bool {
+	active := t.Stop()
+	t.Timer.Reset(d)
+	t.watch()
+
+	return active
+}
+
+// MaintenanceRequest is the body of a POST to the MaintenanceHandler. If
+// Workers is empty every worker is paused or resumed.
+type MaintenanceRequest struct {
+	Paused  bool     `json:"paused"`
+	Workers []string `json:"workers,omitempty"`
+}
+
+// MaintenanceHandler serves whether each of the background workers is
+// paused, by name, as JSON, and pauses or resumes them on POST
+type MaintenanceHandler struct {
+	Maintenance *Maintenance
+}
+
+func (h *MaintenanceHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case "GET":
+	case "POST":
+		var request MaintenanceRequest
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			writeError(w, r, "invalid request: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		set := h.Maintenance.Resume
+		if request.Paused {
+			set = h.Maintenance.Pause
+		}
+
+		if err := set(request.Workers...); err != nil {
+			writeError(w, r, err.Error(), http.StatusBadRequest)
+			return
+		}
+	default:
+		writeError(w, r, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"workers": h.Maintenance.Workers()})
+}
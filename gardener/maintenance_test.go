@@ -0,0 +1,112 @@
+package gardener_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"time"
+
+	"github.com/cloudfoundry-incubator/guardian/gardener"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/pivotal-golang/clock"
+	"github.com/pivotal-golang/clock/fakeclock"
+	"github.com/pivotal-golang/lager/lagertest"
+)
+
+var _ = Describe("Maintenance", func() {
+	var (
+		fakeClock   *fakeclock.FakeClock
+		maintenance *gardener.Maintenance
+		ticker      clock.Ticker
+	)
+
+	BeforeEach(func() {
+		fakeClock = fakeclock.NewFakeClock(time.Now())
+		maintenance = &gardener.Maintenance{Logger: lagertest.NewTestLogger("test")}
+		ticker = maintenance.Clock("some-worker", fakeClock).NewTicker(time.Second)
+		maintenance.Clock("other-worker", fakeClock)
+	})
+
+	AfterEach(func() {
+		ticker.Stop()
+	})
+
+	It("ticks workers which are not paused", func() {
+		fakeClock.WaitForWatcherAndIncrement(time.Second)
+		Eventually(ticker.C()).Should(Receive())
+	})
+
+	It("drops the ticks of paused workers until they are resumed", func() {
+		Expect(maintenance.Pause("some-worker")).To(Succeed())
+		Expect(maintenance.Workers()).To(Equal(map[string]bool{"some-worker": true, "other-worker": false}))
+
+		fakeClock.WaitForWatcherAndIncrement(time.Second)
+		Consistently(ticker.C()).ShouldNot(Receive())
+
+		Expect(maintenance.Resume("some-worker")).To(Succeed())
+		fakeClock.Increment(time.Second)
+		Eventually(ticker.C()).Should(Receive())
+	})
+
+	It("holds back the timers of paused workers until they are resumed", func() {
+		timer := maintenance.Clock("other-worker", fakeClock).NewTimer(time.Second)
+		defer timer.Stop()
+
+		Expect(maintenance.Pause("other-worker")).To(Succeed())
+		fakeClock.WaitForWatcherAndIncrement(time.Second)
+		Consistently(timer.C()).ShouldNot(Receive())
+
+		Expect(maintenance.Resume("other-worker")).To(Succeed())
+		Eventually(timer.C()).Should(Receive())
+	})
+
+	It("pauses and resumes every worker when none are given", func() {
+		Expect(maintenance.Pause()).To(Succeed())
+		Expect(maintenance.Workers()).To(Equal(map[string]bool{"some-worker": true, "other-worker": true}))
+
+		Expect(maintenance.Resume()).To(Succeed())
+		Expect(maintenance.Workers()).To(Equal(map[string]bool{"some-worker": false, "other-worker": false}))
+	})
+
+	It("refuses unknown workers", func() {
+		Expect(maintenance.Pause("some-worker", "no-such-worker")).To(MatchError("unknown worker: 'no-such-worker'"))
+		Expect(maintenance.Paused("some-worker")).To(BeFalse())
+	})
+
+	Describe("MaintenanceHandler", func() {
+		serve := func(method, body string) (*httptest.ResponseRecorder, map[string]bool) {
+			recorder := httptest.NewRecorder()
+			req, err := http.NewRequest(method, "/maintenance", strings.NewReader(body))
+			Expect(err).NotTo(HaveOccurred())
+			(&gardener.MaintenanceHandler{Maintenance: maintenance}).ServeHTTP(recorder, req)
+
+			var response struct {
+				Workers map[string]bool `json:"workers"`
+			}
+			if recorder.Code == http.StatusOK {
+				Expect(json.NewDecoder(recorder.Body).Decode(&response)).To(Succeed())
+			}
+			return recorder, response.Workers
+		}
+
+		It("serves whether each worker is paused", func() {
+			_, workers := serve("GET", "")
+			Expect(workers).To(Equal(map[string]bool{"some-worker": false, "other-worker": false}))
+		})
+
+		It("pauses and resumes workers on POST", func() {
+			_, workers := serve("POST", `{"paused": true, "workers": ["other-worker"]}`)
+			Expect(workers).To(Equal(map[string]bool{"some-worker": false, "other-worker": true}))
+
+			_, workers = serve("POST", `{"paused": false}`)
+			Expect(workers).To(Equal(map[string]bool{"some-worker": false, "other-worker": false}))
+		})
+
+		It("refuses unknown workers", func() {
+			recorder, _ := serve("POST", `{"paused": true, "workers": ["no-such-worker"]}`)
+			Expect(recorder.Code).To(Equal(http.StatusBadRequest))
+		})
+	})
+})
@@ -0,0 +1,119 @@
+package gardener
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/pivotal-golang/lager"
+)
+
+// ErrNetInProtocolsNotSupported is returned when a port is forwarded over
+// other protocols than TCP, or unmapped, for a container whose Networker
+// cannot do so
+var ErrNetInProtocolsNotSupported = errors.New("forwarding ports over other protocols than tcp, and unmapping them, are not supported by the network plugin")
+
+//go:generate counterfeiter . ProtocolNetworker
+
+// ProtocolNetworker is implemented by Networkers which can forward a port
+// over UDP as well as TCP, and stop forwarding it again
+type ProtocolNetworker interface {
+	NetInProtocols(log lager.Logger, handle string, hostPort, containerPort uint32, protocols []string) (uint32, uint32, error)
+	NetInRemove(log lager.Logger, handle string, hostPort uint32) error
+}
+
+// NetInProtocols forwards the host port to the container port of the
+// container with the given handle over each of the protocols, "tcp" and/or
+// "udp". As with NetIn, either port may be 0 to pick one.
+func (g *Gardener) NetInProtocols(handle string, hostPort, containerPort uint32, protocols []string) (uint32, uint32, error) {
+	networker, ok := g.Networker.(ProtocolNetworker)
+	if !ok {
+		return 0, 0, ErrNetInProtocolsNotSupported
+	}
+
+	log := g.Annotator.Logger(g.Logger, handle)
+	return networker.NetInProtocols(log, handle, hostPort, containerPort, protocols)
+}
+
+// NetInRemove stops forwarding the host port to the container with the given
+// handle
+func (g *Gardener) NetInRemove(handle string, hostPort uint32) error {
+	networker, ok := g.Networker.(ProtocolNetworker)
+	if !ok {
+		return ErrNetInProtocolsNotSupported
+	}
+
+	log := g.Annotator.Logger(g.Logger, handle)
+	return networker.NetInRemove(log, handle, hostPort)
+}
+
+//go:generate counterfeiter . PortMapper
+
+type PortMapper interface {
+	NetInProtocols(handle string, hostPort, containerPort uint32, protocols []string) (uint32, uint32, error)
+	NetInRemove(handle string, hostPort uint32) error
+}
+
+// NetInRequest is the body of a POST to the NetInHandler, and the response
+// to it with the ports which were picked
+type NetInRequest struct {
+	HostPort      uint32   `json:"host_port"`
+	ContainerPort uint32   `json:"container_port"`
+	Protocols     []string `json:"protocols,omitempty"`
+}
+
+// NetInHandler changes the ports forwarded to the container named by the
+// `handle` query parameter. A POST forwards the ports over the protocols
+// given as JSON in the request body, which garden's NetIn cannot choose; a
+// DELETE stops forwarding the `host_port` query parameter.
+type NetInHandler struct {
+	Mapper PortMapper
+}
+
+func (h *NetInHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	handle := r.URL.Query().Get("handle")
+	if handle == "" {
+		writeError(w, r, "handle is required", http.StatusBadRequest)
+		return
+	}
+
+	switch r.Method {
+	case "POST":
+		var request NetInRequest
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			writeError(w, r, "invalid net in: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		if len(request.Protocols) == 0 {
+			request.Protocols = []string{"tcp"}
+		}
+
+		hostPort, containerPort, err := h.Mapper.NetInProtocols(handle, request.HostPort, request.ContainerPort, request.Protocols)
+		if err != nil {
+			writeError(w, r, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		request.HostPort, request.ContainerPort = hostPort, containerPort
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(request)
+	case "DELETE":
+		hostPort, err := strconv.ParseUint(r.URL.Query().Get("host_port"), 10, 16)
+		if err != nil || hostPort == 0 {
+			writeError(w, r, "host_port must be a port number", http.StatusBadRequest)
+			return
+		}
+
+		if err := h.Mapper.NetInRemove(handle, uint32(hostPort)); err != nil {
+			writeError(w, r, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		writeError(w, r, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
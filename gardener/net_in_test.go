@@ -0,0 +1,155 @@
+package gardener_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	"github.com/cloudfoundry-incubator/guardian/gardener"
+	"github.com/cloudfoundry-incubator/guardian/gardener/fakes"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/pivotal-golang/lager/lagertest"
+)
+
+type fakeProtocolNetworker struct {
+	*fakes.FakeNetworker
+	*fakes.FakeProtocolNetworker
+}
+
+var _ = Describe("Net in over other protocols", func() {
+	var (
+		networker fakeProtocolNetworker
+		gdnr      *gardener.Gardener
+	)
+
+	BeforeEach(func() {
+		networker = fakeProtocolNetworker{new(fakes.FakeNetworker), new(fakes.FakeProtocolNetworker)}
+
+		gdnr = &gardener.Gardener{
+			Networker: networker,
+			Logger:    lagertest.NewTestLogger("test"),
+		}
+	})
+
+	It("forwards the ports over the protocols", func() {
+		networker.FakeProtocolNetworker.NetInProtocolsReturns(61000, 514, nil)
+
+		hostPort, containerPort, err := gdnr.NetInProtocols("some-handle", 0, 514, []string{"udp"})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(hostPort).To(BeEquivalentTo(61000))
+		Expect(containerPort).To(BeEquivalentTo(514))
+
+		_, handle, actualHostPort, actualContainerPort, protocols := networker.FakeProtocolNetworker.NetInProtocolsArgsForCall(0)
+		Expect(handle).To(Equal("some-handle"))
+		Expect(actualHostPort).To(BeEquivalentTo(0))
+		Expect(actualContainerPort).To(BeEquivalentTo(514))
+		Expect(protocols).To(Equal([]string{"udp"}))
+	})
+
+	It("unmaps the host port", func() {
+		Expect(gdnr.NetInRemove("some-handle", 61000)).To(Succeed())
+
+		_, handle, hostPort := networker.NetInRemoveArgsForCall(0)
+		Expect(handle).To(Equal("some-handle"))
+		Expect(hostPort).To(BeEquivalentTo(61000))
+	})
+
+	Context("when the networker cannot forward other protocols", func() {
+		BeforeEach(func() {
+			gdnr.Networker = new(fakes.FakeNetworker)
+		})
+
+		It("returns an error rather than forwarding TCP", func() {
+			_, _, err := gdnr.NetInProtocols("some-handle", 0, 514, []string{"udp"})
+			Expect(err).To(MatchError(gardener.ErrNetInProtocolsNotSupported))
+			Expect(gdnr.NetInRemove("some-handle", 61000)).To(MatchError(gardener.ErrNetInProtocolsNotSupported))
+		})
+	})
+})
+
+var _ = Describe("NetInHandler", func() {
+	var (
+		fakeMapper *fakes.FakePortMapper
+		recorder   *httptest.ResponseRecorder
+		handler    *gardener.NetInHandler
+	)
+
+	serve := func(method, url, body string) {
+		req, err := http.NewRequest(method, url, strings.NewReader(body))
+		Expect(err).NotTo(HaveOccurred())
+		handler.ServeHTTP(recorder, req)
+	}
+
+	BeforeEach(func() {
+		fakeMapper = new(fakes.FakePortMapper)
+		recorder = httptest.NewRecorder()
+		handler = &gardener.NetInHandler{Mapper: fakeMapper}
+	})
+
+	It("forwards the ports in the body of a POST, and returns those picked", func() {
+		fakeMapper.NetInProtocolsReturns(61000, 514, nil)
+
+		serve("POST", "/containers/net-in?handle=some-handle", `{"container_port":514,"protocols":["tcp","udp"]}`)
+
+		Expect(recorder.Code).To(Equal(http.StatusOK))
+		Expect(recorder.Body.String()).To(MatchJSON(`{"host_port":61000,"container_port":514,"protocols":["tcp","udp"]}`))
+
+		handle, hostPort, containerPort, protocols := fakeMapper.NetInProtocolsArgsForCall(0)
+		Expect(handle).To(Equal("some-handle"))
+		Expect(hostPort).To(BeEquivalentTo(0))
+		Expect(containerPort).To(BeEquivalentTo(514))
+		Expect(protocols).To(Equal([]string{"tcp", "udp"}))
+	})
+
+	It("forwards TCP when no protocols are given", func() {
+		serve("POST", "/containers/net-in?handle=some-handle", `{"host_port":61000}`)
+
+		_, _, _, protocols := fakeMapper.NetInProtocolsArgsForCall(0)
+		Expect(protocols).To(Equal([]string{"tcp"}))
+	})
+
+	It("returns 400 when the body is not valid JSON", func() {
+		serve("POST", "/containers/net-in?handle=some-handle", `{"host_port":`)
+
+		Expect(recorder.Code).To(Equal(http.StatusBadRequest))
+		Expect(fakeMapper.NetInProtocolsCallCount()).To(Equal(0))
+	})
+
+	It("returns 500 when forwarding fails", func() {
+		fakeMapper.NetInProtocolsReturns(0, 0, errors.New("unknown protocol 'sctp'"))
+		serve("POST", "/containers/net-in?handle=some-handle", `{"protocols":["sctp"]}`)
+
+		Expect(recorder.Code).To(Equal(http.StatusInternalServerError))
+		Expect(recorder.Body.String()).To(ContainSubstring("unknown protocol 'sctp'"))
+	})
+
+	It("unmaps the host_port on a DELETE", func() {
+		serve("DELETE", "/containers/net-in?handle=some-handle&host_port=61000", "")
+
+		Expect(recorder.Code).To(Equal(http.StatusNoContent))
+		handle, hostPort := fakeMapper.NetInRemoveArgsForCall(0)
+		Expect(handle).To(Equal("some-handle"))
+		Expect(hostPort).To(BeEquivalentTo(61000))
+	})
+
+	It("returns 400 when a DELETE has no valid host_port", func() {
+		serve("DELETE", "/containers/net-in?handle=some-handle&host_port=70000", "")
+
+		Expect(recorder.Code).To(Equal(http.StatusBadRequest))
+		Expect(fakeMapper.NetInRemoveCallCount()).To(Equal(0))
+	})
+
+	It("returns 400 without a handle", func() {
+		serve("DELETE", "/containers/net-in?host_port=61000", "")
+
+		Expect(recorder.Code).To(Equal(http.StatusBadRequest))
+	})
+
+	It("returns 405 for other methods", func() {
+		serve("GET", "/containers/net-in?handle=some-handle", "")
+
+		Expect(recorder.Code).To(Equal(http.StatusMethodNotAllowed))
+	})
+})
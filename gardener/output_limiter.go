@@ -0,0 +1,182 @@
+package gardener
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/cloudfoundry-incubator/garden"
+	"github.com/pivotal-golang/clock"
+)
+
+// OutputRateProperty is the container property which may hold the maximum
+// number of bytes per second its processes may write to stdout and stderr.
+// It may lower the OutputLimiter's default but not raise it (0 means the
+// default).
+const OutputRateProperty = "output-rate"
+
+// OutputLimiter caps the rate at which the processes in each container write
+// to stdout and stderr. All the processes of a container share a token
+// bucket, which holds up to a second's worth of bytes; once it is empty,
+// writes wait for it to refill, so that a runaway logger is slowed down
+// rather than flooding guardian and whatever consumes its output. A nil
+// OutputLimiter imposes no limit.
+type OutputLimiter struct {
+	mu      sync.Mutex
+	rate    int64
+	clock   clock.Clock
+	buckets map[string]*tokenBucket
+}
+
+// NewOutputLimiter returns an OutputLimiter which by default limits each
+// container to rate bytes per second (0 means no limit).
+func NewOutputLimiter(rate int64, clock clock.Clock) *OutputLimiter {
+	return &OutputLimiter{
+		rate:    rate,
+		clock:   clock,
+		buckets: make(map[string]*tokenBucket),
+	}
+}
+
+// Limit wraps the stdout and stderr of a process in the container so that
+// they are written no faster than rate bytes per second, or the default rate
+// if rate is 0 or above it. The process's output is not limited if neither
+// is set.
+func (l *OutputLimiter) Limit(handle string, rate int64, pio garden.ProcessIO) garden.ProcessIO {
+	if l == nil {
+		return pio
+	}
+
+	if rate == 0 || (l.rate > 0 && rate > l.rate) {
+		rate = l.rate
+	}
+
+	if rate <= 0 {
+		return pio
+	}
+
+	bucket := l.bucket(handle, rate)
+	if pio.Stdout != nil {
+		pio.Stdout = &limitedWriter{Writer: pio.Stdout, bucket: bucket, clock: l.clock}
+	}
+
+	if pio.Stderr != nil {
+		pio.Stderr = &limitedWriter{Writer: pio.Stderr, bucket: bucket, clock: l.clock}
+	}
+
+	return pio
+}
+
+// Throttled returns, for each container, the total number of bytes of output
+// which have had to wait for the container's bucket to refill.
+func (l *OutputLimiter) Throttled() (map[string]float64, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	throttled := make(map[string]float64, len(l.buckets))
+	for handle, bucket := range l.buckets {
+		throttled[handle] = float64(bucket.throttledBytes())
+	}
+
+	return throttled, nil
+}
+
+// Forget drops the container's bucket, e.g. once it is destroyed.
+func (l *OutputLimiter) Forget(handle string) {
+	if l == nil {
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	delete(l.buckets, handle)
+}
+
+func (l *OutputLimiter) bucket(handle string, rate int64) *tokenBucket {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	bucket, ok := l.buckets[handle]
+	if !ok {
+		bucket = &tokenBucket{tokens: float64(rate), last: l.clock.Now()}
+		l.buckets[handle] = bucket
+	}
+
+	bucket.setRate(rate)
+	return bucket
+}
+
+type tokenBucket struct {
+	mu        sync.Mutex
+	rate      float64
+	tokens    float64
+	last      time.Time
+	throttled int64
+}
+
+func (b *tokenBucket) setRate(rate int64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.rate = float64(rate)
+}
+
+// take removes n tokens from the bucket, returning how long the caller must
+// wait for them to have been refilled. The bucket may go into debt, so that
+// concurrent writers queue up behind each other.
+func (b *tokenBucket) take(now time.Time, n int) time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.tokens += now.Sub(b.last).Seconds() * b.rate
+	if b.tokens > b.rate {
+		b.tokens = b.rate
+	}
+	b.last = now
+
+	b.tokens -= float64(n)
+	if b.tokens >= 0 {
+		return 0
+	}
+
+	b.throttled += int64(n)
+	return time.Duration(-b.tokens / b.rate * float64(time.Second))
+}
+
+func (b *tokenBucket) throttledBytes() int64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.throttled
+}
+
+type limitedWriter struct {
+	io.Writer
+	bucket *tokenBucket
+	clock  clock.Clock
+}
+
+func (w *limitedWriter) Write(p []byte) (int, error) {
+	if wait := w.bucket.take(w.clock.Now(), len(p)); wait > 0 {
+		w.clock.Sleep(wait)
+	}
+
+	return w.Writer.Write(p)
+}
+
+func parseOutputRate(properties garden.Properties) (int64, error) {
+	raw, ok := properties[OutputRateProperty]
+	if !ok {
+		return 0, nil
+	}
+
+	rate, err := strconv.ParseInt(raw, 10, 64)
+	if err != nil || rate < 0 {
+		return 0, fmt.Errorf("invalid %s property: '%s'", OutputRateProperty, raw)
+	}
+
+	return rate, nil
+}
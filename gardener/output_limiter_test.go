@@ -0,0 +1,144 @@
+package gardener_test
+
+import (
+	"time"
+
+	"github.com/cloudfoundry-incubator/garden"
+	"github.com/cloudfoundry-incubator/guardian/gardener"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+	"github.com/pivotal-golang/clock/fakeclock"
+)
+
+var _ = Describe("OutputLimiter", func() {
+	var (
+		fakeClock *fakeclock.FakeClock
+		limiter   *gardener.OutputLimiter
+		stdout    *gbytes.Buffer
+		stderr    *gbytes.Buffer
+	)
+
+	BeforeEach(func() {
+		fakeClock = fakeclock.NewFakeClock(time.Now())
+		limiter = gardener.NewOutputLimiter(10, fakeClock)
+		stdout = gbytes.NewBuffer()
+		stderr = gbytes.NewBuffer()
+	})
+
+	write := func(pio garden.ProcessIO, data string) chan struct{} {
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			pio.Stdout.Write([]byte(data))
+		}()
+		return done
+	}
+
+	It("lets a second's worth of output through straight away", func() {
+		pio := limiter.Limit("some-handle", 0, garden.ProcessIO{Stdout: stdout, Stderr: stderr})
+
+		Eventually(write(pio, "0123456789")).Should(BeClosed())
+		Expect(stdout).To(gbytes.Say("0123456789"))
+	})
+
+	It("makes further output wait for the bucket to refill", func() {
+		pio := limiter.Limit("some-handle", 0, garden.ProcessIO{Stdout: stdout, Stderr: stderr})
+		Eventually(write(pio, "0123456789")).Should(BeClosed())
+
+		done := write(pio, "abcde")
+		fakeClock.WaitForWatcherAndIncrement(400 * time.Millisecond)
+		Consistently(done).ShouldNot(BeClosed())
+
+		fakeClock.Increment(100 * time.Millisecond)
+		Eventually(done).Should(BeClosed())
+		Expect(stdout).To(gbytes.Say("abcde"))
+	})
+
+	It("counts the bytes which were made to wait", func() {
+		pio := limiter.Limit("some-handle", 0, garden.ProcessIO{Stdout: stdout, Stderr: stderr})
+		Eventually(write(pio, "0123456789")).Should(BeClosed())
+
+		done := write(pio, "abcde")
+		fakeClock.WaitForWatcherAndIncrement(time.Second)
+		Eventually(done).Should(BeClosed())
+
+		Expect(limiter.Throttled()).To(Equal(map[string]float64{"some-handle": 5}))
+	})
+
+	It("shares the bucket between stdout and stderr, and between processes", func() {
+		first := limiter.Limit("some-handle", 0, garden.ProcessIO{Stdout: stdout, Stderr: stderr})
+		second := limiter.Limit("some-handle", 0, garden.ProcessIO{Stdout: gbytes.NewBuffer()})
+
+		_, err := first.Stderr.Write([]byte("01234"))
+		Expect(err).NotTo(HaveOccurred())
+		Eventually(write(first, "56789")).Should(BeClosed())
+
+		done := write(second, "a")
+		fakeClock.WaitForWatcherAndIncrement(100 * time.Millisecond)
+		Eventually(done).Should(BeClosed())
+	})
+
+	It("limits each container separately", func() {
+		pio := limiter.Limit("some-handle", 0, garden.ProcessIO{Stdout: stdout})
+		Eventually(write(pio, "0123456789")).Should(BeClosed())
+
+		other := limiter.Limit("other-handle", 0, garden.ProcessIO{Stdout: gbytes.NewBuffer()})
+		Eventually(write(other, "0123456789")).Should(BeClosed())
+	})
+
+	It("uses the container's own rate when it is below the default", func() {
+		pio := limiter.Limit("some-handle", 5, garden.ProcessIO{Stdout: stdout})
+		Eventually(write(pio, "01234")).Should(BeClosed())
+
+		done := write(pio, "5")
+		fakeClock.WaitForWatcher()
+		Consistently(done).ShouldNot(BeClosed())
+		fakeClock.Increment(time.Second)
+		Eventually(done).Should(BeClosed())
+	})
+
+	It("does not let the container's own rate raise the default", func() {
+		pio := limiter.Limit("some-handle", 20, garden.ProcessIO{Stdout: stdout})
+		Eventually(write(pio, "0123456789")).Should(BeClosed())
+
+		done := write(pio, "abcde")
+		fakeClock.WaitForWatcher()
+		Consistently(done).ShouldNot(BeClosed())
+		fakeClock.Increment(time.Second)
+		Eventually(done).Should(BeClosed())
+	})
+
+	It("leaves missing streams alone", func() {
+		pio := limiter.Limit("some-handle", 0, garden.ProcessIO{Stdout: stdout})
+		Expect(pio.Stderr).To(BeNil())
+	})
+
+	It("forgets the container's bucket", func() {
+		pio := limiter.Limit("some-handle", 0, garden.ProcessIO{Stdout: stdout})
+		Eventually(write(pio, "0123456789")).Should(BeClosed())
+
+		limiter.Forget("some-handle")
+		Expect(limiter.Throttled()).To(BeEmpty())
+	})
+
+	Context("when there is no default rate", func() {
+		BeforeEach(func() {
+			limiter = gardener.NewOutputLimiter(0, fakeClock)
+		})
+
+		It("does not limit containers without their own rate", func() {
+			pio := garden.ProcessIO{Stdout: stdout, Stderr: stderr}
+			Expect(limiter.Limit("some-handle", 0, pio)).To(Equal(pio))
+		})
+	})
+
+	Context("when the limiter is nil", func() {
+		It("imposes no limit", func() {
+			var nilLimiter *gardener.OutputLimiter
+			pio := garden.ProcessIO{Stdout: stdout, Stderr: stderr}
+			Expect(nilLimiter.Limit("some-handle", 10, pio)).To(Equal(pio))
+			nilLimiter.Forget("some-handle")
+		})
+	})
+})
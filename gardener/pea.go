@@ -0,0 +1,206 @@
+package gardener
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/cloudfoundry-incubator/garden"
+	"github.com/cloudfoundry-incubator/garden-shed/rootfs_provider"
+	"github.com/pivotal-golang/lager"
+)
+
+// PeaRunner is implemented by Containerizers which can run a process in its
+// own rootfs, in a new mount namespace inside a container's other namespaces
+// (a "pea")
+type PeaRunner interface {
+	RunPea(log lager.Logger, handle string, pea PeaSpec, io garden.ProcessIO) (garden.Process, error)
+}
+
+// PeasKey is the property which records the IDs of a container's peas,
+// separated by commas, so that their volumes are destroyed even if guardian
+// restarts before their processes exit
+const PeasKey = "garden.peas"
+
+//go:generate counterfeiter . PeaReaper
+
+// PeaReaper is implemented by Containerizers which can remove the peas of a
+// container whose processes have exited, e.g. while guardian was not running,
+// returning their IDs
+type PeaReaper interface {
+	ReapPeas(log lager.Logger, handle string) ([]string, error)
+}
+
+type PeaSpec struct {
+	// ID identifies the pea, and its volume, uniquely across all containers
+	ID string
+
+	// Handle is the container the pea runs in
+	Handle string
+
+	// Path to the Root Filesystem provisioned for the pea
+	RootFSPath string
+
+	// Process to run in the pea, its Env starting with that of the pea's image
+	Process garden.ProcessSpec
+}
+
+// runPea provisions a volume from the process's image in the same way as the
+// container's own, and runs the process in it. The volume is destroyed once
+// the process exits, or, if guardian restarts first, when the container is
+// recovered or destroyed.
+func (g *Gardener) runPea(log lager.Logger, handle string, spec garden.ProcessSpec, io garden.ProcessIO) (garden.Process, error) {
+	log = log.Session("run-pea", lager.Data{"image": spec.Image.URI})
+
+	peaRunner, ok := g.Containerizer.(PeaRunner)
+	if !ok {
+		return nil, fmt.Errorf("the containerizer does not support process images")
+	}
+
+	rootFSURL, err := url.Parse(spec.Image.URI)
+	if err != nil {
+		return nil, fmt.Errorf("invalid process image: %s", err)
+	}
+
+	containerSpec, err := g.containerSpec(handle)
+	if err != nil {
+		return nil, err
+	}
+
+	idMappings, err := parseIDMappings(containerSpec, g.IDMappings)
+	if err != nil {
+		return nil, err
+	}
+
+	peaID := fmt.Sprintf("%s-pea-%s", handle, g.UidGenerator.Generate())
+	g.recordPea(handle, peaID)
+
+	rootFSPath, env, err := g.createVolume(log, peaID, rootfs_provider.Spec{
+		RootFS:     rootFSURL,
+		Namespaced: !containerSpec.Privileged,
+	}, idMappings, nil)
+	if err != nil {
+		log.Error("create-volume-failed", err)
+		g.forgetPea(log, handle, peaID)
+		return nil, fmt.Errorf("create process image: %s", err)
+	}
+
+	spec.Env = append(env, spec.Env...)
+	process, err := peaRunner.RunPea(log, handle, PeaSpec{
+		ID:         peaID,
+		Handle:     handle,
+		RootFSPath: rootFSPath,
+		Process:    spec,
+	}, io)
+	if err != nil {
+		g.forgetPea(log, handle, peaID)
+		return nil, err
+	}
+
+	go func() {
+		process.Wait()
+		g.forgetPea(log, handle, peaID)
+	}()
+
+	return process, nil
+}
+
+// RecoverPeas destroys the volumes of the container's peas whose processes
+// exited while guardian was not running, once the Containerizer has removed
+// them
+func (g *Gardener) RecoverPeas(log lager.Logger, handle string) error {
+	reaper, ok := g.Containerizer.(PeaReaper)
+	if !ok {
+		return nil
+	}
+
+	reaped, err := reaper.ReapPeas(log, handle)
+	if err != nil {
+		return err
+	}
+
+	for _, peaID := range reaped {
+		g.forgetPea(log, handle, peaID)
+	}
+
+	return nil
+}
+
+// recordPea adds the pea to the container's PeasKey
+func (g *Gardener) recordPea(handle, peaID string) {
+	g.peasMu.Lock()
+	defer g.peasMu.Unlock()
+
+	peas := append(g.peas(handle), peaID)
+	g.PropertyManager.Set(handle, PeasKey, strings.Join(peas, ","))
+}
+
+// forgetPea destroys the pea's volume and removes it from the container's
+// PeasKey, unless it has already been forgotten, e.g. by destroyPeas
+func (g *Gardener) forgetPea(log lager.Logger, handle, peaID string) {
+	g.peasMu.Lock()
+	defer g.peasMu.Unlock()
+
+	var (
+		remaining []string
+		recorded  bool
+	)
+	for _, id := range g.peas(handle) {
+		if id == peaID {
+			recorded = true
+		} else {
+			remaining = append(remaining, id)
+		}
+	}
+
+	if !recorded {
+		return
+	}
+
+	g.destroyVolume(log, peaID)
+	if len(remaining) == 0 {
+		g.PropertyManager.Remove(handle, PeasKey)
+	} else {
+		g.PropertyManager.Set(handle, PeasKey, strings.Join(remaining, ","))
+	}
+}
+
+// destroyPeas destroys the volumes of all of the container's peas, once the
+// container, and so the peas' processes, are gone
+func (g *Gardener) destroyPeas(log lager.Logger, handle string) {
+	g.peasMu.Lock()
+	defer g.peasMu.Unlock()
+
+	for _, peaID := range g.peas(handle) {
+		g.destroyVolume(log, peaID)
+	}
+
+	g.PropertyManager.Remove(handle, PeasKey)
+}
+
+func (g *Gardener) peas(handle string) []string {
+	recorded, err := g.PropertyManager.Get(handle, PeasKey)
+	if err != nil || recorded == "" {
+		return nil
+	}
+
+	return strings.Split(recorded, ",")
+}
+
+// containerSpec returns the spec a container was created with, along with its
+// current properties
+func (g *Gardener) containerSpec(handle string) (garden.ContainerSpec, error) {
+	props, err := g.PropertyManager.All(handle)
+	if err != nil {
+		return garden.ContainerSpec{}, err
+	}
+
+	var spec garden.ContainerSpec
+	if err := json.Unmarshal([]byte(props[SpecKey]), &spec); err != nil {
+		return garden.ContainerSpec{}, fmt.Errorf("container '%s' has no recorded spec", handle)
+	}
+
+	spec.Properties = props
+	return spec, nil
+}
@@ -0,0 +1,128 @@
+package gardener
+
+import (
+	"fmt"
+	"sync"
+)
+
+// ProcessLimitExceededError is returned by Run when a container already has
+// the maximum number of active processes.
+type ProcessLimitExceededError struct {
+	Handle string
+	Limit  int
+}
+
+func (e ProcessLimitExceededError) Error() string {
+	return fmt.Sprintf("container %s has reached its limit of %d active processes", e.Handle, e.Limit)
+}
+
+// ProcessLimiter caps the number of processes which may be active in each
+// container at once. Requests beyond the cap are rejected before any process
+// state is created, rather than failing part way through starting the process
+// when the pids cgroup limit is hit. A nil ProcessLimiter imposes no limit.
+type ProcessLimiter struct {
+	mu         sync.Mutex
+	max        int
+	generation uint64
+	active     map[string]*processSlots
+}
+
+// processSlots are the slots in use in a container, and the generation of
+// the container they were acquired in
+type processSlots struct {
+	generation uint64
+	active     int
+}
+
+// ProcessSlot is a slot reserved with Acquire, to be freed with Release. It
+// belongs to the container which held the handle when it was acquired, so
+// releasing it once the container is forgotten does not free a slot of a new
+// container with the same handle.
+type ProcessSlot struct {
+	handle     string
+	generation uint64
+}
+
+func NewProcessLimiter(max int) *ProcessLimiter {
+	return &ProcessLimiter{
+		max:    max,
+		active: make(map[string]*processSlots),
+	}
+}
+
+// Acquire reserves a process slot in the container, returning a
+// ProcessLimitExceededError if none are available.
+func (l *ProcessLimiter) Acquire(handle string) (ProcessSlot, error) {
+	if l == nil {
+		return ProcessSlot{}, nil
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	slots, ok := l.active[handle]
+	if !ok {
+		l.generation++
+		slots = &processSlots{generation: l.generation}
+	}
+
+	if slots.active >= l.max {
+		return ProcessSlot{}, ProcessLimitExceededError{Handle: handle, Limit: l.max}
+	}
+
+	slots.active++
+	l.active[handle] = slots
+	return ProcessSlot{handle: handle, generation: slots.generation}, nil
+}
+
+// Release frees a slot previously reserved with Acquire, unless its
+// container has since been forgotten.
+func (l *ProcessLimiter) Release(slot ProcessSlot) {
+	if l == nil {
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	slots, ok := l.active[slot.handle]
+	if !ok || slots.generation != slot.generation {
+		return
+	}
+
+	if slots.active <= 1 {
+		delete(l.active, slot.handle)
+		return
+	}
+
+	slots.active--
+}
+
+// Active returns the number of process slots in use in the container.
+func (l *ProcessLimiter) Active(handle string) int {
+	if l == nil {
+		return 0
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if slots, ok := l.active[handle]; ok {
+		return slots.active
+	}
+
+	return 0
+}
+
+// Forget drops all slots held by a container, e.g. once it is destroyed.
+// Slots it acquired are not freed again when they are released.
+func (l *ProcessLimiter) Forget(handle string) {
+	if l == nil {
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	delete(l.active, handle)
+}
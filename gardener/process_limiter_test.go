@@ -0,0 +1,87 @@
+package gardener_test
+
+import (
+	"github.com/cloudfoundry-incubator/guardian/gardener"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ProcessLimiter", func() {
+	var limiter *gardener.ProcessLimiter
+
+	BeforeEach(func() {
+		limiter = gardener.NewProcessLimiter(2)
+	})
+
+	acquire := func(handle string) gardener.ProcessSlot {
+		slot, err := limiter.Acquire(handle)
+		Expect(err).NotTo(HaveOccurred())
+		return slot
+	}
+
+	It("allows processes up to the limit", func() {
+		acquire("some-handle")
+		acquire("some-handle")
+		Expect(limiter.Active("some-handle")).To(Equal(2))
+	})
+
+	It("rejects processes beyond the limit", func() {
+		acquire("some-handle")
+		acquire("some-handle")
+
+		_, err := limiter.Acquire("some-handle")
+		Expect(err).To(Equal(gardener.ProcessLimitExceededError{Handle: "some-handle", Limit: 2}))
+		Expect(err).To(MatchError("container some-handle has reached its limit of 2 active processes"))
+	})
+
+	It("limits each container separately", func() {
+		acquire("some-handle")
+		acquire("some-handle")
+		acquire("other-handle")
+	})
+
+	It("allows another process once one is released", func() {
+		slot := acquire("some-handle")
+		acquire("some-handle")
+		limiter.Release(slot)
+
+		acquire("some-handle")
+	})
+
+	It("does not go negative when released more than acquired", func() {
+		slot := acquire("some-handle")
+		limiter.Release(slot)
+		limiter.Release(slot)
+		Expect(limiter.Active("some-handle")).To(Equal(0))
+	})
+
+	It("forgets all of a container's processes", func() {
+		acquire("some-handle")
+		limiter.Forget("some-handle")
+
+		Expect(limiter.Active("some-handle")).To(Equal(0))
+	})
+
+	It("ignores releases of slots acquired before the container was forgotten", func() {
+		stale := acquire("some-handle")
+		limiter.Forget("some-handle")
+
+		acquire("some-handle")
+		acquire("some-handle")
+		limiter.Release(stale)
+
+		Expect(limiter.Active("some-handle")).To(Equal(2))
+		_, err := limiter.Acquire("some-handle")
+		Expect(err).To(HaveOccurred())
+	})
+
+	Context("when the limiter is nil", func() {
+		It("imposes no limit", func() {
+			var nilLimiter *gardener.ProcessLimiter
+			for i := 0; i < 10; i++ {
+				_, err := nilLimiter.Acquire("some-handle")
+				Expect(err).NotTo(HaveOccurred())
+			}
+		})
+	})
+})
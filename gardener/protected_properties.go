@@ -0,0 +1,91 @@
+package gardener
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/cloudfoundry-incubator/garden"
+)
+
+// guardianProperties are set by guardian itself, so clients may neither set
+// nor remove them
+var guardianProperties = map[string]bool{
+	AgeProperty:              true,
+	CPUThrottledProperty:     true,
+	LimitsProperty:           true,
+	MetricsRelayPathProperty: true,
+	MountedVolumesKey:        true,
+	PeasKey:                  true,
+}
+
+// hiddenProperties are kept by guardian for its own use, such as the spec a
+// container was created with, so are not shown to clients either
+var hiddenProperties = map[string]bool{
+	SpecKey: true,
+}
+
+// guardianPropertyPrefixes are the prefixes of properties which record the
+// container's network state, such as its NetOut rules and iptables chain;
+// they are applied again when the container is recovered or restored, so
+// clients may neither set nor remove them
+var guardianPropertyPrefixes = []string{"garden.network.", "kawasaki."}
+
+// createOnlyProperties are acted on when a container is created, and some
+// again when it is recovered, so they may be given in its spec but not
+// changed afterwards
+var createOnlyProperties = map[string]bool{
+	MetricsSocketProperty: true,
+	RestartPolicyProperty: true,
+	VolumesProperty:       true,
+}
+
+// checkSpecProperties refuses specs which set guardian's own properties
+func checkSpecProperties(properties garden.Properties) error {
+	for name := range properties {
+		if isGuardianProperty(name) {
+			return fmt.Errorf("%s property cannot be set", name)
+		}
+	}
+
+	return nil
+}
+
+// checkChangeable refuses changes to properties of an existing container
+// which clients may not make
+func checkChangeable(name string) error {
+	if isGuardianProperty(name) {
+		return fmt.Errorf("%s property cannot be set", name)
+	}
+
+	if createOnlyProperties[name] {
+		return fmt.Errorf("%s property can only be set when the container is created", name)
+	}
+
+	return nil
+}
+
+func isGuardianProperty(name string) bool {
+	if guardianProperties[name] || hiddenProperties[name] {
+		return true
+	}
+
+	for _, prefix := range guardianPropertyPrefixes {
+		if strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// withoutHidden returns the properties other than hiddenProperties
+func withoutHidden(properties garden.Properties) garden.Properties {
+	visible := garden.Properties{}
+	for name, value := range properties {
+		if !hiddenProperties[name] {
+			visible[name] = value
+		}
+	}
+
+	return visible
+}
@@ -0,0 +1,123 @@
+package gardener
+
+import (
+	"errors"
+	"io"
+	"time"
+
+	"github.com/cloudfoundry-incubator/garden"
+)
+
+// ErrReadOnly is returned by a ReadOnlyBackend, and the containers it looks
+// up, for any request which would change a container or the host
+var ErrReadOnly = errors.New("the garden API is read-only on this listener")
+
+// ReadOnlyBackend serves the requests of a Backend which only observe it
+// (ping, capacity, listing containers, their info, metrics and properties),
+// so that monitoring agents can be given access to the garden API without
+// being able to create, destroy or run processes in containers. It shares
+// the Backend with the main listener, so does not start or stop it.
+type ReadOnlyBackend struct {
+	garden.Backend
+}
+
+func (ReadOnlyBackend) Start() error { return nil }
+func (ReadOnlyBackend) Stop()        {}
+
+func (ReadOnlyBackend) Create(garden.ContainerSpec) (garden.Container, error) {
+	return nil, ErrReadOnly
+}
+
+func (ReadOnlyBackend) Destroy(string) error {
+	return ErrReadOnly
+}
+
+func (b ReadOnlyBackend) Lookup(handle string) (garden.Container, error) {
+	container, err := b.Backend.Lookup(handle)
+	if err != nil {
+		return nil, err
+	}
+
+	return readOnlyContainer{container}, nil
+}
+
+func (b ReadOnlyBackend) Containers(props garden.Properties) ([]garden.Container, error) {
+	containers, err := b.Backend.Containers(props)
+	if err != nil {
+		return nil, err
+	}
+
+	readOnly := make([]garden.Container, len(containers))
+	for i, container := range containers {
+		readOnly[i] = readOnlyContainer{container}
+	}
+
+	return readOnly, nil
+}
+
+// readOnlyContainer refuses everything but reading a container's info,
+// limits, metrics and properties. Streaming files out is refused too, since
+// a container's files may hold its secrets.
+type readOnlyContainer struct {
+	garden.Container
+}
+
+func (readOnlyContainer) Run(garden.ProcessSpec, garden.ProcessIO) (garden.Process, error) {
+	return nil, ErrReadOnly
+}
+
+func (readOnlyContainer) Attach(string, garden.ProcessIO) (garden.Process, error) {
+	return nil, ErrReadOnly
+}
+
+func (readOnlyContainer) Stop(bool) error {
+	return ErrReadOnly
+}
+
+func (readOnlyContainer) StreamIn(garden.StreamInSpec) error {
+	return ErrReadOnly
+}
+
+func (readOnlyContainer) StreamOut(garden.StreamOutSpec) (io.ReadCloser, error) {
+	return nil, ErrReadOnly
+}
+
+func (readOnlyContainer) LimitBandwidth(garden.BandwidthLimits) error {
+	return ErrReadOnly
+}
+
+func (readOnlyContainer) LimitCPU(garden.CPULimits) error {
+	return ErrReadOnly
+}
+
+func (readOnlyContainer) LimitDisk(garden.DiskLimits) error {
+	return ErrReadOnly
+}
+
+func (readOnlyContainer) LimitMemory(garden.MemoryLimits) error {
+	return ErrReadOnly
+}
+
+func (readOnlyContainer) NetIn(uint32, uint32) (uint32, uint32, error) {
+	return 0, 0, ErrReadOnly
+}
+
+func (readOnlyContainer) NetOut(garden.NetOutRule) error {
+	return ErrReadOnly
+}
+
+func (readOnlyContainer) BulkNetOut([]garden.NetOutRule) error {
+	return ErrReadOnly
+}
+
+func (readOnlyContainer) SetProperty(string, string) error {
+	return ErrReadOnly
+}
+
+func (readOnlyContainer) RemoveProperty(string) error {
+	return ErrReadOnly
+}
+
+func (readOnlyContainer) SetGraceTime(time.Duration) error {
+	return ErrReadOnly
+}
@@ -0,0 +1,138 @@
+package gardener_test
+
+import (
+	"errors"
+
+	"github.com/cloudfoundry-incubator/garden"
+	gardenfakes "github.com/cloudfoundry-incubator/garden/fakes"
+	"github.com/cloudfoundry-incubator/guardian/gardener"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ReadOnlyBackend", func() {
+	var (
+		fakeBackend   *gardenfakes.FakeBackend
+		fakeContainer *gardenfakes.FakeContainer
+		backend       gardener.ReadOnlyBackend
+	)
+
+	BeforeEach(func() {
+		fakeBackend = new(gardenfakes.FakeBackend)
+		fakeContainer = new(gardenfakes.FakeContainer)
+		fakeContainer.HandleReturns("banana")
+		fakeBackend.LookupReturns(fakeContainer, nil)
+		fakeBackend.ContainersReturns([]garden.Container{fakeContainer}, nil)
+
+		backend = gardener.ReadOnlyBackend{Backend: fakeBackend}
+	})
+
+	It("does not start or stop the shared backend", func() {
+		Expect(backend.Start()).To(Succeed())
+		backend.Stop()
+
+		Expect(fakeBackend.StartCallCount()).To(Equal(0))
+		Expect(fakeBackend.StopCallCount()).To(Equal(0))
+	})
+
+	It("refuses to create containers", func() {
+		_, err := backend.Create(garden.ContainerSpec{Handle: "banana"})
+		Expect(err).To(MatchError(gardener.ErrReadOnly))
+		Expect(fakeBackend.CreateCallCount()).To(Equal(0))
+	})
+
+	It("refuses to destroy containers", func() {
+		Expect(backend.Destroy("banana")).To(MatchError(gardener.ErrReadOnly))
+		Expect(fakeBackend.DestroyCallCount()).To(Equal(0))
+	})
+
+	It("lists containers through the backend", func() {
+		containers, err := backend.Containers(garden.Properties{"foo": "bar"})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(containers).To(HaveLen(1))
+		Expect(containers[0].Handle()).To(Equal("banana"))
+		Expect(fakeBackend.ContainersArgsForCall(0)).To(Equal(garden.Properties{"foo": "bar"}))
+	})
+
+	It("returns the backend's errors when listing containers", func() {
+		fakeBackend.ContainersReturns(nil, errors.New("boom"))
+
+		_, err := backend.Containers(nil)
+		Expect(err).To(MatchError("boom"))
+	})
+
+	It("returns the backend's errors when looking up a container", func() {
+		fakeBackend.LookupReturns(nil, errors.New("not found"))
+
+		_, err := backend.Lookup("banana")
+		Expect(err).To(MatchError("not found"))
+	})
+
+	It("passes bulk info through to the backend", func() {
+		fakeBackend.BulkInfoReturns(map[string]garden.ContainerInfoEntry{"banana": {}}, nil)
+
+		info, err := backend.BulkInfo([]string{"banana"})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(info).To(HaveKey("banana"))
+	})
+
+	Describe("a looked up container", func() {
+		var container garden.Container
+
+		BeforeEach(func() {
+			var err error
+			container, err = backend.Lookup("banana")
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("reads its info, metrics and properties from the container", func() {
+			fakeContainer.InfoReturns(garden.ContainerInfo{State: "active"}, nil)
+			fakeContainer.PropertiesReturns(garden.Properties{"foo": "bar"}, nil)
+
+			info, err := container.Info()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(info.State).To(Equal("active"))
+
+			_, err = container.Metrics()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(fakeContainer.MetricsCallCount()).To(Equal(1))
+
+			props, err := container.Properties()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(props).To(Equal(garden.Properties{"foo": "bar"}))
+		})
+
+		It("refuses to run or attach to processes", func() {
+			_, err := container.Run(garden.ProcessSpec{Path: "rm"}, garden.ProcessIO{})
+			Expect(err).To(MatchError(gardener.ErrReadOnly))
+
+			_, err = container.Attach("123", garden.ProcessIO{})
+			Expect(err).To(MatchError(gardener.ErrReadOnly))
+
+			Expect(fakeContainer.RunCallCount()).To(Equal(0))
+			Expect(fakeContainer.AttachCallCount()).To(Equal(0))
+		})
+
+		It("refuses to stream files in or out", func() {
+			Expect(container.StreamIn(garden.StreamInSpec{})).To(MatchError(gardener.ErrReadOnly))
+
+			_, err := container.StreamOut(garden.StreamOutSpec{})
+			Expect(err).To(MatchError(gardener.ErrReadOnly))
+		})
+
+		It("refuses to change the container", func() {
+			Expect(container.Stop(true)).To(MatchError(gardener.ErrReadOnly))
+			Expect(container.LimitMemory(garden.MemoryLimits{})).To(MatchError(gardener.ErrReadOnly))
+			Expect(container.NetOut(garden.NetOutRule{})).To(MatchError(gardener.ErrReadOnly))
+			Expect(container.SetProperty("foo", "bar")).To(MatchError(gardener.ErrReadOnly))
+			Expect(container.RemoveProperty("foo")).To(MatchError(gardener.ErrReadOnly))
+
+			_, _, err := container.NetIn(0, 8080)
+			Expect(err).To(MatchError(gardener.ErrReadOnly))
+
+			Expect(fakeContainer.StopCallCount()).To(Equal(0))
+			Expect(fakeContainer.SetPropertyCallCount()).To(Equal(0))
+			Expect(fakeContainer.NetInCallCount()).To(Equal(0))
+		})
+	})
+})
@@ -0,0 +1,167 @@
+package gardener
+
+import (
+	"fmt"
+
+	"github.com/cloudfoundry-incubator/garden"
+	"github.com/pivotal-golang/lager"
+)
+
+// Recoverer is a Starter which re-establishes guardian's state for the
+// containers left in the depot by a previous run, so that they keep running,
+// and keep their network, across a restart of guardian. It must be started
+// after any starters which reset host state, such as the global iptables
+// chains.
+//
+// Containers which cannot be recovered are logged and left in place rather
+// than destroyed, so that they can be inspected or destroyed through the API.
+//
+// If a SocketRelay is given, the metrics sockets of the containers which are
+// still running are relayed to the host again.
+//
+// If the Networker is a PruningNetworker, the addresses held by containers
+// which are no longer in the depot are released once the others have been
+// recovered.
+//
+// If Peas is given, the peas whose processes exited while guardian was not
+// running are cleaned up.
+//
+// If the Containerizer is a ContainerRestarter, stopped containers whose
+// restart policy asks for it are re-started.
+type Recoverer struct {
+	Containerizer Containerizer
+	Networker     Networker
+	Logger        lager.Logger
+
+	// Peas cleans up containers' exited peas (optional)
+	Peas PeaRecoverer
+
+	// SocketRelay and PropertyManager re-establish the relays of containers'
+	// metrics sockets (optional)
+	SocketRelay     SocketRelay
+	PropertyManager PropertyManager
+}
+
+//go:generate counterfeiter . PeaRecoverer
+
+// PeaRecoverer cleans up the peas of a container whose processes exited while
+// guardian was not running, e.g. the Gardener
+type PeaRecoverer interface {
+	RecoverPeas(log lager.Logger, handle string) error
+}
+
+//go:generate counterfeiter . ContainerRestarter
+
+// ContainerRestarter is implemented by Containerizers which can re-start a
+// container whose init process has died, if its restart policy asks for it
+type ContainerRestarter interface {
+	Restart(log lager.Logger, handle string) (bool, error)
+}
+
+//go:generate counterfeiter . PruningNetworker
+
+// PruningNetworker is implemented by Networkers which persist the addresses
+// they allocate to containers across a restart
+type PruningNetworker interface {
+	Prune(log lager.Logger, handles []string) error
+}
+
+func (r *Recoverer) Start() error {
+	log := r.Logger.Session("recover")
+
+	handles, err := r.Containerizer.Handles()
+	if err != nil {
+		log.Error("list-containers-failed", err)
+		return fmt.Errorf("recover: list containers: %s", err)
+	}
+
+	var (
+		running, stopped int
+		failed           = []string{}
+	)
+
+	for _, handle := range handles {
+		hLog := log.Session("container", lager.Data{"handle": handle})
+
+		info, err := r.Containerizer.Info(hLog, handle)
+		if err != nil {
+			hLog.Error("info-failed", err)
+			failed = append(failed, handle)
+			continue
+		}
+
+		if err := r.Networker.Recover(hLog, handle); err != nil {
+			hLog.Error("recover-network-failed", err)
+			failed = append(failed, handle)
+			continue
+		}
+
+		if r.Peas != nil {
+			if err := r.Peas.RecoverPeas(hLog, handle); err != nil {
+				hLog.Error("recover-peas-failed", err)
+			}
+		}
+
+		if info.Stopped && !r.restart(hLog, handle) {
+			stopped++
+			continue
+		}
+
+		running++
+		if err := r.relayMetricsSocket(hLog, handle); err != nil {
+			hLog.Error("relay-metrics-socket-failed", err)
+		}
+	}
+
+	if pruner, ok := r.Networker.(PruningNetworker); ok {
+		if err := pruner.Prune(log, handles); err != nil {
+			log.Error("prune-network-failed", err)
+			return fmt.Errorf("recover: release addresses of removed containers: %s", err)
+		}
+	}
+
+	r.Logger.Info("recovered", lager.Data{
+		"running": running,
+		"stopped": stopped,
+		"failed":  failed,
+	})
+
+	return nil
+}
+
+// restart re-starts the stopped container if its restart policy asks for it,
+// returning whether it is running again
+func (r *Recoverer) restart(log lager.Logger, handle string) bool {
+	restarter, ok := r.Containerizer.(ContainerRestarter)
+	if !ok {
+		return false
+	}
+
+	restarted, err := restarter.Restart(log, handle)
+	if err != nil {
+		log.Error("restart-failed", err)
+		return false
+	}
+
+	return restarted
+}
+
+// relayMetricsSocket relays the container's metrics socket to the host again,
+// if it has one
+func (r *Recoverer) relayMetricsSocket(log lager.Logger, handle string) error {
+	if r.SocketRelay == nil {
+		return nil
+	}
+
+	stored, err := r.PropertyManager.Get(handle, MetricsSocketProperty)
+	if err != nil || stored == "" {
+		return nil
+	}
+
+	socket, err := parseMetricsSocket(garden.Properties{MetricsSocketProperty: stored})
+	if err != nil {
+		return err
+	}
+
+	return relaySocket(log, r.SocketRelay, r.PropertyManager, handle, socket)
+}
@@ -0,0 +1,261 @@
+package gardener_test
+
+import (
+	"errors"
+
+	"github.com/cloudfoundry-incubator/guardian/gardener"
+	"github.com/cloudfoundry-incubator/guardian/gardener/fakes"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/pivotal-golang/lager"
+	"github.com/pivotal-golang/lager/lagertest"
+)
+
+var _ = Describe("Recoverer", func() {
+	var (
+		containerizer *fakes.FakeContainerizer
+		networker     *fakes.FakeNetworker
+		logger        *lagertest.TestLogger
+		recoverer     *gardener.Recoverer
+	)
+
+	BeforeEach(func() {
+		containerizer = new(fakes.FakeContainerizer)
+		networker = new(fakes.FakeNetworker)
+		logger = lagertest.NewTestLogger("guardian")
+
+		recoverer = &gardener.Recoverer{
+			Containerizer: containerizer,
+			Networker:     networker,
+			Logger:        logger,
+		}
+
+		containerizer.HandlesReturns([]string{"running-container", "stopped-container"}, nil)
+		containerizer.InfoStub = func(_ lager.Logger, handle string) (gardener.ActualContainerSpec, error) {
+			return gardener.ActualContainerSpec{Stopped: handle == "stopped-container"}, nil
+		}
+	})
+
+	It("recovers the network of every container in the depot", func() {
+		Expect(recoverer.Start()).To(Succeed())
+
+		Expect(networker.RecoverCallCount()).To(Equal(2))
+		_, handle := networker.RecoverArgsForCall(0)
+		Expect(handle).To(Equal("running-container"))
+		_, handle = networker.RecoverArgsForCall(1)
+		Expect(handle).To(Equal("stopped-container"))
+	})
+
+	It("logs 'guardian.recovered' with a summary", func() {
+		Expect(recoverer.Start()).To(Succeed())
+
+		logs := logger.Logs()
+		Expect(logs).NotTo(BeEmpty())
+
+		last := logs[len(logs)-1]
+		Expect(last.Message).To(Equal("guardian.recovered"))
+		Expect(last.Data).To(HaveKeyWithValue("running", BeNumerically("==", 1)))
+		Expect(last.Data).To(HaveKeyWithValue("stopped", BeNumerically("==", 1)))
+	})
+
+	It("does not destroy anything", func() {
+		Expect(recoverer.Start()).To(Succeed())
+
+		Expect(containerizer.DestroyCallCount()).To(Equal(0))
+		Expect(networker.DestroyCallCount()).To(Equal(0))
+	})
+
+	Context("when the containerizer can re-start containers", func() {
+		var restarter *fakes.FakeContainerRestarter
+
+		BeforeEach(func() {
+			restarter = new(fakes.FakeContainerRestarter)
+			recoverer.Containerizer = restartingContainerizer{
+				FakeContainerizer:      containerizer,
+				FakeContainerRestarter: restarter,
+			}
+		})
+
+		It("re-starts only the stopped containers", func() {
+			restarter.RestartReturns(true, nil)
+
+			Expect(recoverer.Start()).To(Succeed())
+
+			Expect(restarter.RestartCallCount()).To(Equal(1))
+			_, handle := restarter.RestartArgsForCall(0)
+			Expect(handle).To(Equal("stopped-container"))
+
+			last := logger.Logs()[len(logger.Logs())-1]
+			Expect(last.Data).To(HaveKeyWithValue("running", BeNumerically("==", 2)))
+			Expect(last.Data).To(HaveKeyWithValue("stopped", BeNumerically("==", 0)))
+		})
+
+		It("counts containers which are not re-started as stopped", func() {
+			restarter.RestartReturns(false, errors.New("bundle gone"))
+
+			Expect(recoverer.Start()).To(Succeed())
+
+			last := logger.Logs()[len(logger.Logs())-1]
+			Expect(last.Data).To(HaveKeyWithValue("stopped", BeNumerically("==", 1)))
+		})
+	})
+
+	Context("when a pea recoverer is given", func() {
+		var peas *fakes.FakePeaRecoverer
+
+		BeforeEach(func() {
+			peas = new(fakes.FakePeaRecoverer)
+			recoverer.Peas = peas
+		})
+
+		It("recovers the peas of every container", func() {
+			Expect(recoverer.Start()).To(Succeed())
+
+			Expect(peas.RecoverPeasCallCount()).To(Equal(2))
+			_, handle := peas.RecoverPeasArgsForCall(0)
+			Expect(handle).To(Equal("running-container"))
+			_, handle = peas.RecoverPeasArgsForCall(1)
+			Expect(handle).To(Equal("stopped-container"))
+		})
+
+		Context("when a container's peas cannot be recovered", func() {
+			It("recovers the rest of the container", func() {
+				peas.RecoverPeasReturns(errors.New("runc exploded"))
+
+				Expect(recoverer.Start()).To(Succeed())
+				Expect(networker.RecoverCallCount()).To(Equal(2))
+			})
+		})
+	})
+
+	Context("when a container's network cannot be recovered", func() {
+		BeforeEach(func() {
+			networker.RecoverStub = func(_ lager.Logger, handle string) error {
+				if handle == "running-container" {
+					return errors.New("no config")
+				}
+
+				return nil
+			}
+		})
+
+		It("recovers the other containers and reports the failure", func() {
+			Expect(recoverer.Start()).To(Succeed())
+			Expect(networker.RecoverCallCount()).To(Equal(2))
+
+			logs := logger.Logs()
+			Expect(logs[len(logs)-1].Data).To(HaveKeyWithValue("failed", ConsistOf("running-container")))
+			Expect(containerizer.DestroyCallCount()).To(Equal(0))
+		})
+	})
+
+	Context("when the state of a container cannot be read", func() {
+		BeforeEach(func() {
+			containerizer.InfoReturns(gardener.ActualContainerSpec{}, errors.New("no state"))
+			containerizer.InfoStub = nil
+		})
+
+		It("does not recover its network", func() {
+			Expect(recoverer.Start()).To(Succeed())
+			Expect(networker.RecoverCallCount()).To(Equal(0))
+		})
+	})
+
+	Context("when a socket relay is given", func() {
+		var (
+			relay           *fakes.FakeSocketRelay
+			propertyManager *fakes.FakePropertyManager
+		)
+
+		BeforeEach(func() {
+			relay = new(fakes.FakeSocketRelay)
+			relay.RelayReturns("/var/run/relays/running-container.sock", nil)
+			propertyManager = new(fakes.FakePropertyManager)
+			propertyManager.GetStub = func(handle, name string) (string, error) {
+				if name == gardener.MetricsSocketProperty {
+					return "@exporter", nil
+				}
+
+				return "", errors.New("no such property")
+			}
+
+			recoverer.SocketRelay = relay
+			recoverer.PropertyManager = propertyManager
+		})
+
+		It("relays the metrics sockets of running containers again", func() {
+			Expect(recoverer.Start()).To(Succeed())
+
+			Expect(relay.RelayCallCount()).To(Equal(1))
+			_, handle, socket := relay.RelayArgsForCall(0)
+			Expect(handle).To(Equal("running-container"))
+			Expect(socket).To(Equal("@exporter"))
+
+			handle, name, value := propertyManager.SetArgsForCall(0)
+			Expect(handle).To(Equal("running-container"))
+			Expect(name).To(Equal(gardener.MetricsRelayPathProperty))
+			Expect(value).To(Equal("/var/run/relays/running-container.sock"))
+		})
+
+		It("does not relay a stored socket which is not valid", func() {
+			propertyManager.GetReturns("run/guardian.sock", nil)
+			propertyManager.GetStub = nil
+
+			Expect(recoverer.Start()).To(Succeed())
+			Expect(relay.RelayCallCount()).To(Equal(0))
+		})
+
+		It("still recovers the container when its socket cannot be relayed", func() {
+			relay.RelayReturns("", errors.New("address already in use"))
+			Expect(recoverer.Start()).To(Succeed())
+
+			logs := logger.Logs()
+			Expect(logs[len(logs)-1].Data).To(HaveKeyWithValue("failed", BeEmpty()))
+		})
+	})
+
+	Context("when the networker persists the addresses it allocates", func() {
+		var pruner *fakes.FakePruningNetworker
+
+		BeforeEach(func() {
+			pruner = new(fakes.FakePruningNetworker)
+			recoverer.Networker = struct {
+				*fakes.FakeNetworker
+				*fakes.FakePruningNetworker
+			}{networker, pruner}
+		})
+
+		It("releases the addresses of containers which are not in the depot", func() {
+			Expect(recoverer.Start()).To(Succeed())
+
+			Expect(pruner.PruneCallCount()).To(Equal(1))
+			_, handles := pruner.PruneArgsForCall(0)
+			Expect(handles).To(ConsistOf("running-container", "stopped-container"))
+		})
+
+		It("keeps the addresses of containers which could not be recovered", func() {
+			networker.RecoverReturns(errors.New("no config"))
+			Expect(recoverer.Start()).To(Succeed())
+
+			_, handles := pruner.PruneArgsForCall(0)
+			Expect(handles).To(ConsistOf("running-container", "stopped-container"))
+		})
+
+		It("returns an error when the addresses cannot be released", func() {
+			pruner.PruneReturns(errors.New("read-only filesystem"))
+			Expect(recoverer.Start()).To(MatchError("recover: release addresses of removed containers: read-only filesystem"))
+		})
+	})
+
+	Context("when the containers cannot be listed", func() {
+		It("returns an error", func() {
+			containerizer.HandlesReturns(nil, errors.New("no depot"))
+			Expect(recoverer.Start()).To(MatchError("recover: list containers: no depot"))
+		})
+	})
+})
+
+type restartingContainerizer struct {
+	*fakes.FakeContainerizer
+	*fakes.FakeContainerRestarter
+}
@@ -0,0 +1,63 @@
+package gardener
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/cloudfoundry-incubator/garden"
+	"github.com/cloudfoundry-incubator/garden-shed/rootfs_provider"
+	"github.com/pivotal-golang/lager"
+)
+
+// RootFSLayersProperty lists, separated by commas and lowest first, base
+// layers already on the host on which the container's rootfs is built (e.g.
+// as overlay lower directories), so that containers with common bases share
+// their layers rather than each fetching and unpacking them
+const RootFSLayersProperty = "rootfs-layers"
+
+var errLayersNotSupported = Classify(FailureInvalidSpec, errors.New("the volume creator does not support base layers"))
+
+//go:generate counterfeiter . LayeredVolumeCreator
+
+// LayeredVolumeCreator is implemented by VolumeCreators which can build a
+// rootfs on top of base layers already on the host. If mappings is nil the
+// volume creator's default id mappings are used.
+type LayeredVolumeCreator interface {
+	CreateLayered(log lager.Logger, handle string, spec rootfs_provider.Spec, mappings *IDMappings, layers []string) (string, []string, error)
+}
+
+// parseRootFSLayers returns the container's base layers, or nil if it has
+// none. Layers must be absolute paths within layerDir, which the operator
+// controls: they are mounted as the container's rootfs, so must not be
+// arbitrary host directories.
+func parseRootFSLayers(properties garden.Properties, layerDir string) ([]string, error) {
+	raw, ok := properties[RootFSLayersProperty]
+	if !ok || raw == "" {
+		return nil, nil
+	}
+
+	if layerDir == "" {
+		return nil, fmt.Errorf("invalid %s property: base layers are not enabled", RootFSLayersProperty)
+	}
+
+	layerDir = filepath.Clean(layerDir)
+
+	var layers []string
+	for _, layer := range strings.Split(raw, ",") {
+		layer = strings.TrimSpace(layer)
+		if !filepath.IsAbs(layer) {
+			return nil, fmt.Errorf("invalid %s property: '%s' is not an absolute path", RootFSLayersProperty, layer)
+		}
+
+		layer = filepath.Clean(layer)
+		if !strings.HasPrefix(layer, layerDir+string(filepath.Separator)) {
+			return nil, fmt.Errorf("invalid %s property: '%s' is not in %s", RootFSLayersProperty, layer, layerDir)
+		}
+
+		layers = append(layers, layer)
+	}
+
+	return layers, nil
+}
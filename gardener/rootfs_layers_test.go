@@ -0,0 +1,109 @@
+package gardener_test
+
+import (
+	"github.com/cloudfoundry-incubator/garden"
+	"github.com/cloudfoundry-incubator/garden-shed/rootfs_provider"
+	"github.com/cloudfoundry-incubator/guardian/gardener"
+	"github.com/cloudfoundry-incubator/guardian/gardener/fakes"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/pivotal-golang/lager"
+	"github.com/pivotal-golang/lager/lagertest"
+)
+
+type fakeLayeredVolumeCreator struct {
+	*fakes.FakeVolumeCreator
+	*fakes.FakeLayeredVolumeCreator
+}
+
+var _ = Describe("Rootfs layers", func() {
+	var (
+		volumeCreator fakeLayeredVolumeCreator
+		gdnr          *gardener.Gardener
+	)
+
+	BeforeEach(func() {
+		volumeCreator = fakeLayeredVolumeCreator{new(fakes.FakeVolumeCreator), new(fakes.FakeLayeredVolumeCreator)}
+		volumeCreator.CreateLayeredReturns("/path/to/rootfs", nil, nil)
+
+		gdnr = &gardener.Gardener{
+			Containerizer:   new(fakes.FakeContainerizer),
+			Networker:       new(fakes.FakeNetworker),
+			VolumeCreator:   volumeCreator,
+			PropertyManager: new(fakes.FakePropertyManager),
+			UidGenerator:    new(fakes.FakeUidGenerator),
+			Logger:          lagertest.NewTestLogger("test"),
+			LayerDir:        "/layers",
+		}
+	})
+
+	create := func(properties garden.Properties) error {
+		_, err := gdnr.Create(garden.ContainerSpec{Handle: "bob", RootFSPath: "docker:///busybox", Properties: properties})
+		return err
+	}
+
+	It("builds the rootfs on the base layers", func() {
+		Expect(create(garden.Properties{gardener.RootFSLayersProperty: "/layers/base, /layers/runtime/"})).To(Succeed())
+
+		Expect(volumeCreator.CreateLayeredCallCount()).To(Equal(1))
+		_, handle, spec, mappings, layers := volumeCreator.CreateLayeredArgsForCall(0)
+		Expect(handle).To(Equal("bob"))
+		Expect(spec.RootFS.String()).To(Equal("docker:///busybox"))
+		Expect(mappings).To(BeNil())
+		Expect(layers).To(Equal([]string{"/layers/base", "/layers/runtime"}))
+
+		Expect(volumeCreator.CreateCallCount()).To(Equal(0))
+	})
+
+	It("creates the rootfs as usual when there are no layers", func() {
+		Expect(create(garden.Properties{})).To(Succeed())
+
+		Expect(volumeCreator.CreateCallCount()).To(Equal(1))
+		Expect(volumeCreator.CreateLayeredCallCount()).To(Equal(0))
+	})
+
+	It("rejects relative layers", func() {
+		Expect(create(garden.Properties{gardener.RootFSLayersProperty: "layers/base"})).To(
+			MatchError("invalid rootfs-layers property: 'layers/base' is not an absolute path"),
+		)
+		Expect(volumeCreator.CreateLayeredCallCount()).To(Equal(0))
+	})
+
+	It("rejects layers outside the layer directory", func() {
+		Expect(create(garden.Properties{gardener.RootFSLayersProperty: "/layers/base,/layers/../etc"})).To(
+			MatchError("invalid rootfs-layers property: '/etc' is not in /layers"),
+		)
+		Expect(volumeCreator.CreateLayeredCallCount()).To(Equal(0))
+	})
+
+	It("rejects the layer directory itself", func() {
+		Expect(create(garden.Properties{gardener.RootFSLayersProperty: "/layers/"})).To(
+			MatchError("invalid rootfs-layers property: '/layers' is not in /layers"),
+		)
+	})
+
+	Context("when there is no layer directory", func() {
+		It("rejects layers", func() {
+			gdnr.LayerDir = ""
+
+			Expect(create(garden.Properties{gardener.RootFSLayersProperty: "/layers/base"})).To(
+				MatchError("invalid rootfs-layers property: base layers are not enabled"),
+			)
+			Expect(volumeCreator.CreateLayeredCallCount()).To(Equal(0))
+		})
+	})
+
+	Context("when the volume creator does not support layers", func() {
+		It("fails", func() {
+			plain := new(fakes.FakeVolumeCreator)
+			plain.CreateStub = func(lager.Logger, string, rootfs_provider.Spec) (string, []string, error) {
+				return "/path/to/rootfs", nil, nil
+			}
+			gdnr.VolumeCreator = plain
+
+			Expect(create(garden.Properties{gardener.RootFSLayersProperty: "/layers/base"})).To(
+				MatchError("the volume creator does not support base layers"),
+			)
+		})
+	})
+})
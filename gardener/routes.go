@@ -0,0 +1,83 @@
+package gardener
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/cloudfoundry-incubator/garden"
+	"github.com/pivotal-golang/lager"
+)
+
+// RoutesProperty lists, separated by commas, static routes added to the
+// container's network namespace, each a destination CIDR optionally followed
+// by 'via' and the next hop, e.g. "10.100.0.0/16,172.20.0.0/16 via 10.254.0.5".
+// Routes without a next hop are via the container's gateway.
+const RoutesProperty = "network-routes"
+
+// Route is a static route in a container's network namespace
+type Route struct {
+	Destination *net.IPNet
+
+	// Via is the next hop, or nil for the container's gateway
+	Via net.IP
+}
+
+func (r Route) String() string {
+	if r.Via == nil {
+		return r.Destination.String()
+	}
+
+	return fmt.Sprintf("%s via %s", r.Destination, r.Via)
+}
+
+// ParseRoute parses a route as formatted by Route.String
+func ParseRoute(raw string) (Route, error) {
+	fields := strings.Fields(raw)
+	if len(fields) != 1 && (len(fields) != 3 || fields[1] != "via") {
+		return Route{}, fmt.Errorf("'%s' is not a CIDR optionally followed by 'via' and an IP address", raw)
+	}
+
+	_, destination, err := net.ParseCIDR(fields[0])
+	if err != nil {
+		return Route{}, fmt.Errorf("'%s' is not a CIDR", fields[0])
+	}
+
+	route := Route{Destination: destination}
+	if len(fields) == 3 {
+		if route.Via = net.ParseIP(fields[2]); route.Via == nil {
+			return Route{}, fmt.Errorf("'%s' is not an IP address", fields[2])
+		}
+
+		if (route.Via.To4() == nil) != (destination.IP.To4() == nil) {
+			return Route{}, fmt.Errorf("next hop '%s' is not in the same address family as '%s'", fields[2], fields[0])
+		}
+	}
+
+	return route, nil
+}
+
+//go:generate counterfeiter . RoutesNetworker
+
+// RoutesNetworker is implemented by Networkers which can add static routes
+// to a container's network namespace
+type RoutesNetworker interface {
+	HooksWithRoutes(log lager.Logger, handle, spec string, dns DNSSpec, routes []Route) (Hooks, error)
+}
+
+var errRoutesNotSupported = Classify(FailureInvalidSpec, errors.New("the networker does not support per-container routes"))
+
+func parseRoutes(properties garden.Properties) ([]Route, error) {
+	var routes []Route
+	for _, raw := range splitProperty(properties[RoutesProperty]) {
+		route, err := ParseRoute(raw)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s property: %s", RoutesProperty, err)
+		}
+
+		routes = append(routes, route)
+	}
+
+	return routes, nil
+}
@@ -0,0 +1,89 @@
+package gardener_test
+
+import (
+	"net"
+
+	"github.com/cloudfoundry-incubator/garden"
+	"github.com/cloudfoundry-incubator/guardian/gardener"
+	"github.com/cloudfoundry-incubator/guardian/gardener/fakes"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/pivotal-golang/lager/lagertest"
+)
+
+type fakeRoutesNetworker struct {
+	*fakes.FakeNetworker
+	*fakes.FakeRoutesNetworker
+}
+
+var _ = Describe("Per-container routes", func() {
+	var (
+		networker fakeRoutesNetworker
+		gdnr      *gardener.Gardener
+	)
+
+	BeforeEach(func() {
+		networker = fakeRoutesNetworker{new(fakes.FakeNetworker), new(fakes.FakeRoutesNetworker)}
+		networker.HooksWithRoutesReturns(gardener.Hooks{Prestart: gardener.Hook{Path: "/path/to/hook"}}, nil)
+
+		gdnr = &gardener.Gardener{
+			Containerizer:   new(fakes.FakeContainerizer),
+			Networker:       networker,
+			VolumeCreator:   new(fakes.FakeVolumeCreator),
+			PropertyManager: new(fakes.FakePropertyManager),
+			UidGenerator:    new(fakes.FakeUidGenerator),
+			Logger:          lagertest.NewTestLogger("test"),
+		}
+	})
+
+	create := func(properties garden.Properties) error {
+		_, err := gdnr.Create(garden.ContainerSpec{Handle: "bob", Network: "10.0.0.0/30", Properties: properties})
+		return err
+	}
+
+	It("passes the container's routes and DNS configuration to the networker", func() {
+		Expect(create(garden.Properties{
+			gardener.RoutesProperty:           "10.100.0.0/16, 172.20.0.0/16  via 10.0.0.1",
+			gardener.DNSSearchDomainsProperty: "example.com",
+		})).To(Succeed())
+
+		Expect(networker.HooksWithRoutesCallCount()).To(Equal(1))
+		_, handle, spec, dns, routes := networker.HooksWithRoutesArgsForCall(0)
+		Expect(handle).To(Equal("bob"))
+		Expect(spec).To(Equal("10.0.0.0/30"))
+		Expect(dns.SearchDomains).To(Equal([]string{"example.com"}))
+
+		_, serviceNet, _ := net.ParseCIDR("10.100.0.0/16")
+		_, otherNet, _ := net.ParseCIDR("172.20.0.0/16")
+		Expect(routes).To(Equal([]gardener.Route{
+			{Destination: serviceNet},
+			{Destination: otherNet, Via: net.ParseIP("10.0.0.1")},
+		}))
+		Expect(networker.HooksCallCount()).To(Equal(0))
+	})
+
+	It("formats routes as they are parsed", func() {
+		route, err := gardener.ParseRoute("172.20.0.0/16 via 10.0.0.1")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(route.String()).To(Equal("172.20.0.0/16 via 10.0.0.1"))
+	})
+
+	It("rejects routes which are not a CIDR optionally followed by a next hop", func() {
+		err := create(garden.Properties{gardener.RoutesProperty: "10.100.0.0/16 through 10.0.0.1"})
+		Expect(err).To(MatchError("invalid network-routes property: '10.100.0.0/16 through 10.0.0.1' is not a CIDR optionally followed by 'via' and an IP address"))
+		Expect(networker.HooksWithRoutesCallCount()).To(Equal(0))
+	})
+
+	It("rejects next hops in another address family", func() {
+		err := create(garden.Properties{gardener.RoutesProperty: "10.100.0.0/16 via fd00::1"})
+		Expect(err).To(MatchError("invalid network-routes property: next hop 'fd00::1' is not in the same address family as '10.100.0.0/16'"))
+	})
+
+	Context("when the networker does not support per-container routes", func() {
+		It("fails", func() {
+			gdnr.Networker = new(fakes.FakeNetworker)
+			err := create(garden.Properties{gardener.RoutesProperty: "10.100.0.0/16"})
+			Expect(err).To(MatchError("the networker does not support per-container routes"))
+		})
+	})
+})
@@ -0,0 +1,173 @@
+package gardener
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/pivotal-golang/lager"
+)
+
+//go:generate counterfeiter . RuntimeInspector
+//go:generate counterfeiter . NetworkInspector
+//go:generate counterfeiter . ContainerRuntimeInspector
+
+// ContainerRuntime is the low-level state of a container's runtime
+type ContainerRuntime struct {
+	BundlePath string `json:"bundle_path"`
+
+	// InitPid is the host pid of the container's init process, and Stale
+	// is true if it has died even though runc's state says it is running
+	InitPid int  `json:"init_pid"`
+	Stale   bool `json:"stale"`
+
+	// Status is the runtime's status of the container, e.g. "running" or
+	// "stopped", if the runtime reports it
+	Status string `json:"status,omitempty"`
+
+	// RootFSPath is the path of the container's image volume, its rootfs
+	RootFSPath string `json:"rootfs_path,omitempty"`
+
+	// NetnsPath is the path of the container's network namespace on the
+	// host
+	NetnsPath string `json:"netns_path,omitempty"`
+
+	// CgroupPaths are the paths of the container's init process in each
+	// cgroup hierarchy, relative to the hierarchy's mount point, by the
+	// hierarchy's controllers, e.g. "cpu,cpuacct", or "unified" for cgroup v2
+	CgroupPaths map[string]string `json:"cgroup_paths,omitempty"`
+
+	// IODaemonPids are the host pids of the iodaemons serving the
+	// container's processes, by process ID
+	IODaemonPids map[string]int `json:"iodaemon_pids,omitempty"`
+
+	// RuntimeState is the runtime's own state document of the container,
+	// e.g. runc's state.json, in full
+	RuntimeState json.RawMessage `json:"runtime_state,omitempty"`
+}
+
+// NetworkRuntime is the low-level state of a container's network
+type NetworkRuntime struct {
+	HostInterface      string `json:"host_interface"`
+	ContainerInterface string `json:"container_interface"`
+	BridgeInterface    string `json:"bridge_interface"`
+	ContainerIP        string `json:"container_ip"`
+	BridgeIP           string `json:"bridge_ip"`
+}
+
+// RuntimeDetails are the low-level details of a container, for monitoring
+// and debugging tools which would otherwise have to read the depot and the
+// runtime's state themselves. Container and Network are nil if the
+// containerizer or the networker cannot be inspected.
+type RuntimeDetails struct {
+	Handle    string            `json:"handle"`
+	Container *ContainerRuntime `json:"container,omitempty"`
+	Network   *NetworkRuntime   `json:"network,omitempty"`
+}
+
+// RuntimeInspector reads the runtime state of containers
+type RuntimeInspector interface {
+	Inspect(log lager.Logger, handle string) (ContainerRuntime, error)
+}
+
+// NetworkInspector is implemented by Networkers which can report the
+// interfaces they created for a container
+type NetworkInspector interface {
+	InspectNetwork(log lager.Logger, handle string) (NetworkRuntime, error)
+}
+
+type ContainerRuntimeInspector interface {
+	InspectRuntime(handle string) (RuntimeDetails, error)
+}
+
+// InspectRuntime returns the low-level details of the container
+func (g *Gardener) InspectRuntime(handle string) (RuntimeDetails, error) {
+	log := g.Annotator.Logger(g.Logger, handle).Session("inspect-runtime")
+
+	if _, err := g.Containerizer.Info(log, handle); err != nil {
+		return RuntimeDetails{}, err
+	}
+
+	details := RuntimeDetails{Handle: handle}
+	if g.RuntimeInspector != nil {
+		container, err := g.RuntimeInspector.Inspect(log, handle)
+		if err != nil {
+			return RuntimeDetails{}, err
+		}
+
+		details.Container = &container
+	}
+
+	if inspector, ok := g.Networker.(NetworkInspector); ok {
+		network, err := inspector.InspectNetwork(log, handle)
+		if err != nil {
+			return RuntimeDetails{}, err
+		}
+
+		details.Network = &network
+	}
+
+	return details, nil
+}
+
+// RuntimeHandler serves the RuntimeDetails of the container named by the
+// `handle` query parameter as JSON
+type RuntimeHandler struct {
+	Inspector ContainerRuntimeInspector
+}
+
+func (h *RuntimeHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		writeError(w, r, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	handle := r.URL.Query().Get("handle")
+	if handle == "" {
+		writeError(w, r, "handle is required", http.StatusBadRequest)
+		return
+	}
+
+	details, err := h.Inspector.InspectRuntime(handle)
+	if err != nil {
+		writeError(w, r, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(details)
+}
+
+// ContainerStatePath is the prefix of the debug server's container state
+// documents, which are served at ContainerStatePath + "<handle>/state"
+const ContainerStatePath = "/debug/containers/"
+
+// ContainerStateHandler serves the RuntimeDetails of a container at
+// ContainerStatePath + "<handle>/state": its runtime state, bundle, cgroups,
+// network namespace, interfaces and rootfs in one document, for operators
+// debugging it during an incident
+type ContainerStateHandler struct {
+	Inspector ContainerRuntimeInspector
+}
+
+func (h *ContainerStateHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		writeError(w, r, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	handle := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, ContainerStatePath), "/state")
+	if handle == "" || strings.Contains(handle, "/") || !strings.HasSuffix(r.URL.Path, "/state") {
+		writeError(w, r, "not found", http.StatusNotFound)
+		return
+	}
+
+	details, err := h.Inspector.InspectRuntime(handle)
+	if err != nil {
+		writeError(w, r, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(details)
+}
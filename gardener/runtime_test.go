@@ -0,0 +1,143 @@
+package gardener_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/cloudfoundry-incubator/guardian/gardener"
+	"github.com/cloudfoundry-incubator/guardian/gardener/fakes"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/pivotal-golang/lager/lagertest"
+)
+
+type fakeInspectableNetworker struct {
+	*fakes.FakeNetworker
+	*fakes.FakeNetworkInspector
+}
+
+var _ = Describe("InspectRuntime", func() {
+	var (
+		containerizer    *fakes.FakeContainerizer
+		runtimeInspector *fakes.FakeRuntimeInspector
+		networker        fakeInspectableNetworker
+		gdnr             *gardener.Gardener
+	)
+
+	BeforeEach(func() {
+		containerizer = new(fakes.FakeContainerizer)
+		runtimeInspector = new(fakes.FakeRuntimeInspector)
+		runtimeInspector.InspectReturns(gardener.ContainerRuntime{BundlePath: "/depot/bob", InitPid: 42}, nil)
+		networker = fakeInspectableNetworker{new(fakes.FakeNetworker), new(fakes.FakeNetworkInspector)}
+		networker.InspectNetworkReturns(gardener.NetworkRuntime{HostInterface: "w1abc-0", BridgeInterface: "w1br"}, nil)
+
+		gdnr = &gardener.Gardener{
+			Containerizer:    containerizer,
+			Networker:        networker,
+			RuntimeInspector: runtimeInspector,
+			Logger:           lagertest.NewTestLogger("test"),
+		}
+	})
+
+	It("combines the runtime and network details of the container", func() {
+		details, err := gdnr.InspectRuntime("bob")
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(details).To(Equal(gardener.RuntimeDetails{
+			Handle:    "bob",
+			Container: &gardener.ContainerRuntime{BundlePath: "/depot/bob", InitPid: 42},
+			Network:   &gardener.NetworkRuntime{HostInterface: "w1abc-0", BridgeInterface: "w1br"},
+		}))
+
+		_, handle := runtimeInspector.InspectArgsForCall(0)
+		Expect(handle).To(Equal("bob"))
+	})
+
+	It("leaves out the details of components which cannot be inspected", func() {
+		gdnr.RuntimeInspector = nil
+		gdnr.Networker = new(fakes.FakeNetworker)
+
+		details, err := gdnr.InspectRuntime("bob")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(details).To(Equal(gardener.RuntimeDetails{Handle: "bob"}))
+	})
+
+	It("fails for containers which do not exist", func() {
+		containerizer.InfoReturns(gardener.ActualContainerSpec{}, errors.New("no such container"))
+
+		_, err := gdnr.InspectRuntime("bob")
+		Expect(err).To(MatchError("no such container"))
+		Expect(runtimeInspector.InspectCallCount()).To(Equal(0))
+	})
+
+	Describe("RuntimeHandler", func() {
+		serve := func(method, url string) *httptest.ResponseRecorder {
+			recorder := httptest.NewRecorder()
+			req, err := http.NewRequest(method, url, nil)
+			Expect(err).NotTo(HaveOccurred())
+			(&gardener.RuntimeHandler{Inspector: gdnr}).ServeHTTP(recorder, req)
+			return recorder
+		}
+
+		It("serves the details as JSON", func() {
+			recorder := serve("GET", "/containers/runtime?handle=bob")
+			Expect(recorder.Code).To(Equal(http.StatusOK))
+
+			var details gardener.RuntimeDetails
+			Expect(json.NewDecoder(recorder.Body).Decode(&details)).To(Succeed())
+			Expect(details.Container.InitPid).To(Equal(42))
+			Expect(details.Network.BridgeInterface).To(Equal("w1br"))
+		})
+
+		It("requires a handle", func() {
+			Expect(serve("GET", "/containers/runtime").Code).To(Equal(http.StatusBadRequest))
+		})
+
+		It("only allows GET", func() {
+			Expect(serve("POST", "/containers/runtime?handle=bob").Code).To(Equal(http.StatusMethodNotAllowed))
+		})
+	})
+
+	Describe("ContainerStateHandler", func() {
+		serve := func(method, url string) *httptest.ResponseRecorder {
+			recorder := httptest.NewRecorder()
+			req, err := http.NewRequest(method, url, nil)
+			Expect(err).NotTo(HaveOccurred())
+			(&gardener.ContainerStateHandler{Inspector: gdnr}).ServeHTTP(recorder, req)
+			return recorder
+		}
+
+		It("serves the details of the container named in the path as JSON", func() {
+			recorder := serve("GET", "/debug/containers/bob/state")
+			Expect(recorder.Code).To(Equal(http.StatusOK))
+
+			var details gardener.RuntimeDetails
+			Expect(json.NewDecoder(recorder.Body).Decode(&details)).To(Succeed())
+			Expect(details.Handle).To(Equal("bob"))
+			Expect(details.Container.BundlePath).To(Equal("/depot/bob"))
+			Expect(details.Network.HostInterface).To(Equal("w1abc-0"))
+
+			Expect(containerizer.InfoCallCount()).To(Equal(1))
+			_, handle := containerizer.InfoArgsForCall(0)
+			Expect(handle).To(Equal("bob"))
+		})
+
+		It("does not serve other paths", func() {
+			Expect(serve("GET", "/debug/containers/bob").Code).To(Equal(http.StatusNotFound))
+			Expect(serve("GET", "/debug/containers//state").Code).To(Equal(http.StatusNotFound))
+			Expect(serve("GET", "/debug/containers/bob/other/state").Code).To(Equal(http.StatusNotFound))
+			Expect(containerizer.InfoCallCount()).To(Equal(0))
+		})
+
+		It("fails when the container cannot be inspected", func() {
+			containerizer.InfoReturns(gardener.ActualContainerSpec{}, errors.New("no such container"))
+			Expect(serve("GET", "/debug/containers/bob/state").Code).To(Equal(http.StatusInternalServerError))
+		})
+
+		It("only allows GET", func() {
+			Expect(serve("DELETE", "/debug/containers/bob/state").Code).To(Equal(http.StatusMethodNotAllowed))
+		})
+	})
+})
@@ -0,0 +1,95 @@
+package gardener
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/cloudfoundry-incubator/garden"
+	"github.com/pivotal-golang/lager"
+)
+
+// MetricsSocketProperty is the container property which may name a socket
+// in the container, e.g. that of a metrics exporter, for the SocketRelay to
+// relay to a socket on the host, so that the exporter can be scraped without
+// a NetIn. A name beginning with @ is an abstract socket in the container's
+// network namespace; any other name is the absolute path of a socket in its
+// filesystem.
+const MetricsSocketProperty = "garden.metrics-socket"
+
+// MetricsRelayPathProperty is set to the path of the host's socket once the
+// container's metrics socket is being relayed
+const MetricsRelayPathProperty = "garden.metrics-relay-path"
+
+// StageRelay is the stage of creating a container at which its metrics
+// socket is relayed to the host
+const StageRelay = "relay"
+
+var errSocketRelayNotSupported = Classify(FailureInvalidSpec, errors.New("metrics sockets are not supported: no socket relay is configured"))
+
+//go:generate counterfeiter . SocketRelay
+
+// SocketRelay listens on a socket on the host for a container, and relays
+// each connection to it to a socket in the container
+type SocketRelay interface {
+	// Relay starts relaying to the socket, and returns the path of the
+	// host's socket
+	Relay(log lager.Logger, handle, socket string) (string, error)
+
+	// Stop stops relaying and removes the host's socket, if there is one
+	Stop(log lager.Logger, handle string) error
+}
+
+func parseMetricsSocket(properties garden.Properties) (string, error) {
+	socket, ok := properties[MetricsSocketProperty]
+	if !ok {
+		return "", nil
+	}
+
+	if strings.HasPrefix(socket, "@") {
+		if len(socket) == 1 {
+			return "", fmt.Errorf("invalid %s property: '%s': abstract socket has no name", MetricsSocketProperty, socket)
+		}
+
+		return socket, nil
+	}
+
+	if !path.IsAbs(socket) {
+		return "", fmt.Errorf("invalid %s property: '%s': must be an abstract socket (@name) or an absolute path", MetricsSocketProperty, socket)
+	}
+
+	return socket, nil
+}
+
+// relayMetricsSocket relays the container's metrics socket, if it has one,
+// and records where on the host it is relayed to
+func (g *Gardener) relayMetricsSocket(log lager.Logger, handle, socket string) error {
+	if socket == "" {
+		return nil
+	}
+
+	return relaySocket(log, g.SocketRelay, g.PropertyManager, handle, socket)
+}
+
+func relaySocket(log lager.Logger, relay SocketRelay, propertyManager PropertyManager, handle, socket string) error {
+	hostPath, err := relay.Relay(log, handle, socket)
+	if err != nil {
+		return fmt.Errorf("relay metrics socket: %s", err)
+	}
+
+	propertyManager.Set(handle, MetricsRelayPathProperty, hostPath)
+	return nil
+}
+
+// stopRelay stops relaying the container's metrics socket, logging any
+// failure, as the container is being destroyed regardless
+func (g *Gardener) stopRelay(log lager.Logger, handle string) {
+	if g.SocketRelay == nil {
+		return
+	}
+
+	if err := g.SocketRelay.Stop(log, handle); err != nil {
+		log.Error("stop-socket-relay-failed", err)
+	}
+}
@@ -0,0 +1,143 @@
+package gardener_test
+
+import (
+	"errors"
+
+	"github.com/cloudfoundry-incubator/garden"
+	"github.com/cloudfoundry-incubator/guardian/gardener"
+	"github.com/cloudfoundry-incubator/guardian/gardener/fakes"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+	"github.com/pivotal-golang/lager/lagertest"
+)
+
+var _ = Describe("Relaying metrics sockets", func() {
+	var (
+		relay           *fakes.FakeSocketRelay
+		containerizer   *fakes.FakeContainerizer
+		propertyManager *fakes.FakePropertyManager
+		metrics         *fakes.FakeMetricsRecorder
+
+		gdnr *gardener.Gardener
+	)
+
+	BeforeEach(func() {
+		relay = new(fakes.FakeSocketRelay)
+		relay.RelayReturns("/var/run/relays/some-handle.sock", nil)
+		containerizer = new(fakes.FakeContainerizer)
+		propertyManager = new(fakes.FakePropertyManager)
+		metrics = new(fakes.FakeMetricsRecorder)
+
+		gdnr = &gardener.Gardener{
+			SysInfoProvider: new(fakes.FakeSysInfoProvider),
+			Containerizer:   containerizer,
+			UidGenerator:    new(fakes.FakeUidGenerator),
+			Networker:       new(fakes.FakeNetworker),
+			VolumeCreator:   new(fakes.FakeVolumeCreator),
+			PropertyManager: propertyManager,
+			Metrics:         metrics,
+			SocketRelay:     relay,
+			Logger:          lagertest.NewTestLogger("test"),
+		}
+	})
+
+	create := func(socket string) error {
+		_, err := gdnr.Create(garden.ContainerSpec{
+			Handle:     "some-handle",
+			Properties: garden.Properties{gardener.MetricsSocketProperty: socket},
+		})
+		return err
+	}
+
+	It("relays the container's socket to the host", func() {
+		Expect(create("@exporter")).To(Succeed())
+
+		Expect(relay.RelayCallCount()).To(Equal(1))
+		_, handle, socket := relay.RelayArgsForCall(0)
+		Expect(handle).To(Equal("some-handle"))
+		Expect(socket).To(Equal("@exporter"))
+	})
+
+	It("records the path of the host's socket in the container's properties", func() {
+		Expect(create("/run/exporter.sock")).To(Succeed())
+
+		Expect(propertyManager.SetCallCount()).To(BeNumerically(">", 0))
+		found := false
+		for i := 0; i < propertyManager.SetCallCount(); i++ {
+			handle, name, value := propertyManager.SetArgsForCall(i)
+			if name == gardener.MetricsRelayPathProperty {
+				Expect(handle).To(Equal("some-handle"))
+				Expect(value).To(Equal("/var/run/relays/some-handle.sock"))
+				found = true
+			}
+		}
+		Expect(found).To(BeTrue())
+	})
+
+	It("does not relay anything for containers without a metrics socket", func() {
+		_, err := gdnr.Create(garden.ContainerSpec{Handle: "some-handle"})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(relay.RelayCallCount()).To(Equal(0))
+	})
+
+	DescribeTable("invalid metrics sockets",
+		func(socket, message string) {
+			Expect(create(socket)).To(MatchError(ContainSubstring(message)))
+			Expect(containerizer.CreateCallCount()).To(Equal(0))
+		},
+		Entry("an abstract socket with no name", "@", "abstract socket has no name"),
+		Entry("a relative path", "run/exporter.sock", "must be an abstract socket (@name) or an absolute path"),
+	)
+
+	It("refuses to let the relay path be chosen", func() {
+		_, err := gdnr.Create(garden.ContainerSpec{
+			Properties: garden.Properties{gardener.MetricsRelayPathProperty: "/tmp/elsewhere.sock"},
+		})
+		Expect(err).To(MatchError("garden.metrics-relay-path property cannot be set"))
+	})
+
+	Context("when no socket relay is configured", func() {
+		BeforeEach(func() {
+			gdnr.SocketRelay = nil
+		})
+
+		It("refuses containers with a metrics socket before creating anything", func() {
+			Expect(create("@exporter")).To(MatchError(ContainSubstring("no socket relay is configured")))
+			Expect(containerizer.CreateCallCount()).To(Equal(0))
+		})
+	})
+
+	Context("when the socket cannot be relayed", func() {
+		BeforeEach(func() {
+			relay.RelayReturns("", errors.New("address already in use"))
+		})
+
+		It("destroys the container", func() {
+			Expect(create("@exporter")).To(MatchError("relay metrics socket: address already in use"))
+			Expect(containerizer.DestroyCallCount()).To(Equal(1))
+
+			Expect(metrics.ContainerCreateFailedCallCount()).To(Equal(1))
+			stage, _ := metrics.ContainerCreateFailedArgsForCall(0)
+			Expect(stage).To(Equal(gardener.StageRelay))
+		})
+	})
+
+	It("refuses to change the metrics socket of an existing container", func() {
+		container, err := gdnr.Lookup("some-handle")
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(container.SetProperty(gardener.MetricsSocketProperty, "/../../run/guardian.sock")).To(
+			MatchError("garden.metrics-socket property can only be set when the container is created"),
+		)
+		Expect(container.RemoveProperty(gardener.MetricsRelayPathProperty)).To(HaveOccurred())
+	})
+
+	It("stops relaying when the container is destroyed", func() {
+		Expect(gdnr.Destroy("some-handle")).To(Succeed())
+
+		Expect(relay.StopCallCount()).To(Equal(1))
+		_, handle := relay.StopArgsForCall(0)
+		Expect(handle).To(Equal("some-handle"))
+	})
+})
@@ -0,0 +1,337 @@
+package gardener
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/cloudfoundry-incubator/garden"
+	"github.com/cloudfoundry-incubator/garden-shed/rootfs_provider"
+	"github.com/pivotal-golang/lager"
+)
+
+//go:generate counterfeiter . VolumeValidator
+//go:generate counterfeiter . ContainerValidator
+//go:generate counterfeiter . CreateValidator
+
+// VolumeValidator is implemented by VolumeCreators which can check that they
+// could create a rootfs, e.g. by resolving its image reference, without
+// creating it
+type VolumeValidator interface {
+	ValidateVolume(log lager.Logger, spec rootfs_provider.Spec, idMappings *IDMappings, layers []string) error
+}
+
+// ContainerValidator is implemented by Containerizers which can check that
+// they could create a container, e.g. by generating its bundle, without
+// creating it. The spec's DryRun is set, and it has no rootfs or network
+// hooks.
+type ContainerValidator interface {
+	Validate(log lager.Logger, spec DesiredContainerSpec) error
+}
+
+type CreateValidator interface {
+	ValidateCreate(spec garden.ContainerSpec) error
+}
+
+// CreateValidationError is why Create would fail: the stage of the create
+// which would fail, the class of the failure and the error Create would
+// return
+type CreateValidationError struct {
+	Stage string
+	Class string
+	Err   error
+}
+
+func (e CreateValidationError) Error() string {
+	return e.Err.Error()
+}
+
+// createSpec is what Create parses from a ContainerSpec before it allocates
+// anything
+type createSpec struct {
+	maxPids    int64
+	umask      string
+	cpuQuota   CPUQuota
+	cpuSet     CPUSet
+	idMappings *IDMappings
+	layers     []string
+	dns        DNSSpec
+	routes     []Route
+	volumes    []Volume
+	rootFSURL  *url.URL
+
+	metricsSocket string
+	restartPolicy string
+}
+
+func (g *Gardener) parseCreateSpec(spec garden.ContainerSpec) (createSpec, error) {
+	var parsed createSpec
+
+	if g.DestroyQueue.Destroying(spec.Handle) {
+		return parsed, fmt.Errorf("container %s is still being destroyed", spec.Handle)
+	}
+
+	if err := validateHandle(spec.Handle); err != nil {
+		return parsed, err
+	}
+
+	for _, reserved := range g.ReservedHandles {
+		if spec.Handle == reserved {
+			return parsed, fmt.Errorf("handle '%s' is reserved", spec.Handle)
+		}
+	}
+
+	if g.Rootless && spec.Privileged {
+		return parsed, errors.New("privileged containers cannot be created by a rootless server")
+	}
+
+	if err := validateContainerType(spec, g.AllowContainerRunners); err != nil {
+		return parsed, err
+	}
+
+	if err := checkSpecProperties(spec.Properties); err != nil {
+		return parsed, err
+	}
+
+	var err error
+	if parsed.maxPids, err = parseMaxPids(spec.Properties); err != nil {
+		return parsed, err
+	}
+
+	if parsed.umask, err = parseUmask(spec.Properties); err != nil {
+		return parsed, err
+	}
+
+	if parsed.umask == "" {
+		parsed.umask = g.DefaultUmask
+	}
+
+	if parsed.restartPolicy, err = parseRestartPolicy(spec.Properties); err != nil {
+		return parsed, err
+	}
+
+	if _, err := parseOutputRate(spec.Properties); err != nil {
+		return parsed, err
+	}
+
+	if _, _, err := parseExecConcurrency(spec.Properties); err != nil {
+		return parsed, err
+	}
+
+	if err := validateDiskQuotaAction(spec.Properties); err != nil {
+		return parsed, err
+	}
+
+	if parsed.cpuQuota, err = parseCPUQuota(spec.Properties); err != nil {
+		return parsed, err
+	}
+
+	if err := validateCPUMax(spec.Properties); err != nil {
+		return parsed, err
+	}
+
+	if parsed.cpuSet, err = parseCPUSet(spec.Properties, g.OnlineCPUs); err != nil {
+		return parsed, err
+	}
+
+	if parsed.idMappings, err = parseIDMappings(spec, g.IDMappings); err != nil {
+		return parsed, err
+	}
+
+	if parsed.layers, err = parseRootFSLayers(spec.Properties, g.LayerDir); err != nil {
+		return parsed, err
+	}
+
+	if parsed.dns, err = parseDNS(spec.Properties); err != nil {
+		return parsed, err
+	}
+
+	if parsed.routes, err = parseRoutes(spec.Properties); err != nil {
+		return parsed, err
+	}
+
+	if parsed.volumes, err = parseVolumes(spec.Properties); err != nil {
+		return parsed, err
+	}
+
+	if parsed.rootFSURL, err = url.Parse(spec.RootFSPath); err != nil {
+		return parsed, err
+	}
+
+	if parsed.metricsSocket, err = parseMetricsSocket(spec.Properties); err != nil {
+		return parsed, err
+	}
+
+	if parsed.metricsSocket != "" && g.SocketRelay == nil {
+		return parsed, errSocketRelayNotSupported
+	}
+
+	return parsed, nil
+}
+
+// validateHandle refuses handles which cannot name the files guardian keeps
+// for each container, e.g. its bundle and its properties, without escaping
+// the directories they are kept in. Validating a spec without a handle
+// leaves it to be generated.
+func validateHandle(handle string) error {
+	if handle == "." || handle == ".." || strings.ContainsAny(handle, "/\x00") {
+		return fmt.Errorf("invalid handle '%s'", handle)
+	}
+
+	return nil
+}
+
+func (c createSpec) volumeSpec(spec garden.ContainerSpec) rootfs_provider.Spec {
+	return rootfs_provider.Spec{
+		RootFS:     c.rootFSURL,
+		QuotaSize:  int64(spec.Limits.Disk.ByteHard),
+		QuotaScope: spec.Limits.Disk.Scope,
+		Namespaced: !spec.Privileged,
+	}
+}
+
+func (c createSpec) desiredSpec(spec garden.ContainerSpec, rootFSPath string, hooks Hooks, env []string) DesiredContainerSpec {
+	return DesiredContainerSpec{
+		Handle:        spec.Handle,
+		RootFSPath:    rootFSPath,
+		NetworkHooks:  hooks,
+		Privileged:    spec.Privileged,
+		BindMounts:    spec.BindMounts,
+		Limits:        spec.Limits,
+		IDMappings:    c.idMappings,
+		MaxPids:       c.maxPids,
+		Umask:         c.umask,
+		CPUQuota:      c.cpuQuota,
+		CPUSet:        c.cpuSet,
+		RestartPolicy: c.restartPolicy,
+		Env:           append(env, spec.Env...),
+		Properties:    spec.Properties,
+	}
+}
+
+// ValidateCreate checks whether Create would accept the spec without
+// allocating anything: it parses and validates the spec as Create does,
+// verifies the image, asks the volume creator to resolve the rootfs, if it
+// can, and the containerizer to generate the container's bundle, which
+// checks its bind mounts, capabilities, devices and the like, if it can. The
+// network is not checked. Failures are CreateValidationErrors wrapping the
+// error Create would return.
+func (g *Gardener) ValidateCreate(spec garden.ContainerSpec) error {
+	log := g.Annotator.SpecLogger(g.Logger, spec).Session("validate-create")
+
+	log.Info("started")
+	defer log.Info("finished")
+
+	fail := func(stage, class string, err error) error {
+		log.Info("invalid", lager.Data{"stage": stage, "error": err.Error()})
+		return CreateValidationError{Stage: stage, Class: FailureClass(err, class), Err: err}
+	}
+
+	if spec.Handle != "" {
+		handles, err := g.Containerizer.Handles()
+		if err != nil {
+			return err
+		}
+
+		for _, handle := range handles {
+			if handle == spec.Handle {
+				return fail(StageSpec, FailureInvalidSpec, fmt.Errorf("handle already exists: %s", spec.Handle))
+			}
+		}
+	}
+
+	parsed, err := g.parseCreateSpec(spec)
+	if err != nil {
+		return fail(StageSpec, FailureInvalidSpec, err)
+	}
+
+	if len(parsed.routes) > 0 {
+		if _, ok := g.Networker.(RoutesNetworker); !ok {
+			return fail(StageNetwork, FailureNetwork, errRoutesNotSupported)
+		}
+	} else if !parsed.dns.Empty() {
+		if _, ok := g.Networker.(DNSNetworker); !ok {
+			return fail(StageNetwork, FailureNetwork, errDNSNotSupported)
+		}
+	}
+
+	if len(parsed.layers) > 0 {
+		if _, ok := g.VolumeCreator.(LayeredVolumeCreator); !ok {
+			return fail(StageImage, FailureImagePull, errLayersNotSupported)
+		}
+	} else if parsed.idMappings != nil {
+		if _, ok := g.VolumeCreator.(MappingVolumeCreator); !ok {
+			return fail(StageImage, FailureImagePull, errMappingsNotSupported)
+		}
+	}
+
+	if parsed.rootFSURL, err = g.verifyImage(log, parsed.rootFSURL); err != nil {
+		return fail(StageImage, FailureUntrustedImage, err)
+	}
+
+	if validator, ok := g.VolumeCreator.(VolumeValidator); ok {
+		if err := validator.ValidateVolume(log, parsed.volumeSpec(spec), parsed.idMappings, parsed.layers); err != nil {
+			return fail(StageImage, FailureImagePull, err)
+		}
+	}
+
+	if len(parsed.volumes) > 0 && g.VolumeMounter == nil {
+		return fail(StageVolumes, FailureOther, errVolumesNotSupported)
+	}
+
+	if validator, ok := g.Containerizer.(ContainerValidator); ok {
+		desired := parsed.desiredSpec(spec, "", Hooks{}, nil)
+		desired.DryRun = true
+
+		if err := validator.Validate(log, desired); err != nil {
+			return fail(StageContainer, FailureOther, err)
+		}
+	}
+
+	return nil
+}
+
+// ValidationResult is the body of a response from the ValidateHandler
+type ValidationResult struct {
+	Valid bool   `json:"valid"`
+	Stage string `json:"stage,omitempty"`
+	Class string `json:"class,omitempty"`
+	Error string `json:"error,omitempty"`
+}
+
+// ValidateHandler checks whether the garden.ContainerSpec in the JSON request
+// body could be created, without creating anything, and serves the
+// ValidationResult as JSON
+type ValidateHandler struct {
+	Validator CreateValidator
+}
+
+func (h *ValidateHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		writeError(w, r, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var spec garden.ContainerSpec
+	if err := json.NewDecoder(r.Body).Decode(&spec); err != nil {
+		writeError(w, r, "invalid spec: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	result := ValidationResult{Valid: true}
+	if err := h.Validator.ValidateCreate(spec); err != nil {
+		invalid, ok := err.(CreateValidationError)
+		if !ok {
+			writeError(w, r, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		result = ValidationResult{Stage: invalid.Stage, Class: invalid.Class, Error: invalid.Error()}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
@@ -0,0 +1,187 @@
+package gardener_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	"github.com/cloudfoundry-incubator/garden"
+	"github.com/cloudfoundry-incubator/guardian/gardener"
+	"github.com/cloudfoundry-incubator/guardian/gardener/fakes"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/pivotal-golang/lager/lagertest"
+)
+
+var _ = Describe("ValidateCreate", func() {
+	var (
+		networker     *fakes.FakeNetworker
+		volumeCreator *struct {
+			*fakes.FakeVolumeCreator
+			*fakes.FakeVolumeValidator
+		}
+		containerizer *struct {
+			*fakes.FakeContainerizer
+			*fakes.FakeContainerValidator
+		}
+
+		gdnr *gardener.Gardener
+	)
+
+	BeforeEach(func() {
+		networker = new(fakes.FakeNetworker)
+		volumeCreator = &struct {
+			*fakes.FakeVolumeCreator
+			*fakes.FakeVolumeValidator
+		}{new(fakes.FakeVolumeCreator), new(fakes.FakeVolumeValidator)}
+		containerizer = &struct {
+			*fakes.FakeContainerizer
+			*fakes.FakeContainerValidator
+		}{new(fakes.FakeContainerizer), new(fakes.FakeContainerValidator)}
+
+		gdnr = &gardener.Gardener{
+			Containerizer:   containerizer,
+			Networker:       networker,
+			VolumeCreator:   volumeCreator,
+			PropertyManager: new(fakes.FakePropertyManager),
+			Logger:          lagertest.NewTestLogger("test"),
+		}
+	})
+
+	spec := garden.ContainerSpec{
+		Handle:     "some-handle",
+		RootFSPath: "docker:///busybox",
+		Properties: garden.Properties{gardener.MaxPidsProperty: "64"},
+	}
+
+	It("resolves the rootfs and generates the bundle without allocating anything", func() {
+		Expect(gdnr.ValidateCreate(spec)).To(Succeed())
+
+		Expect(volumeCreator.ValidateVolumeCallCount()).To(Equal(1))
+		_, volumeSpec, _, _ := volumeCreator.ValidateVolumeArgsForCall(0)
+		Expect(volumeSpec.RootFS.String()).To(Equal("docker:///busybox"))
+
+		Expect(containerizer.ValidateCallCount()).To(Equal(1))
+		_, desired := containerizer.ValidateArgsForCall(0)
+		Expect(desired.Handle).To(Equal("some-handle"))
+		Expect(desired.MaxPids).To(BeNumerically("==", 64))
+		Expect(desired.DryRun).To(BeTrue())
+
+		Expect(networker.HooksCallCount()).To(Equal(0))
+		Expect(volumeCreator.CreateCallCount()).To(Equal(0))
+		Expect(containerizer.CreateCallCount()).To(Equal(0))
+	})
+
+	It("returns the errors Create would for invalid specs", func() {
+		invalid := spec
+		invalid.Properties = garden.Properties{gardener.MaxPidsProperty: "lots"}
+
+		err := gdnr.ValidateCreate(invalid)
+		Expect(err).To(MatchError("invalid max-pids property: 'lots'"))
+		Expect(err).To(Equal(gardener.CreateValidationError{
+			Stage: gardener.StageSpec,
+			Class: gardener.FailureInvalidSpec,
+			Err:   errors.New("invalid max-pids property: 'lots'"),
+		}))
+		Expect(containerizer.ValidateCallCount()).To(Equal(0))
+	})
+
+	It("refuses handles which are in use", func() {
+		containerizer.HandlesReturns([]string{"some-handle"}, nil)
+
+		err := gdnr.ValidateCreate(spec)
+		Expect(err).To(MatchError("handle already exists: some-handle"))
+	})
+
+	It("returns image resolution failures", func() {
+		volumeCreator.ValidateVolumeReturns(errors.New("manifest unknown"))
+
+		err := gdnr.ValidateCreate(spec)
+		Expect(err).To(MatchError("manifest unknown"))
+		Expect(err.(gardener.CreateValidationError).Stage).To(Equal(gardener.StageImage))
+		Expect(err.(gardener.CreateValidationError).Class).To(Equal(gardener.FailureImagePull))
+	})
+
+	It("returns bundle failures with their class", func() {
+		containerizer.ValidateReturns(gardener.Classify(gardener.FailureBundle, errors.New("invalid devices property")))
+
+		err := gdnr.ValidateCreate(spec)
+		Expect(err).To(MatchError("invalid devices property"))
+		Expect(err.(gardener.CreateValidationError).Stage).To(Equal(gardener.StageContainer))
+		Expect(err.(gardener.CreateValidationError).Class).To(Equal(gardener.FailureBundle))
+	})
+
+	It("refuses per-container DNS when the networker does not support it", func() {
+		withDNS := spec
+		withDNS.Properties = garden.Properties{gardener.DNSSearchDomainsProperty: "example.com"}
+
+		err := gdnr.ValidateCreate(withDNS)
+		Expect(err).To(MatchError("the networker does not support per-container DNS"))
+		Expect(err.(gardener.CreateValidationError).Class).To(Equal(gardener.FailureInvalidSpec))
+	})
+})
+
+var _ = Describe("ValidateHandler", func() {
+	var (
+		fakeValidator *fakes.FakeCreateValidator
+		recorder      *httptest.ResponseRecorder
+	)
+
+	BeforeEach(func() {
+		fakeValidator = new(fakes.FakeCreateValidator)
+		recorder = httptest.NewRecorder()
+	})
+
+	serve := func(method, body string) gardener.ValidationResult {
+		req, err := http.NewRequest(method, "/containers/validate", strings.NewReader(body))
+		Expect(err).NotTo(HaveOccurred())
+		(&gardener.ValidateHandler{Validator: fakeValidator}).ServeHTTP(recorder, req)
+
+		var result gardener.ValidationResult
+		if recorder.Code == http.StatusOK {
+			Expect(json.NewDecoder(recorder.Body).Decode(&result)).To(Succeed())
+		}
+		return result
+	}
+
+	It("validates the spec in the body", func() {
+		result := serve("POST", `{"handle": "some-handle"}`)
+
+		Expect(recorder.Code).To(Equal(http.StatusOK))
+		Expect(result).To(Equal(gardener.ValidationResult{Valid: true}))
+		Expect(fakeValidator.ValidateCreateArgsForCall(0).Handle).To(Equal("some-handle"))
+	})
+
+	It("serves why the spec is invalid", func() {
+		fakeValidator.ValidateCreateReturns(gardener.CreateValidationError{
+			Stage: gardener.StageSpec,
+			Class: gardener.FailureInvalidSpec,
+			Err:   errors.New("invalid max-pids property: 'lots'"),
+		})
+
+		Expect(serve("POST", `{}`)).To(Equal(gardener.ValidationResult{
+			Stage: "spec",
+			Class: "invalid-spec",
+			Error: "invalid max-pids property: 'lots'",
+		}))
+	})
+
+	It("fails when validation itself fails", func() {
+		fakeValidator.ValidateCreateReturns(errors.New("depot unreadable"))
+
+		serve("POST", `{}`)
+		Expect(recorder.Code).To(Equal(http.StatusInternalServerError))
+	})
+
+	It("refuses bodies which are not specs", func() {
+		serve("POST", `not json`)
+		Expect(recorder.Code).To(Equal(http.StatusBadRequest))
+	})
+
+	It("only allows POST", func() {
+		serve("GET", "")
+		Expect(recorder.Code).To(Equal(http.StatusMethodNotAllowed))
+	})
+})
@@ -0,0 +1,160 @@
+package gardener
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path/filepath"
+
+	"github.com/cloudfoundry-incubator/garden"
+	"github.com/pivotal-golang/lager"
+)
+
+//go:generate counterfeiter . VolumeMounter
+
+// VolumesProperty is the container property which may hold a JSON list of
+// Volumes for the VolumeMounter to mount when the container is created, e.g.
+// '[{"name": "data", "destination": "/data", "options": {"server": "nfs-1"}}]'
+const VolumesProperty = "volumes"
+
+// MountedVolumesKey is the property in which guardian records the Volumes it
+// mounted for a container, so that they are unmounted when it is destroyed
+// whatever its client does to its properties
+const MountedVolumesKey = "garden.mounted-volumes"
+
+// Volume is storage, e.g. an NFS share, which the VolumeMounter mounts on
+// the host and which is bind mounted in to the container at DstPath.
+// Options are passed to the VolumeMounter as they are.
+type Volume struct {
+	Name     string            `json:"name"`
+	DstPath  string            `json:"destination"`
+	ReadOnly bool              `json:"read_only,omitempty"`
+	Options  map[string]string `json:"options,omitempty"`
+}
+
+// VolumeMounter mounts and unmounts the Volumes of a container, e.g. by
+// running a volume plugin binary
+type VolumeMounter interface {
+	// Mount mounts the volume for the container and returns the host path
+	// at which it is mounted
+	Mount(log lager.Logger, handle string, volume Volume) (string, error)
+	Unmount(log lager.Logger, handle string, volume Volume) error
+}
+
+var errVolumesNotSupported = errors.New("volumes are not supported: no volume plugin is configured")
+
+func parseVolumes(properties garden.Properties) ([]Volume, error) {
+	raw, ok := properties[VolumesProperty]
+	if !ok {
+		return nil, nil
+	}
+
+	var volumes []Volume
+	if err := json.Unmarshal([]byte(raw), &volumes); err != nil {
+		return nil, fmt.Errorf("invalid %s property: '%s': %s", VolumesProperty, raw, err)
+	}
+
+	names := map[string]bool{}
+	for _, volume := range volumes {
+		if volume.Name == "" {
+			return nil, fmt.Errorf("invalid %s property: '%s': volumes must have a name", VolumesProperty, raw)
+		}
+
+		if names[volume.Name] {
+			return nil, fmt.Errorf("invalid %s property: '%s': volume '%s' is given more than once", VolumesProperty, raw, volume.Name)
+		}
+
+		if !filepath.IsAbs(volume.DstPath) {
+			return nil, fmt.Errorf("invalid %s property: '%s': destination '%s' is not an absolute path", VolumesProperty, raw, volume.DstPath)
+		}
+
+		names[volume.Name] = true
+	}
+
+	return volumes, nil
+}
+
+// mountVolumes mounts the container's volumes and returns the bind mounts
+// of them in to the container. If any volume fails to mount, those already
+// mounted are unmounted.
+func (g *Gardener) mountVolumes(log lager.Logger, handle string, volumes []Volume) ([]garden.BindMount, error) {
+	if len(volumes) == 0 {
+		return nil, nil
+	}
+
+	if g.VolumeMounter == nil {
+		return nil, errVolumesNotSupported
+	}
+
+	var mounts []garden.BindMount
+	for i, volume := range volumes {
+		hostPath, err := g.VolumeMounter.Mount(log, handle, volume)
+		if err != nil {
+			g.unmountVolumes(log, handle, volumes[:i])
+			return nil, fmt.Errorf("mount volume '%s': %s", volume.Name, err)
+		}
+
+		mode := garden.BindMountModeRW
+		if volume.ReadOnly {
+			mode = garden.BindMountModeRO
+		}
+
+		mounts = append(mounts, garden.BindMount{
+			SrcPath: hostPath,
+			DstPath: volume.DstPath,
+			Mode:    mode,
+			Origin:  garden.BindMountOriginHost,
+		})
+	}
+
+	return mounts, nil
+}
+
+// unmountVolumes unmounts the volumes of a container which could not be
+// created, logging any failures
+func (g *Gardener) unmountVolumes(log lager.Logger, handle string, volumes []Volume) {
+	for _, volume := range volumes {
+		if err := g.VolumeMounter.Unmount(log, handle, volume); err != nil {
+			log.Error("unmount-volume-failed", err, lager.Data{"volume": volume.Name})
+		}
+	}
+}
+
+// recordVolumes records the volumes mounted for the container, so that they
+// are unmounted when it is destroyed
+func (g *Gardener) recordVolumes(handle string, volumes []Volume) error {
+	data, err := json.Marshal(volumes)
+	if err != nil {
+		return err
+	}
+
+	g.PropertyManager.Set(handle, MountedVolumesKey, string(data))
+	return nil
+}
+
+// destroyVolumes unmounts the volumes guardian recorded as mounted for the
+// container
+func (g *Gardener) destroyVolumes(log lager.Logger, handle string) error {
+	if g.VolumeMounter == nil {
+		return nil
+	}
+
+	raw, err := g.PropertyManager.Get(handle, MountedVolumesKey)
+	if err != nil || raw == "" {
+		return nil
+	}
+
+	var volumes []Volume
+	if err := json.Unmarshal([]byte(raw), &volumes); err != nil {
+		log.Error("parse-volumes-failed", err)
+		return nil
+	}
+
+	for _, volume := range volumes {
+		if err := g.VolumeMounter.Unmount(log, handle, volume); err != nil {
+			return fmt.Errorf("unmount volume '%s': %s", volume.Name, err)
+		}
+	}
+
+	return nil
+}
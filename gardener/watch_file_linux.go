@@ -0,0 +1,60 @@
+package gardener
+
+import (
+	"os"
+	"path/filepath"
+	"syscall"
+
+	"github.com/cloudfoundry-incubator/guardian/pkg/inotify"
+)
+
+const watchFileEvents = syscall.IN_CLOSE_WRITE | syscall.IN_MOVED_TO | syscall.IN_CREATE
+
+// watchFile sends on the returned channel whenever the file at path is
+// written or replaced. The file's directory is watched rather than the file
+// itself so that files which are replaced by rename (as most editors and
+// config management tools do) continue to be watched.
+func watchFile(path string, stop <-chan struct{}) (<-chan struct{}, error) {
+	fd, err := syscall.InotifyInit1(syscall.IN_CLOEXEC | syscall.IN_NONBLOCK)
+	if err != nil {
+		return nil, os.NewSyscallError("inotify_init1", err)
+	}
+
+	dir, name := filepath.Split(path)
+	if _, err := syscall.InotifyAddWatch(fd, filepath.Clean(dir), watchFileEvents); err != nil {
+		syscall.Close(fd)
+		return nil, os.NewSyscallError("inotify_add_watch", err)
+	}
+
+	// the descriptor is non-blocking so that reads go through the runtime
+	// poller, which lets closing the file interrupt a blocked read
+	events := os.NewFile(uintptr(fd), "inotify")
+	go func() {
+		<-stop
+		events.Close()
+	}()
+
+	changes := make(chan struct{}, 1)
+	go func() {
+		defer close(changes)
+
+		buf := make([]byte, 64*(syscall.SizeofInotifyEvent+syscall.NAME_MAX+1))
+		for {
+			n, err := events.Read(buf)
+			if err != nil || n <= 0 {
+				return
+			}
+
+			if !inotify.ContainsEventFor(buf[:n], name) {
+				continue
+			}
+
+			select {
+			case changes <- struct{}{}:
+			default:
+			}
+		}
+	}()
+
+	return changes, nil
+}
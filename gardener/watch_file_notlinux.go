@@ -0,0 +1,9 @@
+// +build !linux
+
+package gardener
+
+import "errors"
+
+func watchFile(path string, stop <-chan struct{}) (<-chan struct{}, error) {
+	return nil, errors.New("watching files is not supported on this platform")
+}
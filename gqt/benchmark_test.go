@@ -0,0 +1,62 @@
+package gqt_test
+
+import (
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/cloudfoundry-incubator/garden"
+	"github.com/cloudfoundry-incubator/guardian/gqt/runner"
+	"github.com/cloudfoundry-incubator/guardian/gqt/runner/specs"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// The budgets are generous so that only real regressions fail on loaded CI
+// workers. Set GARDEN_BENCHMARK_ITERATIONS and GARDEN_BENCHMARK_CONCURRENCY
+// for longer runs.
+var _ = Describe("Benchmarks", func() {
+	var (
+		client      *runner.RunningGarden
+		iterations  int
+		concurrency int
+	)
+
+	envInt := func(name string, defaultValue int) int {
+		value, err := strconv.Atoi(os.Getenv(name))
+		if err != nil {
+			return defaultValue
+		}
+
+		return value
+	}
+
+	BeforeEach(func() {
+		iterations = envInt("GARDEN_BENCHMARK_ITERATIONS", 10)
+		concurrency = envInt("GARDEN_BENCHMARK_CONCURRENCY", 2)
+		client = startGarden()
+	})
+
+	AfterEach(func() {
+		Expect(client.DestroyAndStop()).To(Succeed())
+	})
+
+	It("creates and destroys containers within budget", func() {
+		creates, destroys := client.BenchmarkCreate(specs.Container().Build(), iterations, concurrency)
+		creates.Report(GinkgoWriter)
+		destroys.Report(GinkgoWriter)
+
+		creates.ExpectWithinBudget(runner.LatencyBudget{P95: 10 * time.Second, Max: 20 * time.Second})
+		destroys.ExpectWithinBudget(runner.LatencyBudget{P95: 5 * time.Second, Max: 10 * time.Second})
+	})
+
+	It("execs processes within budget", func() {
+		container, err := client.Create(specs.Container().Build())
+		Expect(err).NotTo(HaveOccurred())
+
+		execs := client.BenchmarkExec(container, garden.ProcessSpec{Path: "true"}, iterations, concurrency)
+		execs.Report(GinkgoWriter)
+
+		execs.ExpectWithinBudget(runner.LatencyBudget{P50: time.Second, P95: 2 * time.Second})
+	})
+})
@@ -8,6 +8,7 @@ import (
 
 	"github.com/cloudfoundry-incubator/garden"
 	"github.com/cloudfoundry-incubator/guardian/gqt/runner"
+	"github.com/cloudfoundry-incubator/guardian/gqt/runner/specs"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 )
@@ -46,16 +47,12 @@ var _ = Describe("Bind mount", func() {
 
 		var err error
 		container, err = client.Create(
-			garden.ContainerSpec{
-				Privileged: privilegedContainer,
-				BindMounts: []garden.BindMount{garden.BindMount{
-					SrcPath: srcPath,
-					DstPath: dstPath,
-					Mode:    bindMountMode,
-					Origin:  bindMountOrigin,
-				}},
-				Network: fmt.Sprintf("10.0.%d.0/24", GinkgoParallelNode()),
-			})
+			specs.Container().
+				PrivilegedIf(privilegedContainer).
+				WithBindMount(srcPath, dstPath, bindMountMode, bindMountOrigin).
+				WithNetwork(fmt.Sprintf("10.0.%d.0/24", GinkgoParallelNode())).
+				Build(),
+		)
 		Expect(err).NotTo(HaveOccurred())
 	})
 
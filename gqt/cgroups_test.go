@@ -0,0 +1,90 @@
+package gqt_test
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/cloudfoundry-incubator/garden"
+	"github.com/cloudfoundry-incubator/guardian/gqt/runner"
+	"github.com/cloudfoundry-incubator/guardian/gqt/runner/specs"
+	"github.com/cloudfoundry-incubator/guardian/sysinfo"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+)
+
+var _ = Describe("Cgroups", func() {
+	var (
+		client    *runner.RunningGarden
+		container garden.Container
+	)
+
+	BeforeEach(func() {
+		client = startGarden()
+	})
+
+	AfterEach(func() {
+		Expect(client.DestroyAndStop()).To(Succeed())
+	})
+
+	JustBeforeEach(func() {
+		var err error
+		container, err = client.Create(specs.Container().WithProperty("max-pids", "100").Build())
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	containerCgroups := func() string {
+		contents, err := ioutil.ReadFile(fmt.Sprintf("/proc/%d/cgroup", initProcessPID(container.Handle())))
+		Expect(err).NotTo(HaveOccurred())
+		return string(contents)
+	}
+
+	cgroupPath := func(subsystem, file string) string {
+		root := filepath.Join(os.TempDir(), fmt.Sprintf("cgroups-%d", GinkgoParallelNode()))
+		if runner.GuardianCgroupMode() == sysinfo.CgroupModeV2 {
+			return filepath.Join(root, container.Handle(), file)
+		}
+
+		return filepath.Join(root, subsystem, container.Handle(), file)
+	}
+
+	It("logs the cgroup mode it runs containers in", func() {
+		Eventually(client).Should(gbytes.Say(fmt.Sprintf(`"mode":"%s"`, runner.GuardianCgroupMode())))
+	})
+
+	Context("when running containers in cgroup v1", func() {
+		BeforeEach(func() {
+			runner.SkipUnlessCgroupMode(sysinfo.CgroupModeV1)
+		})
+
+		It("places the container in a cgroup of each v1 hierarchy", func() {
+			Expect(containerCgroups()).To(ContainSubstring(":memory:"))
+		})
+	})
+
+	Context("when running containers in cgroup v2", func() {
+		BeforeEach(func() {
+			runner.SkipUnlessCgroupMode(sysinfo.CgroupModeV2)
+		})
+
+		It("places the container in a single cgroup of the unified hierarchy", func() {
+			Expect(strings.TrimSpace(containerCgroups())).To(HavePrefix("0::"))
+			Expect(strings.Split(strings.TrimSpace(containerCgroups()), "\n")).To(HaveLen(1))
+		})
+	})
+
+	Context("when the host can limit pids", func() {
+		BeforeEach(func() {
+			runner.SkipUnlessCapability(sysinfo.CapabilityPidsLimits)
+		})
+
+		It("limits the container's pids in its pids cgroup", func() {
+			contents, err := ioutil.ReadFile(cgroupPath("pids", "pids.max"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(strings.TrimSpace(string(contents))).To(Equal("100"))
+		})
+	})
+})
@@ -0,0 +1,73 @@
+package gqt_test
+
+import (
+	"io"
+	"net"
+	"time"
+
+	"github.com/cloudfoundry-incubator/guardian/gqt/runner"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("API connections", func() {
+	var (
+		limits runner.ConnectionLimits
+		client *runner.RunningGarden
+	)
+
+	BeforeEach(func() {
+		limits = runner.ConnectionLimits{}
+	})
+
+	JustBeforeEach(func() {
+		client = startGarden(limits.Args()...)
+	})
+
+	AfterEach(func() {
+		Expect(client.DestroyAndStop()).To(Succeed())
+	})
+
+	dial := func() net.Conn {
+		conn, err := client.Dial()
+		Expect(err).NotTo(HaveOccurred())
+		return conn
+	}
+
+	Context("when connections per client are limited", func() {
+		BeforeEach(func() {
+			limits.MaxPerClient = 2
+		})
+
+		It("closes the client's connections beyond the limit", func() {
+			held := []net.Conn{dial(), dial()}
+			defer held[1].Close()
+
+			extra := dial()
+			defer extra.Close()
+			extra.SetReadDeadline(time.Now().Add(5 * time.Second))
+			_, err := extra.Read(make([]byte, 1))
+			Expect(err).To(Equal(io.EOF))
+
+			Expect(held[0].Close()).To(Succeed())
+			Eventually(client.Ping).Should(Succeed())
+		})
+	})
+
+	Context("when idle connections time out", func() {
+		BeforeEach(func() {
+			limits.IdleTimeout = time.Second
+		})
+
+		It("closes connections on which nothing is sent", func() {
+			idle := dial()
+			defer idle.Close()
+
+			idle.SetReadDeadline(time.Now().Add(10 * time.Second))
+			_, err := idle.Read(make([]byte, 1))
+			Expect(err).To(Equal(io.EOF))
+
+			Expect(client.Ping()).To(Succeed())
+		})
+	})
+})
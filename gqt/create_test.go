@@ -14,6 +14,7 @@ import (
 
 	"github.com/cloudfoundry-incubator/garden"
 	"github.com/cloudfoundry-incubator/guardian/gqt/runner"
+	"github.com/cloudfoundry-incubator/guardian/gqt/runner/specs"
 
 	. "github.com/cloudfoundry-incubator/guardian/matchers"
 	. "github.com/onsi/ginkgo"
@@ -51,9 +52,7 @@ var _ = Describe("Creating a Container", func() {
 
 		JustBeforeEach(func() {
 			var err error
-			container, err = client.Create(garden.ContainerSpec{
-				Privileged: privileged,
-			})
+			container, err = client.Create(specs.Container().PrivilegedIf(privileged).Build())
 			Expect(err).NotTo(HaveOccurred())
 
 			initProcPid = initProcessPID(container.Handle())
@@ -165,9 +164,7 @@ var _ = Describe("Creating a Container", func() {
 			Expect(ioutil.WriteFile(filepath.Join(rootFSPath, "my-file"), []byte("some-content"), 0644)).To(Succeed())
 			Expect(os.Mkdir(path.Join(rootFSPath, "somedir"), 0777)).To(Succeed())
 
-			container, err = client.Create(garden.ContainerSpec{
-				RootFSPath: rootFSPath,
-			})
+			container, err = client.Create(specs.Container().WithRootfs(rootFSPath).Build())
 			Expect(err).NotTo(HaveOccurred())
 		})
 
@@ -179,9 +176,7 @@ var _ = Describe("Creating a Container", func() {
 			runCommand(container, "touch", []string{"/somedir/created-file"})
 			Expect(container).To(HaveFile("/somedir/created-file"))
 
-			container2, err := client.Create(garden.ContainerSpec{
-				RootFSPath: rootFSPath,
-			})
+			container2, err := client.Create(specs.Container().WithRootfs(rootFSPath).Build())
 			Expect(err).NotTo(HaveOccurred())
 
 			Expect(container2).To(HaveFile("/my-file"))
@@ -191,9 +186,7 @@ var _ = Describe("Creating a Container", func() {
 
 	Context("after creating a container with a specified handle", func() {
 		It("should lookup the right container for the handle", func() {
-			container, err := client.Create(garden.ContainerSpec{
-				Handle: "container-banana",
-			})
+			container, err := client.Create(specs.Container().WithHandle("container-banana").Build())
 			Expect(err).NotTo(HaveOccurred())
 
 			lookupContainer, lookupError := client.Lookup("container-banana")
@@ -202,16 +195,12 @@ var _ = Describe("Creating a Container", func() {
 		})
 
 		It("allow the container to be created with the same name after destroying", func() {
-			container, err := client.Create(garden.ContainerSpec{
-				Handle: "another-banana",
-			})
+			container, err := client.Create(specs.Container().WithHandle("another-banana").Build())
 			Expect(err).NotTo(HaveOccurred())
 
 			Expect(client.Destroy(container.Handle())).To(Succeed())
 
-			container, err = client.Create(garden.ContainerSpec{
-				Handle: "another-banana",
-			})
+			container, err = client.Create(specs.Container().WithHandle("another-banana").Build())
 			Expect(err).NotTo(HaveOccurred())
 		})
 	})
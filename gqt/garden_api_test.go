@@ -1,6 +1,11 @@
 package gqt_test
 
 import (
+	"fmt"
+
+	"github.com/cloudfoundry-incubator/garden"
+	gardenclient "github.com/cloudfoundry-incubator/garden/client"
+	"github.com/cloudfoundry-incubator/garden/client/connection"
 	"github.com/cloudfoundry-incubator/guardian/gqt/runner"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
@@ -46,4 +51,24 @@ var _ = Describe("Garden API", func() {
 			})
 		})
 	})
+
+	Context("when an additional tcp listener is configured", func() {
+		var tcpAddr string
+
+		BeforeEach(func() {
+			tcpAddr = fmt.Sprintf("127.0.0.1:%d", 7700+GinkgoParallelNode())
+			args = append(args, "--additionalListen", "tcp:"+tcpAddr)
+		})
+
+		It("serves the same backend on both listeners", func() {
+			container, err := client.Create(garden.ContainerSpec{})
+			Expect(err).NotTo(HaveOccurred())
+
+			tcpClient := gardenclient.New(connection.New("tcp", tcpAddr))
+			Expect(tcpClient.Ping()).To(Succeed())
+
+			_, err = tcpClient.Lookup(container.Handle())
+			Expect(err).NotTo(HaveOccurred())
+		})
+	})
 })
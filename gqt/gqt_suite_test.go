@@ -4,6 +4,7 @@ import (
 	"os"
 	"os/exec"
 	"path"
+	"path/filepath"
 	"runtime"
 	"time"
 
@@ -18,7 +19,8 @@ import (
 )
 
 var defaultRuntime = map[string]string{
-	"linux": "runc",
+	"linux":   "runc",
+	"windows": os.Getenv("GARDEN_TEST_WINC_PATH"),
 }
 
 var ginkgoIO = garden.ProcessIO{Stdout: GinkgoWriter, Stderr: GinkgoWriter}
@@ -41,21 +43,39 @@ func TestGqt(t *testing.T) {
 			bins["garden_bin_path"], err = gexec.Build("github.com/cloudfoundry-incubator/guardian/cmd/guardian", "-tags", "daemon")
 			Expect(err).NotTo(HaveOccurred())
 
-			bins["kawasaki_bin_path"], err = gexec.Build("github.com/cloudfoundry-incubator/guardian/cmd/kawasaki")
-			Expect(err).NotTo(HaveOccurred())
-
-			bins["iodaemon_bin_path"], err = gexec.Build("github.com/cloudfoundry-incubator/guardian/rundmc/iodaemon/cmd/iodaemon")
-			Expect(err).NotTo(HaveOccurred())
-
 			bins["init_bin_path"], err = gexec.Build("github.com/cloudfoundry-incubator/guardian/cmd/init")
 			Expect(err).NotTo(HaveOccurred())
 
-			cmd := exec.Command("make")
-			cmd.Dir = "../rundmc/nstar"
-			cmd.Stdout = GinkgoWriter
-			cmd.Stderr = GinkgoWriter
-			Expect(cmd.Run()).To(Succeed())
-			bins["nstar_bin_path"] = "../rundmc/nstar/nstar"
+			if runtime.GOOS == "windows" {
+				// the server is installed as gdn.exe on Windows, where kawasaki,
+				// iodaemon, nstar and remount are not used
+				gdnBin := filepath.Join(filepath.Dir(bins["garden_bin_path"]), "gdn.exe")
+				Expect(os.Rename(bins["garden_bin_path"], gdnBin)).To(Succeed())
+				bins["garden_bin_path"] = gdnBin
+			} else {
+				bins["kawasaki_bin_path"], err = gexec.Build("github.com/cloudfoundry-incubator/guardian/cmd/kawasaki")
+				Expect(err).NotTo(HaveOccurred())
+
+				bins["iodaemon_bin_path"], err = gexec.Build("github.com/cloudfoundry-incubator/guardian/rundmc/iodaemon/cmd/iodaemon")
+				Expect(err).NotTo(HaveOccurred())
+
+				cmd := exec.Command("make")
+				cmd.Dir = "../rundmc/nstar"
+				cmd.Stdout = GinkgoWriter
+				cmd.Stderr = GinkgoWriter
+				Expect(cmd.Run()).To(Succeed())
+				bins["nstar_bin_path"] = "../rundmc/nstar/nstar"
+
+				cmd = exec.Command("make")
+				cmd.Dir = "../rundmc/remount"
+				cmd.Stdout = GinkgoWriter
+				cmd.Stderr = GinkgoWriter
+				Expect(cmd.Run()).To(Succeed())
+				bins["remount_bin_path"] = "../rundmc/remount/remount"
+				runner.RemountBin = bins["remount_bin_path"]
+			}
+
+			prepareSnapshot(bins)
 		}
 
 		data, err := json.Marshal(bins)
@@ -70,6 +90,7 @@ func TestGqt(t *testing.T) {
 		gardenBin = bins["garden_bin_path"]
 		iodaemonBin = bins["iodaemon_bin_path"]
 		nstarBin = bins["nstar_bin_path"]
+		runner.RemountBin = bins["remount_bin_path"]
 		kawasakiBin = bins["kawasaki_bin_path"]
 		initBin = bins["init_bin_path"]
 	})
@@ -83,13 +104,15 @@ func TestGqt(t *testing.T) {
 			Skip("No Garden RootFS")
 		}
 
+		runner.SkipUnlessHostAllowsCgroupMode()
+
 		Expect(os.Chmod(initBin, 0755)).To(Succeed())
 		Expect(os.Chmod(path.Dir(initBin), 0755)).To(Succeed())
 		Expect(os.Chmod(path.Dir(path.Dir(initBin)), 0755)).To(Succeed())
 	})
 
 	SetDefaultEventuallyTimeout(5 * time.Second)
-	RunSpecs(t, "GQT Suite")
+	RunSpecs(t, runner.CgroupModeDescription("GQT Suite"))
 }
 
 func startGarden(argv ...string) *runner.RunningGarden {
@@ -99,3 +122,21 @@ func startGarden(argv ...string) *runner.RunningGarden {
 
 	return runner.Start(gardenBin, initBin, kawasakiBin, iodaemonBin, nstarBin, argv...)
 }
+
+// prepareSnapshot warms a server's graph with the rootfs the suite uses and
+// snapshots it, so that every server in the suite starts with it, when
+// GARDEN_TEST_SNAPSHOT is set and does not exist yet
+func prepareSnapshot(bins map[string]string) {
+	if runner.SnapshotPath == "" || os.Getenv("GARDEN_TEST_ROOTFS") == "" {
+		return
+	}
+
+	Expect(os.Chmod(bins["init_bin_path"], 0755)).To(Succeed())
+
+	runner.PrepareSnapshot(func() *runner.RunningGarden {
+		return runner.Start(bins["garden_bin_path"], bins["init_bin_path"], bins["kawasaki_bin_path"], bins["iodaemon_bin_path"], bins["nstar_bin_path"])
+	}, func(gdn *runner.RunningGarden) {
+		_, err := gdn.Create(garden.ContainerSpec{})
+		Expect(err).NotTo(HaveOccurred())
+	})
+}
@@ -5,6 +5,7 @@ import (
 
 	"github.com/cloudfoundry-incubator/garden"
 	"github.com/cloudfoundry-incubator/guardian/gqt/runner"
+	"github.com/cloudfoundry-incubator/guardian/gqt/runner/specs"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 )
@@ -81,14 +82,10 @@ var _ = Describe("BulkInfo", func() {
 
 	BeforeEach(func() {
 		client = startGarden()
-		_, err := client.Create(garden.ContainerSpec{
-			Handle: "first",
-		})
+		_, err := client.Create(specs.Container().WithHandle("first").Build())
 		Expect(err).NotTo(HaveOccurred())
 
-		_, err = client.Create(garden.ContainerSpec{
-			Handle: "second",
-		})
+		_, err = client.Create(specs.Container().WithHandle("second").Build())
 		Expect(err).NotTo(HaveOccurred())
 	})
 
@@ -0,0 +1,101 @@
+package gqt_test
+
+import (
+	"fmt"
+	"os/exec"
+
+	"github.com/cloudfoundry-incubator/garden"
+	"github.com/cloudfoundry-incubator/guardian/gqt/runner"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+)
+
+var _ = Describe("Restarting guardian", func() {
+	var (
+		client           *runner.RunningGarden
+		container        garden.Container
+		containerNetwork string
+		hostPort         uint32
+	)
+
+	BeforeEach(func() {
+		containerNetwork = fmt.Sprintf("192.168.%d.0/24", 100+GinkgoParallelNode())
+		client = startGarden()
+
+		var err error
+		container, err = client.Create(garden.ContainerSpec{
+			Network:    containerNetwork,
+			Properties: garden.Properties{"somename": "somevalue"},
+		})
+		Expect(err).NotTo(HaveOccurred())
+
+		hostPort, _, err = container.NetIn(0, 8080)
+		Expect(err).NotTo(HaveOccurred())
+
+		_, err = container.Run(garden.ProcessSpec{
+			Path: "sh",
+			Args: []string{"-c", "while true; do sleep 1; done"},
+			User: "root",
+		}, ginkgoIO)
+		Expect(err).NotTo(HaveOccurred())
+
+		client = client.Restart()
+	})
+
+	AfterEach(func() {
+		Expect(client.DestroyAndStop()).To(Succeed())
+	})
+
+	It("logs that it recovered the containers", func() {
+		Eventually(client.Buffer()).Should(gbytes.Say("guardian.recovered"))
+	})
+
+	It("still lists the container", func() {
+		containers, err := client.Containers(nil)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(containers).To(HaveLen(1))
+		Expect(containers[0].Handle()).To(Equal(container.Handle()))
+	})
+
+	It("keeps the container's properties", func() {
+		props, err := container.Properties()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(props).To(HaveKeyWithValue("somename", "somevalue"))
+	})
+
+	It("keeps the container's processes running", func() {
+		process, err := container.Run(garden.ProcessSpec{
+			Path: "sh",
+			Args: []string{"-c", "ps -o args | grep -v grep | grep -q 'sleep 1'"},
+			User: "root",
+		}, ginkgoIO)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(process.Wait()).To(Equal(0))
+	})
+
+	It("keeps the container's network", func() {
+		out, err := exec.Command("/bin/ping", "-c 2", ipAddress(containerNetwork, 2)).Output()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(out).To(ContainSubstring(" 0% packet loss"))
+	})
+
+	It("keeps the container's port mappings", func() {
+		info, err := container.Info()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(info.MappedPorts).To(ContainElement(garden.PortMapping{HostPort: hostPort, ContainerPort: 8080}))
+	})
+
+	It("does not give the container's IP or host port to new containers", func() {
+		other, err := client.Create(garden.ContainerSpec{Network: containerNetwork})
+		Expect(err).NotTo(HaveOccurred())
+
+		info, err := other.Info()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(info.ContainerIP).To(Equal(ipAddress(containerNetwork, 3)))
+
+		otherHostPort, _, err := other.NetIn(0, 8080)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(otherHostPort).NotTo(Equal(hostPort))
+	})
+})
@@ -13,6 +13,7 @@ import (
 
 	"github.com/cloudfoundry-incubator/garden"
 	"github.com/cloudfoundry-incubator/guardian/gqt/runner"
+	"github.com/cloudfoundry-incubator/guardian/gqt/runner/specs"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
@@ -78,7 +79,7 @@ var _ = Describe("Rootfs container create parameter", func() {
 			It("succesfully creates the container", func() {
 				var err error
 
-				container, err = client.Create(garden.ContainerSpec{RootFSPath: "docker:///cloudfoundry/garden-busybox"})
+				container, err = client.Create(specs.Busybox().Build())
 				Expect(err).ToNot(HaveOccurred())
 			})
 
@@ -5,6 +5,7 @@ import (
 
 	"github.com/cloudfoundry-incubator/garden"
 	"github.com/cloudfoundry-incubator/guardian/gqt/runner"
+	"github.com/cloudfoundry-incubator/guardian/gqt/runner/specs"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/ginkgo/extensions/table"
 	. "github.com/onsi/gomega"
@@ -62,9 +63,7 @@ var _ = Describe("Run", func() {
 	Context("when container is privileged", func() {
 		It("can run a process as a particular user", func() {
 			client = startGarden()
-			container, err := client.Create(garden.ContainerSpec{
-				Privileged: true,
-			})
+			container, err := client.Create(specs.Privileged().Build())
 			Expect(err).NotTo(HaveOccurred())
 
 			out := gbytes.NewBuffer()
@@ -129,9 +128,7 @@ var _ = Describe("Run", func() {
 		BeforeEach(func() {
 			client = startGarden()
 			var err error
-			container, err = client.Create(garden.ContainerSpec{
-				Env: []string{"USER=ppp", "HOME=/home/ppp"},
-			})
+			container, err = client.Create(specs.Container().WithEnv("USER=ppp", "HOME=/home/ppp").Build())
 			Expect(err).NotTo(HaveOccurred())
 		})
 
@@ -0,0 +1,230 @@
+package runner
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/cloudfoundry-incubator/garden"
+	. "github.com/onsi/gomega"
+)
+
+// Benchmark runs an operation a number of times, optionally concurrently,
+// and records how long each run took
+type Benchmark struct {
+	Name       string
+	Iterations int
+
+	// Concurrency is how many runs are in flight at once; 0 or 1 runs them
+	// one after another
+	Concurrency int
+
+	// Op is run once per iteration, with the index of the iteration
+	Op func(i int) error
+}
+
+type BenchmarkResult struct {
+	Name      string
+	Latencies []time.Duration
+	Errors    []error
+
+	// Elapsed is the wall-clock time of the whole benchmark
+	Elapsed time.Duration
+}
+
+// LatencyBudget is the most a benchmark may take. Zero fields are not
+// checked.
+type LatencyBudget struct {
+	P50 time.Duration
+	P95 time.Duration
+	P99 time.Duration
+	Max time.Duration
+
+	// MinThroughput is the fewest operations per second
+	MinThroughput float64
+}
+
+func (b Benchmark) Run() BenchmarkResult {
+	concurrency := b.Concurrency
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	result := BenchmarkResult{Name: b.Name}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	iterations := make(chan int)
+
+	start := time.Now()
+	for w := 0; w < concurrency; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			for i := range iterations {
+				opStart := time.Now()
+				err := b.Op(i)
+				latency := time.Since(opStart)
+
+				mu.Lock()
+				if err != nil {
+					result.Errors = append(result.Errors, err)
+				} else {
+					result.Latencies = append(result.Latencies, latency)
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+
+	for i := 0; i < b.Iterations; i++ {
+		iterations <- i
+	}
+
+	close(iterations)
+	wg.Wait()
+	result.Elapsed = time.Since(start)
+
+	sort.Sort(byDuration(result.Latencies))
+	return result
+}
+
+// Percentile returns the latency under which p percent of successful runs
+// completed, using the nearest-rank method
+func (r BenchmarkResult) Percentile(p float64) time.Duration {
+	if len(r.Latencies) == 0 {
+		return 0
+	}
+
+	rank := int(math.Ceil(p / 100 * float64(len(r.Latencies))))
+	if rank < 1 {
+		rank = 1
+	}
+
+	return r.Latencies[rank-1]
+}
+
+func (r BenchmarkResult) Max() time.Duration {
+	return r.Percentile(100)
+}
+
+// Throughput is the number of successful runs per second
+func (r BenchmarkResult) Throughput() float64 {
+	if r.Elapsed == 0 {
+		return 0
+	}
+
+	return float64(len(r.Latencies)) / r.Elapsed.Seconds()
+}
+
+func (r BenchmarkResult) Report(w io.Writer) {
+	fmt.Fprintf(w, "%s: %d ok, %d failed in %s (%.2f/s) p50=%s p95=%s p99=%s max=%s\n",
+		r.Name, len(r.Latencies), len(r.Errors), r.Elapsed, r.Throughput(),
+		r.Percentile(50), r.Percentile(95), r.Percentile(99), r.Max())
+}
+
+// ExpectWithinBudget fails the test if any run failed or the result exceeds
+// the budget
+func (r BenchmarkResult) ExpectWithinBudget(budget LatencyBudget) {
+	ExpectWithOffset(1, r.Errors).To(BeEmpty(), "%s: runs failed", r.Name)
+
+	for _, check := range []struct {
+		name   string
+		actual time.Duration
+		budget time.Duration
+	}{
+		{"p50", r.Percentile(50), budget.P50},
+		{"p95", r.Percentile(95), budget.P95},
+		{"p99", r.Percentile(99), budget.P99},
+		{"max", r.Max(), budget.Max},
+	} {
+		if check.budget == 0 {
+			continue
+		}
+
+		ExpectWithOffset(1, check.actual).To(BeNumerically("<=", check.budget), "%s: %s latency over budget", r.Name, check.name)
+	}
+
+	if budget.MinThroughput != 0 {
+		ExpectWithOffset(1, r.Throughput()).To(BeNumerically(">=", budget.MinThroughput), "%s: throughput under budget", r.Name)
+	}
+}
+
+// BenchmarkCreate creates containers from spec and then destroys them,
+// returning the results of the creates and of the destroys. Containers get
+// generated handles unless spec has one, in which case the iteration is
+// appended to it.
+func (r *RunningGarden) BenchmarkCreate(spec garden.ContainerSpec, iterations, concurrency int) (BenchmarkResult, BenchmarkResult) {
+	handles := make([]string, iterations)
+
+	creates := Benchmark{
+		Name:        "create",
+		Iterations:  iterations,
+		Concurrency: concurrency,
+		Op: func(i int) error {
+			containerSpec := spec
+			if spec.Handle != "" {
+				containerSpec.Handle = fmt.Sprintf("%s-%d", spec.Handle, i)
+			}
+
+			container, err := r.Create(containerSpec)
+			if err != nil {
+				return err
+			}
+
+			handles[i] = container.Handle()
+			return nil
+		},
+	}.Run()
+
+	destroys := Benchmark{
+		Name:        "destroy",
+		Iterations:  iterations,
+		Concurrency: concurrency,
+		Op: func(i int) error {
+			if handles[i] == "" {
+				return fmt.Errorf("container %d was not created", i)
+			}
+
+			return r.Destroy(handles[i])
+		},
+	}.Run()
+
+	return creates, destroys
+}
+
+// BenchmarkExec runs spec in container and waits for it to exit
+func (r *RunningGarden) BenchmarkExec(container garden.Container, spec garden.ProcessSpec, iterations, concurrency int) BenchmarkResult {
+	return Benchmark{
+		Name:        "exec",
+		Iterations:  iterations,
+		Concurrency: concurrency,
+		Op: func(i int) error {
+			process, err := container.Run(spec, garden.ProcessIO{})
+			if err != nil {
+				return err
+			}
+
+			exitCode, err := process.Wait()
+			if err != nil {
+				return err
+			}
+
+			if exitCode != 0 {
+				return fmt.Errorf("process exited with status %d", exitCode)
+			}
+
+			return nil
+		},
+	}.Run()
+}
+
+type byDuration []time.Duration
+
+func (d byDuration) Len() int           { return len(d) }
+func (d byDuration) Less(i, j int) bool { return d[i] < d[j] }
+func (d byDuration) Swap(i, j int)      { d[i], d[j] = d[j], d[i] }
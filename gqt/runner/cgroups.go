@@ -0,0 +1,113 @@
+package runner
+
+import (
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/cloudfoundry-incubator/guardian/sysinfo"
+	"github.com/onsi/ginkgo"
+	"github.com/pivotal-golang/lager/lagertest"
+)
+
+// CgroupMode is the cgroup mode the suite is run in, from
+// GARDEN_TEST_CGROUP_MODE: "v1" or "v2" force guardian in to that mode,
+// "hybrid" runs guardian in v1 mode and requires a hybrid host, and "" lets
+// guardian choose. A CI matrix runs the suite once per mode.
+var CgroupMode = sysinfo.CgroupMode(os.Getenv("GARDEN_TEST_CGROUP_MODE"))
+
+const sysCgroupPath = "/sys/fs/cgroup"
+
+var (
+	hostCapabilitiesOnce sync.Once
+	hostCapabilities     *sysinfo.Capabilities
+)
+
+// HostCgroupMode is the cgroup mode of the host the tests are run on
+func HostCgroupMode() sysinfo.CgroupMode {
+	return sysinfo.DetectCgroupMode(sysCgroupPath)
+}
+
+// GuardianCgroupMode is the cgroup mode guardian runs containers in
+func GuardianCgroupMode() sysinfo.CgroupMode {
+	switch CgroupMode {
+	case sysinfo.CgroupModeV1, sysinfo.CgroupModeV2:
+		return CgroupMode
+	case sysinfo.CgroupModeHybrid:
+		return sysinfo.CgroupModeV1
+	}
+
+	if HostCgroupMode() == sysinfo.CgroupModeV2 {
+		return sysinfo.CgroupModeV2
+	}
+
+	return sysinfo.CgroupModeV1
+}
+
+// CgroupModeDescription annotates the suite's description with the cgroup
+// mode it runs in, so that the runs of a matrix can be told apart
+func CgroupModeDescription(description string) string {
+	return fmt.Sprintf("%s (cgroup %s on a %s host)", description, GuardianCgroupMode(), HostCgroupMode())
+}
+
+// SkipUnlessHostAllowsCgroupMode skips the current test when the host cannot
+// run the suite in the requested cgroup mode, e.g. v1 on a host with only
+// cgroup v2, rather than failing every test in the run
+func SkipUnlessHostAllowsCgroupMode() {
+	if CgroupMode == "" {
+		return
+	}
+
+	if CgroupMode == sysinfo.CgroupModeHybrid {
+		if HostCgroupMode() != sysinfo.CgroupModeHybrid {
+			ginkgo.Skip(fmt.Sprintf("cgroup mode hybrid needs a hybrid host, not a %s one", HostCgroupMode()))
+		}
+		return
+	}
+
+	if err := sysinfo.CheckCgroupMode(CgroupMode, HostCgroupMode()); err != nil {
+		ginkgo.Skip(err.Error())
+	}
+}
+
+// SkipUnlessCgroupMode skips the current test unless guardian runs
+// containers in one of the given cgroup modes
+func SkipUnlessCgroupMode(modes ...sysinfo.CgroupMode) {
+	mode := GuardianCgroupMode()
+	for _, m := range modes {
+		if m == mode {
+			return
+		}
+	}
+
+	ginkgo.Skip(fmt.Sprintf("guardian runs containers in cgroup %s", mode))
+}
+
+// SkipUnlessCapability skips the current test unless the host has the kernel
+// feature (one of the sysinfo Capability names) in the cgroup mode guardian
+// runs containers in
+func SkipUnlessCapability(name string) {
+	hostCapabilitiesOnce.Do(func() {
+		hostCapabilities = &sysinfo.Capabilities{}
+		hostCapabilities.Set((&sysinfo.CapabilityProber{
+			ProcPath:   "/proc",
+			CgroupPath: sysCgroupPath,
+			Unified:    GuardianCgroupMode() == sysinfo.CgroupModeV2,
+			Logger:     lagertest.NewTestLogger("capability-prober"),
+		}).Probe())
+	})
+
+	for _, capability := range hostCapabilities.List() {
+		if capability.Name == name && !capability.Available {
+			ginkgo.Skip(fmt.Sprintf("%s is not available: %s", name, capability.Reason))
+		}
+	}
+}
+
+func cgroupModeFlags() [][]string {
+	if CgroupMode == "" {
+		return nil
+	}
+
+	return [][]string{{"--cgroupMode", string(GuardianCgroupMode())}}
+}
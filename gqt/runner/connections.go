@@ -0,0 +1,39 @@
+package runner
+
+import (
+	"net"
+	"strconv"
+	"time"
+)
+
+// ConnectionLimits govern the connections clients open to the server's
+// garden API. Zero fields are left at the server's defaults.
+type ConnectionLimits struct {
+	MaxPerClient int
+	KeepAlive    time.Duration
+	IdleTimeout  time.Duration
+}
+
+// Args are the server's flags for the limits, to be passed to Start
+func (l ConnectionLimits) Args() []string {
+	var args []string
+	if l.MaxPerClient != 0 {
+		args = append(args, "--maxConnectionsPerClient", strconv.Itoa(l.MaxPerClient))
+	}
+
+	if l.KeepAlive != 0 {
+		args = append(args, "--connectionKeepAlive", l.KeepAlive.String())
+	}
+
+	if l.IdleTimeout != 0 {
+		args = append(args, "--connectionIdleTimeout", l.IdleTimeout.String())
+	}
+
+	return args
+}
+
+// Dial opens a bare connection to the server's garden API, e.g. to hold one
+// of a client's connections open
+func (r *RunningGarden) Dial() (net.Conn, error) {
+	return net.Dial(r.network, r.addr)
+}
@@ -0,0 +1,26 @@
+// +build !windows
+
+package runner
+
+import (
+	"fmt"
+	"os/exec"
+	"syscall"
+
+	"github.com/onsi/ginkgo"
+)
+
+func defaultListener() (network, addr string) {
+	return "unix", fmt.Sprintf("/tmp/garden_%d.sock", ginkgo.GinkgoParallelNode())
+}
+
+func platformDefaultFlags() [][]string {
+	return nil
+}
+
+func prepareCmd(cmd *exec.Cmd) {}
+
+// signalStop asks the server to stop gracefully
+func (r *RunningGarden) signalStop() {
+	r.process.Signal(syscall.SIGTERM)
+}
@@ -0,0 +1,45 @@
+package runner
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"syscall"
+
+	"github.com/onsi/ginkgo"
+)
+
+// WincPath is the winc binary guardian runs Windows containers with
+var WincPath = os.Getenv("GARDEN_TEST_WINC_PATH")
+
+// WincImagePath is the image plugin which creates Windows rootfses, as the
+// graph drivers only run on Linux
+var WincImagePath = os.Getenv("GARDEN_TEST_WINC_IMAGE_PATH")
+
+var generateConsoleCtrlEvent = syscall.NewLazyDLL("kernel32.dll").NewProc("GenerateConsoleCtrlEvent")
+
+func defaultListener() (network, addr string) {
+	return "tcp", fmt.Sprintf("127.0.0.1:%d", 7777+ginkgo.GinkgoParallelNode())
+}
+
+func platformDefaultFlags() [][]string {
+	return [][]string{
+		{"--runtimePlugin", WincPath},
+		{"--imagePlugin", WincImagePath},
+	}
+}
+
+// prepareCmd starts the server in a process group of its own, so that it
+// can be sent a CTRL_BREAK_EVENT without it also reaching the tests
+func prepareCmd(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{CreationFlags: syscall.CREATE_NEW_PROCESS_GROUP}
+}
+
+// signalStop asks the server to stop gracefully. Processes cannot be sent
+// SIGTERM on Windows, so it is sent a CTRL_BREAK_EVENT, which Go delivers to
+// the server as os.Interrupt.
+func (r *RunningGarden) signalStop() {
+	if ok, _, err := generateConsoleCtrlEvent.Call(syscall.CTRL_BREAK_EVENT, uintptr(r.Pid)); ok == 0 {
+		r.logger.Error("signal-stop-failed", err)
+	}
+}
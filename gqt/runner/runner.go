@@ -1,8 +1,10 @@
 package runner
 
 import (
+	"crypto/tls"
 	"errors"
 	"fmt"
+	"net"
 	"os"
 	"os/exec"
 	"path/filepath"
@@ -26,6 +28,10 @@ var RootFSPath = os.Getenv("GARDEN_TEST_ROOTFS")
 var GraphRoot = os.Getenv("GARDEN_TEST_GRAPHPATH")
 var TarPath = os.Getenv("GARDEN_TAR_PATH")
 
+// RemountBin is the remount helper passed to servers as -remountBin; the
+// suite builds it alongside nstar
+var RemountBin string
+
 type RunningGarden struct {
 	client.Client
 
@@ -36,16 +42,46 @@ type RunningGarden struct {
 
 	tmpdir string
 
+	// network and addr are where the server listens for the garden API
+	network string
+	addr    string
+
 	DepotDir  string
 	GraphRoot string
 	GraphPath string
 
 	logger lager.Logger
+
+	// restart starts a new server with the same flags and directories
+	restart func() *RunningGarden
 }
 
 func Start(bin, initBin, kawasakiBin, iodaemonBin, nstarBin string, argv ...string) *RunningGarden {
-	network := "unix"
-	addr := fmt.Sprintf("/tmp/garden_%d.sock", GinkgoParallelNode())
+	network, addr := defaultListener()
+	return start(network, addr, connection.New(network, addr), bin, initBin, kawasakiBin, iodaemonBin, nstarBin, argv...)
+}
+
+// TLSFiles are the PEM files the server is given to serve the garden API
+// over mutually-authenticated TLS
+type TLSFiles struct {
+	Cert string
+	Key  string
+	CA   string
+}
+
+// StartWithTLS starts a server which listens on tcp with TLS, and connects
+// to it with clientConfig
+func StartWithTLS(files TLSFiles, clientConfig *tls.Config, bin, initBin, kawasakiBin, iodaemonBin, nstarBin string, argv ...string) *RunningGarden {
+	addr := fmt.Sprintf("127.0.0.1:%d", 7800+ginkgo.GinkgoParallelNode())
+	dial := func(string, string) (net.Conn, error) {
+		return tls.Dial("tcp", addr, clientConfig)
+	}
+
+	argv = append(argv, "--tlsCert", files.Cert, "--tlsKey", files.Key, "--tlsCA", files.CA)
+	return start("tcp", addr, connection.NewWithDialerAndLogger(dial, lagertest.NewTestLogger("garden-client")), bin, initBin, kawasakiBin, iodaemonBin, nstarBin, argv...)
+}
+
+func start(network, addr string, conn connection.Connection, bin, initBin, kawasakiBin, iodaemonBin, nstarBin string, argv ...string) *RunningGarden {
 	tmpDir := filepath.Join(
 		os.TempDir(),
 		fmt.Sprintf("test-garden-%d", ginkgo.GinkgoParallelNode()),
@@ -58,7 +94,14 @@ func Start(bin, initBin, kawasakiBin, iodaemonBin, nstarBin string, argv ...stri
 	graphPath := filepath.Join(GraphRoot, fmt.Sprintf("node-%d", ginkgo.GinkgoParallelNode()))
 	depotDir := filepath.Join(tmpDir, "containers")
 
+	// a restarted server keeps the graph it had
+	_, err := os.Stat(graphPath)
+	freshGraph := os.IsNotExist(err)
+
 	MustMountTmpfs(graphPath)
+	if freshGraph {
+		restoreSnapshot(graphPath)
+	}
 
 	r := &RunningGarden{
 		DepotDir: depotDir,
@@ -66,9 +109,15 @@ func Start(bin, initBin, kawasakiBin, iodaemonBin, nstarBin string, argv ...stri
 		GraphRoot: GraphRoot,
 		GraphPath: graphPath,
 		tmpdir:    tmpDir,
+		network:   network,
+		addr:      addr,
 		logger:    lagertest.NewTestLogger("garden-runner"),
 
-		Client: client.New(connection.New(network, addr)),
+		Client: client.New(conn),
+	}
+
+	r.restart = func() *RunningGarden {
+		return start(network, addr, conn, bin, initBin, kawasakiBin, iodaemonBin, nstarBin, argv...)
 	}
 
 	c := cmd(tmpDir, depotDir, graphPath, network, addr, bin, initBin, kawasakiBin, iodaemonBin, nstarBin, TarPath, RootFSPath, argv...)
@@ -110,7 +159,7 @@ func (r *RunningGarden) DestroyAndStop() error {
 }
 
 func (r *RunningGarden) Stop() error {
-	r.process.Signal(syscall.SIGTERM)
+	r.signalStop()
 
 	var err error
 	for i := 0; i < 5; i++ {
@@ -118,7 +167,7 @@ func (r *RunningGarden) Stop() error {
 		case err := <-r.process.Wait():
 			return err
 		case <-time.After(time.Second * 5):
-			r.process.Signal(syscall.SIGTERM)
+			r.signalStop()
 			err = errors.New("timed out waiting for garden to shutdown after 5 seconds")
 		}
 	}
@@ -127,6 +176,14 @@ func (r *RunningGarden) Stop() error {
 	return err
 }
 
+// Restart stops the server gracefully and starts a new one with the same
+// flags, depot, properties and graph, as guardian is restarted in place when
+// it is upgraded. It returns the new server; r must not be used afterwards.
+func (r *RunningGarden) Restart() *RunningGarden {
+	Expect(r.Stop()).To(Succeed())
+	return r.restart()
+}
+
 func cmd(tmpdir, depotDir, graphPath, network, addr, bin, initBin, kawasakiBin, iodaemonBin, nstarBin, tarBin, rootFSPath string, argv ...string) *exec.Cmd {
 	Expect(os.MkdirAll(tmpdir, 0755)).To(Succeed())
 
@@ -156,17 +213,28 @@ func cmd(tmpdir, depotDir, graphPath, network, addr, bin, initBin, kawasakiBin,
 	gardenArgs = appendDefaultFlag(gardenArgs, "--listenNetwork", network)
 	gardenArgs = appendDefaultFlag(gardenArgs, "--listenAddr", addr)
 	gardenArgs = appendDefaultFlag(gardenArgs, "--depot", depotDir)
+	gardenArgs = appendDefaultFlag(gardenArgs, "--propertiesDir", filepath.Join(tmpdir, "properties"))
+	gardenArgs = appendDefaultFlag(gardenArgs, "--networkPoolStateFile", filepath.Join(tmpdir, "network-pool.json"))
 	gardenArgs = appendDefaultFlag(gardenArgs, "--graph", graphPath)
 	gardenArgs = appendDefaultFlag(gardenArgs, "--tag", fmt.Sprintf("%d", GinkgoParallelNode()))
 	gardenArgs = appendDefaultFlag(gardenArgs, "--initBin", initBin)
 	gardenArgs = appendDefaultFlag(gardenArgs, "--iodaemonBin", iodaemonBin)
 	gardenArgs = appendDefaultFlag(gardenArgs, "--kawasakiBin", kawasakiBin)
 	gardenArgs = appendDefaultFlag(gardenArgs, "--nstarBin", nstarBin)
+	gardenArgs = appendDefaultFlag(gardenArgs, "--remountBin", RemountBin)
 	gardenArgs = appendDefaultFlag(gardenArgs, "--tarBin", tarBin)
 	gardenArgs = appendDefaultFlag(gardenArgs, "--logLevel", "debug")
 	gardenArgs = appendDefaultFlag(gardenArgs, "--debugAddr", fmt.Sprintf(":808%d", ginkgo.GinkgoParallelNode()))
 	gardenArgs = appendDefaultFlag(gardenArgs, "--rootfs", rootFSPath)
-	return exec.Command(bin, gardenArgs...)
+	for _, flag := range platformDefaultFlags() {
+		gardenArgs = appendDefaultFlag(gardenArgs, flag[0], flag[1])
+	}
+	for _, flag := range cgroupModeFlags() {
+		gardenArgs = appendDefaultFlag(gardenArgs, flag[0], flag[1])
+	}
+	c := exec.Command(bin, gardenArgs...)
+	prepareCmd(c)
+	return c
 }
 
 func (r *RunningGarden) Cleanup() {
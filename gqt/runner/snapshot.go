@@ -0,0 +1,71 @@
+package runner
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	. "github.com/onsi/gomega"
+)
+
+// SnapshotPath is a gzipped tarball of a prepared graph, i.e. a store of
+// pulled images and layers. When it exists, every server starts from a copy
+// of it rather than from an empty graph, so that suites do not pull the same
+// images over and over.
+var SnapshotPath = os.Getenv("GARDEN_TEST_SNAPSHOT")
+
+// Snapshot archives the server's graph to a gzipped tarball. The server
+// should be stopped first, so that the graph is not changing. The tarball
+// appears whole, so that parallel nodes never restore a partial one.
+func (r *RunningGarden) Snapshot(tarball string) error {
+	if err := os.MkdirAll(filepath.Dir(tarball), 0755); err != nil {
+		return err
+	}
+
+	partial := fmt.Sprintf("%s.%d.tmp", tarball, os.Getpid())
+	if out, err := exec.Command("tar", "-czf", partial, "-C", r.GraphPath, ".").CombinedOutput(); err != nil {
+		os.Remove(partial)
+		return fmt.Errorf("snapshot graph: %s: %s", err, out)
+	}
+
+	return os.Rename(partial, tarball)
+}
+
+// PrepareSnapshot creates the snapshot at SnapshotPath, unless there is one
+// already. It starts a server with start, lets prepare warm its graph, e.g.
+// by creating a container from every image the suite uses, and snapshots it
+// once it has stopped. It is meant to be run once, before the suite's
+// servers are started.
+func PrepareSnapshot(start func() *RunningGarden, prepare func(gdn *RunningGarden)) {
+	if SnapshotPath == "" {
+		return
+	}
+
+	if _, err := os.Stat(SnapshotPath); err == nil {
+		return
+	}
+
+	gdn := start()
+	defer gdn.Cleanup()
+
+	prepare(gdn)
+
+	Expect(gdn.DestroyAndStop()).To(Succeed())
+	Expect(gdn.Snapshot(SnapshotPath)).To(Succeed())
+}
+
+// restoreSnapshot extracts the snapshot at SnapshotPath, if there is one, in
+// to an empty graph
+func restoreSnapshot(graphPath string) {
+	if SnapshotPath == "" {
+		return
+	}
+
+	if _, err := os.Stat(SnapshotPath); os.IsNotExist(err) {
+		return
+	}
+
+	out, err := exec.Command("tar", "-xzf", SnapshotPath, "-C", graphPath).CombinedOutput()
+	Expect(err).NotTo(HaveOccurred(), "restore snapshot: %s", out)
+}
@@ -0,0 +1,119 @@
+// Package specs builds garden.ContainerSpecs for gqt suites, so that suites
+// share presets rather than each defining their own spec literals.
+//
+//	client.Create(specs.Container().WithRootfs(specs.BusyboxRootFS).PrivilegedIf(privileged).Build())
+package specs
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/cloudfoundry-incubator/garden"
+	"github.com/onsi/ginkgo"
+)
+
+// BusyboxRootFS is a small docker image suitable for most tests.
+const BusyboxRootFS = "docker:///cloudfoundry/garden-busybox"
+
+type ContainerSpecBuilder struct {
+	spec garden.ContainerSpec
+}
+
+// Container starts building a spec for a container using the default rootfs.
+func Container() *ContainerSpecBuilder {
+	return &ContainerSpecBuilder{}
+}
+
+// Privileged is a preset for a privileged container.
+func Privileged() *ContainerSpecBuilder {
+	return Container().PrivilegedIf(true)
+}
+
+// Busybox is a preset for an unprivileged busybox container.
+func Busybox() *ContainerSpecBuilder {
+	return Container().WithRootfs(BusyboxRootFS)
+}
+
+// OnNodeSubnet is a preset for a container on a subnet unique to the ginkgo
+// node, so that parallel suites do not collide. host is the last octet of
+// the container's IP.
+func OnNodeSubnet(host int) *ContainerSpecBuilder {
+	return Container().WithNetwork(fmt.Sprintf("10.253.%d.%d/24", ginkgo.GinkgoParallelNode(), host))
+}
+
+func (b *ContainerSpecBuilder) WithHandle(handle string) *ContainerSpecBuilder {
+	b.spec.Handle = handle
+	return b
+}
+
+func (b *ContainerSpecBuilder) WithRootfs(rootfs string) *ContainerSpecBuilder {
+	b.spec.RootFSPath = rootfs
+	return b
+}
+
+func (b *ContainerSpecBuilder) WithNetwork(network string) *ContainerSpecBuilder {
+	b.spec.Network = network
+	return b
+}
+
+func (b *ContainerSpecBuilder) PrivilegedIf(privileged bool) *ContainerSpecBuilder {
+	b.spec.Privileged = privileged
+	return b
+}
+
+func (b *ContainerSpecBuilder) WithMemoryLimit(limitInBytes uint64) *ContainerSpecBuilder {
+	b.spec.Limits.Memory.LimitInBytes = limitInBytes
+	return b
+}
+
+func (b *ContainerSpecBuilder) WithDiskLimit(byteHard uint64) *ContainerSpecBuilder {
+	b.spec.Limits.Disk.ByteHard = byteHard
+	return b
+}
+
+func (b *ContainerSpecBuilder) WithProperty(name, value string) *ContainerSpecBuilder {
+	if b.spec.Properties == nil {
+		b.spec.Properties = garden.Properties{}
+	}
+
+	b.spec.Properties[name] = value
+	return b
+}
+
+func (b *ContainerSpecBuilder) WithEnv(env ...string) *ContainerSpecBuilder {
+	b.spec.Env = append(b.spec.Env, env...)
+	return b
+}
+
+func (b *ContainerSpecBuilder) WithBindMount(src, dst string, mode garden.BindMountMode, origin garden.BindMountOrigin) *ContainerSpecBuilder {
+	b.spec.BindMounts = append(b.spec.BindMounts, garden.BindMount{
+		SrcPath: src,
+		DstPath: dst,
+		Mode:    mode,
+		Origin:  origin,
+	})
+
+	return b
+}
+
+func (b *ContainerSpecBuilder) WithGraceTime(graceTime time.Duration) *ContainerSpecBuilder {
+	b.spec.GraceTime = graceTime
+	return b
+}
+
+// Build returns the spec. The builder may continue to be used afterwards
+// without affecting the returned spec.
+func (b *ContainerSpecBuilder) Build() garden.ContainerSpec {
+	spec := b.spec
+
+	if b.spec.Properties != nil {
+		spec.Properties = garden.Properties{}
+		for name, value := range b.spec.Properties {
+			spec.Properties[name] = value
+		}
+	}
+
+	spec.Env = append([]string(nil), b.spec.Env...)
+	spec.BindMounts = append([]garden.BindMount(nil), b.spec.BindMounts...)
+	return spec
+}
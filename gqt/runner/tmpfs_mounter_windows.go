@@ -0,0 +1,16 @@
+package runner
+
+import (
+	"os"
+
+	. "github.com/onsi/gomega"
+)
+
+// there is no tmpfs on Windows, so the graph is an ordinary directory
+func MustMountTmpfs(destination string) {
+	Expect(os.MkdirAll(destination, 0755)).To(Succeed())
+}
+
+func MustUnmountTmpfs(destination string) {}
+
+func Unmount(destination string) {}
@@ -0,0 +1,183 @@
+package gqt_test
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/cloudfoundry-incubator/garden"
+	gardenclient "github.com/cloudfoundry-incubator/garden/client"
+	"github.com/cloudfoundry-incubator/garden/client/connection"
+	"github.com/cloudfoundry-incubator/guardian/gqt/runner"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/pivotal-golang/lager/lagertest"
+)
+
+var _ = Describe("TLS", func() {
+	var (
+		certDir    string
+		ca         *certificateAuthority
+		files      runner.TLSFiles
+		clientCert tls.Certificate
+		client     *runner.RunningGarden
+	)
+
+	BeforeEach(func() {
+		var err error
+		certDir, err = ioutil.TempDir("", "gqt-tls")
+		Expect(err).NotTo(HaveOccurred())
+
+		ca = newCertificateAuthority("garden-ca")
+		files = runner.TLSFiles{
+			Cert: filepath.Join(certDir, "server.crt"),
+			Key:  filepath.Join(certDir, "server.key"),
+			CA:   filepath.Join(certDir, "ca.crt"),
+		}
+
+		ca.writeCert(files.CA)
+		ca.issue("127.0.0.1", x509.ExtKeyUsageServerAuth).write(files.Cert, files.Key)
+		clientCert = ca.issue("garden-client", x509.ExtKeyUsageClientAuth).tlsCertificate()
+
+		client = runner.StartWithTLS(files, &tls.Config{
+			Certificates: []tls.Certificate{clientCert},
+			RootCAs:      ca.pool(),
+		}, gardenBin, initBin, kawasakiBin, iodaemonBin, nstarBin)
+	})
+
+	AfterEach(func() {
+		Expect(client.DestroyAndStop()).To(Succeed())
+		Expect(os.RemoveAll(certDir)).To(Succeed())
+	})
+
+	clientWith := func(config *tls.Config) garden.Client {
+		addr := fmt.Sprintf("127.0.0.1:%d", 7800+GinkgoParallelNode())
+		return gardenclient.New(connection.NewWithDialerAndLogger(func(string, string) (net.Conn, error) {
+			return tls.Dial("tcp", addr, config)
+		}, lagertest.NewTestLogger("tls-client")))
+	}
+
+	It("serves the garden API to clients with a certificate signed by the CA", func() {
+		container, err := client.Create(garden.ContainerSpec{})
+		Expect(err).NotTo(HaveOccurred())
+
+		process, err := container.Run(garden.ProcessSpec{Path: "echo", Args: []string{"hello"}}, ginkgoIO)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(process.Wait()).To(Equal(0))
+	})
+
+	It("refuses clients without a certificate", func() {
+		Expect(clientWith(&tls.Config{RootCAs: ca.pool()}).Ping()).NotTo(Succeed())
+	})
+
+	It("refuses clients with a certificate signed by another CA", func() {
+		otherCert := newCertificateAuthority("other-ca").issue("garden-client", x509.ExtKeyUsageClientAuth).tlsCertificate()
+		Expect(clientWith(&tls.Config{Certificates: []tls.Certificate{otherCert}, RootCAs: ca.pool()}).Ping()).NotTo(Succeed())
+	})
+
+	It("refuses plaintext clients", func() {
+		addr := fmt.Sprintf("127.0.0.1:%d", 7800+GinkgoParallelNode())
+		Expect(gardenclient.New(connection.New("tcp", addr)).Ping()).NotTo(Succeed())
+	})
+})
+
+type certificateAuthority struct {
+	keyPair
+}
+
+type keyPair struct {
+	cert *x509.Certificate
+	der  []byte
+	key  *rsa.PrivateKey
+}
+
+func newCertificateAuthority(name string) *certificateAuthority {
+	template := certificateTemplate(name)
+	template.IsCA = true
+	template.BasicConstraintsValid = true
+	template.KeyUsage = x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	Expect(err).NotTo(HaveOccurred())
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	Expect(err).NotTo(HaveOccurred())
+
+	cert, err := x509.ParseCertificate(der)
+	Expect(err).NotTo(HaveOccurred())
+
+	return &certificateAuthority{keyPair{cert: cert, der: der, key: key}}
+}
+
+// issue returns a key pair signed by the CA, for an IP address if name is
+// one
+func (ca *certificateAuthority) issue(name string, usage x509.ExtKeyUsage) keyPair {
+	template := certificateTemplate(name)
+	template.KeyUsage = x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment
+	template.ExtKeyUsage = []x509.ExtKeyUsage{usage}
+	if ip := net.ParseIP(name); ip != nil {
+		template.IPAddresses = []net.IP{ip}
+	}
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	Expect(err).NotTo(HaveOccurred())
+
+	der, err := x509.CreateCertificate(rand.Reader, template, ca.cert, &key.PublicKey, ca.key)
+	Expect(err).NotTo(HaveOccurred())
+
+	cert, err := x509.ParseCertificate(der)
+	Expect(err).NotTo(HaveOccurred())
+
+	return keyPair{cert: cert, der: der, key: key}
+}
+
+func (ca *certificateAuthority) pool() *x509.CertPool {
+	pool := x509.NewCertPool()
+	pool.AddCert(ca.cert)
+	return pool
+}
+
+func (ca *certificateAuthority) writeCert(path string) {
+	Expect(ioutil.WriteFile(path, ca.certPEM(), 0644)).To(Succeed())
+}
+
+func (k keyPair) certPEM() []byte {
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: k.der})
+}
+
+func (k keyPair) keyPEM() []byte {
+	return pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(k.key)})
+}
+
+func (k keyPair) write(certPath, keyPath string) {
+	Expect(ioutil.WriteFile(certPath, k.certPEM(), 0644)).To(Succeed())
+	Expect(ioutil.WriteFile(keyPath, k.keyPEM(), 0600)).To(Succeed())
+}
+
+func (k keyPair) tlsCertificate() tls.Certificate {
+	cert, err := tls.X509KeyPair(k.certPEM(), k.keyPEM())
+	Expect(err).NotTo(HaveOccurred())
+	return cert
+}
+
+var serialNumber int64
+
+func certificateTemplate(name string) *x509.Certificate {
+	serialNumber++
+	return &x509.Certificate{
+		SerialNumber: big.NewInt(serialNumber),
+		Subject:      pkix.Name{CommonName: name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+}
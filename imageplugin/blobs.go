@@ -0,0 +1,163 @@
+package imageplugin
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pivotal-golang/lager"
+)
+
+var sha256Digest = regexp.MustCompile(`^sha256:[a-f0-9]{64}$`)
+
+// BlobCache stores blobs (layers and image configs) by digest so that they
+// are only downloaded once, however many containers use them. Blobs which
+// are not pinned can be pruned once they have not been fetched for a while.
+type BlobCache struct {
+	Path string
+
+	// mu is held while a cached blob is looked up or pruned, so that a blob
+	// being fetched is never pruned from under its fetcher
+	mu     sync.Mutex
+	pinned map[string]bool
+}
+
+// Fetch returns the path of the cached blob, calling fetch to download it if
+// it is not yet cached. Downloaded content is verified against the digest.
+func (c *BlobCache) Fetch(log lager.Logger, digest string, fetch func() (io.ReadCloser, error)) (string, error) {
+	if !sha256Digest.MatchString(digest) {
+		return "", fmt.Errorf("unsupported digest '%s'", digest)
+	}
+
+	sum := strings.TrimPrefix(digest, "sha256:")
+	dir := filepath.Join(c.Path, "sha256")
+	path := filepath.Join(dir, sum)
+
+	if c.touch(path) {
+		return path, nil
+	}
+
+	log = log.Session("fetch-blob", lager.Data{"digest": digest})
+	log.Info("started")
+	defer log.Info("finished")
+
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", err
+	}
+
+	body, err := fetch()
+	if err != nil {
+		log.Error("fetch-failed", err)
+		return "", err
+	}
+	defer body.Close()
+
+	// download beside the final path so that a concurrent or interrupted
+	// fetch never leaves a partial blob in the cache
+	tmp, err := ioutil.TempFile(dir, sum+".partial")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(tmp.Name())
+
+	hash := sha256.New()
+	_, err = io.Copy(io.MultiWriter(tmp, hash), body)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+
+	if err != nil {
+		log.Error("download-failed", err)
+		return "", fmt.Errorf("download blob %s: %s", digest, err)
+	}
+
+	if actual := hex.EncodeToString(hash.Sum(nil)); actual != sum {
+		return "", fmt.Errorf("blob %s has digest sha256:%s", digest, actual)
+	}
+
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return "", err
+	}
+
+	return path, nil
+}
+
+// touch marks the blob at path as fetched now, returning false if it is not
+// cached
+func (c *BlobCache) touch(path string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, err := os.Stat(path); err != nil {
+		return false
+	}
+
+	now := time.Now()
+	os.Chtimes(path, now, now)
+	return true
+}
+
+// Pin keeps the blobs with the given digests in the cache however long ago
+// they were fetched
+func (c *BlobCache) Pin(digests ...string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.pinned == nil {
+		c.pinned = map[string]bool{}
+	}
+
+	for _, digest := range digests {
+		c.pinned[digest] = true
+	}
+}
+
+// Prune removes the blobs which are not pinned and were last fetched before
+// the given time. Containers' rootfses are unpacked copies of their blobs, so
+// they are unaffected.
+func (c *BlobCache) Prune(log lager.Logger, before time.Time) error {
+	log = log.Session("prune-blobs")
+
+	entries, err := ioutil.ReadDir(filepath.Join(c.Path, "sha256"))
+	if os.IsNotExist(err) {
+		return nil
+	}
+
+	if err != nil {
+		log.Error("list-blobs-failed", err)
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, entry := range entries {
+		digest := "sha256:" + entry.Name()
+		if !sha256Digest.MatchString(digest) || c.pinned[digest] {
+			continue
+		}
+
+		path := filepath.Join(c.Path, "sha256", entry.Name())
+		info, err := os.Stat(path)
+		if err != nil || !info.ModTime().Before(before) {
+			continue
+		}
+
+		if err := os.Remove(path); err != nil {
+			log.Error("remove-failed", err, lager.Data{"digest": digest})
+			continue
+		}
+
+		log.Info("removed", lager.Data{"digest": digest})
+	}
+
+	return nil
+}
@@ -0,0 +1,172 @@
+package imageplugin_test
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/cloudfoundry-incubator/guardian/imageplugin"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/pivotal-golang/clock/fakeclock"
+	"github.com/pivotal-golang/lager/lagertest"
+)
+
+var _ = Describe("BlobCache", func() {
+	var (
+		cacheDir string
+		cache    *imageplugin.BlobCache
+		digest   string
+		fetches  int
+		content  []byte
+	)
+
+	fetch := func() (io.ReadCloser, error) {
+		fetches++
+		return ioutil.NopCloser(bytes.NewReader(content)), nil
+	}
+
+	BeforeEach(func() {
+		var err error
+		cacheDir, err = ioutil.TempDir("", "blobs")
+		Expect(err).NotTo(HaveOccurred())
+
+		cache = &imageplugin.BlobCache{Path: cacheDir}
+		content = []byte("some-blob")
+		fetches = 0
+
+		sum := sha256.Sum256(content)
+		digest = "sha256:" + hex.EncodeToString(sum[:])
+	})
+
+	AfterEach(func() {
+		Expect(os.RemoveAll(cacheDir)).To(Succeed())
+	})
+
+	It("downloads the blob in to the cache", func() {
+		path, err := cache.Fetch(lagertest.NewTestLogger("test"), digest, fetch)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(path).To(Equal(filepath.Join(cacheDir, "sha256", digest[len("sha256:"):])))
+		Expect(ioutil.ReadFile(path)).To(Equal(content))
+	})
+
+	It("only downloads each blob once", func() {
+		_, err := cache.Fetch(lagertest.NewTestLogger("test"), digest, fetch)
+		Expect(err).NotTo(HaveOccurred())
+		_, err = cache.Fetch(lagertest.NewTestLogger("test"), digest, fetch)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(fetches).To(Equal(1))
+	})
+
+	It("rejects content which does not match the digest", func() {
+		content = []byte("tampered")
+
+		_, err := cache.Fetch(lagertest.NewTestLogger("test"), digest, fetch)
+		Expect(err).To(MatchError(ContainSubstring("has digest")))
+
+		entries, err := ioutil.ReadDir(filepath.Join(cacheDir, "sha256"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(entries).To(BeEmpty())
+	})
+
+	It("rejects unsupported or malformed digests", func() {
+		_, err := cache.Fetch(lagertest.NewTestLogger("test"), "sha256:../../etc/passwd", fetch)
+		Expect(err).To(MatchError("unsupported digest 'sha256:../../etc/passwd'"))
+		Expect(fetches).To(Equal(0))
+	})
+
+	It("returns fetch errors", func() {
+		_, err := cache.Fetch(lagertest.NewTestLogger("test"), digest, func() (io.ReadCloser, error) {
+			return nil, errors.New("network down")
+		})
+		Expect(err).To(MatchError("network down"))
+	})
+
+	Describe("Prune", func() {
+		var path string
+
+		BeforeEach(func() {
+			var err error
+			path, err = cache.Fetch(lagertest.NewTestLogger("test"), digest, fetch)
+			Expect(err).NotTo(HaveOccurred())
+
+			lastFetched := time.Now().Add(-time.Hour)
+			Expect(os.Chtimes(path, lastFetched, lastFetched)).To(Succeed())
+		})
+
+		It("removes the blobs which were last fetched before the given time", func() {
+			Expect(cache.Prune(lagertest.NewTestLogger("test"), time.Now().Add(-time.Minute))).To(Succeed())
+			Expect(path).NotTo(BeAnExistingFile())
+		})
+
+		It("keeps the blobs which were fetched since", func() {
+			Expect(cache.Prune(lagertest.NewTestLogger("test"), time.Now().Add(-2*time.Hour))).To(Succeed())
+			Expect(path).To(BeAnExistingFile())
+		})
+
+		It("counts fetching a cached blob as fetching it", func() {
+			_, err := cache.Fetch(lagertest.NewTestLogger("test"), digest, fetch)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(cache.Prune(lagertest.NewTestLogger("test"), time.Now().Add(-time.Minute))).To(Succeed())
+			Expect(path).To(BeAnExistingFile())
+			Expect(fetches).To(Equal(1))
+		})
+
+		It("keeps pinned blobs", func() {
+			cache.Pin(digest)
+
+			Expect(cache.Prune(lagertest.NewTestLogger("test"), time.Now())).To(Succeed())
+			Expect(path).To(BeAnExistingFile())
+		})
+
+		It("leaves files which are not blobs alone", func() {
+			partial := filepath.Join(cacheDir, "sha256", "something.partial")
+			Expect(ioutil.WriteFile(partial, nil, 0600)).To(Succeed())
+
+			Expect(cache.Prune(lagertest.NewTestLogger("test"), time.Now().Add(time.Hour))).To(Succeed())
+			Expect(partial).To(BeAnExistingFile())
+		})
+
+		It("does nothing when nothing has been cached", func() {
+			empty := &imageplugin.BlobCache{Path: filepath.Join(cacheDir, "empty")}
+			Expect(empty.Prune(lagertest.NewTestLogger("test"), time.Now())).To(Succeed())
+		})
+	})
+})
+
+var _ = Describe("BlobPruner", func() {
+	It("prunes the blobs which have not been fetched for MaxAge every Interval", func() {
+		cacheDir, err := ioutil.TempDir("", "blobs")
+		Expect(err).NotTo(HaveOccurred())
+		defer os.RemoveAll(cacheDir)
+
+		content := []byte("some-blob")
+		sum := sha256.Sum256(content)
+		path := filepath.Join(cacheDir, "sha256", hex.EncodeToString(sum[:]))
+		Expect(os.MkdirAll(filepath.Dir(path), 0700)).To(Succeed())
+		Expect(ioutil.WriteFile(path, content, 0600)).To(Succeed())
+
+		fakeClock := fakeclock.NewFakeClock(time.Now().Add(2 * time.Hour))
+		pruner := &imageplugin.BlobPruner{
+			Cache:    &imageplugin.BlobCache{Path: cacheDir},
+			MaxAge:   time.Hour,
+			Clock:    fakeClock,
+			Interval: time.Minute,
+			Logger:   lagertest.NewTestLogger("test"),
+		}
+
+		Expect(pruner.Start()).To(Succeed())
+		Expect(path).To(BeAnExistingFile())
+
+		fakeClock.WaitForWatcherAndIncrement(time.Minute)
+		Eventually(path).ShouldNot(BeAnExistingFile())
+	})
+})
@@ -0,0 +1,13 @@
+package imageplugin_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"testing"
+)
+
+func TestImageplugin(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Imageplugin Suite")
+}
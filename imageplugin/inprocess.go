@@ -0,0 +1,332 @@
+package imageplugin
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+
+	"github.com/cloudfoundry-incubator/garden-shed/rootfs_provider"
+	"github.com/cloudfoundry-incubator/guardian/gardener"
+	"github.com/pivotal-golang/lager"
+)
+
+// ImageConfig is the subset of an OCI image config used to create a rootfs
+type ImageConfig struct {
+	Config struct {
+		Env        []string `json:"Env"`
+		Entrypoint []string `json:"Entrypoint"`
+		Cmd        []string `json:"Cmd"`
+		User       string   `json:"User"`
+		WorkingDir string   `json:"WorkingDir"`
+	} `json:"config"`
+}
+
+var errFallbackMappingsNotSupported = errors.New("per-container id mappings are not supported by the fallback volume creator")
+
+// InProcessPlugin pulls docker:// rootfses directly from a registry, so that
+// no external image plugin is needed. Layers are cached by digest and unpacked
+// in to a fresh directory for each container.
+//
+// Other rootfses, and rootfses with a disk quota, are delegated to Fallback.
+type InProcessPlugin struct {
+	Registry    *RegistryClient
+	Blobs       *BlobCache
+	Unpacker    Unpacker
+	RootFSPath  string
+	UIDMappings rootfs_provider.MappingList
+	GIDMappings rootfs_provider.MappingList
+
+	Fallback gardener.VolumeCreator
+}
+
+func (p *InProcessPlugin) Create(log lager.Logger, handle string, spec rootfs_provider.Spec) (string, []string, error) {
+	if p.delegates(spec) {
+		return p.Fallback.Create(log, handle, spec)
+	}
+
+	return p.create(log, handle, spec, gardener.IDMappings{UID: p.UIDMappings, GID: p.GIDMappings})
+}
+
+// CreateMapped creates a rootfs for a container with its own id mappings.
+// Rootfses which would be delegated to Fallback can only be created if
+// Fallback also supports per-container mappings.
+func (p *InProcessPlugin) CreateMapped(log lager.Logger, handle string, spec rootfs_provider.Spec, mappings gardener.IDMappings) (string, []string, error) {
+	if p.delegates(spec) {
+		fallback, ok := p.Fallback.(gardener.MappingVolumeCreator)
+		if !ok {
+			return "", nil, errFallbackMappingsNotSupported
+		}
+
+		return fallback.CreateMapped(log, handle, spec, mappings)
+	}
+
+	return p.create(log, handle, spec, mappings)
+}
+
+// CreateLayered creates a rootfs on top of base layers already on the host.
+// Layers are only supported by Fallback, so rootfses with layers are always
+// delegated to it.
+func (p *InProcessPlugin) CreateLayered(log lager.Logger, handle string, spec rootfs_provider.Spec, mappings *gardener.IDMappings, layers []string) (string, []string, error) {
+	fallback, ok := p.Fallback.(gardener.LayeredVolumeCreator)
+	if !ok {
+		return "", nil, fmt.Errorf("base layers are only supported by external image plugins")
+	}
+
+	return fallback.CreateLayered(log, handle, spec, mappings, layers)
+}
+
+// ValidateVolume resolves a docker:// rootfs's image to its manifest, without
+// fetching its layers, and passes other rootfses to Fallback to validate, if
+// it can.
+func (p *InProcessPlugin) ValidateVolume(log lager.Logger, spec rootfs_provider.Spec, mappings *gardener.IDMappings, layers []string) error {
+	if len(layers) > 0 {
+		if _, ok := p.Fallback.(gardener.LayeredVolumeCreator); !ok {
+			return fmt.Errorf("base layers are only supported by external image plugins")
+		}
+	}
+
+	if len(layers) == 0 && mappings != nil && p.delegates(spec) {
+		if _, ok := p.Fallback.(gardener.MappingVolumeCreator); !ok {
+			return errFallbackMappingsNotSupported
+		}
+	}
+
+	if len(layers) > 0 || p.delegates(spec) {
+		if fallback, ok := p.Fallback.(gardener.VolumeValidator); ok {
+			return fallback.ValidateVolume(log, spec, mappings, layers)
+		}
+
+		return nil
+	}
+
+	log = log.Session("image-plugin-validate", lager.Data{"rootfs": spec.RootFS.String()})
+
+	ref, err := ParseImageURL(spec.RootFS, p.Registry.defaultRegistry())
+	if err != nil {
+		return err
+	}
+
+	if _, err := p.Registry.Manifest(log, ref); err != nil {
+		log.Info("fetch-manifest-failed", lager.Data{"error": err.Error()})
+		return fmt.Errorf("fetch manifest: %s", err)
+	}
+
+	return nil
+}
+
+func (p *InProcessPlugin) delegates(spec rootfs_provider.Spec) bool {
+	return spec.RootFS == nil || spec.RootFS.Scheme != "docker" || spec.QuotaSize > 0
+}
+
+func (p *InProcessPlugin) create(log lager.Logger, handle string, spec rootfs_provider.Spec, mappings gardener.IDMappings) (string, []string, error) {
+	log = log.Session("image-plugin-create", lager.Data{"handle": handle, "rootfs": spec.RootFS.String()})
+
+	log.Info("started")
+	defer log.Info("finished")
+
+	ref, err := ParseImageURL(spec.RootFS, p.Registry.defaultRegistry())
+	if err != nil {
+		return "", nil, err
+	}
+
+	manifest, err := p.Registry.Manifest(log, ref)
+	if err != nil {
+		log.Error("fetch-manifest-failed", err)
+		return "", nil, fmt.Errorf("fetch manifest: %s", err)
+	}
+
+	config, err := p.imageConfig(log, ref, manifest.Config.Digest)
+	if err != nil {
+		log.Error("fetch-config-failed", err)
+		return "", nil, fmt.Errorf("fetch image config: %s", err)
+	}
+
+	mapUID, mapGID := IDMapper(IdentityMapper), IDMapper(IdentityMapper)
+	if spec.Namespaced {
+		mapUID, mapGID = mappings.UID.Map, mappings.GID.Map
+	}
+
+	rootfs := filepath.Join(p.RootFSPath, handle)
+	if err := p.unpack(log, ref, manifest.Layers, rootfs, mapUID, mapGID); err != nil {
+		log.Error("unpack-failed", err)
+		if removeErr := os.RemoveAll(rootfs); removeErr != nil {
+			log.Error("cleanup-failed", removeErr)
+		}
+
+		return "", nil, err
+	}
+
+	if err := p.saveConfig(handle, config); err != nil {
+		log.Error("save-config-failed", err)
+		if removeErr := os.RemoveAll(rootfs); removeErr != nil {
+			log.Error("cleanup-failed", removeErr)
+		}
+
+		return "", nil, fmt.Errorf("save image config: %s", err)
+	}
+
+	return rootfs, config.Config.Env, nil
+}
+
+// ImageConfig returns the defaults from the config of the image a rootfs
+// was created from. Rootfses created by Fallback have a config only if it
+// also supports them.
+func (p *InProcessPlugin) ImageConfig(log lager.Logger, handle string) (gardener.ImageConfig, error) {
+	f, err := os.Open(p.configPath(handle))
+	if os.IsNotExist(err) {
+		if fallback, ok := p.Fallback.(gardener.ImageConfigVolumeCreator); ok {
+			return fallback.ImageConfig(log, handle)
+		}
+
+		return gardener.ImageConfig{}, nil
+	}
+
+	if err != nil {
+		return gardener.ImageConfig{}, err
+	}
+	defer f.Close()
+
+	var config ImageConfig
+	if err := json.NewDecoder(f).Decode(&config); err != nil {
+		return gardener.ImageConfig{}, err
+	}
+
+	return gardener.ImageConfig{
+		Entrypoint: config.Config.Entrypoint,
+		Cmd:        config.Config.Cmd,
+		User:       config.Config.User,
+		WorkingDir: config.Config.WorkingDir,
+	}, nil
+}
+
+func (p *InProcessPlugin) saveConfig(handle string, config ImageConfig) error {
+	f, err := os.Create(p.configPath(handle))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	return json.NewEncoder(f).Encode(config)
+}
+
+// configPath is beside, rather than in, the rootfs so that it is not
+// visible to the container
+func (p *InProcessPlugin) configPath(handle string) string {
+	return filepath.Join(p.RootFSPath, handle+".config.json")
+}
+
+func (p *InProcessPlugin) Destroy(log lager.Logger, handle string) error {
+	rootfs := filepath.Join(p.RootFSPath, handle)
+	if _, err := os.Stat(rootfs); err != nil {
+		return p.Fallback.Destroy(log, handle)
+	}
+
+	log = log.Session("image-plugin-destroy", lager.Data{"handle": handle})
+
+	log.Info("started")
+	defer log.Info("finished")
+
+	if err := os.RemoveAll(p.configPath(handle)); err != nil {
+		return err
+	}
+
+	return os.RemoveAll(rootfs)
+}
+
+func (p *InProcessPlugin) unpack(log lager.Logger, ref ImageRef, layers []Descriptor, rootfs string, mapUID, mapGID IDMapper) error {
+	if err := os.MkdirAll(rootfs, 0755); err != nil {
+		return err
+	}
+
+	if uid, gid := mapUID(0), mapGID(0); uid != 0 || gid != 0 {
+		if err := os.Chown(rootfs, uid, gid); err != nil {
+			return err
+		}
+	}
+
+	for _, layer := range layers {
+		path, err := p.fetch(log, ref, layer.Digest)
+		if err != nil {
+			return fmt.Errorf("fetch layer %s: %s", layer.Digest, err)
+		}
+
+		if err := p.Unpacker.Unpack(path, rootfs, mapUID, mapGID); err != nil {
+			return fmt.Errorf("layer %s: %s", layer.Digest, err)
+		}
+	}
+
+	return nil
+}
+
+// Pull fetches the manifest, config and layers of a docker:// rootfs's image
+// in to the blob cache without creating a rootfs, so that containers created
+// from the image later need not download anything. It returns the digests of
+// the image's config, which identifies the image, and of its layers.
+func (p *InProcessPlugin) Pull(log lager.Logger, rootfs *url.URL) (string, []string, error) {
+	log = log.Session("image-plugin-pull", lager.Data{"rootfs": rootfs.String()})
+
+	log.Info("started")
+	defer log.Info("finished")
+
+	if rootfs.Scheme != "docker" {
+		return "", nil, fmt.Errorf("only docker:// images can be pulled: %s", rootfs)
+	}
+
+	ref, err := ParseImageURL(rootfs, p.Registry.defaultRegistry())
+	if err != nil {
+		return "", nil, err
+	}
+
+	manifest, err := p.Registry.Manifest(log, ref)
+	if err != nil {
+		log.Error("fetch-manifest-failed", err)
+		return "", nil, fmt.Errorf("fetch manifest: %s", err)
+	}
+
+	if _, err := p.imageConfig(log, ref, manifest.Config.Digest); err != nil {
+		log.Error("fetch-config-failed", err)
+		return "", nil, fmt.Errorf("fetch image config: %s", err)
+	}
+
+	var layers []string
+	for _, layer := range manifest.Layers {
+		if _, err := p.fetch(log, ref, layer.Digest); err != nil {
+			log.Error("fetch-layer-failed", err)
+			return "", nil, fmt.Errorf("fetch layer %s: %s", layer.Digest, err)
+		}
+
+		layers = append(layers, layer.Digest)
+	}
+
+	return manifest.Config.Digest, layers, nil
+}
+
+func (p *InProcessPlugin) imageConfig(log lager.Logger, ref ImageRef, digest string) (ImageConfig, error) {
+	path, err := p.fetch(log, ref, digest)
+	if err != nil {
+		return ImageConfig{}, err
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return ImageConfig{}, err
+	}
+	defer f.Close()
+
+	var config ImageConfig
+	if err := json.NewDecoder(f).Decode(&config); err != nil {
+		return ImageConfig{}, err
+	}
+
+	return config, nil
+}
+
+func (p *InProcessPlugin) fetch(log lager.Logger, ref ImageRef, digest string) (string, error) {
+	return p.Blobs.Fetch(log, digest, func() (io.ReadCloser, error) {
+		return p.Registry.Blob(log, ref, digest)
+	})
+}
@@ -0,0 +1,340 @@
+package imageplugin_test
+
+import (
+	"archive/tar"
+	"errors"
+	"io/ioutil"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/cloudfoundry-incubator/garden-shed/rootfs_provider"
+	"github.com/cloudfoundry-incubator/guardian/gardener"
+	"github.com/cloudfoundry-incubator/guardian/gardener/fakes"
+	"github.com/cloudfoundry-incubator/guardian/imageplugin"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/pivotal-golang/lager"
+	"github.com/pivotal-golang/lager/lagertest"
+)
+
+type fakeMappingFallback struct {
+	*fakes.FakeVolumeCreator
+	*fakes.FakeMappingVolumeCreator
+}
+
+var _ = Describe("InProcessPlugin", func() {
+	var (
+		logger   lager.Logger
+		tmpDir   string
+		registry *fakeRegistry
+		server   *httptest.Server
+		fallback *fakes.FakeVolumeCreator
+		plugin   *imageplugin.InProcessPlugin
+		spec     rootfs_provider.Spec
+	)
+
+	BeforeEach(func() {
+		logger = lagertest.NewTestLogger("test")
+
+		var err error
+		tmpDir, err = ioutil.TempDir("", "inprocess")
+		Expect(err).NotTo(HaveOccurred())
+
+		registry = newFakeRegistry("some/image")
+		server = httptest.NewServer(registry)
+		host := strings.TrimPrefix(server.URL, "http://")
+
+		layerPath := filepath.Join(tmpDir, "layer.tar")
+		writeLayer(layerPath, true, layerEntry{Name: "hello", Type: tar.TypeReg, Content: "world"})
+		layer, err := ioutil.ReadFile(layerPath)
+		Expect(err).NotTo(HaveOccurred())
+
+		registry.Manifests["v1"] = map[string]interface{}{
+			"schemaVersion": 2,
+			"mediaType":     imageplugin.MediaTypeOCIManifest,
+			"config":        map[string]interface{}{"digest": registry.AddBlob([]byte(`{"config":{"Env":["PATH=/bin"],"Entrypoint":["/bin/app"],"Cmd":["--serve"],"User":"app","WorkingDir":"/srv"}}`))},
+			"layers": []map[string]interface{}{
+				{"digest": registry.AddBlob(layer)},
+			},
+		}
+
+		fallback = new(fakes.FakeVolumeCreator)
+		plugin = &imageplugin.InProcessPlugin{
+			Registry: &imageplugin.RegistryClient{
+				Insecure: []string{host},
+			},
+			Blobs:      &imageplugin.BlobCache{Path: filepath.Join(tmpDir, "blobs")},
+			RootFSPath: filepath.Join(tmpDir, "rootfs"),
+			Fallback:   fallback,
+		}
+
+		spec = rootfs_provider.Spec{RootFS: &url.URL{Scheme: "docker", Host: host, Path: "/some/image", Fragment: "v1"}}
+	})
+
+	AfterEach(func() {
+		server.Close()
+		Expect(os.RemoveAll(tmpDir)).To(Succeed())
+	})
+
+	Describe("Create", func() {
+		It("unpacks the image in to a rootfs for the container", func() {
+			path, env, err := plugin.Create(logger, "some-handle", spec)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(path).To(Equal(filepath.Join(tmpDir, "rootfs", "some-handle")))
+			Expect(ioutil.ReadFile(filepath.Join(path, "hello"))).To(Equal([]byte("world")))
+			Expect(env).To(Equal([]string{"PATH=/bin"}))
+			Expect(fallback.CreateCallCount()).To(Equal(0))
+		})
+
+		It("caches the layers", func() {
+			_, _, err := plugin.Create(logger, "some-handle", spec)
+			Expect(err).NotTo(HaveOccurred())
+			_, _, err = plugin.Create(logger, "another-handle", spec)
+			Expect(err).NotTo(HaveOccurred())
+
+			blobRequests := 0
+			for _, r := range registry.Requests {
+				if strings.Contains(r, "/blobs/") {
+					blobRequests++
+				}
+			}
+			Expect(blobRequests).To(Equal(2))
+		})
+
+		It("removes the rootfs when the image cannot be unpacked", func() {
+			for digest := range registry.Blobs {
+				if !strings.Contains(string(registry.Blobs[digest]), "Env") {
+					delete(registry.Blobs, digest)
+				}
+			}
+
+			_, _, err := plugin.Create(logger, "some-handle", spec)
+			Expect(err).To(HaveOccurred())
+			Expect(filepath.Join(tmpDir, "rootfs", "some-handle")).NotTo(BeADirectory())
+		})
+
+		It("delegates non-docker rootfses to the fallback", func() {
+			fallback.CreateReturns("/some/rootfs", []string{"A=B"}, nil)
+			spec.RootFS = &url.URL{Scheme: "raw", Path: "/some/rootfs"}
+
+			path, env, err := plugin.Create(logger, "some-handle", spec)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(path).To(Equal("/some/rootfs"))
+			Expect(env).To(Equal([]string{"A=B"}))
+			Expect(registry.Requests).To(BeEmpty())
+		})
+
+		It("delegates rootfses with a disk quota to the fallback", func() {
+			spec.QuotaSize = 1024
+
+			_, _, err := plugin.Create(logger, "some-handle", spec)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(fallback.CreateCallCount()).To(Equal(1))
+			Expect(registry.Requests).To(BeEmpty())
+		})
+	})
+
+	Describe("CreateMapped", func() {
+		It("unpacks docker images itself", func() {
+			path, _, err := plugin.CreateMapped(logger, "some-handle", spec, gardener.IDMappings{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(ioutil.ReadFile(filepath.Join(path, "hello"))).To(Equal([]byte("world")))
+		})
+
+		It("refuses rootfses which the fallback would create, when it does not support per-container mappings", func() {
+			spec.RootFS = &url.URL{Scheme: "raw", Path: "/some/rootfs"}
+
+			_, _, err := plugin.CreateMapped(logger, "some-handle", spec, gardener.IDMappings{})
+			Expect(err).To(MatchError("per-container id mappings are not supported by the fallback volume creator"))
+			Expect(fallback.CreateCallCount()).To(Equal(0))
+		})
+
+		It("passes the mappings of rootfses with a disk quota to a fallback which supports them", func() {
+			mappingFallback := fakeMappingFallback{fallback, new(fakes.FakeMappingVolumeCreator)}
+			mappingFallback.CreateMappedReturns("/path/to/rootfs", nil, nil)
+			plugin.Fallback = mappingFallback
+
+			spec.QuotaSize = 1024
+			mappings := gardener.IDMappings{
+				UID: rootfs_provider.MappingList{{ContainerID: 0, HostID: 100000, Size: 65536}},
+				GID: rootfs_provider.MappingList{{ContainerID: 0, HostID: 200000, Size: 65536}},
+			}
+
+			path, _, err := plugin.CreateMapped(logger, "some-handle", spec, mappings)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(path).To(Equal("/path/to/rootfs"))
+
+			Expect(mappingFallback.CreateMappedCallCount()).To(Equal(1))
+			_, handle, _, fallbackMappings := mappingFallback.CreateMappedArgsForCall(0)
+			Expect(handle).To(Equal("some-handle"))
+			Expect(fallbackMappings).To(Equal(mappings))
+			Expect(registry.Requests).To(BeEmpty())
+		})
+	})
+
+	Describe("ValidateVolume", func() {
+		It("resolves the image without fetching its layers", func() {
+			Expect(plugin.ValidateVolume(logger, spec, nil, nil)).To(Succeed())
+
+			for _, r := range registry.Requests {
+				Expect(r).NotTo(ContainSubstring("/blobs/"))
+			}
+			Expect(filepath.Join(tmpDir, "rootfs")).NotTo(BeADirectory())
+		})
+
+		It("fails when the image cannot be resolved", func() {
+			spec.RootFS.Fragment = "v2"
+
+			Expect(plugin.ValidateVolume(logger, spec, nil, nil)).To(MatchError(HavePrefix("fetch manifest:")))
+		})
+
+		It("does not validate rootfses which the fallback would create", func() {
+			spec.RootFS = &url.URL{Scheme: "raw", Path: "/some/rootfs"}
+
+			Expect(plugin.ValidateVolume(logger, spec, nil, nil)).To(Succeed())
+			Expect(registry.Requests).To(BeEmpty())
+		})
+
+		It("refuses what the fallback could not create", func() {
+			spec.RootFS = &url.URL{Scheme: "raw", Path: "/some/rootfs"}
+
+			Expect(plugin.ValidateVolume(logger, spec, &gardener.IDMappings{}, nil)).To(MatchError("per-container id mappings are not supported by the fallback volume creator"))
+			Expect(plugin.ValidateVolume(logger, spec, nil, []string{"docker:///base"})).To(MatchError("base layers are only supported by external image plugins"))
+		})
+	})
+
+	Describe("Pull", func() {
+		It("caches the image's blobs without creating a rootfs, so that creates need not download them", func() {
+			imageID, layers, err := plugin.Pull(logger, spec.RootFS)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(imageID).To(HavePrefix("sha256:"))
+			Expect(layers).To(HaveLen(1))
+			Expect(filepath.Join(tmpDir, "rootfs")).NotTo(BeADirectory())
+
+			registry.Requests = nil
+			_, _, err = plugin.Create(logger, "some-handle", spec)
+			Expect(err).NotTo(HaveOccurred())
+			for _, r := range registry.Requests {
+				Expect(r).NotTo(ContainSubstring("/blobs/"))
+			}
+		})
+
+		It("refuses rootfses which are not docker images", func() {
+			_, _, err := plugin.Pull(logger, &url.URL{Scheme: "raw", Path: "/some/rootfs"})
+			Expect(err).To(MatchError("only docker:// images can be pulled: raw:///some/rootfs"))
+		})
+	})
+
+	Describe("PersistentImages", func() {
+		var images *imageplugin.PersistentImages
+
+		BeforeEach(func() {
+			missing := *spec.RootFS
+			missing.Fragment = "v2"
+
+			images = &imageplugin.PersistentImages{
+				RootFSes: []string{spec.RootFS.String(), missing.String()},
+				Puller:   plugin,
+				Logger:   logger,
+			}
+		})
+
+		pulled := func() []imageplugin.PersistentImage {
+			Expect(images.Start()).To(Succeed())
+
+			Eventually(func() bool {
+				for _, image := range images.Images() {
+					if image.Pulling {
+						return false
+					}
+				}
+				return true
+			}).Should(BeTrue())
+
+			return images.Images()
+		}
+
+		It("pulls the images in the background on start and reports their digests, or why they could not be pulled", func() {
+			pulled := pulled()
+			Expect(pulled).To(HaveLen(2))
+			Expect(pulled[0].RootFS).To(Equal(spec.RootFS.String()))
+			Expect(pulled[0].ImageID).To(HavePrefix("sha256:"))
+			Expect(pulled[0].Error).To(BeEmpty())
+			Expect(pulled[1].ImageID).To(BeEmpty())
+			Expect(pulled[1].Error).To(HavePrefix("fetch manifest:"))
+		})
+
+		It("reports the images as being pulled until they have been", func() {
+			registry.Delay = make(chan struct{})
+			defer close(registry.Delay)
+
+			Expect(images.Start()).To(Succeed())
+			Expect(images.Images()).To(HaveLen(2))
+			Expect(images.Images()[0].Pulling).To(BeTrue())
+		})
+
+		It("pins the pulled images' blobs, so that they are not pruned", func() {
+			Expect(pulled()[0].Error).To(BeEmpty())
+
+			Expect(plugin.Blobs.Prune(logger, time.Now().Add(time.Hour))).To(Succeed())
+
+			registry.Requests = nil
+			_, _, err := plugin.Create(logger, "some-handle", spec)
+			Expect(err).NotTo(HaveOccurred())
+			for _, r := range registry.Requests {
+				Expect(r).NotTo(ContainSubstring("/blobs/"))
+			}
+		})
+	})
+
+	Describe("ImageConfig", func() {
+		It("returns the defaults from the config of the image", func() {
+			_, _, err := plugin.Create(logger, "some-handle", spec)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(plugin.ImageConfig(logger, "some-handle")).To(Equal(gardener.ImageConfig{
+				Entrypoint: []string{"/bin/app"},
+				Cmd:        []string{"--serve"},
+				User:       "app",
+				WorkingDir: "/srv",
+			}))
+		})
+
+		It("does not put the config in the rootfs", func() {
+			path, _, err := plugin.Create(logger, "some-handle", spec)
+			Expect(err).NotTo(HaveOccurred())
+
+			files, err := ioutil.ReadDir(path)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(files).To(HaveLen(1))
+		})
+
+		It("returns no defaults for rootfses created by a fallback which has no image configs", func() {
+			Expect(plugin.ImageConfig(logger, "some-handle")).To(Equal(gardener.ImageConfig{}))
+		})
+	})
+
+	Describe("Destroy", func() {
+		It("removes rootfses it created", func() {
+			path, _, err := plugin.Create(logger, "some-handle", spec)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(plugin.Destroy(logger, "some-handle")).To(Succeed())
+			Expect(path).NotTo(BeADirectory())
+			Expect(plugin.ImageConfig(logger, "some-handle")).To(Equal(gardener.ImageConfig{}))
+			Expect(fallback.DestroyCallCount()).To(Equal(0))
+		})
+
+		It("delegates other rootfses to the fallback", func() {
+			fallback.DestroyReturns(errors.New("boom"))
+
+			Expect(plugin.Destroy(logger, "some-handle")).To(MatchError("boom"))
+			Expect(fallback.DestroyCallCount()).To(Equal(1))
+		})
+	})
+})
@@ -0,0 +1,7 @@
+package imageplugin
+
+import "errors"
+
+func mknod(path string, mode uint32, dev int) error {
+	return errors.New("device files and fifos cannot be created on windows")
+}
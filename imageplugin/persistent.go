@@ -0,0 +1,108 @@
+package imageplugin
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"sync"
+
+	"github.com/pivotal-golang/lager"
+)
+
+// PersistentImage is an image pulled at start by PersistentImages
+type PersistentImage struct {
+	RootFS string `json:"rootfs"`
+
+	// Pulling is true until the image has been pulled, or has failed to be
+	Pulling bool `json:"pulling,omitempty"`
+
+	// ImageID is the digest of the image's config, and Layers the digests of
+	// its layers; both are empty until the image has been pulled
+	ImageID string   `json:"image_id,omitempty"`
+	Layers  []string `json:"layers,omitempty"`
+
+	// Error is why the image could not be pulled, if it could not
+	Error string `json:"error,omitempty"`
+}
+
+// PersistentImages pulls the operator's base images in to the blob cache in
+// the background on Start, so that the first containers created from them
+// after a cell is rebuilt are as fast to create as later ones, without
+// holding up guardian's start on the registry. Pulled images' blobs are
+// pinned in the cache, so that they are never pruned. Images which cannot be
+// pulled are logged and reported, but do not stop guardian from starting,
+// since the registry may only be briefly unavailable.
+type PersistentImages struct {
+	RootFSes []string
+	Puller   *InProcessPlugin
+	Logger   lager.Logger
+
+	mu     sync.Mutex
+	images []PersistentImage
+}
+
+func (p *PersistentImages) Start() error {
+	p.mu.Lock()
+	for _, rootfs := range p.RootFSes {
+		p.images = append(p.images, PersistentImage{RootFS: rootfs, Pulling: true})
+	}
+	p.mu.Unlock()
+
+	go p.pull()
+
+	return nil
+}
+
+func (p *PersistentImages) pull() {
+	log := p.Logger.Session("pull-persistent-images")
+
+	log.Info("started")
+	defer log.Info("finished")
+
+	for i, rootfs := range p.RootFSes {
+		image := PersistentImage{RootFS: rootfs}
+
+		u, err := url.Parse(rootfs)
+		if err == nil {
+			image.ImageID, image.Layers, err = p.Puller.Pull(log, u)
+		}
+
+		if err != nil {
+			log.Error("pull-failed", err, lager.Data{"rootfs": rootfs})
+			image.Error = err.Error()
+		} else {
+			p.Puller.Blobs.Pin(append([]string{image.ImageID}, image.Layers...)...)
+		}
+
+		p.mu.Lock()
+		p.images[i] = image
+		p.mu.Unlock()
+	}
+}
+
+// Images returns the images being pulled, and which have been pulled or
+// failed to be
+func (p *PersistentImages) Images() []PersistentImage {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	images := make([]PersistentImage, len(p.images))
+	copy(images, p.images)
+	return images
+}
+
+// PersistentImagesHandler serves the persistent images and their digests as
+// JSON
+type PersistentImagesHandler struct {
+	Images *PersistentImages
+}
+
+func (h *PersistentImagesHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string][]PersistentImage{"images": h.Images.Images()})
+}
@@ -0,0 +1,141 @@
+package imageplugin
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/cloudfoundry-incubator/garden-shed/rootfs_provider"
+	"github.com/cloudfoundry-incubator/guardian/gardener"
+	"github.com/cloudfoundry/gunk/command_runner"
+	"github.com/pivotal-golang/lager"
+)
+
+// ExternalPlugin creates rootfses by running an image plugin binary (such as
+// grootfs) which takes 'create' and 'delete' commands. Plugins are run with
+// positional args unless Negotiate finds they support ProtocolV2.
+type ExternalPlugin struct {
+	Binary        string
+	ExtraArgs     []string
+	CommandRunner command_runner.CommandRunner
+	UIDMappings   rootfs_provider.MappingList
+	GIDMappings   rootfs_provider.MappingList
+
+	// Readiness is waited for before creating rootfses (optional)
+	Readiness *ReadinessProbe
+
+	// NegotiateProtocol has the protocol negotiated before the plugin is
+	// first used, once it is ready, rather than with an explicit Negotiate
+	NegotiateProtocol bool
+
+	// Capabilities are those negotiated with a ProtocolV2 plugin; nil for
+	// plugins which only support v1
+	Capabilities *Capabilities
+
+	negotiateMu     sync.Mutex
+	protocolDecided bool
+}
+
+func (p *ExternalPlugin) Create(log lager.Logger, handle string, spec rootfs_provider.Spec) (string, []string, error) {
+	return p.CreateMapped(log, handle, spec, gardener.IDMappings{UID: p.UIDMappings, GID: p.GIDMappings})
+}
+
+// CreateMapped creates a rootfs for a container with its own id mappings.
+func (p *ExternalPlugin) CreateMapped(log lager.Logger, handle string, spec rootfs_provider.Spec, mappings gardener.IDMappings) (string, []string, error) {
+	return p.create(log, handle, spec, mappings, nil)
+}
+
+// CreateLayered creates a rootfs on top of base layers already on the host,
+// which are passed to the plugin, lowest first, with --layer
+func (p *ExternalPlugin) CreateLayered(log lager.Logger, handle string, spec rootfs_provider.Spec, mappings *gardener.IDMappings, layers []string) (string, []string, error) {
+	if mappings == nil {
+		mappings = &gardener.IDMappings{UID: p.UIDMappings, GID: p.GIDMappings}
+	}
+
+	return p.create(log, handle, spec, *mappings, layers)
+}
+
+func (p *ExternalPlugin) create(log lager.Logger, handle string, spec rootfs_provider.Spec, mappings gardener.IDMappings, layers []string) (string, []string, error) {
+	log = log.Session("image-plugin-create", lager.Data{"handle": handle, "rootfs": spec.RootFS.String(), "layers": layers})
+
+	log.Info("started")
+	defer log.Info("finished")
+
+	if err := p.Readiness.Wait(log); err != nil {
+		return "", nil, err
+	}
+
+	if err := p.negotiated(log); err != nil {
+		return "", nil, err
+	}
+
+	if p.Capabilities != nil {
+		return p.createV2(log, handle, spec, mappings, layers)
+	}
+
+	args := []string{"create"}
+	if spec.Namespaced {
+		for _, m := range mappings.UID {
+			args = append(args, "--uid-mapping", fmt.Sprintf("%d:%d:%d", m.ContainerID, m.HostID, m.Size))
+		}
+
+		for _, m := range mappings.GID {
+			args = append(args, "--gid-mapping", fmt.Sprintf("%d:%d:%d", m.ContainerID, m.HostID, m.Size))
+		}
+	}
+
+	if spec.QuotaSize > 0 {
+		args = append(args, "--disk-limit-size-bytes", strconv.FormatInt(spec.QuotaSize, 10))
+
+		if spec.QuotaScope == "exclusive" {
+			args = append(args, "--exclude-image-from-quota")
+		}
+	}
+
+	for _, layer := range layers {
+		args = append(args, "--layer", layer)
+	}
+
+	output, err := p.run(log, append(args, spec.RootFS.String(), handle)...)
+	if err != nil {
+		return "", nil, err
+	}
+
+	return strings.TrimSpace(output), nil, nil
+}
+
+func (p *ExternalPlugin) Destroy(log lager.Logger, handle string) error {
+	log = log.Session("image-plugin-destroy", lager.Data{"handle": handle})
+
+	log.Info("started")
+	defer log.Info("finished")
+
+	if err := p.negotiated(log); err != nil {
+		return err
+	}
+
+	if p.Capabilities != nil {
+		return p.runV2(log, "delete", DeleteRequest{Handle: handle}, nil)
+	}
+
+	_, err := p.run(log, "delete", handle)
+	return err
+}
+
+func (p *ExternalPlugin) run(log lager.Logger, args ...string) (string, error) {
+	stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+
+	cmd := exec.Command(p.Binary, append(append([]string{}, p.ExtraArgs...), args...)...)
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+
+	if err := p.CommandRunner.Run(cmd); err != nil {
+		log.Error("image-plugin-failed", err, lager.Data{"stderr": stderr.String()})
+		return "", fmt.Errorf("image plugin %s: %s: %s", args[0], err, strings.TrimSpace(stderr.String()))
+	}
+
+	return stdout.String(), nil
+}
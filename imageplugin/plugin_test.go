@@ -0,0 +1,156 @@
+package imageplugin_test
+
+import (
+	"errors"
+	"net/url"
+	"os/exec"
+
+	"github.com/cloudfoundry-incubator/garden-shed/rootfs_provider"
+	"github.com/cloudfoundry-incubator/guardian/gardener"
+	"github.com/cloudfoundry-incubator/guardian/imageplugin"
+	"github.com/cloudfoundry/gunk/command_runner/fake_command_runner"
+	. "github.com/cloudfoundry/gunk/command_runner/fake_command_runner/matchers"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/pivotal-golang/lager/lagertest"
+)
+
+var _ = Describe("ExternalPlugin", func() {
+	var (
+		commandRunner *fake_command_runner.FakeCommandRunner
+		plugin        *imageplugin.ExternalPlugin
+		spec          rootfs_provider.Spec
+	)
+
+	BeforeEach(func() {
+		commandRunner = fake_command_runner.New()
+		plugin = &imageplugin.ExternalPlugin{
+			Binary:        "/path/to/grootfs",
+			ExtraArgs:     []string{"--store", "/var/store"},
+			CommandRunner: commandRunner,
+			UIDMappings: rootfs_provider.MappingList{
+				{ContainerID: 0, HostID: 4294967294, Size: 1},
+				{ContainerID: 1, HostID: 1, Size: 4294967293},
+			},
+			GIDMappings: rootfs_provider.MappingList{
+				{ContainerID: 0, HostID: 4294967294, Size: 1},
+				{ContainerID: 1, HostID: 1, Size: 4294967293},
+			},
+		}
+
+		rootfs, err := url.Parse("docker:///busybox")
+		Expect(err).NotTo(HaveOccurred())
+		spec = rootfs_provider.Spec{RootFS: rootfs}
+
+		commandRunner.WhenRunning(fake_command_runner.CommandSpec{Path: "/path/to/grootfs"}, func(cmd *exec.Cmd) error {
+			cmd.Stdout.Write([]byte("/var/store/images/some-handle/rootfs\n"))
+			return nil
+		})
+	})
+
+	Describe("Create", func() {
+		It("runs the plugin's create command and returns the rootfs path it prints", func() {
+			path, env, err := plugin.Create(lagertest.NewTestLogger("test"), "some-handle", spec)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(path).To(Equal("/var/store/images/some-handle/rootfs"))
+			Expect(env).To(BeEmpty())
+
+			Expect(commandRunner).To(HaveExecutedSerially(fake_command_runner.CommandSpec{
+				Path: "/path/to/grootfs",
+				Args: []string{"--store", "/var/store", "create", "docker:///busybox", "some-handle"},
+			}))
+		})
+
+		It("passes the id mappings for unprivileged containers", func() {
+			spec.Namespaced = true
+
+			_, _, err := plugin.Create(lagertest.NewTestLogger("test"), "some-handle", spec)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(commandRunner).To(HaveExecutedSerially(fake_command_runner.CommandSpec{
+				Path: "/path/to/grootfs",
+				Args: []string{
+					"--store", "/var/store", "create",
+					"--uid-mapping", "0:4294967294:1", "--uid-mapping", "1:1:4294967293",
+					"--gid-mapping", "0:4294967294:1", "--gid-mapping", "1:1:4294967293",
+					"docker:///busybox", "some-handle",
+				},
+			}))
+		})
+
+		It("passes the container's own id mappings when it has them", func() {
+			spec.Namespaced = true
+
+			_, _, err := plugin.CreateMapped(lagertest.NewTestLogger("test"), "some-handle", spec, gardener.IDMappings{
+				UID: rootfs_provider.MappingList{{ContainerID: 0, HostID: 100000, Size: 65536}},
+				GID: rootfs_provider.MappingList{{ContainerID: 0, HostID: 200000, Size: 65536}},
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(commandRunner).To(HaveExecutedSerially(fake_command_runner.CommandSpec{
+				Path: "/path/to/grootfs",
+				Args: []string{
+					"--store", "/var/store", "create",
+					"--uid-mapping", "0:100000:65536", "--gid-mapping", "0:200000:65536",
+					"docker:///busybox", "some-handle",
+				},
+			}))
+		})
+
+		It("passes the disk limit", func() {
+			spec.QuotaSize = 1024
+			spec.QuotaScope = "exclusive"
+
+			_, _, err := plugin.Create(lagertest.NewTestLogger("test"), "some-handle", spec)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(commandRunner).To(HaveExecutedSerially(fake_command_runner.CommandSpec{
+				Path: "/path/to/grootfs",
+				Args: []string{
+					"--store", "/var/store", "create",
+					"--disk-limit-size-bytes", "1024", "--exclude-image-from-quota",
+					"docker:///busybox", "some-handle",
+				},
+			}))
+		})
+
+		It("passes base layers, lowest first", func() {
+			spec.Namespaced = true
+
+			_, _, err := plugin.CreateLayered(lagertest.NewTestLogger("test"), "some-handle", spec, nil, []string{"/layers/base", "/layers/runtime"})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(commandRunner).To(HaveExecutedSerially(fake_command_runner.CommandSpec{
+				Path: "/path/to/grootfs",
+				Args: []string{
+					"--store", "/var/store", "create",
+					"--uid-mapping", "0:4294967294:1", "--uid-mapping", "1:1:4294967293",
+					"--gid-mapping", "0:4294967294:1", "--gid-mapping", "1:1:4294967293",
+					"--layer", "/layers/base", "--layer", "/layers/runtime",
+					"docker:///busybox", "some-handle",
+				},
+			}))
+		})
+
+		It("returns the plugin's stderr when it fails", func() {
+			commandRunner.WhenRunning(fake_command_runner.CommandSpec{Path: "/path/to/grootfs"}, func(cmd *exec.Cmd) error {
+				cmd.Stderr.Write([]byte("image not found\n"))
+				return errors.New("exit status 1")
+			})
+
+			_, _, err := plugin.Create(lagertest.NewTestLogger("test"), "some-handle", spec)
+			Expect(err).To(MatchError("image plugin create: exit status 1: image not found"))
+		})
+	})
+
+	Describe("Destroy", func() {
+		It("runs the plugin's delete command", func() {
+			Expect(plugin.Destroy(lagertest.NewTestLogger("test"), "some-handle")).To(Succeed())
+
+			Expect(commandRunner).To(HaveExecutedSerially(fake_command_runner.CommandSpec{
+				Path: "/path/to/grootfs",
+				Args: []string{"--store", "/var/store", "delete", "some-handle"},
+			}))
+		})
+	})
+})
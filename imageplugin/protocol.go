@@ -0,0 +1,313 @@
+package imageplugin
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"strings"
+
+	"github.com/cloudfoundry-incubator/garden-shed/rootfs_provider"
+	"github.com/cloudfoundry-incubator/guardian/gardener"
+	"github.com/pivotal-golang/lager"
+)
+
+// ProtocolV2 is the version of the image plugin protocol in which guardian
+// writes a JSON request to the plugin's stdin and the plugin writes a JSON
+// response to its stdout, rather than passing everything as args:
+//
+//	<plugin> [extra args] capabilities
+//	  response: {"protocol_version": 2, "features": ["id-mappings", ...]}
+//	<plugin> [extra args] create
+//	  request:  {"handle": ..., "rootfs": ..., "uid_mappings": [...], ...}
+//	  response: {"rootfs_path": ..., "env": [...]}
+//	<plugin> [extra args] delete
+//	  request:  {"handle": ...}
+//	<plugin> [extra args] stats
+//	  request:  {"handle": ...}
+//	  response: {"disk_usage": {"total_bytes_used": ..., "exclusive_bytes_used": ...},
+//	             "quota_bytes": ..., "quota_exceeded": false}
+//
+// Plugins whose capabilities command exits with an error status, e.g. because
+// they do not know it, are run with the original positional args protocol.
+const ProtocolV2 = 2
+
+// The features a plugin may advertise. Guardian refuses to create rootfses
+// which need a feature the plugin lacks, rather than silently ignoring it.
+const (
+	FeatureIDMappings = "id-mappings"
+	FeatureDiskLimit  = "disk-limit"
+	FeatureLayers     = "layers"
+	FeatureStats      = "stats"
+)
+
+type Capabilities struct {
+	ProtocolVersion int      `json:"protocol_version"`
+	Features        []string `json:"features"`
+}
+
+func (c Capabilities) Supports(feature string) bool {
+	for _, f := range c.Features {
+		if f == feature {
+			return true
+		}
+	}
+
+	return false
+}
+
+type Mapping struct {
+	ContainerID int `json:"container_id"`
+	HostID      int `json:"host_id"`
+	Size        int `json:"size"`
+}
+
+type DiskLimit struct {
+	SizeBytes    int64 `json:"size_bytes"`
+	ExcludeImage bool  `json:"exclude_image"`
+}
+
+type CreateRequest struct {
+	Handle      string     `json:"handle"`
+	RootFS      string     `json:"rootfs"`
+	UIDMappings []Mapping  `json:"uid_mappings,omitempty"`
+	GIDMappings []Mapping  `json:"gid_mappings,omitempty"`
+	DiskLimit   *DiskLimit `json:"disk_limit,omitempty"`
+	Layers      []string   `json:"layers,omitempty"`
+}
+
+type CreateResponse struct {
+	RootFSPath string   `json:"rootfs_path"`
+	Env        []string `json:"env"`
+}
+
+type DeleteRequest struct {
+	Handle string `json:"handle"`
+}
+
+type StatsRequest struct {
+	Handle string `json:"handle"`
+}
+
+type DiskUsageStats struct {
+	TotalBytesUsed     uint64 `json:"total_bytes_used"`
+	ExclusiveBytesUsed uint64 `json:"exclusive_bytes_used"`
+}
+
+type StatsResponse struct {
+	DiskUsage     DiskUsageStats `json:"disk_usage"`
+	QuotaBytes    uint64         `json:"quota_bytes"`
+	QuotaExceeded bool           `json:"quota_exceeded"`
+}
+
+// Negotiate asks the plugin which protocol version and features it supports,
+// once it is ready, and uses v2 if the plugin supports it. Plugins which are
+// never negotiated with use v1, as do those whose capabilities command exits
+// with an error status. Other failures, e.g. to run the plugin at all, are
+// returned without deciding the protocol, so that it is negotiated again.
+func (p *ExternalPlugin) Negotiate(log lager.Logger) error {
+	p.negotiateMu.Lock()
+	defer p.negotiateMu.Unlock()
+
+	return p.negotiate(log)
+}
+
+// negotiated negotiates with a plugin whose protocol has not been decided,
+// if it is to be negotiated on first use
+func (p *ExternalPlugin) negotiated(log lager.Logger) error {
+	if !p.NegotiateProtocol {
+		return nil
+	}
+
+	p.negotiateMu.Lock()
+	defer p.negotiateMu.Unlock()
+
+	if p.protocolDecided {
+		return nil
+	}
+
+	return p.negotiate(log)
+}
+
+func (p *ExternalPlugin) negotiate(log lager.Logger) error {
+	log = log.Session("image-plugin-negotiate")
+
+	if err := p.Readiness.Wait(log); err != nil {
+		return err
+	}
+
+	stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+
+	cmd := exec.Command(p.Binary, append(append([]string{}, p.ExtraArgs...), "capabilities")...)
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+
+	if err := p.CommandRunner.Run(cmd); err != nil {
+		if exitErr, ok := err.(*exec.ExitError); !ok || !exitErr.Exited() {
+			log.Error("capabilities-failed", err, lager.Data{"stderr": strings.TrimSpace(stderr.String())})
+			return fmt.Errorf("image plugin capabilities: %s: %s", err, strings.TrimSpace(stderr.String()))
+		}
+
+		log.Info("falling-back-to-v1", lager.Data{"error": err.Error(), "stderr": strings.TrimSpace(stderr.String())})
+		p.Capabilities, p.protocolDecided = nil, true
+		return nil
+	}
+
+	var capabilities Capabilities
+	if err := json.Unmarshal(stdout.Bytes(), &capabilities); err != nil {
+		return fmt.Errorf("image plugin capabilities: invalid response: %s", err)
+	}
+
+	p.protocolDecided = true
+	if capabilities.ProtocolVersion < ProtocolV2 {
+		log.Info("falling-back-to-v1", lager.Data{"protocol-version": capabilities.ProtocolVersion})
+		p.Capabilities = nil
+		return nil
+	}
+
+	log.Info("negotiated", lager.Data{"protocol-version": capabilities.ProtocolVersion, "features": capabilities.Features})
+	p.Capabilities = &capabilities
+	return nil
+}
+
+func (p *ExternalPlugin) createV2(log lager.Logger, handle string, spec rootfs_provider.Spec, mappings gardener.IDMappings, layers []string) (string, []string, error) {
+	request := createRequest(handle, spec, mappings, layers)
+	if err := p.checkFeatures(request); err != nil {
+		return "", nil, err
+	}
+
+	var response CreateResponse
+	if err := p.runV2(log, "create", request, &response); err != nil {
+		return "", nil, err
+	}
+
+	if response.RootFSPath == "" {
+		return "", nil, fmt.Errorf("image plugin create: response has no rootfs_path")
+	}
+
+	return response.RootFSPath, response.Env, nil
+}
+
+// ValidateVolume checks that a ProtocolV2 plugin has the features creating
+// the rootfs needs. Plugins have no way to resolve an image without creating
+// a rootfs from it, so the image itself is not checked.
+func (p *ExternalPlugin) ValidateVolume(log lager.Logger, spec rootfs_provider.Spec, mappings *gardener.IDMappings, layers []string) error {
+	if err := p.negotiated(log); err != nil {
+		return err
+	}
+
+	if p.Capabilities == nil {
+		return nil
+	}
+
+	if mappings == nil {
+		mappings = &gardener.IDMappings{UID: p.UIDMappings, GID: p.GIDMappings}
+	}
+
+	return p.checkFeatures(createRequest("", spec, *mappings, layers))
+}
+
+func createRequest(handle string, spec rootfs_provider.Spec, mappings gardener.IDMappings, layers []string) CreateRequest {
+	request := CreateRequest{
+		Handle: handle,
+		RootFS: spec.RootFS.String(),
+		Layers: layers,
+	}
+
+	if spec.Namespaced {
+		request.UIDMappings = toMappings(mappings.UID)
+		request.GIDMappings = toMappings(mappings.GID)
+	}
+
+	if spec.QuotaSize > 0 {
+		request.DiskLimit = &DiskLimit{
+			SizeBytes:    spec.QuotaSize,
+			ExcludeImage: spec.QuotaScope == "exclusive",
+		}
+	}
+
+	return request
+}
+
+func (p *ExternalPlugin) checkFeatures(request CreateRequest) error {
+	required := map[string]bool{
+		FeatureIDMappings: len(request.UIDMappings) > 0 || len(request.GIDMappings) > 0,
+		FeatureDiskLimit:  request.DiskLimit != nil,
+		FeatureLayers:     len(request.Layers) > 0,
+	}
+
+	for _, feature := range []string{FeatureIDMappings, FeatureDiskLimit, FeatureLayers} {
+		if required[feature] && !p.Capabilities.Supports(feature) {
+			return fmt.Errorf("image plugin create: the plugin does not support the '%s' feature", feature)
+		}
+	}
+
+	return nil
+}
+
+// DiskUsage asks a plugin with the stats feature how much disk the
+// container's rootfs uses, and whether it has exceeded its quota
+func (p *ExternalPlugin) DiskUsage(log lager.Logger, handle string) (gardener.DiskUsage, error) {
+	if err := p.negotiated(log); err != nil {
+		return gardener.DiskUsage{}, err
+	}
+
+	if p.Capabilities == nil || !p.Capabilities.Supports(FeatureStats) {
+		return gardener.DiskUsage{}, gardener.ErrDiskUsageNotSupported
+	}
+
+	log = log.Session("image-plugin-stats", lager.Data{"handle": handle})
+
+	var response StatsResponse
+	if err := p.runV2(log, "stats", StatsRequest{Handle: handle}, &response); err != nil {
+		return gardener.DiskUsage{}, err
+	}
+
+	return gardener.DiskUsage{
+		TotalBytesUsed:     response.DiskUsage.TotalBytesUsed,
+		ExclusiveBytesUsed: response.DiskUsage.ExclusiveBytesUsed,
+		QuotaBytes:         response.QuotaBytes,
+		QuotaExceeded:      response.QuotaExceeded,
+	}, nil
+}
+
+// runV2 runs a v2 command with the request as JSON on stdin, decoding its
+// stdout in to response unless response is nil
+func (p *ExternalPlugin) runV2(log lager.Logger, command string, request, response interface{}) error {
+	stdin, err := json.Marshal(request)
+	if err != nil {
+		return fmt.Errorf("image plugin %s: %s", command, err)
+	}
+
+	stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+
+	cmd := exec.Command(p.Binary, append(append([]string{}, p.ExtraArgs...), command)...)
+	cmd.Stdin = bytes.NewReader(stdin)
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+
+	if err := p.CommandRunner.Run(cmd); err != nil {
+		log.Error("image-plugin-failed", err, lager.Data{"stderr": stderr.String()})
+		return fmt.Errorf("image plugin %s: %s: %s", command, err, strings.TrimSpace(stderr.String()))
+	}
+
+	if response == nil {
+		return nil
+	}
+
+	if err := json.Unmarshal(stdout.Bytes(), response); err != nil {
+		log.Error("invalid-response", err, lager.Data{"stdout": stdout.String()})
+		return fmt.Errorf("image plugin %s: invalid response: %s", command, err)
+	}
+
+	return nil
+}
+
+func toMappings(mappings rootfs_provider.MappingList) []Mapping {
+	var result []Mapping
+	for _, m := range mappings {
+		result = append(result, Mapping{ContainerID: m.ContainerID, HostID: m.HostID, Size: m.Size})
+	}
+
+	return result
+}
@@ -0,0 +1,310 @@
+package imageplugin_test
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/url"
+	"os/exec"
+	"time"
+
+	"github.com/cloudfoundry-incubator/garden-shed/rootfs_provider"
+	"github.com/cloudfoundry-incubator/guardian/gardener"
+	"github.com/cloudfoundry-incubator/guardian/imageplugin"
+	"github.com/cloudfoundry/gunk/command_runner/fake_command_runner"
+	. "github.com/cloudfoundry/gunk/command_runner/fake_command_runner/matchers"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/pivotal-golang/clock/fakeclock"
+	"github.com/pivotal-golang/lager/lagertest"
+)
+
+var _ = Describe("ExternalPlugin protocol v2", func() {
+	var (
+		commandRunner *fake_command_runner.FakeCommandRunner
+		plugin        *imageplugin.ExternalPlugin
+		spec          rootfs_provider.Spec
+		capabilities  string
+		requests      map[string][]byte
+	)
+
+	BeforeEach(func() {
+		commandRunner = fake_command_runner.New()
+		plugin = &imageplugin.ExternalPlugin{
+			Binary:        "/path/to/plugin",
+			ExtraArgs:     []string{"--store", "/var/store"},
+			CommandRunner: commandRunner,
+			UIDMappings:   rootfs_provider.MappingList{{ContainerID: 0, HostID: 4294967294, Size: 1}},
+			GIDMappings:   rootfs_provider.MappingList{{ContainerID: 0, HostID: 4294967294, Size: 1}},
+		}
+
+		rootfs, err := url.Parse("docker:///busybox")
+		Expect(err).NotTo(HaveOccurred())
+		spec = rootfs_provider.Spec{RootFS: rootfs}
+
+		capabilities = `{"protocol_version": 2, "features": ["id-mappings", "disk-limit"]}`
+		requests = make(map[string][]byte)
+
+		commandRunner.WhenRunning(fake_command_runner.CommandSpec{
+			Path: "/path/to/plugin",
+			Args: []string{"--store", "/var/store", "capabilities"},
+		}, func(cmd *exec.Cmd) error {
+			cmd.Stdout.Write([]byte(capabilities))
+			return nil
+		})
+
+		for _, command := range []string{"create", "delete"} {
+			command := command
+			commandRunner.WhenRunning(fake_command_runner.CommandSpec{
+				Path: "/path/to/plugin",
+				Args: []string{"--store", "/var/store", command},
+			}, func(cmd *exec.Cmd) error {
+				request, err := ioutil.ReadAll(cmd.Stdin)
+				Expect(err).NotTo(HaveOccurred())
+				requests[command] = request
+
+				if command == "create" {
+					cmd.Stdout.Write([]byte(`{"rootfs_path": "/var/store/images/some-handle/rootfs", "env": ["PATH=/bin"]}`))
+				}
+				return nil
+			})
+		}
+	})
+
+	Describe("Negotiate", func() {
+		It("uses v2 when the plugin supports it", func() {
+			Expect(plugin.Negotiate(lagertest.NewTestLogger("test"))).To(Succeed())
+			Expect(plugin.Capabilities).To(Equal(&imageplugin.Capabilities{
+				ProtocolVersion: 2,
+				Features:        []string{"id-mappings", "disk-limit"},
+			}))
+		})
+
+		It("falls back to v1 when the plugin does not know the capabilities command", func() {
+			commandRunner.WhenRunning(fake_command_runner.CommandSpec{
+				Path: "/path/to/plugin",
+				Args: []string{"--store", "/var/store", "capabilities"},
+			}, func(cmd *exec.Cmd) error {
+				return exec.Command("false").Run()
+			})
+
+			Expect(plugin.Negotiate(lagertest.NewTestLogger("test"))).To(Succeed())
+			Expect(plugin.Capabilities).To(BeNil())
+		})
+
+		It("fails, rather than falling back to v1, when the plugin cannot be run", func() {
+			commandRunner.WhenRunning(fake_command_runner.CommandSpec{
+				Path: "/path/to/plugin",
+				Args: []string{"--store", "/var/store", "capabilities"},
+			}, func(cmd *exec.Cmd) error {
+				return errors.New("fork/exec /path/to/plugin: permission denied")
+			})
+
+			Expect(plugin.Negotiate(lagertest.NewTestLogger("test"))).To(MatchError(ContainSubstring("image plugin capabilities: fork/exec /path/to/plugin: permission denied")))
+		})
+
+		It("waits for the plugin to be ready first", func() {
+			plugin.Readiness = &imageplugin.ReadinessProbe{
+				Binary:        "/path/to/plugin",
+				Args:          []string{"--store", "/var/store", "ready"},
+				CommandRunner: commandRunner,
+				Clock:         fakeclock.NewFakeClock(time.Now()),
+				Interval:      time.Second,
+				Timeout:       5 * time.Second,
+			}
+
+			Expect(plugin.Negotiate(lagertest.NewTestLogger("test"))).To(Succeed())
+			Expect(commandRunner).To(HaveExecutedSerially(
+				fake_command_runner.CommandSpec{Path: "/path/to/plugin", Args: []string{"--store", "/var/store", "ready"}},
+				fake_command_runner.CommandSpec{Path: "/path/to/plugin", Args: []string{"--store", "/var/store", "capabilities"}},
+			))
+		})
+
+		It("falls back to v1 when the plugin only supports v1", func() {
+			capabilities = `{"protocol_version": 1}`
+
+			Expect(plugin.Negotiate(lagertest.NewTestLogger("test"))).To(Succeed())
+			Expect(plugin.Capabilities).To(BeNil())
+		})
+
+		It("fails when the plugin's response is not JSON", func() {
+			capabilities = "what?"
+
+			Expect(plugin.Negotiate(lagertest.NewTestLogger("test"))).To(MatchError(ContainSubstring("image plugin capabilities: invalid response")))
+		})
+	})
+
+	Context("when the protocol is negotiated on first use", func() {
+		BeforeEach(func() {
+			plugin.NegotiateProtocol = true
+		})
+
+		It("negotiates before creating the rootfs", func() {
+			_, _, err := plugin.Create(lagertest.NewTestLogger("test"), "some-handle", spec)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(commandRunner).To(HaveExecutedSerially(
+				fake_command_runner.CommandSpec{Path: "/path/to/plugin", Args: []string{"--store", "/var/store", "capabilities"}},
+				fake_command_runner.CommandSpec{Path: "/path/to/plugin", Args: []string{"--store", "/var/store", "create"}},
+			))
+			Expect(requests).To(HaveKey("create"))
+		})
+
+		It("negotiates only once", func() {
+			Expect(plugin.Destroy(lagertest.NewTestLogger("test"), "some-handle")).To(Succeed())
+			Expect(plugin.Destroy(lagertest.NewTestLogger("test"), "some-handle")).To(Succeed())
+
+			capabilitiesRuns := 0
+			for _, cmd := range commandRunner.ExecutedCommands() {
+				if cmd.Args[len(cmd.Args)-1] == "capabilities" {
+					capabilitiesRuns++
+				}
+			}
+			Expect(capabilitiesRuns).To(Equal(1))
+		})
+
+		It("negotiates again once the plugin can be run after failing to", func() {
+			failing := true
+			commandRunner.WhenRunning(fake_command_runner.CommandSpec{
+				Path: "/path/to/plugin",
+				Args: []string{"--store", "/var/store", "capabilities"},
+			}, func(cmd *exec.Cmd) error {
+				if failing {
+					return errors.New("fork/exec /path/to/plugin: text file busy")
+				}
+
+				cmd.Stdout.Write([]byte(capabilities))
+				return nil
+			})
+
+			Expect(plugin.Destroy(lagertest.NewTestLogger("test"), "some-handle")).To(MatchError(ContainSubstring("text file busy")))
+			Expect(requests).NotTo(HaveKey("delete"))
+
+			failing = false
+			Expect(plugin.Destroy(lagertest.NewTestLogger("test"), "some-handle")).To(Succeed())
+			Expect(plugin.Capabilities).NotTo(BeNil())
+			Expect(requests["delete"]).To(MatchJSON(`{"handle": "some-handle"}`))
+		})
+	})
+
+	Context("when negotiated", func() {
+		BeforeEach(func() {
+			Expect(plugin.Negotiate(lagertest.NewTestLogger("test"))).To(Succeed())
+		})
+
+		Describe("Create", func() {
+			It("writes the spec to the plugin's stdin and returns the rootfs path and env it responds with", func() {
+				spec.Namespaced = true
+				spec.QuotaSize = 1024
+				spec.QuotaScope = "exclusive"
+
+				path, env, err := plugin.Create(lagertest.NewTestLogger("test"), "some-handle", spec)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(path).To(Equal("/var/store/images/some-handle/rootfs"))
+				Expect(env).To(Equal([]string{"PATH=/bin"}))
+
+				var request imageplugin.CreateRequest
+				Expect(json.Unmarshal(requests["create"], &request)).To(Succeed())
+				Expect(request).To(Equal(imageplugin.CreateRequest{
+					Handle:      "some-handle",
+					RootFS:      "docker:///busybox",
+					UIDMappings: []imageplugin.Mapping{{ContainerID: 0, HostID: 4294967294, Size: 1}},
+					GIDMappings: []imageplugin.Mapping{{ContainerID: 0, HostID: 4294967294, Size: 1}},
+					DiskLimit:   &imageplugin.DiskLimit{SizeBytes: 1024, ExcludeImage: true},
+				}))
+			})
+
+			It("does not pass id mappings for privileged containers", func() {
+				_, _, err := plugin.Create(lagertest.NewTestLogger("test"), "some-handle", spec)
+				Expect(err).NotTo(HaveOccurred())
+
+				var request imageplugin.CreateRequest
+				Expect(json.Unmarshal(requests["create"], &request)).To(Succeed())
+				Expect(request.UIDMappings).To(BeEmpty())
+				Expect(request.GIDMappings).To(BeEmpty())
+			})
+
+			It("refuses to create rootfses needing features the plugin lacks", func() {
+				_, _, err := plugin.CreateLayered(lagertest.NewTestLogger("test"), "some-handle", spec, nil, []string{"/layers/base"})
+				Expect(err).To(MatchError("image plugin create: the plugin does not support the 'layers' feature"))
+				Expect(requests).NotTo(HaveKey("create"))
+			})
+
+			It("fails when the response has no rootfs path", func() {
+				commandRunner.WhenRunning(fake_command_runner.CommandSpec{
+					Path: "/path/to/plugin",
+					Args: []string{"--store", "/var/store", "create"},
+				}, func(cmd *exec.Cmd) error {
+					cmd.Stdout.Write([]byte(`{}`))
+					return nil
+				})
+
+				_, _, err := plugin.Create(lagertest.NewTestLogger("test"), "some-handle", spec)
+				Expect(err).To(MatchError("image plugin create: response has no rootfs_path"))
+			})
+
+			It("returns the plugin's stderr when it fails", func() {
+				commandRunner.WhenRunning(fake_command_runner.CommandSpec{
+					Path: "/path/to/plugin",
+					Args: []string{"--store", "/var/store", "create"},
+				}, func(cmd *exec.Cmd) error {
+					cmd.Stderr.Write([]byte("image not found\n"))
+					return errors.New("exit status 1")
+				})
+
+				_, _, err := plugin.Create(lagertest.NewTestLogger("test"), "some-handle", spec)
+				Expect(err).To(MatchError("image plugin create: exit status 1: image not found"))
+			})
+		})
+
+		Describe("Destroy", func() {
+			It("writes the handle to the plugin's stdin", func() {
+				Expect(plugin.Destroy(lagertest.NewTestLogger("test"), "some-handle")).To(Succeed())
+
+				Expect(commandRunner).To(HaveExecutedSerially(fake_command_runner.CommandSpec{
+					Path: "/path/to/plugin",
+					Args: []string{"--store", "/var/store", "delete"},
+				}))
+				Expect(requests["delete"]).To(MatchJSON(`{"handle": "some-handle"}`))
+			})
+		})
+
+		Describe("DiskUsage", func() {
+			It("is not supported unless the plugin has the stats feature", func() {
+				_, err := plugin.DiskUsage(lagertest.NewTestLogger("test"), "some-handle")
+				Expect(err).To(Equal(gardener.ErrDiskUsageNotSupported))
+			})
+
+			Context("when the plugin has the stats feature", func() {
+				BeforeEach(func() {
+					plugin.Capabilities.Features = append(plugin.Capabilities.Features, imageplugin.FeatureStats)
+
+					commandRunner.WhenRunning(fake_command_runner.CommandSpec{
+						Path: "/path/to/plugin",
+						Args: []string{"--store", "/var/store", "stats"},
+					}, func(cmd *exec.Cmd) error {
+						request, err := ioutil.ReadAll(cmd.Stdin)
+						Expect(err).NotTo(HaveOccurred())
+						requests["stats"] = request
+
+						cmd.Stdout.Write([]byte(`{"disk_usage": {"total_bytes_used": 3072, "exclusive_bytes_used": 1024}, "quota_bytes": 1000, "quota_exceeded": true}`))
+						return nil
+					})
+				})
+
+				It("returns the usage the plugin reports", func() {
+					usage, err := plugin.DiskUsage(lagertest.NewTestLogger("test"), "some-handle")
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(requests["stats"]).To(MatchJSON(`{"handle": "some-handle"}`))
+					Expect(usage).To(Equal(gardener.DiskUsage{
+						TotalBytesUsed:     3072,
+						ExclusiveBytesUsed: 1024,
+						QuotaBytes:         1000,
+						QuotaExceeded:      true,
+					}))
+				})
+			})
+		})
+	})
+})
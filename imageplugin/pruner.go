@@ -0,0 +1,38 @@
+package imageplugin
+
+import (
+	"time"
+
+	"github.com/pivotal-golang/clock"
+	"github.com/pivotal-golang/lager"
+)
+
+// BlobPruner prunes the blobs which have not been fetched for MaxAge from
+// Cache every Interval, so that the cache does not keep every layer ever
+// pulled. It does not prune at start, so that persistent images, which are
+// pinned once they have been pulled, are not pruned while they are pulled.
+type BlobPruner struct {
+	Cache    *BlobCache
+	MaxAge   time.Duration
+	Clock    clock.Clock
+	Interval time.Duration
+	Logger   lager.Logger
+}
+
+func (p *BlobPruner) Start() error {
+	go func() {
+		ticker := p.Clock.NewTicker(p.Interval)
+		defer ticker.Stop()
+
+		for range ticker.C() {
+			p.Prune()
+		}
+	}()
+
+	return nil
+}
+
+// Prune prunes the blobs which have not been fetched for MaxAge once
+func (p *BlobPruner) Prune() {
+	p.Cache.Prune(p.Logger, p.Clock.Now().Add(-p.MaxAge))
+}
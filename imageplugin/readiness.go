@@ -0,0 +1,112 @@
+package imageplugin
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cloudfoundry-incubator/guardian/gardener"
+	"github.com/cloudfoundry/gunk/command_runner"
+	"github.com/pivotal-golang/clock"
+	"github.com/pivotal-golang/lager"
+)
+
+// ReadinessProbe waits for an image plugin's store to be ready, e.g. while
+// grootfs is still initializing its store after the host boots, so that
+// containers created in the meantime wait rather than fail. The plugin is
+// ready once running it with Args succeeds; it is probed every Interval
+// until Timeout has passed. Callers which wait at the same time share one
+// probe, and its deadline. Once the plugin has been ready it is never probed
+// again. A nil ReadinessProbe is always ready.
+//
+// Each run of the plugin should be bounded by the CommandRunner, e.g. a
+// cmdtimeout.Runner, as a run which hangs holds up every waiting caller.
+type ReadinessProbe struct {
+	Binary        string
+	Args          []string
+	CommandRunner command_runner.CommandRunner
+	Clock         clock.Clock
+	Interval      time.Duration
+	Timeout       time.Duration
+
+	mu       sync.Mutex
+	ready    bool
+	inFlight *readinessWait
+}
+
+// readinessWait is a probe which callers are waiting for
+type readinessWait struct {
+	done chan struct{}
+	err  error
+}
+
+func (p *ReadinessProbe) Wait(log lager.Logger) error {
+	if p == nil {
+		return nil
+	}
+
+	p.mu.Lock()
+	if p.ready {
+		p.mu.Unlock()
+		return nil
+	}
+
+	wait := p.inFlight
+	if wait == nil {
+		wait = &readinessWait{done: make(chan struct{})}
+		p.inFlight = wait
+		go p.run(log.Session("wait-for-image-plugin"), wait)
+	}
+	p.mu.Unlock()
+
+	<-wait.done
+	return wait.err
+}
+
+// run probes the plugin until it is ready or Timeout has passed, and then
+// releases the callers waiting for it
+func (p *ReadinessProbe) run(log lager.Logger, wait *readinessWait) {
+	wait.err = p.retry(log)
+
+	p.mu.Lock()
+	p.ready = wait.err == nil
+	p.inFlight = nil
+	p.mu.Unlock()
+
+	close(wait.done)
+}
+
+func (p *ReadinessProbe) retry(log lager.Logger) error {
+	deadline := p.Clock.Now().Add(p.Timeout)
+
+	for {
+		err := p.probe()
+		if err == nil {
+			return nil
+		}
+
+		if !p.Clock.Now().Before(deadline) {
+			log.Error("not-ready", err, lager.Data{"timeout": p.Timeout.String()})
+			return gardener.Classify(gardener.FailurePluginTimeout, fmt.Errorf("image plugin not ready after %s: %s", p.Timeout, err))
+		}
+
+		log.Info("not-ready-retrying", lager.Data{"error": err.Error()})
+		p.Clock.Sleep(p.Interval)
+	}
+}
+
+func (p *ReadinessProbe) probe() error {
+	stderr := &bytes.Buffer{}
+
+	cmd := exec.Command(p.Binary, p.Args...)
+	cmd.Stderr = stderr
+
+	if err := p.CommandRunner.Run(cmd); err != nil {
+		return fmt.Errorf("%s: %s", err, strings.TrimSpace(stderr.String()))
+	}
+
+	return nil
+}
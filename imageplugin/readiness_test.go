@@ -0,0 +1,170 @@
+package imageplugin_test
+
+import (
+	"errors"
+	"net/url"
+	"os/exec"
+	"sync"
+	"time"
+
+	"github.com/cloudfoundry-incubator/garden-shed/rootfs_provider"
+	"github.com/cloudfoundry-incubator/guardian/imageplugin"
+	"github.com/cloudfoundry/gunk/command_runner/fake_command_runner"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+	"github.com/pivotal-golang/clock/fakeclock"
+	"github.com/pivotal-golang/lager/lagertest"
+)
+
+var _ = Describe("ReadinessProbe", func() {
+	var (
+		commandRunner *fake_command_runner.FakeCommandRunner
+		fakeClock     *fakeclock.FakeClock
+		probe         *imageplugin.ReadinessProbe
+		logger        *lagertest.TestLogger
+
+		mu        sync.Mutex
+		probes    int
+		failUntil int
+	)
+
+	probeCount := func() int {
+		mu.Lock()
+		defer mu.Unlock()
+		return probes
+	}
+
+	BeforeEach(func() {
+		commandRunner = fake_command_runner.New()
+		fakeClock = fakeclock.NewFakeClock(time.Now())
+		logger = lagertest.NewTestLogger("test")
+
+		probes = 0
+		failUntil = 0
+		commandRunner.WhenRunning(fake_command_runner.CommandSpec{Path: "/path/to/grootfs"}, func(cmd *exec.Cmd) error {
+			mu.Lock()
+			defer mu.Unlock()
+
+			probes++
+			if probes <= failUntil {
+				cmd.Stderr.Write([]byte("store is not initialized\n"))
+				return errors.New("exit status 1")
+			}
+
+			return nil
+		})
+
+		probe = &imageplugin.ReadinessProbe{
+			Binary:        "/path/to/grootfs",
+			Args:          []string{"--store", "/var/store", "stats"},
+			CommandRunner: commandRunner,
+			Clock:         fakeClock,
+			Interval:      time.Second,
+			Timeout:       5 * time.Second,
+		}
+	})
+
+	It("runs the probe command", func() {
+		Expect(probe.Wait(logger)).To(Succeed())
+
+		Expect(commandRunner.ExecutedCommands()).To(HaveLen(1))
+		Expect(commandRunner.ExecutedCommands()[0].Args).To(Equal([]string{"/path/to/grootfs", "--store", "/var/store", "stats"}))
+	})
+
+	It("does not probe again once the plugin has been ready", func() {
+		Expect(probe.Wait(logger)).To(Succeed())
+		Expect(probe.Wait(logger)).To(Succeed())
+
+		Expect(probeCount()).To(Equal(1))
+	})
+
+	Context("when the plugin is not ready at first", func() {
+		BeforeEach(func() {
+			failUntil = 2
+		})
+
+		It("probes every interval until it is", func() {
+			errs := make(chan error, 1)
+			go func() { errs <- probe.Wait(logger) }()
+
+			Eventually(fakeClock.WatcherCount).Should(Equal(1))
+			Expect(probeCount()).To(Equal(1))
+
+			fakeClock.Increment(time.Second)
+			Eventually(probeCount).Should(Equal(2))
+
+			Eventually(fakeClock.WatcherCount).Should(Equal(1))
+			fakeClock.Increment(time.Second)
+
+			Eventually(errs).Should(Receive(BeNil()))
+			Expect(probeCount()).To(Equal(3))
+			Expect(logger).To(gbytes.Say("not-ready-retrying"))
+		})
+
+		It("shares one probe among concurrent callers", func() {
+			errs := make(chan error, 2)
+			go func() { errs <- probe.Wait(logger) }()
+
+			Eventually(fakeClock.WatcherCount).Should(Equal(1))
+			go func() { errs <- probe.Wait(logger) }()
+			Consistently(probeCount).Should(Equal(1))
+
+			fakeClock.Increment(time.Second)
+			Eventually(probeCount).Should(Equal(2))
+			Eventually(fakeClock.WatcherCount).Should(Equal(1))
+			fakeClock.Increment(time.Second)
+
+			Eventually(errs).Should(Receive(BeNil()))
+			Eventually(errs).Should(Receive(BeNil()))
+			Expect(probeCount()).To(Equal(3))
+		})
+	})
+
+	Context("when the plugin does not become ready before the timeout", func() {
+		BeforeEach(func() {
+			failUntil = 100
+		})
+
+		It("returns the last error", func() {
+			errs := make(chan error, 1)
+			go func() { errs <- probe.Wait(logger) }()
+
+			for i := 0; i < 5; i++ {
+				Eventually(fakeClock.WatcherCount).Should(Equal(1))
+				fakeClock.Increment(time.Second)
+			}
+
+			var err error
+			Eventually(errs).Should(Receive(&err))
+			Expect(err).To(MatchError("image plugin not ready after 5s: exit status 1: store is not initialized"))
+		})
+	})
+
+	Context("when the probe is nil", func() {
+		It("is always ready", func() {
+			var nilProbe *imageplugin.ReadinessProbe
+			Expect(nilProbe.Wait(logger)).To(Succeed())
+		})
+	})
+
+	Describe("ExternalPlugin", func() {
+		It("waits for the plugin to be ready before creating a rootfs", func() {
+			failUntil = 100
+			probe.Timeout = 0
+
+			plugin := &imageplugin.ExternalPlugin{
+				Binary:        "/path/to/grootfs",
+				CommandRunner: commandRunner,
+				Readiness:     probe,
+			}
+
+			rootfs, err := url.Parse("docker:///busybox")
+			Expect(err).NotTo(HaveOccurred())
+
+			_, _, err = plugin.Create(logger, "some-handle", rootfs_provider.Spec{RootFS: rootfs})
+			Expect(err).To(MatchError(ContainSubstring("image plugin not ready")))
+			Expect(commandRunner.ExecutedCommands()).To(HaveLen(1))
+		})
+	})
+})
@@ -0,0 +1,401 @@
+package imageplugin
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"runtime"
+	"strings"
+	"sync"
+
+	"github.com/pivotal-golang/lager"
+)
+
+const DefaultRegistry = "registry-1.docker.io"
+
+const (
+	MediaTypeDockerManifest     = "application/vnd.docker.distribution.manifest.v2+json"
+	MediaTypeDockerManifestList = "application/vnd.docker.distribution.manifest.list.v2+json"
+	MediaTypeOCIManifest        = "application/vnd.oci.image.manifest.v1+json"
+	MediaTypeOCIIndex           = "application/vnd.oci.image.index.v1+json"
+)
+
+var manifestMediaTypes = []string{
+	MediaTypeDockerManifest,
+	MediaTypeDockerManifestList,
+	MediaTypeOCIManifest,
+	MediaTypeOCIIndex,
+}
+
+// ImageRef identifies an image in a registry
+type ImageRef struct {
+	Registry   string
+	Repository string
+	Tag        string
+}
+
+// ParseImageURL parses a rootfs URL of the form docker://[registry]/repo#tag.
+// Official images on the default registry may omit the 'library/' prefix and
+// the tag defaults to 'latest'.
+func ParseImageURL(u *url.URL, defaultRegistry string) (ImageRef, error) {
+	ref := ImageRef{
+		Registry:   u.Host,
+		Repository: strings.Trim(u.Path, "/"),
+		Tag:        u.Fragment,
+	}
+
+	if ref.Repository == "" {
+		return ImageRef{}, fmt.Errorf("image url has no repository: %s", u)
+	}
+
+	if ref.Registry == "" {
+		ref.Registry = defaultRegistry
+	}
+
+	if ref.Registry == defaultRegistry && !strings.Contains(ref.Repository, "/") {
+		ref.Repository = "library/" + ref.Repository
+	}
+
+	if ref.Tag == "" {
+		ref.Tag = "latest"
+	}
+
+	return ref, nil
+}
+
+type Descriptor struct {
+	MediaType string    `json:"mediaType"`
+	Digest    string    `json:"digest"`
+	Size      int64     `json:"size"`
+	Platform  *Platform `json:"platform,omitempty"`
+}
+
+type Platform struct {
+	Architecture string `json:"architecture"`
+	OS           string `json:"os"`
+}
+
+// Manifest is an image manifest, or an index of manifests for different
+// platforms
+type Manifest struct {
+	SchemaVersion int          `json:"schemaVersion"`
+	MediaType     string       `json:"mediaType"`
+	Config        Descriptor   `json:"config"`
+	Layers        []Descriptor `json:"layers"`
+	Manifests     []Descriptor `json:"manifests"`
+}
+
+// Credential authenticates with a registry
+type Credential struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+
+// RegistryClient fetches manifests and blobs using the docker registry v2
+// API, authenticating with basic or token auth as the registry requests.
+type RegistryClient struct {
+	HTTPClient *http.Client
+
+	// DefaultRegistry is used for image URLs which do not name a registry
+	DefaultRegistry string
+
+	// Mirrors are base URLs (e.g. https://mirror.example.com) of mirrors of
+	// the default registry, tried in order before the registry itself. Use
+	// SetMirrors to change them once the client is in use.
+	Mirrors   []string
+	mirrorsMu sync.RWMutex
+
+	// Insecure lists registry hosts which are accessed over plain HTTP
+	Insecure []string
+
+	// Credentials are keyed by registry (or mirror) host
+	Credentials map[string]Credential
+}
+
+// Manifest fetches the manifest of the image. If the tag refers to an index
+// of manifests, the manifest for the current platform is returned.
+func (r *RegistryClient) Manifest(log lager.Logger, ref ImageRef) (Manifest, error) {
+	manifest, err := r.manifest(log, ref, ref.Tag)
+	if err != nil {
+		return Manifest{}, err
+	}
+
+	if manifest.MediaType != MediaTypeDockerManifestList && manifest.MediaType != MediaTypeOCIIndex {
+		return manifest, nil
+	}
+
+	for _, m := range manifest.Manifests {
+		if m.Platform != nil && m.Platform.OS == "linux" && m.Platform.Architecture == runtime.GOARCH {
+			return r.manifest(log, ref, m.Digest)
+		}
+	}
+
+	return Manifest{}, fmt.Errorf("image %s:%s has no manifest for linux/%s", ref.Repository, ref.Tag, runtime.GOARCH)
+}
+
+func (r *RegistryClient) manifest(log lager.Logger, ref ImageRef, reference string) (Manifest, error) {
+	body, err := r.get(log, ref, "manifests/"+reference, strings.Join(manifestMediaTypes, ", "))
+	if err != nil {
+		return Manifest{}, err
+	}
+	defer body.Close()
+
+	var manifest Manifest
+	if err := json.NewDecoder(body).Decode(&manifest); err != nil {
+		return Manifest{}, fmt.Errorf("decode manifest: %s", err)
+	}
+
+	if manifest.SchemaVersion != 2 {
+		return Manifest{}, fmt.Errorf("unsupported manifest schema version %d", manifest.SchemaVersion)
+	}
+
+	return manifest, nil
+}
+
+// Blob returns the content of the blob with the given digest
+func (r *RegistryClient) Blob(log lager.Logger, ref ImageRef, digest string) (io.ReadCloser, error) {
+	return r.get(log, ref, "blobs/"+digest, "")
+}
+
+func (r *RegistryClient) defaultRegistry() string {
+	if r.DefaultRegistry == "" {
+		return DefaultRegistry
+	}
+
+	return r.DefaultRegistry
+}
+
+// SetMirrors replaces the mirrors of the default registry, affecting
+// subsequent fetches
+func (r *RegistryClient) SetMirrors(mirrors []string) {
+	r.mirrorsMu.Lock()
+	defer r.mirrorsMu.Unlock()
+
+	r.Mirrors = mirrors
+}
+
+func (r *RegistryClient) endpoints(registry string) []string {
+	var endpoints []string
+	if registry == r.defaultRegistry() {
+		r.mirrorsMu.RLock()
+		endpoints = append(endpoints, r.Mirrors...)
+		r.mirrorsMu.RUnlock()
+	}
+
+	scheme := "https://"
+	for _, insecure := range r.Insecure {
+		if insecure == registry {
+			scheme = "http://"
+		}
+	}
+
+	return append(endpoints, scheme+registry)
+}
+
+func (r *RegistryClient) get(log lager.Logger, ref ImageRef, path, accept string) (io.ReadCloser, error) {
+	var lastErr error
+	for _, endpoint := range r.endpoints(ref.Registry) {
+		body, err := r.getFrom(endpoint, ref, path, accept)
+		if err == nil {
+			return body, nil
+		}
+
+		log.Info("endpoint-failed", lager.Data{"endpoint": endpoint, "error": err.Error()})
+		lastErr = err
+	}
+
+	return nil, lastErr
+}
+
+func (r *RegistryClient) getFrom(endpoint string, ref ImageRef, path, accept string) (io.ReadCloser, error) {
+	u := strings.TrimRight(endpoint, "/") + "/v2/" + ref.Repository + "/" + path
+
+	resp, err := r.do(u, accept, "")
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode == http.StatusUnauthorized {
+		resp.Body.Close()
+
+		authorization, err := r.authorize(resp, endpoint, ref)
+		if err != nil {
+			return nil, fmt.Errorf("authenticate with %s: %s", endpoint, err)
+		}
+
+		if resp, err = r.do(u, accept, authorization); err != nil {
+			return nil, err
+		}
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("GET %s: %s", u, resp.Status)
+	}
+
+	return resp.Body, nil
+}
+
+func (r *RegistryClient) do(u, accept, authorization string) (*http.Response, error) {
+	req, err := http.NewRequest("GET", u, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if accept != "" {
+		req.Header.Set("Accept", accept)
+	}
+
+	if authorization != "" {
+		req.Header.Set("Authorization", authorization)
+	}
+
+	return r.httpClient().Do(req)
+}
+
+// authorize answers the challenge in a 401 response, returning the value of
+// the Authorization header to retry the request with
+func (r *RegistryClient) authorize(resp *http.Response, endpoint string, ref ImageRef) (string, error) {
+	scheme, params := parseChallenge(resp.Header.Get("WWW-Authenticate"))
+
+	var credential *Credential
+	if u, err := url.Parse(endpoint); err == nil {
+		if c, ok := r.Credentials[u.Host]; ok {
+			credential = &c
+		}
+	}
+
+	switch strings.ToLower(scheme) {
+	case "basic":
+		if credential == nil {
+			return "", fmt.Errorf("registry requires credentials")
+		}
+
+		return "Basic " + basicAuth(*credential), nil
+	case "bearer":
+		token, err := r.token(params, credential, ref)
+		if err != nil {
+			return "", err
+		}
+
+		return "Bearer " + token, nil
+	default:
+		return "", fmt.Errorf("unsupported authentication scheme '%s'", scheme)
+	}
+}
+
+func (r *RegistryClient) token(params map[string]string, credential *Credential, ref ImageRef) (string, error) {
+	realm, err := url.Parse(params["realm"])
+	if err != nil || params["realm"] == "" {
+		return "", fmt.Errorf("invalid token realm '%s'", params["realm"])
+	}
+
+	query := realm.Query()
+	if service, ok := params["service"]; ok {
+		query.Set("service", service)
+	}
+
+	scope := params["scope"]
+	if scope == "" {
+		scope = "repository:" + ref.Repository + ":pull"
+	}
+	query.Set("scope", scope)
+	realm.RawQuery = query.Encode()
+
+	req, err := http.NewRequest("GET", realm.String(), nil)
+	if err != nil {
+		return "", err
+	}
+
+	if credential != nil {
+		req.SetBasicAuth(credential.Username, credential.Password)
+	}
+
+	resp, err := r.httpClient().Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 1024))
+		return "", fmt.Errorf("get token: %s: %s", resp.Status, body)
+	}
+
+	var tokenResponse struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(&tokenResponse); err != nil {
+		return "", fmt.Errorf("decode token: %s", err)
+	}
+
+	if tokenResponse.Token != "" {
+		return tokenResponse.Token, nil
+	}
+
+	return tokenResponse.AccessToken, nil
+}
+
+func (r *RegistryClient) httpClient() *http.Client {
+	if r.HTTPClient == nil {
+		return http.DefaultClient
+	}
+
+	return r.HTTPClient
+}
+
+// parseChallenge parses a WWW-Authenticate header such as
+// `Bearer realm="https://auth.example.com/token",service="registry"`
+func parseChallenge(header string) (string, map[string]string) {
+	params := make(map[string]string)
+
+	parts := strings.SplitN(strings.TrimSpace(header), " ", 2)
+	if len(parts) < 2 {
+		return parts[0], params
+	}
+
+	for _, param := range splitParams(parts[1]) {
+		kv := strings.SplitN(param, "=", 2)
+		if len(kv) != 2 {
+			continue
+		}
+
+		params[strings.ToLower(strings.TrimSpace(kv[0]))] = strings.Trim(strings.TrimSpace(kv[1]), `"`)
+	}
+
+	return parts[0], params
+}
+
+// splitParams splits on commas which are not within quotes, since scopes may
+// contain commas (e.g. "repository:foo:pull,push")
+func splitParams(s string) []string {
+	var (
+		params []string
+		start  int
+		quoted bool
+	)
+
+	for i, c := range s {
+		switch c {
+		case '"':
+			quoted = !quoted
+		case ',':
+			if !quoted {
+				params = append(params, s[start:i])
+				start = i + 1
+			}
+		}
+	}
+
+	return append(params, s[start:])
+}
+
+func basicAuth(c Credential) string {
+	return base64.StdEncoding.EncodeToString([]byte(c.Username + ":" + c.Password))
+}
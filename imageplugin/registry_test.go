@@ -0,0 +1,272 @@
+package imageplugin_test
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"runtime"
+	"strings"
+
+	"github.com/cloudfoundry-incubator/guardian/imageplugin"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+	"github.com/pivotal-golang/lager"
+	"github.com/pivotal-golang/lager/lagertest"
+)
+
+// fakeRegistry serves manifests and blobs for a single repository
+type fakeRegistry struct {
+	Repository string
+	Manifests  map[string]interface{}
+	Blobs      map[string][]byte
+
+	// Token, if set, is required as a bearer token
+	Token string
+
+	Requests []string
+
+	// Delay, if set, holds up each request until it is closed
+	Delay chan struct{}
+}
+
+func (f *fakeRegistry) AddBlob(content []byte) string {
+	sum := sha256.Sum256(content)
+	digest := "sha256:" + hex.EncodeToString(sum[:])
+	f.Blobs[digest] = content
+	return digest
+}
+
+func (f *fakeRegistry) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if f.Delay != nil {
+		<-f.Delay
+	}
+
+	f.Requests = append(f.Requests, r.URL.Path)
+
+	if r.URL.Path == "/token" {
+		if r.URL.Query().Get("scope") != "repository:"+f.Repository+":pull" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+
+		json.NewEncoder(w).Encode(map[string]string{"token": f.Token})
+		return
+	}
+
+	if f.Token != "" && r.Header.Get("Authorization") != "Bearer "+f.Token {
+		w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer realm="http://%s/token",service="fake"`, r.Host))
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	prefix := "/v2/" + f.Repository + "/"
+	if !strings.HasPrefix(r.URL.Path, prefix) {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	path := strings.TrimPrefix(r.URL.Path, prefix)
+	switch {
+	case strings.HasPrefix(path, "manifests/"):
+		manifest, ok := f.Manifests[strings.TrimPrefix(path, "manifests/")]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		json.NewEncoder(w).Encode(manifest)
+	case strings.HasPrefix(path, "blobs/"):
+		blob, ok := f.Blobs[strings.TrimPrefix(path, "blobs/")]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		w.Write(blob)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func newFakeRegistry(repository string) *fakeRegistry {
+	return &fakeRegistry{
+		Repository: repository,
+		Manifests:  make(map[string]interface{}),
+		Blobs:      make(map[string][]byte),
+	}
+}
+
+var _ = Describe("ParseImageURL", func() {
+	DescribeTable("parsing rootfs urls",
+		func(rootfs string, expected imageplugin.ImageRef) {
+			u, err := url.Parse(rootfs)
+			Expect(err).NotTo(HaveOccurred())
+
+			ref, err := imageplugin.ParseImageURL(u, "registry-1.docker.io")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(ref).To(Equal(expected))
+		},
+		Entry("an official image", "docker:///busybox", imageplugin.ImageRef{
+			Registry: "registry-1.docker.io", Repository: "library/busybox", Tag: "latest",
+		}),
+		Entry("a tagged image", "docker:///cloudfoundry/garden-busybox#1.0", imageplugin.ImageRef{
+			Registry: "registry-1.docker.io", Repository: "cloudfoundry/garden-busybox", Tag: "1.0",
+		}),
+		Entry("an image on another registry", "docker://my-registry:5000/busybox#2", imageplugin.ImageRef{
+			Registry: "my-registry:5000", Repository: "busybox", Tag: "2",
+		}),
+	)
+
+	It("rejects urls without a repository", func() {
+		_, err := imageplugin.ParseImageURL(&url.URL{Scheme: "docker", Host: "my-registry"}, "registry-1.docker.io")
+		Expect(err).To(HaveOccurred())
+	})
+})
+
+var _ = Describe("RegistryClient", func() {
+	var (
+		logger   lager.Logger
+		registry *fakeRegistry
+		server   *httptest.Server
+		client   *imageplugin.RegistryClient
+		ref      imageplugin.ImageRef
+		manifest map[string]interface{}
+	)
+
+	BeforeEach(func() {
+		logger = lagertest.NewTestLogger("test")
+		registry = newFakeRegistry("some/image")
+		server = httptest.NewServer(registry)
+
+		host := strings.TrimPrefix(server.URL, "http://")
+		client = &imageplugin.RegistryClient{
+			DefaultRegistry: "registry.example.com",
+			Insecure:        []string{host},
+		}
+		ref = imageplugin.ImageRef{Registry: host, Repository: "some/image", Tag: "latest"}
+
+		manifest = map[string]interface{}{
+			"schemaVersion": 2,
+			"mediaType":     imageplugin.MediaTypeDockerManifest,
+			"config":        map[string]interface{}{"digest": registry.AddBlob([]byte("{}"))},
+			"layers": []map[string]interface{}{
+				{"digest": registry.AddBlob([]byte("layer"))},
+			},
+		}
+		registry.Manifests["latest"] = manifest
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	It("fetches the manifest", func() {
+		m, err := client.Manifest(logger, ref)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(m.Layers).To(HaveLen(1))
+		Expect(m.Layers[0].Digest).To(Equal(manifest["layers"].([]map[string]interface{})[0]["digest"]))
+	})
+
+	It("fetches blobs", func() {
+		body, err := client.Blob(logger, ref, manifest["layers"].([]map[string]interface{})[0]["digest"].(string))
+		Expect(err).NotTo(HaveOccurred())
+		defer body.Close()
+
+		Expect(ioutil.ReadAll(body)).To(Equal([]byte("layer")))
+	})
+
+	It("returns an error when the tag does not exist", func() {
+		ref.Tag = "missing"
+		_, err := client.Manifest(logger, ref)
+		Expect(err).To(MatchError(ContainSubstring("404")))
+	})
+
+	Context("when the tag refers to a manifest list", func() {
+		BeforeEach(func() {
+			registry.Manifests["sha256:for-this-platform"] = manifest
+			registry.Manifests["latest"] = map[string]interface{}{
+				"schemaVersion": 2,
+				"mediaType":     imageplugin.MediaTypeDockerManifestList,
+				"manifests": []map[string]interface{}{
+					{"digest": "sha256:for-another-platform", "platform": map[string]string{"os": "windows", "architecture": runtime.GOARCH}},
+					{"digest": "sha256:for-this-platform", "platform": map[string]string{"os": "linux", "architecture": runtime.GOARCH}},
+				},
+			}
+		})
+
+		It("fetches the manifest for the current platform", func() {
+			m, err := client.Manifest(logger, ref)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(m.Layers).To(HaveLen(1))
+		})
+	})
+
+	Context("when the manifest has schema version 1", func() {
+		BeforeEach(func() {
+			manifest["schemaVersion"] = 1
+		})
+
+		It("returns an error", func() {
+			_, err := client.Manifest(logger, ref)
+			Expect(err).To(MatchError("unsupported manifest schema version 1"))
+		})
+	})
+
+	Context("when the registry requires a token", func() {
+		BeforeEach(func() {
+			registry.Token = "some-token"
+		})
+
+		It("gets a token for the repository and retries", func() {
+			_, err := client.Manifest(logger, ref)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(registry.Requests).To(Equal([]string{
+				"/v2/some/image/manifests/latest",
+				"/token",
+				"/v2/some/image/manifests/latest",
+			}))
+		})
+	})
+
+	Context("when mirrors are configured", func() {
+		var mirror *httptest.Server
+
+		BeforeEach(func() {
+			mirror = httptest.NewServer(registry)
+			client.DefaultRegistry = ref.Registry
+			client.Mirrors = []string{"http://127.0.0.1:1", mirror.URL}
+		})
+
+		AfterEach(func() {
+			mirror.Close()
+		})
+
+		It("falls through unreachable mirrors to the next one", func() {
+			_, err := client.Manifest(logger, ref)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(registry.Requests).To(HaveLen(1))
+		})
+
+		It("does not use the mirrors for other registries", func() {
+			client.DefaultRegistry = "registry.example.com"
+
+			_, err := client.Manifest(logger, ref)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(logger.(*lagertest.TestLogger).LogMessages()).NotTo(ContainElement(ContainSubstring("endpoint-failed")))
+		})
+
+		It("uses mirrors replaced with SetMirrors", func() {
+			client.SetMirrors([]string{"http://127.0.0.1:1"})
+
+			_, err := client.Manifest(logger, ref)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(logger.(*lagertest.TestLogger).LogMessages()).To(ContainElement(ContainSubstring("endpoint-failed")))
+		})
+	})
+})
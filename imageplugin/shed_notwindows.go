@@ -0,0 +1,99 @@
+// +build !windows
+
+package imageplugin
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/cloudfoundry-incubator/garden-shed/layercake"
+	"github.com/cloudfoundry-incubator/garden-shed/repository_fetcher"
+	"github.com/cloudfoundry-incubator/garden-shed/rootfs_provider"
+	"github.com/cloudfoundry-incubator/guardian/gardener"
+	"github.com/pivotal-golang/lager"
+)
+
+// ShedVolumeCreator creates rootfses with garden-shed, supporting
+// per-container id mappings, so that they can be used with rootfses which
+// are not docker:// images or which have a disk quota. garden-shed only
+// passes the handle down to the creation of the container's layer, so
+// Layers, which must be the LayerCreator of the CakeOrdinator, looks up the
+// mappings of the container being created by its handle.
+type ShedVolumeCreator struct {
+	*rootfs_provider.CakeOrdinator
+	Layers *MappingLayerCreator
+}
+
+// CreateMapped creates a rootfs for a container with its own id mappings.
+func (s *ShedVolumeCreator) CreateMapped(log lager.Logger, handle string, spec rootfs_provider.Spec, mappings gardener.IDMappings) (string, []string, error) {
+	s.Layers.assign(handle, mappings)
+	defer s.Layers.unassign(handle)
+
+	return s.CakeOrdinator.Create(log, handle, spec)
+}
+
+// MappingLayerCreator creates the layer of each container with garden-shed,
+// namespacing it with the mappings assigned to the container, or with
+// Default. garden-shed keys namespaced layers by their mappings, so
+// containers with different mappings do not share them.
+type MappingLayerCreator struct {
+	Cake    layercake.Cake
+	Default gardener.IDMappings
+	Logger  lager.Logger
+
+	mu       sync.Mutex
+	assigned map[string]gardener.IDMappings
+
+	// creators has a LayerCreator for each set of mappings, as each
+	// serializes the namespacing of its layers
+	creators map[string]rootfs_provider.LayerCreator
+}
+
+func (c *MappingLayerCreator) Create(id string, parentImage *repository_fetcher.Image, spec rootfs_provider.Spec) (string, []string, error) {
+	return c.creator(id).Create(id, parentImage, spec)
+}
+
+func (c *MappingLayerCreator) creator(handle string) rootfs_provider.LayerCreator {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	mappings, ok := c.assigned[handle]
+	if !ok {
+		mappings = c.Default
+	}
+
+	key := fmt.Sprintf("%v+%v", mappings.UID, mappings.GID)
+	if creator, ok := c.creators[key]; ok {
+		return creator
+	}
+
+	if c.creators == nil {
+		c.creators = make(map[string]rootfs_provider.LayerCreator)
+	}
+
+	creator := rootfs_provider.NewLayerCreator(c.Cake, rootfs_provider.SimpleVolumeCreator{}, &rootfs_provider.UidNamespacer{
+		Logger:     c.Logger,
+		Translator: rootfs_provider.NewUidTranslator(mappings.UID, mappings.GID),
+	})
+	c.creators[key] = creator
+
+	return creator
+}
+
+func (c *MappingLayerCreator) assign(handle string, mappings gardener.IDMappings) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.assigned == nil {
+		c.assigned = make(map[string]gardener.IDMappings)
+	}
+
+	c.assigned[handle] = mappings
+}
+
+func (c *MappingLayerCreator) unassign(handle string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	delete(c.assigned, handle)
+}
@@ -0,0 +1,263 @@
+package imageplugin
+
+import (
+	"archive/tar"
+	"bufio"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+)
+
+const (
+	whiteoutPrefix = ".wh."
+	opaqueWhiteout = ".wh..wh..opq"
+)
+
+// IDMapper maps a uid or gid in an image to the id on the host
+type IDMapper func(id int) int
+
+func IdentityMapper(id int) int {
+	return id
+}
+
+// Unpacker applies image layers (optionally gzipped tar archives) to a
+// rootfs directory, processing whiteouts. Paths in a layer are resolved
+// within the rootfs, including through symlinks created by earlier layers,
+// so that a malicious image cannot write outside it.
+type Unpacker struct{}
+
+func (u Unpacker) Unpack(layerPath, rootfs string, mapUID, mapGID IDMapper) error {
+	f, err := os.Open(layerPath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	buffered := bufio.NewReader(f)
+	var stream io.Reader = buffered
+	if magic, err := buffered.Peek(2); err == nil && magic[0] == 0x1f && magic[1] == 0x8b {
+		gz, err := gzip.NewReader(buffered)
+		if err != nil {
+			return err
+		}
+		defer gz.Close()
+
+		stream = gz
+	}
+
+	tr := tar.NewReader(stream)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+
+		if err != nil {
+			return fmt.Errorf("read layer: %s", err)
+		}
+
+		if err := u.apply(rootfs, hdr, tr, mapUID, mapGID); err != nil {
+			return fmt.Errorf("unpack %s: %s", hdr.Name, err)
+		}
+	}
+}
+
+func (u Unpacker) apply(rootfs string, hdr *tar.Header, content io.Reader, mapUID, mapGID IDMapper) error {
+	name := filepath.Clean("/" + hdr.Name)
+	if name == "/" {
+		return nil
+	}
+
+	dir, base := filepath.Split(name)
+	parent, err := resolveInRoot(rootfs, dir)
+	if err != nil {
+		return err
+	}
+
+	if base == opaqueWhiteout {
+		return removeContents(parent)
+	}
+
+	if strings.HasPrefix(base, whiteoutPrefix) {
+		target, err := whiteoutTarget(rootfs, parent, strings.TrimPrefix(base, whiteoutPrefix))
+		if err != nil {
+			return err
+		}
+
+		return os.RemoveAll(target)
+	}
+
+	if err := os.MkdirAll(parent, 0755); err != nil {
+		return err
+	}
+
+	target := filepath.Join(parent, base)
+	if info, err := os.Lstat(target); err == nil && !(info.IsDir() && hdr.Typeflag == tar.TypeDir) {
+		if err := os.RemoveAll(target); err != nil {
+			return err
+		}
+	}
+
+	mode := hdr.FileInfo().Mode()
+	switch hdr.Typeflag {
+	case tar.TypeDir:
+		if err := os.MkdirAll(target, mode.Perm()); err != nil {
+			return err
+		}
+	case tar.TypeReg, tar.TypeRegA:
+		if err := writeFile(target, content, mode.Perm()); err != nil {
+			return err
+		}
+	case tar.TypeSymlink:
+		if err := os.Symlink(hdr.Linkname, target); err != nil {
+			return err
+		}
+	case tar.TypeLink:
+		linkDir, linkBase := filepath.Split(filepath.Clean("/" + hdr.Linkname))
+		source, err := resolveInRoot(rootfs, linkDir)
+		if err != nil {
+			return err
+		}
+
+		if err := os.Link(filepath.Join(source, linkBase), target); err != nil {
+			return err
+		}
+	case tar.TypeChar, tar.TypeBlock, tar.TypeFifo:
+		if err := mknod(target, deviceMode(hdr), mkdev(hdr.Devmajor, hdr.Devminor)); err != nil {
+			return err
+		}
+	default:
+		// e.g. extended headers, which carry nothing needed in the rootfs
+		return nil
+	}
+
+	if err := os.Lchown(target, mapUID(hdr.Uid), mapGID(hdr.Gid)); err != nil {
+		return err
+	}
+
+	if hdr.Typeflag == tar.TypeSymlink || hdr.Typeflag == tar.TypeLink {
+		return nil
+	}
+
+	// chown clears the setuid and setgid bits, so the mode is set afterwards
+	if err := os.Chmod(target, mode); err != nil {
+		return err
+	}
+
+	return os.Chtimes(target, hdr.ModTime, hdr.ModTime)
+}
+
+// whiteoutTarget returns the path a whiteout of name in the (resolved)
+// parent directory removes. The whited-out entry itself is not resolved, so
+// that a whiteout of a symlink removes the link rather than what it points
+// to, but it must name an entry strictly within the rootfs.
+func whiteoutTarget(rootfs, parent, name string) (string, error) {
+	if name == "" || name == "." || name == ".." || strings.ContainsRune(name, filepath.Separator) {
+		return "", fmt.Errorf("invalid whiteout: %q", whiteoutPrefix+name)
+	}
+
+	target := filepath.Join(parent, name)
+	if !strings.HasPrefix(target, filepath.Clean(rootfs)+string(filepath.Separator)) {
+		return "", fmt.Errorf("whiteout outside rootfs: %q", whiteoutPrefix+name)
+	}
+
+	return target, nil
+}
+
+// resolveInRoot returns the host path of path within root, following
+// symlinks as if root were the filesystem root.
+func resolveInRoot(root, path string) (string, error) {
+	resolved := "/"
+	remaining := strings.Split(filepath.Clean("/"+path), "/")
+
+	for links := 0; len(remaining) > 0; {
+		component := remaining[0]
+		remaining = remaining[1:]
+
+		switch component {
+		case "", ".":
+			continue
+		case "..":
+			resolved = filepath.Dir(resolved)
+			continue
+		}
+
+		next := filepath.Join(resolved, component)
+		info, err := os.Lstat(filepath.Join(root, next))
+		if err != nil || info.Mode()&os.ModeSymlink == 0 {
+			resolved = next
+			continue
+		}
+
+		if links++; links > 255 {
+			return "", errors.New("too many levels of symbolic links")
+		}
+
+		target, err := os.Readlink(filepath.Join(root, next))
+		if err != nil {
+			return "", err
+		}
+
+		if filepath.IsAbs(target) {
+			resolved = "/"
+		}
+
+		remaining = append(strings.Split(target, "/"), remaining...)
+	}
+
+	return filepath.Join(root, resolved), nil
+}
+
+func removeContents(dir string) error {
+	entries, err := ioutil.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		if err := os.RemoveAll(filepath.Join(dir, entry.Name())); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func writeFile(path string, content io.Reader, perm os.FileMode) error {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, perm)
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.Copy(f, content); err != nil {
+		f.Close()
+		return err
+	}
+
+	return f.Close()
+}
+
+func deviceMode(hdr *tar.Header) uint32 {
+	mode := uint32(hdr.Mode) & 07777
+	switch hdr.Typeflag {
+	case tar.TypeChar:
+		return mode | syscall.S_IFCHR
+	case tar.TypeBlock:
+		return mode | syscall.S_IFBLK
+	default:
+		return mode | syscall.S_IFIFO
+	}
+}
+
+// mkdev encodes a device number as the kernel's new_encode_dev does
+func mkdev(major, minor int64) int {
+	return int((minor & 0xff) | (major&0xfff)<<8 | (minor&^0xff)<<12)
+}
@@ -0,0 +1,178 @@
+package imageplugin_test
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/cloudfoundry-incubator/guardian/imageplugin"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+)
+
+type layerEntry struct {
+	Name     string
+	Type     byte
+	Content  string
+	Linkname string
+	Mode     int64
+}
+
+func writeLayer(path string, gzipped bool, entries ...layerEntry) {
+	buf := &bytes.Buffer{}
+	tw := tar.NewWriter(buf)
+	for _, e := range entries {
+		mode := e.Mode
+		if mode == 0 {
+			mode = 0755
+		}
+
+		Expect(tw.WriteHeader(&tar.Header{
+			Name:     e.Name,
+			Typeflag: e.Type,
+			Linkname: e.Linkname,
+			Mode:     mode,
+			Size:     int64(len(e.Content)),
+			Uid:      os.Getuid(),
+			Gid:      os.Getgid(),
+		})).To(Succeed())
+
+		_, err := tw.Write([]byte(e.Content))
+		Expect(err).NotTo(HaveOccurred())
+	}
+	Expect(tw.Close()).To(Succeed())
+
+	data := buf.Bytes()
+	if gzipped {
+		gzBuf := &bytes.Buffer{}
+		gz := gzip.NewWriter(gzBuf)
+		_, err := gz.Write(data)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(gz.Close()).To(Succeed())
+		data = gzBuf.Bytes()
+	}
+
+	Expect(ioutil.WriteFile(path, data, 0600)).To(Succeed())
+}
+
+var _ = Describe("Unpacker", func() {
+	var (
+		tmpDir    string
+		rootfs    string
+		layerPath string
+		unpacker  imageplugin.Unpacker
+	)
+
+	unpack := func() error {
+		return unpacker.Unpack(layerPath, rootfs, imageplugin.IdentityMapper, imageplugin.IdentityMapper)
+	}
+
+	BeforeEach(func() {
+		var err error
+		tmpDir, err = ioutil.TempDir("", "unpack")
+		Expect(err).NotTo(HaveOccurred())
+
+		rootfs = filepath.Join(tmpDir, "rootfs")
+		Expect(os.Mkdir(rootfs, 0755)).To(Succeed())
+		layerPath = filepath.Join(tmpDir, "layer.tar")
+	})
+
+	AfterEach(func() {
+		Expect(os.RemoveAll(tmpDir)).To(Succeed())
+	})
+
+	It("unpacks directories, files, symlinks and hardlinks", func() {
+		writeLayer(layerPath, false,
+			layerEntry{Name: "etc/", Type: tar.TypeDir},
+			layerEntry{Name: "etc/hostname", Type: tar.TypeReg, Content: "box", Mode: 0640},
+			layerEntry{Name: "etc/link", Type: tar.TypeSymlink, Linkname: "hostname"},
+			layerEntry{Name: "etc/hard", Type: tar.TypeLink, Linkname: "etc/hostname"},
+		)
+		Expect(unpack()).To(Succeed())
+
+		Expect(ioutil.ReadFile(filepath.Join(rootfs, "etc", "hostname"))).To(Equal([]byte("box")))
+		info, err := os.Stat(filepath.Join(rootfs, "etc", "hostname"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(info.Mode().Perm()).To(Equal(os.FileMode(0640)))
+
+		Expect(os.Readlink(filepath.Join(rootfs, "etc", "link"))).To(Equal("hostname"))
+		Expect(ioutil.ReadFile(filepath.Join(rootfs, "etc", "hard"))).To(Equal([]byte("box")))
+	})
+
+	It("unpacks gzipped layers", func() {
+		writeLayer(layerPath, true, layerEntry{Name: "file", Type: tar.TypeReg, Content: "zipped"})
+		Expect(unpack()).To(Succeed())
+		Expect(ioutil.ReadFile(filepath.Join(rootfs, "file"))).To(Equal([]byte("zipped")))
+	})
+
+	It("replaces files from earlier layers", func() {
+		Expect(ioutil.WriteFile(filepath.Join(rootfs, "file"), []byte("old"), 0644)).To(Succeed())
+
+		writeLayer(layerPath, false, layerEntry{Name: "file", Type: tar.TypeReg, Content: "new"})
+		Expect(unpack()).To(Succeed())
+		Expect(ioutil.ReadFile(filepath.Join(rootfs, "file"))).To(Equal([]byte("new")))
+	})
+
+	It("removes whited-out files", func() {
+		Expect(ioutil.WriteFile(filepath.Join(rootfs, "deleted"), []byte("old"), 0644)).To(Succeed())
+
+		writeLayer(layerPath, false, layerEntry{Name: ".wh.deleted", Type: tar.TypeReg})
+		Expect(unpack()).To(Succeed())
+		Expect(filepath.Join(rootfs, "deleted")).NotTo(BeAnExistingFile())
+		Expect(filepath.Join(rootfs, ".wh.deleted")).NotTo(BeAnExistingFile())
+	})
+
+	DescribeTable("rejects whiteouts that do not name an entry in the rootfs",
+		func(name string) {
+			Expect(ioutil.WriteFile(filepath.Join(rootfs, "kept"), []byte("kept"), 0644)).To(Succeed())
+			Expect(ioutil.WriteFile(filepath.Join(tmpDir, "sibling"), []byte("sibling"), 0644)).To(Succeed())
+
+			writeLayer(layerPath, false, layerEntry{Name: name, Type: tar.TypeReg})
+			Expect(unpack()).To(MatchError(ContainSubstring("invalid whiteout")))
+
+			Expect(filepath.Join(rootfs, "kept")).To(BeAnExistingFile())
+			Expect(filepath.Join(tmpDir, "sibling")).To(BeAnExistingFile())
+		},
+		Entry("empty", ".wh."),
+		Entry("the directory itself", ".wh.."),
+		Entry("the parent directory", ".wh..."),
+		Entry("the parent directory of a subdirectory", "dir/.wh..."),
+	)
+
+	It("empties directories with an opaque whiteout", func() {
+		Expect(os.Mkdir(filepath.Join(rootfs, "dir"), 0755)).To(Succeed())
+		Expect(ioutil.WriteFile(filepath.Join(rootfs, "dir", "old"), []byte("old"), 0644)).To(Succeed())
+
+		writeLayer(layerPath, false,
+			layerEntry{Name: "dir/.wh..wh..opq", Type: tar.TypeReg},
+			layerEntry{Name: "dir/new", Type: tar.TypeReg, Content: "new"},
+		)
+		Expect(unpack()).To(Succeed())
+
+		Expect(filepath.Join(rootfs, "dir", "old")).NotTo(BeAnExistingFile())
+		Expect(filepath.Join(rootfs, "dir", "new")).To(BeAnExistingFile())
+	})
+
+	It("does not follow '..' out of the rootfs", func() {
+		writeLayer(layerPath, false, layerEntry{Name: "../../escaped", Type: tar.TypeReg, Content: "x"})
+		Expect(unpack()).To(Succeed())
+
+		Expect(filepath.Join(tmpDir, "escaped")).NotTo(BeAnExistingFile())
+		Expect(filepath.Join(rootfs, "escaped")).To(BeAnExistingFile())
+	})
+
+	It("resolves symlinks from earlier layers within the rootfs", func() {
+		writeLayer(layerPath, false,
+			layerEntry{Name: "evil", Type: tar.TypeSymlink, Linkname: tmpDir},
+			layerEntry{Name: "evil/escaped", Type: tar.TypeReg, Content: "x"},
+		)
+		Expect(unpack()).To(Succeed())
+
+		Expect(filepath.Join(tmpDir, "escaped")).NotTo(BeAnExistingFile())
+		Expect(filepath.Join(rootfs, tmpDir, "escaped")).To(BeAnExistingFile())
+	})
+})
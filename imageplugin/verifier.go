@@ -0,0 +1,53 @@
+package imageplugin
+
+import (
+	"bytes"
+	"fmt"
+	"net/url"
+	"os/exec"
+	"regexp"
+	"strings"
+
+	"github.com/cloudfoundry/gunk/command_runner"
+	"github.com/pivotal-golang/lager"
+)
+
+// ExternalVerifier verifies the signatures of images by running a verifier
+// binary, e.g. a wrapper around notary or cosign:
+//
+//	<binary> <extra args> verify <image>
+//
+// where the image is the container's rootfs, e.g. docker:///busybox#1.24.
+// The binary exits 0 and prints the digest of the image it verified, e.g.
+// sha256:..., if the image is signed by a trusted party; otherwise what it
+// prints on stderr is why it is not. CommandRunner should time the binary
+// out (see cmdtimeout), so that a verifier which hangs cannot hang creates.
+type ExternalVerifier struct {
+	Binary        string
+	ExtraArgs     []string
+	CommandRunner command_runner.CommandRunner
+}
+
+var digestPattern = regexp.MustCompile(`^[a-z0-9]+:[a-f0-9]{32,}$`)
+
+func (v *ExternalVerifier) VerifyImage(log lager.Logger, image *url.URL) (string, error) {
+	log.Info("started")
+	defer log.Info("finished")
+
+	stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+	cmd := exec.Command(v.Binary, append(append([]string{}, v.ExtraArgs...), "verify", image.String())...)
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+
+	if err := v.CommandRunner.Run(cmd); err != nil {
+		log.Error("image-verifier-failed", err, lager.Data{"stderr": stderr.String()})
+		return "", fmt.Errorf("image verifier: %s: %s", err, strings.TrimSpace(stderr.String()))
+	}
+
+	digest := strings.TrimSpace(stdout.String())
+	if !digestPattern.MatchString(digest) {
+		return "", fmt.Errorf("image verifier: invalid digest: '%s'", digest)
+	}
+
+	return digest, nil
+}
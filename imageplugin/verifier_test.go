@@ -0,0 +1,80 @@
+package imageplugin_test
+
+import (
+	"errors"
+	"net/url"
+	"os/exec"
+
+	"github.com/cloudfoundry-incubator/guardian/imageplugin"
+	"github.com/cloudfoundry/gunk/command_runner/fake_command_runner"
+	. "github.com/cloudfoundry/gunk/command_runner/fake_command_runner/matchers"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/pivotal-golang/lager/lagertest"
+)
+
+var _ = Describe("ExternalVerifier", func() {
+	var (
+		commandRunner *fake_command_runner.FakeCommandRunner
+		verifier      *imageplugin.ExternalVerifier
+		image         *url.URL
+
+		verifierStdout string
+		verifierStderr string
+		verifierErr    error
+	)
+
+	BeforeEach(func() {
+		commandRunner = fake_command_runner.New()
+		verifier = &imageplugin.ExternalVerifier{
+			Binary:        "/path/to/verifier",
+			ExtraArgs:     []string{"--trust-root", "/var/trust"},
+			CommandRunner: commandRunner,
+		}
+
+		var err error
+		image, err = url.Parse("docker:///busybox#1.24")
+		Expect(err).NotTo(HaveOccurred())
+
+		verifierStdout = "sha256:0123456789abcdef0123456789abcdef\n"
+		verifierStderr = ""
+		verifierErr = nil
+		commandRunner.WhenRunning(fake_command_runner.CommandSpec{Path: "/path/to/verifier"}, func(cmd *exec.Cmd) error {
+			cmd.Stdout.Write([]byte(verifierStdout))
+			cmd.Stderr.Write([]byte(verifierStderr))
+			return verifierErr
+		})
+	})
+
+	It("runs the verifier's verify command with the image", func() {
+		_, err := verifier.VerifyImage(lagertest.NewTestLogger("test"), image)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(commandRunner).To(HaveExecutedSerially(fake_command_runner.CommandSpec{
+			Path: "/path/to/verifier",
+			Args: []string{"--trust-root", "/var/trust", "verify", "docker:///busybox#1.24"},
+		}))
+	})
+
+	It("returns the digest of the image the verifier verified", func() {
+		digest, err := verifier.VerifyImage(lagertest.NewTestLogger("test"), image)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(digest).To(Equal("sha256:0123456789abcdef0123456789abcdef"))
+	})
+
+	It("fails when the verifier does not print a digest", func() {
+		verifierStdout = "verified\n"
+
+		_, err := verifier.VerifyImage(lagertest.NewTestLogger("test"), image)
+		Expect(err).To(MatchError("image verifier: invalid digest: 'verified'"))
+	})
+
+	It("returns why the image is not trusted when the verifier fails", func() {
+		verifierStdout = ""
+		verifierStderr = "no signatures found\n"
+		verifierErr = errors.New("exit status 1")
+
+		_, err := verifier.VerifyImage(lagertest.NewTestLogger("test"), image)
+		Expect(err).To(MatchError("image verifier: exit status 1: no signatures found"))
+	})
+})
@@ -5,6 +5,7 @@ import (
 	"net"
 	"strings"
 
+	"github.com/cloudfoundry-incubator/guardian/gardener"
 	"github.com/cloudfoundry-incubator/guardian/kawasaki/subnets"
 	"github.com/pivotal-golang/lager"
 )
@@ -31,6 +32,19 @@ type NetworkConfig struct {
 	ExternalIP      net.IP
 	Subnet          *net.IPNet
 	Mtu             int
+
+	// IPv6 configuration; these are nil unless an IPv6 pool is configured
+	BridgeIPv6    net.IP
+	ContainerIPv6 net.IP
+	SubnetV6      *net.IPNet
+
+	// Routes are static routes added to the container's network namespace,
+	// besides the default routes
+	Routes []gardener.Route
+
+	// LogDenied logs the new outbound connections which none of the
+	// container's net-out rules allow
+	LogDenied bool
 }
 
 type Creator struct {
@@ -38,9 +52,10 @@ type Creator struct {
 	interfacePrefix string
 	chainPrefix     string
 	externalIP      net.IP
+	logDenied       bool
 }
 
-func NewConfigCreator(idGenerator IDGenerator, interfacePrefix, chainPrefix string, externalIP net.IP) *Creator {
+func NewConfigCreator(idGenerator IDGenerator, interfacePrefix, chainPrefix string, externalIP net.IP, logDenied bool) *Creator {
 	if len(interfacePrefix) > maxInterfacePrefixLen {
 		panic("interface prefix is too long")
 	}
@@ -54,6 +69,7 @@ func NewConfigCreator(idGenerator IDGenerator, interfacePrefix, chainPrefix stri
 		interfacePrefix: interfacePrefix,
 		chainPrefix:     chainPrefix,
 		externalIP:      externalIP,
+		logDenied:       logDenied,
 	}
 }
 
@@ -68,6 +84,7 @@ func (c *Creator) Create(log lager.Logger, handle string, subnet *net.IPNet, ip
 		ContainerIP:     ip,
 		BridgeIP:        subnets.GatewayIP(subnet),
 		ExternalIP:      c.externalIP,
+		LogDenied:       c.logDenied,
 		Subnet:          subnet,
 		Mtu:             1500,
 	}, nil
@@ -31,18 +31,18 @@ var _ = Describe("ConfigCreator", func() {
 		logger = lagertest.NewTestLogger("test")
 		idGenerator = &fakes.FakeIDGenerator{}
 
-		creator = kawasaki.NewConfigCreator(idGenerator, "w1", "0123456789abcdef", externalIP)
+		creator = kawasaki.NewConfigCreator(idGenerator, "w1", "0123456789abcdef", externalIP, false)
 	})
 
 	It("panics if the interface prefix is longer than 2 characters", func() {
 		Expect(func() {
-			kawasaki.NewConfigCreator(idGenerator, "too-long", "wc", externalIP)
+			kawasaki.NewConfigCreator(idGenerator, "too-long", "wc", externalIP, false)
 		}).To(Panic())
 	})
 
 	It("panics if the chain prefix is longer than 16 characters", func() {
 		Expect(func() {
-			kawasaki.NewConfigCreator(idGenerator, "w1", "0123456789abcdefg", externalIP)
+			kawasaki.NewConfigCreator(idGenerator, "w1", "0123456789abcdefg", externalIP, false)
 		}).To(Panic())
 	})
 
@@ -95,6 +95,26 @@ var _ = Describe("ConfigCreator", func() {
 		Expect(config.ExternalIP.String()).To(Equal("220.10.120.5"))
 	})
 
+	It("does not log denied connections by default", func() {
+		config, err := creator.Create(logger, "banana", subnet, ip)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(config.LogDenied).To(BeFalse())
+	})
+
+	Context("when denied connections are logged", func() {
+		BeforeEach(func() {
+			creator = kawasaki.NewConfigCreator(idGenerator, "w1", "0123456789abcdef", externalIP, true)
+		})
+
+		It("saves it in the config", func() {
+			config, err := creator.Create(logger, "banana", subnet, ip)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(config.LogDenied).To(BeTrue())
+		})
+	})
+
 	It("saves the subnet and ip", func() {
 		config, err := creator.Create(logger, "banana", subnet, ip)
 		Expect(err).NotTo(HaveOccurred())
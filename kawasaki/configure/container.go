@@ -11,10 +11,15 @@ type Container struct {
 	Link interface {
 		AddIP(intf *net.Interface, ip net.IP, subnet *net.IPNet) error
 		AddDefaultGW(intf *net.Interface, ip net.IP) error
+		AddRoute(intf *net.Interface, destination *net.IPNet, via net.IP) error
 		SetUp(intf *net.Interface) error
 		SetMTU(intf *net.Interface, mtu int) error
 		InterfaceByName(name string) (*net.Interface, bool, error)
 	}
+
+	Neighbor interface {
+		AnnounceIP(intf *net.Interface, ip net.IP) error
+	}
 }
 
 func (c *Container) Apply(log lager.Logger, config kawasaki.NetworkConfig) error {
@@ -22,14 +27,30 @@ func (c *Container) Apply(log lager.Logger, config kawasaki.NetworkConfig) error
 		return err
 	}
 
-	return c.configureContainerIntf(
+	if err := c.configureContainerIntf(
 		log,
 		config.ContainerIntf,
 		config.ContainerIP,
 		config.BridgeIP,
 		config.Subnet,
 		config.Mtu,
-	)
+	); err != nil {
+		return err
+	}
+
+	if config.ContainerIPv6 != nil {
+		if err := c.configureContainerIntfV6(
+			log,
+			config.ContainerIntf,
+			config.ContainerIPv6,
+			config.BridgeIPv6,
+			config.SubnetV6,
+		); err != nil {
+			return err
+		}
+	}
+
+	return c.configureRoutes(log, config)
 }
 
 func (c *Container) configureContainerIntf(log lager.Logger, name string, ip, gatewayIP net.IP, subnet *net.IPNet, mtu int) (err error) {
@@ -65,10 +86,76 @@ func (c *Container) configureContainerIntf(log lager.Logger, name string, ip, ga
 		return &MTUError{err, intf, mtu}
 	}
 
+	// the IP may have belonged to a recently destroyed container, so update
+	// any stale neighbor entries for it. This is only an optimisation: they
+	// would expire eventually, so failing to send is not fatal.
+	if err := c.Neighbor.AnnounceIP(intf, ip); err != nil {
+		cLog.Error("announce-ip-failed", err)
+	}
+
+	cLog.Debug("done")
+	return nil
+}
+
+// configureContainerIntfV6 adds an IPv6 address and default route to an
+// interface which has already been brought up by configureContainerIntf.
+func (c *Container) configureContainerIntfV6(log lager.Logger, name string, ip, gatewayIP net.IP, subnet *net.IPNet) (err error) {
+	cLog := log.Session("configure-container-ipv6", lager.Data{
+		"name":    name,
+		"ip":      ip,
+		"gateway": gatewayIP,
+		"subnet":  subnet,
+	})
+
+	cLog.Debug("start")
+
+	var found bool
+	var intf *net.Interface
+	if intf, found, err = c.Link.InterfaceByName(name); !found || err != nil {
+		return &FindLinkError{err, "container", name}
+	}
+
+	if err := c.Link.AddIP(intf, ip, subnet); err != nil {
+		return &ConfigureLinkError{err, "container", intf, ip, subnet}
+	}
+
+	if err := c.Link.AddDefaultGW(intf, gatewayIP); err != nil {
+		return &ConfigureDefaultGWError{err, intf, gatewayIP}
+	}
+
 	cLog.Debug("done")
 	return nil
 }
 
+// configureRoutes adds the container's static routes to its interface, via
+// its gateway in the route's address family unless they have a next hop
+func (c *Container) configureRoutes(log lager.Logger, config kawasaki.NetworkConfig) error {
+	if len(config.Routes) == 0 {
+		return nil
+	}
+
+	intf, found, err := c.Link.InterfaceByName(config.ContainerIntf)
+	if !found || err != nil {
+		return &FindLinkError{err, "container", config.ContainerIntf}
+	}
+
+	for _, route := range config.Routes {
+		via := route.Via
+		if via == nil && route.Destination.IP.To4() != nil {
+			via = config.BridgeIP
+		} else if via == nil {
+			via = config.BridgeIPv6
+		}
+
+		log.Debug("add-route", lager.Data{"destination": route.Destination, "via": via})
+		if err := c.Link.AddRoute(intf, route.Destination, via); err != nil {
+			return &ConfigureRouteError{err, intf, route.Destination, via}
+		}
+	}
+
+	return nil
+}
+
 func (c *Container) configureLoopbackIntf() (err error) {
 	var found bool
 	var lo *net.Interface
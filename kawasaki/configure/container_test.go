@@ -4,6 +4,7 @@ import (
 	"errors"
 	"net"
 
+	"github.com/cloudfoundry-incubator/guardian/gardener"
 	"github.com/cloudfoundry-incubator/guardian/kawasaki"
 	"github.com/cloudfoundry-incubator/guardian/kawasaki/configure"
 	"github.com/cloudfoundry-incubator/guardian/kawasaki/devices/fakedevices"
@@ -17,6 +18,7 @@ import (
 var _ = Describe("Container", func() {
 	var (
 		linkApplyr *fakedevices.FakeLink
+		neighbor   *fakedevices.FakeNeighbor
 		configurer *configure.Container
 		config     kawasaki.NetworkConfig
 		logger     lager.Logger
@@ -25,8 +27,10 @@ var _ = Describe("Container", func() {
 	BeforeEach(func() {
 		logger = lagertest.NewTestLogger("test")
 		linkApplyr = &fakedevices.FakeLink{AddIPReturns: make(map[string]error)}
+		neighbor = &fakedevices.FakeNeighbor{}
 		configurer = &configure.Container{
-			Link: linkApplyr,
+			Link:     linkApplyr,
+			Neighbor: neighbor,
 		}
 	})
 
@@ -209,5 +213,86 @@ var _ = Describe("Container", func() {
 				Expect(err).To(MatchError(&configure.ConfigureDefaultGWError{Cause: linkApplyr.AddDefaultGWReturns, Interface: &net.Interface{Name: "foo"}, IP: net.ParseIP("2.3.4.5")}))
 			})
 		})
+
+		It("adds the container's routes, via its gateway unless they have a next hop", func() {
+			_, serviceNet, _ := net.ParseCIDR("10.100.0.0/16")
+			_, otherNet, _ := net.ParseCIDR("172.20.0.0/16")
+
+			config.ContainerIntf = "foo"
+			config.BridgeIP = net.ParseIP("2.3.4.5")
+			config.Routes = []gardener.Route{
+				{Destination: serviceNet},
+				{Destination: otherNet, Via: net.ParseIP("2.3.4.9")},
+			}
+			Expect(configurer.Apply(logger, config)).To(Succeed())
+
+			Expect(linkApplyr.AddRouteCalledWith).To(Equal([]fakedevices.InterfaceRoute{
+				{Interface: &net.Interface{Name: "foo"}, Destination: serviceNet, Via: net.ParseIP("2.3.4.5")},
+				{Interface: &net.Interface{Name: "foo"}, Destination: otherNet, Via: net.ParseIP("2.3.4.9")},
+			}))
+		})
+
+		Context("when adding a route fails", func() {
+			It("returns a wrapped error", func() {
+				_, serviceNet, _ := net.ParseCIDR("10.100.0.0/16")
+				linkApplyr.AddRouteReturns = errors.New("network unreachable")
+
+				config.ContainerIntf = "foo"
+				config.BridgeIP = net.ParseIP("2.3.4.5")
+				config.Routes = []gardener.Route{{Destination: serviceNet}}
+				err := configurer.Apply(logger, config)
+				Expect(err).To(MatchError(&configure.ConfigureRouteError{Cause: linkApplyr.AddRouteReturns, Interface: &net.Interface{Name: "foo"}, Destination: serviceNet, Via: net.ParseIP("2.3.4.5")}))
+			})
+		})
+
+		It("announces the container's IP with a gratuitous ARP", func() {
+			config.ContainerIntf = "foo"
+			config.ContainerIP = net.ParseIP("2.3.4.5")
+			Expect(configurer.Apply(logger, config)).To(Succeed())
+
+			Expect(neighbor.AnnounceIPCalledWith).To(ConsistOf(fakedevices.InterfaceAndIP{
+				Interface: &net.Interface{Name: "foo"},
+				IP:        net.ParseIP("2.3.4.5"),
+			}))
+		})
+
+		Context("when announcing the IP fails", func() {
+			It("still succeeds", func() {
+				neighbor.AnnounceIPReturns = errors.New("no arp for you")
+
+				config.ContainerIntf = "foo"
+				Expect(configurer.Apply(logger, config)).To(Succeed())
+			})
+		})
+
+		Context("when an IPv6 address is configured", func() {
+			BeforeEach(func() {
+				config.ContainerIntf = "foo"
+				config.ContainerIP, config.Subnet, _ = net.ParseCIDR("2.3.4.5/30")
+				config.BridgeIP = net.ParseIP("2.3.4.6")
+				config.ContainerIPv6, config.SubnetV6, _ = net.ParseCIDR("fd00::2/126")
+				config.BridgeIPv6 = net.ParseIP("fd00::1")
+			})
+
+			It("adds the requested IPv6 address as well as the IPv4 address", func() {
+				Expect(configurer.Apply(logger, config)).To(Succeed())
+				Expect(linkApplyr.AddIPCalledWith).To(ContainElement(fakedevices.InterfaceIPAndSubnet{
+					Interface: &net.Interface{Name: "foo"},
+					IP:        config.ContainerIP,
+					Subnet:    config.Subnet,
+				}))
+				Expect(linkApplyr.AddIPCalledWith).To(ContainElement(fakedevices.InterfaceIPAndSubnet{
+					Interface: &net.Interface{Name: "foo"},
+					IP:        config.ContainerIPv6,
+					Subnet:    config.SubnetV6,
+				}))
+			})
+
+			It("adds an IPv6 default gateway via the bridge", func() {
+				Expect(configurer.Apply(logger, config)).To(Succeed())
+				Expect(linkApplyr.AddDefaultGWCalledWith.Interface).To(Equal(&net.Interface{Name: "foo"}))
+				Expect(linkApplyr.AddDefaultGWCalledWith.IP).To(Equal(net.ParseIP("fd00::1")))
+			})
+		})
 	})
 })
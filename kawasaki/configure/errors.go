@@ -105,6 +105,18 @@ func (err ConfigureDefaultGWError) Error() string {
 	return fmtErr("failed to set default gateway to IP %v via device %v", err.IP, err.Interface, err.Cause)
 }
 
+// ConfigureRouteError is returned if a static route cannot be added
+type ConfigureRouteError struct {
+	Cause       error
+	Interface   *net.Interface
+	Destination *net.IPNet
+	Via         net.IP
+}
+
+func (err ConfigureRouteError) Error() string {
+	return fmtErr("failed to add route to %v via %v on device %v: %v", err.Destination, err.Via, err.Interface, err.Cause)
+}
+
 // DeleteLinkError is returned if an interface cannot be succesfully destroyed
 type DeleteLinkError struct {
 	Cause error
@@ -14,6 +14,7 @@ type Host struct {
 	}
 
 	Link interface {
+		AddIP(intf *net.Interface, ip net.IP, subnet *net.IPNet) error
 		SetUp(intf *net.Interface) error
 		SetMTU(intf *net.Interface, mtu int) error
 		SetNs(intf *net.Interface, fd int) error
@@ -25,6 +26,10 @@ type Host struct {
 		Add(bridge, slave *net.Interface) error
 		Destroy(bridgeName string) error
 	}
+
+	Neighbor interface {
+		Flush(intfName string, ip net.IP) error
+	}
 }
 
 func (c *Host) Apply(logger lager.Logger, config kawasaki.NetworkConfig, netns *os.File) error {
@@ -39,6 +44,8 @@ func (c *Host) Apply(logger lager.Logger, config kawasaki.NetworkConfig, netns *
 		"bridgeName":     config.BridgeName,
 		"bridgeIP":       config.BridgeIP,
 		"subnet":         config.Subnet,
+		"bridgeIPv6":     config.BridgeIPv6,
+		"subnetV6":       config.SubnetV6,
 		"containerIface": config.ContainerIntf,
 		"hostIface":      config.HostIntf,
 		"mtu":            config.Mtu,
@@ -51,6 +58,13 @@ func (c *Host) Apply(logger lager.Logger, config kawasaki.NetworkConfig, netns *
 		return err
 	}
 
+	if config.BridgeIPv6 != nil {
+		if err = c.Link.AddIP(bridge, config.BridgeIPv6, config.SubnetV6); err != nil {
+			cLog.Error("add-bridge-ipv6", err)
+			return &ConfigureLinkError{err, "bridge", bridge, config.BridgeIPv6, config.SubnetV6}
+		}
+	}
+
 	if host, container, err = c.configureVethPair(cLog, config.HostIntf, config.ContainerIntf); err != nil {
 		return err
 	}
@@ -67,7 +81,20 @@ func (c *Host) Apply(logger lager.Logger, config kawasaki.NetworkConfig, netns *
 	return nil
 }
 
+// Destroy removes the host's neighbor entries for the container's IPs, so
+// that a container which reuses them is reachable straight away, and then
+// destroys the bridge.
 func (c *Host) Destroy(config kawasaki.NetworkConfig) error {
+	for _, ip := range []net.IP{config.ContainerIP, config.ContainerIPv6} {
+		if ip == nil {
+			continue
+		}
+
+		if err := c.Neighbor.Flush(config.BridgeName, ip); err != nil {
+			return err
+		}
+	}
+
 	return c.Bridge.Destroy(config.BridgeName)
 }
 
@@ -21,6 +21,7 @@ var _ = Describe("Host", func() {
 		vethCreator    *fakedevices.FaveVethCreator
 		linkConfigurer *fakedevices.FakeLink
 		bridger        *fakedevices.FakeBridge
+		neighbor       *fakedevices.FakeNeighbor
 
 		configurer *configure.Host
 
@@ -32,9 +33,10 @@ var _ = Describe("Host", func() {
 		vethCreator = &fakedevices.FaveVethCreator{}
 		linkConfigurer = &fakedevices.FakeLink{AddIPReturns: make(map[string]error)}
 		bridger = &fakedevices.FakeBridge{}
+		neighbor = &fakedevices.FakeNeighbor{}
 
 		logger = lagertest.NewTestLogger("test")
-		configurer = &configure.Host{Veth: vethCreator, Link: linkConfigurer, Bridge: bridger}
+		configurer = &configure.Host{Veth: vethCreator, Link: linkConfigurer, Bridge: bridger, Neighbor: neighbor}
 
 		config = kawasaki.NetworkConfig{}
 	})
@@ -199,6 +201,46 @@ var _ = Describe("Host", func() {
 					})
 				})
 			})
+
+			Describe("IPv6", func() {
+				BeforeEach(func() {
+					config.BridgeName = "bridge"
+					config.BridgeIPv6 = net.ParseIP("fd00::1")
+					_, config.SubnetV6, _ = net.ParseCIDR("fd00::/126")
+				})
+
+				It("adds the IPv6 gateway address to the bridge", func() {
+					Expect(configurer.Apply(logger, config, netnsFD)).To(Succeed())
+					Expect(linkConfigurer.AddIPCalledWith).To(ContainElement(fakedevices.InterfaceIPAndSubnet{
+						Interface: existingBridge,
+						IP:        config.BridgeIPv6,
+						Subnet:    config.SubnetV6,
+					}))
+				})
+
+				Context("when adding the IPv6 address fails", func() {
+					It("returns a wrapped error", func() {
+						linkConfigurer.AddIPReturns["bridge"] = errors.New("o no")
+
+						err := configurer.Apply(logger, config, netnsFD)
+						Expect(err).To(MatchError(&configure.ConfigureLinkError{
+							Cause:          errors.New("o no"),
+							Role:           "bridge",
+							Interface:      existingBridge,
+							IntendedIP:     config.BridgeIPv6,
+							IntendedSubnet: config.SubnetV6,
+						}))
+					})
+				})
+
+				Context("when no IPv6 address is configured", func() {
+					It("does not add any address to the bridge", func() {
+						config.BridgeIPv6 = nil
+						Expect(configurer.Apply(logger, config, netnsFD)).To(Succeed())
+						Expect(linkConfigurer.AddIPCalledWith).To(BeEmpty())
+					})
+				})
+			})
 		})
 	})
 
@@ -212,6 +254,28 @@ var _ = Describe("Host", func() {
 			Expect(bridger.DestroyCalledWith[0]).To(Equal(config.BridgeName))
 		})
 
+		It("should flush the neighbor entries for the container's IPs from the bridge", func() {
+			config.BridgeName = "spiderman-bridge"
+			config.ContainerIP = net.ParseIP("10.0.0.2")
+			config.ContainerIPv6 = net.ParseIP("fd00::2")
+			Expect(configurer.Destroy(config)).To(Succeed())
+
+			Expect(neighbor.FlushCalledWith).To(Equal([]fakedevices.NameAndIP{
+				{Name: "spiderman-bridge", IP: net.ParseIP("10.0.0.2")},
+				{Name: "spiderman-bridge", IP: net.ParseIP("fd00::2")},
+			}))
+		})
+
+		Context("when flushing the neighbor entries fails", func() {
+			It("should return an error without destroying the bridge", func() {
+				config.ContainerIP = net.ParseIP("10.0.0.2")
+				neighbor.FlushReturns = errors.New("netlink-failure")
+
+				Expect(configurer.Destroy(config)).To(MatchError("netlink-failure"))
+				Expect(bridger.DestroyCalledWith).To(BeEmpty())
+			})
+		})
+
 		Context("when bridge fails to be destroyed", func() {
 			It("should return an error", func() {
 				bridger.DestroyReturns = errors.New("banana-bridge-failure")
@@ -60,11 +60,35 @@ func (c *configurer) Apply(log lager.Logger, cfg NetworkConfig, nsPath string) e
 		return err
 	}
 
+	if cfg.ContainerIPv6 != nil {
+		if err := c.instanceChainCreator.Create(log, cfg.IPTableInstance, cfg.BridgeName, cfg.ContainerIPv6, cfg.SubnetV6); err != nil {
+			return err
+		}
+	}
+
 	return c.nsExecer.Exec(fd, func() error {
 		return c.containerApplier.Apply(log, cfg)
 	})
 }
 
+// Recover re-creates the iptables instance chains of a container whose
+// interfaces survived a restart of guardian.
+func (c *configurer) Recover(log lager.Logger, cfg NetworkConfig) error {
+	if err := c.instanceChainCreator.Destroy(log, cfg.IPTableInstance); err != nil {
+		return err
+	}
+
+	if err := c.instanceChainCreator.Create(log, cfg.IPTableInstance, cfg.BridgeName, cfg.ContainerIP, cfg.Subnet); err != nil {
+		return err
+	}
+
+	if cfg.ContainerIPv6 != nil {
+		return c.instanceChainCreator.Create(log, cfg.IPTableInstance, cfg.BridgeName, cfg.ContainerIPv6, cfg.SubnetV6)
+	}
+
+	return nil
+}
+
 func (c *configurer) Destroy(log lager.Logger, cfg NetworkConfig) error {
 	if err := c.instanceChainCreator.Destroy(log, cfg.IPTableInstance); err != nil {
 		return err
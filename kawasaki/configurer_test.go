@@ -117,6 +117,27 @@ var _ = Describe("Configurer", func() {
 				Expect(subnet).To(Equal(subnet))
 			})
 
+			It("applies the iptable configuration for the IPv6 address when one is configured", func() {
+				_, subnet, _ := net.ParseCIDR("1.2.3.4/30")
+				containerIPv6, subnetV6, _ := net.ParseCIDR("fd00::2/126")
+				cfg := kawasaki.NetworkConfig{
+					IPTableInstance: "instance",
+					BridgeName:      "the-bridge-name",
+					ContainerIP:     net.ParseIP("1.2.3.4"),
+					Subnet:          subnet,
+					ContainerIPv6:   containerIPv6,
+					SubnetV6:        subnetV6,
+				}
+
+				Expect(configurer.Apply(logger, cfg, netnsFD.Name())).To(Succeed())
+				Expect(fakeInstanceChainCreator.CreateCallCount()).To(Equal(2))
+				_, instanceChain, bridgeName, ip, network := fakeInstanceChainCreator.CreateArgsForCall(1)
+				Expect(instanceChain).To(Equal("instance"))
+				Expect(bridgeName).To(Equal("the-bridge-name"))
+				Expect(ip).To(Equal(containerIPv6))
+				Expect(network).To(Equal(subnetV6))
+			})
+
 			Context("when applying IPTables configuration fails", func() {
 				It("returns the error", func() {
 					fakeInstanceChainCreator.CreateReturns(errors.New("oh no"))
@@ -176,6 +197,54 @@ var _ = Describe("Configurer", func() {
 
 	})
 
+	Describe("Recover", func() {
+		var cfg kawasaki.NetworkConfig
+
+		BeforeEach(func() {
+			cfg = kawasaki.NetworkConfig{
+				IPTableInstance: "sausages",
+				BridgeName:      "bridge",
+				ContainerIP:     net.ParseIP("1.2.3.4"),
+				Subnet:          &net.IPNet{IP: net.ParseIP("1.2.3.0"), Mask: net.CIDRMask(24, 32)},
+			}
+		})
+
+		It("re-creates the instance chains", func() {
+			Expect(configurer.Recover(logger, cfg)).To(Succeed())
+
+			Expect(fakeInstanceChainCreator.DestroyCallCount()).To(Equal(1))
+			Expect(fakeInstanceChainCreator.CreateCallCount()).To(Equal(1))
+			_, instance, bridge, ip, subnet := fakeInstanceChainCreator.CreateArgsForCall(0)
+			Expect(instance).To(Equal("sausages"))
+			Expect(bridge).To(Equal("bridge"))
+			Expect(ip).To(Equal(cfg.ContainerIP))
+			Expect(subnet).To(Equal(cfg.Subnet))
+		})
+
+		It("does not touch the host configuration", func() {
+			Expect(configurer.Recover(logger, cfg)).To(Succeed())
+
+			Expect(fakeHostConfigurer.ApplyCallCount()).To(Equal(0))
+			Expect(fakeHostConfigurer.DestroyCallCount()).To(Equal(0))
+		})
+
+		Context("when the container has an IPv6 address", func() {
+			It("re-creates the IPv6 instance chains too", func() {
+				cfg.ContainerIPv6 = net.ParseIP("fd00::2")
+				Expect(configurer.Recover(logger, cfg)).To(Succeed())
+
+				Expect(fakeInstanceChainCreator.CreateCallCount()).To(Equal(2))
+			})
+		})
+
+		Context("when creating the chains fails", func() {
+			It("returns the error", func() {
+				fakeInstanceChainCreator.CreateReturns(errors.New("iptables failed"))
+				Expect(configurer.Recover(logger, cfg)).To(MatchError("iptables failed"))
+			})
+		})
+	})
+
 	Describe("Destroy", func() {
 		It("should tear down the IP tables chains", func() {
 			cfg := kawasaki.NetworkConfig{
@@ -0,0 +1,138 @@
+package kawasaki
+
+import (
+	"bufio"
+	"io"
+	"strings"
+	"sync"
+
+	"github.com/cloudfoundry-incubator/guardian/gardener"
+	"github.com/pivotal-golang/lager"
+)
+
+//go:generate counterfeiter . HandleLister
+
+type HandleLister interface {
+	Handles() ([]string, error)
+}
+
+// deniedMarker follows the container's iptables instance in the prefix of
+// the kernel log lines of its denied connections
+const deniedMarker = " DENY "
+
+// DeniedWatcher publishes an EventNetOutDenied whenever a container makes an
+// outbound connection which none of its net-out rules allow. Such
+// connections are only logged, to the kernel log read from Log (usually
+// /dev/kmsg), when guardian is run with -denyNetworkLog. Each line names
+// the container by its iptables instance, which is mapped to its handle.
+type DeniedWatcher struct {
+	Log         io.Reader
+	Lister      HandleLister
+	ConfigStore ConfigStore
+	Publisher   gardener.EventPublisher
+	Logger      lager.Logger
+
+	mu        sync.Mutex
+	instances map[string]string
+}
+
+func (w *DeniedWatcher) Start() error {
+	go w.watch()
+	return nil
+}
+
+func (w *DeniedWatcher) watch() {
+	log := w.Logger.Session("denied-watcher")
+
+	scanner := bufio.NewScanner(w.Log)
+	for scanner.Scan() {
+		instance, data, ok := parseDenied(scanner.Text())
+		if !ok {
+			continue
+		}
+
+		handle, ok := w.handle(log, instance)
+		if !ok {
+			log.Debug("unknown-instance", lager.Data{"instance": instance})
+			continue
+		}
+
+		w.Publisher.Publish(gardener.Event{
+			Handle: handle,
+			Type:   gardener.EventNetOutDenied,
+			Data:   data,
+		})
+	}
+
+	if err := scanner.Err(); err != nil {
+		log.Error("read-log-failed", err)
+	}
+}
+
+// handle returns the handle of the container with the iptables instance,
+// looking up the instances of every container again when it is not known,
+// e.g. because the container is new
+func (w *DeniedWatcher) handle(log lager.Logger, instance string) (string, bool) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if handle, ok := w.instances[instance]; ok {
+		return handle, true
+	}
+
+	handles, err := w.Lister.Handles()
+	if err != nil {
+		log.Error("list-handles-failed", err)
+		return "", false
+	}
+
+	w.instances = make(map[string]string)
+	for _, handle := range handles {
+		if inst, err := w.ConfigStore.Get(handle, iptableInstanceKey); err == nil {
+			w.instances[inst] = handle
+		}
+	}
+
+	handle, ok := w.instances[instance]
+	return handle, ok
+}
+
+// parseDenied returns the iptables instance of the container whose
+// connection a kernel log line records, and its protocol, destination and
+// destination port, if the line records a denied connection
+func parseDenied(line string) (string, map[string]string, bool) {
+	// /dev/kmsg prefixes each message with its priority, sequence number and
+	// timestamp, up to a semicolon
+	if i := strings.Index(line, ";"); i >= 0 {
+		line = line[i+1:]
+	}
+
+	i := strings.Index(line, deniedMarker)
+	if i <= 0 {
+		return "", nil, false
+	}
+
+	instance := strings.TrimSpace(line[:i])
+	if strings.ContainsAny(instance, " \t") {
+		return "", nil, false
+	}
+
+	data := map[string]string{}
+	for _, field := range strings.Fields(line[i+len(deniedMarker):]) {
+		kv := strings.SplitN(field, "=", 2)
+		if len(kv) != 2 {
+			continue
+		}
+
+		switch kv[0] {
+		case "DST":
+			data["destination"] = kv[1]
+		case "PROTO":
+			data["protocol"] = strings.ToLower(kv[1])
+		case "DPT":
+			data["port"] = kv[1]
+		}
+	}
+
+	return instance, data, true
+}
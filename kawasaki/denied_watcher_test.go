@@ -0,0 +1,108 @@
+package kawasaki_test
+
+import (
+	"errors"
+	"io"
+
+	"github.com/cloudfoundry-incubator/guardian/gardener"
+	gardenerfakes "github.com/cloudfoundry-incubator/guardian/gardener/fakes"
+	"github.com/cloudfoundry-incubator/guardian/kawasaki"
+	"github.com/cloudfoundry-incubator/guardian/kawasaki/fakes"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/pivotal-golang/lager/lagertest"
+)
+
+var _ = Describe("DeniedWatcher", func() {
+	var (
+		logW          *io.PipeWriter
+		fakeLister    *fakes.FakeHandleLister
+		fakeStore     *fakes.FakeConfigStore
+		fakePublisher *gardenerfakes.FakeEventPublisher
+		instances     map[string]string
+	)
+
+	BeforeEach(func() {
+		var logR *io.PipeReader
+		logR, logW = io.Pipe()
+
+		instances = map[string]string{"some-handle": "0a1b2c3d4e5", "other-handle": "f6g7h8i9j0k"}
+
+		fakeLister = new(fakes.FakeHandleLister)
+		fakeLister.HandlesStub = func() ([]string, error) {
+			handles := []string{}
+			for handle := range instances {
+				handles = append(handles, handle)
+			}
+
+			return handles, nil
+		}
+
+		fakeStore = new(fakes.FakeConfigStore)
+		fakeStore.GetStub = func(handle, name string) (string, error) {
+			Expect(name).To(Equal("kawasaki.iptable-inst"))
+			return instances[handle], nil
+		}
+
+		fakePublisher = new(gardenerfakes.FakeEventPublisher)
+
+		watcher := &kawasaki.DeniedWatcher{
+			Log:         logR,
+			Lister:      fakeLister,
+			ConfigStore: fakeStore,
+			Publisher:   fakePublisher,
+			Logger:      lagertest.NewTestLogger("test"),
+		}
+		Expect(watcher.Start()).To(Succeed())
+	})
+
+	AfterEach(func() {
+		logW.Close()
+	})
+
+	It("publishes an event for the container of each denied connection", func() {
+		io.WriteString(logW, "4,1234,5678901,-;0a1b2c3d4e5 DENY IN=w1b-0a1b OUT=eth0 SRC=10.254.0.2 DST=1.2.3.4 LEN=60 PROTO=TCP SPT=40000 DPT=443 WINDOW=29200 SYN\n")
+
+		Eventually(fakePublisher.PublishCallCount).Should(Equal(1))
+		Expect(fakePublisher.PublishArgsForCall(0)).To(Equal(gardener.Event{
+			Handle: "some-handle",
+			Type:   gardener.EventNetOutDenied,
+			Data: map[string]string{
+				"destination": "1.2.3.4",
+				"protocol":    "tcp",
+				"port":        "443",
+			},
+		}))
+	})
+
+	It("ignores the lines of connections logged by net-out rules, and other lines", func() {
+		io.WriteString(logW, "4,1234,5678901,-;0a1b2c3d4e5 IN=w1b-0a1b OUT=eth0 SRC=10.254.0.2 DST=1.2.3.4 PROTO=TCP DPT=443\n")
+		io.WriteString(logW, "6,1235,5678902,-;eth0: link up\n")
+		io.WriteString(logW, "4,1236,5678903,-;f6g7h8i9j0k DENY IN=w1b-f6g7 OUT=eth0 SRC=10.254.0.6 DST=8.8.8.8 PROTO=UDP SPT=5000 DPT=53\n")
+
+		Eventually(fakePublisher.PublishCallCount).Should(Equal(1))
+		Expect(fakePublisher.PublishArgsForCall(0).Handle).To(Equal("other-handle"))
+		Expect(fakePublisher.PublishArgsForCall(0).Data["protocol"]).To(Equal("udp"))
+	})
+
+	It("finds containers created since their instances were last looked up", func() {
+		io.WriteString(logW, "4,1234,5678901,-;0a1b2c3d4e5 DENY DST=1.2.3.4 PROTO=TCP DPT=443\n")
+		Eventually(fakePublisher.PublishCallCount).Should(Equal(1))
+
+		instances["new-handle"] = "l1m2n3o4p5q"
+		io.WriteString(logW, "4,1235,5678902,-;l1m2n3o4p5q DENY DST=1.2.3.4 PROTO=TCP DPT=443\n")
+
+		Eventually(fakePublisher.PublishCallCount).Should(Equal(2))
+		Expect(fakePublisher.PublishArgsForCall(1).Handle).To(Equal("new-handle"))
+		Expect(fakeLister.HandlesCallCount()).To(Equal(2))
+	})
+
+	It("does not publish anything for unknown containers", func() {
+		fakeLister.HandlesReturns(nil, errors.New("depot gone"))
+		fakeLister.HandlesStub = nil
+
+		io.WriteString(logW, "4,1234,5678901,-;0a1b2c3d4e5 DENY DST=1.2.3.4 PROTO=TCP DPT=443\n")
+		Eventually(fakeLister.HandlesCallCount).Should(Equal(1))
+		Consistently(fakePublisher.PublishCallCount).Should(Equal(0))
+	})
+})
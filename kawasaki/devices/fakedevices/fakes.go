@@ -27,6 +27,12 @@ type InterfaceIPAndSubnet struct {
 	Subnet    *net.IPNet
 }
 
+type InterfaceRoute struct {
+	Interface   *net.Interface
+	Destination *net.IPNet
+	Via         net.IP
+}
+
 type FakeLink struct {
 	AddIPCalledWith        []InterfaceIPAndSubnet
 	SetUpCalledWith        []*net.Interface
@@ -35,6 +41,8 @@ type FakeLink struct {
 		IP        net.IP
 	}
 
+	AddRouteCalledWith []InterfaceRoute
+
 	SetMTUCalledWith struct {
 		Interface *net.Interface
 		MTU       int
@@ -50,6 +58,7 @@ type FakeLink struct {
 
 	AddIPReturns        map[string]error
 	AddDefaultGWReturns error
+	AddRouteReturns     error
 	SetMTUReturns       error
 	SetNsReturns        error
 	StatisticsReturns   error
@@ -66,6 +75,11 @@ func (f *FakeLink) AddDefaultGW(intf *net.Interface, ip net.IP) error {
 	return f.AddDefaultGWReturns
 }
 
+func (f *FakeLink) AddRoute(intf *net.Interface, destination *net.IPNet, via net.IP) error {
+	f.AddRouteCalledWith = append(f.AddRouteCalledWith, InterfaceRoute{intf, destination, via})
+	return f.AddRouteReturns
+}
+
 func (f *FakeLink) SetUp(intf *net.Interface) error {
 	f.SetUpCalledWith = append(f.SetUpCalledWith, intf)
 	if f.SetUpFunc == nil {
@@ -146,3 +160,31 @@ func (f *FakeBridge) Destroy(bridge string) error {
 	f.DestroyCalledWith = append(f.DestroyCalledWith, bridge)
 	return f.DestroyReturns
 }
+
+type InterfaceAndIP struct {
+	Interface *net.Interface
+	IP        net.IP
+}
+
+type NameAndIP struct {
+	Name string
+	IP   net.IP
+}
+
+type FakeNeighbor struct {
+	AnnounceIPCalledWith []InterfaceAndIP
+	AnnounceIPReturns    error
+
+	FlushCalledWith []NameAndIP
+	FlushReturns    error
+}
+
+func (f *FakeNeighbor) AnnounceIP(intf *net.Interface, ip net.IP) error {
+	f.AnnounceIPCalledWith = append(f.AnnounceIPCalledWith, InterfaceAndIP{intf, ip})
+	return f.AnnounceIPReturns
+}
+
+func (f *FakeNeighbor) Flush(name string, ip net.IP) error {
+	f.FlushCalledWith = append(f.FlushCalledWith, NameAndIP{name, ip})
+	return f.FlushReturns
+}
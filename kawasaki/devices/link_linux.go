@@ -46,6 +46,25 @@ func (Link) AddDefaultGW(intf *net.Interface, ip net.IP) error {
 	return errF(netlink.RouteAdd(route))
 }
 
+func (Link) AddRoute(intf *net.Interface, destination *net.IPNet, via net.IP) error {
+	netlinkMu.Lock()
+	defer netlinkMu.Unlock()
+
+	link, err := netlink.LinkByName(intf.Name)
+	if err != nil {
+		return errF(err)
+	}
+
+	route := &netlink.Route{
+		Scope:     netlink.SCOPE_UNIVERSE,
+		LinkIndex: link.Attrs().Index,
+		Dst:       destination,
+		Gw:        via,
+	}
+
+	return errF(netlink.RouteAdd(route))
+}
+
 func (Link) SetUp(intf *net.Interface) error {
 	netlinkMu.Lock()
 	defer netlinkMu.Unlock()
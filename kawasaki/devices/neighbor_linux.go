@@ -0,0 +1,103 @@
+package devices
+
+import (
+	"encoding/binary"
+	"fmt"
+	"net"
+	"syscall"
+
+	"github.com/vishvananda/netlink"
+)
+
+// Neighbor manages the ARP (and NDP) neighbor entries which refer to
+// container IPs, so that a reused IP is reachable as soon as its new
+// container is created rather than once stale entries time out.
+type Neighbor struct{}
+
+// AnnounceIP broadcasts a gratuitous ARP request for ip from the interface,
+// which updates any existing entry for ip in the neighbor tables of the
+// other hosts on its network. IPv6 addresses are announced by the kernel
+// when they are added, so only IPv4 addresses are announced here.
+func (Neighbor) AnnounceIP(intf *net.Interface, ip net.IP) error {
+	ip4 := ip.To4()
+	if ip4 == nil {
+		return nil
+	}
+
+	if len(intf.HardwareAddr) != 6 {
+		return fmt.Errorf("devices: announce ip: interface %s has no ethernet address", intf.Name)
+	}
+
+	fd, err := syscall.Socket(syscall.AF_PACKET, syscall.SOCK_DGRAM, int(htons(syscall.ETH_P_ARP)))
+	if err != nil {
+		return fmt.Errorf("devices: announce ip: %s", err)
+	}
+	defer syscall.Close(fd)
+
+	addr := &syscall.SockaddrLinklayer{
+		Protocol: htons(syscall.ETH_P_ARP),
+		Ifindex:  intf.Index,
+		Halen:    6,
+		Addr:     [8]uint8{0xff, 0xff, 0xff, 0xff, 0xff, 0xff},
+	}
+
+	if err := syscall.Sendto(fd, gratuitousARP(intf.HardwareAddr, ip4), 0, addr); err != nil {
+		return fmt.Errorf("devices: announce ip: %s", err)
+	}
+
+	return nil
+}
+
+// Flush removes any neighbor entries for ip from the named interface. It
+// does nothing if the interface does not exist.
+func (Neighbor) Flush(intfName string, ip net.IP) error {
+	netlinkMu.Lock()
+	defer netlinkMu.Unlock()
+
+	link, err := netlink.LinkByName(intfName)
+	if err != nil {
+		return nil
+	}
+
+	family := netlink.FAMILY_V6
+	if ip.To4() != nil {
+		family = netlink.FAMILY_V4
+	}
+
+	neighs, err := netlink.NeighList(link.Attrs().Index, family)
+	if err != nil {
+		return errF(err)
+	}
+
+	for _, neigh := range neighs {
+		if !neigh.IP.Equal(ip) {
+			continue
+		}
+
+		if err := netlink.NeighDel(&neigh); err != nil {
+			return errF(err)
+		}
+	}
+
+	return nil
+}
+
+// gratuitousARP builds an ARP request in which both the sender and the
+// target protocol address are ip
+func gratuitousARP(mac net.HardwareAddr, ip net.IP) []byte {
+	packet := make([]byte, 28)
+	binary.BigEndian.PutUint16(packet[0:2], 1)      // hardware type: ethernet
+	binary.BigEndian.PutUint16(packet[2:4], 0x0800) // protocol type: ipv4
+	packet[4] = 6                                   // hardware address length
+	packet[5] = 4                                   // protocol address length
+	binary.BigEndian.PutUint16(packet[6:8], 1)      // operation: request
+	copy(packet[8:14], mac)
+	copy(packet[14:18], ip)
+	copy(packet[24:28], ip)
+
+	return packet
+}
+
+func htons(i uint16) uint16 {
+	return i<<8 | i>>8
+}
@@ -0,0 +1,94 @@
+package devices_test
+
+import (
+	"fmt"
+	"net"
+
+	"github.com/cloudfoundry-incubator/guardian/kawasaki/devices"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/vishvananda/netlink"
+)
+
+var _ = Describe("Neighbor", func() {
+	var (
+		n    devices.Neighbor
+		name string
+		link netlink.Link
+		intf *net.Interface
+	)
+
+	BeforeEach(func() {
+		name = fmt.Sprintf("gdn-neigh-%d", GinkgoParallelNode())
+		Expect(netlink.LinkAdd(&netlink.GenericLink{
+			LinkAttrs: netlink.LinkAttrs{Name: name},
+			LinkType:  "dummy",
+		})).To(Succeed())
+
+		var err error
+		link, err = netlink.LinkByName(name)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(netlink.LinkSetUp(link)).To(Succeed())
+
+		intf, err = net.InterfaceByName(name)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		cleanup(name)
+	})
+
+	Describe("AnnounceIP", func() {
+		It("sends a gratuitous ARP from the interface", func() {
+			Expect(n.AnnounceIP(intf, net.ParseIP("10.9.8.7"))).To(Succeed())
+		})
+
+		It("does nothing for IPv6 addresses", func() {
+			Expect(n.AnnounceIP(&net.Interface{Name: "does-not-exist"}, net.ParseIP("fd00::2"))).To(Succeed())
+		})
+
+		Context("when the interface has no ethernet address", func() {
+			It("returns an error", func() {
+				Expect(n.AnnounceIP(&net.Interface{Name: "lo"}, net.ParseIP("10.9.8.7"))).To(MatchError(ContainSubstring("no ethernet address")))
+			})
+		})
+	})
+
+	Describe("Flush", func() {
+		neighbors := func() []string {
+			neighs, err := netlink.NeighList(link.Attrs().Index, netlink.FAMILY_V4)
+			Expect(err).NotTo(HaveOccurred())
+
+			var ips []string
+			for _, neigh := range neighs {
+				ips = append(ips, neigh.IP.String())
+			}
+
+			return ips
+		}
+
+		BeforeEach(func() {
+			mac, err := net.ParseMAC("02:00:00:00:00:01")
+			Expect(err).NotTo(HaveOccurred())
+
+			for _, ip := range []string{"10.9.8.7", "10.9.8.6"} {
+				Expect(netlink.NeighAdd(&netlink.Neigh{
+					LinkIndex:    link.Attrs().Index,
+					Family:       netlink.FAMILY_V4,
+					State:        netlink.NUD_PERMANENT,
+					IP:           net.ParseIP(ip),
+					HardwareAddr: mac,
+				})).To(Succeed())
+			}
+		})
+
+		It("removes the entries for the IP only", func() {
+			Expect(n.Flush(name, net.ParseIP("10.9.8.7"))).To(Succeed())
+			Expect(neighbors()).To(ConsistOf("10.9.8.6"))
+		})
+
+		It("does nothing when the interface does not exist", func() {
+			Expect(n.Flush("does-not-exist", net.ParseIP("10.9.8.7"))).To(Succeed())
+		})
+	})
+})
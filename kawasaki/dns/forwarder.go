@@ -0,0 +1,109 @@
+package dns
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"github.com/cloudfoundry/gunk/command_runner"
+	"github.com/pivotal-golang/lager"
+)
+
+// Forwarder runs dnsmasq on the host, answering DNS queries from containers
+// on the address of their bridge. It is a gardener.Starter, and is stopped
+// when guardian exits.
+type Forwarder struct {
+	Bin             string
+	InterfacePrefix string
+	PidFile         string
+
+	// Upstreams, if any, are queried instead of the servers in the host's
+	// resolv.conf
+	Upstreams []net.IP
+
+	Runner command_runner.CommandRunner
+	Logger lager.Logger
+}
+
+func (f *Forwarder) Start() error {
+	log := f.Logger.Session("dns-forwarder-start", lager.Data{"bin": f.Bin, "pidFile": f.PidFile})
+
+	log.Info("started")
+	defer log.Info("finished")
+
+	// a forwarder left behind by a previous run is bound to the same
+	// interfaces
+	f.stop(log)
+
+	args := []string{
+		"--bind-dynamic",
+		fmt.Sprintf("--interface=%s*", f.InterfacePrefix),
+		"--except-interface=lo",
+		"--no-hosts",
+		fmt.Sprintf("--pid-file=%s", f.PidFile),
+	}
+
+	if len(f.Upstreams) > 0 {
+		args = append(args, "--no-resolv")
+		for _, upstream := range f.Upstreams {
+			args = append(args, fmt.Sprintf("--server=%s", upstream))
+		}
+	}
+
+	// dnsmasq daemonizes itself once it is listening
+	if err := f.Runner.Run(exec.Command(f.Bin, args...)); err != nil {
+		log.Error("run-failed", err)
+		return fmt.Errorf("starting dns forwarder: %s", err)
+	}
+
+	return nil
+}
+
+// Stop stops the forwarder
+func (f *Forwarder) Stop() {
+	log := f.Logger.Session("dns-forwarder-stop", lager.Data{"bin": f.Bin, "pidFile": f.PidFile})
+
+	log.Info("started")
+	defer log.Info("finished")
+
+	f.stop(log)
+}
+
+// stop signals the forwarder in the pid file, unless the pid has since been
+// reused by a process which is not the forwarder
+func (f *Forwarder) stop(log lager.Logger) {
+	contents, err := ioutil.ReadFile(f.PidFile)
+	if err != nil {
+		return
+	}
+
+	pid, err := strconv.Atoi(strings.TrimSpace(string(contents)))
+	if err != nil {
+		return
+	}
+
+	cmdline, err := ioutil.ReadFile(fmt.Sprintf("/proc/%d/cmdline", pid))
+	if err != nil || strings.SplitN(string(cmdline), "\x00", 2)[0] != f.Bin {
+		log.Info("forwarder-not-running", lager.Data{"pid": pid})
+		return
+	}
+
+	process, err := os.FindProcess(pid)
+	if err != nil {
+		return
+	}
+
+	if err := process.Signal(syscall.SIGTERM); err != nil {
+		log.Info("forwarder-not-running", lager.Data{"pid": pid})
+		return
+	}
+
+	if err := os.Remove(f.PidFile); err != nil {
+		log.Error("remove-pid-file-failed", err)
+	}
+}
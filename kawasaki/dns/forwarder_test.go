@@ -0,0 +1,124 @@
+package dns_test
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	"github.com/cloudfoundry-incubator/guardian/kawasaki/dns"
+	"github.com/cloudfoundry/gunk/command_runner/fake_command_runner"
+	. "github.com/cloudfoundry/gunk/command_runner/fake_command_runner/matchers"
+	"github.com/pivotal-golang/lager/lagertest"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Forwarder", func() {
+	var (
+		tmpDir     string
+		fakeRunner *fake_command_runner.FakeCommandRunner
+		forwarder  *dns.Forwarder
+	)
+
+	BeforeEach(func() {
+		var err error
+		tmpDir, err = ioutil.TempDir("", "forwarder")
+		Expect(err).NotTo(HaveOccurred())
+
+		fakeRunner = fake_command_runner.New()
+		forwarder = &dns.Forwarder{
+			Bin:             "/path/to/dnsmasq",
+			InterfacePrefix: "w1",
+			PidFile:         filepath.Join(tmpDir, "dnsmasq.pid"),
+			Runner:          fakeRunner,
+			Logger:          lagertest.NewTestLogger("test"),
+		}
+	})
+
+	AfterEach(func() {
+		Expect(os.RemoveAll(tmpDir)).To(Succeed())
+	})
+
+	It("runs dnsmasq on the container interfaces", func() {
+		Expect(forwarder.Start()).To(Succeed())
+
+		Expect(fakeRunner).To(HaveExecutedSerially(fake_command_runner.CommandSpec{
+			Path: "/path/to/dnsmasq",
+			Args: []string{
+				"--bind-dynamic",
+				"--interface=w1*",
+				"--except-interface=lo",
+				"--no-hosts",
+				fmt.Sprintf("--pid-file=%s", filepath.Join(tmpDir, "dnsmasq.pid")),
+			},
+		}))
+	})
+
+	Context("when upstream servers are given", func() {
+		It("forwards to them rather than to the host's resolv.conf", func() {
+			forwarder.Upstreams = []net.IP{net.ParseIP("10.0.0.1"), net.ParseIP("10.0.0.2")}
+			Expect(forwarder.Start()).To(Succeed())
+
+			args := fakeRunner.ExecutedCommands()[0].Args
+			Expect(args[len(args)-3:]).To(Equal([]string{"--no-resolv", "--server=10.0.0.1", "--server=10.0.0.2"}))
+		})
+	})
+
+	Context("when a previous forwarder is running", func() {
+		var previous *exec.Cmd
+
+		BeforeEach(func() {
+			previous = exec.Command("sleep", "100")
+			Expect(previous.Start()).To(Succeed())
+			Expect(ioutil.WriteFile(forwarder.PidFile, []byte(fmt.Sprintf("%d\n", previous.Process.Pid)), 0644)).To(Succeed())
+		})
+
+		AfterEach(func() {
+			previous.Process.Kill()
+		})
+
+		It("stops it", func() {
+			forwarder.Bin = "sleep"
+
+			Expect(forwarder.Start()).To(Succeed())
+			Expect(previous.Wait()).To(MatchError("signal: terminated"))
+		})
+
+		Context("and its pid has been reused by another process", func() {
+			It("leaves the process alone", func() {
+				Expect(forwarder.Start()).To(Succeed())
+
+				exited := make(chan error, 1)
+				go func() { exited <- previous.Wait() }()
+				Consistently(exited).ShouldNot(Receive())
+			})
+		})
+	})
+
+	Describe("Stop", func() {
+		It("stops the forwarder and removes its pid file", func() {
+			forwarder.Bin = "sleep"
+			running := exec.Command("sleep", "100")
+			Expect(running.Start()).To(Succeed())
+			Expect(ioutil.WriteFile(forwarder.PidFile, []byte(fmt.Sprintf("%d\n", running.Process.Pid)), 0644)).To(Succeed())
+
+			forwarder.Stop()
+			Expect(running.Wait()).To(MatchError("signal: terminated"))
+			Expect(forwarder.PidFile).NotTo(BeAnExistingFile())
+		})
+	})
+
+	Context("when dnsmasq fails to start", func() {
+		It("returns an error", func() {
+			fakeRunner.WhenRunning(fake_command_runner.CommandSpec{Path: "/path/to/dnsmasq"}, func(*exec.Cmd) error {
+				return fmt.Errorf("address in use")
+			})
+
+			Expect(forwarder.Start()).To(MatchError("starting dns forwarder: address in use"))
+		})
+	})
+})
@@ -3,6 +3,7 @@ package dns
 import (
 	"fmt"
 	"net"
+	"strings"
 
 	"github.com/pivotal-golang/lager"
 )
@@ -10,9 +11,17 @@ import (
 type HostsFileCompiler struct {
 	Handle string
 	IP     net.IP
+
+	// AdditionalHosts are extra lines, each an IP address followed by host
+	// names
+	AdditionalHosts []string
 }
 
 func (h *HostsFileCompiler) Compile(log lager.Logger) ([]byte, error) {
 	contents := fmt.Sprintf("127.0.0.1 localhost\n%s %s\n", h.IP, h.Handle)
+	for _, entry := range h.AdditionalHosts {
+		contents += strings.TrimSpace(entry) + "\n"
+	}
+
 	return []byte(contents), nil
 }
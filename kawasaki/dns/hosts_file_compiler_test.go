@@ -39,5 +39,13 @@ var _ = Describe("HostsFileCompiler", func() {
 			Expect(err).NotTo(HaveOccurred())
 			Expect(string(contents)).To(ContainSubstring("123.124.126.128 my-handle"))
 		})
+
+		It("should add the additional hosts", func() {
+			compiler.AdditionalHosts = []string{"10.0.0.5 db db.internal", "fd00::1 v6host"}
+
+			contents, err := compiler.Compile(log)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(contents)).To(HaveSuffix("123.124.126.128 my-handle\n10.0.0.5 db db.internal\nfd00::1 v6host\n"))
+		})
 	})
 })
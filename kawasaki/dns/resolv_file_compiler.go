@@ -1,11 +1,15 @@
 package dns
 
 import (
+	"bufio"
+	"bytes"
 	"fmt"
 	"io/ioutil"
 	"net"
 	"os"
 	"regexp"
+	"strings"
+	"text/template"
 
 	"github.com/pivotal-golang/lager"
 )
@@ -13,12 +17,33 @@ import (
 type ResolvFileCompiler struct {
 	HostResolvConfPath string
 	HostIP             net.IP
+
+	// TemplatePath is an optional text/template rendered in place of the
+	// host's resolv.conf, with the fields of ResolvTemplateData
+	TemplatePath string
+
+	// Nameservers, if any, are used instead of the host's
+	Nameservers []net.IP
+
+	SearchDomains []string
+
+	// Forwarder is set when a DNS forwarder listens on HostIP, which then
+	// becomes the container's only nameserver
+	Forwarder bool
+}
+
+// ResolvTemplateData is passed to resolv.conf templates
+type ResolvTemplateData struct {
+	Nameservers    []string
+	SearchDomains  []string
+	HostResolvConf string
 }
 
 func (r *ResolvFileCompiler) Compile(log lager.Logger) ([]byte, error) {
 	log = log.Session("resolv-file-compile", lager.Data{
 		"HostResolvConfPath": r.HostResolvConfPath,
 		"HostIP":             r.HostIP,
+		"TemplatePath":       r.TemplatePath,
 	})
 
 	f, err := os.Open(r.HostResolvConfPath)
@@ -34,6 +59,19 @@ func (r *ResolvFileCompiler) Compile(log lager.Logger) ([]byte, error) {
 		return nil, fmt.Errorf("reading file '%s': %s", r.HostResolvConfPath, err)
 	}
 
+	if r.TemplatePath != "" {
+		return r.render(log, contents)
+	}
+
+	if nameservers := r.nameservers(); len(nameservers) > 0 {
+		var buf bytes.Buffer
+		for _, ns := range nameservers {
+			fmt.Fprintf(&buf, "nameserver %s\n", ns)
+		}
+
+		return r.withSearch(buf.Bytes()), nil
+	}
+
 	matches, err := regexp.Match(`^\s*nameserver\s+127\.0\.0\.1\s*$`, contents)
 	if err != nil {
 		log.Error("matching-regexp", err)
@@ -41,7 +79,78 @@ func (r *ResolvFileCompiler) Compile(log lager.Logger) ([]byte, error) {
 	}
 
 	if matches {
-		return []byte(fmt.Sprintf("nameserver %s\n", r.HostIP.String())), nil
+		return r.withSearch([]byte(fmt.Sprintf("nameserver %s\n", r.HostIP.String()))), nil
+	}
+	return r.withSearch(contents), nil
+}
+
+func (r *ResolvFileCompiler) render(log lager.Logger, hostContents []byte) ([]byte, error) {
+	tmpl, err := template.ParseFiles(r.TemplatePath)
+	if err != nil {
+		log.Error("parsing-template", err)
+		return nil, fmt.Errorf("parsing resolv.conf template: %s", err)
+	}
+
+	data := ResolvTemplateData{
+		SearchDomains:  r.SearchDomains,
+		HostResolvConf: string(hostContents),
+	}
+
+	if nameservers := r.nameservers(); len(nameservers) > 0 {
+		for _, ns := range nameservers {
+			data.Nameservers = append(data.Nameservers, ns.String())
+		}
+	} else {
+		data.Nameservers = r.hostNameservers(hostContents)
 	}
-	return contents, nil
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		log.Error("executing-template", err)
+		return nil, fmt.Errorf("rendering resolv.conf template: %s", err)
+	}
+
+	return buf.Bytes(), nil
+}
+
+func (r *ResolvFileCompiler) nameservers() []net.IP {
+	if r.Forwarder {
+		return []net.IP{r.HostIP}
+	}
+
+	return r.Nameservers
+}
+
+// hostNameservers returns the nameservers in the host's resolv.conf, with
+// loopback addresses, which are unreachable from the container, replaced by
+// HostIP
+func (r *ResolvFileCompiler) hostNameservers(contents []byte) []string {
+	var nameservers []string
+	scanner := bufio.NewScanner(bytes.NewReader(contents))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 2 || fields[0] != "nameserver" {
+			continue
+		}
+
+		if ip := net.ParseIP(fields[1]); ip != nil && ip.IsLoopback() {
+			fields[1] = r.HostIP.String()
+		}
+
+		nameservers = append(nameservers, fields[1])
+	}
+
+	return nameservers
+}
+
+func (r *ResolvFileCompiler) withSearch(contents []byte) []byte {
+	if len(r.SearchDomains) == 0 {
+		return contents
+	}
+
+	if len(contents) > 0 && contents[len(contents)-1] != '\n' {
+		contents = append(contents, '\n')
+	}
+
+	return append(contents, []byte(fmt.Sprintf("search %s\n", strings.Join(r.SearchDomains, " ")))...)
 }
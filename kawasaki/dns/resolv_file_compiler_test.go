@@ -16,6 +16,10 @@ import (
 var _ = Describe("ResolvFileCompiler", func() {
 	var (
 		hostResolvConfPath string
+		templatePath       string
+		nameservers        []net.IP
+		searchDomains      []string
+		forwarder          bool
 
 		log      lager.Logger
 		compiler *dns.ResolvFileCompiler
@@ -23,12 +27,20 @@ var _ = Describe("ResolvFileCompiler", func() {
 
 	BeforeEach(func() {
 		log = lagertest.NewTestLogger("test")
+		templatePath = ""
+		nameservers = nil
+		searchDomains = nil
+		forwarder = false
 	})
 
 	JustBeforeEach(func() {
 		compiler = &dns.ResolvFileCompiler{
 			HostResolvConfPath: hostResolvConfPath,
 			HostIP:             net.ParseIP("254.253.252.251"),
+			TemplatePath:       templatePath,
+			Nameservers:        nameservers,
+			SearchDomains:      searchDomains,
+			Forwarder:          forwarder,
 		}
 	})
 
@@ -91,5 +103,82 @@ var _ = Describe("ResolvFileCompiler", func() {
 				Expect(string(contents)).To(Equal(resolvConfContents))
 			})
 		})
+
+		Context("and search domains are given", func() {
+			BeforeEach(func() {
+				writeFile(hostResolvConfPath, "nameserver 8.8.4.4\n")
+				searchDomains = []string{"corp.example.com", "example.com"}
+			})
+
+			It("should append them to the host's resolv.conf", func() {
+				contents, err := compiler.Compile(log)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(string(contents)).To(Equal("nameserver 8.8.4.4\nsearch corp.example.com example.com\n"))
+			})
+		})
+
+		Context("and nameservers are given", func() {
+			BeforeEach(func() {
+				writeFile(hostResolvConfPath, "nameserver 8.8.4.4\n")
+				nameservers = []net.IP{net.ParseIP("10.0.0.1"), net.ParseIP("10.0.0.2")}
+			})
+
+			It("should use them instead of the host's", func() {
+				contents, err := compiler.Compile(log)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(string(contents)).To(Equal("nameserver 10.0.0.1\nnameserver 10.0.0.2\n"))
+			})
+
+			Context("and there is a DNS forwarder", func() {
+				BeforeEach(func() {
+					forwarder = true
+				})
+
+				It("should only use the forwarder", func() {
+					contents, err := compiler.Compile(log)
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(string(contents)).To(Equal("nameserver 254.253.252.251\n"))
+				})
+			})
+		})
+
+		Context("and a template is given", func() {
+			BeforeEach(func() {
+				writeFile(hostResolvConfPath, "nameserver 127.0.0.1\nnameserver 8.8.4.4\n")
+				searchDomains = []string{"example.com"}
+
+				f, err := ioutil.TempFile("", "")
+				Expect(err).NotTo(HaveOccurred())
+				templatePath = f.Name()
+				Expect(f.Close()).To(Succeed())
+
+				writeFile(templatePath, "{{range .Nameservers}}nameserver {{.}}\n{{end}}search {{range .SearchDomains}}{{.}} {{end}}\noptions ndots:2\n")
+			})
+
+			AfterEach(func() {
+				Expect(os.Remove(templatePath)).To(Succeed())
+			})
+
+			It("should render it with the host's nameservers, replacing loopback addresses", func() {
+				contents, err := compiler.Compile(log)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(string(contents)).To(Equal("nameserver 254.253.252.251\nnameserver 8.8.4.4\nsearch example.com \noptions ndots:2\n"))
+			})
+
+			Context("and the template is invalid", func() {
+				BeforeEach(func() {
+					writeFile(templatePath, "{{range}}")
+				})
+
+				It("should return an error", func() {
+					_, err := compiler.Compile(log)
+					Expect(err).To(MatchError(ContainSubstring("parsing resolv.conf template")))
+				})
+			})
+		})
 	})
 })
@@ -8,21 +8,23 @@ import (
 	"github.com/cloudfoundry-incubator/guardian/kawasaki/netns"
 )
 
-func NewDefaultConfigurer(ipt *iptables.IPTables) kawasaki.Configurer {
+func NewDefaultConfigurer(ipt *iptables.IPTables, logDenied bool) kawasaki.Configurer {
 	hostConfigurer := &configure.Host{
-		Veth:   &devices.VethCreator{},
-		Link:   &devices.Link{},
-		Bridge: &devices.Bridge{},
+		Veth:     &devices.VethCreator{},
+		Link:     &devices.Link{},
+		Bridge:   &devices.Bridge{},
+		Neighbor: &devices.Neighbor{},
 	}
 
 	containerCfgApplier := &configure.Container{
-		Link: &devices.Link{},
+		Link:     &devices.Link{},
+		Neighbor: &devices.Neighbor{},
 	}
 
 	return kawasaki.NewConfigurer(
 		hostConfigurer,
 		containerCfgApplier,
-		iptables.NewInstanceChainCreator(ipt),
+		iptables.NewInstanceChainCreator(ipt, logDenied),
 		&netns.Execer{},
 	)
 }
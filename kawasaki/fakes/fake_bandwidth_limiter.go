@@ -0,0 +1,101 @@
+// This file was generated by counterfeiter
+package fakes
+
+import (
+	"sync"
+
+	"github.com/cloudfoundry-incubator/garden"
+	"github.com/cloudfoundry-incubator/guardian/kawasaki"
+	"github.com/pivotal-golang/lager"
+)
+
+type FakeBandwidthLimiter struct {
+	LimitStub        func(log lager.Logger, hostIntf string, limits garden.BandwidthLimits) error
+	limitMutex       sync.RWMutex
+	limitArgsForCall []struct {
+		log      lager.Logger
+		hostIntf string
+		limits   garden.BandwidthLimits
+	}
+	limitReturns struct {
+		result1 error
+	}
+	RemoveStub        func(log lager.Logger, hostIntf string) error
+	removeMutex       sync.RWMutex
+	removeArgsForCall []struct {
+		log      lager.Logger
+		hostIntf string
+	}
+	removeReturns struct {
+		result1 error
+	}
+}
+
+func (fake *FakeBandwidthLimiter) Limit(log lager.Logger, hostIntf string, limits garden.BandwidthLimits) error {
+	fake.limitMutex.Lock()
+	fake.limitArgsForCall = append(fake.limitArgsForCall, struct {
+		log      lager.Logger
+		hostIntf string
+		limits   garden.BandwidthLimits
+	}{log, hostIntf, limits})
+	fake.limitMutex.Unlock()
+	if fake.LimitStub != nil {
+		return fake.LimitStub(log, hostIntf, limits)
+	} else {
+		return fake.limitReturns.result1
+	}
+}
+
+func (fake *FakeBandwidthLimiter) LimitCallCount() int {
+	fake.limitMutex.RLock()
+	defer fake.limitMutex.RUnlock()
+	return len(fake.limitArgsForCall)
+}
+
+func (fake *FakeBandwidthLimiter) LimitArgsForCall(i int) (lager.Logger, string, garden.BandwidthLimits) {
+	fake.limitMutex.RLock()
+	defer fake.limitMutex.RUnlock()
+	return fake.limitArgsForCall[i].log, fake.limitArgsForCall[i].hostIntf, fake.limitArgsForCall[i].limits
+}
+
+func (fake *FakeBandwidthLimiter) LimitReturns(result1 error) {
+	fake.LimitStub = nil
+	fake.limitReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeBandwidthLimiter) Remove(log lager.Logger, hostIntf string) error {
+	fake.removeMutex.Lock()
+	fake.removeArgsForCall = append(fake.removeArgsForCall, struct {
+		log      lager.Logger
+		hostIntf string
+	}{log, hostIntf})
+	fake.removeMutex.Unlock()
+	if fake.RemoveStub != nil {
+		return fake.RemoveStub(log, hostIntf)
+	} else {
+		return fake.removeReturns.result1
+	}
+}
+
+func (fake *FakeBandwidthLimiter) RemoveCallCount() int {
+	fake.removeMutex.RLock()
+	defer fake.removeMutex.RUnlock()
+	return len(fake.removeArgsForCall)
+}
+
+func (fake *FakeBandwidthLimiter) RemoveArgsForCall(i int) (lager.Logger, string) {
+	fake.removeMutex.RLock()
+	defer fake.removeMutex.RUnlock()
+	return fake.removeArgsForCall[i].log, fake.removeArgsForCall[i].hostIntf
+}
+
+func (fake *FakeBandwidthLimiter) RemoveReturns(result1 error) {
+	fake.RemoveStub = nil
+	fake.removeReturns = struct {
+		result1 error
+	}{result1}
+}
+
+var _ kawasaki.BandwidthLimiter = new(FakeBandwidthLimiter)
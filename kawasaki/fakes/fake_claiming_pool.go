@@ -0,0 +1,101 @@
+// This file was generated by counterfeiter
+package fakes
+
+import (
+	"net"
+	"sync"
+
+	"github.com/cloudfoundry-incubator/guardian/kawasaki"
+	"github.com/pivotal-golang/lager"
+)
+
+type FakeClaimingPool struct {
+	ClaimStub        func(subnet *net.IPNet, ip net.IP, handle string) error
+	claimMutex       sync.RWMutex
+	claimArgsForCall []struct {
+		subnet *net.IPNet
+		ip     net.IP
+		handle string
+	}
+	claimReturns struct {
+		result1 error
+	}
+	PruneStub        func(log lager.Logger, handles []string) error
+	pruneMutex       sync.RWMutex
+	pruneArgsForCall []struct {
+		log     lager.Logger
+		handles []string
+	}
+	pruneReturns struct {
+		result1 error
+	}
+}
+
+func (fake *FakeClaimingPool) Claim(subnet *net.IPNet, ip net.IP, handle string) error {
+	fake.claimMutex.Lock()
+	fake.claimArgsForCall = append(fake.claimArgsForCall, struct {
+		subnet *net.IPNet
+		ip     net.IP
+		handle string
+	}{subnet, ip, handle})
+	fake.claimMutex.Unlock()
+	if fake.ClaimStub != nil {
+		return fake.ClaimStub(subnet, ip, handle)
+	} else {
+		return fake.claimReturns.result1
+	}
+}
+
+func (fake *FakeClaimingPool) ClaimCallCount() int {
+	fake.claimMutex.RLock()
+	defer fake.claimMutex.RUnlock()
+	return len(fake.claimArgsForCall)
+}
+
+func (fake *FakeClaimingPool) ClaimArgsForCall(i int) (*net.IPNet, net.IP, string) {
+	fake.claimMutex.RLock()
+	defer fake.claimMutex.RUnlock()
+	return fake.claimArgsForCall[i].subnet, fake.claimArgsForCall[i].ip, fake.claimArgsForCall[i].handle
+}
+
+func (fake *FakeClaimingPool) ClaimReturns(result1 error) {
+	fake.ClaimStub = nil
+	fake.claimReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeClaimingPool) Prune(log lager.Logger, handles []string) error {
+	fake.pruneMutex.Lock()
+	fake.pruneArgsForCall = append(fake.pruneArgsForCall, struct {
+		log     lager.Logger
+		handles []string
+	}{log, handles})
+	fake.pruneMutex.Unlock()
+	if fake.PruneStub != nil {
+		return fake.PruneStub(log, handles)
+	} else {
+		return fake.pruneReturns.result1
+	}
+}
+
+func (fake *FakeClaimingPool) PruneCallCount() int {
+	fake.pruneMutex.RLock()
+	defer fake.pruneMutex.RUnlock()
+	return len(fake.pruneArgsForCall)
+}
+
+func (fake *FakeClaimingPool) PruneArgsForCall(i int) (lager.Logger, []string) {
+	fake.pruneMutex.RLock()
+	defer fake.pruneMutex.RUnlock()
+	return fake.pruneArgsForCall[i].log, fake.pruneArgsForCall[i].handles
+}
+
+func (fake *FakeClaimingPool) PruneReturns(result1 error) {
+	fake.PruneStub = nil
+	fake.pruneReturns = struct {
+		result1 error
+	}{result1}
+}
+
+var _ kawasaki.ClaimingPool = new(FakeClaimingPool)
@@ -19,6 +19,15 @@ type FakeConfigurer struct {
 	applyReturns struct {
 		result1 error
 	}
+	RecoverStub        func(log lager.Logger, cfg kawasaki.NetworkConfig) error
+	recoverMutex       sync.RWMutex
+	recoverArgsForCall []struct {
+		log lager.Logger
+		cfg kawasaki.NetworkConfig
+	}
+	recoverReturns struct {
+		result1 error
+	}
 	DestroyStub        func(log lager.Logger, cfg kawasaki.NetworkConfig) error
 	destroyMutex       sync.RWMutex
 	destroyArgsForCall []struct {
@@ -64,6 +73,39 @@ func (fake *FakeConfigurer) ApplyReturns(result1 error) {
 	}{result1}
 }
 
+func (fake *FakeConfigurer) Recover(log lager.Logger, cfg kawasaki.NetworkConfig) error {
+	fake.recoverMutex.Lock()
+	fake.recoverArgsForCall = append(fake.recoverArgsForCall, struct {
+		log lager.Logger
+		cfg kawasaki.NetworkConfig
+	}{log, cfg})
+	fake.recoverMutex.Unlock()
+	if fake.RecoverStub != nil {
+		return fake.RecoverStub(log, cfg)
+	} else {
+		return fake.recoverReturns.result1
+	}
+}
+
+func (fake *FakeConfigurer) RecoverCallCount() int {
+	fake.recoverMutex.RLock()
+	defer fake.recoverMutex.RUnlock()
+	return len(fake.recoverArgsForCall)
+}
+
+func (fake *FakeConfigurer) RecoverArgsForCall(i int) (lager.Logger, kawasaki.NetworkConfig) {
+	fake.recoverMutex.RLock()
+	defer fake.recoverMutex.RUnlock()
+	return fake.recoverArgsForCall[i].log, fake.recoverArgsForCall[i].cfg
+}
+
+func (fake *FakeConfigurer) RecoverReturns(result1 error) {
+	fake.RecoverStub = nil
+	fake.recoverReturns = struct {
+		result1 error
+	}{result1}
+}
+
 func (fake *FakeConfigurer) Destroy(log lager.Logger, cfg kawasaki.NetworkConfig) error {
 	fake.destroyMutex.Lock()
 	fake.destroyArgsForCall = append(fake.destroyArgsForCall, struct {
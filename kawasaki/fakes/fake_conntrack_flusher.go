@@ -0,0 +1,61 @@
+// This file was generated by counterfeiter
+package fakes
+
+import (
+	"net"
+	"sync"
+
+	"github.com/cloudfoundry-incubator/guardian/kawasaki"
+	"github.com/pivotal-golang/lager"
+)
+
+type FakeConntrackFlusher struct {
+	FlushStub        func(log lager.Logger, protocol string, containerIP net.IP, containerPort uint32) error
+	flushMutex       sync.RWMutex
+	flushArgsForCall []struct {
+		log           lager.Logger
+		protocol      string
+		containerIP   net.IP
+		containerPort uint32
+	}
+	flushReturns struct {
+		result1 error
+	}
+}
+
+func (fake *FakeConntrackFlusher) Flush(log lager.Logger, protocol string, containerIP net.IP, containerPort uint32) error {
+	fake.flushMutex.Lock()
+	fake.flushArgsForCall = append(fake.flushArgsForCall, struct {
+		log           lager.Logger
+		protocol      string
+		containerIP   net.IP
+		containerPort uint32
+	}{log, protocol, containerIP, containerPort})
+	fake.flushMutex.Unlock()
+	if fake.FlushStub != nil {
+		return fake.FlushStub(log, protocol, containerIP, containerPort)
+	} else {
+		return fake.flushReturns.result1
+	}
+}
+
+func (fake *FakeConntrackFlusher) FlushCallCount() int {
+	fake.flushMutex.RLock()
+	defer fake.flushMutex.RUnlock()
+	return len(fake.flushArgsForCall)
+}
+
+func (fake *FakeConntrackFlusher) FlushArgsForCall(i int) (lager.Logger, string, net.IP, uint32) {
+	fake.flushMutex.RLock()
+	defer fake.flushMutex.RUnlock()
+	return fake.flushArgsForCall[i].log, fake.flushArgsForCall[i].protocol, fake.flushArgsForCall[i].containerIP, fake.flushArgsForCall[i].containerPort
+}
+
+func (fake *FakeConntrackFlusher) FlushReturns(result1 error) {
+	fake.FlushStub = nil
+	fake.flushReturns = struct {
+		result1 error
+	}{result1}
+}
+
+var _ kawasaki.ConntrackFlusher = new(FakeConntrackFlusher)
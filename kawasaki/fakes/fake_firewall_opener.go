@@ -20,6 +20,16 @@ type FakeFirewallOpener struct {
 	openReturns struct {
 		result1 error
 	}
+	BulkOpenStub        func(log lager.Logger, instance string, rules []garden.NetOutRule) error
+	bulkOpenMutex       sync.RWMutex
+	bulkOpenArgsForCall []struct {
+		log      lager.Logger
+		instance string
+		rules    []garden.NetOutRule
+	}
+	bulkOpenReturns struct {
+		result1 error
+	}
 }
 
 func (fake *FakeFirewallOpener) Open(log lager.Logger, instance string, rule garden.NetOutRule) error {
@@ -56,4 +66,38 @@ func (fake *FakeFirewallOpener) OpenReturns(result1 error) {
 	}{result1}
 }
 
+func (fake *FakeFirewallOpener) BulkOpen(log lager.Logger, instance string, rules []garden.NetOutRule) error {
+	fake.bulkOpenMutex.Lock()
+	fake.bulkOpenArgsForCall = append(fake.bulkOpenArgsForCall, struct {
+		log      lager.Logger
+		instance string
+		rules    []garden.NetOutRule
+	}{log, instance, rules})
+	fake.bulkOpenMutex.Unlock()
+	if fake.BulkOpenStub != nil {
+		return fake.BulkOpenStub(log, instance, rules)
+	} else {
+		return fake.bulkOpenReturns.result1
+	}
+}
+
+func (fake *FakeFirewallOpener) BulkOpenCallCount() int {
+	fake.bulkOpenMutex.RLock()
+	defer fake.bulkOpenMutex.RUnlock()
+	return len(fake.bulkOpenArgsForCall)
+}
+
+func (fake *FakeFirewallOpener) BulkOpenArgsForCall(i int) (lager.Logger, string, []garden.NetOutRule) {
+	fake.bulkOpenMutex.RLock()
+	defer fake.bulkOpenMutex.RUnlock()
+	return fake.bulkOpenArgsForCall[i].log, fake.bulkOpenArgsForCall[i].instance, fake.bulkOpenArgsForCall[i].rules
+}
+
+func (fake *FakeFirewallOpener) BulkOpenReturns(result1 error) {
+	fake.BulkOpenStub = nil
+	fake.bulkOpenReturns = struct {
+		result1 error
+	}{result1}
+}
+
 var _ kawasaki.FirewallOpener = new(FakeFirewallOpener)
@@ -16,6 +16,14 @@ type FakePortForwarder struct {
 	forwardReturns struct {
 		result1 error
 	}
+	UnforwardStub        func(spec kawasaki.PortForwarderSpec) error
+	unforwardMutex       sync.RWMutex
+	unforwardArgsForCall []struct {
+		spec kawasaki.PortForwarderSpec
+	}
+	unforwardReturns struct {
+		result1 error
+	}
 }
 
 func (fake *FakePortForwarder) Forward(spec kawasaki.PortForwarderSpec) error {
@@ -50,4 +58,36 @@ func (fake *FakePortForwarder) ForwardReturns(result1 error) {
 	}{result1}
 }
 
+func (fake *FakePortForwarder) Unforward(spec kawasaki.PortForwarderSpec) error {
+	fake.unforwardMutex.Lock()
+	fake.unforwardArgsForCall = append(fake.unforwardArgsForCall, struct {
+		spec kawasaki.PortForwarderSpec
+	}{spec})
+	fake.unforwardMutex.Unlock()
+	if fake.UnforwardStub != nil {
+		return fake.UnforwardStub(spec)
+	} else {
+		return fake.unforwardReturns.result1
+	}
+}
+
+func (fake *FakePortForwarder) UnforwardCallCount() int {
+	fake.unforwardMutex.RLock()
+	defer fake.unforwardMutex.RUnlock()
+	return len(fake.unforwardArgsForCall)
+}
+
+func (fake *FakePortForwarder) UnforwardArgsForCall(i int) kawasaki.PortForwarderSpec {
+	fake.unforwardMutex.RLock()
+	defer fake.unforwardMutex.RUnlock()
+	return fake.unforwardArgsForCall[i].spec
+}
+
+func (fake *FakePortForwarder) UnforwardReturns(result1 error) {
+	fake.UnforwardStub = nil
+	fake.unforwardReturns = struct {
+		result1 error
+	}{result1}
+}
+
 var _ kawasaki.PortForwarder = new(FakePortForwarder)
@@ -8,21 +8,62 @@ import (
 )
 
 type FakePortPool struct {
-	AcquireStub        func() (uint32, error)
+	AcquireStub        func(handle string) (uint32, error)
 	acquireMutex       sync.RWMutex
-	acquireArgsForCall []struct{}
-	acquireReturns     struct {
+	acquireArgsForCall []struct {
+		handle string
+	}
+	acquireReturns struct {
+		result1 uint32
+		result2 error
+	}
+	AcquireInRangeStub        func(handle string, start, end uint32) (uint32, error)
+	acquireInRangeMutex       sync.RWMutex
+	acquireInRangeArgsForCall []struct {
+		handle string
+		start  uint32
+		end    uint32
+	}
+	acquireInRangeReturns struct {
 		result1 uint32
 		result2 error
 	}
+	RemoveStub        func(handle string, port uint32) error
+	removeMutex       sync.RWMutex
+	removeArgsForCall []struct {
+		handle string
+		port   uint32
+	}
+	removeReturns struct {
+		result1 error
+	}
+	ReleasePortStub        func(handle string, port uint32) error
+	releasePortMutex       sync.RWMutex
+	releasePortArgsForCall []struct {
+		handle string
+		port   uint32
+	}
+	releasePortReturns struct {
+		result1 error
+	}
+	ReleaseAllStub        func(handle string) error
+	releaseAllMutex       sync.RWMutex
+	releaseAllArgsForCall []struct {
+		handle string
+	}
+	releaseAllReturns struct {
+		result1 error
+	}
 }
 
-func (fake *FakePortPool) Acquire() (uint32, error) {
+func (fake *FakePortPool) Acquire(handle string) (uint32, error) {
 	fake.acquireMutex.Lock()
-	fake.acquireArgsForCall = append(fake.acquireArgsForCall, struct{}{})
+	fake.acquireArgsForCall = append(fake.acquireArgsForCall, struct {
+		handle string
+	}{handle})
 	fake.acquireMutex.Unlock()
 	if fake.AcquireStub != nil {
-		return fake.AcquireStub()
+		return fake.AcquireStub(handle)
 	} else {
 		return fake.acquireReturns.result1, fake.acquireReturns.result2
 	}
@@ -34,6 +75,12 @@ func (fake *FakePortPool) AcquireCallCount() int {
 	return len(fake.acquireArgsForCall)
 }
 
+func (fake *FakePortPool) AcquireArgsForCall(i int) string {
+	fake.acquireMutex.RLock()
+	defer fake.acquireMutex.RUnlock()
+	return fake.acquireArgsForCall[i].handle
+}
+
 func (fake *FakePortPool) AcquireReturns(result1 uint32, result2 error) {
 	fake.AcquireStub = nil
 	fake.acquireReturns = struct {
@@ -42,4 +89,137 @@ func (fake *FakePortPool) AcquireReturns(result1 uint32, result2 error) {
 	}{result1, result2}
 }
 
+func (fake *FakePortPool) AcquireInRange(handle string, start uint32, end uint32) (uint32, error) {
+	fake.acquireInRangeMutex.Lock()
+	fake.acquireInRangeArgsForCall = append(fake.acquireInRangeArgsForCall, struct {
+		handle string
+		start  uint32
+		end    uint32
+	}{handle, start, end})
+	fake.acquireInRangeMutex.Unlock()
+	if fake.AcquireInRangeStub != nil {
+		return fake.AcquireInRangeStub(handle, start, end)
+	} else {
+		return fake.acquireInRangeReturns.result1, fake.acquireInRangeReturns.result2
+	}
+}
+
+func (fake *FakePortPool) AcquireInRangeCallCount() int {
+	fake.acquireInRangeMutex.RLock()
+	defer fake.acquireInRangeMutex.RUnlock()
+	return len(fake.acquireInRangeArgsForCall)
+}
+
+func (fake *FakePortPool) AcquireInRangeArgsForCall(i int) (string, uint32, uint32) {
+	fake.acquireInRangeMutex.RLock()
+	defer fake.acquireInRangeMutex.RUnlock()
+	return fake.acquireInRangeArgsForCall[i].handle, fake.acquireInRangeArgsForCall[i].start, fake.acquireInRangeArgsForCall[i].end
+}
+
+func (fake *FakePortPool) AcquireInRangeReturns(result1 uint32, result2 error) {
+	fake.AcquireInRangeStub = nil
+	fake.acquireInRangeReturns = struct {
+		result1 uint32
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakePortPool) Remove(handle string, port uint32) error {
+	fake.removeMutex.Lock()
+	fake.removeArgsForCall = append(fake.removeArgsForCall, struct {
+		handle string
+		port   uint32
+	}{handle, port})
+	fake.removeMutex.Unlock()
+	if fake.RemoveStub != nil {
+		return fake.RemoveStub(handle, port)
+	} else {
+		return fake.removeReturns.result1
+	}
+}
+
+func (fake *FakePortPool) RemoveCallCount() int {
+	fake.removeMutex.RLock()
+	defer fake.removeMutex.RUnlock()
+	return len(fake.removeArgsForCall)
+}
+
+func (fake *FakePortPool) RemoveArgsForCall(i int) (string, uint32) {
+	fake.removeMutex.RLock()
+	defer fake.removeMutex.RUnlock()
+	return fake.removeArgsForCall[i].handle, fake.removeArgsForCall[i].port
+}
+
+func (fake *FakePortPool) RemoveReturns(result1 error) {
+	fake.RemoveStub = nil
+	fake.removeReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakePortPool) ReleasePort(handle string, port uint32) error {
+	fake.releasePortMutex.Lock()
+	fake.releasePortArgsForCall = append(fake.releasePortArgsForCall, struct {
+		handle string
+		port   uint32
+	}{handle, port})
+	fake.releasePortMutex.Unlock()
+	if fake.ReleasePortStub != nil {
+		return fake.ReleasePortStub(handle, port)
+	} else {
+		return fake.releasePortReturns.result1
+	}
+}
+
+func (fake *FakePortPool) ReleasePortCallCount() int {
+	fake.releasePortMutex.RLock()
+	defer fake.releasePortMutex.RUnlock()
+	return len(fake.releasePortArgsForCall)
+}
+
+func (fake *FakePortPool) ReleasePortArgsForCall(i int) (string, uint32) {
+	fake.releasePortMutex.RLock()
+	defer fake.releasePortMutex.RUnlock()
+	return fake.releasePortArgsForCall[i].handle, fake.releasePortArgsForCall[i].port
+}
+
+func (fake *FakePortPool) ReleasePortReturns(result1 error) {
+	fake.ReleasePortStub = nil
+	fake.releasePortReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakePortPool) ReleaseAll(handle string) error {
+	fake.releaseAllMutex.Lock()
+	fake.releaseAllArgsForCall = append(fake.releaseAllArgsForCall, struct {
+		handle string
+	}{handle})
+	fake.releaseAllMutex.Unlock()
+	if fake.ReleaseAllStub != nil {
+		return fake.ReleaseAllStub(handle)
+	} else {
+		return fake.releaseAllReturns.result1
+	}
+}
+
+func (fake *FakePortPool) ReleaseAllCallCount() int {
+	fake.releaseAllMutex.RLock()
+	defer fake.releaseAllMutex.RUnlock()
+	return len(fake.releaseAllArgsForCall)
+}
+
+func (fake *FakePortPool) ReleaseAllArgsForCall(i int) string {
+	fake.releaseAllMutex.RLock()
+	defer fake.releaseAllMutex.RUnlock()
+	return fake.releaseAllArgsForCall[i].handle
+}
+
+func (fake *FakePortPool) ReleaseAllReturns(result1 error) {
+	fake.ReleaseAllStub = nil
+	fake.releaseAllReturns = struct {
+		result1 error
+	}{result1}
+}
+
 var _ kawasaki.PortPool = new(FakePortPool)
@@ -0,0 +1,52 @@
+package iptables
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"os/exec"
+	"strings"
+
+	"github.com/pivotal-golang/lager"
+)
+
+// conntrack exits non-zero when it finds nothing to delete
+const noFlowsDeleted = "0 flow entries have been deleted"
+
+type ConntrackFlusher struct {
+	iptables      *IPTables
+	conntrackPath string
+}
+
+func NewConntrackFlusher(iptables *IPTables, conntrackPath string) *ConntrackFlusher {
+	return &ConntrackFlusher{
+		iptables:      iptables,
+		conntrackPath: conntrackPath,
+	}
+}
+
+// Flush deletes the tracked flows whose replies come from the container's
+// port, i.e. those DNATed to it by a port forwarding rule
+func (c *ConntrackFlusher) Flush(log lager.Logger, protocol string, containerIP net.IP, containerPort uint32) error {
+	log = log.Session("flush-conntrack", lager.Data{"protocol": protocol, "ip": containerIP, "port": containerPort})
+
+	var stderr bytes.Buffer
+	cmd := exec.Command(c.conntrackPath,
+		"-D",
+		"--proto", protocol,
+		"--reply-src", containerIP.String(),
+		"--reply-port-src", fmt.Sprintf("%d", containerPort),
+	)
+	cmd.Stderr = &stderr
+
+	if err := c.iptables.runner.Run(cmd); err != nil {
+		if strings.Contains(stderr.String(), noFlowsDeleted) {
+			return nil
+		}
+
+		log.Error("failed", err, lager.Data{"stderr": stderr.String()})
+		return fmt.Errorf("conntrack delete: %s", stderr.String())
+	}
+
+	return nil
+}
@@ -0,0 +1,74 @@
+package iptables_test
+
+import (
+	"errors"
+	"net"
+	"os/exec"
+
+	"github.com/cloudfoundry-incubator/guardian/kawasaki/iptables"
+	"github.com/cloudfoundry/gunk/command_runner/fake_command_runner"
+	. "github.com/cloudfoundry/gunk/command_runner/fake_command_runner/matchers"
+	"github.com/pivotal-golang/lager/lagertest"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ConntrackFlusher", func() {
+	var (
+		fakeRunner *fake_command_runner.FakeCommandRunner
+		flusher    *iptables.ConntrackFlusher
+		logger     *lagertest.TestLogger
+	)
+
+	BeforeEach(func() {
+		fakeRunner = fake_command_runner.New()
+		flusher = iptables.NewConntrackFlusher(
+			iptables.New(fakeRunner, "prefix-"),
+			"/path/to/conntrack",
+		)
+		logger = lagertest.NewTestLogger("test")
+	})
+
+	It("deletes the flows DNATed to the container's port", func() {
+		Expect(flusher.Flush(logger, "udp", net.ParseIP("1.2.3.4"), 514)).To(Succeed())
+
+		Expect(fakeRunner).To(HaveExecutedSerially(
+			fake_command_runner.CommandSpec{
+				Path: "/path/to/conntrack",
+				Args: []string{
+					"-D",
+					"--proto", "udp",
+					"--reply-src", "1.2.3.4",
+					"--reply-port-src", "514",
+				},
+			},
+		))
+	})
+
+	Context("when there are no flows to delete", func() {
+		It("succeeds", func() {
+			fakeRunner.WhenRunning(fake_command_runner.CommandSpec{
+				Path: "/path/to/conntrack",
+			}, func(cmd *exec.Cmd) error {
+				cmd.Stderr.Write([]byte("conntrack v1.4.3 (conntrack-tools): 0 flow entries have been deleted.\n"))
+				return errors.New("exit status 1")
+			})
+
+			Expect(flusher.Flush(logger, "udp", net.ParseIP("1.2.3.4"), 514)).To(Succeed())
+		})
+	})
+
+	Context("when conntrack fails", func() {
+		It("returns a wrapped error, including stderr", func() {
+			fakeRunner.WhenRunning(fake_command_runner.CommandSpec{
+				Path: "/path/to/conntrack",
+			}, func(cmd *exec.Cmd) error {
+				cmd.Stderr.Write([]byte("operation not permitted"))
+				return errors.New("exit status 1")
+			})
+
+			Expect(flusher.Flush(logger, "udp", net.ParseIP("1.2.3.4"), 514)).To(MatchError("conntrack delete: operation not permitted"))
+		})
+	})
+})
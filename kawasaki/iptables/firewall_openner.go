@@ -24,23 +24,88 @@ func (f *FirewallOpener) Open(logger lager.Logger, instance string, r garden.Net
 	logger = logger.Session("prepend-filter-rule", lager.Data{"rule": r, "instance": instance, "chain": chain})
 	logger.Debug("started")
 
-	if len(r.Ports) > 0 && !allowsPort(r.Protocol) {
-		return fmt.Errorf("Ports cannot be specified for Protocol %s", strings.ToUpper(protocols[r.Protocol]))
+	filters, err := filterRules(r)
+	if err != nil {
+		return err
+	}
+
+	for _, filter := range filters {
+		if err := f.iptables.prependRule(chain, filter); err != nil {
+			return err
+		}
+	}
+
+	logger.Debug("ending")
+	return nil
+}
+
+// BulkOpen applies a whole set of rules with a single iptables-restore (and,
+// if any of the rules are for IPv6 networks, a single ip6tables-restore)
+// invocation, rather than running iptables once per rule. The resulting
+// chain is the same as if Open had been called with each rule in turn.
+func (f *FirewallOpener) BulkOpen(logger lager.Logger, instance string, rules []garden.NetOutRule) error {
+	chain := f.iptables.instanceChain(instance)
+
+	logger = logger.Session("bulk-prepend-filter-rules", lager.Data{"rules": len(rules), "instance": instance, "chain": chain})
+	logger.Debug("started")
+
+	var ipv4, ipv6 []string
+	for _, r := range rules {
+		filters, err := filterRules(r)
+		if err != nil {
+			return err
+		}
+
+		for _, filter := range filters {
+			line, err := restoreLine(append([]string{"-I", chain, "1"}, filter.flags(chain)...))
+			if err != nil {
+				return err
+			}
+
+			if filter.ipv6() {
+				ipv6 = append(ipv6, line)
+			} else {
+				ipv4 = append(ipv4, line)
+			}
+		}
 	}
 
-	filter := singleFilterRule{
-		Protocol: r.Protocol,
-		ICMPs:    r.ICMPs,
-		Log:      r.Log,
+	if err := f.iptables.restore("/sbin/iptables-restore", ipv4); err != nil {
+		return err
+	}
+
+	if err := f.iptables.restore("/sbin/ip6tables-restore", ipv6); err != nil {
+		return err
+	}
+
+	logger.Debug("ending")
+	return nil
+}
+
+// filterRules expands a rule in to one filter per network and port
+func filterRules(r garden.NetOutRule) ([]singleFilterRule, error) {
+	if len(r.Ports) > 0 && !allowsPort(r.Protocol) {
+		return nil, fmt.Errorf("Ports cannot be specified for Protocol %s", strings.ToUpper(protocols[r.Protocol]))
 	}
 
 	if _, ok := protocols[r.Protocol]; !ok {
-		return fmt.Errorf("invalid protocol: %d", r.Protocol)
+		return nil, fmt.Errorf("invalid protocol: %d", r.Protocol)
+	}
+
+	if r.ICMPs != nil && r.Protocol != garden.ProtocolICMP {
+		return nil, fmt.Errorf("ICMPs cannot be specified for Protocol %s", strings.ToUpper(protocols[r.Protocol]))
 	}
 
+	var filters []singleFilterRule
+
 	// It should still loop once even if there are no networks or ports.
 	for j := 0; j < len(r.Networks) || j == 0; j++ {
 		for i := 0; i < len(r.Ports) || i == 0; i++ {
+			filter := singleFilterRule{
+				Protocol: r.Protocol,
+				ICMPs:    r.ICMPs,
+				Log:      r.Log,
+			}
 
 			// Preserve nils unless there are ports specified
 			if len(r.Ports) > 0 {
@@ -52,14 +117,11 @@ func (f *FirewallOpener) Open(logger lager.Logger, instance string, r garden.Net
 				filter.Networks = &r.Networks[j]
 			}
 
-			if err := f.iptables.prependRule(chain, filter); err != nil {
-				return err
-			}
+			filters = append(filters, filter)
 		}
 	}
 
-	logger.Debug("ending")
-	return nil
+	return filters, nil
 }
 
 func allowsPort(p garden.Protocol) bool {
@@ -2,6 +2,7 @@ package iptables_test
 
 import (
 	"errors"
+	"io/ioutil"
 	"net"
 	"os/exec"
 
@@ -136,6 +137,42 @@ var _ = Describe("FirewallOpenner", func() {
 					}))
 				})
 			})
+
+			Context("when an IPv6 destination is specified", func() {
+				It("opens only that IP using ip6tables", func() {
+					Expect(opener.Open(logger, "foo-bar-baz", garden.NetOutRule{
+						Networks: []garden.IPRange{
+							{
+								Start: net.ParseIP("2001:db8::1"),
+							},
+						},
+					})).To(Succeed())
+
+					Expect(fakeRunner).To(HaveExecutedSerially(fake_command_runner.CommandSpec{
+						Path: "/sbin/ip6tables",
+						Args: []string{"-w", "-I", "prefix-instance-foo-bar-baz", "1", "--protocol", "all", "--destination", "2001:db8::1", "--jump", "RETURN"},
+					}))
+				})
+
+				It("uses icmpv6 for icmp rules", func() {
+					Expect(opener.Open(logger, "foo-bar-baz", garden.NetOutRule{
+						Protocol: garden.ProtocolICMP,
+						Networks: []garden.IPRange{
+							{
+								Start: net.ParseIP("2001:db8::1"),
+							},
+						},
+						ICMPs: &garden.ICMPControl{
+							Type: 128,
+						},
+					})).To(Succeed())
+
+					Expect(fakeRunner).To(HaveExecutedSerially(fake_command_runner.CommandSpec{
+						Path: "/sbin/ip6tables",
+						Args: []string{"-w", "-I", "prefix-instance-foo-bar-baz", "1", "--protocol", "icmpv6", "--destination", "2001:db8::1", "--icmpv6-type", "128", "--jump", "RETURN"},
+					}))
+				})
+			})
 		})
 
 		Describe("Ports", func() {
@@ -341,6 +378,23 @@ var _ = Describe("FirewallOpenner", func() {
 			})
 		})
 
+		Context("when ICMPs are specified for ProtocolUDP", func() {
+			It("returns a nice error message", func() {
+				Expect(opener.Open(logger, "foo-bar-baz", garden.NetOutRule{
+					Protocol: garden.ProtocolUDP,
+					ICMPs:    &garden.ICMPControl{Type: 8},
+				})).To(MatchError("ICMPs cannot be specified for Protocol UDP"))
+			})
+
+			It("does not run iptables", func() {
+				opener.Open(logger, "foo-bar-baz", garden.NetOutRule{
+					Protocol: garden.ProtocolUDP,
+					ICMPs:    &garden.ICMPControl{Type: 8},
+				})
+				Expect(fakeRunner.ExecutedCommands()).To(BeEmpty())
+			})
+		})
+
 		Context("when an invaild protocol is specified", func() {
 			It("returns an error", func() {
 				err := opener.Open(logger, "foo-bar-baz", garden.NetOutRule{
@@ -367,4 +421,108 @@ var _ = Describe("FirewallOpenner", func() {
 			})
 		})
 	})
+
+	Describe("BulkOpen", func() {
+		var (
+			stdin      map[string]string
+			restoreErr error
+		)
+
+		BeforeEach(func() {
+			stdin = make(map[string]string)
+			restoreErr = nil
+			for _, binary := range []string{"/sbin/iptables-restore", "/sbin/ip6tables-restore"} {
+				binary := binary
+				fakeRunner.WhenRunning(
+					fake_command_runner.CommandSpec{Path: binary},
+					func(cmd *exec.Cmd) error {
+						input, err := ioutil.ReadAll(cmd.Stdin)
+						Expect(err).NotTo(HaveOccurred())
+						stdin[binary] = string(input)
+
+						if restoreErr != nil {
+							cmd.Stderr.Write([]byte("stderr contents"))
+						}
+						return restoreErr
+					},
+				)
+			}
+		})
+
+		It("applies all of the rules with a single iptables-restore", func() {
+			Expect(opener.BulkOpen(logger, "foo-bar-baz", []garden.NetOutRule{
+				{
+					Protocol: garden.ProtocolTCP,
+					Networks: []garden.IPRange{{Start: net.ParseIP("1.2.3.4")}},
+					Ports:    []garden.PortRange{garden.PortRangeFromPort(80), garden.PortRangeFromPort(443)},
+				},
+				{Protocol: garden.ProtocolUDP, Ports: []garden.PortRange{garden.PortRangeFromPort(53)}, Log: true},
+			})).To(Succeed())
+
+			Expect(fakeRunner).To(HaveExecutedSerially(fake_command_runner.CommandSpec{
+				Path: "/sbin/iptables-restore",
+				Args: []string{"--noflush"},
+			}))
+			Expect(fakeRunner.ExecutedCommands()).To(HaveLen(1))
+
+			Expect(stdin["/sbin/iptables-restore"]).To(Equal("*filter\n" +
+				"-I prefix-instance-foo-bar-baz 1 --protocol tcp --destination 1.2.3.4 --destination-port 80 --jump RETURN\n" +
+				"-I prefix-instance-foo-bar-baz 1 --protocol tcp --destination 1.2.3.4 --destination-port 443 --jump RETURN\n" +
+				"-I prefix-instance-foo-bar-baz 1 --protocol udp --destination-port 53 --goto prefix-instance-foo-bar-baz-log\n" +
+				"COMMIT\n"))
+		})
+
+		It("applies IPv6 rules with ip6tables-restore", func() {
+			Expect(opener.BulkOpen(logger, "foo-bar-baz", []garden.NetOutRule{
+				{Networks: []garden.IPRange{{Start: net.ParseIP("2001:db8::1")}}},
+			})).To(Succeed())
+
+			Expect(fakeRunner).To(HaveExecutedSerially(fake_command_runner.CommandSpec{
+				Path: "/sbin/ip6tables-restore",
+				Args: []string{"--noflush"},
+			}))
+			Expect(fakeRunner.ExecutedCommands()).To(HaveLen(1))
+
+			Expect(stdin["/sbin/ip6tables-restore"]).To(Equal("*filter\n" +
+				"-I prefix-instance-foo-bar-baz 1 --protocol all --destination 2001:db8::1 --jump RETURN\n" +
+				"COMMIT\n"))
+		})
+
+		Context("when there are no rules", func() {
+			It("does not run anything", func() {
+				Expect(opener.BulkOpen(logger, "foo-bar-baz", nil)).To(Succeed())
+				Expect(fakeRunner.ExecutedCommands()).To(BeEmpty())
+			})
+		})
+
+		Context("when a rule is invalid", func() {
+			It("returns an error without applying any of the rules", func() {
+				err := opener.BulkOpen(logger, "foo-bar-baz", []garden.NetOutRule{
+					{Protocol: garden.ProtocolTCP},
+					{Protocol: garden.ProtocolICMP, Ports: []garden.PortRange{garden.PortRangeFromPort(80)}},
+				})
+
+				Expect(err).To(MatchError("Ports cannot be specified for Protocol ICMP"))
+				Expect(fakeRunner.ExecutedCommands()).To(BeEmpty())
+			})
+		})
+
+		Context("when the instance would inject a line into iptables-restore's input", func() {
+			It("returns an error without applying any of the rules", func() {
+				err := opener.BulkOpen(logger, "foo\n-F", []garden.NetOutRule{{Protocol: garden.ProtocolTCP}})
+
+				Expect(err).To(MatchError(ContainSubstring("invalid iptables argument")))
+				Expect(fakeRunner.ExecutedCommands()).To(BeEmpty())
+			})
+		})
+
+		Context("when iptables-restore fails", func() {
+			It("returns a wrapped error, including stderr", func() {
+				restoreErr = errors.New("exit status 1")
+
+				Expect(opener.BulkOpen(logger, "foo-bar-baz", []garden.NetOutRule{{}})).
+					To(MatchError("iptables restore: stderr contents"))
+			})
+		})
+	})
 })
@@ -2,8 +2,11 @@ package iptables
 
 import (
 	"fmt"
+	"net"
 	"os"
 	"os/exec"
+	"strings"
+	"sync"
 )
 
 const SetupScript = `
@@ -19,66 +22,68 @@ const SetupScript = `
 	nat_prerouting_chain="${GARDEN_IPTABLES_NAT_PREROUTING_CHAIN}"
 	nat_postrouting_chain="${GARDEN_IPTABLES_NAT_POSTROUTING_CHAIN}"
 	nat_instance_prefix="${GARDEN_IPTABLES_NAT_INSTANCE_PREFIX}"
+	iptables="${GARDEN_IPTABLES_BIN:-iptables}"
+	reject_with="${GARDEN_IPTABLES_REJECT_WITH:-icmp-host-prohibited}"
 
 	function teardown_deprecated_rules() {
 		# Remove jump to garden-dispatch from INPUT
-		iptables -w -S INPUT 2> /dev/null |
+		${iptables} -w -S INPUT 2> /dev/null |
 		grep " -j garden-dispatch" |
 		sed -e "s/-A/-D/" -e "s/\s\+\$//" |
-		xargs --no-run-if-empty --max-lines=1 iptables -w
+		xargs --no-run-if-empty --max-lines=1 ${iptables} -w
 
 		# Remove jump to garden-dispatch from FORWARD
-		iptables -w -S FORWARD 2> /dev/null |
+		${iptables} -w -S FORWARD 2> /dev/null |
 		grep " -j garden-dispatch" |
 		sed -e "s/-A/-D/" -e "s/\s\+\$//" |
-		xargs --no-run-if-empty --max-lines=1 iptables -w
+		xargs --no-run-if-empty --max-lines=1 ${iptables} -w
 
 		# Prune garden-dispatch
-		iptables -w -F garden-dispatch 2> /dev/null || true
+		${iptables} -w -F garden-dispatch 2> /dev/null || true
 
 		# Delete garden-dispatch
-		iptables -w -X garden-dispatch 2> /dev/null || true
+		${iptables} -w -X garden-dispatch 2> /dev/null || true
 	}
 
 	function teardown_filter() {
 		teardown_deprecated_rules
 
 		# Prune garden-forward chain
-		iptables -w -S ${filter_forward_chain} 2> /dev/null |
+		${iptables} -w -S ${filter_forward_chain} 2> /dev/null |
 		grep "\-g ${filter_instance_prefix}" |
 		sed -e "s/-A/-D/" -e "s/\s\+\$//" |
-		xargs --no-run-if-empty --max-lines=1 iptables -w
+		xargs --no-run-if-empty --max-lines=1 ${iptables} -w
 
 		# Prune per-instance chains
-		iptables -w -S 2> /dev/null |
+		${iptables} -w -S 2> /dev/null |
 		grep "^-A ${filter_instance_prefix}" |
 		sed -e "s/-A/-D/" -e "s/\s\+\$//" |
-		xargs --no-run-if-empty --max-lines=1 iptables -w
+		xargs --no-run-if-empty --max-lines=1 ${iptables} -w
 
 		# Delete per-instance chains
-		iptables -w -S 2> /dev/null |
+		${iptables} -w -S 2> /dev/null |
 		grep "^-N ${filter_instance_prefix}" |
 		sed -e "s/-N/-X/" -e "s/\s\+\$//" |
-		xargs --no-run-if-empty --max-lines=1 iptables -w || true
+		xargs --no-run-if-empty --max-lines=1 ${iptables} -w || true
 
 		# Remove jump to garden-forward from FORWARD
-		iptables -w -S FORWARD 2> /dev/null |
+		${iptables} -w -S FORWARD 2> /dev/null |
 		grep " -j ${filter_forward_chain}" |
 		sed -e "s/-A/-D/" -e "s/\s\+\$//" |
-		xargs --no-run-if-empty --max-lines=1 iptables -w || true
+		xargs --no-run-if-empty --max-lines=1 ${iptables} -w || true
 
-		iptables -w -F ${filter_forward_chain} 2> /dev/null || true
-		iptables -w -F ${filter_default_chain} 2> /dev/null || true
+		${iptables} -w -F ${filter_forward_chain} 2> /dev/null || true
+		${iptables} -w -F ${filter_default_chain} 2> /dev/null || true
 
 		# Remove jump to filter input chain from INPUT
-		iptables -w -S INPUT 2> /dev/null |
+		${iptables} -w -S INPUT 2> /dev/null |
 		grep " -j ${filter_input_chain}" |
 		sed -e "s/-A/-D/" -e "s/\s\+\$//" |
-		xargs --no-run-if-empty --max-lines=1 iptables -w || true
+		xargs --no-run-if-empty --max-lines=1 ${iptables} -w || true
 
 		# Empty and delete filter input chain
-		iptables -w -F ${filter_input_chain} 2> /dev/null || true
-		iptables -w -X ${filter_input_chain} 2> /dev/null || true
+		${iptables} -w -F ${filter_input_chain} 2> /dev/null || true
+		${iptables} -w -X ${filter_input_chain} 2> /dev/null || true
 	}
 
 	function setup_filter() {
@@ -88,90 +93,97 @@ const SetupScript = `
 		default_interface=$(ip route show | grep default | cut -d' ' -f5 | head -1)
 
 		# Create, or empty existing, filter input chain
-		iptables -w -N ${filter_input_chain} 2> /dev/null || iptables -w -F ${filter_input_chain}
+		${iptables} -w -N ${filter_input_chain} 2> /dev/null || ${iptables} -w -F ${filter_input_chain}
 
 		# Accept inbound packets if default interface is matched by filter prefix
-		iptables -w -I ${filter_input_chain} -i $default_interface --jump ACCEPT
+		${iptables} -w -I ${filter_input_chain} -i $default_interface --jump ACCEPT
 
 		# Put connection tracking rule in filter input chain
 		# to accept packets related to previously established connections
-		iptables -w -A ${filter_input_chain} -m conntrack --ctstate ESTABLISHED,RELATED --jump ACCEPT
+		${iptables} -w -A ${filter_input_chain} -m conntrack --ctstate ESTABLISHED,RELATED --jump ACCEPT
+
+		# Allow DNS queries to the host-local forwarder, which only listens on
+		# the containers' bridges
+		for dns_network in ${GARDEN_IPTABLES_DNS_NETWORKS}; do
+		${iptables} -w -A ${filter_input_chain} --destination ${dns_network} -p udp --dport 53 --jump ACCEPT
+		${iptables} -w -A ${filter_input_chain} --destination ${dns_network} -p tcp --dport 53 --jump ACCEPT
+		done
 
 		if [ "${GARDEN_IPTABLES_ALLOW_HOST_ACCESS}" != "true" ]; then
-		iptables -w -A ${filter_input_chain} --jump REJECT --reject-with icmp-host-prohibited
+		${iptables} -w -A ${filter_input_chain} --jump REJECT --reject-with ${reject_with}
 		else
-		iptables -w -A ${filter_input_chain} --jump ACCEPT
+		${iptables} -w -A ${filter_input_chain} --jump ACCEPT
 		fi
 
 		# Forward input traffic via ${filter_input_chain}
-		iptables -w -A INPUT -i ${GARDEN_NETWORK_INTERFACE_PREFIX}+ --jump ${filter_input_chain}
+		${iptables} -w -A INPUT -i ${GARDEN_NETWORK_INTERFACE_PREFIX}+ --jump ${filter_input_chain}
 
 		# Create or flush forward chain
-		iptables -w -N ${filter_forward_chain} 2> /dev/null || iptables -w -F ${filter_forward_chain}
-		iptables -w -A ${filter_forward_chain} -j DROP
+		${iptables} -w -N ${filter_forward_chain} 2> /dev/null || ${iptables} -w -F ${filter_forward_chain}
+		${iptables} -w -A ${filter_forward_chain} -j DROP
 
 		# Create or flush default chain
-		iptables -w -N ${filter_default_chain} 2> /dev/null || iptables -w -F ${filter_default_chain}
+		${iptables} -w -N ${filter_default_chain} 2> /dev/null || ${iptables} -w -F ${filter_default_chain}
 
 		# Always allow established connections to containers
-		iptables -w -A ${filter_default_chain} -m conntrack --ctstate ESTABLISHED,RELATED -j ACCEPT
+		${iptables} -w -A ${filter_default_chain} -m conntrack --ctstate ESTABLISHED,RELATED -j ACCEPT
 
 		# Forward outbound traffic via ${filter_forward_chain}
-		iptables -w -A FORWARD -i ${GARDEN_NETWORK_INTERFACE_PREFIX}+ --jump ${filter_forward_chain}
+		${iptables} -w -A FORWARD -i ${GARDEN_NETWORK_INTERFACE_PREFIX}+ --jump ${filter_forward_chain}
 
 		# Forward inbound traffic immediately
-		iptables -w -I ${filter_forward_chain} -i $default_interface --jump ACCEPT
+		${iptables} -w -I ${filter_forward_chain} -i $default_interface --jump ACCEPT
 	}
 
 	function teardown_nat() {
 		# Prune prerouting chain
-		iptables -w -t nat -S ${nat_prerouting_chain} 2> /dev/null |
+		${iptables} -w -t nat -S ${nat_prerouting_chain} 2> /dev/null |
 		grep "\-j ${nat_instance_prefix}" |
 		sed -e "s/-A/-D/" -e "s/\s\+\$//" |
-		xargs --no-run-if-empty --max-lines=1 iptables -w -t nat
+		xargs --no-run-if-empty --max-lines=1 ${iptables} -w -t nat
 
 		# Prune per-instance chains
-		iptables -w -t nat -S 2> /dev/null |
+		${iptables} -w -t nat -S 2> /dev/null |
 		grep "^-A ${nat_instance_prefix}" |
 		sed -e "s/-A/-D/" -e "s/\s\+\$//" |
-		xargs --no-run-if-empty --max-lines=1 iptables -w -t nat
+		xargs --no-run-if-empty --max-lines=1 ${iptables} -w -t nat
 
 		# Delete per-instance chains
-		iptables -w -t nat -S 2> /dev/null |
+		${iptables} -w -t nat -S 2> /dev/null |
 		grep "^-N ${nat_instance_prefix}" |
 		sed -e "s/-N/-X/" -e "s/\s\+\$//" |
-		xargs --no-run-if-empty --max-lines=1 iptables -w -t nat || true
+		xargs --no-run-if-empty --max-lines=1 ${iptables} -w -t nat || true
 
 		# Flush prerouting chain
-		iptables -w -t nat -F ${nat_prerouting_chain} 2> /dev/null || true
+		${iptables} -w -t nat -F ${nat_prerouting_chain} 2> /dev/null || true
 
 		# Flush postrouting chain
-		iptables -w -t nat -F ${nat_postrouting_chain} 2> /dev/null || true
+		${iptables} -w -t nat -F ${nat_postrouting_chain} 2> /dev/null || true
 	}
 
 	function setup_nat() {
 		teardown_nat
 
 		# Create prerouting chain
-		iptables -w -t nat -N ${nat_prerouting_chain} 2> /dev/null || true
+		${iptables} -w -t nat -N ${nat_prerouting_chain} 2> /dev/null || true
 
 		# Bind chain to PREROUTING
-		(iptables -w -t nat -S PREROUTING | grep -q "\-j ${nat_prerouting_chain}\b") ||
-		iptables -w -t nat -A PREROUTING \
+		(${iptables} -w -t nat -S PREROUTING | grep -q "\-j ${nat_prerouting_chain}\b") ||
+		${iptables} -w -t nat -A PREROUTING \
 		--jump ${nat_prerouting_chain}
 
 		# Bind chain to OUTPUT (for traffic originating from same host)
-		(iptables -w -t nat -S OUTPUT | grep -q "\-j ${nat_prerouting_chain}\b") ||
-		iptables -w -t nat -A OUTPUT \
+		(${iptables} -w -t nat -S OUTPUT | grep -q "\-j ${nat_prerouting_chain}\b") ||
+		${iptables} -w -t nat -A OUTPUT \
 		--out-interface "lo" \
 		--jump ${nat_prerouting_chain}
 
 		# Create postrouting chain
-		iptables -w -t nat -N ${nat_postrouting_chain} 2> /dev/null || true
+		${iptables} -w -t nat -N ${nat_postrouting_chain} 2> /dev/null || true
 
 		# Bind chain to POSTROUTING
-		(iptables -w -t nat -S POSTROUTING | grep -q "\-j ${nat_postrouting_chain}\b") ||
-		iptables -w -t nat -A POSTROUTING \
+		(${iptables} -w -t nat -S POSTROUTING | grep -q "\-j ${nat_postrouting_chain}\b") ||
+		${iptables} -w -t nat -A POSTROUTING \
 		--jump ${nat_postrouting_chain}
 	}
 
@@ -181,7 +193,11 @@ const SetupScript = `
 	setup_nat
 
 	# Enable forwarding
+	if [ "${iptables}" = "ip6tables" ]; then
+	echo 1 > /proc/sys/net/ipv6/conf/all/forwarding
+	else
 	echo 1 > /proc/sys/net/ipv4/ip_forward
+	fi
 	;;
 	teardown)
 	teardown_filter
@@ -197,22 +213,97 @@ const SetupScript = `
 type Starter struct {
 	iptables        *IPTables
 	allowHostAccess bool
+	dnsNetworks     []string
 	nicPrefix       string
+	ipv6            bool
 
-	denyNetworks []string
+	mu            sync.Mutex
+	allowNetworks []string
+	denyNetworks  []string
+	networkRules  []rule
 }
 
-func NewStarter(iptables *IPTables, allowHostAccess bool, nicPrefix string, denyNetworks []string) *Starter {
+// NewStarter returns a Starter which sets up the global chains. DNS queries
+// from containers are allowed to the host addresses in dnsNetworks, which
+// are the networks of the container bridges when a DNS forwarder listens on
+// them.
+func NewStarter(iptables *IPTables, allowHostAccess bool, dnsNetworks []string, nicPrefix string, allowNetworks, denyNetworks []string, ipv6 bool) *Starter {
 	return &Starter{
 		iptables:        iptables,
 		allowHostAccess: allowHostAccess,
+		dnsNetworks:     dnsNetworks,
 		nicPrefix:       nicPrefix,
+		ipv6:            ipv6,
+
+		allowNetworks: allowNetworks,
+		denyNetworks:  denyNetworks,
+	}
+}
+
+func (s *Starter) Start() error {
+	if err := s.iptables.run("setup-global-chains", s.setupCommand(false)); err != nil {
+		return fmt.Errorf("setting up default chains: %s", err)
+	}
+
+	if s.ipv6 {
+		cmd := s.setupCommand(true)
+		cmd.Env = append(cmd.Env,
+			"GARDEN_IPTABLES_BIN=ip6tables",
+			"GARDEN_IPTABLES_REJECT_WITH=icmp6-adm-prohibited",
+		)
 
-		denyNetworks: denyNetworks,
+		if err := s.iptables.run("setup-global-ipv6-chains", cmd); err != nil {
+			return fmt.Errorf("setting up default ipv6 chains: %s", err)
+		}
 	}
+
+	s.mu.Lock()
+	// the setup script has flushed the default chain
+	s.networkRules = nil
+	allow, deny := s.allowNetworks, s.denyNetworks
+	s.mu.Unlock()
+
+	return s.SetNetworks(allow, deny)
 }
 
-func (s Starter) Start() error {
+// SetNetworks replaces the rules in the default chain which allow or deny
+// containers access to networks, so that they can be changed while the
+// server is running. Allowed networks take precedence over denied ones. The
+// new rules are added before the old ones are removed, so there is no window
+// in which neither apply.
+func (s *Starter) SetNetworks(allow, deny []string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var rules []rule
+	for _, n := range allow {
+		rules = append(rules, acceptRule(n))
+	}
+
+	for _, n := range deny {
+		rules = append(rules, rejectRule(n))
+	}
+
+	for i, r := range rules {
+		if err := s.iptables.appendRule(s.iptables.defaultChain, r); err != nil {
+			// remember what was added so that the next call removes it
+			s.networkRules = append(s.networkRules, rules[:i]...)
+			return err
+		}
+	}
+
+	for i, r := range s.networkRules {
+		if err := s.iptables.deleteRule(s.iptables.defaultChain, r); err != nil {
+			s.networkRules = append(append([]rule{}, s.networkRules[i:]...), rules...)
+			return err
+		}
+	}
+
+	s.allowNetworks, s.denyNetworks, s.networkRules = allow, deny, rules
+	return nil
+}
+
+func (s *Starter) setupCommand(ipv6 bool) *exec.Cmd {
 	cmd := exec.Command("bash", "-c", SetupScript)
 	cmd.Env = []string{
 		fmt.Sprintf("PATH=%s", os.Getenv("PATH")),
@@ -226,17 +317,22 @@ func (s Starter) Start() error {
 		fmt.Sprintf("GARDEN_IPTABLES_NAT_INSTANCE_PREFIX=%s", s.iptables.instanceChainPrefix),
 		fmt.Sprintf("GARDEN_NETWORK_INTERFACE_PREFIX=%s", s.nicPrefix),
 		fmt.Sprintf("GARDEN_IPTABLES_ALLOW_HOST_ACCESS=%t", s.allowHostAccess),
+		fmt.Sprintf("GARDEN_IPTABLES_DNS_NETWORKS=%s", strings.Join(s.dnsNetworksFor(ipv6), " ")),
 	}
 
-	if err := s.iptables.run("setup-global-chains", cmd); err != nil {
-		return fmt.Errorf("setting up default chains: %s", err)
-	}
+	return cmd
+}
 
-	for _, n := range s.denyNetworks {
-		if err := s.iptables.appendRule(s.iptables.defaultChain, rejectRule(n)); err != nil {
-			return err
+// dnsNetworksFor returns the DNS networks of one IP version, as iptables and
+// ip6tables each only accept their own
+func (s *Starter) dnsNetworksFor(ipv6 bool) []string {
+	var networks []string
+	for _, network := range s.dnsNetworks {
+		ip, _, err := net.ParseCIDR(network)
+		if err == nil && (ip.To4() == nil) == ipv6 {
+			networks = append(networks, network)
 		}
 	}
 
-	return nil
+	return networks
 }
@@ -14,21 +14,29 @@ import (
 
 var _ = Describe("Setup", func() {
 	var (
-		fakeRunner   *fake_command_runner.FakeCommandRunner
-		denyNetworks []string
-		starter      *iptables.Starter
+		fakeRunner    *fake_command_runner.FakeCommandRunner
+		allowNetworks []string
+		denyNetworks  []string
+		dnsNetworks   []string
+		ipv6          bool
+		starter       *iptables.Starter
 	)
 
 	BeforeEach(func() {
 		fakeRunner = fake_command_runner.New()
+		ipv6 = false
+		dnsNetworks = nil
 	})
 
 	JustBeforeEach(func() {
 		starter = iptables.NewStarter(
 			iptables.New(fakeRunner, "prefix-"),
 			true,
+			dnsNetworks,
 			"the-nic-prefix",
+			allowNetworks,
 			denyNetworks,
+			ipv6,
 		)
 	})
 
@@ -51,10 +59,67 @@ var _ = Describe("Setup", func() {
 				"GARDEN_IPTABLES_NAT_INSTANCE_PREFIX=prefix-instance-",
 				"GARDEN_NETWORK_INTERFACE_PREFIX=the-nic-prefix",
 				"GARDEN_IPTABLES_ALLOW_HOST_ACCESS=true",
+				"GARDEN_IPTABLES_DNS_NETWORKS=",
 			},
 		}))
 	})
 
+	It("does not set up the IPv6 chains", func() {
+		Expect(starter.Start()).To(Succeed())
+		Expect(fakeRunner.ExecutedCommands()).To(HaveLen(1))
+	})
+
+	Context("when IPv6 is enabled", func() {
+		BeforeEach(func() {
+			ipv6 = true
+		})
+
+		It("runs the setup script a second time for ip6tables", func() {
+			Expect(starter.Start()).To(Succeed())
+
+			Expect(fakeRunner.ExecutedCommands()).To(HaveLen(2))
+			ipv6Cmd := fakeRunner.ExecutedCommands()[1]
+			Expect(ipv6Cmd.Args).To(Equal([]string{"bash", "-c", iptables.SetupScript}))
+			Expect(ipv6Cmd.Env).To(ContainElement("GARDEN_IPTABLES_BIN=ip6tables"))
+			Expect(ipv6Cmd.Env).To(ContainElement("GARDEN_IPTABLES_REJECT_WITH=icmp6-adm-prohibited"))
+			Expect(ipv6Cmd.Env).To(ContainElement("GARDEN_IPTABLES_FILTER_INPUT_CHAIN=prefix-input"))
+		})
+
+		Context("when DNS is allowed to the bridges", func() {
+			BeforeEach(func() {
+				dnsNetworks = []string{"10.254.0.0/22", "fd00::/64"}
+			})
+
+			It("only allows each IP version's own networks", func() {
+				Expect(starter.Start()).To(Succeed())
+
+				Expect(fakeRunner.ExecutedCommands()[0].Env).To(ContainElement("GARDEN_IPTABLES_DNS_NETWORKS=10.254.0.0/22"))
+				Expect(fakeRunner.ExecutedCommands()[1].Env).To(ContainElement("GARDEN_IPTABLES_DNS_NETWORKS=fd00::/64"))
+			})
+		})
+
+		Context("when denyNetworks contains an IPv6 network", func() {
+			BeforeEach(func() {
+				denyNetworks = []string{"2001:db8::/32"}
+			})
+
+			AfterEach(func() {
+				denyNetworks = nil
+			})
+
+			It("denies it using ip6tables", func() {
+				Expect(starter.Start()).To(Succeed())
+
+				Expect(fakeRunner).To(HaveExecutedSerially(
+					fake_command_runner.CommandSpec{
+						Path: "/sbin/ip6tables",
+						Args: []string{"-w", "-A", "prefix-default", "--destination", "2001:db8::/32", "--jump", "REJECT"},
+					},
+				))
+			})
+		})
+	})
+
 	Context("when running the setup script fails", func() {
 		BeforeEach(func() {
 			fakeRunner.WhenRunning(fake_command_runner.CommandSpec{
@@ -116,4 +181,97 @@ var _ = Describe("Setup", func() {
 			})
 		})
 	})
+
+	Context("when allowNetworks is set", func() {
+		BeforeEach(func() {
+			allowNetworks = []string{"1.2.3.4/32"}
+			denyNetworks = []string{"1.2.0.0/16"}
+		})
+
+		AfterEach(func() {
+			allowNetworks = nil
+			denyNetworks = nil
+		})
+
+		It("accepts the allowed networks before rejecting the denied ones", func() {
+			Expect(starter.Start()).To(Succeed())
+
+			Expect(fakeRunner).To(HaveExecutedSerially(
+				fake_command_runner.CommandSpec{
+					Path: "/sbin/iptables",
+					Args: []string{"-w", "-A", "prefix-default", "--destination", "1.2.3.4/32", "--jump", "ACCEPT"},
+				},
+				fake_command_runner.CommandSpec{
+					Path: "/sbin/iptables",
+					Args: []string{"-w", "-A", "prefix-default", "--destination", "1.2.0.0/16", "--jump", "REJECT"},
+				},
+			))
+		})
+	})
+
+	Describe("SetNetworks", func() {
+		BeforeEach(func() {
+			denyNetworks = []string{"10.0.0.0/8"}
+		})
+
+		AfterEach(func() {
+			denyNetworks = nil
+		})
+
+		JustBeforeEach(func() {
+			Expect(starter.Start()).To(Succeed())
+		})
+
+		It("adds the new rules and then removes the old ones", func() {
+			Expect(starter.SetNetworks([]string{"10.1.0.0/16"}, []string{"10.0.0.0/8", "192.168.0.0/16"})).To(Succeed())
+
+			Expect(fakeRunner.ExecutedCommands()[2:]).To(HaveLen(4))
+			Expect(fakeRunner).To(HaveExecutedSerially(
+				fake_command_runner.CommandSpec{
+					Path: "/sbin/iptables",
+					Args: []string{"-w", "-A", "prefix-default", "--destination", "10.1.0.0/16", "--jump", "ACCEPT"},
+				},
+				fake_command_runner.CommandSpec{
+					Path: "/sbin/iptables",
+					Args: []string{"-w", "-A", "prefix-default", "--destination", "10.0.0.0/8", "--jump", "REJECT"},
+				},
+				fake_command_runner.CommandSpec{
+					Path: "/sbin/iptables",
+					Args: []string{"-w", "-A", "prefix-default", "--destination", "192.168.0.0/16", "--jump", "REJECT"},
+				},
+				fake_command_runner.CommandSpec{
+					Path: "/sbin/iptables",
+					Args: []string{"-w", "-D", "prefix-default", "--destination", "10.0.0.0/8", "--jump", "REJECT"},
+				},
+			))
+		})
+
+		It("removes the rules it added last time", func() {
+			Expect(starter.SetNetworks(nil, []string{"192.168.0.0/16"})).To(Succeed())
+			Expect(starter.SetNetworks(nil, nil)).To(Succeed())
+
+			Expect(fakeRunner).To(HaveExecutedSerially(fake_command_runner.CommandSpec{
+				Path: "/sbin/iptables",
+				Args: []string{"-w", "-D", "prefix-default", "--destination", "192.168.0.0/16", "--jump", "REJECT"},
+			}))
+		})
+
+		Context("when adding a rule fails", func() {
+			It("returns the error and keeps the old rules", func() {
+				fakeRunner.WhenRunning(fake_command_runner.CommandSpec{
+					Path: "/sbin/iptables",
+					Args: []string{"-w", "-A", "prefix-default", "--destination", "not-a-network", "--jump", "REJECT"},
+				}, func(cmd *exec.Cmd) error {
+					cmd.Stderr.Write([]byte("host/network not found"))
+					return fmt.Errorf("exit status 2")
+				})
+
+				Expect(starter.SetNetworks(nil, []string{"not-a-network"})).To(MatchError(ContainSubstring("host/network not found")))
+				Expect(fakeRunner).NotTo(HaveExecutedSerially(fake_command_runner.CommandSpec{
+					Path: "/sbin/iptables",
+					Args: []string{"-w", "-D", "prefix-default", "--destination", "10.0.0.0/8", "--jump", "REJECT"},
+				}))
+			})
+		})
+	})
 })
@@ -10,39 +10,84 @@ import (
 
 type InstanceChainCreator struct {
 	iptables *IPTables
+
+	// logDenied logs the new connections which none of the container's
+	// net-out rules allow
+	logDenied bool
 }
 
-func NewInstanceChainCreator(iptables *IPTables) *InstanceChainCreator {
+func NewInstanceChainCreator(iptables *IPTables, logDenied bool) *InstanceChainCreator {
 	return &InstanceChainCreator{
-		iptables: iptables,
+		iptables:  iptables,
+		logDenied: logDenied,
 	}
 }
 
+// logPrefix is the prefix of the kernel log lines of a container's packets.
+// iptables limits prefixes to 29 characters.
+func logPrefix(instanceId, suffix string) string {
+	return fmt.Sprintf("%.20s %s", instanceId, suffix)
+}
+
+// logLimit limits the rate at which each of a container's log rules writes to
+// the kernel log, so that a container cannot flood it
+var logLimit = []string{"-m", "limit", "--limit", "5/second", "--limit-burst", "10"}
+
+func logRule(bin, chain, prefix string) *exec.Cmd {
+	args := []string{"--wait", "-A", chain, "-m", "conntrack", "--ctstate", "NEW,UNTRACKED,INVALID"}
+	args = append(args, logLimit...)
+	return exec.Command(bin, append(args, "--jump", "LOG", "--log-prefix", prefix)...)
+}
+
 func (cc *InstanceChainCreator) Create(logger lager.Logger, instanceId, bridgeName string, ip net.IP, network *net.IPNet) error {
 	instanceChain := cc.iptables.instanceChain(instanceId)
+	logChain := instanceChain + "-log"
+	bin := binaryFor(ip)
 
 	commands := []*exec.Cmd{
 		// Create nat instance chain
-		exec.Command("iptables", "--wait", "--table", "nat", "-N", instanceChain),
+		exec.Command(bin, "--wait", "--table", "nat", "-N", instanceChain),
 		// Bind nat instance chain to nat prerouting chain
-		exec.Command("iptables", "--wait", "--table", "nat", "-A", cc.iptables.preroutingChain, "--jump", instanceChain),
+		exec.Command(bin, "--wait", "--table", "nat", "-A", cc.iptables.preroutingChain, "--jump", instanceChain),
 		// Enable NAT for traffic coming from containers
 		exec.Command("sh", "-c", fmt.Sprintf(
-			`(iptables --wait --table nat -S %s | grep "\-j MASQUERADE\b" | grep -q -F -- "-s %s") || iptables --wait --table nat -A %s --source %s ! --destination %s --jump MASQUERADE`,
-			cc.iptables.postroutingChain, network.String(), cc.iptables.postroutingChain,
+			`(%s --wait --table nat -S %s | grep "\-j MASQUERADE\b" | grep -q -F -- "-s %s") || %s --wait --table nat -A %s --source %s ! --destination %s --jump MASQUERADE`,
+			bin, cc.iptables.postroutingChain, network.String(), bin, cc.iptables.postroutingChain,
 			network.String(), network.String(),
 		)),
 
+		// Create log chain, which net-out rules with logging go to
+		exec.Command(bin, "--wait", "-N", logChain),
+		logRule(bin, logChain, logPrefix(instanceId, "")),
+		exec.Command(bin, "--wait", "-A", logChain, "--jump", "RETURN"),
+
 		// Create filter instance chain
-		exec.Command("iptables", "--wait", "-N", instanceChain),
+		exec.Command(bin, "--wait", "-N", instanceChain),
 		// Allow intra-subnet traffic (Linux ethernet bridging goes through ip stack)
-		exec.Command("iptables", "--wait", "-A", instanceChain, "-s", network.String(), "-d", network.String(), "-j", "ACCEPT"),
-		// Otherwise, use the default filter chain
-		exec.Command("iptables", "--wait", "-A", instanceChain, "--goto", cc.iptables.defaultChain),
-		// Bind filter instance chain to filter forward chain
-		exec.Command("iptables", "--wait", "-I", cc.iptables.forwardChain, "2", "--in-interface", bridgeName, "--source", ip.String(), "--goto", instanceChain),
+		exec.Command(bin, "--wait", "-A", instanceChain, "-s", network.String(), "-d", network.String(), "-j", "ACCEPT"),
+	}
+
+	if cc.logDenied {
+		commands = append(commands,
+			// Otherwise, use the default filter chain, returning here when it
+			// neither accepts nor rejects the packet
+			exec.Command(bin, "--wait", "-A", instanceChain, "--jump", cc.iptables.defaultChain),
+			// Log connections which neither the container's net-out rules nor
+			// the default chain allow
+			logRule(bin, instanceChain, logPrefix(instanceId, "DENY ")),
+		)
+	} else {
+		commands = append(commands,
+			// Otherwise, use the default filter chain
+			exec.Command(bin, "--wait", "-A", instanceChain, "--goto", cc.iptables.defaultChain),
+		)
 	}
 
+	commands = append(commands,
+		// Bind filter instance chain to filter forward chain
+		exec.Command(bin, "--wait", "-I", cc.iptables.forwardChain, "2", "--in-interface", bridgeName, "--source", ip.String(), "--goto", instanceChain),
+	)
+
 	for _, cmd := range commands {
 		if err := cc.iptables.run("create-instance-chains", cmd); err != nil {
 			return err
@@ -52,28 +97,45 @@ func (cc *InstanceChainCreator) Create(logger lager.Logger, instanceId, bridgeNa
 	return nil
 }
 
+// Destroy removes the instance chains from both the IPv4 and the IPv6
+// tables. The IPv6 teardown is harmless when the container never had an
+// IPv6 address, as every command tolerates missing chains.
 func (cc *InstanceChainCreator) Destroy(logger lager.Logger, instanceId string) error {
+	for _, bin := range []string{"iptables", "ip6tables"} {
+		if err := cc.destroy(bin, instanceId); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (cc *InstanceChainCreator) destroy(bin, instanceId string) error {
 	instanceChain := cc.iptables.instanceChain(instanceId)
 
 	commands := []*exec.Cmd{
 		// Prune nat prerouting chain
 		exec.Command("sh", "-c", fmt.Sprintf(
-			`iptables --wait --table nat -S %s 2> /dev/null | grep "\-j %s\b" | sed -e "s/-A/-D/" | xargs --no-run-if-empty --max-lines=1 iptables --wait --table nat`,
-			cc.iptables.preroutingChain, instanceChain,
+			`%s --wait --table nat -S %s 2> /dev/null | grep "\-j %s\b" | sed -e "s/-A/-D/" | xargs --no-run-if-empty --max-lines=1 %s --wait --table nat`,
+			bin, cc.iptables.preroutingChain, instanceChain, bin,
 		)),
 		// Flush nat instance chain
-		exec.Command("sh", "-c", fmt.Sprintf(`iptables --wait --table nat -F %s 2> /dev/null || true`, instanceChain)),
+		exec.Command("sh", "-c", fmt.Sprintf(`%s --wait --table nat -F %s 2> /dev/null || true`, bin, instanceChain)),
 		// Delete nat instance chain
-		exec.Command("sh", "-c", fmt.Sprintf(`iptables --wait --table nat -X %s 2> /dev/null || true`, instanceChain)),
+		exec.Command("sh", "-c", fmt.Sprintf(`%s --wait --table nat -X %s 2> /dev/null || true`, bin, instanceChain)),
 		// Prune forward chain
 		exec.Command("sh", "-c", fmt.Sprintf(
-			`iptables --wait -S %s 2> /dev/null | grep "\-g %s\b" | sed -e "s/-A/-D/" | xargs --no-run-if-empty --max-lines=1 iptables --wait`,
-			cc.iptables.forwardChain, instanceChain,
+			`%s --wait -S %s 2> /dev/null | grep "\-g %s\b" | sed -e "s/-A/-D/" | xargs --no-run-if-empty --max-lines=1 %s --wait`,
+			bin, cc.iptables.forwardChain, instanceChain, bin,
 		)),
 		// Flush instance chain
-		exec.Command("sh", "-c", fmt.Sprintf("iptables --wait -F %s 2> /dev/null || true", instanceChain)),
+		exec.Command("sh", "-c", fmt.Sprintf("%s --wait -F %s 2> /dev/null || true", bin, instanceChain)),
 		// Delete instance chain
-		exec.Command("sh", "-c", fmt.Sprintf("iptables --wait -X %s 2> /dev/null || true", instanceChain)),
+		exec.Command("sh", "-c", fmt.Sprintf("%s --wait -X %s 2> /dev/null || true", bin, instanceChain)),
+		// Flush log chain, once the instance chain no longer goes to it
+		exec.Command("sh", "-c", fmt.Sprintf("%s --wait -F %s-log 2> /dev/null || true", bin, instanceChain)),
+		// Delete log chain
+		exec.Command("sh", "-c", fmt.Sprintf("%s --wait -X %s-log 2> /dev/null || true", bin, instanceChain)),
 	}
 
 	for _, cmd := range commands {
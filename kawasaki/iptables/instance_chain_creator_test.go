@@ -25,6 +25,7 @@ var _ = Describe("Create", func() {
 		ip         net.IP
 		network    *net.IPNet
 		logger     lager.Logger
+		logDenied  bool
 	)
 
 	BeforeEach(func() {
@@ -37,8 +38,13 @@ var _ = Describe("Create", func() {
 		ip, network, err = net.ParseCIDR("1.2.3.4/28")
 		Expect(err).NotTo(HaveOccurred())
 
+		logDenied = false
+	})
+
+	JustBeforeEach(func() {
 		creator = iptables.NewInstanceChainCreator(
 			iptables.New(fakeRunner, "prefix-"),
+			logDenied,
 		)
 	})
 
@@ -64,6 +70,21 @@ var _ = Describe("Create", func() {
 						network.String(), network.String(),
 					)},
 				},
+				fake_command_runner.CommandSpec{
+					Path: "iptables",
+					Args: []string{"--wait", "-N", "prefix-instance-some-id-log"},
+				},
+				fake_command_runner.CommandSpec{
+					Path: "iptables",
+					Args: []string{"--wait", "-A", "prefix-instance-some-id-log",
+						"-m", "conntrack", "--ctstate", "NEW,UNTRACKED,INVALID",
+						"-m", "limit", "--limit", "5/second", "--limit-burst", "10",
+						"--jump", "LOG", "--log-prefix", "some-id "},
+				},
+				fake_command_runner.CommandSpec{
+					Path: "iptables",
+					Args: []string{"--wait", "-A", "prefix-instance-some-id-log", "--jump", "RETURN"},
+				},
 				fake_command_runner.CommandSpec{
 					Path: "iptables",
 					Args: []string{"--wait", "-N", "prefix-instance-some-id"},
@@ -91,6 +112,62 @@ var _ = Describe("Create", func() {
 			Expect(fakeRunner).To(HaveExecutedSerially(specs...))
 		})
 
+		It("should not log denied connections", func() {
+			Expect(creator.Create(logger, "some-id", bridgeName, ip, network)).To(Succeed())
+			Expect(fakeRunner).NotTo(HaveExecutedSerially(fake_command_runner.CommandSpec{
+				Path: "iptables",
+				Args: []string{"--wait", "-A", "prefix-instance-some-id",
+					"-m", "conntrack", "--ctstate", "NEW,UNTRACKED,INVALID",
+					"-m", "limit", "--limit", "5/second", "--limit-burst", "10",
+					"--jump", "LOG", "--log-prefix", "some-id DENY "},
+			}))
+		})
+
+		Context("when denied connections are logged", func() {
+			BeforeEach(func() {
+				logDenied = true
+			})
+
+			It("should log what the default chain does not decide", func() {
+				Expect(creator.Create(logger, "some-id", bridgeName, ip, network)).To(Succeed())
+				Expect(fakeRunner).To(HaveExecutedSerially(
+					fake_command_runner.CommandSpec{
+						Path: "iptables",
+						Args: []string{"--wait", "-A", "prefix-instance-some-id",
+							"-s", network.String(), "-d", network.String(), "-j", "ACCEPT"},
+					},
+					fake_command_runner.CommandSpec{
+						Path: "iptables",
+						Args: []string{"--wait", "-A", "prefix-instance-some-id",
+							"--jump", "prefix-default"},
+					},
+					fake_command_runner.CommandSpec{
+						Path: "iptables",
+						Args: []string{"--wait", "-A", "prefix-instance-some-id",
+							"-m", "conntrack", "--ctstate", "NEW,UNTRACKED,INVALID",
+							"-m", "limit", "--limit", "5/second", "--limit-burst", "10",
+							"--jump", "LOG", "--log-prefix", "some-id DENY "},
+					},
+				))
+				Expect(fakeRunner).NotTo(HaveExecutedSerially(fake_command_runner.CommandSpec{
+					Path: "iptables",
+					Args: []string{"--wait", "-A", "prefix-instance-some-id",
+						"--goto", "prefix-default"},
+				}))
+			})
+
+			It("should truncate long container ids in the log prefix", func() {
+				Expect(creator.Create(logger, "a-very-long-container-handle-id", bridgeName, ip, network)).To(Succeed())
+				Expect(fakeRunner).To(HaveExecutedSerially(fake_command_runner.CommandSpec{
+					Path: "iptables",
+					Args: []string{"--wait", "-A", "prefix-instance-a-very-long-container-handle-id",
+						"-m", "conntrack", "--ctstate", "NEW,UNTRACKED,INVALID",
+						"-m", "limit", "--limit", "5/second", "--limit-burst", "10",
+						"--jump", "LOG", "--log-prefix", "a-very-long-containe DENY "},
+				}))
+			})
+		})
+
 		DescribeTable("iptables failures",
 			func(specIndex int, errorString string) {
 				fakeRunner.WhenRunning(specs[specIndex], func(cmd *exec.Cmd) error {
@@ -106,6 +183,37 @@ var _ = Describe("Create", func() {
 		)
 	})
 
+	Describe("Create with an IPv6 address", func() {
+		BeforeEach(func() {
+			var err error
+			ip, network, err = net.ParseCIDR("fd00::2/126")
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("should set up the chain using ip6tables", func() {
+			Expect(creator.Create(logger, "some-id", bridgeName, ip, network)).To(Succeed())
+			Expect(fakeRunner).To(HaveExecutedSerially(
+				fake_command_runner.CommandSpec{
+					Path: "ip6tables",
+					Args: []string{"--wait", "--table", "nat", "-N", "prefix-instance-some-id"},
+				},
+				fake_command_runner.CommandSpec{
+					Path: "sh",
+					Args: []string{"-c", fmt.Sprintf(
+						`(ip6tables --wait --table nat -S %s | grep "\-j MASQUERADE\b" | grep -q -F -- "-s %s") || ip6tables --wait --table nat -A %s --source %s ! --destination %s --jump MASQUERADE`,
+						"prefix-postrouting", "fd00::/126", "prefix-postrouting",
+						"fd00::/126", "fd00::/126",
+					)},
+				},
+				fake_command_runner.CommandSpec{
+					Path: "ip6tables",
+					Args: []string{"--wait", "-I", "prefix-forward", "2", "--in-interface", bridgeName,
+						"--source", "fd00::2", "--goto", "prefix-instance-some-id"},
+				},
+			))
+		})
+	})
+
 	Describe("ContainerTeardown", func() {
 		var specs []fake_command_runner.CommandSpec
 
@@ -148,6 +256,14 @@ var _ = Describe("Create", func() {
 						Path: "sh",
 						Args: []string{"-c", fmt.Sprintf("iptables --wait -X %s 2> /dev/null || true", "prefix-instance-some-id")},
 					},
+					fake_command_runner.CommandSpec{
+						Path: "sh",
+						Args: []string{"-c", fmt.Sprintf("iptables --wait -F %s 2> /dev/null || true", "prefix-instance-some-id-log")},
+					},
+					fake_command_runner.CommandSpec{
+						Path: "sh",
+						Args: []string{"-c", fmt.Sprintf("iptables --wait -X %s 2> /dev/null || true", "prefix-instance-some-id-log")},
+					},
 				}
 			})
 
@@ -156,6 +272,27 @@ var _ = Describe("Create", func() {
 				Expect(fakeRunner).To(HaveExecutedSerially(specs...))
 			})
 
+			It("should also tear down any IPv6 chain", func() {
+				Expect(creator.Destroy(logger, "some-id")).To(Succeed())
+				Expect(fakeRunner).To(HaveExecutedSerially(
+					fake_command_runner.CommandSpec{
+						Path: "sh",
+						Args: []string{"-c", fmt.Sprintf("iptables --wait -X %s 2> /dev/null || true", "prefix-instance-some-id-log")},
+					},
+					fake_command_runner.CommandSpec{
+						Path: "sh",
+						Args: []string{"-c", fmt.Sprintf(
+							`ip6tables --wait --table nat -S %s 2> /dev/null | grep "\-j %s\b" | sed -e "s/-A/-D/" | xargs --no-run-if-empty --max-lines=1 ip6tables --wait --table nat`,
+							"prefix-prerouting", "prefix-instance-some-id",
+						)},
+					},
+					fake_command_runner.CommandSpec{
+						Path: "sh",
+						Args: []string{"-c", fmt.Sprintf("ip6tables --wait -X %s 2> /dev/null || true", "prefix-instance-some-id")},
+					},
+				))
+			})
+
 			DescribeTable("iptables failures",
 				func(specIndex int, errorString string) {
 					fakeRunner.WhenRunning(specs[specIndex], func(cmd *exec.Cmd) error {
@@ -3,7 +3,9 @@ package iptables
 import (
 	"bytes"
 	"fmt"
+	"net"
 	"os/exec"
+	"strings"
 
 	"github.com/cloudfoundry-incubator/garden"
 	"github.com/cloudfoundry/gunk/command_runner"
@@ -42,12 +44,28 @@ type rule interface {
 	flags(chain string) []string
 }
 
+// ipv6Rule is implemented by rules which may need to be applied with
+// ip6tables rather than iptables.
+type ipv6Rule interface {
+	ipv6() bool
+}
+
 type iptablesFlags []string
 
 func (flags iptablesFlags) flags(chain string) []string {
 	return flags
 }
 
+type ip6tablesFlags []string
+
+func (flags ip6tablesFlags) flags(chain string) []string {
+	return flags
+}
+
+func (flags ip6tablesFlags) ipv6() bool {
+	return true
+}
+
 func (iptables *IPTables) run(action string, cmd *exec.Cmd) error {
 	var buff bytes.Buffer
 	cmd.Stderr = &buff
@@ -59,22 +77,76 @@ func (iptables *IPTables) run(action string, cmd *exec.Cmd) error {
 	return nil
 }
 
+// binaryFor returns the name of the iptables binary which manages rules for
+// the given address family.
+func binaryFor(ip net.IP) string {
+	if ip != nil && ip.To4() == nil {
+		return "ip6tables"
+	}
+
+	return "iptables"
+}
+
 func (iptables *IPTables) instanceChain(instanceId string) string {
 	return iptables.instanceChainPrefix + instanceId
 }
 
 func (iptables *IPTables) appendRule(chain string, rule rule) error {
-	return iptables.run("append", exec.Command("/sbin/iptables", append([]string{"-w", "-A", chain}, rule.flags(chain)...)...))
+	return iptables.run("append", exec.Command(binaryPathFor(rule), append([]string{"-w", "-A", chain}, rule.flags(chain)...)...))
 }
 
 func (iptables *IPTables) prependRule(chain string, rule rule) error {
-	return iptables.run("prepend", exec.Command("/sbin/iptables", append([]string{"-w", "-I", chain, "1"}, rule.flags(chain)...)...))
+	return iptables.run("prepend", exec.Command(binaryPathFor(rule), append([]string{"-w", "-I", chain, "1"}, rule.flags(chain)...)...))
+}
+
+func (iptables *IPTables) deleteRule(chain string, rule rule) error {
+	return iptables.run("delete", exec.Command(binaryPathFor(rule), append([]string{"-w", "-D", chain}, rule.flags(chain)...)...))
+}
+
+// restore adds the given rules to the filter table in a single invocation of
+// binary (iptables-restore or ip6tables-restore), without flushing the rules
+// which are already there. Each rule is a line of iptables arguments.
+func (iptables *IPTables) restore(binary string, rules []string) error {
+	if len(rules) == 0 {
+		return nil
+	}
+
+	input := "*filter\n" + strings.Join(rules, "\n") + "\nCOMMIT\n"
+
+	cmd := exec.Command(binary, "--noflush")
+	cmd.Stdin = strings.NewReader(input)
+	return iptables.run("restore", cmd)
+}
+
+// restoreLine joins args into a line of iptables-restore input. It refuses
+// any argument which iptables-restore would split or treat as a quote or
+// comment, since the line is not passed through a shell which would quote it.
+func restoreLine(args []string) (string, error) {
+	for _, arg := range args {
+		if arg == "" || strings.IndexFunc(arg, func(r rune) bool { return !restoreSafe(r) }) != -1 {
+			return "", fmt.Errorf("invalid iptables argument: %q", arg)
+		}
+	}
+
+	return strings.Join(args, " "), nil
+}
+
+func restoreSafe(r rune) bool {
+	return r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || strings.ContainsRune("-_.:/", r)
 }
 
-func natRule(destination string, destinationPort uint32, containerIP string, containerPort uint32) rule {
+func binaryPathFor(rule rule) string {
+	if r, ok := rule.(ipv6Rule); ok && r.ipv6() {
+		return "/sbin/ip6tables"
+	}
+
+	return "/sbin/iptables"
+}
+
+func natRule(protocol, destination string, destinationPort uint32, containerIP string, containerPort uint32) rule {
 	return iptablesFlags([]string{
 		"--table", "nat",
-		"--protocol", "tcp",
+		"--protocol", protocol,
 		"--destination", destination,
 		"--destination-port", fmt.Sprintf("%d", destinationPort),
 		"--jump", "DNAT",
@@ -83,10 +155,24 @@ func natRule(destination string, destinationPort uint32, containerIP string, con
 }
 
 func rejectRule(destination string) rule {
-	return iptablesFlags([]string{
+	return destinationRule(destination, "REJECT")
+}
+
+func acceptRule(destination string) rule {
+	return destinationRule(destination, "ACCEPT")
+}
+
+func destinationRule(destination, target string) rule {
+	flags := []string{
 		"--destination", destination,
-		"--jump", "REJECT",
-	})
+		"--jump", target,
+	}
+
+	if ip, _, err := net.ParseCIDR(destination); err == nil && ip.To4() == nil {
+		return ip6tablesFlags(flags)
+	}
+
+	return iptablesFlags(flags)
 }
 
 type singleFilterRule struct {
@@ -97,8 +183,26 @@ type singleFilterRule struct {
 	Log      bool
 }
 
+// ipv6 returns true if the rule's destination is an IPv6 network.
+func (r singleFilterRule) ipv6() bool {
+	if r.Networks == nil {
+		return false
+	}
+
+	for _, ip := range []net.IP{r.Networks.Start, r.Networks.End} {
+		if ip != nil && ip.To4() == nil {
+			return true
+		}
+	}
+
+	return false
+}
+
 func (r singleFilterRule) flags(chain string) (params []string) {
 	protocolString := protocols[r.Protocol]
+	if r.Protocol == garden.ProtocolICMP && r.ipv6() {
+		protocolString = "icmpv6"
+	}
 
 	params = append(params, "--protocol", protocolString)
 
@@ -128,7 +232,11 @@ func (r singleFilterRule) flags(chain string) (params []string) {
 			icmpType = fmt.Sprintf("%d/%d", r.ICMPs.Type, *r.ICMPs.Code)
 		}
 
-		params = append(params, "--icmp-type", icmpType)
+		if r.ipv6() {
+			params = append(params, "--icmpv6-type", icmpType)
+		} else {
+			params = append(params, "--icmp-type", icmpType)
+		}
 	}
 
 	if r.Log {
@@ -13,13 +13,25 @@ func NewPortForwarder(iptables *IPTables) *PortForwarder {
 }
 
 func (p *PortForwarder) Forward(spec kawasaki.PortForwarderSpec) error {
-	return p.iptables.appendRule(
-		p.iptables.instanceChain(spec.InstanceID),
-		natRule(
-			spec.ExternalIP.String(),
-			spec.FromPort,
-			spec.ContainerIP.String(),
-			spec.ToPort,
-		),
+	return p.iptables.appendRule(p.iptables.instanceChain(spec.InstanceID), forwardRule(spec))
+}
+
+// Unforward deletes the rule added by Forward with the same spec
+func (p *PortForwarder) Unforward(spec kawasaki.PortForwarderSpec) error {
+	return p.iptables.deleteRule(p.iptables.instanceChain(spec.InstanceID), forwardRule(spec))
+}
+
+func forwardRule(spec kawasaki.PortForwarderSpec) rule {
+	protocol := spec.Protocol
+	if protocol == "" {
+		protocol = "tcp"
+	}
+
+	return natRule(
+		protocol,
+		spec.ExternalIP.String(),
+		spec.FromPort,
+		spec.ContainerIP.String(),
+		spec.ToPort,
 	)
 }
@@ -50,4 +50,58 @@ var _ = Describe("PortForwarder", func() {
 			},
 		))
 	})
+
+	It("forwards the port over the given protocol", func() {
+		Expect(forwarder.Forward(kawasaki.PortForwarderSpec{
+			InstanceID:  "some-instance",
+			Protocol:    "udp",
+			ExternalIP:  net.ParseIP("5.6.7.8"),
+			ContainerIP: net.ParseIP("1.2.3.4"),
+			FromPort:    514,
+			ToPort:      514,
+		})).To(Succeed())
+
+		Expect(fakeRunner).To(HaveExecutedSerially(
+			fake_command_runner.CommandSpec{
+				Path: "/sbin/iptables",
+				Args: []string{
+					"-w",
+					"-A", "prefix-instance-some-instance",
+					"--table", "nat",
+					"--protocol", "udp",
+					"--destination", "5.6.7.8",
+					"--destination-port", "514",
+					"--jump", "DNAT",
+					"--to-destination", "1.2.3.4:514",
+				},
+			},
+		))
+	})
+
+	It("deletes the NAT rule to stop forwarding the port", func() {
+		Expect(forwarder.Unforward(kawasaki.PortForwarderSpec{
+			InstanceID:  "some-instance",
+			Protocol:    "udp",
+			ExternalIP:  net.ParseIP("5.6.7.8"),
+			ContainerIP: net.ParseIP("1.2.3.4"),
+			FromPort:    514,
+			ToPort:      514,
+		})).To(Succeed())
+
+		Expect(fakeRunner).To(HaveExecutedSerially(
+			fake_command_runner.CommandSpec{
+				Path: "/sbin/iptables",
+				Args: []string{
+					"-w",
+					"-D", "prefix-instance-some-instance",
+					"--table", "nat",
+					"--protocol", "udp",
+					"--destination", "5.6.7.8",
+					"--destination-port", "514",
+					"--jump", "DNAT",
+					"--to-destination", "1.2.3.4:514",
+				},
+			},
+		))
+	})
 })
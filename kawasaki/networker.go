@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"net"
 	"strconv"
+	"strings"
 
 	"github.com/cloudfoundry-incubator/garden"
 	"github.com/cloudfoundry-incubator/guardian/gardener"
@@ -14,8 +15,11 @@ import (
 
 // generic gardener properties
 const containerIpKey = gardener.ContainerIPKey
+const containerIpv6Key = gardener.ContainerIPv6Key
 const bridgeIpKey = gardener.BridgeIPKey
 const externalIpKey = gardener.ExternalIPKey
+const netOutRulesKey = gardener.NetOutRulesKey
+const netInPortRangeProperty = gardener.NetInPortRangeProperty
 
 // kawasaki-specific state properties
 const hostIntfKey = "kawasaki.host-interface"
@@ -25,6 +29,10 @@ const subnetKey = "kawasaki.subnet"
 const iptablePrefixKey = "kawasaki.iptable-prefix"
 const iptableInstanceKey = "kawasaki.iptable-inst"
 const mtuKey = "kawasaki.mtu"
+const bridgeIpv6Key = "kawasaki.bridge-ipv6"
+const subnetV6Key = "kawasaki.subnet-v6"
+const portProtocolsKey = "kawasaki.port-protocols"
+const bandwidthKey = "kawasaki.bandwidth"
 
 //go:generate counterfeiter . NetnsMgr
 
@@ -50,6 +58,7 @@ type ConfigCreator interface {
 
 type Configurer interface {
 	Apply(log lager.Logger, cfg NetworkConfig, nsPath string) error
+	Recover(log lager.Logger, cfg NetworkConfig) error
 	Destroy(log lager.Logger, cfg NetworkConfig) error
 }
 
@@ -63,27 +72,76 @@ type ConfigStore interface {
 //go:generate counterfeiter . PortPool
 
 type PortPool interface {
-	Acquire() (uint32, error)
+	Acquire(handle string) (uint32, error)
+	AcquireInRange(handle string, start, end uint32) (uint32, error)
+	Remove(handle string, port uint32) error
+	ReleasePort(handle string, port uint32) error
+	ReleaseAll(handle string) error
+}
+
+//go:generate counterfeiter . ClaimingPool
+
+// ClaimingPool is a subnet pool which records which container holds each IP
+// it has allocated, such as a subnets.PersistentPool, so that a container is
+// not recovered with an IP another container holds, and the IPs of
+// containers which no longer exist can be released
+type ClaimingPool interface {
+	Claim(subnet *net.IPNet, ip net.IP, handle string) error
+	Prune(log lager.Logger, handles []string) error
 }
 
 //go:generate counterfeiter . PortForwarder
 
 type PortForwarder interface {
 	Forward(spec PortForwarderSpec) error
+	Unforward(spec PortForwarderSpec) error
 }
 
 type PortForwarderSpec struct {
 	InstanceID  string
+	Protocol    string
 	FromPort    uint32
 	ToPort      uint32
 	ContainerIP net.IP
 	ExternalIP  net.IP
 }
 
+//go:generate counterfeiter . ConntrackFlusher
+
+// ConntrackFlusher forgets the connections tracked to a container's port, so
+// that datagrams to a host port which was forwarded to it are not still sent
+// there once the forwarding rule is gone
+type ConntrackFlusher interface {
+	Flush(log lager.Logger, protocol string, containerIP net.IP, containerPort uint32) error
+}
+
 //go:generate counterfeiter . FirewallOpener
 
 type FirewallOpener interface {
 	Open(log lager.Logger, instance string, rule garden.NetOutRule) error
+	BulkOpen(log lager.Logger, instance string, rules []garden.NetOutRule) error
+}
+
+//go:generate counterfeiter . BandwidthLimiter
+
+// BandwidthLimiter shapes the traffic through a container's host interface
+type BandwidthLimiter interface {
+	Limit(log lager.Logger, hostIntf string, limits garden.BandwidthLimits) error
+	Remove(log lager.Logger, hostIntf string) error
+}
+
+// DNSConfig is the operator's DNS configuration for all containers
+type DNSConfig struct {
+	// ResolvConfTemplate is the path of a text/template for the containers'
+	// resolv.conf
+	ResolvConfTemplate string
+
+	// Nameservers, if any, replace those in the host's resolv.conf
+	Nameservers []net.IP
+
+	// Forwarder is set when a DNS forwarder runs on the host, in which case
+	// containers use their bridge IP as their nameserver
+	Forwarder bool
 }
 
 type Networker struct {
@@ -91,30 +149,41 @@ type Networker struct {
 
 	specParser     SpecParser
 	subnetPool     subnets.Pool
+	subnetPoolV6   subnets.Pool // nil unless IPv6 is enabled
 	configCreator  ConfigCreator
 	configurer     Configurer
 	configStore    ConfigStore
 	portForwarder  PortForwarder
 	portPool       PortPool
 	firewallOpener FirewallOpener
+
+	conntrackFlusher ConntrackFlusher
+	bandwidthLimiter BandwidthLimiter
+
+	dnsConfig DNSConfig
 }
 
 func New(
 	kawasakiBinPath string,
 	specParser SpecParser,
 	subnetPool subnets.Pool,
+	subnetPoolV6 subnets.Pool,
 	configCreator ConfigCreator,
 	configurer Configurer,
 	configStore ConfigStore,
 	portPool PortPool,
 	portForwarder PortForwarder,
 	firewallOpener FirewallOpener,
+	conntrackFlusher ConntrackFlusher,
+	bandwidthLimiter BandwidthLimiter,
+	dnsConfig DNSConfig,
 ) *Networker {
 	return &Networker{
 		kawasakiBinPath: kawasakiBinPath,
 
 		specParser:    specParser,
 		subnetPool:    subnetPool,
+		subnetPoolV6:  subnetPoolV6,
 		configCreator: configCreator,
 		configurer:    configurer,
 		configStore:   configStore,
@@ -123,12 +192,34 @@ func New(
 		portPool:      portPool,
 
 		firewallOpener: firewallOpener,
+
+		conntrackFlusher: conntrackFlusher,
+		bandwidthLimiter: bandwidthLimiter,
+
+		dnsConfig: dnsConfig,
 	}
 }
 
 // Hook provides path and appropriate arguments to the kawasaki executable that
 // applies the network configuration after the network namesapce creation.
 func (n *Networker) Hooks(log lager.Logger, handle, spec string) (gardener.Hooks, error) {
+	return n.hooks(log, handle, spec, gardener.DNSSpec{}, nil)
+}
+
+// HooksWithDNS is Hooks for a container with its own search domains and
+// /etc/hosts entries
+func (n *Networker) HooksWithDNS(log lager.Logger, handle, spec string, dns gardener.DNSSpec) (gardener.Hooks, error) {
+	return n.hooks(log, handle, spec, dns, nil)
+}
+
+// HooksWithRoutes is HooksWithDNS for a container which also has static
+// routes of its own, e.g. to service networks which are not reachable via
+// the default route
+func (n *Networker) HooksWithRoutes(log lager.Logger, handle, spec string, dns gardener.DNSSpec, routes []gardener.Route) (gardener.Hooks, error) {
+	return n.hooks(log, handle, spec, dns, routes)
+}
+
+func (n *Networker) hooks(log lager.Logger, handle, spec string, dns gardener.DNSSpec, routes []gardener.Route) (gardener.Hooks, error) {
 	log = log.Session("network", lager.Data{
 		"handle": handle,
 		"spec":   spec,
@@ -149,84 +240,392 @@ func (n *Networker) Hooks(log lager.Logger, handle, spec string) (gardener.Hooks
 		return gardener.Hooks{}, err
 	}
 
+	if err := claim(n.subnetPool, subnet, ip, handle); err != nil {
+		log.Error("claim-failed", err)
+		n.subnetPool.Release(subnet, ip)
+		return gardener.Hooks{}, err
+	}
+
 	config, err := n.configCreator.Create(log, handle, subnet, ip)
 	if err != nil {
 		log.Error("create-config-failed", err)
 		return gardener.Hooks{}, fmt.Errorf("create network config: %s", err)
 	}
+
+	if n.subnetPoolV6 != nil {
+		subnetV6, ipV6, err := n.subnetPoolV6.Acquire(log, subnets.DynamicSubnetSelector, subnets.DynamicIPSelector)
+		if err != nil {
+			log.Error("acquire-ipv6-failed", err)
+			n.subnetPool.Release(subnet, ip)
+			return gardener.Hooks{}, fmt.Errorf("acquire ipv6 address: %s", err)
+		}
+
+		if err := claim(n.subnetPoolV6, subnetV6, ipV6, handle); err != nil {
+			log.Error("claim-ipv6-failed", err)
+			n.subnetPool.Release(subnet, ip)
+			n.subnetPoolV6.Release(subnetV6, ipV6)
+			return gardener.Hooks{}, fmt.Errorf("claim ipv6 address: %s", err)
+		}
+
+		config.SubnetV6 = subnetV6
+		config.ContainerIPv6 = ipV6
+		config.BridgeIPv6 = subnets.GatewayIP(subnetV6)
+	}
+
+	config.Routes = routes
+	if err := checkRoutes(config); err != nil {
+		log.Error("check-routes-failed", err)
+		n.subnetPool.Release(subnet, ip)
+		if config.SubnetV6 != nil {
+			n.subnetPoolV6.Release(config.SubnetV6, config.ContainerIPv6)
+		}
+
+		return gardener.Hooks{}, err
+	}
+
 	log.Info("config-create", lager.Data{"config": config})
 
 	save(n.configStore, handle, config)
 
+	args := []string{
+		n.kawasakiBinPath,
+		fmt.Sprintf("--host-interface=%s", config.HostIntf),
+		fmt.Sprintf("--container-interface=%s", config.ContainerIntf),
+		fmt.Sprintf("--bridge-interface=%s", config.BridgeName),
+		fmt.Sprintf("--bridge-ip=%s", config.BridgeIP),
+		fmt.Sprintf("--container-ip=%s", config.ContainerIP),
+		fmt.Sprintf("--external-ip=%s", config.ExternalIP),
+		fmt.Sprintf("--subnet=%s", config.Subnet.String()),
+		fmt.Sprintf("--mtu=%d", config.Mtu),
+		fmt.Sprintf("--iptable-prefix=%s", config.IPTablePrefix),
+		fmt.Sprintf("--iptable-instance=%s", config.IPTableInstance),
+	}
+
+	if config.SubnetV6 != nil {
+		args = append(args,
+			fmt.Sprintf("--bridge-ipv6=%s", config.BridgeIPv6),
+			fmt.Sprintf("--container-ipv6=%s", config.ContainerIPv6),
+			fmt.Sprintf("--subnet-v6=%s", config.SubnetV6.String()),
+		)
+	}
+
+	for _, route := range config.Routes {
+		args = append(args, fmt.Sprintf("--route=%s", route))
+	}
+
+	if config.LogDenied {
+		args = append(args, "--log-denied")
+	}
+
+	args = append(args, n.dnsArgs(dns)...)
+
 	return gardener.Hooks{
 		Prestart: gardener.Hook{
 			Path: n.kawasakiBinPath,
-			Args: []string{
-				n.kawasakiBinPath,
-				fmt.Sprintf("--host-interface=%s", config.HostIntf),
-				fmt.Sprintf("--container-interface=%s", config.ContainerIntf),
-				fmt.Sprintf("--bridge-interface=%s", config.BridgeName),
-				fmt.Sprintf("--bridge-ip=%s", config.BridgeIP),
-				fmt.Sprintf("--container-ip=%s", config.ContainerIP),
-				fmt.Sprintf("--external-ip=%s", config.ExternalIP),
-				fmt.Sprintf("--subnet=%s", config.Subnet.String()),
-				fmt.Sprintf("--mtu=%d", config.Mtu),
-				fmt.Sprintf("--iptable-prefix=%s", config.IPTablePrefix),
-				fmt.Sprintf("--iptable-instance=%s", config.IPTableInstance),
-			},
+			Args: args,
 		},
 	}, nil
 }
 
+// checkRoutes checks that the routes can be added to the container's network
+// namespace: that the container has an address in each route's family, and
+// that each next hop is in the container's subnet
+func checkRoutes(config NetworkConfig) error {
+	for _, route := range config.Routes {
+		subnet := config.Subnet
+		if route.Destination.IP.To4() == nil {
+			subnet = config.SubnetV6
+		}
+
+		if subnet == nil {
+			return fmt.Errorf("route to %s: the container has no IPv6 address", route.Destination)
+		}
+
+		if route.Via != nil && !subnet.Contains(route.Via) {
+			return fmt.Errorf("route to %s: next hop %s is not in the container's subnet %s", route.Destination, route.Via, subnet)
+		}
+	}
+
+	return nil
+}
+
+func (n *Networker) dnsArgs(dns gardener.DNSSpec) []string {
+	var args []string
+	if n.dnsConfig.ResolvConfTemplate != "" {
+		args = append(args, fmt.Sprintf("--resolv-conf-template=%s", n.dnsConfig.ResolvConfTemplate))
+	}
+
+	for _, nameserver := range n.dnsConfig.Nameservers {
+		args = append(args, fmt.Sprintf("--nameserver=%s", nameserver))
+	}
+
+	if n.dnsConfig.Forwarder {
+		args = append(args, "--dns-forwarder")
+	}
+
+	for _, domain := range dns.SearchDomains {
+		args = append(args, fmt.Sprintf("--search-domain=%s", domain))
+	}
+
+	for _, entry := range dns.AdditionalHosts {
+		args = append(args, fmt.Sprintf("--additional-host=%s", entry))
+	}
+
+	return args
+}
+
+// InspectNetwork returns the interfaces and addresses of the container's
+// network
+func (n *Networker) InspectNetwork(log lager.Logger, handle string) (gardener.NetworkRuntime, error) {
+	cfg, err := load(n.configStore, handle)
+	if err != nil {
+		return gardener.NetworkRuntime{}, err
+	}
+
+	return gardener.NetworkRuntime{
+		HostInterface:      cfg.HostIntf,
+		ContainerInterface: cfg.ContainerIntf,
+		BridgeInterface:    cfg.BridgeName,
+		ContainerIP:        cfg.ContainerIP.String(),
+		BridgeIP:           cfg.BridgeIP.String(),
+	}, nil
+}
+
 // Capacity returns the number of subnets this network can host
 func (n *Networker) Capacity() uint64 {
 	return uint64(n.subnetPool.Capacity())
 }
 
 func (n *Networker) NetIn(log lager.Logger, handle string, externalPort, containerPort uint32) (uint32, uint32, error) {
+	return n.netIn(log, handle, externalPort, containerPort, []string{"tcp"})
+}
+
+// NetInProtocols is NetIn forwarding the given protocols, "tcp" and/or "udp",
+// rather than only TCP
+func (n *Networker) NetInProtocols(log lager.Logger, handle string, externalPort, containerPort uint32, protocols []string) (uint32, uint32, error) {
+	if err := validateProtocols(protocols); err != nil {
+		return 0, 0, err
+	}
+
+	return n.netIn(log, handle, externalPort, containerPort, protocols)
+}
+
+func (n *Networker) netIn(log lager.Logger, handle string, externalPort, containerPort uint32, protocols []string) (uint32, uint32, error) {
 	cfg, err := load(n.configStore, handle)
 	if err != nil {
 		return 0, 0, err
 	}
 
 	if externalPort == 0 {
-		externalPort, err = n.portPool.Acquire()
+		externalPort, err = n.acquirePort(handle)
 		if err != nil {
 			return 0, 0, err
 		}
+	} else if err := n.portPool.Remove(handle, externalPort); err != nil {
+		return 0, 0, err
 	}
 
 	if containerPort == 0 {
 		containerPort = externalPort
 	}
 
-	err = n.portForwarder.Forward(PortForwarderSpec{
-		InstanceID:  cfg.IPTableInstance,
-		FromPort:    externalPort,
-		ToPort:      containerPort,
-		ContainerIP: cfg.ContainerIP,
-		ExternalIP:  cfg.ExternalIP,
-	})
+	mapping := garden.PortMapping{
+		HostPort:      externalPort,
+		ContainerPort: containerPort,
+	}
 
-	if err != nil {
+	if err := n.forward(cfg, mapping, protocols); err != nil {
 		return 0, 0, err
 	}
 
-	addPortMapping(log, n.configStore, handle, garden.PortMapping{
-		HostPort:      externalPort,
-		ContainerPort: containerPort,
-	})
+	addPortMapping(log, n.configStore, handle, mapping)
+	if !tcpOnly(protocols) {
+		addPortProtocols(n.configStore, handle, externalPort, protocols)
+	}
 
 	return externalPort, containerPort, nil
 }
 
+// NetInRemove stops forwarding the host port to the container, forgets the
+// connections tracked through it, so that they are not kept going to the
+// container, and puts the port back in the pool
+func (n *Networker) NetInRemove(log lager.Logger, handle string, hostPort uint32) error {
+	log = log.Session("net-in-remove", lager.Data{"handle": handle, "host-port": hostPort})
+
+	cfg, err := load(n.configStore, handle)
+	if err != nil {
+		return err
+	}
+
+	var mapping *garden.PortMapping
+	mappings := portMappings(n.configStore, handle)
+	for i := range mappings {
+		if mappings[i].HostPort == hostPort {
+			mapping = &mappings[i]
+			break
+		}
+	}
+
+	if mapping == nil {
+		return fmt.Errorf("port %d is not mapped to container %s", hostPort, handle)
+	}
+
+	protocols := portProtocols(n.configStore, handle)[hostPort]
+	for _, protocol := range protocols {
+		err := n.portForwarder.Unforward(PortForwarderSpec{
+			InstanceID:  cfg.IPTableInstance,
+			Protocol:    protocol,
+			FromPort:    mapping.HostPort,
+			ToPort:      mapping.ContainerPort,
+			ContainerIP: cfg.ContainerIP,
+			ExternalIP:  cfg.ExternalIP,
+		})
+		if err != nil {
+			log.Error("unforward-failed", err, lager.Data{"protocol": protocol})
+			return err
+		}
+	}
+
+	// unlike when the container is destroyed, its TCP connections do not end
+	// when the port is unmapped, so are forgotten too
+	for _, protocol := range protocols {
+		if err := n.conntrackFlusher.Flush(log, protocol, cfg.ContainerIP, mapping.ContainerPort); err != nil {
+			log.Error("flush-conntrack-failed", err, lager.Data{"protocol": protocol})
+		}
+	}
+
+	removePortMapping(n.configStore, handle, hostPort)
+
+	return n.portPool.ReleasePort(handle, hostPort)
+}
+
+// acquirePort reserves a host port from the container's net-in-port-range,
+// if it has one, or from anywhere in the pool
+func (n *Networker) acquirePort(handle string) (uint32, error) {
+	value, err := n.configStore.Get(handle, netInPortRangeProperty)
+	if err != nil || value == "" {
+		return n.portPool.Acquire(handle)
+	}
+
+	start, end, err := parsePortRange(value)
+	if err != nil {
+		return 0, err
+	}
+
+	return n.portPool.AcquireInRange(handle, start, end)
+}
+
+func parsePortRange(value string) (uint32, uint32, error) {
+	invalid := fmt.Errorf("invalid %s property: '%s'", netInPortRangeProperty, value)
+
+	bounds := strings.SplitN(value, "-", 2)
+	if len(bounds) != 2 {
+		return 0, 0, invalid
+	}
+
+	start, err := strconv.ParseUint(strings.TrimSpace(bounds[0]), 10, 16)
+	if err != nil {
+		return 0, 0, invalid
+	}
+
+	end, err := strconv.ParseUint(strings.TrimSpace(bounds[1]), 10, 16)
+	if err != nil || end < start {
+		return 0, 0, invalid
+	}
+
+	return uint32(start), uint32(end), nil
+}
+
+func (n *Networker) forward(cfg NetworkConfig, mapping garden.PortMapping, protocols []string) error {
+	for _, protocol := range protocols {
+		err := n.portForwarder.Forward(PortForwarderSpec{
+			InstanceID:  cfg.IPTableInstance,
+			Protocol:    protocol,
+			FromPort:    mapping.HostPort,
+			ToPort:      mapping.ContainerPort,
+			ContainerIP: cfg.ContainerIP,
+			ExternalIP:  cfg.ExternalIP,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// flushConntrack forgets the UDP flows to the container's forwarded ports.
+// Unlike TCP connections, which end when the container goes away, UDP flows
+// stay tracked while datagrams keep arriving, and would otherwise continue
+// to be sent to the container's IP after its forwarding rules are removed.
+func (n *Networker) flushConntrack(log lager.Logger, handle string, cfg NetworkConfig) {
+	protocols := portProtocols(n.configStore, handle)
+	for _, mapping := range portMappings(n.configStore, handle) {
+		for _, protocol := range protocols[mapping.HostPort] {
+			if protocol != "udp" {
+				continue
+			}
+
+			if err := n.conntrackFlusher.Flush(log, protocol, cfg.ContainerIP, mapping.ContainerPort); err != nil {
+				log.Error("flush-conntrack-failed", err, lager.Data{"mapping": mapping})
+			}
+		}
+	}
+}
+
 func (n *Networker) NetOut(log lager.Logger, handle string, rule garden.NetOutRule) error {
 	cfg, err := load(n.configStore, handle)
 	if err != nil {
 		return err
 	}
 
-	return n.firewallOpener.Open(log, cfg.IPTableInstance, rule)
+	if err := n.firewallOpener.Open(log, cfg.IPTableInstance, rule); err != nil {
+		return err
+	}
+
+	return addNetOutRules(n.configStore, handle, []garden.NetOutRule{rule})
+}
+
+// BulkNetOut applies a whole set of rules at once, which is much quicker than
+// calling NetOut for each of them when there are many.
+func (n *Networker) BulkNetOut(log lager.Logger, handle string, rules []garden.NetOutRule) error {
+	cfg, err := load(n.configStore, handle)
+	if err != nil {
+		return err
+	}
+
+	if err := n.firewallOpener.BulkOpen(log, cfg.IPTableInstance, rules); err != nil {
+		return err
+	}
+
+	return addNetOutRules(n.configStore, handle, rules)
+}
+
+// LimitBandwidth shapes the container's traffic in both directions, and
+// records the limits so that they can be reported and applied again when the
+// container is restored
+func (n *Networker) LimitBandwidth(log lager.Logger, handle string, limits garden.BandwidthLimits) error {
+	log = log.Session("limit-bandwidth", lager.Data{"handle": handle})
+
+	cfg, err := load(n.configStore, handle)
+	if err != nil {
+		return err
+	}
+
+	if err := n.bandwidthLimiter.Limit(log, cfg.HostIntf, limits); err != nil {
+		return err
+	}
+
+	// Since the object we are marshalling here is always going to be
+	// valid, not checking for errors here
+	limitsJson, _ := json.Marshal(limits)
+	n.configStore.Set(handle, bandwidthKey, string(limitsJson))
+
+	return nil
+}
+
+func (n *Networker) CurrentBandwidthLimits(log lager.Logger, handle string) (garden.BandwidthLimits, error) {
+	return bandwidthLimits(n.configStore, handle), nil
 }
 
 func (n *Networker) Destroy(log lager.Logger, handle string) error {
@@ -235,12 +634,290 @@ func (n *Networker) Destroy(log lager.Logger, handle string) error {
 		return err
 	}
 
+	if limits := bandwidthLimits(n.configStore, handle); limits.RateInBytesPerSecond > 0 {
+		n.bandwidthLimiter.Remove(log, cfg.HostIntf)
+	}
+
+	if err := n.configurer.Destroy(log, cfg); err != nil {
+		log.Error("destroy-config-failed", err)
+		return err
+	}
+
+	n.flushConntrack(log, handle, cfg)
+
+	if err := n.portPool.ReleaseAll(handle); err != nil {
+		log.Error("release-ports-failed", err)
+	}
+
+	// both addresses are always released, so that a failure to release one
+	// does not leak the other
+	var v6Err error
+	if n.subnetPoolV6 != nil && cfg.SubnetV6 != nil {
+		if v6Err = n.subnetPoolV6.Release(cfg.SubnetV6, cfg.ContainerIPv6); v6Err != nil {
+			log.Error("release-ipv6-failed", v6Err)
+		}
+	}
+
+	if err := n.subnetPool.Release(cfg.Subnet, cfg.ContainerIP); err != nil {
+		if v6Err != nil {
+			return fmt.Errorf("%s; release ipv6 address: %s", err, v6Err)
+		}
+
+		return err
+	}
+
+	return v6Err
+}
+
+// Checkpoint tears down the host side of a container's network when the
+// container is checkpointed, keeping its subnet and IP reserved. The host
+// side is re-created by the kawasaki prestart hook, which runc runs again
+// when the container is restored.
+func (n *Networker) Checkpoint(log lager.Logger, handle string) error {
+	log = log.Session("checkpoint-network", lager.Data{"handle": handle})
+
+	cfg, err := load(n.configStore, handle)
+	if err != nil {
+		return err
+	}
+
 	if err := n.configurer.Destroy(log, cfg); err != nil {
 		log.Error("destroy-config-failed", err)
 		return err
 	}
 
-	return n.subnetPool.Release(cfg.Subnet, cfg.ContainerIP)
+	n.flushConntrack(log, handle, cfg)
+
+	if limits := bandwidthLimits(n.configStore, handle); limits.RateInBytesPerSecond > 0 {
+		n.bandwidthLimiter.Remove(log, cfg.HostIntf)
+	}
+
+	return nil
+}
+
+// Restore re-creates the parts of a restored container's network which the
+// prestart hook does not: the reservation of its subnet and IP, which is lost
+// if the container was checkpointed on another host or before guardian
+// restarted, and its port forwarding rules.
+func (n *Networker) Restore(log lager.Logger, handle string) error {
+	log = log.Session("restore-network", lager.Data{"handle": handle})
+
+	cfg, err := load(n.configStore, handle)
+	if err != nil {
+		return err
+	}
+
+	return n.reacquire(log, handle, cfg)
+}
+
+// Recover re-creates the network state of a container which survived a
+// restart of guardian. Its interfaces are still in place but its iptables
+// chains were removed when the global chains were set up again, and its
+// subnet, IP and host ports are no longer reserved.
+func (n *Networker) Recover(log lager.Logger, handle string) error {
+	log = log.Session("recover-network", lager.Data{"handle": handle})
+
+	cfg, err := load(n.configStore, handle)
+	if err != nil {
+		return err
+	}
+
+	if err := n.configurer.Recover(log, cfg); err != nil {
+		log.Error("recover-config-failed", err)
+		return err
+	}
+
+	return n.reacquire(log, handle, cfg)
+}
+
+// reacquire reserves the container's subnet, IP and host ports, forwards its
+// ports again and re-applies its net out rules
+func (n *Networker) reacquire(log lager.Logger, handle string, cfg NetworkConfig) error {
+	if err := reserve(n.subnetPool, handle, cfg.Subnet, cfg.ContainerIP); err != nil {
+		log.Error("reserve-failed", err)
+		return fmt.Errorf("reserve container ip: %s", err)
+	}
+
+	if n.subnetPoolV6 != nil && cfg.SubnetV6 != nil {
+		if err := reserve(n.subnetPoolV6, handle, cfg.SubnetV6, cfg.ContainerIPv6); err != nil {
+			log.Error("reserve-ipv6-failed", err)
+			return fmt.Errorf("reserve container ipv6: %s", err)
+		}
+	}
+
+	protocols := portProtocols(n.configStore, handle)
+	for _, mapping := range portMappings(n.configStore, handle) {
+		// the port is already reserved if the pool was persisted, and may
+		// have been taken by another container if it was not
+		if err := n.portPool.Remove(handle, mapping.HostPort); err != nil {
+			log.Debug("port-not-reserved", lager.Data{"port": mapping.HostPort, "reason": err.Error()})
+		}
+
+		if err := n.forward(cfg, mapping, protocols[mapping.HostPort]); err != nil {
+			log.Error("forward-failed", err, lager.Data{"mapping": mapping})
+			return err
+		}
+	}
+
+	rules, err := netOutRules(n.configStore, handle)
+	if err != nil {
+		log.Error("net-out-rules-failed", err)
+		return err
+	}
+
+	if len(rules) > 0 {
+		if err := n.firewallOpener.BulkOpen(log, cfg.IPTableInstance, rules); err != nil {
+			log.Error("net-out-failed", err)
+			return err
+		}
+	}
+
+	// a restored container's host interface is new, so has no limits;
+	// replacing those of a recovered container is harmless
+	if limits := bandwidthLimits(n.configStore, handle); limits.RateInBytesPerSecond > 0 {
+		if err := n.bandwidthLimiter.Limit(log, cfg.HostIntf, limits); err != nil {
+			log.Error("limit-bandwidth-failed", err)
+			return err
+		}
+	}
+
+	return nil
+}
+
+// reserve marks the IP as allocated in the pool, and as held by the
+// container. The IP is already allocated if the container is restored by the
+// guardian which checkpointed it, or if the pool was persisted, in which case
+// the pool can tell whether it is held by another container.
+func reserve(pool subnets.Pool, handle string, subnet *net.IPNet, ip net.IP) error {
+	if err := pool.Remove(subnet, ip); err != nil && err != subnets.ErrOverlapsExistingSubnet {
+		return err
+	}
+
+	return claim(pool, subnet, ip, handle)
+}
+
+// claim records that the container holds the IP, if the pool keeps track
+func claim(pool subnets.Pool, subnet *net.IPNet, ip net.IP, handle string) error {
+	if claimingPool, ok := pool.(ClaimingPool); ok {
+		return claimingPool.Claim(subnet, ip, handle)
+	}
+
+	return nil
+}
+
+// Prune releases the IPs held by containers other than the given ones, which
+// are those left in the depot, if the subnet pools keep track of which
+// container holds each IP
+func (n *Networker) Prune(log lager.Logger, handles []string) error {
+	log = log.Session("prune-network")
+
+	for _, pool := range []subnets.Pool{n.subnetPool, n.subnetPoolV6} {
+		if claimingPool, ok := pool.(ClaimingPool); ok {
+			if err := claimingPool.Prune(log, handles); err != nil {
+				log.Error("prune-failed", err)
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func portMappings(configStore ConfigStore, handle string) []garden.PortMapping {
+	mappings := []garden.PortMapping{}
+
+	mappingsJson, err := configStore.Get(handle, gardener.MappedPortsKey)
+	if err != nil {
+		return mappings
+	}
+
+	json.Unmarshal([]byte(mappingsJson), &mappings)
+	return mappings
+}
+
+// validateProtocols checks the protocols given to NetInProtocols are each
+// "tcp" or "udp", given once
+func validateProtocols(protocols []string) error {
+	if len(protocols) == 0 {
+		return fmt.Errorf("no protocols given")
+	}
+
+	seen := map[string]bool{}
+	for _, protocol := range protocols {
+		if protocol != "tcp" && protocol != "udp" {
+			return fmt.Errorf("unknown protocol '%s'", protocol)
+		}
+
+		if seen[protocol] {
+			return fmt.Errorf("protocol '%s' given more than once", protocol)
+		}
+
+		seen[protocol] = true
+	}
+
+	return nil
+}
+
+func tcpOnly(protocols []string) bool {
+	return len(protocols) == 1 && protocols[0] == "tcp"
+}
+
+// portProtocols returns the protocols forwarded from each of the container's
+// host ports. Ports only forwarding TCP, as all ports did before UDP was
+// supported, are not recorded.
+func portProtocols(configStore ConfigStore, handle string) map[uint32][]string {
+	protocols := map[uint32][]string{}
+
+	protocolsJson, err := configStore.Get(handle, portProtocolsKey)
+	if err == nil {
+		json.Unmarshal([]byte(protocolsJson), &protocols)
+	}
+
+	for _, mapping := range portMappings(configStore, handle) {
+		if _, ok := protocols[mapping.HostPort]; !ok {
+			protocols[mapping.HostPort] = []string{"tcp"}
+		}
+	}
+
+	return protocols
+}
+
+func addPortProtocols(configStore ConfigStore, handle string, hostPort uint32, protocols []string) {
+	current := map[uint32][]string{}
+	if currentJson, err := configStore.Get(handle, portProtocolsKey); err == nil {
+		json.Unmarshal([]byte(currentJson), &current)
+	}
+
+	current[hostPort] = protocols
+
+	updatedJson, _ := json.Marshal(current)
+	configStore.Set(handle, portProtocolsKey, string(updatedJson))
+}
+
+// removePortMapping forgets the mapping from the host port, and the
+// protocols it forwarded
+func removePortMapping(configStore ConfigStore, handle string, hostPort uint32) {
+	remaining := []garden.PortMapping{}
+	for _, mapping := range portMappings(configStore, handle) {
+		if mapping.HostPort != hostPort {
+			remaining = append(remaining, mapping)
+		}
+	}
+
+	mappingsJson, _ := json.Marshal(remaining)
+	configStore.Set(handle, gardener.MappedPortsKey, string(mappingsJson))
+
+	protocols := map[uint32][]string{}
+	if protocolsJson, err := configStore.Get(handle, portProtocolsKey); err == nil {
+		json.Unmarshal([]byte(protocolsJson), &protocols)
+	}
+
+	if _, ok := protocols[hostPort]; ok {
+		delete(protocols, hostPort)
+
+		protocolsJson, _ := json.Marshal(protocols)
+		configStore.Set(handle, portProtocolsKey, string(protocolsJson))
+	}
 }
 
 func addPortMapping(logger lager.Logger, configStore ConfigStore, handle string, newMapping garden.PortMapping) {
@@ -288,6 +965,12 @@ func save(config ConfigStore, handle string, netConfig NetworkConfig) {
 	config.Set(handle, iptableInstanceKey, netConfig.IPTableInstance)
 	config.Set(handle, mtuKey, strconv.Itoa(netConfig.Mtu))
 	config.Set(handle, externalIpKey, netConfig.ExternalIP.String())
+
+	if netConfig.SubnetV6 != nil {
+		config.Set(handle, containerIpv6Key, netConfig.ContainerIPv6.String())
+		config.Set(handle, bridgeIpv6Key, netConfig.BridgeIPv6.String())
+		config.Set(handle, subnetV6Key, netConfig.SubnetV6.String())
+	}
 }
 
 func load(config ConfigStore, handle string) (NetworkConfig, error) {
@@ -307,7 +990,7 @@ func load(config ConfigStore, handle string) (NetworkConfig, error) {
 		return NetworkConfig{}, err
 	}
 
-	return NetworkConfig{
+	cfg := NetworkConfig{
 		HostIntf:        vals[0],
 		ContainerIntf:   vals[1],
 		BridgeName:      vals[2],
@@ -318,5 +1001,65 @@ func load(config ConfigStore, handle string) (NetworkConfig, error) {
 		IPTablePrefix:   vals[6],
 		IPTableInstance: vals[7],
 		Mtu:             mtu,
-	}, nil
+	}
+
+	// the IPv6 keys are only present if the container was given an IPv6 address
+	if v6vals, err := getAll(config, handle, containerIpv6Key, bridgeIpv6Key, subnetV6Key); err == nil && v6vals[2] != "" {
+		_, ipnetV6, err := net.ParseCIDR(v6vals[2])
+		if err != nil {
+			return NetworkConfig{}, err
+		}
+
+		cfg.ContainerIPv6 = net.ParseIP(v6vals[0])
+		cfg.BridgeIPv6 = net.ParseIP(v6vals[1])
+		cfg.SubnetV6 = ipnetV6
+	}
+
+	return cfg, nil
+}
+
+func bandwidthLimits(configStore ConfigStore, handle string) garden.BandwidthLimits {
+	var limits garden.BandwidthLimits
+
+	limitsJson, err := configStore.Get(handle, bandwidthKey)
+	if err != nil {
+		return limits
+	}
+
+	json.Unmarshal([]byte(limitsJson), &limits)
+	return limits
+}
+
+// netOutRules returns the rules which have been applied to the container. A
+// record which cannot be decoded is an error rather than no rules, so that it
+// is never silently replaced.
+func netOutRules(configStore ConfigStore, handle string) ([]garden.NetOutRule, error) {
+	rules := []garden.NetOutRule{}
+
+	rulesJson, err := configStore.Get(handle, netOutRulesKey)
+	if err != nil {
+		return rules, nil
+	}
+
+	if err := json.Unmarshal([]byte(rulesJson), &rules); err != nil {
+		return nil, fmt.Errorf("decode %s: %s", netOutRulesKey, err)
+	}
+
+	return rules, nil
+}
+
+// addNetOutRules records rules which have been applied to the container, so
+// that they can be applied again when it is recovered or restored
+func addNetOutRules(configStore ConfigStore, handle string, rules []garden.NetOutRule) error {
+	existing, err := netOutRules(configStore, handle)
+	if err != nil {
+		return err
+	}
+
+	// Since the object we are marshalling here is always going to be
+	// valid, not checking for errors here
+	updatedRulesJson, _ := json.Marshal(append(existing, rules...))
+
+	configStore.Set(handle, netOutRulesKey, string(updatedRulesJson))
+	return nil
 }
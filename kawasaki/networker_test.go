@@ -1,6 +1,7 @@
 package kawasaki_test
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
 	"net"
@@ -10,9 +11,11 @@ import (
 	"github.com/cloudfoundry-incubator/guardian/gardener"
 	"github.com/cloudfoundry-incubator/guardian/kawasaki"
 	"github.com/cloudfoundry-incubator/guardian/kawasaki/fakes"
+	"github.com/cloudfoundry-incubator/guardian/kawasaki/ports"
 	"github.com/cloudfoundry-incubator/guardian/kawasaki/subnets"
 	"github.com/cloudfoundry-incubator/guardian/kawasaki/subnets/fake_subnet_pool"
 	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
 	. "github.com/onsi/gomega"
 	"github.com/pivotal-golang/lager"
 	"github.com/pivotal-golang/lager/lagertest"
@@ -20,18 +23,20 @@ import (
 
 var _ = Describe("Networker", func() {
 	var (
-		fakeSpecParser     *fakes.FakeSpecParser
-		fakeSubnetPool     *fake_subnet_pool.FakePool
-		fakeConfigCreator  *fakes.FakeConfigCreator
-		fakeConfigurer     *fakes.FakeConfigurer
-		fakeConfigStore    *fakes.FakeConfigStore
-		fakePortForwarder  *fakes.FakePortForwarder
-		fakePortPool       *fakes.FakePortPool
-		fakeFirewallOpener *fakes.FakeFirewallOpener
-		networker          *kawasaki.Networker
-		logger             lager.Logger
-		networkConfig      kawasaki.NetworkConfig
-		config             map[string]string
+		fakeSpecParser       *fakes.FakeSpecParser
+		fakeSubnetPool       *fake_subnet_pool.FakePool
+		fakeConfigCreator    *fakes.FakeConfigCreator
+		fakeConfigurer       *fakes.FakeConfigurer
+		fakeConfigStore      *fakes.FakeConfigStore
+		fakePortForwarder    *fakes.FakePortForwarder
+		fakePortPool         *fakes.FakePortPool
+		fakeFirewallOpener   *fakes.FakeFirewallOpener
+		fakeConntrack        *fakes.FakeConntrackFlusher
+		fakeBandwidthLimiter *fakes.FakeBandwidthLimiter
+		networker            *kawasaki.Networker
+		logger               lager.Logger
+		networkConfig        kawasaki.NetworkConfig
+		config               map[string]string
 	)
 
 	BeforeEach(func() {
@@ -43,18 +48,24 @@ var _ = Describe("Networker", func() {
 		fakePortForwarder = new(fakes.FakePortForwarder)
 		fakePortPool = new(fakes.FakePortPool)
 		fakeFirewallOpener = new(fakes.FakeFirewallOpener)
+		fakeConntrack = new(fakes.FakeConntrackFlusher)
+		fakeBandwidthLimiter = new(fakes.FakeBandwidthLimiter)
 
 		logger = lagertest.NewTestLogger("test")
 		networker = kawasaki.New(
 			"/path/to/kawasaki",
 			fakeSpecParser,
 			fakeSubnetPool,
+			nil,
 			fakeConfigCreator,
 			fakeConfigurer,
 			fakeConfigStore,
 			fakePortPool,
 			fakePortForwarder,
 			fakeFirewallOpener,
+			fakeConntrack,
+			fakeBandwidthLimiter,
+			kawasaki.DNSConfig{},
 		)
 
 		ip, subnet, err := net.ParseCIDR("123.123.123.12/24")
@@ -184,6 +195,283 @@ var _ = Describe("Networker", func() {
 			Expect(hooks.Prestart.Args).To(ContainElement("--iptable-prefix=" + networkConfig.IPTablePrefix))
 			Expect(hooks.Prestart.Args).To(ContainElement("--mtu=" + strconv.Itoa(networkConfig.Mtu)))
 		})
+
+		It("does not pass any IPv6 flags to the binary", func() {
+			hooks, err := networker.Hooks(logger, "some-handle", "1.2.3.4/30")
+			Expect(err).NotTo(HaveOccurred())
+
+			for _, arg := range hooks.Prestart.Args {
+				Expect(arg).NotTo(ContainSubstring("ipv6"))
+				Expect(arg).NotTo(ContainSubstring("subnet-v6"))
+			}
+		})
+
+		It("does not pass any DNS flags to the binary", func() {
+			hooks, err := networker.Hooks(logger, "some-handle", "1.2.3.4/30")
+			Expect(err).NotTo(HaveOccurred())
+
+			for _, arg := range hooks.Prestart.Args {
+				Expect(arg).NotTo(ContainSubstring("dns"))
+				Expect(arg).NotTo(ContainSubstring("nameserver"))
+			}
+		})
+
+		Context("when DNS is configured", func() {
+			BeforeEach(func() {
+				networker = kawasaki.New(
+					"/path/to/kawasaki",
+					fakeSpecParser,
+					fakeSubnetPool,
+					nil,
+					fakeConfigCreator,
+					fakeConfigurer,
+					fakeConfigStore,
+					fakePortPool,
+					fakePortForwarder,
+					fakeFirewallOpener,
+					fakeConntrack,
+					fakeBandwidthLimiter,
+					kawasaki.DNSConfig{
+						ResolvConfTemplate: "/path/to/resolv.conf.tmpl",
+						Nameservers:        []net.IP{net.ParseIP("10.0.0.1"), net.ParseIP("10.0.0.2")},
+						Forwarder:          true,
+					},
+				)
+			})
+
+			It("passes the operator's DNS config as flags to the binary", func() {
+				hooks, err := networker.Hooks(logger, "some-handle", "1.2.3.4/30")
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(hooks.Prestart.Args).To(ContainElement("--resolv-conf-template=/path/to/resolv.conf.tmpl"))
+				Expect(hooks.Prestart.Args).To(ContainElement("--nameserver=10.0.0.1"))
+				Expect(hooks.Prestart.Args).To(ContainElement("--nameserver=10.0.0.2"))
+				Expect(hooks.Prestart.Args).To(ContainElement("--dns-forwarder"))
+			})
+
+			It("passes the container's search domains and additional hosts as flags to the binary", func() {
+				hooks, err := networker.HooksWithDNS(logger, "some-handle", "1.2.3.4/30", gardener.DNSSpec{
+					SearchDomains:   []string{"example.com"},
+					AdditionalHosts: []string{"10.0.0.5 db db.internal"},
+				})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(hooks.Prestart.Args).To(ContainElement("--search-domain=example.com"))
+				Expect(hooks.Prestart.Args).To(ContainElement("--additional-host=10.0.0.5 db db.internal"))
+				Expect(hooks.Prestart.Args).To(ContainElement("--dns-forwarder"))
+			})
+		})
+
+		It("does not log denied connections by default", func() {
+			hooks, err := networker.Hooks(logger, "some-handle", "1.2.3.4/30")
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(hooks.Prestart.Args).NotTo(ContainElement("--log-denied"))
+		})
+
+		Context("when denied connections are logged", func() {
+			BeforeEach(func() {
+				networkConfig.LogDenied = true
+				fakeConfigCreator.CreateReturns(networkConfig, nil)
+			})
+
+			It("passes the flag to the binary", func() {
+				hooks, err := networker.Hooks(logger, "some-handle", "1.2.3.4/30")
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(hooks.Prestart.Args).To(ContainElement("--log-denied"))
+			})
+		})
+
+		Context("when the container has routes", func() {
+			route := func(raw string) gardener.Route {
+				r, err := gardener.ParseRoute(raw)
+				Expect(err).NotTo(HaveOccurred())
+				return r
+			}
+
+			It("passes the routes as flags to the binary", func() {
+				hooks, err := networker.HooksWithRoutes(logger, "some-handle", "1.2.3.4/30", gardener.DNSSpec{}, []gardener.Route{
+					route("10.100.0.0/16"),
+					route("172.20.0.0/16 via 123.123.123.5"),
+				})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(hooks.Prestart.Args).To(ContainElement("--route=10.100.0.0/16"))
+				Expect(hooks.Prestart.Args).To(ContainElement("--route=172.20.0.0/16 via 123.123.123.5"))
+			})
+
+			It("refuses next hops outside the container's subnet, releasing its IP", func() {
+				_, err := networker.HooksWithRoutes(logger, "some-handle", "1.2.3.4/30", gardener.DNSSpec{}, []gardener.Route{
+					route("172.20.0.0/16 via 10.0.0.1"),
+				})
+				Expect(err).To(MatchError("route to 172.20.0.0/16: next hop 10.0.0.1 is not in the container's subnet 123.123.123.0/24"))
+				Expect(fakeSubnetPool.ReleaseCallCount()).To(Equal(1))
+			})
+
+			It("refuses IPv6 routes when the container has no IPv6 address", func() {
+				_, err := networker.HooksWithRoutes(logger, "some-handle", "1.2.3.4/30", gardener.DNSSpec{}, []gardener.Route{
+					route("fd10::/64"),
+				})
+				Expect(err).To(MatchError("route to fd10::/64: the container has no IPv6 address"))
+			})
+		})
+
+		Context("when an IPv6 subnet pool is configured", func() {
+			var (
+				fakeSubnetPoolV6 *fake_subnet_pool.FakePool
+				subnetV6         *net.IPNet
+				ipV6             net.IP
+			)
+
+			BeforeEach(func() {
+				var err error
+				ipV6, subnetV6, err = net.ParseCIDR("fd00::2/126")
+				Expect(err).NotTo(HaveOccurred())
+
+				fakeSubnetPoolV6 = new(fake_subnet_pool.FakePool)
+				fakeSubnetPoolV6.AcquireReturns(subnetV6, ipV6, nil)
+
+				networker = kawasaki.New(
+					"/path/to/kawasaki",
+					fakeSpecParser,
+					fakeSubnetPool,
+					fakeSubnetPoolV6,
+					fakeConfigCreator,
+					fakeConfigurer,
+					fakeConfigStore,
+					fakePortPool,
+					fakePortForwarder,
+					fakeFirewallOpener,
+					fakeConntrack,
+					fakeBandwidthLimiter,
+					kawasaki.DNSConfig{},
+				)
+			})
+
+			It("dynamically acquires an IPv6 subnet and IP", func() {
+				_, err := networker.Hooks(logger, "some-handle", "1.2.3.4/30")
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(fakeSubnetPoolV6.AcquireCallCount()).To(Equal(1))
+				_, sr, ir := fakeSubnetPoolV6.AcquireArgsForCall(0)
+				Expect(sr).To(Equal(subnets.DynamicSubnetSelector))
+				Expect(ir).To(Equal(subnets.DynamicIPSelector))
+			})
+
+			It("passes the IPv6 config as flags to the binary", func() {
+				hooks, err := networker.Hooks(logger, "some-handle", "1.2.3.4/30")
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(hooks.Prestart.Args).To(ContainElement("--container-ipv6=fd00::2"))
+				Expect(hooks.Prestart.Args).To(ContainElement("--bridge-ipv6=fd00::1"))
+				Expect(hooks.Prestart.Args).To(ContainElement("--subnet-v6=fd00::/126"))
+			})
+
+			It("stores the IPv6 address so that it is visible in the container's info", func() {
+				config := make(map[string]string)
+				fakeConfigStore.SetStub = func(handle, name, value string) {
+					config[name] = value
+				}
+
+				_, err := networker.Hooks(logger, "some-handle", "1.2.3.4/30")
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(config[gardener.ContainerIPv6Key]).To(Equal("fd00::2"))
+				Expect(config["kawasaki.bridge-ipv6"]).To(Equal("fd00::1"))
+				Expect(config["kawasaki.subnet-v6"]).To(Equal("fd00::/126"))
+			})
+
+			Context("when acquiring the IPv6 subnet fails", func() {
+				BeforeEach(func() {
+					fakeSubnetPoolV6.AcquireReturns(nil, nil, errors.New("no v6 for you"))
+				})
+
+				It("returns a wrapped error", func() {
+					_, err := networker.Hooks(logger, "some-handle", "1.2.3.4/30")
+					Expect(err).To(MatchError("acquire ipv6 address: no v6 for you"))
+				})
+
+				It("releases the IPv4 subnet", func() {
+					someIp, someSubnet, err := net.ParseCIDR("1.2.3.4/30")
+					Expect(err).NotTo(HaveOccurred())
+					fakeSubnetPool.AcquireReturns(someSubnet, someIp, nil)
+
+					networker.Hooks(logger, "some-handle", "1.2.3.4/30")
+
+					Expect(fakeSubnetPool.ReleaseCallCount()).To(Equal(1))
+					subnet, ip := fakeSubnetPool.ReleaseArgsForCall(0)
+					Expect(subnet).To(Equal(someSubnet))
+					Expect(ip).To(Equal(someIp))
+				})
+			})
+
+			Describe("Destroy", func() {
+				BeforeEach(func() {
+					config[gardener.ContainerIPv6Key] = "fd00::2"
+					config["kawasaki.bridge-ipv6"] = "fd00::1"
+					config["kawasaki.subnet-v6"] = "fd00::/126"
+				})
+
+				It("releases the IPv6 subnet", func() {
+					Expect(networker.Destroy(logger, "some-handle")).To(Succeed())
+
+					Expect(fakeSubnetPoolV6.ReleaseCallCount()).To(Equal(1))
+					actualSubnet, actualIp := fakeSubnetPoolV6.ReleaseArgsForCall(0)
+					Expect(actualSubnet).To(Equal(subnetV6))
+					Expect(actualIp).To(Equal(ipV6))
+				})
+
+				It("passes the IPv6 config to the configurer", func() {
+					Expect(networker.Destroy(logger, "some-handle")).To(Succeed())
+
+					_, netConfig := fakeConfigurer.DestroyArgsForCall(0)
+					Expect(netConfig.ContainerIPv6).To(Equal(ipV6))
+					Expect(netConfig.BridgeIPv6.String()).To(Equal("fd00::1"))
+					Expect(netConfig.SubnetV6).To(Equal(subnetV6))
+				})
+
+				Context("when releasing the IPv6 subnet fails", func() {
+					BeforeEach(func() {
+						fakeSubnetPoolV6.ReleaseReturns(errors.New("v6 oh no"))
+					})
+
+					It("returns the error", func() {
+						Expect(networker.Destroy(logger, "some-handle")).To(MatchError("v6 oh no"))
+					})
+
+					It("still releases the IPv4 subnet", func() {
+						networker.Destroy(logger, "some-handle")
+
+						Expect(fakeSubnetPool.ReleaseCallCount()).To(Equal(1))
+						actualSubnet, actualIp := fakeSubnetPool.ReleaseArgsForCall(0)
+						Expect(actualIp).To(Equal(networkConfig.ContainerIP))
+						Expect(actualSubnet).To(Equal(networkConfig.Subnet))
+					})
+
+					Context("and releasing the IPv4 subnet fails too", func() {
+						It("returns both errors", func() {
+							fakeSubnetPool.ReleaseReturns(errors.New("v4 oh no"))
+							Expect(networker.Destroy(logger, "some-handle")).To(MatchError("v4 oh no; release ipv6 address: v6 oh no"))
+						})
+					})
+				})
+			})
+		})
+	})
+
+	Describe("InspectNetwork", func() {
+		It("returns the container's interfaces and addresses", func() {
+			network, err := networker.InspectNetwork(logger, "some-handle")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(network).To(Equal(gardener.NetworkRuntime{
+				HostInterface:      "banana-iface",
+				ContainerInterface: "container-of-bananas-iface",
+				BridgeInterface:    "bananas-bridge",
+				ContainerIP:        "123.123.123.12",
+				BridgeIP:           "123.123.123.1",
+			}))
+		})
 	})
 
 	Describe("Capacity", func() {
@@ -234,6 +522,318 @@ var _ = Describe("Networker", func() {
 				Expect(networker.Destroy(logger, "some-handle")).To(MatchError("oh no"))
 			})
 		})
+
+		Context("when ports are forwarded over UDP", func() {
+			BeforeEach(func() {
+				config[gardener.MappedPortsKey] = `[{"HostPort":60000,"ContainerPort":8080},{"HostPort":60001,"ContainerPort":514}]`
+				config["kawasaki.port-protocols"] = `{"60001":["udp"]}`
+			})
+
+			It("flushes the tracked UDP flows to the container", func() {
+				Expect(networker.Destroy(logger, "some-handle")).To(Succeed())
+
+				Expect(fakeConntrack.FlushCallCount()).To(Equal(1))
+				_, protocol, ip, port := fakeConntrack.FlushArgsForCall(0)
+				Expect(protocol).To(Equal("udp"))
+				Expect(ip).To(Equal(networkConfig.ContainerIP))
+				Expect(port).To(BeEquivalentTo(514))
+			})
+
+			Context("when flushing fails", func() {
+				It("still releases the subnet", func() {
+					fakeConntrack.FlushReturns(errors.New("conntrack failed"))
+
+					Expect(networker.Destroy(logger, "some-handle")).To(Succeed())
+					Expect(fakeSubnetPool.ReleaseCallCount()).To(Equal(1))
+				})
+			})
+		})
+
+		It("releases the container's host ports", func() {
+			Expect(networker.Destroy(logger, "some-handle")).To(Succeed())
+
+			Expect(fakePortPool.ReleaseAllCallCount()).To(Equal(1))
+			Expect(fakePortPool.ReleaseAllArgsForCall(0)).To(Equal("some-handle"))
+		})
+
+		Context("when releasing the host ports fails", func() {
+			It("still releases the subnet", func() {
+				fakePortPool.ReleaseAllReturns(errors.New("disk full"))
+
+				Expect(networker.Destroy(logger, "some-handle")).To(Succeed())
+				Expect(fakeSubnetPool.ReleaseCallCount()).To(Equal(1))
+			})
+		})
+
+		It("does not flush anything when only TCP ports are forwarded", func() {
+			config[gardener.MappedPortsKey] = `[{"HostPort":60000,"ContainerPort":8080}]`
+
+			Expect(networker.Destroy(logger, "some-handle")).To(Succeed())
+			Expect(fakeConntrack.FlushCallCount()).To(Equal(0))
+		})
+	})
+
+	Describe("Checkpoint", func() {
+		It("destroys the host configuration", func() {
+			Expect(networker.Checkpoint(logger, "some-handle")).To(Succeed())
+
+			Expect(fakeConfigurer.DestroyCallCount()).To(Equal(1))
+			_, netConfig := fakeConfigurer.DestroyArgsForCall(0)
+			Expect(netConfig).To(Equal(networkConfig))
+		})
+
+		It("keeps the subnet reserved", func() {
+			Expect(networker.Checkpoint(logger, "some-handle")).To(Succeed())
+			Expect(fakeSubnetPool.ReleaseCallCount()).To(Equal(0))
+		})
+
+		It("flushes the tracked UDP flows to the container", func() {
+			config[gardener.MappedPortsKey] = `[{"HostPort":60001,"ContainerPort":514}]`
+			config["kawasaki.port-protocols"] = `{"60001":["udp"]}`
+
+			Expect(networker.Checkpoint(logger, "some-handle")).To(Succeed())
+			Expect(fakeConntrack.FlushCallCount()).To(Equal(1))
+		})
+
+		Context("when the configuration is not destroyed", func() {
+			It("returns the error", func() {
+				fakeConfigurer.DestroyReturns(errors.New("spiderman-error"))
+				Expect(networker.Checkpoint(logger, "some-handle")).To(MatchError("spiderman-error"))
+			})
+		})
+	})
+
+	Describe("Restore", func() {
+		It("reserves the container's subnet and IP", func() {
+			Expect(networker.Restore(logger, "some-handle")).To(Succeed())
+
+			Expect(fakeSubnetPool.RemoveCallCount()).To(Equal(1))
+			subnet, ip := fakeSubnetPool.RemoveArgsForCall(0)
+			Expect(subnet).To(Equal(networkConfig.Subnet))
+			Expect(ip).To(Equal(networkConfig.ContainerIP))
+		})
+
+		Context("when the IP is already reserved", func() {
+			It("succeeds", func() {
+				fakeSubnetPool.RemoveReturns(subnets.ErrOverlapsExistingSubnet)
+				Expect(networker.Restore(logger, "some-handle")).To(Succeed())
+			})
+		})
+
+		Context("when reserving the IP fails", func() {
+			It("returns the error", func() {
+				fakeSubnetPool.RemoveReturns(errors.New("bad subnet"))
+				Expect(networker.Restore(logger, "some-handle")).To(MatchError("reserve container ip: bad subnet"))
+			})
+		})
+
+		It("re-applies the container's port forwarding rules", func() {
+			config[gardener.MappedPortsKey] = `[{"HostPort":60000,"ContainerPort":8080},{"HostPort":60001,"ContainerPort":9090}]`
+
+			Expect(networker.Restore(logger, "some-handle")).To(Succeed())
+
+			Expect(fakePortForwarder.ForwardCallCount()).To(Equal(2))
+			Expect(fakePortForwarder.ForwardArgsForCall(0)).To(Equal(kawasaki.PortForwarderSpec{
+				InstanceID:  networkConfig.IPTableInstance,
+				Protocol:    "tcp",
+				FromPort:    60000,
+				ToPort:      8080,
+				ContainerIP: networkConfig.ContainerIP,
+				ExternalIP:  networkConfig.ExternalIP,
+			}))
+			Expect(fakePortForwarder.ForwardArgsForCall(1).FromPort).To(BeEquivalentTo(60001))
+		})
+
+		It("re-applies UDP port forwarding rules", func() {
+			config[gardener.MappedPortsKey] = `[{"HostPort":60000,"ContainerPort":8080},{"HostPort":60001,"ContainerPort":9090}]`
+			config["kawasaki.port-protocols"] = `{"60001":["tcp","udp"]}`
+
+			Expect(networker.Restore(logger, "some-handle")).To(Succeed())
+
+			Expect(fakePortForwarder.ForwardCallCount()).To(Equal(3))
+			Expect(fakePortForwarder.ForwardArgsForCall(0).Protocol).To(Equal("tcp"))
+			Expect(fakePortForwarder.ForwardArgsForCall(1).Protocol).To(Equal("tcp"))
+			Expect(fakePortForwarder.ForwardArgsForCall(1).FromPort).To(BeEquivalentTo(60001))
+			Expect(fakePortForwarder.ForwardArgsForCall(2).Protocol).To(Equal("udp"))
+			Expect(fakePortForwarder.ForwardArgsForCall(2).FromPort).To(BeEquivalentTo(60001))
+		})
+
+		Context("when forwarding a port fails", func() {
+			It("returns the error", func() {
+				config[gardener.MappedPortsKey] = `[{"HostPort":60000,"ContainerPort":8080}]`
+				fakePortForwarder.ForwardReturns(errors.New("iptables failed"))
+
+				Expect(networker.Restore(logger, "some-handle")).To(MatchError("iptables failed"))
+			})
+		})
+
+		Context("when the configuration cannot be loaded", func() {
+			It("returns the error", func() {
+				fakeConfigStore.GetReturns("", errors.New("no config"))
+				fakeConfigStore.GetStub = nil
+
+				Expect(networker.Restore(logger, "some-handle")).To(MatchError("no config"))
+			})
+		})
+	})
+
+	Describe("Recover", func() {
+		It("re-creates the container's iptables chains", func() {
+			Expect(networker.Recover(logger, "some-handle")).To(Succeed())
+
+			Expect(fakeConfigurer.RecoverCallCount()).To(Equal(1))
+			_, netConfig := fakeConfigurer.RecoverArgsForCall(0)
+			Expect(netConfig).To(Equal(networkConfig))
+		})
+
+		It("reserves the container's subnet and IP", func() {
+			Expect(networker.Recover(logger, "some-handle")).To(Succeed())
+
+			Expect(fakeSubnetPool.RemoveCallCount()).To(Equal(1))
+			subnet, ip := fakeSubnetPool.RemoveArgsForCall(0)
+			Expect(subnet).To(Equal(networkConfig.Subnet))
+			Expect(ip).To(Equal(networkConfig.ContainerIP))
+		})
+
+		It("reserves and forwards the container's host ports", func() {
+			config[gardener.MappedPortsKey] = `[{"HostPort":60000,"ContainerPort":8080}]`
+
+			Expect(networker.Recover(logger, "some-handle")).To(Succeed())
+
+			Expect(fakePortPool.RemoveCallCount()).To(Equal(1))
+			handle, port := fakePortPool.RemoveArgsForCall(0)
+			Expect(handle).To(Equal("some-handle"))
+			Expect(port).To(BeEquivalentTo(60000))
+			Expect(fakePortForwarder.ForwardCallCount()).To(Equal(1))
+		})
+
+		Context("when a host port cannot be reserved", func() {
+			It("still forwards it", func() {
+				config[gardener.MappedPortsKey] = `[{"HostPort":60000,"ContainerPort":8080}]`
+				fakePortPool.RemoveReturns(ports.PortTakenError{Port: 60000})
+
+				Expect(networker.Recover(logger, "some-handle")).To(Succeed())
+				Expect(fakePortForwarder.ForwardCallCount()).To(Equal(1))
+			})
+		})
+
+		It("re-applies the container's net out rules in bulk", func() {
+			config[gardener.NetOutRulesKey] = `[{"protocol":1},{"protocol":3}]`
+
+			Expect(networker.Recover(logger, "some-handle")).To(Succeed())
+
+			Expect(fakeFirewallOpener.BulkOpenCallCount()).To(Equal(1))
+			_, instance, rules := fakeFirewallOpener.BulkOpenArgsForCall(0)
+			Expect(instance).To(Equal(networkConfig.IPTableInstance))
+			Expect(rules).To(Equal([]garden.NetOutRule{
+				{Protocol: garden.ProtocolTCP},
+				{Protocol: garden.ProtocolICMP},
+			}))
+		})
+
+		Context("when the container has no net out rules", func() {
+			It("does not apply any", func() {
+				Expect(networker.Recover(logger, "some-handle")).To(Succeed())
+				Expect(fakeFirewallOpener.BulkOpenCallCount()).To(Equal(0))
+			})
+		})
+
+		Context("when the net out rules cannot be re-applied", func() {
+			It("returns the error", func() {
+				config[gardener.NetOutRulesKey] = `[{"protocol":1}]`
+				fakeFirewallOpener.BulkOpenReturns(errors.New("iptables-restore failed"))
+
+				Expect(networker.Recover(logger, "some-handle")).To(MatchError("iptables-restore failed"))
+			})
+		})
+
+		Context("when the chains cannot be re-created", func() {
+			It("returns the error without reserving anything", func() {
+				fakeConfigurer.RecoverReturns(errors.New("iptables failed"))
+
+				Expect(networker.Recover(logger, "some-handle")).To(MatchError("iptables failed"))
+				Expect(fakeSubnetPool.RemoveCallCount()).To(Equal(0))
+			})
+		})
+	})
+
+	Context("when the subnet pool records which container holds each IP", func() {
+		var claimingPool *fakes.FakeClaimingPool
+
+		BeforeEach(func() {
+			claimingPool = new(fakes.FakeClaimingPool)
+			networker = kawasaki.New(
+				"/path/to/kawasaki",
+				fakeSpecParser,
+				struct {
+					*fake_subnet_pool.FakePool
+					*fakes.FakeClaimingPool
+				}{fakeSubnetPool, claimingPool},
+				nil,
+				fakeConfigCreator,
+				fakeConfigurer,
+				fakeConfigStore,
+				fakePortPool,
+				fakePortForwarder,
+				fakeFirewallOpener,
+				fakeConntrack,
+				fakeBandwidthLimiter,
+				kawasaki.DNSConfig{},
+			)
+
+			fakeSubnetPool.AcquireReturns(networkConfig.Subnet, networkConfig.ContainerIP, nil)
+		})
+
+		It("claims the IP it acquires for the container", func() {
+			_, err := networker.Hooks(logger, "some-handle", "")
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(claimingPool.ClaimCallCount()).To(Equal(1))
+			subnet, ip, handle := claimingPool.ClaimArgsForCall(0)
+			Expect(subnet).To(Equal(networkConfig.Subnet))
+			Expect(ip).To(Equal(networkConfig.ContainerIP))
+			Expect(handle).To(Equal("some-handle"))
+		})
+
+		It("releases the IP when it cannot be claimed", func() {
+			claimingPool.ClaimReturns(errors.New("disk full"))
+
+			_, err := networker.Hooks(logger, "some-handle", "")
+			Expect(err).To(MatchError("disk full"))
+			Expect(fakeSubnetPool.ReleaseCallCount()).To(Equal(1))
+		})
+
+		It("claims the IP of a recovered container", func() {
+			fakeSubnetPool.RemoveReturns(subnets.ErrOverlapsExistingSubnet)
+			Expect(networker.Recover(logger, "some-handle")).To(Succeed())
+
+			Expect(claimingPool.ClaimCallCount()).To(Equal(1))
+			_, _, handle := claimingPool.ClaimArgsForCall(0)
+			Expect(handle).To(Equal("some-handle"))
+		})
+
+		Context("when the IP of a recovered container is held by another container", func() {
+			It("does not recover it", func() {
+				fakeSubnetPool.RemoveReturns(subnets.ErrOverlapsExistingSubnet)
+				claimingPool.ClaimReturns(subnets.AllocationConflictError{
+					Subnet: networkConfig.Subnet,
+					IP:     networkConfig.ContainerIP,
+					Holder: "other-handle",
+				})
+
+				Expect(networker.Recover(logger, "some-handle")).To(MatchError(
+					"reserve container ip: 123.123.123.12 in subnet 123.123.123.0/24 is already allocated to container 'other-handle'",
+				))
+			})
+		})
+
+		It("prunes the IPs of containers which no longer exist", func() {
+			Expect(networker.Prune(logger, []string{"some-handle"})).To(Succeed())
+
+			Expect(claimingPool.PruneCallCount()).To(Equal(1))
+			_, handles := claimingPool.PruneArgsForCall(0)
+			Expect(handles).To(ConsistOf("some-handle"))
+		})
 	})
 
 	Describe("NetOut", func() {
@@ -247,6 +847,147 @@ var _ = Describe("Networker", func() {
 			Expect(chainArg).To(Equal(networkConfig.IPTableInstance))
 			Expect(ruleArg).To(Equal(rule))
 		})
+
+		It("records the rule, so it can be re-applied", func() {
+			config[gardener.NetOutRulesKey] = `[{"protocol":1}]`
+
+			rule := garden.NetOutRule{Protocol: garden.ProtocolICMP}
+			Expect(networker.NetOut(logger, "some-handle", rule)).To(Succeed())
+
+			Expect(fakeConfigStore.SetCallCount()).To(Equal(1))
+			handle, name, value := fakeConfigStore.SetArgsForCall(0)
+			Expect(handle).To(Equal("some-handle"))
+			Expect(name).To(Equal(gardener.NetOutRulesKey))
+
+			var rules []garden.NetOutRule
+			Expect(json.Unmarshal([]byte(value), &rules)).To(Succeed())
+			Expect(rules).To(Equal([]garden.NetOutRule{{Protocol: garden.ProtocolTCP}, rule}))
+		})
+
+		Context("when the recorded rules cannot be decoded", func() {
+			It("returns an error rather than replacing them", func() {
+				config[gardener.NetOutRulesKey] = `[{"protocol":`
+
+				Expect(networker.NetOut(logger, "some-handle", garden.NetOutRule{})).To(MatchError(ContainSubstring("decode " + gardener.NetOutRulesKey)))
+				Expect(fakeConfigStore.SetCallCount()).To(Equal(0))
+			})
+		})
+
+		Context("when the rule cannot be applied", func() {
+			It("does not record it", func() {
+				fakeFirewallOpener.OpenReturns(errors.New("potato"))
+
+				Expect(networker.NetOut(logger, "some-handle", garden.NetOutRule{})).NotTo(Succeed())
+				Expect(fakeConfigStore.SetCallCount()).To(Equal(0))
+			})
+		})
+	})
+
+	Describe("BulkNetOut", func() {
+		var rules []garden.NetOutRule
+
+		BeforeEach(func() {
+			rules = []garden.NetOutRule{
+				{Protocol: garden.ProtocolTCP, Ports: []garden.PortRange{garden.PortRangeFromPort(80)}},
+				{Protocol: garden.ProtocolUDP, Ports: []garden.PortRange{garden.PortRangeFromPort(53)}},
+			}
+		})
+
+		It("applies the rules in one go", func() {
+			Expect(networker.BulkNetOut(logger, "some-handle", rules)).To(Succeed())
+
+			Expect(fakeFirewallOpener.OpenCallCount()).To(Equal(0))
+			Expect(fakeFirewallOpener.BulkOpenCallCount()).To(Equal(1))
+			_, instance, rulesArg := fakeFirewallOpener.BulkOpenArgsForCall(0)
+			Expect(instance).To(Equal(networkConfig.IPTableInstance))
+			Expect(rulesArg).To(Equal(rules))
+		})
+
+		It("records the rules, so they can be re-applied", func() {
+			Expect(networker.BulkNetOut(logger, "some-handle", rules)).To(Succeed())
+
+			Expect(fakeConfigStore.SetCallCount()).To(Equal(1))
+			_, name, value := fakeConfigStore.SetArgsForCall(0)
+			Expect(name).To(Equal(gardener.NetOutRulesKey))
+
+			var recorded []garden.NetOutRule
+			Expect(json.Unmarshal([]byte(value), &recorded)).To(Succeed())
+			Expect(recorded).To(Equal(rules))
+		})
+
+		Context("when the rules cannot be applied", func() {
+			It("returns the error and does not record them", func() {
+				fakeFirewallOpener.BulkOpenReturns(errors.New("iptables-restore failed"))
+
+				Expect(networker.BulkNetOut(logger, "some-handle", rules)).To(MatchError("iptables-restore failed"))
+				Expect(fakeConfigStore.SetCallCount()).To(Equal(0))
+			})
+		})
+	})
+
+	Describe("LimitBandwidth", func() {
+		var limits garden.BandwidthLimits
+
+		BeforeEach(func() {
+			limits = garden.BandwidthLimits{RateInBytesPerSecond: 1024, BurstRateInBytesPerSecond: 4096}
+		})
+
+		It("limits the container's host interface", func() {
+			Expect(networker.LimitBandwidth(logger, "some-handle", limits)).To(Succeed())
+
+			Expect(fakeBandwidthLimiter.LimitCallCount()).To(Equal(1))
+			_, hostIntf, limitsArg := fakeBandwidthLimiter.LimitArgsForCall(0)
+			Expect(hostIntf).To(Equal(networkConfig.HostIntf))
+			Expect(limitsArg).To(Equal(limits))
+		})
+
+		It("records the limits, so they can be reported", func() {
+			Expect(networker.LimitBandwidth(logger, "some-handle", limits)).To(Succeed())
+
+			Expect(fakeConfigStore.SetCallCount()).To(Equal(1))
+			_, name, value := fakeConfigStore.SetArgsForCall(0)
+			Expect(name).To(Equal("kawasaki.bandwidth"))
+
+			config[name] = value
+			Expect(networker.CurrentBandwidthLimits(logger, "some-handle")).To(Equal(limits))
+		})
+
+		It("reports no limits for containers which have none", func() {
+			Expect(networker.CurrentBandwidthLimits(logger, "some-handle")).To(Equal(garden.BandwidthLimits{}))
+		})
+
+		Context("when the limits cannot be applied", func() {
+			It("returns the error and does not record them", func() {
+				fakeBandwidthLimiter.LimitReturns(errors.New("tc failed"))
+
+				Expect(networker.LimitBandwidth(logger, "some-handle", limits)).To(MatchError("tc failed"))
+				Expect(fakeConfigStore.SetCallCount()).To(Equal(0))
+			})
+		})
+
+		It("removes the limits when the container is destroyed", func() {
+			limitsJSON, err := json.Marshal(limits)
+			Expect(err).NotTo(HaveOccurred())
+			config["kawasaki.bandwidth"] = string(limitsJSON)
+
+			Expect(networker.Destroy(logger, "some-handle")).To(Succeed())
+
+			Expect(fakeBandwidthLimiter.RemoveCallCount()).To(Equal(1))
+			_, hostIntf := fakeBandwidthLimiter.RemoveArgsForCall(0)
+			Expect(hostIntf).To(Equal(networkConfig.HostIntf))
+		})
+
+		It("re-applies the limits when the container is recovered", func() {
+			limitsJSON, err := json.Marshal(limits)
+			Expect(err).NotTo(HaveOccurred())
+			config["kawasaki.bandwidth"] = string(limitsJSON)
+
+			Expect(networker.Recover(logger, "some-handle")).To(Succeed())
+
+			Expect(fakeBandwidthLimiter.LimitCallCount()).To(Equal(1))
+			_, _, limitsArg := fakeBandwidthLimiter.LimitArgsForCall(0)
+			Expect(limitsArg).To(Equal(limits))
+		})
 	})
 
 	Describe("NetIn", func() {
@@ -278,6 +1019,60 @@ var _ = Describe("Networker", func() {
 			Expect(fakePortPool.AcquireCallCount()).To(Equal(0))
 		})
 
+		It("reserves the given external port for the container", func() {
+			_, _, err := networker.NetIn(logger, handle, externalPort, containerPort)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(fakePortPool.RemoveCallCount()).To(Equal(1))
+			actualHandle, port := fakePortPool.RemoveArgsForCall(0)
+			Expect(actualHandle).To(Equal(handle))
+			Expect(port).To(Equal(externalPort))
+		})
+
+		Context("when the given external port is reserved by another container", func() {
+			It("returns the error without forwarding it", func() {
+				fakePortPool.RemoveReturns(ports.PortTakenError{Port: externalPort})
+
+				_, _, err := networker.NetIn(logger, handle, externalPort, containerPort)
+				Expect(err).To(MatchError("port already acquired: 123"))
+				Expect(fakePortForwarder.ForwardCallCount()).To(Equal(0))
+			})
+		})
+
+		Context("when the container has a net-in-port-range", func() {
+			BeforeEach(func() {
+				config[gardener.NetInPortRangeProperty] = "61000-61099"
+				fakePortPool.AcquireInRangeReturns(61005, nil)
+			})
+
+			It("acquires a port from the range", func() {
+				actualHostPort, _, err := networker.NetIn(logger, handle, 0, containerPort)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(actualHostPort).To(BeEquivalentTo(61005))
+
+				Expect(fakePortPool.AcquireCallCount()).To(Equal(0))
+				Expect(fakePortPool.AcquireInRangeCallCount()).To(Equal(1))
+				actualHandle, start, end := fakePortPool.AcquireInRangeArgsForCall(0)
+				Expect(actualHandle).To(Equal(handle))
+				Expect(start).To(BeEquivalentTo(61000))
+				Expect(end).To(BeEquivalentTo(61099))
+			})
+
+			DescribeTable("rejects invalid ranges",
+				func(value string) {
+					config[gardener.NetInPortRangeProperty] = value
+
+					_, _, err := networker.NetIn(logger, handle, 0, containerPort)
+					Expect(err).To(MatchError(fmt.Sprintf("invalid net-in-port-range property: '%s'", value)))
+					Expect(fakePortPool.AcquireInRangeCallCount()).To(Equal(0))
+				},
+				Entry("without a dash", "61000"),
+				Entry("with a non-numeric bound", "61000-lots"),
+				Entry("with an end before its start", "61099-61000"),
+				Entry("beyond the last port", "61000-70000"),
+			)
+		})
+
 		Context("when external port is not specified", func() {
 			It("acquires a random port from the pool", func() {
 				fakePortPool.AcquireReturns(externalPort, nil)
@@ -355,6 +1150,51 @@ var _ = Describe("Networker", func() {
 			Expect(actualValue).To(Equal(`[{"HostPort":123,"ContainerPort":456},{"HostPort":654,"ContainerPort":987}]`))
 		})
 
+		It("forwards TCP by default", func() {
+			_, _, err := networker.NetIn(logger, handle, externalPort, containerPort)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(fakePortForwarder.ForwardCallCount()).To(Equal(1))
+			Expect(fakePortForwarder.ForwardArgsForCall(0).Protocol).To(Equal("tcp"))
+		})
+
+		Describe("NetInProtocols", func() {
+			It("forwards each protocol", func() {
+				_, _, err := networker.NetInProtocols(logger, handle, externalPort, containerPort, []string{"tcp", "udp"})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(fakePortForwarder.ForwardCallCount()).To(Equal(2))
+				Expect(fakePortForwarder.ForwardArgsForCall(0).Protocol).To(Equal("tcp"))
+				Expect(fakePortForwarder.ForwardArgsForCall(1).Protocol).To(Equal("udp"))
+				Expect(fakePortForwarder.ForwardArgsForCall(1).FromPort).To(Equal(externalPort))
+				Expect(fakePortForwarder.ForwardArgsForCall(1).ToPort).To(Equal(containerPort))
+			})
+
+			It("records the protocols of the mapping, so they can be re-applied and flushed", func() {
+				config["kawasaki.port-protocols"] = `{"60000":["udp"]}`
+
+				_, _, err := networker.NetInProtocols(logger, handle, externalPort, containerPort, []string{"tcp", "udp"})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(fakeConfigStore.SetCallCount()).To(Equal(2))
+				_, name, value := fakeConfigStore.SetArgsForCall(1)
+				Expect(name).To(Equal("kawasaki.port-protocols"))
+				Expect(value).To(MatchJSON(`{"60000":["udp"],"123":["tcp","udp"]}`))
+			})
+
+			DescribeTable("rejects invalid protocols without forwarding anything",
+				func(protocols []string, message string) {
+					_, _, err := networker.NetInProtocols(logger, handle, externalPort, containerPort, protocols)
+					Expect(err).To(MatchError(message))
+					Expect(fakePortForwarder.ForwardCallCount()).To(Equal(0))
+					Expect(fakePortPool.RemoveCallCount()).To(Equal(0))
+				},
+				Entry("none", []string{}, "no protocols given"),
+				Entry("unknown", []string{"udp", "sctp"}, "unknown protocol 'sctp'"),
+				Entry("duplicated", []string{"udp", "udp"}, "protocol 'udp' given more than once"),
+			)
+		})
+
 		Context("when the PortForwarder fails", func() {
 			var err error
 
@@ -383,4 +1223,88 @@ var _ = Describe("Networker", func() {
 			})
 		})
 	})
+
+	Describe("NetInRemove", func() {
+		BeforeEach(func() {
+			config[gardener.MappedPortsKey] = `[{"HostPort":60000,"ContainerPort":8080},{"HostPort":60001,"ContainerPort":514}]`
+			config["kawasaki.port-protocols"] = `{"60001":["tcp","udp"]}`
+
+			fakeConfigStore.SetStub = func(handle, name, value string) {
+				config[name] = value
+			}
+		})
+
+		It("deletes the forwarding rule of each of the port's protocols", func() {
+			Expect(networker.NetInRemove(logger, "some-handle", 60001)).To(Succeed())
+
+			Expect(fakePortForwarder.UnforwardCallCount()).To(Equal(2))
+			spec := fakePortForwarder.UnforwardArgsForCall(1)
+			Expect(spec).To(Equal(kawasaki.PortForwarderSpec{
+				InstanceID:  networkConfig.IPTableInstance,
+				Protocol:    "udp",
+				FromPort:    60001,
+				ToPort:      514,
+				ContainerIP: networkConfig.ContainerIP,
+				ExternalIP:  networkConfig.ExternalIP,
+			}))
+			Expect(fakePortForwarder.UnforwardArgsForCall(0).Protocol).To(Equal("tcp"))
+		})
+
+		It("flushes the flows of each protocol, as the container's connections are not ended", func() {
+			Expect(networker.NetInRemove(logger, "some-handle", 60001)).To(Succeed())
+
+			Expect(fakeConntrack.FlushCallCount()).To(Equal(2))
+			_, protocol, ip, port := fakeConntrack.FlushArgsForCall(0)
+			Expect(protocol).To(Equal("tcp"))
+			Expect(ip).To(Equal(networkConfig.ContainerIP))
+			Expect(port).To(BeEquivalentTo(514))
+			_, protocol, _, _ = fakeConntrack.FlushArgsForCall(1)
+			Expect(protocol).To(Equal("udp"))
+		})
+
+		It("forgets the mapping and its protocols", func() {
+			Expect(networker.NetInRemove(logger, "some-handle", 60001)).To(Succeed())
+
+			Expect(config[gardener.MappedPortsKey]).To(MatchJSON(`[{"HostPort":60000,"ContainerPort":8080}]`))
+			Expect(config["kawasaki.port-protocols"]).To(MatchJSON(`{}`))
+		})
+
+		It("releases the host port", func() {
+			Expect(networker.NetInRemove(logger, "some-handle", 60001)).To(Succeed())
+
+			Expect(fakePortPool.ReleasePortCallCount()).To(Equal(1))
+			handle, port := fakePortPool.ReleasePortArgsForCall(0)
+			Expect(handle).To(Equal("some-handle"))
+			Expect(port).To(BeEquivalentTo(60001))
+		})
+
+		Context("when the port is not mapped to the container", func() {
+			It("returns an error without changing anything", func() {
+				Expect(networker.NetInRemove(logger, "some-handle", 60002)).To(MatchError("port 60002 is not mapped to container some-handle"))
+
+				Expect(fakePortForwarder.UnforwardCallCount()).To(Equal(0))
+				Expect(fakeConfigStore.SetCallCount()).To(Equal(0))
+				Expect(fakePortPool.ReleasePortCallCount()).To(Equal(0))
+			})
+		})
+
+		Context("when deleting a forwarding rule fails", func() {
+			It("keeps the mapping and the port", func() {
+				fakePortForwarder.UnforwardReturns(errors.New("iptables failed"))
+
+				Expect(networker.NetInRemove(logger, "some-handle", 60001)).To(MatchError("iptables failed"))
+				Expect(fakeConfigStore.SetCallCount()).To(Equal(0))
+				Expect(fakePortPool.ReleasePortCallCount()).To(Equal(0))
+			})
+		})
+
+		Context("when flushing fails", func() {
+			It("still unmaps the port", func() {
+				fakeConntrack.FlushReturns(errors.New("conntrack failed"))
+
+				Expect(networker.NetInRemove(logger, "some-handle", 60001)).To(Succeed())
+				Expect(fakePortPool.ReleasePortCallCount()).To(Equal(1))
+			})
+		})
+	})
 })
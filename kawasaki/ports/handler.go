@@ -0,0 +1,34 @@
+package ports
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// Reservations is the body of a response from the Handler
+type Reservations struct {
+	Start uint32              `json:"start"`
+	Size  uint32              `json:"size"`
+	Ports map[string][]uint32 `json:"ports"`
+}
+
+// Handler serves the range of the pool and the ports reserved by each
+// container, as JSON
+type Handler struct {
+	Pool *PortPool
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	start, size := h.Pool.Range()
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(Reservations{
+		Start: start,
+		Size:  size,
+		Ports: h.Pool.Reservations(),
+	})
+}
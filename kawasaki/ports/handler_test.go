@@ -0,0 +1,47 @@
+package ports_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/cloudfoundry-incubator/guardian/kawasaki/ports"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Handler", func() {
+	var (
+		pool    *ports.PortPool
+		handler *ports.Handler
+	)
+
+	BeforeEach(func() {
+		var err error
+		pool, err = ports.NewPool(10000, 5, ports.State{})
+		Expect(err).NotTo(HaveOccurred())
+
+		handler = &ports.Handler{Pool: pool}
+	})
+
+	It("serves the range of the pool and each container's ports", func() {
+		Expect(pool.Acquire("some-handle")).To(BeEquivalentTo(10000))
+
+		recorder := httptest.NewRecorder()
+		request, err := http.NewRequest("GET", "/ports", nil)
+		Expect(err).NotTo(HaveOccurred())
+		handler.ServeHTTP(recorder, request)
+
+		Expect(recorder.Code).To(Equal(http.StatusOK))
+		Expect(recorder.HeaderMap.Get("Content-Type")).To(Equal("application/json"))
+		Expect(recorder.Body.String()).To(MatchJSON(`{"start":10000,"size":5,"ports":{"some-handle":[10000]}}`))
+	})
+
+	It("only allows GET", func() {
+		recorder := httptest.NewRecorder()
+		request, err := http.NewRequest("POST", "/ports", nil)
+		Expect(err).NotTo(HaveOccurred())
+		handler.ServeHTTP(recorder, request)
+
+		Expect(recorder.Code).To(Equal(http.StatusMethodNotAllowed))
+	})
+})
@@ -0,0 +1,95 @@
+package ports
+
+import (
+	"os"
+	"sync"
+)
+
+// PersistentPool is a PortPool which saves its state to a file whenever it
+// changes, and is loaded from that file, so that each container keeps its
+// ports, and ports are handed out in the same order, across a restart. Each
+// change and the save which follows it are made under one lock, so that the
+// file always holds the latest state.
+type PersistentPool struct {
+	*PortPool
+	path string
+
+	mu sync.Mutex
+}
+
+func NewPersistentPool(start, size uint32, path string) (*PersistentPool, error) {
+	state := State{}
+	if _, err := os.Stat(path); err == nil {
+		if state, err = LoadState(path); err != nil {
+			return nil, err
+		}
+	}
+
+	pool, err := NewPool(start, size, state)
+	if err != nil {
+		return nil, err
+	}
+
+	return &PersistentPool{PortPool: pool, path: path}, nil
+}
+
+func (p *PersistentPool) Acquire(handle string) (uint32, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	port, err := p.PortPool.Acquire(handle)
+	if err != nil {
+		return 0, err
+	}
+
+	return port, p.save()
+}
+
+func (p *PersistentPool) AcquireInRange(handle string, start, end uint32) (uint32, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	port, err := p.PortPool.AcquireInRange(handle, start, end)
+	if err != nil {
+		return 0, err
+	}
+
+	return port, p.save()
+}
+
+func (p *PersistentPool) Remove(handle string, port uint32) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if err := p.PortPool.Remove(handle, port); err != nil {
+		return err
+	}
+
+	return p.save()
+}
+
+func (p *PersistentPool) ReleasePort(handle string, port uint32) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if err := p.PortPool.ReleasePort(handle, port); err != nil {
+		return err
+	}
+
+	return p.save()
+}
+
+func (p *PersistentPool) ReleaseAll(handle string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if err := p.PortPool.ReleaseAll(handle); err != nil {
+		return err
+	}
+
+	return p.save()
+}
+
+func (p *PersistentPool) save() error {
+	return SaveState(p.path, p.RefreshState())
+}
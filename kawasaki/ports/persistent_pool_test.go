@@ -0,0 +1,123 @@
+package ports_test
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/cloudfoundry-incubator/guardian/kawasaki/ports"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("PersistentPool", func() {
+	var (
+		tmpDir   string
+		filePath string
+	)
+
+	BeforeEach(func() {
+		var err error
+
+		tmpDir, err = ioutil.TempDir("", "")
+		Expect(err).NotTo(HaveOccurred())
+		filePath = filepath.Join(tmpDir, "ports.json")
+	})
+
+	AfterEach(func() {
+		Expect(os.RemoveAll(tmpDir)).To(Succeed())
+	})
+
+	It("starts empty when there is no state file", func() {
+		pool, err := ports.NewPersistentPool(10000, 5, filePath)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(pool.Acquire("some-handle")).To(BeEquivalentTo(10000))
+	})
+
+	It("keeps each container's ports, and the order ports are handed out in, across a restart", func() {
+		pool, err := ports.NewPersistentPool(10000, 5, filePath)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(pool.Acquire("some-handle")).To(BeEquivalentTo(10000))
+		Expect(pool.AcquireInRange("other-handle", 10003, 10004)).To(BeEquivalentTo(10003))
+		Expect(pool.Remove("other-handle", 10001)).To(Succeed())
+
+		restarted, err := ports.NewPersistentPool(10000, 5, filePath)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(restarted.Reservations()).To(Equal(map[string][]uint32{
+			"some-handle":  {10000},
+			"other-handle": {10001, 10003},
+		}))
+		Expect(restarted.Acquire("new-handle")).To(BeEquivalentTo(10002))
+	})
+
+	It("saves released ports", func() {
+		pool, err := ports.NewPersistentPool(10000, 5, filePath)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(pool.Acquire("some-handle")).To(BeEquivalentTo(10000))
+		Expect(pool.ReleaseAll("some-handle")).To(Succeed())
+
+		restarted, err := ports.NewPersistentPool(10000, 5, filePath)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(restarted.Reservations()).To(BeEmpty())
+	})
+
+	It("saves a released port", func() {
+		pool, err := ports.NewPersistentPool(10000, 5, filePath)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(pool.Acquire("some-handle")).To(BeEquivalentTo(10000))
+		Expect(pool.Acquire("some-handle")).To(BeEquivalentTo(10001))
+		Expect(pool.ReleasePort("some-handle", 10000)).To(Succeed())
+
+		restarted, err := ports.NewPersistentPool(10000, 5, filePath)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(restarted.Reservations()).To(Equal(map[string][]uint32{"some-handle": {10001}}))
+	})
+
+	Context("when the state file is corrupt", func() {
+		It("returns an error", func() {
+			Expect(ioutil.WriteFile(filePath, []byte("{"), 0600)).To(Succeed())
+
+			_, err := ports.NewPersistentPool(10000, 5, filePath)
+			Expect(err).To(BeAssignableToTypeOf(ports.CorruptStateError{}))
+			Expect(err).To(MatchError(ContainSubstring("parsing state file")))
+		})
+	})
+
+	It("saves concurrent acquisitions without losing any", func() {
+		pool, err := ports.NewPersistentPool(10000, 50, filePath)
+		Expect(err).NotTo(HaveOccurred())
+
+		done := make(chan struct{})
+		for i := 0; i < 20; i++ {
+			go func(i int) {
+				defer GinkgoRecover()
+				_, err := pool.Acquire(fmt.Sprintf("handle-%d", i))
+				Expect(err).NotTo(HaveOccurred())
+				done <- struct{}{}
+			}(i)
+		}
+		for i := 0; i < 20; i++ {
+			<-done
+		}
+
+		restarted, err := ports.NewPersistentPool(10000, 50, filePath)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(restarted.Reservations()).To(HaveLen(20))
+	})
+
+	Context("when the state cannot be saved", func() {
+		It("returns an error", func() {
+			pool, err := ports.NewPersistentPool(10000, 5, filepath.Join(tmpDir, "missing", "ports.json"))
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = pool.Acquire("some-handle")
+			Expect(err).To(MatchError(ContainSubstring("creating state file")))
+		})
+	})
+})
@@ -2,6 +2,7 @@ package ports
 
 import (
 	"fmt"
+	"sort"
 	"sync"
 )
 
@@ -10,6 +11,7 @@ type PortPool struct {
 	size  uint32
 
 	pool      []uint32
+	reserved  map[uint32]string
 	poolMutex sync.Mutex
 
 	state State
@@ -21,6 +23,14 @@ func (e PoolExhaustedError) Error() string {
 	return "port pool is exhausted"
 }
 
+type RangeExhaustedError struct {
+	Start, End uint32
+}
+
+func (e RangeExhaustedError) Error() string {
+	return fmt.Sprintf("no free port in range %d-%d", e.Start, e.End)
+}
+
 type PortTakenError struct {
 	Port uint32
 }
@@ -45,20 +55,34 @@ func NewPool(start, size uint32, state State) (*PortPool, error) {
 		pool[i] = port
 		i += 1
 	}
+
 	for port := start; port < start+state.Offset; port++ {
 		pool[i] = port
 		i += 1
 	}
 
-	return &PortPool{
+	p := &PortPool{
 		start: start,
 		size:  size,
 
-		pool: pool,
-	}, nil
+		pool:     pool,
+		reserved: make(map[uint32]string),
+	}
+
+	// ports reserved by a previous run stay with the same containers
+	for handle, ports := range state.Reserved {
+		for _, port := range ports {
+			if p.inRange(port) {
+				p.take(handle, port)
+			}
+		}
+	}
+
+	return p, nil
 }
 
-func (p *PortPool) Acquire() (uint32, error) {
+// Acquire reserves the next free port in the pool for the container
+func (p *PortPool) Acquire(handle string) (uint32, error) {
 	p.poolMutex.Lock()
 	defer p.poolMutex.Unlock()
 
@@ -67,58 +91,167 @@ func (p *PortPool) Acquire() (uint32, error) {
 	}
 
 	port := p.pool[0]
-
 	p.pool = p.pool[1:]
+	p.reserved[port] = handle
 
 	return port, nil
 }
 
-func (p *PortPool) Remove(port uint32) error {
-	idx := 0
-	found := false
-
+// AcquireInRange reserves the next free port in the pool between start and
+// end, inclusive, for the container
+func (p *PortPool) AcquireInRange(handle string, start, end uint32) (uint32, error) {
 	p.poolMutex.Lock()
 	defer p.poolMutex.Unlock()
 
-	for i, existingPort := range p.pool {
-		if existingPort == port {
-			idx = i
-			found = true
-			break
+	for _, port := range p.pool {
+		if port >= start && port <= end {
+			p.take(handle, port)
+			return port, nil
 		}
 	}
 
-	if !found {
+	return 0, RangeExhaustedError{Start: start, End: end}
+}
+
+// Remove reserves a specific port for the container. Ports outside the pool
+// are not managed by it, so are always available; ports in the pool are
+// available unless another container has reserved them.
+func (p *PortPool) Remove(handle string, port uint32) error {
+	if !p.inRange(port) {
+		return nil
+	}
+
+	p.poolMutex.Lock()
+	defer p.poolMutex.Unlock()
+
+	if owner, ok := p.reserved[port]; ok {
+		if owner == handle {
+			return nil
+		}
+
 		return PortTakenError{port}
 	}
 
-	p.pool = append(p.pool[:idx], p.pool[idx+1:]...)
+	if !p.take(handle, port) {
+		return PortTakenError{port}
+	}
 
 	return nil
 }
 
 func (p *PortPool) Release(port uint32) {
-	if port < p.start || port >= p.start+p.size {
+	if !p.inRange(port) {
 		return
 	}
 
 	p.poolMutex.Lock()
 	defer p.poolMutex.Unlock()
 
-	for _, existingPort := range p.pool {
-		if existingPort == port {
-			return
+	p.release(port)
+}
+
+// ReleasePort puts one of the container's ports back in the pool, e.g. once
+// it is no longer forwarded. Ports reserved by other containers are kept.
+func (p *PortPool) ReleasePort(handle string, port uint32) error {
+	if !p.inRange(port) {
+		return nil
+	}
+
+	p.poolMutex.Lock()
+	defer p.poolMutex.Unlock()
+
+	if owner, ok := p.reserved[port]; ok && owner == handle {
+		p.release(port)
+	}
+
+	return nil
+}
+
+// ReleaseAll puts all of the container's ports back in the pool
+func (p *PortPool) ReleaseAll(handle string) error {
+	p.poolMutex.Lock()
+	defer p.poolMutex.Unlock()
+
+	for port, owner := range p.reserved {
+		if owner == handle {
+			p.release(port)
 		}
 	}
 
-	p.pool = append(p.pool, port)
+	return nil
+}
+
+// Reservations returns the ports reserved by each container, in order
+func (p *PortPool) Reservations() map[string][]uint32 {
+	p.poolMutex.Lock()
+	defer p.poolMutex.Unlock()
+
+	return p.reservations()
+}
+
+// Range returns the first port of the pool and its size
+func (p *PortPool) Range() (uint32, uint32) {
+	return p.start, p.size
 }
 
 func (p *PortPool) RefreshState() State {
+	p.poolMutex.Lock()
+	defer p.poolMutex.Unlock()
+
 	if len(p.pool) == 0 {
 		p.state.Offset = 0
 	} else {
 		p.state.Offset = p.pool[0] - p.start
 	}
+
+	p.state.Reserved = p.reservations()
 	return p.state
 }
+
+func (p *PortPool) inRange(port uint32) bool {
+	return port >= p.start && port < p.start+p.size
+}
+
+// take removes port from the free list, returning false if it is not there
+func (p *PortPool) take(handle string, port uint32) bool {
+	for i, existingPort := range p.pool {
+		if existingPort == port {
+			p.pool = append(p.pool[:i], p.pool[i+1:]...)
+			p.reserved[port] = handle
+			return true
+		}
+	}
+
+	return false
+}
+
+func (p *PortPool) release(port uint32) {
+	delete(p.reserved, port)
+
+	for _, existingPort := range p.pool {
+		if existingPort == port {
+			return
+		}
+	}
+
+	p.pool = append(p.pool, port)
+}
+
+func (p *PortPool) reservations() map[string][]uint32 {
+	reservations := make(map[string][]uint32)
+	for port, handle := range p.reserved {
+		reservations[handle] = append(reservations[handle], port)
+	}
+
+	for _, ports := range reservations {
+		sort.Sort(portList(ports))
+	}
+
+	return reservations
+}
+
+type portList []uint32
+
+func (l portList) Len() int           { return len(l) }
+func (l portList) Less(i, j int) bool { return l[i] < l[j] }
+func (l portList) Swap(i, j int)      { l[i], l[j] = l[j], l[i] }
@@ -28,10 +28,10 @@ var _ = Describe("Port pool", func() {
 			pool, err := ports.NewPool(10000, 5, initialState)
 			Expect(err).ToNot(HaveOccurred())
 
-			port1, err := pool.Acquire()
+			port1, err := pool.Acquire("some-handle")
 			Expect(err).ToNot(HaveOccurred())
 
-			port2, err := pool.Acquire()
+			port2, err := pool.Acquire("some-handle")
 			Expect(err).ToNot(HaveOccurred())
 
 			Expect(port1).To(Equal(uint32(10000)))
@@ -44,11 +44,11 @@ var _ = Describe("Port pool", func() {
 				Expect(err).ToNot(HaveOccurred())
 
 				for i := 0; i < 5; i++ {
-					_, err := pool.Acquire()
+					_, err := pool.Acquire("some-handle")
 					Expect(err).ToNot(HaveOccurred())
 				}
 
-				_, err = pool.Acquire()
+				_, err = pool.Acquire("some-handle")
 				Expect(err).To(HaveOccurred())
 			})
 		})
@@ -59,10 +59,10 @@ var _ = Describe("Port pool", func() {
 				pool, err := ports.NewPool(10000, 5, initialState)
 				Expect(err).ToNot(HaveOccurred())
 
-				port1, err := pool.Acquire()
+				port1, err := pool.Acquire("some-handle")
 				Expect(err).ToNot(HaveOccurred())
 
-				port2, err := pool.Acquire()
+				port2, err := pool.Acquire("some-handle")
 				Expect(err).ToNot(HaveOccurred())
 
 				Expect(port1).To(Equal(uint32(10002)))
@@ -74,7 +74,7 @@ var _ = Describe("Port pool", func() {
 					pool, err := ports.NewPool(10000, 5, initialState)
 					Expect(err).ToNot(HaveOccurred())
 
-					port, err := pool.Acquire()
+					port, err := pool.Acquire("some-handle")
 					Expect(err).ToNot(HaveOccurred())
 					Expect(port).To(Equal(uint32(10000)))
 				})
@@ -88,12 +88,12 @@ var _ = Describe("Port pool", func() {
 				pool, err := ports.NewPool(startPort, 5, initialState)
 				Expect(err).ToNot(HaveOccurred())
 
-				port, err := pool.Acquire()
+				port, err := pool.Acquire("some-handle")
 				Expect(port).To(Equal(uint32(10004)))
 				Expect(err).ToNot(HaveOccurred())
 
 				for i := uint32(0); i < portOffset; i++ {
-					port, err := pool.Acquire()
+					port, err := pool.Acquire("some-handle")
 					Expect(err).ToNot(HaveOccurred())
 					Expect(port).To(Equal(startPort + i))
 				}
@@ -106,14 +106,14 @@ var _ = Describe("Port pool", func() {
 			pool, err := ports.NewPool(10000, 2, initialState)
 			Expect(err).ToNot(HaveOccurred())
 
-			err = pool.Remove(10000)
+			err = pool.Remove("some-handle", 10000)
 			Expect(err).ToNot(HaveOccurred())
 
-			port, err := pool.Acquire()
+			port, err := pool.Acquire("some-handle")
 			Expect(err).ToNot(HaveOccurred())
 			Expect(port).To(Equal(uint32(10001)))
 
-			_, err = pool.Acquire()
+			_, err = pool.Acquire("some-handle")
 			Expect(err).To(HaveOccurred())
 		})
 
@@ -122,13 +122,113 @@ var _ = Describe("Port pool", func() {
 				pool, err := ports.NewPool(10000, 2, initialState)
 				Expect(err).ToNot(HaveOccurred())
 
-				port, err := pool.Acquire()
+				port, err := pool.Acquire("some-handle")
 				Expect(err).ToNot(HaveOccurred())
 
-				err = pool.Remove(port)
+				err = pool.Remove("other-handle", port)
 				Expect(err).To(Equal(ports.PortTakenError{Port: port}))
 			})
 		})
+
+		Context("when the port is already reserved by the same container", func() {
+			It("succeeds", func() {
+				pool, err := ports.NewPool(10000, 2, initialState)
+				Expect(err).ToNot(HaveOccurred())
+
+				port, err := pool.Acquire("some-handle")
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(pool.Remove("some-handle", port)).To(Succeed())
+			})
+		})
+
+		Context("when the port is outside the pool", func() {
+			It("succeeds without reserving it", func() {
+				pool, err := ports.NewPool(10000, 2, initialState)
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(pool.Remove("some-handle", 20000)).To(Succeed())
+				Expect(pool.Reservations()).To(BeEmpty())
+			})
+		})
+	})
+
+	Describe("acquiring in a range", func() {
+		It("returns the next available port in the range", func() {
+			pool, err := ports.NewPool(10000, 10, initialState)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(pool.AcquireInRange("some-handle", 10005, 10006)).To(BeEquivalentTo(10005))
+			Expect(pool.AcquireInRange("some-handle", 10005, 10006)).To(BeEquivalentTo(10006))
+
+			port, err := pool.Acquire("some-handle")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(port).To(BeEquivalentTo(10000))
+		})
+
+		Context("when the range has no free ports", func() {
+			It("returns a RangeExhaustedError", func() {
+				pool, err := ports.NewPool(10000, 10, initialState)
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(pool.Remove("other-handle", 10005)).To(Succeed())
+
+				_, err = pool.AcquireInRange("some-handle", 10005, 10005)
+				Expect(err).To(Equal(ports.RangeExhaustedError{Start: 10005, End: 10005}))
+				Expect(err).To(MatchError("no free port in range 10005-10005"))
+			})
+		})
+	})
+
+	Describe("reservations", func() {
+		It("lists the ports reserved by each container, in order", func() {
+			pool, err := ports.NewPool(10000, 10, initialState)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(pool.Remove("some-handle", 10007)).To(Succeed())
+			Expect(pool.Acquire("some-handle")).To(BeEquivalentTo(10000))
+			Expect(pool.Acquire("other-handle")).To(BeEquivalentTo(10001))
+
+			Expect(pool.Reservations()).To(Equal(map[string][]uint32{
+				"some-handle":  {10000, 10007},
+				"other-handle": {10001},
+			}))
+		})
+
+		It("releases all of a container's ports", func() {
+			pool, err := ports.NewPool(10000, 2, initialState)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(pool.Acquire("some-handle")).To(BeEquivalentTo(10000))
+			Expect(pool.Acquire("some-handle")).To(BeEquivalentTo(10001))
+			Expect(pool.ReleaseAll("some-handle")).To(Succeed())
+
+			Expect(pool.Reservations()).To(BeEmpty())
+			Expect(pool.Acquire("other-handle")).To(BeEquivalentTo(10000))
+		})
+
+		It("releases one of a container's ports, but not another container's", func() {
+			pool, err := ports.NewPool(10000, 2, initialState)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(pool.Acquire("some-handle")).To(BeEquivalentTo(10000))
+			Expect(pool.Acquire("other-handle")).To(BeEquivalentTo(10001))
+			Expect(pool.ReleasePort("some-handle", 10000)).To(Succeed())
+			Expect(pool.ReleasePort("some-handle", 10001)).To(Succeed())
+
+			Expect(pool.Reservations()).To(Equal(map[string][]uint32{"other-handle": {10001}}))
+		})
+
+		It("restores the reservations from the state", func() {
+			initialState.Reserved = map[string][]uint32{"some-handle": {10001}, "old-handle": {20000}}
+			pool, err := ports.NewPool(10000, 3, initialState)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(pool.Reservations()).To(Equal(map[string][]uint32{"some-handle": {10001}}))
+			Expect(pool.Remove("other-handle", 10001)).To(Equal(ports.PortTakenError{Port: 10001}))
+			Expect(pool.Acquire("other-handle")).To(BeEquivalentTo(10000))
+			Expect(pool.Acquire("other-handle")).To(BeEquivalentTo(10002))
+		})
 	})
 
 	Describe("releasing", func() {
@@ -136,17 +236,17 @@ var _ = Describe("Port pool", func() {
 			pool, err := ports.NewPool(10000, 2, initialState)
 			Expect(err).ToNot(HaveOccurred())
 
-			port1, err := pool.Acquire()
+			port1, err := pool.Acquire("some-handle")
 			Expect(err).ToNot(HaveOccurred())
 			Expect(port1).To(Equal(uint32(10000)))
 
 			pool.Release(port1)
 
-			port2, err := pool.Acquire()
+			port2, err := pool.Acquire("some-handle")
 			Expect(err).ToNot(HaveOccurred())
 			Expect(port2).To(Equal(uint32(10001)))
 
-			nextPort, err := pool.Acquire()
+			nextPort, err := pool.Acquire("some-handle")
 			Expect(err).ToNot(HaveOccurred())
 			Expect(nextPort).To(Equal(uint32(10000)))
 		})
@@ -158,7 +258,7 @@ var _ = Describe("Port pool", func() {
 
 				pool.Release(20000)
 
-				_, err = pool.Acquire()
+				_, err = pool.Acquire("some-handle")
 				Expect(err).To(HaveOccurred())
 			})
 		})
@@ -168,22 +268,22 @@ var _ = Describe("Port pool", func() {
 				pool, err := ports.NewPool(10000, 2, initialState)
 				Expect(err).ToNot(HaveOccurred())
 
-				port1, err := pool.Acquire()
+				port1, err := pool.Acquire("some-handle")
 				Expect(err).ToNot(HaveOccurred())
 				Expect(port1).To(Equal(uint32(10000)))
 
 				pool.Release(port1)
 				pool.Release(port1)
 
-				port2, err := pool.Acquire()
+				port2, err := pool.Acquire("some-handle")
 				Expect(err).ToNot(HaveOccurred())
 				Expect(port2).ToNot(Equal(port1))
 
-				port3, err := pool.Acquire()
+				port3, err := pool.Acquire("some-handle")
 				Expect(err).ToNot(HaveOccurred())
 				Expect(port3).To(Equal(port1))
 
-				_, err = pool.Acquire()
+				_, err = pool.Acquire("some-handle")
 				Expect(err).To(HaveOccurred())
 			})
 		})
@@ -194,19 +294,29 @@ var _ = Describe("Port pool", func() {
 			pool, err := ports.NewPool(10000, 5, initialState)
 			Expect(err).ToNot(HaveOccurred())
 
-			_, err = pool.Acquire()
+			_, err = pool.Acquire("some-handle")
 			Expect(err).NotTo(HaveOccurred())
 
 			newState := pool.RefreshState()
 			Expect(newState.Offset).To(BeNumerically("==", 1))
 		})
 
+		It("includes the reservations", func() {
+			pool, err := ports.NewPool(10000, 5, initialState)
+			Expect(err).ToNot(HaveOccurred())
+
+			_, err = pool.Acquire("some-handle")
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(pool.RefreshState().Reserved).To(Equal(map[string][]uint32{"some-handle": {10000}}))
+		})
+
 		Context("when port pool is exhausted", func() {
 			It("returns the state reset to offset 0", func() {
 				pool, err := ports.NewPool(10000, 1, initialState)
 				Expect(err).ToNot(HaveOccurred())
 
-				_, err = pool.Acquire()
+				_, err = pool.Acquire("some-handle")
 				Expect(err).NotTo(HaveOccurred())
 
 				newState := pool.RefreshState()
@@ -4,10 +4,24 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+
+	"github.com/cloudfoundry-incubator/guardian/pkg/atomicfile"
 )
 
 type State struct {
-	Offset uint32 `json:"offset"`
+	Offset   uint32              `json:"offset"`
+	Reserved map[string][]uint32 `json:"reserved,omitempty"`
+}
+
+// CorruptStateError is returned by LoadState when the state file cannot be
+// parsed
+type CorruptStateError struct {
+	Path string
+	Err  error
+}
+
+func (e CorruptStateError) Error() string {
+	return fmt.Sprintf("parsing state file %s: %s", e.Path, e.Err)
 }
 
 func LoadState(filePath string) (State, error) {
@@ -19,19 +33,18 @@ func LoadState(filePath string) (State, error) {
 
 	var state State
 	if err := json.NewDecoder(stateFile).Decode(&state); err != nil {
-		return State{}, fmt.Errorf("parsing state file: %s", err)
+		return State{}, CorruptStateError{Path: filePath, Err: err}
 	}
 
 	return state, nil
 }
 
+// SaveState replaces the state file atomically, so that a crash while it is
+// being written cannot leave it truncated
 func SaveState(filePath string, state State) error {
-	stateFile, err := os.Create(filePath)
-	if err != nil {
+	if err := atomicfile.WriteJSON(filePath, state); err != nil {
 		return fmt.Errorf("creating state file: %s", err)
 	}
-	defer stateFile.Close()
 
-	json.NewEncoder(stateFile).Encode(state)
 	return nil
 }
@@ -0,0 +1,186 @@
+package subnets
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"sync"
+
+	"github.com/pivotal-golang/lager"
+)
+
+// PersistentPool is a Pool which saves the IPs it has allocated, and which
+// container holds each of them, to a file whenever they change, and is loaded
+// from that file. The IPs of containers which are still in the depot after a
+// restart therefore stay allocated, even if the containers cannot be
+// recovered, rather than being handed out to new containers.
+type PersistentPool struct {
+	Pool
+	path string
+
+	mu          sync.Mutex
+	allocations []Allocation
+}
+
+// AllocationConflictError is returned by Claim if the IP is held by another
+// container
+type AllocationConflictError struct {
+	Subnet *net.IPNet
+	IP     net.IP
+	Holder string
+}
+
+func (e AllocationConflictError) Error() string {
+	return fmt.Sprintf("%s in subnet %s is already allocated to container '%s'", e.IP, e.Subnet, e.Holder)
+}
+
+func NewPersistentPool(ipNet *net.IPNet, path string) (*PersistentPool, error) {
+	state := State{}
+	if _, err := os.Stat(path); err == nil {
+		if state, err = LoadState(path); err != nil {
+			return nil, err
+		}
+	}
+
+	p := &PersistentPool{Pool: NewPool(ipNet), path: path}
+	for _, allocation := range state.Allocations {
+		_, subnet, err := net.ParseCIDR(allocation.Subnet)
+		if err != nil {
+			return nil, fmt.Errorf("loading %s: %s", path, err)
+		}
+
+		ip := net.ParseIP(allocation.IP)
+		if ip == nil {
+			return nil, fmt.Errorf("loading %s: invalid IP '%s'", path, allocation.IP)
+		}
+
+		if err := p.Pool.Remove(subnet, ip); err != nil {
+			return nil, fmt.Errorf("loading %s: %s in subnet %s is allocated twice", path, ip, subnet)
+		}
+
+		p.allocations = append(p.allocations, allocation)
+	}
+
+	return p, nil
+}
+
+func (p *PersistentPool) Acquire(log lager.Logger, sn SubnetSelector, i IPSelector) (*net.IPNet, net.IP, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	subnet, ip, err := p.Pool.Acquire(log, sn, i)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	p.allocations = append(p.allocations, Allocation{Subnet: subnet.String(), IP: ip.String()})
+	if err := p.save(); err != nil {
+		p.Pool.Release(subnet, ip)
+		p.allocations = p.allocations[:len(p.allocations)-1]
+		return nil, nil, err
+	}
+
+	return subnet, ip, nil
+}
+
+// Remove allocates the IP if it is not already allocated; as for the Pool, it
+// returns ErrOverlapsExistingSubnet if it is, e.g. because it was loaded from
+// the state file
+func (p *PersistentPool) Remove(subnet *net.IPNet, ip net.IP) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if err := p.Pool.Remove(subnet, ip); err != nil {
+		return err
+	}
+
+	p.allocations = append(p.allocations, Allocation{Subnet: subnet.String(), IP: ip.String()})
+	return p.save()
+}
+
+func (p *PersistentPool) Release(subnet *net.IPNet, ip net.IP) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if err := p.Pool.Release(subnet, ip); err != nil {
+		return err
+	}
+
+	if i, found := p.indexOf(subnet, ip); found {
+		p.allocations = append(p.allocations[:i], p.allocations[i+1:]...)
+	}
+
+	return p.save()
+}
+
+// Claim records that the container holds the allocated IP. It returns an
+// AllocationConflictError if another container already holds it.
+func (p *PersistentPool) Claim(subnet *net.IPNet, ip net.IP, handle string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	i, found := p.indexOf(subnet, ip)
+	if !found {
+		return ErrReleasedUnallocatedSubnet
+	}
+
+	switch p.allocations[i].Handle {
+	case handle:
+		return nil
+	case "":
+		p.allocations[i].Handle = handle
+		return p.save()
+	default:
+		return AllocationConflictError{Subnet: subnet, IP: ip, Holder: p.allocations[i].Handle}
+	}
+}
+
+// Prune releases the IPs which are not held by any of the given containers,
+// e.g. those of containers which were removed from the depot while guardian
+// was not running
+func (p *PersistentPool) Prune(log lager.Logger, handles []string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	exists := make(map[string]bool, len(handles))
+	for _, handle := range handles {
+		exists[handle] = true
+	}
+
+	kept := []Allocation{}
+	for _, allocation := range p.allocations {
+		if exists[allocation.Handle] {
+			kept = append(kept, allocation)
+			continue
+		}
+
+		_, subnet, _ := net.ParseCIDR(allocation.Subnet)
+		p.Pool.Release(subnet, net.ParseIP(allocation.IP))
+		log.Info("released-unheld-ip", lager.Data{"subnet": allocation.Subnet, "ip": allocation.IP, "handle": allocation.Handle})
+	}
+
+	p.allocations = kept
+	return p.save()
+}
+
+// Allocations are the IPs the pool has allocated, e.g. for debugging
+func (p *PersistentPool) Allocations() []Allocation {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return append([]Allocation{}, p.allocations...)
+}
+
+func (p *PersistentPool) indexOf(subnet *net.IPNet, ip net.IP) (int, bool) {
+	for i, allocation := range p.allocations {
+		if allocation.Subnet == subnet.String() && net.ParseIP(allocation.IP).Equal(ip) {
+			return i, true
+		}
+	}
+
+	return -1, false
+}
+
+func (p *PersistentPool) save() error {
+	return SaveState(p.path, State{Allocations: p.allocations})
+}
@@ -0,0 +1,165 @@
+package subnets_test
+
+import (
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+
+	"github.com/cloudfoundry-incubator/guardian/kawasaki/subnets"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/pivotal-golang/lager/lagertest"
+)
+
+var _ = Describe("PersistentPool", func() {
+	var (
+		tmpDir   string
+		filePath string
+		logger   *lagertest.TestLogger
+		ipNet    *net.IPNet
+	)
+
+	BeforeEach(func() {
+		var err error
+
+		tmpDir, err = ioutil.TempDir("", "")
+		Expect(err).NotTo(HaveOccurred())
+		filePath = filepath.Join(tmpDir, "network-pool.json")
+
+		logger = lagertest.NewTestLogger("test")
+		_, ipNet, err = net.ParseCIDR("10.2.3.0/28")
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		Expect(os.RemoveAll(tmpDir)).To(Succeed())
+	})
+
+	acquire := func(pool *subnets.PersistentPool, handle string) (*net.IPNet, net.IP) {
+		subnet, ip, err := pool.Acquire(logger, subnets.DynamicSubnetSelector, subnets.DynamicIPSelector)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(pool.Claim(subnet, ip, handle)).To(Succeed())
+		return subnet, ip
+	}
+
+	It("starts empty when there is no state file", func() {
+		pool, err := subnets.NewPersistentPool(ipNet, filePath)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(pool.Allocations()).To(BeEmpty())
+	})
+
+	It("keeps each container's IP allocated across a restart", func() {
+		pool, err := subnets.NewPersistentPool(ipNet, filePath)
+		Expect(err).NotTo(HaveOccurred())
+
+		subnet, ip := acquire(pool, "some-handle")
+
+		restarted, err := subnets.NewPersistentPool(ipNet, filePath)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(restarted.Allocations()).To(ConsistOf(subnets.Allocation{
+			Subnet: subnet.String(),
+			IP:     ip.String(),
+			Handle: "some-handle",
+		}))
+
+		newSubnet, _ := acquire(restarted, "new-handle")
+		Expect(newSubnet.String()).NotTo(Equal(subnet.String()))
+	})
+
+	It("saves released IPs", func() {
+		pool, err := subnets.NewPersistentPool(ipNet, filePath)
+		Expect(err).NotTo(HaveOccurred())
+
+		subnet, ip := acquire(pool, "some-handle")
+		Expect(pool.Release(subnet, ip)).To(Succeed())
+
+		restarted, err := subnets.NewPersistentPool(ipNet, filePath)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(restarted.Allocations()).To(BeEmpty())
+	})
+
+	Describe("Claim", func() {
+		It("succeeds when the container already holds the IP", func() {
+			pool, err := subnets.NewPersistentPool(ipNet, filePath)
+			Expect(err).NotTo(HaveOccurred())
+
+			subnet, ip := acquire(pool, "some-handle")
+			Expect(pool.Claim(subnet, ip, "some-handle")).To(Succeed())
+		})
+
+		It("returns a conflict when another container holds the IP", func() {
+			pool, err := subnets.NewPersistentPool(ipNet, filePath)
+			Expect(err).NotTo(HaveOccurred())
+
+			subnet, ip := acquire(pool, "some-handle")
+			err = pool.Claim(subnet, ip, "other-handle")
+			Expect(err).To(Equal(subnets.AllocationConflictError{Subnet: subnet, IP: ip, Holder: "some-handle"}))
+			Expect(err).To(MatchError("10.2.3.2 in subnet 10.2.3.0/30 is already allocated to container 'some-handle'"))
+		})
+
+		It("returns an error when the IP is not allocated", func() {
+			pool, err := subnets.NewPersistentPool(ipNet, filePath)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(pool.Claim(ipNet, net.ParseIP("10.2.3.2"), "some-handle")).To(Equal(subnets.ErrReleasedUnallocatedSubnet))
+		})
+	})
+
+	Describe("Prune", func() {
+		It("releases the IPs of containers which are not given", func() {
+			pool, err := subnets.NewPersistentPool(ipNet, filePath)
+			Expect(err).NotTo(HaveOccurred())
+
+			acquire(pool, "kept-handle")
+			removedSubnet, removedIP := acquire(pool, "removed-handle")
+			_, _, err = pool.Acquire(logger, subnets.DynamicSubnetSelector, subnets.DynamicIPSelector)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(pool.Prune(logger, []string{"kept-handle"})).To(Succeed())
+
+			restarted, err := subnets.NewPersistentPool(ipNet, filePath)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(restarted.Allocations()).To(HaveLen(1))
+			Expect(restarted.Allocations()[0].Handle).To(Equal("kept-handle"))
+
+			subnet, ip := acquire(restarted, "new-handle")
+			Expect(subnet.String()).To(Equal(removedSubnet.String()))
+			Expect(ip.String()).To(Equal(removedIP.String()))
+		})
+	})
+
+	Context("when the state file allocates an IP twice", func() {
+		It("returns an error", func() {
+			Expect(ioutil.WriteFile(filePath, []byte(`{"allocations":[
+				{"subnet":"10.2.3.0/30","ip":"10.2.3.2","handle":"some-handle"},
+				{"subnet":"10.2.3.0/30","ip":"10.2.3.2","handle":"other-handle"}
+			]}`), 0600)).To(Succeed())
+
+			_, err := subnets.NewPersistentPool(ipNet, filePath)
+			Expect(err).To(MatchError(ContainSubstring("10.2.3.2 in subnet 10.2.3.0/30 is allocated twice")))
+		})
+	})
+
+	Context("when the state file is corrupt", func() {
+		It("returns an error", func() {
+			Expect(ioutil.WriteFile(filePath, []byte("{"), 0600)).To(Succeed())
+
+			_, err := subnets.NewPersistentPool(ipNet, filePath)
+			Expect(err).To(MatchError(ContainSubstring("parsing state file")))
+		})
+	})
+
+	Context("when the state cannot be saved", func() {
+		It("returns an error and does not allocate the IP", func() {
+			pool, err := subnets.NewPersistentPool(ipNet, filepath.Join(tmpDir, "missing", "network-pool.json"))
+			Expect(err).NotTo(HaveOccurred())
+
+			_, _, err = pool.Acquire(logger, subnets.DynamicSubnetSelector, subnets.DynamicIPSelector)
+			Expect(err).To(MatchError(ContainSubstring("saving state file")))
+			Expect(pool.Allocations()).To(BeEmpty())
+		})
+	})
+})
@@ -4,8 +4,8 @@ package subnets
 
 import (
 	"fmt"
-	"math"
 	"net"
+	"strconv"
 	"sync"
 
 	"github.com/pivotal-golang/lager"
@@ -29,7 +29,8 @@ type Pool interface {
 	// Remove an IP address so it appears to be associated with the given subnet.
 	Remove(*net.IPNet, net.IP) error
 
-	// Returns the number of /30 subnets which can be Acquired by a DynamicSubnetSelector.
+	// Returns the number of /30 (or, for an IPv6 pool, /126) subnets which can be Acquired by a
+	// DynamicSubnetSelector.
 	Capacity() int
 }
 
@@ -121,11 +122,24 @@ func (p *pool) Release(subnet *net.IPNet, ip net.IP) error {
 	return ErrReleasedUnallocatedSubnet
 }
 
-// Capacity returns the number of /30 subnets that can be allocated
-// from the pool's dynamic allocation range.
+// maxCapacity is the largest int, which the Capacity of an IPv6 range of
+// more than a /65 or so exceeds
+const maxCapacity = int(^uint(0) >> 1)
+
+// Capacity returns the number of /30 (or /126) subnets that can be allocated
+// from the pool's dynamic allocation range, or maxCapacity if there are more.
 func (m *pool) Capacity() int {
 	masked, total := m.dynamicRange.Mask.Size()
-	return int(math.Pow(2, float64(total-masked)) / 4)
+	subnetBits := total - masked - 2
+	if subnetBits < 0 {
+		return 0
+	}
+
+	if subnetBits >= strconv.IntSize-1 {
+		return maxCapacity
+	}
+
+	return 1 << uint(subnetBits)
 }
 
 // Returns the gateway IP of a given subnet, which is always the maximum valid IP
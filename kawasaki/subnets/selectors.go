@@ -27,7 +27,8 @@ func (s StaticSubnetSelector) SelectSubnet(dynamic *net.IPNet, existing []*net.I
 type dynamicSubnetSelector int
 
 // DynamicSubnetSelector requests the next unallocated ("dynamic") subnet from the dynamic range.
-// Returns an error if there are no remaining subnets in the dynamic range.
+// Subnets are /30s for an IPv4 range and /126s for an IPv6 range, so both have room for exactly
+// one container IP. Returns an error if there are no remaining subnets in the dynamic range.
 var DynamicSubnetSelector dynamicSubnetSelector = 0
 
 func (dynamicSubnetSelector) SelectSubnet(dynamic *net.IPNet, existing []*net.IPNet) (*net.IPNet, error) {
@@ -38,6 +39,10 @@ func (dynamicSubnetSelector) SelectSubnet(dynamic *net.IPNet, existing []*net.IP
 
 	min := dynamic.IP
 	mask := net.CIDRMask(30, 32) // /30
+	if dynamic.IP.To4() == nil {
+		mask = net.CIDRMask(126, 128) // /126
+	}
+
 	for ip := min; dynamic.Contains(ip); ip = next(ip) {
 		subnet := &net.IPNet{IP: ip, Mask: mask}
 		ip = next(next(next(ip)))
@@ -0,0 +1,47 @@
+package subnets
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/cloudfoundry-incubator/guardian/pkg/atomicfile"
+)
+
+// State is the allocations of a PersistentPool, as saved in its state file
+type State struct {
+	Allocations []Allocation `json:"allocations,omitempty"`
+}
+
+// Allocation is an IP allocated from a subnet, and the handle of the
+// container which holds it once it has been claimed
+type Allocation struct {
+	Subnet string `json:"subnet"`
+	IP     string `json:"ip"`
+	Handle string `json:"handle,omitempty"`
+}
+
+func LoadState(filePath string) (State, error) {
+	stateFile, err := os.Open(filePath)
+	if err != nil {
+		return State{}, fmt.Errorf("opening state file: %s", err)
+	}
+	defer stateFile.Close()
+
+	var state State
+	if err := json.NewDecoder(stateFile).Decode(&state); err != nil {
+		return State{}, fmt.Errorf("parsing state file: %s", err)
+	}
+
+	return state, nil
+}
+
+// SaveState replaces the state file atomically, so that a crash while it is
+// being written cannot leave it truncated
+func SaveState(filePath string, state State) error {
+	if err := atomicfile.WriteJSON(filePath, state); err != nil {
+		return fmt.Errorf("saving state file: %s", err)
+	}
+
+	return nil
+}
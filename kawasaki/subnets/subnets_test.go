@@ -588,6 +588,62 @@ var _ = Describe("Subnet Pool", func() {
 			})
 		})
 
+		Describe("Dynamic /126 Subnet Allocation", func() {
+			Context("when the pool is an IPv6 range", func() {
+				BeforeEach(func() {
+					defaultSubnetPool = subnetPool("fd00::/125")
+				})
+
+				It("has room for two /126 subnets", func() {
+					Expect(subnetpool.Capacity()).To(Equal(2))
+				})
+
+				Context("when the range has more /126 subnets than an int can count", func() {
+					BeforeEach(func() {
+						defaultSubnetPool = subnetPool("fd00::/48")
+					})
+
+					It("returns the largest int rather than overflowing", func() {
+						Expect(subnetpool.Capacity()).To(Equal(int(^uint(0) >> 1)))
+					})
+				})
+
+				It("returns a /126 network within the range", func() {
+					subnet, _, err := subnetpool.Acquire(logger, subnets.DynamicSubnetSelector, subnets.DynamicIPSelector)
+					Expect(err).ToNot(HaveOccurred())
+
+					Expect(subnet.String()).To(Equal("fd00::/126"))
+				})
+
+				It("allocates an IP which is neither the network, gateway nor last IP of the subnet", func() {
+					subnet, ip, err := subnetpool.Acquire(logger, subnets.DynamicSubnetSelector, subnets.DynamicIPSelector)
+					Expect(err).ToNot(HaveOccurred())
+
+					Expect(ip.String()).To(Equal("fd00::2"))
+					Expect(subnets.GatewayIP(subnet).String()).To(Equal("fd00::1"))
+				})
+
+				It("returns the second /126 network on a subsequent request", func() {
+					_, _, err := subnetpool.Acquire(logger, subnets.DynamicSubnetSelector, subnets.DynamicIPSelector)
+					Expect(err).ToNot(HaveOccurred())
+
+					subnet, _, err := subnetpool.Acquire(logger, subnets.DynamicSubnetSelector, subnets.DynamicIPSelector)
+					Expect(err).ToNot(HaveOccurred())
+					Expect(subnet.String()).To(Equal("fd00::4/126"))
+				})
+
+				It("returns an error when the range is exhausted", func() {
+					for i := 0; i < 2; i++ {
+						_, _, err := subnetpool.Acquire(logger, subnets.DynamicSubnetSelector, subnets.DynamicIPSelector)
+						Expect(err).ToNot(HaveOccurred())
+					}
+
+					_, _, err := subnetpool.Acquire(logger, subnets.DynamicSubnetSelector, subnets.DynamicIPSelector)
+					Expect(err).To(MatchError(subnets.ErrInsufficientSubnets))
+				})
+			})
+		})
+
 		Describe("Removeing", func() {
 			BeforeEach(func() {
 				defaultSubnetPool = subnetPool("10.2.3.0/29")
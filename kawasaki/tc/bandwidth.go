@@ -0,0 +1,107 @@
+package tc
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"strings"
+
+	"github.com/cloudfoundry-incubator/garden"
+	"github.com/cloudfoundry/gunk/command_runner"
+	"github.com/pivotal-golang/lager"
+)
+
+// BandwidthLimiter shapes the traffic of a container with tc on the host side
+// of its veth pair. Traffic to the container leaves the host interface, so is
+// shaped by an htb qdisc on it; traffic from the container arrives on the
+// host interface, where ingress traffic cannot be shaped, so is redirected to
+// an ifb device of the container's own and shaped as it leaves that.
+type BandwidthLimiter struct {
+	Runner command_runner.CommandRunner
+}
+
+// IFBName is the name of the ifb device which shapes the traffic arriving on
+// a host interface: the interface's name with its "-0" suffix replaced, so
+// that it is no longer than the interface's
+func IFBName(hostIntf string) string {
+	return strings.TrimSuffix(hostIntf, "-0") + "-i"
+}
+
+// Limit shapes traffic in both directions to the rate and burst of the
+// limits, replacing any existing limits. Zero limits remove any limits.
+func (l *BandwidthLimiter) Limit(log lager.Logger, hostIntf string, limits garden.BandwidthLimits) error {
+	log = log.Session("limit-bandwidth", lager.Data{"interface": hostIntf, "limits": limits})
+
+	if limits.RateInBytesPerSecond == 0 {
+		return l.Remove(log, hostIntf)
+	}
+
+	rate := fmt.Sprintf("%dbps", limits.RateInBytesPerSecond)
+	burst := fmt.Sprintf("%db", burstBytes(limits))
+	ifb := IFBName(hostIntf)
+
+	// a left over ifb device, e.g. from before the container was restored,
+	// would make adding it fail
+	l.run(exec.Command("ip", "link", "del", ifb))
+
+	for _, cmd := range []*exec.Cmd{
+		exec.Command("tc", "qdisc", "replace", "dev", hostIntf, "root", "handle", "1:", "htb", "default", "1"),
+		exec.Command("tc", "class", "replace", "dev", hostIntf, "parent", "1:", "classid", "1:1", "htb", "rate", rate, "burst", burst),
+
+		exec.Command("ip", "link", "add", "name", ifb, "type", "ifb"),
+		exec.Command("ip", "link", "set", ifb, "up"),
+		exec.Command("tc", "qdisc", "replace", "dev", ifb, "root", "handle", "1:", "htb", "default", "1"),
+		exec.Command("tc", "class", "replace", "dev", ifb, "parent", "1:", "classid", "1:1", "htb", "rate", rate, "burst", burst),
+
+		exec.Command("tc", "qdisc", "replace", "dev", hostIntf, "handle", "ffff:", "ingress"),
+		exec.Command("tc", "filter", "replace", "dev", hostIntf, "parent", "ffff:", "protocol", "all",
+			"u32", "match", "u32", "0", "0", "action", "mirred", "egress", "redirect", "dev", ifb),
+	} {
+		if err := l.run(cmd); err != nil {
+			log.Error("failed", err)
+			return fmt.Errorf("limit bandwidth: %s", err)
+		}
+	}
+
+	return nil
+}
+
+// Remove removes any limits from the interface, along with its ifb device.
+// Limits which are not there are not an error.
+func (l *BandwidthLimiter) Remove(log lager.Logger, hostIntf string) error {
+	log = log.Session("remove-bandwidth-limits", lager.Data{"interface": hostIntf})
+
+	for _, cmd := range []*exec.Cmd{
+		exec.Command("tc", "qdisc", "del", "dev", hostIntf, "root"),
+		exec.Command("tc", "qdisc", "del", "dev", hostIntf, "ingress"),
+		exec.Command("ip", "link", "del", IFBName(hostIntf)),
+	} {
+		if err := l.run(cmd); err != nil {
+			log.Debug("not-removed", lager.Data{"error": err.Error()})
+		}
+	}
+
+	return nil
+}
+
+func (l *BandwidthLimiter) run(cmd *exec.Cmd) error {
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	if err := l.Runner.Run(cmd); err != nil {
+		return fmt.Errorf("%s: %s: %s", strings.Join(cmd.Args, " "), err, strings.TrimSpace(stderr.String()))
+	}
+
+	return nil
+}
+
+// burstBytes is the burst of the limits, or, if it is too small for htb to
+// send a full packet at once, the smallest burst which is not
+func burstBytes(limits garden.BandwidthLimits) uint64 {
+	const minBurst = 1600
+	if limits.BurstRateInBytesPerSecond < minBurst {
+		return minBurst
+	}
+
+	return limits.BurstRateInBytesPerSecond
+}
@@ -0,0 +1,99 @@
+package tc_test
+
+import (
+	"errors"
+	"os/exec"
+
+	"github.com/cloudfoundry-incubator/garden"
+	"github.com/cloudfoundry-incubator/guardian/kawasaki/tc"
+	"github.com/cloudfoundry/gunk/command_runner/fake_command_runner"
+	. "github.com/cloudfoundry/gunk/command_runner/fake_command_runner/matchers"
+	"github.com/pivotal-golang/lager/lagertest"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("BandwidthLimiter", func() {
+	var (
+		fakeRunner *fake_command_runner.FakeCommandRunner
+		limiter    *tc.BandwidthLimiter
+		logger     *lagertest.TestLogger
+	)
+
+	BeforeEach(func() {
+		fakeRunner = fake_command_runner.New()
+		limiter = &tc.BandwidthLimiter{Runner: fakeRunner}
+		logger = lagertest.NewTestLogger("test")
+	})
+
+	It("names the ifb device after the host interface", func() {
+		Expect(tc.IFBName("w123abc-0")).To(Equal("w123abc-i"))
+	})
+
+	Describe("Limit", func() {
+		It("shapes the traffic leaving and arriving on the host interface", func() {
+			Expect(limiter.Limit(logger, "w1-0", garden.BandwidthLimits{
+				RateInBytesPerSecond:      1024,
+				BurstRateInBytesPerSecond: 4096,
+			})).To(Succeed())
+
+			Expect(fakeRunner).To(HaveExecutedSerially(
+				fake_command_runner.CommandSpec{Args: []string{"link", "del", "w1-i"}},
+				fake_command_runner.CommandSpec{Args: []string{"qdisc", "replace", "dev", "w1-0", "root", "handle", "1:", "htb", "default", "1"}},
+				fake_command_runner.CommandSpec{Args: []string{"class", "replace", "dev", "w1-0", "parent", "1:", "classid", "1:1", "htb", "rate", "1024bps", "burst", "4096b"}},
+				fake_command_runner.CommandSpec{Args: []string{"link", "add", "name", "w1-i", "type", "ifb"}},
+				fake_command_runner.CommandSpec{Args: []string{"link", "set", "w1-i", "up"}},
+				fake_command_runner.CommandSpec{Args: []string{"qdisc", "replace", "dev", "w1-i", "root", "handle", "1:", "htb", "default", "1"}},
+				fake_command_runner.CommandSpec{Args: []string{"class", "replace", "dev", "w1-i", "parent", "1:", "classid", "1:1", "htb", "rate", "1024bps", "burst", "4096b"}},
+				fake_command_runner.CommandSpec{Args: []string{"qdisc", "replace", "dev", "w1-0", "handle", "ffff:", "ingress"}},
+				fake_command_runner.CommandSpec{Args: []string{"filter", "replace", "dev", "w1-0", "parent", "ffff:", "protocol", "all",
+					"u32", "match", "u32", "0", "0", "action", "mirred", "egress", "redirect", "dev", "w1-i"}},
+			))
+		})
+
+		It("raises a burst too small to send a packet", func() {
+			Expect(limiter.Limit(logger, "w1-0", garden.BandwidthLimits{RateInBytesPerSecond: 1024})).To(Succeed())
+
+			Expect(fakeRunner).To(HaveExecuted(
+				fake_command_runner.CommandSpec{Args: []string{"class", "replace", "dev", "w1-0", "parent", "1:", "classid", "1:1", "htb", "rate", "1024bps", "burst", "1600b"}},
+			))
+		})
+
+		It("removes the limits when the rate is zero", func() {
+			Expect(limiter.Limit(logger, "w1-0", garden.BandwidthLimits{})).To(Succeed())
+
+			Expect(fakeRunner).To(HaveExecutedSerially(
+				fake_command_runner.CommandSpec{Args: []string{"qdisc", "del", "dev", "w1-0", "root"}},
+				fake_command_runner.CommandSpec{Args: []string{"qdisc", "del", "dev", "w1-0", "ingress"}},
+				fake_command_runner.CommandSpec{Args: []string{"link", "del", "w1-i"}},
+			))
+		})
+
+		Context("when a command fails", func() {
+			It("returns the error along with tc's output", func() {
+				fakeRunner.WhenRunning(fake_command_runner.CommandSpec{
+					Args: []string{"link", "add", "name", "w1-i", "type", "ifb"},
+				}, func(cmd *exec.Cmd) error {
+					cmd.Stderr.Write([]byte("RTNETLINK answers: Operation not supported\n"))
+					return errors.New("exit status 2")
+				})
+
+				err := limiter.Limit(logger, "w1-0", garden.BandwidthLimits{RateInBytesPerSecond: 1024})
+				Expect(err).To(MatchError("limit bandwidth: ip link add name w1-i type ifb: exit status 2: RTNETLINK answers: Operation not supported"))
+			})
+		})
+	})
+
+	Describe("Remove", func() {
+		Context("when there are no limits to remove", func() {
+			It("succeeds", func() {
+				fakeRunner.WhenRunning(fake_command_runner.CommandSpec{}, func(cmd *exec.Cmd) error {
+					return errors.New("exit status 2")
+				})
+
+				Expect(limiter.Remove(logger, "w1-0")).To(Succeed())
+			})
+		})
+	})
+})
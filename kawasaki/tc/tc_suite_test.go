@@ -0,0 +1,13 @@
+package tc_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"testing"
+)
+
+func TestTc(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "TC Suite")
+}
@@ -0,0 +1,130 @@
+package metrics
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/cloudfoundry/gunk/command_runner"
+)
+
+// IptablesRuleCounter counts the rules in each of the given iptables tables.
+type IptablesRuleCounter struct {
+	Runner command_runner.CommandRunner
+	Binary string
+	Tables []string
+}
+
+func (c *IptablesRuleCounter) Collect() (map[string]float64, error) {
+	counts := make(map[string]float64)
+	for _, table := range c.Tables {
+		var stdout bytes.Buffer
+		cmd := exec.Command(c.Binary, "-w", "-t", table, "-S")
+		cmd.Stdout = &stdout
+		if err := c.Runner.Run(cmd); err != nil {
+			return nil, fmt.Errorf("list %s rules: %s", table, err)
+		}
+
+		rules := 0
+		scanner := bufio.NewScanner(&stdout)
+		for scanner.Scan() {
+			if strings.HasPrefix(scanner.Text(), "-A ") {
+				rules++
+			}
+		}
+
+		counts[table] = float64(rules)
+	}
+
+	return counts, nil
+}
+
+// LoopDeviceCounter counts the loop devices known to the kernel, partitioned
+// by whether or not they are attached to a backing file.
+type LoopDeviceCounter struct {
+	SysBlockPath string
+}
+
+func (c *LoopDeviceCounter) Collect() (map[string]float64, error) {
+	devices, err := filepath.Glob(filepath.Join(c.SysBlockPath, "loop*"))
+	if err != nil {
+		return nil, err
+	}
+
+	counts := map[string]float64{"attached": 0, "detached": 0}
+	for _, device := range devices {
+		if _, err := os.Stat(filepath.Join(device, "loop", "backing_file")); err == nil {
+			counts["attached"]++
+		} else {
+			counts["detached"]++
+		}
+	}
+
+	return counts, nil
+}
+
+// DepotSizer measures the disk space used by the depot.
+type DepotSizer struct {
+	Runner    command_runner.CommandRunner
+	DepotPath string
+}
+
+func (s *DepotSizer) Collect() (map[string]float64, error) {
+	var stdout bytes.Buffer
+	cmd := exec.Command("du", "-sxb", s.DepotPath)
+	cmd.Stdout = &stdout
+	if err := s.Runner.Run(cmd); err != nil {
+		return nil, fmt.Errorf("measure depot: %s", err)
+	}
+
+	fields := strings.Fields(stdout.String())
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("unexpected du output: %q", stdout.String())
+	}
+
+	size, err := strconv.ParseUint(fields[0], 10, 64)
+	if err != nil {
+		return nil, err
+	}
+
+	return map[string]float64{"": float64(size)}, nil
+}
+
+// ProcessCounter counts running processes whose executable (argv[0]) is
+// Binary, e.g. the dadoos which wrap the runtime's execs.
+type ProcessCounter struct {
+	ProcPath string
+	Binary   string
+}
+
+func (c *ProcessCounter) Collect() (map[string]float64, error) {
+	entries, err := ioutil.ReadDir(c.ProcPath)
+	if err != nil {
+		return nil, err
+	}
+
+	count := 0
+	for _, entry := range entries {
+		if _, err := strconv.Atoi(entry.Name()); err != nil {
+			continue
+		}
+
+		cmdline, err := ioutil.ReadFile(filepath.Join(c.ProcPath, entry.Name(), "cmdline"))
+		if err != nil {
+			// the process exited while we were looking
+			continue
+		}
+
+		if argv0 := strings.SplitN(string(cmdline), "\x00", 2)[0]; argv0 == c.Binary {
+			count++
+		}
+	}
+
+	return map[string]float64{"": float64(count)}, nil
+}
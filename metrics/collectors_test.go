@@ -0,0 +1,125 @@
+package metrics_test
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	"github.com/cloudfoundry-incubator/guardian/metrics"
+	"github.com/cloudfoundry/gunk/command_runner/fake_command_runner"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Collectors", func() {
+	var (
+		fakeRunner *fake_command_runner.FakeCommandRunner
+		tmpDir     string
+	)
+
+	BeforeEach(func() {
+		var err error
+		fakeRunner = fake_command_runner.New()
+		tmpDir, err = ioutil.TempDir("", "metrics")
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		Expect(os.RemoveAll(tmpDir)).To(Succeed())
+	})
+
+	Describe("IptablesRuleCounter", func() {
+		var counter *metrics.IptablesRuleCounter
+
+		BeforeEach(func() {
+			counter = &metrics.IptablesRuleCounter{
+				Runner: fakeRunner,
+				Binary: "/sbin/iptables",
+				Tables: []string{"filter", "nat"},
+			}
+		})
+
+		It("counts the rules in each table", func() {
+			fakeRunner.WhenRunning(fake_command_runner.CommandSpec{
+				Path: "/sbin/iptables",
+				Args: []string{"-w", "-t", "filter", "-S"},
+			}, func(cmd *exec.Cmd) error {
+				cmd.Stdout.Write([]byte("-P INPUT ACCEPT\n-N w--input\n-A INPUT -j w--input\n-A w--input -j ACCEPT\n"))
+				return nil
+			})
+
+			fakeRunner.WhenRunning(fake_command_runner.CommandSpec{
+				Path: "/sbin/iptables",
+				Args: []string{"-w", "-t", "nat", "-S"},
+			}, func(cmd *exec.Cmd) error {
+				cmd.Stdout.Write([]byte("-P PREROUTING ACCEPT\n-A PREROUTING -j w--prerouting\n"))
+				return nil
+			})
+
+			Expect(counter.Collect()).To(Equal(map[string]float64{"filter": 2, "nat": 1}))
+		})
+
+		It("returns an error when iptables fails", func() {
+			fakeRunner.WhenRunning(fake_command_runner.CommandSpec{}, func(*exec.Cmd) error {
+				return errors.New("banana")
+			})
+
+			_, err := counter.Collect()
+			Expect(err).To(MatchError("list filter rules: banana"))
+		})
+	})
+
+	Describe("LoopDeviceCounter", func() {
+		It("counts attached and detached loop devices", func() {
+			Expect(os.MkdirAll(filepath.Join(tmpDir, "loop0", "loop"), 0755)).To(Succeed())
+			Expect(ioutil.WriteFile(filepath.Join(tmpDir, "loop0", "loop", "backing_file"), []byte("/some/file"), 0644)).To(Succeed())
+			Expect(os.MkdirAll(filepath.Join(tmpDir, "loop1"), 0755)).To(Succeed())
+			Expect(os.MkdirAll(filepath.Join(tmpDir, "loop2"), 0755)).To(Succeed())
+			Expect(os.MkdirAll(filepath.Join(tmpDir, "sda"), 0755)).To(Succeed())
+
+			counter := &metrics.LoopDeviceCounter{SysBlockPath: tmpDir}
+			Expect(counter.Collect()).To(Equal(map[string]float64{"attached": 1, "detached": 2}))
+		})
+	})
+
+	Describe("DepotSizer", func() {
+		It("reports the size of the depot", func() {
+			fakeRunner.WhenRunning(fake_command_runner.CommandSpec{
+				Path: "du",
+				Args: []string{"-sxb", "/depot"},
+			}, func(cmd *exec.Cmd) error {
+				cmd.Stdout.Write([]byte("12345\t/depot\n"))
+				return nil
+			})
+
+			sizer := &metrics.DepotSizer{Runner: fakeRunner, DepotPath: "/depot"}
+			Expect(sizer.Collect()).To(Equal(map[string]float64{"": 12345}))
+		})
+
+		It("returns an error when du produces no output", func() {
+			sizer := &metrics.DepotSizer{Runner: fakeRunner, DepotPath: "/depot"}
+			_, err := sizer.Collect()
+			Expect(err).To(MatchError(ContainSubstring("unexpected du output")))
+		})
+	})
+
+	Describe("ProcessCounter", func() {
+		writeProc := func(pid, cmdline string) {
+			Expect(os.MkdirAll(filepath.Join(tmpDir, pid), 0755)).To(Succeed())
+			Expect(ioutil.WriteFile(filepath.Join(tmpDir, pid, "cmdline"), []byte(cmdline), 0644)).To(Succeed())
+		}
+
+		It("counts processes running the binary", func() {
+			writeProc("1", "/sbin/init\x00")
+			writeProc("10", "/path/to/dadoo\x00exec\x00runc\x00/some/bundle\x00some-id\x00")
+			writeProc("11", "/path/to/dadoo\x00exec\x00runc\x00/another/bundle\x00another-id\x00")
+			writeProc("12", "/path/to/dadoo-imposter\x00")
+			Expect(os.MkdirAll(filepath.Join(tmpDir, "self"), 0755)).To(Succeed())
+
+			counter := &metrics.ProcessCounter{ProcPath: tmpDir, Binary: "/path/to/dadoo"}
+			Expect(counter.Collect()).To(Equal(map[string]float64{"": 2}))
+		})
+	})
+})
@@ -0,0 +1,80 @@
+package metrics
+
+import "strings"
+
+//go:generate counterfeiter . Annotator
+
+// Annotator returns the values of selected properties of a container (such
+// as an org, space or app id), which are added as labels to its metrics
+type Annotator interface {
+	AnnotationKeys() []string
+	Annotations(handle string) map[string]string
+}
+
+// NewContainerGaugeFunc registers a gauge whose function returns a value per
+// container handle. Each value is labelled with the handle and, if there is
+// an Annotator, with each of the container's annotations, so that the values
+// can be aggregated without first joining handles to metadata. Annotations
+// which a container does not have are empty.
+func (r *Registry) NewContainerGaugeFunc(name, help string, annotator Annotator, fn func() (map[string]float64, error)) {
+	labels, values := byContainer(annotator, fn)
+	r.NewGaugeVecFunc(name, help, labels, values)
+}
+
+// NewContainerCounterFunc is like NewContainerGaugeFunc, but for a counter.
+func (r *Registry) NewContainerCounterFunc(name, help string, annotator Annotator, fn func() (map[string]float64, error)) {
+	labels, values := byContainer(annotator, fn)
+	r.NewCounterVecFunc(name, help, labels, values)
+}
+
+func byContainer(annotator Annotator, fn func() (map[string]float64, error)) ([]string, func() (map[string]float64, error)) {
+	var keys []string
+	if annotator != nil {
+		keys = annotator.AnnotationKeys()
+	}
+
+	labels := []string{"handle"}
+	for _, key := range keys {
+		labels = append(labels, labelName(key))
+	}
+
+	return labels, func() (map[string]float64, error) {
+		byHandle, err := fn()
+		if err != nil {
+			return nil, err
+		}
+
+		values := make(map[string]float64, len(byHandle))
+		for handle, value := range byHandle {
+			labelValues := []string{handle}
+			if len(keys) > 0 {
+				annotations := annotator.Annotations(handle)
+				for _, key := range keys {
+					labelValues = append(labelValues, annotations[key])
+				}
+			}
+
+			values[LabelValues(labelValues...)] = value
+		}
+
+		return values, nil
+	}
+}
+
+// labelName replaces the characters of a property name (e.g. the dots of
+// "network.app_id") which cannot appear in a label name with underscores
+func labelName(key string) string {
+	name := strings.Map(func(r rune) rune {
+		if r == '_' || (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') {
+			return r
+		}
+
+		return '_'
+	}, key)
+
+	if name == "" || (name[0] >= '0' && name[0] <= '9') {
+		return "_" + name
+	}
+
+	return name
+}
@@ -0,0 +1,78 @@
+package metrics_test
+
+import (
+	"bytes"
+	"errors"
+
+	"github.com/cloudfoundry-incubator/guardian/metrics"
+	"github.com/cloudfoundry-incubator/guardian/metrics/fakes"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/pivotal-golang/lager/lagertest"
+)
+
+var _ = Describe("Container metrics", func() {
+	var (
+		logger    *lagertest.TestLogger
+		registry  *metrics.Registry
+		annotator *fakes.FakeAnnotator
+		byHandle  func() (map[string]float64, error)
+	)
+
+	BeforeEach(func() {
+		logger = lagertest.NewTestLogger("test")
+		registry = metrics.NewRegistry(logger)
+		annotator = new(fakes.FakeAnnotator)
+		byHandle = func() (map[string]float64, error) {
+			return map[string]float64{"banana": 2, "apple": 1}, nil
+		}
+	})
+
+	scrape := func() string {
+		buf := &bytes.Buffer{}
+		Expect(registry.WriteTo(buf)).To(Succeed())
+		return buf.String()
+	}
+
+	It("labels each container's value with its handle", func() {
+		registry.NewContainerGaugeFunc("things", "Things.", nil, byHandle)
+
+		Expect(scrape()).To(ContainSubstring("things{handle=\"apple\"} 1\nthings{handle=\"banana\"} 2\n"))
+	})
+
+	It("labels each container's value with its annotations", func() {
+		annotator.AnnotationKeysReturns([]string{"network.app_id", "org"})
+		annotator.AnnotationsStub = func(handle string) map[string]string {
+			if handle == "banana" {
+				return map[string]string{"network.app_id": "some-app", "org": "some-org"}
+			}
+
+			return map[string]string{"org": "other-org"}
+		}
+
+		registry.NewContainerGaugeFunc("things", "Things.", annotator, byHandle)
+
+		out := scrape()
+		Expect(out).To(ContainSubstring("things{handle=\"apple\",network_app_id=\"\",org=\"other-org\"} 1\n"))
+		Expect(out).To(ContainSubstring("things{handle=\"banana\",network_app_id=\"some-app\",org=\"some-org\"} 2\n"))
+	})
+
+	It("exports counters labelled like gauges", func() {
+		annotator.AnnotationKeysReturns([]string{"org"})
+		annotator.AnnotationsReturns(map[string]string{"org": "some-org"})
+
+		registry.NewContainerCounterFunc("things_total", "Things.", annotator, byHandle)
+
+		out := scrape()
+		Expect(out).To(ContainSubstring("# TYPE things_total counter\n"))
+		Expect(out).To(ContainSubstring("things_total{handle=\"banana\",org=\"some-org\"} 2\n"))
+	})
+
+	It("does not export the values which fail to be collected", func() {
+		registry.NewContainerGaugeFunc("things", "Things.", annotator, func() (map[string]float64, error) {
+			return nil, errors.New("banana")
+		})
+
+		Expect(scrape()).NotTo(ContainSubstring("things{"))
+	})
+})
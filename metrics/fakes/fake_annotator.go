@@ -0,0 +1,83 @@
+// This file was generated by counterfeiter
+package fakes
+
+import (
+	"sync"
+
+	"github.com/cloudfoundry-incubator/guardian/metrics"
+)
+
+type FakeAnnotator struct {
+	AnnotationKeysStub        func() []string
+	annotationKeysMutex       sync.RWMutex
+	annotationKeysArgsForCall []struct{}
+	annotationKeysReturns     struct {
+		result1 []string
+	}
+	AnnotationsStub        func(handle string) map[string]string
+	annotationsMutex       sync.RWMutex
+	annotationsArgsForCall []struct {
+		handle string
+	}
+	annotationsReturns struct {
+		result1 map[string]string
+	}
+}
+
+func (fake *FakeAnnotator) AnnotationKeys() []string {
+	fake.annotationKeysMutex.Lock()
+	fake.annotationKeysArgsForCall = append(fake.annotationKeysArgsForCall, struct{}{})
+	fake.annotationKeysMutex.Unlock()
+	if fake.AnnotationKeysStub != nil {
+		return fake.AnnotationKeysStub()
+	} else {
+		return fake.annotationKeysReturns.result1
+	}
+}
+
+func (fake *FakeAnnotator) AnnotationKeysCallCount() int {
+	fake.annotationKeysMutex.RLock()
+	defer fake.annotationKeysMutex.RUnlock()
+	return len(fake.annotationKeysArgsForCall)
+}
+
+func (fake *FakeAnnotator) AnnotationKeysReturns(result1 []string) {
+	fake.AnnotationKeysStub = nil
+	fake.annotationKeysReturns = struct {
+		result1 []string
+	}{result1}
+}
+
+func (fake *FakeAnnotator) Annotations(handle string) map[string]string {
+	fake.annotationsMutex.Lock()
+	fake.annotationsArgsForCall = append(fake.annotationsArgsForCall, struct {
+		handle string
+	}{handle})
+	fake.annotationsMutex.Unlock()
+	if fake.AnnotationsStub != nil {
+		return fake.AnnotationsStub(handle)
+	} else {
+		return fake.annotationsReturns.result1
+	}
+}
+
+func (fake *FakeAnnotator) AnnotationsCallCount() int {
+	fake.annotationsMutex.RLock()
+	defer fake.annotationsMutex.RUnlock()
+	return len(fake.annotationsArgsForCall)
+}
+
+func (fake *FakeAnnotator) AnnotationsArgsForCall(i int) string {
+	fake.annotationsMutex.RLock()
+	defer fake.annotationsMutex.RUnlock()
+	return fake.annotationsArgsForCall[i].handle
+}
+
+func (fake *FakeAnnotator) AnnotationsReturns(result1 map[string]string) {
+	fake.AnnotationsStub = nil
+	fake.annotationsReturns = struct {
+		result1 map[string]string
+	}{result1}
+}
+
+var _ metrics.Annotator = new(FakeAnnotator)
@@ -0,0 +1,54 @@
+package metrics
+
+import "time"
+
+// CreateLatencyBuckets are the upper bounds, in seconds, of the container
+// create latency histogram.
+var CreateLatencyBuckets = []float64{0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60}
+
+// CreateQueueBuckets are the upper bounds, in seconds, of the histogram of
+// time spent waiting to start creating a container.
+var CreateQueueBuckets = []float64{0.01, 0.1, 0.5, 1, 5, 10, 30, 60}
+
+// GardenerMetrics records container lifecycle metrics reported by the
+// gardener.
+type GardenerMetrics struct {
+	created         *Counter
+	destroyed       *Counter
+	createLatency   *Histogram
+	createQueued    *Histogram
+	createFailures  *CounterVec
+	destroyFailures *CounterVec
+}
+
+func NewGardenerMetrics(registry *Registry) *GardenerMetrics {
+	return &GardenerMetrics{
+		created:         registry.NewCounter("guardian_containers_created_total", "Number of containers successfully created."),
+		destroyed:       registry.NewCounter("guardian_containers_destroyed_total", "Number of containers successfully destroyed."),
+		createLatency:   registry.NewHistogram("guardian_container_create_duration_seconds", "Time taken to create a container.", CreateLatencyBuckets),
+		createQueued:    registry.NewHistogram("guardian_container_create_queue_wait_seconds", "Time a container create waited for one of the --maxConcurrentCreates slots.", CreateQueueBuckets),
+		createFailures:  registry.NewCounterVec("guardian_container_create_failures_total", "Number of failed container creations, by the stage which failed and the class of failure.", "stage", "class"),
+		destroyFailures: registry.NewCounterVec("guardian_container_destroy_failures_total", "Number of failed container destructions, by the stage which failed and the class of failure.", "stage", "class"),
+	}
+}
+
+func (m *GardenerMetrics) ContainerCreated(duration time.Duration) {
+	m.created.Inc()
+	m.createLatency.Observe(duration.Seconds())
+}
+
+func (m *GardenerMetrics) ContainerCreateQueued(wait time.Duration) {
+	m.createQueued.Observe(wait.Seconds())
+}
+
+func (m *GardenerMetrics) ContainerDestroyed() {
+	m.destroyed.Inc()
+}
+
+func (m *GardenerMetrics) ContainerCreateFailed(stage, class string) {
+	m.createFailures.With(stage, class).Inc()
+}
+
+func (m *GardenerMetrics) ContainerDestroyFailed(stage, class string) {
+	m.destroyFailures.With(stage, class).Inc()
+}
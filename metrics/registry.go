@@ -0,0 +1,335 @@
+package metrics
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/pivotal-golang/lager"
+)
+
+// Registry holds a set of metrics and serves them in the Prometheus text
+// exposition format.
+type Registry struct {
+	Logger lager.Logger
+
+	mu      sync.Mutex
+	metrics map[string]metric
+}
+
+type metric interface {
+	kind() string
+	help() string
+	write(w io.Writer, name string) error
+}
+
+func NewRegistry(logger lager.Logger) *Registry {
+	return &Registry{
+		Logger:  logger,
+		metrics: make(map[string]metric),
+	}
+}
+
+func (r *Registry) register(name string, m metric) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.metrics[name]; ok {
+		panic(fmt.Sprintf("metric %s registered twice", name))
+	}
+
+	r.metrics[name] = m
+}
+
+func (r *Registry) NewCounter(name, help string) *Counter {
+	c := &Counter{helpText: help}
+	r.register(name, c)
+	return c
+}
+
+func (r *Registry) NewCounterVec(name, help string, labels ...string) *CounterVec {
+	c := &CounterVec{helpText: help, labels: labels, counters: make(map[string]*Counter)}
+	r.register(name, c)
+	return c
+}
+
+func (r *Registry) NewHistogram(name, help string, buckets []float64) *Histogram {
+	h := &Histogram{helpText: help, buckets: buckets, counts: make([]uint64, len(buckets))}
+	r.register(name, h)
+	return h
+}
+
+// NewGaugeFunc registers a gauge whose values are computed on every scrape.
+// The function returns a value per label value; pass an empty label to
+// export a single unlabelled value under the "" key.
+func (r *Registry) NewGaugeFunc(name, help, label string, fn func() (map[string]float64, error)) {
+	r.NewGaugeVecFunc(name, help, singleLabel(label), fn)
+}
+
+// NewGaugeVecFunc is like NewGaugeFunc, but for a gauge with several labels.
+// The function returns a value per combination of label values, keyed by
+// LabelValues.
+func (r *Registry) NewGaugeVecFunc(name, help string, labels []string, fn func() (map[string]float64, error)) {
+	r.register(name, &gaugeFunc{helpText: help, labels: labels, fn: fn})
+}
+
+// NewCounterFunc registers a counter whose values are read on every scrape,
+// from a source which keeps its own running totals. Like NewGaugeFunc, the
+// function returns a value per label value.
+func (r *Registry) NewCounterFunc(name, help, label string, fn func() (map[string]float64, error)) {
+	r.NewCounterVecFunc(name, help, singleLabel(label), fn)
+}
+
+// NewCounterVecFunc is like NewCounterFunc, but for a counter with several
+// labels, keyed like NewGaugeVecFunc's.
+func (r *Registry) NewCounterVecFunc(name, help string, labels []string, fn func() (map[string]float64, error)) {
+	r.register(name, &counterFunc{gaugeFunc{helpText: help, labels: labels, fn: fn}})
+}
+
+// LabelValues returns the key of a value of a metric with several labels,
+// from its label values in the same order as the metric's labels
+func LabelValues(values ...string) string {
+	return strings.Join(values, labelValueSeparator)
+}
+
+func singleLabel(label string) []string {
+	if label == "" {
+		return nil
+	}
+
+	return []string{label}
+}
+
+func (r *Registry) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	if err := r.WriteTo(w); err != nil {
+		r.Logger.Error("write-metrics-failed", err)
+	}
+}
+
+// WriteTo writes every metric, sorted by name. Metrics which fail to be
+// collected are skipped and logged so that one broken collector does not
+// hide the others.
+func (r *Registry) WriteTo(w io.Writer) error {
+	r.mu.Lock()
+	names := make([]string, 0, len(r.metrics))
+	for name := range r.metrics {
+		names = append(names, name)
+	}
+	metrics := r.metrics
+	r.mu.Unlock()
+
+	sort.Strings(names)
+	for _, name := range names {
+		m := metrics[name]
+
+		buf := &bytes.Buffer{}
+		if err := m.write(buf, name); err != nil {
+			r.Logger.Error("collect-failed", err, lager.Data{"metric": name})
+			continue
+		}
+
+		if _, err := fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n%s", name, m.help(), name, m.kind(), buf.String()); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+type Counter struct {
+	helpText string
+
+	mu    sync.Mutex
+	value float64
+}
+
+func (c *Counter) Inc() {
+	c.Add(1)
+}
+
+func (c *Counter) Add(v float64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.value += v
+}
+
+func (c *Counter) Value() float64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.value
+}
+
+func (c *Counter) kind() string { return "counter" }
+func (c *Counter) help() string { return c.helpText }
+
+func (c *Counter) write(w io.Writer, name string) error {
+	_, err := fmt.Fprintf(w, "%s %s\n", name, formatFloat(c.Value()))
+	return err
+}
+
+// CounterVec is a family of counters partitioned by one or more labels.
+type CounterVec struct {
+	helpText string
+	labels   []string
+
+	mu       sync.Mutex
+	counters map[string]*Counter
+}
+
+// With returns the counter for the given label values, which are in the
+// same order as the CounterVec's labels
+func (c *CounterVec) With(labelValues ...string) *Counter {
+	key := LabelValues(labelValues...)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if counter, ok := c.counters[key]; ok {
+		return counter
+	}
+
+	counter := &Counter{}
+	c.counters[key] = counter
+	return counter
+}
+
+func (c *CounterVec) kind() string { return "counter" }
+func (c *CounterVec) help() string { return c.helpText }
+
+func (c *CounterVec) write(w io.Writer, name string) error {
+	c.mu.Lock()
+	values := make(map[string]float64, len(c.counters))
+	for key, counter := range c.counters {
+		values[key] = counter.Value()
+	}
+	c.mu.Unlock()
+
+	return writeLabelled(w, name, c.labels, values)
+}
+
+// labelValueSeparator joins label values into the keys of the values of
+// metrics with several labels; it cannot appear in valid UTF-8 label values
+const labelValueSeparator = "\xff"
+
+type Histogram struct {
+	helpText string
+	buckets  []float64
+
+	mu     sync.Mutex
+	counts []uint64
+	count  uint64
+	sum    float64
+}
+
+func (h *Histogram) Observe(v float64) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for i, upper := range h.buckets {
+		if v <= upper {
+			h.counts[i]++
+		}
+	}
+
+	h.count++
+	h.sum += v
+}
+
+func (h *Histogram) kind() string { return "histogram" }
+func (h *Histogram) help() string { return h.helpText }
+
+func (h *Histogram) write(w io.Writer, name string) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for i, upper := range h.buckets {
+		if _, err := fmt.Fprintf(w, "%s_bucket{le=%q} %d\n", name, formatFloat(upper), h.counts[i]); err != nil {
+			return err
+		}
+	}
+
+	_, err := fmt.Fprintf(w, "%s_bucket{le=\"+Inf\"} %d\n%s_sum %s\n%s_count %d\n", name, h.count, name, formatFloat(h.sum), name, h.count)
+	return err
+}
+
+type gaugeFunc struct {
+	helpText string
+	labels   []string
+	fn       func() (map[string]float64, error)
+}
+
+func (g *gaugeFunc) kind() string { return "gauge" }
+func (g *gaugeFunc) help() string { return g.helpText }
+
+func (g *gaugeFunc) write(w io.Writer, name string) error {
+	values, err := g.fn()
+	if err != nil {
+		return err
+	}
+
+	return writeLabelled(w, name, g.labels, values)
+}
+
+type counterFunc struct {
+	gaugeFunc
+}
+
+func (c *counterFunc) kind() string { return "counter" }
+
+// writeLabelled writes a value per key, where each key is the label values
+// joined by LabelValues. Metrics without labels have a single value.
+func writeLabelled(w io.Writer, name string, labels []string, values map[string]float64) error {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		var err error
+		if len(labels) == 0 {
+			_, err = fmt.Fprintf(w, "%s %s\n", name, formatFloat(values[key]))
+		} else {
+			_, err = fmt.Fprintf(w, "%s{%s} %s\n", name, labelPairs(labels, key), formatFloat(values[key]))
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func labelPairs(labels []string, key string) string {
+	labelValues := strings.Split(key, labelValueSeparator)
+
+	pairs := make([]string, len(labels))
+	for i, label := range labels {
+		var value string
+		if i < len(labelValues) {
+			value = labelValues[i]
+		}
+
+		pairs[i] = fmt.Sprintf("%s=%q", label, value)
+	}
+
+	return strings.Join(pairs, ",")
+}
+
+func formatFloat(v float64) string {
+	if math.IsInf(v, 1) {
+		return "+Inf"
+	}
+
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
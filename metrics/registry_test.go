@@ -0,0 +1,170 @@
+package metrics_test
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	"github.com/cloudfoundry-incubator/guardian/metrics"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+	"github.com/pivotal-golang/lager/lagertest"
+)
+
+var _ = Describe("Registry", func() {
+	var (
+		logger   *lagertest.TestLogger
+		registry *metrics.Registry
+	)
+
+	BeforeEach(func() {
+		logger = lagertest.NewTestLogger("test")
+		registry = metrics.NewRegistry(logger)
+	})
+
+	scrape := func() string {
+		buf := &bytes.Buffer{}
+		Expect(registry.WriteTo(buf)).To(Succeed())
+		return buf.String()
+	}
+
+	It("exports counters", func() {
+		counter := registry.NewCounter("things_total", "Number of things.")
+		counter.Inc()
+		counter.Add(2)
+
+		Expect(scrape()).To(Equal("# HELP things_total Number of things.\n# TYPE things_total counter\nthings_total 3\n"))
+	})
+
+	It("exports labelled counters", func() {
+		counter := registry.NewCounterVec("failures_total", "Number of failures.", "op")
+		counter.With("start").Inc()
+		counter.With("kill").Inc()
+		counter.With("kill").Inc()
+
+		Expect(scrape()).To(ContainSubstring("failures_total{op=\"kill\"} 2\nfailures_total{op=\"start\"} 1\n"))
+	})
+
+	It("exports counters with several labels", func() {
+		counter := registry.NewCounterVec("failures_total", "Number of failures.", "op", "reason")
+		counter.With("create", "network").Inc()
+		counter.With("create", "bundle").Inc()
+		counter.With("create", "network").Inc()
+
+		Expect(scrape()).To(ContainSubstring("failures_total{op=\"create\",reason=\"bundle\"} 1\nfailures_total{op=\"create\",reason=\"network\"} 2\n"))
+	})
+
+	It("exports histograms", func() {
+		histogram := registry.NewHistogram("latency_seconds", "Latency.", []float64{1, 5})
+		histogram.Observe(0.5)
+		histogram.Observe(2)
+		histogram.Observe(10)
+
+		Expect(scrape()).To(Equal(`# HELP latency_seconds Latency.
+# TYPE latency_seconds histogram
+latency_seconds_bucket{le="1"} 1
+latency_seconds_bucket{le="5"} 2
+latency_seconds_bucket{le="+Inf"} 3
+latency_seconds_sum 12.5
+latency_seconds_count 3
+`))
+	})
+
+	It("exports gauges computed at scrape time", func() {
+		value := 1.0
+		registry.NewGaugeFunc("things", "Number of things.", "", func() (map[string]float64, error) {
+			return map[string]float64{"": value}, nil
+		})
+
+		Expect(scrape()).To(ContainSubstring("things 1\n"))
+		value = 2
+		Expect(scrape()).To(ContainSubstring("things 2\n"))
+	})
+
+	It("exports counters read at scrape time", func() {
+		registry.NewCounterFunc("things_total", "Things seen.", "kind", func() (map[string]float64, error) {
+			return map[string]float64{"banana": 3}, nil
+		})
+
+		out := scrape()
+		Expect(out).To(ContainSubstring("# TYPE things_total counter\n"))
+		Expect(out).To(ContainSubstring(`things_total{kind="banana"} 3`))
+	})
+
+	It("exports gauges with several labels", func() {
+		registry.NewGaugeVecFunc("things", "Number of things.", []string{"kind", "colour"}, func() (map[string]float64, error) {
+			return map[string]float64{
+				metrics.LabelValues("banana", "yellow"): 2,
+				metrics.LabelValues("apple", "red"):     1,
+			}, nil
+		})
+
+		Expect(scrape()).To(ContainSubstring("things{kind=\"apple\",colour=\"red\"} 1\nthings{kind=\"banana\",colour=\"yellow\"} 2\n"))
+	})
+
+	It("sorts metrics by name", func() {
+		registry.NewCounter("b_total", "B.")
+		registry.NewCounter("a_total", "A.")
+
+		out := scrape()
+		Expect(bytes.Index([]byte(out), []byte("a_total"))).To(BeNumerically("<", bytes.Index([]byte(out), []byte("b_total"))))
+	})
+
+	Context("when a gauge cannot be collected", func() {
+		BeforeEach(func() {
+			registry.NewGaugeFunc("broken", "Broken.", "", func() (map[string]float64, error) {
+				return nil, errors.New("banana")
+			})
+			registry.NewCounter("working_total", "Working.")
+		})
+
+		It("skips it and logs the error", func() {
+			out := scrape()
+			Expect(out).NotTo(ContainSubstring("broken"))
+			Expect(out).To(ContainSubstring("working_total 0"))
+			Expect(logger).To(gbytes.Say("collect-failed"))
+		})
+	})
+
+	It("panics when a metric is registered twice", func() {
+		registry.NewCounter("things_total", "Things.")
+		Expect(func() { registry.NewCounter("things_total", "Things.") }).To(Panic())
+	})
+
+	It("serves the metrics over HTTP", func() {
+		registry.NewCounter("things_total", "Things.")
+
+		recorder := httptest.NewRecorder()
+		registry.ServeHTTP(recorder, &http.Request{})
+
+		Expect(recorder.Code).To(Equal(http.StatusOK))
+		Expect(recorder.HeaderMap.Get("Content-Type")).To(Equal("text/plain; version=0.0.4"))
+		Expect(recorder.Body.String()).To(ContainSubstring("things_total 0"))
+	})
+
+	Describe("GardenerMetrics", func() {
+		It("counts created and destroyed containers and create latency", func() {
+			gardenerMetrics := metrics.NewGardenerMetrics(registry)
+			gardenerMetrics.ContainerCreated(2 * time.Second)
+			gardenerMetrics.ContainerDestroyed()
+
+			out := scrape()
+			Expect(out).To(ContainSubstring("guardian_containers_created_total 1\n"))
+			Expect(out).To(ContainSubstring("guardian_containers_destroyed_total 1\n"))
+			Expect(out).To(ContainSubstring("guardian_container_create_duration_seconds_bucket{le=\"2.5\"} 1\n"))
+			Expect(out).To(ContainSubstring("guardian_container_create_duration_seconds_bucket{le=\"1\"} 0\n"))
+		})
+
+		It("records how long creates were queued", func() {
+			gardenerMetrics := metrics.NewGardenerMetrics(registry)
+			gardenerMetrics.ContainerCreateQueued(50 * time.Millisecond)
+
+			out := scrape()
+			Expect(out).To(ContainSubstring("guardian_container_create_queue_wait_seconds_bucket{le=\"0.1\"} 1\n"))
+			Expect(out).To(ContainSubstring("guardian_container_create_queue_wait_seconds_bucket{le=\"0.01\"} 0\n"))
+		})
+	})
+})
@@ -0,0 +1,56 @@
+package metrics
+
+import (
+	"github.com/cloudfoundry-incubator/garden"
+	"github.com/cloudfoundry-incubator/guardian/rundmc"
+	"github.com/pivotal-golang/lager"
+)
+
+// BundleRunner counts failed runc invocations, partitioned by operation.
+type BundleRunner struct {
+	rundmc.BundleRunner
+	Failures *CounterVec
+}
+
+func NewBundleRunner(registry *Registry, runner rundmc.BundleRunner) *BundleRunner {
+	return &BundleRunner{
+		BundleRunner: runner,
+		Failures:     registry.NewCounterVec("guardian_runc_failures_total", "Number of failed runc invocations.", "operation"),
+	}
+}
+
+func (r *BundleRunner) Start(log lager.Logger, bundlePath, id string, io garden.ProcessIO) (garden.Process, error) {
+	process, err := r.BundleRunner.Start(log, bundlePath, id, io)
+	if err != nil {
+		r.Failures.With("start").Inc()
+	}
+
+	return process, err
+}
+
+func (r *BundleRunner) Exec(log lager.Logger, id, bundlePath string, spec garden.ProcessSpec, io garden.ProcessIO) (garden.Process, error) {
+	process, err := r.BundleRunner.Exec(log, id, bundlePath, spec, io)
+	if err != nil {
+		r.Failures.With("exec").Inc()
+	}
+
+	return process, err
+}
+
+func (r *BundleRunner) Kill(log lager.Logger, bundlePath string) error {
+	err := r.BundleRunner.Kill(log, bundlePath)
+	if err != nil {
+		r.Failures.With("kill").Inc()
+	}
+
+	return err
+}
+
+func (r *BundleRunner) Delete(log lager.Logger, handle string) error {
+	err := r.BundleRunner.Delete(log, handle)
+	if err != nil {
+		r.Failures.With("delete").Inc()
+	}
+
+	return err
+}
@@ -0,0 +1,69 @@
+package metrics_test
+
+import (
+	"bytes"
+	"errors"
+
+	"github.com/cloudfoundry-incubator/garden"
+	"github.com/cloudfoundry-incubator/guardian/metrics"
+	"github.com/cloudfoundry-incubator/guardian/rundmc/fakes"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/pivotal-golang/lager/lagertest"
+)
+
+var _ = Describe("BundleRunner", func() {
+	var (
+		logger   *lagertest.TestLogger
+		registry *metrics.Registry
+		inner    *fakes.FakeBundleRunner
+		runner   *metrics.BundleRunner
+	)
+
+	BeforeEach(func() {
+		logger = lagertest.NewTestLogger("test")
+		registry = metrics.NewRegistry(logger)
+		inner = new(fakes.FakeBundleRunner)
+		runner = metrics.NewBundleRunner(registry, inner)
+	})
+
+	scrape := func() string {
+		buf := &bytes.Buffer{}
+		Expect(registry.WriteTo(buf)).To(Succeed())
+		return buf.String()
+	}
+
+	It("delegates to the wrapped runner", func() {
+		_, err := runner.Start(logger, "/bundle", "some-id", garden.ProcessIO{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(inner.StartCallCount()).To(Equal(1))
+
+		_, bundlePath, id, _ := inner.StartArgsForCall(0)
+		Expect(bundlePath).To(Equal("/bundle"))
+		Expect(id).To(Equal("some-id"))
+	})
+
+	It("does not count successful invocations", func() {
+		runner.Start(logger, "/bundle", "some-id", garden.ProcessIO{})
+		runner.Exec(logger, "some-id", "/bundle", garden.ProcessSpec{}, garden.ProcessIO{})
+		runner.Kill(logger, "some-id")
+
+		Expect(scrape()).NotTo(ContainSubstring("operation="))
+	})
+
+	It("counts failures by operation", func() {
+		inner.StartReturns(nil, errors.New("boom"))
+		inner.ExecReturns(nil, errors.New("boom"))
+		inner.KillReturns(errors.New("boom"))
+
+		runner.Start(logger, "/bundle", "some-id", garden.ProcessIO{})
+		runner.Exec(logger, "some-id", "/bundle", garden.ProcessSpec{}, garden.ProcessIO{})
+		runner.Exec(logger, "some-id", "/bundle", garden.ProcessSpec{}, garden.ProcessIO{})
+		Expect(runner.Kill(logger, "some-id")).To(MatchError("boom"))
+
+		out := scrape()
+		Expect(out).To(ContainSubstring("guardian_runc_failures_total{operation=\"start\"} 1\n"))
+		Expect(out).To(ContainSubstring("guardian_runc_failures_total{operation=\"exec\"} 2\n"))
+		Expect(out).To(ContainSubstring("guardian_runc_failures_total{operation=\"kill\"} 1\n"))
+	})
+})
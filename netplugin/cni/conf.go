@@ -0,0 +1,53 @@
+package cni
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+)
+
+// NetConf is a CNI network configuration. Only the fields guardian needs
+// are parsed; the raw bytes are passed to the plugin unmodified.
+type NetConf struct {
+	Name  string `json:"name"`
+	Type  string `json:"type"`
+	Bytes []byte `json:"-"`
+}
+
+// LoadConf returns the first (in lexical order) network configuration in
+// dir, following the same convention as other CNI runtimes.
+func LoadConf(dir string) (NetConf, error) {
+	var files []string
+	for _, ext := range []string{"*.conf", "*.json"} {
+		matches, err := filepath.Glob(filepath.Join(dir, ext))
+		if err != nil {
+			return NetConf{}, err
+		}
+
+		files = append(files, matches...)
+	}
+
+	if len(files) == 0 {
+		return NetConf{}, fmt.Errorf("no cni network configuration found in %s", dir)
+	}
+
+	sort.Strings(files)
+
+	bytes, err := ioutil.ReadFile(files[0])
+	if err != nil {
+		return NetConf{}, err
+	}
+
+	conf := NetConf{Bytes: bytes}
+	if err := json.Unmarshal(bytes, &conf); err != nil {
+		return NetConf{}, fmt.Errorf("parse cni network configuration %s: %s", files[0], err)
+	}
+
+	if conf.Type == "" {
+		return NetConf{}, fmt.Errorf("cni network configuration %s does not specify a type", files[0])
+	}
+
+	return conf, nil
+}
@@ -0,0 +1,67 @@
+package cni_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/cloudfoundry-incubator/guardian/netplugin/cni"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("LoadConf", func() {
+	var confDir string
+
+	BeforeEach(func() {
+		var err error
+		confDir, err = ioutil.TempDir("", "cni-conf")
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		Expect(os.RemoveAll(confDir)).To(Succeed())
+	})
+
+	writeConf := func(name, contents string) {
+		Expect(ioutil.WriteFile(filepath.Join(confDir, name), []byte(contents), 0644)).To(Succeed())
+	}
+
+	It("loads the first configuration in lexical order", func() {
+		writeConf("20-flannel.conf", `{"name": "flannel", "type": "flannel"}`)
+		writeConf("10-calico.json", `{"name": "calico", "type": "calico"}`)
+		writeConf("README", `not a conf`)
+
+		conf, err := cni.LoadConf(confDir)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(conf.Name).To(Equal("calico"))
+		Expect(conf.Type).To(Equal("calico"))
+		Expect(string(conf.Bytes)).To(Equal(`{"name": "calico", "type": "calico"}`))
+	})
+
+	Context("when there are no configurations", func() {
+		It("returns an error", func() {
+			_, err := cni.LoadConf(confDir)
+			Expect(err).To(MatchError(ContainSubstring("no cni network configuration found")))
+		})
+	})
+
+	Context("when the configuration is not valid JSON", func() {
+		It("returns an error", func() {
+			writeConf("10-bad.conf", `{`)
+
+			_, err := cni.LoadConf(confDir)
+			Expect(err).To(MatchError(ContainSubstring("parse cni network configuration")))
+		})
+	})
+
+	Context("when the configuration does not specify a type", func() {
+		It("returns an error", func() {
+			writeConf("10-untyped.conf", `{"name": "untyped"}`)
+
+			_, err := cni.LoadConf(confDir)
+			Expect(err).To(MatchError(ContainSubstring("does not specify a type")))
+		})
+	})
+})
@@ -0,0 +1,138 @@
+package cni
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/cloudfoundry/gunk/command_runner"
+	"github.com/pivotal-golang/lager"
+)
+
+// Invoker runs CNI plugin binaries following the CNI specification.
+type Invoker struct {
+	// BinDir is a colon-separated list of directories to search for plugins
+	BinDir        string
+	CommandRunner command_runner.CommandRunner
+}
+
+// Container identifies the container a plugin is invoked for.
+type Container struct {
+	ID     string
+	NetNS  string
+	IfName string
+	Args   string
+}
+
+// NewContainer returns the Container of the garden container with the given
+// handle, which is attached to the network as eth0. The network spec the
+// client requested, if any, is passed to the plugin as GARDEN_NETWORK.
+func NewContainer(handle, network string) (Container, error) {
+	if err := ValidateNetwork(network); err != nil {
+		return Container{}, err
+	}
+
+	container := Container{
+		ID:     handle,
+		IfName: "eth0",
+		Args:   "IgnoreUnknown=1",
+	}
+
+	if network != "" {
+		container.Args += ";GARDEN_NETWORK=" + network
+	}
+
+	return container, nil
+}
+
+// ValidateNetwork refuses network specs which cannot be passed to plugins in
+// CNI_ARGS, whose key-value pairs are separated by ';' and '=', as they would
+// pass arguments of their own
+func ValidateNetwork(network string) error {
+	if strings.ContainsAny(network, ";=") {
+		return fmt.Errorf("invalid network spec for cni: '%s' must not contain ';' or '='", network)
+	}
+
+	return nil
+}
+
+// PluginError is the error format returned by CNI plugins on stdout.
+type PluginError struct {
+	Code    uint   `json:"code"`
+	Msg     string `json:"msg"`
+	Details string `json:"details,omitempty"`
+}
+
+func (e PluginError) Error() string {
+	if e.Details == "" {
+		return e.Msg
+	}
+
+	return fmt.Sprintf("%s; %s", e.Msg, e.Details)
+}
+
+// Add attaches the container to the network and returns the plugin's raw
+// result.
+func (i *Invoker) Add(log lager.Logger, conf NetConf, container Container) ([]byte, error) {
+	return i.invoke(log.Session("cni-add"), "ADD", conf, container)
+}
+
+// Del detaches the container from the network.
+func (i *Invoker) Del(log lager.Logger, conf NetConf, container Container) error {
+	_, err := i.invoke(log.Session("cni-del"), "DEL", conf, container)
+	return err
+}
+
+func (i *Invoker) invoke(log lager.Logger, command string, conf NetConf, container Container) ([]byte, error) {
+	log = log.Session("invoke", lager.Data{"type": conf.Type, "network": conf.Name, "container": container})
+	log.Info("started")
+	defer log.Info("finished")
+
+	pluginPath, err := i.find(conf.Type)
+	if err != nil {
+		log.Error("find-plugin-failed", err)
+		return nil, err
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command(pluginPath)
+	cmd.Stdin = bytes.NewReader(conf.Bytes)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	cmd.Env = []string{
+		"CNI_COMMAND=" + command,
+		"CNI_CONTAINERID=" + container.ID,
+		"CNI_NETNS=" + container.NetNS,
+		"CNI_IFNAME=" + container.IfName,
+		"CNI_ARGS=" + container.Args,
+		"CNI_PATH=" + i.BinDir,
+		"PATH=" + os.Getenv("PATH"),
+	}
+
+	if err := i.CommandRunner.Run(cmd); err != nil {
+		var pluginErr PluginError
+		if jsonErr := json.Unmarshal(stdout.Bytes(), &pluginErr); jsonErr == nil && pluginErr.Msg != "" {
+			err = pluginErr
+		}
+
+		log.Error("plugin-failed", err, lager.Data{"stderr": stderr.String()})
+		return nil, fmt.Errorf("cni %s %s: %s", strings.ToLower(command), conf.Type, err)
+	}
+
+	return stdout.Bytes(), nil
+}
+
+func (i *Invoker) find(pluginType string) (string, error) {
+	for _, dir := range filepath.SplitList(i.BinDir) {
+		path := filepath.Join(dir, pluginType)
+		if _, err := os.Stat(path); err == nil {
+			return path, nil
+		}
+	}
+
+	return "", fmt.Errorf("cni plugin %s not found in %s", pluginType, i.BinDir)
+}
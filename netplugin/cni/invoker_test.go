@@ -0,0 +1,177 @@
+package cni_test
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	"github.com/cloudfoundry-incubator/guardian/netplugin/cni"
+	"github.com/cloudfoundry/gunk/command_runner/fake_command_runner"
+	"github.com/pivotal-golang/lager"
+	"github.com/pivotal-golang/lager/lagertest"
+
+	. "github.com/cloudfoundry/gunk/command_runner/fake_command_runner/matchers"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Invoker", func() {
+	var (
+		binDir     string
+		fakeRunner *fake_command_runner.FakeCommandRunner
+		invoker    *cni.Invoker
+		conf       cni.NetConf
+		container  cni.Container
+		logger     lager.Logger
+	)
+
+	BeforeEach(func() {
+		var err error
+		binDir, err = ioutil.TempDir("", "cni-bin")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(ioutil.WriteFile(filepath.Join(binDir, "bridge"), []byte{}, 0755)).To(Succeed())
+
+		fakeRunner = fake_command_runner.New()
+		invoker = &cni.Invoker{
+			BinDir:        binDir,
+			CommandRunner: fakeRunner,
+		}
+
+		conf = cni.NetConf{
+			Name:  "mynet",
+			Type:  "bridge",
+			Bytes: []byte(`{"name": "mynet", "type": "bridge"}`),
+		}
+
+		container = cni.Container{
+			ID:     "some-handle",
+			NetNS:  "/proc/42/ns/net",
+			IfName: "eth0",
+			Args:   "IgnoreUnknown=1",
+		}
+
+		logger = lagertest.NewTestLogger("test")
+	})
+
+	AfterEach(func() {
+		Expect(os.RemoveAll(binDir)).To(Succeed())
+	})
+
+	Describe("Add", func() {
+		It("runs the plugin with the CNI environment", func() {
+			_, err := invoker.Add(logger, conf, container)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(fakeRunner).To(HaveExecutedSerially(fake_command_runner.CommandSpec{
+				Path: filepath.Join(binDir, "bridge"),
+				Env: []string{
+					"CNI_COMMAND=ADD",
+					"CNI_CONTAINERID=some-handle",
+					"CNI_NETNS=/proc/42/ns/net",
+					"CNI_IFNAME=eth0",
+					"CNI_ARGS=IgnoreUnknown=1",
+					"CNI_PATH=" + binDir,
+					"PATH=" + os.Getenv("PATH"),
+				},
+			}))
+		})
+
+		It("passes the network configuration on stdin", func() {
+			var stdin []byte
+			fakeRunner.WhenRunning(fake_command_runner.CommandSpec{
+				Path: filepath.Join(binDir, "bridge"),
+			}, func(cmd *exec.Cmd) error {
+				var err error
+				stdin, err = ioutil.ReadAll(cmd.Stdin)
+				return err
+			})
+
+			_, err := invoker.Add(logger, conf, container)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(stdin).To(Equal(conf.Bytes))
+		})
+
+		It("returns the plugin's result", func() {
+			fakeRunner.WhenRunning(fake_command_runner.CommandSpec{
+				Path: filepath.Join(binDir, "bridge"),
+			}, func(cmd *exec.Cmd) error {
+				cmd.Stdout.Write([]byte(`{"ip4": {"ip": "10.1.0.5/16"}}`))
+				return nil
+			})
+
+			result, err := invoker.Add(logger, conf, container)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(result)).To(Equal(`{"ip4": {"ip": "10.1.0.5/16"}}`))
+		})
+
+		Context("when the plugin fails with a CNI error", func() {
+			It("returns the plugin's error message", func() {
+				fakeRunner.WhenRunning(fake_command_runner.CommandSpec{
+					Path: filepath.Join(binDir, "bridge"),
+				}, func(cmd *exec.Cmd) error {
+					cmd.Stdout.Write([]byte(`{"code": 100, "msg": "no more IPs"}`))
+					return errors.New("exit status 1")
+				})
+
+				_, err := invoker.Add(logger, conf, container)
+				Expect(err).To(MatchError("cni add bridge: no more IPs"))
+			})
+		})
+
+		Context("when the plugin fails without a CNI error", func() {
+			It("returns the exit error", func() {
+				fakeRunner.WhenRunning(fake_command_runner.CommandSpec{
+					Path: filepath.Join(binDir, "bridge"),
+				}, func(cmd *exec.Cmd) error {
+					return errors.New("exit status 2")
+				})
+
+				_, err := invoker.Add(logger, conf, container)
+				Expect(err).To(MatchError("cni add bridge: exit status 2"))
+			})
+		})
+
+		Context("when the plugin does not exist", func() {
+			It("returns an error without running anything", func() {
+				conf.Type = "flannel"
+
+				_, err := invoker.Add(logger, conf, container)
+				Expect(err).To(MatchError(fmt.Sprintf("cni plugin flannel not found in %s", binDir)))
+				Expect(fakeRunner.ExecutedCommands()).To(BeEmpty())
+			})
+		})
+	})
+
+	Describe("Del", func() {
+		It("runs the plugin with the DEL command", func() {
+			Expect(invoker.Del(logger, conf, container)).To(Succeed())
+
+			Expect(fakeRunner.ExecutedCommands()).To(HaveLen(1))
+			Expect(fakeRunner.ExecutedCommands()[0].Env).To(ContainElement("CNI_COMMAND=DEL"))
+		})
+	})
+})
+
+var _ = Describe("NewContainer", func() {
+	It("attaches the container as eth0, passing the network spec to the plugin", func() {
+		Expect(cni.NewContainer("some-handle", "10.0.0.0/30")).To(Equal(cni.Container{
+			ID:     "some-handle",
+			IfName: "eth0",
+			Args:   "IgnoreUnknown=1;GARDEN_NETWORK=10.0.0.0/30",
+		}))
+	})
+
+	It("passes no network spec when there is none", func() {
+		container, err := cni.NewContainer("some-handle", "")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(container.Args).To(Equal("IgnoreUnknown=1"))
+	})
+
+	It("refuses network specs which would pass arguments of their own", func() {
+		_, err := cni.NewContainer("some-handle", "x;K8S_POD_NAME=evil")
+		Expect(err).To(MatchError("invalid network spec for cni: 'x;K8S_POD_NAME=evil' must not contain ';' or '='"))
+	})
+})
@@ -0,0 +1,125 @@
+package netplugin
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+
+	"github.com/cloudfoundry-incubator/garden"
+	"github.com/cloudfoundry-incubator/guardian/gardener"
+	"github.com/cloudfoundry-incubator/guardian/netplugin/cni"
+	"github.com/cloudfoundry/gunk/command_runner"
+	"github.com/pivotal-golang/lager"
+)
+
+var ErrNotSupportedByCNI = errors.New("not supported when using cni networking")
+
+// CNIPlugin configures container networking by running standard CNI plugins.
+// The plugins are invoked from the container's prestart and poststop hooks
+// by the cni hook binary, which records each plugin result in the state
+// directory.
+type CNIPlugin struct {
+	hookPath string
+	confDir  string
+	binDir   string
+	stateDir string
+	invoker  *cni.Invoker
+}
+
+func NewCNIPlugin(hookPath, confDir, binDir, stateDir string, runner command_runner.CommandRunner) *CNIPlugin {
+	return &CNIPlugin{
+		hookPath: hookPath,
+		confDir:  confDir,
+		binDir:   binDir,
+		stateDir: stateDir,
+		invoker:  &cni.Invoker{BinDir: binDir, CommandRunner: runner},
+	}
+}
+
+func (p *CNIPlugin) Hooks(log lager.Logger, handle, spec string) (gardener.Hooks, error) {
+	if err := cni.ValidateNetwork(spec); err != nil {
+		return gardener.Hooks{}, err
+	}
+
+	flags := []string{
+		"--handle", handle,
+		"--network", spec,
+		"--cni-conf-dir", p.confDir,
+		"--cni-bin-dir", p.binDir,
+		"--cni-state-dir", p.stateDir,
+	}
+
+	return gardener.Hooks{
+		Prestart: gardener.Hook{
+			Path: p.hookPath,
+			Args: append([]string{p.hookPath, "--action", "up"}, flags...),
+		},
+		Poststop: gardener.Hook{
+			Path: p.hookPath,
+			Args: append([]string{p.hookPath, "--action", "down"}, flags...),
+		},
+	}, nil
+}
+
+func (p *CNIPlugin) Capacity() uint64 {
+	return 0
+}
+
+// Destroy detaches the container from the network and removes any result
+// left behind, in case the poststop hook did not run, e.g. because the
+// container failed to start after the prestart hook added it, or the
+// prestart hook failed part way through adding it. CNI plugins ignore the
+// deletion of a container which has already been deleted.
+func (p *CNIPlugin) Destroy(log lager.Logger, handle string) error {
+	log = log.Session("cni-destroy", lager.Data{"handle": handle})
+
+	conf, err := cni.LoadConf(p.confDir)
+	if err != nil {
+		log.Error("load-conf-failed", err)
+		return err
+	}
+
+	container, err := cni.NewContainer(handle, "")
+	if err != nil {
+		return err
+	}
+
+	// the container's network namespace has gone, which CNI allows a DEL
+	if err := p.invoker.Del(log, conf, container); err != nil {
+		return err
+	}
+
+	if err := os.Remove(filepath.Join(p.stateDir, handle+".json")); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	return nil
+}
+
+func (p *CNIPlugin) NetIn(log lager.Logger, handle string, hostPort, containerPort uint32) (uint32, uint32, error) {
+	return 0, 0, ErrNotSupportedByCNI
+}
+
+func (p *CNIPlugin) NetOut(log lager.Logger, handle string, rule garden.NetOutRule) error {
+	return ErrNotSupportedByCNI
+}
+
+func (p *CNIPlugin) BulkNetOut(log lager.Logger, handle string, rules []garden.NetOutRule) error {
+	return ErrNotSupportedByCNI
+}
+
+// Checkpoint does nothing: the CNI prestart hook is run again when runc
+// restores the container, which re-adds it to the network.
+func (p *CNIPlugin) Checkpoint(log lager.Logger, handle string) error {
+	return nil
+}
+
+func (p *CNIPlugin) Restore(log lager.Logger, handle string) error {
+	return nil
+}
+
+// Recover does nothing: CNI plugins keep their own state, which survives a
+// restart of guardian.
+func (p *CNIPlugin) Recover(log lager.Logger, handle string) error {
+	return nil
+}
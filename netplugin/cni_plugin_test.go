@@ -0,0 +1,136 @@
+package netplugin_test
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	"github.com/cloudfoundry-incubator/garden"
+	"github.com/cloudfoundry-incubator/guardian/netplugin"
+	"github.com/cloudfoundry/gunk/command_runner/fake_command_runner"
+	"github.com/pivotal-golang/lager/lagertest"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("CNIPlugin", func() {
+	var (
+		stateDir   string
+		confDir    string
+		binDir     string
+		fakeRunner *fake_command_runner.FakeCommandRunner
+		plugin     *netplugin.CNIPlugin
+		logger     *lagertest.TestLogger
+	)
+
+	BeforeEach(func() {
+		var err error
+		stateDir, err = ioutil.TempDir("", "cni-state")
+		Expect(err).NotTo(HaveOccurred())
+
+		confDir, err = ioutil.TempDir("", "cni-conf")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(ioutil.WriteFile(filepath.Join(confDir, "10-mynet.conf"), []byte(`{"name": "mynet", "type": "bridge"}`), 0644)).To(Succeed())
+
+		binDir, err = ioutil.TempDir("", "cni-bin")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(ioutil.WriteFile(filepath.Join(binDir, "bridge"), []byte{}, 0755)).To(Succeed())
+
+		fakeRunner = fake_command_runner.New()
+		logger = lagertest.NewTestLogger("test")
+		plugin = netplugin.NewCNIPlugin("/path/to/cni-hook", confDir, binDir, stateDir, fakeRunner)
+	})
+
+	AfterEach(func() {
+		Expect(os.RemoveAll(stateDir)).To(Succeed())
+		Expect(os.RemoveAll(confDir)).To(Succeed())
+		Expect(os.RemoveAll(binDir)).To(Succeed())
+	})
+
+	Describe("Hooks", func() {
+		It("runs the cni hook to bring the network up before the container starts", func() {
+			hooks, err := plugin.Hooks(logger, "some-handle", "potato")
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(hooks.Prestart.Path).To(Equal("/path/to/cni-hook"))
+			Expect(hooks.Prestart.Args).To(Equal([]string{
+				"/path/to/cni-hook",
+				"--action", "up",
+				"--handle", "some-handle",
+				"--network", "potato",
+				"--cni-conf-dir", confDir,
+				"--cni-bin-dir", binDir,
+				"--cni-state-dir", stateDir,
+			}))
+		})
+
+		It("runs the cni hook to bring the network down after the container stops", func() {
+			hooks, err := plugin.Hooks(logger, "some-handle", "potato")
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(hooks.Poststop.Path).To(Equal("/path/to/cni-hook"))
+			Expect(hooks.Poststop.Args[1:3]).To(Equal([]string{"--action", "down"}))
+		})
+
+		It("refuses network specs which would pass cni arguments of their own", func() {
+			_, err := plugin.Hooks(logger, "some-handle", "x;K8S_POD_NAME=evil")
+			Expect(err).To(MatchError(ContainSubstring("must not contain ';' or '='")))
+		})
+	})
+
+	Describe("Destroy", func() {
+		It("runs the plugin's DEL, in case the poststop hook did not", func() {
+			Expect(plugin.Destroy(logger, "some-handle")).To(Succeed())
+
+			Expect(fakeRunner.ExecutedCommands()).To(HaveLen(1))
+			cmd := fakeRunner.ExecutedCommands()[0]
+			Expect(cmd.Path).To(Equal(filepath.Join(binDir, "bridge")))
+			Expect(cmd.Env).To(ContainElement("CNI_COMMAND=DEL"))
+			Expect(cmd.Env).To(ContainElement("CNI_CONTAINERID=some-handle"))
+			Expect(cmd.Env).To(ContainElement("CNI_IFNAME=eth0"))
+		})
+
+		Context("when the plugin's DEL fails", func() {
+			It("returns the error and keeps the stored result", func() {
+				resultPath := filepath.Join(stateDir, "some-handle.json")
+				Expect(ioutil.WriteFile(resultPath, []byte("{}"), 0644)).To(Succeed())
+
+				fakeRunner.WhenRunning(fake_command_runner.CommandSpec{
+					Path: filepath.Join(binDir, "bridge"),
+				}, func(cmd *exec.Cmd) error {
+					return errors.New("exit status 1")
+				})
+
+				Expect(plugin.Destroy(logger, "some-handle")).To(MatchError("cni del bridge: exit status 1"))
+				Expect(resultPath).To(BeAnExistingFile())
+			})
+		})
+
+		It("removes the stored result", func() {
+			resultPath := filepath.Join(stateDir, "some-handle.json")
+			Expect(ioutil.WriteFile(resultPath, []byte("{}"), 0644)).To(Succeed())
+
+			Expect(plugin.Destroy(logger, "some-handle")).To(Succeed())
+			Expect(resultPath).NotTo(BeAnExistingFile())
+		})
+
+		Context("when there is no stored result", func() {
+			It("succeeds", func() {
+				Expect(plugin.Destroy(logger, "some-handle")).To(Succeed())
+			})
+		})
+	})
+
+	Describe("NetIn and NetOut", func() {
+		It("are not supported", func() {
+			_, _, err := plugin.NetIn(logger, "some-handle", 1, 2)
+			Expect(err).To(Equal(netplugin.ErrNotSupportedByCNI))
+
+			Expect(plugin.NetOut(logger, "some-handle", garden.NetOutRule{})).To(Equal(netplugin.ErrNotSupportedByCNI))
+			Expect(plugin.BulkNetOut(logger, "some-handle", []garden.NetOutRule{{}})).To(Equal(netplugin.ErrNotSupportedByCNI))
+		})
+	})
+})
@@ -0,0 +1,59 @@
+package netplugin
+
+import (
+	"fmt"
+
+	"github.com/cloudfoundry-incubator/garden"
+	"github.com/cloudfoundry-incubator/guardian/gardener"
+	"github.com/pivotal-golang/lager"
+)
+
+// HostNetwork is a networker for containers which share the host's network
+// (on Windows, its network compartment), e.g. under winc. Nothing needs to
+// be set up or torn down, all outbound traffic is already allowed and a
+// container port is reachable on the same host port.
+type HostNetwork struct{}
+
+func (HostNetwork) Hooks(log lager.Logger, handle, spec string) (gardener.Hooks, error) {
+	return gardener.Hooks{}, nil
+}
+
+func (HostNetwork) Capacity() uint64 {
+	return 0
+}
+
+func (HostNetwork) Destroy(log lager.Logger, handle string) error {
+	return nil
+}
+
+func (HostNetwork) NetIn(log lager.Logger, handle string, hostPort, containerPort uint32) (uint32, uint32, error) {
+	if containerPort == 0 {
+		containerPort = hostPort
+	}
+
+	if hostPort != 0 && hostPort != containerPort {
+		return 0, 0, fmt.Errorf("host networking cannot map host port %d to container port %d", hostPort, containerPort)
+	}
+
+	return containerPort, containerPort, nil
+}
+
+func (HostNetwork) NetOut(log lager.Logger, handle string, rule garden.NetOutRule) error {
+	return nil
+}
+
+func (HostNetwork) BulkNetOut(log lager.Logger, handle string, rules []garden.NetOutRule) error {
+	return nil
+}
+
+func (HostNetwork) Checkpoint(log lager.Logger, handle string) error {
+	return nil
+}
+
+func (HostNetwork) Restore(log lager.Logger, handle string) error {
+	return nil
+}
+
+func (HostNetwork) Recover(log lager.Logger, handle string) error {
+	return nil
+}
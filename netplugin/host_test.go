@@ -0,0 +1,39 @@
+package netplugin_test
+
+import (
+	"github.com/cloudfoundry-incubator/guardian/gardener"
+	"github.com/cloudfoundry-incubator/guardian/netplugin"
+	"github.com/pivotal-golang/lager/lagertest"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("HostNetwork", func() {
+	var network netplugin.HostNetwork
+
+	It("has no hooks", func() {
+		Expect(network.Hooks(lagertest.NewTestLogger("test"), "some-handle", "potato")).To(Equal(gardener.Hooks{}))
+	})
+
+	Describe("NetIn", func() {
+		It("maps the container port to the same host port", func() {
+			hostPort, containerPort, err := network.NetIn(lagertest.NewTestLogger("test"), "some-handle", 0, 8080)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(hostPort).To(BeEquivalentTo(8080))
+			Expect(containerPort).To(BeEquivalentTo(8080))
+		})
+
+		It("uses the host port when no container port is given", func() {
+			hostPort, containerPort, err := network.NetIn(lagertest.NewTestLogger("test"), "some-handle", 9090, 0)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(hostPort).To(BeEquivalentTo(9090))
+			Expect(containerPort).To(BeEquivalentTo(9090))
+		})
+
+		It("cannot map a host port to a different container port", func() {
+			_, _, err := network.NetIn(lagertest.NewTestLogger("test"), "some-handle", 9090, 8080)
+			Expect(err).To(MatchError("host networking cannot map host port 9090 to container port 8080"))
+		})
+	})
+})
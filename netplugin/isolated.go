@@ -0,0 +1,52 @@
+package netplugin
+
+import (
+	"errors"
+
+	"github.com/cloudfoundry-incubator/garden"
+	"github.com/cloudfoundry-incubator/guardian/gardener"
+	"github.com/pivotal-golang/lager"
+)
+
+var ErrNoNetwork = errors.New("containers have no network beyond their loopback interface")
+
+// Isolated is a networker for containers which have only their own loopback
+// interface, e.g. those of a rootless guardian which cannot create network
+// devices on the host
+type Isolated struct{}
+
+func (Isolated) Hooks(log lager.Logger, handle, spec string) (gardener.Hooks, error) {
+	return gardener.Hooks{}, nil
+}
+
+func (Isolated) Capacity() uint64 {
+	return 0
+}
+
+func (Isolated) Destroy(log lager.Logger, handle string) error {
+	return nil
+}
+
+func (Isolated) NetIn(log lager.Logger, handle string, hostPort, containerPort uint32) (uint32, uint32, error) {
+	return 0, 0, ErrNoNetwork
+}
+
+func (Isolated) NetOut(log lager.Logger, handle string, rule garden.NetOutRule) error {
+	return ErrNoNetwork
+}
+
+func (Isolated) BulkNetOut(log lager.Logger, handle string, rules []garden.NetOutRule) error {
+	return ErrNoNetwork
+}
+
+func (Isolated) Checkpoint(log lager.Logger, handle string) error {
+	return nil
+}
+
+func (Isolated) Restore(log lager.Logger, handle string) error {
+	return nil
+}
+
+func (Isolated) Recover(log lager.Logger, handle string) error {
+	return nil
+}
@@ -54,3 +54,19 @@ func (Plugin) NetIn(log lager.Logger, handle string, hostPort, containerPort uin
 func (Plugin) NetOut(log lager.Logger, handle string, rule garden.NetOutRule) error {
 	return nil
 }
+
+func (Plugin) BulkNetOut(log lager.Logger, handle string, rules []garden.NetOutRule) error {
+	return nil
+}
+
+func (Plugin) Checkpoint(log lager.Logger, handle string) error {
+	return nil
+}
+
+func (Plugin) Restore(log lager.Logger, handle string) error {
+	return nil
+}
+
+func (Plugin) Recover(log lager.Logger, handle string) error {
+	return nil
+}
@@ -0,0 +1,215 @@
+package netplugin
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"github.com/cloudfoundry-incubator/garden"
+	"github.com/cloudfoundry-incubator/guardian/gardener"
+	"github.com/pivotal-golang/lager"
+)
+
+// slirp4netnsUp is the prestart hook: it reads the container's pid from the
+// state on stdin, starts slirp4netns in the background to give the
+// container's network namespace a tap device routed through the host's
+// network, and waits for its API socket.
+const slirp4netnsUp = `pid=$(sed -n 's/.*"pid": *\([0-9]*\).*/\1/p')
+"$1" --configure --mtu=65520 --disable-host-loopback --api-socket "$2" "$pid" tap0 </dev/null >/dev/null 2>&1 &
+echo $! > "$3"
+for i in $(seq 50); do
+	[ -S "$2" ] && exit 0
+	sleep 0.1
+done
+echo "slirp4netns did not start" >&2
+exit 1`
+
+// slirp4netnsDown is the poststop hook, which stops slirp4netns. The pid is
+// only killed if it is still the container's slirp4netns, i.e. has its API
+// socket in its command line, as slirp4netns may have exited and its pid
+// been reused.
+const slirp4netnsDown = `pid=$(cat "$2" 2>/dev/null)
+if [ -n "$pid" ] && tr '\0' '\n' 2>/dev/null <"/proc/$pid/cmdline" | grep -qxF -e "$1"; then
+	kill "$pid"
+fi
+rm -f "$1" "$2"
+true`
+
+// Slirp4netns is a networker for the containers of a rootless guardian, which
+// cannot create network devices on the host. Each container's traffic is
+// routed through the host's network by a slirp4netns process in user space,
+// which its hooks start and stop. All outbound traffic is allowed, and
+// NetIn forwards TCP host ports to the container through slirp4netns's API.
+type Slirp4netns struct {
+	Bin      string
+	StateDir string
+
+	// HostAddr is the host address NetIn's ports are bound to, or the
+	// loopback address if it is nil, rather than every address of the host
+	HostAddr net.IP
+}
+
+func (s *Slirp4netns) Hooks(log lager.Logger, handle, spec string) (gardener.Hooks, error) {
+	return gardener.Hooks{
+		Prestart: gardener.Hook{
+			Path: "/bin/sh",
+			Args: []string{"sh", "-c", slirp4netnsUp, "sh", s.Bin, s.apiSocket(handle), s.pidFile(handle)},
+		},
+		Poststop: gardener.Hook{
+			Path: "/bin/sh",
+			Args: []string{"sh", "-c", slirp4netnsDown, "sh", s.apiSocket(handle), s.pidFile(handle)},
+		},
+	}, nil
+}
+
+func (s *Slirp4netns) Capacity() uint64 {
+	return 0
+}
+
+// Destroy stops slirp4netns if the poststop hook did not run, e.g. because
+// the container failed to start
+func (s *Slirp4netns) Destroy(log lager.Logger, handle string) error {
+	if data, err := ioutil.ReadFile(s.pidFile(handle)); err == nil {
+		if pid, err := strconv.Atoi(strings.TrimSpace(string(data))); err == nil && s.isSlirp4netns(pid, handle) {
+			if process, err := os.FindProcess(pid); err == nil {
+				process.Signal(syscall.SIGTERM)
+			}
+		}
+	}
+
+	for _, path := range []string{s.pidFile(handle), s.apiSocket(handle)} {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// isSlirp4netns returns whether the process is the container's slirp4netns,
+// which was started with its API socket, rather than another process which
+// was given its pid once it exited
+func (s *Slirp4netns) isSlirp4netns(pid int, handle string) bool {
+	cmdline, err := ioutil.ReadFile(fmt.Sprintf("/proc/%d/cmdline", pid))
+	if err != nil {
+		return false
+	}
+
+	for _, arg := range strings.Split(string(cmdline), "\x00") {
+		if arg == s.apiSocket(handle) {
+			return true
+		}
+	}
+
+	return false
+}
+
+type slirp4netnsRequest struct {
+	Execute   string                 `json:"execute"`
+	Arguments slirp4netnsHostForward `json:"arguments"`
+}
+
+type slirp4netnsHostForward struct {
+	Proto     string `json:"proto"`
+	HostAddr  string `json:"host_addr"`
+	HostPort  uint32 `json:"host_port"`
+	GuestPort uint32 `json:"guest_port"`
+}
+
+type slirp4netnsResponse struct {
+	Error *struct {
+		Desc string `json:"desc"`
+	} `json:"error"`
+}
+
+// NetIn forwards the host port to the container port. slirp4netns cannot
+// choose a host port, so when either port is 0 the other is used for both.
+func (s *Slirp4netns) NetIn(log lager.Logger, handle string, hostPort, containerPort uint32) (uint32, uint32, error) {
+	if containerPort == 0 {
+		containerPort = hostPort
+	}
+
+	if hostPort == 0 {
+		hostPort = containerPort
+	}
+
+	if hostPort == 0 {
+		return 0, 0, errors.New("slirp4netns networking cannot choose a host port: a host or container port must be given")
+	}
+
+	hostAddr := s.HostAddr
+	if hostAddr == nil {
+		hostAddr = net.IPv4(127, 0, 0, 1)
+	}
+
+	conn, err := net.Dial("unix", s.apiSocket(handle))
+	if err != nil {
+		return 0, 0, fmt.Errorf("connect to slirp4netns: %s", err)
+	}
+
+	defer conn.Close()
+
+	if err := json.NewEncoder(conn).Encode(slirp4netnsRequest{
+		Execute: "add_hostfwd",
+		Arguments: slirp4netnsHostForward{
+			Proto:     "tcp",
+			HostAddr:  hostAddr.String(),
+			HostPort:  hostPort,
+			GuestPort: containerPort,
+		},
+	}); err != nil {
+		return 0, 0, fmt.Errorf("send to slirp4netns: %s", err)
+	}
+
+	// slirp4netns reads the request until the end of the stream
+	conn.(*net.UnixConn).CloseWrite()
+
+	var response slirp4netnsResponse
+	if err := json.NewDecoder(conn).Decode(&response); err != nil {
+		return 0, 0, fmt.Errorf("read from slirp4netns: %s", err)
+	}
+
+	if response.Error != nil {
+		return 0, 0, fmt.Errorf("slirp4netns: %s", response.Error.Desc)
+	}
+
+	return hostPort, containerPort, nil
+}
+
+// NetOut does nothing: slirp4netns allows all outbound traffic
+func (s *Slirp4netns) NetOut(log lager.Logger, handle string, rule garden.NetOutRule) error {
+	return nil
+}
+
+func (s *Slirp4netns) BulkNetOut(log lager.Logger, handle string, rules []garden.NetOutRule) error {
+	return nil
+}
+
+func (s *Slirp4netns) Checkpoint(log lager.Logger, handle string) error {
+	return nil
+}
+
+func (s *Slirp4netns) Restore(log lager.Logger, handle string) error {
+	return nil
+}
+
+// Recover does nothing: slirp4netns outlives guardian, and its API socket
+// is found by the container's handle
+func (s *Slirp4netns) Recover(log lager.Logger, handle string) error {
+	return nil
+}
+
+func (s *Slirp4netns) apiSocket(handle string) string {
+	return filepath.Join(s.StateDir, handle+".sock")
+}
+
+func (s *Slirp4netns) pidFile(handle string) string {
+	return filepath.Join(s.StateDir, handle+".pid")
+}
@@ -0,0 +1,224 @@
+package netplugin_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	"github.com/cloudfoundry-incubator/garden"
+	"github.com/cloudfoundry-incubator/guardian/netplugin"
+	"github.com/pivotal-golang/lager/lagertest"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Slirp4netns", func() {
+	var (
+		stateDir string
+		network  *netplugin.Slirp4netns
+	)
+
+	BeforeEach(func() {
+		var err error
+		stateDir, err = ioutil.TempDir("", "slirp4netns")
+		Expect(err).NotTo(HaveOccurred())
+
+		network = &netplugin.Slirp4netns{Bin: "/path/to/slirp4netns", StateDir: stateDir}
+	})
+
+	AfterEach(func() {
+		Expect(os.RemoveAll(stateDir)).To(Succeed())
+	})
+
+	It("starts and stops slirp4netns from the container's hooks", func() {
+		hooks, err := network.Hooks(lagertest.NewTestLogger("test"), "some-handle", "")
+		Expect(err).NotTo(HaveOccurred())
+
+		socket := filepath.Join(stateDir, "some-handle.sock")
+		pidFile := filepath.Join(stateDir, "some-handle.pid")
+
+		Expect(hooks.Prestart.Path).To(Equal("/bin/sh"))
+		Expect(hooks.Prestart.Args[0:2]).To(Equal([]string{"sh", "-c"}))
+		Expect(hooks.Prestart.Args[3:]).To(Equal([]string{"sh", "/path/to/slirp4netns", socket, pidFile}))
+
+		Expect(hooks.Poststop.Path).To(Equal("/bin/sh"))
+		Expect(hooks.Poststop.Args[3:]).To(Equal([]string{"sh", socket, pidFile}))
+	})
+
+	Describe("the poststop hook", func() {
+		runPoststop := func(pid int) {
+			Expect(ioutil.WriteFile(filepath.Join(stateDir, "some-handle.pid"), []byte(fmt.Sprintf("%d\n", pid)), 0644)).To(Succeed())
+
+			hooks, err := network.Hooks(lagertest.NewTestLogger("test"), "some-handle", "")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(exec.Command(hooks.Poststop.Path, hooks.Poststop.Args[1:]...).Run()).To(Succeed())
+		}
+
+		It("stops slirp4netns", func() {
+			cmd := exec.Command("sh", "-c", "sleep 100", "slirp4netns", "--api-socket", filepath.Join(stateDir, "some-handle.sock"))
+			Expect(cmd.Start()).To(Succeed())
+
+			runPoststop(cmd.Process.Pid)
+			Expect(cmd.Wait()).To(MatchError("signal: terminated"))
+			Expect(filepath.Join(stateDir, "some-handle.pid")).NotTo(BeAnExistingFile())
+		})
+
+		It("does not kill another process which has been given slirp4netns's pid", func() {
+			cmd := exec.Command("sleep", "100")
+			Expect(cmd.Start()).To(Succeed())
+			defer cmd.Process.Kill()
+
+			runPoststop(cmd.Process.Pid)
+
+			exited := make(chan error, 1)
+			go func() { exited <- cmd.Wait() }()
+			Consistently(exited, "200ms").ShouldNot(Receive())
+		})
+	})
+
+	Describe("Destroy", func() {
+		It("removes the container's state", func() {
+			Expect(ioutil.WriteFile(filepath.Join(stateDir, "some-handle.pid"), []byte("not-a-pid\n"), 0644)).To(Succeed())
+			Expect(ioutil.WriteFile(filepath.Join(stateDir, "some-handle.sock"), nil, 0644)).To(Succeed())
+
+			Expect(network.Destroy(lagertest.NewTestLogger("test"), "some-handle")).To(Succeed())
+
+			Expect(filepath.Join(stateDir, "some-handle.pid")).NotTo(BeAnExistingFile())
+			Expect(filepath.Join(stateDir, "some-handle.sock")).NotTo(BeAnExistingFile())
+		})
+
+		Context("when slirp4netns is still running", func() {
+			It("stops it", func() {
+				socket := filepath.Join(stateDir, "some-handle.sock")
+				cmd := exec.Command("sh", "-c", "sleep 100", "slirp4netns", "--api-socket", socket)
+				Expect(cmd.Start()).To(Succeed())
+				Expect(ioutil.WriteFile(filepath.Join(stateDir, "some-handle.pid"), []byte(fmt.Sprintf("%d\n", cmd.Process.Pid)), 0644)).To(Succeed())
+
+				Expect(network.Destroy(lagertest.NewTestLogger("test"), "some-handle")).To(Succeed())
+				Expect(cmd.Wait()).To(MatchError("signal: terminated"))
+			})
+		})
+
+		Context("when the pid has been reused by another process", func() {
+			It("does not signal it", func() {
+				cmd := exec.Command("sleep", "100")
+				Expect(cmd.Start()).To(Succeed())
+				defer cmd.Process.Kill()
+				Expect(ioutil.WriteFile(filepath.Join(stateDir, "some-handle.pid"), []byte(fmt.Sprintf("%d\n", cmd.Process.Pid)), 0644)).To(Succeed())
+
+				Expect(network.Destroy(lagertest.NewTestLogger("test"), "some-handle")).To(Succeed())
+
+				exited := make(chan error, 1)
+				go func() { exited <- cmd.Wait() }()
+				Consistently(exited, "200ms").ShouldNot(Receive())
+			})
+		})
+
+		It("succeeds when there is no state", func() {
+			Expect(network.Destroy(lagertest.NewTestLogger("test"), "some-handle")).To(Succeed())
+		})
+	})
+
+	Describe("NetIn", func() {
+		var (
+			listener net.Listener
+			requests chan map[string]interface{}
+			response string
+		)
+
+		BeforeEach(func() {
+			var err error
+			listener, err = net.Listen("unix", filepath.Join(stateDir, "some-handle.sock"))
+			Expect(err).NotTo(HaveOccurred())
+
+			requests = make(chan map[string]interface{}, 1)
+			response = `{"return": {"id": 1}}`
+
+			go func() {
+				defer GinkgoRecover()
+
+				conn, err := listener.Accept()
+				if err != nil {
+					return
+				}
+
+				defer conn.Close()
+
+				var request map[string]interface{}
+				Expect(json.NewDecoder(conn).Decode(&request)).To(Succeed())
+				requests <- request
+
+				conn.Write([]byte(response))
+			}()
+		})
+
+		AfterEach(func() {
+			listener.Close()
+		})
+
+		It("forwards the host port to the container port through the API socket", func() {
+			hostPort, containerPort, err := network.NetIn(lagertest.NewTestLogger("test"), "some-handle", 8080, 80)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(hostPort).To(BeEquivalentTo(8080))
+			Expect(containerPort).To(BeEquivalentTo(80))
+
+			var request map[string]interface{}
+			Eventually(requests).Should(Receive(&request))
+			Expect(request).To(Equal(map[string]interface{}{
+				"execute": "add_hostfwd",
+				"arguments": map[string]interface{}{
+					"proto":      "tcp",
+					"host_addr":  "127.0.0.1",
+					"host_port":  8080.0,
+					"guest_port": 80.0,
+				},
+			}))
+		})
+
+		It("uses the same port for both when one is not given", func() {
+			hostPort, containerPort, err := network.NetIn(lagertest.NewTestLogger("test"), "some-handle", 0, 8080)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(hostPort).To(BeEquivalentTo(8080))
+			Expect(containerPort).To(BeEquivalentTo(8080))
+		})
+
+		It("returns slirp4netns's errors", func() {
+			response = `{"error": {"desc": "bad request: add_hostfwd: slirp_add_hostfwd failed"}}`
+
+			_, _, err := network.NetIn(lagertest.NewTestLogger("test"), "some-handle", 8080, 80)
+			Expect(err).To(MatchError("slirp4netns: bad request: add_hostfwd: slirp_add_hostfwd failed"))
+		})
+
+		It("binds the host port to the host address, when one is given", func() {
+			network.HostAddr = net.ParseIP("10.0.0.5")
+
+			_, _, err := network.NetIn(lagertest.NewTestLogger("test"), "some-handle", 8080, 80)
+			Expect(err).NotTo(HaveOccurred())
+
+			var request map[string]interface{}
+			Eventually(requests).Should(Receive(&request))
+			Expect(request["arguments"]).To(HaveKeyWithValue("host_addr", "10.0.0.5"))
+		})
+
+		It("cannot choose a host port", func() {
+			_, _, err := network.NetIn(lagertest.NewTestLogger("test"), "some-handle", 0, 0)
+			Expect(err).To(MatchError(ContainSubstring("cannot choose a host port")))
+		})
+	})
+})
+
+var _ = Describe("Isolated", func() {
+	var network netplugin.Isolated
+
+	It("has no network to map ports or allow traffic on", func() {
+		_, _, err := network.NetIn(lagertest.NewTestLogger("test"), "some-handle", 0, 8080)
+		Expect(err).To(Equal(netplugin.ErrNoNetwork))
+
+		Expect(network.NetOut(lagertest.NewTestLogger("test"), "some-handle", garden.NetOutRule{})).To(Equal(netplugin.ErrNoNetwork))
+	})
+})
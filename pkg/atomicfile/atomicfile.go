@@ -0,0 +1,38 @@
+// Package atomicfile replaces files, e.g. state files, atomically, so that a
+// crash while one is being written leaves either its old or its new contents
+// in place rather than a truncated file.
+package atomicfile
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// WriteJSON replaces the file at path with v encoded as JSON. The JSON is
+// written to a temporary file in the same directory, which is synced and then
+// renamed over path.
+func WriteJSON(path string, v interface{}) error {
+	tmp, err := ioutil.TempFile(filepath.Dir(path), "."+filepath.Base(path))
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if err := json.NewEncoder(tmp).Encode(v); err != nil {
+		tmp.Close()
+		return err
+	}
+
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
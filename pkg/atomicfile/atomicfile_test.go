@@ -0,0 +1,70 @@
+package atomicfile_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/cloudfoundry-incubator/guardian/pkg/atomicfile"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("WriteJSON", func() {
+	var (
+		tmpDir string
+		path   string
+	)
+
+	BeforeEach(func() {
+		var err error
+		tmpDir, err = ioutil.TempDir("", "atomicfile")
+		Expect(err).NotTo(HaveOccurred())
+
+		path = filepath.Join(tmpDir, "state.json")
+	})
+
+	AfterEach(func() {
+		Expect(os.RemoveAll(tmpDir)).To(Succeed())
+	})
+
+	It("replaces the file with the value encoded as JSON", func() {
+		Expect(ioutil.WriteFile(path, []byte(`{"old": "contents which are longer than the new ones"}`), 0600)).To(Succeed())
+
+		Expect(atomicfile.WriteJSON(path, map[string]int{"new": 1})).To(Succeed())
+
+		contents, err := ioutil.ReadFile(path)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(contents).To(MatchJSON(`{"new": 1}`))
+	})
+
+	It("leaves no temporary files behind", func() {
+		Expect(atomicfile.WriteJSON(path, map[string]int{"new": 1})).To(Succeed())
+
+		entries, err := ioutil.ReadDir(tmpDir)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(entries).To(HaveLen(1))
+	})
+
+	Context("when the value cannot be encoded", func() {
+		It("returns the error and leaves the file as it was", func() {
+			Expect(ioutil.WriteFile(path, []byte(`{"old": 1}`), 0600)).To(Succeed())
+
+			Expect(atomicfile.WriteJSON(path, func() {})).NotTo(Succeed())
+
+			contents, err := ioutil.ReadFile(path)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(contents).To(MatchJSON(`{"old": 1}`))
+
+			entries, err := ioutil.ReadDir(tmpDir)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(entries).To(HaveLen(1))
+		})
+	})
+
+	Context("when the directory does not exist", func() {
+		It("returns an error", func() {
+			Expect(atomicfile.WriteJSON(filepath.Join(tmpDir, "nonexistent", "state.json"), 1)).NotTo(Succeed())
+		})
+	})
+})
@@ -0,0 +1,75 @@
+// Package cmdtimeout runs commands which must not be allowed to hang, e.g.
+// plugins and policy binaries which guardian waits on while serving a request.
+package cmdtimeout
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/cloudfoundry/gunk/command_runner"
+)
+
+// Error is returned when a command is killed for running longer than its
+// timeout
+type Error struct {
+	Args    []string
+	Timeout time.Duration
+}
+
+func (e Error) Error() string {
+	return fmt.Sprintf("timed out after %s: %s", e.Timeout, strings.Join(e.Args, " "))
+}
+
+// Runner is a CommandRunner whose Run kills the command, along with any
+// processes it has started, if it has not exited within Timeout. Only Run is
+// limited: Start is used for long-running processes, which must not time out.
+type Runner struct {
+	command_runner.CommandRunner
+
+	// Timeout is how long a command may run for; if it is zero, commands
+	// are not timed out
+	Timeout time.Duration
+}
+
+func (r Runner) Run(cmd *exec.Cmd) error {
+	if r.Timeout <= 0 {
+		return r.CommandRunner.Run(cmd)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), r.Timeout)
+	defer cancel()
+
+	err := RunContext(ctx, r.CommandRunner, cmd)
+	if err == context.DeadlineExceeded {
+		return Error{Args: cmd.Args, Timeout: r.Timeout}
+	}
+
+	return err
+}
+
+// RunContext runs the command, killing it, along with any processes it has
+// started, if ctx is done before it exits, in which case ctx's error is
+// returned
+func RunContext(ctx context.Context, runner command_runner.CommandRunner, cmd *exec.Cmd) error {
+	newProcessGroup(cmd)
+	if err := runner.Start(cmd); err != nil {
+		return err
+	}
+
+	exited := make(chan error, 1)
+	go func() {
+		exited <- runner.Wait(cmd)
+	}()
+
+	select {
+	case err := <-exited:
+		return err
+	case <-ctx.Done():
+		killProcessGroup(cmd)
+		<-exited
+		return ctx.Err()
+	}
+}
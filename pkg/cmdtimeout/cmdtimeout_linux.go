@@ -0,0 +1,24 @@
+package cmdtimeout
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// newProcessGroup makes the command the leader of a process group of its own,
+// so that any processes it starts can be killed along with it
+func newProcessGroup(cmd *exec.Cmd) {
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+
+	cmd.SysProcAttr.Setpgid = true
+}
+
+func killProcessGroup(cmd *exec.Cmd) {
+	if cmd.Process == nil {
+		return
+	}
+
+	syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+}
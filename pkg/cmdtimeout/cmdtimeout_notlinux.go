@@ -0,0 +1,17 @@
+// +build !linux
+
+package cmdtimeout
+
+import "os/exec"
+
+// newProcessGroup does nothing, as process groups are not supported on this
+// platform; only the command itself is killed when it times out
+func newProcessGroup(cmd *exec.Cmd) {}
+
+func killProcessGroup(cmd *exec.Cmd) {
+	if cmd.Process == nil {
+		return
+	}
+
+	cmd.Process.Kill()
+}
@@ -0,0 +1,13 @@
+package cmdtimeout_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"testing"
+)
+
+func TestCmdtimeout(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Cmdtimeout Suite")
+}
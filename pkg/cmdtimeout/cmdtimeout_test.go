@@ -0,0 +1,89 @@
+package cmdtimeout_test
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/cloudfoundry-incubator/guardian/pkg/cmdtimeout"
+	"github.com/cloudfoundry/gunk/command_runner/linux_command_runner"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Runner", func() {
+	var (
+		tmpDir string
+		runner cmdtimeout.Runner
+	)
+
+	BeforeEach(func() {
+		var err error
+		tmpDir, err = ioutil.TempDir("", "cmdtimeout")
+		Expect(err).NotTo(HaveOccurred())
+
+		runner = cmdtimeout.Runner{CommandRunner: linux_command_runner.New(), Timeout: 200 * time.Millisecond}
+	})
+
+	AfterEach(func() {
+		Expect(os.RemoveAll(tmpDir)).To(Succeed())
+	})
+
+	It("returns the result of commands which exit in time", func() {
+		Expect(runner.Run(exec.Command("true"))).To(Succeed())
+		Expect(runner.Run(exec.Command("false"))).To(MatchError("exit status 1"))
+	})
+
+	It("kills commands which run for too long, and returns a timeout error", func() {
+		started := time.Now()
+		err := runner.Run(exec.Command("sleep", "100"))
+		Expect(time.Since(started)).To(BeNumerically("<", 5*time.Second))
+
+		Expect(err).To(Equal(cmdtimeout.Error{Args: []string{"sleep", "100"}, Timeout: 200 * time.Millisecond}))
+		Expect(err).To(MatchError("timed out after 200ms: sleep 100"))
+	})
+
+	It("kills the processes started by commands which time out", func() {
+		pidFile := filepath.Join(tmpDir, "pid")
+		err := runner.Run(exec.Command("sh", "-c", "sleep 100 & echo $! > "+pidFile+"; wait"))
+		Expect(err).To(BeAssignableToTypeOf(cmdtimeout.Error{}))
+
+		contents, err := ioutil.ReadFile(pidFile)
+		Expect(err).NotTo(HaveOccurred())
+		pid, err := strconv.Atoi(strings.TrimSpace(string(contents)))
+		Expect(err).NotTo(HaveOccurred())
+
+		Eventually(func() error {
+			return syscall.Kill(pid, 0)
+		}).Should(HaveOccurred())
+	})
+
+	Context("when the timeout is zero", func() {
+		It("does not time commands out", func() {
+			runner.Timeout = 0
+			Expect(runner.Run(exec.Command("sleep", "0.3"))).To(Succeed())
+		})
+	})
+})
+
+var _ = Describe("RunContext", func() {
+	It("returns the result of commands which exit before the context is done", func() {
+		Expect(cmdtimeout.RunContext(context.Background(), linux_command_runner.New(), exec.Command("false"))).To(MatchError("exit status 1"))
+	})
+
+	It("kills commands which are still running when the context is cancelled, and returns its error", func() {
+		ctx, cancel := context.WithCancel(context.Background())
+		time.AfterFunc(200*time.Millisecond, cancel)
+
+		started := time.Now()
+		err := cmdtimeout.RunContext(ctx, linux_command_runner.New(), exec.Command("sleep", "100"))
+		Expect(time.Since(started)).To(BeNumerically("<", 5*time.Second))
+		Expect(err).To(Equal(context.Canceled))
+	})
+})
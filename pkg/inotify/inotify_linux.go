@@ -0,0 +1,41 @@
+// Package inotify reads the events returned by inotify(7), for the watchers
+// of files which guardian reloads or re-verifies when they change.
+package inotify
+
+import (
+	"syscall"
+	"unsafe"
+)
+
+// ContainsEventFor returns whether the events read from an inotify
+// descriptor into buf include one for the file called name in a watched
+// directory
+func ContainsEventFor(buf []byte, name string) bool {
+	for offset := 0; offset+syscall.SizeofInotifyEvent <= len(buf); {
+		event := (*syscall.InotifyEvent)(unsafe.Pointer(&buf[offset]))
+		nameStart := offset + syscall.SizeofInotifyEvent
+		nameEnd := nameStart + int(event.Len)
+		if nameEnd > len(buf) {
+			return false
+		}
+
+		if trimNull(buf[nameStart:nameEnd]) == name {
+			return true
+		}
+
+		offset = nameEnd
+	}
+
+	return false
+}
+
+// trimNull returns the name in an event, which the kernel pads with nulls
+func trimNull(b []byte) string {
+	for i, c := range b {
+		if c == 0 {
+			return string(b[:i])
+		}
+	}
+
+	return string(b)
+}
@@ -0,0 +1,67 @@
+package inotify_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"syscall"
+
+	"github.com/cloudfoundry-incubator/guardian/pkg/inotify"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ContainsEventFor", func() {
+	var (
+		tmpDir string
+		fd     int
+		buf    []byte
+	)
+
+	BeforeEach(func() {
+		var err error
+		tmpDir, err = ioutil.TempDir("", "inotify")
+		Expect(err).NotTo(HaveOccurred())
+
+		fd, err = syscall.InotifyInit1(syscall.IN_CLOEXEC)
+		Expect(err).NotTo(HaveOccurred())
+
+		_, err = syscall.InotifyAddWatch(fd, tmpDir, syscall.IN_CLOSE_WRITE)
+		Expect(err).NotTo(HaveOccurred())
+
+		buf = make([]byte, 64*(syscall.SizeofInotifyEvent+syscall.NAME_MAX+1))
+	})
+
+	AfterEach(func() {
+		syscall.Close(fd)
+		Expect(os.RemoveAll(tmpDir)).To(Succeed())
+	})
+
+	readEvents := func() []byte {
+		n, err := syscall.Read(fd, buf)
+		Expect(err).NotTo(HaveOccurred())
+		return buf[:n]
+	}
+
+	It("finds the event for a file among the events for others", func() {
+		Expect(ioutil.WriteFile(filepath.Join(tmpDir, "some-file"), nil, 0644)).To(Succeed())
+		Expect(ioutil.WriteFile(filepath.Join(tmpDir, "the-file"), nil, 0644)).To(Succeed())
+
+		events := readEvents()
+		Expect(inotify.ContainsEventFor(events, "the-file")).To(BeTrue())
+		Expect(inotify.ContainsEventFor(events, "some-file")).To(BeTrue())
+	})
+
+	It("does not match files whose names only start with the name", func() {
+		Expect(ioutil.WriteFile(filepath.Join(tmpDir, "the-file.swp"), nil, 0644)).To(Succeed())
+
+		Expect(inotify.ContainsEventFor(readEvents(), "the-file")).To(BeFalse())
+	})
+
+	It("ignores events cut short at the end of the buffer", func() {
+		Expect(ioutil.WriteFile(filepath.Join(tmpDir, "the-file"), nil, 0644)).To(Succeed())
+
+		events := readEvents()
+		Expect(inotify.ContainsEventFor(events[:len(events)-1], "the-file")).To(BeFalse())
+	})
+})
@@ -0,0 +1,13 @@
+package inotify_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"testing"
+)
+
+func TestInotify(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Inotify Suite")
+}
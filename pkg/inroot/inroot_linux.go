@@ -0,0 +1,134 @@
+// Package inroot opens and creates paths within a directory as if it were
+// the root directory, so that .. and symlinks, including absolute ones,
+// cannot lead out of it. It is used for paths in containers' roots, which
+// the containers control.
+package inroot
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"unsafe"
+)
+
+const (
+	sysOpenat2          = 437 // the same on every architecture
+	oPath               = 0x200000
+	resolveNoMagiclinks = 0x02
+	resolveInRoot       = 0x10
+)
+
+// openHow is the kernel's struct open_how
+type openHow struct {
+	flags   uint64
+	mode    uint64
+	resolve uint64
+}
+
+// Open opens path within root with O_PATH, so that it can be used through
+// its /proc/self/fd link (see FdPath) without being resolved again
+func Open(root *os.File, path string) (*os.File, error) {
+	return openat2(root, path, oPath)
+}
+
+// MkdirAll creates the directory at path within root, and any missing
+// parents, with perm
+func MkdirAll(root *os.File, path string, perm os.FileMode) error {
+	dir, err := mkdirAll(root, path, perm)
+	if err != nil {
+		return err
+	}
+
+	return dir.Close()
+}
+
+// Create creates an empty file at path within root, and any missing parent
+// directories, unless something already exists there
+func Create(root *os.File, path string, perm os.FileMode) error {
+	if existing, err := Open(root, path); err == nil {
+		return existing.Close()
+	}
+
+	parent, err := mkdirAll(root, filepath.Dir(path), 0755)
+	if err != nil {
+		return err
+	}
+	defer parent.Close()
+
+	// the parent has been resolved within root, and the name is a single
+	// component which is not followed if it is a symlink
+	fd, err := syscall.Openat(int(parent.Fd()), filepath.Base(path), syscall.O_CREAT|syscall.O_EXCL|syscall.O_NOFOLLOW|syscall.O_WRONLY|syscall.O_CLOEXEC, uint32(perm))
+	if err != nil {
+		return &os.PathError{Op: "create", Path: path, Err: err}
+	}
+
+	return syscall.Close(fd)
+}
+
+// FdPath returns the /proc/self/fd link to file, through which it can be
+// used by syscalls which take paths, such as mount(2)
+func FdPath(file *os.File) string {
+	return fmt.Sprintf("/proc/self/fd/%d", file.Fd())
+}
+
+// mkdirAll returns the directory at path within root, having created it and
+// its missing parents. Each component is created in its parent as resolved
+// within root, so a symlink cannot redirect the creation elsewhere.
+func mkdirAll(root *os.File, path string, perm os.FileMode) (*os.File, error) {
+	dir, err := openat2(root, "/", oPath|syscall.O_DIRECTORY)
+	if err != nil {
+		return nil, err
+	}
+
+	prefix := "/"
+	for _, name := range strings.Split(filepath.Clean("/"+path), "/") {
+		if name == "" {
+			continue
+		}
+
+		prefix = filepath.Join(prefix, name)
+		next, err := openat2(root, prefix, oPath|syscall.O_DIRECTORY)
+		if isNotExist(err) {
+			if err := syscall.Mkdirat(int(dir.Fd()), name, uint32(perm)); err != nil && err != syscall.EEXIST {
+				dir.Close()
+				return nil, &os.PathError{Op: "mkdir", Path: prefix, Err: err}
+			}
+
+			next, err = openat2(root, prefix, oPath|syscall.O_DIRECTORY)
+		}
+
+		dir.Close()
+		if err != nil {
+			return nil, err
+		}
+
+		dir = next
+	}
+
+	return dir, nil
+}
+
+func openat2(root *os.File, path string, flags uint64) (*os.File, error) {
+	pathPtr, err := syscall.BytePtrFromString(path)
+	if err != nil {
+		return nil, err
+	}
+
+	how := openHow{flags: flags | syscall.O_CLOEXEC, resolve: resolveInRoot | resolveNoMagiclinks}
+	fd, _, errno := syscall.Syscall6(sysOpenat2, root.Fd(), uintptr(unsafe.Pointer(pathPtr)), uintptr(unsafe.Pointer(&how)), unsafe.Sizeof(how), 0, 0)
+	if errno != 0 {
+		return nil, &os.PathError{Op: "openat2", Path: path, Err: errno}
+	}
+
+	return os.NewFile(fd, path), nil
+}
+
+func isNotExist(err error) bool {
+	if pathErr, ok := err.(*os.PathError); ok {
+		return pathErr.Err == syscall.ENOENT
+	}
+
+	return false
+}
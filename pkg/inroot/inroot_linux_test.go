@@ -0,0 +1,111 @@
+package inroot_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/cloudfoundry-incubator/guardian/pkg/inroot"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Inroot", func() {
+	var (
+		tmpDir  string
+		rootDir string
+		outside string
+		root    *os.File
+	)
+
+	BeforeEach(func() {
+		var err error
+		tmpDir, err = ioutil.TempDir("", "inroot")
+		Expect(err).NotTo(HaveOccurred())
+
+		rootDir = filepath.Join(tmpDir, "root")
+		outside = filepath.Join(tmpDir, "outside")
+		Expect(os.MkdirAll(outside, 0755)).To(Succeed())
+
+		// the targets of the symlinks, as resolved within the root
+		Expect(os.MkdirAll(filepath.Join(rootDir, outside), 0755)).To(Succeed())
+		Expect(os.MkdirAll(filepath.Join(rootDir, "outside"), 0755)).To(Succeed())
+
+		Expect(os.Symlink(outside, filepath.Join(rootDir, "absolute"))).To(Succeed())
+		Expect(os.Symlink("../../outside", filepath.Join(rootDir, "relative"))).To(Succeed())
+
+		root, err = os.Open(rootDir)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		root.Close()
+		os.RemoveAll(tmpDir)
+	})
+
+	resolved := func(file *os.File) string {
+		target, err := os.Readlink(inroot.FdPath(file))
+		Expect(err).NotTo(HaveOccurred())
+		return target
+	}
+
+	Describe("Open", func() {
+		It("resolves absolute symlinks within the root", func() {
+			file, err := inroot.Open(root, "/absolute")
+			Expect(err).NotTo(HaveOccurred())
+			defer file.Close()
+
+			Expect(resolved(file)).To(Equal(filepath.Join(rootDir, outside)))
+		})
+
+		It("does not let .. lead out of the root", func() {
+			file, err := inroot.Open(root, "/../../outside")
+			Expect(err).NotTo(HaveOccurred())
+			defer file.Close()
+
+			Expect(resolved(file)).To(Equal(filepath.Join(rootDir, "outside")))
+		})
+
+		It("does not let relative symlinks lead out of the root", func() {
+			file, err := inroot.Open(root, "/relative")
+			Expect(err).NotTo(HaveOccurred())
+			defer file.Close()
+
+			Expect(resolved(file)).To(Equal(filepath.Join(rootDir, "outside")))
+		})
+	})
+
+	Describe("MkdirAll", func() {
+		It("creates the directory and its parents", func() {
+			Expect(inroot.MkdirAll(root, "/a/b/c", 0755)).To(Succeed())
+			Expect(filepath.Join(rootDir, "a", "b", "c")).To(BeADirectory())
+		})
+
+		It("creates directories through symlinks within the root", func() {
+			Expect(inroot.MkdirAll(root, "/absolute/created", 0755)).To(Succeed())
+
+			Expect(filepath.Join(rootDir, outside, "created")).To(BeADirectory())
+			Expect(filepath.Join(outside, "created")).NotTo(BeADirectory())
+		})
+	})
+
+	Describe("Create", func() {
+		It("creates an empty file and its parent directories", func() {
+			Expect(inroot.Create(root, "/etc/config", 0644)).To(Succeed())
+			Expect(filepath.Join(rootDir, "etc", "config")).To(BeARegularFile())
+		})
+
+		It("leaves an existing file alone", func() {
+			Expect(ioutil.WriteFile(filepath.Join(rootDir, "existing"), []byte("contents"), 0644)).To(Succeed())
+			Expect(inroot.Create(root, "/existing", 0644)).To(Succeed())
+			Expect(ioutil.ReadFile(filepath.Join(rootDir, "existing"))).To(Equal([]byte("contents")))
+		})
+
+		It("creates files through symlinks within the root", func() {
+			Expect(inroot.Create(root, "/absolute/file", 0644)).To(Succeed())
+
+			Expect(filepath.Join(rootDir, outside, "file")).To(BeARegularFile())
+			Expect(filepath.Join(outside, "file")).NotTo(BeAnExistingFile())
+		})
+	})
+})
@@ -0,0 +1,27 @@
+// +build !linux
+
+package inroot
+
+import (
+	"errors"
+	"fmt"
+	"os"
+)
+
+var errNotSupported = errors.New("resolving paths within a root is not supported on this platform")
+
+func Open(root *os.File, path string) (*os.File, error) {
+	return nil, errNotSupported
+}
+
+func MkdirAll(root *os.File, path string, perm os.FileMode) error {
+	return errNotSupported
+}
+
+func Create(root *os.File, path string, perm os.FileMode) error {
+	return errNotSupported
+}
+
+func FdPath(file *os.File) string {
+	return fmt.Sprintf("/proc/self/fd/%d", file.Fd())
+}
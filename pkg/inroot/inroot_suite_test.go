@@ -0,0 +1,13 @@
+package inroot_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"testing"
+)
+
+func TestInroot(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Inroot Suite")
+}
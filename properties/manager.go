@@ -1,29 +1,105 @@
 package properties
 
 import (
+	"encoding/json"
 	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
 	"sync"
 
 	"github.com/cloudfoundry-incubator/garden"
+	"github.com/pivotal-golang/lager"
 )
 
 type Manager struct {
 	propMutex sync.RWMutex
 	prop      map[string]map[string]string
+
+	// index holds the handles of the containers with each value of each
+	// property, so that containers can be selected by their properties
+	// without checking every container
+	index map[string]map[string]map[string]struct{}
+
+	// dir, if set, holds a JSON file of the properties of each container so
+	// that they survive a restart of guardian
+	dir string
+	log lager.Logger
 }
 
 func NewManager() *Manager {
 	return &Manager{
-		prop: make(map[string]map[string]string),
+		prop:  make(map[string]map[string]string),
+		index: make(map[string]map[string]map[string]struct{}),
 	}
 }
 
+// NewPersistentManager returns a Manager which saves properties in dir,
+// loading any saved by a previous run of guardian.
+func NewPersistentManager(log lager.Logger, dir string) (*Manager, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	m := NewManager()
+	m.dir = dir
+	m.log = log.Session("properties")
+
+	for _, file := range files {
+		if !strings.HasSuffix(file.Name(), ".json") {
+			continue
+		}
+
+		data, err := ioutil.ReadFile(filepath.Join(dir, file.Name()))
+		if err != nil {
+			return nil, err
+		}
+
+		props := make(map[string]string)
+		if err := json.Unmarshal(data, &props); err != nil {
+			return nil, fmt.Errorf("load properties from %s: %s", file.Name(), err)
+		}
+
+		handle := strings.TrimSuffix(file.Name(), ".json")
+		m.prop[handle] = props
+		for name, value := range props {
+			m.addToIndex(handle, name, value)
+		}
+	}
+
+	return m, nil
+}
+
 func (m *Manager) DestroyKeySpace(handle string) error {
 	m.propMutex.Lock()
 	defer m.propMutex.Unlock()
 
+	for name, value := range m.prop[handle] {
+		m.removeFromIndex(handle, name, value)
+	}
+
 	delete(m.prop, handle)
 
+	if m.dir != "" {
+		// nothing can have been saved for a handle which is not a valid file
+		// name
+		path, err := m.path(handle)
+		if err != nil {
+			return nil
+		}
+
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+
 	return nil
 }
 
@@ -35,7 +111,34 @@ func (m *Manager) Set(handle string, name string, value string) {
 		m.prop[handle] = make(map[string]string)
 	}
 
+	m.set(handle, name, value)
+}
+
+// SetIfExists sets the property only if the container has properties, so
+// that updates made in the background cannot recreate the properties of a
+// container which has been destroyed. It returns whether it was set.
+func (m *Manager) SetIfExists(handle string, name string, value string) bool {
+	m.propMutex.Lock()
+	defer m.propMutex.Unlock()
+
+	if _, ok := m.prop[handle]; !ok {
+		return false
+	}
+
+	m.set(handle, name, value)
+	return true
+}
+
+// set sets the property of a container which has properties. It must be
+// called with the lock held.
+func (m *Manager) set(handle string, name string, value string) {
+	if old, ok := m.prop[handle][name]; ok {
+		m.removeFromIndex(handle, name, old)
+	}
+
 	m.prop[handle][name] = value
+	m.addToIndex(handle, name, value)
+	m.persist(handle)
 }
 
 func (m *Manager) All(handle string) (garden.Properties, error) {
@@ -73,7 +176,9 @@ func (m *Manager) Remove(handle string, name string) error {
 		}
 	}
 
+	m.removeFromIndex(handle, name, m.prop[handle][name])
 	delete(m.prop[handle], name)
+	m.persist(handle)
 
 	return nil
 }
@@ -91,6 +196,147 @@ func (m *Manager) MatchesAll(handle string, props garden.Properties) bool {
 	return true
 }
 
+// Select returns the handles of the containers whose properties match all
+// of props exactly and meet every requirement of the label selector (see
+// ParseSelector), sorted. With neither, every container with properties is
+// returned.
+func (m *Manager) Select(props garden.Properties, selector string) ([]string, error) {
+	requirements, err := ParseSelector(selector)
+	if err != nil {
+		return nil, err
+	}
+
+	for name, value := range props {
+		requirements = append(requirements, Requirement{Key: name, Values: []string{value}})
+	}
+
+	m.propMutex.RLock()
+	defer m.propMutex.RUnlock()
+
+	var matching map[string]struct{}
+	if len(requirements) == 0 {
+		matching = make(map[string]struct{}, len(m.prop))
+		for handle := range m.prop {
+			matching[handle] = struct{}{}
+		}
+	}
+
+	for i, requirement := range requirements {
+		selected := m.selectRequirement(requirement)
+		if i == 0 {
+			matching = selected
+			continue
+		}
+
+		for handle := range matching {
+			if _, ok := selected[handle]; !ok {
+				delete(matching, handle)
+			}
+		}
+	}
+
+	handles := make([]string, 0, len(matching))
+	for handle := range matching {
+		handles = append(handles, handle)
+	}
+
+	sort.Strings(handles)
+	return handles, nil
+}
+
+// selectRequirement returns the handles which meet the requirement. It must
+// be called with the lock held.
+func (m *Manager) selectRequirement(requirement Requirement) map[string]struct{} {
+	selected := make(map[string]struct{})
+
+	add := func(handles map[string]struct{}) {
+		for handle := range handles {
+			selected[handle] = struct{}{}
+		}
+	}
+
+	if len(requirement.Values) > 0 {
+		for _, value := range requirement.Values {
+			add(m.index[requirement.Key][value])
+		}
+
+		return selected
+	}
+
+	for value, handles := range m.index[requirement.Key] {
+		if strings.HasPrefix(value, requirement.Prefix) {
+			add(handles)
+		}
+	}
+
+	return selected
+}
+
+// addToIndex and removeFromIndex must be called with the lock held
+func (m *Manager) addToIndex(handle, name, value string) {
+	if _, ok := m.index[name]; !ok {
+		m.index[name] = make(map[string]map[string]struct{})
+	}
+
+	if _, ok := m.index[name][value]; !ok {
+		m.index[name][value] = make(map[string]struct{})
+	}
+
+	m.index[name][value][handle] = struct{}{}
+}
+
+func (m *Manager) removeFromIndex(handle, name, value string) {
+	delete(m.index[name][value], handle)
+
+	if len(m.index[name][value]) == 0 {
+		delete(m.index[name], value)
+	}
+
+	if len(m.index[name]) == 0 {
+		delete(m.index, name)
+	}
+}
+
+// persist saves the properties of the container, if the Manager is
+// persistent. It must be called with the lock held.
+func (m *Manager) persist(handle string) {
+	if m.dir == "" {
+		return
+	}
+
+	path, err := m.path(handle)
+	if err != nil {
+		m.log.Error("invalid-handle", err, lager.Data{"handle": handle})
+		return
+	}
+
+	data, err := json.Marshal(m.prop[handle])
+	if err != nil {
+		m.log.Error("marshal-failed", err, lager.Data{"handle": handle})
+		return
+	}
+
+	tmp := path + ".tmp"
+	if err := ioutil.WriteFile(tmp, data, 0600); err != nil {
+		m.log.Error("write-failed", err, lager.Data{"handle": handle})
+		return
+	}
+
+	if err := os.Rename(tmp, path); err != nil {
+		m.log.Error("rename-failed", err, lager.Data{"handle": handle})
+	}
+}
+
+// path is the path of the file holding the container's properties, which
+// must be in dir whatever the handle
+func (m *Manager) path(handle string) (string, error) {
+	if handle == "" || handle == "." || handle == ".." || strings.ContainsAny(handle, "/\x00") {
+		return "", fmt.Errorf("invalid handle '%s'", handle)
+	}
+
+	return filepath.Join(m.dir, handle+".json"), nil
+}
+
 type NoSuchPropertyError struct {
 	Message string
 }
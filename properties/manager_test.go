@@ -1,10 +1,16 @@
 package properties_test
 
 import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
 	"github.com/cloudfoundry-incubator/garden"
 	"github.com/cloudfoundry-incubator/guardian/properties"
 	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
 	. "github.com/onsi/gomega"
+	"github.com/pivotal-golang/lager/lagertest"
 )
 
 var _ = Describe("Properties", func() {
@@ -84,6 +90,25 @@ var _ = Describe("Properties", func() {
 			})
 		})
 
+		Describe("SetIfExists", func() {
+			It("sets the property of a container which has properties", func() {
+				propertyManager.Set("some-handle", "name", "value")
+
+				Expect(propertyManager.SetIfExists("some-handle", "other-name", "other-value")).To(BeTrue())
+				Expect(propertyManager.Get("some-handle", "other-name")).To(Equal("other-value"))
+			})
+
+			It("does not recreate the properties of a destroyed container", func() {
+				propertyManager.Set("some-handle", "name", "value")
+				Expect(propertyManager.DestroyKeySpace("some-handle")).To(Succeed())
+
+				Expect(propertyManager.SetIfExists("some-handle", "name", "value")).To(BeFalse())
+				props, err := propertyManager.All("some-handle")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(props).To(BeEmpty())
+			})
+		})
+
 		Describe("MatchesAll", func() {
 			Context("when the properties list is empty", func() {
 				It("matches", func() {
@@ -146,5 +171,136 @@ var _ = Describe("Properties", func() {
 				})
 			})
 		})
+
+		Describe("Select", func() {
+			BeforeEach(func() {
+				propertyManager.Set("web-1", "app", "web")
+				propertyManager.Set("web-1", "team", "data-eng")
+				propertyManager.Set("web-2", "app", "web")
+				propertyManager.Set("web-2", "team", "platform")
+				propertyManager.Set("worker", "app", "worker")
+				propertyManager.Set("worker", "team", "data-science")
+			})
+
+			DescribeTable("selecting containers",
+				func(props garden.Properties, selector string, expected []string) {
+					handles, err := propertyManager.Select(props, selector)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(handles).To(Equal(expected))
+				},
+				Entry("by exact property", garden.Properties{"app": "web"}, "", []string{"web-1", "web-2"}),
+				Entry("by an equality selector", nil, "app=worker", []string{"worker"}),
+				Entry("by a set selector", nil, "app in (web, worker)", []string{"web-1", "web-2", "worker"}),
+				Entry("by a prefix selector", nil, "team=data-*", []string{"web-1", "worker"}),
+				Entry("by an existence selector", nil, "name", []string{"handle"}),
+				Entry("by several requirements", garden.Properties{"app": "web"}, "team=data-*", []string{"web-1"}),
+				Entry("by requirements nothing meets", nil, "app=web,team in (nobody)", []string{}),
+				Entry("by nothing", nil, "", []string{"handle", "web-1", "web-2", "worker"}),
+			)
+
+			It("keeps the index up to date as properties change", func() {
+				propertyManager.Set("web-2", "app", "worker")
+				Expect(propertyManager.Remove("web-1", "app")).To(Succeed())
+				Expect(propertyManager.DestroyKeySpace("worker")).To(Succeed())
+
+				Expect(propertyManager.Select(nil, "app=web")).To(BeEmpty())
+				Expect(propertyManager.Select(nil, "app=worker")).To(Equal([]string{"web-2"}))
+			})
+
+			It("fails when the selector is invalid", func() {
+				_, err := propertyManager.Select(nil, "app in web")
+				Expect(err).To(MatchError(ContainSubstring("invalid label selector 'app in web'")))
+			})
+		})
+	})
+
+	Describe("ParseSelector", func() {
+		It("parses every kind of requirement", func() {
+			requirements, err := properties.ParseSelector("app=web, team=data-*,tier in (a,b), canary")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(requirements).To(Equal([]properties.Requirement{
+				{Key: "app", Values: []string{"web"}},
+				{Key: "team", Prefix: "data-"},
+				{Key: "tier", Values: []string{"a", "b"}},
+				{Key: "canary"},
+			}))
+		})
+
+		DescribeTable("invalid selectors",
+			func(selector string) {
+				_, err := properties.ParseSelector(selector)
+				Expect(err).To(MatchError(HavePrefix("invalid label selector")))
+			},
+			Entry("set without parentheses", "app in web"),
+			Entry("empty set", "app in ()"),
+			Entry("missing key", "=web"),
+			Entry("key with spaces", "my app"),
+		)
+	})
+
+	Describe("PersistentManager", func() {
+		var dir string
+
+		BeforeEach(func() {
+			var err error
+			dir, err = ioutil.TempDir("", "properties")
+			Expect(err).NotTo(HaveOccurred())
+
+			propertyManager, err = properties.NewPersistentManager(lagertest.NewTestLogger("test"), dir)
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		AfterEach(func() {
+			Expect(os.RemoveAll(dir)).To(Succeed())
+		})
+
+		reload := func() *properties.Manager {
+			reloaded, err := properties.NewPersistentManager(lagertest.NewTestLogger("test"), dir)
+			Expect(err).NotTo(HaveOccurred())
+			return reloaded
+		}
+
+		It("loads properties saved by a previous manager", func() {
+			propertyManager.Set("handle", "name", "value")
+			propertyManager.Set("handle", "other-name", "other-value")
+			Expect(propertyManager.Remove("handle", "other-name")).To(Succeed())
+
+			props, err := reload().All("handle")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(props).To(Equal(garden.Properties{"name": "value"}))
+		})
+
+		It("indexes the loaded properties", func() {
+			propertyManager.Set("handle", "app", "web")
+
+			Expect(reload().Select(nil, "app in (web)")).To(Equal([]string{"handle"}))
+		})
+
+		It("forgets the properties of destroyed key spaces", func() {
+			propertyManager.Set("handle", "name", "value")
+			Expect(propertyManager.DestroyKeySpace("handle")).To(Succeed())
+
+			props, err := reload().All("handle")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(props).To(BeEmpty())
+			Expect(filepath.Join(dir, "handle.json")).NotTo(BeAnExistingFile())
+		})
+
+		It("does not save the properties of handles which would escape the directory", func() {
+			propertyManager.Set("../escaped", "name", "value")
+			Expect(filepath.Join(filepath.Dir(dir), "escaped.json")).NotTo(BeAnExistingFile())
+
+			props, err := propertyManager.All("../escaped")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(props).To(Equal(garden.Properties{"name": "value"}))
+			Expect(propertyManager.DestroyKeySpace("../escaped")).To(Succeed())
+		})
+
+		It("fails when saved properties are corrupt", func() {
+			Expect(ioutil.WriteFile(filepath.Join(dir, "handle.json"), []byte("{"), 0600)).To(Succeed())
+
+			_, err := properties.NewPersistentManager(lagertest.NewTestLogger("test"), dir)
+			Expect(err).To(MatchError(ContainSubstring("load properties from handle.json")))
+		})
 	})
 })
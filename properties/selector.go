@@ -0,0 +1,112 @@
+package properties
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Requirement is one term of a label selector: a property which must be set
+// to one of Values, to a value starting with Prefix, or, if neither is
+// given, to anything
+type Requirement struct {
+	Key    string
+	Values []string
+	Prefix string
+}
+
+// ParseSelector parses a label selector, a comma-separated list of
+// requirements which must all be met:
+//
+//	key=value          the property is set to value
+//	key=prefix*        the property is set to a value starting with prefix
+//	key in (a,b)       the property is set to one of the values
+//	key                the property is set
+func ParseSelector(selector string) ([]Requirement, error) {
+	var requirements []Requirement
+
+	for _, term := range splitTerms(selector) {
+		term = strings.TrimSpace(term)
+		if term == "" {
+			continue
+		}
+
+		requirement, err := parseRequirement(term)
+		if err != nil {
+			return nil, fmt.Errorf("invalid label selector '%s': %s", selector, err)
+		}
+
+		requirements = append(requirements, requirement)
+	}
+
+	return requirements, nil
+}
+
+func parseRequirement(term string) (Requirement, error) {
+	if i := strings.Index(term, " in "); i >= 0 {
+		key := strings.TrimSpace(term[:i])
+		set := strings.TrimSpace(term[i+len(" in "):])
+		if !strings.HasPrefix(set, "(") || !strings.HasSuffix(set, ")") {
+			return Requirement{}, fmt.Errorf("'%s': the values must be in parentheses", term)
+		}
+
+		var values []string
+		for _, value := range strings.Split(set[1:len(set)-1], ",") {
+			if value = strings.TrimSpace(value); value != "" {
+				values = append(values, value)
+			}
+		}
+
+		if len(values) == 0 {
+			return Requirement{}, fmt.Errorf("'%s': no values", term)
+		}
+
+		return requirement(term, key, Requirement{Key: key, Values: values})
+	}
+
+	if i := strings.Index(term, "="); i >= 0 {
+		key := strings.TrimSpace(term[:i])
+		value := strings.TrimSpace(term[i+1:])
+
+		if strings.HasSuffix(value, "*") {
+			return requirement(term, key, Requirement{Key: key, Prefix: strings.TrimSuffix(value, "*")})
+		}
+
+		return requirement(term, key, Requirement{Key: key, Values: []string{value}})
+	}
+
+	return requirement(term, term, Requirement{Key: term})
+}
+
+func requirement(term, key string, r Requirement) (Requirement, error) {
+	if key == "" || strings.ContainsAny(key, " ()") {
+		return Requirement{}, fmt.Errorf("'%s': invalid key '%s'", term, key)
+	}
+
+	return r, nil
+}
+
+// splitTerms splits the selector on the commas which are not inside
+// parentheses
+func splitTerms(selector string) []string {
+	var (
+		terms []string
+		depth int
+		start int
+	)
+
+	for i, c := range selector {
+		switch c {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		case ',':
+			if depth == 0 {
+				terms = append(terms, selector[start:i])
+				start = i + 1
+			}
+		}
+	}
+
+	return append(terms, selector[start:])
+}
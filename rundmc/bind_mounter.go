@@ -0,0 +1,210 @@
+package rundmc
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/cloudfoundry-incubator/garden"
+	"github.com/cloudfoundry-incubator/goci"
+	"github.com/cloudfoundry-incubator/guardian/pkg/inroot"
+	"github.com/opencontainers/specs"
+	"github.com/pivotal-golang/lager"
+)
+
+//go:generate counterfeiter . Mounter
+
+// Mounter makes and removes bind mounts in the host's mount namespace. The
+// destinations may be /proc/self/fd links, so must be resolved by the
+// calling process.
+type Mounter interface {
+	BindMount(log lager.Logger, src, dst string, readOnly bool) error
+	Unmount(log lager.Logger, dst string) error
+}
+
+// LiveBindMounter adds bind mounts to, and removes them from, running
+// containers. The mounts are made under the container's rootfs in the host's
+// mount namespace, and reach the container through mount propagation: the
+// container's root is made a slave of the host's mount of the rootfs (see
+// RootfsPropagation). It returns the container's bundle with its mounts
+// updated, so that the change can be persisted and survives recovery.
+//
+// The container controls its rootfs, so destinations are resolved within it
+// (see pkg/inroot) and mounted on through their /proc/self/fd links; sources
+// must be under one of AllowedSources.
+type LiveBindMounter struct {
+	BundleLoader   BundleLoader
+	Mounter        Mounter
+	AllowedSources []string
+}
+
+// RootfsPropagation is the propagation of containers' roots which lets live
+// bind mounts reach them
+const RootfsPropagation = "rslave"
+
+func (m *LiveBindMounter) Add(log lager.Logger, bundlePath string, mount garden.BindMount) (*goci.Bndl, error) {
+	log = log.Session("add-bind-mount", lager.Data{"src": mount.SrcPath, "dst": mount.DstPath})
+
+	bndl, err := m.BundleLoader.Load(bundlePath)
+	if err != nil {
+		log.Error("load-bundle-failed", err)
+		return nil, fmt.Errorf("load bundle: %s", err)
+	}
+
+	if _, ok := findBindMount(bndl, mount.DstPath); ok {
+		return nil, fmt.Errorf("add bind mount: '%s' is already mounted", mount.DstPath)
+	}
+
+	src, err := m.allowedSource(mount.SrcPath)
+	if err != nil {
+		return nil, fmt.Errorf("add bind mount: %s", err)
+	}
+
+	info, err := os.Stat(src)
+	if err != nil {
+		return nil, fmt.Errorf("add bind mount: %s", err)
+	}
+
+	readOnly := mount.Mode != garden.BindMountModeRW
+	err = inRootFS(bndl, func(root *os.File) error {
+		if err := createMountPoint(root, mount.DstPath, info.IsDir()); err != nil {
+			log.Error("create-mount-point-failed", err)
+			return fmt.Errorf("create mount point: %s", err)
+		}
+
+		target, err := inroot.Open(root, mount.DstPath)
+		if err != nil {
+			return err
+		}
+		defer target.Close()
+
+		if err := m.Mounter.BindMount(log, src, inroot.FdPath(target), readOnly); err != nil {
+			log.Error("mount-failed", err)
+			return err
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("add bind mount: %s", err)
+	}
+
+	modeOpt := "ro"
+	if !readOnly {
+		modeOpt = "rw"
+	}
+
+	return bndl.WithMounts(specs.Mount{
+		Destination: mount.DstPath,
+		Source:      src,
+		Type:        "bind",
+		Options:     []string{"bind", modeOpt},
+	}), nil
+}
+
+func (m *LiveBindMounter) Remove(log lager.Logger, bundlePath, dstPath string) (*goci.Bndl, error) {
+	log = log.Session("remove-bind-mount", lager.Data{"dst": dstPath})
+
+	bndl, err := m.BundleLoader.Load(bundlePath)
+	if err != nil {
+		log.Error("load-bundle-failed", err)
+		return nil, fmt.Errorf("load bundle: %s", err)
+	}
+
+	index, ok := findBindMount(bndl, dstPath)
+	if !ok {
+		return nil, fmt.Errorf("remove bind mount: '%s' is not a bind mount", dstPath)
+	}
+
+	if err := m.unmount(log, bndl, dstPath); err != nil {
+		log.Error("unmount-failed", err)
+		return nil, fmt.Errorf("remove bind mount: %s", err)
+	}
+
+	mounts := bndl.Mounts()
+	newBndl := *bndl
+	newBndl.Spec.Mounts = append(append([]specs.Mount{}, mounts[:index]...), mounts[index+1:]...)
+	return &newBndl, nil
+}
+
+// Release removes any bind mounts added to a container from the host's mount
+// namespace, so that the container's rootfs can be destroyed. Bind mounts the
+// container was created with exist only in its own mount namespace, so fail
+// to unmount; failures are only logged.
+func (m *LiveBindMounter) Release(log lager.Logger, bundlePath string) {
+	log = log.Session("release-bind-mounts")
+
+	bndl, err := m.BundleLoader.Load(bundlePath)
+	if err != nil {
+		log.Error("load-bundle-failed", err)
+		return
+	}
+
+	for _, mount := range bndl.Mounts() {
+		if mount.Type != "bind" {
+			continue
+		}
+
+		if err := m.unmount(log, bndl, mount.Destination); err != nil {
+			log.Debug("not-mounted-on-host", lager.Data{"dst": mount.Destination, "error": err.Error()})
+		}
+	}
+}
+
+// allowedSource returns the source with any symlinks resolved, as long as it
+// is under one of the allowed directories
+func (m *LiveBindMounter) allowedSource(path string) (string, error) {
+	src, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		return "", err
+	}
+
+	for _, dir := range m.AllowedSources {
+		if src == filepath.Clean(dir) || strings.HasPrefix(src, filepath.Clean(dir)+string(filepath.Separator)) {
+			return src, nil
+		}
+	}
+
+	return "", fmt.Errorf("source '%s' is not in an allowed directory", path)
+}
+
+func (m *LiveBindMounter) unmount(log lager.Logger, bndl *goci.Bndl, dstPath string) error {
+	return inRootFS(bndl, func(root *os.File) error {
+		target, err := inroot.Open(root, dstPath)
+		if err != nil {
+			return err
+		}
+		defer target.Close()
+
+		return m.Mounter.Unmount(log, inroot.FdPath(target))
+	})
+}
+
+func inRootFS(bndl *goci.Bndl, fn func(root *os.File) error) error {
+	root, err := os.Open(bndl.RootFS())
+	if err != nil {
+		return fmt.Errorf("open rootfs: %s", err)
+	}
+	defer root.Close()
+
+	return fn(root)
+}
+
+func findBindMount(bndl *goci.Bndl, dstPath string) (int, bool) {
+	for i, mount := range bndl.Mounts() {
+		if mount.Type == "bind" && filepath.Clean(mount.Destination) == filepath.Clean(dstPath) {
+			return i, true
+		}
+	}
+
+	return 0, false
+}
+
+func createMountPoint(root *os.File, path string, dir bool) error {
+	if dir {
+		return inroot.MkdirAll(root, path, 0755)
+	}
+
+	return inroot.Create(root, path, 0644)
+}
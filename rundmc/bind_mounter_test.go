@@ -0,0 +1,248 @@
+package rundmc_test
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/cloudfoundry-incubator/garden"
+	"github.com/cloudfoundry-incubator/goci"
+	"github.com/cloudfoundry-incubator/guardian/rundmc"
+	"github.com/cloudfoundry-incubator/guardian/rundmc/fakes"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/opencontainers/specs"
+	"github.com/pivotal-golang/lager"
+	"github.com/pivotal-golang/lager/lagertest"
+)
+
+var _ = Describe("LiveBindMounter", func() {
+	var (
+		logger      *lagertest.TestLogger
+		tmpDir      string
+		rootfsPath  string
+		srcPath     string
+		fakeLoader  *fakes.FakeBundleLoader
+		fakeMounter *fakes.FakeMounter
+		mounter     *rundmc.LiveBindMounter
+		procMount   specs.Mount
+		mountedOn   []string
+	)
+
+	BeforeEach(func() {
+		var err error
+		tmpDir, err = ioutil.TempDir("", "live-bind-mounts")
+		Expect(err).NotTo(HaveOccurred())
+
+		rootfsPath = filepath.Join(tmpDir, "rootfs")
+		Expect(os.MkdirAll(filepath.Join(rootfsPath, "cache"), 0755)).To(Succeed())
+
+		srcPath = filepath.Join(tmpDir, "volumes", "volume")
+		Expect(os.MkdirAll(srcPath, 0755)).To(Succeed())
+
+		logger = lagertest.NewTestLogger("test")
+		procMount = specs.Mount{Type: "proc", Source: "proc", Destination: "/proc"}
+
+		fakeLoader = new(fakes.FakeBundleLoader)
+		fakeLoader.LoadReturns(goci.Bundle().
+			WithRootFS(rootfsPath).
+			WithMounts(procMount, specs.Mount{Type: "bind", Source: "/var/cache", Destination: "/cache", Options: []string{"bind", "ro"}}), nil)
+
+		// the mounter is given /proc/self/fd links, which are only valid
+		// during the call
+		mountedOn = nil
+		fakeMounter = new(fakes.FakeMounter)
+		fakeMounter.BindMountStub = func(_ lager.Logger, _, dst string, _ bool) error {
+			target, err := os.Readlink(dst)
+			Expect(err).NotTo(HaveOccurred())
+			mountedOn = append(mountedOn, target)
+			return nil
+		}
+		fakeMounter.UnmountStub = func(_ lager.Logger, dst string) error {
+			target, err := os.Readlink(dst)
+			Expect(err).NotTo(HaveOccurred())
+			mountedOn = append(mountedOn, target)
+			return nil
+		}
+
+		mounter = &rundmc.LiveBindMounter{
+			BundleLoader:   fakeLoader,
+			Mounter:        fakeMounter,
+			AllowedSources: []string{filepath.Join(tmpDir, "volumes")},
+		}
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(tmpDir)
+	})
+
+	Describe("Add", func() {
+		var mount garden.BindMount
+
+		BeforeEach(func() {
+			mount = garden.BindMount{SrcPath: srcPath, DstPath: "/data", Mode: garden.BindMountModeRW}
+		})
+
+		It("bind mounts the source under the container's rootfs", func() {
+			_, err := mounter.Add(logger, "/path/to/bundle", mount)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(fakeLoader.LoadArgsForCall(0)).To(Equal("/path/to/bundle"))
+			Expect(fakeMounter.BindMountCallCount()).To(Equal(1))
+			_, src, dst, readOnly := fakeMounter.BindMountArgsForCall(0)
+			Expect(src).To(Equal(srcPath))
+			Expect(dst).To(HavePrefix("/proc/self/fd/"))
+			Expect(mountedOn).To(Equal([]string{filepath.Join(rootfsPath, "data")}))
+			Expect(readOnly).To(BeFalse())
+		})
+
+		Context("when the destination leads out of the rootfs through a symlink", func() {
+			It("mounts on the destination as resolved within the rootfs", func() {
+				Expect(os.Symlink("/", filepath.Join(rootfsPath, "escape"))).To(Succeed())
+				mount.DstPath = "/escape" + tmpDir + "/target"
+
+				_, err := mounter.Add(logger, "/path/to/bundle", mount)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(mountedOn).To(Equal([]string{filepath.Join(rootfsPath, tmpDir, "target")}))
+				Expect(filepath.Join(tmpDir, "target")).NotTo(BeADirectory())
+			})
+		})
+
+		Context("when the source is not in an allowed directory", func() {
+			It("returns an error without mounting anything", func() {
+				mount.SrcPath = rootfsPath
+
+				_, err := mounter.Add(logger, "/path/to/bundle", mount)
+				Expect(err).To(MatchError(fmt.Sprintf("add bind mount: source '%s' is not in an allowed directory", rootfsPath)))
+				Expect(fakeMounter.BindMountCallCount()).To(Equal(0))
+			})
+		})
+
+		Context("when the source leads out of the allowed directories through a symlink", func() {
+			It("returns an error without mounting anything", func() {
+				mount.SrcPath = filepath.Join(tmpDir, "volumes", "sneaky")
+				Expect(os.Symlink(rootfsPath, mount.SrcPath)).To(Succeed())
+
+				_, err := mounter.Add(logger, "/path/to/bundle", mount)
+				Expect(err).To(MatchError(ContainSubstring("is not in an allowed directory")))
+				Expect(fakeMounter.BindMountCallCount()).To(Equal(0))
+			})
+		})
+
+		It("creates the mount point", func() {
+			_, err := mounter.Add(logger, "/path/to/bundle", mount)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(filepath.Join(rootfsPath, "data")).To(BeADirectory())
+		})
+
+		It("returns the bundle with the mount added", func() {
+			bndl, err := mounter.Add(logger, "/path/to/bundle", mount)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(bndl.Mounts()).To(ContainElement(procMount))
+			Expect(bndl.Mounts()).To(ContainElement(specs.Mount{
+				Type: "bind", Source: srcPath, Destination: "/data", Options: []string{"bind", "rw"},
+			}))
+		})
+
+		Context("when the source is a file", func() {
+			It("creates a file as the mount point", func() {
+				mount.SrcPath = filepath.Join(tmpDir, "volumes", "config.yml")
+				Expect(ioutil.WriteFile(mount.SrcPath, []byte("config"), 0644)).To(Succeed())
+
+				_, err := mounter.Add(logger, "/path/to/bundle", mount)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(filepath.Join(rootfsPath, "data")).To(BeARegularFile())
+			})
+		})
+
+		Context("when the mount is read-only", func() {
+			It("makes a read-only mount", func() {
+				mount.Mode = garden.BindMountModeRO
+
+				bndl, err := mounter.Add(logger, "/path/to/bundle", mount)
+				Expect(err).NotTo(HaveOccurred())
+
+				_, _, _, readOnly := fakeMounter.BindMountArgsForCall(0)
+				Expect(readOnly).To(BeTrue())
+				Expect(bndl.Mounts()).To(ContainElement(specs.Mount{
+					Type: "bind", Source: srcPath, Destination: "/data", Options: []string{"bind", "ro"},
+				}))
+			})
+		})
+
+		Context("when the destination is already bind mounted", func() {
+			It("returns an error without mounting anything", func() {
+				mount.DstPath = "/cache/"
+
+				_, err := mounter.Add(logger, "/path/to/bundle", mount)
+				Expect(err).To(MatchError("add bind mount: '/cache/' is already mounted"))
+				Expect(fakeMounter.BindMountCallCount()).To(Equal(0))
+			})
+		})
+
+		Context("when the source does not exist", func() {
+			It("returns an error without mounting anything", func() {
+				mount.SrcPath = filepath.Join(tmpDir, "volumes", "missing")
+
+				_, err := mounter.Add(logger, "/path/to/bundle", mount)
+				Expect(err).To(HaveOccurred())
+				Expect(fakeMounter.BindMountCallCount()).To(Equal(0))
+			})
+		})
+
+		Context("when mounting fails", func() {
+			It("returns an error", func() {
+				fakeMounter.BindMountReturns(errors.New("permission denied"))
+
+				_, err := mounter.Add(logger, "/path/to/bundle", mount)
+				Expect(err).To(MatchError("add bind mount: permission denied"))
+			})
+		})
+	})
+
+	Describe("Remove", func() {
+		It("unmounts the mount from under the container's rootfs", func() {
+			_, err := mounter.Remove(logger, "/path/to/bundle", "/cache")
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(fakeMounter.UnmountCallCount()).To(Equal(1))
+			Expect(mountedOn).To(Equal([]string{filepath.Join(rootfsPath, "cache")}))
+		})
+
+		It("returns the bundle without the mount", func() {
+			bndl, err := mounter.Remove(logger, "/path/to/bundle", "/cache")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(bndl.Mounts()).To(Equal([]specs.Mount{procMount}))
+		})
+
+		Context("when the destination is not a bind mount", func() {
+			It("returns an error without unmounting anything", func() {
+				_, err := mounter.Remove(logger, "/path/to/bundle", "/proc")
+				Expect(err).To(MatchError("remove bind mount: '/proc' is not a bind mount"))
+				Expect(fakeMounter.UnmountCallCount()).To(Equal(0))
+			})
+		})
+
+		Context("when unmounting fails", func() {
+			It("returns an error", func() {
+				fakeMounter.UnmountReturns(errors.New("device busy"))
+
+				_, err := mounter.Remove(logger, "/path/to/bundle", "/cache")
+				Expect(err).To(MatchError("remove bind mount: device busy"))
+			})
+		})
+	})
+
+	Describe("Release", func() {
+		It("unmounts each bind mount from the host, ignoring failures", func() {
+			fakeMounter.UnmountReturns(errors.New("not mounted"))
+
+			mounter.Release(logger, "/path/to/bundle")
+			Expect(fakeMounter.UnmountCallCount()).To(Equal(1))
+		})
+	})
+})
@@ -7,19 +7,22 @@ import (
 
 //go:generate counterfeiter . BundlerRule
 type BundlerRule interface {
-	Apply(bndle *goci.Bndl, spec gardener.DesiredContainerSpec) *goci.Bndl
+	Apply(bndle *goci.Bndl, spec gardener.DesiredContainerSpec) (*goci.Bndl, error)
 }
 
 type BundleTemplate struct {
 	Rules []BundlerRule
 }
 
-func (b BundleTemplate) Generate(spec gardener.DesiredContainerSpec) *goci.Bndl {
+func (b BundleTemplate) Generate(spec gardener.DesiredContainerSpec) (*goci.Bndl, error) {
 	var bndl *goci.Bndl
 
 	for _, rule := range b.Rules {
-		bndl = rule.Apply(bndl, spec)
+		var err error
+		if bndl, err = rule.Apply(bndl, spec); err != nil {
+			return nil, err
+		}
 	}
 
-	return bndl
+	return bndl, nil
 }
@@ -1,6 +1,8 @@
 package rundmc_test
 
 import (
+	"errors"
+
 	"github.com/cloudfoundry-incubator/goci"
 	"github.com/cloudfoundry-incubator/guardian/gardener"
 	"github.com/cloudfoundry-incubator/guardian/rundmc"
@@ -25,12 +27,13 @@ var _ = Describe("BundleTemplate", func() {
 
 		It("returns the bundle from the first rule", func() {
 			returnedSpec := goci.Bndl{}.WithRootFS("something")
-			rule.ApplyStub = func(bndle *goci.Bndl, spec gardener.DesiredContainerSpec) *goci.Bndl {
+			rule.ApplyStub = func(bndle *goci.Bndl, spec gardener.DesiredContainerSpec) (*goci.Bndl, error) {
 				Expect(spec.RootFSPath).To(Equal("the-rootfs"))
-				return returnedSpec
+				return returnedSpec, nil
 			}
 
-			result := bundler.Generate(gardener.DesiredContainerSpec{RootFSPath: "the-rootfs"})
+			result, err := bundler.Generate(gardener.DesiredContainerSpec{RootFSPath: "the-rootfs"})
+			Expect(err).NotTo(HaveOccurred())
 			Expect(result).To(Equal(returnedSpec))
 		})
 
@@ -70,7 +73,7 @@ var _ = Describe("BundleTemplate", func() {
 				specs.Mount{Destination: "test_a"},
 				specs.Mount{Destination: "test_b"},
 			)
-			ruleA.ApplyReturns(bndl)
+			ruleA.ApplyReturns(bndl, nil)
 
 			bundler.Generate(gardener.DesiredContainerSpec{})
 
@@ -84,10 +87,27 @@ var _ = Describe("BundleTemplate", func() {
 				specs.Mount{Destination: "test_a"},
 				specs.Mount{Destination: "test_b"},
 			)
-			ruleB.ApplyReturns(bndl)
+			ruleB.ApplyReturns(bndl, nil)
 
-			recBndl := bundler.Generate(gardener.DesiredContainerSpec{})
+			recBndl, err := bundler.Generate(gardener.DesiredContainerSpec{})
+			Expect(err).NotTo(HaveOccurred())
 			Expect(recBndl).To(Equal(bndl))
 		})
+
+		Context("when a rule fails", func() {
+			BeforeEach(func() {
+				ruleA.ApplyReturns(nil, errors.New("bad-rule"))
+			})
+
+			It("returns the error", func() {
+				_, err := bundler.Generate(gardener.DesiredContainerSpec{})
+				Expect(err).To(MatchError("bad-rule"))
+			})
+
+			It("does not apply the subsequent rules", func() {
+				bundler.Generate(gardener.DesiredContainerSpec{})
+				Expect(ruleB.ApplyCallCount()).To(Equal(0))
+			})
+		})
 	})
 })
@@ -1,19 +1,30 @@
 package bundlerules
 
 import (
+	"errors"
+
 	"github.com/cloudfoundry-incubator/goci"
 	"github.com/cloudfoundry-incubator/guardian/gardener"
+	"github.com/cloudfoundry-incubator/guardian/sysinfo"
 )
 
 type Base struct {
 	PrivilegedBase   *goci.Bndl
 	UnprivilegedBase *goci.Bndl
+
+	// Capabilities determines whether unprivileged containers, which need a
+	// user namespace, can be created
+	Capabilities *sysinfo.Capabilities
 }
 
-func (r Base) Apply(bndl *goci.Bndl, spec gardener.DesiredContainerSpec) *goci.Bndl {
+func (r Base) Apply(bndl *goci.Bndl, spec gardener.DesiredContainerSpec) (*goci.Bndl, error) {
 	if spec.Privileged {
-		return r.PrivilegedBase
-	} else {
-		return r.UnprivilegedBase
+		return r.PrivilegedBase, nil
 	}
+
+	if !r.Capabilities.Has(sysinfo.CapabilityUserNamespaces) {
+		return nil, errors.New("unprivileged containers are not supported: user namespaces are not available")
+	}
+
+	return r.UnprivilegedBase, nil
 }
@@ -7,6 +7,7 @@ import (
 	"github.com/cloudfoundry-incubator/goci"
 	"github.com/cloudfoundry-incubator/guardian/gardener"
 	"github.com/cloudfoundry-incubator/guardian/rundmc/bundlerules"
+	"github.com/cloudfoundry-incubator/guardian/sysinfo"
 )
 
 var _ = Describe("Base", func() {
@@ -28,9 +29,10 @@ var _ = Describe("Base", func() {
 
 	Context("when it is privileged", func() {
 		It("should use the correct base", func() {
-			retBndl := rule.Apply(nil, gardener.DesiredContainerSpec{
+			retBndl, err := rule.Apply(nil, gardener.DesiredContainerSpec{
 				Privileged: true,
 			})
+			Expect(err).NotTo(HaveOccurred())
 
 			Expect(retBndl).To(Equal(privilegeBndl))
 		})
@@ -38,11 +40,23 @@ var _ = Describe("Base", func() {
 
 	Context("when it is not privileged", func() {
 		It("should use the correct base", func() {
-			retBndl := rule.Apply(nil, gardener.DesiredContainerSpec{
+			retBndl, err := rule.Apply(nil, gardener.DesiredContainerSpec{
 				Privileged: false,
 			})
+			Expect(err).NotTo(HaveOccurred())
 
 			Expect(retBndl).To(Equal(unprivilegeBndl))
 		})
+
+		Context("and user namespaces are not available", func() {
+			It("returns an error", func() {
+				capabilities := &sysinfo.Capabilities{}
+				capabilities.Set([]sysinfo.Capability{{Name: sysinfo.CapabilityUserNamespaces}})
+				rule.Capabilities = capabilities
+
+				_, err := rule.Apply(nil, gardener.DesiredContainerSpec{})
+				Expect(err).To(MatchError("unprivileged containers are not supported: user namespaces are not available"))
+			})
+		})
 	})
 })
@@ -15,7 +15,8 @@ var _ = Describe("BindMountsRule", func() {
 	var newBndl *goci.Bndl
 
 	BeforeEach(func() {
-		newBndl = bundlerules.BindMounts{}.Apply(goci.Bundle(), gardener.DesiredContainerSpec{
+		var err error
+		newBndl, err = bundlerules.BindMounts{}.Apply(goci.Bundle(), gardener.DesiredContainerSpec{
 			BindMounts: []garden.BindMount{
 				{
 					SrcPath: "/path/to/ro/src",
@@ -29,6 +30,7 @@ var _ = Describe("BindMountsRule", func() {
 				},
 			},
 		})
+		Expect(err).NotTo(HaveOccurred())
 	})
 
 	It("adds mounts in the bundle spec", func() {
@@ -0,0 +1,28 @@
+package bundlerules
+
+import (
+	"path/filepath"
+
+	"github.com/cloudfoundry-incubator/goci"
+	"github.com/cloudfoundry-incubator/guardian/gardener"
+)
+
+// CgroupsPath places the container's cgroup under Parent, a path relative to
+// the root of the cgroup hierarchy, rather than at its root, e.g. under the
+// cgroup delegated to a rootless guardian. If Parent is empty the runtime's
+// default is left alone.
+type CgroupsPath struct {
+	Parent string
+}
+
+func (c CgroupsPath) Apply(bndl *goci.Bndl, spec gardener.DesiredContainerSpec) (*goci.Bndl, error) {
+	if c.Parent == "" {
+		return bndl, nil
+	}
+
+	cgroupsPath := filepath.Join("/", c.Parent, spec.Handle)
+
+	newBndl := *bndl
+	newBndl.Spec.Linux.CgroupsPath = &cgroupsPath
+	return &newBndl, nil
+}
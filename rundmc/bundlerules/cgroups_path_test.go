@@ -0,0 +1,29 @@
+package bundlerules_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/cloudfoundry-incubator/goci"
+	"github.com/cloudfoundry-incubator/guardian/gardener"
+	"github.com/cloudfoundry-incubator/guardian/rundmc/bundlerules"
+)
+
+var _ = Describe("CgroupsPath", func() {
+	It("places the container's cgroup under the parent", func() {
+		newBndl, err := bundlerules.CgroupsPath{Parent: "user.slice/user-1000.slice"}.Apply(goci.Bundle(), gardener.DesiredContainerSpec{Handle: "some-handle"})
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(newBndl.Spec.Linux.CgroupsPath).NotTo(BeNil())
+		Expect(*newBndl.Spec.Linux.CgroupsPath).To(Equal("/user.slice/user-1000.slice/some-handle"))
+	})
+
+	It("leaves the runtime's default alone without a parent", func() {
+		bndl := goci.Bundle()
+
+		newBndl, err := bundlerules.CgroupsPath{}.Apply(bndl, gardener.DesiredContainerSpec{Handle: "some-handle"})
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(newBndl).To(Equal(bndl))
+	})
+})
@@ -0,0 +1,80 @@
+package bundlerules
+
+import (
+	"errors"
+
+	"github.com/cloudfoundry-incubator/goci"
+	"github.com/cloudfoundry-incubator/guardian/gardener"
+	"github.com/opencontainers/specs"
+)
+
+// ContainerRunnerCapabilities are the capabilities of container-runner
+// containers: every capability, since a nested runtime needs most of them to
+// set up its own containers
+var ContainerRunnerCapabilities = []string{
+	"CAP_AUDIT_CONTROL", "CAP_AUDIT_READ", "CAP_AUDIT_WRITE", "CAP_BLOCK_SUSPEND",
+	"CAP_CHOWN", "CAP_DAC_OVERRIDE", "CAP_DAC_READ_SEARCH", "CAP_FOWNER",
+	"CAP_FSETID", "CAP_IPC_LOCK", "CAP_IPC_OWNER", "CAP_KILL", "CAP_LEASE",
+	"CAP_LINUX_IMMUTABLE", "CAP_MAC_ADMIN", "CAP_MAC_OVERRIDE", "CAP_MKNOD",
+	"CAP_NET_ADMIN", "CAP_NET_BIND_SERVICE", "CAP_NET_BROADCAST", "CAP_NET_RAW",
+	"CAP_SETFCAP", "CAP_SETGID", "CAP_SETPCAP", "CAP_SETUID", "CAP_SYS_ADMIN",
+	"CAP_SYS_BOOT", "CAP_SYS_CHROOT", "CAP_SYS_MODULE", "CAP_SYS_NICE",
+	"CAP_SYS_PACCT", "CAP_SYS_PTRACE", "CAP_SYS_RAWIO", "CAP_SYS_RESOURCE",
+	"CAP_SYS_TIME", "CAP_SYS_TTY_CONFIG", "CAP_SYSLOG", "CAP_WAKE_ALARM",
+}
+
+// ContainerRunnerMounts give container-runner containers a /sys, and their
+// own cgroups at /sys/fs/cgroup, in which a nested runtime creates the
+// cgroups of its containers
+var ContainerRunnerMounts = []specs.Mount{
+	{Type: "sysfs", Source: "sysfs", Destination: "/sys", Options: []string{"nosuid", "noexec", "nodev"}},
+	{Type: "cgroup", Source: "cgroup", Destination: "/sys/fs/cgroup", Options: []string{"nosuid", "noexec", "nodev"}},
+}
+
+// ContainerRunnerDevices are the devices container-runner containers may use
+// in addition to those of other containers: loop devices (and loop-control)
+// for mounting images, and fuse for image plugins which need it
+var ContainerRunnerDevices = []specs.DeviceCgroup{
+	containerRunnerDevice('b', 7, nil),
+	containerRunnerDevice('c', 10, int64Ptr(237)),
+	containerRunnerDevice('c', 10, int64Ptr(229)),
+}
+
+// ContainerRunner gives containers of the container-runner type what a
+// nested runtime needs: every capability, their cgroups, the loop and fuse
+// devices and no seccomp profile. It must be applied after the rules which
+// set the init process, mounts, resources and seccomp profile.
+type ContainerRunner struct{}
+
+func (ContainerRunner) Apply(bndl *goci.Bndl, spec gardener.DesiredContainerSpec) (*goci.Bndl, error) {
+	if spec.Properties[gardener.ContainerTypeProperty] != gardener.ContainerRunnerType {
+		return bndl, nil
+	}
+
+	if !spec.Privileged {
+		return nil, errors.New("container-runner containers must be privileged")
+	}
+
+	resources := specs.Resources{}
+	if bndl.Resources() != nil {
+		resources = *bndl.Resources()
+	}
+
+	resources.Devices = append(append([]specs.DeviceCgroup{}, resources.Devices...), ContainerRunnerDevices...)
+
+	newBndl := *bndl.
+		WithCapabilities(ContainerRunnerCapabilities...).
+		WithMounts(ContainerRunnerMounts...).
+		WithResources(&resources)
+	newBndl.Spec.Linux.Seccomp = specs.Seccomp{}
+	return &newBndl, nil
+}
+
+func containerRunnerDevice(deviceType rune, major int64, minor *int64) specs.DeviceCgroup {
+	access := "rwm"
+	return specs.DeviceCgroup{Allow: true, Type: &deviceType, Major: &major, Minor: minor, Access: &access}
+}
+
+func int64Ptr(i int64) *int64 {
+	return &i
+}
@@ -0,0 +1,84 @@
+package bundlerules_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/opencontainers/specs"
+
+	"github.com/cloudfoundry-incubator/goci"
+	"github.com/cloudfoundry-incubator/guardian/gardener"
+	"github.com/cloudfoundry-incubator/guardian/rundmc/bundlerules"
+)
+
+var _ = Describe("ContainerRunner", func() {
+	var (
+		bndl *goci.Bndl
+		spec gardener.DesiredContainerSpec
+	)
+
+	BeforeEach(func() {
+		limit := int64(64)
+		bndl = goci.Bundle().
+			WithCapabilities("CAP_CHOWN").
+			WithMounts(specs.Mount{Type: "proc", Source: "proc", Destination: "/proc"}).
+			WithResources(&specs.Resources{Pids: &specs.Pids{Limit: &limit}})
+		bndl.Spec.Linux.Seccomp = specs.Seccomp{DefaultAction: specs.ActErrno}
+
+		spec = gardener.DesiredContainerSpec{
+			Privileged: true,
+			Properties: map[string]string{gardener.ContainerTypeProperty: gardener.ContainerRunnerType},
+		}
+	})
+
+	It("gives the container every capability", func() {
+		newBndl, err := bundlerules.ContainerRunner{}.Apply(bndl, spec)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(newBndl.Spec.Process.Capabilities).To(ConsistOf(bundlerules.ContainerRunnerCapabilities))
+		Expect(newBndl.Spec.Process.Capabilities).To(ContainElement("CAP_SYS_ADMIN"))
+	})
+
+	It("mounts the container's cgroups alongside its other mounts", func() {
+		newBndl, err := bundlerules.ContainerRunner{}.Apply(bndl, spec)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(newBndl.Mounts()).To(ContainElement(specs.Mount{Type: "proc", Source: "proc", Destination: "/proc"}))
+		Expect(newBndl.Mounts()).To(ContainElement(specs.Mount{
+			Type: "cgroup", Source: "cgroup", Destination: "/sys/fs/cgroup", Options: []string{"nosuid", "noexec", "nodev"},
+		}))
+	})
+
+	It("allows the loop and fuse devices without clobbering other resources", func() {
+		newBndl, err := bundlerules.ContainerRunner{}.Apply(bndl, spec)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(newBndl.Resources().Devices).To(Equal(bundlerules.ContainerRunnerDevices))
+		Expect(*newBndl.Resources().Pids.Limit).To(BeNumerically("==", 64))
+	})
+
+	It("removes the seccomp profile", func() {
+		newBndl, err := bundlerules.ContainerRunner{}.Apply(bndl, spec)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(newBndl.Spec.Linux.Seccomp).To(Equal(specs.Seccomp{}))
+	})
+
+	Context("when the container is not a container-runner", func() {
+		It("leaves the bundle alone", func() {
+			spec.Properties = nil
+
+			newBndl, err := bundlerules.ContainerRunner{}.Apply(bndl, spec)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(newBndl).To(Equal(bndl))
+		})
+	})
+
+	Context("when the container is not privileged", func() {
+		It("returns an error", func() {
+			spec.Privileged = false
+
+			_, err := bundlerules.ContainerRunner{}.Apply(bndl, spec)
+			Expect(err).To(MatchError("container-runner containers must be privileged"))
+		})
+	})
+})
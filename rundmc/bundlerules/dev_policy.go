@@ -0,0 +1,213 @@
+package bundlerules
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/cloudfoundry-incubator/goci"
+	"github.com/cloudfoundry-incubator/guardian/gardener"
+	"github.com/opencontainers/specs"
+)
+
+// DevPolicyProperty is the container property which may hold the /dev
+// policy of the container, overriding the server's: DevPolicyDefault,
+// DevPolicyMinimal or a comma separated list of the names of DevNodes, e.g.
+// "null,zero,urandom,pts,fuse". It may only list the nodes of the server's
+// policy and the devices the server allows containers to ask for.
+const DevPolicyProperty = "dev-policy"
+
+const (
+	// DevPolicyDefault gives containers DefaultDevNodes
+	DevPolicyDefault = "default"
+
+	// DevPolicyMinimal gives containers MinimalDevNodes
+	DevPolicyMinimal = "minimal"
+)
+
+// DevNode is a device node, or a filesystem mounted in /dev, which a /dev
+// policy may give a container
+type DevNode struct {
+	// Device is the node and the device cgroup rule allowing it, if any
+	Device *specs.Device
+
+	// RuntimeCreated devices are always created in /dev by the runtime, so
+	// leaving them out of a policy only denies them in the device cgroup
+	RuntimeCreated bool
+
+	// Mount is the filesystem mounted in /dev, if any
+	Mount *specs.Mount
+}
+
+// DevNodes are the nodes /dev policies may list, by name
+var DevNodes = map[string]DevNode{
+	"null":    {Device: charDevice("/dev/null", 1, 3), RuntimeCreated: true},
+	"zero":    {Device: charDevice("/dev/zero", 1, 5), RuntimeCreated: true},
+	"full":    {Device: charDevice("/dev/full", 1, 7), RuntimeCreated: true},
+	"random":  {Device: charDevice("/dev/random", 1, 8), RuntimeCreated: true},
+	"urandom": {Device: charDevice("/dev/urandom", 1, 9), RuntimeCreated: true},
+	"tty":     {Device: charDevice("/dev/tty", 5, 0), RuntimeCreated: true},
+	"fuse":    {Device: charDevice("/dev/fuse", 10, 229)},
+	"shm":     {Mount: &specs.Mount{Type: "tmpfs", Source: "tmpfs", Destination: "/dev/shm"}},
+	"pts": {Mount: &specs.Mount{Type: "devpts", Source: "devpts", Destination: "/dev/pts",
+		Options: []string{"nosuid", "noexec", "newinstance", "ptmxmode=0666", "mode=0620"}}},
+	"mqueue": {Mount: &specs.Mount{Type: "mqueue", Source: "mqueue", Destination: "/dev/mqueue",
+		Options: []string{"nosuid", "noexec", "nodev"}}},
+}
+
+// DefaultDevNodes are the nodes containers have always been given
+var DefaultDevNodes = []string{"null", "zero", "full", "random", "urandom", "tty", "shm", "pts"}
+
+// MinimalDevNodes are the fewest nodes most programs, and processes with a
+// tty, need
+var MinimalDevNodes = []string{"null", "zero", "random", "urandom", "pts"}
+
+// ParseDevPolicy returns the names of the nodes a /dev policy gives
+// containers, or an error naming the first node it does not know
+func ParseDevPolicy(policy string) ([]string, error) {
+	switch strings.TrimSpace(policy) {
+	case DevPolicyDefault:
+		return DefaultDevNodes, nil
+	case DevPolicyMinimal:
+		return MinimalDevNodes, nil
+	}
+
+	var names []string
+	seen := map[string]bool{}
+	for _, name := range strings.Split(policy, ",") {
+		name = strings.TrimSpace(name)
+		if _, ok := DevNodes[name]; !ok {
+			return nil, fmt.Errorf("'%s' is not a known device", name)
+		}
+
+		if !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+
+	return names, nil
+}
+
+// DevPolicy decides which device nodes are created, and which filesystems
+// mounted, in each container's /dev, and allows only those devices in its
+// device cgroup, so that locked-down deployments can drop nodes which others
+// need. Containers, privileged or not, may choose their own policy with the
+// dev-policy property, from the nodes the server allows them. It replaces the
+// base bundle's /dev mounts and device cgroup rules, so must be applied
+// straight after the Base rule.
+type DevPolicy struct {
+	// Default is the policy of containers without the property
+	Default string
+
+	// AllowedDevices are the devices containers may ask for (see Devices),
+	// by path, whose nodes they may list as well as the Default's
+	AllowedDevices map[string]specs.Device
+}
+
+func (r DevPolicy) Apply(bndl *goci.Bndl, spec gardener.DesiredContainerSpec) (*goci.Bndl, error) {
+	names, err := ParseDevPolicy(r.defaultPolicy())
+	if err != nil {
+		return nil, err
+	}
+
+	if property, ok := spec.Properties[DevPolicyProperty]; ok {
+		if names, err = r.parseProperty(property, names); err != nil {
+			return nil, fmt.Errorf("invalid %s property: %s", DevPolicyProperty, err)
+		}
+	}
+
+	managed := map[string]bool{}
+	for _, node := range DevNodes {
+		if node.Mount != nil {
+			managed[node.Mount.Destination] = true
+		}
+		if node.Device != nil {
+			managed[node.Device.Path] = true
+		}
+	}
+
+	var mounts []specs.Mount
+	for _, mount := range bndl.Mounts() {
+		if !managed[mount.Destination] {
+			mounts = append(mounts, mount)
+		}
+	}
+
+	var devices []specs.Device
+	for _, device := range bndl.Spec.Linux.Devices {
+		if !managed[device.Path] {
+			devices = append(devices, device)
+		}
+	}
+
+	access := "rwm"
+	resources := specs.Resources{}
+	if bndl.Resources() != nil {
+		resources = *bndl.Resources()
+	}
+	resources.Devices = []specs.DeviceCgroup{{Allow: false, Access: &access}}
+
+	for _, name := range names {
+		node := DevNodes[name]
+		if node.Mount != nil {
+			mounts = append(mounts, *node.Mount)
+		}
+
+		if node.Device != nil {
+			resources.Devices = append(resources.Devices, deviceCgroupRule(*node.Device))
+			if !node.RuntimeCreated {
+				devices = append(devices, *node.Device)
+			}
+		}
+	}
+
+	newBndl := *bndl.WithResources(&resources)
+	newBndl.Spec.Mounts = mounts
+	newBndl.Spec.Linux.Devices = devices
+	return &newBndl, nil
+}
+
+func (r DevPolicy) defaultPolicy() string {
+	if r.Default == "" {
+		return DevPolicyDefault
+	}
+
+	return r.Default
+}
+
+// parseProperty parses a container's policy, refusing nodes which are
+// neither in the server's policy nor allowed devices
+func (r DevPolicy) parseProperty(property string, defaults []string) ([]string, error) {
+	names, err := ParseDevPolicy(property)
+	if err != nil {
+		return nil, err
+	}
+
+	permitted := map[string]bool{}
+	for _, name := range defaults {
+		permitted[name] = true
+	}
+
+	for _, name := range names {
+		if permitted[name] {
+			continue
+		}
+
+		if device := DevNodes[name].Device; device != nil {
+			if _, ok := r.AllowedDevices[device.Path]; ok {
+				continue
+			}
+		}
+
+		return nil, fmt.Errorf("'%s' is not allowed by the server", name)
+	}
+
+	return names, nil
+}
+
+func charDevice(path string, major, minor int64) *specs.Device {
+	mode := os.FileMode(0666)
+	uid, gid := uint32(0), uint32(0)
+	return &specs.Device{Path: path, Type: 'c', Major: major, Minor: minor, FileMode: &mode, UID: &uid, GID: &gid}
+}
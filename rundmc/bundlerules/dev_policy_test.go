@@ -0,0 +1,132 @@
+package bundlerules_test
+
+import (
+	"fmt"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/opencontainers/specs"
+
+	"github.com/cloudfoundry-incubator/goci"
+	"github.com/cloudfoundry-incubator/guardian/gardener"
+	"github.com/cloudfoundry-incubator/guardian/rundmc/bundlerules"
+)
+
+var _ = Describe("DevPolicy", func() {
+	var (
+		bndl      *goci.Bndl
+		procMount specs.Mount
+	)
+
+	BeforeEach(func() {
+		limit := int64(64)
+		procMount = specs.Mount{Type: "proc", Source: "proc", Destination: "/proc"}
+		bndl = goci.Bundle().
+			WithResources(&specs.Resources{Pids: &specs.Pids{Limit: &limit}}).
+			WithMounts(procMount, *bundlerules.DevNodes["shm"].Mount)
+	})
+
+	destinations := func(b *goci.Bndl) []string {
+		var dsts []string
+		for _, mount := range b.Mounts() {
+			dsts = append(dsts, mount.Destination)
+		}
+		return dsts
+	}
+
+	allowed := func(b *goci.Bndl) []string {
+		var rules []string
+		for _, rule := range b.Resources().Devices {
+			if !rule.Allow {
+				Expect(rule.Type).To(BeNil())
+				rules = append(rules, "deny all")
+				continue
+			}
+			rules = append(rules, fmt.Sprintf("%c %d:%d", *rule.Type, *rule.Major, *rule.Minor))
+		}
+		return rules
+	}
+
+	It("gives containers the default nodes by default", func() {
+		newBndl, err := bundlerules.DevPolicy{}.Apply(bndl, gardener.DesiredContainerSpec{})
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(destinations(newBndl)).To(Equal([]string{"/proc", "/dev/shm", "/dev/pts"}))
+		Expect(allowed(newBndl)).To(Equal([]string{"deny all", "c 1:3", "c 1:5", "c 1:7", "c 1:8", "c 1:9", "c 5:0"}))
+		Expect(newBndl.Spec.Linux.Devices).To(BeEmpty())
+		Expect(*newBndl.Resources().Pids.Limit).To(BeNumerically("==", 64))
+	})
+
+	It("gives containers the server's policy", func() {
+		newBndl, err := bundlerules.DevPolicy{Default: bundlerules.DevPolicyMinimal}.Apply(bndl, gardener.DesiredContainerSpec{})
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(destinations(newBndl)).To(Equal([]string{"/proc", "/dev/pts"}))
+		Expect(allowed(newBndl)).To(Equal([]string{"deny all", "c 1:3", "c 1:5", "c 1:8", "c 1:9"}))
+	})
+
+	It("lets containers choose their own policy from the server's and the allowed devices", func() {
+		policy := bundlerules.DevPolicy{
+			Default:        "null,zero,random,urandom,pts,mqueue",
+			AllowedDevices: map[string]specs.Device{"/dev/fuse": *bundlerules.DevNodes["fuse"].Device},
+		}
+
+		newBndl, err := policy.Apply(bndl, gardener.DesiredContainerSpec{
+			Properties: map[string]string{bundlerules.DevPolicyProperty: "null, urandom,fuse,mqueue"},
+		})
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(destinations(newBndl)).To(Equal([]string{"/proc", "/dev/mqueue"}))
+		Expect(allowed(newBndl)).To(Equal([]string{"deny all", "c 1:3", "c 1:9", "c 10:229"}))
+
+		By("creating the nodes the runtime does not create itself")
+		Expect(newBndl.Spec.Linux.Devices).To(Equal([]specs.Device{*bundlerules.DevNodes["fuse"].Device}))
+	})
+
+	It("refuses policies listing nodes which the server does not allow", func() {
+		for _, privileged := range []bool{false, true} {
+			_, err := bundlerules.DevPolicy{Default: bundlerules.DevPolicyMinimal}.Apply(bndl, gardener.DesiredContainerSpec{
+				Privileged: privileged,
+				Properties: map[string]string{bundlerules.DevPolicyProperty: "null,fuse"},
+			})
+			Expect(err).To(MatchError("invalid dev-policy property: 'fuse' is not allowed by the server"))
+
+			_, err = bundlerules.DevPolicy{Default: bundlerules.DevPolicyMinimal}.Apply(bndl, gardener.DesiredContainerSpec{
+				Privileged: privileged,
+				Properties: map[string]string{bundlerules.DevPolicyProperty: "null,mqueue"},
+			})
+			Expect(err).To(MatchError("invalid dev-policy property: 'mqueue' is not allowed by the server"))
+		}
+	})
+
+	It("refuses policies listing unknown nodes", func() {
+		_, err := bundlerules.DevPolicy{}.Apply(bndl, gardener.DesiredContainerSpec{
+			Properties: map[string]string{bundlerules.DevPolicyProperty: "null,sda"},
+		})
+		Expect(err).To(MatchError("invalid dev-policy property: 'sda' is not a known device"))
+	})
+
+	It("does not modify the original bundle", func() {
+		_, err := bundlerules.DevPolicy{Default: bundlerules.DevPolicyMinimal}.Apply(bndl, gardener.DesiredContainerSpec{})
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(destinations(bndl)).To(Equal([]string{"/proc", "/dev/shm"}))
+		Expect(bndl.Resources().Devices).To(BeEmpty())
+	})
+})
+
+var _ = Describe("ParseDevPolicy", func() {
+	It("understands the named policies", func() {
+		Expect(bundlerules.ParseDevPolicy("default")).To(Equal(bundlerules.DefaultDevNodes))
+		Expect(bundlerules.ParseDevPolicy("minimal")).To(Equal(bundlerules.MinimalDevNodes))
+	})
+
+	It("de-duplicates custom lists", func() {
+		Expect(bundlerules.ParseDevPolicy("null,zero,null")).To(Equal([]string{"null", "zero"}))
+	})
+
+	It("refuses empty lists", func() {
+		_, err := bundlerules.ParseDevPolicy("")
+		Expect(err).To(MatchError("'' is not a known device"))
+	})
+})
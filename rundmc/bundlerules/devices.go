@@ -0,0 +1,66 @@
+package bundlerules
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/cloudfoundry-incubator/goci"
+	"github.com/cloudfoundry-incubator/guardian/gardener"
+	"github.com/opencontainers/specs"
+)
+
+// DevicesProperty lists, separated by commas, the paths of host devices
+// (e.g. /dev/fuse) to create in the container and allow it to use. Only the
+// devices the server allows may be listed.
+const DevicesProperty = "devices"
+
+// Devices creates the devices a container asks for with the devices
+// property in the container, and allows them in its device cgroup, so that
+// containers needing e.g. fuse need not be privileged.
+type Devices struct {
+	// Allowed are the devices containers may ask for, by path
+	Allowed map[string]specs.Device
+}
+
+func (d Devices) Apply(bndl *goci.Bndl, spec gardener.DesiredContainerSpec) (*goci.Bndl, error) {
+	raw := spec.Properties[DevicesProperty]
+	if strings.TrimSpace(raw) == "" {
+		return bndl, nil
+	}
+
+	devices := append([]specs.Device{}, bndl.Spec.Linux.Devices...)
+
+	resources := specs.Resources{}
+	if bndl.Resources() != nil {
+		resources = *bndl.Resources()
+	}
+	resources.Devices = append([]specs.DeviceCgroup{}, resources.Devices...)
+
+	seen := map[string]bool{}
+	for _, path := range strings.Split(raw, ",") {
+		path = filepath.Clean(strings.TrimSpace(path))
+		if seen[path] {
+			continue
+		}
+		seen[path] = true
+
+		device, ok := d.Allowed[path]
+		if !ok {
+			return nil, fmt.Errorf("invalid %s property: '%s' is not an allowed device", DevicesProperty, path)
+		}
+
+		devices = append(devices, device)
+		resources.Devices = append(resources.Devices, deviceCgroupRule(device))
+	}
+
+	newBndl := *bndl.WithResources(&resources)
+	newBndl.Spec.Linux.Devices = devices
+	return &newBndl, nil
+}
+
+func deviceCgroupRule(device specs.Device) specs.DeviceCgroup {
+	deviceType, major, minor := device.Type, device.Major, device.Minor
+	access := "rwm"
+	return specs.DeviceCgroup{Allow: true, Type: &deviceType, Major: &major, Minor: &minor, Access: &access}
+}
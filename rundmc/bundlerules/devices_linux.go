@@ -0,0 +1,42 @@
+package bundlerules
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+
+	"github.com/opencontainers/specs"
+)
+
+// LookupDevice describes the host device at path, so that it can be created
+// with the same type, numbers and mode in containers
+func LookupDevice(path string) (specs.Device, error) {
+	var stat syscall.Stat_t
+	if err := syscall.Stat(path, &stat); err != nil {
+		return specs.Device{}, fmt.Errorf("lookup device: %s", err)
+	}
+
+	var deviceType rune
+	switch stat.Mode & syscall.S_IFMT {
+	case syscall.S_IFCHR:
+		deviceType = 'c'
+	case syscall.S_IFBLK:
+		deviceType = 'b'
+	default:
+		return specs.Device{}, fmt.Errorf("lookup device: '%s' is not a device", path)
+	}
+
+	rdev := uint64(stat.Rdev)
+	mode := os.FileMode(stat.Mode &^ syscall.S_IFMT)
+	uid, gid := uint32(0), uint32(0)
+
+	return specs.Device{
+		Path:     path,
+		Type:     deviceType,
+		Major:    int64((rdev>>8)&0xfff | (rdev>>32)&^0xfff),
+		Minor:    int64(rdev&0xff | (rdev>>12)&^0xff),
+		FileMode: &mode,
+		UID:      &uid,
+		GID:      &gid,
+	}, nil
+}
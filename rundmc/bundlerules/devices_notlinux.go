@@ -0,0 +1,13 @@
+// +build !linux
+
+package bundlerules
+
+import (
+	"errors"
+
+	"github.com/opencontainers/specs"
+)
+
+func LookupDevice(path string) (specs.Device, error) {
+	return specs.Device{}, errors.New("devices are not supported on this platform")
+}
@@ -0,0 +1,69 @@
+package bundlerules_test
+
+import (
+	"os"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/opencontainers/specs"
+
+	"github.com/cloudfoundry-incubator/goci"
+	"github.com/cloudfoundry-incubator/guardian/gardener"
+	"github.com/cloudfoundry-incubator/guardian/rundmc/bundlerules"
+)
+
+var _ = Describe("Devices", func() {
+	var (
+		bndl *goci.Bndl
+		rule bundlerules.Devices
+		fuse specs.Device
+	)
+
+	BeforeEach(func() {
+		limit := int64(64)
+		bndl = goci.Bundle().WithResources(&specs.Resources{Pids: &specs.Pids{Limit: &limit}})
+
+		mode := os.FileMode(0666)
+		fuse = specs.Device{Path: "/dev/fuse", Type: 'c', Major: 10, Minor: 229, FileMode: &mode}
+		rule = bundlerules.Devices{Allowed: map[string]specs.Device{"/dev/fuse": fuse}}
+	})
+
+	It("creates and allows the devices the container asks for", func() {
+		newBndl, err := rule.Apply(bndl, gardener.DesiredContainerSpec{
+			Properties: map[string]string{bundlerules.DevicesProperty: "/dev/fuse"},
+		})
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(newBndl.Spec.Linux.Devices).To(Equal([]specs.Device{fuse}))
+
+		deviceType, major, minor, access := 'c', int64(10), int64(229), "rwm"
+		Expect(newBndl.Resources().Devices).To(Equal([]specs.DeviceCgroup{
+			{Allow: true, Type: &deviceType, Major: &major, Minor: &minor, Access: &access},
+		}))
+		Expect(*newBndl.Resources().Pids.Limit).To(BeNumerically("==", 64))
+	})
+
+	It("leaves containers which ask for no devices alone", func() {
+		newBndl, err := rule.Apply(bndl, gardener.DesiredContainerSpec{})
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(newBndl).To(Equal(bndl))
+	})
+
+	It("refuses devices the server does not allow", func() {
+		_, err := rule.Apply(bndl, gardener.DesiredContainerSpec{
+			Properties: map[string]string{bundlerules.DevicesProperty: "/dev/fuse, /dev/sda"},
+		})
+		Expect(err).To(MatchError("invalid devices property: '/dev/sda' is not an allowed device"))
+	})
+
+	It("does not modify the original bundle", func() {
+		_, err := rule.Apply(bndl, gardener.DesiredContainerSpec{
+			Properties: map[string]string{bundlerules.DevicesProperty: "/dev/fuse"},
+		})
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(bndl.Spec.Linux.Devices).To(BeEmpty())
+		Expect(bndl.Resources().Devices).To(BeEmpty())
+	})
+})
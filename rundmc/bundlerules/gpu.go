@@ -0,0 +1,191 @@
+package bundlerules
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/cloudfoundry-incubator/goci"
+	"github.com/cloudfoundry-incubator/guardian/gardener"
+	"github.com/cloudfoundry/gunk/command_runner"
+	"github.com/opencontainers/specs"
+)
+
+// GPUProperty is the container property which, when "true", gives the
+// container the host's NVIDIA GPUs
+const GPUProperty = "gpu"
+
+const (
+	// NvidiaLibraryPath is where the driver libraries are mounted in
+	// containers given GPUs, and is added to their LD_LIBRARY_PATH
+	NvidiaLibraryPath = "/usr/local/nvidia/lib64"
+
+	// NvidiaBinaryPath is where the driver's utilities, e.g. nvidia-smi, are
+	// mounted in containers given GPUs, so that they are on the default PATH
+	NvidiaBinaryPath = "/usr/bin"
+)
+
+// NvidiaGPUEnv is added to the environment of containers given GPUs. The
+// variables are those CUDA images, and nvidia-container-runtime-hook, expect.
+var NvidiaGPUEnv = []string{
+	"NVIDIA_VISIBLE_DEVICES=all",
+	"NVIDIA_DRIVER_CAPABILITIES=compute,utility",
+}
+
+// nvidiaBinaries are the driver utilities mounted in to containers, if the
+// host has them
+var nvidiaBinaries = []string{"nvidia-smi", "nvidia-debugdump", "nvidia-persistenced", "nvidia-cuda-mps-control", "nvidia-cuda-mps-server"}
+
+// NvidiaGPU gives containers which ask for them with the gpu property the
+// host's NVIDIA GPUs, so that ML workloads need not be privileged or
+// hand-roll their own device and driver mounts. If Hook is set it is run as a
+// prestart hook (e.g. nvidia-container-runtime-hook, which uses
+// nvidia-container-cli) to inject the GPUs; otherwise the devices, libraries
+// and binaries found by DetectNvidiaGPU are injected directly. It must be
+// applied after the rules which set the hooks, resources and init process.
+type NvidiaGPU struct {
+	Hook string
+
+	Devices   []specs.Device
+	Libraries []string
+	Binaries  []string
+}
+
+func (n NvidiaGPU) Apply(bndl *goci.Bndl, spec gardener.DesiredContainerSpec) (*goci.Bndl, error) {
+	switch spec.Properties[GPUProperty] {
+	case "", "false":
+		return bndl, nil
+	case "true":
+	default:
+		return nil, fmt.Errorf("invalid %s property: '%s'", GPUProperty, spec.Properties[GPUProperty])
+	}
+
+	process := bndl.Spec.Spec.Process
+	process.Env = append(append([]string{}, process.Env...), NvidiaGPUEnv...)
+
+	if n.Hook != "" {
+		hooks := append([]specs.Hook{}, bndl.Spec.Hooks.Prestart...)
+		hooks = append(hooks, specs.Hook{
+			Path: n.Hook,
+			Args: []string{n.Hook, "prestart"},
+			Env:  []string{"PATH=" + os.Getenv("PATH")},
+		})
+
+		return bndl.WithPrestartHooks(hooks...).WithProcess(process), nil
+	}
+
+	if len(n.Devices) == 0 {
+		return nil, errors.New("gpu: no NVIDIA GPUs are available")
+	}
+
+	process.Env = append(process.Env, "LD_LIBRARY_PATH="+NvidiaLibraryPath)
+
+	var mounts []specs.Mount
+	for _, library := range n.Libraries {
+		mounts = append(mounts, readOnlyBindMount(library, filepath.Join(NvidiaLibraryPath, filepath.Base(library))))
+	}
+
+	for _, binary := range n.Binaries {
+		mounts = append(mounts, readOnlyBindMount(binary, filepath.Join(NvidiaBinaryPath, filepath.Base(binary))))
+	}
+
+	resources := specs.Resources{}
+	if bndl.Resources() != nil {
+		resources = *bndl.Resources()
+	}
+	resources.Devices = append([]specs.DeviceCgroup{}, resources.Devices...)
+	for _, device := range n.Devices {
+		resources.Devices = append(resources.Devices, deviceCgroupRule(device))
+	}
+
+	newBndl := *bndl.WithMounts(mounts...).WithResources(&resources).WithProcess(process)
+	newBndl.Spec.Linux.Devices = append(append([]specs.Device{}, bndl.Spec.Linux.Devices...), n.Devices...)
+	return &newBndl, nil
+}
+
+func readOnlyBindMount(src, dst string) specs.Mount {
+	return specs.Mount{Type: "bind", Source: src, Destination: dst, Options: []string{"bind", "ro"}}
+}
+
+// DetectNvidiaGPU finds the host's NVIDIA devices in devPath (usually /dev),
+// the driver libraries known to ldconfig and the driver's utilities on the
+// PATH, for NvidiaGPU to inject in to containers.
+func DetectNvidiaGPU(devPath string, runner command_runner.CommandRunner) (NvidiaGPU, error) {
+	var gpu NvidiaGPU
+
+	paths, err := filepath.Glob(filepath.Join(devPath, "nvidia*"))
+	if err != nil {
+		return gpu, fmt.Errorf("detect gpus: %s", err)
+	}
+
+	for _, path := range paths {
+		device, err := LookupDevice(path)
+		if err != nil {
+			// e.g. the nvidia-caps directory
+			continue
+		}
+
+		device.Path = filepath.Join("/dev", filepath.Base(path))
+		gpu.Devices = append(gpu.Devices, device)
+	}
+
+	if len(gpu.Devices) == 0 {
+		return gpu, errors.New("detect gpus: no NVIDIA devices found")
+	}
+
+	libraries, err := nvidiaLibraries(runner)
+	if err != nil {
+		return gpu, fmt.Errorf("detect gpus: %s", err)
+	}
+	gpu.Libraries = libraries
+
+	for _, binary := range nvidiaBinaries {
+		if path, err := exec.LookPath(binary); err == nil {
+			gpu.Binaries = append(gpu.Binaries, path)
+		}
+	}
+
+	return gpu, nil
+}
+
+// nvidiaLibraries lists the native (not 32-bit compatibility) NVIDIA and
+// CUDA driver libraries in ldconfig's cache, by their sonames, e.g.
+//
+//	libcuda.so.1 (libc6,x86-64) => /usr/lib/x86_64-linux-gnu/libcuda.so.1
+func nvidiaLibraries(runner command_runner.CommandRunner) ([]string, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command("ldconfig", "-p")
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := runner.Run(cmd); err != nil {
+		return nil, fmt.Errorf("ldconfig -p: %s: %s", err, strings.TrimSpace(stderr.String()))
+	}
+
+	var libraries []string
+	seen := map[string]bool{}
+	for _, line := range strings.Split(stdout.String(), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 4 || fields[len(fields)-2] != "=>" {
+			continue
+		}
+
+		name, arch, path := fields[0], fields[1], fields[len(fields)-1]
+		if !strings.HasPrefix(name, "libnvidia-") && !strings.HasPrefix(name, "libcuda.") {
+			continue
+		}
+
+		if arch == "(libc6)" || seen[name] {
+			continue
+		}
+
+		seen[name] = true
+		libraries = append(libraries, path)
+	}
+
+	return libraries, nil
+}
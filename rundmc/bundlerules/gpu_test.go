@@ -0,0 +1,131 @@
+package bundlerules_test
+
+import (
+	"io/ioutil"
+	"os"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/opencontainers/specs"
+
+	"github.com/cloudfoundry-incubator/goci"
+	"github.com/cloudfoundry-incubator/guardian/gardener"
+	"github.com/cloudfoundry-incubator/guardian/rundmc/bundlerules"
+	"github.com/cloudfoundry/gunk/command_runner/fake_command_runner"
+)
+
+var _ = Describe("NvidiaGPU", func() {
+	var (
+		bndl    *goci.Bndl
+		rule    bundlerules.NvidiaGPU
+		spec    gardener.DesiredContainerSpec
+		nvidia0 specs.Device
+	)
+
+	BeforeEach(func() {
+		bndl = goci.Bundle().
+			WithProcess(specs.Process{Env: []string{"FOO=bar"}}).
+			WithPrestartHooks(specs.Hook{Path: "/network-hook"})
+
+		nvidia0 = specs.Device{Path: "/dev/nvidia0", Type: 'c', Major: 195, Minor: 0}
+		rule = bundlerules.NvidiaGPU{
+			Devices:   []specs.Device{nvidia0},
+			Libraries: []string{"/usr/lib/x86_64-linux-gnu/libcuda.so.1"},
+			Binaries:  []string{"/usr/bin/nvidia-smi"},
+		}
+
+		spec = gardener.DesiredContainerSpec{
+			Properties: map[string]string{bundlerules.GPUProperty: "true"},
+		}
+	})
+
+	It("leaves containers which do not ask for GPUs alone", func() {
+		newBndl, err := rule.Apply(bndl, gardener.DesiredContainerSpec{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(newBndl).To(Equal(bndl))
+	})
+
+	It("refuses gpu properties which are not true or false", func() {
+		_, err := rule.Apply(bndl, gardener.DesiredContainerSpec{
+			Properties: map[string]string{bundlerules.GPUProperty: "lots"},
+		})
+		Expect(err).To(MatchError("invalid gpu property: 'lots'"))
+	})
+
+	It("creates and allows the GPU devices", func() {
+		newBndl, err := rule.Apply(bndl, spec)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(newBndl.Spec.Linux.Devices).To(Equal([]specs.Device{nvidia0}))
+
+		deviceType, major, minor, access := 'c', int64(195), int64(0), "rwm"
+		Expect(newBndl.Resources().Devices).To(Equal([]specs.DeviceCgroup{
+			{Allow: true, Type: &deviceType, Major: &major, Minor: &minor, Access: &access},
+		}))
+	})
+
+	It("mounts the driver's libraries and binaries read-only", func() {
+		newBndl, err := rule.Apply(bndl, spec)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(newBndl.Mounts()).To(ConsistOf(
+			specs.Mount{Type: "bind", Source: "/usr/lib/x86_64-linux-gnu/libcuda.so.1", Destination: "/usr/local/nvidia/lib64/libcuda.so.1", Options: []string{"bind", "ro"}},
+			specs.Mount{Type: "bind", Source: "/usr/bin/nvidia-smi", Destination: "/usr/bin/nvidia-smi", Options: []string{"bind", "ro"}},
+		))
+	})
+
+	It("adds the driver's environment", func() {
+		newBndl, err := rule.Apply(bndl, spec)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(newBndl.Spec.Spec.Process.Env).To(Equal([]string{
+			"FOO=bar",
+			"NVIDIA_VISIBLE_DEVICES=all",
+			"NVIDIA_DRIVER_CAPABILITIES=compute,utility",
+			"LD_LIBRARY_PATH=/usr/local/nvidia/lib64",
+		}))
+		Expect(bndl.Spec.Spec.Process.Env).To(Equal([]string{"FOO=bar"}))
+	})
+
+	Context("when the host has no GPUs", func() {
+		It("refuses containers which ask for them", func() {
+			_, err := bundlerules.NvidiaGPU{}.Apply(bndl, spec)
+			Expect(err).To(MatchError("gpu: no NVIDIA GPUs are available"))
+		})
+	})
+
+	Context("when a hook injects the GPUs", func() {
+		BeforeEach(func() {
+			rule = bundlerules.NvidiaGPU{Hook: "/usr/bin/nvidia-container-runtime-hook"}
+		})
+
+		It("adds the hook after the existing prestart hooks", func() {
+			newBndl, err := rule.Apply(bndl, spec)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(newBndl.Spec.Hooks.Prestart).To(HaveLen(2))
+			Expect(newBndl.Spec.Hooks.Prestart[0].Path).To(Equal("/network-hook"))
+			Expect(newBndl.Spec.Hooks.Prestart[1].Path).To(Equal("/usr/bin/nvidia-container-runtime-hook"))
+			Expect(newBndl.Spec.Hooks.Prestart[1].Args).To(Equal([]string{"/usr/bin/nvidia-container-runtime-hook", "prestart"}))
+		})
+
+		It("tells the hook which GPUs and capabilities to inject", func() {
+			newBndl, err := rule.Apply(bndl, spec)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(newBndl.Spec.Spec.Process.Env).To(ContainElement("NVIDIA_VISIBLE_DEVICES=all"))
+			Expect(newBndl.Spec.Linux.Devices).To(BeEmpty())
+		})
+	})
+
+	Describe("DetectNvidiaGPU", func() {
+		It("fails when there are no NVIDIA devices", func() {
+			devPath, err := ioutil.TempDir("", "dev")
+			Expect(err).NotTo(HaveOccurred())
+			defer os.RemoveAll(devPath)
+
+			_, err = bundlerules.DetectNvidiaGPU(devPath, fake_command_runner.New())
+			Expect(err).To(MatchError("detect gpus: no NVIDIA devices found"))
+		})
+	})
+})
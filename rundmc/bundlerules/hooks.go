@@ -9,23 +9,48 @@ import (
 	"github.com/opencontainers/specs"
 )
 
+// Hooks adds the container's network hooks to its bundle, along with the
+// operator's Prestart and Poststop hooks, the paths of executables run around
+// every container. The runtime passes all of them the container's state on
+// stdin, as the OCI runtime spec says. The operator's prestart hooks run after
+// the network is set up, and its poststop hooks before it is torn down.
 type Hooks struct {
 	LogFilePattern string
+
+	Prestart []string
+	Poststop []string
 }
 
-func (r Hooks) Apply(bndl *goci.Bndl, spec gardener.DesiredContainerSpec) *goci.Bndl {
+func (r Hooks) Apply(bndl *goci.Bndl, spec gardener.DesiredContainerSpec) (*goci.Bndl, error) {
 	env := []string{fmt.Sprintf(
 		"GARDEN_LOG_FILE="+r.LogFilePattern, spec.Handle),
 		"PATH=" + os.Getenv("PATH"),
 	}
 
-	return bndl.WithPrestartHooks(specs.Hook{
+	prestart := append([]specs.Hook{{
 		Env:  env,
 		Path: spec.NetworkHooks.Prestart.Path,
 		Args: spec.NetworkHooks.Prestart.Args,
-	}).WithPoststopHooks(specs.Hook{
+	}}, operatorHooks(r.Prestart)...)
+
+	poststop := append(operatorHooks(r.Poststop), specs.Hook{
 		Env:  env,
 		Path: spec.NetworkHooks.Poststop.Path,
 		Args: spec.NetworkHooks.Poststop.Args,
 	})
+
+	return bndl.WithPrestartHooks(prestart...).WithPoststopHooks(poststop...), nil
+}
+
+func operatorHooks(paths []string) []specs.Hook {
+	var hooks []specs.Hook
+	for _, path := range paths {
+		hooks = append(hooks, specs.Hook{
+			Env:  []string{"PATH=" + os.Getenv("PATH")},
+			Path: path,
+			Args: []string{path},
+		})
+	}
+
+	return hooks
 }
@@ -17,9 +17,10 @@ var _ = Describe("Hooks", func() {
 	DescribeTable("the envirionment should contain", func(envVar string) {
 		rule := bundlerules.Hooks{LogFilePattern: "/path/to/%s.log"}
 
-		newBndl := rule.Apply(goci.Bundle(), gardener.DesiredContainerSpec{
+		newBndl, err := rule.Apply(goci.Bundle(), gardener.DesiredContainerSpec{
 			Handle: "fred",
 		})
+		Expect(err).NotTo(HaveOccurred())
 
 		Expect(newBndl.PrestartHooks()[0].Env).To(
 			ContainElement(envVar),
@@ -30,7 +31,7 @@ var _ = Describe("Hooks", func() {
 	)
 
 	It("adds the prestart and poststop hooks of the passed bundle", func() {
-		newBndl := bundlerules.Hooks{}.Apply(goci.Bundle(), gardener.DesiredContainerSpec{
+		newBndl, err := bundlerules.Hooks{}.Apply(goci.Bundle(), gardener.DesiredContainerSpec{
 			NetworkHooks: gardener.Hooks{
 				Prestart: gardener.Hook{
 					Path: "/path/to/bananas/network",
@@ -42,6 +43,7 @@ var _ = Describe("Hooks", func() {
 				},
 			},
 		})
+		Expect(err).NotTo(HaveOccurred())
 
 		Expect(pathAndArgsOf(newBndl.PrestartHooks())).To(ContainElement(PathAndArgs{
 			Path: "/path/to/bananas/network",
@@ -53,6 +55,43 @@ var _ = Describe("Hooks", func() {
 			Args: []string{"arg", "barg"},
 		}))
 	})
+
+	Context("when the operator has hooks", func() {
+		var newBndl *goci.Bndl
+
+		BeforeEach(func() {
+			var err error
+			newBndl, err = bundlerules.Hooks{
+				Prestart: []string{"/path/to/register", "/path/to/configure-nic"},
+				Poststop: []string{"/path/to/deregister"},
+			}.Apply(goci.Bundle(), gardener.DesiredContainerSpec{
+				NetworkHooks: gardener.Hooks{
+					Prestart: gardener.Hook{Path: "/path/to/network", Args: []string{"up"}},
+					Poststop: gardener.Hook{Path: "/path/to/network", Args: []string{"down"}},
+				},
+			})
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("runs its prestart hooks after the network is set up", func() {
+			Expect(pathAndArgsOf(newBndl.PrestartHooks())).To(Equal([]PathAndArgs{
+				{Path: "/path/to/network", Args: []string{"up"}},
+				{Path: "/path/to/register", Args: []string{"/path/to/register"}},
+				{Path: "/path/to/configure-nic", Args: []string{"/path/to/configure-nic"}},
+			}))
+		})
+
+		It("runs its poststop hooks before the network is torn down", func() {
+			Expect(pathAndArgsOf(newBndl.PoststopHooks())).To(Equal([]PathAndArgs{
+				{Path: "/path/to/deregister", Args: []string{"/path/to/deregister"}},
+				{Path: "/path/to/network", Args: []string{"down"}},
+			}))
+		})
+
+		It("gives them a sensible PATH", func() {
+			Expect(newBndl.PrestartHooks()[1].Env).To(ConsistOf("PATH=" + os.Getenv("PATH")))
+		})
+	})
 })
 
 func pathAndArgsOf(a []specs.Hook) (b []PathAndArgs) {
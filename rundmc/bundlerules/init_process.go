@@ -10,8 +10,8 @@ type InitProcess struct {
 	Process specs.Process
 }
 
-func (r InitProcess) Apply(bndl *goci.Bndl, spec gardener.DesiredContainerSpec) *goci.Bndl {
+func (r InitProcess) Apply(bndl *goci.Bndl, spec gardener.DesiredContainerSpec) (*goci.Bndl, error) {
 	r.Process.Env = append(r.Process.Env, spec.Env...)
 
-	return bndl.WithProcess(r.Process)
+	return bndl.WithProcess(r.Process), nil
 }
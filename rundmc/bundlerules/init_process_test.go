@@ -30,9 +30,11 @@ var _ = Describe("InitProcessRule", func() {
 			Process: process,
 		}
 
-		newBndl = rule.Apply(goci.Bundle(), gardener.DesiredContainerSpec{
+		var err error
+		newBndl, err = rule.Apply(goci.Bundle(), gardener.DesiredContainerSpec{
 			Env: env,
 		})
+		Expect(err).NotTo(HaveOccurred())
 	})
 
 	It("adds the injected init process in the bundle spec", func() {
@@ -59,9 +61,10 @@ var _ = Describe("InitProcessRule", func() {
 					"FRUIT=banana",
 					"TERM=xterm",
 				}
-				newNewBndl := rule.Apply(goci.Bundle(), gardener.DesiredContainerSpec{
+				newNewBndl, err := rule.Apply(goci.Bundle(), gardener.DesiredContainerSpec{
 					Env: newEnv,
 				})
+				Expect(err).NotTo(HaveOccurred())
 
 				Expect(newNewBndl.Spec.Process.Env).To(Equal([]string{
 					"ENV_CONTAINER=1", "FRUIT=banana", "TERM=xterm",
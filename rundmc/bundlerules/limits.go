@@ -1,15 +1,85 @@
 package bundlerules
 
 import (
+	"errors"
+
 	"github.com/cloudfoundry-incubator/goci"
 	"github.com/cloudfoundry-incubator/guardian/gardener"
+	"github.com/cloudfoundry-incubator/guardian/sysinfo"
 	"github.com/opencontainers/specs"
 )
 
+// Limits sets the container's memory limit, CPU shares, CPU quota and
+// cpuset. When swap accounting is available the memory limit also covers
+// swap, so that containers cannot exceed it by swapping.
 type Limits struct {
+	Capabilities *sysinfo.Capabilities
+}
+
+func (l Limits) Apply(bndl *goci.Bndl, spec gardener.DesiredContainerSpec) (*goci.Bndl, error) {
+	bndl, err := l.applyMemory(bndl, spec)
+	if err != nil {
+		return nil, err
+	}
+
+	return l.applyCPU(bndl, spec)
 }
 
-func (l Limits) Apply(bndl *goci.Bndl, spec gardener.DesiredContainerSpec) *goci.Bndl {
+func (l Limits) applyMemory(bndl *goci.Bndl, spec gardener.DesiredContainerSpec) (*goci.Bndl, error) {
 	limit := uint64(spec.Limits.Memory.LimitInBytes)
-	return bndl.WithMemoryLimit(specs.Memory{Limit: &limit})
+	if limit == 0 {
+		return bndl.WithMemoryLimit(specs.Memory{Limit: &limit}), nil
+	}
+
+	if !l.Capabilities.Has(sysinfo.CapabilityMemoryLimits) {
+		return nil, errors.New("memory limits are not supported: the memory cgroup is not enabled")
+	}
+
+	memory := specs.Memory{Limit: &limit}
+	if l.Capabilities.Has(sysinfo.CapabilitySwapLimits) {
+		memory.Swap = &limit
+	}
+
+	return bndl.WithMemoryLimit(memory), nil
+}
+
+func (l Limits) applyCPU(bndl *goci.Bndl, spec gardener.DesiredContainerSpec) (*goci.Bndl, error) {
+	shares := spec.Limits.CPU.LimitInShares
+	if shares == 0 && spec.CPUQuota.Quota == 0 && spec.CPUSet == (gardener.CPUSet{}) {
+		return bndl, nil
+	}
+
+	cpu := &specs.CPU{}
+	if shares != 0 {
+		cpu.Shares = &shares
+	}
+
+	if spec.CPUQuota.Quota != 0 {
+		if !l.Capabilities.Has(sysinfo.CapabilityCPUQuota) {
+			return nil, errors.New("cpu quotas are not supported: CFS bandwidth control is not available")
+		}
+
+		quota := uint64(spec.CPUQuota.Quota)
+		period := spec.CPUQuota.Period
+		cpu.Quota = &quota
+		cpu.Period = &period
+	}
+
+	if spec.CPUSet.CPUs != "" {
+		cpus := spec.CPUSet.CPUs
+		cpu.Cpus = &cpus
+	}
+
+	if spec.CPUSet.Mems != "" {
+		mems := spec.CPUSet.Mems
+		cpu.Mems = &mems
+	}
+
+	resources := specs.Resources{}
+	if bndl.Resources() != nil {
+		resources = *bndl.Resources()
+	}
+
+	resources.CPU = cpu
+	return bndl.WithResources(&resources), nil
 }
@@ -9,15 +9,17 @@ import (
 	"github.com/cloudfoundry-incubator/goci"
 	"github.com/cloudfoundry-incubator/guardian/gardener"
 	"github.com/cloudfoundry-incubator/guardian/rundmc/bundlerules"
+	"github.com/cloudfoundry-incubator/guardian/sysinfo"
 )
 
 var _ = Describe("LimitsRule", func() {
 	It("sets the correct memory limit in bundle resources", func() {
-		newBndl := bundlerules.Limits{}.Apply(goci.Bundle(), gardener.DesiredContainerSpec{
+		newBndl, err := bundlerules.Limits{}.Apply(goci.Bundle(), gardener.DesiredContainerSpec{
 			Limits: garden.Limits{
 				Memory: garden.MemoryLimits{LimitInBytes: 4096},
 			},
 		})
+		Expect(err).NotTo(HaveOccurred())
 
 		Expect(*(newBndl.Resources().Memory.Limit)).To(BeNumerically("==", 4096))
 	})
@@ -30,13 +32,133 @@ var _ = Describe("LimitsRule", func() {
 			},
 		)
 
-		newBndl := bundlerules.Limits{}.Apply(bndl, gardener.DesiredContainerSpec{
+		newBndl, err := bundlerules.Limits{}.Apply(bndl, gardener.DesiredContainerSpec{
 			Limits: garden.Limits{
 				Memory: garden.MemoryLimits{LimitInBytes: 4096},
 			},
 		})
+		Expect(err).NotTo(HaveOccurred())
 
 		Expect(*(newBndl.Resources().Memory.Limit)).To(BeNumerically("==", 4096))
 		Expect(newBndl.Resources().Devices).To(Equal(bndl.Resources().Devices))
 	})
+
+	It("limits swap to the memory limit", func() {
+		newBndl, err := bundlerules.Limits{}.Apply(goci.Bundle(), gardener.DesiredContainerSpec{
+			Limits: garden.Limits{
+				Memory: garden.MemoryLimits{LimitInBytes: 4096},
+			},
+		})
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(*(newBndl.Resources().Memory.Swap)).To(BeNumerically("==", 4096))
+	})
+
+	Context("when swap accounting is not available", func() {
+		It("only limits memory", func() {
+			capabilities := &sysinfo.Capabilities{}
+			capabilities.Set([]sysinfo.Capability{{Name: sysinfo.CapabilitySwapLimits}})
+
+			newBndl, err := bundlerules.Limits{Capabilities: capabilities}.Apply(goci.Bundle(), gardener.DesiredContainerSpec{
+				Limits: garden.Limits{
+					Memory: garden.MemoryLimits{LimitInBytes: 4096},
+				},
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(*(newBndl.Resources().Memory.Limit)).To(BeNumerically("==", 4096))
+			Expect(newBndl.Resources().Memory.Swap).To(BeNil())
+		})
+	})
+
+	Context("when the memory cgroup is not available", func() {
+		var capabilities *sysinfo.Capabilities
+
+		BeforeEach(func() {
+			capabilities = &sysinfo.Capabilities{}
+			capabilities.Set([]sysinfo.Capability{{Name: sysinfo.CapabilityMemoryLimits}})
+		})
+
+		It("rejects containers with a memory limit", func() {
+			_, err := bundlerules.Limits{Capabilities: capabilities}.Apply(goci.Bundle(), gardener.DesiredContainerSpec{
+				Limits: garden.Limits{
+					Memory: garden.MemoryLimits{LimitInBytes: 4096},
+				},
+			})
+			Expect(err).To(MatchError("memory limits are not supported: the memory cgroup is not enabled"))
+		})
+
+		It("accepts containers without a memory limit", func() {
+			_, err := bundlerules.Limits{Capabilities: capabilities}.Apply(goci.Bundle(), gardener.DesiredContainerSpec{})
+			Expect(err).NotTo(HaveOccurred())
+		})
+	})
+
+	Describe("CPU", func() {
+		It("sets the cpu shares", func() {
+			newBndl, err := bundlerules.Limits{}.Apply(goci.Bundle(), gardener.DesiredContainerSpec{
+				Limits: garden.Limits{
+					CPU: garden.CPULimits{LimitInShares: 512},
+				},
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(*(newBndl.Resources().CPU.Shares)).To(BeNumerically("==", 512))
+			Expect(newBndl.Resources().CPU.Quota).To(BeNil())
+		})
+
+		It("sets the cpu quota and period", func() {
+			newBndl, err := bundlerules.Limits{}.Apply(goci.Bundle(), gardener.DesiredContainerSpec{
+				CPUQuota: gardener.CPUQuota{Quota: 150000, Period: 100000},
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(*(newBndl.Resources().CPU.Quota)).To(BeNumerically("==", 150000))
+			Expect(*(newBndl.Resources().CPU.Period)).To(BeNumerically("==", 100000))
+			Expect(newBndl.Resources().CPU.Shares).To(BeNil())
+		})
+
+		It("pins the container to its cpuset", func() {
+			newBndl, err := bundlerules.Limits{}.Apply(goci.Bundle(), gardener.DesiredContainerSpec{
+				CPUSet: gardener.CPUSet{CPUs: "2-3", Mems: "1"},
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(*(newBndl.Resources().CPU.Cpus)).To(Equal("2-3"))
+			Expect(*(newBndl.Resources().CPU.Mems)).To(Equal("1"))
+			Expect(newBndl.Resources().CPU.Shares).To(BeNil())
+		})
+
+		It("does not clobber the memory limit", func() {
+			newBndl, err := bundlerules.Limits{}.Apply(goci.Bundle(), gardener.DesiredContainerSpec{
+				Limits: garden.Limits{
+					CPU:    garden.CPULimits{LimitInShares: 512},
+					Memory: garden.MemoryLimits{LimitInBytes: 4096},
+				},
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(*(newBndl.Resources().Memory.Limit)).To(BeNumerically("==", 4096))
+			Expect(*(newBndl.Resources().CPU.Shares)).To(BeNumerically("==", 512))
+		})
+
+		It("does not set any cpu limits by default", func() {
+			newBndl, err := bundlerules.Limits{}.Apply(goci.Bundle(), gardener.DesiredContainerSpec{})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(newBndl.Resources().CPU).To(BeNil())
+		})
+
+		Context("when CFS bandwidth control is not available", func() {
+			It("rejects containers with a cpu quota", func() {
+				capabilities := &sysinfo.Capabilities{}
+				capabilities.Set([]sysinfo.Capability{{Name: sysinfo.CapabilityCPUQuota}})
+
+				_, err := bundlerules.Limits{Capabilities: capabilities}.Apply(goci.Bundle(), gardener.DesiredContainerSpec{
+					CPUQuota: gardener.CPUQuota{Quota: 50000, Period: 100000},
+				})
+				Expect(err).To(MatchError("cpu quotas are not supported: CFS bandwidth control is not available"))
+			})
+		})
+	})
 })
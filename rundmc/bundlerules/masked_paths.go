@@ -0,0 +1,93 @@
+package bundlerules
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/cloudfoundry-incubator/goci"
+	"github.com/cloudfoundry-incubator/guardian/gardener"
+)
+
+// MaskedPathsProperty lists, separated by commas, paths in the container
+// which are masked in addition to the server's
+const MaskedPathsProperty = "masked-paths"
+
+// ReadonlyPathsProperty lists, separated by commas, paths in the container
+// which are made read-only in addition to the server's
+const ReadonlyPathsProperty = "readonly-paths"
+
+// DefaultMaskedPaths are masked in every container unless the server is
+// given its own list: they leak information about the host's kernel, or
+// other containers, which containers have no need of
+var DefaultMaskedPaths = []string{
+	"/proc/kcore",
+	"/proc/latency_stats",
+	"/proc/timer_list",
+	"/proc/timer_stats",
+	"/proc/sched_debug",
+	"/sys/firmware",
+}
+
+// DefaultReadonlyPaths are read-only in every container unless the server is
+// given its own list: writing to them would change the host's kernel
+var DefaultReadonlyPaths = []string{
+	"/proc/asound",
+	"/proc/bus",
+	"/proc/fs",
+	"/proc/irq",
+	"/proc/sys",
+	"/proc/sysrq-trigger",
+}
+
+// MaskedPaths sets the paths runc masks (by mounting over them) and makes
+// read-only in the container. Containers may add paths of their own with the
+// masked-paths and readonly-paths properties, but not remove the server's.
+type MaskedPaths struct {
+	Masked   []string
+	Readonly []string
+}
+
+func (m MaskedPaths) Apply(bndl *goci.Bndl, spec gardener.DesiredContainerSpec) (*goci.Bndl, error) {
+	masked, err := pathsWithProperty(m.Masked, spec.Properties, MaskedPathsProperty)
+	if err != nil {
+		return nil, err
+	}
+
+	readonly, err := pathsWithProperty(m.Readonly, spec.Properties, ReadonlyPathsProperty)
+	if err != nil {
+		return nil, err
+	}
+
+	newBndl := *bndl
+	newBndl.Spec.Linux.MaskedPaths = masked
+	newBndl.Spec.Linux.ReadonlyPaths = readonly
+	return &newBndl, nil
+}
+
+func pathsWithProperty(defaults []string, properties map[string]string, property string) ([]string, error) {
+	paths := append([]string{}, defaults...)
+	seen := map[string]bool{}
+	for _, path := range paths {
+		seen[path] = true
+	}
+
+	for _, path := range strings.Split(properties[property], ",") {
+		path = strings.TrimSpace(path)
+		if path == "" {
+			continue
+		}
+
+		if !filepath.IsAbs(path) {
+			return nil, fmt.Errorf("invalid %s property: '%s' is not an absolute path", property, path)
+		}
+
+		path = filepath.Clean(path)
+		if !seen[path] {
+			seen[path] = true
+			paths = append(paths, path)
+		}
+	}
+
+	return paths, nil
+}
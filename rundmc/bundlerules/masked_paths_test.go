@@ -0,0 +1,60 @@
+package bundlerules_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/cloudfoundry-incubator/goci"
+	"github.com/cloudfoundry-incubator/guardian/gardener"
+	"github.com/cloudfoundry-incubator/guardian/rundmc/bundlerules"
+)
+
+var _ = Describe("MaskedPaths", func() {
+	var (
+		bndl *goci.Bndl
+		rule bundlerules.MaskedPaths
+	)
+
+	BeforeEach(func() {
+		bndl = goci.Bundle()
+		rule = bundlerules.MaskedPaths{
+			Masked:   []string{"/proc/kcore"},
+			Readonly: []string{"/proc/sys"},
+		}
+	})
+
+	It("masks and makes read-only the server's paths", func() {
+		newBndl, err := rule.Apply(bndl, gardener.DesiredContainerSpec{})
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(newBndl.Spec.Linux.MaskedPaths).To(Equal([]string{"/proc/kcore"}))
+		Expect(newBndl.Spec.Linux.ReadonlyPaths).To(Equal([]string{"/proc/sys"}))
+	})
+
+	It("adds the container's own paths", func() {
+		newBndl, err := rule.Apply(bndl, gardener.DesiredContainerSpec{
+			Properties: map[string]string{
+				bundlerules.MaskedPathsProperty:   "/proc/cpuinfo, /proc/kcore",
+				bundlerules.ReadonlyPathsProperty: "/etc/secrets/",
+			},
+		})
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(newBndl.Spec.Linux.MaskedPaths).To(Equal([]string{"/proc/kcore", "/proc/cpuinfo"}))
+		Expect(newBndl.Spec.Linux.ReadonlyPaths).To(Equal([]string{"/proc/sys", "/etc/secrets"}))
+	})
+
+	It("rejects relative paths", func() {
+		_, err := rule.Apply(bndl, gardener.DesiredContainerSpec{
+			Properties: map[string]string{bundlerules.MaskedPathsProperty: "proc/cpuinfo"},
+		})
+		Expect(err).To(MatchError("invalid masked-paths property: 'proc/cpuinfo' is not an absolute path"))
+	})
+
+	It("does not modify the original bundle", func() {
+		_, err := rule.Apply(bndl, gardener.DesiredContainerSpec{})
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(bndl.Spec.Linux.MaskedPaths).To(BeEmpty())
+	})
+})
@@ -0,0 +1,44 @@
+package bundlerules
+
+import (
+	"errors"
+
+	"github.com/cloudfoundry-incubator/goci"
+	"github.com/cloudfoundry-incubator/guardian/gardener"
+	"github.com/cloudfoundry-incubator/guardian/sysinfo"
+	"github.com/opencontainers/specs"
+)
+
+// Pids limits the number of pids in the container's pids cgroup, so that a
+// fork bomb in one container cannot exhaust the host's pid space. The
+// container's MaxPids wins over Default; if both are 0 no limit is set. When
+// the pids cgroup is unavailable Default is ignored, and containers which set
+// MaxPids are rejected.
+type Pids struct {
+	Default      int64
+	Capabilities *sysinfo.Capabilities
+}
+
+func (p Pids) Apply(bndl *goci.Bndl, spec gardener.DesiredContainerSpec) (*goci.Bndl, error) {
+	supported := p.Capabilities.Has(sysinfo.CapabilityPidsLimits)
+	if spec.MaxPids != 0 && !supported {
+		return nil, errors.New("pid limits are not supported: the pids cgroup is not enabled")
+	}
+
+	limit := spec.MaxPids
+	if limit == 0 && supported {
+		limit = p.Default
+	}
+
+	if limit == 0 {
+		return bndl, nil
+	}
+
+	resources := specs.Resources{}
+	if bndl.Resources() != nil {
+		resources = *bndl.Resources()
+	}
+
+	resources.Pids = &specs.Pids{Limit: &limit}
+	return bndl.WithResources(&resources), nil
+}
@@ -0,0 +1,78 @@
+package bundlerules_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/opencontainers/specs"
+
+	"github.com/cloudfoundry-incubator/goci"
+	"github.com/cloudfoundry-incubator/guardian/gardener"
+	"github.com/cloudfoundry-incubator/guardian/rundmc/bundlerules"
+	"github.com/cloudfoundry-incubator/guardian/sysinfo"
+)
+
+var _ = Describe("PidsRule", func() {
+	It("sets the container's pid limit in the bundle resources", func() {
+		newBndl, err := bundlerules.Pids{Default: 1024}.Apply(goci.Bundle(), gardener.DesiredContainerSpec{
+			MaxPids: 64,
+		})
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(*newBndl.Resources().Pids.Limit).To(BeNumerically("==", 64))
+	})
+
+	It("uses the default limit when the container does not set one", func() {
+		newBndl, err := bundlerules.Pids{Default: 1024}.Apply(goci.Bundle(), gardener.DesiredContainerSpec{})
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(*newBndl.Resources().Pids.Limit).To(BeNumerically("==", 1024))
+	})
+
+	It("does not set a limit when there is neither a container limit nor a default", func() {
+		newBndl, err := bundlerules.Pids{}.Apply(goci.Bundle(), gardener.DesiredContainerSpec{})
+		Expect(err).NotTo(HaveOccurred())
+
+		if newBndl.Resources() != nil {
+			Expect(newBndl.Resources().Pids).To(BeNil())
+		}
+	})
+
+	It("does not clobber other fields of the resources section", func() {
+		foo := "foo"
+		bndl := goci.Bundle().WithResources(
+			&specs.Resources{
+				Devices: []specs.DeviceCgroup{{Access: &foo}},
+			},
+		)
+
+		newBndl, err := bundlerules.Pids{}.Apply(bndl, gardener.DesiredContainerSpec{MaxPids: 64})
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(*newBndl.Resources().Pids.Limit).To(BeNumerically("==", 64))
+		Expect(newBndl.Resources().Devices).To(Equal(bndl.Resources().Devices))
+		Expect(bndl.Resources().Pids).To(BeNil())
+	})
+
+	Context("when the pids cgroup is not available", func() {
+		var capabilities *sysinfo.Capabilities
+
+		BeforeEach(func() {
+			capabilities = &sysinfo.Capabilities{}
+			capabilities.Set([]sysinfo.Capability{{Name: sysinfo.CapabilityPidsLimits}})
+		})
+
+		It("ignores the default limit", func() {
+			newBndl, err := bundlerules.Pids{Default: 1024, Capabilities: capabilities}.Apply(goci.Bundle(), gardener.DesiredContainerSpec{})
+			Expect(err).NotTo(HaveOccurred())
+
+			if newBndl.Resources() != nil {
+				Expect(newBndl.Resources().Pids).To(BeNil())
+			}
+		})
+
+		It("rejects containers with their own limit", func() {
+			_, err := bundlerules.Pids{Capabilities: capabilities}.Apply(goci.Bundle(), gardener.DesiredContainerSpec{MaxPids: 64})
+			Expect(err).To(MatchError("pid limits are not supported: the pids cgroup is not enabled"))
+		})
+	})
+})
@@ -0,0 +1,206 @@
+package bundlerules
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"reflect"
+	"strings"
+
+	"github.com/cloudfoundry-incubator/goci"
+	"github.com/cloudfoundry-incubator/guardian/gardener"
+	"github.com/cloudfoundry/gunk/command_runner"
+	"github.com/opencontainers/specs"
+)
+
+// PluginRequest is written, as JSON, to a bundle plugin's stdin
+type PluginRequest struct {
+	// Config is the container's config.json as generated by the preceding rules
+	Config specs.LinuxSpec `json:"config"`
+
+	// Spec is the spec the container is being created with
+	Spec gardener.DesiredContainerSpec `json:"spec"`
+}
+
+// Plugin runs an operator's executable to mutate the bundle, e.g. to add
+// mounts or annotations, without guardian having to be forked to add a rule.
+// The executable is given a PluginRequest on stdin and must write the mutated
+// config.json to stdout.
+//
+// Plugins are trusted to customize containers, not to escape them: the
+// mutated config must keep the container's rootfs, hooks, namespaces and id
+// mappings, which guardian relies on to set up and isolate the container, and
+// may only drop capabilities and tighten the seccomp profile.
+//
+// Plugins are not run when the spec is only being validated, as validating
+// must not run operators' executables on behalf of clients.
+type Plugin struct {
+	Path          string
+	CommandRunner command_runner.CommandRunner
+}
+
+func (p Plugin) Apply(bndl *goci.Bndl, spec gardener.DesiredContainerSpec) (*goci.Bndl, error) {
+	if spec.DryRun {
+		return bndl, nil
+	}
+
+	request, err := json.Marshal(PluginRequest{Config: bndl.Spec, Spec: spec})
+	if err != nil {
+		return nil, fmt.Errorf("bundle plugin %s: %s", p.Path, err)
+	}
+
+	stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+
+	cmd := exec.Command(p.Path)
+	cmd.Stdin = bytes.NewReader(request)
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+
+	if err := p.CommandRunner.Run(cmd); err != nil {
+		return nil, fmt.Errorf("bundle plugin %s: %s: %s", p.Path, err, strings.TrimSpace(stderr.String()))
+	}
+
+	var config specs.LinuxSpec
+	if err := json.Unmarshal(stdout.Bytes(), &config); err != nil {
+		return nil, fmt.Errorf("bundle plugin %s: invalid config: %s", p.Path, err)
+	}
+
+	// the original is compared as the plugin saw it, so that e.g. empty lists
+	// which were omitted from the request do not count as changes
+	var original PluginRequest
+	if err := json.Unmarshal(request, &original); err != nil {
+		return nil, fmt.Errorf("bundle plugin %s: %s", p.Path, err)
+	}
+
+	mutated := &goci.Bndl{Spec: config}
+	if err := validatePluginBundle(&goci.Bndl{Spec: original.Config}, mutated); err != nil {
+		return nil, fmt.Errorf("bundle plugin %s: %s", p.Path, err)
+	}
+
+	return mutated, nil
+}
+
+func validatePluginBundle(original, mutated *goci.Bndl) error {
+	if mutated.RootFS() != original.RootFS() {
+		return fmt.Errorf("the rootfs may not be changed")
+	}
+
+	if len(mutated.Spec.Process.Args) == 0 {
+		return fmt.Errorf("the process has no args")
+	}
+
+	if !reflect.DeepEqual(mutated.Spec.Hooks, original.Spec.Hooks) {
+		return fmt.Errorf("the hooks may not be changed")
+	}
+
+	if err := checkPluginNamespaces(original.Spec.Linux.Namespaces, mutated.Spec.Linux.Namespaces); err != nil {
+		return err
+	}
+
+	if !reflect.DeepEqual(mutated.Spec.Linux.UIDMappings, original.Spec.Linux.UIDMappings) ||
+		!reflect.DeepEqual(mutated.Spec.Linux.GIDMappings, original.Spec.Linux.GIDMappings) {
+		return fmt.Errorf("the id mappings may not be changed")
+	}
+
+	for _, capability := range mutated.Spec.Process.Capabilities {
+		if !containsString(original.Spec.Process.Capabilities, capability) {
+			return fmt.Errorf("the %s capability may not be added", capability)
+		}
+	}
+
+	return checkPluginSeccomp(original.Spec.Linux.Seccomp, mutated.Spec.Linux.Seccomp)
+}
+
+// checkPluginNamespaces checks that the container keeps all of its
+// namespaces, and that any namespace the plugin adds is a new one rather than
+// one joined by path
+func checkPluginNamespaces(original, mutated []specs.Namespace) error {
+	for _, ns := range original {
+		if !containsNamespace(mutated, ns) {
+			return fmt.Errorf("the %s namespace may not be removed or changed", ns.Type)
+		}
+	}
+
+	for _, ns := range mutated {
+		if ns.Path != "" && !containsNamespace(original, ns) {
+			return fmt.Errorf("the %s namespace at %s may not be joined", ns.Type, ns.Path)
+		}
+	}
+
+	return nil
+}
+
+// checkPluginSeccomp checks that the plugin's seccomp profile, which replaces
+// the original, stops every syscall at least as firmly as the original does
+func checkPluginSeccomp(original, mutated specs.Seccomp) error {
+	if original.DefaultAction == "" && len(original.Syscalls) == 0 {
+		return nil
+	}
+
+	if mutated.DefaultAction == "" {
+		return fmt.Errorf("the seccomp profile may not be removed")
+	}
+
+	// the original's rules which the plugin has kept as they were still stop
+	// their syscalls as firmly, so only the rest are checked
+	changed := mutated
+	changed.Syscalls = nil
+	for _, rule := range mutated.Syscalls {
+		if !containsSyscallRule(original.Syscalls, rule) {
+			changed.Syscalls = append(changed.Syscalls, rule)
+		}
+	}
+
+	if err := CheckSeccompTightens(&original, &changed); err != nil {
+		return fmt.Errorf("the seccomp profile may not be loosened: %s", err)
+	}
+
+	for _, rule := range original.Syscalls {
+		if !hasSyscallRule(mutated, rule.Name) && seccompStrictness[mutated.DefaultAction] < seccompStrictness[rule.Action] {
+			return fmt.Errorf("the seccomp profile may not be loosened: the rule for syscall '%s' was removed", rule.Name)
+		}
+	}
+
+	return nil
+}
+
+func containsNamespace(namespaces []specs.Namespace, ns specs.Namespace) bool {
+	for _, n := range namespaces {
+		if n.Type == ns.Type && n.Path == ns.Path {
+			return true
+		}
+	}
+
+	return false
+}
+
+func containsSyscallRule(rules []specs.Syscall, rule specs.Syscall) bool {
+	for _, r := range rules {
+		if reflect.DeepEqual(r, rule) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func hasSyscallRule(profile specs.Seccomp, name string) bool {
+	for _, rule := range profile.Syscalls {
+		if rule.Name == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+func containsString(list []string, s string) bool {
+	for _, l := range list {
+		if l == s {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,165 @@
+package bundlerules_test
+
+import (
+	"encoding/json"
+	"errors"
+	"os/exec"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+	"github.com/opencontainers/specs"
+
+	"github.com/cloudfoundry-incubator/goci"
+	"github.com/cloudfoundry-incubator/guardian/gardener"
+	"github.com/cloudfoundry-incubator/guardian/rundmc/bundlerules"
+	"github.com/cloudfoundry/gunk/command_runner/fake_command_runner"
+)
+
+var _ = Describe("Plugin", func() {
+	var (
+		commandRunner *fake_command_runner.FakeCommandRunner
+		rule          bundlerules.Plugin
+		bndl          *goci.Bndl
+		spec          gardener.DesiredContainerSpec
+		mutate        func(config *specs.LinuxSpec)
+		request       bundlerules.PluginRequest
+	)
+
+	BeforeEach(func() {
+		commandRunner = fake_command_runner.New()
+		rule = bundlerules.Plugin{Path: "/path/to/plugin", CommandRunner: commandRunner}
+
+		mappings := []specs.IDMapping{{ContainerID: 0, HostID: 4294967294, Size: 1}}
+		bndl = goci.Bundle().
+			WithRootFS("/path/to/rootfs").
+			WithNamespace(goci.UserNamespace).
+			WithUIDMappings(mappings...).
+			WithGIDMappings(mappings...).
+			WithPrestartHooks(specs.Hook{Path: "/path/to/network-hook", Args: []string{"up"}}).
+			WithProcess(specs.Process{Args: []string{"/tmp/garden-init"}}).
+			WithCapabilities("CAP_CHOWN", "CAP_KILL")
+		bndl.Spec.Linux.Seccomp = specs.Seccomp{
+			DefaultAction: specs.ActErrno,
+			Syscalls:      []specs.Syscall{{Name: "read", Action: specs.ActAllow}, {Name: "ptrace", Action: specs.ActKill}},
+		}
+
+		spec = gardener.DesiredContainerSpec{
+			Handle:     "some-handle",
+			Properties: map[string]string{"team": "banana"},
+		}
+
+		mutate = func(config *specs.LinuxSpec) {
+			config.Mounts = append(config.Mounts, specs.Mount{Destination: "/etc/team", Source: "/var/teams/banana", Type: "bind"})
+		}
+
+		commandRunner.WhenRunning(fake_command_runner.CommandSpec{Path: "/path/to/plugin"}, func(cmd *exec.Cmd) error {
+			Expect(json.NewDecoder(cmd.Stdin).Decode(&request)).To(Succeed())
+
+			config := request.Config
+			mutate(&config)
+			return json.NewEncoder(cmd.Stdout).Encode(config)
+		})
+	})
+
+	It("passes the config and the container's spec to the plugin", func() {
+		_, err := rule.Apply(bndl, spec)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(request.Spec.Handle).To(Equal("some-handle"))
+		Expect(request.Spec.Properties).To(HaveKeyWithValue("team", "banana"))
+		Expect(request.Config.Spec.Root.Path).To(Equal("/path/to/rootfs"))
+	})
+
+	It("returns the bundle with the config the plugin writes", func() {
+		newBndl, err := rule.Apply(bndl, spec)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(newBndl.Mounts()).To(ContainElement(specs.Mount{Destination: "/etc/team", Source: "/var/teams/banana", Type: "bind"}))
+		Expect(newBndl.RootFS()).To(Equal("/path/to/rootfs"))
+	})
+
+	It("returns the plugin's stderr when it fails", func() {
+		commandRunner.WhenRunning(fake_command_runner.CommandSpec{Path: "/path/to/plugin"}, func(cmd *exec.Cmd) error {
+			cmd.Stderr.Write([]byte("no team\n"))
+			return errors.New("exit status 1")
+		})
+
+		_, err := rule.Apply(bndl, spec)
+		Expect(err).To(MatchError("bundle plugin /path/to/plugin: exit status 1: no team"))
+	})
+
+	Context("when the spec is only being validated", func() {
+		It("returns the bundle without running the plugin", func() {
+			spec.DryRun = true
+
+			newBndl, err := rule.Apply(bndl, spec)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(newBndl).To(Equal(bndl))
+			Expect(commandRunner.ExecutedCommands()).To(BeEmpty())
+		})
+	})
+
+	It("fails when the plugin does not write a config", func() {
+		commandRunner.WhenRunning(fake_command_runner.CommandSpec{Path: "/path/to/plugin"}, func(cmd *exec.Cmd) error {
+			cmd.Stdout.Write([]byte("done"))
+			return nil
+		})
+
+		_, err := rule.Apply(bndl, spec)
+		Expect(err).To(MatchError(ContainSubstring("bundle plugin /path/to/plugin: invalid config")))
+	})
+
+	DescribeTable("rejecting configs which would break the container",
+		func(change func(config *specs.LinuxSpec), message string) {
+			mutate = change
+
+			_, err := rule.Apply(bndl, spec)
+			Expect(err).To(MatchError("bundle plugin /path/to/plugin: " + message))
+		},
+		Entry("changing the rootfs", func(config *specs.LinuxSpec) {
+			config.Spec.Root.Path = "/"
+		}, "the rootfs may not be changed"),
+		Entry("removing the process", func(config *specs.LinuxSpec) {
+			config.Spec.Process.Args = nil
+		}, "the process has no args"),
+		Entry("changing the hooks", func(config *specs.LinuxSpec) {
+			config.Spec.Hooks.Prestart = append(config.Spec.Hooks.Prestart, specs.Hook{Path: "/bin/sh"})
+		}, "the hooks may not be changed"),
+		Entry("removing the user namespace", func(config *specs.LinuxSpec) {
+			config.Linux.Namespaces = nil
+		}, "the user namespace may not be removed or changed"),
+		Entry("joining a namespace by path", func(config *specs.LinuxSpec) {
+			config.Linux.Namespaces = append(config.Linux.Namespaces, specs.Namespace{Type: specs.NetworkNamespace, Path: "/proc/1/ns/net"})
+		}, "the network namespace at /proc/1/ns/net may not be joined"),
+		Entry("changing the id mappings", func(config *specs.LinuxSpec) {
+			config.Linux.UIDMappings = []specs.IDMapping{{ContainerID: 0, HostID: 0, Size: 1}}
+		}, "the id mappings may not be changed"),
+		Entry("adding a capability", func(config *specs.LinuxSpec) {
+			config.Process.Capabilities = append(config.Process.Capabilities, "CAP_SYS_ADMIN")
+		}, "the CAP_SYS_ADMIN capability may not be added"),
+		Entry("removing the seccomp profile", func(config *specs.LinuxSpec) {
+			config.Linux.Seccomp = specs.Seccomp{}
+		}, "the seccomp profile may not be removed"),
+		Entry("weakening the seccomp default action", func(config *specs.LinuxSpec) {
+			config.Linux.Seccomp.DefaultAction = specs.ActAllow
+		}, "the seccomp profile may not be loosened: default action 'SCMP_ACT_ALLOW' is weaker than the default profile's 'SCMP_ACT_ERRNO'"),
+		Entry("removing a seccomp rule", func(config *specs.LinuxSpec) {
+			config.Linux.Seccomp.Syscalls = config.Linux.Seccomp.Syscalls[:1]
+		}, "the seccomp profile may not be loosened: the rule for syscall 'ptrace' was removed"),
+	)
+
+	It("lets the plugin drop capabilities and tighten the seccomp profile", func() {
+		mutate = func(config *specs.LinuxSpec) {
+			config.Process.Capabilities = []string{"CAP_CHOWN"}
+			config.Linux.Seccomp.DefaultAction = specs.ActKill
+			config.Linux.Namespaces = append(config.Linux.Namespaces, specs.Namespace{Type: specs.IPCNamespace})
+		}
+
+		newBndl, err := rule.Apply(bndl, spec)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(newBndl.Spec.Process.Capabilities).To(Equal([]string{"CAP_CHOWN"}))
+		Expect(newBndl.Spec.Linux.Seccomp.DefaultAction).To(Equal(specs.ActKill))
+	})
+})
@@ -18,15 +18,29 @@ type RootFS struct {
 	MkdirChowner MkdirChowner
 }
 
-func (r RootFS) Apply(bndl *goci.Bndl, spec gardener.DesiredContainerSpec) *goci.Bndl {
+func (r RootFS) Apply(bndl *goci.Bndl, spec gardener.DesiredContainerSpec) (*goci.Bndl, error) {
+	if spec.DryRun {
+		return bndl.WithRootFS(spec.RootFSPath), nil
+	}
+
+	uid, gid := r.ContainerRootUID, r.ContainerRootGID
+	if spec.IDMappings != nil {
+		uid, gid = spec.IDMappings.UID.Map(0), spec.IDMappings.GID.Map(0)
+	}
+
 	os.RemoveAll(path.Join(spec.RootFSPath, "dev"))
-	r.mkdirAsContainerRoot(filepath.Join(spec.RootFSPath, ".pivot_root"), 0700)
-	r.mkdirAsContainerRoot(filepath.Join(spec.RootFSPath, "dev"), 0755)
-	r.mkdirAsContainerRoot(filepath.Join(spec.RootFSPath, "proc"), 0755)
-	r.mkdirAsContainerRoot(filepath.Join(spec.RootFSPath, "sys"), 0755)
-	return bndl.WithRootFS(spec.RootFSPath)
+	r.MkdirChowner.MkdirChown(filepath.Join(spec.RootFSPath, ".pivot_root"), 0700, uid, gid)
+	r.MkdirChowner.MkdirChown(filepath.Join(spec.RootFSPath, "dev"), 0755, uid, gid)
+	r.MkdirChowner.MkdirChown(filepath.Join(spec.RootFSPath, "proc"), 0755, uid, gid)
+	r.MkdirChowner.MkdirChown(filepath.Join(spec.RootFSPath, "sys"), 0755, uid, gid)
+	return bndl.WithRootFS(spec.RootFSPath), nil
 }
 
-func (r RootFS) mkdirAsContainerRoot(path string, perms os.FileMode) {
-	r.MkdirChowner.MkdirChown(path, perms, r.ContainerRootUID, r.ContainerRootGID)
+// VolumeRootFS uses the container's rootfs as it is, without creating the
+// mount points a Linux container needs in it, e.g. for a Windows container
+// whose rootfs is a volume which the runtime sets up itself
+type VolumeRootFS struct{}
+
+func (VolumeRootFS) Apply(bndl *goci.Bndl, spec gardener.DesiredContainerSpec) (*goci.Bndl, error) {
+	return bndl.WithRootFS(spec.RootFSPath), nil
 }
@@ -8,6 +8,7 @@ import (
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 
+	"github.com/cloudfoundry-incubator/garden-shed/rootfs_provider"
 	"github.com/cloudfoundry-incubator/goci"
 	"github.com/cloudfoundry-incubator/guardian/gardener"
 	"github.com/cloudfoundry-incubator/guardian/rundmc/bundlerules"
@@ -38,9 +39,11 @@ var _ = Describe("RootFS", func() {
 		Expect(ioutil.WriteFile(path.Join(rootfsPath, "dev", "foo"), []byte("blah"), 0700)).To(Succeed())
 		Expect(os.MkdirAll(path.Join(rootfsPath, "notdev", "shm"), 0700)).To(Succeed())
 
-		returnedBundle = rule.Apply(goci.Bundle(), gardener.DesiredContainerSpec{
+		var err error
+		returnedBundle, err = rule.Apply(goci.Bundle(), gardener.DesiredContainerSpec{
 			RootFSPath: rootfsPath,
 		})
+		Expect(err).NotTo(HaveOccurred())
 	})
 
 	AfterEach(func() {
@@ -91,6 +94,57 @@ var _ = Describe("RootFS", func() {
 			}))
 		}
 	})
+
+	Context("when the container has its own id mappings", func() {
+		It("creates the directories as the container's root", func() {
+			_, err := rule.Apply(goci.Bundle(), gardener.DesiredContainerSpec{
+				RootFSPath: rootfsPath,
+				IDMappings: &gardener.IDMappings{
+					UID: rootfs_provider.MappingList{{ContainerID: 0, HostID: 100000, Size: 65536}},
+					GID: rootfs_provider.MappingList{{ContainerID: 0, HostID: 200000, Size: 65536}},
+				},
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(mkdirChownCalls(fakeMkdirChowner)).To(ContainElement(mkdirChownCall{
+				path:  path.Join(rootfsPath, ".pivot_root"),
+				perms: os.FileMode(0700),
+				uid:   100000,
+				gid:   200000,
+			}))
+		})
+	})
+
+	Context("when the spec is only being validated", func() {
+		It("does not touch the rootfs", func() {
+			callsBefore := fakeMkdirChowner.MkdirChownCallCount()
+
+			_, err := rule.Apply(goci.Bundle(), gardener.DesiredContainerSpec{
+				RootFSPath: rootfsPath,
+				DryRun:     true,
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(fakeMkdirChowner.MkdirChownCallCount()).To(Equal(callsBefore))
+		})
+	})
+})
+
+var _ = Describe("VolumeRootFS", func() {
+	It("applies the rootfs to the passed bundle without creating anything in it", func() {
+		rootfsPath := tmp()
+		defer os.RemoveAll(rootfsPath)
+
+		bndl, err := bundlerules.VolumeRootFS{}.Apply(goci.Bundle(), gardener.DesiredContainerSpec{
+			RootFSPath: rootfsPath,
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(bndl.Spec.Root.Path).To(Equal(rootfsPath))
+
+		entries, err := ioutil.ReadDir(rootfsPath)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(entries).To(BeEmpty())
+	})
 })
 
 func tmp() string {
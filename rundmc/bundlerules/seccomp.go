@@ -0,0 +1,241 @@
+package bundlerules
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+
+	"github.com/cloudfoundry-incubator/goci"
+	"github.com/cloudfoundry-incubator/guardian/gardener"
+	"github.com/cloudfoundry-incubator/guardian/sysinfo"
+	"github.com/opencontainers/specs"
+)
+
+// SeccompProfileProperty is the container property which may hold a seccomp
+// profile (as JSON) to merge over the default profile. The profile is passed
+// inline rather than as a path so that clients cannot read files on the host.
+const SeccompProfileProperty = "seccomp-profile"
+
+var validSeccompActions = map[specs.Action]bool{
+	specs.ActKill:  true,
+	specs.ActTrap:  true,
+	specs.ActErrno: true,
+	specs.ActTrace: true,
+	specs.ActAllow: true,
+}
+
+// seccompStrictness orders the actions from the one which lets a syscall
+// through to the ones which stop it most firmly; a traced syscall may be let
+// through by the tracer
+var seccompStrictness = map[specs.Action]int{
+	specs.ActAllow: 0,
+	specs.ActTrace: 1,
+	specs.ActErrno: 2,
+	specs.ActTrap:  3,
+	specs.ActKill:  4,
+}
+
+// Seccomp sets the seccomp profile of the bundle. The per-container profile
+// (if any) is merged over the default: its syscall rules replace default
+// rules for the same syscall and its default action, if set, wins. Since the
+// profile is chosen by the client, it may only tighten the default, never
+// let through a syscall the default would stop. When the kernel does not
+// support seccomp the default is not applied, and containers with their own
+// profile are rejected.
+type Seccomp struct {
+	Default      *specs.Seccomp
+	Capabilities *sysinfo.Capabilities
+}
+
+func (s Seccomp) Apply(bndl *goci.Bndl, spec gardener.DesiredContainerSpec) (*goci.Bndl, error) {
+	raw, hasOverride := spec.Properties[SeccompProfileProperty]
+	if !s.Capabilities.Has(sysinfo.CapabilitySeccomp) {
+		if hasOverride {
+			return nil, fmt.Errorf("seccomp: the %s property is not supported: the kernel was built without seccomp support", SeccompProfileProperty)
+		}
+
+		return bndl, nil
+	}
+
+	profile := s.Default
+	if hasOverride {
+		override, err := ParseSeccompProfile([]byte(raw))
+		if err != nil {
+			return nil, fmt.Errorf("seccomp: invalid %s property: %s", SeccompProfileProperty, err)
+		}
+
+		if err := CheckSeccompTightens(s.Default, override); err != nil {
+			return nil, fmt.Errorf("seccomp: %s property: %s", SeccompProfileProperty, err)
+		}
+
+		profile = MergeSeccompProfiles(s.Default, override)
+	}
+
+	if profile == nil {
+		return bndl, nil
+	}
+
+	newBndl := *bndl
+	newBndl.Spec.Linux.Seccomp = *profile
+	return &newBndl, nil
+}
+
+// LoadSeccompProfile reads and validates a seccomp profile from a JSON file.
+func LoadSeccompProfile(path string) (*specs.Seccomp, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("seccomp: read profile: %s", err)
+	}
+
+	profile, err := ParseSeccompProfile(data)
+	if err != nil {
+		return nil, fmt.Errorf("seccomp: %s: %s", path, err)
+	}
+
+	return profile, nil
+}
+
+// ParseSeccompProfile decodes and validates a JSON seccomp profile.
+func ParseSeccompProfile(data []byte) (*specs.Seccomp, error) {
+	var profile specs.Seccomp
+	if err := json.Unmarshal(data, &profile); err != nil {
+		return nil, fmt.Errorf("decode profile: %s", err)
+	}
+
+	if err := ValidateSeccompProfile(&profile); err != nil {
+		return nil, err
+	}
+
+	return &profile, nil
+}
+
+// ValidateSeccompProfile checks that every action in the profile is known and
+// that each syscall is named and appears only once. An empty default action
+// is allowed so that overrides may only adjust individual syscalls.
+func ValidateSeccompProfile(profile *specs.Seccomp) error {
+	if profile.DefaultAction != "" && !validSeccompActions[profile.DefaultAction] {
+		return fmt.Errorf("unknown default action '%s'", profile.DefaultAction)
+	}
+
+	seen := map[string]bool{}
+	for _, syscall := range profile.Syscalls {
+		if syscall.Name == "" {
+			return fmt.Errorf("syscall rule has no name")
+		}
+
+		if seen[syscall.Name] {
+			return fmt.Errorf("duplicate rule for syscall '%s'", syscall.Name)
+		}
+		seen[syscall.Name] = true
+
+		if !validSeccompActions[syscall.Action] {
+			return fmt.Errorf("unknown action '%s' for syscall '%s'", syscall.Action, syscall.Name)
+		}
+	}
+
+	return nil
+}
+
+// CheckSeccompTightens returns an error unless merging override over base
+// stops every syscall at least as firmly as base does alone: its default
+// action and each of its syscall rules must be no weaker than the action base
+// would otherwise take, and it may only drop architectures. A nil base
+// allows everything.
+func CheckSeccompTightens(base, override *specs.Seccomp) error {
+	baseDefault := specs.ActAllow
+	var baseSyscalls []specs.Syscall
+	var baseArchitectures []specs.Arch
+	if base != nil {
+		baseDefault = base.DefaultAction
+		baseSyscalls = base.Syscalls
+		baseArchitectures = base.Architectures
+	}
+
+	mergedDefault := baseDefault
+	if override.DefaultAction != "" {
+		if seccompStrictness[override.DefaultAction] < seccompStrictness[baseDefault] {
+			return fmt.Errorf("default action '%s' is weaker than the default profile's '%s'", override.DefaultAction, baseDefault)
+		}
+
+		mergedDefault = override.DefaultAction
+	}
+
+	for _, syscall := range override.Syscalls {
+		// the replaced rule's action applies to the calls it matched, and
+		// the default to the rest
+		floor := mergedDefault
+		for _, existing := range baseSyscalls {
+			if existing.Name == syscall.Name && seccompStrictness[existing.Action] > seccompStrictness[floor] {
+				floor = existing.Action
+			}
+		}
+
+		if seccompStrictness[syscall.Action] < seccompStrictness[floor] {
+			return fmt.Errorf("action '%s' for syscall '%s' is weaker than the default profile's '%s'", syscall.Action, syscall.Name, floor)
+		}
+	}
+
+	if len(baseArchitectures) > 0 {
+		for _, arch := range override.Architectures {
+			if !containsArch(baseArchitectures, arch) {
+				return fmt.Errorf("architecture '%s' is not in the default profile", arch)
+			}
+		}
+	}
+
+	return nil
+}
+
+func containsArch(architectures []specs.Arch, arch specs.Arch) bool {
+	for _, a := range architectures {
+		if a == arch {
+			return true
+		}
+	}
+
+	return false
+}
+
+// MergeSeccompProfiles returns a new profile consisting of base with override
+// applied over it. Either profile may be nil.
+func MergeSeccompProfiles(base, override *specs.Seccomp) *specs.Seccomp {
+	merged := specs.Seccomp{}
+	if base != nil {
+		merged.DefaultAction = base.DefaultAction
+		merged.Architectures = append(merged.Architectures, base.Architectures...)
+		merged.Syscalls = append(merged.Syscalls, base.Syscalls...)
+	}
+
+	if override == nil {
+		return &merged
+	}
+
+	if override.DefaultAction != "" {
+		merged.DefaultAction = override.DefaultAction
+	}
+
+	if len(override.Architectures) > 0 {
+		merged.Architectures = append([]specs.Arch{}, override.Architectures...)
+	}
+
+	for _, syscall := range override.Syscalls {
+		replaced := false
+		for i, existing := range merged.Syscalls {
+			if existing.Name == syscall.Name {
+				merged.Syscalls[i] = syscall
+				replaced = true
+				break
+			}
+		}
+
+		if !replaced {
+			merged.Syscalls = append(merged.Syscalls, syscall)
+		}
+	}
+
+	if merged.DefaultAction == "" {
+		merged.DefaultAction = specs.ActAllow
+	}
+
+	return &merged
+}
@@ -0,0 +1,210 @@
+package bundlerules_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+	"github.com/opencontainers/specs"
+
+	"github.com/cloudfoundry-incubator/garden"
+	"github.com/cloudfoundry-incubator/goci"
+	"github.com/cloudfoundry-incubator/guardian/gardener"
+	"github.com/cloudfoundry-incubator/guardian/rundmc/bundlerules"
+	"github.com/cloudfoundry-incubator/guardian/sysinfo"
+)
+
+var _ = Describe("SeccompRule", func() {
+	var defaultProfile *specs.Seccomp
+
+	BeforeEach(func() {
+		defaultProfile = &specs.Seccomp{
+			DefaultAction: specs.ActAllow,
+			Syscalls: []specs.Syscall{
+				{Name: "kexec_load", Action: specs.ActErrno},
+				{Name: "ptrace", Action: specs.ActErrno},
+			},
+		}
+	})
+
+	It("applies the default profile", func() {
+		newBndl, err := bundlerules.Seccomp{Default: defaultProfile}.Apply(goci.Bundle(), gardener.DesiredContainerSpec{})
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(newBndl.Spec.Linux.Seccomp).To(Equal(*defaultProfile))
+	})
+
+	It("does not modify the passed bundle", func() {
+		bndl := goci.Bundle()
+		_, err := bundlerules.Seccomp{Default: defaultProfile}.Apply(bndl, gardener.DesiredContainerSpec{})
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(bndl.Spec.Linux.Seccomp).To(Equal(specs.Seccomp{}))
+	})
+
+	Context("when the kernel does not support seccomp", func() {
+		var capabilities *sysinfo.Capabilities
+
+		BeforeEach(func() {
+			capabilities = &sysinfo.Capabilities{}
+			capabilities.Set([]sysinfo.Capability{{Name: sysinfo.CapabilitySeccomp}})
+		})
+
+		It("does not apply the default profile", func() {
+			bndl := goci.Bundle()
+			newBndl, err := bundlerules.Seccomp{Default: defaultProfile, Capabilities: capabilities}.Apply(bndl, gardener.DesiredContainerSpec{})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(newBndl).To(Equal(bndl))
+		})
+
+		It("rejects containers with their own profile", func() {
+			_, err := bundlerules.Seccomp{Default: defaultProfile, Capabilities: capabilities}.Apply(goci.Bundle(), gardener.DesiredContainerSpec{
+				Properties: map[string]string{bundlerules.SeccompProfileProperty: "{}"},
+			})
+			Expect(err).To(MatchError("seccomp: the seccomp-profile property is not supported: the kernel was built without seccomp support"))
+		})
+	})
+
+	Context("when there is no default profile or override", func() {
+		It("returns the bundle unchanged", func() {
+			bndl := goci.Bundle()
+			newBndl, err := bundlerules.Seccomp{}.Apply(bndl, gardener.DesiredContainerSpec{})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(newBndl).To(Equal(bndl))
+		})
+	})
+
+	Context("when the container has a seccomp-profile property", func() {
+		It("merges the container's syscall rules over the default", func() {
+			newBndl, err := bundlerules.Seccomp{Default: defaultProfile}.Apply(goci.Bundle(), gardener.DesiredContainerSpec{
+				Properties: garden.Properties{
+					"seccomp-profile": `{"syscalls":[{"name":"ptrace","action":"SCMP_ACT_KILL"},{"name":"mount","action":"SCMP_ACT_KILL"}]}`,
+				},
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(newBndl.Spec.Linux.Seccomp.DefaultAction).To(Equal(specs.ActAllow))
+			Expect(newBndl.Spec.Linux.Seccomp.Syscalls).To(Equal([]specs.Syscall{
+				{Name: "kexec_load", Action: specs.ActErrno},
+				{Name: "ptrace", Action: specs.ActKill},
+				{Name: "mount", Action: specs.ActKill},
+			}))
+		})
+
+		It("uses the container's default action when it has one", func() {
+			newBndl, err := bundlerules.Seccomp{Default: defaultProfile}.Apply(goci.Bundle(), gardener.DesiredContainerSpec{
+				Properties: garden.Properties{"seccomp-profile": `{"defaultAction":"SCMP_ACT_ERRNO"}`},
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(newBndl.Spec.Linux.Seccomp.DefaultAction).To(Equal(specs.ActErrno))
+		})
+
+		It("does not modify the default profile", func() {
+			_, err := bundlerules.Seccomp{Default: defaultProfile}.Apply(goci.Bundle(), gardener.DesiredContainerSpec{
+				Properties: garden.Properties{"seccomp-profile": `{"syscalls":[{"name":"ptrace","action":"SCMP_ACT_KILL"}]}`},
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(defaultProfile.Syscalls[1].Action).To(Equal(specs.ActErrno))
+		})
+
+		Context("and there is no default profile", func() {
+			It("allows syscalls which are not listed", func() {
+				newBndl, err := bundlerules.Seccomp{}.Apply(goci.Bundle(), gardener.DesiredContainerSpec{
+					Properties: garden.Properties{"seccomp-profile": `{"syscalls":[{"name":"mount","action":"SCMP_ACT_KILL"}]}`},
+				})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(newBndl.Spec.Linux.Seccomp).To(Equal(specs.Seccomp{
+					DefaultAction: specs.ActAllow,
+					Syscalls:      []specs.Syscall{{Name: "mount", Action: specs.ActKill}},
+				}))
+			})
+		})
+
+		DescribeTable("rejects profiles which loosen the default",
+			func(profile, expectedErr string) {
+				defaultProfile.DefaultAction = specs.ActErrno
+				defaultProfile.Architectures = []specs.Arch{specs.ArchX86_64}
+				defaultProfile.Syscalls = append(defaultProfile.Syscalls, specs.Syscall{Name: "read", Action: specs.ActAllow})
+
+				_, err := bundlerules.Seccomp{Default: defaultProfile}.Apply(goci.Bundle(), gardener.DesiredContainerSpec{
+					Properties: garden.Properties{"seccomp-profile": profile},
+				})
+				Expect(err).To(MatchError(ContainSubstring(expectedErr)))
+			},
+			Entry("a weaker default action", `{"defaultAction":"SCMP_ACT_ALLOW"}`, "default action 'SCMP_ACT_ALLOW' is weaker than the default profile's 'SCMP_ACT_ERRNO'"),
+			Entry("a weaker rule for a listed syscall", `{"syscalls":[{"name":"ptrace","action":"SCMP_ACT_TRACE"}]}`, "action 'SCMP_ACT_TRACE' for syscall 'ptrace' is weaker than the default profile's 'SCMP_ACT_ERRNO'"),
+			Entry("allowing an unlisted syscall", `{"syscalls":[{"name":"mount","action":"SCMP_ACT_ALLOW"}]}`, "action 'SCMP_ACT_ALLOW' for syscall 'mount' is weaker than the default profile's 'SCMP_ACT_ERRNO'"),
+			Entry("an extra architecture", `{"architectures":["SCMP_ARCH_X86"]}`, "architecture 'SCMP_ARCH_X86' is not in the default profile"),
+		)
+
+		It("accepts rules which keep an allowed syscall allowed under a stricter default", func() {
+			defaultProfile.Syscalls = append(defaultProfile.Syscalls, specs.Syscall{Name: "read", Action: specs.ActAllow})
+
+			_, err := bundlerules.Seccomp{Default: defaultProfile}.Apply(goci.Bundle(), gardener.DesiredContainerSpec{
+				Properties: garden.Properties{"seccomp-profile": `{"defaultAction":"SCMP_ACT_ERRNO","syscalls":[{"name":"ptrace","action":"SCMP_ACT_KILL"}]}`},
+			})
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		DescribeTable("rejects invalid profiles",
+			func(profile, expectedErr string) {
+				_, err := bundlerules.Seccomp{Default: defaultProfile}.Apply(goci.Bundle(), gardener.DesiredContainerSpec{
+					Properties: garden.Properties{"seccomp-profile": profile},
+				})
+				Expect(err).To(MatchError(ContainSubstring(expectedErr)))
+			},
+			Entry("malformed JSON", `{"syscalls":`, "decode profile"),
+			Entry("unknown default action", `{"defaultAction":"SCMP_ACT_PANIC"}`, "unknown default action 'SCMP_ACT_PANIC'"),
+			Entry("unknown syscall action", `{"syscalls":[{"name":"mount","action":"nope"}]}`, "unknown action 'nope' for syscall 'mount'"),
+			Entry("unnamed syscall", `{"syscalls":[{"action":"SCMP_ACT_KILL"}]}`, "syscall rule has no name"),
+			Entry("duplicate syscall", `{"syscalls":[{"name":"mount","action":"SCMP_ACT_KILL"},{"name":"mount","action":"SCMP_ACT_ALLOW"}]}`, "duplicate rule for syscall 'mount'"),
+		)
+	})
+
+	Describe("LoadSeccompProfile", func() {
+		var tmpDir string
+
+		BeforeEach(func() {
+			var err error
+			tmpDir, err = ioutil.TempDir("", "seccomp")
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		AfterEach(func() {
+			Expect(os.RemoveAll(tmpDir)).To(Succeed())
+		})
+
+		It("loads a valid profile", func() {
+			path := filepath.Join(tmpDir, "profile.json")
+			Expect(ioutil.WriteFile(path, []byte(`{"defaultAction":"SCMP_ACT_ERRNO","syscalls":[{"name":"read","action":"SCMP_ACT_ALLOW"}]}`), 0644)).To(Succeed())
+
+			profile, err := bundlerules.LoadSeccompProfile(path)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(profile).To(Equal(&specs.Seccomp{
+				DefaultAction: specs.ActErrno,
+				Syscalls:      []specs.Syscall{{Name: "read", Action: specs.ActAllow}},
+			}))
+		})
+
+		It("returns an error when the profile is invalid", func() {
+			path := filepath.Join(tmpDir, "profile.json")
+			Expect(ioutil.WriteFile(path, []byte(`{"defaultAction":"bananas"}`), 0644)).To(Succeed())
+
+			_, err := bundlerules.LoadSeccompProfile(path)
+			Expect(err).To(MatchError(ContainSubstring("unknown default action")))
+		})
+
+		It("returns an error when the file does not exist", func() {
+			_, err := bundlerules.LoadSeccompProfile(filepath.Join(tmpDir, "missing.json"))
+			Expect(err).To(HaveOccurred())
+		})
+	})
+})
@@ -0,0 +1,22 @@
+package bundlerules
+
+import (
+	"github.com/cloudfoundry-incubator/goci"
+	"github.com/cloudfoundry-incubator/guardian/gardener"
+)
+
+// UserNamespace replaces the default id mappings of an unprivileged
+// container's user namespace with the container's own mappings, if it has
+// any.
+type UserNamespace struct {
+}
+
+func (u UserNamespace) Apply(bndl *goci.Bndl, spec gardener.DesiredContainerSpec) (*goci.Bndl, error) {
+	if spec.Privileged || spec.IDMappings == nil {
+		return bndl, nil
+	}
+
+	return bndl.
+		WithUIDMappings(spec.IDMappings.UID...).
+		WithGIDMappings(spec.IDMappings.GID...), nil
+}
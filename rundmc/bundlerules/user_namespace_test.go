@@ -0,0 +1,52 @@
+package bundlerules_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/opencontainers/specs"
+
+	"github.com/cloudfoundry-incubator/garden-shed/rootfs_provider"
+	"github.com/cloudfoundry-incubator/goci"
+	"github.com/cloudfoundry-incubator/guardian/gardener"
+	"github.com/cloudfoundry-incubator/guardian/rundmc/bundlerules"
+)
+
+var _ = Describe("UserNamespace", func() {
+	var bndl *goci.Bndl
+
+	BeforeEach(func() {
+		defaults := []specs.IDMapping{{ContainerID: 0, HostID: 4294967294, Size: 1}}
+		bndl = goci.Bundle().WithUIDMappings(defaults...).WithGIDMappings(defaults...)
+	})
+
+	It("replaces the default mappings with the container's own", func() {
+		newBndl, err := bundlerules.UserNamespace{}.Apply(bndl, gardener.DesiredContainerSpec{
+			IDMappings: &gardener.IDMappings{
+				UID: rootfs_provider.MappingList{{ContainerID: 0, HostID: 100000, Size: 65536}},
+				GID: rootfs_provider.MappingList{{ContainerID: 0, HostID: 200000, Size: 65536}},
+			},
+		})
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(newBndl.Spec.Linux.UIDMappings).To(Equal([]specs.IDMapping{{ContainerID: 0, HostID: 100000, Size: 65536}}))
+		Expect(newBndl.Spec.Linux.GIDMappings).To(Equal([]specs.IDMapping{{ContainerID: 0, HostID: 200000, Size: 65536}}))
+	})
+
+	It("keeps the default mappings when the container does not have its own", func() {
+		newBndl, err := bundlerules.UserNamespace{}.Apply(bndl, gardener.DesiredContainerSpec{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(newBndl).To(Equal(bndl))
+	})
+
+	It("does not add mappings to privileged containers", func() {
+		privileged := goci.Bundle()
+		newBndl, err := bundlerules.UserNamespace{}.Apply(privileged, gardener.DesiredContainerSpec{
+			Privileged: true,
+			IDMappings: &gardener.IDMappings{
+				UID: rootfs_provider.MappingList{{ContainerID: 0, HostID: 100000, Size: 65536}},
+			},
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(newBndl).To(Equal(privileged))
+	})
+})
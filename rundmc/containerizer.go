@@ -3,12 +3,15 @@ package rundmc
 import (
 	"fmt"
 	"io"
+	"os"
+	"path/filepath"
 
 	"github.com/cloudfoundry-incubator/garden"
 	"github.com/cloudfoundry-incubator/goci"
 	"github.com/cloudfoundry-incubator/guardian/gardener"
 	"github.com/cloudfoundry-incubator/guardian/logging"
 	"github.com/cloudfoundry-incubator/guardian/rundmc/depot"
+	"github.com/cloudfoundry-incubator/guardian/rundmc/runrunc"
 	"github.com/pivotal-golang/lager"
 )
 
@@ -19,16 +22,24 @@ import (
 //go:generate counterfeiter . NstarRunner
 //go:generate counterfeiter . ContainerStater
 //go:generate counterfeiter . Retrier
+//go:generate counterfeiter . DiskQuotaEnforcer
+//go:generate counterfeiter . BundleCheckpointer
+//go:generate counterfeiter . EventWatcher
+//go:generate counterfeiter . PeaCreator
+//go:generate counterfeiter . BindMounter
 
 type Depot interface {
 	Create(log lager.Logger, handle string, bundle depot.BundleSaver) error
+	Update(log lager.Logger, handle string, bundle depot.BundleSaver) error
 	Lookup(log lager.Logger, handle string) (path string, err error)
 	Destroy(log lager.Logger, handle string) error
 	Handles() ([]string, error)
+	Export(log lager.Logger, handle, dest string) error
+	Import(log lager.Logger, handle, src string) error
 }
 
 type BundleGenerator interface {
-	Generate(spec gardener.DesiredContainerSpec) *goci.Bndl
+	Generate(spec gardener.DesiredContainerSpec) (*goci.Bndl, error)
 }
 
 type Checker interface {
@@ -42,7 +53,42 @@ type ContainerStater interface {
 type BundleRunner interface {
 	Start(log lager.Logger, bundlePath, id string, io garden.ProcessIO) (garden.Process, error)
 	Exec(log lager.Logger, id, bundlePath string, spec garden.ProcessSpec, io garden.ProcessIO) (garden.Process, error)
+	Attach(log lager.Logger, id, processID string, io garden.ProcessIO) (garden.Process, error)
 	Kill(log lager.Logger, bundlePath string) error
+	Delete(log lager.Logger, handle string) error
+}
+
+type BundleCheckpointer interface {
+	Checkpoint(log lager.Logger, id, imagePath string) error
+	Restore(log lager.Logger, bundlePath, id, imagePath string, io garden.ProcessIO) (garden.Process, error)
+}
+
+// EventWatcher records events, such as OOMs, which happen to containers
+type EventWatcher interface {
+	Watch(log lager.Logger, handle string) error
+	Unwatch(handle string)
+	Events(handle string) []string
+}
+
+// PeaCreator runs a process in its own rootfs, inside the namespaces of the
+// container whose bundle and init process pid are given
+type PeaCreator interface {
+	CreatePea(log lager.Logger, bundlePath string, pid int, pea gardener.PeaSpec, io garden.ProcessIO) (garden.Process, error)
+}
+
+// PeaReaper is implemented by PeaCreators which can remove the peas in the
+// bundle of a container whose processes have exited, or all of its peas,
+// returning their IDs
+type PeaReaper interface {
+	Reap(log lager.Logger, bundlePath string, all bool) ([]string, error)
+}
+
+// BindMounter adds bind mounts to, and removes them from, the container whose
+// bundle is given, returning the bundle with its mounts updated
+type BindMounter interface {
+	Add(log lager.Logger, bundlePath string, mount garden.BindMount) (*goci.Bndl, error)
+	Remove(log lager.Logger, bundlePath, dstPath string) (*goci.Bndl, error)
+	Release(log lager.Logger, bundlePath string)
 }
 
 type NstarRunner interface {
@@ -50,6 +96,11 @@ type NstarRunner interface {
 	StreamOut(log lager.Logger, pid int, path string, user string) (io.ReadCloser, error)
 }
 
+type DiskQuotaEnforcer interface {
+	Prepare(log lager.Logger, spec gardener.DesiredContainerSpec) (gardener.DesiredContainerSpec, error)
+	Release(log lager.Logger, handle string) error
+}
+
 type Retrier interface {
 	Run(fn func() error) error
 }
@@ -63,9 +114,14 @@ type Containerizer struct {
 	stateChecker ContainerStater
 	nstar        NstarRunner
 	retrier      Retrier
+	quotas       DiskQuotaEnforcer
+	checkpointer BundleCheckpointer
+	events       EventWatcher
+	peas         PeaCreator
+	bindMounter  BindMounter
 }
 
-func New(depot Depot, bundler BundleGenerator, runner BundleRunner, startChecker Checker, stateChecker ContainerStater, nstarRunner NstarRunner, retrier Retrier) *Containerizer {
+func New(depot Depot, bundler BundleGenerator, runner BundleRunner, startChecker Checker, stateChecker ContainerStater, nstarRunner NstarRunner, retrier Retrier, quotas DiskQuotaEnforcer, checkpointer BundleCheckpointer, events EventWatcher, peas PeaCreator, bindMounter BindMounter) *Containerizer {
 	return &Containerizer{
 		depot:        depot,
 		bundler:      bundler,
@@ -74,6 +130,11 @@ func New(depot Depot, bundler BundleGenerator, runner BundleRunner, startChecker
 		stateChecker: stateChecker,
 		nstar:        nstarRunner,
 		retrier:      retrier,
+		quotas:       quotas,
+		checkpointer: checkpointer,
+		events:       events,
+		peas:         peas,
+		bindMounter:  bindMounter,
 	}
 }
 
@@ -84,36 +145,138 @@ func (c *Containerizer) Create(log lager.Logger, spec gardener.DesiredContainerS
 	log.Info("started")
 	defer log.Info("finished")
 
-	if err := c.depot.Create(log, spec.Handle, c.bundler.Generate(spec)); err != nil {
-		log.Error("create-failed", err)
+	if err := c.createBundle(log, spec); err != nil {
 		return err
 	}
 
+	return c.startBundle(log, spec.Handle)
+}
+
+// CreateBundle creates a bundle in the depot without starting it
+func (c *Containerizer) CreateBundle(log lager.Logger, spec gardener.DesiredContainerSpec) error {
+	log = log.Session("containerizer-create-bundle", lager.Data{"handle": spec.Handle})
+
+	log.Info("started")
+	defer log.Info("finished")
+
+	return c.createBundle(log, spec)
+}
+
+// StartBundle starts the init process of a bundle created by CreateBundle
+func (c *Containerizer) StartBundle(log lager.Logger, handle string) error {
+	log = log.Session("containerizer-start-bundle", lager.Data{"handle": handle})
+
+	log.Info("started")
+	defer log.Info("finished")
+
+	return c.startBundle(log, handle)
+}
+
+func (c *Containerizer) createBundle(log lager.Logger, spec gardener.DesiredContainerSpec) error {
+	spec, err := c.quotas.Prepare(log, spec)
+	if err != nil {
+		log.Error("prepare-disk-quota-failed", err)
+		return gardener.Classify(gardener.FailureBundle, err)
+	}
+
+	bndl, err := c.bundler.Generate(spec)
+	if err != nil {
+		log.Error("generate-bundle-failed", err)
+		c.releaseOrLog(log, spec.Handle)
+		return gardener.Classify(gardener.FailureBundle, err)
+	}
+
+	if err := c.depot.Create(log, spec.Handle, bndl); err != nil {
+		log.Error("create-failed", err)
+		c.releaseOrLog(log, spec.Handle)
+		return gardener.Classify(gardener.FailureBundle, err)
+	}
+
+	if err := c.saveProcessSettings(log, spec); err != nil {
+		log.Error("save-process-settings-failed", err)
+		if err := c.depot.Destroy(log, spec.Handle); err != nil {
+			log.Error("destroy-bundle-failed", err)
+		}
+		c.releaseOrLog(log, spec.Handle)
+		return gardener.Classify(gardener.FailureBundle, err)
+	}
+
+	return nil
+}
+
+// saveProcessSettings saves the settings of the container's processes which
+// are not part of its bundle's config alongside it: the umask, which is kept
+// out of the init process's environment, and the init process's restart
+// policy
+func (c *Containerizer) saveProcessSettings(log lager.Logger, spec gardener.DesiredContainerSpec) error {
+	restarts := spec.RestartPolicy != "" && spec.RestartPolicy != gardener.RestartNever
+	if spec.Umask == "" && !restarts {
+		return nil
+	}
+
 	path, err := c.depot.Lookup(log, spec.Handle)
 	if err != nil {
-		log.Error("lookup-failed", err)
 		return err
 	}
 
+	if spec.Umask != "" {
+		if err := runrunc.SaveUmask(path, spec.Umask); err != nil {
+			return err
+		}
+	}
+
+	if !restarts {
+		return nil
+	}
+
+	return SaveRestartPolicy(path, spec.RestartPolicy)
+}
+
+func (c *Containerizer) startBundle(log lager.Logger, handle string) error {
+	path, err := c.depot.Lookup(log, handle)
+	if err != nil {
+		log.Error("lookup-failed", err)
+		return gardener.Classify(gardener.FailureBundle, err)
+	}
+
 	stdoutR, stdoutW := io.Pipe()
-	_, err = c.runner.Start(log, path, spec.Handle, garden.ProcessIO{
+	_, err = c.runner.Start(log, path, handle, garden.ProcessIO{
 		Stdout: io.MultiWriter(logging.Writer(log), stdoutW),
 		Stderr: logging.Writer(log),
 	})
 
 	if err != nil {
 		log.Error("start", err)
-		return err
+		return gardener.Classify(gardener.FailureRuncStart, err)
 	}
 
 	if err := c.startChecker.Check(log, stdoutR); err != nil {
 		log.Error("check", err)
-		return err
+		return gardener.Classify(gardener.FailureRuncStart, err)
 	}
 
-	if err := c.waitForStateJSON(log, spec.Handle); err != nil {
+	if err := c.waitForStateJSON(log, handle); err != nil {
 		log.Error("check-state-failed", err)
-		return fmt.Errorf("create: state file not found for container: %s", err)
+		return gardener.Classify(gardener.FailureRuncStart, fmt.Errorf("create: state file not found for container: %s", err))
+	}
+
+	// the container works without events, so failing to watch it is not fatal
+	if err := c.events.Watch(log, handle); err != nil {
+		log.Error("watch-events-failed", err)
+	}
+
+	return nil
+}
+
+// Validate generates the container's bundle without creating anything, so
+// that the rules which check the spec, e.g. its bind mounts, devices and
+// capabilities, fail as they would when the container is created
+func (c *Containerizer) Validate(log lager.Logger, spec gardener.DesiredContainerSpec) error {
+	log = log.Session("containerizer-validate", lager.Data{"handle": spec.Handle})
+
+	if _, err := c.bundler.Generate(spec); err != nil {
+		log.Info("generate-bundle-failed", lager.Data{"error": err.Error()})
+		return gardener.Classify(gardener.FailureBundle, err)
 	}
 
 	return nil
@@ -135,6 +298,111 @@ func (c *Containerizer) Run(log lager.Logger, handle string, spec garden.Process
 	return c.runner.Exec(log, path, handle, spec, io)
 }
 
+// Attach attaches to a process running inside a container
+func (c *Containerizer) Attach(log lager.Logger, handle, processID string, io garden.ProcessIO) (garden.Process, error) {
+	log = log.Session("attach", lager.Data{"handle": handle, "process-id": processID})
+
+	log.Info("started")
+	defer log.Info("finished")
+
+	return c.runner.Attach(log, handle, processID, io)
+}
+
+// RunPea runs a process in its own rootfs inside a running container
+func (c *Containerizer) RunPea(log lager.Logger, handle string, pea gardener.PeaSpec, io garden.ProcessIO) (garden.Process, error) {
+	log = log.Session("run-pea", lager.Data{"handle": handle, "id": pea.ID})
+
+	log.Info("started")
+	defer log.Info("finished")
+
+	if c.peas == nil {
+		return nil, fmt.Errorf("run pea: process images are not supported")
+	}
+
+	path, err := c.depot.Lookup(log, handle)
+	if err != nil {
+		log.Error("lookup", err)
+		return nil, err
+	}
+
+	state, err := c.stateChecker.State(log, handle)
+	if err != nil {
+		log.Error("check-pid-failed", err)
+		return nil, fmt.Errorf("run pea: pid not found for container")
+	}
+
+	return c.peas.CreatePea(log, path, state.Pid, pea, io)
+}
+
+// ReapPeas removes the container's peas whose processes have exited, e.g.
+// while guardian was not running, returning their IDs
+func (c *Containerizer) ReapPeas(log lager.Logger, handle string) ([]string, error) {
+	log = log.Session("reap-peas", lager.Data{"handle": handle})
+
+	reaper, ok := c.peas.(PeaReaper)
+	if !ok {
+		return nil, nil
+	}
+
+	path, err := c.depot.Lookup(log, handle)
+	if err != nil {
+		log.Error("lookup", err)
+		return nil, err
+	}
+
+	return reaper.Reap(log, path, false)
+}
+
+// AddBindMount adds a bind mount to a running container, and to its bundle
+// so that the mount is kept when the container is recovered
+func (c *Containerizer) AddBindMount(log lager.Logger, handle string, mount garden.BindMount) error {
+	log = log.Session("add-bind-mount", lager.Data{"handle": handle, "dst": mount.DstPath})
+
+	log.Info("started")
+	defer log.Info("finished")
+
+	return c.updateBindMounts(log, handle, func(path string) (*goci.Bndl, error) {
+		return c.bindMounter.Add(log, path, mount)
+	})
+}
+
+// RemoveBindMount removes a bind mount from a running container and its
+// bundle
+func (c *Containerizer) RemoveBindMount(log lager.Logger, handle, dstPath string) error {
+	log = log.Session("remove-bind-mount", lager.Data{"handle": handle, "dst": dstPath})
+
+	log.Info("started")
+	defer log.Info("finished")
+
+	return c.updateBindMounts(log, handle, func(path string) (*goci.Bndl, error) {
+		return c.bindMounter.Remove(log, path, dstPath)
+	})
+}
+
+func (c *Containerizer) updateBindMounts(log lager.Logger, handle string, update func(path string) (*goci.Bndl, error)) error {
+	if c.bindMounter == nil {
+		return fmt.Errorf("changing the bind mounts of running containers is not supported")
+	}
+
+	path, err := c.depot.Lookup(log, handle)
+	if err != nil {
+		log.Error("lookup", err)
+		return err
+	}
+
+	bndl, err := update(path)
+	if err != nil {
+		return err
+	}
+
+	if err := c.depot.Update(log, handle, bndl); err != nil {
+		log.Error("update-bundle-failed", err)
+		return fmt.Errorf("persist bind mounts: %s", err)
+	}
+
+	return nil
+}
+
 // StreamIn streams files in to the container
 func (c *Containerizer) StreamIn(log lager.Logger, handle string, spec garden.StreamInSpec) error {
 	log = log.Session("stream-in", lager.Data{"handle": handle})
@@ -185,10 +453,21 @@ func (c *Containerizer) Destroy(log lager.Logger, handle string) error {
 	log.Info("started")
 	defer log.Info("finished")
 
-	_, err := c.stateChecker.State(log, handle)
+	state, err := c.stateChecker.State(log, handle)
 	if err != nil {
 		log.Error("pid-gone-skip-kill", err)
-		return c.depot.Destroy(log, handle)
+		return c.destroyBundle(log, handle)
+	}
+
+	// runc cannot kill a container whose init process has already died
+	if state.Stale {
+		log.Info("stale-state-deleting", lager.Data{"pid": state.Pid})
+		if err := c.runner.Delete(log, handle); err != nil {
+			log.Error("delete-failed", err)
+			return err
+		}
+
+		return c.destroyBundle(log, handle)
 	}
 
 	if err := c.runner.Kill(log, handle); err != nil {
@@ -196,7 +475,148 @@ func (c *Containerizer) Destroy(log lager.Logger, handle string) error {
 		return err
 	}
 
-	return c.depot.Destroy(log, handle)
+	return c.destroyBundle(log, handle)
+}
+
+// Restart re-starts the container if its init process has died and its
+// restart policy asks for it, returning whether it was restarted
+func (c *Containerizer) Restart(log lager.Logger, handle string) (bool, error) {
+	restarter, ok := c.runner.(initRestarter)
+	if !ok {
+		return false, nil
+	}
+
+	path, err := c.depot.Lookup(log, handle)
+	if err != nil {
+		return false, err
+	}
+
+	return restarter.Restart(log, path, handle)
+}
+
+// initRestarter is implemented by BundleRunners, such as the
+// RestartingRunner, which can re-start a container whose init process has died
+type initRestarter interface {
+	Restart(log lager.Logger, bundlePath, id string) (bool, error)
+}
+
+// Checkpoint dumps the container's processes to the checkpoint directory of
+// its bundle, stopping the container. If destination is not empty the bundle
+// and checkpoint images are also copied there, e.g. so that the container can
+// be restored on another host.
+func (c *Containerizer) Checkpoint(log lager.Logger, handle, destination string) error {
+	log = log.Session("checkpoint", lager.Data{"handle": handle, "destination": destination})
+
+	log.Info("started")
+	defer log.Info("finished")
+
+	path, err := c.depot.Lookup(log, handle)
+	if err != nil {
+		log.Error("lookup-failed", err)
+		return err
+	}
+
+	imagePath := filepath.Join(path, depot.CheckpointDir)
+	if err := os.RemoveAll(imagePath); err != nil {
+		log.Error("remove-old-checkpoint-failed", err)
+		return fmt.Errorf("checkpoint: remove old checkpoint: %s", err)
+	}
+
+	if err := c.checkpointer.Checkpoint(log, handle, imagePath); err != nil {
+		log.Error("checkpoint-failed", err)
+		return err
+	}
+
+	if destination == "" {
+		return nil
+	}
+
+	if err := c.depot.Export(log, handle, destination); err != nil {
+		log.Error("export-failed", err)
+		return err
+	}
+
+	return nil
+}
+
+// Restore restores a container from the images written by Checkpoint. If
+// source is not empty and the container's bundle is not already in the depot,
+// the bundle is first imported from source.
+func (c *Containerizer) Restore(log lager.Logger, handle, source string) error {
+	log = log.Session("restore", lager.Data{"handle": handle, "source": source})
+
+	log.Info("started")
+	defer log.Info("finished")
+
+	if source != "" {
+		if err := c.depot.Import(log, handle, source); err != nil && err != depot.ErrAlreadyExists {
+			log.Error("import-failed", err)
+			return err
+		}
+	}
+
+	path, err := c.depot.Lookup(log, handle)
+	if err != nil {
+		log.Error("lookup-failed", err)
+		return err
+	}
+
+	imagePath := filepath.Join(path, depot.CheckpointDir)
+	if _, err := os.Stat(imagePath); err != nil {
+		log.Error("no-checkpoint", err)
+		return fmt.Errorf("restore: no checkpoint found for container %s", handle)
+	}
+
+	_, err = c.checkpointer.Restore(log, path, handle, imagePath, garden.ProcessIO{
+		Stdout: logging.Writer(log),
+		Stderr: logging.Writer(log),
+	})
+	if err != nil {
+		log.Error("restore-failed", err)
+		return err
+	}
+
+	if err := c.waitForStateJSON(log, handle); err != nil {
+		log.Error("check-state-failed", err)
+		return fmt.Errorf("restore: state file not found for container: %s", err)
+	}
+
+	// the restored container has a new memory cgroup
+	if err := c.events.Watch(log, handle); err != nil {
+		log.Error("watch-events-failed", err)
+	}
+
+	return nil
+}
+
+func (c *Containerizer) destroyBundle(log lager.Logger, handle string) error {
+	c.events.Unwatch(handle)
+
+	if path, err := c.depot.Lookup(log, handle); err == nil {
+		if c.bindMounter != nil {
+			c.bindMounter.Release(log, path)
+		}
+
+		// the container's peas die with its pid namespace, but runc still
+		// has their state
+		if reaper, ok := c.peas.(PeaReaper); ok {
+			if _, err := reaper.Reap(log, path, true); err != nil {
+				log.Error("reap-peas-failed", err)
+			}
+		}
+	}
+
+	if err := c.depot.Destroy(log, handle); err != nil {
+		return err
+	}
+
+	return c.quotas.Release(log, handle)
+}
+
+func (c *Containerizer) releaseOrLog(log lager.Logger, handle string) {
+	if err := c.quotas.Release(log, handle); err != nil {
+		log.Error("release-disk-quota-failed", err)
+	}
 }
 
 func (c *Containerizer) Info(log lager.Logger, handle string) (gardener.ActualContainerSpec, error) {
@@ -208,6 +628,7 @@ func (c *Containerizer) Info(log lager.Logger, handle string) (gardener.ActualCo
 
 	return gardener.ActualContainerSpec{
 		BundlePath: bundlePath,
+		Events:     c.events.Events(handle),
 	}, nil
 }
 
@@ -3,13 +3,18 @@ package rundmc_test
 import (
 	"errors"
 	"io"
+	"io/ioutil"
 	"os"
+	"path/filepath"
 
 	"github.com/cloudfoundry-incubator/garden"
+	gardenfakes "github.com/cloudfoundry-incubator/garden/fakes"
 	"github.com/cloudfoundry-incubator/goci"
 	"github.com/cloudfoundry-incubator/guardian/gardener"
 	"github.com/cloudfoundry-incubator/guardian/rundmc"
+	"github.com/cloudfoundry-incubator/guardian/rundmc/depot"
 	"github.com/cloudfoundry-incubator/guardian/rundmc/fakes"
+	"github.com/cloudfoundry-incubator/guardian/rundmc/runrunc"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 	"github.com/onsi/gomega/gbytes"
@@ -27,6 +32,11 @@ var _ = Describe("Rundmc", func() {
 		fakeStater          *fakes.FakeContainerStater
 		logger              lager.Logger
 		fakeRetrier         *fakes.FakeRetrier
+		fakeQuotas          *fakes.FakeDiskQuotaEnforcer
+		fakeCheckpointer    *fakes.FakeBundleCheckpointer
+		fakeEvents          *fakes.FakeEventWatcher
+		fakePeas            *fakes.FakePeaCreator
+		fakeBindMounter     *fakes.FakeBindMounter
 
 		containerizer *rundmc.Containerizer
 	)
@@ -49,14 +59,44 @@ var _ = Describe("Rundmc", func() {
 			return fn()
 		}
 
-		containerizer = rundmc.New(fakeDepot, fakeBundler, fakeContainerRunner, fakeStartChecker, fakeStater, fakeNstarRunner, fakeRetrier)
+		fakeQuotas = new(fakes.FakeDiskQuotaEnforcer)
+		fakeQuotas.PrepareStub = func(_ lager.Logger, spec gardener.DesiredContainerSpec) (gardener.DesiredContainerSpec, error) {
+			return spec, nil
+		}
+
+		fakeCheckpointer = new(fakes.FakeBundleCheckpointer)
+		fakeEvents = new(fakes.FakeEventWatcher)
+		fakePeas = new(fakes.FakePeaCreator)
+		fakeBindMounter = new(fakes.FakeBindMounter)
+
+		containerizer = rundmc.New(fakeDepot, fakeBundler, fakeContainerRunner, fakeStartChecker, fakeStater, fakeNstarRunner, fakeRetrier, fakeQuotas, fakeCheckpointer, fakeEvents, fakePeas, fakeBindMounter)
+	})
+
+	Describe("Validate", func() {
+		It("generates the bundle without creating anything", func() {
+			spec := gardener.DesiredContainerSpec{Handle: "exuberant!", DryRun: true}
+			Expect(containerizer.Validate(logger, spec)).To(Succeed())
+
+			Expect(fakeBundler.GenerateArgsForCall(0)).To(Equal(spec))
+			Expect(fakeQuotas.PrepareCallCount()).To(Equal(0))
+			Expect(fakeDepot.CreateCallCount()).To(Equal(0))
+			Expect(fakeContainerRunner.StartCallCount()).To(Equal(0))
+		})
+
+		It("returns bundle failures as a create would", func() {
+			fakeBundler.GenerateReturns(nil, errors.New("invalid-seccomp"))
+
+			err := containerizer.Validate(logger, gardener.DesiredContainerSpec{Handle: "exuberant!", DryRun: true})
+			Expect(err).To(MatchError("invalid-seccomp"))
+			Expect(gardener.FailureClass(err, "")).To(Equal(gardener.FailureBundle))
+		})
 	})
 
 	Describe("Create", func() {
 		It("should ask the depot to create a container", func() {
 			var returnedBundle *goci.Bndl
-			fakeBundler.GenerateStub = func(spec gardener.DesiredContainerSpec) *goci.Bndl {
-				return returnedBundle
+			fakeBundler.GenerateStub = func(spec gardener.DesiredContainerSpec) (*goci.Bndl, error) {
+				return returnedBundle, nil
 			}
 
 			containerizer.Create(logger, gardener.DesiredContainerSpec{
@@ -70,6 +110,113 @@ var _ = Describe("Rundmc", func() {
 			Expect(bundle).To(Equal(returnedBundle))
 		})
 
+		Context("when the container has a umask", func() {
+			var bundlePath string
+
+			BeforeEach(func() {
+				var err error
+				bundlePath, err = ioutil.TempDir("", "bundle")
+				Expect(err).NotTo(HaveOccurred())
+				fakeDepot.LookupReturns(bundlePath, nil)
+			})
+
+			AfterEach(func() {
+				Expect(os.RemoveAll(bundlePath)).To(Succeed())
+			})
+
+			It("saves it in the bundle, for the runner to start the init process with", func() {
+				Expect(containerizer.Create(logger, gardener.DesiredContainerSpec{
+					Handle: "exuberant!",
+					Umask:  "0027",
+				})).To(Succeed())
+
+				Expect(runrunc.LoadUmask(bundlePath)).To(Equal("0027"))
+			})
+
+			Context("when the umask cannot be saved", func() {
+				BeforeEach(func() {
+					fakeDepot.LookupReturns(filepath.Join(bundlePath, "missing"), nil)
+				})
+
+				It("destroys the bundle and returns a bundle failure", func() {
+					err := containerizer.Create(logger, gardener.DesiredContainerSpec{
+						Handle: "exuberant!",
+						Umask:  "0027",
+					})
+					Expect(gardener.FailureClass(err, "")).To(Equal(gardener.FailureBundle))
+					Expect(fakeDepot.DestroyCallCount()).To(Equal(1))
+					Expect(fakeContainerRunner.StartCallCount()).To(Equal(0))
+				})
+			})
+		})
+
+		Context("when the container has a restart policy", func() {
+			var bundlePath string
+
+			BeforeEach(func() {
+				var err error
+				bundlePath, err = ioutil.TempDir("", "bundle")
+				Expect(err).NotTo(HaveOccurred())
+				fakeDepot.LookupReturns(bundlePath, nil)
+			})
+
+			AfterEach(func() {
+				Expect(os.RemoveAll(bundlePath)).To(Succeed())
+			})
+
+			It("saves it in the bundle", func() {
+				Expect(containerizer.Create(logger, gardener.DesiredContainerSpec{
+					Handle:        "exuberant!",
+					RestartPolicy: gardener.RestartAlways,
+				})).To(Succeed())
+
+				Expect(rundmc.LoadRestartPolicy(bundlePath)).To(Equal(gardener.RestartAlways))
+			})
+
+			Context("when the policy cannot be saved", func() {
+				BeforeEach(func() {
+					fakeDepot.LookupReturns(filepath.Join(bundlePath, "missing"), nil)
+				})
+
+				It("destroys the bundle and returns a bundle failure", func() {
+					err := containerizer.Create(logger, gardener.DesiredContainerSpec{
+						Handle:        "exuberant!",
+						RestartPolicy: gardener.RestartAlways,
+					})
+					Expect(gardener.FailureClass(err, "")).To(Equal(gardener.FailureBundle))
+					Expect(fakeDepot.DestroyCallCount()).To(Equal(1))
+					Expect(fakeContainerRunner.StartCallCount()).To(Equal(0))
+				})
+			})
+		})
+
+		Context("when generating the bundle fails", func() {
+			BeforeEach(func() {
+				fakeBundler.GenerateReturns(nil, errors.New("invalid-seccomp"))
+			})
+
+			It("returns the error", func() {
+				Expect(containerizer.Create(logger, gardener.DesiredContainerSpec{
+					Handle: "exuberant!",
+				})).To(MatchError("invalid-seccomp"))
+			})
+
+			It("classifies the failure as a bundle failure", func() {
+				err := containerizer.Create(logger, gardener.DesiredContainerSpec{Handle: "exuberant!"})
+				Expect(gardener.FailureClass(err, "")).To(Equal(gardener.FailureBundle))
+			})
+
+			It("does not create the depot directory", func() {
+				containerizer.Create(logger, gardener.DesiredContainerSpec{Handle: "exuberant!"})
+				Expect(fakeDepot.CreateCallCount()).To(Equal(0))
+			})
+
+			It("releases the disk quota", func() {
+				containerizer.Create(logger, gardener.DesiredContainerSpec{Handle: "exuberant!"})
+				Expect(fakeQuotas.ReleaseCallCount()).To(Equal(1))
+			})
+		})
+
 		Context("when creating the depot directory fails", func() {
 			It("returns an error", func() {
 				fakeDepot.CreateReturns(errors.New("blam"))
@@ -77,6 +224,44 @@ var _ = Describe("Rundmc", func() {
 					Handle: "exuberant!",
 				})).NotTo(Succeed())
 			})
+
+			It("releases the disk quota", func() {
+				fakeDepot.CreateReturns(errors.New("blam"))
+				containerizer.Create(logger, gardener.DesiredContainerSpec{
+					Handle: "exuberant!",
+				})
+
+				Expect(fakeQuotas.ReleaseCallCount()).To(Equal(1))
+				Expect(arg2(fakeQuotas.ReleaseArgsForCall(0))).To(Equal("exuberant!"))
+			})
+		})
+
+		It("generates the bundle from the spec prepared by the disk quota enforcer", func() {
+			fakeQuotas.PrepareStub = func(_ lager.Logger, spec gardener.DesiredContainerSpec) (gardener.DesiredContainerSpec, error) {
+				spec.BindMounts = append(spec.BindMounts, garden.BindMount{SrcPath: "/scratch", DstPath: "/tmp"})
+				return spec, nil
+			}
+
+			Expect(containerizer.Create(logger, gardener.DesiredContainerSpec{
+				Handle: "exuberant!",
+			})).To(Succeed())
+
+			Expect(fakeBundler.GenerateCallCount()).To(Equal(1))
+			Expect(fakeBundler.GenerateArgsForCall(0).BindMounts).To(ConsistOf(garden.BindMount{SrcPath: "/scratch", DstPath: "/tmp"}))
+		})
+
+		Context("when preparing the disk quota fails", func() {
+			BeforeEach(func() {
+				fakeQuotas.PrepareReturns(gardener.DesiredContainerSpec{}, errors.New("no quota for you"))
+			})
+
+			It("returns the error without creating the bundle", func() {
+				Expect(containerizer.Create(logger, gardener.DesiredContainerSpec{
+					Handle: "exuberant!",
+				})).To(MatchError("no quota for you"))
+
+				Expect(fakeDepot.CreateCallCount()).To(Equal(0))
+			})
 		})
 
 		It("should start a container in the created directory", func() {
@@ -126,6 +311,26 @@ var _ = Describe("Rundmc", func() {
 
 				Expect(containerizer.Create(logger, gardener.DesiredContainerSpec{Handle: "the-handle"})).To(MatchError("I died"))
 			})
+
+			It("classifies the failure as a failure to start runc", func() {
+				fakeStartChecker.CheckReturns(errors.New("I died"))
+
+				err := containerizer.Create(logger, gardener.DesiredContainerSpec{Handle: "the-handle"})
+				Expect(gardener.FailureClass(err, "")).To(Equal(gardener.FailureRuncStart))
+			})
+		})
+
+		It("watches the container for events", func() {
+			Expect(containerizer.Create(logger, gardener.DesiredContainerSpec{Handle: "the-handle"})).To(Succeed())
+			Expect(fakeEvents.WatchCallCount()).To(Equal(1))
+			Expect(arg2(fakeEvents.WatchArgsForCall(0))).To(Equal("the-handle"))
+		})
+
+		Context("when the container cannot be watched", func() {
+			It("still creates the container", func() {
+				fakeEvents.WatchReturns(errors.New("no memory cgroup"))
+				Expect(containerizer.Create(logger, gardener.DesiredContainerSpec{Handle: "the-handle"})).To(Succeed())
+			})
 		})
 
 		Context("when the state file was not written even after PID 1 has started", func() {
@@ -134,6 +339,12 @@ var _ = Describe("Rundmc", func() {
 				Expect(containerizer.Create(logger, gardener.DesiredContainerSpec{})).To(MatchError(ContainSubstring("create: state file not found")))
 			})
 
+			It("does not watch the container", func() {
+				fakeStater.StateReturns(rundmc.State{}, errors.New("state-not-found"))
+				containerizer.Create(logger, gardener.DesiredContainerSpec{})
+				Expect(fakeEvents.WatchCallCount()).To(Equal(0))
+			})
+
 			Context("if it eventually appears", func() {
 				BeforeEach(func() {
 					stateCallCounter := 0
@@ -159,6 +370,32 @@ var _ = Describe("Rundmc", func() {
 		})
 	})
 
+	Describe("CreateBundle", func() {
+		It("creates the bundle in the depot without starting it", func() {
+			Expect(containerizer.CreateBundle(logger, gardener.DesiredContainerSpec{Handle: "exuberant!"})).To(Succeed())
+
+			Expect(fakeDepot.CreateCallCount()).To(Equal(1))
+			_, handle, _ := fakeDepot.CreateArgsForCall(0)
+			Expect(handle).To(Equal("exuberant!"))
+
+			Expect(fakeContainerRunner.StartCallCount()).To(Equal(0))
+		})
+	})
+
+	Describe("StartBundle", func() {
+		It("starts the container in its depot directory and watches it", func() {
+			Expect(containerizer.StartBundle(logger, "exuberant!")).To(Succeed())
+
+			Expect(fakeContainerRunner.StartCallCount()).To(Equal(1))
+			_, path, id, _ := fakeContainerRunner.StartArgsForCall(0)
+			Expect(path).To(Equal("/path/to/exuberant!"))
+			Expect(id).To(Equal("exuberant!"))
+
+			Expect(fakeDepot.CreateCallCount()).To(Equal(0))
+			Expect(fakeEvents.WatchCallCount()).To(Equal(1))
+		})
+	})
+
 	Describe("Run", func() {
 		It("should ask the execer to exec a process in the container", func() {
 			containerizer.Run(logger, "some-handle", garden.ProcessSpec{Path: "hello"}, garden.ProcessIO{})
@@ -185,6 +422,140 @@ var _ = Describe("Rundmc", func() {
 		})
 	})
 
+	Describe("Attach", func() {
+		It("asks the runner to attach to the process in the container", func() {
+			process := new(gardenfakes.FakeProcess)
+			fakeContainerRunner.AttachReturns(process, nil)
+
+			attached, err := containerizer.Attach(logger, "some-handle", "some-process-id", garden.ProcessIO{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(attached).To(Equal(process))
+
+			_, id, processID, _ := fakeContainerRunner.AttachArgsForCall(0)
+			Expect(id).To(Equal("some-handle"))
+			Expect(processID).To(Equal("some-process-id"))
+		})
+	})
+
+	Describe("RunPea", func() {
+		var pea gardener.PeaSpec
+
+		BeforeEach(func() {
+			pea = gardener.PeaSpec{
+				ID:         "some-handle-pea-1",
+				RootFSPath: "/path/to/pea/rootfs",
+				Process:    garden.ProcessSpec{Path: "hello"},
+			}
+
+			fakeStater.StateReturns(rundmc.State{Pid: 12}, nil)
+		})
+
+		It("creates the pea in the container's bundle and namespaces", func() {
+			process := new(gardenfakes.FakeProcess)
+			fakePeas.CreatePeaReturns(process, nil)
+
+			Expect(containerizer.RunPea(logger, "some-handle", pea, garden.ProcessIO{})).To(Equal(process))
+
+			Expect(fakePeas.CreatePeaCallCount()).To(Equal(1))
+			_, bundlePath, pid, actualPea, _ := fakePeas.CreatePeaArgsForCall(0)
+			Expect(bundlePath).To(Equal("/path/to/some-handle"))
+			Expect(pid).To(Equal(12))
+			Expect(actualPea).To(Equal(pea))
+		})
+
+		Context("when the container's pid cannot be found", func() {
+			It("returns an error without creating the pea", func() {
+				fakeStater.StateReturns(rundmc.State{}, errors.New("no state"))
+
+				_, err := containerizer.RunPea(logger, "some-handle", pea, garden.ProcessIO{})
+				Expect(err).To(MatchError("run pea: pid not found for container"))
+				Expect(fakePeas.CreatePeaCallCount()).To(Equal(0))
+			})
+		})
+
+		Context("when peas are not supported", func() {
+			It("returns an error", func() {
+				containerizer = rundmc.New(fakeDepot, fakeBundler, fakeContainerRunner, fakeStartChecker, fakeStater, fakeNstarRunner, fakeRetrier, fakeQuotas, fakeCheckpointer, fakeEvents, nil, fakeBindMounter)
+
+				_, err := containerizer.RunPea(logger, "some-handle", pea, garden.ProcessIO{})
+				Expect(err).To(MatchError("run pea: process images are not supported"))
+			})
+		})
+	})
+
+	Describe("AddBindMount", func() {
+		var (
+			mount garden.BindMount
+			bndl  *goci.Bndl
+		)
+
+		BeforeEach(func() {
+			mount = garden.BindMount{SrcPath: "/src", DstPath: "/dst"}
+			bndl = goci.Bundle().WithRootFS("/rootfs")
+			fakeBindMounter.AddReturns(bndl, nil)
+		})
+
+		It("adds the mount to the container", func() {
+			Expect(containerizer.AddBindMount(logger, "some-handle", mount)).To(Succeed())
+
+			Expect(fakeBindMounter.AddCallCount()).To(Equal(1))
+			_, path, actualMount := fakeBindMounter.AddArgsForCall(0)
+			Expect(path).To(Equal("/path/to/some-handle"))
+			Expect(actualMount).To(Equal(mount))
+		})
+
+		It("saves the updated bundle in the depot", func() {
+			Expect(containerizer.AddBindMount(logger, "some-handle", mount)).To(Succeed())
+
+			Expect(fakeDepot.UpdateCallCount()).To(Equal(1))
+			_, handle, saved := fakeDepot.UpdateArgsForCall(0)
+			Expect(handle).To(Equal("some-handle"))
+			Expect(saved).To(Equal(bndl))
+		})
+
+		Context("when adding the mount fails", func() {
+			It("returns the error without updating the bundle", func() {
+				fakeBindMounter.AddReturns(nil, errors.New("no such file"))
+
+				Expect(containerizer.AddBindMount(logger, "some-handle", mount)).To(MatchError("no such file"))
+				Expect(fakeDepot.UpdateCallCount()).To(Equal(0))
+			})
+		})
+
+		Context("when updating the bundle fails", func() {
+			It("returns an error", func() {
+				fakeDepot.UpdateReturns(errors.New("disk full"))
+
+				Expect(containerizer.AddBindMount(logger, "some-handle", mount)).To(MatchError("persist bind mounts: disk full"))
+			})
+		})
+
+		Context("when bind mounts cannot be changed", func() {
+			It("returns an error", func() {
+				containerizer = rundmc.New(fakeDepot, fakeBundler, fakeContainerRunner, fakeStartChecker, fakeStater, fakeNstarRunner, fakeRetrier, fakeQuotas, fakeCheckpointer, fakeEvents, fakePeas, nil)
+
+				Expect(containerizer.AddBindMount(logger, "some-handle", mount)).To(MatchError("changing the bind mounts of running containers is not supported"))
+			})
+		})
+	})
+
+	Describe("RemoveBindMount", func() {
+		It("removes the mount from the container and saves the updated bundle", func() {
+			bndl := goci.Bundle().WithRootFS("/rootfs")
+			fakeBindMounter.RemoveReturns(bndl, nil)
+
+			Expect(containerizer.RemoveBindMount(logger, "some-handle", "/dst")).To(Succeed())
+
+			_, path, dst := fakeBindMounter.RemoveArgsForCall(0)
+			Expect(path).To(Equal("/path/to/some-handle"))
+			Expect(dst).To(Equal("/dst"))
+
+			_, handle, saved := fakeDepot.UpdateArgsForCall(0)
+			Expect(handle).To(Equal("some-handle"))
+			Expect(saved).To(Equal(bndl))
+		})
+	})
+
 	Describe("StreamIn", func() {
 		It("should execute the NSTar command with the container PID", func() {
 			fakeStater.StateReturns(rundmc.State{
@@ -271,6 +642,37 @@ var _ = Describe("Rundmc", func() {
 				Expect(fakeDepot.DestroyCallCount()).To(Equal(1))
 				Expect(arg2(fakeDepot.DestroyArgsForCall(0))).To(Equal("some-handle"))
 			})
+
+			It("should release the disk quota", func() {
+				Expect(containerizer.Destroy(logger, "some-handle")).To(Succeed())
+				Expect(fakeQuotas.ReleaseCallCount()).To(Equal(1))
+				Expect(arg2(fakeQuotas.ReleaseArgsForCall(0))).To(Equal("some-handle"))
+			})
+
+			It("stops watching the container", func() {
+				Expect(containerizer.Destroy(logger, "some-handle")).To(Succeed())
+				Expect(fakeEvents.UnwatchCallCount()).To(Equal(1))
+				Expect(fakeEvents.UnwatchArgsForCall(0)).To(Equal("some-handle"))
+			})
+
+			It("releases the bind mounts added to the container before destroying its bundle", func() {
+				fakeBindMounter.ReleaseStub = func(lager.Logger, string) {
+					Expect(fakeDepot.DestroyCallCount()).To(Equal(0))
+				}
+
+				Expect(containerizer.Destroy(logger, "some-handle")).To(Succeed())
+				Expect(fakeBindMounter.ReleaseCallCount()).To(Equal(1))
+				_, path := fakeBindMounter.ReleaseArgsForCall(0)
+				Expect(path).To(Equal("/path/to/some-handle"))
+			})
+
+			Context("when destroying the depot directory fails", func() {
+				It("does not release the disk quota", func() {
+					fakeDepot.DestroyReturns(errors.New("busy"))
+					Expect(containerizer.Destroy(logger, "some-handle")).To(MatchError("busy"))
+					Expect(fakeQuotas.ReleaseCallCount()).To(Equal(0))
+				})
+			})
 		})
 
 		Context("when state.json exists", func() {
@@ -300,6 +702,57 @@ var _ = Describe("Rundmc", func() {
 				})
 			})
 		})
+
+		Context("when state.json is stale", func() {
+			BeforeEach(func() {
+				fakeStater.StateReturns(rundmc.State{Pid: 42, Stale: true}, nil)
+			})
+
+			It("force deletes the container rather than killing it", func() {
+				Expect(containerizer.Destroy(logger, "some-handle")).To(Succeed())
+				Expect(fakeContainerRunner.KillCallCount()).To(Equal(0))
+				Expect(fakeContainerRunner.DeleteCallCount()).To(Equal(1))
+				Expect(arg2(fakeContainerRunner.DeleteArgsForCall(0))).To(Equal("some-handle"))
+			})
+
+			It("destroys the depot directory", func() {
+				Expect(containerizer.Destroy(logger, "some-handle")).To(Succeed())
+				Expect(fakeDepot.DestroyCallCount()).To(Equal(1))
+			})
+
+			Context("when delete fails", func() {
+				It("returns the error without destroying the depot directory", func() {
+					fakeContainerRunner.DeleteReturns(errors.New("runc delete failed"))
+					Expect(containerizer.Destroy(logger, "some-handle")).To(MatchError("runc delete failed"))
+					Expect(fakeDepot.DestroyCallCount()).To(Equal(0))
+				})
+			})
+		})
+	})
+
+	Describe("Restart", func() {
+		Context("when the runner cannot re-start containers", func() {
+			It("does not re-start the container", func() {
+				restarted, err := containerizer.Restart(logger, "some-handle")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(restarted).To(BeFalse())
+				Expect(fakeContainerRunner.StartCallCount()).To(Equal(0))
+			})
+		})
+
+		Context("when the runner can re-start containers", func() {
+			It("reads the restart policy from the container's bundle", func() {
+				restartingRunner := &rundmc.RestartingRunner{BundleRunner: fakeContainerRunner}
+				containerizer = rundmc.New(fakeDepot, fakeBundler, restartingRunner, fakeStartChecker, fakeStater, fakeNstarRunner, fakeRetrier, fakeQuotas, fakeCheckpointer, fakeEvents, fakePeas, fakeBindMounter)
+
+				restarted, err := containerizer.Restart(logger, "some-handle")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(restarted).To(BeFalse())
+
+				_, handle := fakeDepot.LookupArgsForCall(0)
+				Expect(handle).To(Equal("some-handle"))
+			})
+		})
 	})
 
 	Describe("Info", func() {
@@ -309,6 +762,15 @@ var _ = Describe("Rundmc", func() {
 			Expect(actualSpec.BundlePath).To(Equal("/path/to/some-handle"))
 		})
 
+		It("includes the container's events", func() {
+			fakeEvents.EventsReturns([]string{"out of memory"})
+
+			actualSpec, err := containerizer.Info(logger, "some-handle")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(actualSpec.Events).To(Equal([]string{"out of memory"}))
+			Expect(fakeEvents.EventsArgsForCall(0)).To(Equal("some-handle"))
+		})
+
 		Context("when the lookup fails", func() {
 			It("should return the error", func() {
 				fakeDepot.LookupReturns("", errors.New("spiderman-error"))
@@ -342,6 +804,173 @@ var _ = Describe("Rundmc", func() {
 			})
 		})
 	})
+
+	Describe("Checkpoint", func() {
+		var bundlePath string
+
+		BeforeEach(func() {
+			var err error
+			bundlePath, err = ioutil.TempDir("", "bundle")
+			Expect(err).NotTo(HaveOccurred())
+
+			fakeDepot.LookupReturns(bundlePath, nil)
+		})
+
+		AfterEach(func() {
+			Expect(os.RemoveAll(bundlePath)).To(Succeed())
+		})
+
+		It("checkpoints the container to the checkpoint directory of its bundle", func() {
+			Expect(containerizer.Checkpoint(logger, "some-handle", "")).To(Succeed())
+
+			Expect(fakeCheckpointer.CheckpointCallCount()).To(Equal(1))
+			_, id, imagePath := fakeCheckpointer.CheckpointArgsForCall(0)
+			Expect(id).To(Equal("some-handle"))
+			Expect(imagePath).To(Equal(filepath.Join(bundlePath, "checkpoint")))
+		})
+
+		It("removes the images of any previous checkpoint first", func() {
+			Expect(os.MkdirAll(filepath.Join(bundlePath, "checkpoint"), 0700)).To(Succeed())
+			Expect(ioutil.WriteFile(filepath.Join(bundlePath, "checkpoint", "old.img"), nil, 0600)).To(Succeed())
+
+			Expect(containerizer.Checkpoint(logger, "some-handle", "")).To(Succeed())
+			Expect(filepath.Join(bundlePath, "checkpoint", "old.img")).NotTo(BeAnExistingFile())
+		})
+
+		It("does not export the bundle when there is no destination", func() {
+			Expect(containerizer.Checkpoint(logger, "some-handle", "")).To(Succeed())
+			Expect(fakeDepot.ExportCallCount()).To(Equal(0))
+		})
+
+		Context("when a destination is given", func() {
+			It("exports the bundle to the destination", func() {
+				Expect(containerizer.Checkpoint(logger, "some-handle", "/some/destination")).To(Succeed())
+
+				Expect(fakeDepot.ExportCallCount()).To(Equal(1))
+				_, handle, dest := fakeDepot.ExportArgsForCall(0)
+				Expect(handle).To(Equal("some-handle"))
+				Expect(dest).To(Equal("/some/destination"))
+			})
+
+			Context("and exporting fails", func() {
+				It("returns the error", func() {
+					fakeDepot.ExportReturns(errors.New("disk full"))
+					Expect(containerizer.Checkpoint(logger, "some-handle", "/some/destination")).To(MatchError("disk full"))
+				})
+			})
+		})
+
+		Context("when checkpointing fails", func() {
+			BeforeEach(func() {
+				fakeCheckpointer.CheckpointReturns(errors.New("criu failed"))
+			})
+
+			It("returns the error without exporting", func() {
+				Expect(containerizer.Checkpoint(logger, "some-handle", "/some/destination")).To(MatchError("criu failed"))
+				Expect(fakeDepot.ExportCallCount()).To(Equal(0))
+			})
+		})
+
+		Context("when the container does not exist", func() {
+			It("returns the error", func() {
+				fakeDepot.LookupReturns("", errors.New("does not exist"))
+				Expect(containerizer.Checkpoint(logger, "some-handle", "")).To(MatchError("does not exist"))
+			})
+		})
+	})
+
+	Describe("Restore", func() {
+		var bundlePath string
+
+		BeforeEach(func() {
+			var err error
+			bundlePath, err = ioutil.TempDir("", "bundle")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(os.MkdirAll(filepath.Join(bundlePath, "checkpoint"), 0700)).To(Succeed())
+
+			fakeDepot.LookupReturns(bundlePath, nil)
+		})
+
+		AfterEach(func() {
+			Expect(os.RemoveAll(bundlePath)).To(Succeed())
+		})
+
+		It("restores the container from the checkpoint images in its bundle", func() {
+			Expect(containerizer.Restore(logger, "some-handle", "")).To(Succeed())
+
+			Expect(fakeCheckpointer.RestoreCallCount()).To(Equal(1))
+			_, path, id, imagePath, _ := fakeCheckpointer.RestoreArgsForCall(0)
+			Expect(path).To(Equal(bundlePath))
+			Expect(id).To(Equal("some-handle"))
+			Expect(imagePath).To(Equal(filepath.Join(bundlePath, "checkpoint")))
+		})
+
+		It("waits for the container's state file", func() {
+			Expect(containerizer.Restore(logger, "some-handle", "")).To(Succeed())
+			Expect(fakeStater.StateCallCount()).To(Equal(1))
+		})
+
+		It("watches the restored container for events", func() {
+			Expect(containerizer.Restore(logger, "some-handle", "")).To(Succeed())
+			Expect(fakeEvents.WatchCallCount()).To(Equal(1))
+			Expect(arg2(fakeEvents.WatchArgsForCall(0))).To(Equal("some-handle"))
+		})
+
+		It("does not import the bundle when there is no source", func() {
+			Expect(containerizer.Restore(logger, "some-handle", "")).To(Succeed())
+			Expect(fakeDepot.ImportCallCount()).To(Equal(0))
+		})
+
+		Context("when a source is given", func() {
+			It("imports the bundle from the source", func() {
+				Expect(containerizer.Restore(logger, "some-handle", "/some/source")).To(Succeed())
+
+				Expect(fakeDepot.ImportCallCount()).To(Equal(1))
+				_, handle, src := fakeDepot.ImportArgsForCall(0)
+				Expect(handle).To(Equal("some-handle"))
+				Expect(src).To(Equal("/some/source"))
+			})
+
+			Context("and the bundle is already in the depot", func() {
+				It("restores from the existing bundle", func() {
+					fakeDepot.ImportReturns(depot.ErrAlreadyExists)
+					Expect(containerizer.Restore(logger, "some-handle", "/some/source")).To(Succeed())
+					Expect(fakeCheckpointer.RestoreCallCount()).To(Equal(1))
+				})
+			})
+
+			Context("and importing fails", func() {
+				It("returns the error without restoring", func() {
+					fakeDepot.ImportReturns(errors.New("no such directory"))
+					Expect(containerizer.Restore(logger, "some-handle", "/some/source")).To(MatchError("no such directory"))
+					Expect(fakeCheckpointer.RestoreCallCount()).To(Equal(0))
+				})
+			})
+		})
+
+		Context("when the container has not been checkpointed", func() {
+			It("returns an error without restoring", func() {
+				Expect(os.RemoveAll(filepath.Join(bundlePath, "checkpoint"))).To(Succeed())
+
+				Expect(containerizer.Restore(logger, "some-handle", "")).To(MatchError("restore: no checkpoint found for container some-handle"))
+				Expect(fakeCheckpointer.RestoreCallCount()).To(Equal(0))
+			})
+		})
+
+		Context("when restoring fails", func() {
+			It("returns the error", func() {
+				fakeCheckpointer.RestoreReturns(nil, errors.New("criu failed"))
+				Expect(containerizer.Restore(logger, "some-handle", "")).To(MatchError("criu failed"))
+			})
+		})
+
+		Context("when the state file does not appear", func() {
+			It("returns an error", func() {
+				fakeStater.StateReturns(rundmc.State{}, errors.New("no state"))
+				Expect(containerizer.Restore(logger, "some-handle", "")).To(MatchError(ContainSubstring("state file not found")))
+			})
+		})
+	})
 })
 
 func arg2(_ lager.Logger, i interface{}) interface{} {
@@ -0,0 +1,167 @@
+package rundmc
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cloudfoundry-incubator/garden"
+	"github.com/cloudfoundry-incubator/guardian/gardener"
+	"github.com/pivotal-golang/lager"
+)
+
+// CgroupCPULimiter changes the CPU limits of running containers by writing
+// to their cpu cgroups, which are mounted under CgroupPath.
+type CgroupCPULimiter struct {
+	CgroupPath string
+	// Unified is whether CgroupPath is cgroup v2's unified hierarchy, in
+	// which shares are converted to a cpu.weight and the quota is cpu.max
+	Unified bool
+}
+
+// LimitCPU sets the container's cpu shares, unless they are 0, and its CFS
+// quota. A zero quota removes the container's CPU cap.
+func (l *CgroupCPULimiter) LimitCPU(log lager.Logger, handle string, limits garden.CPULimits, quota gardener.CPUQuota) error {
+	log = log.Session("limit-cpu", lager.Data{"handle": handle, "shares": limits.LimitInShares, "quota": quota})
+
+	log.Info("started")
+	defer log.Info("finished")
+
+	if l.Unified {
+		return l.limitUnified(handle, limits, quota)
+	}
+
+	if limits.LimitInShares != 0 {
+		if err := l.write(handle, "cpu.shares", strconv.FormatUint(limits.LimitInShares, 10)); err != nil {
+			return err
+		}
+	}
+
+	if quota.Quota == 0 {
+		return l.write(handle, "cpu.cfs_quota_us", "-1")
+	}
+
+	if err := l.write(handle, "cpu.cfs_period_us", strconv.FormatUint(quota.Period, 10)); err != nil {
+		return err
+	}
+
+	return l.write(handle, "cpu.cfs_quota_us", strconv.FormatInt(quota.Quota, 10))
+}
+
+func (l *CgroupCPULimiter) limitUnified(handle string, limits garden.CPULimits, quota gardener.CPUQuota) error {
+	if limits.LimitInShares != 0 {
+		weight := SharesToWeight(limits.LimitInShares)
+		if err := l.write(handle, "cpu.weight", strconv.FormatUint(weight, 10)); err != nil {
+			return err
+		}
+	}
+
+	if quota.Quota == 0 {
+		return l.write(handle, "cpu.max", "max")
+	}
+
+	return l.write(handle, "cpu.max", fmt.Sprintf("%d %d", quota.Quota, quota.Period))
+}
+
+func (l *CgroupCPULimiter) CurrentCPULimits(log lager.Logger, handle string) (garden.CPULimits, error) {
+	file := "cpu.shares"
+	if l.Unified {
+		file = "cpu.weight"
+	}
+
+	data, err := ioutil.ReadFile(l.path(handle, file))
+	if err != nil {
+		return garden.CPULimits{}, fmt.Errorf("read cpu shares: %s", err)
+	}
+
+	shares, err := strconv.ParseUint(strings.TrimSpace(string(data)), 10, 64)
+	if err != nil {
+		return garden.CPULimits{}, fmt.Errorf("read cpu shares: %s", err)
+	}
+
+	if l.Unified {
+		shares = WeightToShares(shares)
+	}
+
+	return garden.CPULimits{LimitInShares: shares}, nil
+}
+
+// CPUUsage is the total CPU time used by the container's processes, from
+// cpuacct.usage, or from the usage_usec of cpu.stat in the unified hierarchy
+func (l *CgroupCPULimiter) CPUUsage(log lager.Logger, handle string) (time.Duration, error) {
+	if l.Unified {
+		usec, err := readCPUStat(filepath.Join(l.CgroupPath, handle, "cpu.stat"), "usage_usec")
+		if err != nil {
+			return 0, fmt.Errorf("read cpu usage: %s", err)
+		}
+
+		return time.Duration(usec) * time.Microsecond, nil
+	}
+
+	nsec, err := readCgroupUint(filepath.Join(l.CgroupPath, "cpuacct", handle), "cpuacct.usage")
+	if err != nil {
+		return 0, fmt.Errorf("read cpu usage: %s", err)
+	}
+
+	return time.Duration(nsec), nil
+}
+
+// readCPUStat reads one field of a cgroup v2 cpu.stat file, whose lines are
+// 'field value'
+func readCPUStat(path, field string) (uint64, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, line := range strings.Split(string(data), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 2 && fields[0] == field {
+			return strconv.ParseUint(fields[1], 10, 64)
+		}
+	}
+
+	return 0, fmt.Errorf("no %s in %s", field, path)
+}
+
+// SharesToWeight converts cgroup v1 cpu shares, in [2, 262144], to a cgroup
+// v2 cpu.weight, in [1, 10000], the same way runc does
+func SharesToWeight(shares uint64) uint64 {
+	if shares < 2 {
+		shares = 2
+	}
+
+	if shares > 262144 {
+		shares = 262144
+	}
+
+	return 1 + ((shares-2)*9999)/262142
+}
+
+// WeightToShares is the (approximate) inverse of SharesToWeight
+func WeightToShares(weight uint64) uint64 {
+	if weight < 1 {
+		weight = 1
+	}
+
+	return 2 + ((weight-1)*262142)/9999
+}
+
+func (l *CgroupCPULimiter) write(handle, file, value string) error {
+	if err := ioutil.WriteFile(l.path(handle, file), []byte(value), 0644); err != nil {
+		return fmt.Errorf("write %s: %s", file, err)
+	}
+
+	return nil
+}
+
+func (l *CgroupCPULimiter) path(handle, file string) string {
+	if l.Unified {
+		return filepath.Join(l.CgroupPath, handle, file)
+	}
+
+	return filepath.Join(l.CgroupPath, "cpu", handle, file)
+}
@@ -0,0 +1,144 @@
+package rundmc_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/cloudfoundry-incubator/garden"
+	"github.com/cloudfoundry-incubator/guardian/gardener"
+	"github.com/cloudfoundry-incubator/guardian/rundmc"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/pivotal-golang/lager"
+	"github.com/pivotal-golang/lager/lagertest"
+)
+
+var _ = Describe("CgroupCPULimiter", func() {
+	var (
+		limiter   *rundmc.CgroupCPULimiter
+		logger    lager.Logger
+		tmp       string
+		cgroupDir string
+	)
+
+	readCgroupFile := func(name string) string {
+		data, err := ioutil.ReadFile(filepath.Join(cgroupDir, name))
+		Expect(err).NotTo(HaveOccurred())
+		return string(data)
+	}
+
+	BeforeEach(func() {
+		logger = lagertest.NewTestLogger("test")
+
+		var err error
+		tmp, err = ioutil.TempDir("", "cpulimitertest")
+		Expect(err).NotTo(HaveOccurred())
+
+		cgroupDir = filepath.Join(tmp, "cpu", "some-handle")
+		Expect(os.MkdirAll(cgroupDir, 0755)).To(Succeed())
+
+		limiter = &rundmc.CgroupCPULimiter{CgroupPath: tmp}
+	})
+
+	AfterEach(func() {
+		Expect(os.RemoveAll(tmp)).To(Succeed())
+	})
+
+	Describe("LimitCPU", func() {
+		It("writes the shares, quota and period to the container's cpu cgroup", func() {
+			Expect(limiter.LimitCPU(logger, "some-handle", garden.CPULimits{LimitInShares: 512}, gardener.CPUQuota{Quota: 50000, Period: 200000})).To(Succeed())
+
+			Expect(readCgroupFile("cpu.shares")).To(Equal("512"))
+			Expect(readCgroupFile("cpu.cfs_quota_us")).To(Equal("50000"))
+			Expect(readCgroupFile("cpu.cfs_period_us")).To(Equal("200000"))
+		})
+
+		It("removes the quota when it is zero", func() {
+			Expect(limiter.LimitCPU(logger, "some-handle", garden.CPULimits{}, gardener.CPUQuota{})).To(Succeed())
+
+			Expect(readCgroupFile("cpu.cfs_quota_us")).To(Equal("-1"))
+			Expect(filepath.Join(cgroupDir, "cpu.shares")).NotTo(BeAnExistingFile())
+		})
+
+		It("returns an error when the container has no cpu cgroup", func() {
+			err := limiter.LimitCPU(logger, "missing-handle", garden.CPULimits{LimitInShares: 512}, gardener.CPUQuota{})
+			Expect(err).To(MatchError(ContainSubstring("write cpu.shares")))
+		})
+	})
+
+	Describe("CurrentCPULimits", func() {
+		It("reads the shares from the container's cpu cgroup", func() {
+			Expect(ioutil.WriteFile(filepath.Join(cgroupDir, "cpu.shares"), []byte("1024\n"), 0644)).To(Succeed())
+
+			limits, err := limiter.CurrentCPULimits(logger, "some-handle")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(limits).To(Equal(garden.CPULimits{LimitInShares: 1024}))
+		})
+	})
+
+	Describe("CPUUsage", func() {
+		It("reads the usage from the container's cpuacct cgroup", func() {
+			cpuacct := filepath.Join(tmp, "cpuacct", "some-handle")
+			Expect(os.MkdirAll(cpuacct, 0755)).To(Succeed())
+			Expect(ioutil.WriteFile(filepath.Join(cpuacct, "cpuacct.usage"), []byte("1500000000\n"), 0644)).To(Succeed())
+
+			Expect(limiter.CPUUsage(logger, "some-handle")).To(Equal(1500 * time.Millisecond))
+		})
+
+		It("returns an error when the container has no cpuacct cgroup", func() {
+			_, err := limiter.CPUUsage(logger, "some-handle")
+			Expect(err).To(MatchError(ContainSubstring("read cpu usage")))
+		})
+	})
+
+	Context("when the cgroups are the unified hierarchy", func() {
+		BeforeEach(func() {
+			cgroupDir = filepath.Join(tmp, "some-handle")
+			Expect(os.MkdirAll(cgroupDir, 0755)).To(Succeed())
+
+			limiter.Unified = true
+		})
+
+		It("writes the shares as a weight and the quota and period to cpu.max", func() {
+			Expect(limiter.LimitCPU(logger, "some-handle", garden.CPULimits{LimitInShares: 1024}, gardener.CPUQuota{Quota: 50000, Period: 200000})).To(Succeed())
+
+			Expect(readCgroupFile("cpu.weight")).To(Equal("39"))
+			Expect(readCgroupFile("cpu.max")).To(Equal("50000 200000"))
+		})
+
+		It("removes the quota when it is zero", func() {
+			Expect(limiter.LimitCPU(logger, "some-handle", garden.CPULimits{}, gardener.CPUQuota{})).To(Succeed())
+
+			Expect(readCgroupFile("cpu.max")).To(Equal("max"))
+			Expect(filepath.Join(cgroupDir, "cpu.weight")).NotTo(BeAnExistingFile())
+		})
+
+		It("reads the shares back from the weight", func() {
+			Expect(ioutil.WriteFile(filepath.Join(cgroupDir, "cpu.weight"), []byte("100\n"), 0644)).To(Succeed())
+
+			limits, err := limiter.CurrentCPULimits(logger, "some-handle")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(limits).To(Equal(garden.CPULimits{LimitInShares: 2597}))
+		})
+
+		It("reads the usage from cpu.stat", func() {
+			Expect(ioutil.WriteFile(filepath.Join(cgroupDir, "cpu.stat"), []byte("usage_usec 2500000\nuser_usec 2000000\nsystem_usec 500000\n"), 0644)).To(Succeed())
+
+			Expect(limiter.CPUUsage(logger, "some-handle")).To(Equal(2500 * time.Millisecond))
+		})
+	})
+
+	Describe("SharesToWeight", func() {
+		It("maps the range of shares on to the range of weights", func() {
+			Expect(rundmc.SharesToWeight(2)).To(Equal(uint64(1)))
+			Expect(rundmc.SharesToWeight(262144)).To(Equal(uint64(10000)))
+		})
+
+		It("clamps shares outside of the range", func() {
+			Expect(rundmc.SharesToWeight(0)).To(Equal(uint64(1)))
+			Expect(rundmc.SharesToWeight(1000000)).To(Equal(uint64(10000)))
+		})
+	})
+})
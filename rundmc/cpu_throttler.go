@@ -0,0 +1,470 @@
+package rundmc
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cloudfoundry-incubator/guardian/gardener"
+	"github.com/cloudfoundry-incubator/guardian/pkg/atomicfile"
+	"github.com/pivotal-golang/clock"
+	"github.com/pivotal-golang/lager"
+)
+
+// throttledShares are the cpu shares of the throttled cgroup, the fewest
+// the kernel allows, so that its containers only get the CPU which no other
+// container wants
+const throttledShares = 2
+
+// throttledWeight is the cpu.weight of a throttled container in the unified
+// hierarchy, the least the kernel allows
+const throttledWeight = 1
+
+// CPUThrottler enforces containers' CPU entitlements. A container is
+// entitled to the share of the host's CPUs its cpu shares would get it if
+// every container were busy. Containers which use more than their
+// entitlement for ThrottleAfter checks in a row are moved in to the
+// throttled cgroup, a sibling of the containers' cgroups with the fewest
+// possible shares, and are moved back once they have used less than their
+// entitlement for ReleaseAfter checks in a row. Otherwise a container which
+// keeps every CPU busy gets CPU at the expense of well-behaved containers
+// whenever they are idle, and they are starved when they become busy again.
+//
+// A process in cgroup v2's unified hierarchy has a single cgroup, so it
+// cannot be moved for its CPU alone. There, a throttled container's own
+// cpu.weight is lowered to the least possible instead, and restored when it
+// is released.
+type CPUThrottler struct {
+	CgroupPath string
+	// Unified is whether CgroupPath is cgroup v2's unified hierarchy
+	Unified bool
+	// ThrottledCgroup is the name of the throttled cgroup in the cpu
+	// hierarchy. It is a sibling of the containers' cgroups, so no container
+	// may have it as its handle (see gardener.Gardener.ReservedHandles). It
+	// is not used in the unified hierarchy.
+	ThrottledCgroup string
+	// StatePath is the file in which the cpu.weights of throttled containers
+	// are saved in the unified hierarchy, so that they are still released
+	// after a restart (optional)
+	StatePath string
+	// CPUs is the number of CPUs on the host
+	CPUs   int
+	Lister HandleLister
+
+	ThrottleAfter int
+	ReleaseAfter  int
+
+	// Publisher is optional
+	Publisher gardener.EventPublisher
+
+	Clock    clock.Clock
+	Interval time.Duration
+	Logger   lager.Logger
+
+	mu         sync.Mutex
+	containers map[string]*cpuEntitlement
+}
+
+// cpuEntitlement is what the throttler knows of a container
+type cpuEntitlement struct {
+	usage     uint64
+	sampledAt time.Time
+
+	over      int
+	under     int
+	throttled bool
+
+	// weight is the container's cpu.weight before it was throttled, in the
+	// unified hierarchy
+	weight uint64
+}
+
+// Start creates the throttled cgroup, resumes tracking the containers which
+// were throttled before a restart and begins checking every Interval in the
+// background
+func (t *CPUThrottler) Start() error {
+	containers, err := t.resume()
+	if err != nil {
+		return err
+	}
+
+	t.mu.Lock()
+	t.containers = containers
+	t.mu.Unlock()
+
+	go func() {
+		ticker := t.Clock.NewTicker(t.Interval)
+		defer ticker.Stop()
+
+		for range ticker.C() {
+			t.Check()
+		}
+	}()
+
+	return nil
+}
+
+// resume returns the containers which were throttled before a restart
+func (t *CPUThrottler) resume() (map[string]*cpuEntitlement, error) {
+	containers := make(map[string]*cpuEntitlement)
+
+	if t.Unified {
+		if t.StatePath == "" {
+			return containers, nil
+		}
+
+		weights := map[string]uint64{}
+		data, err := ioutil.ReadFile(t.StatePath)
+		if os.IsNotExist(err) {
+			return containers, nil
+		}
+
+		if err == nil {
+			err = json.Unmarshal(data, &weights)
+		}
+
+		if err != nil {
+			// the containers stay at the throttled weight until their limits
+			// are next set, which is better than not starting at all
+			t.Logger.Error("read-throttled-containers-failed", err)
+			return containers, nil
+		}
+
+		for handle, weight := range weights {
+			containers[handle] = &cpuEntitlement{throttled: true, weight: weight}
+		}
+
+		return containers, nil
+	}
+
+	throttled := t.throttledPath()
+	if err := os.MkdirAll(throttled, 0755); err != nil {
+		return nil, fmt.Errorf("create throttled cgroup: %s", err)
+	}
+
+	if err := writeCgroupFile(throttled, "cpu.shares", strconv.Itoa(throttledShares)); err != nil {
+		return nil, fmt.Errorf("create throttled cgroup: %s", err)
+	}
+
+	entries, err := ioutil.ReadDir(throttled)
+	if err != nil {
+		return nil, fmt.Errorf("read throttled cgroup: %s", err)
+	}
+
+	for _, entry := range entries {
+		if entry.IsDir() {
+			containers[entry.Name()] = &cpuEntitlement{throttled: true}
+		}
+	}
+
+	return containers, nil
+}
+
+// Check compares each container's CPU usage since the last check with its
+// entitlement once, throttling or releasing it as needed
+func (t *CPUThrottler) Check() {
+	log := t.Logger.Session("check-cpu-entitlements")
+
+	handles, err := t.Lister.Handles()
+	if err != nil {
+		log.Error("list-handles-failed", err)
+		return
+	}
+
+	current := make(map[string]uint64)
+	for _, handle := range handles {
+		s, err := t.readShares(handle)
+		if err != nil {
+			// the container is being created or destroyed
+			continue
+		}
+
+		current[handle] = s
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.containers == nil {
+		t.containers = make(map[string]*cpuEntitlement)
+	}
+
+	shares := make(map[string]uint64, len(current))
+	var totalShares uint64
+	for handle, s := range current {
+		// a throttled container's own weight is the throttled weight in the
+		// unified hierarchy, but it is entitled to what it had before
+		if c, ok := t.containers[handle]; ok && c.throttled && t.Unified {
+			s = WeightToShares(c.weight)
+		}
+
+		shares[handle] = s
+		totalShares += s
+	}
+
+	changed := false
+	defer func() {
+		if changed {
+			t.saveState(log)
+		}
+	}()
+
+	for handle := range t.containers {
+		if _, ok := shares[handle]; !ok {
+			t.forget(log, handle)
+			changed = true
+		}
+	}
+
+	now := t.Clock.Now()
+	for handle, s := range shares {
+		usage, err := t.readUsage(handle)
+		if err != nil {
+			log.Error("read-cpu-usage-failed", err, lager.Data{"handle": handle})
+			continue
+		}
+
+		c, ok := t.containers[handle]
+		if !ok {
+			c = &cpuEntitlement{}
+			t.containers[handle] = c
+		}
+
+		if c.throttled && !t.Unified {
+			// processes run in the container since it was throttled start in
+			// its own cgroup
+			if err := movePids(t.containerPath(handle), t.throttledPath(handle)); err != nil {
+				log.Error("throttle-new-processes-failed", err, lager.Data{"handle": handle})
+			}
+		}
+
+		previous, elapsed := c.usage, now.Sub(c.sampledAt)
+		first := c.sampledAt.IsZero()
+		c.usage, c.sampledAt = usage, now
+
+		if first || elapsed <= 0 || usage < previous {
+			continue
+		}
+
+		used := float64(usage-previous) / float64(elapsed)
+		entitled := float64(t.CPUs) * float64(s) / float64(totalShares)
+
+		if used > entitled {
+			c.over, c.under = c.over+1, 0
+		} else {
+			c.over, c.under = 0, c.under+1
+		}
+
+		data := lager.Data{"handle": handle, "used": used, "entitled": entitled}
+
+		if !c.throttled && c.over >= t.ThrottleAfter {
+			if err := t.throttle(handle, c, s); err != nil {
+				log.Error("throttle-failed", err, data)
+				continue
+			}
+
+			c.throttled, changed = true, true
+			log.Info("throttled", data)
+			t.publish(handle, gardener.EventCPUThrottled, used, entitled)
+		}
+
+		if c.throttled && c.under >= t.ReleaseAfter {
+			if err := t.release(log, handle, c); err != nil {
+				log.Error("release-failed", err, data)
+				continue
+			}
+
+			c.throttled, changed = false, true
+			log.Info("released", data)
+			t.publish(handle, gardener.EventCPUReleased, used, entitled)
+		}
+	}
+}
+
+// Throttled is whether each container is throttled, as 1 or 0, by handle,
+// e.g. for a metric
+func (t *CPUThrottler) Throttled() map[string]float64 {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	throttled := make(map[string]float64, len(t.containers))
+	for handle, c := range t.containers {
+		throttled[handle] = 0
+		if c.throttled {
+			throttled[handle] = 1
+		}
+	}
+
+	return throttled
+}
+
+// IsThrottled is whether the container is throttled, e.g. for its
+// gardener.CPUThrottledProperty
+func (t *CPUThrottler) IsThrottled(handle string) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	c, ok := t.containers[handle]
+	return ok && c.throttled
+}
+
+func (t *CPUThrottler) throttle(handle string, c *cpuEntitlement, shares uint64) error {
+	if t.Unified {
+		weight, err := readCgroupUint(t.containerPath(handle), "cpu.weight")
+		if err != nil {
+			return err
+		}
+
+		if err := writeCgroupFile(t.containerPath(handle), "cpu.weight", strconv.Itoa(throttledWeight)); err != nil {
+			return err
+		}
+
+		c.weight = weight
+		return nil
+	}
+
+	throttled := t.throttledPath(handle)
+	if err := os.MkdirAll(throttled, 0755); err != nil {
+		return err
+	}
+
+	// the throttled containers still compete with each other by their shares
+	if err := writeCgroupFile(throttled, "cpu.shares", strconv.FormatUint(shares, 10)); err != nil {
+		return err
+	}
+
+	return movePids(t.containerPath(handle), throttled)
+}
+
+func (t *CPUThrottler) release(log lager.Logger, handle string, c *cpuEntitlement) error {
+	if t.Unified {
+		return writeCgroupFile(t.containerPath(handle), "cpu.weight", strconv.FormatUint(c.weight, 10))
+	}
+
+	throttled := t.throttledPath(handle)
+	if err := movePids(throttled, t.containerPath(handle)); err != nil {
+		return err
+	}
+
+	// the container is released once its processes have moved; a throttled
+	// cgroup left behind is only empty
+	if err := os.Remove(throttled); err != nil {
+		log.Error("remove-throttled-cgroup-failed", err, lager.Data{"handle": handle})
+	}
+
+	return nil
+}
+
+// forget stops tracking a container which has been destroyed, removing its
+// throttled cgroup if it had one
+func (t *CPUThrottler) forget(log lager.Logger, handle string) {
+	if t.containers[handle].throttled && !t.Unified {
+		if err := os.Remove(t.throttledPath(handle)); err != nil && !os.IsNotExist(err) {
+			log.Error("remove-throttled-cgroup-failed", err, lager.Data{"handle": handle})
+		}
+	}
+
+	delete(t.containers, handle)
+}
+
+func (t *CPUThrottler) publish(handle string, eventType gardener.EventType, used, entitled float64) {
+	if t.Publisher == nil {
+		return
+	}
+
+	t.Publisher.Publish(gardener.Event{Handle: handle, Type: eventType, Data: map[string]string{
+		"used":     strconv.FormatFloat(used, 'f', 3, 64),
+		"entitled": strconv.FormatFloat(entitled, 'f', 3, 64),
+	}})
+}
+
+// saveState saves the weights of the throttled containers in the unified
+// hierarchy, if there is a StatePath. t.mu must be held.
+func (t *CPUThrottler) saveState(log lager.Logger) {
+	if !t.Unified || t.StatePath == "" {
+		return
+	}
+
+	weights := map[string]uint64{}
+	for handle, c := range t.containers {
+		if c.throttled {
+			weights[handle] = c.weight
+		}
+	}
+
+	if err := atomicfile.WriteJSON(t.StatePath, weights); err != nil {
+		log.Error("save-throttled-containers-failed", err)
+	}
+}
+
+// readShares reads the container's cpu shares, converted from its
+// cpu.weight in the unified hierarchy
+func (t *CPUThrottler) readShares(handle string) (uint64, error) {
+	if t.Unified {
+		weight, err := readCgroupUint(t.containerPath(handle), "cpu.weight")
+		if err != nil {
+			return 0, err
+		}
+
+		return WeightToShares(weight), nil
+	}
+
+	return readCgroupUint(t.containerPath(handle), "cpu.shares")
+}
+
+// readUsage reads the CPU time, in nanoseconds, the container has used
+func (t *CPUThrottler) readUsage(handle string) (uint64, error) {
+	if t.Unified {
+		usec, err := readCPUStat(filepath.Join(t.containerPath(handle), "cpu.stat"), "usage_usec")
+		return usec * uint64(time.Microsecond), err
+	}
+
+	return readCgroupUint(filepath.Join(t.CgroupPath, "cpuacct", handle), "cpuacct.usage")
+}
+
+func (t *CPUThrottler) containerPath(handle string) string {
+	if t.Unified {
+		return filepath.Join(t.CgroupPath, handle)
+	}
+
+	return filepath.Join(t.CgroupPath, "cpu", handle)
+}
+
+func (t *CPUThrottler) throttledPath(handle ...string) string {
+	return filepath.Join(append([]string{t.CgroupPath, "cpu", t.ThrottledCgroup}, handle...)...)
+}
+
+// movePids moves every process in one cgroup to another
+func movePids(from, to string) error {
+	data, err := ioutil.ReadFile(filepath.Join(from, "cgroup.procs"))
+	if err != nil {
+		return err
+	}
+
+	for _, pid := range strings.Fields(string(data)) {
+		if err := writeCgroupFile(to, "cgroup.procs", pid); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func readCgroupUint(cgroup, file string) (uint64, error) {
+	data, err := ioutil.ReadFile(filepath.Join(cgroup, file))
+	if err != nil {
+		return 0, err
+	}
+
+	return strconv.ParseUint(strings.TrimSpace(string(data)), 10, 64)
+}
+
+func writeCgroupFile(cgroup, file, value string) error {
+	return ioutil.WriteFile(filepath.Join(cgroup, file), []byte(value), 0644)
+}
@@ -0,0 +1,314 @@
+package rundmc_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/cloudfoundry-incubator/guardian/gardener"
+	gardenerfakes "github.com/cloudfoundry-incubator/guardian/gardener/fakes"
+	"github.com/cloudfoundry-incubator/guardian/rundmc"
+	"github.com/cloudfoundry-incubator/guardian/rundmc/fakes"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/pivotal-golang/clock/fakeclock"
+	"github.com/pivotal-golang/lager/lagertest"
+)
+
+var _ = Describe("CPUThrottler", func() {
+	var (
+		cgroupPath    string
+		fakeLister    *fakes.FakeHandleLister
+		fakePublisher *gardenerfakes.FakeEventPublisher
+		fakeClock     *fakeclock.FakeClock
+
+		throttler *rundmc.CPUThrottler
+	)
+
+	writeFile := func(path, contents string) {
+		Expect(os.MkdirAll(filepath.Dir(path), 0755)).To(Succeed())
+		Expect(ioutil.WriteFile(path, []byte(contents), 0644)).To(Succeed())
+	}
+
+	readFile := func(path string) string {
+		data, err := ioutil.ReadFile(path)
+		Expect(err).NotTo(HaveOccurred())
+		return string(data)
+	}
+
+	createContainer := func(handle, shares, pid string) {
+		writeFile(filepath.Join(cgroupPath, "cpu", handle, "cpu.shares"), shares)
+		writeFile(filepath.Join(cgroupPath, "cpu", handle, "cgroup.procs"), pid)
+		writeFile(filepath.Join(cgroupPath, "cpuacct", handle, "cpuacct.usage"), "0")
+	}
+
+	// use sets the CPU the container has used, in CPU seconds
+	use := func(handle string, seconds float64) {
+		writeFile(filepath.Join(cgroupPath, "cpuacct", handle, "cpuacct.usage"), strconv.FormatInt(int64(seconds*float64(time.Second)), 10))
+	}
+
+	BeforeEach(func() {
+		var err error
+		cgroupPath, err = ioutil.TempDir("", "cgroups")
+		Expect(err).NotTo(HaveOccurred())
+
+		// the good container is entitled to 1 CPU, and the greedy one to 3
+		createContainer("good", "256", "100")
+		createContainer("greedy", "768", "200")
+
+		fakeLister = new(fakes.FakeHandleLister)
+		fakeLister.HandlesReturns([]string{"good", "greedy"}, nil)
+		fakePublisher = new(gardenerfakes.FakeEventPublisher)
+		fakeClock = fakeclock.NewFakeClock(time.Now())
+
+		throttler = &rundmc.CPUThrottler{
+			CgroupPath:      cgroupPath,
+			ThrottledCgroup: "throttled",
+			CPUs:            4,
+			Lister:          fakeLister,
+			ThrottleAfter:   2,
+			ReleaseAfter:    2,
+			Publisher:       fakePublisher,
+			Clock:           fakeClock,
+			Interval:        time.Minute,
+			Logger:          lagertest.NewTestLogger("test"),
+		}
+
+		Expect(throttler.Start()).To(Succeed())
+		throttler.Check()
+	})
+
+	AfterEach(func() {
+		Expect(os.RemoveAll(cgroupPath)).To(Succeed())
+	})
+
+	It("creates the throttled cgroup with the fewest shares", func() {
+		Expect(readFile(filepath.Join(cgroupPath, "cpu", "throttled", "cpu.shares"))).To(Equal("2"))
+	})
+
+	Context("when a container uses more than its entitlement for ThrottleAfter checks", func() {
+		BeforeEach(func() {
+			fakeClock.Increment(10 * time.Second)
+			use("good", 20)
+			throttler.Check()
+
+			Expect(throttler.IsThrottled("good")).To(BeFalse())
+
+			fakeClock.Increment(10 * time.Second)
+			use("good", 40)
+			throttler.Check()
+		})
+
+		It("moves the container's processes to the throttled cgroup with its shares", func() {
+			Expect(throttler.IsThrottled("good")).To(BeTrue())
+			Expect(readFile(filepath.Join(cgroupPath, "cpu", "throttled", "good", "cgroup.procs"))).To(Equal("100"))
+			Expect(readFile(filepath.Join(cgroupPath, "cpu", "throttled", "good", "cpu.shares"))).To(Equal("256"))
+		})
+
+		It("publishes an event and counts the container as throttled", func() {
+			Expect(fakePublisher.PublishCallCount()).To(Equal(1))
+
+			event := fakePublisher.PublishArgsForCall(0)
+			Expect(event.Handle).To(Equal("good"))
+			Expect(event.Type).To(Equal(gardener.EventCPUThrottled))
+			Expect(event.Data).To(HaveKeyWithValue("used", "2.000"))
+			Expect(event.Data).To(HaveKeyWithValue("entitled", "1.000"))
+
+			Expect(throttler.Throttled()).To(HaveKeyWithValue("good", 1.0))
+			Expect(throttler.Throttled()).To(HaveKeyWithValue("greedy", 0.0))
+		})
+
+		It("does not throttle containers within their entitlement", func() {
+			Expect(throttler.IsThrottled("greedy")).To(BeFalse())
+		})
+
+		It("moves processes later run in the container to the throttled cgroup", func() {
+			writeFile(filepath.Join(cgroupPath, "cpu", "good", "cgroup.procs"), "101")
+
+			fakeClock.Increment(10 * time.Second)
+			use("good", 60)
+			throttler.Check()
+
+			Expect(readFile(filepath.Join(cgroupPath, "cpu", "throttled", "good", "cgroup.procs"))).To(Equal("101"))
+		})
+
+		It("resumes tracking the throttled container after a restart", func() {
+			restarted := &rundmc.CPUThrottler{
+				CgroupPath:      cgroupPath,
+				ThrottledCgroup: "throttled",
+				Clock:           fakeClock,
+				Interval:        time.Minute,
+			}
+			Expect(restarted.Start()).To(Succeed())
+
+			Expect(restarted.IsThrottled("good")).To(BeTrue())
+		})
+
+		Context("and then uses less than its entitlement for ReleaseAfter checks", func() {
+			BeforeEach(func() {
+				writeFile(filepath.Join(cgroupPath, "cpu", "good", "cgroup.procs"), "")
+
+				fakeClock.Increment(10 * time.Second)
+				use("good", 41)
+				throttler.Check()
+
+				Expect(throttler.IsThrottled("good")).To(BeTrue())
+
+				fakeClock.Increment(10 * time.Second)
+				use("good", 42)
+				throttler.Check()
+			})
+
+			It("moves the container's processes back", func() {
+				Expect(throttler.IsThrottled("good")).To(BeFalse())
+				Expect(readFile(filepath.Join(cgroupPath, "cpu", "good", "cgroup.procs"))).To(Equal("100"))
+			})
+
+			It("publishes an event", func() {
+				Expect(fakePublisher.PublishCallCount()).To(Equal(2))
+
+				event := fakePublisher.PublishArgsForCall(1)
+				Expect(event.Handle).To(Equal("good"))
+				Expect(event.Type).To(Equal(gardener.EventCPUReleased))
+
+				Expect(throttler.Throttled()).To(HaveKeyWithValue("good", 0.0))
+			})
+		})
+
+		Context("and is then destroyed", func() {
+			It("forgets the container", func() {
+				fakeLister.HandlesReturns([]string{"greedy"}, nil)
+				throttler.Check()
+
+				Expect(throttler.IsThrottled("good")).To(BeFalse())
+				Expect(throttler.Throttled()).NotTo(HaveKey("good"))
+			})
+		})
+	})
+
+	Context("when a container uses more than its entitlement only briefly", func() {
+		It("is not throttled", func() {
+			fakeClock.Increment(10 * time.Second)
+			use("good", 20)
+			throttler.Check()
+
+			fakeClock.Increment(10 * time.Second)
+			use("good", 25)
+			throttler.Check()
+
+			fakeClock.Increment(10 * time.Second)
+			use("good", 45)
+			throttler.Check()
+
+			Expect(throttler.IsThrottled("good")).To(BeFalse())
+			Expect(fakePublisher.PublishCallCount()).To(Equal(0))
+		})
+	})
+})
+
+var _ = Describe("CPUThrottler in the unified hierarchy", func() {
+	var (
+		cgroupPath string
+		statePath  string
+		fakeLister *fakes.FakeHandleLister
+		fakeClock  *fakeclock.FakeClock
+
+		throttler *rundmc.CPUThrottler
+	)
+
+	writeFile := func(path, contents string) {
+		Expect(os.MkdirAll(filepath.Dir(path), 0755)).To(Succeed())
+		Expect(ioutil.WriteFile(path, []byte(contents), 0644)).To(Succeed())
+	}
+
+	readFile := func(path string) string {
+		data, err := ioutil.ReadFile(path)
+		Expect(err).NotTo(HaveOccurred())
+		return string(data)
+	}
+
+	// use sets the CPU the container has used, in CPU seconds
+	use := func(handle string, seconds float64) {
+		writeFile(filepath.Join(cgroupPath, handle, "cpu.stat"), "usage_usec "+strconv.FormatInt(int64(seconds*1e6), 10)+"\nuser_usec 0\n")
+	}
+
+	newThrottler := func() *rundmc.CPUThrottler {
+		return &rundmc.CPUThrottler{
+			CgroupPath:    cgroupPath,
+			Unified:       true,
+			StatePath:     statePath,
+			CPUs:          4,
+			Lister:        fakeLister,
+			ThrottleAfter: 2,
+			ReleaseAfter:  2,
+			Clock:         fakeClock,
+			Interval:      time.Minute,
+			Logger:        lagertest.NewTestLogger("test"),
+		}
+	}
+
+	BeforeEach(func() {
+		var err error
+		cgroupPath, err = ioutil.TempDir("", "cgroups")
+		Expect(err).NotTo(HaveOccurred())
+		statePath = filepath.Join(cgroupPath, "throttled.json")
+
+		// the good container is entitled to 1 CPU, and the greedy one to 3
+		for handle, weight := range map[string]string{"good": "100", "greedy": "300"} {
+			writeFile(filepath.Join(cgroupPath, handle, "cpu.weight"), weight)
+			use(handle, 0)
+		}
+
+		fakeLister = new(fakes.FakeHandleLister)
+		fakeLister.HandlesReturns([]string{"good", "greedy"}, nil)
+		fakeClock = fakeclock.NewFakeClock(time.Now())
+
+		throttler = newThrottler()
+		Expect(throttler.Start()).To(Succeed())
+		throttler.Check()
+
+		for _, used := range []float64{20, 40} {
+			fakeClock.Increment(10 * time.Second)
+			use("good", used)
+			throttler.Check()
+		}
+	})
+
+	AfterEach(func() {
+		Expect(os.RemoveAll(cgroupPath)).To(Succeed())
+	})
+
+	It("throttles a container by lowering its own weight", func() {
+		Expect(throttler.IsThrottled("good")).To(BeTrue())
+		Expect(readFile(filepath.Join(cgroupPath, "good", "cpu.weight"))).To(Equal("1"))
+		Expect(throttler.IsThrottled("greedy")).To(BeFalse())
+	})
+
+	It("restores the container's weight when it is released", func() {
+		for _, used := range []float64{41, 42} {
+			fakeClock.Increment(10 * time.Second)
+			use("good", used)
+			throttler.Check()
+		}
+
+		Expect(throttler.IsThrottled("good")).To(BeFalse())
+		Expect(readFile(filepath.Join(cgroupPath, "good", "cpu.weight"))).To(Equal("100"))
+	})
+
+	It("still releases the container after a restart", func() {
+		restarted := newThrottler()
+		Expect(restarted.Start()).To(Succeed())
+		Expect(restarted.IsThrottled("good")).To(BeTrue())
+
+		for _, used := range []float64{40, 41, 42} {
+			fakeClock.Increment(10 * time.Second)
+			use("good", used)
+			restarted.Check()
+		}
+
+		Expect(restarted.IsThrottled("good")).To(BeFalse())
+		Expect(readFile(filepath.Join(cgroupPath, "good", "cpu.weight"))).To(Equal("100"))
+	})
+})
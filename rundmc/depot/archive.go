@@ -0,0 +1,181 @@
+package depot
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/cloudfoundry-incubator/goci"
+	"github.com/pivotal-golang/lager"
+)
+
+// CheckpointDir is the directory within a bundle which holds the CRIU images
+// of the container's most recent checkpoint.
+const CheckpointDir = "checkpoint"
+
+// ExportedRootFSDir is the directory within an exported bundle which holds a
+// copy of the container's rootfs, so that the container can be restored on a
+// host which does not have its image.
+const ExportedRootFSDir = "rootfs"
+
+var ErrAlreadyExists = errors.New("already exists")
+
+// CheckpointPath returns the directory in which the checkpoint images of the
+// container are stored.
+func (d *DirectoryDepot) CheckpointPath(handle string) string {
+	return filepath.Join(d.toDir(handle), CheckpointDir)
+}
+
+// Export copies the bundle, including any checkpoint images, to dest so that
+// it can be imported by another depot. The container's rootfs is copied too,
+// unless it is already in the bundle. Any earlier export to dest is replaced.
+func (d *DirectoryDepot) Export(log lager.Logger, handle, dest string) error {
+	log = log.Session("export", lager.Data{"handle": handle, "dest": dest})
+
+	log.Info("started")
+	defer log.Info("finished")
+
+	src := d.toDir(handle)
+	if _, err := os.Stat(src); err != nil {
+		return ErrDoesNotExist
+	}
+
+	bndl, err := (&goci.BndlLoader{}).Load(src)
+	if err != nil {
+		log.Error("load-bundle-failed", err)
+		return fmt.Errorf("export bundle: %s", err)
+	}
+
+	if err := os.RemoveAll(dest); err != nil {
+		log.Error("remove-old-export-failed", err)
+		return fmt.Errorf("export bundle: %s", err)
+	}
+
+	if err := copyTree(src, dest); err != nil {
+		log.Error("copy-failed", err)
+		return fmt.Errorf("export bundle: %s", err)
+	}
+
+	rootfs := filepath.Clean(bndl.RootFS())
+	if rootfs == "." || strings.HasPrefix(rootfs, src+string(filepath.Separator)) {
+		return nil
+	}
+
+	if err := copyTree(rootfs, filepath.Join(dest, ExportedRootFSDir)); err != nil {
+		log.Error("copy-rootfs-failed", err)
+		return fmt.Errorf("export rootfs: %s", err)
+	}
+
+	return nil
+}
+
+// Import copies a bundle previously exported to src in to the depot. If the
+// export has a copy of the rootfs, the imported bundle is changed to use it.
+func (d *DirectoryDepot) Import(log lager.Logger, handle, src string) error {
+	log = log.Session("import", lager.Data{"handle": handle, "src": src})
+
+	log.Info("started")
+	defer log.Info("finished")
+
+	dest := d.toDir(handle)
+	if _, err := os.Stat(dest); err == nil {
+		return ErrAlreadyExists
+	}
+
+	if err := copyTree(src, dest); err != nil {
+		removeOrLog(log, dest)
+		log.Error("copy-failed", err)
+		return fmt.Errorf("import bundle: %s", err)
+	}
+
+	if err := d.useImportedRootFS(dest); err != nil {
+		removeOrLog(log, dest)
+		log.Error("use-imported-rootfs-failed", err)
+		return fmt.Errorf("import bundle: %s", err)
+	}
+
+	return nil
+}
+
+func (d *DirectoryDepot) useImportedRootFS(path string) error {
+	rootfs := filepath.Join(path, ExportedRootFSDir)
+	if _, err := os.Stat(rootfs); os.IsNotExist(err) {
+		return nil
+	}
+
+	bndl, err := (&goci.BndlLoader{}).Load(path)
+	if err != nil {
+		return err
+	}
+
+	if err := bndl.WithRootFS(rootfs).Save(path); err != nil {
+		return err
+	}
+
+	return d.recordHash(path)
+}
+
+// copyTree copies the directories, regular files and symlinks under src to
+// dest, which must not exist yet, preserving their permissions and owners
+func copyTree(src, dest string) error {
+	return filepath.Walk(src, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+
+		target := filepath.Join(dest, rel)
+		switch {
+		case info.IsDir():
+			if err := os.MkdirAll(target, info.Mode().Perm()); err != nil {
+				return err
+			}
+		case info.Mode().IsRegular():
+			if err := copyFile(path, target, info.Mode().Perm()); err != nil {
+				return err
+			}
+		case info.Mode()&os.ModeSymlink != 0:
+			// symlinks are copied as they are; nothing is ever copied
+			// through them, since Walk does not follow them
+			link, err := os.Readlink(path)
+			if err != nil {
+				return err
+			}
+
+			if err := os.Symlink(link, target); err != nil {
+				return err
+			}
+		default:
+			return fmt.Errorf("unsupported file type: %s", path)
+		}
+
+		return preserveOwner(target, info)
+	})
+}
+
+func copyFile(src, dest string, mode os.FileMode) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dest, os.O_WRONLY|os.O_CREATE|os.O_EXCL, mode)
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+
+	return out.Close()
+}
@@ -0,0 +1,17 @@
+package depot
+
+import (
+	"os"
+	"syscall"
+)
+
+// preserveOwner gives the copy at path the owner of the original, so that
+// exported rootfses keep files owned by containers' (mapped) users
+func preserveOwner(path string, info os.FileInfo) error {
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return nil
+	}
+
+	return os.Lchown(path, int(stat.Uid), int(stat.Gid))
+}
@@ -0,0 +1,9 @@
+// +build !linux
+
+package depot
+
+import "os"
+
+func preserveOwner(path string, info os.FileInfo) error {
+	return nil
+}
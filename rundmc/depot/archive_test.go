@@ -0,0 +1,139 @@
+package depot_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/cloudfoundry-incubator/goci"
+	"github.com/cloudfoundry-incubator/guardian/rundmc/depot"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/pivotal-golang/lager"
+	"github.com/pivotal-golang/lager/lagertest"
+)
+
+var _ = Describe("Exporting and importing bundles", func() {
+	var (
+		depotDir  string
+		exportDir string
+		rootfsDir string
+		dirdepot  *depot.DirectoryDepot
+		logger    lager.Logger
+	)
+
+	BeforeEach(func() {
+		var err error
+		depotDir, err = ioutil.TempDir("", "depot-test")
+		Expect(err).NotTo(HaveOccurred())
+
+		exportDir, err = ioutil.TempDir("", "depot-export")
+		Expect(err).NotTo(HaveOccurred())
+		exportDir = filepath.Join(exportDir, "some-export")
+
+		logger = lagertest.NewTestLogger("test")
+		dirdepot = depot.New(depotDir)
+
+		rootfsDir, err = ioutil.TempDir("", "depot-rootfs")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(os.MkdirAll(filepath.Join(rootfsDir, "etc"), 0755)).To(Succeed())
+		Expect(ioutil.WriteFile(filepath.Join(rootfsDir, "etc", "hostname"), []byte("container"), 0644)).To(Succeed())
+		Expect(os.Symlink("/etc/hostname", filepath.Join(rootfsDir, "hostname"))).To(Succeed())
+
+		bundlePath := filepath.Join(depotDir, "some-handle")
+		Expect(os.MkdirAll(filepath.Join(bundlePath, depot.CheckpointDir), 0700)).To(Succeed())
+		Expect(goci.Bundle().WithRootFS(rootfsDir).Save(bundlePath)).To(Succeed())
+		Expect(ioutil.WriteFile(filepath.Join(bundlePath, depot.CheckpointDir, "pages-1.img"), []byte("pages"), 0644)).To(Succeed())
+	})
+
+	AfterEach(func() {
+		Expect(os.RemoveAll(depotDir)).To(Succeed())
+		Expect(os.RemoveAll(rootfsDir)).To(Succeed())
+		Expect(os.RemoveAll(filepath.Dir(exportDir))).To(Succeed())
+	})
+
+	It("stores checkpoint images alongside the bundle", func() {
+		Expect(dirdepot.CheckpointPath("some-handle")).To(Equal(filepath.Join(depotDir, "some-handle", "checkpoint")))
+	})
+
+	Describe("Export", func() {
+		It("copies the bundle and checkpoint images to the destination", func() {
+			Expect(dirdepot.Export(logger, "some-handle", exportDir)).To(Succeed())
+
+			Expect(filepath.Join(exportDir, "config.json")).To(BeARegularFile())
+			Expect(ioutil.ReadFile(filepath.Join(exportDir, "checkpoint", "pages-1.img"))).To(Equal([]byte("pages")))
+		})
+
+		It("preserves file permissions", func() {
+			Expect(dirdepot.Export(logger, "some-handle", exportDir)).To(Succeed())
+
+			info, err := os.Stat(filepath.Join(exportDir, "checkpoint", "pages-1.img"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(info.Mode().Perm()).To(Equal(os.FileMode(0644)))
+		})
+
+		It("copies the rootfs, keeping symlinks as they are", func() {
+			Expect(dirdepot.Export(logger, "some-handle", exportDir)).To(Succeed())
+
+			Expect(ioutil.ReadFile(filepath.Join(exportDir, depot.ExportedRootFSDir, "etc", "hostname"))).To(Equal([]byte("container")))
+			Expect(os.Readlink(filepath.Join(exportDir, depot.ExportedRootFSDir, "hostname"))).To(Equal("/etc/hostname"))
+		})
+
+		It("replaces an earlier export", func() {
+			Expect(os.MkdirAll(exportDir, 0700)).To(Succeed())
+			Expect(ioutil.WriteFile(filepath.Join(exportDir, "stale"), []byte("stale"), 0600)).To(Succeed())
+
+			Expect(dirdepot.Export(logger, "some-handle", exportDir)).To(Succeed())
+			Expect(filepath.Join(exportDir, "stale")).NotTo(BeAnExistingFile())
+		})
+
+		Context("when the bundle does not exist", func() {
+			It("returns ErrDoesNotExist", func() {
+				Expect(dirdepot.Export(logger, "potato", exportDir)).To(MatchError(depot.ErrDoesNotExist))
+			})
+		})
+	})
+
+	Describe("Import", func() {
+		BeforeEach(func() {
+			Expect(dirdepot.Export(logger, "some-handle", exportDir)).To(Succeed())
+		})
+
+		It("copies the exported bundle in to the depot", func() {
+			Expect(dirdepot.Import(logger, "other-handle", exportDir)).To(Succeed())
+
+			path, err := dirdepot.Lookup(logger, "other-handle")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(ioutil.ReadFile(filepath.Join(path, "checkpoint", "pages-1.img"))).To(Equal([]byte("pages")))
+		})
+
+		It("uses the exported copy of the rootfs", func() {
+			Expect(dirdepot.Import(logger, "other-handle", exportDir)).To(Succeed())
+
+			path, err := dirdepot.Lookup(logger, "other-handle")
+			Expect(err).NotTo(HaveOccurred())
+
+			bndl, err := (&goci.BndlLoader{}).Load(path)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(bndl.RootFS()).To(Equal(filepath.Join(path, depot.ExportedRootFSDir)))
+			Expect(ioutil.ReadFile(filepath.Join(bndl.RootFS(), "etc", "hostname"))).To(Equal([]byte("container")))
+
+			Expect(dirdepot.Verify(logger, "other-handle")).To(Succeed())
+		})
+
+		Context("when the bundle already exists in the depot", func() {
+			It("returns ErrAlreadyExists", func() {
+				Expect(dirdepot.Import(logger, "some-handle", exportDir)).To(MatchError(depot.ErrAlreadyExists))
+			})
+		})
+
+		Context("when the source does not exist", func() {
+			It("returns an error and does not leave a bundle behind", func() {
+				Expect(dirdepot.Import(logger, "other-handle", "/does/not/exist")).NotTo(Succeed())
+
+				_, err := dirdepot.Lookup(logger, "other-handle")
+				Expect(err).To(MatchError(depot.ErrDoesNotExist))
+			})
+		})
+	})
+})
@@ -47,6 +47,45 @@ func (d *DirectoryDepot) Create(log lager.Logger, handle string, bundle BundleSa
 		return err
 	}
 
+	if err := d.recordHash(path); err != nil {
+		removeOrLog(log, path)
+		log.Error("record-hash", err, lager.Data{"path": path})
+		return err
+	}
+
+	if err := d.recordVersion(path, SchemaVersion); err != nil {
+		removeOrLog(log, path)
+		log.Error("record-version", err, lager.Data{"path": path})
+		return err
+	}
+
+	return nil
+}
+
+// Update replaces the configuration of an existing bundle, e.g. when a
+// running container's mounts are changed, and records its new hash so that
+// the change is not mistaken for drift
+func (d *DirectoryDepot) Update(log lager.Logger, handle string, bundle BundleSaver) error {
+	log = log.Session("depot-update", lager.Data{"handle": handle})
+
+	log.Info("started")
+	defer log.Info("finished")
+
+	path := d.toDir(handle)
+	if _, err := os.Stat(path); err != nil {
+		return ErrDoesNotExist
+	}
+
+	if err := bundle.Save(path); err != nil {
+		log.Error("save", err, lager.Data{"path": path})
+		return err
+	}
+
+	if err := d.recordHash(path); err != nil {
+		log.Error("record-hash", err, lager.Data{"path": path})
+		return err
+	}
+
 	return nil
 }
 
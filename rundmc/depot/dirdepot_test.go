@@ -74,6 +74,40 @@ var _ = Describe("Depot", func() {
 		})
 	})
 
+	Describe("update", func() {
+		BeforeEach(func() {
+			fakeBundle.SaveStub = func(path string) error {
+				return ioutil.WriteFile(filepath.Join(path, "config.json"), []byte("original"), 0600)
+			}
+			Expect(dirdepot.Create(logger, "aardvaark", fakeBundle)).To(Succeed())
+		})
+
+		It("saves the new config over the old", func() {
+			fakeBundle.SaveStub = func(path string) error {
+				return ioutil.WriteFile(filepath.Join(path, "config.json"), []byte("updated"), 0600)
+			}
+
+			Expect(dirdepot.Update(logger, "aardvaark", fakeBundle)).To(Succeed())
+			Expect(ioutil.ReadFile(filepath.Join(depotDir, "aardvaark", "config.json"))).To(BeEquivalentTo("updated"))
+		})
+
+		It("records the new hash, so the bundle has not drifted", func() {
+			fakeBundle.SaveStub = func(path string) error {
+				return ioutil.WriteFile(filepath.Join(path, "config.json"), []byte("updated"), 0600)
+			}
+
+			Expect(dirdepot.Update(logger, "aardvaark", fakeBundle)).To(Succeed())
+			Expect(dirdepot.Verify(logger, "aardvaark")).To(Succeed())
+		})
+
+		Context("when the bundle does not exist", func() {
+			It("returns ErrDoesNotExist without saving anything", func() {
+				Expect(dirdepot.Update(logger, "potato", fakeBundle)).To(MatchError(depot.ErrDoesNotExist))
+				Expect(filepath.Join(depotDir, "potato")).NotTo(BeAnExistingFile())
+			})
+		})
+	})
+
 	Describe("destroy", func() {
 		It("should destroy the container directory", func() {
 			Expect(os.MkdirAll(filepath.Join(depotDir, "potato"), 0755)).To(Succeed())
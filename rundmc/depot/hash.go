@@ -0,0 +1,111 @@
+package depot
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pivotal-golang/lager"
+)
+
+// BundleConfigFiles are the files, relative to the bundle directory, whose
+// contents make up a bundle's configuration.
+var BundleConfigFiles = []string{"config.json", "runtime.json"}
+
+// HashFile records the hash of a bundle's configuration at creation time.
+const HashFile = "config.sha256"
+
+// QuarantineFile marks a bundle which has been quarantined; it contains the
+// reason for the quarantine.
+const QuarantineFile = "quarantined"
+
+type BundleDriftedError struct {
+	Handle   string
+	Expected string
+	Actual   string
+}
+
+func (e *BundleDriftedError) Error() string {
+	return fmt.Sprintf("bundle %s has drifted: config hash is %s, expected %s", e.Handle, e.Actual, e.Expected)
+}
+
+// HashBundle computes a reproducible sha256 hash over the configuration files
+// of the bundle in dir. Missing files are hashed as absent, so that deleting
+// a file counts as drift.
+func HashBundle(dir string) (string, error) {
+	h := sha256.New()
+	for _, name := range BundleConfigFiles {
+		contents, err := ioutil.ReadFile(filepath.Join(dir, name))
+		if os.IsNotExist(err) {
+			fmt.Fprintf(h, "%s\x00absent\x00", name)
+			continue
+		}
+
+		if err != nil {
+			return "", err
+		}
+
+		fmt.Fprintf(h, "%s\x00%d\x00", name, len(contents))
+		h.Write(contents)
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// Verify checks that the configuration of the bundle for handle still matches
+// the hash recorded when it was created, returning a *BundleDriftedError if
+// not. Bundles without a recorded hash are not verified.
+func (d *DirectoryDepot) Verify(log lager.Logger, handle string) error {
+	log = log.Session("verify", lager.Data{"handle": handle})
+
+	recorded, err := ioutil.ReadFile(filepath.Join(d.toDir(handle), HashFile))
+	if os.IsNotExist(err) {
+		log.Debug("no-recorded-hash")
+		return nil
+	}
+
+	if err != nil {
+		return fmt.Errorf("read bundle hash: %s", err)
+	}
+
+	actual, err := HashBundle(d.toDir(handle))
+	if err != nil {
+		return fmt.Errorf("hash bundle: %s", err)
+	}
+
+	expected := strings.TrimSpace(string(recorded))
+	if actual != expected {
+		return &BundleDriftedError{Handle: handle, Expected: expected, Actual: actual}
+	}
+
+	return nil
+}
+
+// Quarantine marks the bundle for handle as quarantined, recording reason.
+func (d *DirectoryDepot) Quarantine(log lager.Logger, handle, reason string) error {
+	log = log.Session("quarantine", lager.Data{"handle": handle})
+
+	log.Info("started")
+	defer log.Info("finished")
+
+	return ioutil.WriteFile(filepath.Join(d.toDir(handle), QuarantineFile), []byte(reason+"\n"), 0600)
+}
+
+// Quarantined returns whether the bundle for handle has been quarantined.
+func (d *DirectoryDepot) Quarantined(handle string) bool {
+	_, err := os.Stat(filepath.Join(d.toDir(handle), QuarantineFile))
+	return err == nil
+}
+
+func (d *DirectoryDepot) recordHash(path string) error {
+	hash, err := HashBundle(path)
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(filepath.Join(path, HashFile), []byte(hash+"\n"), 0600)
+}
@@ -0,0 +1,132 @@
+package depot_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/cloudfoundry-incubator/guardian/rundmc/depot"
+	"github.com/cloudfoundry-incubator/guardian/rundmc/depot/fakes"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/pivotal-golang/lager"
+	"github.com/pivotal-golang/lager/lagertest"
+)
+
+var _ = Describe("Bundle hashing", func() {
+	var (
+		depotDir   string
+		fakeBundle *fakes.FakeBundleCreator
+		dirdepot   *depot.DirectoryDepot
+		logger     lager.Logger
+	)
+
+	BeforeEach(func() {
+		var err error
+
+		depotDir, err = ioutil.TempDir("", "depot-test")
+		Expect(err).NotTo(HaveOccurred())
+
+		logger = lagertest.NewTestLogger("test")
+
+		fakeBundle = new(fakes.FakeBundleCreator)
+		fakeBundle.SaveStub = func(path string) error {
+			Expect(ioutil.WriteFile(filepath.Join(path, "config.json"), []byte(`{"config":true}`), 0600)).To(Succeed())
+			return ioutil.WriteFile(filepath.Join(path, "runtime.json"), []byte(`{"runtime":true}`), 0600)
+		}
+
+		dirdepot = depot.New(depotDir)
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(depotDir)
+	})
+
+	Describe("HashBundle", func() {
+		It("is reproducible", func() {
+			Expect(dirdepot.Create(logger, "a", fakeBundle)).To(Succeed())
+			Expect(dirdepot.Create(logger, "b", fakeBundle)).To(Succeed())
+
+			hashA, err := depot.HashBundle(filepath.Join(depotDir, "a"))
+			Expect(err).NotTo(HaveOccurred())
+			hashB, err := depot.HashBundle(filepath.Join(depotDir, "b"))
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(hashA).To(HaveLen(64))
+			Expect(hashA).To(Equal(hashB))
+		})
+
+		It("changes when a config file is removed", func() {
+			Expect(dirdepot.Create(logger, "a", fakeBundle)).To(Succeed())
+			before, err := depot.HashBundle(filepath.Join(depotDir, "a"))
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(os.Remove(filepath.Join(depotDir, "a", "runtime.json"))).To(Succeed())
+			Expect(depot.HashBundle(filepath.Join(depotDir, "a"))).NotTo(Equal(before))
+		})
+	})
+
+	Describe("create", func() {
+		It("records the hash of the bundle config", func() {
+			Expect(dirdepot.Create(logger, "aardvaark", fakeBundle)).To(Succeed())
+
+			expected, err := depot.HashBundle(filepath.Join(depotDir, "aardvaark"))
+			Expect(err).NotTo(HaveOccurred())
+
+			recorded, err := ioutil.ReadFile(filepath.Join(depotDir, "aardvaark", depot.HashFile))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(recorded)).To(Equal(expected + "\n"))
+		})
+	})
+
+	Describe("verify", func() {
+		BeforeEach(func() {
+			Expect(dirdepot.Create(logger, "aardvaark", fakeBundle)).To(Succeed())
+		})
+
+		It("succeeds when the bundle has not changed", func() {
+			Expect(dirdepot.Verify(logger, "aardvaark")).To(Succeed())
+		})
+
+		It("returns a BundleDriftedError when the config has been edited", func() {
+			Expect(ioutil.WriteFile(filepath.Join(depotDir, "aardvaark", "config.json"), []byte(`{"config":false}`), 0600)).To(Succeed())
+
+			err := dirdepot.Verify(logger, "aardvaark")
+			Expect(err).To(BeAssignableToTypeOf(&depot.BundleDriftedError{}))
+			Expect(err.(*depot.BundleDriftedError).Handle).To(Equal("aardvaark"))
+		})
+
+		It("ignores files other than the bundle config", func() {
+			Expect(ioutil.WriteFile(filepath.Join(depotDir, "aardvaark", "something-else"), []byte("hi"), 0600)).To(Succeed())
+			Expect(dirdepot.Verify(logger, "aardvaark")).To(Succeed())
+		})
+
+		Context("when the bundle has no recorded hash", func() {
+			It("does not verify it", func() {
+				Expect(os.Remove(filepath.Join(depotDir, "aardvaark", depot.HashFile))).To(Succeed())
+				Expect(ioutil.WriteFile(filepath.Join(depotDir, "aardvaark", "config.json"), []byte(`{"config":false}`), 0600)).To(Succeed())
+
+				Expect(dirdepot.Verify(logger, "aardvaark")).To(Succeed())
+			})
+		})
+	})
+
+	Describe("quarantine", func() {
+		BeforeEach(func() {
+			Expect(dirdepot.Create(logger, "aardvaark", fakeBundle)).To(Succeed())
+		})
+
+		It("is not quarantined by default", func() {
+			Expect(dirdepot.Quarantined("aardvaark")).To(BeFalse())
+		})
+
+		It("marks the bundle as quarantined, recording the reason", func() {
+			Expect(dirdepot.Quarantine(logger, "aardvaark", "it drifted")).To(Succeed())
+			Expect(dirdepot.Quarantined("aardvaark")).To(BeTrue())
+
+			reason, err := ioutil.ReadFile(filepath.Join(depotDir, "aardvaark", depot.QuarantineFile))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(reason)).To(Equal("it drifted\n"))
+		})
+	})
+})
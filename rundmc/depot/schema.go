@@ -0,0 +1,166 @@
+package depot
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/pivotal-golang/lager"
+)
+
+// VersionFile records the schema version of a bundle, i.e. the layout of its
+// config.json and of the state files guardian keeps alongside it. Bundles
+// created before versioning was introduced have no version file, and are at
+// version 0.
+const VersionFile = "version"
+
+// SchemaVersion is the version of the bundles this guardian creates.
+const SchemaVersion = 1
+
+// A Migration upgrades a bundle from schema version From to From+1. It is
+// given the path of the bundle, and must leave it untouched on error.
+type Migration struct {
+	From        int
+	Description string
+	Migrate     func(log lager.Logger, bundlePath string) error
+}
+
+// Migrations upgrade bundles to SchemaVersion. A guardian which changes the
+// layout of bundles must increment SchemaVersion and add a migration from the
+// previous version.
+var Migrations = []Migration{
+	{
+		From:        0,
+		Description: "record the schema version of the bundle",
+		Migrate:     func(lager.Logger, string) error { return nil },
+	},
+}
+
+// Version returns the schema version of the bundle for handle.
+func (d *DirectoryDepot) Version(handle string) (int, error) {
+	contents, err := ioutil.ReadFile(filepath.Join(d.toDir(handle), VersionFile))
+	if os.IsNotExist(err) {
+		return 0, nil
+	}
+
+	if err != nil {
+		return 0, fmt.Errorf("read bundle version: %s", err)
+	}
+
+	version, err := strconv.Atoi(strings.TrimSpace(string(contents)))
+	if err != nil {
+		return 0, fmt.Errorf("parse bundle version: %s", err)
+	}
+
+	return version, nil
+}
+
+func (d *DirectoryDepot) recordVersion(path string, version int) error {
+	return ioutil.WriteFile(filepath.Join(path, VersionFile), []byte(strconv.Itoa(version)+"\n"), 0600)
+}
+
+// Migrator is a Starter which upgrades the bundles left in the depot by an
+// older guardian to the current SchemaVersion, so that they can be recovered.
+// It must be started before the containers are recovered.
+//
+// Bundles which cannot be migrated, which were created by a newer guardian, or
+// whose config has drifted from its recorded hash, are quarantined rather than
+// failing the start, so that the other containers are still recovered. The
+// hash is verified before migrating, so that migrating does not re-record the
+// hash of config which had already drifted.
+type Migrator struct {
+	Depot      *DirectoryDepot
+	Migrations []Migration
+	Version    int
+	Logger     lager.Logger
+}
+
+func (m *Migrator) Start() error {
+	log := m.Logger.Session("migrate-bundles", lager.Data{"version": m.Version})
+
+	handles, err := m.Depot.Handles()
+	if err != nil {
+		log.Error("list-handles-failed", err)
+		return fmt.Errorf("migrate bundles: %s", err)
+	}
+
+	for _, handle := range handles {
+		if m.Depot.Quarantined(handle) {
+			continue
+		}
+
+		hLog := log.Session("bundle", lager.Data{"handle": handle})
+		if err := m.migrate(hLog, handle); err != nil {
+			hLog.Error("failed", err)
+			if err := m.Depot.Quarantine(hLog, handle, err.Error()); err != nil {
+				hLog.Error("quarantine-failed", err)
+			}
+		}
+	}
+
+	return nil
+}
+
+func (m *Migrator) migrate(log lager.Logger, handle string) error {
+	version, err := m.Depot.Version(handle)
+	if err != nil {
+		return err
+	}
+
+	if version > m.Version {
+		return fmt.Errorf("bundle has schema version %d, newer than the supported version %d", version, m.Version)
+	}
+
+	if version == m.Version {
+		return nil
+	}
+
+	if err := m.Depot.Verify(log, handle); err != nil {
+		return fmt.Errorf("verify before migrating: %s", err)
+	}
+
+	path := m.Depot.toDir(handle)
+	_, hashErr := os.Stat(filepath.Join(path, HashFile))
+	hashed := hashErr == nil
+
+	for ; version < m.Version; version++ {
+		migration, ok := m.migrationFrom(version)
+		if !ok {
+			return fmt.Errorf("no migration from schema version %d", version)
+		}
+
+		log.Info("migrating", lager.Data{"from": version, "description": migration.Description})
+		if err := migration.Migrate(log, path); err != nil {
+			return fmt.Errorf("migrate from schema version %d: %s", version, err)
+		}
+
+		// record each step, so that a failed migration is not repeated from
+		// the start
+		if err := m.Depot.recordVersion(path, version+1); err != nil {
+			return fmt.Errorf("record bundle version: %s", err)
+		}
+	}
+
+	// migrations may rewrite the config, which should not count as drift;
+	// the config was verified before it was migrated
+	if hashed {
+		if err := m.Depot.recordHash(path); err != nil {
+			return fmt.Errorf("record bundle hash: %s", err)
+		}
+	}
+
+	return nil
+}
+
+func (m *Migrator) migrationFrom(version int) (Migration, bool) {
+	for _, migration := range m.Migrations {
+		if migration.From == version {
+			return migration, true
+		}
+	}
+
+	return Migration{}, false
+}
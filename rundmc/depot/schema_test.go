@@ -0,0 +1,223 @@
+package depot_test
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/cloudfoundry-incubator/guardian/rundmc/depot"
+	"github.com/cloudfoundry-incubator/guardian/rundmc/depot/fakes"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/pivotal-golang/lager"
+	"github.com/pivotal-golang/lager/lagertest"
+)
+
+var _ = Describe("Bundle schema versioning", func() {
+	var (
+		depotDir   string
+		fakeBundle *fakes.FakeBundleCreator
+		dirdepot   *depot.DirectoryDepot
+		logger     lager.Logger
+	)
+
+	BeforeEach(func() {
+		var err error
+
+		depotDir, err = ioutil.TempDir("", "depot-test")
+		Expect(err).NotTo(HaveOccurred())
+
+		logger = lagertest.NewTestLogger("test")
+
+		fakeBundle = new(fakes.FakeBundleCreator)
+		fakeBundle.SaveStub = func(path string) error {
+			return ioutil.WriteFile(filepath.Join(path, "config.json"), []byte(`{"config":true}`), 0600)
+		}
+
+		dirdepot = depot.New(depotDir)
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(depotDir)
+	})
+
+	writeVersion := func(handle, version string) {
+		Expect(ioutil.WriteFile(filepath.Join(depotDir, handle, depot.VersionFile), []byte(version), 0600)).To(Succeed())
+	}
+
+	Describe("create", func() {
+		It("records the current schema version", func() {
+			Expect(dirdepot.Create(logger, "aardvaark", fakeBundle)).To(Succeed())
+			Expect(dirdepot.Version("aardvaark")).To(Equal(depot.SchemaVersion))
+		})
+	})
+
+	Describe("Version", func() {
+		It("is 0 for bundles created before versioning", func() {
+			Expect(os.MkdirAll(filepath.Join(depotDir, "old"), 0700)).To(Succeed())
+			Expect(dirdepot.Version("old")).To(Equal(0))
+		})
+
+		It("returns an error when the version file is garbage", func() {
+			Expect(os.MkdirAll(filepath.Join(depotDir, "garbage"), 0700)).To(Succeed())
+			writeVersion("garbage", "banana")
+
+			_, err := dirdepot.Version("garbage")
+			Expect(err).To(MatchError(ContainSubstring("parse bundle version")))
+		})
+	})
+
+	Describe("Migrator", func() {
+		var (
+			migrator *depot.Migrator
+			migrated []string
+		)
+
+		BeforeEach(func() {
+			migrated = []string{}
+			migrator = &depot.Migrator{
+				Depot: dirdepot,
+				Migrations: []depot.Migration{
+					{From: 0, Description: "zero to one", Migrate: func(_ lager.Logger, path string) error {
+						migrated = append(migrated, "0:"+filepath.Base(path))
+						return nil
+					}},
+					{From: 1, Description: "one to two", Migrate: func(_ lager.Logger, path string) error {
+						migrated = append(migrated, "1:"+filepath.Base(path))
+						return ioutil.WriteFile(filepath.Join(path, "config.json"), []byte(`{"config":2}`), 0600)
+					}},
+				},
+				Version: 2,
+				Logger:  logger,
+			}
+
+			Expect(dirdepot.Create(logger, "current", fakeBundle)).To(Succeed())
+			writeVersion("current", "2")
+		})
+
+		It("runs each migration needed to bring a bundle up to date, in order", func() {
+			Expect(dirdepot.Create(logger, "old", fakeBundle)).To(Succeed())
+			Expect(os.Remove(filepath.Join(depotDir, "old", depot.VersionFile))).To(Succeed())
+
+			Expect(migrator.Start()).To(Succeed())
+
+			Expect(migrated).To(Equal([]string{"0:old", "1:old"}))
+			Expect(dirdepot.Version("old")).To(Equal(2))
+		})
+
+		It("only runs the migrations the bundle has not had", func() {
+			Expect(dirdepot.Create(logger, "one", fakeBundle)).To(Succeed())
+			writeVersion("one", "1")
+
+			Expect(migrator.Start()).To(Succeed())
+			Expect(migrated).To(Equal([]string{"1:one"}))
+		})
+
+		It("re-records the hash of migrated config, so that it does not count as drift", func() {
+			Expect(dirdepot.Create(logger, "one", fakeBundle)).To(Succeed())
+			writeVersion("one", "1")
+
+			Expect(migrator.Start()).To(Succeed())
+			Expect(dirdepot.Verify(logger, "one")).To(Succeed())
+		})
+
+		It("quarantines bundles whose config has drifted, without migrating them or re-recording their hash", func() {
+			Expect(dirdepot.Create(logger, "drifted", fakeBundle)).To(Succeed())
+			writeVersion("drifted", "0")
+			Expect(ioutil.WriteFile(filepath.Join(depotDir, "drifted", "config.json"), []byte(`{"tampered":true}`), 0600)).To(Succeed())
+
+			Expect(migrator.Start()).To(Succeed())
+
+			Expect(migrated).To(BeEmpty())
+			Expect(dirdepot.Quarantined("drifted")).To(BeTrue())
+			Expect(ioutil.ReadFile(filepath.Join(depotDir, "drifted", depot.QuarantineFile))).To(ContainSubstring("has drifted"))
+			Expect(dirdepot.Version("drifted")).To(Equal(0))
+
+			var driftErr *depot.BundleDriftedError
+			Expect(dirdepot.Verify(logger, "drifted")).To(BeAssignableToTypeOf(driftErr))
+		})
+
+		Context("when a migration fails", func() {
+			BeforeEach(func() {
+				migrator.Migrations[1].Migrate = func(lager.Logger, string) error {
+					return errors.New("bad config")
+				}
+
+				Expect(dirdepot.Create(logger, "old", fakeBundle)).To(Succeed())
+				writeVersion("old", "0")
+			})
+
+			It("quarantines the bundle, recording the step which failed", func() {
+				Expect(migrator.Start()).To(Succeed())
+
+				Expect(dirdepot.Quarantined("old")).To(BeTrue())
+				Expect(ioutil.ReadFile(filepath.Join(depotDir, "old", depot.QuarantineFile))).To(ContainSubstring("migrate from schema version 1: bad config"))
+				Expect(dirdepot.Version("old")).To(Equal(1))
+			})
+
+			It("still migrates the other bundles", func() {
+				Expect(dirdepot.Create(logger, "other", fakeBundle)).To(Succeed())
+				writeVersion("other", "1")
+				migrator.Migrations[1].Migrate = func(_ lager.Logger, path string) error {
+					if filepath.Base(path) == "old" {
+						return errors.New("bad config")
+					}
+					return nil
+				}
+
+				Expect(migrator.Start()).To(Succeed())
+				Expect(dirdepot.Version("other")).To(Equal(2))
+				Expect(dirdepot.Quarantined("other")).To(BeFalse())
+			})
+		})
+
+		Context("when there is no migration from the bundle's version", func() {
+			It("quarantines the bundle", func() {
+				migrator.Migrations = migrator.Migrations[1:]
+				Expect(dirdepot.Create(logger, "old", fakeBundle)).To(Succeed())
+				writeVersion("old", "0")
+
+				Expect(migrator.Start()).To(Succeed())
+				Expect(ioutil.ReadFile(filepath.Join(depotDir, "old", depot.QuarantineFile))).To(ContainSubstring("no migration from schema version 0"))
+			})
+		})
+
+		Context("when the bundle was created by a newer guardian", func() {
+			It("quarantines it without migrating", func() {
+				Expect(dirdepot.Create(logger, "new", fakeBundle)).To(Succeed())
+				writeVersion("new", "3")
+
+				Expect(migrator.Start()).To(Succeed())
+				Expect(migrated).To(BeEmpty())
+				Expect(ioutil.ReadFile(filepath.Join(depotDir, "new", depot.QuarantineFile))).To(ContainSubstring("newer than the supported version 2"))
+			})
+		})
+
+		It("leaves quarantined bundles alone", func() {
+			Expect(dirdepot.Create(logger, "old", fakeBundle)).To(Succeed())
+			writeVersion("old", "0")
+			Expect(dirdepot.Quarantine(logger, "old", "drifted")).To(Succeed())
+
+			Expect(migrator.Start()).To(Succeed())
+			Expect(migrated).To(BeEmpty())
+		})
+
+		Context("when the depot cannot be listed", func() {
+			It("returns an error", func() {
+				migrator.Depot = depot.New("/does/not/exist")
+				Expect(migrator.Start()).To(MatchError(ContainSubstring("migrate bundles")))
+			})
+		})
+
+		It("migrates the bundles of older guardians to the current schema version by default", func() {
+			Expect(dirdepot.Create(logger, "old", fakeBundle)).To(Succeed())
+			Expect(os.Remove(filepath.Join(depotDir, "old", depot.VersionFile))).To(Succeed())
+
+			migrator.Migrations = depot.Migrations
+			migrator.Version = depot.SchemaVersion
+			Expect(migrator.Start()).To(Succeed())
+			Expect(dirdepot.Version("old")).To(Equal(depot.SchemaVersion))
+		})
+	})
+})
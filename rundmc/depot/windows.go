@@ -0,0 +1,159 @@
+package depot
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+
+	"github.com/cloudfoundry-incubator/goci"
+	"github.com/pivotal-golang/lager"
+)
+
+// maxWindowsCPUShares is the highest relative CPU weight a Windows
+// container can be given
+const maxWindowsCPUShares = 10000
+
+type windowsSpec struct {
+	Version  string          `json:"ociVersion"`
+	Platform windowsPlatform `json:"platform"`
+	Process  windowsProcess  `json:"process"`
+	Root     windowsRoot     `json:"root"`
+	Hostname string          `json:"hostname,omitempty"`
+	Mounts   []windowsMount  `json:"mounts,omitempty"`
+	Windows  windowsSection  `json:"windows"`
+}
+
+type windowsPlatform struct {
+	OS   string `json:"os"`
+	Arch string `json:"arch"`
+}
+
+type windowsProcess struct {
+	Terminal bool     `json:"terminal,omitempty"`
+	Args     []string `json:"args"`
+	Env      []string `json:"env,omitempty"`
+	Cwd      string   `json:"cwd"`
+}
+
+type windowsRoot struct {
+	Path string `json:"path"`
+}
+
+type windowsMount struct {
+	Destination string   `json:"destination"`
+	Type        string   `json:"type,omitempty"`
+	Source      string   `json:"source"`
+	Options     []string `json:"options,omitempty"`
+}
+
+type windowsSection struct {
+	Resources *windowsResources `json:"resources,omitempty"`
+}
+
+type windowsResources struct {
+	Memory *windowsMemory `json:"memory,omitempty"`
+	CPU    *windowsCPU    `json:"cpu,omitempty"`
+}
+
+type windowsMemory struct {
+	Limit *uint64 `json:"limit,omitempty"`
+}
+
+type windowsCPU struct {
+	Shares *uint16 `json:"shares,omitempty"`
+}
+
+// WindowsBundle saves a bundle's config.json in the form Windows OCI
+// runtimes such as winc expect. Only the parts of the bundle which apply to
+// Windows are kept: the process, the rootfs volume, bind mounts and the
+// memory and CPU limits. Namespaces, devices, seccomp and the like are
+// dropped.
+type WindowsBundle struct {
+	Bndl *goci.Bndl
+}
+
+func (b WindowsBundle) Save(path string) error {
+	spec := b.Bndl.Spec.Spec
+
+	config := windowsSpec{
+		Version:  spec.Version,
+		Platform: windowsPlatform{OS: "windows", Arch: "amd64"},
+		Process: windowsProcess{
+			Terminal: spec.Process.Terminal,
+			Args:     spec.Process.Args,
+			Env:      spec.Process.Env,
+			Cwd:      spec.Process.Cwd,
+		},
+		Root:     windowsRoot{Path: spec.Root.Path},
+		Hostname: spec.Hostname,
+	}
+
+	if config.Process.Cwd == "" {
+		config.Process.Cwd = `C:\`
+	}
+
+	for _, mount := range spec.Mounts {
+		if mount.Type != "bind" {
+			continue
+		}
+
+		config.Mounts = append(config.Mounts, windowsMount{
+			Destination: mount.Destination,
+			Source:      mount.Source,
+			Options:     mount.Options,
+		})
+	}
+
+	if resources := b.Bndl.Resources(); resources != nil {
+		windowsResources := &windowsResources{}
+		if resources.Memory != nil && resources.Memory.Limit != nil && *resources.Memory.Limit != 0 {
+			windowsResources.Memory = &windowsMemory{Limit: resources.Memory.Limit}
+		}
+
+		if resources.CPU != nil && resources.CPU.Shares != nil {
+			shares := uint16(maxWindowsCPUShares)
+			if *resources.CPU.Shares < maxWindowsCPUShares {
+				shares = uint16(*resources.CPU.Shares)
+			}
+
+			windowsResources.CPU = &windowsCPU{Shares: &shares}
+		}
+
+		if windowsResources.Memory != nil || windowsResources.CPU != nil {
+			config.Windows.Resources = windowsResources
+		}
+	}
+
+	data, err := json.Marshal(config)
+	if err != nil {
+		return fmt.Errorf("windows bundle: %s", err)
+	}
+
+	return ioutil.WriteFile(filepath.Join(path, "config.json"), data, 0600)
+}
+
+// WindowsDepot is a DirectoryDepot which saves bundles as Windows bundles
+type WindowsDepot struct {
+	*DirectoryDepot
+}
+
+func NewWindows(dir string) *WindowsDepot {
+	return &WindowsDepot{DirectoryDepot: New(dir)}
+}
+
+func (d *WindowsDepot) Create(log lager.Logger, handle string, bundle BundleSaver) error {
+	if bndl, ok := bundle.(*goci.Bndl); ok {
+		bundle = WindowsBundle{Bndl: bndl}
+	}
+
+	return d.DirectoryDepot.Create(log, handle, bundle)
+}
+
+func (d *WindowsDepot) Update(log lager.Logger, handle string, bundle BundleSaver) error {
+	if bndl, ok := bundle.(*goci.Bndl); ok {
+		bundle = WindowsBundle{Bndl: bndl}
+	}
+
+	return d.DirectoryDepot.Update(log, handle, bundle)
+}
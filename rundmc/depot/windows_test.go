@@ -0,0 +1,89 @@
+package depot_test
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/cloudfoundry-incubator/goci"
+	"github.com/cloudfoundry-incubator/guardian/rundmc/depot"
+	"github.com/cloudfoundry-incubator/guardian/rundmc/depot/fakes"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/opencontainers/specs"
+	"github.com/pivotal-golang/lager/lagertest"
+)
+
+var _ = Describe("WindowsDepot", func() {
+	var (
+		depotDir     string
+		windowsDepot *depot.WindowsDepot
+		logger       *lagertest.TestLogger
+	)
+
+	BeforeEach(func() {
+		var err error
+		depotDir, err = ioutil.TempDir("", "windows-depot-test")
+		Expect(err).NotTo(HaveOccurred())
+
+		logger = lagertest.NewTestLogger("test")
+		windowsDepot = depot.NewWindows(depotDir)
+	})
+
+	AfterEach(func() {
+		Expect(os.RemoveAll(depotDir)).To(Succeed())
+	})
+
+	readConfig := func(handle string) map[string]interface{} {
+		data, err := ioutil.ReadFile(filepath.Join(depotDir, handle, "config.json"))
+		Expect(err).NotTo(HaveOccurred())
+
+		var config map[string]interface{}
+		Expect(json.Unmarshal(data, &config)).To(Succeed())
+		return config
+	}
+
+	It("saves bundles in the form Windows runtimes expect", func() {
+		memory := uint64(1024)
+		shares := uint64(20000)
+		bndl := goci.Bundle().
+			WithRootFS(`\\?\Volume{some-guid}\`).
+			WithProcess(specs.Process{Args: []string{"cmd.exe"}, Env: []string{"FOO=bar"}}).
+			WithMounts(
+				specs.Mount{Type: "proc", Source: "proc", Destination: "/proc"},
+				specs.Mount{Type: "bind", Source: `C:\src`, Destination: `C:\dst`, Options: []string{"bind", "ro"}},
+			).
+			WithMemoryLimit(specs.Memory{Limit: &memory})
+		resources := *bndl.Resources()
+		resources.CPU = &specs.CPU{Shares: &shares}
+		bndl = bndl.WithResources(&resources)
+
+		Expect(windowsDepot.Create(logger, "some-handle", bndl)).To(Succeed())
+
+		config := readConfig("some-handle")
+		Expect(config["platform"]).To(Equal(map[string]interface{}{"os": "windows", "arch": "amd64"}))
+		Expect(config["root"]).To(Equal(map[string]interface{}{"path": `\\?\Volume{some-guid}\`}))
+		Expect(config["process"]).To(Equal(map[string]interface{}{
+			"args": []interface{}{"cmd.exe"},
+			"env":  []interface{}{"FOO=bar"},
+			"cwd":  `C:\`,
+		}))
+		Expect(config["mounts"]).To(Equal([]interface{}{
+			map[string]interface{}{"destination": `C:\dst`, "source": `C:\src`, "options": []interface{}{"bind", "ro"}},
+		}))
+		Expect(config["windows"]).To(Equal(map[string]interface{}{
+			"resources": map[string]interface{}{
+				"memory": map[string]interface{}{"limit": float64(1024)},
+				"cpu":    map[string]interface{}{"shares": float64(10000)},
+			},
+		}))
+		Expect(config).NotTo(HaveKey("linux"))
+	})
+
+	It("saves other bundles as they are", func() {
+		fakeBundle := new(fakes.FakeBundleSaver)
+		Expect(windowsDepot.Create(logger, "some-handle", fakeBundle)).To(Succeed())
+		Expect(fakeBundle.SaveArgsForCall(0)).To(Equal(filepath.Join(depotDir, "some-handle")))
+	})
+})
@@ -0,0 +1,207 @@
+package rundmc
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/cloudfoundry-incubator/garden"
+	"github.com/cloudfoundry-incubator/guardian/gardener"
+	"github.com/pivotal-golang/lager"
+)
+
+// ScratchMountPath is where a container's scratch space is mounted.
+const ScratchMountPath = "/tmp"
+
+//go:generate counterfeiter . ProjectQuota
+type ProjectQuota interface {
+	Assign(log lager.Logger, path string, project uint32) error
+	Limit(log lager.Logger, project uint32, limitInBytes uint64) error
+	Usage(log lager.Logger, project uint32) (uint64, error)
+}
+
+// NoDiskQuotas is used when no quota filesystem is configured: containers
+// get no scratch space and only the image plugin enforces disk limits.
+type NoDiskQuotas struct{}
+
+func (NoDiskQuotas) Prepare(log lager.Logger, spec gardener.DesiredContainerSpec) (gardener.DesiredContainerSpec, error) {
+	return spec, nil
+}
+
+func (NoDiskQuotas) Release(log lager.Logger, handle string) error {
+	return nil
+}
+
+// DiskQuotaManager gives each container a scratch directory, mounted at
+// ScratchMountPath, and places it in a project quota limited to the
+// container's disk limit. This is in addition to the limit the image plugin
+// places on the root filesystem. The sources of the container's bind mounts
+// are host directories which outlive the container, so they are not placed
+// in its project.
+//
+// Each container's directory under the scratch root holds its scratch space
+// and the project ID it was allocated, so that allocations survive restarts.
+type DiskQuotaManager struct {
+	quota ProjectQuota
+	root  string
+
+	mu          sync.Mutex
+	nextProject uint32
+}
+
+func NewDiskQuotaManager(quota ProjectQuota, scratchRoot string, firstProject uint32) (*DiskQuotaManager, error) {
+	m := &DiskQuotaManager{
+		quota:       quota,
+		root:        scratchRoot,
+		nextProject: firstProject,
+	}
+
+	if err := os.MkdirAll(scratchRoot, 0755); err != nil {
+		return nil, fmt.Errorf("disk quota: create scratch root: %s", err)
+	}
+
+	dirs, err := ioutil.ReadDir(scratchRoot)
+	if err != nil {
+		return nil, fmt.Errorf("disk quota: read scratch root: %s", err)
+	}
+
+	for _, dir := range dirs {
+		project, err := m.project(dir.Name())
+		if err != nil {
+			continue
+		}
+
+		if project >= m.nextProject {
+			m.nextProject = project + 1
+		}
+	}
+
+	return m, nil
+}
+
+// Prepare creates the container's scratch space, applies its quota and
+// returns spec with the scratch space added as a bind mount.
+func (m *DiskQuotaManager) Prepare(log lager.Logger, spec gardener.DesiredContainerSpec) (gardener.DesiredContainerSpec, error) {
+	log = log.Session("disk-quota-prepare", lager.Data{"handle": spec.Handle})
+
+	log.Info("started")
+	defer log.Info("finished")
+
+	scratch := m.scratchPath(spec.Handle)
+	if err := os.MkdirAll(scratch, 0755); err != nil {
+		return spec, fmt.Errorf("disk quota: create scratch: %s", err)
+	}
+
+	// writable by every user in the container, like any /tmp
+	if err := os.Chmod(scratch, 01777); err != nil {
+		m.removeOrLog(log, spec.Handle)
+		return spec, fmt.Errorf("disk quota: chmod scratch: %s", err)
+	}
+
+	project := m.allocate()
+	if err := ioutil.WriteFile(m.projectPath(spec.Handle), []byte(strconv.FormatUint(uint64(project), 10)), 0600); err != nil {
+		m.removeOrLog(log, spec.Handle)
+		return spec, fmt.Errorf("disk quota: record project: %s", err)
+	}
+
+	if err := m.quota.Assign(log, scratch, project); err != nil {
+		m.removeOrLog(log, spec.Handle)
+		return spec, err
+	}
+
+	if limit := spec.Limits.Disk.ByteHard; limit > 0 {
+		if err := m.quota.Limit(log, project, limit); err != nil {
+			m.removeOrLog(log, spec.Handle)
+			return spec, err
+		}
+	}
+
+	bindMounts := make([]garden.BindMount, len(spec.BindMounts), len(spec.BindMounts)+1)
+	copy(bindMounts, spec.BindMounts)
+	spec.BindMounts = append(bindMounts, garden.BindMount{
+		SrcPath: scratch,
+		DstPath: ScratchMountPath,
+		Mode:    garden.BindMountModeRW,
+	})
+
+	return spec, nil
+}
+
+// Release removes the container's quota limit and deletes its scratch space.
+func (m *DiskQuotaManager) Release(log lager.Logger, handle string) error {
+	log = log.Session("disk-quota-release", lager.Data{"handle": handle})
+
+	log.Info("started")
+	defer log.Info("finished")
+
+	project, err := m.project(handle)
+	if os.IsNotExist(err) {
+		return nil
+	}
+
+	if err != nil {
+		return fmt.Errorf("disk quota: read project: %s", err)
+	}
+
+	if err := m.quota.Limit(log, project, 0); err != nil {
+		return err
+	}
+
+	return os.RemoveAll(m.containerPath(handle))
+}
+
+// ScratchUsage returns the number of bytes used by the container's scratch
+// space.
+func (m *DiskQuotaManager) ScratchUsage(log lager.Logger, handle string) (uint64, error) {
+	project, err := m.project(handle)
+	if err != nil {
+		return 0, fmt.Errorf("disk quota: read project: %s", err)
+	}
+
+	return m.quota.Usage(log, project)
+}
+
+func (m *DiskQuotaManager) allocate() uint32 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	project := m.nextProject
+	m.nextProject++
+	return project
+}
+
+func (m *DiskQuotaManager) project(handle string) (uint32, error) {
+	contents, err := ioutil.ReadFile(m.projectPath(handle))
+	if err != nil {
+		return 0, err
+	}
+
+	project, err := strconv.ParseUint(strings.TrimSpace(string(contents)), 10, 32)
+	if err != nil {
+		return 0, err
+	}
+
+	return uint32(project), nil
+}
+
+func (m *DiskQuotaManager) removeOrLog(log lager.Logger, handle string) {
+	if err := os.RemoveAll(m.containerPath(handle)); err != nil {
+		log.Error("remove-failed", err)
+	}
+}
+
+func (m *DiskQuotaManager) containerPath(handle string) string {
+	return filepath.Join(m.root, handle)
+}
+
+func (m *DiskQuotaManager) scratchPath(handle string) string {
+	return filepath.Join(m.root, handle, "scratch")
+}
+
+func (m *DiskQuotaManager) projectPath(handle string) string {
+	return filepath.Join(m.root, handle, "project")
+}
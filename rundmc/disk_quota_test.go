@@ -0,0 +1,228 @@
+package rundmc_test
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/cloudfoundry-incubator/garden"
+	"github.com/cloudfoundry-incubator/guardian/gardener"
+	"github.com/cloudfoundry-incubator/guardian/rundmc"
+	"github.com/cloudfoundry-incubator/guardian/rundmc/fakes"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/pivotal-golang/lager"
+	"github.com/pivotal-golang/lager/lagertest"
+)
+
+var _ = Describe("DiskQuotaManager", func() {
+	var (
+		logger      lager.Logger
+		fakeQuota   *fakes.FakeProjectQuota
+		scratchRoot string
+		manager     *rundmc.DiskQuotaManager
+		spec        gardener.DesiredContainerSpec
+	)
+
+	BeforeEach(func() {
+		var err error
+		logger = lagertest.NewTestLogger("test")
+		fakeQuota = new(fakes.FakeProjectQuota)
+
+		scratchRoot, err = ioutil.TempDir("", "scratch")
+		Expect(err).NotTo(HaveOccurred())
+
+		spec = gardener.DesiredContainerSpec{
+			Handle: "some-handle",
+			BindMounts: []garden.BindMount{
+				{SrcPath: "/host/ro", DstPath: "/ro", Mode: garden.BindMountModeRO},
+				{SrcPath: "/host/rw", DstPath: "/rw", Mode: garden.BindMountModeRW},
+			},
+			Limits: garden.Limits{
+				Disk: garden.DiskLimits{ByteHard: 1024 * 1024},
+			},
+		}
+	})
+
+	JustBeforeEach(func() {
+		var err error
+		manager, err = rundmc.NewDiskQuotaManager(fakeQuota, scratchRoot, 1000)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		Expect(os.RemoveAll(scratchRoot)).To(Succeed())
+	})
+
+	Describe("Prepare", func() {
+		It("creates a world-writable scratch directory", func() {
+			_, err := manager.Prepare(logger, spec)
+			Expect(err).NotTo(HaveOccurred())
+
+			info, err := os.Stat(filepath.Join(scratchRoot, "some-handle", "scratch"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(info.IsDir()).To(BeTrue())
+			Expect(info.Mode() & os.ModePerm).To(Equal(os.FileMode(0777)))
+			Expect(info.Mode() & os.ModeSticky).NotTo(BeZero())
+		})
+
+		It("mounts the scratch directory at /tmp", func() {
+			prepared, err := manager.Prepare(logger, spec)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(prepared.BindMounts).To(Equal([]garden.BindMount{
+				spec.BindMounts[0],
+				spec.BindMounts[1],
+				{SrcPath: filepath.Join(scratchRoot, "some-handle", "scratch"), DstPath: "/tmp", Mode: garden.BindMountModeRW},
+			}))
+		})
+
+		It("does not modify the bind mounts of the original spec", func() {
+			_, err := manager.Prepare(logger, spec)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(spec.BindMounts).To(HaveLen(2))
+		})
+
+		It("places the scratch directory in a project", func() {
+			_, err := manager.Prepare(logger, spec)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(fakeQuota.AssignCallCount()).To(Equal(1))
+			_, path, project := fakeQuota.AssignArgsForCall(0)
+			Expect(path).To(Equal(filepath.Join(scratchRoot, "some-handle", "scratch")))
+			Expect(project).To(BeEquivalentTo(1000))
+		})
+
+		It("does not place the sources of bind mounts, which are host directories, in the project", func() {
+			_, err := manager.Prepare(logger, spec)
+			Expect(err).NotTo(HaveOccurred())
+
+			for i := 0; i < fakeQuota.AssignCallCount(); i++ {
+				_, path, _ := fakeQuota.AssignArgsForCall(i)
+				Expect(path).NotTo(HavePrefix("/host"))
+			}
+		})
+
+		It("limits the project to the disk limit", func() {
+			_, err := manager.Prepare(logger, spec)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(fakeQuota.LimitCallCount()).To(Equal(1))
+			_, project, limit := fakeQuota.LimitArgsForCall(0)
+			Expect(project).To(BeEquivalentTo(1000))
+			Expect(limit).To(BeEquivalentTo(1024 * 1024))
+		})
+
+		It("allocates a different project to each container", func() {
+			_, err := manager.Prepare(logger, spec)
+			Expect(err).NotTo(HaveOccurred())
+
+			spec.Handle = "another-handle"
+			_, err = manager.Prepare(logger, spec)
+			Expect(err).NotTo(HaveOccurred())
+
+			_, _, project := fakeQuota.AssignArgsForCall(2)
+			Expect(project).To(BeEquivalentTo(1001))
+		})
+
+		Context("when there is no disk limit", func() {
+			BeforeEach(func() {
+				spec.Limits.Disk.ByteHard = 0
+			})
+
+			It("assigns a project without limiting it, so that usage is still reported", func() {
+				_, err := manager.Prepare(logger, spec)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(fakeQuota.AssignCallCount()).To(Equal(2))
+				Expect(fakeQuota.LimitCallCount()).To(Equal(0))
+			})
+		})
+
+		Context("when assigning the project fails", func() {
+			BeforeEach(func() {
+				fakeQuota.AssignReturns(errors.New("not a project quota filesystem"))
+			})
+
+			It("returns the error and removes the scratch directory", func() {
+				_, err := manager.Prepare(logger, spec)
+				Expect(err).To(MatchError("not a project quota filesystem"))
+				Expect(filepath.Join(scratchRoot, "some-handle")).NotTo(BeADirectory())
+			})
+		})
+
+		Context("when limiting the project fails", func() {
+			BeforeEach(func() {
+				fakeQuota.LimitReturns(errors.New("boom"))
+			})
+
+			It("returns the error and removes the scratch directory", func() {
+				_, err := manager.Prepare(logger, spec)
+				Expect(err).To(MatchError("boom"))
+				Expect(filepath.Join(scratchRoot, "some-handle")).NotTo(BeADirectory())
+			})
+		})
+	})
+
+	Context("when containers were prepared by a previous manager", func() {
+		BeforeEach(func() {
+			previous, err := rundmc.NewDiskQuotaManager(fakeQuota, scratchRoot, 1000)
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = previous.Prepare(logger, spec)
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("does not reuse their projects", func() {
+			spec.Handle = "another-handle"
+			_, err := manager.Prepare(logger, spec)
+			Expect(err).NotTo(HaveOccurred())
+
+			_, _, project := fakeQuota.AssignArgsForCall(fakeQuota.AssignCallCount() - 1)
+			Expect(project).To(BeEquivalentTo(1001))
+		})
+	})
+
+	Describe("Release", func() {
+		JustBeforeEach(func() {
+			_, err := manager.Prepare(logger, spec)
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("removes the limit and the scratch directory", func() {
+			Expect(manager.Release(logger, "some-handle")).To(Succeed())
+
+			Expect(fakeQuota.LimitCallCount()).To(Equal(2))
+			_, project, limit := fakeQuota.LimitArgsForCall(1)
+			Expect(project).To(BeEquivalentTo(1000))
+			Expect(limit).To(BeZero())
+
+			Expect(filepath.Join(scratchRoot, "some-handle")).NotTo(BeADirectory())
+		})
+
+		It("succeeds when the container has no scratch directory", func() {
+			Expect(manager.Release(logger, "unknown-handle")).To(Succeed())
+		})
+	})
+
+	Describe("ScratchUsage", func() {
+		JustBeforeEach(func() {
+			_, err := manager.Prepare(logger, spec)
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("returns the usage of the container's project", func() {
+			fakeQuota.UsageReturns(4096, nil)
+
+			Expect(manager.ScratchUsage(logger, "some-handle")).To(BeEquivalentTo(4096))
+			_, project := fakeQuota.UsageArgsForCall(0)
+			Expect(project).To(BeEquivalentTo(1000))
+		})
+
+		It("returns an error for an unknown container", func() {
+			_, err := manager.ScratchUsage(logger, "unknown-handle")
+			Expect(err).To(HaveOccurred())
+		})
+	})
+})
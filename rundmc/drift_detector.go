@@ -0,0 +1,103 @@
+package rundmc
+
+import (
+	"time"
+
+	"github.com/cloudfoundry-incubator/guardian/rundmc/depot"
+	"github.com/pivotal-golang/clock"
+	"github.com/pivotal-golang/lager"
+)
+
+//go:generate counterfeiter . BundleVerifier
+type BundleVerifier interface {
+	Handles() ([]string, error)
+	Verify(log lager.Logger, handle string) error
+	Quarantine(log lager.Logger, handle, reason string) error
+	Quarantined(handle string) bool
+}
+
+// DriftDetector periodically checks that the on-disk config of every bundle
+// still matches the config it was created with. Drifted bundles are logged
+// and, if quarantining is enabled, their processes are killed and the bundle
+// is marked as quarantined so that an operator can investigate.
+type DriftDetector struct {
+	verifier   BundleVerifier
+	runner     BundleRunner
+	clock      clock.Clock
+	interval   time.Duration
+	quarantine bool
+	logger     lager.Logger
+}
+
+func NewDriftDetector(logger lager.Logger, verifier BundleVerifier, runner BundleRunner, clock clock.Clock, interval time.Duration, quarantine bool) *DriftDetector {
+	return &DriftDetector{
+		verifier:   verifier,
+		runner:     runner,
+		clock:      clock,
+		interval:   interval,
+		quarantine: quarantine,
+		logger:     logger,
+	}
+}
+
+// Start begins checking in the background every interval.
+func (d *DriftDetector) Start() error {
+	go func() {
+		ticker := d.clock.NewTicker(d.interval)
+		defer ticker.Stop()
+
+		for range ticker.C() {
+			d.CheckAll()
+		}
+	}()
+
+	return nil
+}
+
+// CheckAll verifies every bundle once. Bundles which are already quarantined
+// are skipped. Only bundles whose config has drifted are quarantined; failing
+// to verify a bundle, e.g. because it cannot be read, is only logged.
+func (d *DriftDetector) CheckAll() {
+	log := d.logger.Session("check-all")
+
+	handles, err := d.verifier.Handles()
+	if err != nil {
+		log.Error("list-handles-failed", err)
+		return
+	}
+
+	for _, handle := range handles {
+		if d.verifier.Quarantined(handle) {
+			continue
+		}
+
+		err := d.verifier.Verify(log, handle)
+		if err == nil {
+			continue
+		}
+
+		if _, drifted := err.(*depot.BundleDriftedError); !drifted {
+			log.Error("verify-failed", err, lager.Data{"handle": handle})
+			continue
+		}
+
+		log.Error("bundle-drifted", err, lager.Data{"handle": handle})
+
+		if d.quarantine {
+			d.quarantineBundle(log, handle, err.Error())
+		}
+	}
+}
+
+func (d *DriftDetector) quarantineBundle(log lager.Logger, handle, reason string) {
+	log = log.Session("quarantine", lager.Data{"handle": handle})
+
+	if err := d.verifier.Quarantine(log, handle, reason); err != nil {
+		log.Error("mark-failed", err)
+		return
+	}
+
+	if err := d.runner.Kill(log, handle); err != nil {
+		log.Error("kill-failed", err)
+	}
+}
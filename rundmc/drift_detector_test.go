@@ -0,0 +1,141 @@
+package rundmc_test
+
+import (
+	"errors"
+	"time"
+
+	"github.com/cloudfoundry-incubator/guardian/rundmc"
+	"github.com/cloudfoundry-incubator/guardian/rundmc/depot"
+	"github.com/cloudfoundry-incubator/guardian/rundmc/fakes"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+	"github.com/pivotal-golang/clock/fakeclock"
+	"github.com/pivotal-golang/lager"
+	"github.com/pivotal-golang/lager/lagertest"
+)
+
+var _ = Describe("DriftDetector", func() {
+	var (
+		logger       *lagertest.TestLogger
+		fakeVerifier *fakes.FakeBundleVerifier
+		fakeRunner   *fakes.FakeBundleRunner
+		fakeClock    *fakeclock.FakeClock
+		quarantine   bool
+
+		detector *rundmc.DriftDetector
+	)
+
+	BeforeEach(func() {
+		logger = lagertest.NewTestLogger("test")
+		fakeVerifier = new(fakes.FakeBundleVerifier)
+		fakeRunner = new(fakes.FakeBundleRunner)
+		fakeClock = fakeclock.NewFakeClock(time.Now())
+		quarantine = false
+
+		fakeVerifier.HandlesReturns([]string{"clean", "drifted"}, nil)
+		fakeVerifier.VerifyStub = func(_ lager.Logger, handle string) error {
+			if handle == "drifted" {
+				return &depot.BundleDriftedError{Handle: handle, Expected: "abc", Actual: "def"}
+			}
+
+			return nil
+		}
+	})
+
+	JustBeforeEach(func() {
+		detector = rundmc.NewDriftDetector(logger, fakeVerifier, fakeRunner, fakeClock, time.Minute, quarantine)
+	})
+
+	It("verifies every bundle", func() {
+		detector.CheckAll()
+
+		Expect(fakeVerifier.VerifyCallCount()).To(Equal(2))
+		_, handle := fakeVerifier.VerifyArgsForCall(0)
+		Expect(handle).To(Equal("clean"))
+		_, handle = fakeVerifier.VerifyArgsForCall(1)
+		Expect(handle).To(Equal("drifted"))
+	})
+
+	It("logs drifted bundles", func() {
+		detector.CheckAll()
+
+		Expect(logger).To(gbytes.Say("bundle-drifted.*config hash is def, expected abc.*drifted"))
+	})
+
+	It("does not quarantine drifted bundles by default", func() {
+		detector.CheckAll()
+
+		Expect(fakeVerifier.QuarantineCallCount()).To(Equal(0))
+		Expect(fakeRunner.KillCallCount()).To(Equal(0))
+	})
+
+	It("skips bundles which are already quarantined", func() {
+		fakeVerifier.QuarantinedStub = func(handle string) bool {
+			return handle == "drifted"
+		}
+
+		detector.CheckAll()
+
+		Expect(fakeVerifier.VerifyCallCount()).To(Equal(1))
+	})
+
+	Context("when quarantining is enabled", func() {
+		BeforeEach(func() {
+			quarantine = true
+		})
+
+		It("marks drifted bundles as quarantined and kills them", func() {
+			detector.CheckAll()
+
+			Expect(fakeVerifier.QuarantineCallCount()).To(Equal(1))
+			_, handle, reason := fakeVerifier.QuarantineArgsForCall(0)
+			Expect(handle).To(Equal("drifted"))
+			Expect(reason).To(Equal("bundle drifted has drifted: config hash is def, expected abc"))
+
+			Expect(fakeRunner.KillCallCount()).To(Equal(1))
+			_, killed := fakeRunner.KillArgsForCall(0)
+			Expect(killed).To(Equal("drifted"))
+		})
+
+		It("does not quarantine bundles which could not be verified", func() {
+			fakeVerifier.VerifyReturns(errors.New("input/output error"))
+
+			detector.CheckAll()
+
+			Expect(fakeVerifier.QuarantineCallCount()).To(Equal(0))
+			Expect(fakeRunner.KillCallCount()).To(Equal(0))
+			Expect(logger).To(gbytes.Say("verify-failed.*input/output error"))
+		})
+
+		It("does not kill the container if it cannot be marked", func() {
+			fakeVerifier.QuarantineReturns(errors.New("read-only file system"))
+			detector.CheckAll()
+
+			Expect(fakeRunner.KillCallCount()).To(Equal(0))
+		})
+	})
+
+	Context("when the handles cannot be listed", func() {
+		BeforeEach(func() {
+			fakeVerifier.HandlesReturns(nil, errors.New("banana"))
+		})
+
+		It("logs the error", func() {
+			detector.CheckAll()
+
+			Expect(fakeVerifier.VerifyCallCount()).To(Equal(0))
+			Expect(logger).To(gbytes.Say("list-handles-failed"))
+		})
+	})
+
+	Describe("Start", func() {
+		It("checks every interval", func() {
+			Expect(detector.Start()).To(Succeed())
+
+			Eventually(fakeClock.WatcherCount).Should(Equal(1))
+			fakeClock.Increment(time.Minute)
+			Eventually(fakeVerifier.VerifyCallCount).Should(Equal(2))
+		})
+	})
+})
@@ -0,0 +1,138 @@
+// This file was generated by counterfeiter
+package fakes
+
+import (
+	"sync"
+
+	"github.com/cloudfoundry-incubator/garden"
+	"github.com/cloudfoundry-incubator/goci"
+	"github.com/cloudfoundry-incubator/guardian/rundmc"
+	"github.com/pivotal-golang/lager"
+)
+
+type FakeBindMounter struct {
+	AddStub        func(log lager.Logger, bundlePath string, mount garden.BindMount) (*goci.Bndl, error)
+	addMutex       sync.RWMutex
+	addArgsForCall []struct {
+		log        lager.Logger
+		bundlePath string
+		mount      garden.BindMount
+	}
+	addReturns struct {
+		result1 *goci.Bndl
+		result2 error
+	}
+	RemoveStub        func(log lager.Logger, bundlePath, dstPath string) (*goci.Bndl, error)
+	removeMutex       sync.RWMutex
+	removeArgsForCall []struct {
+		log        lager.Logger
+		bundlePath string
+		dstPath    string
+	}
+	removeReturns struct {
+		result1 *goci.Bndl
+		result2 error
+	}
+	ReleaseStub        func(log lager.Logger, bundlePath string)
+	releaseMutex       sync.RWMutex
+	releaseArgsForCall []struct {
+		log        lager.Logger
+		bundlePath string
+	}
+}
+
+func (fake *FakeBindMounter) Add(log lager.Logger, bundlePath string, mount garden.BindMount) (*goci.Bndl, error) {
+	fake.addMutex.Lock()
+	fake.addArgsForCall = append(fake.addArgsForCall, struct {
+		log        lager.Logger
+		bundlePath string
+		mount      garden.BindMount
+	}{log, bundlePath, mount})
+	fake.addMutex.Unlock()
+	if fake.AddStub != nil {
+		return fake.AddStub(log, bundlePath, mount)
+	} else {
+		return fake.addReturns.result1, fake.addReturns.result2
+	}
+}
+
+func (fake *FakeBindMounter) AddCallCount() int {
+	fake.addMutex.RLock()
+	defer fake.addMutex.RUnlock()
+	return len(fake.addArgsForCall)
+}
+
+func (fake *FakeBindMounter) AddArgsForCall(i int) (lager.Logger, string, garden.BindMount) {
+	fake.addMutex.RLock()
+	defer fake.addMutex.RUnlock()
+	return fake.addArgsForCall[i].log, fake.addArgsForCall[i].bundlePath, fake.addArgsForCall[i].mount
+}
+
+func (fake *FakeBindMounter) AddReturns(result1 *goci.Bndl, result2 error) {
+	fake.AddStub = nil
+	fake.addReturns = struct {
+		result1 *goci.Bndl
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeBindMounter) Remove(log lager.Logger, bundlePath string, dstPath string) (*goci.Bndl, error) {
+	fake.removeMutex.Lock()
+	fake.removeArgsForCall = append(fake.removeArgsForCall, struct {
+		log        lager.Logger
+		bundlePath string
+		dstPath    string
+	}{log, bundlePath, dstPath})
+	fake.removeMutex.Unlock()
+	if fake.RemoveStub != nil {
+		return fake.RemoveStub(log, bundlePath, dstPath)
+	} else {
+		return fake.removeReturns.result1, fake.removeReturns.result2
+	}
+}
+
+func (fake *FakeBindMounter) RemoveCallCount() int {
+	fake.removeMutex.RLock()
+	defer fake.removeMutex.RUnlock()
+	return len(fake.removeArgsForCall)
+}
+
+func (fake *FakeBindMounter) RemoveArgsForCall(i int) (lager.Logger, string, string) {
+	fake.removeMutex.RLock()
+	defer fake.removeMutex.RUnlock()
+	return fake.removeArgsForCall[i].log, fake.removeArgsForCall[i].bundlePath, fake.removeArgsForCall[i].dstPath
+}
+
+func (fake *FakeBindMounter) RemoveReturns(result1 *goci.Bndl, result2 error) {
+	fake.RemoveStub = nil
+	fake.removeReturns = struct {
+		result1 *goci.Bndl
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeBindMounter) Release(log lager.Logger, bundlePath string) {
+	fake.releaseMutex.Lock()
+	fake.releaseArgsForCall = append(fake.releaseArgsForCall, struct {
+		log        lager.Logger
+		bundlePath string
+	}{log, bundlePath})
+	fake.releaseMutex.Unlock()
+	if fake.ReleaseStub != nil {
+		fake.ReleaseStub(log, bundlePath)
+	}
+}
+
+func (fake *FakeBindMounter) ReleaseCallCount() int {
+	fake.releaseMutex.RLock()
+	defer fake.releaseMutex.RUnlock()
+	return len(fake.releaseArgsForCall)
+}
+
+func (fake *FakeBindMounter) ReleaseArgsForCall(i int) (lager.Logger, string) {
+	fake.releaseMutex.RLock()
+	defer fake.releaseMutex.RUnlock()
+	return fake.releaseArgsForCall[i].log, fake.releaseArgsForCall[i].bundlePath
+}
+
+var _ rundmc.BindMounter = new(FakeBindMounter)
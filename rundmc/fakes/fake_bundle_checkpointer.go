@@ -0,0 +1,109 @@
+// This file was generated by counterfeiter
+package fakes
+
+import (
+	"sync"
+
+	"github.com/cloudfoundry-incubator/garden"
+	"github.com/cloudfoundry-incubator/guardian/rundmc"
+	"github.com/pivotal-golang/lager"
+)
+
+type FakeBundleCheckpointer struct {
+	CheckpointStub        func(log lager.Logger, id, imagePath string) error
+	checkpointMutex       sync.RWMutex
+	checkpointArgsForCall []struct {
+		log       lager.Logger
+		id        string
+		imagePath string
+	}
+	checkpointReturns struct {
+		result1 error
+	}
+	RestoreStub        func(log lager.Logger, bundlePath, id, imagePath string, io garden.ProcessIO) (garden.Process, error)
+	restoreMutex       sync.RWMutex
+	restoreArgsForCall []struct {
+		log        lager.Logger
+		bundlePath string
+		id         string
+		imagePath  string
+		io         garden.ProcessIO
+	}
+	restoreReturns struct {
+		result1 garden.Process
+		result2 error
+	}
+}
+
+func (fake *FakeBundleCheckpointer) Checkpoint(log lager.Logger, id string, imagePath string) error {
+	fake.checkpointMutex.Lock()
+	fake.checkpointArgsForCall = append(fake.checkpointArgsForCall, struct {
+		log       lager.Logger
+		id        string
+		imagePath string
+	}{log, id, imagePath})
+	fake.checkpointMutex.Unlock()
+	if fake.CheckpointStub != nil {
+		return fake.CheckpointStub(log, id, imagePath)
+	} else {
+		return fake.checkpointReturns.result1
+	}
+}
+
+func (fake *FakeBundleCheckpointer) CheckpointCallCount() int {
+	fake.checkpointMutex.RLock()
+	defer fake.checkpointMutex.RUnlock()
+	return len(fake.checkpointArgsForCall)
+}
+
+func (fake *FakeBundleCheckpointer) CheckpointArgsForCall(i int) (lager.Logger, string, string) {
+	fake.checkpointMutex.RLock()
+	defer fake.checkpointMutex.RUnlock()
+	return fake.checkpointArgsForCall[i].log, fake.checkpointArgsForCall[i].id, fake.checkpointArgsForCall[i].imagePath
+}
+
+func (fake *FakeBundleCheckpointer) CheckpointReturns(result1 error) {
+	fake.CheckpointStub = nil
+	fake.checkpointReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeBundleCheckpointer) Restore(log lager.Logger, bundlePath string, id string, imagePath string, io garden.ProcessIO) (garden.Process, error) {
+	fake.restoreMutex.Lock()
+	fake.restoreArgsForCall = append(fake.restoreArgsForCall, struct {
+		log        lager.Logger
+		bundlePath string
+		id         string
+		imagePath  string
+		io         garden.ProcessIO
+	}{log, bundlePath, id, imagePath, io})
+	fake.restoreMutex.Unlock()
+	if fake.RestoreStub != nil {
+		return fake.RestoreStub(log, bundlePath, id, imagePath, io)
+	} else {
+		return fake.restoreReturns.result1, fake.restoreReturns.result2
+	}
+}
+
+func (fake *FakeBundleCheckpointer) RestoreCallCount() int {
+	fake.restoreMutex.RLock()
+	defer fake.restoreMutex.RUnlock()
+	return len(fake.restoreArgsForCall)
+}
+
+func (fake *FakeBundleCheckpointer) RestoreArgsForCall(i int) (lager.Logger, string, string, string, garden.ProcessIO) {
+	fake.restoreMutex.RLock()
+	defer fake.restoreMutex.RUnlock()
+	return fake.restoreArgsForCall[i].log, fake.restoreArgsForCall[i].bundlePath, fake.restoreArgsForCall[i].id, fake.restoreArgsForCall[i].imagePath, fake.restoreArgsForCall[i].io
+}
+
+func (fake *FakeBundleCheckpointer) RestoreReturns(result1 garden.Process, result2 error) {
+	fake.RestoreStub = nil
+	fake.restoreReturns = struct {
+		result1 garden.Process
+		result2 error
+	}{result1, result2}
+}
+
+var _ rundmc.BundleCheckpointer = new(FakeBundleCheckpointer)
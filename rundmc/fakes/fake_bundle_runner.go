@@ -35,6 +35,18 @@ type FakeBundleRunner struct {
 		result1 garden.Process
 		result2 error
 	}
+	AttachStub        func(log lager.Logger, id, processID string, io garden.ProcessIO) (garden.Process, error)
+	attachMutex       sync.RWMutex
+	attachArgsForCall []struct {
+		log       lager.Logger
+		id        string
+		processID string
+		io        garden.ProcessIO
+	}
+	attachReturns struct {
+		result1 garden.Process
+		result2 error
+	}
 	KillStub        func(log lager.Logger, bundlePath string) error
 	killMutex       sync.RWMutex
 	killArgsForCall []struct {
@@ -44,6 +56,15 @@ type FakeBundleRunner struct {
 	killReturns struct {
 		result1 error
 	}
+	DeleteStub        func(log lager.Logger, handle string) error
+	deleteMutex       sync.RWMutex
+	deleteArgsForCall []struct {
+		log    lager.Logger
+		handle string
+	}
+	deleteReturns struct {
+		result1 error
+	}
 }
 
 func (fake *FakeBundleRunner) Start(log lager.Logger, bundlePath string, id string, io garden.ProcessIO) (garden.Process, error) {
@@ -119,6 +140,42 @@ func (fake *FakeBundleRunner) ExecReturns(result1 garden.Process, result2 error)
 	}{result1, result2}
 }
 
+func (fake *FakeBundleRunner) Attach(log lager.Logger, id string, processID string, io garden.ProcessIO) (garden.Process, error) {
+	fake.attachMutex.Lock()
+	fake.attachArgsForCall = append(fake.attachArgsForCall, struct {
+		log       lager.Logger
+		id        string
+		processID string
+		io        garden.ProcessIO
+	}{log, id, processID, io})
+	fake.attachMutex.Unlock()
+	if fake.AttachStub != nil {
+		return fake.AttachStub(log, id, processID, io)
+	} else {
+		return fake.attachReturns.result1, fake.attachReturns.result2
+	}
+}
+
+func (fake *FakeBundleRunner) AttachCallCount() int {
+	fake.attachMutex.RLock()
+	defer fake.attachMutex.RUnlock()
+	return len(fake.attachArgsForCall)
+}
+
+func (fake *FakeBundleRunner) AttachArgsForCall(i int) (lager.Logger, string, string, garden.ProcessIO) {
+	fake.attachMutex.RLock()
+	defer fake.attachMutex.RUnlock()
+	return fake.attachArgsForCall[i].log, fake.attachArgsForCall[i].id, fake.attachArgsForCall[i].processID, fake.attachArgsForCall[i].io
+}
+
+func (fake *FakeBundleRunner) AttachReturns(result1 garden.Process, result2 error) {
+	fake.AttachStub = nil
+	fake.attachReturns = struct {
+		result1 garden.Process
+		result2 error
+	}{result1, result2}
+}
+
 func (fake *FakeBundleRunner) Kill(log lager.Logger, bundlePath string) error {
 	fake.killMutex.Lock()
 	fake.killArgsForCall = append(fake.killArgsForCall, struct {
@@ -152,4 +209,37 @@ func (fake *FakeBundleRunner) KillReturns(result1 error) {
 	}{result1}
 }
 
+func (fake *FakeBundleRunner) Delete(log lager.Logger, handle string) error {
+	fake.deleteMutex.Lock()
+	fake.deleteArgsForCall = append(fake.deleteArgsForCall, struct {
+		log    lager.Logger
+		handle string
+	}{log, handle})
+	fake.deleteMutex.Unlock()
+	if fake.DeleteStub != nil {
+		return fake.DeleteStub(log, handle)
+	} else {
+		return fake.deleteReturns.result1
+	}
+}
+
+func (fake *FakeBundleRunner) DeleteCallCount() int {
+	fake.deleteMutex.RLock()
+	defer fake.deleteMutex.RUnlock()
+	return len(fake.deleteArgsForCall)
+}
+
+func (fake *FakeBundleRunner) DeleteArgsForCall(i int) (lager.Logger, string) {
+	fake.deleteMutex.RLock()
+	defer fake.deleteMutex.RUnlock()
+	return fake.deleteArgsForCall[i].log, fake.deleteArgsForCall[i].handle
+}
+
+func (fake *FakeBundleRunner) DeleteReturns(result1 error) {
+	fake.DeleteStub = nil
+	fake.deleteReturns = struct {
+		result1 error
+	}{result1}
+}
+
 var _ rundmc.BundleRunner = new(FakeBundleRunner)
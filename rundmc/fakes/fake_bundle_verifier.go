@@ -0,0 +1,172 @@
+// This file was generated by counterfeiter
+package fakes
+
+import (
+	"sync"
+
+	"github.com/cloudfoundry-incubator/guardian/rundmc"
+	"github.com/pivotal-golang/lager"
+)
+
+type FakeBundleVerifier struct {
+	HandlesStub        func() ([]string, error)
+	handlesMutex       sync.RWMutex
+	handlesArgsForCall []struct{}
+	handlesReturns     struct {
+		result1 []string
+		result2 error
+	}
+	VerifyStub        func(log lager.Logger, handle string) error
+	verifyMutex       sync.RWMutex
+	verifyArgsForCall []struct {
+		log    lager.Logger
+		handle string
+	}
+	verifyReturns struct {
+		result1 error
+	}
+	QuarantineStub        func(log lager.Logger, handle, reason string) error
+	quarantineMutex       sync.RWMutex
+	quarantineArgsForCall []struct {
+		log    lager.Logger
+		handle string
+		reason string
+	}
+	quarantineReturns struct {
+		result1 error
+	}
+	QuarantinedStub        func(handle string) bool
+	quarantinedMutex       sync.RWMutex
+	quarantinedArgsForCall []struct {
+		handle string
+	}
+	quarantinedReturns struct {
+		result1 bool
+	}
+}
+
+func (fake *FakeBundleVerifier) Handles() ([]string, error) {
+	fake.handlesMutex.Lock()
+	fake.handlesArgsForCall = append(fake.handlesArgsForCall, struct{}{})
+	fake.handlesMutex.Unlock()
+	if fake.HandlesStub != nil {
+		return fake.HandlesStub()
+	} else {
+		return fake.handlesReturns.result1, fake.handlesReturns.result2
+	}
+}
+
+func (fake *FakeBundleVerifier) HandlesCallCount() int {
+	fake.handlesMutex.RLock()
+	defer fake.handlesMutex.RUnlock()
+	return len(fake.handlesArgsForCall)
+}
+
+func (fake *FakeBundleVerifier) HandlesReturns(result1 []string, result2 error) {
+	fake.HandlesStub = nil
+	fake.handlesReturns = struct {
+		result1 []string
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeBundleVerifier) Verify(log lager.Logger, handle string) error {
+	fake.verifyMutex.Lock()
+	fake.verifyArgsForCall = append(fake.verifyArgsForCall, struct {
+		log    lager.Logger
+		handle string
+	}{log, handle})
+	fake.verifyMutex.Unlock()
+	if fake.VerifyStub != nil {
+		return fake.VerifyStub(log, handle)
+	} else {
+		return fake.verifyReturns.result1
+	}
+}
+
+func (fake *FakeBundleVerifier) VerifyCallCount() int {
+	fake.verifyMutex.RLock()
+	defer fake.verifyMutex.RUnlock()
+	return len(fake.verifyArgsForCall)
+}
+
+func (fake *FakeBundleVerifier) VerifyArgsForCall(i int) (lager.Logger, string) {
+	fake.verifyMutex.RLock()
+	defer fake.verifyMutex.RUnlock()
+	return fake.verifyArgsForCall[i].log, fake.verifyArgsForCall[i].handle
+}
+
+func (fake *FakeBundleVerifier) VerifyReturns(result1 error) {
+	fake.VerifyStub = nil
+	fake.verifyReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeBundleVerifier) Quarantine(log lager.Logger, handle string, reason string) error {
+	fake.quarantineMutex.Lock()
+	fake.quarantineArgsForCall = append(fake.quarantineArgsForCall, struct {
+		log    lager.Logger
+		handle string
+		reason string
+	}{log, handle, reason})
+	fake.quarantineMutex.Unlock()
+	if fake.QuarantineStub != nil {
+		return fake.QuarantineStub(log, handle, reason)
+	} else {
+		return fake.quarantineReturns.result1
+	}
+}
+
+func (fake *FakeBundleVerifier) QuarantineCallCount() int {
+	fake.quarantineMutex.RLock()
+	defer fake.quarantineMutex.RUnlock()
+	return len(fake.quarantineArgsForCall)
+}
+
+func (fake *FakeBundleVerifier) QuarantineArgsForCall(i int) (lager.Logger, string, string) {
+	fake.quarantineMutex.RLock()
+	defer fake.quarantineMutex.RUnlock()
+	return fake.quarantineArgsForCall[i].log, fake.quarantineArgsForCall[i].handle, fake.quarantineArgsForCall[i].reason
+}
+
+func (fake *FakeBundleVerifier) QuarantineReturns(result1 error) {
+	fake.QuarantineStub = nil
+	fake.quarantineReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeBundleVerifier) Quarantined(handle string) bool {
+	fake.quarantinedMutex.Lock()
+	fake.quarantinedArgsForCall = append(fake.quarantinedArgsForCall, struct {
+		handle string
+	}{handle})
+	fake.quarantinedMutex.Unlock()
+	if fake.QuarantinedStub != nil {
+		return fake.QuarantinedStub(handle)
+	} else {
+		return fake.quarantinedReturns.result1
+	}
+}
+
+func (fake *FakeBundleVerifier) QuarantinedCallCount() int {
+	fake.quarantinedMutex.RLock()
+	defer fake.quarantinedMutex.RUnlock()
+	return len(fake.quarantinedArgsForCall)
+}
+
+func (fake *FakeBundleVerifier) QuarantinedArgsForCall(i int) string {
+	fake.quarantinedMutex.RLock()
+	defer fake.quarantinedMutex.RUnlock()
+	return fake.quarantinedArgsForCall[i].handle
+}
+
+func (fake *FakeBundleVerifier) QuarantinedReturns(result1 bool) {
+	fake.QuarantinedStub = nil
+	fake.quarantinedReturns = struct {
+		result1 bool
+	}{result1}
+}
+
+var _ rundmc.BundleVerifier = new(FakeBundleVerifier)
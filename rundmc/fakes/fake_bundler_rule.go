@@ -10,7 +10,7 @@ import (
 )
 
 type FakeBundlerRule struct {
-	ApplyStub        func(bndle *goci.Bndl, spec gardener.DesiredContainerSpec) *goci.Bndl
+	ApplyStub        func(bndle *goci.Bndl, spec gardener.DesiredContainerSpec) (*goci.Bndl, error)
 	applyMutex       sync.RWMutex
 	applyArgsForCall []struct {
 		bndle *goci.Bndl
@@ -18,10 +18,11 @@ type FakeBundlerRule struct {
 	}
 	applyReturns struct {
 		result1 *goci.Bndl
+		result2 error
 	}
 }
 
-func (fake *FakeBundlerRule) Apply(bndle *goci.Bndl, spec gardener.DesiredContainerSpec) *goci.Bndl {
+func (fake *FakeBundlerRule) Apply(bndle *goci.Bndl, spec gardener.DesiredContainerSpec) (*goci.Bndl, error) {
 	fake.applyMutex.Lock()
 	fake.applyArgsForCall = append(fake.applyArgsForCall, struct {
 		bndle *goci.Bndl
@@ -31,7 +32,7 @@ func (fake *FakeBundlerRule) Apply(bndle *goci.Bndl, spec gardener.DesiredContai
 	if fake.ApplyStub != nil {
 		return fake.ApplyStub(bndle, spec)
 	} else {
-		return fake.applyReturns.result1
+		return fake.applyReturns.result1, fake.applyReturns.result2
 	}
 }
 
@@ -47,11 +48,12 @@ func (fake *FakeBundlerRule) ApplyArgsForCall(i int) (*goci.Bndl, gardener.Desir
 	return fake.applyArgsForCall[i].bndle, fake.applyArgsForCall[i].spec
 }
 
-func (fake *FakeBundlerRule) ApplyReturns(result1 *goci.Bndl) {
+func (fake *FakeBundlerRule) ApplyReturns(result1 *goci.Bndl, result2 error) {
 	fake.ApplyStub = nil
 	fake.applyReturns = struct {
 		result1 *goci.Bndl
-	}{result1}
+		result2 error
+	}{result1, result2}
 }
 
 var _ rundmc.BundlerRule = new(FakeBundlerRule)
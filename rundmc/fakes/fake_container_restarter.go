@@ -0,0 +1,58 @@
+// This file was generated by counterfeiter
+package fakes
+
+import (
+	"sync"
+
+	"github.com/cloudfoundry-incubator/guardian/rundmc"
+	"github.com/pivotal-golang/lager"
+)
+
+type FakeContainerRestarter struct {
+	RestartStub        func(log lager.Logger, handle string) (bool, error)
+	restartMutex       sync.RWMutex
+	restartArgsForCall []struct {
+		log    lager.Logger
+		handle string
+	}
+	restartReturns struct {
+		result1 bool
+		result2 error
+	}
+}
+
+func (fake *FakeContainerRestarter) Restart(log lager.Logger, handle string) (bool, error) {
+	fake.restartMutex.Lock()
+	fake.restartArgsForCall = append(fake.restartArgsForCall, struct {
+		log    lager.Logger
+		handle string
+	}{log, handle})
+	fake.restartMutex.Unlock()
+	if fake.RestartStub != nil {
+		return fake.RestartStub(log, handle)
+	} else {
+		return fake.restartReturns.result1, fake.restartReturns.result2
+	}
+}
+
+func (fake *FakeContainerRestarter) RestartCallCount() int {
+	fake.restartMutex.RLock()
+	defer fake.restartMutex.RUnlock()
+	return len(fake.restartArgsForCall)
+}
+
+func (fake *FakeContainerRestarter) RestartArgsForCall(i int) (lager.Logger, string) {
+	fake.restartMutex.RLock()
+	defer fake.restartMutex.RUnlock()
+	return fake.restartArgsForCall[i].log, fake.restartArgsForCall[i].handle
+}
+
+func (fake *FakeContainerRestarter) RestartReturns(result1 bool, result2 error) {
+	fake.RestartStub = nil
+	fake.restartReturns = struct {
+		result1 bool
+		result2 error
+	}{result1, result2}
+}
+
+var _ rundmc.ContainerRestarter = new(FakeContainerRestarter)
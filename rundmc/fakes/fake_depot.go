@@ -20,6 +20,16 @@ type FakeDepot struct {
 	createReturns struct {
 		result1 error
 	}
+	UpdateStub        func(log lager.Logger, handle string, bundle depot.BundleSaver) error
+	updateMutex       sync.RWMutex
+	updateArgsForCall []struct {
+		log    lager.Logger
+		handle string
+		bundle depot.BundleSaver
+	}
+	updateReturns struct {
+		result1 error
+	}
 	LookupStub        func(log lager.Logger, handle string) (path string, err error)
 	lookupMutex       sync.RWMutex
 	lookupArgsForCall []struct {
@@ -46,6 +56,26 @@ type FakeDepot struct {
 		result1 []string
 		result2 error
 	}
+	ExportStub        func(log lager.Logger, handle, dest string) error
+	exportMutex       sync.RWMutex
+	exportArgsForCall []struct {
+		log    lager.Logger
+		handle string
+		dest   string
+	}
+	exportReturns struct {
+		result1 error
+	}
+	ImportStub        func(log lager.Logger, handle, src string) error
+	importMutex       sync.RWMutex
+	importArgsForCall []struct {
+		log    lager.Logger
+		handle string
+		src    string
+	}
+	importReturns struct {
+		result1 error
+	}
 }
 
 func (fake *FakeDepot) Create(log lager.Logger, handle string, bundle depot.BundleSaver) error {
@@ -82,6 +112,40 @@ func (fake *FakeDepot) CreateReturns(result1 error) {
 	}{result1}
 }
 
+func (fake *FakeDepot) Update(log lager.Logger, handle string, bundle depot.BundleSaver) error {
+	fake.updateMutex.Lock()
+	fake.updateArgsForCall = append(fake.updateArgsForCall, struct {
+		log    lager.Logger
+		handle string
+		bundle depot.BundleSaver
+	}{log, handle, bundle})
+	fake.updateMutex.Unlock()
+	if fake.UpdateStub != nil {
+		return fake.UpdateStub(log, handle, bundle)
+	} else {
+		return fake.updateReturns.result1
+	}
+}
+
+func (fake *FakeDepot) UpdateCallCount() int {
+	fake.updateMutex.RLock()
+	defer fake.updateMutex.RUnlock()
+	return len(fake.updateArgsForCall)
+}
+
+func (fake *FakeDepot) UpdateArgsForCall(i int) (lager.Logger, string, depot.BundleSaver) {
+	fake.updateMutex.RLock()
+	defer fake.updateMutex.RUnlock()
+	return fake.updateArgsForCall[i].log, fake.updateArgsForCall[i].handle, fake.updateArgsForCall[i].bundle
+}
+
+func (fake *FakeDepot) UpdateReturns(result1 error) {
+	fake.UpdateStub = nil
+	fake.updateReturns = struct {
+		result1 error
+	}{result1}
+}
+
 func (fake *FakeDepot) Lookup(log lager.Logger, handle string) (path string, err error) {
 	fake.lookupMutex.Lock()
 	fake.lookupArgsForCall = append(fake.lookupArgsForCall, struct {
@@ -174,4 +238,72 @@ func (fake *FakeDepot) HandlesReturns(result1 []string, result2 error) {
 	}{result1, result2}
 }
 
+func (fake *FakeDepot) Export(log lager.Logger, handle string, dest string) error {
+	fake.exportMutex.Lock()
+	fake.exportArgsForCall = append(fake.exportArgsForCall, struct {
+		log    lager.Logger
+		handle string
+		dest   string
+	}{log, handle, dest})
+	fake.exportMutex.Unlock()
+	if fake.ExportStub != nil {
+		return fake.ExportStub(log, handle, dest)
+	} else {
+		return fake.exportReturns.result1
+	}
+}
+
+func (fake *FakeDepot) ExportCallCount() int {
+	fake.exportMutex.RLock()
+	defer fake.exportMutex.RUnlock()
+	return len(fake.exportArgsForCall)
+}
+
+func (fake *FakeDepot) ExportArgsForCall(i int) (lager.Logger, string, string) {
+	fake.exportMutex.RLock()
+	defer fake.exportMutex.RUnlock()
+	return fake.exportArgsForCall[i].log, fake.exportArgsForCall[i].handle, fake.exportArgsForCall[i].dest
+}
+
+func (fake *FakeDepot) ExportReturns(result1 error) {
+	fake.ExportStub = nil
+	fake.exportReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeDepot) Import(log lager.Logger, handle string, src string) error {
+	fake.importMutex.Lock()
+	fake.importArgsForCall = append(fake.importArgsForCall, struct {
+		log    lager.Logger
+		handle string
+		src    string
+	}{log, handle, src})
+	fake.importMutex.Unlock()
+	if fake.ImportStub != nil {
+		return fake.ImportStub(log, handle, src)
+	} else {
+		return fake.importReturns.result1
+	}
+}
+
+func (fake *FakeDepot) ImportCallCount() int {
+	fake.importMutex.RLock()
+	defer fake.importMutex.RUnlock()
+	return len(fake.importArgsForCall)
+}
+
+func (fake *FakeDepot) ImportArgsForCall(i int) (lager.Logger, string, string) {
+	fake.importMutex.RLock()
+	defer fake.importMutex.RUnlock()
+	return fake.importArgsForCall[i].log, fake.importArgsForCall[i].handle, fake.importArgsForCall[i].src
+}
+
+func (fake *FakeDepot) ImportReturns(result1 error) {
+	fake.ImportStub = nil
+	fake.importReturns = struct {
+		result1 error
+	}{result1}
+}
+
 var _ rundmc.Depot = new(FakeDepot)
@@ -0,0 +1,101 @@
+// This file was generated by counterfeiter
+package fakes
+
+import (
+	"sync"
+
+	"github.com/cloudfoundry-incubator/guardian/gardener"
+	"github.com/cloudfoundry-incubator/guardian/rundmc"
+	"github.com/pivotal-golang/lager"
+)
+
+type FakeDiskQuotaEnforcer struct {
+	PrepareStub        func(log lager.Logger, spec gardener.DesiredContainerSpec) (gardener.DesiredContainerSpec, error)
+	prepareMutex       sync.RWMutex
+	prepareArgsForCall []struct {
+		log  lager.Logger
+		spec gardener.DesiredContainerSpec
+	}
+	prepareReturns struct {
+		result1 gardener.DesiredContainerSpec
+		result2 error
+	}
+	ReleaseStub        func(log lager.Logger, handle string) error
+	releaseMutex       sync.RWMutex
+	releaseArgsForCall []struct {
+		log    lager.Logger
+		handle string
+	}
+	releaseReturns struct {
+		result1 error
+	}
+}
+
+func (fake *FakeDiskQuotaEnforcer) Prepare(log lager.Logger, spec gardener.DesiredContainerSpec) (gardener.DesiredContainerSpec, error) {
+	fake.prepareMutex.Lock()
+	fake.prepareArgsForCall = append(fake.prepareArgsForCall, struct {
+		log  lager.Logger
+		spec gardener.DesiredContainerSpec
+	}{log, spec})
+	fake.prepareMutex.Unlock()
+	if fake.PrepareStub != nil {
+		return fake.PrepareStub(log, spec)
+	} else {
+		return fake.prepareReturns.result1, fake.prepareReturns.result2
+	}
+}
+
+func (fake *FakeDiskQuotaEnforcer) PrepareCallCount() int {
+	fake.prepareMutex.RLock()
+	defer fake.prepareMutex.RUnlock()
+	return len(fake.prepareArgsForCall)
+}
+
+func (fake *FakeDiskQuotaEnforcer) PrepareArgsForCall(i int) (lager.Logger, gardener.DesiredContainerSpec) {
+	fake.prepareMutex.RLock()
+	defer fake.prepareMutex.RUnlock()
+	return fake.prepareArgsForCall[i].log, fake.prepareArgsForCall[i].spec
+}
+
+func (fake *FakeDiskQuotaEnforcer) PrepareReturns(result1 gardener.DesiredContainerSpec, result2 error) {
+	fake.PrepareStub = nil
+	fake.prepareReturns = struct {
+		result1 gardener.DesiredContainerSpec
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeDiskQuotaEnforcer) Release(log lager.Logger, handle string) error {
+	fake.releaseMutex.Lock()
+	fake.releaseArgsForCall = append(fake.releaseArgsForCall, struct {
+		log    lager.Logger
+		handle string
+	}{log, handle})
+	fake.releaseMutex.Unlock()
+	if fake.ReleaseStub != nil {
+		return fake.ReleaseStub(log, handle)
+	} else {
+		return fake.releaseReturns.result1
+	}
+}
+
+func (fake *FakeDiskQuotaEnforcer) ReleaseCallCount() int {
+	fake.releaseMutex.RLock()
+	defer fake.releaseMutex.RUnlock()
+	return len(fake.releaseArgsForCall)
+}
+
+func (fake *FakeDiskQuotaEnforcer) ReleaseArgsForCall(i int) (lager.Logger, string) {
+	fake.releaseMutex.RLock()
+	defer fake.releaseMutex.RUnlock()
+	return fake.releaseArgsForCall[i].log, fake.releaseArgsForCall[i].handle
+}
+
+func (fake *FakeDiskQuotaEnforcer) ReleaseReturns(result1 error) {
+	fake.ReleaseStub = nil
+	fake.releaseReturns = struct {
+		result1 error
+	}{result1}
+}
+
+var _ rundmc.DiskQuotaEnforcer = new(FakeDiskQuotaEnforcer)
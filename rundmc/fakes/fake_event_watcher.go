@@ -0,0 +1,124 @@
+// This file was generated by counterfeiter
+package fakes
+
+import (
+	"sync"
+
+	"github.com/cloudfoundry-incubator/guardian/rundmc"
+	"github.com/pivotal-golang/lager"
+)
+
+type FakeEventWatcher struct {
+	WatchStub        func(log lager.Logger, handle string) error
+	watchMutex       sync.RWMutex
+	watchArgsForCall []struct {
+		log    lager.Logger
+		handle string
+	}
+	watchReturns struct {
+		result1 error
+	}
+	UnwatchStub        func(handle string)
+	unwatchMutex       sync.RWMutex
+	unwatchArgsForCall []struct {
+		handle string
+	}
+	EventsStub        func(handle string) []string
+	eventsMutex       sync.RWMutex
+	eventsArgsForCall []struct {
+		handle string
+	}
+	eventsReturns struct {
+		result1 []string
+	}
+}
+
+func (fake *FakeEventWatcher) Watch(log lager.Logger, handle string) error {
+	fake.watchMutex.Lock()
+	fake.watchArgsForCall = append(fake.watchArgsForCall, struct {
+		log    lager.Logger
+		handle string
+	}{log, handle})
+	fake.watchMutex.Unlock()
+	if fake.WatchStub != nil {
+		return fake.WatchStub(log, handle)
+	} else {
+		return fake.watchReturns.result1
+	}
+}
+
+func (fake *FakeEventWatcher) WatchCallCount() int {
+	fake.watchMutex.RLock()
+	defer fake.watchMutex.RUnlock()
+	return len(fake.watchArgsForCall)
+}
+
+func (fake *FakeEventWatcher) WatchArgsForCall(i int) (lager.Logger, string) {
+	fake.watchMutex.RLock()
+	defer fake.watchMutex.RUnlock()
+	return fake.watchArgsForCall[i].log, fake.watchArgsForCall[i].handle
+}
+
+func (fake *FakeEventWatcher) WatchReturns(result1 error) {
+	fake.WatchStub = nil
+	fake.watchReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeEventWatcher) Unwatch(handle string) {
+	fake.unwatchMutex.Lock()
+	fake.unwatchArgsForCall = append(fake.unwatchArgsForCall, struct {
+		handle string
+	}{handle})
+	fake.unwatchMutex.Unlock()
+	if fake.UnwatchStub != nil {
+		fake.UnwatchStub(handle)
+	}
+}
+
+func (fake *FakeEventWatcher) UnwatchCallCount() int {
+	fake.unwatchMutex.RLock()
+	defer fake.unwatchMutex.RUnlock()
+	return len(fake.unwatchArgsForCall)
+}
+
+func (fake *FakeEventWatcher) UnwatchArgsForCall(i int) string {
+	fake.unwatchMutex.RLock()
+	defer fake.unwatchMutex.RUnlock()
+	return fake.unwatchArgsForCall[i].handle
+}
+
+func (fake *FakeEventWatcher) Events(handle string) []string {
+	fake.eventsMutex.Lock()
+	fake.eventsArgsForCall = append(fake.eventsArgsForCall, struct {
+		handle string
+	}{handle})
+	fake.eventsMutex.Unlock()
+	if fake.EventsStub != nil {
+		return fake.EventsStub(handle)
+	} else {
+		return fake.eventsReturns.result1
+	}
+}
+
+func (fake *FakeEventWatcher) EventsCallCount() int {
+	fake.eventsMutex.RLock()
+	defer fake.eventsMutex.RUnlock()
+	return len(fake.eventsArgsForCall)
+}
+
+func (fake *FakeEventWatcher) EventsArgsForCall(i int) string {
+	fake.eventsMutex.RLock()
+	defer fake.eventsMutex.RUnlock()
+	return fake.eventsArgsForCall[i].handle
+}
+
+func (fake *FakeEventWatcher) EventsReturns(result1 []string) {
+	fake.EventsStub = nil
+	fake.eventsReturns = struct {
+		result1 []string
+	}{result1}
+}
+
+var _ rundmc.EventWatcher = new(FakeEventWatcher)
@@ -0,0 +1,53 @@
+// This file was generated by counterfeiter
+package fakes
+
+import (
+	"sync"
+
+	"github.com/cloudfoundry-incubator/guardian/rundmc"
+)
+
+type FakeIODaemonPidLister struct {
+	IODaemonPidsStub        func(handle string) map[string]int
+	iODaemonPidsMutex       sync.RWMutex
+	iODaemonPidsArgsForCall []struct {
+		handle string
+	}
+	iODaemonPidsReturns struct {
+		result1 map[string]int
+	}
+}
+
+func (fake *FakeIODaemonPidLister) IODaemonPids(handle string) map[string]int {
+	fake.iODaemonPidsMutex.Lock()
+	fake.iODaemonPidsArgsForCall = append(fake.iODaemonPidsArgsForCall, struct {
+		handle string
+	}{handle})
+	fake.iODaemonPidsMutex.Unlock()
+	if fake.IODaemonPidsStub != nil {
+		return fake.IODaemonPidsStub(handle)
+	} else {
+		return fake.iODaemonPidsReturns.result1
+	}
+}
+
+func (fake *FakeIODaemonPidLister) IODaemonPidsCallCount() int {
+	fake.iODaemonPidsMutex.RLock()
+	defer fake.iODaemonPidsMutex.RUnlock()
+	return len(fake.iODaemonPidsArgsForCall)
+}
+
+func (fake *FakeIODaemonPidLister) IODaemonPidsArgsForCall(i int) string {
+	fake.iODaemonPidsMutex.RLock()
+	defer fake.iODaemonPidsMutex.RUnlock()
+	return fake.iODaemonPidsArgsForCall[i].handle
+}
+
+func (fake *FakeIODaemonPidLister) IODaemonPidsReturns(result1 map[string]int) {
+	fake.IODaemonPidsStub = nil
+	fake.iODaemonPidsReturns = struct {
+		result1 map[string]int
+	}{result1}
+}
+
+var _ rundmc.IODaemonPidLister = new(FakeIODaemonPidLister)
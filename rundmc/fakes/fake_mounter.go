@@ -0,0 +1,102 @@
+// This file was generated by counterfeiter
+package fakes
+
+import (
+	"sync"
+
+	"github.com/cloudfoundry-incubator/guardian/rundmc"
+	"github.com/pivotal-golang/lager"
+)
+
+type FakeMounter struct {
+	BindMountStub        func(log lager.Logger, src, dst string, readOnly bool) error
+	bindMountMutex       sync.RWMutex
+	bindMountArgsForCall []struct {
+		log      lager.Logger
+		src      string
+		dst      string
+		readOnly bool
+	}
+	bindMountReturns struct {
+		result1 error
+	}
+	UnmountStub        func(log lager.Logger, dst string) error
+	unmountMutex       sync.RWMutex
+	unmountArgsForCall []struct {
+		log lager.Logger
+		dst string
+	}
+	unmountReturns struct {
+		result1 error
+	}
+}
+
+func (fake *FakeMounter) BindMount(log lager.Logger, src string, dst string, readOnly bool) error {
+	fake.bindMountMutex.Lock()
+	fake.bindMountArgsForCall = append(fake.bindMountArgsForCall, struct {
+		log      lager.Logger
+		src      string
+		dst      string
+		readOnly bool
+	}{log, src, dst, readOnly})
+	fake.bindMountMutex.Unlock()
+	if fake.BindMountStub != nil {
+		return fake.BindMountStub(log, src, dst, readOnly)
+	} else {
+		return fake.bindMountReturns.result1
+	}
+}
+
+func (fake *FakeMounter) BindMountCallCount() int {
+	fake.bindMountMutex.RLock()
+	defer fake.bindMountMutex.RUnlock()
+	return len(fake.bindMountArgsForCall)
+}
+
+func (fake *FakeMounter) BindMountArgsForCall(i int) (lager.Logger, string, string, bool) {
+	fake.bindMountMutex.RLock()
+	defer fake.bindMountMutex.RUnlock()
+	return fake.bindMountArgsForCall[i].log, fake.bindMountArgsForCall[i].src, fake.bindMountArgsForCall[i].dst, fake.bindMountArgsForCall[i].readOnly
+}
+
+func (fake *FakeMounter) BindMountReturns(result1 error) {
+	fake.BindMountStub = nil
+	fake.bindMountReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeMounter) Unmount(log lager.Logger, dst string) error {
+	fake.unmountMutex.Lock()
+	fake.unmountArgsForCall = append(fake.unmountArgsForCall, struct {
+		log lager.Logger
+		dst string
+	}{log, dst})
+	fake.unmountMutex.Unlock()
+	if fake.UnmountStub != nil {
+		return fake.UnmountStub(log, dst)
+	} else {
+		return fake.unmountReturns.result1
+	}
+}
+
+func (fake *FakeMounter) UnmountCallCount() int {
+	fake.unmountMutex.RLock()
+	defer fake.unmountMutex.RUnlock()
+	return len(fake.unmountArgsForCall)
+}
+
+func (fake *FakeMounter) UnmountArgsForCall(i int) (lager.Logger, string) {
+	fake.unmountMutex.RLock()
+	defer fake.unmountMutex.RUnlock()
+	return fake.unmountArgsForCall[i].log, fake.unmountArgsForCall[i].dst
+}
+
+func (fake *FakeMounter) UnmountReturns(result1 error) {
+	fake.UnmountStub = nil
+	fake.unmountReturns = struct {
+		result1 error
+	}{result1}
+}
+
+var _ rundmc.Mounter = new(FakeMounter)
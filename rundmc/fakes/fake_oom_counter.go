@@ -0,0 +1,31 @@
+// This file was generated by counterfeiter
+package fakes
+
+import (
+	"sync"
+
+	"github.com/cloudfoundry-incubator/guardian/rundmc"
+)
+
+type FakeOomCounter struct {
+	IncStub        func()
+	incMutex       sync.RWMutex
+	incArgsForCall []struct{}
+}
+
+func (fake *FakeOomCounter) Inc() {
+	fake.incMutex.Lock()
+	fake.incArgsForCall = append(fake.incArgsForCall, struct{}{})
+	fake.incMutex.Unlock()
+	if fake.IncStub != nil {
+		fake.IncStub()
+	}
+}
+
+func (fake *FakeOomCounter) IncCallCount() int {
+	fake.incMutex.RLock()
+	defer fake.incMutex.RUnlock()
+	return len(fake.incArgsForCall)
+}
+
+var _ rundmc.OomCounter = new(FakeOomCounter)
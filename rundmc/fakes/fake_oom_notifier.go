@@ -0,0 +1,57 @@
+// This file was generated by counterfeiter
+package fakes
+
+import (
+	"sync"
+
+	"github.com/cloudfoundry-incubator/guardian/rundmc"
+)
+
+type FakeOomNotifier struct {
+	NotifyStub        func(cgroupPath string, stop <-chan struct{}, notify func()) error
+	notifyMutex       sync.RWMutex
+	notifyArgsForCall []struct {
+		cgroupPath string
+		stop       <-chan struct{}
+		notify     func()
+	}
+	notifyReturns struct {
+		result1 error
+	}
+}
+
+func (fake *FakeOomNotifier) Notify(cgroupPath string, stop <-chan struct{}, notify func()) error {
+	fake.notifyMutex.Lock()
+	fake.notifyArgsForCall = append(fake.notifyArgsForCall, struct {
+		cgroupPath string
+		stop       <-chan struct{}
+		notify     func()
+	}{cgroupPath, stop, notify})
+	fake.notifyMutex.Unlock()
+	if fake.NotifyStub != nil {
+		return fake.NotifyStub(cgroupPath, stop, notify)
+	} else {
+		return fake.notifyReturns.result1
+	}
+}
+
+func (fake *FakeOomNotifier) NotifyCallCount() int {
+	fake.notifyMutex.RLock()
+	defer fake.notifyMutex.RUnlock()
+	return len(fake.notifyArgsForCall)
+}
+
+func (fake *FakeOomNotifier) NotifyArgsForCall(i int) (string, <-chan struct{}, func()) {
+	fake.notifyMutex.RLock()
+	defer fake.notifyMutex.RUnlock()
+	return fake.notifyArgsForCall[i].cgroupPath, fake.notifyArgsForCall[i].stop, fake.notifyArgsForCall[i].notify
+}
+
+func (fake *FakeOomNotifier) NotifyReturns(result1 error) {
+	fake.NotifyStub = nil
+	fake.notifyReturns = struct {
+		result1 error
+	}{result1}
+}
+
+var _ rundmc.OomNotifier = new(FakeOomNotifier)
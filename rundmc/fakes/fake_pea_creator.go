@@ -0,0 +1,66 @@
+// This file was generated by counterfeiter
+package fakes
+
+import (
+	"sync"
+
+	"github.com/cloudfoundry-incubator/garden"
+	"github.com/cloudfoundry-incubator/guardian/gardener"
+	"github.com/cloudfoundry-incubator/guardian/rundmc"
+	"github.com/pivotal-golang/lager"
+)
+
+type FakePeaCreator struct {
+	CreatePeaStub        func(log lager.Logger, bundlePath string, pid int, pea gardener.PeaSpec, io garden.ProcessIO) (garden.Process, error)
+	createPeaMutex       sync.RWMutex
+	createPeaArgsForCall []struct {
+		log        lager.Logger
+		bundlePath string
+		pid        int
+		pea        gardener.PeaSpec
+		io         garden.ProcessIO
+	}
+	createPeaReturns struct {
+		result1 garden.Process
+		result2 error
+	}
+}
+
+func (fake *FakePeaCreator) CreatePea(log lager.Logger, bundlePath string, pid int, pea gardener.PeaSpec, io garden.ProcessIO) (garden.Process, error) {
+	fake.createPeaMutex.Lock()
+	fake.createPeaArgsForCall = append(fake.createPeaArgsForCall, struct {
+		log        lager.Logger
+		bundlePath string
+		pid        int
+		pea        gardener.PeaSpec
+		io         garden.ProcessIO
+	}{log, bundlePath, pid, pea, io})
+	fake.createPeaMutex.Unlock()
+	if fake.CreatePeaStub != nil {
+		return fake.CreatePeaStub(log, bundlePath, pid, pea, io)
+	} else {
+		return fake.createPeaReturns.result1, fake.createPeaReturns.result2
+	}
+}
+
+func (fake *FakePeaCreator) CreatePeaCallCount() int {
+	fake.createPeaMutex.RLock()
+	defer fake.createPeaMutex.RUnlock()
+	return len(fake.createPeaArgsForCall)
+}
+
+func (fake *FakePeaCreator) CreatePeaArgsForCall(i int) (lager.Logger, string, int, gardener.PeaSpec, garden.ProcessIO) {
+	fake.createPeaMutex.RLock()
+	defer fake.createPeaMutex.RUnlock()
+	return fake.createPeaArgsForCall[i].log, fake.createPeaArgsForCall[i].bundlePath, fake.createPeaArgsForCall[i].pid, fake.createPeaArgsForCall[i].pea, fake.createPeaArgsForCall[i].io
+}
+
+func (fake *FakePeaCreator) CreatePeaReturns(result1 garden.Process, result2 error) {
+	fake.CreatePeaStub = nil
+	fake.createPeaReturns = struct {
+		result1 garden.Process
+		result2 error
+	}{result1, result2}
+}
+
+var _ rundmc.PeaCreator = new(FakePeaCreator)
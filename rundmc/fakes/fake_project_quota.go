@@ -0,0 +1,146 @@
+// This file was generated by counterfeiter
+package fakes
+
+import (
+	"sync"
+
+	"github.com/cloudfoundry-incubator/guardian/rundmc"
+	"github.com/pivotal-golang/lager"
+)
+
+type FakeProjectQuota struct {
+	AssignStub        func(log lager.Logger, path string, project uint32) error
+	assignMutex       sync.RWMutex
+	assignArgsForCall []struct {
+		log     lager.Logger
+		path    string
+		project uint32
+	}
+	assignReturns struct {
+		result1 error
+	}
+	LimitStub        func(log lager.Logger, project uint32, limitInBytes uint64) error
+	limitMutex       sync.RWMutex
+	limitArgsForCall []struct {
+		log          lager.Logger
+		project      uint32
+		limitInBytes uint64
+	}
+	limitReturns struct {
+		result1 error
+	}
+	UsageStub        func(log lager.Logger, project uint32) (uint64, error)
+	usageMutex       sync.RWMutex
+	usageArgsForCall []struct {
+		log     lager.Logger
+		project uint32
+	}
+	usageReturns struct {
+		result1 uint64
+		result2 error
+	}
+}
+
+func (fake *FakeProjectQuota) Assign(log lager.Logger, path string, project uint32) error {
+	fake.assignMutex.Lock()
+	fake.assignArgsForCall = append(fake.assignArgsForCall, struct {
+		log     lager.Logger
+		path    string
+		project uint32
+	}{log, path, project})
+	fake.assignMutex.Unlock()
+	if fake.AssignStub != nil {
+		return fake.AssignStub(log, path, project)
+	} else {
+		return fake.assignReturns.result1
+	}
+}
+
+func (fake *FakeProjectQuota) AssignCallCount() int {
+	fake.assignMutex.RLock()
+	defer fake.assignMutex.RUnlock()
+	return len(fake.assignArgsForCall)
+}
+
+func (fake *FakeProjectQuota) AssignArgsForCall(i int) (lager.Logger, string, uint32) {
+	fake.assignMutex.RLock()
+	defer fake.assignMutex.RUnlock()
+	return fake.assignArgsForCall[i].log, fake.assignArgsForCall[i].path, fake.assignArgsForCall[i].project
+}
+
+func (fake *FakeProjectQuota) AssignReturns(result1 error) {
+	fake.AssignStub = nil
+	fake.assignReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeProjectQuota) Limit(log lager.Logger, project uint32, limitInBytes uint64) error {
+	fake.limitMutex.Lock()
+	fake.limitArgsForCall = append(fake.limitArgsForCall, struct {
+		log          lager.Logger
+		project      uint32
+		limitInBytes uint64
+	}{log, project, limitInBytes})
+	fake.limitMutex.Unlock()
+	if fake.LimitStub != nil {
+		return fake.LimitStub(log, project, limitInBytes)
+	} else {
+		return fake.limitReturns.result1
+	}
+}
+
+func (fake *FakeProjectQuota) LimitCallCount() int {
+	fake.limitMutex.RLock()
+	defer fake.limitMutex.RUnlock()
+	return len(fake.limitArgsForCall)
+}
+
+func (fake *FakeProjectQuota) LimitArgsForCall(i int) (lager.Logger, uint32, uint64) {
+	fake.limitMutex.RLock()
+	defer fake.limitMutex.RUnlock()
+	return fake.limitArgsForCall[i].log, fake.limitArgsForCall[i].project, fake.limitArgsForCall[i].limitInBytes
+}
+
+func (fake *FakeProjectQuota) LimitReturns(result1 error) {
+	fake.LimitStub = nil
+	fake.limitReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeProjectQuota) Usage(log lager.Logger, project uint32) (uint64, error) {
+	fake.usageMutex.Lock()
+	fake.usageArgsForCall = append(fake.usageArgsForCall, struct {
+		log     lager.Logger
+		project uint32
+	}{log, project})
+	fake.usageMutex.Unlock()
+	if fake.UsageStub != nil {
+		return fake.UsageStub(log, project)
+	} else {
+		return fake.usageReturns.result1, fake.usageReturns.result2
+	}
+}
+
+func (fake *FakeProjectQuota) UsageCallCount() int {
+	fake.usageMutex.RLock()
+	defer fake.usageMutex.RUnlock()
+	return len(fake.usageArgsForCall)
+}
+
+func (fake *FakeProjectQuota) UsageArgsForCall(i int) (lager.Logger, uint32) {
+	fake.usageMutex.RLock()
+	defer fake.usageMutex.RUnlock()
+	return fake.usageArgsForCall[i].log, fake.usageArgsForCall[i].project
+}
+
+func (fake *FakeProjectQuota) UsageReturns(result1 uint64, result2 error) {
+	fake.UsageStub = nil
+	fake.usageReturns = struct {
+		result1 uint64
+		result2 error
+	}{result1, result2}
+}
+
+var _ rundmc.ProjectQuota = new(FakeProjectQuota)
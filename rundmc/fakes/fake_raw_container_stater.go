@@ -0,0 +1,59 @@
+// This file was generated by counterfeiter
+package fakes
+
+import (
+	"encoding/json"
+	"sync"
+
+	"github.com/cloudfoundry-incubator/guardian/rundmc"
+	"github.com/pivotal-golang/lager"
+)
+
+type FakeRawContainerStater struct {
+	RawStateStub        func(log lager.Logger, id string) (json.RawMessage, error)
+	rawStateMutex       sync.RWMutex
+	rawStateArgsForCall []struct {
+		log lager.Logger
+		id  string
+	}
+	rawStateReturns struct {
+		result1 json.RawMessage
+		result2 error
+	}
+}
+
+func (fake *FakeRawContainerStater) RawState(log lager.Logger, id string) (json.RawMessage, error) {
+	fake.rawStateMutex.Lock()
+	fake.rawStateArgsForCall = append(fake.rawStateArgsForCall, struct {
+		log lager.Logger
+		id  string
+	}{log, id})
+	fake.rawStateMutex.Unlock()
+	if fake.RawStateStub != nil {
+		return fake.RawStateStub(log, id)
+	} else {
+		return fake.rawStateReturns.result1, fake.rawStateReturns.result2
+	}
+}
+
+func (fake *FakeRawContainerStater) RawStateCallCount() int {
+	fake.rawStateMutex.RLock()
+	defer fake.rawStateMutex.RUnlock()
+	return len(fake.rawStateArgsForCall)
+}
+
+func (fake *FakeRawContainerStater) RawStateArgsForCall(i int) (lager.Logger, string) {
+	fake.rawStateMutex.RLock()
+	defer fake.rawStateMutex.RUnlock()
+	return fake.rawStateArgsForCall[i].log, fake.rawStateArgsForCall[i].id
+}
+
+func (fake *FakeRawContainerStater) RawStateReturns(result1 json.RawMessage, result2 error) {
+	fake.RawStateStub = nil
+	fake.rawStateReturns = struct {
+		result1 json.RawMessage
+		result2 error
+	}{result1, result2}
+}
+
+var _ rundmc.RawContainerStater = new(FakeRawContainerStater)
@@ -0,0 +1,59 @@
+// This file was generated by counterfeiter
+package fakes
+
+import (
+	"sync"
+
+	"github.com/cloudfoundry-incubator/guardian/rundmc"
+	"github.com/cloudfoundry-incubator/guardian/rundmc/runrunc"
+	"github.com/pivotal-golang/lager"
+)
+
+type FakeResourceUpdater struct {
+	UpdateStub        func(log lager.Logger, id string, resources runrunc.Resources) error
+	updateMutex       sync.RWMutex
+	updateArgsForCall []struct {
+		log       lager.Logger
+		id        string
+		resources runrunc.Resources
+	}
+	updateReturns struct {
+		result1 error
+	}
+}
+
+func (fake *FakeResourceUpdater) Update(log lager.Logger, id string, resources runrunc.Resources) error {
+	fake.updateMutex.Lock()
+	fake.updateArgsForCall = append(fake.updateArgsForCall, struct {
+		log       lager.Logger
+		id        string
+		resources runrunc.Resources
+	}{log, id, resources})
+	fake.updateMutex.Unlock()
+	if fake.UpdateStub != nil {
+		return fake.UpdateStub(log, id, resources)
+	} else {
+		return fake.updateReturns.result1
+	}
+}
+
+func (fake *FakeResourceUpdater) UpdateCallCount() int {
+	fake.updateMutex.RLock()
+	defer fake.updateMutex.RUnlock()
+	return len(fake.updateArgsForCall)
+}
+
+func (fake *FakeResourceUpdater) UpdateArgsForCall(i int) (lager.Logger, string, runrunc.Resources) {
+	fake.updateMutex.RLock()
+	defer fake.updateMutex.RUnlock()
+	return fake.updateArgsForCall[i].log, fake.updateArgsForCall[i].id, fake.updateArgsForCall[i].resources
+}
+
+func (fake *FakeResourceUpdater) UpdateReturns(result1 error) {
+	fake.UpdateStub = nil
+	fake.updateReturns = struct {
+		result1 error
+	}{result1}
+}
+
+var _ rundmc.ResourceUpdater = new(FakeResourceUpdater)
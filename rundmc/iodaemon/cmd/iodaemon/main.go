@@ -7,13 +7,18 @@ import (
 	"time"
 
 	"github.com/cloudfoundry-incubator/guardian/rundmc/iodaemon"
+	"github.com/pivotal-golang/clock"
 )
 
 const USAGE = `usage:
 
-	iodaemon spawn [-timeout timeout] [-tty] <socket> <path> <args...>:
+	iodaemon spawn [-timeout timeout] [-tty] [-ioRateLimit bytes] [-ioMaxBytes bytes] [-timestamp] <socket> <path> <args...>:
 		spawn a subprocess, making its stdio and exit status available via
 		the given socket
+
+	iodaemon pool [-timeout timeout] [-idle-timeout timeout] <socket>:
+		serve requests to spawn subprocesses on the given socket, each as
+		spawn would, until no subprocesses are left for the idle timeout
 `
 
 var timeout = flag.Duration(
@@ -22,6 +27,12 @@ var timeout = flag.Duration(
 	"time duration to wait on an initial link before giving up",
 )
 
+var idleTimeout = flag.Duration(
+	"idle-timeout",
+	time.Minute,
+	"time duration a pool waits, with no subprocesses left, for another request before exiting",
+)
+
 var tty = flag.Bool(
 	"tty",
 	false,
@@ -40,6 +51,24 @@ var windowRows = flag.Int(
 	"initial window rows for the process's tty",
 )
 
+var ioRateLimit = flag.Int64(
+	"ioRateLimit",
+	0,
+	"maximum number of bytes per second the process may write to stdout and stderr, beyond which writes are slowed down (0 means no limit)",
+)
+
+var ioMaxBytes = flag.Int64(
+	"ioMaxBytes",
+	0,
+	"maximum number of bytes the process may write to stdout and stderr, beyond which its output is truncated (0 means no limit)",
+)
+
+var timestamp = flag.Bool(
+	"timestamp",
+	false,
+	"prefix each line the process writes to stdout and stderr with an RFC3339 timestamp of when it was read",
+)
+
 func main() {
 	flag.Parse()
 
@@ -53,6 +82,13 @@ func main() {
 
 		spawn(args)
 
+	case "pool":
+		if len(args) != 2 {
+			usage()
+		}
+
+		pool(args[1])
+
 	default:
 		usage()
 	}
@@ -60,6 +96,14 @@ func main() {
 
 func spawn(args []string) {
 	wirer := &iodaemon.Wirer{WithTty: *tty, WindowColumns: *windowColumns, WindowRows: *windowRows}
+	if *ioRateLimit > 0 || *ioMaxBytes > 0 {
+		wirer.Limiter = &iodaemon.OutputLimiter{RateLimit: *ioRateLimit, MaxBytes: *ioMaxBytes, Clock: clock.NewClock()}
+	}
+
+	if *timestamp {
+		wirer.Timestamper = &iodaemon.Timestamper{Clock: clock.NewClock()}
+	}
+
 	daemon := &iodaemon.Daemon{WithTty: *tty}
 
 	if err := iodaemon.Spawn(args[1], args[2:], *timeout, os.Stdout, wirer, daemon); err != nil {
@@ -70,6 +114,17 @@ func spawn(args []string) {
 	os.Exit(0)
 }
 
+func pool(socketPath string) {
+	pool := &iodaemon.Pool{Timeout: *timeout, IdleTimeout: *idleTimeout}
+
+	if err := pool.Serve(socketPath, os.Stdout); err != nil {
+		fmt.Fprintf(os.Stderr, "failed: %s", err)
+		os.Exit(2)
+	}
+
+	os.Exit(0)
+}
+
 func usage() {
 	println(USAGE)
 	os.Exit(1)
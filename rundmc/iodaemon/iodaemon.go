@@ -24,33 +24,49 @@ func Spawn(
 	wirer *Wirer,
 	daemon *Daemon,
 ) error {
-	var listener net.Listener
-
-	listener, err := listen(socketPath)
+	executablePath, err := exec.LookPath(argv[0])
 	if err != nil {
 		return err
 	}
 
-	defer listener.Close()
+	return spawn(socketPath, child(executablePath, argv), timeout, notifyStream, wirer, daemon)
+}
 
-	executablePath, err := exec.LookPath(argv[0])
+func spawn(
+	socketPath string,
+	cmd *exec.Cmd,
+	timeout time.Duration,
+	notifyStream io.WriteCloser,
+
+	wirer *Wirer,
+	daemon *Daemon,
+) error {
+	var listener net.Listener
+
+	listener, err := listen(socketPath)
 	if err != nil {
 		return err
 	}
 
-	cmd := child(executablePath, argv)
+	defer listener.Close()
 
 	stdinW, stdoutR, stderrR, err := wirer.Wire(cmd)
 	if err != nil {
 		return err
 	}
 
+	// a pool outlives its processes, so must not leak their descriptors
+	defer closeFiles(stdinW, stdoutR, stderrR)
+	defer closeChildFiles(cmd)
+
 	statusR, statusW, err := os.Pipe()
 	if err != nil {
 		return err
 	}
 
-	launched := make(chan bool)
+	defer closeFiles(statusR, statusW)
+
+	launched := make(chan error, 1)
 
 	go func() {
 		var once sync.Once
@@ -62,30 +78,47 @@ func Spawn(
 				return // in general this means the listener has been closed
 			}
 
+			var startErr error
 			once.Do(func() {
-				err := cmd.Start()
-				if err != nil {
-					panic(err)
+				// a failed start must not panic, as a pool spawns many
+				// processes in the one daemon
+				if startErr = cmd.Start(); startErr != nil {
+					notifyStream.Close()
+					launched <- startErr
+					return
 				}
 
 				fmt.Fprintln(notifyStream, "active")
 				notifyStream.Close()
-				launched <- true
+				launched <- nil
 			})
 
+			if startErr != nil {
+				conn.Close()
+				return
+			}
+
 			daemon.HandleConnection(conn, cmd.Process, stdinW)
 		}
 	}()
 
 	select {
-	case <-launched:
+	case err := <-launched:
+		if err != nil {
+			return err
+		}
+
 		var exit byte = 0
 		if err := cmd.Wait(); err != nil {
 			ws := err.(*exec.ExitError).ProcessState.Sys().(syscall.WaitStatus)
 			exit = byte(ws.ExitStatus())
 		}
 
-		fmt.Fprintf(statusW, "%d\n", exit)
+		if dropped := wirer.Limiter.Dropped(); dropped > 0 {
+			fmt.Fprintf(statusW, "%d %d\n", exit, dropped)
+		} else {
+			fmt.Fprintf(statusW, "%d\n", exit)
+		}
 	case <-time.After(timeout):
 		return fmt.Errorf("expected client to connect within %s", timeout)
 	}
@@ -127,3 +160,19 @@ func acceptConnection(listener net.Listener, stdoutR, stderrR, statusR *os.File)
 
 	return conn, nil
 }
+
+func closeFiles(files ...*os.File) {
+	for _, f := range files {
+		if f != nil {
+			f.Close()
+		}
+	}
+}
+
+func closeChildFiles(cmd *exec.Cmd) {
+	for _, stream := range []interface{}{cmd.Stdin, cmd.Stdout, cmd.Stderr} {
+		if f, ok := stream.(*os.File); ok {
+			f.Close()
+		}
+	}
+}
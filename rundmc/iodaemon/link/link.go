@@ -1,6 +1,7 @@
 package link
 
 import (
+	"bufio"
 	"bytes"
 	"fmt"
 	"io"
@@ -21,6 +22,7 @@ type Link struct {
 	*Writer
 
 	exitStatus <-chan int
+	dropped    int64
 }
 
 func Create(socketPath string, stdout io.Writer, stderr io.Writer) (*Link, error) {
@@ -136,11 +138,17 @@ func Create(socketPath string, stdout io.Writer, stderr io.Writer) (*Link, error
 		close(done)
 	}()
 
+	link := &Link{
+		Writer: linkWriter,
+	}
+
 	exitStatus := make(chan int)
 	go func() {
+		// the status is the exit status, followed by the number of bytes
+		// of output the daemon dropped, if it truncated the output
 		var s int
-		_, err := fmt.Fscanf(lstatus, "%d\n", &s)
-		if err != nil {
+		status, err := bufio.NewReader(lstatus).ReadString('\n')
+		if n, _ := fmt.Sscan(status, &s, &link.dropped); err != nil || n < 1 {
 			s = 255
 		}
 
@@ -159,12 +167,17 @@ func Create(socketPath string, stdout io.Writer, stderr io.Writer) (*Link, error
 		exitStatus <- s
 	}()
 
-	return &Link{
-		Writer:     linkWriter,
-		exitStatus: exitStatus,
-	}, nil
+	link.exitStatus = exitStatus
+	return link, nil
 }
 
 func (link *Link) Wait() (int, error) {
 	return <-link.exitStatus, nil
 }
+
+// Dropped returns the number of bytes of output the daemon dropped because
+// the process exceeded its maximum output. It is only valid once Wait has
+// returned.
+func (link *Link) Dropped() int64 {
+	return link.dropped
+}
@@ -0,0 +1,147 @@
+package iodaemon
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/pivotal-golang/clock"
+)
+
+// TruncationMarker is written to a stream, with the number of bytes after
+// which it was truncated, once the process has written MaxBytes of output
+const TruncationMarker = "\n[iodaemon: output truncated after %d bytes]\n"
+
+// OutputLimiter limits the output of a process, which its stdout and stderr
+// share. Once RateLimit bytes per second have been written, with bursts of
+// up to a second's worth, reading from the process slows down, so that it
+// blocks on writing. Once MaxBytes have been written, each stream is ended
+// with a TruncationMarker and the rest of the output is read and dropped, so
+// that the process does not block. Either limit may be 0 for no limit.
+type OutputLimiter struct {
+	RateLimit int64
+	MaxBytes  int64
+	Clock     clock.Clock
+
+	mu      sync.Mutex
+	tokens  float64
+	last    time.Time
+	written int64
+	dropped int64
+}
+
+// Limit returns a pipe which is fed the output of r, limited, in the
+// background
+func (l *OutputLimiter) Limit(r *os.File) (*os.File, error) {
+	limitedR, limitedW, err := os.Pipe()
+	if err != nil {
+		return nil, err
+	}
+
+	go func() {
+		l.Copy(limitedW, r)
+		limitedW.Close()
+	}()
+
+	return limitedR, nil
+}
+
+// Copy copies src to dst, limited, until src ends or dst can no longer be
+// written to
+func (l *OutputLimiter) Copy(dst io.Writer, src io.Reader) error {
+	truncated := false
+	buf := make([]byte, 32*1024)
+
+	for {
+		n, err := src.Read(buf)
+		if n > 0 {
+			allowed := l.take(n)
+			if allowed > 0 {
+				if _, err := dst.Write(buf[:allowed]); err != nil {
+					return err
+				}
+			}
+
+			if allowed < n && !truncated {
+				truncated = true
+				if _, err := fmt.Fprintf(dst, TruncationMarker, l.MaxBytes); err != nil {
+					return err
+				}
+			}
+		}
+
+		if err == io.EOF {
+			return nil
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// Dropped returns the number of bytes of output which have been dropped
+// since MaxBytes was reached
+func (l *OutputLimiter) Dropped() int64 {
+	if l == nil {
+		return 0
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.dropped
+}
+
+// take accounts for n bytes of output, waiting for the rate limit if need be,
+// and returns how many of them may be written
+func (l *OutputLimiter) take(n int) int {
+	l.mu.Lock()
+
+	allowed := n
+	if l.MaxBytes > 0 && l.written+int64(n) > l.MaxBytes {
+		allowed = int(l.MaxBytes - l.written)
+		l.dropped += int64(n - allowed)
+	}
+	l.written += int64(allowed)
+
+	var wait time.Duration
+	if l.RateLimit > 0 && allowed > 0 {
+		wait = l.wait(allowed)
+	}
+
+	l.mu.Unlock()
+
+	if wait > 0 {
+		l.Clock.Sleep(wait)
+	}
+
+	return allowed
+}
+
+// wait removes n tokens from the bucket, returning how long the caller must
+// wait for them to have been refilled. The bucket may go into debt, so that
+// stdout and stderr queue up behind each other.
+func (l *OutputLimiter) wait(n int) time.Duration {
+	rate := float64(l.RateLimit)
+	now := l.Clock.Now()
+
+	if l.last.IsZero() {
+		l.tokens = rate
+	} else {
+		l.tokens += now.Sub(l.last).Seconds() * rate
+		if l.tokens > rate {
+			l.tokens = rate
+		}
+	}
+	l.last = now
+
+	l.tokens -= float64(n)
+	if l.tokens >= 0 {
+		return 0
+	}
+
+	return time.Duration(-l.tokens / rate * float64(time.Second))
+}
@@ -0,0 +1,69 @@
+package iodaemon_test
+
+import (
+	"bytes"
+	"strings"
+	"time"
+
+	"github.com/cloudfoundry-incubator/guardian/rundmc/iodaemon"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/pivotal-golang/clock/fakeclock"
+)
+
+var _ = Describe("OutputLimiter", func() {
+	var (
+		fakeClock *fakeclock.FakeClock
+		limiter   *iodaemon.OutputLimiter
+	)
+
+	BeforeEach(func() {
+		fakeClock = fakeclock.NewFakeClock(time.Now())
+		limiter = &iodaemon.OutputLimiter{Clock: fakeClock}
+	})
+
+	It("copies output within the limits unchanged", func() {
+		limiter.MaxBytes = 100
+
+		var out bytes.Buffer
+		Expect(limiter.Copy(&out, strings.NewReader("hello"))).To(Succeed())
+		Expect(out.String()).To(Equal("hello"))
+		Expect(limiter.Dropped()).To(BeZero())
+	})
+
+	It("truncates output beyond the maximum with a marker, and counts what it drops", func() {
+		limiter.MaxBytes = 5
+
+		var out bytes.Buffer
+		Expect(limiter.Copy(&out, strings.NewReader("hello world"))).To(Succeed())
+		Expect(out.String()).To(Equal("hello\n[iodaemon: output truncated after 5 bytes]\n"))
+		Expect(limiter.Dropped()).To(BeNumerically("==", 6))
+	})
+
+	It("shares the maximum between stdout and stderr", func() {
+		limiter.MaxBytes = 8
+
+		var stdout, stderr bytes.Buffer
+		Expect(limiter.Copy(&stdout, strings.NewReader("hello"))).To(Succeed())
+		Expect(limiter.Copy(&stderr, strings.NewReader("world"))).To(Succeed())
+
+		Expect(stdout.String()).To(Equal("hello"))
+		Expect(stderr.String()).To(Equal("wor\n[iodaemon: output truncated after 8 bytes]\n"))
+	})
+
+	It("slows output beyond the rate down", func() {
+		limiter.RateLimit = 4
+
+		var out bytes.Buffer
+		copied := make(chan error)
+		go func() {
+			copied <- limiter.Copy(&out, strings.NewReader("hello world"))
+		}()
+
+		Consistently(copied).ShouldNot(Receive())
+
+		fakeClock.WaitForWatcherAndIncrement(2 * time.Second)
+		Eventually(copied).Should(Receive(BeNil()))
+		Expect(limiter.Dropped()).To(BeZero())
+	})
+})
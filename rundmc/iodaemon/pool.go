@@ -0,0 +1,139 @@
+package iodaemon
+
+import (
+	"encoding/gob"
+	"fmt"
+	"io"
+	"net"
+	"os/exec"
+	"sync"
+	"time"
+
+	"github.com/pivotal-golang/clock"
+)
+
+// SpawnRequest asks a Pool to spawn a process, as 'iodaemon spawn' would
+type SpawnRequest struct {
+	Socket string
+	Argv   []string
+	Env    []string
+	Dir    string
+
+	Tty           bool
+	WindowColumns int
+	WindowRows    int
+
+	RateLimit int64
+	MaxBytes  int64
+
+	Timestamp bool
+}
+
+// Pool spawns all of a container's processes from the one daemon, saving
+// the fork and exec of an iodaemon per process. Each connection to its
+// socket carries one gob-encoded SpawnRequest, and is then notified as
+// 'iodaemon spawn' notifies its stdout: 'ready' once the process's socket is
+// listening, and 'active' once the process has started. The process is then
+// linked to through its own socket, exactly as if it had its own iodaemon.
+type Pool struct {
+	// Timeout is how long each process waits on an initial link
+	Timeout time.Duration
+
+	// IdleTimeout is how long the pool waits, with no processes left, for
+	// another spawn request before it exits
+	IdleTimeout time.Duration
+
+	mu     sync.Mutex
+	active int
+	idle   *time.Timer
+}
+
+// Serve listens on socketPath, writing 'ready' to notifyStream once it is
+// listening, and serves spawn requests until it has been idle for
+// IdleTimeout
+func (p *Pool) Serve(socketPath string, notifyStream io.WriteCloser) error {
+	listener, err := listen(socketPath)
+	if err != nil {
+		return err
+	}
+
+	p.idle = time.AfterFunc(p.IdleTimeout, func() {
+		p.mu.Lock()
+		defer p.mu.Unlock()
+
+		if p.active == 0 {
+			listener.Close()
+		}
+	})
+
+	fmt.Fprintln(notifyStream, "ready")
+	notifyStream.Close()
+
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			// the listener is closed once the pool is idle
+			return nil
+		}
+
+		p.mu.Lock()
+		p.active++
+		p.idle.Stop()
+		p.mu.Unlock()
+
+		go func() {
+			defer p.done()
+			p.handle(conn)
+		}()
+	}
+}
+
+func (p *Pool) done() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.active--
+	if p.active == 0 {
+		p.idle.Reset(p.IdleTimeout)
+	}
+}
+
+func (p *Pool) handle(conn net.Conn) {
+	defer conn.Close()
+
+	var request SpawnRequest
+	if err := gob.NewDecoder(conn).Decode(&request); err != nil {
+		fmt.Fprintf(conn, "failed: %s\n", err)
+		return
+	}
+
+	if len(request.Argv) == 0 {
+		fmt.Fprintln(conn, "failed: no command to spawn")
+		return
+	}
+
+	executablePath, err := exec.LookPath(request.Argv[0])
+	if err != nil {
+		fmt.Fprintf(conn, "failed: %s\n", err)
+		return
+	}
+
+	cmd := child(executablePath, request.Argv)
+	cmd.Env = request.Env
+	cmd.Dir = request.Dir
+
+	wirer := &Wirer{WithTty: request.Tty, WindowColumns: request.WindowColumns, WindowRows: request.WindowRows}
+	if request.RateLimit > 0 || request.MaxBytes > 0 {
+		wirer.Limiter = &OutputLimiter{RateLimit: request.RateLimit, MaxBytes: request.MaxBytes, Clock: clock.NewClock()}
+	}
+
+	if request.Timestamp {
+		wirer.Timestamper = &Timestamper{Clock: clock.NewClock()}
+	}
+
+	// the notifications are written to the connection, which spawn closes
+	// once the process is active
+	if err := spawn(request.Socket, cmd, p.Timeout, conn, wirer, &Daemon{WithTty: request.Tty}); err != nil {
+		fmt.Fprintf(conn, "failed: %s\n", err)
+	}
+}
@@ -0,0 +1,99 @@
+package iodaemon
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/pivotal-golang/clock"
+)
+
+// Timestamper prefixes each line of a process's output with an RFC3339
+// timestamp of when the daemon read its first byte from the process, so that
+// every client attached to the process, and any log drain, sees when each
+// line was written rather than when it was forwarded.
+type Timestamper struct {
+	Clock clock.Clock
+}
+
+// Timestamp returns a pipe which is fed the output of r, timestamped, in the
+// background
+func (t *Timestamper) Timestamp(r *os.File) (*os.File, error) {
+	timestampedR, timestampedW, err := os.Pipe()
+	if err != nil {
+		return nil, err
+	}
+
+	go func() {
+		t.Copy(timestampedW, r)
+		timestampedW.Close()
+	}()
+
+	return timestampedR, nil
+}
+
+// Copy copies src to dst, timestamped, until src ends or dst can no longer
+// be written to
+func (t *Timestamper) Copy(dst io.Writer, src io.Reader) error {
+	w := &timestampWriter{Writer: dst, clock: t.Clock}
+	buf := make([]byte, 32*1024)
+
+	for {
+		n, err := src.Read(buf)
+		if n > 0 {
+			if _, err := w.Write(buf[:n]); err != nil {
+				return err
+			}
+		}
+
+		if err == io.EOF {
+			return nil
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// timestampWriter prefixes each line written to it with the time at which
+// the line's first byte was written
+type timestampWriter struct {
+	io.Writer
+	clock clock.Clock
+
+	mu      sync.Mutex
+	midLine bool
+}
+
+func (w *timestampWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	n := len(p)
+	timestamp := w.clock.Now().UTC().Format(time.RFC3339Nano) + " "
+
+	var stamped []byte
+	for len(p) > 0 {
+		if !w.midLine {
+			stamped = append(stamped, timestamp...)
+		}
+
+		line := p
+		if i := bytes.IndexByte(p, '\n'); i >= 0 {
+			line = p[:i+1]
+		}
+
+		stamped = append(stamped, line...)
+		w.midLine = line[len(line)-1] != '\n'
+		p = p[len(line):]
+	}
+
+	if _, err := w.Writer.Write(stamped); err != nil {
+		return 0, err
+	}
+
+	return n, nil
+}
@@ -0,0 +1,43 @@
+package iodaemon_test
+
+import (
+	"bytes"
+	"io"
+	"time"
+
+	"github.com/cloudfoundry-incubator/guardian/rundmc/iodaemon"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/pivotal-golang/clock/fakeclock"
+)
+
+var _ = Describe("Timestamper", func() {
+	var (
+		fakeClock   *fakeclock.FakeClock
+		timestamper *iodaemon.Timestamper
+	)
+
+	BeforeEach(func() {
+		fakeClock = fakeclock.NewFakeClock(time.Date(2016, 3, 1, 12, 0, 0, 500, time.UTC))
+		timestamper = &iodaemon.Timestamper{Clock: fakeClock}
+	})
+
+	It("prefixes each line with when its first byte was read", func() {
+		r, w := io.Pipe()
+
+		var out bytes.Buffer
+		copied := make(chan error)
+		go func() {
+			copied <- timestamper.Copy(&out, r)
+		}()
+
+		w.Write([]byte("hello\nwor"))
+		fakeClock.Increment(time.Second)
+		w.Write([]byte("ld\n"))
+		w.Write([]byte("again\n"))
+		w.Close()
+
+		Eventually(copied).Should(Receive(BeNil()))
+		Expect(out.String()).To(Equal("2016-03-01T12:00:00.0000005Z hello\n2016-03-01T12:00:00.0000005Z world\n2016-03-01T12:00:01.0000005Z again\n"))
+	})
+})
@@ -11,6 +11,13 @@ type Wirer struct {
 	WithTty       bool
 	WindowColumns int
 	WindowRows    int
+
+	// Timestamper, if set, timestamps each line of the process's output as
+	// it is read, before it is limited
+	Timestamper *Timestamper
+
+	// Limiter, if set, limits the output of the process
+	Limiter *OutputLimiter
 }
 
 func (w *Wirer) Wire(cmd *exec.Cmd) (*os.File, *os.File, *os.File, error) {
@@ -31,6 +38,32 @@ func (w *Wirer) Wire(cmd *exec.Cmd) (*os.File, *os.File, *os.File, error) {
 		return nil, nil, nil, err
 	}
 
+	if w.Timestamper != nil {
+		if stdoutR, err = w.Timestamper.Timestamp(stdoutR); err != nil {
+			return nil, nil, nil, err
+		}
+
+		// with a tty, stderr is /dev/null, which the link does not read
+		if !w.WithTty {
+			if stderrR, err = w.Timestamper.Timestamp(stderrR); err != nil {
+				return nil, nil, nil, err
+			}
+		}
+	}
+
+	if w.Limiter != nil {
+		if stdoutR, err = w.Limiter.Limit(stdoutR); err != nil {
+			return nil, nil, nil, err
+		}
+
+		// with a tty, stderr is /dev/null, which the link does not read
+		if !w.WithTty {
+			if stderrR, err = w.Limiter.Limit(stderrR); err != nil {
+				return nil, nil, nil, err
+			}
+		}
+	}
+
 	return stdinW, stdoutR, stderrR, nil
 }
 
@@ -0,0 +1,49 @@
+package rundmc
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strconv"
+
+	"github.com/pivotal-golang/lager"
+)
+
+// KeyringQuota sets the kernel's per-user key quotas when guardian starts.
+//
+// The runtime gives each container a session keyring of its own (named
+// _ses.<handle>), rather than letting it join the host's, so containers
+// cannot find each other's keys by name. Key quotas are not namespaced,
+// though: they are kept per host uid, so every unprivileged container whose
+// root maps to the same host uid shares one quota. MaxKeys and MaxBytes size
+// that quota (kernel.keys.maxkeys and kernel.keys.maxbytes) so that busy
+// containers do not exhaust it for everyone else. Zero leaves the kernel's
+// setting alone.
+type KeyringQuota struct {
+	ProcPath string
+	MaxKeys  uint64
+	MaxBytes uint64
+	Logger   lager.Logger
+}
+
+func (q *KeyringQuota) Start() error {
+	log := q.Logger.Session("keyring-quota", lager.Data{"maxkeys": q.MaxKeys, "maxbytes": q.MaxBytes})
+
+	for name, value := range map[string]uint64{
+		"maxkeys":  q.MaxKeys,
+		"maxbytes": q.MaxBytes,
+	} {
+		if value == 0 {
+			continue
+		}
+
+		path := filepath.Join(q.ProcPath, "sys", "kernel", "keys", name)
+		if err := ioutil.WriteFile(path, []byte(strconv.FormatUint(value, 10)), 0644); err != nil {
+			log.Error("failed", err)
+			return fmt.Errorf("set keyring %s: %s", name, err)
+		}
+	}
+
+	log.Info("set")
+	return nil
+}
@@ -0,0 +1,74 @@
+package rundmc_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/cloudfoundry-incubator/guardian/rundmc"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/pivotal-golang/lager/lagertest"
+)
+
+var _ = Describe("KeyringQuota", func() {
+	var (
+		procPath string
+		keysPath string
+		quota    *rundmc.KeyringQuota
+	)
+
+	BeforeEach(func() {
+		var err error
+		procPath, err = ioutil.TempDir("", "proc")
+		Expect(err).NotTo(HaveOccurred())
+
+		keysPath = filepath.Join(procPath, "sys", "kernel", "keys")
+		Expect(os.MkdirAll(keysPath, 0755)).To(Succeed())
+		Expect(ioutil.WriteFile(filepath.Join(keysPath, "maxkeys"), []byte("200\n"), 0644)).To(Succeed())
+		Expect(ioutil.WriteFile(filepath.Join(keysPath, "maxbytes"), []byte("20000\n"), 0644)).To(Succeed())
+
+		quota = &rundmc.KeyringQuota{
+			ProcPath: procPath,
+			Logger:   lagertest.NewTestLogger("test"),
+		}
+	})
+
+	AfterEach(func() {
+		Expect(os.RemoveAll(procPath)).To(Succeed())
+	})
+
+	read := func(name string) string {
+		contents, err := ioutil.ReadFile(filepath.Join(keysPath, name))
+		Expect(err).NotTo(HaveOccurred())
+		return string(contents)
+	}
+
+	It("sets the per-user key quotas", func() {
+		quota.MaxKeys = 1000
+		quota.MaxBytes = 25000
+		Expect(quota.Start()).To(Succeed())
+
+		Expect(read("maxkeys")).To(Equal("1000"))
+		Expect(read("maxbytes")).To(Equal("25000"))
+	})
+
+	It("leaves quotas which are zero alone", func() {
+		quota.MaxBytes = 25000
+		Expect(quota.Start()).To(Succeed())
+
+		Expect(read("maxkeys")).To(Equal("200\n"))
+		Expect(read("maxbytes")).To(Equal("25000"))
+	})
+
+	Context("when the quota cannot be set", func() {
+		BeforeEach(func() {
+			Expect(os.RemoveAll(keysPath)).To(Succeed())
+		})
+
+		It("returns an error", func() {
+			quota.MaxKeys = 1000
+			Expect(quota.Start()).To(MatchError(ContainSubstring("set keyring maxkeys")))
+		})
+	})
+})
@@ -0,0 +1,41 @@
+package rundmc
+
+import (
+	"fmt"
+	"syscall"
+
+	"github.com/pivotal-golang/lager"
+)
+
+// SyscallMounter makes mounts with the mount and umount2 syscalls, in the
+// calling process, so that destinations may be /proc/self/fd links
+type SyscallMounter struct{}
+
+func (SyscallMounter) BindMount(log lager.Logger, src, dst string, readOnly bool) error {
+	if err := syscall.Mount(src, dst, "", syscall.MS_BIND, ""); err != nil {
+		return fmt.Errorf("mount: %s", err)
+	}
+
+	if !readOnly {
+		return nil
+	}
+
+	// the read-only flag is ignored when the bind mount is made, so must be
+	// set by remounting it
+	if err := syscall.Mount("", dst, "", syscall.MS_REMOUNT|syscall.MS_BIND|syscall.MS_RDONLY, ""); err != nil {
+		syscall.Unmount(dst, 0)
+		return fmt.Errorf("remount read-only: %s", err)
+	}
+
+	return nil
+}
+
+// Unmount detaches the mount, since the /proc/self/fd link it is given holds
+// the mount busy until it is closed
+func (SyscallMounter) Unmount(log lager.Logger, dst string) error {
+	if err := syscall.Unmount(dst, syscall.MNT_DETACH); err != nil {
+		return fmt.Errorf("umount: %s", err)
+	}
+
+	return nil
+}
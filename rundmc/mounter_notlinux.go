@@ -0,0 +1,19 @@
+// +build !linux
+
+package rundmc
+
+import (
+	"errors"
+
+	"github.com/pivotal-golang/lager"
+)
+
+type SyscallMounter struct{}
+
+func (SyscallMounter) BindMount(log lager.Logger, src, dst string, readOnly bool) error {
+	return errors.New("bind mounts are not supported on this platform")
+}
+
+func (SyscallMounter) Unmount(log lager.Logger, dst string) error {
+	return errors.New("bind mounts are not supported on this platform")
+}
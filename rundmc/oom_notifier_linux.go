@@ -0,0 +1,136 @@
+package rundmc
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+// CgroupOomNotifier is notified of OOMs by registering an eventfd against
+// the memory cgroup's memory.oom_control
+type CgroupOomNotifier struct{}
+
+func (CgroupOomNotifier) Notify(cgroupPath string, stop <-chan struct{}, notify func()) error {
+	oomControl, err := os.Open(filepath.Join(cgroupPath, "memory.oom_control"))
+	if err != nil {
+		return fmt.Errorf("open oom control: %s", err)
+	}
+
+	fd, _, errno := syscall.RawSyscall(syscall.SYS_EVENTFD2, 0, syscall.O_CLOEXEC|syscall.O_NONBLOCK, 0)
+	if errno != 0 {
+		oomControl.Close()
+		return os.NewSyscallError("eventfd2", errno)
+	}
+
+	// the descriptor is non-blocking so that reads go through the runtime
+	// poller, which lets closing the file interrupt a blocked read
+	eventfd := os.NewFile(fd, "oom-eventfd")
+	registration := fmt.Sprintf("%d %d", fd, oomControl.Fd())
+	if err := ioutil.WriteFile(filepath.Join(cgroupPath, "cgroup.event_control"), []byte(registration), 0700); err != nil {
+		eventfd.Close()
+		oomControl.Close()
+		return fmt.Errorf("register oom eventfd: %s", err)
+	}
+
+	go func() {
+		<-stop
+		eventfd.Close()
+	}()
+
+	go func() {
+		defer oomControl.Close()
+
+		buf := make([]byte, 8)
+		for {
+			if _, err := eventfd.Read(buf); err != nil {
+				return
+			}
+
+			// the eventfd is also signalled when the cgroup is removed
+			if _, err := os.Stat(filepath.Join(cgroupPath, "cgroup.event_control")); err != nil {
+				return
+			}
+
+			if binary.LittleEndian.Uint64(buf) > 0 {
+				notify()
+			}
+		}
+	}()
+
+	return nil
+}
+
+// UnifiedCgroupOomNotifier is notified of OOMs in cgroup v2's unified
+// hierarchy, which has no memory.oom_control, by watching the cgroup's
+// memory.events for increases in its oom_kill count
+type UnifiedCgroupOomNotifier struct{}
+
+func (UnifiedCgroupOomNotifier) Notify(cgroupPath string, stop <-chan struct{}, notify func()) error {
+	eventsPath := filepath.Join(cgroupPath, "memory.events")
+	kills, err := readOomKills(eventsPath)
+	if err != nil {
+		return fmt.Errorf("read memory events: %s", err)
+	}
+
+	fd, err := syscall.InotifyInit1(syscall.IN_CLOEXEC | syscall.IN_NONBLOCK)
+	if err != nil {
+		return os.NewSyscallError("inotify_init1", err)
+	}
+
+	// as with the eventfd, the descriptor is non-blocking so that closing the
+	// file interrupts a blocked read
+	inotify := os.NewFile(uintptr(fd), "oom-inotify")
+	if _, err := syscall.InotifyAddWatch(fd, eventsPath, syscall.IN_MODIFY); err != nil {
+		inotify.Close()
+		return os.NewSyscallError("inotify_add_watch", err)
+	}
+
+	go func() {
+		<-stop
+		inotify.Close()
+	}()
+
+	go func() {
+		buf := make([]byte, syscall.SizeofInotifyEvent*16)
+		for {
+			if _, err := inotify.Read(buf); err != nil {
+				return
+			}
+
+			// the watch is removed, and an event delivered, when the cgroup is
+			// removed
+			current, err := readOomKills(eventsPath)
+			if err != nil {
+				return
+			}
+
+			if current > kills {
+				notify()
+			}
+			kills = current
+		}
+	}()
+
+	return nil
+}
+
+func readOomKills(eventsPath string) (uint64, error) {
+	data, err := ioutil.ReadFile(eventsPath)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, line := range strings.Split(string(data), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 2 && fields[0] == "oom_kill" {
+			return strconv.ParseUint(fields[1], 10, 64)
+		}
+	}
+
+	return 0, nil
+}
@@ -0,0 +1,17 @@
+// +build !linux
+
+package rundmc
+
+import "errors"
+
+type CgroupOomNotifier struct{}
+
+func (CgroupOomNotifier) Notify(cgroupPath string, stop <-chan struct{}, notify func()) error {
+	return errors.New("oom notifications are not supported on this platform")
+}
+
+type UnifiedCgroupOomNotifier struct{}
+
+func (UnifiedCgroupOomNotifier) Notify(cgroupPath string, stop <-chan struct{}, notify func()) error {
+	return errors.New("oom notifications are not supported on this platform")
+}
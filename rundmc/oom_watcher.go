@@ -0,0 +1,161 @@
+package rundmc
+
+import (
+	"path/filepath"
+	"sync"
+
+	"github.com/cloudfoundry-incubator/guardian/gardener"
+	"github.com/pivotal-golang/lager"
+)
+
+// OutOfMemoryEvent is the event recorded, and reported in the container's
+// info, when a container runs out of memory
+const OutOfMemoryEvent = "out of memory"
+
+//go:generate counterfeiter . OomNotifier
+//go:generate counterfeiter . OomCounter
+
+// OomNotifier calls notify whenever the memory cgroup at cgroupPath runs out
+// of memory, until the cgroup is removed or stop is closed
+type OomNotifier interface {
+	Notify(cgroupPath string, stop <-chan struct{}, notify func()) error
+}
+
+// OomCounter counts OOMs, e.g. for a metric
+type OomCounter interface {
+	Inc()
+}
+
+// OomWatcher watches the memory cgroup of every container for OOMs. When a
+// container runs out of memory an OutOfMemoryEvent is recorded for it, an
+// EventOOM is published and the OOM is counted, so that users can tell why
+// their processes were killed.
+//
+// Recorded events are held in memory; after a restart Start resumes watching
+// the containers in the depot, but their earlier OOMs are forgotten.
+type OomWatcher struct {
+	CgroupPath string
+	// Unified is whether CgroupPath is cgroup v2's unified hierarchy, in
+	// which a container has a single cgroup rather than one per subsystem
+	Unified  bool
+	Notifier OomNotifier
+	Lister   HandleLister
+
+	// Publisher and Counter are optional
+	Publisher gardener.EventPublisher
+	Counter   OomCounter
+
+	Logger lager.Logger
+
+	mu       sync.Mutex
+	watching map[string]chan struct{}
+	events   map[string][]string
+}
+
+// Start resumes watching every container in the depot
+func (w *OomWatcher) Start() error {
+	log := w.Logger.Session("oom-watcher-start")
+
+	handles, err := w.Lister.Handles()
+	if err != nil {
+		log.Error("list-handles-failed", err)
+		return nil
+	}
+
+	for _, handle := range handles {
+		if err := w.Watch(log, handle); err != nil {
+			log.Error("watch-failed", err, lager.Data{"handle": handle})
+		}
+	}
+
+	return nil
+}
+
+// Watch starts watching the container's memory cgroup. If the container was
+// already being watched, e.g. before it was checkpointed and restored in to
+// a new cgroup, the old cgroup stops being watched but the container's
+// events are kept.
+func (w *OomWatcher) Watch(log lager.Logger, handle string) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.watching == nil {
+		w.watching = make(map[string]chan struct{})
+		w.events = make(map[string][]string)
+	}
+
+	if stop, ok := w.watching[handle]; ok {
+		close(stop)
+		delete(w.watching, handle)
+	}
+
+	stop := make(chan struct{})
+	if err := w.Notifier.Notify(w.memoryCgroup(handle), stop, func() { w.oom(log, handle) }); err != nil {
+		return err
+	}
+
+	w.watching[handle] = stop
+	return nil
+}
+
+func (w *OomWatcher) memoryCgroup(handle string) string {
+	if w.Unified {
+		return filepath.Join(w.CgroupPath, handle)
+	}
+
+	return filepath.Join(w.CgroupPath, "memory", handle)
+}
+
+// Unwatch stops watching the container and forgets its events
+func (w *OomWatcher) Unwatch(handle string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if stop, ok := w.watching[handle]; ok {
+		close(stop)
+		delete(w.watching, handle)
+	}
+
+	delete(w.events, handle)
+}
+
+// Events returns the events recorded for the container
+func (w *OomWatcher) Events(handle string) []string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return append([]string{}, w.events[handle]...)
+}
+
+func (w *OomWatcher) oom(log lager.Logger, handle string) {
+	log.Info("out-of-memory", lager.Data{"handle": handle})
+
+	w.mu.Lock()
+	if _, ok := w.watching[handle]; !ok {
+		w.mu.Unlock()
+		return
+	}
+
+	if !contains(w.events[handle], OutOfMemoryEvent) {
+		w.events[handle] = append(w.events[handle], OutOfMemoryEvent)
+	}
+	w.mu.Unlock()
+
+	if w.Counter != nil {
+		w.Counter.Inc()
+	}
+
+	if w.Publisher != nil {
+		w.Publisher.Publish(gardener.Event{Handle: handle, Type: gardener.EventOOM})
+	}
+}
+
+func contains(list []string, s string) bool {
+	for _, e := range list {
+		if e == s {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,158 @@
+package rundmc_test
+
+import (
+	"errors"
+
+	"github.com/cloudfoundry-incubator/guardian/gardener"
+	gardenerfakes "github.com/cloudfoundry-incubator/guardian/gardener/fakes"
+	"github.com/cloudfoundry-incubator/guardian/rundmc"
+	"github.com/cloudfoundry-incubator/guardian/rundmc/fakes"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/pivotal-golang/lager/lagertest"
+)
+
+var _ = Describe("OomWatcher", func() {
+	var (
+		logger        *lagertest.TestLogger
+		fakeNotifier  *fakes.FakeOomNotifier
+		fakeLister    *fakes.FakeHandleLister
+		fakePublisher *gardenerfakes.FakeEventPublisher
+		fakeCounter   *fakes.FakeOomCounter
+
+		notifiers map[string]func()
+		stops     map[string]<-chan struct{}
+
+		watcher *rundmc.OomWatcher
+	)
+
+	BeforeEach(func() {
+		logger = lagertest.NewTestLogger("test")
+		fakeNotifier = new(fakes.FakeOomNotifier)
+		fakeLister = new(fakes.FakeHandleLister)
+		fakePublisher = new(gardenerfakes.FakeEventPublisher)
+		fakeCounter = new(fakes.FakeOomCounter)
+
+		notifiers = make(map[string]func())
+		stops = make(map[string]<-chan struct{})
+		fakeNotifier.NotifyStub = func(cgroupPath string, stop <-chan struct{}, notify func()) error {
+			notifiers[cgroupPath] = notify
+			stops[cgroupPath] = stop
+			return nil
+		}
+
+		watcher = &rundmc.OomWatcher{
+			CgroupPath: "/cgroups",
+			Notifier:   fakeNotifier,
+			Lister:     fakeLister,
+			Publisher:  fakePublisher,
+			Counter:    fakeCounter,
+			Logger:     logger,
+		}
+	})
+
+	It("watches the container's memory cgroup", func() {
+		Expect(watcher.Watch(logger, "some-handle")).To(Succeed())
+
+		Expect(fakeNotifier.NotifyCallCount()).To(Equal(1))
+		cgroupPath, _, _ := fakeNotifier.NotifyArgsForCall(0)
+		Expect(cgroupPath).To(Equal("/cgroups/memory/some-handle"))
+	})
+
+	It("watches the container's cgroup when the cgroups are the unified hierarchy", func() {
+		watcher.Unified = true
+		Expect(watcher.Watch(logger, "some-handle")).To(Succeed())
+
+		cgroupPath, _, _ := fakeNotifier.NotifyArgsForCall(0)
+		Expect(cgroupPath).To(Equal("/cgroups/some-handle"))
+	})
+
+	It("records no events until the container runs out of memory", func() {
+		Expect(watcher.Watch(logger, "some-handle")).To(Succeed())
+		Expect(watcher.Events("some-handle")).To(BeEmpty())
+	})
+
+	Context("when the container runs out of memory", func() {
+		BeforeEach(func() {
+			Expect(watcher.Watch(logger, "some-handle")).To(Succeed())
+			notifiers["/cgroups/memory/some-handle"]()
+		})
+
+		It("records an out of memory event, once", func() {
+			notifiers["/cgroups/memory/some-handle"]()
+			Expect(watcher.Events("some-handle")).To(Equal([]string{"out of memory"}))
+		})
+
+		It("counts every oom", func() {
+			notifiers["/cgroups/memory/some-handle"]()
+			Expect(fakeCounter.IncCallCount()).To(Equal(2))
+		})
+
+		It("publishes an oom event", func() {
+			Expect(fakePublisher.PublishCallCount()).To(Equal(1))
+			event := fakePublisher.PublishArgsForCall(0)
+			Expect(event.Handle).To(Equal("some-handle"))
+			Expect(event.Type).To(Equal(gardener.EventOOM))
+		})
+
+		It("does not record events for other containers", func() {
+			Expect(watcher.Events("other-handle")).To(BeEmpty())
+		})
+
+		It("stops watching the old cgroup but keeps the events when the container is watched again", func() {
+			oldStop := stops["/cgroups/memory/some-handle"]
+			Expect(watcher.Watch(logger, "some-handle")).To(Succeed())
+
+			Expect(oldStop).To(BeClosed())
+			Expect(stops["/cgroups/memory/some-handle"]).NotTo(BeClosed())
+			Expect(watcher.Events("some-handle")).To(Equal([]string{"out of memory"}))
+		})
+
+		Context("and the container is unwatched", func() {
+			BeforeEach(func() {
+				watcher.Unwatch("some-handle")
+			})
+
+			It("stops watching and forgets its events", func() {
+				Expect(stops["/cgroups/memory/some-handle"]).To(BeClosed())
+				Expect(watcher.Events("some-handle")).To(BeEmpty())
+			})
+
+			It("ignores any further ooms", func() {
+				notifiers["/cgroups/memory/some-handle"]()
+				Expect(watcher.Events("some-handle")).To(BeEmpty())
+				Expect(fakeCounter.IncCallCount()).To(Equal(1))
+			})
+		})
+	})
+
+	Context("when the container cannot be watched", func() {
+		It("returns the error", func() {
+			fakeNotifier.NotifyReturns(errors.New("no memory cgroup"))
+			fakeNotifier.NotifyStub = nil
+
+			Expect(watcher.Watch(logger, "some-handle")).To(MatchError("no memory cgroup"))
+		})
+	})
+
+	Describe("Start", func() {
+		It("watches every container in the depot", func() {
+			fakeLister.HandlesReturns([]string{"handle-1", "handle-2"}, nil)
+
+			Expect(watcher.Start()).To(Succeed())
+			Expect(notifiers).To(HaveKey("/cgroups/memory/handle-1"))
+			Expect(notifiers).To(HaveKey("/cgroups/memory/handle-2"))
+		})
+
+		It("carries on when a container cannot be watched", func() {
+			fakeLister.HandlesReturns([]string{"handle-1", "handle-2"}, nil)
+			fakeNotifier.NotifyStub = func(cgroupPath string, stop <-chan struct{}, notify func()) error {
+				notifiers[cgroupPath] = notify
+				return errors.New("boom")
+			}
+
+			Expect(watcher.Start()).To(Succeed())
+			Expect(fakeNotifier.NotifyCallCount()).To(Equal(2))
+		})
+	})
+})
@@ -0,0 +1,220 @@
+package rundmc
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/cloudfoundry-incubator/garden"
+	"github.com/cloudfoundry-incubator/goci"
+	"github.com/cloudfoundry-incubator/guardian/gardener"
+	"github.com/cloudfoundry-incubator/guardian/rundmc/runrunc"
+	"github.com/opencontainers/runc/libcontainer/user"
+	"github.com/opencontainers/specs"
+	"github.com/pivotal-golang/lager"
+)
+
+//go:generate counterfeiter . BundleLoader
+//go:generate counterfeiter . UserLookupper
+
+// PeasDir is the directory, inside a container's bundle, which holds the
+// bundles of the container's peas
+const PeasDir = "peas"
+
+type BundleLoader interface {
+	Load(path string) (*goci.Bndl, error)
+}
+
+type UserLookupper interface {
+	Lookup(rootFsPath string, user string) (*user.ExecUser, error)
+}
+
+// peaNamespaces are the namespaces of a container which its peas join; each
+// pea gets a mount namespace of its own
+var peaNamespaces = []struct {
+	namespace specs.Namespace
+	name      string
+}{
+	{goci.NetworkNamespace, "net"},
+	{goci.IPCNamespace, "ipc"},
+	{goci.UTSNamespace, "uts"},
+	{goci.PIDNamespace, "pid"},
+}
+
+// Peas creates peas: runc containers with their own rootfs, which run a
+// single process inside the namespaces of another container, in a child of
+// its cgroup, so that the pea shares the container's limits. A pea's bundle
+// is kept in its container's bundle until the pea's process exits, or until
+// it is reaped if guardian restarts first.
+type Peas struct {
+	BundleLoader BundleLoader
+	Runner       BundleRunner
+	Users        UserLookupper
+	Stater       ContainerStater
+}
+
+func (p *Peas) CreatePea(log lager.Logger, bundlePath string, pid int, pea gardener.PeaSpec, io garden.ProcessIO) (garden.Process, error) {
+	log = log.Session("create-pea", lager.Data{"id": pea.ID, "path": pea.Process.Path})
+
+	log.Info("started")
+	defer log.Info("finished")
+
+	bndl, err := p.BundleLoader.Load(bundlePath)
+	if err != nil {
+		log.Error("load-bundle-failed", err)
+		return nil, fmt.Errorf("load bundle: %s", err)
+	}
+
+	peaBndl, err := p.bundle(bndl, pid, pea)
+	if err != nil {
+		return nil, err
+	}
+
+	peaPath := filepath.Join(bundlePath, PeasDir, pea.ID)
+	if err := os.MkdirAll(peaPath, 0700); err != nil {
+		log.Error("create-bundle-dir-failed", err)
+		return nil, fmt.Errorf("create pea bundle: %s", err)
+	}
+
+	if err := peaBndl.Save(peaPath); err != nil {
+		log.Error("save-bundle-failed", err)
+		os.RemoveAll(peaPath)
+		return nil, fmt.Errorf("create pea bundle: %s", err)
+	}
+
+	process, err := p.Runner.Start(log, peaPath, pea.ID, io)
+	if err != nil {
+		log.Error("start-failed", err)
+		os.RemoveAll(peaPath)
+		return nil, err
+	}
+
+	go p.cleanup(log, peaPath, pea.ID, process)
+
+	return process, nil
+}
+
+// bundle derives a pea's bundle from its container's, so that the pea has
+// the same capabilities, limits and id mappings as the container
+func (p *Peas) bundle(bndl *goci.Bndl, pid int, pea gardener.PeaSpec) (*goci.Bndl, error) {
+	spec := pea.Process
+
+	usr, err := p.Users.Lookup(pea.RootFSPath, spec.User)
+	if err != nil {
+		return nil, err
+	}
+
+	cwd := usr.Home
+	if spec.Dir != "" {
+		cwd = spec.Dir
+	}
+
+	defaultPath := runrunc.DefaultPath
+	if usr.Uid == 0 {
+		defaultPath = runrunc.DefaultRootPath
+	}
+
+	// runc puts containers without a cgroups path in a cgroup named after
+	// them at the root of the hierarchy
+	cgroupsPath := filepath.Join("/", pea.Handle)
+	if bndl.Spec.Linux.CgroupsPath != nil && *bndl.Spec.Linux.CgroupsPath != "" {
+		cgroupsPath = *bndl.Spec.Linux.CgroupsPath
+	}
+	cgroupsPath = filepath.Join(cgroupsPath, pea.ID)
+
+	namespaces := []specs.Namespace{goci.MountNamespace}
+	for _, shared := range peaNamespaces {
+		namespaces = append(namespaces, joinNamespace(shared.namespace, pid, shared.name))
+	}
+
+	if len(bndl.Spec.Linux.UIDMappings) > 0 {
+		namespaces = append(namespaces, joinNamespace(goci.UserNamespace, pid, "user"))
+	}
+
+	// the container's network is already set up, so its hooks must not run
+	// again for the pea
+	peaBndl := bndl.
+		WithPrestartHooks().
+		WithPoststopHooks().
+		WithRootFS(pea.RootFSPath).
+		WithNamespaces(namespaces...).
+		WithProcess(specs.Process{
+			Args: append([]string{spec.Path}, spec.Args...),
+			Env:  withDefaultPath(spec.Env, defaultPath),
+			User: specs.User{
+				UID: uint32(usr.Uid),
+				GID: uint32(usr.Gid),
+			},
+			Cwd:      cwd,
+			Terminal: spec.TTY != nil,
+		})
+	peaBndl.Spec.Linux.CgroupsPath = &cgroupsPath
+
+	return peaBndl, nil
+}
+
+// Reap removes the peas in the container's bundle whose processes have
+// exited, or all of them if all is set, e.g. once the container has been
+// killed, returning their IDs
+func (p *Peas) Reap(log lager.Logger, bundlePath string, all bool) ([]string, error) {
+	peasPath := filepath.Join(bundlePath, PeasDir)
+	entries, err := ioutil.ReadDir(peasPath)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("reap peas: %s", err)
+	}
+
+	var reaped []string
+	for _, entry := range entries {
+		id := entry.Name()
+		if !all {
+			if state, err := p.Stater.State(log, id); err == nil && !state.Stale {
+				continue
+			}
+		}
+
+		if err := p.Runner.Delete(log, id); err != nil {
+			log.Error("delete-pea-failed", err, lager.Data{"id": id})
+		}
+
+		if err := os.RemoveAll(filepath.Join(peasPath, id)); err != nil {
+			return reaped, fmt.Errorf("reap peas: %s", err)
+		}
+
+		reaped = append(reaped, id)
+	}
+
+	return reaped, nil
+}
+
+func (p *Peas) cleanup(log lager.Logger, peaPath, id string, process garden.Process) {
+	process.Wait()
+
+	if err := p.Runner.Delete(log, id); err != nil {
+		log.Error("delete-failed", err)
+	}
+
+	if err := os.RemoveAll(peaPath); err != nil {
+		log.Error("remove-bundle-failed", err)
+	}
+}
+
+func joinNamespace(namespace specs.Namespace, pid int, name string) specs.Namespace {
+	namespace.Path = fmt.Sprintf("/proc/%d/ns/%s", pid, name)
+	return namespace
+}
+
+func withDefaultPath(env []string, defaultPath string) []string {
+	for _, envVar := range env {
+		if strings.HasPrefix(envVar, "PATH=") {
+			return env
+		}
+	}
+
+	return append(env, defaultPath)
+}
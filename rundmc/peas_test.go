@@ -0,0 +1,270 @@
+package rundmc_test
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/cloudfoundry-incubator/garden"
+	gardenfakes "github.com/cloudfoundry-incubator/garden/fakes"
+	"github.com/cloudfoundry-incubator/goci"
+	"github.com/cloudfoundry-incubator/guardian/gardener"
+	"github.com/cloudfoundry-incubator/guardian/rundmc"
+	"github.com/cloudfoundry-incubator/guardian/rundmc/fakes"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/opencontainers/runc/libcontainer/user"
+	"github.com/opencontainers/specs"
+	"github.com/pivotal-golang/lager"
+	"github.com/pivotal-golang/lager/lagertest"
+)
+
+var _ = Describe("Peas", func() {
+	var (
+		logger       *lagertest.TestLogger
+		bundlePath   string
+		fakeLoader   *fakes.FakeBundleLoader
+		fakeRunner   *fakes.FakeBundleRunner
+		fakeUsers    *fakes.FakeUserLookupper
+		fakeStater   *fakes.FakeContainerStater
+		fakeProcess  *gardenfakes.FakeProcess
+		processExits chan int
+		peas         *rundmc.Peas
+		pea          gardener.PeaSpec
+	)
+
+	BeforeEach(func() {
+		var err error
+		bundlePath, err = ioutil.TempDir("", "pea-bundles")
+		Expect(err).NotTo(HaveOccurred())
+
+		logger = lagertest.NewTestLogger("test")
+
+		fakeLoader = new(fakes.FakeBundleLoader)
+		fakeLoader.LoadReturns(goci.Bundle().
+			WithNamespaces(goci.NetworkNamespace, goci.MountNamespace).
+			WithPrestartHooks(specs.Hook{Path: "/path/to/kawasaki"}).
+			WithRootFS("/path/to/container/rootfs"), nil)
+
+		fakeUsers = new(fakes.FakeUserLookupper)
+		fakeUsers.LookupReturns(&user.ExecUser{Uid: 1000, Gid: 1001, Home: "/home/alice"}, nil)
+
+		processExits = make(chan int)
+		fakeProcess = new(gardenfakes.FakeProcess)
+		fakeProcess.WaitStub = func() (int, error) {
+			return <-processExits, nil
+		}
+
+		fakeRunner = new(fakes.FakeBundleRunner)
+		fakeRunner.StartReturns(fakeProcess, nil)
+
+		fakeStater = new(fakes.FakeContainerStater)
+
+		peas = &rundmc.Peas{
+			BundleLoader: fakeLoader,
+			Runner:       fakeRunner,
+			Users:        fakeUsers,
+			Stater:       fakeStater,
+		}
+
+		pea = gardener.PeaSpec{
+			ID:         "some-handle-pea-1",
+			Handle:     "some-handle",
+			RootFSPath: "/path/to/pea/rootfs",
+			Process: garden.ProcessSpec{
+				Path: "sidecar",
+				Args: []string{"--serve"},
+				User: "alice",
+				Env:  []string{"IMAGE=env"},
+			},
+		}
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(bundlePath)
+	})
+
+	peaPath := func() string {
+		return filepath.Join(bundlePath, rundmc.PeasDir, "some-handle-pea-1")
+	}
+
+	It("starts the pea from a bundle inside the container's bundle", func() {
+		process, err := peas.CreatePea(logger, bundlePath, 42, pea, garden.ProcessIO{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(process).To(Equal(fakeProcess))
+
+		Expect(fakeLoader.LoadArgsForCall(0)).To(Equal(bundlePath))
+		Expect(fakeRunner.StartCallCount()).To(Equal(1))
+		_, path, id, _ := fakeRunner.StartArgsForCall(0)
+		Expect(path).To(Equal(peaPath()))
+		Expect(id).To(Equal("some-handle-pea-1"))
+	})
+
+	Describe("the pea's bundle", func() {
+		var bndl *goci.Bndl
+
+		JustBeforeEach(func() {
+			_, err := peas.CreatePea(logger, bundlePath, 42, pea, garden.ProcessIO{})
+			Expect(err).NotTo(HaveOccurred())
+
+			bndl, err = (&goci.BndlLoader{}).Load(peaPath())
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("uses the pea's rootfs", func() {
+			Expect(bndl.Spec.Spec.Root.Path).To(Equal("/path/to/pea/rootfs"))
+		})
+
+		It("runs the process as the user from the pea's rootfs", func() {
+			rootfsPath, username := fakeUsers.LookupArgsForCall(0)
+			Expect(rootfsPath).To(Equal("/path/to/pea/rootfs"))
+			Expect(username).To(Equal("alice"))
+
+			Expect(bndl.Spec.Spec.Process.Args).To(Equal([]string{"sidecar", "--serve"}))
+			Expect(bndl.Spec.Spec.Process.User).To(Equal(specs.User{UID: 1000, GID: 1001}))
+			Expect(bndl.Spec.Spec.Process.Cwd).To(Equal("/home/alice"))
+			Expect(bndl.Spec.Spec.Process.Env).To(ConsistOf("IMAGE=env", HavePrefix("PATH=")))
+		})
+
+		It("joins the container's namespaces, other than its mount namespace", func() {
+			Expect(bndl.Spec.Linux.Namespaces).To(ConsistOf(
+				goci.MountNamespace,
+				specs.Namespace{Type: goci.NetworkNamespace.Type, Path: "/proc/42/ns/net"},
+				specs.Namespace{Type: goci.IPCNamespace.Type, Path: "/proc/42/ns/ipc"},
+				specs.Namespace{Type: goci.UTSNamespace.Type, Path: "/proc/42/ns/uts"},
+				specs.Namespace{Type: goci.PIDNamespace.Type, Path: "/proc/42/ns/pid"},
+			))
+		})
+
+		It("does not run the container's hooks again", func() {
+			Expect(bndl.PrestartHooks()).To(BeEmpty())
+		})
+
+		It("runs the pea in a child of the container's cgroup", func() {
+			Expect(*bndl.Spec.Linux.CgroupsPath).To(Equal("/some-handle/some-handle-pea-1"))
+		})
+
+		Context("when the container has a cgroups path", func() {
+			BeforeEach(func() {
+				bndl := goci.Bundle().WithRootFS("/path/to/container/rootfs")
+				cgroupsPath := "/garden/some-handle"
+				bndl.Spec.Linux.CgroupsPath = &cgroupsPath
+				fakeLoader.LoadReturns(bndl, nil)
+			})
+
+			It("runs the pea in a child of it", func() {
+				Expect(*bndl.Spec.Linux.CgroupsPath).To(Equal("/garden/some-handle/some-handle-pea-1"))
+			})
+		})
+
+		Context("when the process has a working directory", func() {
+			BeforeEach(func() {
+				pea.Process.Dir = "/work"
+			})
+
+			It("runs the process in it", func() {
+				Expect(bndl.Spec.Spec.Process.Cwd).To(Equal("/work"))
+			})
+		})
+
+		Context("when the container has a user namespace", func() {
+			BeforeEach(func() {
+				fakeLoader.LoadReturns(goci.Bundle().
+					WithNamespace(goci.UserNamespace).
+					WithUIDMappings(specs.IDMapping{HostID: 100000, ContainerID: 0, Size: 65536}).
+					WithGIDMappings(specs.IDMapping{HostID: 100000, ContainerID: 0, Size: 65536}), nil)
+			})
+
+			It("joins it too", func() {
+				Expect(bndl.Spec.Linux.Namespaces).To(ContainElement(
+					specs.Namespace{Type: goci.UserNamespace.Type, Path: "/proc/42/ns/user"},
+				))
+			})
+		})
+	})
+
+	Context("when the process exits", func() {
+		It("deletes the pea and its bundle", func() {
+			_, err := peas.CreatePea(logger, bundlePath, 42, pea, garden.ProcessIO{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(peaPath()).To(BeADirectory())
+
+			processExits <- 0
+
+			Eventually(fakeRunner.DeleteCallCount).Should(Equal(1))
+			_, id := fakeRunner.DeleteArgsForCall(0)
+			Expect(id).To(Equal("some-handle-pea-1"))
+			Eventually(peaPath).ShouldNot(BeADirectory())
+		})
+	})
+
+	Context("when the user cannot be found", func() {
+		It("returns an error without starting the pea", func() {
+			fakeUsers.LookupReturns(nil, errors.New("no such user"))
+
+			_, err := peas.CreatePea(logger, bundlePath, 42, pea, garden.ProcessIO{})
+			Expect(err).To(MatchError("no such user"))
+			Expect(fakeRunner.StartCallCount()).To(Equal(0))
+		})
+	})
+
+	Context("when starting the pea fails", func() {
+		It("removes its bundle and returns the error", func() {
+			fakeRunner.StartReturns(nil, errors.New("runc exploded"))
+
+			_, err := peas.CreatePea(logger, bundlePath, 42, pea, garden.ProcessIO{})
+			Expect(err).To(MatchError("runc exploded"))
+			Expect(peaPath()).NotTo(BeADirectory())
+		})
+	})
+
+	Describe("Reap", func() {
+		BeforeEach(func() {
+			for _, id := range []string{"some-handle-pea-1", "some-handle-pea-2", "some-handle-pea-3"} {
+				Expect(os.MkdirAll(filepath.Join(bundlePath, rundmc.PeasDir, id), 0700)).To(Succeed())
+			}
+
+			fakeStater.StateStub = func(_ lager.Logger, id string) (rundmc.State, error) {
+				switch id {
+				case "some-handle-pea-1":
+					return rundmc.State{Pid: 1}, nil
+				case "some-handle-pea-2":
+					return rundmc.State{Pid: 2, Stale: true}, nil
+				default:
+					return rundmc.State{}, errors.New("no such container")
+				}
+			}
+		})
+
+		It("removes the peas whose processes have exited", func() {
+			reaped, err := peas.Reap(logger, bundlePath, false)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(reaped).To(ConsistOf("some-handle-pea-2", "some-handle-pea-3"))
+
+			Expect(fakeRunner.DeleteCallCount()).To(Equal(2))
+			Expect(filepath.Join(bundlePath, rundmc.PeasDir, "some-handle-pea-1")).To(BeADirectory())
+			Expect(filepath.Join(bundlePath, rundmc.PeasDir, "some-handle-pea-2")).NotTo(BeADirectory())
+			Expect(filepath.Join(bundlePath, rundmc.PeasDir, "some-handle-pea-3")).NotTo(BeADirectory())
+		})
+
+		Context("when all the peas should be reaped", func() {
+			It("removes running peas too", func() {
+				reaped, err := peas.Reap(logger, bundlePath, true)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(reaped).To(ConsistOf("some-handle-pea-1", "some-handle-pea-2", "some-handle-pea-3"))
+				Expect(fakeStater.StateCallCount()).To(Equal(0))
+			})
+		})
+
+		Context("when the container has no peas", func() {
+			It("reaps nothing", func() {
+				Expect(os.RemoveAll(filepath.Join(bundlePath, rundmc.PeasDir))).To(Succeed())
+
+				reaped, err := peas.Reap(logger, bundlePath, false)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(reaped).To(BeEmpty())
+			})
+		})
+	})
+})
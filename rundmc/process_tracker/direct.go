@@ -0,0 +1,179 @@
+package process_tracker
+
+import (
+	"errors"
+	"os/exec"
+	"sync"
+	"syscall"
+
+	"github.com/cloudfoundry-incubator/garden"
+	"github.com/cloudfoundry-incubator/guardian/rundmc/process_tracker/writer"
+	"github.com/cloudfoundry/gunk/command_runner"
+)
+
+// ErrTTYNotSupported is returned when a process needs a TTY, which only the
+// iodaemon-backed tracker can provide
+var ErrTTYNotSupported = errors.New("process_tracker: ttys are not supported without iodaemon")
+
+type directTracker struct {
+	runner command_runner.CommandRunner
+
+	processes      map[string]*directProcess
+	processesMutex *sync.RWMutex
+}
+
+// NewDirect returns a ProcessTracker which runs processes as its own
+// children rather than under iodaemon, for platforms (e.g. Windows) where
+// iodaemon cannot run. Processes do not outlive the tracker, so they
+// cannot be restored after a restart, and cannot have a TTY.
+func NewDirect(runner command_runner.CommandRunner) ProcessTracker {
+	return &directTracker{
+		runner: runner,
+
+		processes:      make(map[string]*directProcess),
+		processesMutex: new(sync.RWMutex),
+	}
+}
+
+func (t *directTracker) Run(processID string, cmd *exec.Cmd, processIO garden.ProcessIO, tty *garden.TTYSpec) (garden.Process, error) {
+	if tty != nil {
+		return nil, ErrTTYNotSupported
+	}
+
+	process := &directProcess{
+		id:     processID,
+		cmd:    cmd,
+		exited: make(chan struct{}),
+		stdin:  writer.NewFanIn(),
+		stdout: writer.NewFanOut(),
+		stderr: writer.NewFanOut(),
+	}
+
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+
+	process.stdin.AddSink(stdin)
+	cmd.Stdout = process.stdout
+	cmd.Stderr = process.stderr
+	process.Attach(processIO)
+
+	if err := t.runner.Start(cmd); err != nil {
+		return nil, err
+	}
+
+	t.processesMutex.Lock()
+	t.processes[processID] = process
+	t.processesMutex.Unlock()
+
+	go func() {
+		process.completed(t.runner.Wait(cmd))
+
+		t.processesMutex.Lock()
+		delete(t.processes, processID)
+		t.processesMutex.Unlock()
+	}()
+
+	return process, nil
+}
+
+func (t *directTracker) Attach(processID string, processIO garden.ProcessIO) (garden.Process, error) {
+	t.processesMutex.RLock()
+	process, ok := t.processes[processID]
+	t.processesMutex.RUnlock()
+
+	if !ok {
+		return nil, UnknownProcessError{processID}
+	}
+
+	process.Attach(processIO)
+	return process, nil
+}
+
+// Restore does nothing: processes run by the direct tracker die with it
+func (t *directTracker) Restore(processID string) {}
+
+func (t *directTracker) ActiveProcesses() []garden.Process {
+	t.processesMutex.RLock()
+	defer t.processesMutex.RUnlock()
+
+	processes := make([]garden.Process, 0, len(t.processes))
+	for _, process := range t.processes {
+		processes = append(processes, process)
+	}
+
+	return processes
+}
+
+type directProcess struct {
+	id  string
+	cmd *exec.Cmd
+
+	exited     chan struct{}
+	exitStatus int
+	exitErr    error
+
+	stdin  writer.FanIn
+	stdout writer.FanOut
+	stderr writer.FanOut
+}
+
+func (p *directProcess) ID() string {
+	return p.id
+}
+
+func (p *directProcess) Wait() (int, error) {
+	<-p.exited
+	return p.exitStatus, p.exitErr
+}
+
+func (p *directProcess) SetTTY(garden.TTYSpec) error {
+	return ErrTTYNotSupported
+}
+
+func (p *directProcess) Signal(signal garden.Signal) error {
+	if signal == garden.SignalKill {
+		return p.cmd.Process.Kill()
+	}
+
+	return p.cmd.Process.Signal(syscall.SIGTERM)
+}
+
+func (p *directProcess) Attach(processIO garden.ProcessIO) {
+	if processIO.Stdin != nil {
+		p.stdin.AddSource(processIO.Stdin)
+	}
+
+	if processIO.Stdout != nil {
+		p.stdout.AddSink(processIO.Stdout)
+	}
+
+	if processIO.Stderr != nil {
+		p.stderr.AddSink(processIO.Stderr)
+	}
+}
+
+func (p *directProcess) completed(err error) {
+	// don't leak stdin pipe
+	defer p.stdin.Close()
+
+	if err != nil {
+		p.exitStatus, p.exitErr = exitStatus(err)
+	}
+
+	close(p.exited)
+}
+
+func exitStatus(err error) (int, error) {
+	exitErr, ok := err.(*exec.ExitError)
+	if !ok {
+		return -1, err
+	}
+
+	if status, ok := exitErr.Sys().(syscall.WaitStatus); ok {
+		return status.ExitStatus(), nil
+	}
+
+	return -1, err
+}
@@ -0,0 +1,85 @@
+package process_tracker_test
+
+import (
+	"bytes"
+	"os/exec"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+
+	"github.com/cloudfoundry-incubator/garden"
+	"github.com/cloudfoundry-incubator/guardian/rundmc/process_tracker"
+	"github.com/cloudfoundry/gunk/command_runner/linux_command_runner"
+)
+
+var _ = Describe("Direct process tracker", func() {
+	var processTracker process_tracker.ProcessTracker
+
+	BeforeEach(func() {
+		processTracker = process_tracker.NewDirect(linux_command_runner.New())
+	})
+
+	It("runs the process and returns its exit code", func() {
+		process, err := processTracker.Run("555", exec.Command("bash", "-c", "exit 42"), garden.ProcessIO{}, nil)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(process.Wait()).To(Equal(42))
+	})
+
+	It("streams the process's stdin, stdout and stderr", func() {
+		stdout := gbytes.NewBuffer()
+		stderr := gbytes.NewBuffer()
+
+		process, err := processTracker.Run("555", exec.Command("bash", "-c", "cat; echo error >&2"), garden.ProcessIO{
+			Stdin:  bytes.NewBufferString("hello\n"),
+			Stdout: stdout,
+			Stderr: stderr,
+		}, nil)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(process.Wait()).To(Equal(0))
+		Expect(stdout).To(gbytes.Say("hello"))
+		Expect(stderr).To(gbytes.Say("error"))
+	})
+
+	It("can attach to a running process", func() {
+		process, err := processTracker.Run("555", exec.Command("bash", "-c", "read; echo attached"), garden.ProcessIO{}, nil)
+		Expect(err).NotTo(HaveOccurred())
+
+		stdout := gbytes.NewBuffer()
+		attached, err := processTracker.Attach("555", garden.ProcessIO{
+			Stdin:  bytes.NewBufferString("go\n"),
+			Stdout: stdout,
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(attached.ID()).To(Equal(process.ID()))
+
+		Expect(attached.Wait()).To(Equal(0))
+		Expect(stdout).To(gbytes.Say("attached"))
+	})
+
+	It("forgets processes once they have exited", func() {
+		process, err := processTracker.Run("555", exec.Command("true"), garden.ProcessIO{}, nil)
+		Expect(err).NotTo(HaveOccurred())
+		process.Wait()
+
+		Eventually(processTracker.ActiveProcesses).Should(BeEmpty())
+
+		_, err = processTracker.Attach("555", garden.ProcessIO{})
+		Expect(err).To(Equal(process_tracker.UnknownProcessError{ProcessID: "555"}))
+	})
+
+	It("kills processes", func() {
+		process, err := processTracker.Run("555", exec.Command("sleep", "100"), garden.ProcessIO{}, nil)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(process.Signal(garden.SignalKill)).To(Succeed())
+		Expect(process.Wait()).To(Equal(-1))
+	})
+
+	It("does not support ttys", func() {
+		_, err := processTracker.Run("555", exec.Command("true"), garden.ProcessIO{}, &garden.TTYSpec{})
+		Expect(err).To(Equal(process_tracker.ErrTTYNotSupported))
+	})
+})
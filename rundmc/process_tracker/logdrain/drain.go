@@ -0,0 +1,279 @@
+// Package logdrain forwards the output of containers' processes to a log
+// aggregator, i.e. syslog or fluentd, in addition to streaming it to the
+// clients attached to the processes.
+package logdrain
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pivotal-golang/clock"
+	"github.com/pivotal-golang/lager"
+)
+
+const (
+	// QueueSize is the number of lines which may be waiting to be sent
+	// before further lines are dropped
+	QueueSize = 1024
+
+	// MaxLineBytes is the longest line which is sent as one message; longer
+	// lines are split
+	MaxLineBytes = 8 * 1024
+
+	// RedialInterval is the least time between attempts to connect to the
+	// aggregator
+	RedialInterval = time.Second
+
+	// WriteTimeout is the longest a message may take to be sent before the
+	// aggregator is taken to have stalled, so that it cannot hold up the
+	// lines queued behind it
+	WriteTimeout = 5 * time.Second
+)
+
+// DropCounter counts the lines which were dropped because the aggregator
+// could not keep up or could not be reached, e.g. for a metric
+type DropCounter interface {
+	Inc()
+}
+
+// Line is one line of a process's output
+type Line struct {
+	Time      time.Time
+	Handle    string
+	ProcessID string
+	Stream    string
+	Text      []byte
+}
+
+// Encoder encodes a line as one message for the aggregator
+type Encoder interface {
+	Encode(line Line) ([]byte, error)
+}
+
+// Drain sends lines of output to an aggregator in the background. Lines are
+// never allowed to block the processes' output: when the queue is full, or
+// the aggregator cannot be reached, they are dropped and counted.
+type Drain struct {
+	network string
+	addr    string
+	encoder Encoder
+
+	clock   clock.Clock
+	dropped DropCounter
+	logger  lager.Logger
+
+	queue chan Line
+	conn  net.Conn
+
+	lastDial time.Time
+}
+
+// New parses the URL of a drain, one of
+//
+//	syslog://host:port           RFC 5424 syslog over TCP
+//	syslog+udp://host:port       RFC 5424 syslog over UDP
+//	fluentd://host:port[/tag]    fluentd's forward protocol; tag defaults to "garden"
+//
+// and starts sending lines to it. dropped is optional.
+func New(logger lager.Logger, drainURL string, dropped DropCounter) (*Drain, error) {
+	parsed, err := url.Parse(drainURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid log drain '%s': %s", drainURL, err)
+	}
+
+	if parsed.Host == "" {
+		return nil, fmt.Errorf("invalid log drain '%s': no host", drainURL)
+	}
+
+	var (
+		network string
+		encoder Encoder
+	)
+
+	switch parsed.Scheme {
+	case "syslog":
+		network, encoder = "tcp", &SyslogEncoder{Hostname: hostname(), Framed: true}
+	case "syslog+udp":
+		network, encoder = "udp", &SyslogEncoder{Hostname: hostname()}
+	case "fluentd":
+		tag := strings.Trim(parsed.Path, "/")
+		if tag == "" {
+			tag = "garden"
+		}
+
+		network, encoder = "tcp", &FluentdEncoder{Tag: tag}
+	default:
+		return nil, fmt.Errorf("invalid log drain '%s': unsupported scheme '%s'", drainURL, parsed.Scheme)
+	}
+
+	drain := NewDrain(logger, network, parsed.Host, encoder, clock.NewClock(), dropped)
+	drain.Start()
+
+	return drain, nil
+}
+
+// NewDrain returns a Drain which sends lines encoded by encoder to addr. It
+// does not send anything until it is started.
+func NewDrain(logger lager.Logger, network, addr string, encoder Encoder, clock clock.Clock, dropped DropCounter) *Drain {
+	return &Drain{
+		network: network,
+		addr:    addr,
+		encoder: encoder,
+
+		clock:   clock,
+		dropped: dropped,
+		logger:  logger.Session("log-drain", lager.Data{"network": network, "addr": addr}),
+
+		queue: make(chan Line, QueueSize),
+	}
+}
+
+// Start sends the queued lines in the background
+func (d *Drain) Start() {
+	go func() {
+		for line := range d.queue {
+			d.send(line)
+		}
+	}()
+}
+
+// Stream returns a writer which splits a stream of a process's output, e.g.
+// "stdout", in to lines and queues them to be sent. Closing it queues what
+// remains of the last line; output written after it is closed, e.g. which
+// was still being copied when the process exited, is queued as it is
+// written, as nothing will flush it later.
+func (d *Drain) Stream(handle, processID, stream string) io.WriteCloser {
+	return &lineWriter{drain: d, handle: handle, processID: processID, stream: stream}
+}
+
+func (d *Drain) enqueue(line Line) {
+	select {
+	case d.queue <- line:
+	default:
+		d.drop()
+	}
+}
+
+func (d *Drain) send(line Line) {
+	message, err := d.encoder.Encode(line)
+	if err != nil {
+		d.logger.Error("encode-failed", err)
+		d.drop()
+		return
+	}
+
+	if d.conn == nil && !d.dial() {
+		d.drop()
+		return
+	}
+
+	// deadlines are in real time, whatever the drain's clock
+	if err := d.conn.SetWriteDeadline(time.Now().Add(WriteTimeout)); err != nil {
+		d.logger.Error("set-write-deadline-failed", err)
+	}
+
+	if _, err := d.conn.Write(message); err != nil {
+		d.logger.Error("write-failed", err)
+		d.conn.Close()
+		d.conn = nil
+		d.drop()
+	}
+}
+
+// dial connects to the aggregator, at most once every RedialInterval so
+// that an aggregator which is down is not hammered
+func (d *Drain) dial() bool {
+	now := d.clock.Now()
+	if !d.lastDial.IsZero() && now.Sub(d.lastDial) < RedialInterval {
+		return false
+	}
+	d.lastDial = now
+
+	conn, err := net.DialTimeout(d.network, d.addr, RedialInterval)
+	if err != nil {
+		d.logger.Error("dial-failed", err)
+		return false
+	}
+
+	d.conn = conn
+	return true
+}
+
+func (d *Drain) drop() {
+	if d.dropped != nil {
+		d.dropped.Inc()
+	}
+}
+
+type lineWriter struct {
+	drain     *Drain
+	handle    string
+	processID string
+	stream    string
+
+	mu      sync.Mutex
+	partial []byte
+	closed  bool
+}
+
+// Write never fails, so that the writer is never dropped as a sink
+func (w *lineWriter) Write(data []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.partial = append(w.partial, data...)
+	for {
+		if i := bytes.IndexByte(w.partial, '\n'); i >= 0 && i <= MaxLineBytes {
+			w.emit(w.partial[:i])
+			w.partial = w.partial[i+1:]
+		} else if len(w.partial) > MaxLineBytes {
+			w.emit(w.partial[:MaxLineBytes])
+			w.partial = w.partial[MaxLineBytes:]
+		} else {
+			break
+		}
+	}
+
+	if w.closed {
+		w.flush()
+		return len(data), nil
+	}
+
+	// don't hold on to the lines which were emitted
+	w.partial = append([]byte{}, w.partial...)
+
+	return len(data), nil
+}
+
+func (w *lineWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.closed = true
+	w.flush()
+
+	return nil
+}
+
+func (w *lineWriter) flush() {
+	if len(w.partial) > 0 {
+		w.emit(w.partial)
+		w.partial = nil
+	}
+}
+
+func (w *lineWriter) emit(text []byte) {
+	w.drain.enqueue(Line{
+		Time:      w.drain.clock.Now(),
+		Handle:    w.handle,
+		ProcessID: w.processID,
+		Stream:    w.stream,
+		Text:      append([]byte{}, text...),
+	})
+}
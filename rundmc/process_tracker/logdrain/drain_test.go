@@ -0,0 +1,148 @@
+package logdrain_test
+
+import (
+	"bufio"
+	"net"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/cloudfoundry-incubator/guardian/rundmc/process_tracker/logdrain"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/pivotal-golang/clock/fakeclock"
+	"github.com/pivotal-golang/lager/lagertest"
+)
+
+// lineEncoder encodes each line as "handle process stream: text\n"
+type lineEncoder struct{}
+
+func (lineEncoder) Encode(line logdrain.Line) ([]byte, error) {
+	return []byte(line.Handle + " " + line.ProcessID + " " + line.Stream + ": " + string(line.Text) + "\n"), nil
+}
+
+type dropCounter struct {
+	count int64
+}
+
+func (c *dropCounter) Inc() {
+	atomic.AddInt64(&c.count, 1)
+}
+
+func (c *dropCounter) Count() int64 {
+	return atomic.LoadInt64(&c.count)
+}
+
+var _ = Describe("Drain", func() {
+	var (
+		listener  net.Listener
+		received  chan string
+		fakeClock *fakeclock.FakeClock
+		dropped   *dropCounter
+		drain     *logdrain.Drain
+	)
+
+	BeforeEach(func() {
+		var err error
+		listener, err = net.Listen("tcp", "127.0.0.1:0")
+		Expect(err).NotTo(HaveOccurred())
+
+		received = make(chan string, 100)
+		go func() {
+			defer GinkgoRecover()
+
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+
+			scanner := bufio.NewScanner(conn)
+			for scanner.Scan() {
+				received <- scanner.Text()
+			}
+		}()
+
+		fakeClock = fakeclock.NewFakeClock(time.Now())
+		dropped = new(dropCounter)
+		drain = logdrain.NewDrain(lagertest.NewTestLogger("test"), "tcp", listener.Addr().String(), lineEncoder{}, fakeClock, dropped)
+		drain.Start()
+	})
+
+	AfterEach(func() {
+		listener.Close()
+	})
+
+	It("sends each line of each stream, tagged with the handle, process and stream", func() {
+		stdout := drain.Stream("some-handle", "some-process", "stdout")
+		stderr := drain.Stream("some-handle", "some-process", "stderr")
+
+		stdout.Write([]byte("hello\nwor"))
+		stderr.Write([]byte("oops\n"))
+		stdout.Write([]byte("ld\n"))
+
+		Eventually(received).Should(Receive(Equal("some-handle some-process stdout: hello")))
+		Eventually(received).Should(Receive(Equal("some-handle some-process stderr: oops")))
+		Eventually(received).Should(Receive(Equal("some-handle some-process stdout: world")))
+	})
+
+	It("sends the rest of the last line when the stream is closed", func() {
+		stdout := drain.Stream("some-handle", "some-process", "stdout")
+		stdout.Write([]byte("no newline"))
+		Consistently(received).ShouldNot(Receive())
+
+		Expect(stdout.Close()).To(Succeed())
+		Eventually(received).Should(Receive(Equal("some-handle some-process stdout: no newline")))
+	})
+
+	It("still sends output written after the stream is closed, including the rest of its last line", func() {
+		stdout := drain.Stream("some-handle", "some-process", "stdout")
+		Expect(stdout.Close()).To(Succeed())
+
+		stdout.Write([]byte("late\nno newline"))
+		Eventually(received).Should(Receive(Equal("some-handle some-process stdout: late")))
+		Eventually(received).Should(Receive(Equal("some-handle some-process stdout: no newline")))
+	})
+
+	It("splits lines longer than MaxLineBytes", func() {
+		stdout := drain.Stream("some-handle", "some-process", "stdout")
+		stdout.Write([]byte(strings.Repeat("x", logdrain.MaxLineBytes+1) + "\n"))
+
+		Eventually(received).Should(Receive(Equal("some-handle some-process stdout: " + strings.Repeat("x", logdrain.MaxLineBytes))))
+		Eventually(received).Should(Receive(Equal("some-handle some-process stdout: x")))
+	})
+
+	Context("when the aggregator cannot be reached", func() {
+		BeforeEach(func() {
+			listener.Close()
+		})
+
+		It("drops the lines without failing the writes, and counts them", func() {
+			stdout := drain.Stream("some-handle", "some-process", "stdout")
+
+			n, err := stdout.Write([]byte("one\ntwo\n"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(n).To(Equal(8))
+
+			Eventually(dropped.Count).Should(BeEquivalentTo(2))
+		})
+	})
+
+	Describe("New", func() {
+		It("accepts syslog and fluentd URLs", func() {
+			for _, url := range []string{"syslog://127.0.0.1:514", "syslog+udp://127.0.0.1:514", "fluentd://127.0.0.1:24224/some.tag"} {
+				_, err := logdrain.New(lagertest.NewTestLogger("test"), url, nil)
+				Expect(err).NotTo(HaveOccurred(), url)
+			}
+		})
+
+		It("rejects other schemes", func() {
+			_, err := logdrain.New(lagertest.NewTestLogger("test"), "http://127.0.0.1:80", nil)
+			Expect(err).To(MatchError("invalid log drain 'http://127.0.0.1:80': unsupported scheme 'http'"))
+		})
+
+		It("requires a host", func() {
+			_, err := logdrain.New(lagertest.NewTestLogger("test"), "syslog:///var/log", nil)
+			Expect(err).To(MatchError("invalid log drain 'syslog:///var/log': no host"))
+		})
+	})
+})
@@ -0,0 +1,76 @@
+package logdrain_test
+
+import (
+	"time"
+
+	"github.com/cloudfoundry-incubator/guardian/rundmc/process_tracker/logdrain"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Encoders", func() {
+	var line logdrain.Line
+
+	BeforeEach(func() {
+		line = logdrain.Line{
+			Time:      time.Date(2016, 1, 2, 3, 4, 5, 0, time.UTC),
+			Handle:    "some-handle",
+			ProcessID: "some-process",
+			Stream:    "stdout",
+			Text:      []byte("hello"),
+		}
+	})
+
+	Describe("SyslogEncoder", func() {
+		It("encodes the line as an RFC 5424 message tagged with the handle, process and stream", func() {
+			message, err := (&logdrain.SyslogEncoder{Hostname: "some-cell"}).Encode(line)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(message)).To(Equal("<14>1 2016-01-02T03:04:05Z some-cell some-handle some-process stdout - hello"))
+		})
+
+		It("gives stderr the severity err", func() {
+			line.Stream = "stderr"
+			message, err := (&logdrain.SyslogEncoder{Hostname: "some-cell"}).Encode(line)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(message)).To(HavePrefix("<11>1 "))
+		})
+
+		It("prefixes framed messages with their length", func() {
+			message, err := (&logdrain.SyslogEncoder{Hostname: "some-cell", Framed: true}).Encode(line)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(message)).To(Equal("76 <14>1 2016-01-02T03:04:05Z some-cell some-handle some-process stdout - hello"))
+		})
+
+		It("uses the nil value for empty fields", func() {
+			line.Handle = ""
+			message, err := (&logdrain.SyslogEncoder{}).Encode(line)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(message)).To(Equal("<14>1 2016-01-02T03:04:05Z - - some-process stdout - hello"))
+		})
+	})
+
+	Describe("FluentdEncoder", func() {
+		It("encodes the line as a msgpack [tag, time, record] message", func() {
+			message, err := (&logdrain.FluentdEncoder{Tag: "garden"}).Encode(line)
+			Expect(err).NotTo(HaveOccurred())
+
+			expected := []byte{0x93, 0xa6}
+			expected = append(expected, "garden"...)
+			expected = append(expected, 0xce, 0x56, 0x87, 0x3e, 0x25) // 1451703845
+			expected = append(expected, 0x84)
+			for _, s := range []string{"handle", "some-handle", "process_id", "some-process", "stream", "stdout", "log", "hello"} {
+				expected = append(expected, 0xa0|byte(len(s)))
+				expected = append(expected, s...)
+			}
+
+			Expect(message).To(Equal(expected))
+		})
+
+		It("encodes long strings with their length", func() {
+			line.Text = make([]byte, 300)
+			message, err := (&logdrain.FluentdEncoder{Tag: "garden"}).Encode(line)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(message[len(message)-303 : len(message)-300]).To(Equal([]byte{0xda, 0x01, 0x2c}))
+		})
+	})
+})
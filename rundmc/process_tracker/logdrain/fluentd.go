@@ -0,0 +1,63 @@
+package logdrain
+
+import (
+	"bytes"
+	"encoding/binary"
+)
+
+// FluentdEncoder encodes lines as messages of fluentd's forward protocol,
+// i.e. msgpack arrays of [tag, time, record], whose record has the handle,
+// process id, stream and the line in "log"
+type FluentdEncoder struct {
+	Tag string
+}
+
+func (e *FluentdEncoder) Encode(line Line) ([]byte, error) {
+	buf := new(bytes.Buffer)
+
+	buf.WriteByte(0x93) // fixarray of 3
+	writeMsgpackString(buf, e.Tag)
+	writeMsgpackUint(buf, uint64(line.Time.Unix()))
+
+	buf.WriteByte(0x84) // fixmap of 4
+	writeMsgpackString(buf, "handle")
+	writeMsgpackString(buf, line.Handle)
+	writeMsgpackString(buf, "process_id")
+	writeMsgpackString(buf, line.ProcessID)
+	writeMsgpackString(buf, "stream")
+	writeMsgpackString(buf, line.Stream)
+	writeMsgpackString(buf, "log")
+	writeMsgpackString(buf, string(line.Text))
+
+	return buf.Bytes(), nil
+}
+
+func writeMsgpackString(buf *bytes.Buffer, s string) {
+	switch n := len(s); {
+	case n < 32:
+		buf.WriteByte(0xa0 | byte(n))
+	case n <= 0xff:
+		buf.Write([]byte{0xd9, byte(n)})
+	case n <= 0xffff:
+		buf.WriteByte(0xda)
+		binary.Write(buf, binary.BigEndian, uint16(n))
+	default:
+		buf.WriteByte(0xdb)
+		binary.Write(buf, binary.BigEndian, uint32(n))
+	}
+
+	buf.WriteString(s)
+}
+
+func writeMsgpackUint(buf *bytes.Buffer, n uint64) {
+	switch {
+	case n < 0x80:
+		buf.WriteByte(byte(n))
+	case n <= 0xffffffff:
+		buf.WriteByte(0xce)
+		binary.Write(buf, binary.BigEndian, uint32(n))
+	default:
+		buf.WriteByte(0xcf)
+		binary.Write(buf, binary.BigEndian, n)
+	}
+}
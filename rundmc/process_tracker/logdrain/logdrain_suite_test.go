@@ -0,0 +1,13 @@
+package logdrain_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"testing"
+)
+
+func TestLogdrain(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Logdrain Suite")
+}
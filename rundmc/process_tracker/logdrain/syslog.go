@@ -0,0 +1,72 @@
+package logdrain
+
+import (
+	"fmt"
+	"os"
+	"time"
+)
+
+const (
+	// the messages are from the "user" facility, at severity "info" for
+	// stdout and "err" for stderr
+	syslogPriorityStdout = 1*8 + 6
+	syslogPriorityStderr = 1*8 + 3
+
+	maxAppNameLength = 48
+	maxProcIDLength  = 128
+)
+
+// SyslogEncoder encodes lines as RFC 5424 syslog messages, whose app name is
+// the container's handle, whose proc id is the process id and whose message
+// id is the stream. Framed messages are prefixed with their length, as RFC
+// 6587 requires for syslog over TCP.
+type SyslogEncoder struct {
+	Hostname string
+	Framed   bool
+}
+
+func (e *SyslogEncoder) Encode(line Line) ([]byte, error) {
+	priority := syslogPriorityStdout
+	if line.Stream == "stderr" {
+		priority = syslogPriorityStderr
+	}
+
+	message := fmt.Sprintf("<%d>1 %s %s %s %s %s - %s",
+		priority,
+		line.Time.UTC().Format(time.RFC3339Nano),
+		syslogField(e.Hostname, 255),
+		syslogField(line.Handle, maxAppNameLength),
+		syslogField(line.ProcessID, maxProcIDLength),
+		syslogField(line.Stream, 32),
+		line.Text,
+	)
+
+	if e.Framed {
+		message = fmt.Sprintf("%d %s", len(message), message)
+	}
+
+	return []byte(message), nil
+}
+
+// syslogField is value truncated to max, or the nil value, "-", if it is
+// empty
+func syslogField(value string, max int) string {
+	if value == "" {
+		return "-"
+	}
+
+	if len(value) > max {
+		return value[:max]
+	}
+
+	return value
+}
+
+func hostname() string {
+	name, err := os.Hostname()
+	if err != nil {
+		return ""
+	}
+
+	return name
+}
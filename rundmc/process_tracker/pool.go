@@ -0,0 +1,106 @@
+// +build !windows
+
+package process_tracker
+
+import (
+	"bufio"
+	"encoding/gob"
+	"fmt"
+	"net"
+	"os/exec"
+	"path"
+	"strings"
+	"sync"
+
+	"github.com/cloudfoundry-incubator/guardian/rundmc/iodaemon"
+	"github.com/cloudfoundry/gunk/command_runner"
+)
+
+// iodaemonPool is a container's 'iodaemon pool', which is started on the
+// container's first spawn and exits once it has no processes left
+type iodaemonPool struct {
+	socketPath  string
+	iodaemonBin string
+	runner      command_runner.CommandRunner
+
+	// starting serializes the pools' starts, so each is only started once
+	starting *sync.Mutex
+}
+
+// spawn sends the request to the pool, starting the pool if it is not
+// running, and returns the connection its notifications are read from
+func (p *iodaemonPool) spawn(request iodaemon.SpawnRequest) (net.Conn, error) {
+	conn, err := p.dial()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := gob.NewEncoder(conn).Encode(request); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("send spawn request: %s", err)
+	}
+
+	return conn, nil
+}
+
+func (p *iodaemonPool) dial() (net.Conn, error) {
+	if conn, err := net.Dial("unix", p.socketPath); err == nil {
+		return conn, nil
+	}
+
+	p.starting.Lock()
+	defer p.starting.Unlock()
+
+	// another spawn may have started the pool meanwhile
+	if conn, err := net.Dial("unix", p.socketPath); err == nil {
+		return conn, nil
+	}
+
+	if err := p.start(); err != nil {
+		return nil, err
+	}
+
+	return net.Dial("unix", p.socketPath)
+}
+
+func (p *iodaemonPool) start() error {
+	start := exec.Command(
+		"bash", "-c",
+		// serve but not as a child process (fork off in the bash subprocess).
+		p.iodaemonBin+` "$@" &`,
+		p.iodaemonBin,
+		"pool", p.socketPath,
+	)
+
+	startR, err := start.StdoutPipe()
+	if err != nil {
+		return err
+	}
+
+	if err := p.runner.Start(start); err != nil {
+		return fmt.Errorf("start iodaemon pool: %s", err)
+	}
+
+	defer start.Wait()
+
+	return readNotification(bufio.NewReader(startR), "ready")
+}
+
+// readNotification reads the next notification from an iodaemon, failing
+// unless it is the expected one
+func readNotification(r *bufio.Reader, expected string) error {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %s", expected, err)
+	}
+
+	if line = strings.TrimSpace(line); line != expected {
+		return fmt.Errorf("failed to read %s: %s", expected, line)
+	}
+
+	return nil
+}
+
+func poolSocketPath(containerPath, handle string) string {
+	return path.Join(containerPath, "pools", fmt.Sprintf("%s.sock", handle))
+}
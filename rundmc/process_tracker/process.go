@@ -1,13 +1,17 @@
+// +build !windows
+
 package process_tracker
 
 import (
 	"bufio"
 	"fmt"
+	"io"
 	"os/exec"
 	"path"
 	"sync"
 
 	"github.com/cloudfoundry-incubator/garden"
+	"github.com/cloudfoundry-incubator/guardian/rundmc/iodaemon"
 	"github.com/cloudfoundry-incubator/guardian/rundmc/iodaemon/link"
 	"github.com/cloudfoundry-incubator/guardian/rundmc/process_tracker/writer"
 	"github.com/cloudfoundry/gunk/command_runner"
@@ -16,11 +20,21 @@ import (
 type Process struct {
 	id string
 
+	// handle is the container the process was run in, if any
+	handle string
+
 	iodaemonBin string
+	limits      OutputLimits
 
 	containerPath string
 	runner        command_runner.CommandRunner
 
+	// pool, if set, spawns the process instead of its own iodaemon
+	pool *iodaemonPool
+
+	// timestamp has the iodaemon timestamp each line of output as it reads it
+	timestamp bool
+
 	runningLink *sync.Once
 	linked      chan struct{}
 	link        *link.Link
@@ -32,6 +46,10 @@ type Process struct {
 	stdin  writer.FanIn
 	stdout writer.FanOut
 	stderr writer.FanOut
+
+	// drains are closed once the process exits, to flush its last lines;
+	// they still send any output which is copied after that
+	drains []io.Closer
 }
 
 func NewProcess(
@@ -39,11 +57,13 @@ func NewProcess(
 	containerPath string,
 	iodaemonBin string,
 	runner command_runner.CommandRunner,
+	limits OutputLimits,
 ) *Process {
 	return &Process{
 		id: id,
 
 		iodaemonBin:   iodaemonBin,
+		limits:        limits,
 		containerPath: containerPath,
 		runner:        runner,
 
@@ -54,8 +74,8 @@ func NewProcess(
 		exited: make(chan struct{}),
 
 		stdin:  writer.NewFanIn(),
-		stdout: writer.NewFanOut(),
-		stderr: writer.NewFanOut(),
+		stdout: writer.NewReplayingFanOut(limits.ReplayBytes),
+		stderr: writer.NewReplayingFanOut(limits.ReplayBytes),
 	}
 }
 
@@ -88,7 +108,12 @@ func (p *Process) Spawn(cmd *exec.Cmd, tty *garden.TTYSpec) (ready, active chan
 	ready = make(chan error, 1)
 	active = make(chan error, 1)
 
-	processSock := path.Join(p.containerPath, "processes", fmt.Sprintf("%s.sock", p.ID()))
+	processSock := processSocketPath(p.containerPath, p.ID())
+
+	if p.pool != nil {
+		go p.spawnPooled(processSock, cmd, tty, ready, active)
+		return
+	}
 
 	bashFlags := []string{
 		"-c",
@@ -109,6 +134,18 @@ func (p *Process) Spawn(cmd *exec.Cmd, tty *garden.TTYSpec) (ready, active chan
 		}
 	}
 
+	if p.limits.RateLimit > 0 {
+		bashFlags = append(bashFlags, fmt.Sprintf("-ioRateLimit=%d", p.limits.RateLimit))
+	}
+
+	if p.limits.MaxBytes > 0 {
+		bashFlags = append(bashFlags, fmt.Sprintf("-ioMaxBytes=%d", p.limits.MaxBytes))
+	}
+
+	if p.timestamp {
+		bashFlags = append(bashFlags, "-timestamp")
+	}
+
 	bashFlags = append(bashFlags, "spawn", processSock)
 
 	spawn := exec.Command("bash", append(bashFlags, cmd.Args...)...)
@@ -152,6 +189,45 @@ func (p *Process) Spawn(cmd *exec.Cmd, tty *garden.TTYSpec) (ready, active chan
 	return
 }
 
+func (p *Process) spawnPooled(processSock string, cmd *exec.Cmd, tty *garden.TTYSpec, ready, active chan error) {
+	request := iodaemon.SpawnRequest{
+		Socket:    processSock,
+		Argv:      cmd.Args,
+		Env:       cmd.Env,
+		Dir:       cmd.Dir,
+		RateLimit: p.limits.RateLimit,
+		MaxBytes:  p.limits.MaxBytes,
+		Timestamp: p.timestamp,
+	}
+
+	if tty != nil {
+		request.Tty = true
+		request.WindowColumns, request.WindowRows = 80, 24
+
+		if tty.WindowSize != nil {
+			request.WindowColumns, request.WindowRows = tty.WindowSize.Columns, tty.WindowSize.Rows
+		}
+	}
+
+	conn, err := p.pool.spawn(request)
+	if err != nil {
+		ready <- err
+		return
+	}
+
+	defer conn.Close()
+	notifications := bufio.NewReader(conn)
+
+	if err := readNotification(notifications, "ready"); err != nil {
+		ready <- err
+		return
+	}
+
+	ready <- nil
+
+	active <- readNotification(notifications, "active")
+}
+
 func (p *Process) Link() {
 	p.runningLink.Do(p.runLinker)
 }
@@ -170,9 +246,20 @@ func (p *Process) Attach(processIO garden.ProcessIO) {
 	}
 }
 
+// DrainTo sends the process's stdout and stderr to the drain, tagged with
+// the container's handle. It must be called before the process is linked.
+func (p *Process) DrainTo(drain LogDrain, handle string) {
+	stdout := drain.Stream(handle, p.id, "stdout")
+	stderr := drain.Stream(handle, p.id, "stderr")
+
+	p.stdout.AddSink(stdout)
+	p.stderr.AddSink(stderr)
+	p.drains = append(p.drains, stdout, stderr)
+}
+
 // This is guarded by runningLink so will only run once per Process per garden.
 func (p *Process) runLinker() {
-	processSock := path.Join(p.containerPath, "processes", fmt.Sprintf("%s.sock", p.ID()))
+	processSock := processSocketPath(p.containerPath, p.ID())
 
 	link, err := link.Create(processSock, p.stdout, p.stderr)
 	if err != nil {
@@ -185,14 +272,27 @@ func (p *Process) runLinker() {
 	p.link = link
 	close(p.linked)
 
-	p.completed(p.link.Wait())
+	exitStatus, err := p.link.Wait()
+	if p.link.Dropped() > 0 && p.limits.Truncations != nil {
+		p.limits.Truncations.Inc()
+	}
+
+	p.completed(exitStatus, err)
 
 	// don't leak stdin pipe
 	p.stdin.Close()
 }
 
 func (p *Process) completed(exitStatus int, err error) {
+	for _, drain := range p.drains {
+		drain.Close()
+	}
+
 	p.exitStatus = exitStatus
 	p.exitErr = err
 	close(p.exited)
 }
+
+func processSocketPath(containerPath, processID string) string {
+	return path.Join(containerPath, "processes", fmt.Sprintf("%s.sock", processID))
+}
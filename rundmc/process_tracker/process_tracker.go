@@ -2,11 +2,10 @@ package process_tracker
 
 import (
 	"fmt"
+	"io"
 	"os/exec"
-	"sync"
 
 	"github.com/cloudfoundry-incubator/garden"
-	"github.com/cloudfoundry/gunk/command_runner"
 )
 
 //go:generate counterfeiter -o fake_process_tracker/fake_process_tracker.go . ProcessTracker
@@ -17,123 +16,65 @@ type ProcessTracker interface {
 	ActiveProcesses() []garden.Process
 }
 
-type processTracker struct {
-	containerPath string
-	runner        command_runner.CommandRunner
+// ContainerProcessTracker is implemented by ProcessTrackers which spawn the
+// processes of each container together, e.g. from an iodaemon pool
+type ContainerProcessTracker interface {
+	RunInContainer(handle, processID string, cmd *exec.Cmd, io garden.ProcessIO, tty *garden.TTYSpec) (garden.Process, error)
 
-	iodaemonBin string
-
-	processes      map[string]*Process
-	processesMutex *sync.RWMutex
+	// AttachInContainer attaches to a process only if it was run in the
+	// container, so that one container's clients cannot attach to another's
+	// processes
+	AttachInContainer(handle, processID string, io garden.ProcessIO) (garden.Process, error)
 }
 
-type UnknownProcessError struct {
-	ProcessID string
+// IODaemonPidLister is implemented by ProcessTrackers whose processes are
+// served by iodaemons, to report the host pids of the iodaemons serving a
+// container's processes, by process ID
+type IODaemonPidLister interface {
+	IODaemonPids(handle string) map[string]int
 }
 
-func (e UnknownProcessError) Error() string {
-	return fmt.Sprintf("process_tracker: unknown process: %s", e.ProcessID)
-}
-
-func New(containerPath string, iodaemonBin string, runner command_runner.CommandRunner) ProcessTracker {
-	return &processTracker{
-		containerPath: containerPath,
-		runner:        runner,
-
-		iodaemonBin: iodaemonBin,
-
-		processesMutex: new(sync.RWMutex),
-		processes:      make(map[string]*Process),
-	}
-}
-
-func (t *processTracker) Run(processID string, cmd *exec.Cmd, processIO garden.ProcessIO, tty *garden.TTYSpec) (garden.Process, error) {
-	t.processesMutex.Lock()
-	process := NewProcess(processID, t.containerPath, t.iodaemonBin, t.runner)
-	t.processes[processID] = process
-	t.processesMutex.Unlock()
-
-	ready, active := process.Spawn(cmd, tty)
-
-	err := <-ready
-	if err != nil {
-		return nil, err
-	}
-
-	process.Attach(processIO)
-
-	go t.link(process.ID())
-
-	err = <-active
-	if err != nil {
-		return nil, err
-	}
-
-	return process, nil
+// TimestampingProcessTracker is implemented by ProcessTrackers whose
+// iodaemons can prefix each line of a process's output with when they read
+// it, so that every client attached to the process sees the same timestamps
+type TimestampingProcessTracker interface {
+	RunTimestamped(handle, processID string, cmd *exec.Cmd, io garden.ProcessIO, tty *garden.TTYSpec) (garden.Process, error)
 }
 
-func (t *processTracker) Attach(processID string, processIO garden.ProcessIO) (garden.Process, error) {
-	t.processesMutex.RLock()
-	process, ok := t.processes[processID]
-	t.processesMutex.RUnlock()
-
-	if !ok {
-		return nil, UnknownProcessError{processID}
-	}
-
-	process.Attach(processIO)
-
-	go t.link(processID)
-
-	return process, nil
+// TruncationCounter counts the processes whose output was truncated, e.g.
+// for a metric
+type TruncationCounter interface {
+	Inc()
 }
 
-func (t *processTracker) Restore(processID string) {
-	t.processesMutex.Lock()
-
-	process := NewProcess(processID, t.containerPath, t.iodaemonBin, t.runner)
-
-	t.processes[processID] = process
-
-	go t.link(processID)
-
-	t.processesMutex.Unlock()
+// LogDrain forwards the output of processes, e.g. to syslog. See logdrain.
+type LogDrain interface {
+	Stream(handle, processID, stream string) io.WriteCloser
 }
 
-func (t *processTracker) ActiveProcesses() []garden.Process {
-	t.processesMutex.RLock()
-	defer t.processesMutex.RUnlock()
+// OutputLimits are the limits on the output of each process, which its
+// iodaemon enforces. See iodaemon.OutputLimiter.
+type OutputLimits struct {
+	RateLimit int64
+	MaxBytes  int64
 
-	processes := make([]garden.Process, len(t.processes))
+	// ReplayBytes of each of stdout and stderr are kept and replayed to
+	// attachers, so that a process's output can be attached to more than
+	// once without missing what was written before
+	ReplayBytes int
 
-	i := 0
-	for _, process := range t.processes {
-		processes[i] = process
-		i++
-	}
+	// Truncations is optional
+	Truncations TruncationCounter
 
-	return processes
+	// Drain, if set, is sent the output of every process as well as its
+	// attachers
+	Drain LogDrain
 }
 
-func (t *processTracker) link(processID string) {
-	t.processesMutex.RLock()
-	process, ok := t.processes[processID]
-	t.processesMutex.RUnlock()
-
-	if !ok {
-		return
-	}
-
-	defer t.unregister(processID)
-
-	process.Link()
-
-	return
+type UnknownProcessError struct {
+	ProcessID string
 }
 
-func (t *processTracker) unregister(processID string) {
-	t.processesMutex.Lock()
-	defer t.processesMutex.Unlock()
-
-	delete(t.processes, processID)
+func (e UnknownProcessError) Error() string {
+	return fmt.Sprintf("process_tracker: unknown process: %s", e.ProcessID)
 }
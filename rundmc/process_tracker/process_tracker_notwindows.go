@@ -0,0 +1,275 @@
+// +build !windows
+
+package process_tracker
+
+import (
+	"io/ioutil"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/cloudfoundry-incubator/garden"
+	"github.com/cloudfoundry/gunk/command_runner"
+)
+
+// processTracker runs each process under an iodaemon, which cannot run on
+// Windows. See NewDirect.
+type processTracker struct {
+	containerPath string
+	runner        command_runner.CommandRunner
+
+	iodaemonBin string
+	limits      OutputLimits
+
+	// pooled spawns each container's processes from its iodaemon pool
+	pooled        bool
+	startingPools *sync.Mutex
+
+	processes      map[string]*Process
+	processesMutex *sync.RWMutex
+}
+
+func New(containerPath string, iodaemonBin string, runner command_runner.CommandRunner) ProcessTracker {
+	return NewWithOutputLimits(containerPath, iodaemonBin, runner, OutputLimits{})
+}
+
+// NewWithOutputLimits returns a ProcessTracker whose processes' output is
+// limited by their iodaemons
+func NewWithOutputLimits(containerPath string, iodaemonBin string, runner command_runner.CommandRunner, limits OutputLimits) ProcessTracker {
+	return &processTracker{
+		containerPath: containerPath,
+		runner:        runner,
+
+		iodaemonBin: iodaemonBin,
+		limits:      limits,
+
+		processesMutex: new(sync.RWMutex),
+		processes:      make(map[string]*Process),
+	}
+}
+
+// NewPooled returns a ProcessTracker which, when run in a container, spawns
+// the process from the container's 'iodaemon pool' rather than from an
+// iodaemon of its own, saving a fork and exec and several fds per process
+func NewPooled(containerPath string, iodaemonBin string, runner command_runner.CommandRunner, limits OutputLimits) ProcessTracker {
+	tracker := NewWithOutputLimits(containerPath, iodaemonBin, runner, limits).(*processTracker)
+	tracker.pooled = true
+	tracker.startingPools = new(sync.Mutex)
+
+	return tracker
+}
+
+func (t *processTracker) Run(processID string, cmd *exec.Cmd, processIO garden.ProcessIO, tty *garden.TTYSpec) (garden.Process, error) {
+	return t.run("", NewProcess(processID, t.containerPath, t.iodaemonBin, t.runner, t.limits), cmd, processIO, tty)
+}
+
+func (t *processTracker) RunInContainer(handle, processID string, cmd *exec.Cmd, processIO garden.ProcessIO, tty *garden.TTYSpec) (garden.Process, error) {
+	return t.run(handle, t.containerProcess(handle, processID), cmd, processIO, tty)
+}
+
+func (t *processTracker) RunTimestamped(handle, processID string, cmd *exec.Cmd, processIO garden.ProcessIO, tty *garden.TTYSpec) (garden.Process, error) {
+	process := t.containerProcess(handle, processID)
+	process.timestamp = true
+
+	return t.run(handle, process, cmd, processIO, tty)
+}
+
+func (t *processTracker) containerProcess(handle, processID string) *Process {
+	process := NewProcess(processID, t.containerPath, t.iodaemonBin, t.runner, t.limits)
+	if t.pooled {
+		process.pool = &iodaemonPool{
+			socketPath:  poolSocketPath(t.containerPath, handle),
+			iodaemonBin: t.iodaemonBin,
+			runner:      t.runner,
+			starting:    t.startingPools,
+		}
+	}
+
+	return process
+}
+
+func (t *processTracker) run(handle string, process *Process, cmd *exec.Cmd, processIO garden.ProcessIO, tty *garden.TTYSpec) (garden.Process, error) {
+	process.handle = handle
+
+	t.processesMutex.Lock()
+	t.processes[process.ID()] = process
+	t.processesMutex.Unlock()
+
+	ready, active := process.Spawn(cmd, tty)
+
+	err := <-ready
+	if err != nil {
+		return nil, err
+	}
+
+	if t.limits.Drain != nil {
+		process.DrainTo(t.limits.Drain, handle)
+	}
+
+	process.Attach(processIO)
+
+	go t.link(process.ID())
+
+	err = <-active
+	if err != nil {
+		return nil, err
+	}
+
+	return process, nil
+}
+
+func (t *processTracker) Attach(processID string, processIO garden.ProcessIO) (garden.Process, error) {
+	t.processesMutex.RLock()
+	process, ok := t.processes[processID]
+	t.processesMutex.RUnlock()
+
+	if !ok {
+		return nil, UnknownProcessError{processID}
+	}
+
+	return t.attach(process, processIO)
+}
+
+func (t *processTracker) AttachInContainer(handle, processID string, processIO garden.ProcessIO) (garden.Process, error) {
+	t.processesMutex.RLock()
+	process, ok := t.processes[processID]
+	t.processesMutex.RUnlock()
+
+	if !ok || process.handle != handle {
+		return nil, UnknownProcessError{processID}
+	}
+
+	return t.attach(process, processIO)
+}
+
+func (t *processTracker) attach(process *Process, processIO garden.ProcessIO) (garden.Process, error) {
+	process.Attach(processIO)
+
+	go t.link(process.ID())
+
+	return process, nil
+}
+
+func (t *processTracker) Restore(processID string) {
+	t.processesMutex.Lock()
+
+	process := NewProcess(processID, t.containerPath, t.iodaemonBin, t.runner, t.limits)
+
+	t.processes[processID] = process
+
+	go t.link(processID)
+
+	t.processesMutex.Unlock()
+}
+
+func (t *processTracker) ActiveProcesses() []garden.Process {
+	t.processesMutex.RLock()
+	defer t.processesMutex.RUnlock()
+
+	processes := make([]garden.Process, len(t.processes))
+
+	i := 0
+	for _, process := range t.processes {
+		processes[i] = process
+		i++
+	}
+
+	return processes
+}
+
+// IODaemonPids finds the iodaemons serving the container's processes in
+// /proc by the sockets on their command lines. The processes of a container
+// with an iodaemon pool are all served by the pool. Processes restored after
+// a restart are not known to be in any container, so are not listed.
+func (t *processTracker) IODaemonPids(handle string) map[string]int {
+	sockets := map[string]string{}
+	t.processesMutex.RLock()
+	for id, process := range t.processes {
+		if process.handle == handle {
+			sockets[processSocketPath(t.containerPath, id)] = id
+		}
+	}
+	t.processesMutex.RUnlock()
+
+	if len(sockets) == 0 {
+		return nil
+	}
+
+	entries, err := ioutil.ReadDir("/proc")
+	if err != nil {
+		return nil
+	}
+
+	poolSocket := poolSocketPath(t.containerPath, handle)
+	pids := map[string]int{}
+	for _, entry := range entries {
+		pid, err := strconv.Atoi(entry.Name())
+		if err != nil {
+			continue
+		}
+
+		cmdline, err := ioutil.ReadFile(filepath.Join("/proc", entry.Name(), "cmdline"))
+		if err != nil {
+			continue
+		}
+
+		args := strings.Split(strings.TrimRight(string(cmdline), "\x00"), "\x00")
+		if args[0] != t.iodaemonBin {
+			continue
+		}
+
+		socket := iodaemonSocket(args[1:])
+		if socket == poolSocket {
+			for _, id := range sockets {
+				pids[id] = pid
+			}
+		} else if id, ok := sockets[socket]; ok {
+			pids[id] = pid
+		}
+	}
+
+	return pids
+}
+
+// iodaemonSocket returns the socket an iodaemon with the given args listens
+// on, which follows its 'spawn' or 'pool' command and any flags
+func iodaemonSocket(args []string) string {
+	for i, arg := range args {
+		if strings.HasPrefix(arg, "-") {
+			continue
+		}
+
+		if (arg == "spawn" || arg == "pool") && i+1 < len(args) {
+			return args[i+1]
+		}
+
+		return ""
+	}
+
+	return ""
+}
+
+func (t *processTracker) link(processID string) {
+	t.processesMutex.RLock()
+	process, ok := t.processes[processID]
+	t.processesMutex.RUnlock()
+
+	if !ok {
+		return
+	}
+
+	defer t.unregister(processID)
+
+	process.Link()
+
+	return
+}
+
+func (t *processTracker) unregister(processID string) {
+	t.processesMutex.Lock()
+	defer t.processesMutex.Unlock()
+
+	delete(t.processes, processID)
+}
@@ -1,13 +1,17 @@
+// +build !windows
+
 package process_tracker_test
 
 import (
 	"bytes"
 	"errors"
+	"fmt"
 	"io"
 	"io/ioutil"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"sync"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
@@ -315,6 +319,122 @@ var _ = Describe("Process tracker", func() {
 			Eventually(stdout).Should(gbytes.Say("hi stdout this-is-stdin"))
 			Eventually(stderr).Should(gbytes.Say("hi stderr this-is-stdin"))
 		})
+
+		Context("when the process was run in a container", func() {
+			var containerTracker process_tracker.ContainerProcessTracker
+
+			BeforeEach(func() {
+				containerTracker = processTracker.(process_tracker.ContainerProcessTracker)
+			})
+
+			It("attaches to it from the same container", func() {
+				process, err := containerTracker.RunInContainer("some-handle", "858", exec.Command("bash", "-c", "cat; echo hi stdout"), garden.ProcessIO{}, nil)
+				Expect(err).NotTo(HaveOccurred())
+
+				stdout := gbytes.NewBuffer()
+				_, err = containerTracker.AttachInContainer("some-handle", process.ID(), garden.ProcessIO{
+					Stdin:  bytes.NewBufferString(""),
+					Stdout: stdout,
+				})
+				Expect(err).NotTo(HaveOccurred())
+				Eventually(stdout).Should(gbytes.Say("hi stdout"))
+			})
+
+			It("does not attach to it from another container", func() {
+				stdin, stdinW := io.Pipe()
+				defer stdinW.Close()
+
+				process, err := containerTracker.RunInContainer("some-handle", "859", exec.Command("cat"), garden.ProcessIO{Stdin: stdin}, nil)
+				Expect(err).NotTo(HaveOccurred())
+
+				_, err = containerTracker.AttachInContainer("another-handle", process.ID(), garden.ProcessIO{})
+				Expect(err).To(Equal(process_tracker.UnknownProcessError{ProcessID: "859"}))
+			})
+		})
+
+		Context("when the process's output is timestamped", func() {
+			It("timestamps it as it is captured, for the runner and for attachers alike", func() {
+				stdin, stdinW := io.Pipe()
+				first := gbytes.NewBuffer()
+				process, err := processTracker.(process_tracker.TimestampingProcessTracker).RunTimestamped("some-handle", "860", exec.Command("bash", "-c", "echo before; read; echo after; echo oops >&2"), garden.ProcessIO{Stdin: stdin, Stdout: first}, nil)
+				Expect(err).NotTo(HaveOccurred())
+
+				Eventually(first).Should(gbytes.Say(`^\d{4}-\d{2}-\d{2}T[^ ]+Z before\n`))
+
+				second, stderr := gbytes.NewBuffer(), gbytes.NewBuffer()
+				_, err = processTracker.Attach(process.ID(), garden.ProcessIO{Stdout: second, Stderr: stderr})
+				Expect(err).NotTo(HaveOccurred())
+
+				_, err = stdinW.Write([]byte("\n"))
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(process.Wait()).To(Equal(0))
+				Eventually(second).Should(gbytes.Say(`^\d{4}-\d{2}-\d{2}T[^ ]+Z after\n`))
+				Eventually(stderr).Should(gbytes.Say(`^\d{4}-\d{2}-\d{2}T[^ ]+Z oops\n`))
+			})
+		})
+
+		Context("with a replay buffer", func() {
+			BeforeEach(func() {
+				processTracker = process_tracker.NewWithOutputLimits(tmpdir, iodaemonBin, linux_command_runner.New(), process_tracker.OutputLimits{
+					ReplayBytes: 1024,
+				})
+			})
+
+			It("streams the whole output to every attacher, however late it attaches", func() {
+				cmd := exec.Command("bash", "-c", `
+				echo "before"
+				read
+				echo "after"
+			`)
+
+				stdin, stdinW := io.Pipe()
+				first := gbytes.NewBuffer()
+				process, err := processTracker.Run("856", cmd, garden.ProcessIO{Stdin: stdin, Stdout: first}, nil)
+				Expect(err).NotTo(HaveOccurred())
+
+				Eventually(first).Should(gbytes.Say("before"))
+
+				second := gbytes.NewBuffer()
+				_, err = processTracker.Attach(process.ID(), garden.ProcessIO{Stdout: second})
+				Expect(err).NotTo(HaveOccurred())
+				Eventually(second).Should(gbytes.Say("before"))
+
+				_, err = stdinW.Write([]byte("\n"))
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(process.Wait()).To(Equal(0))
+				Eventually(first).Should(gbytes.Say("after"))
+				Eventually(second).Should(gbytes.Say("after"))
+				Expect(string(second.Contents())).To(Equal("before\nafter\n"))
+			})
+		})
+
+		Context("with a log drain", func() {
+			var drain *fakeLogDrain
+
+			BeforeEach(func() {
+				drain = &fakeLogDrain{streams: map[string]*gbytes.Buffer{}}
+				processTracker = process_tracker.NewWithOutputLimits(tmpdir, iodaemonBin, linux_command_runner.New(), process_tracker.OutputLimits{
+					Drain: drain,
+				})
+			})
+
+			It("sends the output to the drain as well as the attacher, tagged with the container and process, and closes it on exit", func() {
+				stdout := gbytes.NewBuffer()
+				process, err := processTracker.(process_tracker.ContainerProcessTracker).RunInContainer("some-handle", "857", exec.Command("bash", "-c", "echo hi stdout; echo hi stderr >&2"), garden.ProcessIO{Stdout: stdout}, nil)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(process.Wait()).To(Equal(0))
+				Eventually(stdout).Should(gbytes.Say("hi stdout"))
+
+				drainedStdout, drainedStderr := drain.stream("some-handle/857/stdout"), drain.stream("some-handle/857/stderr")
+				Eventually(drainedStdout).Should(gbytes.Say("hi stdout"))
+				Eventually(drainedStderr).Should(gbytes.Say("hi stderr"))
+				Eventually(drainedStdout.Closed).Should(BeTrue())
+				Eventually(drainedStderr.Closed).Should(BeTrue())
+			})
+		})
 	})
 
 	Describe("Listing active process IDs", func() {
@@ -345,8 +465,200 @@ var _ = Describe("Process tracker", func() {
 			Eventually(processTracker.ActiveProcesses).Should(BeEmpty())
 		})
 	})
+
+	Describe("Listing the iodaemons of a container's processes", func() {
+		var stdinW *io.PipeWriter
+
+		runCat := func(handle, processID string) {
+			stdin, w := io.Pipe()
+			stdinW = w
+
+			_, err := processTracker.(process_tracker.ContainerProcessTracker).RunInContainer(handle, processID, exec.Command("cat"), garden.ProcessIO{Stdin: stdin}, nil)
+			Expect(err).NotTo(HaveOccurred())
+		}
+
+		cmdline := func(pid int) string {
+			contents, err := ioutil.ReadFile(fmt.Sprintf("/proc/%d/cmdline", pid))
+			Expect(err).NotTo(HaveOccurred())
+			return string(contents)
+		}
+
+		AfterEach(func() {
+			stdinW.Close()
+		})
+
+		It("lists the iodaemon of each of the container's processes", func() {
+			runCat("other-handle", "960")
+			otherStdinW := stdinW
+			defer otherStdinW.Close()
+
+			runCat("some-handle", "961")
+
+			pids := processTracker.(process_tracker.IODaemonPidLister).IODaemonPids("some-handle")
+			Expect(pids).To(HaveLen(1))
+			Expect(pids).To(HaveKey("961"))
+			Expect(cmdline(pids["961"])).To(ContainSubstring(filepath.Join(tmpdir, "processes", "961.sock")))
+		})
+
+		It("lists the pool as the iodaemon of each of the container's processes when it has one", func() {
+			processTracker = process_tracker.NewPooled(tmpdir, iodaemonBin, linux_command_runner.New(), process_tracker.OutputLimits{})
+
+			runCat("some-handle", "962")
+			firstStdinW := stdinW
+			defer firstStdinW.Close()
+
+			runCat("some-handle", "963")
+
+			pids := processTracker.(process_tracker.IODaemonPidLister).IODaemonPids("some-handle")
+			Expect(pids).To(HaveLen(2))
+			Expect(pids["962"]).To(Equal(pids["963"]))
+			Expect(cmdline(pids["962"])).To(ContainSubstring(filepath.Join(tmpdir, "pools", "some-handle.sock")))
+		})
+
+		It("lists nothing for a container without processes", func() {
+			runCat("some-handle", "964")
+
+			Expect(processTracker.(process_tracker.IODaemonPidLister).IODaemonPids("another-handle")).To(BeEmpty())
+		})
+	})
+
+	Describe("Running processes from a container's iodaemon pool", func() {
+		var containerTracker process_tracker.ContainerProcessTracker
+
+		BeforeEach(func() {
+			processTracker = process_tracker.NewPooled(tmpdir, iodaemonBin, linux_command_runner.New(), process_tracker.OutputLimits{})
+			containerTracker = processTracker.(process_tracker.ContainerProcessTracker)
+		})
+
+		It("runs the processes and returns their exit codes", func() {
+			process1, err := containerTracker.RunInContainer("some-handle", "880", exec.Command("bash", "-c", "exit 42"), garden.ProcessIO{}, nil)
+			Expect(err).NotTo(HaveOccurred())
+
+			process2, err := containerTracker.RunInContainer("some-handle", "881", exec.Command("bash", "-c", "exit 43"), garden.ProcessIO{}, nil)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(process1.Wait()).To(Equal(42))
+			Expect(process2.Wait()).To(Equal(43))
+		})
+
+		It("spawns the processes of each container from one pool", func() {
+			for i, handle := range []string{"handle-a", "handle-a", "handle-b"} {
+				process, err := containerTracker.RunInContainer(handle, fmt.Sprintf("89%d", i), exec.Command("true"), garden.ProcessIO{}, nil)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(process.Wait()).To(Equal(0))
+			}
+
+			pools, err := filepath.Glob(filepath.Join(tmpdir, "pools", "*.sock"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(pools).To(ConsistOf(
+				filepath.Join(tmpdir, "pools", "handle-a.sock"),
+				filepath.Join(tmpdir, "pools", "handle-b.sock"),
+			))
+		})
+
+		It("streams the process's input and output", func() {
+			stdout := gbytes.NewBuffer()
+
+			_, err := containerTracker.RunInContainer("some-handle", "882", exec.Command("cat"), garden.ProcessIO{
+				Stdin:  bytes.NewBufferString("stdin-line1\n"),
+				Stdout: stdout,
+			}, nil)
+			Expect(err).NotTo(HaveOccurred())
+
+			Eventually(stdout).Should(gbytes.Say("stdin-line1\n"))
+		})
+
+		It("runs the process in the specified directory and environment", func() {
+			cmd := exec.Command("bash", "-c", "echo $FOO; pwd")
+			cmd.Dir = tmpdir
+			cmd.Env = []string{"FOO=bar"}
+
+			stdout := gbytes.NewBuffer()
+			process, err := containerTracker.RunInContainer("some-handle", "883", cmd, garden.ProcessIO{Stdout: stdout}, nil)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(process.Wait()).To(Equal(0))
+			Eventually(stdout).Should(gbytes.Say("bar\n" + tmpdir))
+		})
+
+		It("runs processes with a tty", func() {
+			stdout := gbytes.NewBuffer()
+
+			process, err := containerTracker.RunInContainer("some-handle", "884", exec.Command("bash", "-c", "tty && stty size"), garden.ProcessIO{Stdout: stdout}, &garden.TTYSpec{
+				WindowSize: &garden.WindowSize{Columns: 95, Rows: 13},
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(process.Wait()).To(Equal(0))
+			Eventually(stdout).Should(gbytes.Say("/dev/pts/\\d+"))
+			Eventually(stdout).Should(gbytes.Say("13 95"))
+		})
+
+		It("returns an error when the process cannot be spawned", func() {
+			_, err := containerTracker.RunInContainer("some-handle", "885", exec.Command("does-not-exist"), garden.ProcessIO{}, nil)
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("runs processes with their own iodaemon when not run in a container", func() {
+			process, err := processTracker.Run("886", exec.Command("bash", "-c", "exit 42"), garden.ProcessIO{}, nil)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(process.Wait()).To(Equal(42))
+			Expect(filepath.Join(tmpdir, "pools")).NotTo(BeADirectory())
+		})
+	})
+
+	Describe("Limiting output", func() {
+		var truncations *countingTruncations
+
+		BeforeEach(func() {
+			truncations = new(countingTruncations)
+			processTracker = process_tracker.NewWithOutputLimits(tmpdir, iodaemonBin, linux_command_runner.New(), process_tracker.OutputLimits{
+				MaxBytes:    5,
+				Truncations: truncations,
+			})
+		})
+
+		It("truncates the output of processes which write more than the maximum, and counts them", func() {
+			cmd := exec.Command("bash", "-c", "echo hello world; sleep 0.2")
+
+			stdout := gbytes.NewBuffer()
+			process, err := processTracker.Run("777", cmd, garden.ProcessIO{Stdout: stdout}, nil)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(process.Wait()).To(Equal(0))
+			Eventually(stdout).Should(gbytes.Say("hello\n\\[iodaemon: output truncated after 5 bytes\\]"))
+			Expect(stdout.Contents()).NotTo(ContainSubstring("world"))
+			Expect(truncations.count()).To(Equal(1))
+		})
+
+		It("does not count processes which write less than the maximum", func() {
+			process, err := processTracker.Run("778", exec.Command("echo", "hi"), garden.ProcessIO{}, nil)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(process.Wait()).To(Equal(0))
+			Expect(truncations.count()).To(Equal(0))
+		})
+	})
 })
 
+type countingTruncations struct {
+	mu sync.Mutex
+	n  int
+}
+
+func (c *countingTruncations) Inc() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.n++
+}
+
+func (c *countingTruncations) count() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.n
+}
+
 func copyFile(src, dst string) error {
 	s, err := os.Open(src)
 	if err != nil {
@@ -368,3 +680,24 @@ func copyFile(src, dst string) error {
 
 	return d.Close()
 }
+
+type fakeLogDrain struct {
+	mu      sync.Mutex
+	streams map[string]*gbytes.Buffer
+}
+
+func (d *fakeLogDrain) Stream(handle, processID, stream string) io.WriteCloser {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	buffer := gbytes.NewBuffer()
+	d.streams[handle+"/"+processID+"/"+stream] = buffer
+	return buffer
+}
+
+func (d *fakeLogDrain) stream(name string) *gbytes.Buffer {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	return d.streams[name]
+}
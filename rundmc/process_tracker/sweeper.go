@@ -0,0 +1,136 @@
+package process_tracker
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pivotal-golang/clock"
+	"github.com/pivotal-golang/lager"
+)
+
+// SweptCounter counts the sockets a SocketSweeper removes, e.g. for a metric
+type SweptCounter interface {
+	Inc()
+}
+
+// acceptConnections is __SO_ACCEPTCON, which /proc/net/unix sets in the
+// flags of listening sockets
+const acceptConnections = 0x10000
+
+// SocketSweeper removes the sockets of processes, and of iodaemon pools,
+// which no iodaemon is listening on any more, e.g. because it was killed
+// before it could remove them. Such sockets are never reused, so otherwise
+// they would pile up in the process directory forever.
+//
+// Sockets are not connected to, which would start a process whose iodaemon
+// is waiting for its first link, but looked up in ProcNetUnix (usually
+// /proc/net/unix). Sockets younger than MinAge are left alone, as an
+// iodaemon binds its socket before it listens on it.
+type SocketSweeper struct {
+	ContainerPath string
+	ProcNetUnix   string
+	MinAge        time.Duration
+
+	Clock    clock.Clock
+	Interval time.Duration
+
+	// Swept is optional
+	Swept  SweptCounter
+	Logger lager.Logger
+}
+
+// Start sweeps once, and then in the background every Interval if it is
+// set
+func (s *SocketSweeper) Start() error {
+	s.Sweep()
+
+	if s.Interval <= 0 {
+		return nil
+	}
+
+	go func() {
+		ticker := s.Clock.NewTicker(s.Interval)
+		defer ticker.Stop()
+
+		for range ticker.C() {
+			s.Sweep()
+		}
+	}()
+
+	return nil
+}
+
+// Sweep removes the sockets no iodaemon is listening on once
+func (s *SocketSweeper) Sweep() {
+	log := s.Logger.Session("sweep-sockets")
+
+	listening, err := s.listeningSockets()
+	if err != nil {
+		log.Error("read-listening-sockets-failed", err)
+		return
+	}
+
+	for _, dir := range []string{"processes", "pools"} {
+		sockets, err := filepath.Glob(filepath.Join(s.ContainerPath, dir, "*.sock"))
+		if err != nil {
+			log.Error("list-sockets-failed", err)
+			continue
+		}
+
+		for _, socket := range sockets {
+			if listening[socket] {
+				continue
+			}
+
+			info, err := os.Lstat(socket)
+			if err != nil || s.Clock.Since(info.ModTime()) < s.MinAge {
+				continue
+			}
+
+			if err := os.Remove(socket); err != nil {
+				log.Error("remove-failed", err, lager.Data{"socket": socket})
+				continue
+			}
+
+			log.Info("removed", lager.Data{"socket": socket})
+			if s.Swept != nil {
+				s.Swept.Inc()
+			}
+		}
+	}
+}
+
+// listeningSockets returns the paths of the unix sockets which are being
+// listened on
+func (s *SocketSweeper) listeningSockets() (map[string]bool, error) {
+	f, err := os.Open(s.ProcNetUnix)
+	if err != nil {
+		return nil, err
+	}
+
+	defer f.Close()
+
+	listening := make(map[string]bool)
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		// Num RefCount Protocol Flags Type St Inode Path
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 8 {
+			continue
+		}
+
+		flags, err := strconv.ParseUint(fields[3], 16, 32)
+		if err != nil || flags&acceptConnections == 0 {
+			continue
+		}
+
+		listening[fields[7]] = true
+	}
+
+	return listening, scanner.Err()
+}
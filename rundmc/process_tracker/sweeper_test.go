@@ -0,0 +1,118 @@
+package process_tracker_test
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/cloudfoundry-incubator/guardian/rundmc/process_tracker"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/pivotal-golang/clock/fakeclock"
+	"github.com/pivotal-golang/lager/lagertest"
+)
+
+var _ = Describe("SocketSweeper", func() {
+	var (
+		tmpdir      string
+		procNetUnix string
+		fakeClock   *fakeclock.FakeClock
+		swept       *countingTruncations
+		listener    net.Listener
+
+		sweeper *process_tracker.SocketSweeper
+	)
+
+	socket := func(dir, name string) string {
+		return filepath.Join(tmpdir, dir, name+".sock")
+	}
+
+	BeforeEach(func() {
+		var err error
+		tmpdir, err = ioutil.TempDir("", "socket-sweeper")
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(os.MkdirAll(filepath.Join(tmpdir, "processes"), 0755)).To(Succeed())
+		Expect(os.MkdirAll(filepath.Join(tmpdir, "pools"), 0755)).To(Succeed())
+
+		listener, err = net.Listen("unix", socket("processes", "listening"))
+		Expect(err).NotTo(HaveOccurred())
+
+		for _, orphan := range []string{socket("processes", "orphan"), socket("pools", "orphan")} {
+			Expect(ioutil.WriteFile(orphan, nil, 0644)).To(Succeed())
+		}
+
+		procNetUnix = filepath.Join(tmpdir, "unix")
+		Expect(ioutil.WriteFile(procNetUnix, []byte(fmt.Sprintf(
+			"Num       RefCount Protocol Flags    Type St Inode Path\n"+
+				"0000000000000000: 00000002 00000000 00010000 0001 01 1234 %s\n"+
+				"0000000000000000: 00000003 00000000 00000000 0001 03 1235 %s\n"+
+				"0000000000000000: 00000003 00000000 00000000 0001 03 1236\n",
+			socket("processes", "listening"), socket("processes", "orphan"),
+		)), 0644)).To(Succeed())
+
+		fakeClock = fakeclock.NewFakeClock(time.Now().Add(time.Hour))
+		swept = new(countingTruncations)
+
+		sweeper = &process_tracker.SocketSweeper{
+			ContainerPath: tmpdir,
+			ProcNetUnix:   procNetUnix,
+			MinAge:        time.Minute,
+			Clock:         fakeClock,
+			Swept:         swept,
+			Logger:        lagertest.NewTestLogger("test"),
+		}
+	})
+
+	AfterEach(func() {
+		listener.Close()
+		os.RemoveAll(tmpdir)
+	})
+
+	It("removes the sockets nothing is listening on, and counts them", func() {
+		sweeper.Sweep()
+
+		Expect(socket("processes", "orphan")).NotTo(BeAnExistingFile())
+		Expect(socket("pools", "orphan")).NotTo(BeAnExistingFile())
+		Expect(swept.count()).To(Equal(2))
+	})
+
+	It("leaves the sockets which are being listened on", func() {
+		sweeper.Sweep()
+
+		Expect(socket("processes", "listening")).To(BeAnExistingFile())
+	})
+
+	It("leaves sockets younger than the minimum age, which may not be listened on yet", func() {
+		sweeper.MinAge = 2 * time.Hour
+		sweeper.Sweep()
+
+		Expect(socket("processes", "orphan")).To(BeAnExistingFile())
+		Expect(swept.count()).To(Equal(0))
+	})
+
+	It("removes nothing when the listening sockets cannot be read", func() {
+		sweeper.ProcNetUnix = filepath.Join(tmpdir, "does-not-exist")
+		sweeper.Sweep()
+
+		Expect(socket("processes", "orphan")).To(BeAnExistingFile())
+	})
+
+	Describe("Start", func() {
+		It("sweeps straight away, and then every interval", func() {
+			sweeper.Interval = time.Minute
+			Expect(sweeper.Start()).To(Succeed())
+			Expect(socket("processes", "orphan")).NotTo(BeAnExistingFile())
+
+			Expect(ioutil.WriteFile(socket("processes", "another-orphan"), nil, 0644)).To(Succeed())
+			Eventually(func() bool {
+				fakeClock.Increment(time.Minute)
+				_, err := os.Stat(socket("processes", "another-orphan"))
+				return os.IsNotExist(err)
+			}).Should(BeTrue())
+		})
+	})
+})
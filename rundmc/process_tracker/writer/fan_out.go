@@ -11,24 +11,48 @@ type FanOut interface {
 }
 
 func NewFanOut() FanOut {
-	return &fanOut{}
+	return NewReplayingFanOut(0)
+}
+
+// NewReplayingFanOut returns a FanOut which keeps the last replayBytes bytes
+// written to it, and writes them to each sink as it is added, so that every
+// sink receives the whole stream, as long as the stream still fits in the
+// buffer when the sink is added. Sinks which fail to be written to, e.g.
+// because their client has gone away, are dropped without affecting the
+// other sinks.
+func NewReplayingFanOut(replayBytes int) FanOut {
+	return &fanOut{replayBytes: replayBytes}
 }
 
 type fanOut struct {
 	sinks  []io.Writer
 	sinksL sync.Mutex
+
+	replayBytes int
+	replay      []byte
 }
 
 func (w *fanOut) Write(data []byte) (int, error) {
 	w.sinksL.Lock()
 	defer w.sinksL.Unlock()
 
-	// the sinks should be nonblocking and never actually error;
-	// we can assume lossiness here, and do this all within the lock
+	w.buffer(data)
+
+	// the sinks should be nonblocking; we can assume lossiness here, and do
+	// this all within the lock
+	sinks := w.sinks[:0]
 	for _, s := range w.sinks {
-		s.Write(data)
+		if _, err := s.Write(data); err == nil {
+			sinks = append(sinks, s)
+		}
 	}
 
+	// don't hold on to the dropped sinks
+	for i := len(sinks); i < len(w.sinks); i++ {
+		w.sinks[i] = nil
+	}
+	w.sinks = sinks
+
 	return len(data), nil
 }
 
@@ -36,5 +60,32 @@ func (w *fanOut) AddSink(sink io.Writer) {
 	w.sinksL.Lock()
 	defer w.sinksL.Unlock()
 
+	// the sink is added under the same lock as writes, so that it receives
+	// every byte after those replayed exactly once
+	if len(w.replay) > 0 {
+		if _, err := sink.Write(append([]byte{}, w.replay...)); err != nil {
+			return
+		}
+	}
+
 	w.sinks = append(w.sinks, sink)
 }
+
+// buffer keeps the last replayBytes bytes written. It must be called with
+// the lock held.
+func (w *fanOut) buffer(data []byte) {
+	if w.replayBytes <= 0 {
+		return
+	}
+
+	if len(data) >= w.replayBytes {
+		w.replay = append(w.replay[:0], data[len(data)-w.replayBytes:]...)
+		return
+	}
+
+	if overflow := len(w.replay) + len(data) - w.replayBytes; overflow > 0 {
+		w.replay = append(w.replay[:0], w.replay[overflow:]...)
+	}
+
+	w.replay = append(w.replay, data...)
+}
@@ -6,6 +6,7 @@ import (
 	"github.com/cloudfoundry-incubator/guardian/rundmc/process_tracker/writer"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
 )
 
 var _ = Describe("FanOut", func() {
@@ -65,4 +66,71 @@ var _ = Describe("FanOut", func() {
 		Expect(err).ToNot(HaveOccurred())
 		Expect(n).To(Equal(1))
 	})
+
+	It("drops a sink which fails to be written to, without affecting the others", func() {
+		fWriter.errWriteReturn = errors.New("client gone")
+		other := gbytes.NewBuffer()
+		fanOut.AddSink(fWriter)
+		fanOut.AddSink(other)
+
+		fanOut.Write([]byte("one"))
+		fanOut.Write([]byte("two"))
+
+		Expect(fWriter.writeCalls()).To(Equal(1))
+		Expect(other.Contents()).To(Equal([]byte("onetwo")))
+	})
+
+	It("does not replay anything to sinks added later", func() {
+		fanOut.Write([]byte("missed"))
+
+		late := gbytes.NewBuffer()
+		fanOut.AddSink(late)
+		fanOut.Write([]byte("seen"))
+
+		Expect(late.Contents()).To(Equal([]byte("seen")))
+	})
+
+	Context("with a replay buffer", func() {
+		BeforeEach(func() {
+			fanOut = writer.NewReplayingFanOut(8)
+		})
+
+		It("replays what has been written to sinks added later, followed by the rest of the stream", func() {
+			early := gbytes.NewBuffer()
+			fanOut.AddSink(early)
+			fanOut.Write([]byte("hello "))
+
+			late := gbytes.NewBuffer()
+			fanOut.AddSink(late)
+			fanOut.Write([]byte("world"))
+
+			Expect(early.Contents()).To(Equal([]byte("hello world")))
+			Expect(late.Contents()).To(Equal([]byte("hello world")))
+		})
+
+		It("only replays the last bytes written once the stream outgrows the buffer", func() {
+			fanOut.Write([]byte("0123"))
+			fanOut.Write([]byte("456789"))
+
+			late := gbytes.NewBuffer()
+			fanOut.AddSink(late)
+			Expect(late.Contents()).To(Equal([]byte("23456789")))
+
+			fanOut.Write([]byte("abcdefghijk"))
+
+			later := gbytes.NewBuffer()
+			fanOut.AddSink(later)
+			Expect(later.Contents()).To(Equal([]byte("defghijk")))
+		})
+
+		It("does not add a sink which fails to be replayed to", func() {
+			fanOut.Write([]byte("hello"))
+
+			fWriter.errWriteReturn = errors.New("client gone")
+			fanOut.AddSink(fWriter)
+			fanOut.Write([]byte("world"))
+
+			Expect(fWriter.writeCalls()).To(Equal(1))
+		})
+	})
 })
@@ -0,0 +1,117 @@
+package quota
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+
+	"github.com/cloudfoundry/gunk/command_runner"
+	"github.com/pivotal-golang/lager"
+)
+
+const (
+	XFS  = "xfs"
+	Ext4 = "ext4"
+)
+
+// ProjectQuota manages project quotas on a filesystem mounted with project
+// quotas enabled: either XFS (mounted with prjquota) or ext4 (e.g. a loopback
+// image created with the project and quota features, mounted with prjquota).
+type ProjectQuota struct {
+	// Filesystem is either XFS or Ext4
+	Filesystem string
+
+	// MountPoint is where the filesystem is mounted
+	MountPoint string
+
+	CommandRunner command_runner.CommandRunner
+}
+
+// Assign places path, and everything beneath it, in project.
+func (q *ProjectQuota) Assign(log lager.Logger, path string, project uint32) error {
+	log = log.Session("assign", lager.Data{"path": path, "project": project})
+
+	var cmd *exec.Cmd
+	switch q.Filesystem {
+	case XFS:
+		cmd = exec.Command("xfs_quota", "-x", "-c", fmt.Sprintf("project -s -p %s %d", path, project), q.MountPoint)
+	case Ext4:
+		cmd = exec.Command("chattr", "-R", "-p", strconv.FormatUint(uint64(project), 10), "+P", path)
+	default:
+		return fmt.Errorf("quota: unsupported filesystem: %s", q.Filesystem)
+	}
+
+	return q.run(log, cmd)
+}
+
+// Limit sets the hard block limit of project. A limit of 0 removes the limit.
+func (q *ProjectQuota) Limit(log lager.Logger, project uint32, limitInBytes uint64) error {
+	log = log.Session("limit", lager.Data{"project": project, "limit": limitInBytes})
+
+	var cmd *exec.Cmd
+	switch q.Filesystem {
+	case XFS:
+		cmd = exec.Command("xfs_quota", "-x", "-c", fmt.Sprintf("limit -p bhard=%d %d", limitInBytes, project), q.MountPoint)
+	case Ext4:
+		// setquota takes block limits in 1KiB blocks
+		cmd = exec.Command("setquota", "-P", strconv.FormatUint(uint64(project), 10),
+			"0", strconv.FormatUint((limitInBytes+1023)/1024, 10), "0", "0", q.MountPoint)
+	default:
+		return fmt.Errorf("quota: unsupported filesystem: %s", q.Filesystem)
+	}
+
+	return q.run(log, cmd)
+}
+
+// Usage returns the number of bytes used by project.
+func (q *ProjectQuota) Usage(log lager.Logger, project uint32) (uint64, error) {
+	log = log.Session("usage", lager.Data{"project": project})
+
+	stdout := &bytes.Buffer{}
+	cmd := exec.Command("repquota", "-P", "-n", q.MountPoint)
+	cmd.Stdout = stdout
+	if err := q.run(log, cmd); err != nil {
+		return 0, err
+	}
+
+	return parseRepquota(stdout.String(), project)
+}
+
+func (q *ProjectQuota) run(log lager.Logger, cmd *exec.Cmd) error {
+	stderr := &bytes.Buffer{}
+	cmd.Stderr = stderr
+	if err := q.CommandRunner.Run(cmd); err != nil {
+		log.Error("failed", err, lager.Data{"args": cmd.Args, "stderr": stderr.String()})
+		return fmt.Errorf("quota: %s: %s: %s", cmd.Args[0], err, strings.TrimSpace(stderr.String()))
+	}
+
+	return nil
+}
+
+// parseRepquota finds project in the output of `repquota -n`, whose lines
+// look like `#1001 --    4096  0  10240  0  12  0  0  0`, where the first
+// number is the space used in 1KiB blocks.
+func parseRepquota(output string, project uint32) (uint64, error) {
+	id := fmt.Sprintf("#%d", project)
+
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 3 || fields[0] != id {
+			continue
+		}
+
+		blocks, err := strconv.ParseUint(strings.TrimSuffix(fields[2], "*"), 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("quota: parse usage of project %d: %s", project, err)
+		}
+
+		return blocks * 1024, nil
+	}
+
+	// projects which have never been written to are not reported
+	return 0, nil
+}
@@ -0,0 +1,126 @@
+package quota_test
+
+import (
+	"errors"
+	"os/exec"
+
+	"github.com/cloudfoundry-incubator/guardian/rundmc/quota"
+	"github.com/cloudfoundry/gunk/command_runner/fake_command_runner"
+	. "github.com/cloudfoundry/gunk/command_runner/fake_command_runner/matchers"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/pivotal-golang/lager"
+	"github.com/pivotal-golang/lager/lagertest"
+)
+
+var _ = Describe("ProjectQuota", func() {
+	var (
+		logger     lager.Logger
+		fakeRunner *fake_command_runner.FakeCommandRunner
+		q          *quota.ProjectQuota
+	)
+
+	BeforeEach(func() {
+		logger = lagertest.NewTestLogger("test")
+		fakeRunner = fake_command_runner.New()
+		q = &quota.ProjectQuota{MountPoint: "/var/vcap/data/scratch", CommandRunner: fakeRunner}
+	})
+
+	Context("on XFS", func() {
+		BeforeEach(func() {
+			q.Filesystem = quota.XFS
+		})
+
+		It("assigns paths to projects with xfs_quota", func() {
+			Expect(q.Assign(logger, "/some/path", 1001)).To(Succeed())
+			Expect(fakeRunner).To(HaveExecutedSerially(fake_command_runner.CommandSpec{
+				Path: "xfs_quota",
+				Args: []string{"-x", "-c", "project -s -p /some/path 1001", "/var/vcap/data/scratch"},
+			}))
+		})
+
+		It("limits projects with xfs_quota", func() {
+			Expect(q.Limit(logger, 1001, 1048576)).To(Succeed())
+			Expect(fakeRunner).To(HaveExecutedSerially(fake_command_runner.CommandSpec{
+				Path: "xfs_quota",
+				Args: []string{"-x", "-c", "limit -p bhard=1048576 1001", "/var/vcap/data/scratch"},
+			}))
+		})
+	})
+
+	Context("on ext4", func() {
+		BeforeEach(func() {
+			q.Filesystem = quota.Ext4
+		})
+
+		It("assigns paths to projects with chattr", func() {
+			Expect(q.Assign(logger, "/some/path", 1001)).To(Succeed())
+			Expect(fakeRunner).To(HaveExecutedSerially(fake_command_runner.CommandSpec{
+				Path: "chattr",
+				Args: []string{"-R", "-p", "1001", "+P", "/some/path"},
+			}))
+		})
+
+		It("limits projects with setquota, in 1KiB blocks rounded up", func() {
+			Expect(q.Limit(logger, 1001, 1048577)).To(Succeed())
+			Expect(fakeRunner).To(HaveExecutedSerially(fake_command_runner.CommandSpec{
+				Path: "setquota",
+				Args: []string{"-P", "1001", "0", "1025", "0", "0", "/var/vcap/data/scratch"},
+			}))
+		})
+	})
+
+	Context("on an unsupported filesystem", func() {
+		BeforeEach(func() {
+			q.Filesystem = "btrfs"
+		})
+
+		It("returns an error", func() {
+			Expect(q.Assign(logger, "/some/path", 1001)).To(MatchError("quota: unsupported filesystem: btrfs"))
+			Expect(q.Limit(logger, 1001, 1)).To(MatchError("quota: unsupported filesystem: btrfs"))
+		})
+	})
+
+	Describe("Usage", func() {
+		BeforeEach(func() {
+			fakeRunner.WhenRunning(fake_command_runner.CommandSpec{
+				Path: "repquota",
+				Args: []string{"-P", "-n", "/var/vcap/data/scratch"},
+			}, func(cmd *exec.Cmd) error {
+				cmd.Stdout.Write([]byte(`*** Report for project quotas on device /dev/loop0
+Block grace time: 7days; Inode grace time: 7days
+                        Block limits                File limits
+Project         used    soft    hard  grace    used  soft  hard  grace
+----------------------------------------------------------------------
+#0        --      20       0       0              2     0     0
+#1001     +-    1024       0    1024  6days      12     0     0
+#1002     --       4       0       0              1     0     0
+`))
+				return nil
+			})
+		})
+
+		It("returns the bytes used by the project", func() {
+			Expect(q.Usage(logger, 1001)).To(BeEquivalentTo(1024 * 1024))
+			Expect(q.Usage(logger, 1002)).To(BeEquivalentTo(4096))
+		})
+
+		It("returns zero for projects which are not reported", func() {
+			Expect(q.Usage(logger, 1003)).To(BeZero())
+		})
+	})
+
+	Context("when a command fails", func() {
+		BeforeEach(func() {
+			q.Filesystem = quota.XFS
+			fakeRunner.WhenRunning(fake_command_runner.CommandSpec{}, func(cmd *exec.Cmd) error {
+				cmd.Stderr.Write([]byte("project quotas not enabled\n"))
+				return errors.New("exit status 1")
+			})
+		})
+
+		It("returns the error with the command's stderr", func() {
+			Expect(q.Limit(logger, 1001, 1)).To(MatchError("quota: xfs_quota: exit status 1: project quotas not enabled"))
+		})
+	})
+})
@@ -0,0 +1,69 @@
+// The reaper package waits for exited child processes so that they do not
+// linger as zombies, e.g. in a container's init process, which inherits any
+// process orphaned in the container.
+package reaper
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+// Reaper reaps exited children. SIGCHLD is only treated as a hint: pending
+// signals of the same kind coalesce and a burst of exits can outpace the
+// signal channel, so every wakeup drains all exited children with WNOHANG
+// rather than reaping one child per signal. A ticker wakes the loop even if
+// a signal is missed entirely.
+type Reaper struct {
+	// Interval between polls for exited children in the absence of SIGCHLD
+	Interval time.Duration
+
+	// OnExit, if set, is called for every child which is reaped
+	OnExit func(pid int, status syscall.WaitStatus)
+}
+
+// Run reaps children until stop is closed.
+func (r *Reaper) Run(stop <-chan struct{}) {
+	// a single buffered signal is enough: a pending signal guarantees
+	// another drain, which reaps every child that has exited by then
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGCHLD)
+	defer signal.Stop(signals)
+
+	ticker := time.NewTicker(r.Interval)
+	defer ticker.Stop()
+
+	for {
+		r.Reap()
+
+		select {
+		case <-signals:
+		case <-ticker.C:
+		case <-stop:
+			return
+		}
+	}
+}
+
+// Reap waits for every child which has exited, without blocking, and
+// returns the number reaped.
+func (r *Reaper) Reap() int {
+	reaped := 0
+	for {
+		var status syscall.WaitStatus
+		pid, err := syscall.Wait4(-1, &status, syscall.WNOHANG, nil)
+		if err == syscall.EINTR {
+			continue
+		}
+
+		if err != nil || pid <= 0 {
+			return reaped
+		}
+
+		reaped++
+		if r.OnExit != nil {
+			r.OnExit(pid, status)
+		}
+	}
+}
@@ -0,0 +1,116 @@
+package reaper_test
+
+import (
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/cloudfoundry-incubator/guardian/rundmc/reaper"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Reaper", func() {
+	var (
+		mu     sync.Mutex
+		exited map[int]syscall.WaitStatus
+		r      *reaper.Reaper
+	)
+
+	forkExec := func(args ...string) int {
+		pid, err := syscall.ForkExec(args[0], args, &syscall.ProcAttr{})
+		Expect(err).NotTo(HaveOccurred())
+		return pid
+	}
+
+	reapedCount := func() int {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(exited)
+	}
+
+	BeforeEach(func() {
+		exited = make(map[int]syscall.WaitStatus)
+		r = &reaper.Reaper{
+			Interval: 100 * time.Millisecond,
+			OnExit: func(pid int, status syscall.WaitStatus) {
+				mu.Lock()
+				defer mu.Unlock()
+				exited[pid] = status
+			},
+		}
+	})
+
+	Describe("Reap", func() {
+		It("returns immediately when there are no exited children", func() {
+			Expect(r.Reap()).To(Equal(0))
+		})
+
+		It("reaps every exited child and reports its exit status", func() {
+			pids := []int{
+				forkExec("/bin/sh", "-c", "exit 3"),
+				forkExec("/bin/sh", "-c", "exit 4"),
+			}
+
+			Eventually(r.Reap).Should(BeNumerically(">", 0))
+			Eventually(func() int { r.Reap(); return reapedCount() }).Should(Equal(2))
+
+			Expect(exited[pids[0]].ExitStatus()).To(Equal(3))
+			Expect(exited[pids[1]].ExitStatus()).To(Equal(4))
+		})
+
+		It("does not wait for children which are still running", func() {
+			pid := forkExec("/bin/sleep", "10")
+			defer func() {
+				syscall.Kill(pid, syscall.SIGKILL)
+				Eventually(func() int { r.Reap(); return reapedCount() }).Should(Equal(1))
+			}()
+
+			done := make(chan int)
+			go func() { done <- r.Reap() }()
+			Eventually(done).Should(Receive(Equal(0)))
+		})
+	})
+
+	Describe("Run", func() {
+		var stop chan struct{}
+
+		BeforeEach(func() {
+			stop = make(chan struct{})
+		})
+
+		AfterEach(func() {
+			close(stop)
+		})
+
+		It("reaps children as they exit", func() {
+			go r.Run(stop)
+
+			pid := forkExec("/bin/true")
+			Eventually(func() bool {
+				mu.Lock()
+				defer mu.Unlock()
+				_, ok := exited[pid]
+				return ok
+			}).Should(BeTrue())
+		})
+
+		It("does not lose exits when thousands of children exit at once", func() {
+			go r.Run(stop)
+
+			const children = 2000
+			pids := make([]int, children)
+			for i := range pids {
+				pids[i] = forkExec("/bin/true")
+			}
+
+			Eventually(reapedCount, 30*time.Second).Should(Equal(children))
+
+			mu.Lock()
+			defer mu.Unlock()
+			for _, pid := range pids {
+				Expect(exited).To(HaveKey(pid))
+			}
+		})
+	})
+})
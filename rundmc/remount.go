@@ -0,0 +1,51 @@
+package rundmc
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+
+	"github.com/cloudfoundry/gunk/command_runner"
+	"github.com/pivotal-golang/lager"
+)
+
+// RootFSRemounter remounts a running container's root filesystem read-only
+// from inside its mount namespace. Remounting the rootfs in the host's mount
+// namespace would not do: remounts do not propagate to the container's own
+// mount of it.
+//
+// The remount is done by the remount helper (rundmc/remount), which joins the
+// container's mount namespace and makes the mount syscall itself: running
+// mount from inside the namespace would run the container's own binary as
+// root on the host.
+type RootFSRemounter struct {
+	RemountBin    string
+	Stater        ContainerStater
+	CommandRunner command_runner.CommandRunner
+}
+
+func (r *RootFSRemounter) RemountReadOnly(log lager.Logger, handle string) error {
+	log = log.Session("remount-read-only", lager.Data{"handle": handle})
+
+	log.Info("started")
+	defer log.Info("finished")
+
+	state, err := r.Stater.State(log, handle)
+	if err != nil {
+		log.Error("check-pid-failed", err)
+		return fmt.Errorf("remount read-only: pid not found for container")
+	}
+
+	var stderr bytes.Buffer
+	cmd := exec.Command(r.RemountBin, strconv.Itoa(state.Pid))
+	cmd.Stderr = &stderr
+
+	if err := r.CommandRunner.Run(cmd); err != nil {
+		log.Error("remount-failed", err, lager.Data{"stderr": stderr.String()})
+		return fmt.Errorf("remount read-only: %s: %s", err, strings.TrimSpace(stderr.String()))
+	}
+
+	return nil
+}
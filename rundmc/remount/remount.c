@@ -0,0 +1,66 @@
+/*
+ * This executable remounts a container's root filesystem read-only.
+ *
+ * It switches to the container's mount namespace and makes the mount syscall
+ * itself, so that nothing from the container's rootfs (such as its own
+ * mount binary) is ever executed on the host's behalf.
+ */
+
+#include <stdio.h>
+#include <sys/param.h>
+#include <sys/mount.h>
+#include <sys/types.h>
+#include <sys/stat.h>
+#include <fcntl.h>
+#include <unistd.h>
+#include <linux/sched.h>
+
+/* nothing seems to define this... */
+int setns(int fd, int nstype);
+
+int main(int argc, char **argv) {
+  int rv;
+  int mntnsfd;
+  int tpid;
+
+  if(argc != 2) {
+    fprintf(stderr, "Usage: %s <container pid>\n", argv[0]);
+    return 1;
+  }
+
+  rv = sscanf(argv[1], "%d", &tpid);
+  if(rv != 1 || tpid <= 0) {
+    fprintf(stderr, "invalid pid\n");
+    return 1;
+  }
+
+  char mntnspath[PATH_MAX];
+  rv = snprintf(mntnspath, sizeof(mntnspath), "/proc/%u/ns/mnt", tpid);
+  if(rv == -1) {
+    perror("snprintf ns mnt path");
+    return 1;
+  }
+
+  mntnsfd = open(mntnspath, O_RDONLY|O_CLOEXEC);
+  if(mntnsfd == -1) {
+    perror("open mnt namespace");
+    return 1;
+  }
+
+  /* switch to container's mount namespace; this also moves our root to the
+   * namespace's root, i.e. the container's rootfs */
+  rv = setns(mntnsfd, CLONE_NEWNS);
+  if(rv == -1) {
+    perror("setns");
+    return 1;
+  }
+  close(mntnsfd);
+
+  rv = mount(NULL, "/", NULL, MS_REMOUNT|MS_BIND|MS_RDONLY, NULL);
+  if(rv == -1) {
+    perror("remount");
+    return 1;
+  }
+
+  return 0;
+}
@@ -0,0 +1,64 @@
+package rundmc_test
+
+import (
+	"errors"
+	"os/exec"
+
+	"github.com/cloudfoundry-incubator/guardian/rundmc"
+	"github.com/cloudfoundry-incubator/guardian/rundmc/fakes"
+	"github.com/cloudfoundry/gunk/command_runner/fake_command_runner"
+	. "github.com/cloudfoundry/gunk/command_runner/fake_command_runner/matchers"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/pivotal-golang/lager/lagertest"
+)
+
+var _ = Describe("RootFSRemounter", func() {
+	var (
+		commandRunner *fake_command_runner.FakeCommandRunner
+		fakeStater    *fakes.FakeContainerStater
+		remounter     *rundmc.RootFSRemounter
+	)
+
+	BeforeEach(func() {
+		commandRunner = fake_command_runner.New()
+		fakeStater = new(fakes.FakeContainerStater)
+		fakeStater.StateReturns(rundmc.State{Pid: 42}, nil)
+
+		remounter = &rundmc.RootFSRemounter{
+			RemountBin:    "/path/to/remount",
+			Stater:        fakeStater,
+			CommandRunner: commandRunner,
+		}
+	})
+
+	It("remounts the root filesystem read-only in the container's mount namespace", func() {
+		Expect(remounter.RemountReadOnly(lagertest.NewTestLogger("test"), "some-handle")).To(Succeed())
+
+		_, handle := fakeStater.StateArgsForCall(0)
+		Expect(handle).To(Equal("some-handle"))
+		Expect(commandRunner).To(HaveExecutedSerially(fake_command_runner.CommandSpec{
+			Path: "/path/to/remount",
+			Args: []string{"42"},
+		}))
+	})
+
+	Context("when the container has no pid", func() {
+		It("returns an error", func() {
+			fakeStater.StateReturns(rundmc.State{}, errors.New("no state"))
+
+			Expect(remounter.RemountReadOnly(lagertest.NewTestLogger("test"), "some-handle")).To(MatchError("remount read-only: pid not found for container"))
+		})
+	})
+
+	Context("when the remount fails", func() {
+		It("returns the error with the helper's output", func() {
+			commandRunner.WhenRunning(fake_command_runner.CommandSpec{}, func(cmd *exec.Cmd) error {
+				cmd.Stderr.Write([]byte("remount: Permission denied\n"))
+				return errors.New("exit status 32")
+			})
+
+			Expect(remounter.RemountReadOnly(lagertest.NewTestLogger("test"), "some-handle")).To(MatchError("remount read-only: exit status 32: remount: Permission denied"))
+		})
+	})
+})
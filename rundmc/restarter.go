@@ -0,0 +1,357 @@
+package rundmc
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cloudfoundry-incubator/garden"
+	"github.com/cloudfoundry-incubator/guardian/gardener"
+	"github.com/cloudfoundry-incubator/guardian/logging"
+	"github.com/pivotal-golang/clock"
+	"github.com/pivotal-golang/lager"
+)
+
+// RestartPolicyFile is the file in a container's bundle which holds the
+// restart policy of its init process, so that the policy survives restarts of
+// guardian along with the rest of the bundle
+const RestartPolicyFile = "restart-policy"
+
+// SaveRestartPolicy records the restart policy of the init process of the
+// bundle at bundlePath
+func SaveRestartPolicy(bundlePath, policy string) error {
+	return ioutil.WriteFile(filepath.Join(bundlePath, RestartPolicyFile), []byte(policy), 0644)
+}
+
+// LoadRestartPolicy returns the restart policy of the init process of the
+// bundle at bundlePath; bundles without one are never restarted
+func LoadRestartPolicy(bundlePath string) (string, error) {
+	contents, err := ioutil.ReadFile(filepath.Join(bundlePath, RestartPolicyFile))
+	if os.IsNotExist(err) {
+		return gardener.RestartNever, nil
+	}
+
+	if err != nil {
+		return "", fmt.Errorf("read restart policy: %s", err)
+	}
+
+	policy := strings.TrimSpace(string(contents))
+	switch policy {
+	case gardener.RestartNever, gardener.RestartOnFailure, gardener.RestartAlways:
+		return policy, nil
+	default:
+		return "", fmt.Errorf("invalid restart policy: '%s'", policy)
+	}
+}
+
+// RestartingRunner is a BundleRunner which supervises the init processes of
+// containers whose bundles have a restart policy, deleting and re-starting
+// the container with the same bundle when its init process exits. Restarts
+// are delayed by a backoff which doubles from InitialBackoff up to
+// MaxBackoff, and is reset once the process has run for MaxBackoff. The
+// process returned by Start keeps the ID of the first run, and its Wait
+// returns only once the process exits without being restarted. Killing or
+// deleting the container stops its restarts. An EventProcessRestart is
+// published for each restart.
+type RestartingRunner struct {
+	BundleRunner
+
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+	Clock          clock.Clock
+
+	// Publisher is optional
+	Publisher gardener.EventPublisher
+
+	mu         sync.Mutex
+	supervised map[string]*supervisedProcess
+}
+
+func (r *RestartingRunner) Start(log lager.Logger, bundlePath, id string, io garden.ProcessIO) (garden.Process, error) {
+	policy, err := LoadRestartPolicy(bundlePath)
+	if err != nil {
+		return nil, err
+	}
+
+	process, err := r.BundleRunner.Start(log, bundlePath, id, io)
+	if err != nil || policy == gardener.RestartNever {
+		return process, err
+	}
+
+	return r.supervise(log, bundlePath, id, policy, process, 0), nil
+}
+
+// Restart re-starts the container whose init process has died while guardian
+// was not supervising it, e.g. because it exited while guardian was
+// restarting, if the container's restart policy asks for it. Its exit status
+// is lost, so both RestartOnFailure and RestartAlways re-start it. It returns
+// whether the container was restarted, or is being restarted by its
+// supervisor; containers whose supervisor has let them exit are not.
+func (r *RestartingRunner) Restart(log lager.Logger, bundlePath, id string) (bool, error) {
+	log = log.Session("restart", lager.Data{"id": id})
+
+	policy, err := LoadRestartPolicy(bundlePath)
+	if err != nil || policy == gardener.RestartNever {
+		return false, err
+	}
+
+	if supervised, ok := r.lookup(id); ok {
+		return !supervised.hasExited(), nil
+	}
+
+	process, err := r.rerun(log, bundlePath, id)
+	if err != nil {
+		log.Error("restart-failed", err)
+		return false, fmt.Errorf("restart process: %s", err)
+	}
+
+	supervised := r.supervise(log, bundlePath, id, policy, process, 1)
+	r.publish(id, supervised.id, 0, fmt.Errorf("exit status lost"), 1)
+
+	return true, nil
+}
+
+// Kill stops the container being restarted before killing it
+func (r *RestartingRunner) Kill(log lager.Logger, id string) error {
+	r.stop(id)
+	return r.BundleRunner.Kill(log, id)
+}
+
+// Delete stops the container being restarted before deleting it
+func (r *RestartingRunner) Delete(log lager.Logger, id string) error {
+	r.stop(id)
+	return r.BundleRunner.Delete(log, id)
+}
+
+func (r *RestartingRunner) supervise(log lager.Logger, bundlePath, id, policy string, process garden.Process, restarts int) *supervisedProcess {
+	supervised := &supervisedProcess{
+		Process: process,
+		id:      process.ID(),
+		exited:  make(chan struct{}),
+		stopped: make(chan struct{}),
+	}
+
+	r.mu.Lock()
+	if r.supervised == nil {
+		r.supervised = map[string]*supervisedProcess{}
+	}
+	r.supervised[id] = supervised
+	r.mu.Unlock()
+
+	go r.restartUntilExited(log.Session("supervise", lager.Data{"id": id, "process-id": supervised.id, "policy": policy}), bundlePath, id, supervised, policy, restarts)
+
+	return supervised
+}
+
+func (r *RestartingRunner) restartUntilExited(log lager.Logger, bundlePath, id string, process *supervisedProcess, policy string, restarts int) {
+	backoff := r.InitialBackoff
+
+	for {
+		started := r.Clock.Now()
+		exitStatus, err := process.current().Wait()
+
+		if !shouldRestart(policy, exitStatus, err) || process.isStopped() {
+			process.exit(exitStatus, err)
+			return
+		}
+
+		if r.Clock.Since(started) >= r.MaxBackoff {
+			backoff = r.InitialBackoff
+		}
+
+		log.Info("restarting", lager.Data{"exit-status": exitStatus, "backoff": backoff.String()})
+		select {
+		case <-r.Clock.NewTimer(backoff).C():
+		case <-process.stopped:
+			process.exit(exitStatus, err)
+			return
+		}
+
+		// the container is not re-run once it has been stopped, and is not
+		// stopped while it is being re-run, so that it cannot be re-run
+		// after it has been killed
+		process.restartMu.Lock()
+		if process.isStopped() {
+			process.restartMu.Unlock()
+			process.exit(exitStatus, err)
+			return
+		}
+
+		next, runErr := r.rerun(log, bundlePath, id)
+		process.restartMu.Unlock()
+		if runErr != nil {
+			log.Error("restart-failed", runErr)
+			process.exit(exitStatus, fmt.Errorf("restart process: %s", runErr))
+			return
+		}
+
+		restarts++
+		process.replace(next)
+		r.publish(id, process.id, exitStatus, err, restarts)
+
+		backoff *= 2
+		if backoff > r.MaxBackoff {
+			backoff = r.MaxBackoff
+		}
+	}
+}
+
+// rerun deletes the container whose init process has exited, so that runc
+// will start it again from its bundle. The output of the new init process
+// goes to the log, as nothing waits to check it.
+func (r *RestartingRunner) rerun(log lager.Logger, bundlePath, id string) (garden.Process, error) {
+	if err := r.BundleRunner.Delete(log, id); err != nil {
+		return nil, err
+	}
+
+	return r.BundleRunner.Start(log, bundlePath, id, garden.ProcessIO{
+		Stdout: logging.Writer(log),
+		Stderr: logging.Writer(log),
+	})
+}
+
+func (r *RestartingRunner) lookup(id string) (*supervisedProcess, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	process, ok := r.supervised[id]
+	return process, ok
+}
+
+// stop stops the container being restarted and forgets its supervisor
+func (r *RestartingRunner) stop(id string) {
+	r.mu.Lock()
+	process, ok := r.supervised[id]
+	delete(r.supervised, id)
+	r.mu.Unlock()
+
+	if ok {
+		process.stop()
+	}
+}
+
+func (r *RestartingRunner) publish(handle, processID string, exitStatus int, err error, restarts int) {
+	if r.Publisher == nil {
+		return
+	}
+
+	data := map[string]string{
+		"process-id": processID,
+		"restarts":   strconv.Itoa(restarts),
+	}
+	if err != nil {
+		data["error"] = err.Error()
+	} else {
+		data["exit-status"] = strconv.Itoa(exitStatus)
+	}
+
+	r.Publisher.Publish(gardener.Event{Handle: handle, Type: gardener.EventProcessRestart, Data: data})
+}
+
+func shouldRestart(policy string, exitStatus int, err error) bool {
+	switch policy {
+	case gardener.RestartAlways:
+		return true
+	case gardener.RestartOnFailure:
+		return err != nil || exitStatus != 0
+	default:
+		return false
+	}
+}
+
+// supervisedProcess stands in for whichever run of a supervised init process
+// is current
+type supervisedProcess struct {
+	garden.Process
+
+	id string
+
+	mu         sync.Mutex
+	exitStatus int
+	exitErr    error
+	exited     chan struct{}
+	stopped    chan struct{}
+	stopOnce   sync.Once
+	restartMu  sync.Mutex
+}
+
+func (p *supervisedProcess) ID() string {
+	return p.id
+}
+
+func (p *supervisedProcess) Wait() (int, error) {
+	<-p.exited
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.exitStatus, p.exitErr
+}
+
+func (p *supervisedProcess) SetTTY(tty garden.TTYSpec) error {
+	return p.current().SetTTY(tty)
+}
+
+// Signal signals the current run of the process. Killing or terminating it
+// stops it being restarted.
+func (p *supervisedProcess) Signal(signal garden.Signal) error {
+	if signal == garden.SignalKill || signal == garden.SignalTerminate {
+		p.stop()
+	}
+
+	return p.current().Signal(signal)
+}
+
+func (p *supervisedProcess) current() garden.Process {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.Process
+}
+
+func (p *supervisedProcess) replace(process garden.Process) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.Process = process
+}
+
+// stop stops the process being restarted, waiting for any restart in
+// progress to finish
+func (p *supervisedProcess) stop() {
+	p.restartMu.Lock()
+	defer p.restartMu.Unlock()
+
+	p.stopOnce.Do(func() { close(p.stopped) })
+}
+
+func (p *supervisedProcess) isStopped() bool {
+	select {
+	case <-p.stopped:
+		return true
+	default:
+		return false
+	}
+}
+
+func (p *supervisedProcess) hasExited() bool {
+	select {
+	case <-p.exited:
+		return true
+	default:
+		return false
+	}
+}
+
+func (p *supervisedProcess) exit(exitStatus int, err error) {
+	p.mu.Lock()
+	p.exitStatus, p.exitErr = exitStatus, err
+	p.mu.Unlock()
+
+	close(p.exited)
+}
@@ -0,0 +1,335 @@
+package rundmc_test
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/cloudfoundry-incubator/garden"
+	gardenfakes "github.com/cloudfoundry-incubator/garden/fakes"
+	"github.com/cloudfoundry-incubator/guardian/gardener"
+	gardenerfakes "github.com/cloudfoundry-incubator/guardian/gardener/fakes"
+	"github.com/cloudfoundry-incubator/guardian/rundmc"
+	"github.com/cloudfoundry-incubator/guardian/rundmc/fakes"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/pivotal-golang/clock/fakeclock"
+	"github.com/pivotal-golang/lager"
+	"github.com/pivotal-golang/lager/lagertest"
+)
+
+var _ = Describe("RestartingRunner", func() {
+	var (
+		logger        *lagertest.TestLogger
+		fakeRunner    *fakes.FakeBundleRunner
+		fakeClock     *fakeclock.FakeClock
+		fakePublisher *gardenerfakes.FakeEventPublisher
+		runner        *rundmc.RestartingRunner
+		bundlePath    string
+
+		mu        sync.Mutex
+		exits     []chan int
+		processes []*gardenfakes.FakeProcess
+	)
+
+	BeforeEach(func() {
+		logger = lagertest.NewTestLogger("test")
+		fakeRunner = new(fakes.FakeBundleRunner)
+		fakeClock = fakeclock.NewFakeClock(time.Now())
+		fakePublisher = new(gardenerfakes.FakeEventPublisher)
+
+		var err error
+		bundlePath, err = ioutil.TempDir("", "bundle")
+		Expect(err).NotTo(HaveOccurred())
+
+		exits = nil
+		processes = nil
+		fakeRunner.StartStub = func(lager.Logger, string, string, garden.ProcessIO) (garden.Process, error) {
+			mu.Lock()
+			defer mu.Unlock()
+
+			exit := make(chan int, 1)
+			exits = append(exits, exit)
+
+			process := new(gardenfakes.FakeProcess)
+			process.IDReturns("run-" + string('0'+rune(len(exits))))
+			process.WaitStub = func() (int, error) {
+				return <-exit, nil
+			}
+			processes = append(processes, process)
+			return process, nil
+		}
+
+		runner = &rundmc.RestartingRunner{
+			BundleRunner:   fakeRunner,
+			InitialBackoff: time.Second,
+			MaxBackoff:     4 * time.Second,
+			Clock:          fakeClock,
+			Publisher:      fakePublisher,
+		}
+	})
+
+	AfterEach(func() {
+		Expect(os.RemoveAll(bundlePath)).To(Succeed())
+	})
+
+	exitRun := func(run, status int) {
+		Eventually(func() int {
+			mu.Lock()
+			defer mu.Unlock()
+			return len(exits)
+		}).Should(BeNumerically(">", run))
+
+		mu.Lock()
+		defer mu.Unlock()
+		exits[run] <- status
+	}
+
+	start := func(policy string) garden.Process {
+		Expect(rundmc.SaveRestartPolicy(bundlePath, policy)).To(Succeed())
+
+		process, err := runner.Start(logger, bundlePath, "some-handle", garden.ProcessIO{})
+		Expect(err).NotTo(HaveOccurred())
+		return process
+	}
+
+	waitFor := func(process garden.Process) chan int {
+		statuses := make(chan int, 1)
+		go func() {
+			status, _ := process.Wait()
+			statuses <- status
+		}()
+		return statuses
+	}
+
+	It("passes execs on without supervising them", func() {
+		Expect(rundmc.SaveRestartPolicy(bundlePath, gardener.RestartAlways)).To(Succeed())
+		fakeRunner.ExecReturns(new(gardenfakes.FakeProcess), nil)
+
+		_, err := runner.Exec(logger, bundlePath, "some-handle", garden.ProcessSpec{Path: "server"}, garden.ProcessIO{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(fakeRunner.ExecCallCount()).To(Equal(1))
+		Expect(fakeRunner.StartCallCount()).To(Equal(0))
+	})
+
+	Context("when the bundle has no restart policy", func() {
+		It("returns the init process unsupervised", func() {
+			process, err := runner.Start(logger, bundlePath, "some-handle", garden.ProcessIO{})
+			Expect(err).NotTo(HaveOccurred())
+
+			statuses := waitFor(process)
+			exitRun(0, 1)
+			Eventually(statuses).Should(Receive(Equal(1)))
+			Consistently(fakeRunner.StartCallCount).Should(Equal(1))
+		})
+	})
+
+	Context("when the restart policy is invalid", func() {
+		It("returns an error without starting the container", func() {
+			Expect(ioutil.WriteFile(filepath.Join(bundlePath, rundmc.RestartPolicyFile), []byte("sometimes"), 0644)).To(Succeed())
+
+			_, err := runner.Start(logger, bundlePath, "some-handle", garden.ProcessIO{})
+			Expect(err).To(MatchError("invalid restart policy: 'sometimes'"))
+			Expect(fakeRunner.StartCallCount()).To(Equal(0))
+		})
+	})
+
+	Context("when the container fails to start", func() {
+		It("returns the error", func() {
+			fakeRunner.StartStub = nil
+			fakeRunner.StartReturns(nil, errors.New("no runc"))
+
+			Expect(rundmc.SaveRestartPolicy(bundlePath, gardener.RestartAlways)).To(Succeed())
+			_, err := runner.Start(logger, bundlePath, "some-handle", garden.ProcessIO{})
+			Expect(err).To(MatchError("no runc"))
+		})
+	})
+
+	Context("with the on-failure policy", func() {
+		It("deletes and re-starts the container after a backoff when its init process fails", func() {
+			process := start(gardener.RestartOnFailure)
+			exitRun(0, 1)
+
+			fakeClock.WaitForWatcherAndIncrement(999 * time.Millisecond)
+			Consistently(fakeRunner.StartCallCount).Should(Equal(1))
+
+			fakeClock.Increment(time.Millisecond)
+			Eventually(fakeRunner.StartCallCount).Should(Equal(2))
+			_, path, id, _ := fakeRunner.StartArgsForCall(1)
+			Expect(path).To(Equal(bundlePath))
+			Expect(id).To(Equal("some-handle"))
+
+			Expect(fakeRunner.DeleteCallCount()).To(Equal(1))
+			_, deleted := fakeRunner.DeleteArgsForCall(0)
+			Expect(deleted).To(Equal("some-handle"))
+
+			Expect(process.ID()).To(Equal("run-1"))
+		})
+
+		It("publishes an event for each restart", func() {
+			start(gardener.RestartOnFailure)
+			exitRun(0, 3)
+			fakeClock.WaitForWatcherAndIncrement(time.Second)
+
+			Eventually(fakePublisher.PublishCallCount).Should(Equal(1))
+			Expect(fakePublisher.PublishArgsForCall(0)).To(Equal(gardener.Event{
+				Handle: "some-handle",
+				Type:   gardener.EventProcessRestart,
+				Data: map[string]string{
+					"process-id":  "run-1",
+					"exit-status": "3",
+					"restarts":    "1",
+				},
+			}))
+		})
+
+		It("returns the exit status once the init process succeeds", func() {
+			process := start(gardener.RestartOnFailure)
+			statuses := waitFor(process)
+
+			exitRun(0, 1)
+			fakeClock.WaitForWatcherAndIncrement(time.Second)
+			Consistently(statuses).ShouldNot(Receive())
+
+			exitRun(1, 0)
+			Eventually(statuses).Should(Receive(Equal(0)))
+			Expect(fakeRunner.StartCallCount()).To(Equal(2))
+		})
+
+		It("doubles the backoff with each restart, up to the maximum", func() {
+			start(gardener.RestartOnFailure)
+
+			for run, backoff := range []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 4 * time.Second} {
+				exitRun(run, 1)
+				fakeClock.WaitForWatcherAndIncrement(backoff - time.Millisecond)
+				Consistently(fakeRunner.StartCallCount).Should(Equal(run + 1))
+				fakeClock.Increment(time.Millisecond)
+				Eventually(fakeRunner.StartCallCount).Should(Equal(run + 2))
+			}
+		})
+
+		It("resets the backoff once the init process has run for the maximum backoff", func() {
+			start(gardener.RestartOnFailure)
+
+			exitRun(0, 1)
+			fakeClock.WaitForWatcherAndIncrement(time.Second)
+			Eventually(fakeRunner.StartCallCount).Should(Equal(2))
+			Eventually(func() int {
+				mu.Lock()
+				defer mu.Unlock()
+				return processes[1].WaitCallCount()
+			}).Should(Equal(1))
+
+			fakeClock.Increment(4 * time.Second)
+			exitRun(1, 1)
+			fakeClock.WaitForWatcherAndIncrement(time.Second)
+			Eventually(fakeRunner.StartCallCount).Should(Equal(3))
+		})
+
+		Context("when re-starting the container fails", func() {
+			It("returns the error from Wait", func() {
+				process := start(gardener.RestartOnFailure)
+				fakeRunner.StartStub = nil
+				fakeRunner.StartReturns(nil, errors.New("bundle gone"))
+
+				exitRun(0, 1)
+				fakeClock.WaitForWatcherAndIncrement(time.Second)
+
+				_, err := process.Wait()
+				Expect(err).To(MatchError("restart process: bundle gone"))
+			})
+		})
+	})
+
+	Context("with the always policy", func() {
+		It("re-starts the container even when its init process succeeds", func() {
+			start(gardener.RestartAlways)
+			exitRun(0, 0)
+
+			fakeClock.WaitForWatcherAndIncrement(time.Second)
+			Eventually(fakeRunner.StartCallCount).Should(Equal(2))
+		})
+
+		It("stops re-starting the container once it is killed", func() {
+			process := start(gardener.RestartAlways)
+			statuses := waitFor(process)
+
+			Expect(runner.Kill(logger, "some-handle")).To(Succeed())
+			Expect(fakeRunner.KillCallCount()).To(Equal(1))
+			exitRun(0, 137)
+
+			Eventually(statuses).Should(Receive(Equal(137)))
+			Expect(fakeRunner.StartCallCount()).To(Equal(1))
+		})
+
+		It("stops waiting to re-start the container once it is deleted", func() {
+			process := start(gardener.RestartAlways)
+			statuses := waitFor(process)
+
+			exitRun(0, 0)
+			fakeClock.WaitForWatcher()
+			Expect(runner.Delete(logger, "some-handle")).To(Succeed())
+
+			Eventually(statuses).Should(Receive(Equal(0)))
+			Expect(fakeRunner.StartCallCount()).To(Equal(1))
+		})
+	})
+
+	Describe("Restart", func() {
+		It("deletes and re-starts a container whose init process died while it was not supervised", func() {
+			Expect(rundmc.SaveRestartPolicy(bundlePath, gardener.RestartOnFailure)).To(Succeed())
+
+			restarted, err := runner.Restart(logger, bundlePath, "some-handle")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(restarted).To(BeTrue())
+
+			Expect(fakeRunner.DeleteCallCount()).To(Equal(1))
+			Expect(fakeRunner.StartCallCount()).To(Equal(1))
+			Expect(fakePublisher.PublishCallCount()).To(Equal(1))
+			Expect(fakePublisher.PublishArgsForCall(0).Data).To(HaveKeyWithValue("error", "exit status lost"))
+		})
+
+		It("supervises the re-started container", func() {
+			Expect(rundmc.SaveRestartPolicy(bundlePath, gardener.RestartAlways)).To(Succeed())
+			_, err := runner.Restart(logger, bundlePath, "some-handle")
+			Expect(err).NotTo(HaveOccurred())
+
+			exitRun(0, 0)
+			fakeClock.WaitForWatcherAndIncrement(time.Second)
+			Eventually(fakeRunner.StartCallCount).Should(Equal(2))
+		})
+
+		It("leaves a container its supervisor is about to re-start to the supervisor", func() {
+			start(gardener.RestartAlways)
+			exitRun(0, 1)
+			fakeClock.WaitForWatcher()
+
+			restarted, err := runner.Restart(logger, bundlePath, "some-handle")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(restarted).To(BeTrue())
+			Expect(fakeRunner.StartCallCount()).To(Equal(1))
+		})
+
+		It("does not re-start a container whose supervisor let it exit", func() {
+			process := start(gardener.RestartOnFailure)
+			exitRun(0, 0)
+			_, err := process.Wait()
+			Expect(err).NotTo(HaveOccurred())
+
+			restarted, err := runner.Restart(logger, bundlePath, "some-handle")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(restarted).To(BeFalse())
+			Expect(fakeRunner.StartCallCount()).To(Equal(1))
+		})
+
+		It("does not re-start a container without a restart policy", func() {
+			restarted, err := runner.Restart(logger, bundlePath, "some-handle")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(restarted).To(BeFalse())
+			Expect(fakeRunner.StartCallCount()).To(Equal(0))
+		})
+	})
+})
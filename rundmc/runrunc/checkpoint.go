@@ -0,0 +1,100 @@
+package runrunc
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+
+	"github.com/cloudfoundry-incubator/garden"
+	"github.com/cloudfoundry/gunk/command_runner"
+	"github.com/pivotal-golang/lager"
+)
+
+//go:generate counterfeiter . CheckpointBinary
+type CheckpointBinary interface {
+	CheckpointCommand(id, imagePath string) *exec.Cmd
+	RestoreCommand(bundlePath, id, imagePath string) *exec.Cmd
+}
+
+// RuncCheckpointBinary builds 'runc checkpoint' and 'runc restore' commands,
+// which use CRIU to dump and restore the container's processes.
+type RuncCheckpointBinary string
+
+func (runc RuncCheckpointBinary) CheckpointCommand(id, imagePath string) *exec.Cmd {
+	return exec.Command(string(runc), "--id", id, "checkpoint", "--image-path", imagePath)
+}
+
+func (runc RuncCheckpointBinary) RestoreCommand(bundlePath, id, imagePath string) *exec.Cmd {
+	cmd := exec.Command(string(runc), "--id", id, "restore", "--image-path", imagePath)
+	cmd.Dir = bundlePath
+	return cmd
+}
+
+// Checkpointer checkpoints and restores containers using runc
+type Checkpointer struct {
+	tracker       ProcessTracker
+	commandRunner command_runner.CommandRunner
+	pidGenerator  UidGenerator
+	runc          CheckpointBinary
+	verifier      BinaryVerifier
+}
+
+func NewCheckpointer(tracker ProcessTracker, runner command_runner.CommandRunner, pidgen UidGenerator, runc CheckpointBinary, verifier BinaryVerifier) *Checkpointer {
+	return &Checkpointer{
+		tracker:       tracker,
+		commandRunner: runner,
+		pidGenerator:  pidgen,
+		runc:          runc,
+		verifier:      verifier,
+	}
+}
+
+// Checkpoint dumps the container's processes to imagePath using 'runc
+// checkpoint'. The container is stopped once it has been checkpointed.
+func (c *Checkpointer) Checkpoint(log lager.Logger, id, imagePath string) error {
+	log = log.Session("checkpoint", lager.Data{"id": id, "image-path": imagePath})
+
+	log.Info("started")
+	defer log.Info("finished")
+
+	if err := c.verifier.Verify(); err != nil {
+		log.Error("verify-runtime-binary", err)
+		return err
+	}
+
+	buf := &bytes.Buffer{}
+	cmd := c.runc.CheckpointCommand(id, imagePath)
+	cmd.Stdout = buf
+	cmd.Stderr = buf
+	if err := c.commandRunner.Run(cmd); err != nil {
+		log.Error("run-failed", err, lager.Data{"output": buf.String()})
+		return fmt.Errorf("runc checkpoint: %s: %s", err, buf.String())
+	}
+
+	return nil
+}
+
+// Restore restores the container in the bundle from the images in imagePath
+// using 'runc restore'. Like Start, the returned process exits when the
+// container's init process does.
+func (c *Checkpointer) Restore(log lager.Logger, bundlePath, id, imagePath string, io garden.ProcessIO) (garden.Process, error) {
+	log = log.Session("restore", lager.Data{"bundle": bundlePath, "image-path": imagePath})
+
+	log.Info("started")
+	defer log.Info("finished")
+
+	if err := c.verifier.Verify(); err != nil {
+		log.Error("verify-runtime-binary", err)
+		return nil, err
+	}
+
+	cmd := c.runc.RestoreCommand(bundlePath, id, imagePath)
+
+	process, err := c.tracker.Run(c.pidGenerator.Generate(), cmd, io, nil)
+	if err != nil {
+		log.Error("run", err)
+		return nil, err
+	}
+
+	return process, nil
+}
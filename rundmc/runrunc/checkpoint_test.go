@@ -0,0 +1,129 @@
+package runrunc_test
+
+import (
+	"errors"
+	"os/exec"
+
+	"github.com/cloudfoundry-incubator/garden"
+	"github.com/cloudfoundry-incubator/guardian/rundmc/runrunc"
+	"github.com/cloudfoundry-incubator/guardian/rundmc/runrunc/fakes"
+	"github.com/cloudfoundry/gunk/command_runner/fake_command_runner"
+	. "github.com/cloudfoundry/gunk/command_runner/fake_command_runner/matchers"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/pivotal-golang/lager"
+	"github.com/pivotal-golang/lager/lagertest"
+)
+
+var _ = Describe("Checkpointer", func() {
+	var (
+		tracker       *fakes.FakeProcessTracker
+		commandRunner *fake_command_runner.FakeCommandRunner
+		pidGenerator  *fakes.FakeUidGenerator
+		runcBinary    *fakes.FakeCheckpointBinary
+		verifier      *fakes.FakeBinaryVerifier
+		logger        lager.Logger
+
+		checkpointer *runrunc.Checkpointer
+	)
+
+	BeforeEach(func() {
+		tracker = new(fakes.FakeProcessTracker)
+		commandRunner = fake_command_runner.New()
+		pidGenerator = new(fakes.FakeUidGenerator)
+		runcBinary = new(fakes.FakeCheckpointBinary)
+		verifier = new(fakes.FakeBinaryVerifier)
+		logger = lagertest.NewTestLogger("test")
+
+		checkpointer = runrunc.NewCheckpointer(tracker, commandRunner, pidGenerator, runcBinary, verifier)
+
+		runcBinary.CheckpointCommandStub = func(id, imagePath string) *exec.Cmd {
+			return exec.Command("funC", "checkpoint", id, imagePath)
+		}
+
+		runcBinary.RestoreCommandStub = func(bundlePath, id, imagePath string) *exec.Cmd {
+			return exec.Command("funC", "restore", bundlePath, id, imagePath)
+		}
+	})
+
+	Describe("Checkpoint", func() {
+		It("runs 'runc checkpoint' with the image path", func() {
+			Expect(checkpointer.Checkpoint(logger, "some-container", "/path/to/images")).To(Succeed())
+			Expect(commandRunner).To(HaveExecutedSerially(fake_command_runner.CommandSpec{
+				Path: "funC",
+				Args: []string{"checkpoint", "some-container", "/path/to/images"},
+			}))
+		})
+
+		It("returns any output when 'runc checkpoint' fails", func() {
+			commandRunner.WhenRunning(fake_command_runner.CommandSpec{}, func(cmd *exec.Cmd) error {
+				cmd.Stderr.Write([]byte("criu failed"))
+				return errors.New("exit status banana")
+			})
+
+			Expect(checkpointer.Checkpoint(logger, "some-container", "/path/to/images")).To(
+				MatchError("runc checkpoint: exit status banana: criu failed"),
+			)
+		})
+
+		Context("when the runtime binary fails verification", func() {
+			BeforeEach(func() {
+				verifier.VerifyReturns(errors.New("tampered"))
+			})
+
+			It("returns the error without running the binary", func() {
+				Expect(checkpointer.Checkpoint(logger, "some-container", "/path/to/images")).To(MatchError("tampered"))
+				Expect(commandRunner.ExecutedCommands()).To(BeEmpty())
+			})
+		})
+	})
+
+	Describe("Restore", func() {
+		It("runs 'runc restore' using the process tracker", func() {
+			pidGenerator.GenerateReturns("some-process-guid")
+
+			_, err := checkpointer.Restore(logger, "/some/bundle", "some-container", "/path/to/images", garden.ProcessIO{Stdout: GinkgoWriter})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(tracker.RunCallCount()).To(Equal(1))
+			id, cmd, io, _ := tracker.RunArgsForCall(0)
+			Expect(id).To(Equal("some-process-guid"))
+			Expect(cmd.Args).To(Equal([]string{"funC", "restore", "/some/bundle", "some-container", "/path/to/images"}))
+			Expect(io.Stdout).To(Equal(GinkgoWriter))
+		})
+
+		Context("when the tracker fails to run the process", func() {
+			It("returns the error", func() {
+				tracker.RunReturns(nil, errors.New("boom"))
+
+				_, err := checkpointer.Restore(logger, "/some/bundle", "some-container", "/path/to/images", garden.ProcessIO{})
+				Expect(err).To(MatchError("boom"))
+			})
+		})
+
+		Context("when the runtime binary fails verification", func() {
+			BeforeEach(func() {
+				verifier.VerifyReturns(errors.New("tampered"))
+			})
+
+			It("returns the error without running the binary", func() {
+				_, err := checkpointer.Restore(logger, "/some/bundle", "some-container", "/path/to/images", garden.ProcessIO{})
+				Expect(err).To(MatchError("tampered"))
+				Expect(tracker.RunCallCount()).To(Equal(0))
+			})
+		})
+	})
+
+	Describe("RuncCheckpointBinary", func() {
+		It("builds a checkpoint command", func() {
+			cmd := runrunc.RuncCheckpointBinary("runc").CheckpointCommand("some-id", "/images")
+			Expect(cmd.Args).To(Equal([]string{"runc", "--id", "some-id", "checkpoint", "--image-path", "/images"}))
+		})
+
+		It("builds a restore command which runs in the bundle directory", func() {
+			cmd := runrunc.RuncCheckpointBinary("runc").RestoreCommand("/some/bundle", "some-id", "/images")
+			Expect(cmd.Args).To(Equal([]string{"runc", "--id", "some-id", "restore", "--image-path", "/images"}))
+			Expect(cmd.Dir).To(Equal("/some/bundle"))
+		})
+	})
+})
@@ -0,0 +1,114 @@
+package runrunc
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+)
+
+//go:generate counterfeiter . BinaryVerifier
+type BinaryVerifier interface {
+	Verify() error
+}
+
+// NoopVerifier accepts any runtime binary. It is used when no digest has been
+// configured.
+type NoopVerifier struct{}
+
+func (NoopVerifier) Verify() error {
+	return nil
+}
+
+type TamperedBinaryError struct {
+	Path     string
+	Expected string
+	Actual   string
+}
+
+func (e *TamperedBinaryError) Error() string {
+	return fmt.Sprintf("runtime binary %s has sha256 %s, expected %s: refusing to run it", e.Path, e.Actual, e.Expected)
+}
+
+// ChecksumVerifier checks that the runtime binary matches an expected sha256
+// digest. The result is cached until the binary is invalidated (e.g. by a
+// Watcher) or its inode, size or modification time change, so that each runc
+// invocation does not need to re-hash the binary.
+type ChecksumVerifier struct {
+	path     string
+	expected string
+
+	mu       sync.Mutex
+	verified os.FileInfo
+}
+
+func NewChecksumVerifier(path, sha256Hex string) *ChecksumVerifier {
+	return &ChecksumVerifier{
+		path:     path,
+		expected: strings.ToLower(strings.TrimSpace(sha256Hex)),
+	}
+}
+
+func (v *ChecksumVerifier) Path() string {
+	return v.path
+}
+
+func (v *ChecksumVerifier) Verify() error {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	info, err := os.Stat(v.path)
+	if err != nil {
+		v.verified = nil
+		return fmt.Errorf("verify runtime binary: %s", err)
+	}
+
+	if v.verified != nil && unchanged(v.verified, info) {
+		return nil
+	}
+
+	v.verified = nil
+
+	actual, err := sha256Sum(v.path)
+	if err != nil {
+		return fmt.Errorf("verify runtime binary: %s", err)
+	}
+
+	if actual != v.expected {
+		return &TamperedBinaryError{Path: v.path, Expected: v.expected, Actual: actual}
+	}
+
+	v.verified = info
+	return nil
+}
+
+// Invalidate discards the cached verification result so that the next call to
+// Verify re-hashes the binary.
+func (v *ChecksumVerifier) Invalidate() {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	v.verified = nil
+}
+
+func unchanged(a, b os.FileInfo) bool {
+	return os.SameFile(a, b) && a.Size() == b.Size() && a.ModTime().Equal(b.ModTime())
+}
+
+func sha256Sum(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
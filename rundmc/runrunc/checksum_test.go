@@ -0,0 +1,125 @@
+package runrunc_test
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/cloudfoundry-incubator/guardian/rundmc/runrunc"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+	"github.com/pivotal-golang/lager/lagertest"
+)
+
+var _ = Describe("ChecksumVerifier", func() {
+	var (
+		tmpDir   string
+		binPath  string
+		verifier *runrunc.ChecksumVerifier
+	)
+
+	digest := func(contents string) string {
+		sum := sha256.Sum256([]byte(contents))
+		return hex.EncodeToString(sum[:])
+	}
+
+	BeforeEach(func() {
+		var err error
+		tmpDir, err = ioutil.TempDir("", "runc-checksum")
+		Expect(err).NotTo(HaveOccurred())
+
+		binPath = filepath.Join(tmpDir, "runc")
+		Expect(ioutil.WriteFile(binPath, []byte("genuine runc"), 0755)).To(Succeed())
+
+		verifier = runrunc.NewChecksumVerifier(binPath, digest("genuine runc"))
+	})
+
+	AfterEach(func() {
+		Expect(os.RemoveAll(tmpDir)).To(Succeed())
+	})
+
+	It("succeeds when the binary matches the digest", func() {
+		Expect(verifier.Verify()).To(Succeed())
+	})
+
+	It("accepts an upper-case digest with surrounding whitespace", func() {
+		verifier = runrunc.NewChecksumVerifier(binPath, " "+strings.ToUpper(digest("genuine runc"))+"\n")
+		Expect(verifier.Verify()).To(Succeed())
+	})
+
+	Context("when the binary does not match the digest", func() {
+		BeforeEach(func() {
+			Expect(ioutil.WriteFile(binPath, []byte("evil runc"), 0755)).To(Succeed())
+		})
+
+		It("returns a TamperedBinaryError", func() {
+			err := verifier.Verify()
+			Expect(err).To(BeAssignableToTypeOf(&runrunc.TamperedBinaryError{}))
+			Expect(err.(*runrunc.TamperedBinaryError).Actual).To(Equal(digest("evil runc")))
+			Expect(err.(*runrunc.TamperedBinaryError).Expected).To(Equal(digest("genuine runc")))
+		})
+	})
+
+	Context("when the binary does not exist", func() {
+		BeforeEach(func() {
+			Expect(os.Remove(binPath)).To(Succeed())
+		})
+
+		It("returns an error", func() {
+			Expect(verifier.Verify()).To(MatchError(ContainSubstring("verify runtime binary")))
+		})
+	})
+
+	Context("when the binary has already been verified", func() {
+		BeforeEach(func() {
+			Expect(verifier.Verify()).To(Succeed())
+		})
+
+		It("notices when the binary is modified", func() {
+			Expect(ioutil.WriteFile(binPath, []byte("evil runc, longer"), 0755)).To(Succeed())
+			Expect(verifier.Verify()).NotTo(Succeed())
+		})
+
+		It("notices when the binary is replaced", func() {
+			replacement := filepath.Join(tmpDir, "replacement")
+			Expect(ioutil.WriteFile(replacement, []byte("evil runc"), 0755)).To(Succeed())
+			Expect(os.Rename(replacement, binPath)).To(Succeed())
+
+			Expect(verifier.Verify()).NotTo(Succeed())
+		})
+
+		It("re-hashes the binary once invalidated", func() {
+			info, err := os.Stat(binPath)
+			Expect(err).NotTo(HaveOccurred())
+
+			// same size and mtime, so only invalidation can catch it
+			Expect(ioutil.WriteFile(binPath, []byte("genuine ronc"), 0755)).To(Succeed())
+			Expect(os.Chtimes(binPath, info.ModTime(), info.ModTime())).To(Succeed())
+			Expect(verifier.Verify()).To(Succeed())
+
+			verifier.Invalidate()
+			Expect(verifier.Verify()).NotTo(Succeed())
+		})
+	})
+
+	Describe("Watch", func() {
+		It("re-verifies the binary when it changes", func() {
+			logger := lagertest.NewTestLogger("test")
+			Expect(verifier.Verify()).To(Succeed())
+			Expect(runrunc.Watch(logger, verifier)).To(Succeed())
+
+			info, err := os.Stat(binPath)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(ioutil.WriteFile(binPath, []byte("genuine ronc"), 0755)).To(Succeed())
+			Expect(os.Chtimes(binPath, info.ModTime(), info.ModTime())).To(Succeed())
+
+			Eventually(logger.Buffer(), 5*time.Second).Should(gbytes.Say("verification-failed"))
+			Expect(verifier.Verify()).NotTo(Succeed())
+		})
+	})
+})
@@ -0,0 +1,199 @@
+package runrunc
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"syscall"
+
+	"github.com/cloudfoundry-incubator/garden"
+	"github.com/pivotal-golang/lager"
+)
+
+// ClosedOutputEnv is the environment variable in a ProcessSpec's Env which
+// holds what happens to a process's output once the client streaming it has
+// gone. Without it, output is discarded, except that a process with a tty blocks once the tty's buffer
+// is full.
+const ClosedOutputEnv = "GARDEN_CLOSED_OUTPUT"
+
+// What happens to output written after the client has gone
+const (
+	// ClosedOutputDiscard silently discards it
+	ClosedOutputDiscard = "discard"
+
+	// ClosedOutputSigpipe sends the process SIGPIPE, as if it had written to
+	// a closed pipe
+	ClosedOutputSigpipe = "sigpipe"
+
+	// ClosedOutputBuffer appends it to the process's retention logs, beside
+	// its socket in the container's processes directory
+	ClosedOutputBuffer = "buffer"
+)
+
+// extractClosedOutput removes any ClosedOutputEnv entries from env, returning
+// the remaining environment and the value of the last entry ("" if there is
+// none)
+func extractClosedOutput(env []string) ([]string, string, error) {
+	rest, policy := extractEnv(env, ClosedOutputEnv)
+
+	switch policy {
+	case "", ClosedOutputDiscard, ClosedOutputSigpipe, ClosedOutputBuffer:
+		return rest, policy, nil
+	default:
+		return nil, "", fmt.Errorf("invalid closed output policy: '%s'", policy)
+	}
+}
+
+// closedOutputIO wraps the client's stdout and stderr so that, once writing
+// to them fails, output is handled according to policy. It also returns a
+// function which closes any retention logs, to be called once the process
+// has exited.
+func closedOutputIO(log lager.Logger, processIO garden.ProcessIO, policy, bundlePath, processID string, signaller *pipeSignaller) (garden.ProcessIO, func()) {
+	onClosed := func(stream string) func() io.Writer {
+		return func() io.Writer {
+			log.Info("client-gone", lager.Data{"stream": stream, "policy": policy})
+
+			switch policy {
+			case ClosedOutputSigpipe:
+				signaller.Signal(log)
+			case ClosedOutputBuffer:
+				return retentionLog(log, filepath.Join(bundlePath, "processes", fmt.Sprintf("%s.%s.log", processID, stream)))
+			}
+
+			return nil
+		}
+	}
+
+	var writers []*closedOutputWriter
+	if processIO.Stdout != nil {
+		stdout := &closedOutputWriter{client: processIO.Stdout, onClosed: onClosed("stdout")}
+		processIO.Stdout = stdout
+		writers = append(writers, stdout)
+	}
+
+	if processIO.Stderr != nil {
+		stderr := &closedOutputWriter{client: processIO.Stderr, onClosed: onClosed("stderr")}
+		processIO.Stderr = stderr
+		writers = append(writers, stderr)
+	}
+
+	return processIO, func() {
+		for _, w := range writers {
+			w.Close()
+		}
+	}
+}
+
+func retentionLog(log lager.Logger, path string) io.Writer {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		log.Error("create-retention-log-failed", err)
+		return nil
+	}
+
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		log.Error("create-retention-log-failed", err)
+		return nil
+	}
+
+	return f
+}
+
+// closedOutputWriter writes to the client until that fails, and then to
+// whatever onClosed returns, or nowhere if that is nil. Once it is closed,
+// e.g. because the process has exited, anything else is discarded.
+type closedOutputWriter struct {
+	client   io.Writer
+	onClosed func() io.Writer
+
+	mu       sync.Mutex
+	closed   bool
+	done     bool
+	fallback io.Writer
+}
+
+func (w *closedOutputWriter) Write(data []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.done {
+		return len(data), nil
+	}
+
+	if !w.closed {
+		if _, err := w.client.Write(data); err == nil {
+			return len(data), nil
+		}
+
+		w.closed = true
+		w.fallback = w.onClosed()
+	}
+
+	if w.fallback == nil {
+		return len(data), nil
+	}
+
+	return w.fallback.Write(data)
+}
+
+// Close closes the fallback, if it can be
+func (w *closedOutputWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.done = true
+	if closer, ok := w.fallback.(io.Closer); ok {
+		return closer.Close()
+	}
+
+	return nil
+}
+
+// pipeSignaller sends SIGPIPE to a process once its pid is known, and at
+// most once
+type pipeSignaller struct {
+	mu       sync.Mutex
+	pid      int
+	pending  bool
+	signaled bool
+}
+
+func (s *pipeSignaller) SetPid(log lager.Logger, pid int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.pid = pid
+	if s.pending {
+		s.signal(log)
+	}
+}
+
+func (s *pipeSignaller) Signal(log lager.Logger) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.pid == 0 {
+		s.pending = true
+		return
+	}
+
+	s.signal(log)
+}
+
+func (s *pipeSignaller) signal(log lager.Logger) {
+	if s.signaled {
+		return
+	}
+	s.signaled = true
+
+	process, err := os.FindProcess(s.pid)
+	if err == nil {
+		err = process.Signal(syscall.SIGPIPE)
+	}
+
+	if err != nil {
+		log.Error("sigpipe-failed", err, lager.Data{"pid": s.pid})
+	}
+}
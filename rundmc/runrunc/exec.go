@@ -70,9 +70,19 @@ func (r *ExecPreparer) Prepare(log lager.Logger, id, bundlePath string, spec gar
 		defaultPath = DefaultRootPath
 	}
 
-	env := envWithDefaultPath(append(
-		bndl.Spec.Spec.Process.Env, spec.Env...,
-	), defaultPath)
+	// bundles created before the umask was saved alongside them have it in
+	// their process environment
+	env, rawUmask := extractEnv(append(
+		append([]string{}, bndl.Spec.Spec.Process.Env...), spec.Env...,
+	), UmaskEnv)
+
+	if rawUmask == "" {
+		if rawUmask, err = LoadUmask(bundlePath); err != nil {
+			return nil, err
+		}
+	}
+
+	env = envWithDefaultPath(env, defaultPath)
 
 	env = envWithUser(env, spec.User)
 
@@ -89,7 +99,7 @@ func (r *ExecPreparer) Prepare(log lager.Logger, id, bundlePath string, spec gar
 		return nil, fmt.Errorf("writeProcessJSON: %s", err)
 	}
 
-	return runc.ExecCommand(id, tmpFile.Name()), nil
+	return withUmask(runc.ExecCommand(id, tmpFile.Name()), rawUmask)
 }
 
 func envWithDefaultPath(env []string, defaultPath string) []string {
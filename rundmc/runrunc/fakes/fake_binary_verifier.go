@@ -0,0 +1,43 @@
+// This file was generated by counterfeiter
+package fakes
+
+import (
+	"sync"
+
+	"github.com/cloudfoundry-incubator/guardian/rundmc/runrunc"
+)
+
+type FakeBinaryVerifier struct {
+	VerifyStub        func() error
+	verifyMutex       sync.RWMutex
+	verifyArgsForCall []struct{}
+	verifyReturns     struct {
+		result1 error
+	}
+}
+
+func (fake *FakeBinaryVerifier) Verify() error {
+	fake.verifyMutex.Lock()
+	fake.verifyArgsForCall = append(fake.verifyArgsForCall, struct{}{})
+	fake.verifyMutex.Unlock()
+	if fake.VerifyStub != nil {
+		return fake.VerifyStub()
+	} else {
+		return fake.verifyReturns.result1
+	}
+}
+
+func (fake *FakeBinaryVerifier) VerifyCallCount() int {
+	fake.verifyMutex.RLock()
+	defer fake.verifyMutex.RUnlock()
+	return len(fake.verifyArgsForCall)
+}
+
+func (fake *FakeBinaryVerifier) VerifyReturns(result1 error) {
+	fake.VerifyStub = nil
+	fake.verifyReturns = struct {
+		result1 error
+	}{result1}
+}
+
+var _ runrunc.BinaryVerifier = new(FakeBinaryVerifier)
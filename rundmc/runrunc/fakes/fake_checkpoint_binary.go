@@ -0,0 +1,100 @@
+// This file was generated by counterfeiter
+package fakes
+
+import (
+	"os/exec"
+	"sync"
+
+	"github.com/cloudfoundry-incubator/guardian/rundmc/runrunc"
+)
+
+type FakeCheckpointBinary struct {
+	CheckpointCommandStub        func(id, imagePath string) *exec.Cmd
+	checkpointCommandMutex       sync.RWMutex
+	checkpointCommandArgsForCall []struct {
+		id        string
+		imagePath string
+	}
+	checkpointCommandReturns struct {
+		result1 *exec.Cmd
+	}
+	RestoreCommandStub        func(bundlePath, id, imagePath string) *exec.Cmd
+	restoreCommandMutex       sync.RWMutex
+	restoreCommandArgsForCall []struct {
+		bundlePath string
+		id         string
+		imagePath  string
+	}
+	restoreCommandReturns struct {
+		result1 *exec.Cmd
+	}
+}
+
+func (fake *FakeCheckpointBinary) CheckpointCommand(id string, imagePath string) *exec.Cmd {
+	fake.checkpointCommandMutex.Lock()
+	fake.checkpointCommandArgsForCall = append(fake.checkpointCommandArgsForCall, struct {
+		id        string
+		imagePath string
+	}{id, imagePath})
+	fake.checkpointCommandMutex.Unlock()
+	if fake.CheckpointCommandStub != nil {
+		return fake.CheckpointCommandStub(id, imagePath)
+	} else {
+		return fake.checkpointCommandReturns.result1
+	}
+}
+
+func (fake *FakeCheckpointBinary) CheckpointCommandCallCount() int {
+	fake.checkpointCommandMutex.RLock()
+	defer fake.checkpointCommandMutex.RUnlock()
+	return len(fake.checkpointCommandArgsForCall)
+}
+
+func (fake *FakeCheckpointBinary) CheckpointCommandArgsForCall(i int) (string, string) {
+	fake.checkpointCommandMutex.RLock()
+	defer fake.checkpointCommandMutex.RUnlock()
+	return fake.checkpointCommandArgsForCall[i].id, fake.checkpointCommandArgsForCall[i].imagePath
+}
+
+func (fake *FakeCheckpointBinary) CheckpointCommandReturns(result1 *exec.Cmd) {
+	fake.CheckpointCommandStub = nil
+	fake.checkpointCommandReturns = struct {
+		result1 *exec.Cmd
+	}{result1}
+}
+
+func (fake *FakeCheckpointBinary) RestoreCommand(bundlePath string, id string, imagePath string) *exec.Cmd {
+	fake.restoreCommandMutex.Lock()
+	fake.restoreCommandArgsForCall = append(fake.restoreCommandArgsForCall, struct {
+		bundlePath string
+		id         string
+		imagePath  string
+	}{bundlePath, id, imagePath})
+	fake.restoreCommandMutex.Unlock()
+	if fake.RestoreCommandStub != nil {
+		return fake.RestoreCommandStub(bundlePath, id, imagePath)
+	} else {
+		return fake.restoreCommandReturns.result1
+	}
+}
+
+func (fake *FakeCheckpointBinary) RestoreCommandCallCount() int {
+	fake.restoreCommandMutex.RLock()
+	defer fake.restoreCommandMutex.RUnlock()
+	return len(fake.restoreCommandArgsForCall)
+}
+
+func (fake *FakeCheckpointBinary) RestoreCommandArgsForCall(i int) (string, string, string) {
+	fake.restoreCommandMutex.RLock()
+	defer fake.restoreCommandMutex.RUnlock()
+	return fake.restoreCommandArgsForCall[i].bundlePath, fake.restoreCommandArgsForCall[i].id, fake.restoreCommandArgsForCall[i].imagePath
+}
+
+func (fake *FakeCheckpointBinary) RestoreCommandReturns(result1 *exec.Cmd) {
+	fake.RestoreCommandStub = nil
+	fake.restoreCommandReturns = struct {
+		result1 *exec.Cmd
+	}{result1}
+}
+
+var _ runrunc.CheckpointBinary = new(FakeCheckpointBinary)
@@ -0,0 +1,60 @@
+// This file was generated by counterfeiter
+package fakes
+
+import (
+	"sync"
+
+	"github.com/cloudfoundry-incubator/guardian/rundmc/runrunc"
+	"github.com/pivotal-golang/lager"
+)
+
+type FakePrioritizer struct {
+	PrioritizeStub        func(log lager.Logger, handle, class string, pid int) error
+	prioritizeMutex       sync.RWMutex
+	prioritizeArgsForCall []struct {
+		log    lager.Logger
+		handle string
+		class  string
+		pid    int
+	}
+	prioritizeReturns struct {
+		result1 error
+	}
+}
+
+func (fake *FakePrioritizer) Prioritize(log lager.Logger, handle string, class string, pid int) error {
+	fake.prioritizeMutex.Lock()
+	fake.prioritizeArgsForCall = append(fake.prioritizeArgsForCall, struct {
+		log    lager.Logger
+		handle string
+		class  string
+		pid    int
+	}{log, handle, class, pid})
+	fake.prioritizeMutex.Unlock()
+	if fake.PrioritizeStub != nil {
+		return fake.PrioritizeStub(log, handle, class, pid)
+	} else {
+		return fake.prioritizeReturns.result1
+	}
+}
+
+func (fake *FakePrioritizer) PrioritizeCallCount() int {
+	fake.prioritizeMutex.RLock()
+	defer fake.prioritizeMutex.RUnlock()
+	return len(fake.prioritizeArgsForCall)
+}
+
+func (fake *FakePrioritizer) PrioritizeArgsForCall(i int) (lager.Logger, string, string, int) {
+	fake.prioritizeMutex.RLock()
+	defer fake.prioritizeMutex.RUnlock()
+	return fake.prioritizeArgsForCall[i].log, fake.prioritizeArgsForCall[i].handle, fake.prioritizeArgsForCall[i].class, fake.prioritizeArgsForCall[i].pid
+}
+
+func (fake *FakePrioritizer) PrioritizeReturns(result1 error) {
+	fake.PrioritizeStub = nil
+	fake.prioritizeReturns = struct {
+		result1 error
+	}{result1}
+}
+
+var _ runrunc.Prioritizer = new(FakePrioritizer)
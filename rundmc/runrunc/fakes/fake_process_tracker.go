@@ -22,6 +22,16 @@ type FakeProcessTracker struct {
 		result1 garden.Process
 		result2 error
 	}
+	AttachStub        func(id string, io garden.ProcessIO) (garden.Process, error)
+	attachMutex       sync.RWMutex
+	attachArgsForCall []struct {
+		id string
+		io garden.ProcessIO
+	}
+	attachReturns struct {
+		result1 garden.Process
+		result2 error
+	}
 }
 
 func (fake *FakeProcessTracker) Run(id string, cmd *exec.Cmd, io garden.ProcessIO, tty *garden.TTYSpec) (garden.Process, error) {
@@ -60,4 +70,38 @@ func (fake *FakeProcessTracker) RunReturns(result1 garden.Process, result2 error
 	}{result1, result2}
 }
 
+func (fake *FakeProcessTracker) Attach(id string, io garden.ProcessIO) (garden.Process, error) {
+	fake.attachMutex.Lock()
+	fake.attachArgsForCall = append(fake.attachArgsForCall, struct {
+		id string
+		io garden.ProcessIO
+	}{id, io})
+	fake.attachMutex.Unlock()
+	if fake.AttachStub != nil {
+		return fake.AttachStub(id, io)
+	} else {
+		return fake.attachReturns.result1, fake.attachReturns.result2
+	}
+}
+
+func (fake *FakeProcessTracker) AttachCallCount() int {
+	fake.attachMutex.RLock()
+	defer fake.attachMutex.RUnlock()
+	return len(fake.attachArgsForCall)
+}
+
+func (fake *FakeProcessTracker) AttachArgsForCall(i int) (string, garden.ProcessIO) {
+	fake.attachMutex.RLock()
+	defer fake.attachMutex.RUnlock()
+	return fake.attachArgsForCall[i].id, fake.attachArgsForCall[i].io
+}
+
+func (fake *FakeProcessTracker) AttachReturns(result1 garden.Process, result2 error) {
+	fake.AttachStub = nil
+	fake.attachReturns = struct {
+		result1 garden.Process
+		result2 error
+	}{result1, result2}
+}
+
 var _ runrunc.ProcessTracker = new(FakeProcessTracker)
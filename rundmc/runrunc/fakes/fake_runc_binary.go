@@ -36,6 +36,14 @@ type FakeRuncBinary struct {
 	killCommandReturns struct {
 		result1 *exec.Cmd
 	}
+	DeleteCommandStub        func(id string) *exec.Cmd
+	deleteCommandMutex       sync.RWMutex
+	deleteCommandArgsForCall []struct {
+		id string
+	}
+	deleteCommandReturns struct {
+		result1 *exec.Cmd
+	}
 }
 
 func (fake *FakeRuncBinary) StartCommand(path string, id string) *exec.Cmd {
@@ -137,4 +145,36 @@ func (fake *FakeRuncBinary) KillCommandReturns(result1 *exec.Cmd) {
 	}{result1}
 }
 
+func (fake *FakeRuncBinary) DeleteCommand(id string) *exec.Cmd {
+	fake.deleteCommandMutex.Lock()
+	fake.deleteCommandArgsForCall = append(fake.deleteCommandArgsForCall, struct {
+		id string
+	}{id})
+	fake.deleteCommandMutex.Unlock()
+	if fake.DeleteCommandStub != nil {
+		return fake.DeleteCommandStub(id)
+	} else {
+		return fake.deleteCommandReturns.result1
+	}
+}
+
+func (fake *FakeRuncBinary) DeleteCommandCallCount() int {
+	fake.deleteCommandMutex.RLock()
+	defer fake.deleteCommandMutex.RUnlock()
+	return len(fake.deleteCommandArgsForCall)
+}
+
+func (fake *FakeRuncBinary) DeleteCommandArgsForCall(i int) string {
+	fake.deleteCommandMutex.RLock()
+	defer fake.deleteCommandMutex.RUnlock()
+	return fake.deleteCommandArgsForCall[i].id
+}
+
+func (fake *FakeRuncBinary) DeleteCommandReturns(result1 *exec.Cmd) {
+	fake.DeleteCommandStub = nil
+	fake.deleteCommandReturns = struct {
+		result1 *exec.Cmd
+	}{result1}
+}
+
 var _ runrunc.RuncBinary = new(FakeRuncBinary)
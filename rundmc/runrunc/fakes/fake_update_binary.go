@@ -0,0 +1,54 @@
+// This file was generated by counterfeiter
+package fakes
+
+import (
+	"os/exec"
+	"sync"
+
+	"github.com/cloudfoundry-incubator/guardian/rundmc/runrunc"
+)
+
+type FakeUpdateBinary struct {
+	UpdateCommandStub        func(id string) *exec.Cmd
+	updateCommandMutex       sync.RWMutex
+	updateCommandArgsForCall []struct {
+		id string
+	}
+	updateCommandReturns struct {
+		result1 *exec.Cmd
+	}
+}
+
+func (fake *FakeUpdateBinary) UpdateCommand(id string) *exec.Cmd {
+	fake.updateCommandMutex.Lock()
+	fake.updateCommandArgsForCall = append(fake.updateCommandArgsForCall, struct {
+		id string
+	}{id})
+	fake.updateCommandMutex.Unlock()
+	if fake.UpdateCommandStub != nil {
+		return fake.UpdateCommandStub(id)
+	} else {
+		return fake.updateCommandReturns.result1
+	}
+}
+
+func (fake *FakeUpdateBinary) UpdateCommandCallCount() int {
+	fake.updateCommandMutex.RLock()
+	defer fake.updateCommandMutex.RUnlock()
+	return len(fake.updateCommandArgsForCall)
+}
+
+func (fake *FakeUpdateBinary) UpdateCommandArgsForCall(i int) string {
+	fake.updateCommandMutex.RLock()
+	defer fake.updateCommandMutex.RUnlock()
+	return fake.updateCommandArgsForCall[i].id
+}
+
+func (fake *FakeUpdateBinary) UpdateCommandReturns(result1 *exec.Cmd) {
+	fake.UpdateCommandStub = nil
+	fake.updateCommandReturns = struct {
+		result1 *exec.Cmd
+	}{result1}
+}
+
+var _ runrunc.UpdateBinary = new(FakeUpdateBinary)
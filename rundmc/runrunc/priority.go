@@ -0,0 +1,129 @@
+package runrunc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pivotal-golang/lager"
+)
+
+// PriorityEnv is the environment variable in a ProcessSpec's Env which holds
+// the priority class the process is run with
+const PriorityEnv = "GARDEN_PRIORITY_CLASS"
+
+// Priority classes of exec'd processes
+const (
+	PriorityHigh       = "high"
+	PriorityNormal     = "normal"
+	PriorityBackground = "background"
+)
+
+// PriorityShares are the cpu shares of the nested cgroup in which processes
+// of each priority class are placed, relative to the 1024 shares of each
+// process left in the container's own cgroup. Normal priority processes are
+// not moved.
+var PriorityShares = map[string]uint64{
+	PriorityHigh:       4096,
+	PriorityBackground: 64,
+}
+
+//go:generate counterfeiter . Prioritizer
+
+// Prioritizer places a process of a container in its priority class
+type Prioritizer interface {
+	Prioritize(log lager.Logger, handle, class string, pid int) error
+}
+
+// extractPriority removes any PriorityEnv entries from env, returning the
+// remaining environment and the value of the last entry ("" if there is
+// none)
+func extractPriority(env []string) ([]string, string, error) {
+	rest, class := extractEnv(env, PriorityEnv)
+
+	switch class {
+	case "", PriorityHigh, PriorityNormal, PriorityBackground:
+		return rest, class, nil
+	default:
+		return nil, "", fmt.Errorf("invalid priority class: '%s'", class)
+	}
+}
+
+// WithPidFile makes runc write the pid of the process it runs for subcommand,
+// e.g. "exec" or "start", to pidFile, and returns whether the command is one
+// of subcommand's
+func WithPidFile(cmd *exec.Cmd, subcommand, pidFile string) bool {
+	for i, arg := range cmd.Args {
+		if arg == subcommand {
+			args := append(append([]string{}, cmd.Args[:i+1]...), "--pid-file", pidFile)
+			cmd.Args = append(args, cmd.Args[i+1:]...)
+			return true
+		}
+	}
+
+	return false
+}
+
+// readPidFile waits for runc to write the pid file, until ctx is done
+func readPidFile(ctx context.Context, pidFile string) (int, error) {
+	for {
+		data, err := ioutil.ReadFile(pidFile)
+		if err == nil && len(strings.TrimSpace(string(data))) > 0 {
+			return strconv.Atoi(strings.TrimSpace(string(data)))
+		}
+
+		select {
+		case <-ctx.Done():
+			return 0, fmt.Errorf("pid file %s not written: %s", pidFile, ctx.Err())
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+}
+
+// CgroupPrioritizer places processes with a priority class in a nested cpu
+// cgroup of their container, under the cgroups mounted at CgroupPath, with
+// the class's PriorityShares. It does not support cgroup v2's unified
+// hierarchy, in which a cgroup containing processes cannot delegate its cpu
+// controller to nested cgroups.
+type CgroupPrioritizer struct {
+	CgroupPath string
+	Unified    bool
+}
+
+func (p *CgroupPrioritizer) Prioritize(log lager.Logger, handle, class string, pid int) error {
+	shares, ok := PriorityShares[class]
+	if !ok {
+		return nil
+	}
+
+	if p.Unified {
+		return errors.New("priority classes are not supported with cgroup v2")
+	}
+
+	log = log.Session("prioritize", lager.Data{"handle": handle, "class": class, "pid": pid})
+
+	cgroup := filepath.Join(p.CgroupPath, "cpu", handle, "priority-"+class)
+	if err := os.MkdirAll(cgroup, 0755); err != nil {
+		log.Error("mkdir-failed", err)
+		return fmt.Errorf("create priority cgroup: %s", err)
+	}
+
+	if err := ioutil.WriteFile(filepath.Join(cgroup, "cpu.shares"), []byte(strconv.FormatUint(shares, 10)), 0644); err != nil {
+		log.Error("write-shares-failed", err)
+		return fmt.Errorf("set priority cgroup shares: %s", err)
+	}
+
+	if err := ioutil.WriteFile(filepath.Join(cgroup, "cgroup.procs"), []byte(strconv.Itoa(pid)), 0644); err != nil {
+		log.Error("move-process-failed", err)
+		return fmt.Errorf("move process to priority cgroup: %s", err)
+	}
+
+	return nil
+}
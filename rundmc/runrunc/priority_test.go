@@ -0,0 +1,60 @@
+package runrunc_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/cloudfoundry-incubator/guardian/rundmc/runrunc"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/pivotal-golang/lager/lagertest"
+)
+
+var _ = Describe("CgroupPrioritizer", func() {
+	var (
+		cgroupPath  string
+		prioritizer *runrunc.CgroupPrioritizer
+		logger      *lagertest.TestLogger
+	)
+
+	readFile := func(path string) string {
+		data, err := ioutil.ReadFile(path)
+		Expect(err).NotTo(HaveOccurred())
+		return string(data)
+	}
+
+	BeforeEach(func() {
+		var err error
+		cgroupPath, err = ioutil.TempDir("", "prioritizer")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(os.MkdirAll(filepath.Join(cgroupPath, "cpu", "some-handle"), 0755)).To(Succeed())
+
+		logger = lagertest.NewTestLogger("test")
+		prioritizer = &runrunc.CgroupPrioritizer{CgroupPath: cgroupPath}
+	})
+
+	AfterEach(func() {
+		Expect(os.RemoveAll(cgroupPath)).To(Succeed())
+	})
+
+	It("moves the process to a nested cpu cgroup with the class's shares", func() {
+		Expect(prioritizer.Prioritize(logger, "some-handle", runrunc.PriorityBackground, 1234)).To(Succeed())
+
+		cgroup := filepath.Join(cgroupPath, "cpu", "some-handle", "priority-background")
+		Expect(readFile(filepath.Join(cgroup, "cpu.shares"))).To(Equal("64"))
+		Expect(readFile(filepath.Join(cgroup, "cgroup.procs"))).To(Equal("1234"))
+	})
+
+	It("leaves normal priority processes where they are", func() {
+		Expect(prioritizer.Prioritize(logger, "some-handle", runrunc.PriorityNormal, 1234)).To(Succeed())
+		Expect(filepath.Join(cgroupPath, "cpu", "some-handle", "priority-normal")).NotTo(BeADirectory())
+	})
+
+	Context("when the cgroups are the unified hierarchy", func() {
+		It("returns an error", func() {
+			prioritizer.Unified = true
+			Expect(prioritizer.Prioritize(logger, "some-handle", runrunc.PriorityHigh, 1234)).To(MatchError(ContainSubstring("not supported with cgroup v2")))
+		})
+	})
+})
@@ -0,0 +1,22 @@
+package runrunc
+
+import "strings"
+
+// extractEnv removes any entries for the variable name from env, returning
+// the remaining environment and the value of the last entry ("" if there is
+// none). Variables such as PriorityEnv configure how guardian runs a process,
+// so they are never passed on to the process itself.
+func extractEnv(env []string, name string) ([]string, string) {
+	var value string
+	var rest []string
+	for _, envVar := range env {
+		if strings.HasPrefix(envVar, name+"=") {
+			value = strings.TrimPrefix(envVar, name+"=")
+			continue
+		}
+
+		rest = append(rest, envVar)
+	}
+
+	return rest, value
+}
@@ -0,0 +1,107 @@
+package runrunc
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"os/exec"
+
+	"github.com/pivotal-golang/lager"
+)
+
+// WithJSONLog adds the global flags which make runc write its log, as JSON
+// lines, to logPath.
+func WithJSONLog(cmd *exec.Cmd, logPath string) *exec.Cmd {
+	args := []string{cmd.Args[0], "--log", logPath, "--log-format", "json"}
+	cmd.Args = append(args, cmd.Args[1:]...)
+	return cmd
+}
+
+// jsonLogRuncBinary is a RuncBinary whose commands log to logPath. The log
+// flags are added to each runc command as it is built, so that they are
+// passed to runc even when the command is then wrapped, e.g. in a shell which
+// sets the umask.
+type jsonLogRuncBinary struct {
+	RuncBinary
+	logPath string
+}
+
+func (b jsonLogRuncBinary) StartCommand(path, id string) *exec.Cmd {
+	return WithJSONLog(b.RuncBinary.StartCommand(path, id), b.logPath)
+}
+
+func (b jsonLogRuncBinary) ExecCommand(id, processJSONPath string) *exec.Cmd {
+	return WithJSONLog(b.RuncBinary.ExecCommand(id, processJSONPath), b.logPath)
+}
+
+func (b jsonLogRuncBinary) KillCommand(id, signal string) *exec.Cmd {
+	return WithJSONLog(b.RuncBinary.KillCommand(id, signal), b.logPath)
+}
+
+func (b jsonLogRuncBinary) DeleteCommand(id string) *exec.Cmd {
+	return WithJSONLog(b.RuncBinary.DeleteCommand(id), b.logPath)
+}
+
+// ForwardRuncLog re-emits each of runc's JSON log entries as a lager record,
+// so that they carry the logger's session data (such as the container handle
+// and process id). runc's levels are mapped down a level, since runc is
+// noisy: debug and info become debug, warnings become info, and errors,
+// fatals and panics become errors. Lines which are not JSON are emitted as
+// they are, at info.
+func ForwardRuncLog(log lager.Logger, r io.Reader) error {
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+
+		var entry map[string]interface{}
+		if err := json.Unmarshal(line, &entry); err != nil {
+			log.Info("runc", lager.Data{"message": string(line)})
+			continue
+		}
+
+		level, _ := entry["level"].(string)
+		msg, _ := entry["msg"].(string)
+		delete(entry, "level")
+		delete(entry, "msg")
+
+		data := lager.Data{"message": msg}
+		for k, v := range entry {
+			data[k] = v
+		}
+
+		switch level {
+		case "debug", "info":
+			log.Debug("runc", data)
+		case "error", "fatal", "panic":
+			log.Error("runc", errors.New(msg), data)
+		default:
+			log.Info("runc", data)
+		}
+	}
+
+	return scanner.Err()
+}
+
+// forwardRuncLogFile forwards and then removes a runc log file. The file
+// will not exist if runc failed before it opened it.
+func forwardRuncLogFile(log lager.Logger, logPath string) {
+	f, err := os.Open(logPath)
+	if os.IsNotExist(err) {
+		return
+	}
+	if err != nil {
+		log.Error("open-runc-log-failed", err)
+		return
+	}
+	defer os.Remove(logPath)
+	defer f.Close()
+
+	if err := ForwardRuncLog(log, f); err != nil {
+		log.Error("forward-runc-log-failed", err)
+	}
+}
@@ -0,0 +1,71 @@
+package runrunc_test
+
+import (
+	"os/exec"
+	"strings"
+
+	"github.com/cloudfoundry-incubator/guardian/rundmc/runrunc"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+	"github.com/pivotal-golang/lager"
+	"github.com/pivotal-golang/lager/lagertest"
+)
+
+var _ = Describe("WithJSONLog", func() {
+	It("adds the global log flags before the subcommand", func() {
+		cmd := runrunc.WithJSONLog(exec.Command("runc", "kill", "some-handle", "KILL"), "/path/to/log")
+		Expect(cmd.Args).To(Equal([]string{"runc", "--log", "/path/to/log", "--log-format", "json", "kill", "some-handle", "KILL"}))
+	})
+})
+
+var _ = Describe("ForwardRuncLog", func() {
+	var logger *lagertest.TestLogger
+
+	BeforeEach(func() {
+		logger = lagertest.NewTestLogger("test")
+	})
+
+	forward := func(lines ...string) {
+		Expect(runrunc.ForwardRuncLog(logger, strings.NewReader(strings.Join(lines, "\n")))).To(Succeed())
+	}
+
+	It("re-emits each entry with its message and fields", func() {
+		forward(`{"level":"warning","msg":"signal: killed","time":"2016-01-01T00:00:00Z","pid":42}`)
+
+		logs := logger.Logs()
+		Expect(logs).To(HaveLen(1))
+		Expect(logs[0].Message).To(Equal("test.runc"))
+		Expect(logs[0].Data).To(HaveKeyWithValue("message", "signal: killed"))
+		Expect(logs[0].Data).To(HaveKeyWithValue("time", "2016-01-01T00:00:00Z"))
+		Expect(logs[0].Data).To(HaveKeyWithValue("pid", BeNumerically("==", 42)))
+	})
+
+	DescribeTable("maps runc's levels to lager's",
+		func(level string, logLevel lager.LogLevel) {
+			forward(`{"level":"` + level + `","msg":"something happened"}`)
+			Expect(logger.Logs()).To(HaveLen(1))
+			Expect(logger.Logs()[0].LogLevel).To(Equal(logLevel))
+		},
+		Entry("debug", "debug", lager.DEBUG),
+		Entry("info", "info", lager.DEBUG),
+		Entry("warning", "warning", lager.INFO),
+		Entry("error", "error", lager.ERROR),
+		Entry("fatal", "fatal", lager.ERROR),
+		Entry("panic", "panic", lager.ERROR),
+	)
+
+	It("emits the message of error entries as the error", func() {
+		forward(`{"level":"error","msg":"container not running"}`)
+		Expect(logger.Logs()[0].Data).To(HaveKeyWithValue("error", "container not running"))
+	})
+
+	It("emits lines which are not JSON as they are", func() {
+		forward("some plain text", "", `{"level":"info","msg":"ok"}`)
+
+		logs := logger.Logs()
+		Expect(logs).To(HaveLen(2))
+		Expect(logs[0].LogLevel).To(Equal(lager.INFO))
+		Expect(logs[0].Data).To(HaveKeyWithValue("message", "some plain text"))
+	})
+})
@@ -2,12 +2,17 @@ package runrunc
 
 import (
 	"bytes"
-	"fmt"
+	"context"
+	"errors"
 	"os"
 	"os/exec"
+	"path/filepath"
+	"time"
 
 	"github.com/cloudfoundry-incubator/garden"
 	"github.com/cloudfoundry-incubator/goci"
+	"github.com/cloudfoundry-incubator/guardian/gardener"
+	"github.com/cloudfoundry-incubator/guardian/pkg/cmdtimeout"
 	"github.com/cloudfoundry/gunk/command_runner"
 	"github.com/opencontainers/runc/libcontainer/user"
 	"github.com/pivotal-golang/lager"
@@ -19,6 +24,20 @@ const DefaultPath = "PATH=/usr/local/bin:/usr/bin:/bin"
 //go:generate counterfeiter . ProcessTracker
 type ProcessTracker interface {
 	Run(id string, cmd *exec.Cmd, io garden.ProcessIO, tty *garden.TTYSpec) (garden.Process, error)
+	Attach(id string, io garden.ProcessIO) (garden.Process, error)
+}
+
+// ContainerProcessTracker is implemented by ProcessTrackers which spawn the
+// processes of each container together
+type ContainerProcessTracker interface {
+	RunInContainer(handle, id string, cmd *exec.Cmd, io garden.ProcessIO, tty *garden.TTYSpec) (garden.Process, error)
+}
+
+// ContainerProcessAttacher is implemented by ProcessTrackers which know the
+// container each of their processes was run in, so that they only attach to
+// a process from its own container
+type ContainerProcessAttacher interface {
+	AttachInContainer(handle, id string, io garden.ProcessIO) (garden.Process, error)
 }
 
 //go:generate counterfeiter . UidGenerator
@@ -53,8 +72,22 @@ type RunRunc struct {
 	commandRunner command_runner.CommandRunner
 	pidGenerator  UidGenerator
 	runc          RuncBinary
+	verifier      BinaryVerifier
 
 	execPreparer *ExecPreparer
+
+	// prioritizer places processes in their priority class; if nil, priority
+	// classes are not supported
+	prioritizer Prioritizer
+
+	// logDir holds runc's log for each invocation until it has been forwarded
+	// to lager; if empty, runc's log is not forwarded
+	logDir string
+
+	// timeout is how long runc may take to start a container or exec a
+	// process, or to kill or delete a container, before it is assumed to be
+	// wedged and is killed; if zero, runc is not timed out
+	timeout time.Duration
 }
 
 //go:generate counterfeiter . RuncBinary
@@ -62,15 +95,26 @@ type RuncBinary interface {
 	StartCommand(path, id string) *exec.Cmd
 	ExecCommand(id, processJSONPath string) *exec.Cmd
 	KillCommand(id, signal string) *exec.Cmd
+	DeleteCommand(id string) *exec.Cmd
+}
+
+// logConfigurable is implemented by runtimes which know their own log
+// arguments; other runtimes are assumed to take runc's
+type logConfigurable interface {
+	WithLog(logPath string) RuncBinary
 }
 
-func New(tracker ProcessTracker, runner command_runner.CommandRunner, pidgen UidGenerator, runc RuncBinary, execPreparer *ExecPreparer) *RunRunc {
+func New(tracker ProcessTracker, runner command_runner.CommandRunner, pidgen UidGenerator, runc RuncBinary, verifier BinaryVerifier, execPreparer *ExecPreparer, prioritizer Prioritizer, logDir string, timeout time.Duration) *RunRunc {
 	return &RunRunc{
 		tracker:       tracker,
 		commandRunner: runner,
 		pidGenerator:  pidgen,
 		runc:          runc,
+		verifier:      verifier,
 		execPreparer:  execPreparer,
+		prioritizer:   prioritizer,
+		logDir:        logDir,
+		timeout:       timeout,
 	}
 }
 
@@ -81,14 +125,48 @@ func (r *RunRunc) Start(log lager.Logger, bundlePath, id string, io garden.Proce
 	log.Info("started")
 	defer log.Info("finished")
 
-	cmd := r.runc.StartCommand(bundlePath, id)
+	if err := r.verifier.Verify(); err != nil {
+		log.Error("verify-runtime-binary", err)
+		return nil, err
+	}
+
+	ctx, cancel := r.context()
+	defer cancel()
+
+	umask, err := LoadUmask(bundlePath)
+	if err != nil {
+		log.Error("load-umask-failed", err)
+		return nil, err
+	}
+
+	processID := r.pidGenerator.Generate()
+	runc, logPath := r.loggingRunc(processID)
+	cmd, err := withUmask(runc.StartCommand(bundlePath, id), umask)
+	if err != nil {
+		log.Error("invalid-umask", err)
+		return nil, err
+	}
+
+	var pidFile string
+	if r.timeout > 0 {
+		if pidFile, err = withPidFile(cmd, "start", bundlePath, processID); err != nil {
+			return nil, err
+		}
+	}
 
-	process, err := r.tracker.Run(r.pidGenerator.Generate(), cmd, io, nil)
+	process, err := r.run(id, processID, cmd, io, nil, false)
+	r.forwardLog(log, process, logPath, lager.Data{"handle": id, "process-id": processID})
 	if err != nil {
 		log.Error("run", err)
 		return nil, err
 	}
 
+	if pidFile != "" {
+		if _, err := r.started(ctx, log, process, cmd, pidFile); err != nil {
+			return nil, err
+		}
+	}
+
 	return process, nil
 }
 
@@ -99,20 +177,231 @@ func (r *RunRunc) Exec(log lager.Logger, bundlePath, id string, spec garden.Proc
 	log.Info("started")
 	defer log.Info("finished")
 
-	cmd, err := r.execPreparer.Prepare(log, id, bundlePath, spec, r.runc)
+	if err := r.verifier.Verify(); err != nil {
+		log.Error("verify-runtime-binary", err)
+		return nil, err
+	}
+
+	env, priority, err := extractPriority(spec.Env)
 	if err != nil {
 		return nil, err
 	}
+	spec.Env = env
 
-	process, err := r.tracker.Run(r.pidGenerator.Generate(), cmd, io, spec.TTY)
+	env, closedOutput, err := extractClosedOutput(spec.Env)
+	if err != nil {
+		return nil, err
+	}
+	spec.Env = env
+
+	env, timestamp, err := extractTimestampOutput(spec.Env)
+	if err != nil {
+		return nil, err
+	}
+	spec.Env = env
+
+	_, prioritized := PriorityShares[priority]
+	if prioritized && r.prioritizer == nil {
+		return nil, errors.New("exec priority classes are not supported")
+	}
+
+	if _, ok := r.tracker.(TimestampingProcessTracker); timestamp && !ok {
+		return nil, errors.New("output timestamping is not supported")
+	}
+
+	ctx, cancel := r.context()
+	defer cancel()
+
+	processID := r.pidGenerator.Generate()
+	runc, logPath := r.loggingRunc(processID)
+
+	cmd, err := r.execPreparer.Prepare(log, id, bundlePath, spec, runc)
+	if err != nil {
+		return nil, err
+	}
+
+	signaller := &pipeSignaller{}
+	closeOutput := func() {}
+	if closedOutput != "" {
+		io, closeOutput = closedOutputIO(log, io, closedOutput, bundlePath, processID, signaller)
+	}
+
+	var pidFile string
+	if prioritized || closedOutput == ClosedOutputSigpipe || r.timeout > 0 {
+		if pidFile, err = withPidFile(cmd, "exec", bundlePath, processID); err != nil {
+			closeOutput()
+			return nil, err
+		}
+	}
+
+	process, err := r.run(id, processID, cmd, io, spec.TTY, timestamp)
+	r.forwardLog(log, process, logPath, lager.Data{"handle": id, "process-id": processID})
 	if err != nil {
 		log.Error("run-failed", err)
+		closeOutput()
 		return nil, err
 	}
 
+	if closedOutput != "" {
+		go func() {
+			process.Wait()
+			closeOutput()
+		}()
+	}
+
+	if pidFile != "" && r.timeout == 0 {
+		go r.afterStarted(log, id, priority, pidFile, signaller)
+	} else if pidFile != "" {
+		pid, err := r.started(ctx, log, process, cmd, pidFile)
+		if err != nil {
+			return nil, err
+		}
+
+		r.afterExec(log, id, priority, pid, signaller)
+	}
+
 	return process, nil
 }
 
+// run runs the process in the container, together with its other processes
+// if the tracker supports it, timestamping its output if asked to
+func (r *RunRunc) run(handle, processID string, cmd *exec.Cmd, io garden.ProcessIO, tty *garden.TTYSpec, timestamp bool) (garden.Process, error) {
+	if tracker, ok := r.tracker.(TimestampingProcessTracker); ok && timestamp {
+		return tracker.RunTimestamped(handle, processID, cmd, io, tty)
+	}
+
+	if tracker, ok := r.tracker.(ContainerProcessTracker); ok {
+		return tracker.RunInContainer(handle, processID, cmd, io, tty)
+	}
+
+	return r.tracker.Run(processID, cmd, io, tty)
+}
+
+// started waits for runc to write the pid of the process it has started. If
+// runc has not done so within the runc timeout it is assumed to be wedged, so
+// it is killed and a timeout error is returned.
+func (r *RunRunc) started(ctx context.Context, log lager.Logger, process garden.Process, cmd *exec.Cmd, pidFile string) (int, error) {
+	defer os.Remove(pidFile)
+
+	pid, err := readPidFile(ctx, pidFile)
+	if err == nil {
+		return pid, nil
+	}
+
+	log.Error("read-pid-file-failed", err)
+	if process != nil {
+		if err := process.Signal(garden.SignalKill); err != nil {
+			log.Error("kill-runc-failed", err)
+		}
+	}
+
+	return 0, r.timeoutError(cmd)
+}
+
+// Attach attaches to a process which was exec'd in the container, e.g. once a
+// client has lost its connection to the process, replaying what output it can
+func (r *RunRunc) Attach(log lager.Logger, id, processID string, io garden.ProcessIO) (garden.Process, error) {
+	log = log.Session("attach", lager.Data{"id": id, "process-id": processID})
+
+	var process garden.Process
+	var err error
+	if tracker, ok := r.tracker.(ContainerProcessAttacher); ok {
+		process, err = tracker.AttachInContainer(id, processID, io)
+	} else {
+		process, err = r.tracker.Attach(processID, io)
+	}
+
+	if err != nil {
+		log.Error("attach-failed", err)
+		return nil, err
+	}
+
+	return process, nil
+}
+
+// afterExec places the exec'd process in its priority class, and lets it be
+// sent SIGPIPE. The process is already running, so failing to do so is logged
+// rather than failing the exec.
+func (r *RunRunc) afterExec(log lager.Logger, id, class string, pid int, signaller *pipeSignaller) {
+	signaller.SetPid(log, pid)
+
+	if _, ok := PriorityShares[class]; !ok {
+		return
+	}
+
+	if err := r.prioritizer.Prioritize(log, id, class, pid); err != nil {
+		log.Error("prioritize-failed", err, lager.Data{"class": class})
+	}
+}
+
+// afterStarted waits in the background, for up to pidFileTimeout, for runc to
+// write the pid of the process it has exec'd without a runc timeout, so that
+// the exec returns as soon as the process has been started
+func (r *RunRunc) afterStarted(log lager.Logger, id, class, pidFile string, signaller *pipeSignaller) {
+	defer os.Remove(pidFile)
+
+	ctx, cancel := context.WithTimeout(context.Background(), pidFileTimeout)
+	defer cancel()
+
+	pid, err := readPidFile(ctx, pidFile)
+	if err != nil {
+		log.Error("read-pid-file-failed", err)
+		return
+	}
+
+	r.afterExec(log, id, class, pid, signaller)
+}
+
+// pidFileTimeout is how long runc may take to write an exec'd process's pid
+// when there is no runc timeout
+var pidFileTimeout = 5 * time.Second
+
+// withPidFile has runc write the pid of the process it starts to the
+// processes directory of the container's bundle, which only guardian can
+// write to and which is removed along with the container, returning the
+// path of the pid file, or "" if runc is not given one
+func withPidFile(cmd *exec.Cmd, subcommand, bundlePath, processID string) (string, error) {
+	dir := filepath.Join(bundlePath, "processes")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+
+	pidFile := filepath.Join(dir, processID+".pid")
+	if !WithPidFile(cmd, subcommand, pidFile) {
+		return "", nil
+	}
+
+	return pidFile, nil
+}
+
+// context returns the context of a runc invocation, which is done once the
+// runc timeout has passed, if there is one
+func (r *RunRunc) context() (context.Context, context.CancelFunc) {
+	if r.timeout == 0 {
+		return context.Background(), func() {}
+	}
+
+	return context.WithTimeout(context.Background(), r.timeout)
+}
+
+// runCommand runs a runc command which is expected to exit, killing it, along
+// with any processes it has started, if it is still running once ctx is done
+func (r *RunRunc) runCommand(ctx context.Context, cmd *exec.Cmd) error {
+	if r.timeout == 0 {
+		return r.commandRunner.Run(cmd)
+	}
+
+	if err := cmdtimeout.RunContext(ctx, r.commandRunner, cmd); err != context.DeadlineExceeded {
+		return err
+	}
+
+	return r.timeoutError(cmd)
+}
+
+func (r *RunRunc) timeoutError(cmd *exec.Cmd) error {
+	return gardener.Classify(gardener.FailureRuncTimeout, RuncTimeoutError{Args: cmd.Args, Timeout: r.timeout})
+}
+
 // Kill a bundle using 'runc kill'
 func (r *RunRunc) Kill(log lager.Logger, handle string) error {
 	log = log.Session("kill", lager.Data{"handle": handle})
@@ -120,13 +409,90 @@ func (r *RunRunc) Kill(log lager.Logger, handle string) error {
 	log.Info("started")
 	defer log.Info("finished")
 
+	if err := r.verifier.Verify(); err != nil {
+		log.Error("verify-runtime-binary", err)
+		return err
+	}
+
+	ctx, cancel := r.context()
+	defer cancel()
+
 	buf := &bytes.Buffer{}
-	cmd := r.runc.KillCommand(handle, "KILL")
+	runc, logPath := r.loggingRunc("kill-" + handle + "-" + r.pidGenerator.Generate())
+	cmd := runc.KillCommand(handle, "KILL")
 	cmd.Stderr = buf
-	if err := r.commandRunner.Run(cmd); err != nil {
+	err := r.runCommand(ctx, cmd)
+	r.forwardLog(log, nil, logPath, lager.Data{"handle": handle})
+	if err != nil {
 		log.Error("run-failed", err, lager.Data{"stderr": buf.String()})
-		return fmt.Errorf("runc kill: %s: %s", err, string(buf.String()))
+		return runcError("kill", err, buf.String())
 	}
 
 	return nil
 }
+
+// Delete forcibly deletes a container's runc state using 'runc delete
+// --force', killing its processes if there are any left. It is used to clean
+// up containers whose state is stale, which 'runc kill' fails on.
+func (r *RunRunc) Delete(log lager.Logger, handle string) error {
+	log = log.Session("delete", lager.Data{"handle": handle})
+
+	log.Info("started")
+	defer log.Info("finished")
+
+	if err := r.verifier.Verify(); err != nil {
+		log.Error("verify-runtime-binary", err)
+		return err
+	}
+
+	ctx, cancel := r.context()
+	defer cancel()
+
+	buf := &bytes.Buffer{}
+	runc, logPath := r.loggingRunc("delete-" + handle + "-" + r.pidGenerator.Generate())
+	cmd := runc.DeleteCommand(handle)
+	cmd.Stderr = buf
+	err := r.runCommand(ctx, cmd)
+	r.forwardLog(log, nil, logPath, lager.Data{"handle": handle})
+	if err != nil {
+		log.Error("run-failed", err, lager.Data{"stderr": buf.String()})
+		return runcError("delete", err, buf.String())
+	}
+
+	return nil
+}
+
+// loggingRunc returns a RuncBinary whose commands log to a file named after
+// the invocation, and the path of the file. The name must be unique to the
+// invocation, as invocations may overlap, e.g. two kills of one container.
+func (r *RunRunc) loggingRunc(name string) (RuncBinary, string) {
+	if r.logDir == "" {
+		return r.runc, ""
+	}
+
+	logPath := filepath.Join(r.logDir, name+".log")
+	if runtime, ok := r.runc.(logConfigurable); ok {
+		return runtime.WithLog(logPath), logPath
+	}
+
+	return jsonLogRuncBinary{RuncBinary: r.runc, logPath: logPath}, logPath
+}
+
+// forwardLog forwards runc's log once the process (if any) has exited, as
+// runc may carry on logging until then.
+func (r *RunRunc) forwardLog(log lager.Logger, process garden.Process, logPath string, data lager.Data) {
+	if logPath == "" {
+		return
+	}
+
+	log = log.Session("runc-log", data)
+	if process == nil {
+		forwardRuncLogFile(log, logPath)
+		return
+	}
+
+	go func() {
+		process.Wait()
+		forwardRuncLogFile(log, logPath)
+	}()
+}
@@ -3,15 +3,22 @@ package runrunc_test
 import (
 	"encoding/json"
 	"errors"
+	"io/ioutil"
 	"os"
 	"os/exec"
+	"path/filepath"
+	"strconv"
+	"time"
 
 	"github.com/cloudfoundry-incubator/garden"
+	gardenfakes "github.com/cloudfoundry-incubator/garden/fakes"
 	"github.com/cloudfoundry-incubator/goci"
+	"github.com/cloudfoundry-incubator/guardian/gardener"
 	"github.com/cloudfoundry-incubator/guardian/rundmc/runrunc"
 	"github.com/cloudfoundry-incubator/guardian/rundmc/runrunc/fakes"
 	"github.com/cloudfoundry/gunk/command_runner/fake_command_runner"
 	. "github.com/cloudfoundry/gunk/command_runner/fake_command_runner/matchers"
+	"github.com/cloudfoundry/gunk/command_runner/linux_command_runner"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 	"github.com/opencontainers/runc/libcontainer/user"
@@ -26,9 +33,11 @@ var _ = Describe("RuncRunner", func() {
 		commandRunner *fake_command_runner.FakeCommandRunner
 		pidGenerator  *fakes.FakeUidGenerator
 		runcBinary    *fakes.FakeRuncBinary
+		verifier      *fakes.FakeBinaryVerifier
 		bundleLoader  *fakes.FakeBundleLoader
 		users         *fakes.FakeUserLookupper
 		mkdirer       *fakes.FakeMkdirer
+		prioritizer   *fakes.FakePrioritizer
 		logger        lager.Logger
 
 		runner *runrunc.RunRunc
@@ -38,10 +47,12 @@ var _ = Describe("RuncRunner", func() {
 		tracker = new(fakes.FakeProcessTracker)
 		pidGenerator = new(fakes.FakeUidGenerator)
 		runcBinary = new(fakes.FakeRuncBinary)
+		verifier = new(fakes.FakeBinaryVerifier)
 		commandRunner = fake_command_runner.New()
 		bundleLoader = new(fakes.FakeBundleLoader)
 		users = new(fakes.FakeUserLookupper)
 		mkdirer = new(fakes.FakeMkdirer)
+		prioritizer = new(fakes.FakePrioritizer)
 		logger = lagertest.NewTestLogger("test")
 
 		runner = runrunc.New(
@@ -49,11 +60,15 @@ var _ = Describe("RuncRunner", func() {
 			commandRunner,
 			pidGenerator,
 			runcBinary,
+			verifier,
 			runrunc.NewExecPreparer(
 				bundleLoader,
 				users,
 				mkdirer,
 			),
+			prioritizer,
+			"",
+			0,
 		)
 
 		bundleLoader.LoadStub = func(path string) (*goci.Bndl, error) {
@@ -75,6 +90,10 @@ var _ = Describe("RuncRunner", func() {
 		runcBinary.KillCommandStub = func(id, signal string) *exec.Cmd {
 			return exec.Command("funC", "kill", id, signal)
 		}
+
+		runcBinary.DeleteCommandStub = func(id string) *exec.Cmd {
+			return exec.Command("funC", "delete", "--force", id)
+		}
 	})
 
 	Describe("Start", func() {
@@ -95,6 +114,54 @@ var _ = Describe("RuncRunner", func() {
 			id, _, _, _ := tracker.RunArgsForCall(0)
 			Expect(id).To(BeEquivalentTo("some-process-guid"))
 		})
+
+		Context("when the bundle has a umask", func() {
+			var bundlePath string
+
+			BeforeEach(func() {
+				var err error
+				bundlePath, err = ioutil.TempDir("", "bundle")
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(runrunc.SaveUmask(bundlePath, "0027")).To(Succeed())
+			})
+
+			AfterEach(func() {
+				Expect(os.RemoveAll(bundlePath)).To(Succeed())
+			})
+
+			It("starts the init process via a shell which sets the umask", func() {
+				_, err := runner.Start(logger, bundlePath, "handle", garden.ProcessIO{})
+				Expect(err).NotTo(HaveOccurred())
+
+				_, cmd, _, _ := tracker.RunArgsForCall(0)
+				Expect(cmd.Args).To(Equal([]string{"/bin/sh", "-c", `umask 0027 && exec "$@"`, "sh", "funC", "start", bundlePath, "handle"}))
+			})
+
+			Context("when the umask is invalid", func() {
+				BeforeEach(func() {
+					Expect(runrunc.SaveUmask(bundlePath, "rw-r--r--")).To(Succeed())
+				})
+
+				It("returns an error without running anything", func() {
+					_, err := runner.Start(logger, bundlePath, "handle", garden.ProcessIO{})
+					Expect(err).To(MatchError("invalid umask: 'rw-r--r--'"))
+					Expect(tracker.RunCallCount()).To(Equal(0))
+				})
+			})
+		})
+
+		Context("when the runtime binary fails verification", func() {
+			BeforeEach(func() {
+				verifier.VerifyReturns(errors.New("tampered"))
+			})
+
+			It("returns the error without running the binary", func() {
+				_, err := runner.Start(logger, "some/oci/container", "handle", garden.ProcessIO{})
+				Expect(err).To(MatchError("tampered"))
+				Expect(tracker.RunCallCount()).To(Equal(0))
+			})
+		})
 	})
 
 	Describe("Exec", func() {
@@ -107,6 +174,18 @@ var _ = Describe("RuncRunner", func() {
 			Expect(pid).To(BeEquivalentTo("another-process-guid"))
 		})
 
+		Context("when the runtime binary fails verification", func() {
+			BeforeEach(func() {
+				verifier.VerifyReturns(errors.New("tampered"))
+			})
+
+			It("returns the error without running the binary", func() {
+				_, err := runner.Exec(logger, "some/oci/container", "someid", garden.ProcessSpec{}, garden.ProcessIO{})
+				Expect(err).To(MatchError("tampered"))
+				Expect(tracker.RunCallCount()).To(Equal(0))
+			})
+		})
+
 		It("runs exec against the injected runC binary using process tracker", func() {
 			ttyspec := &garden.TTYSpec{WindowSize: &garden.WindowSize{Rows: 1}}
 			runner.Exec(logger, "/some/bundle/path", "some-id", garden.ProcessSpec{TTY: ttyspec}, garden.ProcessIO{Stdout: GinkgoWriter})
@@ -118,6 +197,59 @@ var _ = Describe("RuncRunner", func() {
 			Expect(tty).To(Equal(ttyspec))
 		})
 
+		Context("when the tracker runs processes per container", func() {
+			var containerTracker *containerProcessTracker
+
+			BeforeEach(func() {
+				containerTracker = &containerProcessTracker{FakeProcessTracker: tracker}
+				runner = runrunc.New(
+					containerTracker,
+					commandRunner,
+					pidGenerator,
+					runcBinary,
+					verifier,
+					runrunc.NewExecPreparer(bundleLoader, users, mkdirer),
+					prioritizer,
+					"",
+					0,
+				)
+			})
+
+			It("runs the process in the container", func() {
+				pidGenerator.GenerateReturns("another-process-guid")
+				runner.Exec(logger, "/some/bundle/path", "some-id", garden.ProcessSpec{}, garden.ProcessIO{})
+
+				Expect(containerTracker.handles).To(Equal([]string{"some-id"}))
+				Expect(containerTracker.processIDs).To(Equal([]string{"another-process-guid"}))
+				Expect(tracker.RunCallCount()).To(Equal(0))
+			})
+
+			Context("when the process's output is to be timestamped", func() {
+				It("has the tracker timestamp it, without passing the variable on to the process", func() {
+					pidGenerator.GenerateReturns("another-process-guid")
+					_, err := runner.Exec(logger, "/some/bundle/path", "some-id", garden.ProcessSpec{Env: []string{"A=B", runrunc.TimestampOutputEnv + "=true"}}, garden.ProcessIO{})
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(containerTracker.timestamped).To(Equal([]string{"another-process-guid"}))
+					Expect(containerTracker.handles).To(Equal([]string{"some-id"}))
+				})
+
+				It("refuses values which are not booleans", func() {
+					_, err := runner.Exec(logger, "/some/bundle/path", "some-id", garden.ProcessSpec{Env: []string{runrunc.TimestampOutputEnv + "=sometimes"}}, garden.ProcessIO{})
+					Expect(err).To(MatchError("invalid GARDEN_TIMESTAMP_OUTPUT: 'sometimes'"))
+					Expect(containerTracker.timestamped).To(BeEmpty())
+				})
+			})
+		})
+
+		Context("when the process's output is to be timestamped but the tracker cannot", func() {
+			It("returns an error without running the process", func() {
+				_, err := runner.Exec(logger, "/some/bundle/path", "some-id", garden.ProcessSpec{Env: []string{runrunc.TimestampOutputEnv + "=true"}}, garden.ProcessIO{})
+				Expect(err).To(MatchError("output timestamping is not supported"))
+				Expect(tracker.RunCallCount()).To(Equal(0))
+			})
+		})
+
 		Describe("the process.json passed to 'runc exec'", func() {
 			var spec specs.Process
 
@@ -430,6 +562,410 @@ var _ = Describe("RuncRunner", func() {
 				})
 			})
 		})
+
+		Describe("setting the umask", func() {
+			var (
+				containerEnv []string
+				bundlePath   string
+			)
+
+			BeforeEach(func() {
+				containerEnv = []string{"A=B"}
+
+				var err error
+				bundlePath, err = ioutil.TempDir("", "bundle")
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			AfterEach(func() {
+				Expect(os.RemoveAll(bundlePath)).To(Succeed())
+			})
+
+			JustBeforeEach(func() {
+				bndl := &goci.Bndl{}
+				bndl.Spec.Spec.Root.Path = "/some/rootfs/path"
+				bndl.Spec.Spec.Process.Env = containerEnv
+				bundleLoader.LoadReturns(bndl, nil)
+			})
+
+			It("runs runc directly when there is no umask", func() {
+				_, err := runner.Exec(logger, bundlePath, "someid", garden.ProcessSpec{}, garden.ProcessIO{})
+				Expect(err).NotTo(HaveOccurred())
+
+				_, cmd, _, _ := tracker.RunArgsForCall(0)
+				Expect(cmd.Args[0]).To(Equal("funC"))
+			})
+
+			Context("when the container has a umask", func() {
+				BeforeEach(func() {
+					Expect(runrunc.SaveUmask(bundlePath, "0027")).To(Succeed())
+				})
+
+				It("runs runc via a shell which sets the umask", func() {
+					_, err := runner.Exec(logger, bundlePath, "someid", garden.ProcessSpec{}, garden.ProcessIO{})
+					Expect(err).NotTo(HaveOccurred())
+
+					_, cmd, _, _ := tracker.RunArgsForCall(0)
+					Expect(cmd.Args[:4]).To(Equal([]string{"/bin/sh", "-c", `umask 0027 && exec "$@"`, "sh"}))
+					Expect(cmd.Args[4:7]).To(Equal([]string{"funC", "exec", "someid"}))
+				})
+
+				It("does not pass the umask variable to the process", func() {
+					var spec specs.Process
+					tracker.RunStub = func(_ string, cmd *exec.Cmd, _ garden.ProcessIO, _ *garden.TTYSpec) (garden.Process, error) {
+						f, err := os.Open(cmd.Args[len(cmd.Args)-1])
+						Expect(err).NotTo(HaveOccurred())
+						defer f.Close()
+
+						Expect(json.NewDecoder(f).Decode(&spec)).To(Succeed())
+						return nil, nil
+					}
+
+					_, err := runner.Exec(logger, bundlePath, "someid", garden.ProcessSpec{}, garden.ProcessIO{})
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(spec.Env).To(Equal([]string{"A=B", "PATH=/usr/local/sbin:/usr/local/bin:/usr/sbin:/usr/bin:/sbin:/bin", "USER=root"}))
+				})
+
+				It("lets the process spec override it", func() {
+					_, err := runner.Exec(logger, bundlePath, "someid", garden.ProcessSpec{
+						Env: []string{"GARDEN_UMASK=077"},
+					}, garden.ProcessIO{})
+					Expect(err).NotTo(HaveOccurred())
+
+					_, cmd, _, _ := tracker.RunArgsForCall(0)
+					Expect(cmd.Args[2]).To(Equal(`umask 0077 && exec "$@"`))
+				})
+			})
+
+			Context("when the bundle was created with the umask in its process environment", func() {
+				BeforeEach(func() {
+					containerEnv = append(containerEnv, "GARDEN_UMASK=0027")
+				})
+
+				It("runs runc via a shell which sets the umask without passing it to the process", func() {
+					var spec specs.Process
+					tracker.RunStub = func(_ string, cmd *exec.Cmd, _ garden.ProcessIO, _ *garden.TTYSpec) (garden.Process, error) {
+						f, err := os.Open(cmd.Args[len(cmd.Args)-1])
+						Expect(err).NotTo(HaveOccurred())
+						defer f.Close()
+
+						Expect(json.NewDecoder(f).Decode(&spec)).To(Succeed())
+						return nil, nil
+					}
+
+					_, err := runner.Exec(logger, bundlePath, "someid", garden.ProcessSpec{}, garden.ProcessIO{})
+					Expect(err).NotTo(HaveOccurred())
+
+					_, cmd, _, _ := tracker.RunArgsForCall(0)
+					Expect(cmd.Args[2]).To(Equal(`umask 0027 && exec "$@"`))
+					Expect(spec.Env).To(Equal([]string{"A=B", "PATH=/usr/local/sbin:/usr/local/bin:/usr/sbin:/usr/bin:/sbin:/bin", "USER=root"}))
+				})
+			})
+
+			Context("when the process spec has an invalid umask", func() {
+				It("returns an error without running anything", func() {
+					_, err := runner.Exec(logger, bundlePath, "someid", garden.ProcessSpec{
+						Env: []string{"GARDEN_UMASK=rw-r--r--"},
+					}, garden.ProcessIO{})
+					Expect(err).To(MatchError("invalid umask: 'rw-r--r--'"))
+					Expect(tracker.RunCallCount()).To(Equal(0))
+				})
+			})
+		})
+
+		Describe("priority classes", func() {
+			var bundlePath string
+
+			BeforeEach(func() {
+				var err error
+				bundlePath, err = ioutil.TempDir("", "bundle")
+				Expect(err).NotTo(HaveOccurred())
+
+				pidGenerator.GenerateReturns("some-process-guid")
+			})
+
+			AfterEach(func() {
+				Expect(os.RemoveAll(bundlePath)).To(Succeed())
+			})
+
+			writePid := func(pid string) {
+				tracker.RunStub = func(_ string, cmd *exec.Cmd, _ garden.ProcessIO, _ *garden.TTYSpec) (garden.Process, error) {
+					for i, arg := range cmd.Args {
+						if arg == "--pid-file" {
+							Expect(ioutil.WriteFile(cmd.Args[i+1], []byte(pid), 0644)).To(Succeed())
+						}
+					}
+
+					return nil, nil
+				}
+			}
+
+			It("does not prioritize processes without a priority class", func() {
+				_, err := runner.Exec(logger, bundlePath, "someid", garden.ProcessSpec{}, garden.ProcessIO{})
+				Expect(err).NotTo(HaveOccurred())
+
+				_, cmd, _, _ := tracker.RunArgsForCall(0)
+				Expect(cmd.Args).NotTo(ContainElement("--pid-file"))
+				Expect(prioritizer.PrioritizeCallCount()).To(Equal(0))
+			})
+
+			It("places the exec'd process in its priority class", func() {
+				writePid("1234")
+
+				_, err := runner.Exec(logger, bundlePath, "someid", garden.ProcessSpec{
+					Env: []string{"GARDEN_PRIORITY_CLASS=high"},
+				}, garden.ProcessIO{})
+				Expect(err).NotTo(HaveOccurred())
+
+				_, cmd, _, _ := tracker.RunArgsForCall(0)
+				Expect(cmd.Args[:4]).To(Equal([]string{"funC", "exec", "--pid-file", filepath.Join(bundlePath, "processes", "some-process-guid.pid")}))
+
+				Eventually(prioritizer.PrioritizeCallCount).Should(Equal(1))
+				_, handle, class, pid := prioritizer.PrioritizeArgsForCall(0)
+				Expect(handle).To(Equal("someid"))
+				Expect(class).To(Equal("high"))
+				Expect(pid).To(Equal(1234))
+			})
+
+			It("does not pass the priority class variable to the process", func() {
+				var spec specs.Process
+				tracker.RunStub = func(_ string, cmd *exec.Cmd, _ garden.ProcessIO, _ *garden.TTYSpec) (garden.Process, error) {
+					f, err := os.Open(cmd.Args[len(cmd.Args)-1])
+					Expect(err).NotTo(HaveOccurred())
+					defer f.Close()
+
+					Expect(json.NewDecoder(f).Decode(&spec)).To(Succeed())
+					return nil, nil
+				}
+
+				_, err := runner.Exec(logger, bundlePath, "someid", garden.ProcessSpec{
+					Env: []string{"GARDEN_PRIORITY_CLASS=normal"},
+				}, garden.ProcessIO{})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(spec.Env).NotTo(ContainElement(ContainSubstring("GARDEN_PRIORITY_CLASS")))
+			})
+
+			It("returns before runc has written the process's pid", func() {
+				_, err := runner.Exec(logger, bundlePath, "someid", garden.ProcessSpec{
+					Env: []string{"GARDEN_PRIORITY_CLASS=high"},
+				}, garden.ProcessIO{})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(prioritizer.PrioritizeCallCount()).To(Equal(0))
+
+				_, cmd, _, _ := tracker.RunArgsForCall(0)
+				Expect(ioutil.WriteFile(cmd.Args[3], []byte("1234"), 0644)).To(Succeed())
+				Eventually(prioritizer.PrioritizeCallCount).Should(Equal(1))
+			})
+
+			It("does not move normal priority processes", func() {
+				_, err := runner.Exec(logger, bundlePath, "someid", garden.ProcessSpec{
+					Env: []string{"GARDEN_PRIORITY_CLASS=normal"},
+				}, garden.ProcessIO{})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(prioritizer.PrioritizeCallCount()).To(Equal(0))
+			})
+
+			It("still runs the process when it cannot be prioritized", func() {
+				writePid("1234")
+				prioritizer.PrioritizeReturns(errors.New("no cpu cgroup"))
+
+				_, err := runner.Exec(logger, bundlePath, "someid", garden.ProcessSpec{
+					Env: []string{"GARDEN_PRIORITY_CLASS=background"},
+				}, garden.ProcessIO{})
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			Context("when the priority class is invalid", func() {
+				It("returns an error without running anything", func() {
+					_, err := runner.Exec(logger, bundlePath, "someid", garden.ProcessSpec{
+						Env: []string{"GARDEN_PRIORITY_CLASS=urgent"},
+					}, garden.ProcessIO{})
+					Expect(err).To(MatchError("invalid priority class: 'urgent'"))
+					Expect(tracker.RunCallCount()).To(Equal(0))
+				})
+			})
+		})
+
+		Describe("closed output policies", func() {
+			var (
+				bundlePath string
+				client     *closingWriter
+				process    *gardenfakes.FakeProcess
+				exited     chan struct{}
+			)
+
+			BeforeEach(func() {
+				var err error
+				bundlePath, err = ioutil.TempDir("", "bundle")
+				Expect(err).NotTo(HaveOccurred())
+
+				client = &closingWriter{}
+				pidGenerator.GenerateReturns("some-process-guid")
+
+				exited = make(chan struct{})
+				process = new(gardenfakes.FakeProcess)
+				process.WaitStub = func() (int, error) {
+					<-exited
+					return 0, nil
+				}
+				tracker.RunReturns(process, nil)
+			})
+
+			AfterEach(func() {
+				select {
+				case <-exited:
+				default:
+					close(exited)
+				}
+
+				Expect(os.RemoveAll(bundlePath)).To(Succeed())
+			})
+
+			execWithPolicy := func(policy string) garden.ProcessIO {
+				_, err := runner.Exec(logger, bundlePath, "someid", garden.ProcessSpec{
+					Env: []string{"GARDEN_CLOSED_OUTPUT=" + policy},
+				}, garden.ProcessIO{Stdout: client, Stderr: client})
+				Expect(err).NotTo(HaveOccurred())
+
+				_, _, io, _ := tracker.RunArgsForCall(0)
+				return io
+			}
+
+			It("writes to the client while it is there", func() {
+				io := execWithPolicy("discard")
+				io.Stdout.Write([]byte("hello"))
+				Expect(client.written).To(Equal("hello"))
+			})
+
+			It("discards output once the client has gone", func() {
+				io := execWithPolicy("discard")
+				client.closed = true
+
+				n, err := io.Stdout.Write([]byte("hello"))
+				Expect(err).NotTo(HaveOccurred())
+				Expect(n).To(Equal(5))
+			})
+
+			It("appends output to the retention logs once the client has gone", func() {
+				io := execWithPolicy("buffer")
+				io.Stdout.Write([]byte("seen "))
+				client.closed = true
+				io.Stdout.Write([]byte("hello "))
+				io.Stdout.Write([]byte("world"))
+				io.Stderr.Write([]byte("oops"))
+
+				Expect(ioutil.ReadFile(filepath.Join(bundlePath, "processes", "some-process-guid.stdout.log"))).To(Equal([]byte("hello world")))
+				Expect(ioutil.ReadFile(filepath.Join(bundlePath, "processes", "some-process-guid.stderr.log"))).To(Equal([]byte("oops")))
+			})
+
+			It("closes the retention logs once the process exits, discarding any later output", func() {
+				io := execWithPolicy("buffer")
+				client.closed = true
+				io.Stdout.Write([]byte("hello"))
+
+				close(exited)
+				Eventually(func() error {
+					_, err := io.Stdout.Write([]byte(" late"))
+					if err != nil {
+						return err
+					}
+
+					contents, err := ioutil.ReadFile(filepath.Join(bundlePath, "processes", "some-process-guid.stdout.log"))
+					if err != nil {
+						return err
+					}
+
+					if string(contents) != "hello" {
+						return errors.New("written after exit: " + string(contents))
+					}
+
+					return nil
+				}).Should(Succeed())
+			})
+
+			It("sends the process SIGPIPE once the client has gone", func() {
+				sleep := exec.Command("sleep", "100")
+				Expect(sleep.Start()).To(Succeed())
+
+				tracker.RunStub = func(_ string, cmd *exec.Cmd, _ garden.ProcessIO, _ *garden.TTYSpec) (garden.Process, error) {
+					for i, arg := range cmd.Args {
+						if arg == "--pid-file" {
+							Expect(ioutil.WriteFile(cmd.Args[i+1], []byte(strconv.Itoa(sleep.Process.Pid)), 0644)).To(Succeed())
+						}
+					}
+
+					return process, nil
+				}
+
+				io := execWithPolicy("sigpipe")
+				client.closed = true
+				io.Stdout.Write([]byte("hello"))
+
+				Expect(sleep.Wait()).To(MatchError("signal: broken pipe"))
+			})
+
+			It("does not pass the policy variable to the process", func() {
+				execWithPolicy("discard")
+
+				_, cmd, _, _ := tracker.RunArgsForCall(0)
+				f, err := os.Open(cmd.Args[len(cmd.Args)-1])
+				Expect(err).NotTo(HaveOccurred())
+				defer f.Close()
+
+				var spec specs.Process
+				Expect(json.NewDecoder(f).Decode(&spec)).To(Succeed())
+				Expect(spec.Env).NotTo(ContainElement(ContainSubstring("GARDEN_CLOSED_OUTPUT")))
+			})
+
+			Context("when the policy is invalid", func() {
+				It("returns an error without running anything", func() {
+					_, err := runner.Exec(logger, bundlePath, "someid", garden.ProcessSpec{
+						Env: []string{"GARDEN_CLOSED_OUTPUT=explode"},
+					}, garden.ProcessIO{})
+					Expect(err).To(MatchError("invalid closed output policy: 'explode'"))
+					Expect(tracker.RunCallCount()).To(Equal(0))
+				})
+			})
+		})
+	})
+
+	Describe("Attach", func() {
+		It("attaches to the process using the process tracker", func() {
+			process := new(gardenfakes.FakeProcess)
+			tracker.AttachReturns(process, nil)
+
+			attached, err := runner.Attach(logger, "some-id", "some-process-id", garden.ProcessIO{Stdout: GinkgoWriter})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(attached).To(Equal(process))
+
+			processID, io := tracker.AttachArgsForCall(0)
+			Expect(processID).To(Equal("some-process-id"))
+			Expect(io.Stdout).To(Equal(GinkgoWriter))
+		})
+
+		It("returns the tracker's error", func() {
+			tracker.AttachReturns(nil, errors.New("unknown process"))
+
+			_, err := runner.Attach(logger, "some-id", "some-process-id", garden.ProcessIO{})
+			Expect(err).To(MatchError("unknown process"))
+		})
+
+		Context("when the tracker knows which container each process is in", func() {
+			It("only attaches to processes in the container", func() {
+				containerTracker := &containerProcessTracker{FakeProcessTracker: tracker}
+				runner = runrunc.New(containerTracker, commandRunner, pidGenerator, runcBinary, verifier, runrunc.NewExecPreparer(bundleLoader, users, mkdirer), prioritizer, "", 0)
+
+				_, err := runner.Attach(logger, "some-id", "some-process-id", garden.ProcessIO{})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(containerTracker.handles).To(Equal([]string{"some-id"}))
+				Expect(containerTracker.processIDs).To(Equal([]string{"some-process-id"}))
+				Expect(tracker.AttachCallCount()).To(Equal(0))
+			})
+		})
 	})
 
 	Describe("Kill", func() {
@@ -449,5 +985,321 @@ var _ = Describe("RuncRunner", func() {
 
 			Expect(runner.Kill(logger, "some-container")).To(MatchError("runc kill: exit status banana: some error"))
 		})
+
+		It("returns runc's timeout error as it is when 'runc kill' times out", func() {
+			timeoutErr := gardener.Classify(gardener.FailureRuncTimeout, runrunc.RuncTimeoutError{Args: []string{"funC", "kill"}, Timeout: time.Second})
+			commandRunner.WhenRunning(fake_command_runner.CommandSpec{}, func(cmd *exec.Cmd) error {
+				return timeoutErr
+			})
+
+			Expect(runner.Kill(logger, "some-container")).To(Equal(timeoutErr))
+		})
+
+		Context("when the runtime binary fails verification", func() {
+			BeforeEach(func() {
+				verifier.VerifyReturns(errors.New("tampered"))
+			})
+
+			It("returns the error without running the binary", func() {
+				Expect(runner.Kill(logger, "some-container")).To(MatchError("tampered"))
+				Expect(commandRunner.ExecutedCommands()).To(BeEmpty())
+			})
+		})
+	})
+
+	Describe("Delete", func() {
+		It("runs 'runc delete --force'", func() {
+			Expect(runner.Delete(logger, "some-container")).To(Succeed())
+			Expect(commandRunner).To(HaveExecutedSerially(fake_command_runner.CommandSpec{
+				Path: "funC",
+				Args: []string{"delete", "--force", "some-container"},
+			}))
+		})
+
+		It("returns any stderr output when 'runc delete' fails", func() {
+			commandRunner.WhenRunning(fake_command_runner.CommandSpec{}, func(cmd *exec.Cmd) error {
+				cmd.Stderr.Write([]byte("some error"))
+				return errors.New("exit status banana")
+			})
+
+			Expect(runner.Delete(logger, "some-container")).To(MatchError("runc delete: exit status banana: some error"))
+		})
+
+		Context("when the runtime binary fails verification", func() {
+			BeforeEach(func() {
+				verifier.VerifyReturns(errors.New("tampered"))
+			})
+
+			It("returns the error without running the binary", func() {
+				Expect(runner.Delete(logger, "some-container")).To(MatchError("tampered"))
+				Expect(commandRunner.ExecutedCommands()).To(BeEmpty())
+			})
+		})
+	})
+
+	Describe("timing runc out", func() {
+		var (
+			process    *gardenfakes.FakeProcess
+			bundlePath string
+		)
+
+		writePid := func(_ string, cmd *exec.Cmd, _ garden.ProcessIO, _ *garden.TTYSpec) (garden.Process, error) {
+			for i, arg := range cmd.Args {
+				if arg == "--pid-file" {
+					Expect(ioutil.WriteFile(cmd.Args[i+1], []byte("1234"), 0644)).To(Succeed())
+				}
+			}
+
+			return process, nil
+		}
+
+		BeforeEach(func() {
+			var err error
+			bundlePath, err = ioutil.TempDir("", "bundle")
+			Expect(err).NotTo(HaveOccurred())
+
+			process = new(gardenfakes.FakeProcess)
+			tracker.RunReturns(process, nil)
+
+			runner = runrunc.New(
+				tracker,
+				linux_command_runner.New(),
+				pidGenerator,
+				runcBinary,
+				verifier,
+				runrunc.NewExecPreparer(bundleLoader, users, mkdirer),
+				prioritizer,
+				"",
+				200*time.Millisecond,
+			)
+		})
+
+		AfterEach(func() {
+			Expect(os.RemoveAll(bundlePath)).To(Succeed())
+		})
+
+		It("returns the container's process once runc has started it", func() {
+			tracker.RunStub = writePid
+
+			startedProcess, err := runner.Start(logger, bundlePath, "handle", garden.ProcessIO{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(startedProcess).To(Equal(process))
+
+			_, cmd, _, _ := tracker.RunArgsForCall(0)
+			Expect(cmd.Args[:3]).To(Equal([]string{"funC", "start", "--pid-file"}))
+			Expect(process.SignalCallCount()).To(Equal(0))
+		})
+
+		It("kills runc and returns a timeout error when it does not start the container in time", func() {
+			_, err := runner.Start(logger, bundlePath, "handle", garden.ProcessIO{})
+			Expect(gardener.FailureClass(err, "")).To(Equal(gardener.FailureRuncTimeout))
+			Expect(err).To(MatchError(HavePrefix("runc timed out after 200ms: funC start --pid-file")))
+
+			Expect(process.SignalCallCount()).To(Equal(1))
+			Expect(process.SignalArgsForCall(0)).To(Equal(garden.SignalKill))
+		})
+
+		It("returns the exec'd process once runc has started it", func() {
+			tracker.RunStub = writePid
+
+			execdProcess, err := runner.Exec(logger, bundlePath, "someid", garden.ProcessSpec{}, garden.ProcessIO{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(execdProcess).To(Equal(process))
+			Expect(process.SignalCallCount()).To(Equal(0))
+		})
+
+		It("kills runc and returns a timeout error when it does not exec the process in time", func() {
+			_, err := runner.Exec(logger, bundlePath, "someid", garden.ProcessSpec{}, garden.ProcessIO{})
+			Expect(gardener.FailureClass(err, "")).To(Equal(gardener.FailureRuncTimeout))
+
+			Expect(process.SignalCallCount()).To(Equal(1))
+			Expect(process.SignalArgsForCall(0)).To(Equal(garden.SignalKill))
+		})
+
+		It("kills 'runc kill' and returns a timeout error when it does not exit in time", func() {
+			runcBinary.KillCommandStub = func(id, signal string) *exec.Cmd {
+				return exec.Command("sleep", "100")
+			}
+
+			err := runner.Kill(logger, "some-container")
+			Expect(gardener.FailureClass(err, "")).To(Equal(gardener.FailureRuncTimeout))
+			Expect(err).To(MatchError("runc timed out after 200ms: sleep 100"))
+		})
+
+		It("kills 'runc delete' and returns a timeout error when it does not exit in time", func() {
+			runcBinary.DeleteCommandStub = func(id string) *exec.Cmd {
+				return exec.Command("sleep", "100")
+			}
+
+			err := runner.Delete(logger, "some-container")
+			Expect(gardener.FailureClass(err, "")).To(Equal(gardener.FailureRuncTimeout))
+		})
+	})
+
+	Describe("forwarding runc's log", func() {
+		var (
+			logDir     string
+			testLogger *lagertest.TestLogger
+		)
+
+		writeRuncLog := func(args []string, line string) {
+			Expect(args[1]).To(Equal("--log"))
+			Expect(ioutil.WriteFile(args[2], []byte(line+"\n"), 0600)).To(Succeed())
+		}
+
+		BeforeEach(func() {
+			var err error
+			logDir, err = ioutil.TempDir("", "runc-logs")
+			Expect(err).NotTo(HaveOccurred())
+
+			testLogger = lagertest.NewTestLogger("test")
+			pidGenerator.GenerateReturns("some-process-id")
+
+			runner = runrunc.New(
+				tracker,
+				commandRunner,
+				pidGenerator,
+				runcBinary,
+				verifier,
+				runrunc.NewExecPreparer(bundleLoader, users, mkdirer),
+				prioritizer,
+				logDir,
+				0,
+			)
+		})
+
+		AfterEach(func() {
+			Expect(os.RemoveAll(logDir)).To(Succeed())
+		})
+
+		It("forwards runc's log for 'runc kill' tagged with the handle, then removes it", func() {
+			commandRunner.WhenRunning(fake_command_runner.CommandSpec{}, func(cmd *exec.Cmd) error {
+				Expect(cmd.Args).To(ContainElement("json"))
+				writeRuncLog(cmd.Args, `{"level":"error","msg":"container not running"}`)
+				return errors.New("exit status 1")
+			})
+
+			Expect(runner.Kill(testLogger, "some-container")).NotTo(Succeed())
+
+			entry := logWithMessage(testLogger, "test.kill.runc-log.runc")
+			Expect(entry.Data).To(HaveKeyWithValue("handle", "some-container"))
+			Expect(entry.Data).To(HaveKeyWithValue("message", "container not running"))
+
+			entries, err := ioutil.ReadDir(logDir)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(entries).To(BeEmpty())
+		})
+
+		It("logs overlapping kills of a container to files of their own", func() {
+			generated := 0
+			pidGenerator.GenerateStub = func() string {
+				generated++
+				return "invocation-" + strconv.Itoa(generated)
+			}
+
+			var logPaths []string
+			commandRunner.WhenRunning(fake_command_runner.CommandSpec{}, func(cmd *exec.Cmd) error {
+				logPaths = append(logPaths, cmd.Args[2])
+				return nil
+			})
+
+			Expect(runner.Kill(testLogger, "some-container")).To(Succeed())
+			Expect(runner.Kill(testLogger, "some-container")).To(Succeed())
+
+			Expect(logPaths).To(HaveLen(2))
+			Expect(logPaths[0]).NotTo(Equal(logPaths[1]))
+		})
+
+		It("forwards runc's log for 'runc start' once the process has exited", func() {
+			process := new(gardenfakes.FakeProcess)
+			exited := make(chan struct{})
+			process.WaitStub = func() (int, error) {
+				<-exited
+				return 0, nil
+			}
+
+			tracker.RunStub = func(_ string, cmd *exec.Cmd, _ garden.ProcessIO, _ *garden.TTYSpec) (garden.Process, error) {
+				writeRuncLog(cmd.Args, `{"level":"warning","msg":"something odd"}`)
+				return process, nil
+			}
+
+			_, err := runner.Start(testLogger, "some/oci/container", "some-handle", garden.ProcessIO{})
+			Expect(err).NotTo(HaveOccurred())
+
+			Consistently(testLogger.LogMessages).ShouldNot(ContainElement("test.start.runc-log.runc"))
+			close(exited)
+			Eventually(testLogger.LogMessages).Should(ContainElement("test.start.runc-log.runc"))
+
+			entry := logWithMessage(testLogger, "test.start.runc-log.runc")
+			Expect(entry.Data).To(HaveKeyWithValue("handle", "some-handle"))
+			Expect(entry.Data).To(HaveKeyWithValue("process-id", "some-process-id"))
+		})
+
+		It("passes the log flags to runc rather than to the shell setting the umask", func() {
+			bndl := &goci.Bndl{}
+			bndl.Spec.Spec.Root.Path = "/some/rootfs/path"
+			bndl.Spec.Spec.Process.Env = []string{"GARDEN_UMASK=0022"}
+			bundleLoader.LoadReturns(bndl, nil)
+
+			_, err := runner.Exec(testLogger, "some/oci/container", "someid", garden.ProcessSpec{}, garden.ProcessIO{})
+			Expect(err).NotTo(HaveOccurred())
+
+			_, cmd, _, _ := tracker.RunArgsForCall(0)
+			Expect(cmd.Args[4:7]).To(Equal([]string{"funC", "--log", filepath.Join(logDir, "some-process-id.log")}))
+		})
 	})
 })
+
+func logWithMessage(logger *lagertest.TestLogger, message string) lager.LogFormat {
+	for _, l := range logger.Logs() {
+		if l.Message == message {
+			return l
+		}
+	}
+
+	Fail("no log with message " + message)
+	return lager.LogFormat{}
+}
+
+// closingWriter records what is written to it until it is closed, after
+// which writes fail as they do once a client has disconnected
+type closingWriter struct {
+	written string
+	closed  bool
+}
+
+func (w *closingWriter) Write(data []byte) (int, error) {
+	if w.closed {
+		return 0, errors.New("broken pipe")
+	}
+
+	w.written += string(data)
+	return len(data), nil
+}
+
+// containerProcessTracker records the processes run in each container
+type containerProcessTracker struct {
+	*fakes.FakeProcessTracker
+
+	handles     []string
+	processIDs  []string
+	timestamped []string
+}
+
+func (t *containerProcessTracker) RunInContainer(handle, id string, cmd *exec.Cmd, io garden.ProcessIO, tty *garden.TTYSpec) (garden.Process, error) {
+	t.handles = append(t.handles, handle)
+	t.processIDs = append(t.processIDs, id)
+	return nil, nil
+}
+
+func (t *containerProcessTracker) RunTimestamped(handle, id string, cmd *exec.Cmd, io garden.ProcessIO, tty *garden.TTYSpec) (garden.Process, error) {
+	t.handles = append(t.handles, handle)
+	t.timestamped = append(t.timestamped, id)
+	return nil, nil
+}
+
+func (t *containerProcessTracker) AttachInContainer(handle, id string, io garden.ProcessIO) (garden.Process, error) {
+	t.handles = append(t.handles, handle)
+	t.processIDs = append(t.processIDs, id)
+	return nil, nil
+}
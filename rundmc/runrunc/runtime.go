@@ -0,0 +1,207 @@
+package runrunc
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os/exec"
+	"text/template"
+)
+
+// RuntimeArgs are the arguments an OCI runtime is invoked with for each
+// operation, as text/template templates. Within them .ID is the container's
+// id, .BundlePath is the path of its bundle, .ProcessJSON is the path of an
+// exec'd process's spec, .Signal is the signal to send, .ImagePath is the path
+// of a checkpoint's images and .LogPath is the path the runtime should log to.
+type RuntimeArgs struct {
+	Start      []string `json:"start,omitempty"`
+	Exec       []string `json:"exec,omitempty"`
+	Kill       []string `json:"kill,omitempty"`
+	Delete     []string `json:"delete,omitempty"`
+	Checkpoint []string `json:"checkpoint,omitempty"`
+	Restore    []string `json:"restore,omitempty"`
+
+	// Update reads the container's new resources, as JSON, from stdin
+	Update []string `json:"update,omitempty"`
+
+	// Log are the global arguments which make the runtime write its log, as
+	// JSON lines, to .LogPath
+	Log []string `json:"log,omitempty"`
+}
+
+// RuncArgs are runc's arguments. crun and kata-runtime accept the same ones.
+// runc gives each container it starts a session keyring of its own, named
+// after the container, unless it is passed NoNewKeyringArg.
+var RuncArgs = RuntimeArgs{
+	Start:      []string{"start", "{{.ID}}"},
+	Exec:       []string{"exec", "{{.ID}}", "{{.ProcessJSON}}"},
+	Kill:       []string{"kill", "{{.ID}}", "{{.Signal}}"},
+	Delete:     []string{"delete", "--force", "{{.ID}}"},
+	Checkpoint: []string{"--id", "{{.ID}}", "checkpoint", "--image-path", "{{.ImagePath}}"},
+	Restore:    []string{"--id", "{{.ID}}", "restore", "--image-path", "{{.ImagePath}}"},
+	Update:     []string{"update", "--resources", "-", "{{.ID}}"},
+	Log:        []string{"--log", "{{.LogPath}}", "--log-format", "json"},
+}
+
+// NoNewKeyringArg makes runc start containers in the host's session keyring,
+// where they could find and clobber each other's keys, so runtime plugins
+// refuse to be given it
+const NoNewKeyringArg = "--no-new-keyring"
+
+// WincArgs are the arguments of winc, the Windows OCI runtime. winc cannot
+// checkpoint, restore or update containers.
+var WincArgs = RuntimeArgs{
+	Start:  []string{"run", "--bundle", "{{.BundlePath}}", "{{.ID}}"},
+	Exec:   []string{"exec", "--process", "{{.ProcessJSON}}", "{{.ID}}"},
+	Kill:   []string{"kill", "{{.ID}}", "{{.Signal}}"},
+	Delete: []string{"delete", "{{.ID}}"},
+	Log:    []string{"--log", "{{.LogPath}}", "--log-format", "json"},
+}
+
+// LoadRuntimeArgs reads RuntimeArgs from a JSON file. Operations which are
+// not in the file take their arguments from defaults.
+func LoadRuntimeArgs(path string, defaults RuntimeArgs) (RuntimeArgs, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return RuntimeArgs{}, fmt.Errorf("runtime args: %s", err)
+	}
+
+	args := defaults
+	if err := json.Unmarshal(data, &args); err != nil {
+		return RuntimeArgs{}, fmt.Errorf("runtime args: parse %s: %s", path, err)
+	}
+
+	return args, nil
+}
+
+type runtimeArgValues struct {
+	ID          string
+	BundlePath  string
+	ProcessJSON string
+	Signal      string
+	ImagePath   string
+	LogPath     string
+}
+
+// RuntimePlugin builds the commands for an OCI runtime binary, such as runc,
+// crun or runsc, from templates of its arguments. It implements
+// RuncBinary, CheckpointBinary and UpdateBinary.
+type RuntimePlugin struct {
+	path      string
+	extraArgs []string
+	args      map[string][]*template.Template
+
+	// logPath is where the runtime logs to; if empty, the log arguments are
+	// not passed
+	logPath string
+}
+
+// NewRuntimePlugin returns a RuntimePlugin which runs the binary at path,
+// passing extraArgs before the arguments of every operation
+func NewRuntimePlugin(path string, extraArgs []string, args RuntimeArgs) (*RuntimePlugin, error) {
+	for _, arg := range extraArgs {
+		if arg == NoNewKeyringArg {
+			return nil, fmt.Errorf("runtime plugin: invalid argument '%s': containers must not share the host's session keyring", arg)
+		}
+	}
+
+	plugin := &RuntimePlugin{
+		path:      path,
+		extraArgs: extraArgs,
+		args:      make(map[string][]*template.Template),
+	}
+
+	for op, opArgs := range map[string][]string{
+		"start":      args.Start,
+		"exec":       args.Exec,
+		"kill":       args.Kill,
+		"delete":     args.Delete,
+		"checkpoint": args.Checkpoint,
+		"restore":    args.Restore,
+		"update":     args.Update,
+		"log":        args.Log,
+	} {
+		for _, arg := range opArgs {
+			if arg == NoNewKeyringArg {
+				return nil, fmt.Errorf("runtime plugin: invalid %s argument '%s': containers must not share the host's session keyring", op, arg)
+			}
+
+			tmpl, err := template.New(op).Parse(arg)
+			if err == nil {
+				// unknown fields are only detected when the template is executed
+				err = tmpl.Execute(ioutil.Discard, runtimeArgValues{})
+			}
+
+			if err != nil {
+				return nil, fmt.Errorf("runtime plugin: invalid %s argument '%s': %s", op, arg, err)
+			}
+
+			plugin.args[op] = append(plugin.args[op], tmpl)
+		}
+	}
+
+	return plugin, nil
+}
+
+// WithLog returns a copy of the plugin whose commands log to logPath
+func (p *RuntimePlugin) WithLog(logPath string) RuncBinary {
+	withLog := *p
+	withLog.logPath = logPath
+	return &withLog
+}
+
+func (p *RuntimePlugin) StartCommand(path, id string) *exec.Cmd {
+	cmd := p.command("start", runtimeArgValues{ID: id, BundlePath: path})
+	cmd.Dir = path
+	return cmd
+}
+
+func (p *RuntimePlugin) ExecCommand(id, processJSONPath string) *exec.Cmd {
+	return p.command("exec", runtimeArgValues{ID: id, ProcessJSON: processJSONPath})
+}
+
+func (p *RuntimePlugin) KillCommand(id, signal string) *exec.Cmd {
+	return p.command("kill", runtimeArgValues{ID: id, Signal: signal})
+}
+
+func (p *RuntimePlugin) DeleteCommand(id string) *exec.Cmd {
+	return p.command("delete", runtimeArgValues{ID: id})
+}
+
+func (p *RuntimePlugin) CheckpointCommand(id, imagePath string) *exec.Cmd {
+	return p.command("checkpoint", runtimeArgValues{ID: id, ImagePath: imagePath})
+}
+
+func (p *RuntimePlugin) RestoreCommand(bundlePath, id, imagePath string) *exec.Cmd {
+	cmd := p.command("restore", runtimeArgValues{ID: id, BundlePath: bundlePath, ImagePath: imagePath})
+	cmd.Dir = bundlePath
+	return cmd
+}
+
+func (p *RuntimePlugin) UpdateCommand(id string) *exec.Cmd {
+	return p.command("update", runtimeArgValues{ID: id})
+}
+
+func (p *RuntimePlugin) command(op string, values runtimeArgValues) *exec.Cmd {
+	args := append([]string{}, p.extraArgs...)
+	if p.logPath != "" {
+		values.LogPath = p.logPath
+		args = append(args, p.render("log", values)...)
+	}
+
+	return exec.Command(p.path, append(args, p.render(op, values)...)...)
+}
+
+func (p *RuntimePlugin) render(op string, values runtimeArgValues) []string {
+	var args []string
+	for _, tmpl := range p.args[op] {
+		var buf bytes.Buffer
+
+		// the templates were checked when the plugin was created
+		tmpl.Execute(&buf, values)
+		args = append(args, buf.String())
+	}
+
+	return args
+}
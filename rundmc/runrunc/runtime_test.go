@@ -0,0 +1,203 @@
+package runrunc_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/cloudfoundry-incubator/goci"
+	"github.com/cloudfoundry-incubator/guardian/rundmc/runrunc"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("RuntimePlugin", func() {
+	var plugin *runrunc.RuntimePlugin
+
+	BeforeEach(func() {
+		var err error
+		plugin, err = runrunc.NewRuntimePlugin("/path/to/runc", nil, runrunc.RuncArgs)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	Context("with runc's arguments", func() {
+		It("builds start commands which run in the bundle", func() {
+			cmd := plugin.StartCommand("/path/to/bundle", "some-id")
+			Expect(cmd.Path).To(Equal("/path/to/runc"))
+			Expect(cmd.Args).To(Equal([]string{"/path/to/runc", "start", "some-id"}))
+			Expect(cmd.Dir).To(Equal("/path/to/bundle"))
+		})
+
+		It("builds exec commands", func() {
+			cmd := plugin.ExecCommand("some-id", "/path/to/process.json")
+			Expect(cmd.Args).To(Equal([]string{"/path/to/runc", "exec", "some-id", "/path/to/process.json"}))
+		})
+
+		It("builds kill commands", func() {
+			cmd := plugin.KillCommand("some-id", "KILL")
+			Expect(cmd.Args).To(Equal([]string{"/path/to/runc", "kill", "some-id", "KILL"}))
+		})
+
+		It("builds delete commands which force the deletion", func() {
+			cmd := plugin.DeleteCommand("some-id")
+			Expect(cmd.Args).To(Equal([]string{"/path/to/runc", "delete", "--force", "some-id"}))
+		})
+
+		It("builds checkpoint and restore commands", func() {
+			cmd := plugin.CheckpointCommand("some-id", "/path/to/images")
+			Expect(cmd.Args).To(Equal([]string{"/path/to/runc", "--id", "some-id", "checkpoint", "--image-path", "/path/to/images"}))
+
+			cmd = plugin.RestoreCommand("/path/to/bundle", "some-id", "/path/to/images")
+			Expect(cmd.Args).To(Equal([]string{"/path/to/runc", "--id", "some-id", "restore", "--image-path", "/path/to/images"}))
+			Expect(cmd.Dir).To(Equal("/path/to/bundle"))
+		})
+
+		It("builds update commands which read the resources from stdin", func() {
+			cmd := plugin.UpdateCommand("some-id")
+			Expect(cmd.Args).To(Equal([]string{"/path/to/runc", "update", "--resources", "-", "some-id"}))
+		})
+
+		// guardian ran runc through goci.RuncBinary before runtime plugins,
+		// and still runs runc with runc's arguments when no plugin is set
+		It("builds the same start, exec and kill commands as goci.RuncBinary", func() {
+			runc := goci.RuncBinary("/path/to/runc")
+
+			start, expectedStart := plugin.StartCommand("/path/to/bundle", "some-id"), runc.StartCommand("/path/to/bundle", "some-id")
+			Expect(start.Args).To(Equal(expectedStart.Args))
+			Expect(start.Dir).To(Equal(expectedStart.Dir))
+
+			Expect(plugin.ExecCommand("some-id", "/path/to/process.json").Args).To(Equal(runc.ExecCommand("some-id", "/path/to/process.json").Args))
+			Expect(plugin.KillCommand("some-id", "KILL").Args).To(Equal(runc.KillCommand("some-id", "KILL").Args))
+		})
+	})
+
+	Describe("WithLog", func() {
+		It("adds the log arguments before the operation's arguments", func() {
+			cmd := plugin.WithLog("/path/to/log").KillCommand("some-id", "TERM")
+			Expect(cmd.Args).To(Equal([]string{
+				"/path/to/runc", "--log", "/path/to/log", "--log-format", "json", "kill", "some-id", "TERM",
+			}))
+		})
+
+		It("does not change the original plugin", func() {
+			plugin.WithLog("/path/to/log")
+			Expect(plugin.KillCommand("some-id", "TERM").Args).NotTo(ContainElement("--log"))
+		})
+	})
+
+	Context("with extra arguments", func() {
+		It("passes them first", func() {
+			plugin, err := runrunc.NewRuntimePlugin("/path/to/runsc", []string{"--platform=ptrace", "--network=none"}, runrunc.RuncArgs)
+			Expect(err).NotTo(HaveOccurred())
+
+			cmd := plugin.WithLog("/path/to/log").StartCommand("/path/to/bundle", "some-id")
+			Expect(cmd.Args).To(Equal([]string{
+				"/path/to/runsc", "--platform=ptrace", "--network=none",
+				"--log", "/path/to/log", "--log-format", "json",
+				"start", "some-id",
+			}))
+		})
+	})
+
+	Context("with winc's arguments", func() {
+		BeforeEach(func() {
+			var err error
+			plugin, err = runrunc.NewRuntimePlugin("/path/to/winc.exe", nil, runrunc.WincArgs)
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("builds start commands which run the bundle", func() {
+			Expect(plugin.StartCommand("/path/to/bundle", "some-id").Args).To(Equal([]string{
+				"/path/to/winc.exe", "run", "--bundle", "/path/to/bundle", "some-id",
+			}))
+		})
+
+		It("builds exec commands", func() {
+			Expect(plugin.ExecCommand("some-id", "/path/to/process.json").Args).To(Equal([]string{
+				"/path/to/winc.exe", "exec", "--process", "/path/to/process.json", "some-id",
+			}))
+		})
+
+		It("builds delete commands", func() {
+			Expect(plugin.DeleteCommand("some-id").Args).To(Equal([]string{"/path/to/winc.exe", "delete", "some-id"}))
+		})
+	})
+
+	Context("with custom arguments", func() {
+		It("renders them", func() {
+			args := runrunc.RuncArgs
+			args.Exec = []string{"exec", "--process", "{{.ProcessJSON}}", "{{.ID}}"}
+			args.Start = []string{"start", "--bundle", "{{.BundlePath}}", "{{.ID}}"}
+
+			plugin, err := runrunc.NewRuntimePlugin("/path/to/runtime", nil, args)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(plugin.ExecCommand("some-id", "/path/to/process.json").Args).To(Equal([]string{
+				"/path/to/runtime", "exec", "--process", "/path/to/process.json", "some-id",
+			}))
+			Expect(plugin.StartCommand("/path/to/bundle", "some-id").Args).To(Equal([]string{
+				"/path/to/runtime", "start", "--bundle", "/path/to/bundle", "some-id",
+			}))
+		})
+
+		It("rejects arguments which are not valid templates", func() {
+			args := runrunc.RuncArgs
+			args.Kill = []string{"kill", "{{.ID"}
+
+			_, err := runrunc.NewRuntimePlugin("/path/to/runtime", nil, args)
+			Expect(err).To(MatchError(ContainSubstring("runtime plugin: invalid kill argument '{{.ID'")))
+		})
+
+		It("rejects arguments which refer to unknown values", func() {
+			args := runrunc.RuncArgs
+			args.Kill = []string{"kill", "{{.Handle}}"}
+
+			_, err := runrunc.NewRuntimePlugin("/path/to/runtime", nil, args)
+			Expect(err).To(MatchError(ContainSubstring("runtime plugin: invalid kill argument '{{.Handle}}'")))
+		})
+
+		It("rejects arguments which would share the host's session keyring with containers", func() {
+			args := runrunc.RuncArgs
+			args.Start = []string{"start", runrunc.NoNewKeyringArg, "{{.ID}}"}
+
+			_, err := runrunc.NewRuntimePlugin("/path/to/runtime", nil, args)
+			Expect(err).To(MatchError(ContainSubstring("runtime plugin: invalid start argument '--no-new-keyring'")))
+
+			_, err = runrunc.NewRuntimePlugin("/path/to/runtime", []string{runrunc.NoNewKeyringArg}, runrunc.RuncArgs)
+			Expect(err).To(MatchError(ContainSubstring("runtime plugin: invalid argument '--no-new-keyring'")))
+		})
+	})
+
+	Describe("LoadRuntimeArgs", func() {
+		var dir string
+
+		BeforeEach(func() {
+			var err error
+			dir, err = ioutil.TempDir("", "runtime-args")
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		AfterEach(func() {
+			Expect(os.RemoveAll(dir)).To(Succeed())
+		})
+
+		It("takes the arguments of operations which are not in the file from the defaults", func() {
+			path := filepath.Join(dir, "args.json")
+			Expect(ioutil.WriteFile(path, []byte(`{"exec": ["exec", "--process", "{{.ProcessJSON}}", "{{.ID}}"]}`), 0600)).To(Succeed())
+
+			args, err := runrunc.LoadRuntimeArgs(path, runrunc.RuncArgs)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(args.Exec).To(Equal([]string{"exec", "--process", "{{.ProcessJSON}}", "{{.ID}}"}))
+			Expect(args.Start).To(Equal(runrunc.RuncArgs.Start))
+			Expect(args.Log).To(Equal(runrunc.RuncArgs.Log))
+		})
+
+		It("returns an error when the file is not valid JSON", func() {
+			path := filepath.Join(dir, "args.json")
+			Expect(ioutil.WriteFile(path, []byte(`potato`), 0600)).To(Succeed())
+
+			_, err := runrunc.LoadRuntimeArgs(path, runrunc.RuncArgs)
+			Expect(err).To(MatchError(ContainSubstring("runtime args: parse")))
+		})
+	})
+})
@@ -0,0 +1,56 @@
+package runrunc
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/cloudfoundry-incubator/guardian/gardener"
+	"github.com/cloudfoundry-incubator/guardian/pkg/cmdtimeout"
+	"github.com/cloudfoundry/gunk/command_runner"
+)
+
+// RuncTimeoutError is returned when a runc invocation is killed for taking
+// longer than its timeout, e.g. because runc is wedged on a hung mount
+type RuncTimeoutError struct {
+	Args    []string
+	Timeout time.Duration
+}
+
+func (e RuncTimeoutError) Error() string {
+	return fmt.Sprintf("runc timed out after %s: %s", e.Timeout, strings.Join(e.Args, " "))
+}
+
+// TimeoutRunner is a CommandRunner whose Run kills the command, along with
+// any processes it has started, if it has not exited within Timeout, for
+// runc invocations made outside RunRunc, e.g. 'runc update'. Only Run is
+// limited: Start is used for long-running processes, e.g. a container's init
+// process, which must not time out.
+type TimeoutRunner struct {
+	command_runner.CommandRunner
+
+	// Timeout is how long a command may run for; if it is zero, commands
+	// are not timed out
+	Timeout time.Duration
+}
+
+func (r TimeoutRunner) Run(cmd *exec.Cmd) error {
+	err := cmdtimeout.Runner{CommandRunner: r.CommandRunner, Timeout: r.Timeout}.Run(cmd)
+	if timeoutErr, ok := err.(cmdtimeout.Error); ok {
+		return gardener.Classify(gardener.FailureRuncTimeout, RuncTimeoutError{Args: timeoutErr.Args, Timeout: timeoutErr.Timeout})
+	}
+
+	return err
+}
+
+// runcError returns the error of a runc invocation which failed, with its
+// output, unless it timed out, in which case its structured error is returned
+// as it is
+func runcError(op string, err error, output string) error {
+	if gardener.FailureClass(err, "") == gardener.FailureRuncTimeout {
+		return err
+	}
+
+	return fmt.Errorf("runc %s: %s: %s", op, err, output)
+}
@@ -0,0 +1,36 @@
+package runrunc_test
+
+import (
+	"os/exec"
+	"time"
+
+	"github.com/cloudfoundry-incubator/guardian/gardener"
+	"github.com/cloudfoundry-incubator/guardian/rundmc/runrunc"
+	"github.com/cloudfoundry/gunk/command_runner/linux_command_runner"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("TimeoutRunner", func() {
+	var runner runrunc.TimeoutRunner
+
+	BeforeEach(func() {
+		runner = runrunc.TimeoutRunner{CommandRunner: linux_command_runner.New(), Timeout: 200 * time.Millisecond}
+	})
+
+	It("returns the result of commands which exit in time", func() {
+		Expect(runner.Run(exec.Command("true"))).To(Succeed())
+		Expect(runner.Run(exec.Command("false"))).To(MatchError("exit status 1"))
+	})
+
+	It("kills commands which run for too long, and returns a classified timeout error", func() {
+		err := runner.Run(exec.Command("sleep", "100"))
+
+		Expect(gardener.FailureClass(err, "")).To(Equal(gardener.FailureRuncTimeout))
+		Expect(err).To(MatchError("runc timed out after 200ms: sleep 100"))
+
+		timeoutErr, ok := err.(gardener.ClassifiedError).Err.(runrunc.RuncTimeoutError)
+		Expect(ok).To(BeTrue())
+		Expect(timeoutErr.Timeout).To(Equal(200 * time.Millisecond))
+	})
+})
@@ -0,0 +1,39 @@
+package runrunc
+
+import (
+	"fmt"
+	"os/exec"
+	"strconv"
+
+	"github.com/cloudfoundry-incubator/garden"
+)
+
+// TimestampOutputEnv is the environment variable in a ProcessSpec's Env
+// which, when true, has the process's iodaemon prefix every line the process
+// writes to stdout and stderr with an RFC3339 timestamp of when it was read
+// from the process, so that log pipelines without timestamps of their own
+// can tell when each line was written.
+const TimestampOutputEnv = "GARDEN_TIMESTAMP_OUTPUT"
+
+// TimestampingProcessTracker is implemented by ProcessTrackers which can
+// timestamp the output of a process as they capture it
+type TimestampingProcessTracker interface {
+	RunTimestamped(handle, id string, cmd *exec.Cmd, io garden.ProcessIO, tty *garden.TTYSpec) (garden.Process, error)
+}
+
+// extractTimestampOutput removes any TimestampOutputEnv entries from env,
+// returning the remaining environment and the value of the last entry
+// (false if there is none)
+func extractTimestampOutput(env []string) ([]string, bool, error) {
+	rest, raw := extractEnv(env, TimestampOutputEnv)
+	if raw == "" {
+		return rest, false, nil
+	}
+
+	timestamp, err := strconv.ParseBool(raw)
+	if err != nil {
+		return nil, false, fmt.Errorf("invalid %s: '%s'", TimestampOutputEnv, raw)
+	}
+
+	return rest, timestamp, nil
+}
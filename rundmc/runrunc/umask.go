@@ -0,0 +1,83 @@
+package runrunc
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// UmaskEnv is the environment variable in a ProcessSpec's Env which
+// overrides the umask of the container for a single process.
+//
+// runc does not support setting a umask, and processes run with 'runc start'
+// or 'runc exec' inherit the umask of the runc invocation, so runc is run via
+// a shell which sets it.
+const UmaskEnv = "GARDEN_UMASK"
+
+// UmaskFile is the file in a container's bundle which holds the umask its
+// processes, including its init process, are run with. It is kept out of the
+// bundle's process environment so that it never reaches the init process.
+const UmaskFile = "umask"
+
+// SaveUmask records the umask of the processes of the bundle at bundlePath
+func SaveUmask(bundlePath, umask string) error {
+	return ioutil.WriteFile(filepath.Join(bundlePath, UmaskFile), []byte(umask), 0644)
+}
+
+// LoadUmask returns the umask of the processes of the bundle at bundlePath;
+// it is "" for bundles without one, whose processes inherit the server's
+// umask
+func LoadUmask(bundlePath string) (string, error) {
+	contents, err := ioutil.ReadFile(filepath.Join(bundlePath, UmaskFile))
+	if os.IsNotExist(err) {
+		return "", nil
+	}
+
+	if err != nil {
+		return "", fmt.Errorf("read umask: %s", err)
+	}
+
+	return strings.TrimSpace(string(contents)), nil
+}
+
+// ParseUmask parses an octal umask such as "022" or "0027"
+func ParseUmask(s string) (uint32, error) {
+	umask, err := strconv.ParseUint(s, 8, 32)
+	if err != nil || umask > 0777 {
+		return 0, fmt.Errorf("invalid umask: '%s'", s)
+	}
+
+	return uint32(umask), nil
+}
+
+// withUmask runs cmd via WithUmask if the umask is not empty
+func withUmask(cmd *exec.Cmd, rawUmask string) (*exec.Cmd, error) {
+	if rawUmask == "" {
+		return cmd, nil
+	}
+
+	umask, err := ParseUmask(rawUmask)
+	if err != nil {
+		return nil, err
+	}
+
+	return WithUmask(cmd, umask), nil
+}
+
+// WithUmask wraps cmd in a shell which sets the given umask before exec'ing
+// the original command, so that the command and its children inherit it
+func WithUmask(cmd *exec.Cmd, umask uint32) *exec.Cmd {
+	wrapped := exec.Command("/bin/sh", append([]string{
+		"-c", fmt.Sprintf(`umask %04o && exec "$@"`, umask), "sh", cmd.Path,
+	}, cmd.Args[1:]...)...)
+
+	wrapped.Env = cmd.Env
+	wrapped.Dir = cmd.Dir
+	wrapped.SysProcAttr = cmd.SysProcAttr
+	wrapped.Stdin, wrapped.Stdout, wrapped.Stderr = cmd.Stdin, cmd.Stdout, cmd.Stderr
+	return wrapped
+}
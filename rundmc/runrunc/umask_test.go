@@ -0,0 +1,71 @@
+package runrunc_test
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+
+	"github.com/cloudfoundry-incubator/guardian/rundmc/runrunc"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Umask", func() {
+	Describe("ParseUmask", func() {
+		It("parses octal umasks", func() {
+			Expect(runrunc.ParseUmask("022")).To(BeEquivalentTo(022))
+			Expect(runrunc.ParseUmask("0027")).To(BeEquivalentTo(027))
+		})
+
+		It("rejects umasks which are not octal or are too large", func() {
+			_, err := runrunc.ParseUmask("89")
+			Expect(err).To(MatchError("invalid umask: '89'"))
+
+			_, err = runrunc.ParseUmask("1777")
+			Expect(err).To(MatchError("invalid umask: '1777'"))
+		})
+	})
+
+	Describe("SaveUmask and LoadUmask", func() {
+		var bundlePath string
+
+		BeforeEach(func() {
+			var err error
+			bundlePath, err = ioutil.TempDir("", "bundle")
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		AfterEach(func() {
+			Expect(os.RemoveAll(bundlePath)).To(Succeed())
+		})
+
+		It("saves the umask in the bundle", func() {
+			Expect(runrunc.SaveUmask(bundlePath, "0027")).To(Succeed())
+			Expect(runrunc.LoadUmask(bundlePath)).To(Equal("0027"))
+		})
+
+		It("loads no umask from a bundle without one", func() {
+			Expect(runrunc.LoadUmask(bundlePath)).To(BeEmpty())
+		})
+	})
+
+	Describe("WithUmask", func() {
+		It("runs the command via a shell which sets the umask first", func() {
+			cmd := runrunc.WithUmask(exec.Command("/bin/sh", "-c", "umask"), 027)
+
+			out, err := cmd.Output()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(out)).To(Equal("0027\n"))
+		})
+
+		It("preserves the command's environment and working directory", func() {
+			cmd := exec.Command("/bin/sh", "-c", "echo $FOO; pwd")
+			cmd.Env = []string{"FOO=bar"}
+			cmd.Dir = "/"
+
+			out, err := runrunc.WithUmask(cmd, 022).Output()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(out)).To(Equal("bar\n/\n"))
+		})
+	})
+})
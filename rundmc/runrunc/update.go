@@ -0,0 +1,90 @@
+package runrunc
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+
+	"github.com/cloudfoundry/gunk/command_runner"
+	"github.com/pivotal-golang/lager"
+)
+
+//go:generate counterfeiter . UpdateBinary
+
+// UpdateBinary builds commands which update the resources of a running
+// container from a Resources document on their stdin
+type UpdateBinary interface {
+	UpdateCommand(id string) *exec.Cmd
+}
+
+// Resources are the limits of a running container which can be updated, in
+// the form of the OCI runtime spec's linux resources. Nil limits are left as
+// they are. A memory limit, CPU quota or pids limit of -1 removes the limit,
+// which the spec's own unsigned limits cannot express.
+type Resources struct {
+	Memory *MemoryResources `json:"memory,omitempty"`
+	CPU    *CPUResources    `json:"cpu,omitempty"`
+	Pids   *PidsResources   `json:"pids,omitempty"`
+}
+
+type MemoryResources struct {
+	Limit *int64 `json:"limit,omitempty"`
+	Swap  *int64 `json:"swap,omitempty"`
+}
+
+type CPUResources struct {
+	Shares *uint64 `json:"shares,omitempty"`
+	Quota  *int64  `json:"quota,omitempty"`
+	Period *uint64 `json:"period,omitempty"`
+}
+
+type PidsResources struct {
+	Limit int64 `json:"limit"`
+}
+
+// Updater updates the cgroup limits of running containers with 'runc
+// update'
+type Updater struct {
+	commandRunner command_runner.CommandRunner
+	runc          UpdateBinary
+	verifier      BinaryVerifier
+}
+
+func NewUpdater(runner command_runner.CommandRunner, runc UpdateBinary, verifier BinaryVerifier) *Updater {
+	return &Updater{
+		commandRunner: runner,
+		runc:          runc,
+		verifier:      verifier,
+	}
+}
+
+// Update applies the resources to the running container
+func (u *Updater) Update(log lager.Logger, id string, resources Resources) error {
+	log = log.Session("update", lager.Data{"id": id})
+
+	log.Info("started")
+	defer log.Info("finished")
+
+	if err := u.verifier.Verify(); err != nil {
+		log.Error("verify-runtime-binary", err)
+		return err
+	}
+
+	resourcesJSON, err := json.Marshal(resources)
+	if err != nil {
+		return fmt.Errorf("runc update: %s", err)
+	}
+
+	buf := &bytes.Buffer{}
+	cmd := u.runc.UpdateCommand(id)
+	cmd.Stdin = bytes.NewReader(resourcesJSON)
+	cmd.Stdout = buf
+	cmd.Stderr = buf
+	if err := u.commandRunner.Run(cmd); err != nil {
+		log.Error("run-failed", err, lager.Data{"output": buf.String()})
+		return runcError("update", err, buf.String())
+	}
+
+	return nil
+}
@@ -0,0 +1,88 @@
+package runrunc_test
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os/exec"
+
+	"github.com/cloudfoundry-incubator/guardian/rundmc/runrunc"
+	"github.com/cloudfoundry-incubator/guardian/rundmc/runrunc/fakes"
+	"github.com/cloudfoundry/gunk/command_runner/fake_command_runner"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/pivotal-golang/lager"
+	"github.com/pivotal-golang/lager/lagertest"
+)
+
+var _ = Describe("Updater", func() {
+	var (
+		commandRunner *fake_command_runner.FakeCommandRunner
+		runcBinary    *fakes.FakeUpdateBinary
+		verifier      *fakes.FakeBinaryVerifier
+		logger        lager.Logger
+
+		stdin   []byte
+		runErr  error
+		updater *runrunc.Updater
+	)
+
+	BeforeEach(func() {
+		commandRunner = fake_command_runner.New()
+		runcBinary = new(fakes.FakeUpdateBinary)
+		verifier = new(fakes.FakeBinaryVerifier)
+		logger = lagertest.NewTestLogger("test")
+
+		runcBinary.UpdateCommandStub = func(id string) *exec.Cmd {
+			return exec.Command("funC", "update", id)
+		}
+
+		stdin, runErr = nil, nil
+		commandRunner.WhenRunning(fake_command_runner.CommandSpec{Path: "funC"}, func(cmd *exec.Cmd) error {
+			var err error
+			stdin, err = ioutil.ReadAll(cmd.Stdin)
+			Expect(err).NotTo(HaveOccurred())
+
+			cmd.Stderr.Write([]byte("cgroup is gone"))
+			return runErr
+		})
+
+		updater = runrunc.NewUpdater(commandRunner, runcBinary, verifier)
+	})
+
+	It("runs 'runc update' with the resources on its stdin", func() {
+		limit, shares, quota, period := int64(1024), uint64(512), int64(-1), uint64(100000)
+		Expect(updater.Update(logger, "some-container", runrunc.Resources{
+			Memory: &runrunc.MemoryResources{Limit: &limit},
+			CPU:    &runrunc.CPUResources{Shares: &shares, Quota: &quota, Period: &period},
+			Pids:   &runrunc.PidsResources{Limit: -1},
+		})).To(Succeed())
+
+		Expect(runcBinary.UpdateCommandArgsForCall(0)).To(Equal("some-container"))
+
+		var resources map[string]interface{}
+		Expect(json.Unmarshal(stdin, &resources)).To(Succeed())
+		Expect(resources).To(Equal(map[string]interface{}{
+			"memory": map[string]interface{}{"limit": float64(1024)},
+			"cpu":    map[string]interface{}{"shares": float64(512), "quota": float64(-1), "period": float64(100000)},
+			"pids":   map[string]interface{}{"limit": float64(-1)},
+		}))
+	})
+
+	It("leaves out the resources which are not being updated", func() {
+		Expect(updater.Update(logger, "some-container", runrunc.Resources{})).To(Succeed())
+		Expect(string(stdin)).To(Equal("{}"))
+	})
+
+	It("returns runc's output when it fails", func() {
+		runErr = errors.New("exit status 1")
+		err := updater.Update(logger, "some-container", runrunc.Resources{})
+		Expect(err).To(MatchError("runc update: exit status 1: cgroup is gone"))
+	})
+
+	It("does not run runc when it fails verification", func() {
+		verifier.VerifyReturns(errors.New("checksum mismatch"))
+		Expect(updater.Update(logger, "some-container", runrunc.Resources{})).To(MatchError("checksum mismatch"))
+		Expect(commandRunner.ExecutedCommands()).To(BeEmpty())
+	})
+})
@@ -0,0 +1,63 @@
+package runrunc
+
+import (
+	"fmt"
+	"path/filepath"
+	"syscall"
+
+	"github.com/cloudfoundry-incubator/guardian/pkg/inotify"
+	"github.com/pivotal-golang/lager"
+)
+
+const watchMask = syscall.IN_CLOSE_WRITE | syscall.IN_MOVED_TO | syscall.IN_CREATE |
+	syscall.IN_DELETE | syscall.IN_ATTRIB
+
+// Watch invalidates the verifier whenever the runtime binary changes on disk
+// and re-verifies it immediately, logging an error if it no longer matches.
+// The parent directory is watched so that binaries replaced by rename are
+// noticed too.
+func Watch(log lager.Logger, verifier *ChecksumVerifier) error {
+	log = log.Session("watch-runtime-binary", lager.Data{"path": verifier.Path()})
+
+	fd, err := syscall.InotifyInit1(syscall.IN_CLOEXEC)
+	if err != nil {
+		return fmt.Errorf("watch runtime binary: %s", err)
+	}
+
+	dir, name := filepath.Split(verifier.Path())
+	if _, err := syscall.InotifyAddWatch(fd, filepath.Clean(dir), watchMask); err != nil {
+		syscall.Close(fd)
+		return fmt.Errorf("watch runtime binary: %s", err)
+	}
+
+	go func() {
+		defer syscall.Close(fd)
+
+		buf := make([]byte, 64*(syscall.SizeofInotifyEvent+syscall.NAME_MAX+1))
+		for {
+			n, err := syscall.Read(fd, buf)
+			if err != nil {
+				if err == syscall.EINTR {
+					continue
+				}
+
+				log.Error("read-failed", err)
+				return
+			}
+
+			if !inotify.ContainsEventFor(buf[:n], name) {
+				continue
+			}
+
+			verifier.Invalidate()
+			if err := verifier.Verify(); err != nil {
+				log.Error("verification-failed", err)
+				continue
+			}
+
+			log.Info("verified")
+		}
+	}()
+
+	return nil
+}
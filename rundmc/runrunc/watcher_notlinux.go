@@ -0,0 +1,13 @@
+// +build !linux
+
+package runrunc
+
+import (
+	"errors"
+
+	"github.com/pivotal-golang/lager"
+)
+
+func Watch(log lager.Logger, verifier *ChecksumVerifier) error {
+	return errors.New("watching the runtime binary is not supported on this platform")
+}
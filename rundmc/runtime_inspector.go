@@ -0,0 +1,122 @@
+package rundmc
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/cloudfoundry-incubator/guardian/gardener"
+	"github.com/pivotal-golang/lager"
+)
+
+//go:generate counterfeiter . RawContainerStater
+//go:generate counterfeiter . IODaemonPidLister
+
+// RawContainerStater returns the runtime's own state document of a
+// container, in full
+type RawContainerStater interface {
+	RawState(log lager.Logger, id string) (json.RawMessage, error)
+}
+
+// IODaemonPidLister lists the host pids of the iodaemons serving a
+// container's processes, by process ID
+type IODaemonPidLister interface {
+	IODaemonPids(handle string) map[string]int
+}
+
+// RuntimeInspector reads the runtime state of containers from the depot,
+// runc's state and proc, where ProcPath is mounted (usually /proc). If
+// BundleLoader is set, the container's rootfs is read from its bundle; if
+// RawStater is set, the runtime's state document is included in full; and if
+// IODaemons is set, the pids of the iodaemons of its processes are listed.
+type RuntimeInspector struct {
+	Depot        Depot
+	Stater       ContainerStater
+	BundleLoader BundleLoader
+	RawStater    RawContainerStater
+	IODaemons    IODaemonPidLister
+	ProcPath     string
+}
+
+func (i *RuntimeInspector) Inspect(log lager.Logger, handle string) (gardener.ContainerRuntime, error) {
+	bundlePath, err := i.Depot.Lookup(log, handle)
+	if err != nil {
+		return gardener.ContainerRuntime{}, err
+	}
+
+	state, err := i.Stater.State(log, handle)
+	if err != nil {
+		return gardener.ContainerRuntime{}, fmt.Errorf("read state: %s", err)
+	}
+
+	runtime := gardener.ContainerRuntime{
+		BundlePath: bundlePath,
+		InitPid:    state.Pid,
+		Stale:      state.Stale,
+		Status:     state.Status,
+	}
+
+	if i.BundleLoader != nil {
+		if bndl, err := i.BundleLoader.Load(bundlePath); err != nil {
+			log.Error("load-bundle-failed", err)
+		} else {
+			runtime.RootFSPath = bndl.RootFS()
+		}
+	}
+
+	if i.RawStater != nil {
+		if runtime.RuntimeState, err = i.RawStater.RawState(log, handle); err != nil {
+			log.Error("read-raw-state-failed", err)
+		}
+	}
+
+	if i.IODaemons != nil {
+		runtime.IODaemonPids = i.IODaemons.IODaemonPids(handle)
+	}
+
+	if state.Stale {
+		return runtime, nil
+	}
+
+	procDir := filepath.Join(i.ProcPath, strconv.Itoa(state.Pid))
+	runtime.NetnsPath = filepath.Join(procDir, "ns", "net")
+
+	if runtime.CgroupPaths, err = readCgroupPaths(filepath.Join(procDir, "cgroup")); err != nil {
+		log.Error("read-cgroup-paths-failed", err)
+	}
+
+	return runtime, nil
+}
+
+// readCgroupPaths parses a /proc/<pid>/cgroup file, whose lines are
+// 'hierarchy-id:controllers:path', in to the paths by controllers. The cgroup
+// v2 hierarchy, which has no controllers, is named "unified".
+func readCgroupPaths(path string) (map[string]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	paths := map[string]string{}
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		fields := strings.SplitN(scanner.Text(), ":", 3)
+		if len(fields) != 3 {
+			continue
+		}
+
+		controllers := fields[1]
+		if controllers == "" {
+			controllers = "unified"
+		}
+
+		paths[controllers] = fields[2]
+	}
+
+	return paths, scanner.Err()
+}
@@ -0,0 +1,136 @@
+package rundmc_test
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/cloudfoundry-incubator/goci"
+	"github.com/cloudfoundry-incubator/guardian/rundmc"
+	"github.com/cloudfoundry-incubator/guardian/rundmc/fakes"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/pivotal-golang/lager/lagertest"
+)
+
+var _ = Describe("RuntimeInspector", func() {
+	var (
+		logger    *lagertest.TestLogger
+		procPath  string
+		fakeDepot *fakes.FakeDepot
+		stater    *fakes.FakeContainerStater
+		loader    *fakes.FakeBundleLoader
+		inspector *rundmc.RuntimeInspector
+	)
+
+	BeforeEach(func() {
+		var err error
+		procPath, err = ioutil.TempDir("", "proc")
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(os.MkdirAll(filepath.Join(procPath, "42"), 0700)).To(Succeed())
+		Expect(ioutil.WriteFile(filepath.Join(procPath, "42", "cgroup"), []byte(
+			"4:memory:/garden/some-handle\n3:cpu,cpuacct:/garden/some-handle\n0::/garden/some-handle\n",
+		), 0600)).To(Succeed())
+
+		logger = lagertest.NewTestLogger("test")
+		fakeDepot = new(fakes.FakeDepot)
+		fakeDepot.LookupReturns("/depot/some-handle", nil)
+		stater = new(fakes.FakeContainerStater)
+		stater.StateReturns(rundmc.State{Pid: 42, Status: "running"}, nil)
+		loader = new(fakes.FakeBundleLoader)
+		loader.LoadReturns(goci.Bundle().WithRootFS("/images/some-handle/rootfs"), nil)
+
+		inspector = &rundmc.RuntimeInspector{Depot: fakeDepot, Stater: stater, BundleLoader: loader, ProcPath: procPath}
+	})
+
+	AfterEach(func() {
+		Expect(os.RemoveAll(procPath)).To(Succeed())
+	})
+
+	It("returns the bundle, init process, network namespace and cgroups of the container", func() {
+		runtime, err := inspector.Inspect(logger, "some-handle")
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(runtime.BundlePath).To(Equal("/depot/some-handle"))
+		Expect(runtime.InitPid).To(Equal(42))
+		Expect(runtime.Status).To(Equal("running"))
+		Expect(runtime.RootFSPath).To(Equal("/images/some-handle/rootfs"))
+		Expect(runtime.NetnsPath).To(Equal(filepath.Join(procPath, "42", "ns", "net")))
+		Expect(runtime.CgroupPaths).To(Equal(map[string]string{
+			"memory":      "/garden/some-handle",
+			"cpu,cpuacct": "/garden/some-handle",
+			"unified":     "/garden/some-handle",
+		}))
+	})
+
+	It("does not read proc when the init process is stale", func() {
+		stater.StateReturns(rundmc.State{Pid: 42, Stale: true}, nil)
+
+		runtime, err := inspector.Inspect(logger, "some-handle")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(runtime.Stale).To(BeTrue())
+		Expect(runtime.NetnsPath).To(BeEmpty())
+		Expect(runtime.CgroupPaths).To(BeNil())
+	})
+
+	It("leaves out the rootfs when the bundle cannot be loaded", func() {
+		loader.LoadReturns(nil, errors.New("no config.json"))
+
+		runtime, err := inspector.Inspect(logger, "some-handle")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(runtime.RootFSPath).To(BeEmpty())
+		Expect(loader.LoadArgsForCall(0)).To(Equal("/depot/some-handle"))
+	})
+
+	Context("with a raw stater and an iodaemon pid lister", func() {
+		var (
+			rawStater *fakes.FakeRawContainerStater
+			iodaemons *fakes.FakeIODaemonPidLister
+		)
+
+		BeforeEach(func() {
+			rawStater = new(fakes.FakeRawContainerStater)
+			rawStater.RawStateReturns([]byte(`{"id":"some-handle","init_process_pid":42}`), nil)
+			iodaemons = new(fakes.FakeIODaemonPidLister)
+			iodaemons.IODaemonPidsReturns(map[string]int{"some-process": 43})
+
+			inspector.RawStater = rawStater
+			inspector.IODaemons = iodaemons
+		})
+
+		It("includes the runtime's state in full and the pids of the container's iodaemons", func() {
+			runtime, err := inspector.Inspect(logger, "some-handle")
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(string(runtime.RuntimeState)).To(Equal(`{"id":"some-handle","init_process_pid":42}`))
+			Expect(runtime.IODaemonPids).To(Equal(map[string]int{"some-process": 43}))
+			Expect(iodaemons.IODaemonPidsArgsForCall(0)).To(Equal("some-handle"))
+		})
+
+		It("includes them even when the init process is stale", func() {
+			stater.StateReturns(rundmc.State{Pid: 42, Stale: true}, nil)
+
+			runtime, err := inspector.Inspect(logger, "some-handle")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(runtime.RuntimeState).NotTo(BeEmpty())
+			Expect(runtime.IODaemonPids).To(HaveLen(1))
+		})
+
+		It("leaves out the runtime's state when it cannot be read", func() {
+			rawStater.RawStateReturns(nil, errors.New("no state.json"))
+
+			runtime, err := inspector.Inspect(logger, "some-handle")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(runtime.RuntimeState).To(BeEmpty())
+		})
+	})
+
+	It("fails when the state cannot be read", func() {
+		stater.StateReturns(rundmc.State{}, errors.New("no state.json"))
+
+		_, err := inspector.Inspect(logger, "some-handle")
+		Expect(err).To(MatchError("read state: no state.json"))
+	})
+})
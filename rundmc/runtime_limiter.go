@@ -0,0 +1,288 @@
+package rundmc
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/cloudfoundry-incubator/garden"
+	"github.com/cloudfoundry-incubator/guardian/gardener"
+	"github.com/cloudfoundry-incubator/guardian/rundmc/runrunc"
+	"github.com/opencontainers/specs"
+	"github.com/pivotal-golang/lager"
+)
+
+//go:generate counterfeiter . ResourceUpdater
+
+// ResourceUpdater updates the cgroup limits of a running container
+type ResourceUpdater interface {
+	Update(log lager.Logger, id string, resources runrunc.Resources) error
+}
+
+// unlimitedMemory is the least memory.limit_in_bytes which cgroup v1 reports
+// for a cgroup without a memory limit, which is the largest page-aligned
+// int64
+const unlimitedMemory = 1 << 62
+
+// RuntimeLimiter changes the memory, CPU and pid limits of running
+// containers with the runtime, and records them in the containers' bundles
+// so that they are kept if the container is restored. The current limits
+// are read from the containers' cgroups, which are mounted under CgroupPath,
+// so that they are what the kernel is enforcing.
+type RuntimeLimiter struct {
+	Updater      ResourceUpdater
+	Depot        Depot
+	BundleLoader BundleLoader
+
+	CgroupPath string
+	// Unified is whether CgroupPath is cgroup v2's unified hierarchy
+	Unified bool
+}
+
+// LimitMemory sets the container's memory limit, and its swap limit to the
+// same if the container's swap is limited. A zero limit removes the limit.
+func (l *RuntimeLimiter) LimitMemory(log lager.Logger, handle string, limits garden.MemoryLimits) error {
+	log = log.Session("limit-memory", lager.Data{"handle": handle, "limit": limits.LimitInBytes})
+
+	log.Info("started")
+	defer log.Info("finished")
+
+	return l.update(log, handle, func(bndlResources *specs.Resources, resources *runrunc.Resources) {
+		limit := uint64(limits.LimitInBytes)
+		memory := specs.Memory{Limit: &limit}
+
+		update := int64(limit)
+		if limit == 0 {
+			update = -1
+		}
+
+		resources.Memory = &runrunc.MemoryResources{Limit: &update}
+		if bndlResources.Memory != nil && bndlResources.Memory.Swap != nil {
+			memory.Swap = &limit
+			resources.Memory.Swap = &update
+		}
+
+		bndlResources.Memory = &memory
+	})
+}
+
+// LimitCPU sets the container's cpu shares, unless they are 0, and its CFS
+// quota. A zero quota removes the container's CPU cap.
+func (l *RuntimeLimiter) LimitCPU(log lager.Logger, handle string, limits garden.CPULimits, quota gardener.CPUQuota) error {
+	log = log.Session("limit-cpu", lager.Data{"handle": handle, "shares": limits.LimitInShares, "quota": quota})
+
+	log.Info("started")
+	defer log.Info("finished")
+
+	return l.update(log, handle, func(bndlResources *specs.Resources, resources *runrunc.Resources) {
+		cpu := specs.CPU{}
+		if bndlResources.CPU != nil {
+			cpu = *bndlResources.CPU
+		}
+
+		resources.CPU = &runrunc.CPUResources{}
+		if limits.LimitInShares != 0 {
+			shares := limits.LimitInShares
+			cpu.Shares = &shares
+			resources.CPU.Shares = &shares
+		}
+
+		if quota.Quota == 0 {
+			removed := int64(-1)
+			cpu.Quota, cpu.Period = nil, nil
+			resources.CPU.Quota = &removed
+		} else {
+			bndlQuota, updateQuota, period := uint64(quota.Quota), quota.Quota, quota.Period
+			cpu.Quota, cpu.Period = &bndlQuota, &period
+			resources.CPU.Quota, resources.CPU.Period = &updateQuota, &period
+		}
+
+		bndlResources.CPU = &cpu
+	})
+}
+
+// LimitPids sets the maximum number of pids in the container. Zero removes
+// the limit.
+func (l *RuntimeLimiter) LimitPids(log lager.Logger, handle string, maxPids int64) error {
+	log = log.Session("limit-pids", lager.Data{"handle": handle, "max-pids": maxPids})
+
+	log.Info("started")
+	defer log.Info("finished")
+
+	return l.update(log, handle, func(bndlResources *specs.Resources, resources *runrunc.Resources) {
+		if maxPids == 0 {
+			bndlResources.Pids = nil
+			resources.Pids = &runrunc.PidsResources{Limit: -1}
+			return
+		}
+
+		limit := maxPids
+		bndlResources.Pids = &specs.Pids{Limit: &limit}
+		resources.Pids = &runrunc.PidsResources{Limit: maxPids}
+	})
+}
+
+// update applies the changes which change makes to the container's
+// resources with the runtime, then saves them in its bundle
+func (l *RuntimeLimiter) update(log lager.Logger, handle string, change func(bndlResources *specs.Resources, resources *runrunc.Resources)) error {
+	bundlePath, err := l.Depot.Lookup(log, handle)
+	if err != nil {
+		return err
+	}
+
+	bndl, err := l.BundleLoader.Load(bundlePath)
+	if err != nil {
+		log.Error("load-bundle-failed", err)
+		return fmt.Errorf("load bundle: %s", err)
+	}
+
+	bndlResources := specs.Resources{}
+	if bndl.Resources() != nil {
+		bndlResources = *bndl.Resources()
+	}
+
+	resources := runrunc.Resources{}
+	change(&bndlResources, &resources)
+
+	if err := l.Updater.Update(log, handle, resources); err != nil {
+		return err
+	}
+
+	if err := l.Depot.Update(log, handle, bndl.WithResources(&bndlResources)); err != nil {
+		log.Error("save-bundle-failed", err)
+		return fmt.Errorf("save bundle: %s", err)
+	}
+
+	return nil
+}
+
+func (l *RuntimeLimiter) CurrentCPULimits(log lager.Logger, handle string) (garden.CPULimits, error) {
+	limits, err := l.CurrentLimits(log, handle)
+	return limits.CPU, err
+}
+
+// CurrentLimits reads the container's limits from its cgroups. Limits whose
+// controller is not enabled are reported as no limit.
+func (l *RuntimeLimiter) CurrentLimits(log lager.Logger, handle string) (gardener.Limits, error) {
+	if l.Unified {
+		return l.currentUnified(handle)
+	}
+
+	limits := gardener.Limits{}
+
+	memory, err := l.readLimit(handle, "memory", "memory.limit_in_bytes")
+	if err != nil {
+		return gardener.Limits{}, err
+	}
+
+	if memory < unlimitedMemory {
+		limits.Memory.LimitInBytes = uint64(memory)
+	}
+
+	shares, err := l.readLimit(handle, "cpu", "cpu.shares")
+	if err != nil {
+		return gardener.Limits{}, err
+	}
+	limits.CPU.LimitInShares = uint64(shares)
+
+	quota, err := l.readLimit(handle, "cpu", "cpu.cfs_quota_us")
+	if err != nil {
+		return gardener.Limits{}, err
+	}
+
+	if quota > 0 {
+		period, err := l.readLimit(handle, "cpu", "cpu.cfs_period_us")
+		if err != nil {
+			return gardener.Limits{}, err
+		}
+
+		limits.CPUQuota = gardener.CPUQuota{Quota: quota, Period: uint64(period)}
+	}
+
+	if limits.MaxPids, err = l.readLimit(handle, "pids", "pids.max"); err != nil {
+		return gardener.Limits{}, err
+	}
+
+	return limits, nil
+}
+
+func (l *RuntimeLimiter) currentUnified(handle string) (gardener.Limits, error) {
+	limits := gardener.Limits{}
+
+	memory, err := l.readLimit(handle, "", "memory.max")
+	if err != nil {
+		return gardener.Limits{}, err
+	}
+	limits.Memory.LimitInBytes = uint64(memory)
+
+	weight, err := l.readLimit(handle, "", "cpu.weight")
+	if err != nil {
+		return gardener.Limits{}, err
+	}
+
+	if weight != 0 {
+		limits.CPU.LimitInShares = WeightToShares(uint64(weight))
+	}
+
+	data, err := readCgroupFile(filepath.Join(l.CgroupPath, handle, "cpu.max"))
+	if err != nil {
+		return gardener.Limits{}, fmt.Errorf("read cpu.max: %s", err)
+	}
+
+	if fields := strings.Fields(data); len(fields) == 2 && fields[0] != "max" {
+		quota, err := strconv.ParseInt(fields[0], 10, 64)
+		if err != nil {
+			return gardener.Limits{}, fmt.Errorf("read cpu.max: %s", err)
+		}
+
+		period, err := strconv.ParseUint(fields[1], 10, 64)
+		if err != nil {
+			return gardener.Limits{}, fmt.Errorf("read cpu.max: %s", err)
+		}
+
+		limits.CPUQuota = gardener.CPUQuota{Quota: quota, Period: period}
+	}
+
+	if limits.MaxPids, err = l.readLimit(handle, "", "pids.max"); err != nil {
+		return gardener.Limits{}, err
+	}
+
+	return limits, nil
+}
+
+// readLimit reads a cgroup file holding a single limit, which is 0 if it is
+// "max" or -1 (i.e. unlimited), or the file does not exist
+func (l *RuntimeLimiter) readLimit(handle, subsystem, file string) (int64, error) {
+	data, err := readCgroupFile(filepath.Join(l.CgroupPath, subsystem, handle, file))
+	if err != nil {
+		return 0, fmt.Errorf("read %s: %s", file, err)
+	}
+
+	if data == "" || data == "max" || data == "-1" {
+		return 0, nil
+	}
+
+	limit, err := strconv.ParseInt(data, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("read %s: %s", file, err)
+	}
+
+	return limit, nil
+}
+
+// readCgroupFile reads a cgroup file, or "" if it does not exist
+func readCgroupFile(path string) (string, error) {
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return "", nil
+	}
+
+	if err != nil {
+		return "", err
+	}
+
+	return strings.TrimSpace(string(data)), nil
+}
@@ -0,0 +1,230 @@
+package rundmc_test
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/cloudfoundry-incubator/garden"
+	"github.com/cloudfoundry-incubator/goci"
+	"github.com/cloudfoundry-incubator/guardian/gardener"
+	"github.com/cloudfoundry-incubator/guardian/rundmc"
+	"github.com/cloudfoundry-incubator/guardian/rundmc/fakes"
+	"github.com/cloudfoundry-incubator/guardian/rundmc/runrunc"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/opencontainers/specs"
+	"github.com/pivotal-golang/lager"
+	"github.com/pivotal-golang/lager/lagertest"
+)
+
+var _ = Describe("RuntimeLimiter", func() {
+	var (
+		logger     lager.Logger
+		updater    *fakes.FakeResourceUpdater
+		fakeDepot  *fakes.FakeDepot
+		fakeLoader *fakes.FakeBundleLoader
+		tmp        string
+
+		limiter *rundmc.RuntimeLimiter
+	)
+
+	BeforeEach(func() {
+		logger = lagertest.NewTestLogger("test")
+		updater = new(fakes.FakeResourceUpdater)
+		fakeDepot = new(fakes.FakeDepot)
+		fakeDepot.LookupReturns("/path/to/some-handle", nil)
+
+		limit, shares := uint64(1024), uint64(512)
+		fakeLoader = new(fakes.FakeBundleLoader)
+		fakeLoader.LoadReturns(goci.Bundle().WithResources(&specs.Resources{
+			Memory: &specs.Memory{Limit: &limit, Swap: &limit},
+			CPU:    &specs.CPU{Shares: &shares},
+		}), nil)
+
+		var err error
+		tmp, err = ioutil.TempDir("", "runtimelimitertest")
+		Expect(err).NotTo(HaveOccurred())
+
+		limiter = &rundmc.RuntimeLimiter{
+			Updater:      updater,
+			Depot:        fakeDepot,
+			BundleLoader: fakeLoader,
+			CgroupPath:   tmp,
+		}
+	})
+
+	AfterEach(func() {
+		Expect(os.RemoveAll(tmp)).To(Succeed())
+	})
+
+	savedResources := func() *specs.Resources {
+		Expect(fakeDepot.UpdateCallCount()).To(Equal(1))
+		_, handle, saved := fakeDepot.UpdateArgsForCall(0)
+		Expect(handle).To(Equal("some-handle"))
+		return saved.(*goci.Bndl).Resources()
+	}
+
+	writeCgroupFile := func(path, value string) {
+		Expect(os.MkdirAll(filepath.Join(tmp, filepath.Dir(path)), 0755)).To(Succeed())
+		Expect(ioutil.WriteFile(filepath.Join(tmp, path), []byte(value+"\n"), 0644)).To(Succeed())
+	}
+
+	Describe("LimitMemory", func() {
+		It("updates the memory and swap limits of the running container, and saves them in its bundle", func() {
+			Expect(limiter.LimitMemory(logger, "some-handle", garden.MemoryLimits{LimitInBytes: 2048})).To(Succeed())
+
+			Expect(fakeLoader.LoadArgsForCall(0)).To(Equal("/path/to/some-handle"))
+
+			_, id, resources := updater.UpdateArgsForCall(0)
+			Expect(id).To(Equal("some-handle"))
+			Expect(*resources.Memory.Limit).To(Equal(int64(2048)))
+			Expect(*resources.Memory.Swap).To(Equal(int64(2048)))
+			Expect(resources.CPU).To(BeNil())
+
+			saved := savedResources()
+			Expect(*saved.Memory.Limit).To(Equal(uint64(2048)))
+			Expect(*saved.Memory.Swap).To(Equal(uint64(2048)))
+			Expect(*saved.CPU.Shares).To(Equal(uint64(512)))
+		})
+
+		It("removes the memory limit when it is zero", func() {
+			Expect(limiter.LimitMemory(logger, "some-handle", garden.MemoryLimits{})).To(Succeed())
+
+			_, _, resources := updater.UpdateArgsForCall(0)
+			Expect(*resources.Memory.Limit).To(Equal(int64(-1)))
+		})
+
+		Context("when updating the container fails", func() {
+			It("returns the error without saving the bundle", func() {
+				updater.UpdateReturns(errors.New("runc update: exit status 1"))
+
+				Expect(limiter.LimitMemory(logger, "some-handle", garden.MemoryLimits{LimitInBytes: 2048})).To(MatchError("runc update: exit status 1"))
+				Expect(fakeDepot.UpdateCallCount()).To(Equal(0))
+			})
+		})
+
+		Context("when the container does not exist", func() {
+			It("returns the error without updating it", func() {
+				fakeDepot.LookupReturns("", errors.New("no such container"))
+
+				Expect(limiter.LimitMemory(logger, "some-handle", garden.MemoryLimits{})).To(MatchError("no such container"))
+				Expect(updater.UpdateCallCount()).To(Equal(0))
+			})
+		})
+	})
+
+	Describe("LimitCPU", func() {
+		It("updates the shares and quota of the running container, and saves them in its bundle", func() {
+			Expect(limiter.LimitCPU(logger, "some-handle", garden.CPULimits{LimitInShares: 256}, gardener.CPUQuota{Quota: 50000, Period: 100000})).To(Succeed())
+
+			_, _, resources := updater.UpdateArgsForCall(0)
+			Expect(*resources.CPU.Shares).To(Equal(uint64(256)))
+			Expect(*resources.CPU.Quota).To(Equal(int64(50000)))
+			Expect(*resources.CPU.Period).To(Equal(uint64(100000)))
+
+			saved := savedResources()
+			Expect(*saved.CPU.Shares).To(Equal(uint64(256)))
+			Expect(*saved.CPU.Quota).To(Equal(uint64(50000)))
+			Expect(*saved.CPU.Period).To(Equal(uint64(100000)))
+		})
+
+		It("leaves the shares as they are when they are zero, and removes the quota when it is zero", func() {
+			Expect(limiter.LimitCPU(logger, "some-handle", garden.CPULimits{}, gardener.CPUQuota{})).To(Succeed())
+
+			_, _, resources := updater.UpdateArgsForCall(0)
+			Expect(resources.CPU.Shares).To(BeNil())
+			Expect(*resources.CPU.Quota).To(Equal(int64(-1)))
+
+			saved := savedResources()
+			Expect(*saved.CPU.Shares).To(Equal(uint64(512)))
+			Expect(saved.CPU.Quota).To(BeNil())
+		})
+	})
+
+	Describe("LimitPids", func() {
+		It("updates the pids limit of the running container, and saves it in its bundle", func() {
+			Expect(limiter.LimitPids(logger, "some-handle", 100)).To(Succeed())
+
+			_, _, resources := updater.UpdateArgsForCall(0)
+			Expect(resources.Pids).To(Equal(&runrunc.PidsResources{Limit: 100}))
+			Expect(*savedResources().Pids.Limit).To(Equal(int64(100)))
+		})
+
+		It("removes the limit when it is zero", func() {
+			Expect(limiter.LimitPids(logger, "some-handle", 0)).To(Succeed())
+
+			_, _, resources := updater.UpdateArgsForCall(0)
+			Expect(resources.Pids).To(Equal(&runrunc.PidsResources{Limit: -1}))
+			Expect(savedResources().Pids).To(BeNil())
+		})
+	})
+
+	Describe("CurrentLimits", func() {
+		It("reads the limits from the container's cgroups", func() {
+			writeCgroupFile("memory/some-handle/memory.limit_in_bytes", "1048576")
+			writeCgroupFile("cpu/some-handle/cpu.shares", "512")
+			writeCgroupFile("cpu/some-handle/cpu.cfs_quota_us", "50000")
+			writeCgroupFile("cpu/some-handle/cpu.cfs_period_us", "100000")
+			writeCgroupFile("pids/some-handle/pids.max", "100")
+
+			Expect(limiter.CurrentLimits(logger, "some-handle")).To(Equal(gardener.Limits{
+				Memory:   garden.MemoryLimits{LimitInBytes: 1048576},
+				CPU:      garden.CPULimits{LimitInShares: 512},
+				CPUQuota: gardener.CPUQuota{Quota: 50000, Period: 100000},
+				MaxPids:  100,
+			}))
+		})
+
+		It("reports unlimited and missing limits as zero", func() {
+			writeCgroupFile("memory/some-handle/memory.limit_in_bytes", "9223372036854771712")
+			writeCgroupFile("cpu/some-handle/cpu.cfs_quota_us", "-1")
+			writeCgroupFile("pids/some-handle/pids.max", "max")
+
+			Expect(limiter.CurrentLimits(logger, "some-handle")).To(Equal(gardener.Limits{}))
+		})
+
+		It("returns an error when a limit cannot be parsed", func() {
+			writeCgroupFile("cpu/some-handle/cpu.shares", "lots")
+
+			_, err := limiter.CurrentLimits(logger, "some-handle")
+			Expect(err).To(MatchError(ContainSubstring("read cpu.shares")))
+		})
+
+		Context("when the cgroups are unified", func() {
+			BeforeEach(func() {
+				limiter.Unified = true
+			})
+
+			It("reads the limits from the container's cgroup", func() {
+				writeCgroupFile("some-handle/memory.max", "1048576")
+				writeCgroupFile("some-handle/cpu.weight", "1")
+				writeCgroupFile("some-handle/cpu.max", "50000 100000")
+				writeCgroupFile("some-handle/pids.max", "max")
+
+				Expect(limiter.CurrentLimits(logger, "some-handle")).To(Equal(gardener.Limits{
+					Memory:   garden.MemoryLimits{LimitInBytes: 1048576},
+					CPU:      garden.CPULimits{LimitInShares: 2},
+					CPUQuota: gardener.CPUQuota{Quota: 50000, Period: 100000},
+				}))
+			})
+
+			It("reports no quota when cpu.max is max", func() {
+				writeCgroupFile("some-handle/cpu.max", "max 100000")
+
+				limits, err := limiter.CurrentLimits(logger, "some-handle")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(limits.CPUQuota).To(Equal(gardener.CPUQuota{}))
+			})
+		})
+	})
+
+	Describe("CurrentCPULimits", func() {
+		It("reads the shares from the container's cpu cgroup", func() {
+			writeCgroupFile("cpu/some-handle/cpu.shares", "512")
+
+			Expect(limiter.CurrentCPULimits(logger, "some-handle")).To(Equal(garden.CPULimits{LimitInShares: 512}))
+		})
+	})
+})
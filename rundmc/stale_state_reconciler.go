@@ -0,0 +1,100 @@
+package rundmc
+
+import (
+	"time"
+
+	"github.com/pivotal-golang/clock"
+	"github.com/pivotal-golang/lager"
+)
+
+//go:generate counterfeiter . HandleLister
+type HandleLister interface {
+	Handles() ([]string, error)
+}
+
+//go:generate counterfeiter . ContainerDestroyer
+type ContainerDestroyer interface {
+	Destroy(handle string) error
+}
+
+//go:generate counterfeiter . ContainerRestarter
+type ContainerRestarter interface {
+	Restart(log lager.Logger, handle string) (bool, error)
+}
+
+// StaleStateReconciler periodically looks for containers whose runc state
+// says they are running but whose init process has died, and destroys them.
+// Otherwise they could only be destroyed by hand, and would block their
+// handles from being reused until they were. Containers whose restart policy
+// asks for it are re-started instead, if a Restarter is given.
+type StaleStateReconciler struct {
+	Lister HandleLister
+	Stater ContainerStater
+
+	// Destroyer destroys the container and all of its resources
+	Destroyer ContainerDestroyer
+
+	// Restarter re-starts containers whose restart policy asks for it
+	// (optional)
+	Restarter ContainerRestarter
+
+	Clock    clock.Clock
+	Interval time.Duration
+	Logger   lager.Logger
+}
+
+// Start begins reconciling in the background every interval.
+func (r *StaleStateReconciler) Start() error {
+	go func() {
+		ticker := r.Clock.NewTicker(r.Interval)
+		defer ticker.Stop()
+
+		for range ticker.C() {
+			r.Reconcile()
+		}
+	}()
+
+	return nil
+}
+
+// Reconcile destroys, or re-starts, every container whose state is stale
+// once.
+func (r *StaleStateReconciler) Reconcile() {
+	log := r.Logger.Session("reconcile-stale-state")
+
+	handles, err := r.Lister.Handles()
+	if err != nil {
+		log.Error("list-handles-failed", err)
+		return
+	}
+
+	for _, handle := range handles {
+		state, err := r.Stater.State(log, handle)
+		if err != nil || !state.Stale {
+			continue
+		}
+
+		if r.restart(log, handle) {
+			continue
+		}
+
+		log.Info("stale-state-destroying", lager.Data{"handle": handle, "pid": state.Pid})
+		if err := r.Destroyer.Destroy(handle); err != nil {
+			log.Error("destroy-failed", err, lager.Data{"handle": handle})
+		}
+	}
+}
+
+func (r *StaleStateReconciler) restart(log lager.Logger, handle string) bool {
+	if r.Restarter == nil {
+		return false
+	}
+
+	restarted, err := r.Restarter.Restart(log, handle)
+	if err != nil {
+		log.Error("restart-failed", err, lager.Data{"handle": handle})
+		return false
+	}
+
+	return restarted
+}
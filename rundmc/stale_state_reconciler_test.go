@@ -0,0 +1,133 @@
+package rundmc_test
+
+import (
+	"errors"
+	"time"
+
+	"github.com/cloudfoundry-incubator/guardian/rundmc"
+	"github.com/cloudfoundry-incubator/guardian/rundmc/fakes"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+	"github.com/pivotal-golang/clock/fakeclock"
+	"github.com/pivotal-golang/lager"
+	"github.com/pivotal-golang/lager/lagertest"
+)
+
+var _ = Describe("StaleStateReconciler", func() {
+	var (
+		logger        *lagertest.TestLogger
+		fakeLister    *fakes.FakeHandleLister
+		fakeStater    *fakes.FakeContainerStater
+		fakeDestroyer *fakes.FakeContainerDestroyer
+		fakeClock     *fakeclock.FakeClock
+
+		reconciler *rundmc.StaleStateReconciler
+	)
+
+	BeforeEach(func() {
+		logger = lagertest.NewTestLogger("test")
+		fakeLister = new(fakes.FakeHandleLister)
+		fakeStater = new(fakes.FakeContainerStater)
+		fakeDestroyer = new(fakes.FakeContainerDestroyer)
+		fakeClock = fakeclock.NewFakeClock(time.Now())
+
+		fakeLister.HandlesReturns([]string{"running", "stale", "gone"}, nil)
+		fakeStater.StateStub = func(_ lager.Logger, handle string) (rundmc.State, error) {
+			switch handle {
+			case "running":
+				return rundmc.State{Pid: 1}, nil
+			case "stale":
+				return rundmc.State{Pid: 2, Stale: true}, nil
+			default:
+				return rundmc.State{}, errors.New("no state")
+			}
+		}
+
+		reconciler = &rundmc.StaleStateReconciler{
+			Lister:    fakeLister,
+			Stater:    fakeStater,
+			Destroyer: fakeDestroyer,
+			Clock:     fakeClock,
+			Interval:  time.Minute,
+			Logger:    logger,
+		}
+	})
+
+	It("destroys only the containers whose state is stale", func() {
+		reconciler.Reconcile()
+
+		Expect(fakeStater.StateCallCount()).To(Equal(3))
+		Expect(fakeDestroyer.DestroyCallCount()).To(Equal(1))
+		Expect(fakeDestroyer.DestroyArgsForCall(0)).To(Equal("stale"))
+		Expect(logger).To(gbytes.Say("stale-state-destroying"))
+	})
+
+	It("carries on when a container cannot be destroyed", func() {
+		fakeLister.HandlesReturns([]string{"stale", "stale"}, nil)
+		fakeDestroyer.DestroyReturns(errors.New("busy"))
+
+		reconciler.Reconcile()
+
+		Expect(fakeDestroyer.DestroyCallCount()).To(Equal(2))
+		Expect(logger).To(gbytes.Say("destroy-failed"))
+	})
+
+	Context("when a restarter is given", func() {
+		var fakeRestarter *fakes.FakeContainerRestarter
+
+		BeforeEach(func() {
+			fakeRestarter = new(fakes.FakeContainerRestarter)
+			reconciler.Restarter = fakeRestarter
+		})
+
+		It("does not destroy stale containers which are re-started", func() {
+			fakeRestarter.RestartReturns(true, nil)
+
+			reconciler.Reconcile()
+
+			Expect(fakeRestarter.RestartCallCount()).To(Equal(1))
+			_, handle := fakeRestarter.RestartArgsForCall(0)
+			Expect(handle).To(Equal("stale"))
+			Expect(fakeDestroyer.DestroyCallCount()).To(Equal(0))
+		})
+
+		It("destroys stale containers which are not re-started", func() {
+			fakeRestarter.RestartReturns(false, nil)
+
+			reconciler.Reconcile()
+
+			Expect(fakeDestroyer.DestroyCallCount()).To(Equal(1))
+		})
+
+		It("destroys stale containers which fail to re-start", func() {
+			fakeRestarter.RestartReturns(false, errors.New("bundle gone"))
+
+			reconciler.Reconcile()
+
+			Expect(fakeDestroyer.DestroyCallCount()).To(Equal(1))
+			Expect(logger).To(gbytes.Say("restart-failed"))
+		})
+	})
+
+	Context("when the handles cannot be listed", func() {
+		It("logs the error", func() {
+			fakeLister.HandlesReturns(nil, errors.New("depot gone"))
+
+			reconciler.Reconcile()
+
+			Expect(fakeStater.StateCallCount()).To(Equal(0))
+			Expect(logger).To(gbytes.Say("list-handles-failed"))
+		})
+	})
+
+	Describe("Start", func() {
+		It("reconciles every interval", func() {
+			Expect(reconciler.Start()).To(Succeed())
+
+			Eventually(fakeClock.WatcherCount).Should(Equal(1))
+			fakeClock.Increment(time.Minute)
+			Eventually(fakeDestroyer.DestroyCallCount).Should(Equal(1))
+		})
+	})
+})
@@ -4,11 +4,14 @@ import (
 	"bufio"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"os"
 	"os/exec"
 	"path"
+	"strconv"
 
 	"github.com/cloudfoundry-incubator/guardian/logging"
+	"github.com/cloudfoundry-incubator/guardian/sysinfo"
 	"github.com/cloudfoundry/gunk/command_runner"
 	"github.com/pivotal-golang/lager"
 )
@@ -102,3 +105,94 @@ func (s *CgroupStarter) mountCgroup(log lager.Logger, cgroupPath, cgroupType str
 func (s *CgroupStarter) isMountPoint(path string) bool {
 	return s.CommandRunner.Run(exec.Command("mountpoint", "-q", path)) == nil
 }
+
+// UnifiedCgroupControllers are the cgroup v2 controllers which are enabled
+// for containers, if the kernel has them
+var UnifiedCgroupControllers = []string{"cpu", "cpuset", "io", "memory", "pids"}
+
+// CgroupV2Starter mounts cgroup v2's unified hierarchy at CgroupPath, for
+// hosts which have no cgroup v1 hierarchies, and enables the controllers
+// which containers are limited by in the root cgroup's subtree.
+type CgroupV2Starter struct {
+	CgroupPath    string
+	CommandRunner command_runner.CommandRunner
+	Logger        lager.Logger
+
+	// Delegated is set when CgroupPath is the cgroup delegated to a rootless
+	// guardian, which is already mounted. As controllers cannot be enabled
+	// in the subtree of a cgroup with processes in it, guardian first moves
+	// itself in to a child cgroup of its own.
+	Delegated bool
+}
+
+func (s *CgroupV2Starter) Start() error {
+	log := s.Logger.Session("setup-unified-cgroup", lager.Data{"path": s.CgroupPath})
+
+	log.Info("started")
+	defer log.Info("finished")
+
+	if err := os.MkdirAll(s.CgroupPath, 0755); err != nil {
+		log.Error("mkdir-failed", err)
+		return err
+	}
+
+	if s.Delegated {
+		if err := s.leaveDelegatedCgroup(); err != nil {
+			log.Error("leave-delegated-cgroup-failed", err)
+			return err
+		}
+
+		return s.enableControllers(log)
+	}
+
+	if !s.isMountPoint(s.CgroupPath) {
+		cmd := exec.Command("mount", "-n", "-t", "cgroup2", "cgroup2", s.CgroupPath)
+		cmd.Stderr = logging.Writer(log.Session("mount-cgroup-cmd"))
+		if err := s.CommandRunner.Run(cmd); err != nil {
+			log.Error("mount-cgroup-failed", err)
+			return err
+		}
+	}
+
+	return s.enableControllers(log)
+}
+
+func (s *CgroupV2Starter) enableControllers(log lager.Logger) error {
+	available, err := sysinfo.UnifiedCgroupControllers(s.CgroupPath)
+	if err != nil {
+		log.Error("read-controllers-failed", err)
+		return fmt.Errorf("read cgroup controllers: %s", err)
+	}
+
+	subtreeControl := path.Join(s.CgroupPath, "cgroup.subtree_control")
+	for _, controller := range UnifiedCgroupControllers {
+		if !available[controller] {
+			log.Info("controller-unavailable", lager.Data{"controller": controller})
+			continue
+		}
+
+		// controllers are enabled one at a time so that one which cannot be
+		// enabled (e.g. because of processes in the root cgroup) does not
+		// prevent the others from being; containers simply cannot be limited
+		// by it
+		if err := ioutil.WriteFile(subtreeControl, []byte("+"+controller), 0644); err != nil {
+			log.Error("enable-controller-failed", err, lager.Data{"controller": controller})
+		}
+	}
+
+	return nil
+}
+
+// leaveDelegatedCgroup moves guardian in to the guardian child cgroup
+func (s *CgroupV2Starter) leaveDelegatedCgroup() error {
+	guardianCgroup := path.Join(s.CgroupPath, "guardian")
+	if err := os.MkdirAll(guardianCgroup, 0755); err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(path.Join(guardianCgroup, "cgroup.procs"), []byte(strconv.Itoa(os.Getpid())), 0644)
+}
+
+func (s *CgroupV2Starter) isMountPoint(path string) bool {
+	return s.CommandRunner.Run(exec.Command("mountpoint", "-q", path)) == nil
+}
@@ -7,6 +7,7 @@ import (
 	"os"
 	"os/exec"
 	"path"
+	"strconv"
 
 	"github.com/cloudfoundry-incubator/guardian/rundmc"
 	"github.com/cloudfoundry/gunk/command_runner/fake_command_runner"
@@ -135,3 +136,125 @@ func (f *FakeReadCloser) Close() error {
 	f.closed = true
 	return nil
 }
+
+var _ = Describe("CgroupV2Starter", func() {
+	var (
+		runner  *fake_command_runner.FakeCommandRunner
+		starter *rundmc.CgroupV2Starter
+
+		tmpDir     string
+		cgroupPath string
+	)
+
+	BeforeEach(func() {
+		var err error
+		tmpDir, err = ioutil.TempDir("", "gdncgroup2")
+		Expect(err).NotTo(HaveOccurred())
+
+		cgroupPath = path.Join(tmpDir, "cgroup")
+		Expect(os.MkdirAll(cgroupPath, 0755)).To(Succeed())
+		Expect(ioutil.WriteFile(path.Join(cgroupPath, "cgroup.controllers"), []byte("cpuset cpu io memory hugetlb pids\n"), 0644)).To(Succeed())
+
+		runner = fake_command_runner.New()
+		starter = &rundmc.CgroupV2Starter{
+			CgroupPath:    cgroupPath,
+			CommandRunner: runner,
+			Logger:        lagertest.NewTestLogger("test"),
+		}
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(tmpDir)
+	})
+
+	Context("when the cgroup path is not a mountpoint", func() {
+		BeforeEach(func() {
+			runner.WhenRunning(fake_command_runner.CommandSpec{
+				Path: "mountpoint",
+				Args: []string{"-q", cgroupPath},
+			}, func(cmd *exec.Cmd) error {
+				return errors.New("not a mountpoint")
+			})
+		})
+
+		It("mounts the unified hierarchy", func() {
+			Expect(starter.Start()).To(Succeed())
+			Expect(runner).To(HaveExecutedSerially(fake_command_runner.CommandSpec{
+				Path: "mount",
+				Args: []string{"-n", "-t", "cgroup2", "cgroup2", cgroupPath},
+			}))
+		})
+
+		Context("and mounting fails", func() {
+			It("returns the error", func() {
+				runner.WhenRunning(fake_command_runner.CommandSpec{
+					Path: "mount",
+				}, func(cmd *exec.Cmd) error {
+					return errors.New("no cgroup2")
+				})
+
+				Expect(starter.Start()).To(MatchError("no cgroup2"))
+			})
+		})
+	})
+
+	It("does not mount the hierarchy again", func() {
+		Expect(starter.Start()).To(Succeed())
+		Expect(runner).NotTo(HaveExecutedSerially(fake_command_runner.CommandSpec{
+			Path: "mount",
+		}))
+	})
+
+	It("enables the available controllers which containers are limited by", func() {
+		Expect(starter.Start()).To(Succeed())
+
+		// the file is rewritten for each controller, so only the last one
+		// written remains in a plain directory
+		subtreeControl, err := ioutil.ReadFile(path.Join(cgroupPath, "cgroup.subtree_control"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(subtreeControl)).To(Equal("+pids"))
+	})
+
+	It("does not enable controllers which are unavailable", func() {
+		Expect(ioutil.WriteFile(path.Join(cgroupPath, "cgroup.controllers"), []byte("cpu memory\n"), 0644)).To(Succeed())
+		Expect(starter.Start()).To(Succeed())
+
+		subtreeControl, err := ioutil.ReadFile(path.Join(cgroupPath, "cgroup.subtree_control"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(subtreeControl)).To(Equal("+memory"))
+	})
+
+	Context("when the controllers cannot be read", func() {
+		It("returns an error", func() {
+			Expect(os.Remove(path.Join(cgroupPath, "cgroup.controllers"))).To(Succeed())
+			Expect(starter.Start()).To(MatchError(ContainSubstring("read cgroup controllers")))
+		})
+	})
+
+	Context("when the cgroup is delegated to a rootless guardian", func() {
+		BeforeEach(func() {
+			starter.Delegated = true
+		})
+
+		It("does not mount anything", func() {
+			Expect(starter.Start()).To(Succeed())
+			Expect(runner.ExecutedCommands()).To(BeEmpty())
+		})
+
+		It("moves guardian in to a child cgroup", func() {
+			Expect(starter.Start()).To(Succeed())
+
+			procs, err := ioutil.ReadFile(path.Join(cgroupPath, "guardian", "cgroup.procs"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(procs)).To(Equal(strconv.Itoa(os.Getpid())))
+		})
+
+		It("enables the available controllers", func() {
+			Expect(starter.Start()).To(Succeed())
+
+			subtreeControl, err := ioutil.ReadFile(path.Join(cgroupPath, "cgroup.subtree_control"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(subtreeControl)).To(Equal("+pids"))
+		})
+	})
+})
@@ -1,19 +1,62 @@
 package rundmc
 
 import (
+	"bytes"
 	"encoding/json"
+	"fmt"
+	"io/ioutil"
 	"os"
+	"os/exec"
 	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
 
+	"github.com/cloudfoundry-incubator/guardian/gardener"
+	"github.com/cloudfoundry/gunk/command_runner"
 	"github.com/pivotal-golang/lager"
 )
 
 type State struct {
-	Pid int `json:"init_process_pid"`
+	Pid       int              `json:"init_process_pid"`
+	StartTime ProcessStartTime `json:"init_process_start"`
+
+	// Stale is true if the container's init process has died (or its pid
+	// has been reused) even though runc's state says it is running
+	Stale bool `json:"-"`
+
+	// Status is the container's OCI status, e.g. "running" or "stopped", if
+	// the checker knows it
+	Status string `json:"-"`
+}
+
+// ProcessStartTime is the time a process started, in clock ticks since boot.
+// Versions of runc have recorded it as both a string and a number.
+type ProcessStartTime uint64
+
+func (t *ProcessStartTime) UnmarshalJSON(data []byte) error {
+	s := strings.Trim(string(data), `"`)
+	if s == "" || s == "null" {
+		*t = 0
+		return nil
+	}
+
+	startTime, err := strconv.ParseUint(s, 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid process start time: %s", s)
+	}
+
+	*t = ProcessStartTime(startTime)
+	return nil
 }
 
 type StateChecker struct {
 	StateFileDir string
+
+	// ProcPath is where proc is mounted, usually /proc. If it is set, the
+	// state is cross-checked against the init process in proc to see if it
+	// is stale.
+	ProcPath string
 }
 
 func (s StateChecker) State(log lager.Logger, id string) (State, error) {
@@ -22,9 +65,45 @@ func (s StateChecker) State(log lager.Logger, id string) (State, error) {
 		return State{}, err
 	}
 
+	if s.ProcPath != "" {
+		state.Stale = !s.running(state)
+		state.Status = "running"
+		if state.Stale {
+			state.Status = "stopped"
+		}
+	}
+
 	return state, nil
 }
 
+// RawState returns runc's state file of the container, in full
+func (s StateChecker) RawState(log lager.Logger, id string) (json.RawMessage, error) {
+	return ioutil.ReadFile(path.Join(s.StateFileDir, id, "state.json"))
+}
+
+// running returns true if the state's init process is alive and is the same
+// process runc started (i.e. its pid has not been reused)
+func (s StateChecker) running(state State) bool {
+	stat, err := ioutil.ReadFile(filepath.Join(s.ProcPath, strconv.Itoa(state.Pid), "stat"))
+	if err != nil {
+		return false
+	}
+
+	// the fields following the command, which is in parentheses and may
+	// contain spaces, start with the state (field 3) and include the start
+	// time (field 22)
+	fields := strings.Fields(string(stat[strings.LastIndex(string(stat), ")")+1:]))
+	if len(fields) < 20 || fields[0] == "Z" || fields[0] == "X" {
+		return false
+	}
+
+	if state.StartTime == 0 {
+		return true
+	}
+
+	return fields[19] == strconv.FormatUint(uint64(state.StartTime), 10)
+}
+
 func readFromStateFile(log lager.Logger, path string) (State, error) {
 	log = log.Session("read-state-file", lager.Data{"path": path})
 	log.Info("start")
@@ -46,3 +125,42 @@ func readFromStateFile(log lager.Logger, path string) (State, error) {
 
 	return state, nil
 }
+
+// RuntimeStateChecker gets a container's state from the OCI runtime's
+// `state` command, for runtimes such as winc which do not keep runc's state
+// files. A stopped container's state is stale.
+type RuntimeStateChecker struct {
+	Runtime       string
+	CommandRunner command_runner.CommandRunner
+}
+
+type runtimeState struct {
+	Pid    int    `json:"pid"`
+	Status string `json:"status"`
+}
+
+func (s RuntimeStateChecker) State(log lager.Logger, id string) (State, error) {
+	log = log.Session("runtime-state", lager.Data{"id": id})
+
+	stdout, stderr := new(bytes.Buffer), new(bytes.Buffer)
+	cmd := exec.Command(s.Runtime, "state", id)
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+
+	if err := s.CommandRunner.Run(cmd); err != nil {
+		log.Error("state-failed", err, lager.Data{"stderr": stderr.String()})
+		if gardener.FailureClass(err, "") == gardener.FailureRuncTimeout {
+			return State{}, err
+		}
+
+		return State{}, fmt.Errorf("runtime state: %s: %s", err, strings.TrimSpace(stderr.String()))
+	}
+
+	var state runtimeState
+	if err := json.Unmarshal(stdout.Bytes(), &state); err != nil {
+		log.Error("decode-failed", err)
+		return State{}, fmt.Errorf("runtime state: %s", err)
+	}
+
+	return State{Pid: state.Pid, Stale: state.Status == "stopped", Status: state.Status}, nil
+}
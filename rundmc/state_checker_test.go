@@ -1,11 +1,14 @@
 package rundmc_test
 
 import (
+	"errors"
 	"io/ioutil"
 	"os"
+	"os/exec"
 	"path"
 
 	"github.com/cloudfoundry-incubator/guardian/rundmc"
+	"github.com/cloudfoundry/gunk/command_runner/fake_command_runner"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 	"github.com/pivotal-golang/lager"
@@ -46,6 +49,79 @@ var _ = Describe("StateChecker", func() {
 			Expect(state.Pid).To(Equal(42))
 		})
 
+		It("reads the init process's start time, whether it is a string or a number", func() {
+			Expect(os.MkdirAll(path.Join(tmp, "some-id"), 0700)).To(Succeed())
+
+			Expect(ioutil.WriteFile(path.Join(tmp, "some-id", "state.json"), []byte(`{"init_process_pid":42,"init_process_start":"1234"}`), 0700)).To(Succeed())
+			state, err := checker.State(logger, "some-id")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(state.StartTime).To(BeEquivalentTo(1234))
+
+			Expect(ioutil.WriteFile(path.Join(tmp, "some-id", "state.json"), []byte(`{"init_process_pid":42,"init_process_start":5678}`), 0700)).To(Succeed())
+			state, err = checker.State(logger, "some-id")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(state.StartTime).To(BeEquivalentTo(5678))
+		})
+
+		It("does not check whether the state is stale when there is no proc path", func() {
+			Expect(os.MkdirAll(path.Join(tmp, "some-id"), 0700)).To(Succeed())
+			Expect(ioutil.WriteFile(path.Join(tmp, "some-id", "state.json"), []byte(`{"init_process_pid":42}`), 0700)).To(Succeed())
+
+			state, err := checker.State(logger, "some-id")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(state.Stale).To(BeFalse())
+		})
+
+		Context("when there is a proc path", func() {
+			var procPath string
+
+			writeStat := func(pid, processState, startTime string) {
+				Expect(os.MkdirAll(path.Join(procPath, pid), 0700)).To(Succeed())
+				stat := pid + " (some (odd) name) " + processState + " 1 2 3 4 5 6 7 8 9 10 11 12 13 14 15 16 17 18 " + startTime + " 20 21"
+				Expect(ioutil.WriteFile(path.Join(procPath, pid, "stat"), []byte(stat), 0700)).To(Succeed())
+			}
+
+			BeforeEach(func() {
+				procPath = path.Join(tmp, "proc")
+				checker.ProcPath = procPath
+
+				Expect(os.MkdirAll(path.Join(tmp, "some-id"), 0700)).To(Succeed())
+				Expect(ioutil.WriteFile(path.Join(tmp, "some-id", "state.json"), []byte(`{"init_process_pid":42,"init_process_start":"1234"}`), 0700)).To(Succeed())
+			})
+
+			It("is not stale when the init process is running", func() {
+				writeStat("42", "S", "1234")
+
+				state, err := checker.State(logger, "some-id")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(state.Stale).To(BeFalse())
+				Expect(state.Status).To(Equal("running"))
+			})
+
+			It("is stale when the init process does not exist", func() {
+				state, err := checker.State(logger, "some-id")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(state.Stale).To(BeTrue())
+				Expect(state.Status).To(Equal("stopped"))
+			})
+
+			It("is stale when the init process is a zombie", func() {
+				writeStat("42", "Z", "1234")
+
+				state, err := checker.State(logger, "some-id")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(state.Stale).To(BeTrue())
+			})
+
+			It("is stale when the pid has been reused by another process", func() {
+				writeStat("42", "S", "9999")
+
+				state, err := checker.State(logger, "some-id")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(state.Stale).To(BeTrue())
+			})
+		})
+
 		Context("when the state file does not contain valid JSON", func() {
 			It("should return an error", func() {
 				Expect(os.MkdirAll(path.Join(tmp, "some-id"), 0700)).To(Succeed())
@@ -56,4 +132,69 @@ var _ = Describe("StateChecker", func() {
 			})
 		})
 	})
+
+	Describe("RawState", func() {
+		It("returns the state file of the container in full", func() {
+			Expect(os.MkdirAll(path.Join(tmp, "some-id"), 0700)).To(Succeed())
+			Expect(ioutil.WriteFile(path.Join(tmp, "some-id", "state.json"), []byte(`{"id":"some-id","init_process_pid":42}`), 0700)).To(Succeed())
+
+			state, err := checker.RawState(logger, "some-id")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(state)).To(Equal(`{"id":"some-id","init_process_pid":42}`))
+		})
+
+		It("fails when there is no state file", func() {
+			_, err := checker.RawState(logger, "some-id")
+			Expect(err).To(HaveOccurred())
+		})
+	})
+})
+
+var _ = Describe("RuntimeStateChecker", func() {
+	var (
+		commandRunner *fake_command_runner.FakeCommandRunner
+		checker       rundmc.RuntimeStateChecker
+		logger        lager.Logger
+
+		stateOutput string
+		stateErr    error
+	)
+
+	BeforeEach(func() {
+		logger = lagertest.NewTestLogger("test")
+		commandRunner = fake_command_runner.New()
+		checker = rundmc.RuntimeStateChecker{Runtime: "/path/to/winc", CommandRunner: commandRunner}
+
+		stateOutput = `{"ociVersion": "1.0.0", "id": "some-id", "status": "running", "pid": 4321}`
+		stateErr = nil
+		commandRunner.WhenRunning(fake_command_runner.CommandSpec{
+			Path: "/path/to/winc",
+			Args: []string{"state", "some-id"},
+		}, func(cmd *exec.Cmd) error {
+			cmd.Stdout.Write([]byte(stateOutput))
+			cmd.Stderr.Write([]byte("some-stderr\n"))
+			return stateErr
+		})
+	})
+
+	It("returns the pid of the container's init process and its status", func() {
+		Expect(checker.State(logger, "some-id")).To(Equal(rundmc.State{Pid: 4321, Status: "running"}))
+	})
+
+	Context("when the container has stopped", func() {
+		It("returns a stale state", func() {
+			stateOutput = `{"id": "some-id", "status": "stopped", "pid": 4321}`
+			state, err := checker.State(logger, "some-id")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(state.Stale).To(BeTrue())
+		})
+	})
+
+	Context("when the runtime fails", func() {
+		It("returns an error including its stderr", func() {
+			stateErr = errors.New("exit status 1")
+			_, err := checker.State(logger, "some-id")
+			Expect(err).To(MatchError("runtime state: exit status 1: some-stderr"))
+		})
+	})
 })
@@ -0,0 +1,64 @@
+package socketrelay
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/cloudfoundry-incubator/guardian/kawasaki/netns"
+	"github.com/cloudfoundry-incubator/guardian/pkg/inroot"
+)
+
+// NamespaceDialer dials abstract sockets (@name) from inside the container's
+// network namespace, to which they belong, and other sockets by resolving
+// their paths within the container's root in ProcPath (usually /proc)
+type NamespaceDialer struct {
+	ProcPath string
+}
+
+func (d NamespaceDialer) Dial(pid int, socket string) (net.Conn, error) {
+	procDir := filepath.Join(d.ProcPath, strconv.Itoa(pid))
+	if !strings.HasPrefix(socket, "@") {
+		return dialInRoot(filepath.Join(procDir, "root"), socket)
+	}
+
+	netnsFile, err := os.Open(filepath.Join(procDir, "ns", "net"))
+	if err != nil {
+		return nil, fmt.Errorf("open network namespace: %s", err)
+	}
+	defer netnsFile.Close()
+
+	// the socket is created in the namespace, and stays in it once the
+	// thread leaves
+	var conn net.Conn
+	err = netns.Exec(netnsFile, func() error {
+		var err error
+		conn, err = net.Dial("unix", socket)
+		return err
+	})
+
+	return conn, err
+}
+
+// dialInRoot dials the socket at path as if root were /: .. and symlinks,
+// including absolute ones, cannot lead out of root, which the container
+// controls. The socket is opened with openat2(RESOLVE_IN_ROOT) and dialed
+// through its /proc/self/fd link, so that the path is not resolved again.
+func dialInRoot(root, path string) (net.Conn, error) {
+	rootDir, err := os.Open(root)
+	if err != nil {
+		return nil, fmt.Errorf("open container root: %s", err)
+	}
+	defer rootDir.Close()
+
+	socketFile, err := inroot.Open(rootDir, path)
+	if err != nil {
+		return nil, err
+	}
+	defer socketFile.Close()
+
+	return net.Dial("unix", inroot.FdPath(socketFile))
+}
@@ -0,0 +1,65 @@
+package socketrelay
+
+import (
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("dialInRoot", func() {
+	var (
+		tmpDir    string
+		root      string
+		listeners []net.Listener
+	)
+
+	BeforeEach(func() {
+		var err error
+		tmpDir, err = ioutil.TempDir("", "dial-in-root")
+		Expect(err).NotTo(HaveOccurred())
+
+		root = filepath.Join(tmpDir, "root")
+		Expect(os.MkdirAll(filepath.Join(root, "run"), 0755)).To(Succeed())
+	})
+
+	AfterEach(func() {
+		for _, listener := range listeners {
+			listener.Close()
+		}
+
+		Expect(os.RemoveAll(tmpDir)).To(Succeed())
+	})
+
+	listen := func(path string) {
+		listener, err := net.Listen("unix", path)
+		Expect(err).NotTo(HaveOccurred())
+		listeners = append(listeners, listener)
+	}
+
+	It("dials sockets in the root", func() {
+		listen(filepath.Join(root, "run", "exporter.sock"))
+
+		conn, err := dialInRoot(root, "/run/exporter.sock")
+		Expect(err).NotTo(HaveOccurred())
+		conn.Close()
+	})
+
+	It("does not follow .. out of the root", func() {
+		listen(filepath.Join(tmpDir, "host.sock"))
+
+		_, err := dialInRoot(root, "/../host.sock")
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("does not follow absolute symlinks out of the root", func() {
+		listen(filepath.Join(tmpDir, "host.sock"))
+		Expect(os.Symlink(filepath.Join(tmpDir, "host.sock"), filepath.Join(root, "run", "link.sock"))).To(Succeed())
+
+		_, err := dialInRoot(root, "/run/link.sock")
+		Expect(err).To(HaveOccurred())
+	})
+})
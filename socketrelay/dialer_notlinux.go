@@ -0,0 +1,17 @@
+// +build !linux
+
+package socketrelay
+
+import (
+	"errors"
+	"net"
+)
+
+// NamespaceDialer is only supported on linux
+type NamespaceDialer struct {
+	ProcPath string
+}
+
+func (d NamespaceDialer) Dial(pid int, socket string) (net.Conn, error) {
+	return nil, errors.New("relaying container sockets is not supported on this platform")
+}
@@ -0,0 +1,58 @@
+// This file was generated by counterfeiter
+package fakes
+
+import (
+	"net"
+	"sync"
+
+	"github.com/cloudfoundry-incubator/guardian/socketrelay"
+)
+
+type FakeDialer struct {
+	DialStub        func(pid int, socket string) (net.Conn, error)
+	dialMutex       sync.RWMutex
+	dialArgsForCall []struct {
+		pid    int
+		socket string
+	}
+	dialReturns struct {
+		result1 net.Conn
+		result2 error
+	}
+}
+
+func (fake *FakeDialer) Dial(pid int, socket string) (net.Conn, error) {
+	fake.dialMutex.Lock()
+	fake.dialArgsForCall = append(fake.dialArgsForCall, struct {
+		pid    int
+		socket string
+	}{pid, socket})
+	fake.dialMutex.Unlock()
+	if fake.DialStub != nil {
+		return fake.DialStub(pid, socket)
+	} else {
+		return fake.dialReturns.result1, fake.dialReturns.result2
+	}
+}
+
+func (fake *FakeDialer) DialCallCount() int {
+	fake.dialMutex.RLock()
+	defer fake.dialMutex.RUnlock()
+	return len(fake.dialArgsForCall)
+}
+
+func (fake *FakeDialer) DialArgsForCall(i int) (int, string) {
+	fake.dialMutex.RLock()
+	defer fake.dialMutex.RUnlock()
+	return fake.dialArgsForCall[i].pid, fake.dialArgsForCall[i].socket
+}
+
+func (fake *FakeDialer) DialReturns(result1 net.Conn, result2 error) {
+	fake.DialStub = nil
+	fake.dialReturns = struct {
+		result1 net.Conn
+		result2 error
+	}{result1, result2}
+}
+
+var _ socketrelay.Dialer = new(FakeDialer)
@@ -0,0 +1,161 @@
+// Package socketrelay relays sockets in containers, such as those of metrics
+// exporters, to sockets on the host, so that they can be reached without
+// mapping a port on the host to the container.
+package socketrelay
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/cloudfoundry-incubator/guardian/rundmc"
+	"github.com/pivotal-golang/lager"
+)
+
+//go:generate counterfeiter . Dialer
+
+// Dialer connects to a socket in the container whose init process is pid
+type Dialer interface {
+	Dial(pid int, socket string) (net.Conn, error)
+}
+
+// Relay listens on a socket on the host for each container whose socket it
+// relays, at <Dir>/<handle>.sock, and forwards each connection to the
+// container's socket byte for byte. The container's socket is dialed for each
+// connection, so the exporter behind it may be restarted.
+type Relay struct {
+	Dir    string
+	Stater rundmc.ContainerStater
+	Dialer Dialer
+
+	mu        sync.Mutex
+	listeners map[string]net.Listener
+}
+
+func (r *Relay) Relay(log lager.Logger, handle, socket string) (string, error) {
+	log = log.Session("relay", lager.Data{"handle": handle, "socket": socket})
+
+	hostPath, err := r.hostPath(handle)
+	if err != nil {
+		return "", err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.stop(handle)
+
+	// a socket left by a previous run of guardian cannot be listened on
+	if err := os.Remove(hostPath); err != nil && !os.IsNotExist(err) {
+		return "", err
+	}
+
+	listener, err := net.Listen("unix", hostPath)
+	if err != nil {
+		log.Error("listen-failed", err)
+		return "", err
+	}
+
+	if r.listeners == nil {
+		r.listeners = make(map[string]net.Listener)
+	}
+	r.listeners[handle] = listener
+
+	go r.serve(log, handle, socket, listener)
+
+	log.Info("relaying", lager.Data{"host-path": hostPath})
+	return hostPath, nil
+}
+
+func (r *Relay) Stop(log lager.Logger, handle string) error {
+	hostPath, err := r.hostPath(handle)
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.stop(handle)
+
+	if err := os.Remove(hostPath); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	return nil
+}
+
+// hostPath is the path of the container's socket on the host, which must be
+// in Dir whatever the handle
+func (r *Relay) hostPath(handle string) (string, error) {
+	if handle == "" || handle == "." || handle == ".." || strings.ContainsAny(handle, "/\x00") {
+		return "", fmt.Errorf("invalid handle '%s'", handle)
+	}
+
+	return filepath.Join(r.Dir, handle+".sock"), nil
+}
+
+func (r *Relay) stop(handle string) {
+	if listener, ok := r.listeners[handle]; ok {
+		listener.Close()
+		delete(r.listeners, handle)
+	}
+}
+
+func (r *Relay) serve(log lager.Logger, handle, socket string, listener net.Listener) {
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			if netErr, ok := err.(net.Error); ok && netErr.Temporary() {
+				continue
+			}
+
+			log.Info("stopped", lager.Data{"reason": err.Error()})
+			return
+		}
+
+		go r.forward(log, handle, socket, conn)
+	}
+}
+
+func (r *Relay) forward(log lager.Logger, handle, socket string, conn net.Conn) {
+	defer conn.Close()
+
+	backend, err := r.dial(log, handle, socket)
+	if err != nil {
+		log.Error("dial-container-failed", err)
+		return
+	}
+	defer backend.Close()
+
+	done := make(chan struct{}, 2)
+	go func() {
+		io.Copy(backend, conn)
+		done <- struct{}{}
+	}()
+
+	go func() {
+		io.Copy(conn, backend)
+		done <- struct{}{}
+	}()
+
+	<-done
+}
+
+func (r *Relay) dial(log lager.Logger, handle, socket string) (net.Conn, error) {
+	state, err := r.Stater.State(log, handle)
+	if err != nil {
+		return nil, fmt.Errorf("read state: %s", err)
+	}
+
+	if state.Stale || state.Pid == 0 {
+		return nil, errors.New("the container is not running")
+	}
+
+	return r.Dialer.Dial(state.Pid, socket)
+}
@@ -0,0 +1,155 @@
+package socketrelay_test
+
+import (
+	"errors"
+	"io"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+
+	"github.com/cloudfoundry-incubator/guardian/rundmc"
+	rundmcfakes "github.com/cloudfoundry-incubator/guardian/rundmc/fakes"
+	"github.com/cloudfoundry-incubator/guardian/socketrelay"
+	"github.com/cloudfoundry-incubator/guardian/socketrelay/fakes"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/pivotal-golang/lager/lagertest"
+)
+
+var _ = Describe("Relay", func() {
+	var (
+		dir       string
+		stater    *rundmcfakes.FakeContainerStater
+		dialer    *fakes.FakeDialer
+		container net.Conn
+		relay     *socketrelay.Relay
+		logger    *lagertest.TestLogger
+	)
+
+	BeforeEach(func() {
+		var err error
+		dir, err = ioutil.TempDir("", "socketrelay")
+		Expect(err).NotTo(HaveOccurred())
+
+		stater = new(rundmcfakes.FakeContainerStater)
+		stater.StateReturns(rundmc.State{Pid: 1234}, nil)
+
+		var exporter net.Conn
+		exporter, container = net.Pipe()
+		dialer = new(fakes.FakeDialer)
+		dialer.DialReturns(exporter, nil)
+
+		logger = lagertest.NewTestLogger("test")
+		relay = &socketrelay.Relay{Dir: dir, Stater: stater, Dialer: dialer}
+	})
+
+	AfterEach(func() {
+		Expect(relay.Stop(logger, "some-handle")).To(Succeed())
+		Expect(os.RemoveAll(dir)).To(Succeed())
+	})
+
+	It("listens on a socket on the host named for the container", func() {
+		hostPath, err := relay.Relay(logger, "some-handle", "@exporter")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(hostPath).To(Equal(filepath.Join(dir, "some-handle.sock")))
+	})
+
+	It("forwards each connection to the container's socket", func() {
+		hostPath, err := relay.Relay(logger, "some-handle", "@exporter")
+		Expect(err).NotTo(HaveOccurred())
+
+		conn, err := net.Dial("unix", hostPath)
+		Expect(err).NotTo(HaveOccurred())
+		defer conn.Close()
+
+		go func() {
+			defer GinkgoRecover()
+
+			request := make([]byte, 4)
+			_, err := io.ReadFull(container, request)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(request)).To(Equal("GET "))
+
+			_, err = container.Write([]byte("metric 1\n"))
+			Expect(err).NotTo(HaveOccurred())
+		}()
+
+		_, err = conn.Write([]byte("GET "))
+		Expect(err).NotTo(HaveOccurred())
+
+		response := make([]byte, 9)
+		_, err = io.ReadFull(conn, response)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(response)).To(Equal("metric 1\n"))
+
+		Expect(dialer.DialCallCount()).To(Equal(1))
+		pid, socket := dialer.DialArgsForCall(0)
+		Expect(pid).To(Equal(1234))
+		Expect(socket).To(Equal("@exporter"))
+	})
+
+	It("refuses handles which would put the socket outside its directory", func() {
+		_, err := relay.Relay(logger, "../../run/guardian", "@exporter")
+		Expect(err).To(MatchError("invalid handle '../../run/guardian'"))
+
+		_, err = relay.Relay(logger, "..", "@exporter")
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("replaces a socket left by a previous run", func() {
+		Expect(ioutil.WriteFile(filepath.Join(dir, "some-handle.sock"), nil, 0600)).To(Succeed())
+
+		_, err := relay.Relay(logger, "some-handle", "@exporter")
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("removes the host's socket when it is stopped", func() {
+		hostPath, err := relay.Relay(logger, "some-handle", "@exporter")
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(relay.Stop(logger, "some-handle")).To(Succeed())
+		Expect(hostPath).NotTo(BeAnExistingFile())
+
+		_, err = net.Dial("unix", hostPath)
+		Expect(err).To(HaveOccurred())
+	})
+
+	Context("when the container is not running", func() {
+		BeforeEach(func() {
+			stater.StateReturns(rundmc.State{Pid: 1234, Stale: true}, nil)
+		})
+
+		It("closes the connection without dialing", func() {
+			hostPath, err := relay.Relay(logger, "some-handle", "@exporter")
+			Expect(err).NotTo(HaveOccurred())
+
+			conn, err := net.Dial("unix", hostPath)
+			Expect(err).NotTo(HaveOccurred())
+			defer conn.Close()
+
+			_, err = conn.Read(make([]byte, 1))
+			Expect(err).To(Equal(io.EOF))
+			Expect(dialer.DialCallCount()).To(Equal(0))
+		})
+	})
+
+	Context("when the container's socket cannot be dialed", func() {
+		BeforeEach(func() {
+			dialer.DialReturns(nil, errors.New("connection refused"))
+		})
+
+		It("closes the connection and logs why", func() {
+			hostPath, err := relay.Relay(logger, "some-handle", "@exporter")
+			Expect(err).NotTo(HaveOccurred())
+
+			conn, err := net.Dial("unix", hostPath)
+			Expect(err).NotTo(HaveOccurred())
+			defer conn.Close()
+
+			_, err = conn.Read(make([]byte, 1))
+			Expect(err).To(Equal(io.EOF))
+			Expect(logger.LogMessages()).To(ContainElement("test.relay.dial-container-failed"))
+		})
+	})
+})
@@ -0,0 +1,13 @@
+package socketrelay_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"testing"
+)
+
+func TestSocketrelay(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Socketrelay Suite")
+}
@@ -0,0 +1,12 @@
+// +build !windows
+
+package sysinfo
+
+import "syscall"
+
+// writable is access(2)'s W_OK
+const writable = 0x2
+
+func checkWritable(path string) error {
+	return syscall.Access(path, writable)
+}
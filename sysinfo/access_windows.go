@@ -0,0 +1,7 @@
+package sysinfo
+
+import "errors"
+
+func checkWritable(path string) error {
+	return errors.New("cgroups are not supported on windows")
+}
@@ -0,0 +1,230 @@
+package sysinfo
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/pivotal-golang/lager"
+)
+
+// Kernel features which container features depend on
+const (
+	CapabilityMemoryLimits   = "memory-limits"
+	CapabilitySwapLimits     = "swap-limits"
+	CapabilityPidsLimits     = "pids-limits"
+	CapabilityCPUQuota       = "cpu-quota"
+	CapabilitySeccomp        = "seccomp"
+	CapabilityUserNamespaces = "user-namespaces"
+)
+
+type Capability struct {
+	Name      string `json:"name"`
+	Available bool   `json:"available"`
+
+	// Reason explains why the capability is unavailable
+	Reason string `json:"reason,omitempty"`
+}
+
+// Capabilities records which kernel features are available. Until they have
+// been probed, and when Capabilities is nil, every feature is assumed to be
+// available so that nothing is disabled by mistake.
+type Capabilities struct {
+	mu     sync.RWMutex
+	probed map[string]Capability
+}
+
+func (c *Capabilities) Has(name string) bool {
+	if c == nil {
+		return true
+	}
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	capability, ok := c.probed[name]
+	return !ok || capability.Available
+}
+
+// List returns the probed capabilities, sorted by name
+func (c *Capabilities) List() []Capability {
+	if c == nil {
+		return []Capability{}
+	}
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	list := []Capability{}
+	for _, capability := range c.probed {
+		list = append(list, capability)
+	}
+
+	sort.Sort(byName(list))
+	return list
+}
+
+func (c *Capabilities) Set(list []Capability) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.probed = make(map[string]Capability)
+	for _, capability := range list {
+		c.probed[capability.Name] = capability
+	}
+}
+
+type byName []Capability
+
+func (b byName) Len() int           { return len(b) }
+func (b byName) Swap(i, j int)      { b[i], b[j] = b[j], b[i] }
+func (b byName) Less(i, j int) bool { return b[i].Name < b[j].Name }
+
+// capabilityProbeCgroup is the cgroup created, and removed again, to probe
+// the features of the unified cgroup hierarchy
+const capabilityProbeCgroup = "garden-capability-probe"
+
+// CapabilityProber probes the kernel's features when the server starts, so
+// that features depending on missing ones are disabled up front (with a
+// warning) rather than causing container creation to fail obscurely.
+type CapabilityProber struct {
+	// ProcPath is usually /proc
+	ProcPath string
+	// CgroupPath is where the cgroup hierarchies are mounted; it must be
+	// probed after they have been mounted
+	CgroupPath string
+	// Unified is whether CgroupPath is cgroup v2's unified hierarchy rather
+	// than a directory of cgroup v1 hierarchies
+	Unified bool
+
+	Capabilities *Capabilities
+	Logger       lager.Logger
+}
+
+func (p *CapabilityProber) Start() error {
+	log := p.Logger.Session("probe-capabilities")
+
+	capabilities := p.Probe()
+	for _, capability := range capabilities {
+		if !capability.Available {
+			log.Info("capability-unavailable-disabling-dependent-features", lager.Data{
+				"capability": capability.Name,
+				"reason":     capability.Reason,
+			})
+		}
+	}
+
+	p.Capabilities.Set(capabilities)
+	return nil
+}
+
+func (p *CapabilityProber) Probe() []Capability {
+	cgroups, swap, cfsBandwidth := p.probeCgroups()
+
+	return []Capability{
+		probe(CapabilityMemoryLimits, cgroups["memory"], "the memory cgroup is not enabled"),
+		probe(CapabilitySwapLimits, cgroups["memory"] && swap, "swap accounting is disabled (boot with swapaccount=1 to enable it)"),
+		probe(CapabilityPidsLimits, cgroups["pids"], "the pids cgroup is not enabled (it requires linux 4.3 or later)"),
+		probe(CapabilityCPUQuota, cgroups["cpu"] && cfsBandwidth, "CFS bandwidth control is not available (the kernel must be built with CONFIG_CFS_BANDWIDTH)"),
+		probe(CapabilitySeccomp, p.hasSeccomp(), "the kernel was built without seccomp support"),
+		probe(CapabilityUserNamespaces, p.hasUserNamespaces(), "user namespaces are not supported or are disabled"),
+	}
+}
+
+func probe(name string, available bool, reason string) Capability {
+	if available {
+		return Capability{Name: name, Available: true}
+	}
+
+	return Capability{Name: name, Reason: reason}
+}
+
+func (p *CapabilityProber) probeCgroups() (enabled map[string]bool, swap, cfsBandwidth bool) {
+	if !p.Unified {
+		enabled = p.enabledCgroups()
+		swap = exists(filepath.Join(p.CgroupPath, "memory", "memory.memsw.limit_in_bytes"))
+		cfsBandwidth = exists(filepath.Join(p.CgroupPath, "cpu", "cpu.cfs_quota_us"))
+		return enabled, swap, cfsBandwidth
+	}
+
+	// /proc/cgroups does not describe the unified hierarchy, and the root
+	// cgroup has no limit files, so look in a child cgroup for the files
+	// which depend on the kernel's features
+	enabled, err := UnifiedCgroupControllers(p.CgroupPath)
+	if err != nil {
+		return make(map[string]bool), false, false
+	}
+
+	probePath := filepath.Join(p.CgroupPath, capabilityProbeCgroup)
+	if err := os.Mkdir(probePath, 0755); err != nil && !os.IsExist(err) {
+		return enabled, false, false
+	}
+	defer os.Remove(probePath)
+
+	swap = exists(filepath.Join(probePath, "memory.swap.max"))
+	cfsBandwidth = exists(filepath.Join(probePath, "cpu.max"))
+	return enabled, swap, cfsBandwidth
+}
+
+func (p *CapabilityProber) enabledCgroups() map[string]bool {
+	enabled := make(map[string]bool)
+
+	f, err := os.Open(filepath.Join(p.ProcPath, "cgroups"))
+	if err != nil {
+		return enabled
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var name string
+		var hierarchy, count, isEnabled int
+		if n, _ := fmt.Sscanf(scanner.Text(), "%s %d %d %d", &name, &hierarchy, &count, &isEnabled); n != 4 {
+			continue
+		}
+
+		enabled[name] = isEnabled == 1
+	}
+
+	return enabled
+}
+
+func (p *CapabilityProber) hasSeccomp() bool {
+	status, err := ioutil.ReadFile(filepath.Join(p.ProcPath, "self", "status"))
+	if err != nil {
+		return false
+	}
+
+	return strings.Contains(string(status), "\nSeccomp:")
+}
+
+func (p *CapabilityProber) hasUserNamespaces() bool {
+	if !exists(filepath.Join(p.ProcPath, "self", "ns", "user")) {
+		return false
+	}
+
+	max, err := ioutil.ReadFile(filepath.Join(p.ProcPath, "sys", "user", "max_user_namespaces"))
+	return err != nil || strings.TrimSpace(string(max)) != "0"
+}
+
+func exists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+// CapabilitiesHandler serves the probed capabilities as a JSON list
+type CapabilitiesHandler struct {
+	Capabilities *Capabilities
+}
+
+func (h *CapabilitiesHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(h.Capabilities.List())
+}
@@ -0,0 +1,206 @@
+package sysinfo_test
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+
+	"github.com/cloudfoundry-incubator/guardian/sysinfo"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/pivotal-golang/lager/lagertest"
+)
+
+var _ = Describe("Capabilities", func() {
+	It("assumes every capability is available until they are probed", func() {
+		Expect((&sysinfo.Capabilities{}).Has(sysinfo.CapabilityPidsLimits)).To(BeTrue())
+
+		var capabilities *sysinfo.Capabilities
+		Expect(capabilities.Has(sysinfo.CapabilityPidsLimits)).To(BeTrue())
+	})
+
+	It("reports probed capabilities", func() {
+		capabilities := &sysinfo.Capabilities{}
+		capabilities.Set([]sysinfo.Capability{
+			{Name: sysinfo.CapabilitySeccomp, Available: true},
+			{Name: sysinfo.CapabilityPidsLimits, Reason: "too old"},
+		})
+
+		Expect(capabilities.Has(sysinfo.CapabilitySeccomp)).To(BeTrue())
+		Expect(capabilities.Has(sysinfo.CapabilityPidsLimits)).To(BeFalse())
+		Expect(capabilities.List()).To(Equal([]sysinfo.Capability{
+			{Name: sysinfo.CapabilityPidsLimits, Reason: "too old"},
+			{Name: sysinfo.CapabilitySeccomp, Available: true},
+		}))
+	})
+
+	Describe("CapabilityProber", func() {
+		var (
+			tmpDir       string
+			procPath     string
+			cgroupPath   string
+			capabilities *sysinfo.Capabilities
+			logger       *lagertest.TestLogger
+			prober       *sysinfo.CapabilityProber
+		)
+
+		writeFile := func(path, content string) {
+			Expect(os.MkdirAll(filepath.Dir(path), 0755)).To(Succeed())
+			Expect(ioutil.WriteFile(path, []byte(content), 0644)).To(Succeed())
+		}
+
+		BeforeEach(func() {
+			var err error
+			tmpDir, err = ioutil.TempDir("", "capabilities")
+			Expect(err).NotTo(HaveOccurred())
+
+			procPath = filepath.Join(tmpDir, "proc")
+			cgroupPath = filepath.Join(tmpDir, "cgroup")
+
+			writeFile(filepath.Join(procPath, "cgroups"), "#subsys_name\thierarchy\tnum_cgroups\tenabled\n"+
+				"cpu\t2\t1\t1\n"+
+				"memory\t3\t1\t1\n"+
+				"pids\t4\t1\t1\n")
+			writeFile(filepath.Join(procPath, "self", "status"), "Name:\tgdn\nSeccomp:\t0\n")
+			writeFile(filepath.Join(procPath, "self", "ns", "user"), "")
+			writeFile(filepath.Join(cgroupPath, "memory", "memory.memsw.limit_in_bytes"), "")
+			writeFile(filepath.Join(cgroupPath, "cpu", "cpu.cfs_quota_us"), "-1\n")
+
+			capabilities = &sysinfo.Capabilities{}
+			logger = lagertest.NewTestLogger("test")
+			prober = &sysinfo.CapabilityProber{
+				ProcPath:     procPath,
+				CgroupPath:   cgroupPath,
+				Capabilities: capabilities,
+				Logger:       logger,
+			}
+		})
+
+		AfterEach(func() {
+			Expect(os.RemoveAll(tmpDir)).To(Succeed())
+		})
+
+		It("finds every capability when the kernel supports them", func() {
+			Expect(prober.Start()).To(Succeed())
+
+			for _, capability := range capabilities.List() {
+				Expect(capability.Available).To(BeTrue(), capability.Name)
+			}
+			Expect(capabilities.List()).To(HaveLen(6))
+			Expect(logger.LogMessages()).NotTo(ContainElement(ContainSubstring("capability-unavailable")))
+		})
+
+		It("disables pid limits when the pids cgroup is missing", func() {
+			writeFile(filepath.Join(procPath, "cgroups"), "#subsys_name\thierarchy\tnum_cgroups\tenabled\nmemory\t3\t1\t1\n")
+			Expect(prober.Start()).To(Succeed())
+
+			Expect(capabilities.Has(sysinfo.CapabilityPidsLimits)).To(BeFalse())
+			Expect(capabilities.Has(sysinfo.CapabilityMemoryLimits)).To(BeTrue())
+			Expect(logger.LogMessages()).To(ContainElement("test.probe-capabilities.capability-unavailable-disabling-dependent-features"))
+		})
+
+		It("disables memory and swap limits when the memory cgroup is disabled", func() {
+			writeFile(filepath.Join(procPath, "cgroups"), "#subsys_name\thierarchy\tnum_cgroups\tenabled\nmemory\t0\t1\t0\n")
+			Expect(prober.Start()).To(Succeed())
+
+			Expect(capabilities.Has(sysinfo.CapabilityMemoryLimits)).To(BeFalse())
+			Expect(capabilities.Has(sysinfo.CapabilitySwapLimits)).To(BeFalse())
+		})
+
+		It("disables swap limits when swap accounting is off", func() {
+			Expect(os.Remove(filepath.Join(cgroupPath, "memory", "memory.memsw.limit_in_bytes"))).To(Succeed())
+			Expect(prober.Start()).To(Succeed())
+
+			Expect(capabilities.Has(sysinfo.CapabilitySwapLimits)).To(BeFalse())
+			Expect(capabilities.Has(sysinfo.CapabilityMemoryLimits)).To(BeTrue())
+		})
+
+		It("disables cpu quotas when CFS bandwidth control is not available", func() {
+			Expect(os.Remove(filepath.Join(cgroupPath, "cpu", "cpu.cfs_quota_us"))).To(Succeed())
+			Expect(prober.Start()).To(Succeed())
+
+			Expect(capabilities.Has(sysinfo.CapabilityCPUQuota)).To(BeFalse())
+		})
+
+		It("disables seccomp when the kernel does not report a seccomp mode", func() {
+			writeFile(filepath.Join(procPath, "self", "status"), "Name:\tgdn\n")
+			Expect(prober.Start()).To(Succeed())
+
+			Expect(capabilities.Has(sysinfo.CapabilitySeccomp)).To(BeFalse())
+		})
+
+		It("disables user namespaces when they are limited to 0", func() {
+			writeFile(filepath.Join(procPath, "sys", "user", "max_user_namespaces"), "0\n")
+			Expect(prober.Start()).To(Succeed())
+
+			Expect(capabilities.Has(sysinfo.CapabilityUserNamespaces)).To(BeFalse())
+		})
+
+		Context("when the cgroups are the unified hierarchy", func() {
+			var probeCgroup string
+
+			BeforeEach(func() {
+				cgroupPath = filepath.Join(tmpDir, "unified")
+				probeCgroup = filepath.Join(cgroupPath, "garden-capability-probe")
+
+				writeFile(filepath.Join(cgroupPath, "cgroup.controllers"), "cpuset cpu io memory pids\n")
+				writeFile(filepath.Join(probeCgroup, "memory.swap.max"), "max\n")
+				writeFile(filepath.Join(probeCgroup, "cpu.max"), "max 100000\n")
+
+				prober.CgroupPath = cgroupPath
+				prober.Unified = true
+			})
+
+			It("finds the controllers in cgroup.controllers", func() {
+				writeFile(filepath.Join(procPath, "cgroups"), "")
+				Expect(prober.Start()).To(Succeed())
+
+				for _, capability := range capabilities.List() {
+					Expect(capability.Available).To(BeTrue(), capability.Name)
+				}
+			})
+
+			It("disables pid limits when the pids controller is missing", func() {
+				writeFile(filepath.Join(cgroupPath, "cgroup.controllers"), "cpu memory\n")
+				Expect(prober.Start()).To(Succeed())
+
+				Expect(capabilities.Has(sysinfo.CapabilityPidsLimits)).To(BeFalse())
+				Expect(capabilities.Has(sysinfo.CapabilityMemoryLimits)).To(BeTrue())
+			})
+
+			It("disables swap limits when swap is not accounted", func() {
+				Expect(os.Remove(filepath.Join(probeCgroup, "memory.swap.max"))).To(Succeed())
+				Expect(prober.Start()).To(Succeed())
+
+				Expect(capabilities.Has(sysinfo.CapabilitySwapLimits)).To(BeFalse())
+			})
+
+			It("disables cpu quotas when CFS bandwidth control is not available", func() {
+				Expect(os.Remove(filepath.Join(probeCgroup, "cpu.max"))).To(Succeed())
+				Expect(prober.Start()).To(Succeed())
+
+				Expect(capabilities.Has(sysinfo.CapabilityCPUQuota)).To(BeFalse())
+			})
+		})
+	})
+
+	Describe("CapabilitiesHandler", func() {
+		It("serves the capabilities as JSON", func() {
+			capabilities := &sysinfo.Capabilities{}
+			capabilities.Set([]sysinfo.Capability{{Name: sysinfo.CapabilitySeccomp, Available: true}})
+
+			req, err := http.NewRequest("GET", "/capabilities", nil)
+			Expect(err).NotTo(HaveOccurred())
+
+			recorder := httptest.NewRecorder()
+			(&sysinfo.CapabilitiesHandler{Capabilities: capabilities}).ServeHTTP(recorder, req)
+
+			var list []sysinfo.Capability
+			Expect(json.Unmarshal(recorder.Body.Bytes(), &list)).To(Succeed())
+			Expect(list).To(Equal([]sysinfo.Capability{{Name: sysinfo.CapabilitySeccomp, Available: true}}))
+		})
+	})
+})
@@ -0,0 +1,109 @@
+package sysinfo
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+)
+
+// IsUnifiedCgroupHierarchy reports whether the cgroup filesystem mounted at
+// sysCgroupPath (usually /sys/fs/cgroup) is cgroup v2's unified hierarchy,
+// which is the only one mounted on hosts without cgroup v1.
+func IsUnifiedCgroupHierarchy(sysCgroupPath string) bool {
+	return exists(filepath.Join(sysCgroupPath, "cgroup.controllers"))
+}
+
+// CgroupMode is the cgroup hierarchy a host mounts, or which guardian uses
+type CgroupMode string
+
+const (
+	// CgroupModeV1 hosts mount only cgroup v1 hierarchies
+	CgroupModeV1 CgroupMode = "v1"
+
+	// CgroupModeV2 hosts mount only cgroup v2's unified hierarchy
+	CgroupModeV2 CgroupMode = "v2"
+
+	// CgroupModeHybrid hosts mount cgroup v1 hierarchies, which own every
+	// controller, and an empty unified hierarchy (usually for systemd) at
+	// unified. Guardian runs in v1 mode on them.
+	CgroupModeHybrid CgroupMode = "hybrid"
+)
+
+// DetectCgroupMode reports the cgroup mode of the host whose cgroups are
+// mounted at sysCgroupPath (usually /sys/fs/cgroup)
+func DetectCgroupMode(sysCgroupPath string) CgroupMode {
+	if IsUnifiedCgroupHierarchy(sysCgroupPath) {
+		return CgroupModeV2
+	}
+
+	if IsUnifiedCgroupHierarchy(filepath.Join(sysCgroupPath, "unified")) {
+		return CgroupModeHybrid
+	}
+
+	return CgroupModeV1
+}
+
+// CheckCgroupMode returns whether guardian can run in the given mode (v1 or
+// v2) on a host in hostMode: the controllers are owned by either the v1
+// hierarchies or the unified one, so each mode needs a host which gives
+// them to it.
+func CheckCgroupMode(mode, hostMode CgroupMode) error {
+	switch mode {
+	case CgroupModeV1:
+		if hostMode == CgroupModeV2 {
+			return fmt.Errorf("cgroup mode %s is not available: the host only has cgroup v2", mode)
+		}
+	case CgroupModeV2:
+		if hostMode != CgroupModeV2 {
+			return fmt.Errorf("cgroup mode %s is not available: the host's controllers belong to cgroup v1 (the host is %s)", mode, hostMode)
+		}
+	default:
+		return fmt.Errorf("invalid cgroup mode: '%s'", mode)
+	}
+
+	return nil
+}
+
+// UnifiedCgroupControllers lists the controllers available in the unified
+// cgroup hierarchy's cgroup at cgroupPath
+func UnifiedCgroupControllers(cgroupPath string) (map[string]bool, error) {
+	data, err := ioutil.ReadFile(filepath.Join(cgroupPath, "cgroup.controllers"))
+	if err != nil {
+		return nil, err
+	}
+
+	controllers := make(map[string]bool)
+	for _, controller := range strings.Fields(string(data)) {
+		controllers[controller] = true
+	}
+
+	return controllers, nil
+}
+
+// DelegatedCgroup returns the path, under sysCgroupPath, of the unified
+// hierarchy cgroup which the process whose /proc/<pid>/cgroup file is at
+// procCgroupPath is in, so long as the process's user may write to it. An
+// unprivileged process may only create cgroups, and so limit containers,
+// under such a cgroup: systemd delegates one to each user's session.
+func DelegatedCgroup(procCgroupPath, sysCgroupPath string) (string, error) {
+	data, err := ioutil.ReadFile(procCgroupPath)
+	if err != nil {
+		return "", err
+	}
+
+	for _, line := range strings.Split(string(data), "\n") {
+		if !strings.HasPrefix(line, "0::") {
+			continue
+		}
+
+		cgroupPath := filepath.Join(sysCgroupPath, strings.TrimPrefix(line, "0::"))
+		if err := checkWritable(cgroupPath); err != nil {
+			return "", fmt.Errorf("cgroup %s is not delegated to the user: %s", cgroupPath, err)
+		}
+
+		return cgroupPath, nil
+	}
+
+	return "", fmt.Errorf("no cgroup v2 cgroup is delegated to the user: %s has no unified hierarchy entry", procCgroupPath)
+}
@@ -0,0 +1,92 @@
+package sysinfo_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/cloudfoundry-incubator/guardian/sysinfo"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Cgroup modes", func() {
+	var sysCgroupPath string
+
+	BeforeEach(func() {
+		var err error
+		sysCgroupPath, err = ioutil.TempDir("", "cgroups")
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		Expect(os.RemoveAll(sysCgroupPath)).To(Succeed())
+	})
+
+	Describe("DetectCgroupMode", func() {
+		It("detects hosts with only cgroup v1", func() {
+			Expect(os.Mkdir(filepath.Join(sysCgroupPath, "memory"), 0755)).To(Succeed())
+
+			Expect(sysinfo.DetectCgroupMode(sysCgroupPath)).To(Equal(sysinfo.CgroupModeV1))
+		})
+
+		It("detects hosts with only cgroup v2", func() {
+			Expect(ioutil.WriteFile(filepath.Join(sysCgroupPath, "cgroup.controllers"), []byte("cpu memory pids\n"), 0644)).To(Succeed())
+
+			Expect(sysinfo.DetectCgroupMode(sysCgroupPath)).To(Equal(sysinfo.CgroupModeV2))
+		})
+
+		It("detects hybrid hosts", func() {
+			Expect(os.Mkdir(filepath.Join(sysCgroupPath, "unified"), 0755)).To(Succeed())
+			Expect(ioutil.WriteFile(filepath.Join(sysCgroupPath, "unified", "cgroup.controllers"), []byte("\n"), 0644)).To(Succeed())
+
+			Expect(sysinfo.DetectCgroupMode(sysCgroupPath)).To(Equal(sysinfo.CgroupModeHybrid))
+		})
+	})
+
+	Describe("CheckCgroupMode", func() {
+		It("allows v1 on v1 and hybrid hosts only", func() {
+			Expect(sysinfo.CheckCgroupMode(sysinfo.CgroupModeV1, sysinfo.CgroupModeV1)).To(Succeed())
+			Expect(sysinfo.CheckCgroupMode(sysinfo.CgroupModeV1, sysinfo.CgroupModeHybrid)).To(Succeed())
+			Expect(sysinfo.CheckCgroupMode(sysinfo.CgroupModeV1, sysinfo.CgroupModeV2)).To(MatchError("cgroup mode v1 is not available: the host only has cgroup v2"))
+		})
+
+		It("allows v2 on v2 hosts only", func() {
+			Expect(sysinfo.CheckCgroupMode(sysinfo.CgroupModeV2, sysinfo.CgroupModeV2)).To(Succeed())
+			Expect(sysinfo.CheckCgroupMode(sysinfo.CgroupModeV2, sysinfo.CgroupModeHybrid)).To(HaveOccurred())
+		})
+
+		It("refuses unknown modes", func() {
+			Expect(sysinfo.CheckCgroupMode("v3", sysinfo.CgroupModeV2)).To(MatchError("invalid cgroup mode: 'v3'"))
+		})
+	})
+
+	Describe("DelegatedCgroup", func() {
+		var procCgroupPath string
+
+		BeforeEach(func() {
+			procCgroupPath = filepath.Join(sysCgroupPath, "proc-cgroup")
+			Expect(os.MkdirAll(filepath.Join(sysCgroupPath, "user.slice", "user-1000.slice"), 0755)).To(Succeed())
+		})
+
+		It("returns the unified hierarchy cgroup the process is in", func() {
+			Expect(ioutil.WriteFile(procCgroupPath, []byte("1:name=systemd:/foo\n0::/user.slice/user-1000.slice\n"), 0644)).To(Succeed())
+
+			Expect(sysinfo.DelegatedCgroup(procCgroupPath, sysCgroupPath)).To(Equal(filepath.Join(sysCgroupPath, "user.slice", "user-1000.slice")))
+		})
+
+		It("fails when the process is not in the unified hierarchy", func() {
+			Expect(ioutil.WriteFile(procCgroupPath, []byte("4:memory:/user.slice\n"), 0644)).To(Succeed())
+
+			_, err := sysinfo.DelegatedCgroup(procCgroupPath, sysCgroupPath)
+			Expect(err).To(MatchError(ContainSubstring("no cgroup v2 cgroup is delegated to the user")))
+		})
+
+		It("fails when the cgroup does not exist", func() {
+			Expect(ioutil.WriteFile(procCgroupPath, []byte("0::/system.slice\n"), 0644)).To(Succeed())
+
+			_, err := sysinfo.DelegatedCgroup(procCgroupPath, sysCgroupPath)
+			Expect(err).To(MatchError(ContainSubstring("is not delegated to the user")))
+		})
+	})
+})
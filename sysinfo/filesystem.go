@@ -0,0 +1,25 @@
+package sysinfo
+
+// networkFilesystems maps the statfs magic numbers of network filesystems to
+// their names. On these filesystems FIFOs and unix sockets are local to the
+// host which created them and advisory locks may silently not be honoured, so
+// guardian cannot rely on them there.
+var networkFilesystems = map[uint32]string{
+	0x6969:     "nfs",
+	0xFF534D42: "cifs",
+	0xFE534D42: "smb2",
+	0x517B:     "smb",
+	0x01021997: "9p",
+	0x00C36400: "ceph",
+	0x5346414F: "afs",
+	0x013111A8: "ibrix",
+	0x47504653: "gpfs",
+	0x0BD00BD0: "lustre",
+}
+
+// NetworkFilesystemName returns the name of the network filesystem with the
+// given statfs magic number, or false if it is not a network filesystem.
+func NetworkFilesystemName(magic uint32) (string, bool) {
+	name, ok := networkFilesystems[magic]
+	return name, ok
+}
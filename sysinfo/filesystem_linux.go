@@ -0,0 +1,28 @@
+package sysinfo
+
+import (
+	"os"
+	"path/filepath"
+	"syscall"
+)
+
+// NetworkFilesystem reports whether path, or the nearest existing ancestor
+// of it, is on a network filesystem, and if so which one.
+func NetworkFilesystem(path string) (string, bool, error) {
+	path = filepath.Clean(path)
+
+	for {
+		var stat syscall.Statfs_t
+		err := syscall.Statfs(path, &stat)
+		if err == nil {
+			name, ok := NetworkFilesystemName(uint32(stat.Type))
+			return name, ok, nil
+		}
+
+		if !os.IsNotExist(err) || path == filepath.Dir(path) {
+			return "", false, err
+		}
+
+		path = filepath.Dir(path)
+	}
+}
@@ -0,0 +1,7 @@
+// +build !linux
+
+package sysinfo
+
+func NetworkFilesystem(path string) (string, bool, error) {
+	return "", false, nil
+}
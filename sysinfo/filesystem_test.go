@@ -0,0 +1,51 @@
+package sysinfo_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/cloudfoundry-incubator/guardian/sysinfo"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Filesystem detection", func() {
+	DescribeTable("NetworkFilesystemName",
+		func(magic uint32, expectedName string, expectedNetwork bool) {
+			name, ok := sysinfo.NetworkFilesystemName(magic)
+			Expect(ok).To(Equal(expectedNetwork))
+			Expect(name).To(Equal(expectedName))
+		},
+		Entry("nfs", uint32(0x6969), "nfs", true),
+		Entry("cifs", uint32(0xFF534D42), "cifs", true),
+		Entry("ext4", uint32(0xEF53), "", false),
+		Entry("tmpfs", uint32(0x01021994), "", false),
+	)
+
+	Describe("NetworkFilesystem", func() {
+		var tmpDir string
+
+		BeforeEach(func() {
+			var err error
+			tmpDir, err = ioutil.TempDir("", "fsdetect")
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		AfterEach(func() {
+			Expect(os.RemoveAll(tmpDir)).To(Succeed())
+		})
+
+		It("detects the filesystem of the nearest existing ancestor of paths which do not exist yet", func() {
+			expectedName, expectedNetwork, err := sysinfo.NetworkFilesystem(tmpDir)
+			Expect(err).NotTo(HaveOccurred())
+
+			name, network, err := sysinfo.NetworkFilesystem(filepath.Join(tmpDir, "does", "not", "exist"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(name).To(Equal(expectedName))
+			Expect(network).To(Equal(expectedNetwork))
+		})
+	})
+})
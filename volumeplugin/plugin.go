@@ -0,0 +1,84 @@
+package volumeplugin
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"strings"
+
+	"github.com/cloudfoundry-incubator/guardian/gardener"
+	"github.com/cloudfoundry/gunk/command_runner"
+	"github.com/pivotal-golang/lager"
+)
+
+// ExternalPlugin mounts container volumes, e.g. network storage, by running
+// a volume plugin binary which takes 'mount' and 'unmount' commands:
+//
+//	<binary> <extra args> mount --handle <handle> --volume <name> --options <json>
+//	<binary> <extra args> unmount --handle <handle> --volume <name> --options <json>
+//
+// mount prints the host path at which the volume is mounted, which is bind
+// mounted in to the container. Both commands are given the volume's options
+// as a JSON object, and unmount must succeed for volumes which are not
+// mounted, so that failed creates can be cleaned up.
+type ExternalPlugin struct {
+	Binary        string
+	ExtraArgs     []string
+	CommandRunner command_runner.CommandRunner
+}
+
+func (p *ExternalPlugin) Mount(log lager.Logger, handle string, volume gardener.Volume) (string, error) {
+	log = log.Session("volume-plugin-mount", lager.Data{"handle": handle, "volume": volume.Name})
+
+	log.Info("started")
+	defer log.Info("finished")
+
+	output, err := p.run(log, "mount", handle, volume)
+	if err != nil {
+		return "", err
+	}
+
+	hostPath := strings.TrimSpace(output)
+	if hostPath == "" {
+		return "", fmt.Errorf("volume plugin mount: no host path was printed")
+	}
+
+	return hostPath, nil
+}
+
+func (p *ExternalPlugin) Unmount(log lager.Logger, handle string, volume gardener.Volume) error {
+	log = log.Session("volume-plugin-unmount", lager.Data{"handle": handle, "volume": volume.Name})
+
+	log.Info("started")
+	defer log.Info("finished")
+
+	_, err := p.run(log, "unmount", handle, volume)
+	return err
+}
+
+func (p *ExternalPlugin) run(log lager.Logger, action, handle string, volume gardener.Volume) (string, error) {
+	options := volume.Options
+	if options == nil {
+		options = map[string]string{}
+	}
+
+	encodedOptions, err := json.Marshal(options)
+	if err != nil {
+		return "", err
+	}
+
+	args := append(append([]string{}, p.ExtraArgs...), action, "--handle", handle, "--volume", volume.Name, "--options", string(encodedOptions))
+
+	stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+	cmd := exec.Command(p.Binary, args...)
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+
+	if err := p.CommandRunner.Run(cmd); err != nil {
+		log.Error("volume-plugin-failed", err, lager.Data{"stderr": stderr.String()})
+		return "", fmt.Errorf("volume plugin %s: %s: %s", action, err, strings.TrimSpace(stderr.String()))
+	}
+
+	return stdout.String(), nil
+}
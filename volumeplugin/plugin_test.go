@@ -0,0 +1,92 @@
+package volumeplugin_test
+
+import (
+	"errors"
+	"os/exec"
+
+	"github.com/cloudfoundry-incubator/guardian/gardener"
+	"github.com/cloudfoundry-incubator/guardian/volumeplugin"
+	"github.com/cloudfoundry/gunk/command_runner/fake_command_runner"
+	. "github.com/cloudfoundry/gunk/command_runner/fake_command_runner/matchers"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/pivotal-golang/lager/lagertest"
+)
+
+var _ = Describe("ExternalPlugin", func() {
+	var (
+		commandRunner *fake_command_runner.FakeCommandRunner
+		plugin        *volumeplugin.ExternalPlugin
+		volume        gardener.Volume
+	)
+
+	BeforeEach(func() {
+		commandRunner = fake_command_runner.New()
+		plugin = &volumeplugin.ExternalPlugin{
+			Binary:        "/path/to/nfs-plugin",
+			ExtraArgs:     []string{"--mount-root", "/var/vcap/data/volumes"},
+			CommandRunner: commandRunner,
+		}
+
+		volume = gardener.Volume{
+			Name:    "data",
+			DstPath: "/data",
+			Options: map[string]string{"server": "nfs-1"},
+		}
+
+		commandRunner.WhenRunning(fake_command_runner.CommandSpec{Path: "/path/to/nfs-plugin"}, func(cmd *exec.Cmd) error {
+			cmd.Stdout.Write([]byte("/var/vcap/data/volumes/some-handle/data\n"))
+			return nil
+		})
+	})
+
+	Describe("Mount", func() {
+		It("runs the plugin's mount command and returns the host path it prints", func() {
+			hostPath, err := plugin.Mount(lagertest.NewTestLogger("test"), "some-handle", volume)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(hostPath).To(Equal("/var/vcap/data/volumes/some-handle/data"))
+
+			Expect(commandRunner).To(HaveExecutedSerially(fake_command_runner.CommandSpec{
+				Path: "/path/to/nfs-plugin",
+				Args: []string{
+					"--mount-root", "/var/vcap/data/volumes", "mount",
+					"--handle", "some-handle", "--volume", "data", "--options", `{"server":"nfs-1"}`,
+				},
+			}))
+		})
+
+		It("fails when the plugin prints no host path", func() {
+			commandRunner.WhenRunning(fake_command_runner.CommandSpec{Path: "/path/to/nfs-plugin"}, func(cmd *exec.Cmd) error {
+				return nil
+			})
+
+			_, err := plugin.Mount(lagertest.NewTestLogger("test"), "some-handle", volume)
+			Expect(err).To(MatchError("volume plugin mount: no host path was printed"))
+		})
+
+		It("returns the plugin's stderr when it fails", func() {
+			commandRunner.WhenRunning(fake_command_runner.CommandSpec{Path: "/path/to/nfs-plugin"}, func(cmd *exec.Cmd) error {
+				cmd.Stderr.Write([]byte("server unreachable\n"))
+				return errors.New("exit status 1")
+			})
+
+			_, err := plugin.Mount(lagertest.NewTestLogger("test"), "some-handle", volume)
+			Expect(err).To(MatchError("volume plugin mount: exit status 1: server unreachable"))
+		})
+	})
+
+	Describe("Unmount", func() {
+		It("runs the plugin's unmount command", func() {
+			volume.Options = nil
+			Expect(plugin.Unmount(lagertest.NewTestLogger("test"), "some-handle", volume)).To(Succeed())
+
+			Expect(commandRunner).To(HaveExecutedSerially(fake_command_runner.CommandSpec{
+				Path: "/path/to/nfs-plugin",
+				Args: []string{
+					"--mount-root", "/var/vcap/data/volumes", "unmount",
+					"--handle", "some-handle", "--volume", "data", "--options", "{}",
+				},
+			}))
+		})
+	})
+})
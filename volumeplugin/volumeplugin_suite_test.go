@@ -0,0 +1,13 @@
+package volumeplugin_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"testing"
+)
+
+func TestVolumeplugin(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Volumeplugin Suite")
+}